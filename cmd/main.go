@@ -1,15 +1,18 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/errorreport"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 	"github.com/loganlanou/logans3d-v4/service"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
@@ -20,8 +23,9 @@ import (
 func main() {
 	// slog is configured in slog.go via init()
 
-	// Validate required environment variables
-	validateRequiredEnvVars()
+	migrateOnly := flag.Bool("migrate-only", false, "apply pending database migrations and exit")
+	migrateStatus := flag.Bool("migrate-status", false, "list database migrations and exit")
+	flag.Parse()
 
 	// Load configuration
 	config, err := service.LoadConfig()
@@ -30,8 +34,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize database
-	db, err := storage.New(config.DBPath)
+	// Migration modes only need the database
+	switch {
+	case *migrateStatus:
+		os.Exit(runMigrateStatus(config.DBPath))
+	case *migrateOnly:
+		os.Exit(runMigrateOnly(config.DBPath))
+	}
+
+	// Validate required environment variables
+	validateRequiredEnvVars()
+
+	// Initialize database, refusing one a newer release has migrated
+	db, err := storage.Connect(config.DBPath, storage.Options{Migrate: config.AutoMigrate})
 	if err != nil {
 		slog.Error("failed to initialize database", "error", err)
 		os.Exit(1)
@@ -43,34 +58,18 @@ func main() {
 	e.HideBanner = true
 	e.HidePort = true
 
-	// Custom error handler for 404 and other errors
-	e.HTTPErrorHandler = customHTTPErrorHandler(db.Queries)
-
-	// Middleware
-	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: `{"time":"${time_rfc3339}","id":"${id}","remote_ip":"${remote_ip}","host":"${host}","method":"${method}","uri":"${uri}","user_agent":"${user_agent}","status":${status},"error":"${error}","latency":${latency},"latency_human":"${latency_human}","bytes_in":${bytes_in},"bytes_out":${bytes_out}}` + "\n",
-	}))
-	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
-
-	// Custom slog request middleware
-	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			start := time.Now()
-
-			err := next(c)
+	// Initialize service
+	svc := service.New(db, config)
 
-			slog.Info("request handled",
-				"method", c.Request().Method,
-				"path", c.Request().URL.Path,
-				"status", c.Response().Status,
-				"duration", time.Since(start),
-				"ip", c.RealIP(),
-			)
+	// Error pages, and reporting of server errors and panics
+	e.HTTPErrorHandler = customHTTPErrorHandler(db.Queries, svc.ErrorReporter())
 
-			return err
-		}
-	})
+	// Middleware - tracing, then request logging and metrics, so they see the status
+	// of panics Recover turns into errors and the request log carries the trace ID
+	e.Use(tracing.Middleware())
+	e.Use(svc.RequestMetrics())
+	e.Use(errorreport.Recover())
+	e.Use(middleware.CORS())
 
 	// Custom middleware for security headers
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -87,8 +86,7 @@ func main() {
 	// Static files
 	e.Static("/public", "public")
 
-	// Initialize service and register routes
-	svc := service.New(db, config)
+	// Register routes
 	svc.RegisterRoutes(e)
 
 	// Start server
@@ -108,13 +106,41 @@ func main() {
 	}
 }
 
-func customHTTPErrorHandler(queries *db.Queries) echo.HTTPErrorHandler {
+func customHTTPErrorHandler(queries *db.Queries, reporter *errorreport.Reporter) echo.HTTPErrorHandler {
 	return func(err error, c echo.Context) {
 		code := http.StatusInternalServerError
 		if he, ok := err.(*echo.HTTPError); ok {
 			code = he.Code
 		}
 
+		// Server errors and panics are captured with their stack, request and user, and
+		// the customer gets a reference to quote
+		var reference string
+		if code >= http.StatusInternalServerError {
+			userID, _ := auth.GetUserID(c)
+			reference = errorreport.Reference(reporter.Capture(errorreport.RequestEvent(c, err, code, userID)))
+		}
+
+		if c.Response().Committed {
+			return
+		}
+		if c.Request().Method == http.MethodHead {
+			c.NoContent(code)
+			return
+		}
+
+		// Scripts calling the JSON API read the message from the body
+		if wantsJSON(c) {
+			body := map[string]string{"message": publicErrorMessage(err, code)}
+			if reference != "" {
+				body["reference"] = reference
+			}
+			if jsonErr := c.JSON(code, body); jsonErr != nil {
+				slog.Error("failed to write error response", "error", jsonErr)
+			}
+			return
+		}
+
 		if code == http.StatusNotFound {
 			// Render custom 404 page
 			path := c.Request().URL.Path
@@ -165,18 +191,61 @@ func customHTTPErrorHandler(queries *db.Queries) echo.HTTPErrorHandler {
 			return
 		}
 
-		// For other errors, use Echo's default error handler
-		c.Logger().Error(err)
-		if !c.Response().Committed {
-			if c.Request().Method == http.MethodHead {
-				c.NoContent(code)
-			} else {
-				c.String(code, http.StatusText(code))
-			}
+		// htmx swaps fragments, so it gets the message rather than a whole page
+		if c.Request().Header.Get("HX-Request") == "true" {
+			c.String(code, publicErrorMessage(err, code))
+			return
+		}
+
+		title, message := errorPageText(err, code)
+		c.Response().Status = code
+		meta := layout.NewPageMeta(c, queries)
+		meta.Title = title + " - Logan's 3D Creations"
+		meta.Description = message
+
+		if renderErr := errors.ServerError(c, code, title, message, reference, meta).Render(c.Request().Context(), c.Response()); renderErr != nil {
+			slog.Error("failed to render error page", "error", renderErr, "status", code)
+			c.String(code, http.StatusText(code))
 		}
 	}
 }
 
+// wantsJSON reports whether a failed request came from a script expecting JSON
+func wantsJSON(c echo.Context) bool {
+	if strings.HasPrefix(c.Request().URL.Path, "/api/") {
+		return true
+	}
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+	return strings.Contains(accept, echo.MIMEApplicationJSON) && !strings.Contains(accept, echo.MIMETextHTML)
+}
+
+// publicErrorMessage is the message handlers gave the error, which they write for
+// customers. Errors returned without one, and panics, only say something went wrong.
+func publicErrorMessage(err error, code int) string {
+	if he, ok := err.(*echo.HTTPError); ok {
+		if message, ok := he.Message.(string); ok && message != "" {
+			return message
+		}
+	}
+	if code >= http.StatusInternalServerError {
+		return "Something went wrong on our end"
+	}
+	return http.StatusText(code)
+}
+
+// errorPageText is the heading and message of the error page
+func errorPageText(err error, code int) (string, string) {
+	switch {
+	case code >= http.StatusInternalServerError:
+		return "Something Went Wrong", "We hit a problem on our end. It's been reported, and trying again in a moment usually works."
+	case code == http.StatusForbidden:
+		return "Access Denied", "You don't have access to this page."
+	case code == http.StatusTooManyRequests:
+		return "Slow Down a Little", publicErrorMessage(err, code)
+	}
+	return http.StatusText(code), publicErrorMessage(err, code)
+}
+
 func validateRequiredEnvVars() {
 	requiredVars := []string{
 		"CLERK_SECRET_KEY",
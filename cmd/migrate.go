@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+// runMigrateOnly applies pending migrations and exits, for deploys that migrate
+// before starting the new release
+func runMigrateOnly(dbPath string) int {
+	store, err := storage.New(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	state, err := storage.Migrations(context.Background(), store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read migrations: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s is at migration %d\n", dbPath, state.Current)
+	return 0
+}
+
+// runMigrateStatus lists the binary's migrations and whether the database has each,
+// without changing anything. It exits 1 when the database is ahead of the binary.
+func runMigrateStatus(dbPath string) int {
+	// Opening a missing database would create it, and report every migration as
+	// pending for what's most likely a mistyped DB_PATH
+	if _, err := os.Stat(dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		return 1
+	}
+
+	sqliteDB, err := storage.Open(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		return 1
+	}
+	defer sqliteDB.Close()
+
+	state, err := storage.Migrations(context.Background(), sqliteDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read migrations: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED\tMIGRATION")
+	for _, m := range state.Migrations {
+		applied := "pending"
+		if m.Applied {
+			applied = m.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, applied, m.Name)
+	}
+	for _, version := range state.Unknown {
+		fmt.Fprintf(w, "%d\tapplied\t(not in this binary)\n", version)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%s is at migration %d, this binary's newest is %d, %d pending\n", dbPath, state.Current, state.Latest, state.Pending())
+	if err := state.Check(true); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
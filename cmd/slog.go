@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/lmittmann/tint"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 )
 
 var once sync.Once
@@ -50,13 +51,14 @@ func init() {
 				AddSource:   true,
 			})
 
-			slog.SetDefault(slog.New(handler))
+			slog.SetDefault(slog.New(tracing.LogHandler(handler)))
 			slog.Info("debug logging enabled")
 			return
 		}
 
-		// Set up the logger to be json output
-		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+		// Set up the logger to be json output, with the trace of records logged with a
+		// request's context
+		slog.SetDefault(slog.New(tracing.LogHandler(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))))
 		slog.Info("json logging enabled")
 	})
 }
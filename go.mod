@@ -14,12 +14,12 @@ require (
 	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/labstack/echo/v4 v4.13.3
 	github.com/lmittmann/tint v1.0.7
-	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/oklog/ulid/v2 v2.1.1
 	github.com/pressly/goose/v3 v3.26.0
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/stretchr/testify v1.11.0
 	github.com/stripe/stripe-go/v80 v80.2.1
+	github.com/yuin/goldmark v1.8.2
 	golang.org/x/image v0.33.0
 	golang.org/x/sync v0.18.0
 	modernc.org/sqlite v1.38.2
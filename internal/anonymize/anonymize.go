@@ -0,0 +1,577 @@
+// Package anonymize scrubs customers' personal information from a copy of the
+// database, so a production snapshot can be used in development. Names, emails,
+// phone numbers and addresses are rewritten to fake ones, anything customers or admin
+// wrote is replaced with filler, and the Stripe and Clerk IDs that would reach real
+// accounts are cleared.
+//
+// Only values change. Every row keeps its ID, so the relations between tables hold,
+// and amounts are left alone, so order totals and reports add up as before. A real
+// value maps to the same fake wherever it appears, which keeps rows matched by email
+// or phone matched too.
+package anonymize
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+
+	_ "modernc.org/sqlite"
+)
+
+// Options say how to anonymize
+type Options struct {
+	// Salt keys the choice of fake values. Anonymizing with the same salt gives the same
+	// fakes for the same data; without it, the fakes can't be traced back.
+	Salt string
+}
+
+// Report says what a run changed
+type Report struct {
+	// Rows is how many rows were rewritten in each table
+	Rows map[string]int64
+	// Orders and OrderTotalCents are checked to be the same before and after
+	Orders          int64
+	OrderTotalCents int64
+}
+
+// row is one row's values for the columns a table rewrites, by column name
+type row map[string]sql.NullString
+
+// replace sets a column to value, unless it's NULL or blank, which are left as they are
+func (r row) replace(column, value string) {
+	if v := r[column]; v.Valid && strings.TrimSpace(v.String) != "" {
+		r[column] = sql.NullString{String: value, Valid: true}
+	}
+}
+
+// apply replaces a column with what fn maps it to, leaving NULL and blank values
+func (r row) apply(column string, fn func(string) string) {
+	if v := r[column]; v.Valid && strings.TrimSpace(v.String) != "" {
+		r[column] = sql.NullString{String: fn(v.String), Valid: true}
+	}
+}
+
+func (r row) get(column string) string {
+	return r[column].String
+}
+
+// table is a table with personal information and how to rewrite it
+type table struct {
+	name    string
+	columns []string
+	// key is an expression naming the person a row belongs to, for tables that don't
+	// have their email in one of the columns
+	key     string
+	rewrite func(id *identities, key string, r row)
+}
+
+// tables lists every column holding personal information. A new table or column
+// holding some needs adding here, or it's copied to development as it is.
+var tables = []table{
+	// Before users, since it looks up the real email there
+	{
+		name:    "user_default_addresses",
+		columns: []string{"name", "address_line1", "address_line2", "city_locality", "postal_code"},
+		key:     "(SELECT email FROM users WHERE users.id = user_default_addresses.user_id)",
+		rewrite: func(id *identities, key string, r row) {
+			p := id.person(key)
+			r.replace("name", p.name())
+			r.replace("address_line1", p.street)
+			r.replace("address_line2", p.line2)
+			r.replace("city_locality", p.city)
+			r.replace("postal_code", p.zip)
+		},
+	},
+	{
+		name:    "users",
+		columns: []string{"email", "full_name", "first_name", "last_name", "username"},
+		rewrite: func(id *identities, _ string, r row) {
+			p := id.person(r.get("email"))
+			r.apply("email", id.email)
+			r.replace("full_name", p.name())
+			r.replace("first_name", p.first)
+			r.replace("last_name", p.last)
+			r.replace("username", p.username)
+		},
+	},
+	{
+		name:    "admin_users",
+		columns: []string{"email", "name"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.replace("name", id.person(r.get("email")).name())
+			r.apply("email", id.email)
+		},
+	},
+	{
+		name: "orders",
+		columns: []string{
+			"customer_email", "customer_name", "customer_phone", "sms_phone",
+			"shipping_address_line1", "shipping_address_line2", "shipping_city", "shipping_postal_code",
+			"notes", "customer_notes", "gift_message", "gift_recipient_email", "gift_recipient_name",
+			"stripe_checkout_session_id",
+		},
+		rewrite: func(id *identities, _ string, r row) {
+			p := id.person(r.get("customer_email"))
+			r.replace("customer_name", p.name())
+			r.apply("customer_email", id.email)
+			r.apply("customer_phone", id.phone)
+			r.apply("sms_phone", id.phone)
+			r.replace("shipping_address_line1", p.street)
+			r.replace("shipping_address_line2", p.line2)
+			r.replace("shipping_city", p.city)
+			r.replace("shipping_postal_code", p.zip)
+			r.apply("notes", id.text)
+			r.apply("customer_notes", id.text)
+			r.apply("gift_message", id.text)
+			r.replace("gift_recipient_name", id.person(either(r.get("gift_recipient_email"), r.get("gift_recipient_name"))).name())
+			r.apply("gift_recipient_email", id.email)
+			r.apply("stripe_checkout_session_id", id.stripeID)
+		},
+	},
+	{
+		name:    "abandoned_carts",
+		columns: []string{"customer_email", "customer_name", "notes"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.replace("customer_name", id.person(r.get("customer_email")).name())
+			r.apply("customer_email", id.email)
+			r.apply("notes", id.text)
+		},
+	},
+	{
+		name:    "expired_checkouts",
+		columns: []string{"customer_email"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("customer_email", id.email)
+		},
+	},
+	{
+		name:    "contact_requests",
+		columns: []string{"email", "first_name", "last_name", "phone", "message", "response_notes"},
+		key:     "COALESCE(email, id)",
+		rewrite: func(id *identities, key string, r row) {
+			p := id.person(key)
+			r.replace("first_name", p.first)
+			r.replace("last_name", p.last)
+			r.apply("email", id.email)
+			r.apply("phone", id.phone)
+			r.apply("message", id.text)
+			r.apply("response_notes", id.text)
+		},
+	},
+	{
+		name:    "quote_requests",
+		columns: []string{"customer_email", "customer_name", "customer_phone", "project_description", "admin_notes"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.replace("customer_name", id.person(r.get("customer_email")).name())
+			r.apply("customer_email", id.email)
+			r.apply("customer_phone", id.phone)
+			r.apply("project_description", id.text)
+			r.apply("admin_notes", id.text)
+		},
+	},
+	{
+		name:    "custom_quote_drafts",
+		columns: []string{"email", "name", "description"},
+		key:     "COALESCE(email, id)",
+		rewrite: func(id *identities, key string, r row) {
+			r.replace("name", id.person(key).name())
+			r.apply("email", id.email)
+			r.apply("description", id.text)
+		},
+	},
+	{
+		name:    "marketing_contacts",
+		columns: []string{"email", "first_name", "last_name"},
+		rewrite: func(id *identities, _ string, r row) {
+			p := id.person(r.get("email"))
+			r.replace("first_name", p.first)
+			r.replace("last_name", p.last)
+			r.apply("email", id.email)
+		},
+	},
+	{
+		name:    "email_preferences",
+		columns: []string{"email"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("email", id.email)
+		},
+	},
+	{
+		name:    "email_history",
+		columns: []string{"recipient_email"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("recipient_email", id.email)
+		},
+	},
+	{
+		name:    "promotion_codes",
+		columns: []string{"email"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("email", id.email)
+		},
+	},
+	{
+		name:    "event_registrations",
+		columns: []string{"email", "name"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.replace("name", id.person(r.get("email")).name())
+			r.apply("email", id.email)
+		},
+	},
+	{
+		name:    "product_questions",
+		columns: []string{"asker_email", "asker_name"},
+		key:     "COALESCE(asker_email, id)",
+		rewrite: func(id *identities, key string, r row) {
+			r.replace("asker_name", id.person(key).first)
+			r.apply("asker_email", id.email)
+		},
+	},
+	{
+		name:    "gift_cards",
+		columns: []string{"recipient_email", "recipient_name", "sender_name", "message"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.replace("recipient_name", id.person(either(r.get("recipient_email"), r.get("recipient_name"))).name())
+			r.apply("recipient_email", id.email)
+			r.replace("sender_name", id.person(r.get("sender_name")).name())
+			r.apply("message", id.text)
+		},
+	},
+	{
+		name:    "gift_certificates",
+		columns: []string{"redeemer_name", "redemption_notes"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.replace("redeemer_name", id.person(r.get("redeemer_name")).name())
+			r.apply("redemption_notes", id.text)
+		},
+	},
+	{
+		name:    "gift_card_transactions",
+		columns: []string{"note", "checkout_session_id"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("note", id.text)
+			r.apply("checkout_session_id", id.stripeID)
+		},
+	},
+	{
+		name:    "store_credit_transactions",
+		columns: []string{"note", "checkout_session_id"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("note", id.text)
+			r.apply("checkout_session_id", id.stripeID)
+		},
+	},
+	{
+		name:    "inventory_reservations",
+		columns: []string{"checkout_session_id"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("checkout_session_id", id.stripeID)
+		},
+	},
+	{
+		name:    "returns",
+		columns: []string{"details", "admin_notes"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("details", id.text)
+			r.apply("admin_notes", id.text)
+		},
+	},
+	{
+		name:    "sms_messages",
+		columns: []string{"phone", "body"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("phone", id.phone)
+			r.apply("body", id.text)
+		},
+	},
+	{
+		name:    "sms_opt_outs",
+		columns: []string{"phone"},
+		rewrite: func(id *identities, _ string, r row) {
+			r.apply("phone", id.phone)
+		},
+	},
+	{name: "order_items", columns: []string{"personalization"}, rewrite: rewritePersonalization},
+	{name: "cart_items", columns: []string{"personalization"}, rewrite: rewritePersonalization},
+	{name: "saved_cart_items", columns: []string{"personalization"}, rewrite: rewritePersonalization},
+	{name: "shared_cart_items", columns: []string{"personalization"}, rewrite: rewritePersonalization},
+	// Admin changes are recorded by the admin's email
+	{name: "feature_flags", columns: []string{"updated_by"}, rewrite: rewriteActor("updated_by")},
+	{name: "feature_flag_changes", columns: []string{"changed_by"}, rewrite: rewriteActor("changed_by")},
+	{name: "inventory_movements", columns: []string{"created_by"}, rewrite: rewriteActor("created_by")},
+}
+
+// rewritePersonalization keeps the field labels but not what the customer entered,
+// which is often a name
+func rewritePersonalization(id *identities, _ string, r row) {
+	r.apply("personalization", func(raw string) string {
+		values := utils.DecodePersonalization(raw)
+		if values == nil {
+			return ""
+		}
+		for i := range values {
+			values[i].Value = id.word(values[i].Value)
+		}
+		return utils.EncodePersonalization(values)
+	})
+}
+
+// either returns a, or b when a is blank
+func either(a, b string) string {
+	if strings.TrimSpace(a) != "" {
+		return a
+	}
+	return b
+}
+
+func rewriteActor(column string) func(id *identities, key string, r row) {
+	return func(id *identities, _ string, r row) {
+		if strings.Contains(r.get(column), "@") {
+			r.apply(column, id.email)
+		}
+	}
+}
+
+// clears removes what can't be faked usefully: IDs at Stripe, Clerk and EasyPost,
+// which a development copy can't look up anyway since it uses their test modes, and
+// sessions, which would let someone holding a production cookie in
+var clears = []string{
+	"UPDATE users SET clerk_id = NULL, google_id = NULL, legacy_avatar_url = NULL, profile_image_url = NULL",
+	"DELETE FROM user_sessions",
+	"DELETE FROM session_shipping_selection",
+	"UPDATE orders SET stripe_payment_intent_id = NULL, stripe_customer_id = NULL, easypost_shipment_id = NULL, easypost_label_url = NULL, label_image_url = ''",
+	"UPDATE returns SET stripe_refund_id = '', easypost_shipment_id = '', return_label_url = ''",
+	"UPDATE shipping_labels SET label_pdf_url = NULL",
+	"UPDATE event_registrations SET stripe_session_id = NULL",
+	"UPDATE contact_requests SET ip_address = NULL, user_agent = NULL, referrer = NULL",
+	"UPDATE email_history SET metadata = NULL",
+	"UPDATE products SET stripe_product_id = NULL, stripe_price_id = NULL, stripe_synced_at = NULL",
+	"UPDATE product_skus SET stripe_price_id = NULL",
+	"UPDATE promotion_campaigns SET stripe_promotion_id = NULL",
+	"UPDATE promotion_codes SET stripe_promotion_code_id = NULL",
+}
+
+// Run anonymizes the database in place, in one transaction, so a failure leaves it as
+// it was. Only run it on a copy; see Copy.
+func Run(ctx context.Context, sqlDB *sql.DB, opts Options) (Report, error) {
+	if opts.Salt == "" {
+		return Report{}, errors.New("a salt is required")
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	report := Report{Rows: make(map[string]int64)}
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(total_cents), 0) FROM orders").Scan(&report.Orders, &report.OrderTotalCents); err != nil {
+		return Report{}, fmt.Errorf("failed to total orders: %w", err)
+	}
+	violations, err := foreignKeyViolations(ctx, tx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	id := newIdentities(opts.Salt)
+	for _, t := range tables {
+		n, err := rewriteTable(ctx, tx, id, t)
+		if err != nil {
+			return Report{}, fmt.Errorf("failed to anonymize %s: %w", t.name, err)
+		}
+		report.Rows[t.name] += n
+	}
+
+	if err := replaceGiftCardCodes(ctx, tx); err != nil {
+		return Report{}, err
+	}
+
+	for _, stmt := range clears {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return Report{}, fmt.Errorf("failed to clear IDs (%s): %w", stmt, err)
+		}
+	}
+
+	// Nothing above should touch an amount or an ID, but a mistake here would hand
+	// developers a database that quietly disagrees with itself
+	var orders, totalCents int64
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(total_cents), 0) FROM orders").Scan(&orders, &totalCents); err != nil {
+		return Report{}, fmt.Errorf("failed to total orders: %w", err)
+	}
+	if orders != report.Orders || totalCents != report.OrderTotalCents {
+		return Report{}, fmt.Errorf("order totals changed: %d orders for %d cents before, %d for %d after", report.Orders, report.OrderTotalCents, orders, totalCents)
+	}
+	after, err := foreignKeyViolations(ctx, tx)
+	if err != nil {
+		return Report{}, err
+	}
+	if after > violations {
+		return Report{}, fmt.Errorf("anonymizing broke %d references between tables", after-violations)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Report{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return report, nil
+}
+
+// rewriteTable reads a table's rows, then writes back the rewritten values of the
+// ones that changed
+func rewriteTable(ctx context.Context, tx *sql.Tx, id *identities, t table) (int64, error) {
+	key := t.key
+	if key == "" {
+		key = "''"
+	}
+	// Table and column names come from the list above, never from input
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT rowid, COALESCE(%s, ''), %s FROM %s ORDER BY rowid", key, strings.Join(t.columns, ", "), t.name))
+	if err != nil {
+		return 0, err
+	}
+
+	type pending struct {
+		rowID int64
+		key   string
+		r     row
+	}
+	var all []pending
+	for rows.Next() {
+		p := pending{r: make(row, len(t.columns))}
+		values := make([]sql.NullString, len(t.columns))
+		dest := []any{&p.rowID, &p.key}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		for i, column := range t.columns {
+			p.r[column] = values[i]
+		}
+		all = append(all, p)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	assignments := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		assignments[i] = column + " = ?"
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s WHERE rowid = ?", t.name, strings.Join(assignments, ", "))
+
+	var rewritten int64
+	for _, p := range all {
+		before := make(row, len(p.r))
+		for column, v := range p.r {
+			before[column] = v
+		}
+		t.rewrite(id, p.key, p.r)
+
+		changed := false
+		args := make([]any, 0, len(t.columns)+1)
+		for _, column := range t.columns {
+			if p.r[column] != before[column] {
+				changed = true
+			}
+			args = append(args, p.r[column])
+		}
+		if !changed {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, update, append(args, p.rowID)...); err != nil {
+			return 0, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+// replaceGiftCardCodes gives every gift card a new random code. A code is worth its
+// balance to whoever has it, so it isn't derived from the real one at all.
+func replaceGiftCardCodes(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM gift_cards")
+	if err != nil {
+		return fmt.Errorf("failed to list gift cards: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var cardID string
+		if err := rows.Scan(&cardID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to list gift cards: %w", err)
+		}
+		ids = append(ids, cardID)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to list gift cards: %w", err)
+	}
+
+	for _, cardID := range ids {
+		code, err := giftcards.GenerateCode()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE gift_cards SET code = ? WHERE id = ?", code, cardID); err != nil {
+			return fmt.Errorf("failed to replace gift card code: %w", err)
+		}
+	}
+	return nil
+}
+
+// foreignKeyViolations counts the rows whose references don't resolve. A production
+// copy may already have some from before foreign keys were enforced, so Run checks it
+// adds none rather than that there are none.
+func foreignKeyViolations(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return 0, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	return count, nil
+}
+
+// Copy writes a consistent copy of the database at src to dst with VACUUM INTO, the
+// way backups are taken, so it works on a database the site is still using. src is
+// opened read-only, and dst must not exist yet.
+func Copy(ctx context.Context, src, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	srcDB, err := sql.Open("sqlite", "file:"+src+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer srcDB.Close()
+	if err := srcDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+
+	// VACUUM INTO takes a file name, not a bound parameter on every SQLite build
+	if _, err := srcDB.ExecContext(ctx, "VACUUM INTO '"+strings.ReplaceAll(dst, "'", "''")+"'"); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return nil
+}
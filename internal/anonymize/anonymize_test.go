@@ -0,0 +1,137 @@
+package anonymize
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+// seedCustomer adds a customer whose email and phone appear in several tables, written
+// differently in some
+func seedCustomer(t *testing.T, database *sql.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, full_name, first_name, last_name, clerk_id) VALUES ('user-1', 'Jane@Customer.com', 'Jane Customer', 'Jane', 'Customer', 'user_2abc')`,
+		`INSERT INTO user_sessions (id, user_id, session_token, expires_at) VALUES ('session-1', 'user-1', 'token', '2030-01-01 00:00:00')`,
+		`INSERT INTO user_default_addresses (user_id, name, address_line1, city_locality, state_province, postal_code, country_code) VALUES ('user-1', 'Jane Customer', '12 Elm St', 'Eau Claire', 'WI', '54701', 'US')`,
+		`INSERT INTO orders (
+			id, user_id, customer_name, customer_email, customer_phone, sms_phone,
+			shipping_address_line1, shipping_city, shipping_state, shipping_postal_code,
+			subtotal_cents, tax_cents, shipping_cents, total_cents,
+			stripe_checkout_session_id, stripe_payment_intent_id, customer_notes
+		) VALUES (
+			'order-1', 'user-1', 'Jane C', 'jane@customer.com', '(715) 555-0100', '+17155550100',
+			'12 Elm St', 'Eau Claire', 'WI', '54701',
+			2000, 110, 500, 2610,
+			'cs_live_abc', 'pi_live_abc', 'Leave it with Bob next door'
+		)`,
+		`INSERT INTO products (id, name, slug, price_cents) VALUES ('product-1', 'Name Plate', 'name-plate', 2000)`,
+		`INSERT INTO order_items (id, order_id, product_id, quantity, unit_price_cents, total_price_cents, product_name, personalization) VALUES ('item-1', 'order-1', 'product-1', 1, 2000, 2000, 'Name Plate', '[{"label":"Name","value":"Lily"}]')`,
+		`INSERT INTO marketing_contacts (id, email, first_name, last_name, source) VALUES ('contact-1', 'jane@customer.com', 'Jane', 'Customer', 'popup')`,
+		`INSERT INTO sms_opt_outs (phone) VALUES ('+17155550100')`,
+	} {
+		_, err := database.Exec(stmt)
+		require.NoError(t, err, stmt)
+	}
+}
+
+func TestRun(t *testing.T) {
+	database, _, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err = Run(ctx, database, Options{})
+	require.Error(t, err, "a salt is required")
+
+	seedCustomer(t, database)
+	report, err := Run(ctx, database, Options{Salt: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Orders)
+	assert.Equal(t, int64(2610), report.OrderTotalCents)
+	assert.Equal(t, int64(1), report.Rows["users"])
+
+	var userEmail, userName string
+	var clerkID sql.NullString
+	require.NoError(t, database.QueryRow(`SELECT email, full_name, clerk_id FROM users WHERE id = 'user-1'`).Scan(&userEmail, &userName, &clerkID))
+	assert.Contains(t, userEmail, "@example.com")
+	assert.NotEqual(t, "Jane Customer", userName)
+	assert.False(t, clerkID.Valid, "Clerk IDs are cleared")
+
+	var orderEmail, orderName, orderPhone, smsPhone, street, notes, sessionID string
+	var paymentIntent sql.NullString
+	var total int64
+	require.NoError(t, database.QueryRow(`
+		SELECT customer_email, customer_name, customer_phone, sms_phone, shipping_address_line1, customer_notes,
+			stripe_checkout_session_id, stripe_payment_intent_id, total_cents
+		FROM orders WHERE id = 'order-1'`,
+	).Scan(&orderEmail, &orderName, &orderPhone, &smsPhone, &street, &notes, &sessionID, &paymentIntent, &total))
+	assert.Equal(t, userEmail, orderEmail, "the same email gets the same fake, whatever its case")
+	assert.Equal(t, userName, orderName)
+	assert.Equal(t, orderPhone, smsPhone, "the same number gets the same fake, however it's written")
+	assert.Regexp(t, `^\+1555\d{7}$`, orderPhone)
+	assert.NotEqual(t, "12 Elm St", street)
+	assert.NotContains(t, notes, "Bob")
+	assert.Regexp(t, `^cs_anon_`, sessionID)
+	assert.False(t, paymentIntent.Valid)
+	assert.Equal(t, int64(2610), total, "amounts are left alone")
+
+	var addressName, addressLine string
+	require.NoError(t, database.QueryRow(`SELECT name, address_line1 FROM user_default_addresses WHERE user_id = 'user-1'`).Scan(&addressName, &addressLine))
+	assert.Equal(t, userName, addressName, "a saved address belongs to the same fake person as the account")
+	assert.Equal(t, street, addressLine)
+
+	var contactEmail, optOut, personalization string
+	require.NoError(t, database.QueryRow(`SELECT email FROM marketing_contacts`).Scan(&contactEmail))
+	require.NoError(t, database.QueryRow(`SELECT phone FROM sms_opt_outs`).Scan(&optOut))
+	require.NoError(t, database.QueryRow(`SELECT personalization FROM order_items`).Scan(&personalization))
+	assert.Equal(t, userEmail, contactEmail)
+	assert.Equal(t, orderPhone, optOut, "an opt-out still matches the orders it applies to")
+	assert.Contains(t, personalization, `"label":"Name"`)
+	assert.NotContains(t, personalization, "Lily")
+
+	var sessions int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM user_sessions`).Scan(&sessions))
+	assert.Zero(t, sessions)
+
+	// The same salt on the same data gives the same fakes
+	other, _, otherCleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer otherCleanup()
+	seedCustomer(t, other)
+	_, err = Run(ctx, other, Options{Salt: "test"})
+	require.NoError(t, err)
+	var otherEmail string
+	require.NoError(t, other.QueryRow(`SELECT email FROM users`).Scan(&otherEmail))
+	assert.Equal(t, userEmail, otherEmail)
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "production.db")
+	dst := filepath.Join(dir, "development.db")
+
+	srcDB, err := storage.Open(src)
+	require.NoError(t, err)
+	_, err = srcDB.Exec(`CREATE TABLE things (name TEXT); INSERT INTO things VALUES ('widget')`)
+	require.NoError(t, err)
+	defer srcDB.Close()
+
+	require.NoError(t, Copy(ctx, src, dst), "copies a database that's still open")
+	require.Error(t, Copy(ctx, src, dst), "never overwrites")
+	require.Error(t, Copy(ctx, filepath.Join(dir, "missing.db"), filepath.Join(dir, "other.db")))
+
+	dstDB, err := storage.Open(dst)
+	require.NoError(t, err)
+	defer dstDB.Close()
+	var name string
+	require.NoError(t, dstDB.QueryRow(`SELECT name FROM things`).Scan(&name))
+	assert.Equal(t, "widget", name)
+}
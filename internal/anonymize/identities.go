@@ -0,0 +1,158 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// person is the fake identity standing in for a real customer
+type person struct {
+	first    string
+	last     string
+	username string
+	street   string
+	line2    string
+	city     string
+	zip      string
+}
+
+func (p person) name() string {
+	return p.first + " " + p.last
+}
+
+// identities turns real values into fake ones. Each fake is picked by an HMAC of the
+// real value, so with the same salt the same input always gets the same output, and a
+// value seen in several places, like an email on a user and on their orders, becomes
+// the same fake everywhere. That keeps rows that are matched by email or phone, rather
+// than by ID, matched after the rewrite.
+type identities struct {
+	salt []byte
+	// fakes maps a kind and real value to the fake chosen for it
+	fakes map[string]string
+	// taken records the fakes in use, so two real values never share one
+	taken map[string]bool
+}
+
+func newIdentities(salt string) *identities {
+	return &identities{
+		salt:  []byte(salt),
+		fakes: make(map[string]string),
+		taken: make(map[string]bool),
+	}
+}
+
+// faker returns a generator seeded by the real value. attempt picks another seed when
+// the first choice is taken.
+func (id *identities) faker(kind, value string, attempt int) *gofakeit.Faker {
+	mac := hmac.New(sha256.New, id.salt)
+	fmt.Fprintf(mac, "%s\x00%d\x00%s", kind, attempt, value)
+	return gofakeit.New(binary.BigEndian.Uint64(mac.Sum(nil)))
+}
+
+// unique returns the fake for a real value, picking it with next the first time the
+// value is seen and again whenever the pick belongs to another value already
+func (id *identities) unique(kind, value string, next func(f *gofakeit.Faker) string) string {
+	key := kind + "\x00" + value
+	if fake, ok := id.fakes[key]; ok {
+		return fake
+	}
+	for attempt := 0; ; attempt++ {
+		fake := next(id.faker(kind, value, attempt))
+		if !id.taken[kind+"\x00"+fake] {
+			id.fakes[key] = fake
+			id.taken[kind+"\x00"+fake] = true
+			return fake
+		}
+	}
+}
+
+// person returns the identity for a customer, keyed by their email where there is one
+func (id *identities) person(key string) person {
+	f := id.faker("person", normalize(key), 0)
+	p := person{
+		first:    f.FirstName(),
+		last:     f.LastName(),
+		username: f.Username(),
+		street:   f.Street(),
+		city:     f.City(),
+		zip:      f.Zip(),
+	}
+	p.line2 = fmt.Sprintf("Apt %d", f.Number(1, 400))
+	return p
+}
+
+// email returns the fake address for a real one. Fakes are on example.com, which can't
+// receive mail, so a development copy never emails a customer.
+func (id *identities) email(value string) string {
+	key := normalize(value)
+	p := id.person(key)
+	return id.unique("email", key, func(f *gofakeit.Faker) string {
+		return fmt.Sprintf("%s.%s.%d@example.com", letters(p.first), letters(p.last), f.Number(100, 9999))
+	})
+}
+
+// phone returns the fake number for a real one, in the 555 exchange. Numbers written
+// differently, like (715) 555-0100 and +17155550100, get the same fake.
+func (id *identities) phone(value string) string {
+	return id.unique("phone", digits(value), func(f *gofakeit.Faker) string {
+		return "+1555" + f.Numerify("#######")
+	})
+}
+
+// text returns filler standing in for something a customer or admin wrote
+func (id *identities) text(value string) string {
+	return id.faker("text", value, 0).Sentence()
+}
+
+// word returns a fake name standing in for a short value, like personalization text
+func (id *identities) word(value string) string {
+	return id.faker("word", value, 0).FirstName()
+}
+
+// stripeID returns a placeholder for a Stripe ID that ties rows together, keeping its
+// prefix, so rows that shared the real ID share the placeholder
+func (id *identities) stripeID(value string) string {
+	prefix := ""
+	if i := strings.Index(value, "_"); i > 0 {
+		prefix = value[:i+1]
+	}
+	return id.unique("stripe", value, func(f *gofakeit.Faker) string {
+		return fmt.Sprintf("%sanon_%016x", prefix, f.Uint64())
+	})
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// letters keeps the ASCII letters of a name for use in an email address
+func letters(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// digits keeps a phone number's last ten digits, dropping the country code and any
+// formatting
+func digits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	d := b.String()
+	if len(d) > 10 {
+		d = d[len(d)-10:]
+	}
+	return d
+}
@@ -0,0 +1,23 @@
+// Package api defines the JSON the storefront's own scripts read. Handlers build these
+// types from database rows instead of encoding the rows directly, so renaming or adding
+// a column doesn't change what the browser receives.
+package api
+
+import (
+	"strings"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+)
+
+// Version is sent with every response. It goes up when a field is removed or changes
+// meaning; new fields are added without a bump.
+const Version = 1
+
+// productImageURL resolves an image path stored relative to the product image folder,
+// e.g. "dragon.png" or "styles/dragon-red.png", to its public URL
+func productImageURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "/") {
+		return path
+	}
+	return utils.ProductImagePathPrefix + path
+}
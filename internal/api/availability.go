@@ -0,0 +1,158 @@
+package api
+
+import (
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// defaultLowStockThreshold is used for products saved before the threshold existed
+const defaultLowStockThreshold = 5
+
+// ProductAvailability is the response of GET /api/products/:id/availability, polled by
+// the product and cart pages so their stock badges and add-to-cart buttons keep up
+// with other shoppers' purchases. Status and Message are one of the utils.SavedItem*
+// statuses and the note shown for it, such as "Only 2 left".
+//
+// MaxQuantity is how many more the shopper can add, after what's in their cart, the
+// per-order cap and the per-customer limit; null means nothing caps it.
+type ProductAvailability struct {
+	Version       int               `json:"version"`
+	ProductID     string            `json:"product_id"`
+	Status        string            `json:"status"`
+	Message       string            `json:"message"`
+	Stock         int64             `json:"stock"`
+	CanAddToCart  bool              `json:"can_add_to_cart"`
+	InCart        int64             `json:"in_cart"`
+	MaxPerOrder   int64             `json:"max_per_order"`
+	PurchaseLimit int64             `json:"purchase_limit"`
+	MaxQuantity   *int64            `json:"max_quantity"`
+	Skus          []SkuAvailability `json:"skus"`
+}
+
+// SkuAvailability is the stock of one of a product's variants
+type SkuAvailability struct {
+	ID           string `json:"id"`
+	SKU          string `json:"sku"`
+	Stock        int64  `json:"stock"`
+	Active       bool   `json:"active"`
+	Status       string `json:"status"`
+	Message      string `json:"message"`
+	CanAddToCart bool   `json:"can_add_to_cart"`
+	InCart       int64  `json:"in_cart"`
+	MaxQuantity  *int64 `json:"max_quantity"`
+}
+
+// AvailabilityShopper is what the asking shopper already has of a product
+type AvailabilityShopper struct {
+	// InCart is the units of the product in their cart, across every line
+	InCart int64
+	// SkuInCart is the units of each variant in their cart
+	SkuInCart map[string]int64
+	// PurchaseLimitLeft is utils.PurchaseLimitLeft for them, -1 when there's no limit
+	PurchaseLimitLeft int64
+	// DropUpcoming is set while the product is a drop that isn't on sale yet
+	DropUpcoming bool
+}
+
+// NewProductAvailability works out a product's stock and how much of it the shopper
+// can add. Products that can't be backordered are capped at their stock, less what's
+// already in the cart.
+func NewProductAvailability(product db.Product, skus []db.ListProductSkuStockRow, shopper AvailabilityShopper) ProductAvailability {
+	headroom := int64(-1)
+	if product.MaxPerOrder > 0 {
+		headroom = max(product.MaxPerOrder-shopper.InCart, 0)
+	}
+	headroom = lowerCap(headroom, shopper.PurchaseLimitLeft)
+
+	availability := ProductAvailability{
+		Version:       Version,
+		ProductID:     product.ID,
+		InCart:        shopper.InCart,
+		MaxPerOrder:   product.MaxPerOrder,
+		PurchaseLimit: product.PurchaseLimit,
+		Skus:          []SkuAvailability{},
+	}
+	active := !product.IsActive.Valid || product.IsActive.Bool
+
+	if !product.HasVariants.Valid || !product.HasVariants.Bool {
+		stock := product.StockQuantity.Int64
+		availability.Stock = stock
+		availability.Status, availability.Message = stockStatus(product, active, stock)
+		availability.MaxQuantity = maxQuantity(product, stock, shopper.InCart, headroom)
+		availability.CanAddToCart = canAdd(availability.Status, availability.MaxQuantity, shopper.DropUpcoming)
+		return availability
+	}
+
+	for _, row := range skus {
+		skuActive := active && (!row.IsActive.Valid || row.IsActive.Bool)
+		stock := row.StockQuantity.Int64
+		inCart := shopper.SkuInCart[row.ID]
+		sku := SkuAvailability{
+			ID:     row.ID,
+			SKU:    row.Sku,
+			Stock:  stock,
+			Active: skuActive,
+			InCart: inCart,
+		}
+		sku.Status, sku.Message = stockStatus(product, skuActive, stock)
+		sku.MaxQuantity = maxQuantity(product, stock, inCart, headroom)
+		sku.CanAddToCart = canAdd(sku.Status, sku.MaxQuantity, shopper.DropUpcoming)
+		availability.Skus = append(availability.Skus, sku)
+
+		if skuActive {
+			availability.Stock += stock
+		}
+		availability.CanAddToCart = availability.CanAddToCart || sku.CanAddToCart
+	}
+	availability.Status, availability.Message = stockStatus(product, active, availability.Stock)
+	availability.MaxQuantity = capQuantity(headroom)
+	return availability
+}
+
+// stockStatus describes one unit of stock the way saved lines are described
+func stockStatus(product db.Product, active bool, stock int64) (string, string) {
+	threshold := int64(defaultLowStockThreshold)
+	if product.LowStockThreshold.Valid {
+		threshold = product.LowStockThreshold.Int64
+	}
+	return utils.SavedItemAvailability(utils.SavedItemStock{
+		Active:            active,
+		Stock:             stock,
+		LowStockThreshold: threshold,
+		Quantity:          1,
+		AllowBackorder:    product.AllowBackorder,
+		Preorder:          product.IsPreorder,
+		Digital:           product.ProductType == utils.ProductTypeDigital,
+	})
+}
+
+// maxQuantity caps headroom at the stock left after the cart, for products that
+// can't be sold past their stock
+func maxQuantity(product db.Product, stock, inCart, headroom int64) *int64 {
+	if !product.AllowBackorder && !product.IsPreorder && product.ProductType != utils.ProductTypeDigital {
+		headroom = lowerCap(headroom, max(stock-inCart, 0))
+	}
+	return capQuantity(headroom)
+}
+
+func canAdd(status string, maxQuantity *int64, dropUpcoming bool) bool {
+	return utils.SavedItemMovable(status) && !dropUpcoming && (maxQuantity == nil || *maxQuantity > 0)
+}
+
+// lowerCap returns the smaller of two caps, where -1 means uncapped
+func lowerCap(a, b int64) int64 {
+	switch {
+	case a < 0:
+		return b
+	case b < 0:
+		return a
+	}
+	return min(a, b)
+}
+
+func capQuantity(headroom int64) *int64 {
+	if headroom < 0 {
+		return nil
+	}
+	return &headroom
+}
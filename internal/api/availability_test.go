@@ -0,0 +1,86 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProductAvailability(t *testing.T) {
+	product := db.Product{
+		ID:            "dragon",
+		StockQuantity: sql.NullInt64{Int64: 3, Valid: true},
+		ProductType:   "physical",
+	}
+
+	noLimit := AvailabilityShopper{PurchaseLimitLeft: -1}
+	availability := NewProductAvailability(product, nil, noLimit)
+	assert.Equal(t, utils.SavedItemLowStock, availability.Status)
+	assert.Equal(t, "Only 3 left", availability.Message)
+	assert.True(t, availability.CanAddToCart)
+	require.NotNil(t, availability.MaxQuantity)
+	assert.Equal(t, int64(3), *availability.MaxQuantity)
+
+	availability = NewProductAvailability(product, nil, AvailabilityShopper{InCart: 3, PurchaseLimitLeft: -1})
+	assert.False(t, availability.CanAddToCart, "the rest of the stock is already in the cart")
+	assert.Equal(t, int64(0), *availability.MaxQuantity)
+
+	product.AllowBackorder = true
+	availability = NewProductAvailability(product, nil, noLimit)
+	assert.Nil(t, availability.MaxQuantity, "backordered products aren't capped at their stock")
+
+	product.MaxPerOrder = 4
+	availability = NewProductAvailability(product, nil, AvailabilityShopper{InCart: 1, PurchaseLimitLeft: 2})
+	assert.Equal(t, int64(2), *availability.MaxQuantity, "the purchase limit is lower than the per-order cap")
+
+	availability = NewProductAvailability(product, nil, AvailabilityShopper{PurchaseLimitLeft: -1, DropUpcoming: true})
+	assert.False(t, availability.CanAddToCart)
+}
+
+func TestNewProductAvailabilityVariants(t *testing.T) {
+	product := db.Product{
+		ID:                "dragon",
+		HasVariants:       sql.NullBool{Bool: true, Valid: true},
+		LowStockThreshold: sql.NullInt64{Int64: 2, Valid: true},
+		ProductType:       "physical",
+	}
+	skus := []db.ListProductSkuStockRow{
+		{ID: "red-s", Sku: "DRG-RED-S", StockQuantity: sql.NullInt64{Int64: 2, Valid: true}},
+		{ID: "red-l", Sku: "DRG-RED-L", StockQuantity: sql.NullInt64{Int64: 8, Valid: true}},
+		{ID: "blue-s", Sku: "DRG-BLUE-S", StockQuantity: sql.NullInt64{Int64: 5, Valid: true}, IsActive: sql.NullBool{Valid: true}},
+	}
+
+	availability := NewProductAvailability(product, skus, AvailabilityShopper{
+		InCart:            2,
+		SkuInCart:         map[string]int64{"red-s": 2},
+		PurchaseLimitLeft: -1,
+	})
+	require.Len(t, availability.Skus, 3)
+	assert.Equal(t, int64(10), availability.Stock, "inactive variants don't count toward stock")
+	assert.Equal(t, utils.SavedItemAvailable, availability.Status)
+	assert.True(t, availability.CanAddToCart)
+	assert.Nil(t, availability.MaxQuantity)
+
+	redSmall := availability.Skus[0]
+	assert.Equal(t, "Only 2 left", redSmall.Message)
+	assert.False(t, redSmall.CanAddToCart, "both are already in the cart")
+	assert.Equal(t, int64(2), redSmall.InCart)
+
+	assert.True(t, availability.Skus[1].CanAddToCart)
+	assert.Equal(t, int64(8), *availability.Skus[1].MaxQuantity)
+
+	assert.Equal(t, utils.SavedItemUnavailable, availability.Skus[2].Status)
+	assert.False(t, availability.Skus[2].CanAddToCart)
+
+	assert.Equal(t, []string{
+		"can_add_to_cart", "in_cart", "max_per_order", "max_quantity", "message", "product_id",
+		"purchase_limit", "skus", "status", "stock", "version",
+	}, jsonKeys(t, availability))
+	assert.Equal(t, []string{
+		"active", "can_add_to_cart", "id", "in_cart", "max_quantity", "message", "sku", "status", "stock",
+	}, jsonKeys(t, redSmall))
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Cart is the response of GET /api/cart. Top-level keys are camelCase and item keys
+// snake_case, as cart.js has always read them.
+type Cart struct {
+	Version        int                    `json:"version"`
+	Items          []CartItem             `json:"items"`
+	ItemCount      int64                  `json:"itemCount"`
+	TotalCents     int64                  `json:"totalCents"`
+	TotalDollar    float64                `json:"totalDollar"`
+	VolumePricing  map[string]VolumePrice `json:"volumePricing"`
+	DigitalOnly    bool                   `json:"digitalOnly"`
+	ShippingConfig ShippingConfig         `json:"shippingConfig"`
+	FreeShipping   FreeShipping           `json:"freeShipping"`
+	// DiscountCents is taken off TotalCents at checkout by the applied cart promotion
+	DiscountCents int64       `json:"discountCents"`
+	Promotions    []Promotion `json:"promotions"`
+	// Problems explain what stops checkout, like a product over its per-order cap or
+	// a subtotal under the store minimum
+	Problems []string `json:"problems"`
+}
+
+// CartItem is one cart line. PriceCents is the regular unit price; UnitPriceCents is
+// what the shopper pays after any quantity break, and LineTotalCents is that times the
+// quantity.
+type CartItem struct {
+	ID                    string                       `json:"id"`
+	ProductID             string                       `json:"product_id"`
+	ProductSkuID          string                       `json:"product_sku_id"`
+	Name                  string                       `json:"name"`
+	VariantName           string                       `json:"variant_name"`
+	VariantSKU            string                       `json:"variant_sku"`
+	DisplayName           string                       `json:"display_name"`
+	CategoryName          string                       `json:"category_name"`
+	CategoryID            string                       `json:"category_id"`
+	ImageURL              string                       `json:"image_url"`
+	ProductType           string                       `json:"product_type"`
+	Quantity              int64                        `json:"quantity"`
+	PriceCents            int64                        `json:"price_cents"`
+	UnitPriceCents        int64                        `json:"unit_price_cents"`
+	LineTotalCents        int64                        `json:"line_total_cents"`
+	StockQuantity         int64                        `json:"stock_quantity"`
+	BackorderShipDate     string                       `json:"backorder_ship_date"`
+	IsPreorder            bool                         `json:"is_preorder"`
+	PreorderShipDate      string                       `json:"preorder_ship_date"`
+	BundleID              string                       `json:"bundle_id"`
+	BundleGroupID         string                       `json:"bundle_group_id"`
+	BundleName            string                       `json:"bundle_name"`
+	Personalization       string                       `json:"personalization"`
+	PersonalizationValues []utils.PersonalizationValue `json:"personalization_values"`
+	// MaxPerOrder caps the product's quantity across the cart; 0 means no cap
+	MaxPerOrder int64 `json:"max_per_order"`
+}
+
+// VolumePrice is the quantity-break price applied to a cart line
+type VolumePrice struct {
+	UnitPriceCents    int64 `json:"unitPriceCents"`
+	RegularPriceCents int64 `json:"regularPriceCents"`
+	MinQuantity       int64 `json:"minQuantity"`
+}
+
+// ShippingConfig holds the delivery messages the cart shows under each line
+type ShippingConfig struct {
+	InStockMessage    string `json:"inStockMessage"`
+	OutOfStockMessage string `json:"outOfStockMessage"`
+	BackorderMessage  string `json:"backorderMessage"`
+	PreorderMessage   string `json:"preorderMessage"`
+	PreorderUndated   string `json:"preorderUndated"`
+	DigitalMessage    string `json:"digitalMessage"`
+}
+
+// FreeShipping is the cart's progress toward free shipping. ThresholdCents is zero when
+// free shipping is off or the cart has nothing to ship.
+type FreeShipping struct {
+	ThresholdCents int64 `json:"thresholdCents"`
+	RemainingCents int64 `json:"remainingCents"`
+	Percent        int64 `json:"percent"`
+	Qualifies      bool  `json:"qualifies"`
+}
+
+// NewFreeShipping measures a subtotal against the free-shipping threshold
+func NewFreeShipping(subtotalCents, thresholdCents int64) FreeShipping {
+	if thresholdCents <= 0 {
+		return FreeShipping{}
+	}
+	return FreeShipping{
+		ThresholdCents: thresholdCents,
+		RemainingCents: max(thresholdCents-subtotalCents, 0),
+		Percent:        min(subtotalCents*100/thresholdCents, 100),
+		Qualifies:      subtotalCents >= thresholdCents,
+	}
+}
+
+// Promotion is an automatic cart promotion the cart has earned, or one it's working
+// toward; Message says which, e.g. "Spend $12.50 more for 10% off orders over $50"
+type Promotion struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	Applied       bool   `json:"applied"`
+	DiscountCents int64  `json:"discountCents"`
+	Message       string `json:"message"`
+}
+
+// DefaultShippingConfig returns the site's standard delivery messages
+func DefaultShippingConfig() ShippingConfig {
+	return ShippingConfig{
+		InStockMessage:    utils.ShippingTimeInStock,
+		OutOfStockMessage: utils.ShippingTimeOutOfStock,
+		BackorderMessage:  utils.ShippingTimeBackorderPrefix,
+		PreorderMessage:   utils.ShippingTimePreorderPrefix,
+		PreorderUndated:   utils.ShippingTimePreorderUndated,
+		DigitalMessage:    utils.ShippingTimeDigital,
+	}
+}
+
+// NewCartItem builds a cart line from a signed-in shopper's cart row. Guest rows have
+// the same columns; convert them with db.GetCartByUserRow(row).
+func NewCartItem(row db.GetCartByUserRow) CartItem {
+	personalization := utils.DecodePersonalization(row.Personalization)
+	if personalization == nil {
+		personalization = []utils.PersonalizationValue{}
+	}
+
+	return CartItem{
+		ID:                    row.ID,
+		ProductID:             row.ProductID,
+		ProductSkuID:          row.ProductSkuID.String,
+		Name:                  row.Name,
+		VariantName:           row.VariantName,
+		VariantSKU:            row.VariantSku,
+		DisplayName:           variantDisplayName(row.Name, row.VariantName),
+		CategoryName:          row.CategoryName,
+		CategoryID:            row.CategoryID,
+		ImageURL:              productImageURL(row.ImageUrl),
+		ProductType:           row.ProductType,
+		Quantity:              row.Quantity,
+		PriceCents:            row.PriceCents,
+		UnitPriceCents:        row.PriceCents,
+		LineTotalCents:        row.PriceCents * row.Quantity,
+		StockQuantity:         row.StockQuantity,
+		BackorderShipDate:     row.BackorderShipDate,
+		IsPreorder:            row.IsPreorder,
+		PreorderShipDate:      row.PreorderShipDate,
+		BundleID:              row.BundleID,
+		BundleGroupID:         row.BundleGroupID,
+		BundleName:            row.BundleName,
+		Personalization:       row.Personalization,
+		PersonalizationValues: personalization,
+		MaxPerOrder:           row.MaxPerOrder,
+	}
+}
+
+// NewCart totals the lines, applying quantity-break prices keyed by cart item ID.
+// DigitalOnly is set when every line is a digital product.
+func NewCart(items []CartItem, volumePricing map[string]VolumePrice) Cart {
+	if items == nil {
+		items = []CartItem{}
+	}
+	if volumePricing == nil {
+		volumePricing = map[string]VolumePrice{}
+	}
+
+	cart := Cart{
+		Version:        Version,
+		Items:          items,
+		VolumePricing:  volumePricing,
+		DigitalOnly:    len(items) > 0,
+		ShippingConfig: DefaultShippingConfig(),
+		Promotions:     []Promotion{},
+		Problems:       []string{},
+	}
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if vp, ok := volumePricing[item.ID]; ok {
+			item.UnitPriceCents = vp.UnitPriceCents
+		}
+		item.LineTotalCents = item.UnitPriceCents * item.Quantity
+
+		cart.ItemCount += item.Quantity
+		cart.TotalCents += item.LineTotalCents
+		if item.ProductType != utils.ProductTypeDigital {
+			cart.DigitalOnly = false
+		}
+	}
+	cart.TotalDollar = float64(cart.TotalCents) / 100
+
+	return cart
+}
+
+// variantDisplayName names a line with its variant, e.g. "Dragon (Red - Small)"
+func variantDisplayName(name, variant string) string {
+	if variant == "" {
+		return name
+	}
+	return name + " (" + variant + ")"
+}
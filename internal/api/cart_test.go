@@ -0,0 +1,115 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonKeys returns the sorted keys of v once encoded as a JSON object
+func jsonKeys(t *testing.T, v interface{}) []string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestNewCart(t *testing.T) {
+	dragon := NewCartItem(db.GetCartByUserRow{
+		ID:              "item-1",
+		ProductID:       "dragon",
+		ProductSkuID:    sql.NullString{String: "sku-red-s", Valid: true},
+		Name:            "Dragon",
+		PriceCents:      1000,
+		ImageUrl:        "styles/dragon-red.png",
+		VariantSku:      "DRG-RED-S",
+		VariantName:     "Red - Small",
+		StockQuantity:   3,
+		ProductType:     "physical",
+		Quantity:        12,
+		Personalization: `[{"label":"Name","value":"Ada"}]`,
+	})
+	stl := NewCartItem(db.GetCartByUserRow(db.GetCartBySessionRow{
+		ID:          "item-2",
+		ProductID:   "stl",
+		Name:        "Dragon STL",
+		PriceCents:  500,
+		ImageUrl:    "stl.png",
+		ProductType: "digital",
+		Quantity:    1,
+	}))
+
+	cart := NewCart([]CartItem{dragon, stl}, map[string]VolumePrice{
+		"item-1": {UnitPriceCents: 800, RegularPriceCents: 1000, MinQuantity: 10},
+	})
+
+	assert.Equal(t, Version, cart.Version)
+	assert.Equal(t, int64(13), cart.ItemCount)
+	assert.Equal(t, int64(12*800+500), cart.TotalCents)
+	assert.InDelta(t, 101.00, cart.TotalDollar, 0.001)
+	assert.False(t, cart.DigitalOnly)
+
+	require.Len(t, cart.Items, 2)
+	assert.Equal(t, "Dragon (Red - Small)", cart.Items[0].DisplayName)
+	assert.Equal(t, "/public/images/products/styles/dragon-red.png", cart.Items[0].ImageURL)
+	assert.Equal(t, int64(1000), cart.Items[0].PriceCents, "price_cents stays the regular price")
+	assert.Equal(t, int64(800), cart.Items[0].UnitPriceCents)
+	assert.Equal(t, int64(9600), cart.Items[0].LineTotalCents)
+	assert.Equal(t, "sku-red-s", cart.Items[0].ProductSkuID)
+	require.Len(t, cart.Items[0].PersonalizationValues, 1)
+	assert.Equal(t, "Ada", cart.Items[0].PersonalizationValues[0].Value)
+	assert.Equal(t, "Dragon STL", cart.Items[1].DisplayName)
+	assert.Equal(t, "/public/images/products/stl.png", cart.Items[1].ImageURL)
+	assert.Empty(t, cart.Items[1].PersonalizationValues)
+}
+
+func TestNewCartDigitalOnly(t *testing.T) {
+	assert.False(t, NewCart(nil, nil).DigitalOnly, "an empty cart still needs shipping")
+	assert.True(t, NewCart([]CartItem{{ProductType: "digital", Quantity: 1}}, nil).DigitalOnly)
+}
+
+func TestNewFreeShipping(t *testing.T) {
+	assert.Equal(t, FreeShipping{}, NewFreeShipping(2500, 0), "a zero threshold turns free shipping off")
+	assert.Equal(t, FreeShipping{ThresholdCents: 5000, RemainingCents: 3500, Percent: 30}, NewFreeShipping(1500, 5000))
+	assert.Equal(t, FreeShipping{ThresholdCents: 5000, Percent: 100, Qualifies: true}, NewFreeShipping(5000, 5000))
+	assert.Equal(t, FreeShipping{ThresholdCents: 5000, Percent: 100, Qualifies: true}, NewFreeShipping(8000, 5000))
+}
+
+func TestCartJSONShape(t *testing.T) {
+	cart := NewCart([]CartItem{NewCartItem(db.GetCartByUserRow{ID: "item-1", Quantity: 1})}, nil)
+
+	assert.Equal(t, []string{
+		"digitalOnly", "discountCents", "freeShipping", "itemCount", "items", "problems", "promotions", "shippingConfig",
+		"totalCents", "totalDollar", "version", "volumePricing",
+	}, jsonKeys(t, cart))
+	assert.Equal(t, []string{
+		"backorder_ship_date", "bundle_group_id", "bundle_id", "bundle_name", "category_id", "category_name", "display_name",
+		"id", "image_url", "is_preorder", "line_total_cents", "max_per_order", "name", "personalization", "personalization_values",
+		"preorder_ship_date", "price_cents", "product_id", "product_sku_id", "product_type", "quantity",
+		"stock_quantity", "unit_price_cents", "variant_name", "variant_sku",
+	}, jsonKeys(t, cart.Items[0]))
+	assert.Equal(t, []string{"minQuantity", "regularPriceCents", "unitPriceCents"}, jsonKeys(t, VolumePrice{}))
+	assert.Equal(t, []string{"percent", "qualifies", "remainingCents", "thresholdCents"}, jsonKeys(t, cart.FreeShipping))
+	assert.Equal(t, []string{"applied", "discountCents", "id", "kind", "message", "name"}, jsonKeys(t, Promotion{}))
+	assert.Equal(t, []string{
+		"backorderMessage", "digitalMessage", "inStockMessage", "outOfStockMessage", "preorderMessage", "preorderUndated",
+	}, jsonKeys(t, cart.ShippingConfig))
+
+	data, err := json.Marshal(NewCart(nil, nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"items":[]`, "an empty cart encodes items as a list, not null")
+	assert.Contains(t, string(data), `"promotions":[]`)
+	assert.Contains(t, string(data), `"problems":[]`)
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Order is the response of GET /api/account/orders/:id. Payment provider IDs and
+// internal fulfillment fields are left out.
+type Order struct {
+	Version         int          `json:"version"`
+	ID              string       `json:"id"`
+	Number          string       `json:"number"`
+	Status          string       `json:"status"`
+	CreatedAt       *time.Time   `json:"created_at"`
+	Items           []OrderItem  `json:"items"`
+	ItemCount       int64        `json:"item_count"`
+	SubtotalCents   int64        `json:"subtotal_cents"`
+	DiscountCents   int64        `json:"discount_cents"`
+	ShippingCents   int64        `json:"shipping_cents"`
+	TaxCents        int64        `json:"tax_cents"`
+	TotalCents      int64        `json:"total_cents"`
+	PromotionCode   string       `json:"promotion_code"`
+	ShippingAddress OrderAddress `json:"shipping_address"`
+	Carrier         string       `json:"carrier"`
+	TrackingNumber  string       `json:"tracking_number"`
+	TrackingURL     string       `json:"tracking_url"`
+}
+
+// OrderAddress is where an order ships
+type OrderAddress struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// OrderItem is one line of an order as it was bought. ProductURL is empty once the
+// product is no longer listed.
+type OrderItem struct {
+	ID                    string                       `json:"id"`
+	ProductID             string                       `json:"product_id"`
+	ProductSkuID          string                       `json:"product_sku_id"`
+	Name                  string                       `json:"name"`
+	SKU                   string                       `json:"sku"`
+	CategoryName          string                       `json:"category_name"`
+	ImageURL              string                       `json:"image_url"`
+	ProductURL            string                       `json:"product_url"`
+	Quantity              int64                        `json:"quantity"`
+	UnitPriceCents        int64                        `json:"unit_price_cents"`
+	LineTotalCents        int64                        `json:"line_total_cents"`
+	BackorderedQuantity   int64                        `json:"backordered_quantity"`
+	EstimatedShipDate     string                       `json:"estimated_ship_date"`
+	IsPreorder            bool                         `json:"is_preorder"`
+	PersonalizationValues []utils.PersonalizationValue `json:"personalization_values"`
+}
+
+// NewOrderItem builds an order line. imageURL is already public; productSlug is empty
+// when the product is gone or inactive.
+func NewOrderItem(row db.GetOrderItemsRow, imageURL, productSlug string) OrderItem {
+	personalization := utils.DecodePersonalization(row.Personalization.String)
+	if personalization == nil {
+		personalization = []utils.PersonalizationValue{}
+	}
+
+	item := OrderItem{
+		ID:                    row.ID,
+		ProductID:             row.ProductID,
+		ProductSkuID:          row.ProductSkuID.String,
+		Name:                  row.ProductName,
+		SKU:                   row.ProductSku.String,
+		CategoryName:          row.CategoryName,
+		ImageURL:              imageURL,
+		Quantity:              row.Quantity,
+		UnitPriceCents:        row.UnitPriceCents,
+		LineTotalCents:        row.TotalPriceCents,
+		BackorderedQuantity:   row.BackorderedQuantity,
+		EstimatedShipDate:     row.EstimatedShipDate.String,
+		IsPreorder:            row.IsPreorder,
+		PersonalizationValues: personalization,
+	}
+	if productSlug != "" {
+		item.ProductURL = "/shop/product/" + productSlug
+	}
+	return item
+}
+
+// NewOrder builds the order response around its already built lines
+func NewOrder(order db.Order, items []OrderItem) Order {
+	if items == nil {
+		items = []OrderItem{}
+	}
+
+	number := order.ID
+	if len(number) > 8 {
+		number = number[:8]
+	}
+
+	resp := Order{
+		Version:       Version,
+		ID:            order.ID,
+		Number:        number,
+		Status:        order.Status.String,
+		Items:         items,
+		SubtotalCents: order.SubtotalCents,
+		DiscountCents: order.DiscountCents.Int64,
+		ShippingCents: order.ShippingCents,
+		TaxCents:      order.TaxCents,
+		TotalCents:    order.TotalCents,
+		PromotionCode: order.PromotionCode.String,
+		ShippingAddress: OrderAddress{
+			Name:       order.CustomerName,
+			Line1:      order.ShippingAddressLine1,
+			Line2:      order.ShippingAddressLine2.String,
+			City:       order.ShippingCity,
+			State:      order.ShippingState,
+			PostalCode: order.ShippingPostalCode,
+			Country:    order.ShippingCountry,
+		},
+		Carrier:        order.Carrier.String,
+		TrackingNumber: order.TrackingNumber.String,
+		TrackingURL:    order.TrackingUrl.String,
+	}
+	if order.CreatedAt.Valid {
+		createdAt := order.CreatedAt.Time
+		resp.CreatedAt = &createdAt
+	}
+	for _, item := range items {
+		resp.ItemCount += item.Quantity
+	}
+	return resp
+}
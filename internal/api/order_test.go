@@ -0,0 +1,72 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOrder(t *testing.T) {
+	placed := time.Date(2026, 3, 1, 15, 4, 0, 0, time.UTC)
+	order := db.Order{
+		ID:                   "0c9a7d2e-5f1b-4c3d-8e2a-1b2c3d4e5f60",
+		CustomerName:         "Ada Lovelace",
+		ShippingAddressLine1: "1 Main St",
+		ShippingCity:         "Madison",
+		ShippingState:        "WI",
+		ShippingPostalCode:   "53703",
+		ShippingCountry:      "US",
+		SubtotalCents:        3000,
+		ShippingCents:        500,
+		TaxCents:             180,
+		TotalCents:           3455,
+		DiscountCents:        sql.NullInt64{Int64: 225, Valid: true},
+		Status:               sql.NullString{String: "shipped", Valid: true},
+		TrackingNumber:       sql.NullString{String: "9400", Valid: true},
+		CreatedAt:            sql.NullTime{Time: placed, Valid: true},
+		StripeCustomerID:     sql.NullString{String: "cus_secret", Valid: true},
+	}
+	items := []OrderItem{
+		NewOrderItem(db.GetOrderItemsRow{
+			ID:              "oi-1",
+			ProductID:       "dragon",
+			ProductName:     "Dragon",
+			Quantity:        2,
+			UnitPriceCents:  1500,
+			TotalPriceCents: 3000,
+			Personalization: sql.NullString{String: `[{"label":"Name","value":"Ada"}]`, Valid: true},
+		}, "/public/images/products/dragon.png", "dragon"),
+		NewOrderItem(db.GetOrderItemsRow{ID: "oi-2", ProductID: "retired", ProductName: "Retired print", Quantity: 1}, "", ""),
+	}
+
+	resp := NewOrder(order, items)
+
+	assert.Equal(t, Version, resp.Version)
+	assert.Equal(t, "0c9a7d2e", resp.Number)
+	assert.Equal(t, "shipped", resp.Status)
+	require.NotNil(t, resp.CreatedAt)
+	assert.True(t, placed.Equal(*resp.CreatedAt))
+	assert.Equal(t, int64(3), resp.ItemCount)
+	assert.Equal(t, int64(225), resp.DiscountCents)
+	assert.Equal(t, "Madison", resp.ShippingAddress.City)
+	assert.Equal(t, "/shop/product/dragon", resp.Items[0].ProductURL)
+	assert.Equal(t, int64(3000), resp.Items[0].LineTotalCents)
+	require.Len(t, resp.Items[0].PersonalizationValues, 1)
+	assert.Empty(t, resp.Items[1].ProductURL, "lines for unlisted products don't link anywhere")
+
+	assert.Equal(t, []string{
+		"carrier", "created_at", "discount_cents", "id", "item_count", "items", "number", "promotion_code",
+		"shipping_address", "shipping_cents", "status", "subtotal_cents", "tax_cents", "total_cents",
+		"tracking_number", "tracking_url", "version",
+	}, jsonKeys(t, resp), "payment provider IDs are never exposed")
+	assert.Equal(t, []string{
+		"backordered_quantity", "category_name", "estimated_ship_date", "id", "image_url", "is_preorder",
+		"line_total_cents", "name", "personalization_values", "product_id", "product_sku_id", "product_url",
+		"quantity", "sku", "unit_price_cents",
+	}, jsonKeys(t, resp.Items[0]))
+	assert.Equal(t, []string{"city", "country", "line1", "line2", "name", "postal_code", "state"}, jsonKeys(t, resp.ShippingAddress))
+}
@@ -0,0 +1,37 @@
+package api
+
+import "github.com/loganlanou/logans3d-v4/storage/db"
+
+// ProductSummary is a product as listed by the JSON endpoints, such as a shopper's
+// favorites
+type ProductSummary struct {
+	ProductID  string `json:"product_id"`
+	Name       string `json:"name"`
+	Slug       string `json:"slug"`
+	URL        string `json:"url"`
+	PriceCents int64  `json:"price_cents"`
+	ImageURL   string `json:"image_url"`
+	InStock    bool   `json:"in_stock"`
+	Available  bool   `json:"available"`
+}
+
+// Favorites is the response of GET /api/favorites, newest first
+type Favorites struct {
+	Version   int              `json:"version"`
+	Favorites []ProductSummary `json:"favorites"`
+}
+
+// NewProductSummary builds a listing entry. availableStock is the product's stock, or
+// the total across its variants.
+func NewProductSummary(product db.Product, imageURL string, availableStock int64) ProductSummary {
+	return ProductSummary{
+		ProductID:  product.ID,
+		Name:       product.Name,
+		Slug:       product.Slug,
+		URL:        "/shop/product/" + product.Slug,
+		PriceCents: product.PriceCents,
+		ImageURL:   imageURL,
+		InStock:    availableStock > 0,
+		Available:  !product.IsActive.Valid || product.IsActive.Bool,
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductSummary(t *testing.T) {
+	product := db.Product{ID: "p1", Name: "Dragon", Slug: "dragon", PriceCents: 2500}
+
+	summary := NewProductSummary(product, "/public/images/products/dragon.png", 2)
+	assert.Equal(t, "/shop/product/dragon", summary.URL)
+	assert.True(t, summary.InStock)
+	assert.True(t, summary.Available, "products without an active flag are listed")
+
+	product.IsActive = sql.NullBool{Bool: false, Valid: true}
+	summary = NewProductSummary(product, "", 0)
+	assert.False(t, summary.InStock)
+	assert.False(t, summary.Available)
+
+	assert.Equal(t, []string{
+		"available", "image_url", "in_stock", "name", "price_cents", "product_id", "slug", "url",
+	}, jsonKeys(t, summary))
+	assert.Equal(t, []string{"favorites", "version"}, jsonKeys(t, Favorites{Version: Version}))
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// SavedItems is the response of GET /api/cart/saved: the lines set aside with "Save
+// for later", which aren't part of the cart's totals or shipping
+type SavedItems struct {
+	Version int         `json:"version"`
+	Items   []SavedItem `json:"items"`
+}
+
+// SavedItem is one saved line. Availability is one of the utils.SavedItem* statuses;
+// CanMoveToCart is false once the product sells out or is taken down.
+type SavedItem struct {
+	ID                    string                       `json:"id"`
+	ProductID             string                       `json:"product_id"`
+	ProductSkuID          string                       `json:"product_sku_id"`
+	Name                  string                       `json:"name"`
+	VariantName           string                       `json:"variant_name"`
+	DisplayName           string                       `json:"display_name"`
+	ImageURL              string                       `json:"image_url"`
+	ProductType           string                       `json:"product_type"`
+	Quantity              int64                        `json:"quantity"`
+	PriceCents            int64                        `json:"price_cents"`
+	StockQuantity         int64                        `json:"stock_quantity"`
+	Personalization       string                       `json:"personalization"`
+	PersonalizationValues []utils.PersonalizationValue `json:"personalization_values"`
+	Availability          string                       `json:"availability"`
+	AvailabilityMessage   string                       `json:"availability_message"`
+	CanMoveToCart         bool                         `json:"can_move_to_cart"`
+}
+
+// NewSavedItem builds a saved line with its current availability
+func NewSavedItem(row db.ListSavedCartItemsRow) SavedItem {
+	personalization := utils.DecodePersonalization(row.Personalization)
+	if personalization == nil {
+		personalization = []utils.PersonalizationValue{}
+	}
+
+	status, message := utils.SavedItemAvailability(utils.SavedItemStock{
+		Active:            row.IsActive != 0,
+		Stock:             row.StockQuantity,
+		LowStockThreshold: row.LowStockThreshold,
+		Quantity:          row.Quantity,
+		AllowBackorder:    row.AllowBackorder,
+		Preorder:          row.IsPreorder,
+		Digital:           row.ProductType == utils.ProductTypeDigital,
+	})
+
+	return SavedItem{
+		ID:                    row.ID,
+		ProductID:             row.ProductID,
+		ProductSkuID:          row.ProductSkuID.String,
+		Name:                  row.Name,
+		VariantName:           row.VariantName,
+		DisplayName:           variantDisplayName(row.Name, row.VariantName),
+		ImageURL:              productImageURL(row.ImageUrl),
+		ProductType:           row.ProductType,
+		Quantity:              row.Quantity,
+		PriceCents:            row.PriceCents,
+		StockQuantity:         row.StockQuantity,
+		Personalization:       row.Personalization,
+		PersonalizationValues: personalization,
+		Availability:          status,
+		AvailabilityMessage:   message,
+		CanMoveToCart:         utils.SavedItemMovable(status),
+	}
+}
+
+// NewSavedItems wraps saved lines in the versioned response
+func NewSavedItems(items []SavedItem) SavedItems {
+	if items == nil {
+		items = []SavedItem{}
+	}
+	return SavedItems{Version: Version, Items: items}
+}
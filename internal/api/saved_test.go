@@ -0,0 +1,55 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSavedItem(t *testing.T) {
+	item := NewSavedItem(db.ListSavedCartItemsRow{
+		ID:                "saved-1",
+		ProductID:         "dragon",
+		ProductSkuID:      sql.NullString{String: "sku-red-s", Valid: true},
+		Name:              "Dragon",
+		VariantName:       "Red - Small",
+		ImageUrl:          "styles/dragon-red.png",
+		ProductType:       "physical",
+		Quantity:          2,
+		PriceCents:        1500,
+		StockQuantity:     1,
+		LowStockThreshold: 5,
+		IsActive:          1,
+	})
+
+	assert.Equal(t, "Dragon (Red - Small)", item.DisplayName)
+	assert.Equal(t, utils.ProductImagePathPrefix+"styles/dragon-red.png", item.ImageURL)
+	assert.Equal(t, utils.SavedItemLowStock, item.Availability)
+	assert.Equal(t, "Only 1 left", item.AvailabilityMessage)
+	assert.True(t, item.CanMoveToCart)
+	assert.Equal(t, []utils.PersonalizationValue{}, item.PersonalizationValues)
+
+	soldOut := NewSavedItem(db.ListSavedCartItemsRow{ID: "saved-2", Quantity: 1, IsActive: 1})
+	assert.Equal(t, utils.SavedItemSoldOut, soldOut.Availability)
+	assert.False(t, soldOut.CanMoveToCart)
+}
+
+func TestSavedItemsJSONShape(t *testing.T) {
+	saved := NewSavedItems([]SavedItem{NewSavedItem(db.ListSavedCartItemsRow{ID: "saved-1", Quantity: 1, IsActive: 1})})
+
+	assert.Equal(t, []string{"items", "version"}, jsonKeys(t, saved))
+	assert.Equal(t, []string{
+		"availability", "availability_message", "can_move_to_cart", "display_name", "id", "image_url", "name",
+		"personalization", "personalization_values", "price_cents", "product_id", "product_sku_id", "product_type",
+		"quantity", "stock_quantity", "variant_name",
+	}, jsonKeys(t, saved.Items[0]))
+
+	data, err := json.Marshal(NewSavedItems(nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"items":[]`)
+}
@@ -0,0 +1,62 @@
+package api
+
+import "time"
+
+// SharedCartLink is the response of POST /api/cart/share. Bundle lines are priced
+// as a set, so they're left out of shared carts and counted in SkippedBundleItems.
+type SharedCartLink struct {
+	Version            int    `json:"version"`
+	Token              string `json:"token"`
+	Name               string `json:"name"`
+	URL                string `json:"url"`
+	ItemCount          int    `json:"itemCount"`
+	SkippedBundleItems int    `json:"skippedBundleItems"`
+}
+
+// SharedCartLoad is the response of POST /api/cart/shared/:token/load: how many
+// lines went into the visitor's cart, and why any others didn't
+type SharedCartLoad struct {
+	Version int              `json:"version"`
+	Added   int              `json:"added"`
+	Reduced int              `json:"reduced"`
+	Skipped []SharedCartSkip `json:"skipped"`
+}
+
+// SharedCartSkip is a shared line that couldn't be added, e.g. because it sold out
+type SharedCartSkip struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// NewSharedCartLoad wraps a load result in the versioned response
+func NewSharedCartLoad(added, reduced int, skipped []SharedCartSkip) SharedCartLoad {
+	if skipped == nil {
+		skipped = []SharedCartSkip{}
+	}
+	return SharedCartLoad{Version: Version, Added: added, Reduced: reduced, Skipped: skipped}
+}
+
+// SharedCarts is the response of GET /api/cart/shares: the carts this shopper has
+// shared, newest first
+type SharedCarts struct {
+	Version int                 `json:"version"`
+	Carts   []SharedCartSummary `json:"carts"`
+}
+
+// SharedCartSummary is one shared cart and how often it's been loaded
+type SharedCartSummary struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	URL       string     `json:"url"`
+	ItemCount int64      `json:"item_count"`
+	LoadCount int64      `json:"load_count"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// NewSharedCarts wraps shared carts in the versioned response
+func NewSharedCarts(carts []SharedCartSummary) SharedCarts {
+	if carts == nil {
+		carts = []SharedCartSummary{}
+	}
+	return SharedCarts{Version: Version, Carts: carts}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedCartLoadJSONShape(t *testing.T) {
+	load := NewSharedCartLoad(2, 1, []SharedCartSkip{{Name: "Dragon", Reason: "Dragon is sold out"}})
+
+	assert.Equal(t, []string{"added", "reduced", "skipped", "version"}, jsonKeys(t, load))
+	assert.Equal(t, []string{"name", "reason"}, jsonKeys(t, load.Skipped[0]))
+
+	data, err := json.Marshal(NewSharedCartLoad(1, 0, nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"skipped":[]`)
+}
+
+func TestSharedCartsJSONShape(t *testing.T) {
+	createdAt := time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC)
+	carts := NewSharedCarts([]SharedCartSummary{{ID: "share-1", Name: "Birthday", ItemCount: 3, CreatedAt: &createdAt}})
+
+	assert.Equal(t, []string{"carts", "version"}, jsonKeys(t, carts))
+	assert.Equal(t, []string{"created_at", "id", "item_count", "load_count", "name", "url"}, jsonKeys(t, carts.Carts[0]))
+
+	link := SharedCartLink{Version: Version, Token: "abc", Name: "Birthday"}
+	assert.Equal(t, []string{"itemCount", "name", "skippedBundleItems", "token", "url", "version"}, jsonKeys(t, link))
+
+	data, err := json.Marshal(NewSharedCarts(nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"carts":[]`)
+}
@@ -14,10 +14,33 @@ import (
 
 type ctxKeyAPIKey struct{}
 
+// API key scopes. products:* cover the product importer; catalog:read and
+// inventory:read grant read-only access to the storefront API.
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeCatalogRead   = "catalog:read"
+	ScopeInventoryRead = "inventory:read"
+)
+
+// APIKeyScopes lists every scope an admin can grant, in display order
+var APIKeyScopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeCatalogRead, ScopeInventoryRead}
+
+// IsValidScope reports whether scope is one of APIKeyScopes
+func IsValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 type APIKeyInfo struct {
-	ID          string
-	Name        string
-	Permissions string
+	ID                 string
+	Name               string
+	Permissions        string
+	RateLimitPerMinute int64
 }
 
 // APIKeyAuth creates middleware that authenticates requests using API keys.
@@ -64,9 +87,10 @@ func APIKeyAuth(store *storage.Storage) echo.MiddlewareFunc {
 			}
 
 			info := &APIKeyInfo{
-				ID:          apiKey.ID,
-				Name:        apiKey.Name,
-				Permissions: permissions,
+				ID:                 apiKey.ID,
+				Name:               apiKey.Name,
+				Permissions:        permissions,
+				RateLimitPerMinute: apiKey.RateLimitPerMinute,
 			}
 
 			ctx := context.WithValue(c.Request().Context(), ctxKeyAPIKey{}, info)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"log/slog"
+	"strings"
 
 	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/google/uuid"
@@ -122,6 +123,16 @@ func getFirstEmail(clerkUser *clerk.User) string {
 	return clerkUser.EmailAddresses[0].EmailAddress
 }
 
+// emailVerified reports whether Clerk has verified that the user owns an address
+func emailVerified(clerkUser *clerk.User, address string) bool {
+	for _, email := range clerkUser.EmailAddresses {
+		if strings.EqualFold(email.EmailAddress, address) {
+			return email.Verification != nil && email.Verification.Status == "verified"
+		}
+	}
+	return false
+}
+
 // buildFullName constructs a full name from available user data
 func buildFullName(firstName, lastName, username, email string) string {
 	if firstName != "" && lastName != "" {
@@ -191,5 +202,19 @@ func syncUserToDatabase(ctx context.Context, storage *storage.Storage, clerkUser
 		slog.Info("associated email history with user", "user_id", dbUser.ID, "email", email, "rows_affected", rowsAffected)
 	}
 
+	// Orders checked out as a guest with this email join the new account, as long as
+	// Clerk has confirmed the address belongs to them
+	if email != "" && emailVerified(clerkUser, email) {
+		claimed, err := storage.Queries.ClaimGuestOrdersByEmail(ctx, db.ClaimGuestOrdersByEmailParams{
+			UserID: dbUser.ID,
+			Email:  email,
+		})
+		if err != nil {
+			slog.Error("failed to claim guest orders for user", "error", err, "user_id", dbUser.ID)
+		} else if claimed > 0 {
+			slog.Info("claimed guest orders for user", "user_id", dbUser.ID, "orders", claimed)
+		}
+	}
+
 	return &dbUser, nil
 }
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultAPIKeyRateLimit is used for keys without a positive per-minute limit
+const DefaultAPIKeyRateLimit = 60
+
+// RateLimiter counts requests per API key in fixed one-minute windows. Counts live
+// in memory, so a restart resets them; that is fine for a single-instance server.
+type RateLimiter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	start time.Time
+	count int64
+}
+
+// NewRateLimiter creates an empty per-key limiter with one-minute windows
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		window:  time.Minute,
+		buckets: make(map[string]*rateBucket),
+	}
+}
+
+// Allow records a request for key and reports whether it fits within limit. It
+// returns the requests left in the current window, and when the request is
+// rejected, how long until the window resets.
+func (l *RateLimiter) Allow(key string, limit int64, now time.Time) (bool, int64, time.Duration) {
+	if limit <= 0 {
+		limit = DefaultAPIKeyRateLimit
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok || now.Sub(bucket.start) >= l.window {
+		bucket = &rateBucket{start: now}
+		l.buckets[key] = bucket
+		l.prune(now)
+	}
+
+	if bucket.count >= limit {
+		return false, 0, bucket.start.Add(l.window).Sub(now)
+	}
+	bucket.count++
+	return true, limit - bucket.count, 0
+}
+
+// prune drops expired windows so keys that stop calling don't accumulate.
+// Callers must hold l.mu.
+func (l *RateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.start) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// APIKeyRateLimit limits each API key to its configured requests per minute. It
+// must run after APIKeyAuth so the key is in the request context.
+func APIKeyRateLimit(limiter *RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			apiKey := GetAPIKeyInfo(c.Request().Context())
+			if apiKey == nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "API key required")
+			}
+
+			limit := apiKey.RateLimitPerMinute
+			if limit <= 0 {
+				limit = DefaultAPIKeyRateLimit
+			}
+
+			allowed, remaining, retryAfter := limiter.Allow(apiKey.ID, limit, time.Now())
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+			header.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+			if !allowed {
+				seconds := int64(retryAfter.Seconds())
+				if retryAfter > time.Duration(seconds)*time.Second {
+					seconds++
+				}
+				header.Set("Retry-After", strconv.FormatInt(seconds, 10))
+				slog.Warn("API key rate limit exceeded", "api_key_id", apiKey.ID, "limit", limit)
+				return echo.NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded")
+			}
+
+			return next(c)
+		}
+	}
+}
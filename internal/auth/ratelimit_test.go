@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter := NewRateLimiter()
+	start := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	for i := int64(0); i < 3; i++ {
+		allowed, remaining, _ := limiter.Allow("key-a", 3, start.Add(time.Duration(i)*time.Second))
+		assert.True(t, allowed)
+		assert.Equal(t, 2-i, remaining)
+	}
+
+	allowed, remaining, retryAfter := limiter.Allow("key-a", 3, start.Add(20*time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, int64(0), remaining)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	// Other keys have their own window
+	allowed, _, _ = limiter.Allow("key-b", 3, start.Add(20*time.Second))
+	assert.True(t, allowed)
+
+	// A new window starts once the minute is up
+	allowed, remaining, _ = limiter.Allow("key-a", 3, start.Add(time.Minute))
+	assert.True(t, allowed)
+	assert.Equal(t, int64(2), remaining)
+}
+
+func TestRateLimiter_DefaultLimit(t *testing.T) {
+	limiter := NewRateLimiter()
+	now := time.Now()
+
+	allowed, remaining, _ := limiter.Allow("key", 0, now)
+	assert.True(t, allowed)
+	assert.Equal(t, int64(DefaultAPIKeyRateLimit-1), remaining)
+}
+
+func TestIsValidScope(t *testing.T) {
+	assert.True(t, IsValidScope(ScopeCatalogRead))
+	assert.True(t, IsValidScope(ScopeProductsWrite))
+	assert.False(t, IsValidScope("orders:read"))
+}
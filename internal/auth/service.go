@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -152,3 +154,15 @@ func (c *userCache) Stop() {
 	c.cleanup.Stop()
 	c.done <- true
 }
+
+// DeleteClerkUser deletes a user from Clerk, which ends their sessions, when they delete
+// their account. A user Clerk no longer has counts as deleted, so trying again after a
+// failure part way through finishes the job.
+func DeleteClerkUser(ctx context.Context, clerkID string) error {
+	_, err := user.Delete(ctx, clerkID)
+	var apiErr *clerk.APIErrorResponse
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
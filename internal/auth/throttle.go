@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Throttle scopes: every throttled request spends a token from its IP's bucket and
+// one from its visitor's (signed-in user, else session cookie)
+const (
+	ThrottleScopeIP      = "ip"
+	ThrottleScopeVisitor = "visitor"
+)
+
+// throttleRecentBlocks is how many rejected requests Stats keeps for the dashboard
+const throttleRecentBlocks = 50
+
+// ThrottleLimit is a token bucket: Burst requests at once, refilled at PerMinute
+type ThrottleLimit struct {
+	PerMinute int64
+	Burst     int64
+}
+
+// ThrottleConfig sets the per-IP and per-visitor limits. Requests from Allowlist
+// addresses are never throttled.
+type ThrottleConfig struct {
+	PerIP      ThrottleLimit
+	PerVisitor ThrottleLimit
+	Allowlist  []netip.Prefix
+}
+
+// Throttle rate-limits public endpoints with in-memory token buckets. Like
+// RateLimiter, a restart resets it.
+type Throttle struct {
+	mu        sync.Mutex
+	config    ThrottleConfig
+	buckets   map[string]*tokenBucket
+	counts    map[string]*ThrottleScopeStats
+	exempt    int64
+	recent    []ThrottleBlock
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ThrottleScopeStats counts requests let through and turned away for one scope
+type ThrottleScopeStats struct {
+	Scope   string
+	Allowed int64
+	Limited int64
+}
+
+// ThrottleBlock is a request that got a 429
+type ThrottleBlock struct {
+	At     time.Time
+	Scope  string
+	Key    string
+	Method string
+	Path   string
+}
+
+// ThrottleStats is what the developer dashboard shows
+type ThrottleStats struct {
+	Config  ThrottleConfig
+	Scopes  []ThrottleScopeStats
+	Exempt  int64
+	Buckets int
+	Recent  []ThrottleBlock
+}
+
+// NewThrottle creates a throttle with empty buckets
+func NewThrottle(config ThrottleConfig) *Throttle {
+	return &Throttle{
+		config:  config,
+		buckets: make(map[string]*tokenBucket),
+		counts: map[string]*ThrottleScopeStats{
+			ThrottleScopeIP:      {Scope: ThrottleScopeIP},
+			ThrottleScopeVisitor: {Scope: ThrottleScopeVisitor},
+		},
+	}
+}
+
+// ParseAllowlist reads a comma-separated list of IP addresses and CIDR ranges,
+// e.g. "203.0.113.7, 10.0.0.0/8"
+func ParseAllowlist(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// Allowlisted reports whether ip is on the allowlist
+func (t *Throttle) Allowlisted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t.config.Allowlist {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow spends a token from key's bucket in scope. When the bucket is empty it
+// returns false and how long until the next token arrives.
+func (t *Throttle) Allow(scope, key string, now time.Time) (bool, time.Duration) {
+	limit := t.config.PerIP
+	if scope == ThrottleScopeVisitor {
+		limit = t.config.PerVisitor
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := t.counts[scope]
+	if limit.PerMinute <= 0 {
+		counts.Allowed++
+		return true, 0
+	}
+	burst := float64(max(limit.Burst, 1))
+	perSecond := float64(limit.PerMinute) / 60
+
+	bucketKey := scope + ":" + key
+	bucket, ok := t.buckets[bucketKey]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		t.buckets[bucketKey] = bucket
+	} else {
+		bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond)
+		bucket.last = now
+	}
+	t.prune(now)
+
+	if bucket.tokens < 1 {
+		counts.Limited++
+		wait := time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	counts.Allowed++
+	return true, 0
+}
+
+// prune drops buckets that have refilled completely, since they behave the same as
+// a new bucket. It runs at most once a minute. Callers must hold t.mu.
+func (t *Throttle) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < time.Minute {
+		return
+	}
+	t.lastPrune = now
+	for key, bucket := range t.buckets {
+		limit := t.config.PerIP
+		if strings.HasPrefix(key, ThrottleScopeVisitor+":") {
+			limit = t.config.PerVisitor
+		}
+		if limit.PerMinute <= 0 {
+			delete(t.buckets, key)
+			continue
+		}
+		refill := time.Duration(float64(max(limit.Burst, 1)) / float64(limit.PerMinute) * float64(time.Minute))
+		if now.Sub(bucket.last) >= refill {
+			delete(t.buckets, key)
+		}
+	}
+}
+
+func (t *Throttle) recordExempt() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exempt++
+}
+
+func (t *Throttle) recordBlock(block ThrottleBlock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recent = append(t.recent, block)
+	if len(t.recent) > throttleRecentBlocks {
+		t.recent = t.recent[len(t.recent)-throttleRecentBlocks:]
+	}
+}
+
+// Stats returns the counters since the server started, newest blocks first
+func (t *Throttle) Stats() ThrottleStats {
+	if t == nil {
+		return ThrottleStats{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := ThrottleStats{
+		Config:  t.config,
+		Scopes:  []ThrottleScopeStats{*t.counts[ThrottleScopeIP], *t.counts[ThrottleScopeVisitor]},
+		Exempt:  t.exempt,
+		Buckets: len(t.buckets),
+		Recent:  make([]ThrottleBlock, 0, len(t.recent)),
+	}
+	for i := len(t.recent) - 1; i >= 0; i-- {
+		stats.Recent = append(stats.Recent, t.recent[i])
+	}
+	return stats
+}
+
+// PublicRateLimit throttles requests by IP and by visitor, answering 429 with a
+// Retry-After header once either bucket runs dry. Admins and allowlisted IPs pass
+// straight through, as does everything skip returns true for. It must run after
+// ClerkAuthMiddleware so signed-in shoppers are counted by account. A nil throttle
+// turns it off.
+func PublicRateLimit(t *Throttle, skip middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if t == nil || (skip != nil && skip(c)) {
+				return next(c)
+			}
+
+			ip := c.RealIP()
+			if IsAdmin(c) || t.Allowlisted(ip) {
+				t.recordExempt()
+				return next(c)
+			}
+
+			checks := [][2]string{{ThrottleScopeIP, ip}}
+			if userID, ok := GetUserID(c); ok {
+				checks = append(checks, [2]string{ThrottleScopeVisitor, "user:" + userID})
+			} else if cookie, err := c.Cookie("session_id"); err == nil && cookie.Value != "" {
+				checks = append(checks, [2]string{ThrottleScopeVisitor, "session:" + cookie.Value})
+			}
+
+			now := time.Now()
+			for _, check := range checks {
+				scope, key := check[0], check[1]
+				allowed, retryAfter := t.Allow(scope, key, now)
+				if allowed {
+					continue
+				}
+
+				seconds := int64(math.Ceil(retryAfter.Seconds()))
+				c.Response().Header().Set("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+				t.recordBlock(ThrottleBlock{At: now, Scope: scope, Key: key, Method: c.Request().Method, Path: c.Request().URL.Path})
+				slog.Warn("public rate limit exceeded",
+					"event", "rate_limited",
+					"scope", scope,
+					"key", key,
+					"ip", ip,
+					"method", c.Request().Method,
+					"path", c.Request().URL.Path,
+					"retry_after_seconds", seconds)
+				return echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests, please slow down")
+			}
+
+			return next(c)
+		}
+	}
+}
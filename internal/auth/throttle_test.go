@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle_Allow(t *testing.T) {
+	throttle := NewThrottle(ThrottleConfig{PerIP: ThrottleLimit{PerMinute: 60, Burst: 3}})
+	start := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := throttle.Allow(ThrottleScopeIP, "203.0.113.7", start)
+		assert.True(t, allowed, "the burst is let through at once")
+	}
+
+	allowed, retryAfter := throttle.Allow(ThrottleScopeIP, "203.0.113.7", start)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, retryAfter, "60 a minute refills one token a second")
+
+	// Other addresses have their own bucket
+	allowed, _ = throttle.Allow(ThrottleScopeIP, "203.0.113.8", start)
+	assert.True(t, allowed)
+
+	// A token comes back each second
+	allowed, _ = throttle.Allow(ThrottleScopeIP, "203.0.113.7", start.Add(time.Second))
+	assert.True(t, allowed)
+	allowed, _ = throttle.Allow(ThrottleScopeIP, "203.0.113.7", start.Add(time.Second))
+	assert.False(t, allowed)
+
+	// A zero per-minute limit turns the scope off
+	allowed, _ = throttle.Allow(ThrottleScopeVisitor, "session:abc", start)
+	assert.True(t, allowed)
+
+	stats := throttle.Stats()
+	require.Len(t, stats.Scopes, 2)
+	assert.Equal(t, ThrottleScopeStats{Scope: ThrottleScopeIP, Allowed: 5, Limited: 2}, stats.Scopes[0])
+	assert.Equal(t, ThrottleScopeStats{Scope: ThrottleScopeVisitor, Allowed: 1}, stats.Scopes[1])
+}
+
+func TestParseAllowlist(t *testing.T) {
+	allowlist, err := ParseAllowlist(" 203.0.113.7, 10.0.0.0/8,,2001:db8::1 ")
+	require.NoError(t, err)
+	require.Len(t, allowlist, 3)
+
+	throttle := NewThrottle(ThrottleConfig{Allowlist: allowlist})
+	assert.True(t, throttle.Allowlisted("203.0.113.7"))
+	assert.True(t, throttle.Allowlisted("10.20.30.40"))
+	assert.True(t, throttle.Allowlisted("2001:db8::1"))
+	assert.False(t, throttle.Allowlisted("203.0.113.8"))
+	assert.False(t, throttle.Allowlisted("not-an-ip"))
+
+	_, err = ParseAllowlist("10.0.0.0/99")
+	assert.Error(t, err)
+}
+
+func TestPublicRateLimit(t *testing.T) {
+	e := echo.New()
+	throttle := NewThrottle(ThrottleConfig{
+		PerIP:      ThrottleLimit{PerMinute: 60, Burst: 10},
+		PerVisitor: ThrottleLimit{PerMinute: 60, Burst: 2},
+	})
+	handler := PublicRateLimit(throttle, nil)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := func(ip, session string) (int, error) {
+		req := httptest.NewRequest(http.MethodPost, "/api/cart/add", nil)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		if session != "" {
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: session})
+		}
+		rec := httptest.NewRecorder()
+		err := handler(e.NewContext(req, rec))
+		return rec.Code, err
+	}
+
+	for i := 0; i < 2; i++ {
+		code, err := request("203.0.113.7", "abc")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	_, err := request("203.0.113.7", "abc")
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusTooManyRequests, httpErr.Code, "the session's bucket is empty")
+
+	// A fresh session from the same address still has its IP allowance
+	code, err := request("203.0.113.7", "def")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	stats := throttle.Stats()
+	require.Len(t, stats.Recent, 1)
+	assert.Equal(t, ThrottleScopeVisitor, stats.Recent[0].Scope)
+	assert.Equal(t, "session:abc", stats.Recent[0].Key)
+	assert.Equal(t, "/api/cart/add", stats.Recent[0].Path)
+}
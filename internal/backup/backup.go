@@ -0,0 +1,369 @@
+// Package backup takes online snapshots of the SQLite database, keeps the most
+// recent ones on disk, optionally copies them to S3-compatible storage and can
+// restore the live database from a snapshot.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modernc.org/sqlite"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+const (
+	// snapshotPrefix starts every snapshot file name
+	snapshotPrefix = "logans3d-"
+
+	// snapshotTimeLayout is the timestamp in snapshot file names, in UTC
+	snapshotTimeLayout = "20060102-150405"
+
+	// restorePageStep is how many pages a restore copies per step
+	restorePageStep = 256
+)
+
+// snapshotNamePattern matches snapshot files, with an optional label such as "pre-restore"
+var snapshotNamePattern = regexp.MustCompile(`^logans3d-\d{8}-\d{6}(-[a-z0-9-]+)?\.db$`)
+
+var (
+	// ErrInvalidSnapshot is returned for names that aren't snapshots in the backup directory
+	ErrInvalidSnapshot = errors.New("invalid snapshot name")
+
+	// ErrBusy is returned when a backup or restore is already running
+	ErrBusy = errors.New("a backup or restore is already running")
+)
+
+// Config controls where snapshots go and how often they're taken
+type Config struct {
+	Dir      string        // local snapshot directory
+	Keep     int           // snapshots kept on disk; older ones are deleted
+	Interval time.Duration // time between scheduled snapshots; 0 turns the schedule off
+	S3       S3Config      // optional off-site copy
+}
+
+// Snapshot is a backup file on disk
+type Snapshot struct {
+	Name      string
+	SizeBytes int64
+	CreatedAt time.Time
+}
+
+// Manager takes, lists and restores snapshots. Only one backup or restore runs at a time.
+type Manager struct {
+	storage  *storage.Storage
+	cfg      Config
+	uploader *S3Uploader
+	mu       sync.Mutex
+	timer    *time.Timer
+	done     chan bool
+}
+
+func NewManager(storage *storage.Storage, cfg Config) *Manager {
+	m := &Manager{
+		storage: storage,
+		cfg:     cfg,
+		done:    make(chan bool),
+	}
+	if cfg.S3.Enabled() {
+		m.uploader = NewS3Uploader(cfg.S3)
+	}
+	return m
+}
+
+// Config returns the manager's settings
+func (m *Manager) Config() Config {
+	return m.cfg
+}
+
+// Create takes a snapshot with VACUUM INTO, which copies a consistent, compacted
+// database while the site keeps serving. Old snapshots are rotated out and the new
+// one is uploaded when S3 is configured; a failed upload still keeps the local copy.
+func (m *Manager) Create(ctx context.Context) (Snapshot, error) {
+	if !m.mu.TryLock() {
+		return Snapshot{}, ErrBusy
+	}
+	defer m.mu.Unlock()
+
+	snapshot, err := m.create(ctx, "")
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if m.uploader != nil {
+		if err := m.uploader.Upload(ctx, filepath.Join(m.cfg.Dir, snapshot.Name), snapshot.Name); err != nil {
+			slog.Error("failed to upload backup", "error", err, "name", snapshot.Name)
+		} else {
+			slog.Info("uploaded backup", "name", snapshot.Name, "bucket", m.cfg.S3.Bucket)
+		}
+	}
+	return snapshot, nil
+}
+
+func (m *Manager) create(ctx context.Context, label string) (Snapshot, error) {
+	if err := os.MkdirAll(m.cfg.Dir, 0750); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := snapshotName(time.Now(), label)
+	path := filepath.Join(m.cfg.Dir, name)
+
+	startTime := time.Now()
+	// VACUUM INTO takes a file name, not a bound parameter on every SQLite build
+	if _, err := m.storage.DB().ExecContext(ctx, "VACUUM INTO '"+strings.ReplaceAll(path, "'", "''")+"'"); err != nil {
+		os.Remove(path)
+		return Snapshot{}, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+	slog.Info("database backup created", "name", name, "size_bytes", info.Size(), "duration", time.Since(startTime))
+
+	if err := m.rotate(); err != nil {
+		slog.Error("failed to rotate backups", "error", err)
+	}
+
+	return Snapshot{Name: name, SizeBytes: info.Size(), CreatedAt: info.ModTime()}, nil
+}
+
+// List returns the snapshots on disk, newest first
+func (m *Manager) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.cfg.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || !snapshotNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Name:      entry.Name(),
+			SizeBytes: info.Size(),
+			CreatedAt: snapshotTime(entry.Name(), info.ModTime()),
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Path returns the file for a snapshot name, refusing anything outside the backup directory
+func (m *Manager) Path(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return "", ErrInvalidSnapshot
+	}
+	path := filepath.Join(m.cfg.Dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrInvalidSnapshot
+	}
+	return path, nil
+}
+
+// Restore replaces the live database with a snapshot. The snapshot is checked
+// first and the current database is saved as a "pre-restore" snapshot, so a
+// restore can itself be undone. Pending migrations run afterwards in case the
+// snapshot predates them.
+func (m *Manager) Restore(ctx context.Context, name string) error {
+	path, err := m.Path(name)
+	if err != nil {
+		return err
+	}
+
+	if !m.mu.TryLock() {
+		return ErrBusy
+	}
+	defer m.mu.Unlock()
+
+	if err := checkSnapshot(ctx, path); err != nil {
+		return err
+	}
+
+	safety, err := m.create(ctx, "pre-restore")
+	if err != nil {
+		return fmt.Errorf("failed to save current database before restore: %w", err)
+	}
+
+	conn, err := m.storage.DB().Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		restorer, ok := driverConn.(interface {
+			NewRestore(srcURI string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return errors.New("database driver does not support restore")
+		}
+
+		bck, err := restorer.NewRestore(path)
+		if err != nil {
+			return err
+		}
+		for {
+			more, err := bck.Step(restorePageStep)
+			if err != nil {
+				bck.Finish()
+				return err
+			}
+			if !more {
+				break
+			}
+		}
+		return bck.Finish()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot (current data saved as %s): %w", safety.Name, err)
+	}
+
+	if err := m.storage.Migrate(); err != nil {
+		return fmt.Errorf("restored %s but migrations failed: %w", name, err)
+	}
+
+	slog.Warn("database restored from backup", "name", name, "pre_restore_backup", safety.Name)
+	return nil
+}
+
+// checkSnapshot opens a snapshot read-only and runs SQLite's integrity check
+func checkSnapshot(ctx context.Context, path string) error {
+	snapshotDB, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer snapshotDB.Close()
+
+	var result string
+	if err := snapshotDB.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check snapshot: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("snapshot failed integrity check: %s", result)
+	}
+
+	// A snapshot taken by a newer release has a schema this one doesn't know
+	state, err := storage.Migrations(ctx, snapshotDB)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot migrations: %w", err)
+	}
+	if state.Ahead() {
+		return fmt.Errorf("%w: the snapshot is at migration %d but the newest this binary has is %d", storage.ErrDatabaseAhead, state.Current, state.Latest)
+	}
+	return nil
+}
+
+// rotate deletes the oldest snapshots beyond the configured number to keep
+func (m *Manager) rotate() error {
+	snapshots, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range expiredSnapshots(snapshots, m.cfg.Keep) {
+		if err := os.Remove(filepath.Join(m.cfg.Dir, snapshot.Name)); err != nil {
+			return err
+		}
+		slog.Info("deleted old backup", "name", snapshot.Name)
+	}
+	return nil
+}
+
+// expiredSnapshots returns the snapshots past the newest keep, given newest first.
+// A keep below 1 keeps everything.
+func expiredSnapshots(snapshots []Snapshot, keep int) []Snapshot {
+	if keep < 1 || len(snapshots) <= keep {
+		return nil
+	}
+	return snapshots[keep:]
+}
+
+func snapshotName(t time.Time, label string) string {
+	name := snapshotPrefix + t.UTC().Format(snapshotTimeLayout)
+	if label != "" {
+		name += "-" + label
+	}
+	return name + ".db"
+}
+
+// snapshotTime reads the time from a snapshot name, falling back to the file time
+func snapshotTime(name string, fallback time.Time) time.Time {
+	stamp := strings.TrimPrefix(name, snapshotPrefix)
+	if len(stamp) < len(snapshotTimeLayout) {
+		return fallback
+	}
+	t, err := time.Parse(snapshotTimeLayout, stamp[:len(snapshotTimeLayout)])
+	if err != nil {
+		return fallback
+	}
+	return t
+}
+
+// Start runs scheduled snapshots every Interval, counting from the newest snapshot
+// on disk so restarts don't reset the clock
+func (m *Manager) Start(ctx context.Context) {
+	if m.cfg.Interval <= 0 {
+		slog.Info("scheduled backups disabled")
+		return
+	}
+	slog.Info("starting backup scheduler", "interval", m.cfg.Interval, "dir", m.cfg.Dir, "keep", m.cfg.Keep, "s3", m.uploader != nil)
+
+	var last time.Time
+	if snapshots, err := m.List(); err == nil && len(snapshots) > 0 {
+		last = snapshots[0].CreatedAt
+	}
+	m.timer = time.NewTimer(untilNextBackup(last, time.Now(), m.cfg.Interval))
+
+	go func() {
+		for {
+			select {
+			case <-m.timer.C:
+				if _, err := m.Create(ctx); err != nil {
+					slog.Error("scheduled backup failed", "error", err)
+				}
+				m.timer.Reset(m.cfg.Interval)
+			case <-m.done:
+				slog.Info("backup scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (m *Manager) Stop() {
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	close(m.done)
+}
+
+// untilNextBackup is how long to wait before the next scheduled snapshot. A minute's
+// grace lets the site finish starting when a backup is already due.
+func untilNextBackup(last, now time.Time, interval time.Duration) time.Duration {
+	wait := last.Add(interval).Sub(now)
+	if wait < time.Minute {
+		return time.Minute
+	}
+	return wait
+}
@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotName(t *testing.T) {
+	at := time.Date(2026, 1, 21, 9, 30, 5, 0, time.UTC)
+
+	assert.Equal(t, "logans3d-20260121-093005.db", snapshotName(at, ""))
+	assert.Equal(t, "logans3d-20260121-093005-pre-restore.db", snapshotName(at, "pre-restore"))
+	assert.True(t, snapshotNamePattern.MatchString(snapshotName(at, "pre-restore")))
+	assert.Equal(t, at, snapshotTime(snapshotName(at, "pre-restore"), time.Time{}))
+}
+
+func TestSnapshotNamePattern(t *testing.T) {
+	for _, name := range []string{
+		"../logans3d-20260121-093005.db",
+		"logans3d-20260121-093005.db-wal",
+		"logans3d.db",
+		"other-20260121-093005.db",
+		"logans3d-20260121-093005-../x.db",
+	} {
+		assert.False(t, snapshotNamePattern.MatchString(name), name)
+	}
+}
+
+func TestExpiredSnapshots(t *testing.T) {
+	snapshots := []Snapshot{{Name: "c"}, {Name: "b"}, {Name: "a"}}
+
+	assert.Equal(t, []Snapshot{{Name: "a"}}, expiredSnapshots(snapshots, 2))
+	assert.Empty(t, expiredSnapshots(snapshots, 3))
+	assert.Empty(t, expiredSnapshots(snapshots, 0))
+}
+
+func TestUntilNextBackup(t *testing.T) {
+	now := time.Date(2026, 1, 21, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 18*time.Hour, untilNextBackup(now.Add(-6*time.Hour), now, 24*time.Hour))
+	// Overdue or never backed up: soon after startup
+	assert.Equal(t, time.Minute, untilNextBackup(now.Add(-48*time.Hour), now, 24*time.Hour))
+	assert.Equal(t, time.Minute, untilNextBackup(time.Time{}, now, 24*time.Hour))
+}
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
@@ -0,0 +1,143 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Config points at an S3-compatible bucket (AWS S3, Backblaze B2, Cloudflare R2, MinIO)
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.us-east-1.amazonaws.com
+	Region    string
+	Bucket    string
+	Prefix    string // key prefix, e.g. "backups/"
+	AccessKey string
+	SecretKey string
+}
+
+// Enabled reports whether enough is set to upload
+func (c S3Config) Enabled() bool {
+	return c.Endpoint != "" && c.Bucket != "" && c.AccessKey != "" && c.SecretKey != ""
+}
+
+// S3Uploader PUTs snapshots to a bucket with AWS Signature Version 4. Snapshots are
+// small enough for a single PUT, which keeps this free of an SDK dependency.
+type S3Uploader struct {
+	cfg    S3Config
+	client *http.Client
+}
+
+func NewS3Uploader(cfg S3Config) *S3Uploader {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &S3Uploader{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// Upload copies a local file to {prefix}{key} in the bucket
+func (u *S3Uploader) Upload(ctx context.Context, path, key string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Path-style addressing works with every S3-compatible provider
+	objectURL := strings.TrimRight(u.cfg.Endpoint, "/") + "/" + u.cfg.Bucket + "/" + u.cfg.Prefix + key
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/vnd.sqlite3")
+
+	u.sign(req, time.Now())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 upload failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds SigV4 headers. The body is sent unsigned so it can stream from disk.
+func (u *S3Uploader) sign(req *http.Request, now time.Time) {
+	const payloadHash = "UNSIGNED-PAYLOAD"
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + u.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+	signature := hex.EncodeToString(hmacSHA256(signingKey(u.cfg.SecretKey, date, u.cfg.Region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalPath URI-encodes each path segment the way SigV4 expects for S3
+func canonicalPath(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,250 @@
+// Package badges works out which badges a product shows. Badges are configured in
+// admin; each one either goes on the products it's assigned to or follows a rule such
+// as "low stock" or "best seller", and can be limited to a date range.
+package badges
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Badge rules
+const (
+	RuleManual     = "manual"
+	RuleNew        = "new"
+	RuleFeatured   = "featured"
+	RulePremium    = "premium"
+	RuleLowStock   = "low_stock"
+	RuleBestSeller = "best_seller"
+)
+
+// RuleOption describes a rule for the admin form
+type RuleOption struct {
+	Value string
+	Label string
+	Help  string
+}
+
+var Rules = []RuleOption{
+	{RuleManual, "Assigned products", "Shown on the products you add below"},
+	{RuleNew, "New products", "Products marked New"},
+	{RuleFeatured, "Featured products", "Products marked Featured"},
+	{RulePremium, "Premium products", "Products marked Premium"},
+	{RuleLowStock, "Low stock", "In stock with at most Threshold units left"},
+	{RuleBestSeller, "Best sellers", "The top Threshold products by units sold in the last 30 days"},
+}
+
+// ValidRule reports whether rule is one of the badge rules
+func ValidRule(rule string) bool {
+	for _, r := range Rules {
+		if r.Value == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Color is a badge colour. Name is stored; Hex is used where Tailwind classes can't
+// be, such as OG images.
+type Color struct {
+	Name string
+	Hex  string
+}
+
+var Colors = []Color{
+	{"emerald", "#059669"},
+	{"blue", "#2563eb"},
+	{"purple", "#9333ea"},
+	{"amber", "#d97706"},
+	{"orange", "#ea580c"},
+	{"red", "#dc2626"},
+	{"pink", "#db2777"},
+	{"slate", "#475569"},
+}
+
+// DefaultColor is used for unknown colour names
+const DefaultColor = "emerald"
+
+// ColorHex returns the hex value for a colour name
+func ColorHex(name string) string {
+	for _, c := range Colors {
+		if c.Name == name {
+			return c.Hex
+		}
+	}
+	return ColorHex(DefaultColor)
+}
+
+// ValidColor reports whether name is one of the badge colours
+func ValidColor(name string) bool {
+	for _, c := range Colors {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Icons are the emoji offered in the admin form
+var Icons = []string{"", "✨", "⭐", "👑", "🔥", "⏳", "🏷️", "🎁", "🦖", "🎃", "🎄"}
+
+// Badge is a badge as shown on a product
+type Badge struct {
+	ID    string
+	Label string
+	Color string
+	Icon  string
+	// UpdatedAt is when the badge was last edited, so saved images of it can be redrawn
+	UpdatedAt time.Time
+}
+
+// Facts are what rules are checked against for one product
+type Facts struct {
+	IsNew      bool
+	IsFeatured bool
+	IsPremium  bool
+	Stock      int64
+	// SalesRank is the product's place among recent best sellers, 0 if unranked
+	SalesRank int64
+	Assigned  map[string]bool
+}
+
+// Live reports whether a badge is switched on and inside its date range at now
+func Live(def db.ProductBadge, now time.Time) bool {
+	if !def.IsActive {
+		return false
+	}
+	if def.StartsAt.Valid && now.Before(def.StartsAt.Time) {
+		return false
+	}
+	if def.EndsAt.Valid && !now.Before(def.EndsAt.Time) {
+		return false
+	}
+	return true
+}
+
+// Applies reports whether a badge's rule matches a product
+func Applies(def db.ProductBadge, facts Facts) bool {
+	switch def.Rule {
+	case RuleManual:
+		return facts.Assigned[def.ID]
+	case RuleNew:
+		return facts.IsNew
+	case RuleFeatured:
+		return facts.IsFeatured
+	case RulePremium:
+		return facts.IsPremium
+	case RuleLowStock:
+		return facts.Stock > 0 && facts.Stock <= def.Threshold
+	case RuleBestSeller:
+		return facts.SalesRank > 0 && facts.SalesRank <= def.Threshold
+	}
+	return false
+}
+
+// ForProduct returns the badges that apply, in the order of defs (highest priority first)
+func ForProduct(defs []db.ProductBadge, facts Facts) []Badge {
+	var result []Badge
+	for _, def := range defs {
+		if Applies(def, facts) {
+			result = append(result, Badge{
+				ID:        def.ID,
+				Label:     def.Label,
+				Color:     def.Color,
+				Icon:      def.Icon,
+				UpdatedAt: def.UpdatedAt.Time,
+			})
+		}
+	}
+	return result
+}
+
+// Load returns the badges for each product, keyed by product ID. Only the queries the
+// live badges need are run, so with no badges switched on this is a single query.
+func Load(ctx context.Context, queries *db.Queries, products []db.Product) (map[string][]Badge, error) {
+	result := make(map[string][]Badge, len(products))
+	if len(products) == 0 {
+		return result, nil
+	}
+
+	all, err := queries.ListActiveProductBadges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list badges: %w", err)
+	}
+	now := time.Now()
+	var defs []db.ProductBadge
+	needs := make(map[string]int64)
+	for _, def := range all {
+		if Live(def, now) {
+			defs = append(defs, def)
+			// Remember the largest threshold each rule needs
+			if def.Threshold >= needs[def.Rule] {
+				needs[def.Rule] = def.Threshold
+			}
+		}
+	}
+	if len(defs) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	assigned := make(map[string]map[string]bool)
+	if _, ok := needs[RuleManual]; ok {
+		rows, err := queries.ListProductBadgeAssignments(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list badge assignments: %w", err)
+		}
+		for _, row := range rows {
+			if assigned[row.ProductID] == nil {
+				assigned[row.ProductID] = make(map[string]bool)
+			}
+			assigned[row.ProductID][row.BadgeID] = true
+		}
+	}
+
+	stock := make(map[string]int64)
+	if _, ok := needs[RuleLowStock]; ok {
+		rows, err := queries.ListBadgeStockLevels(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stock levels: %w", err)
+		}
+		for _, row := range rows {
+			stock[row.ProductID] = row.AvailableStock
+		}
+	}
+
+	// Best seller badges can have different thresholds, so keep each product's rank
+	// down to the largest one
+	rank := make(map[string]int64)
+	if limit, ok := needs[RuleBestSeller]; ok && limit > 0 {
+		rows, err := queries.ListBestSellerProductIDs(ctx, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list best sellers: %w", err)
+		}
+		for i, row := range rows {
+			rank[row.ProductID] = int64(i + 1)
+		}
+	}
+
+	for _, product := range products {
+		badges := ForProduct(defs, Facts{
+			IsNew:      product.IsNew.Valid && product.IsNew.Bool,
+			IsFeatured: product.IsFeatured.Valid && product.IsFeatured.Bool,
+			IsPremium:  product.IsPremium.Valid && product.IsPremium.Bool,
+			Stock:      stock[product.ID],
+			SalesRank:  rank[product.ID],
+			Assigned:   assigned[product.ID],
+		})
+		if len(badges) > 0 {
+			result[product.ID] = badges
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package badges
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestLive(t *testing.T) {
+	now := time.Date(2026, 10, 31, 12, 0, 0, 0, time.UTC)
+	halloween := db.ProductBadge{
+		IsActive: true,
+		StartsAt: sql.NullTime{Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		EndsAt:   sql.NullTime{Time: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+
+	assert.True(t, Live(halloween, now))
+	assert.False(t, Live(halloween, time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC)), "not started")
+	assert.False(t, Live(halloween, halloween.EndsAt.Time), "ends at the end date")
+
+	halloween.IsActive = false
+	assert.False(t, Live(halloween, now))
+
+	assert.True(t, Live(db.ProductBadge{IsActive: true}, now), "no dates means always")
+}
+
+func TestApplies(t *testing.T) {
+	lowStock := db.ProductBadge{ID: "low", Rule: RuleLowStock, Threshold: 3}
+	assert.True(t, Applies(lowStock, Facts{Stock: 3}))
+	assert.False(t, Applies(lowStock, Facts{Stock: 4}))
+	assert.False(t, Applies(lowStock, Facts{Stock: 0}), "sold out isn't low stock")
+
+	bestSeller := db.ProductBadge{ID: "best", Rule: RuleBestSeller, Threshold: 5}
+	assert.True(t, Applies(bestSeller, Facts{SalesRank: 5}))
+	assert.False(t, Applies(bestSeller, Facts{SalesRank: 6}))
+	assert.False(t, Applies(bestSeller, Facts{}), "unranked")
+
+	manual := db.ProductBadge{ID: "holiday", Rule: RuleManual}
+	assert.True(t, Applies(manual, Facts{Assigned: map[string]bool{"holiday": true}}))
+	assert.False(t, Applies(manual, Facts{Assigned: map[string]bool{"other": true}}))
+	assert.False(t, Applies(manual, Facts{}))
+
+	assert.True(t, Applies(db.ProductBadge{Rule: RuleNew}, Facts{IsNew: true}))
+	assert.False(t, Applies(db.ProductBadge{Rule: RulePremium}, Facts{IsFeatured: true}))
+	assert.False(t, Applies(db.ProductBadge{Rule: "unknown"}, Facts{IsNew: true}))
+}
+
+func TestForProductKeepsPriorityOrder(t *testing.T) {
+	defs := []db.ProductBadge{
+		{ID: "best", Label: "Best Seller", Color: "red", Icon: "🔥", Rule: RuleBestSeller, Threshold: 10},
+		{ID: "new", Label: "New", Color: "emerald", Rule: RuleNew},
+		{ID: "premium", Label: "Premium", Color: "purple", Rule: RulePremium},
+	}
+
+	badges := ForProduct(defs, Facts{IsNew: true, SalesRank: 2})
+	assert.Equal(t, []Badge{
+		{ID: "best", Label: "Best Seller", Color: "red", Icon: "🔥"},
+		{ID: "new", Label: "New", Color: "emerald"},
+	}, badges)
+
+	assert.Empty(t, ForProduct(defs, Facts{}))
+}
+
+func TestColorHex(t *testing.T) {
+	assert.Equal(t, "#9333ea", ColorHex("purple"))
+	assert.Equal(t, ColorHex(DefaultColor), ColorHex("chartreuse"))
+	assert.True(t, ValidColor("red"))
+	assert.False(t, ValidColor("chartreuse"))
+	assert.True(t, ValidRule(RuleLowStock))
+	assert.False(t, ValidRule("clearance"))
+}
@@ -0,0 +1,194 @@
+// Package cache is a small in-memory read-through cache with TTLs, used to keep hot
+// catalog queries off the database. Keys are "group:id" so a whole group can be
+// invalidated at once and hit rates are reported per group.
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value     any
+	expiresAt time.Time
+}
+
+type counters struct {
+	hits   int64
+	misses int64
+}
+
+// Cache is safe for concurrent use. A nil *Cache never stores anything, so callers
+// can run without one (e.g. in tests) and every lookup goes to the loader.
+type Cache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]entry
+	stats   map[string]*counters
+	purged  time.Time
+	now     func() time.Time
+}
+
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]entry),
+		stats:   make(map[string]*counters),
+		now:     time.Now,
+	}
+}
+
+// TTL returns how long entries live
+func (c *Cache) TTL() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.ttl
+}
+
+// Get returns a live entry and counts a hit or miss for its group
+func (c *Cache) Get(key string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.group(key)
+	e, ok := c.entries[key]
+	if !ok || c.now().After(e.expiresAt) {
+		if ok {
+			delete(c.entries, key)
+		}
+		stats.misses++
+		return nil, false
+	}
+	stats.hits++
+	return e.value, true
+}
+
+// Set stores a value for the cache's TTL
+func (c *Cache) Set(key string, value any) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries[key] = entry{value: value, expiresAt: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+}
+
+// Invalidate drops every entry in a group, e.g. Invalidate("products")
+func (c *Cache) Invalidate(group string) {
+	if c == nil {
+		return
+	}
+	prefix := group + ":"
+	c.mu.Lock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// Purge drops every entry. Hit and miss counts are kept.
+func (c *Cache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries = make(map[string]entry)
+	c.purged = c.now()
+	c.mu.Unlock()
+}
+
+// group returns the counters for a key's group; callers hold the lock
+func (c *Cache) group(key string) *counters {
+	name, _, _ := strings.Cut(key, ":")
+	stats, ok := c.stats[name]
+	if !ok {
+		stats = &counters{}
+		c.stats[name] = stats
+	}
+	return stats
+}
+
+// GroupStats is one group's traffic since startup
+type GroupStats struct {
+	Name    string
+	Entries int
+	Hits    int64
+	Misses  int64
+}
+
+// HitRate is the share of lookups served from the cache, 0 to 1
+func (g GroupStats) HitRate() float64 {
+	if g.Hits+g.Misses == 0 {
+		return 0
+	}
+	return float64(g.Hits) / float64(g.Hits+g.Misses)
+}
+
+// Stats is a snapshot of the cache for the developer page
+type Stats struct {
+	TTL        time.Duration
+	Groups     []GroupStats
+	Total      GroupStats
+	LastPurged time.Time
+}
+
+// Stats returns per-group entry counts and hit rates, sorted by group name
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	groups := make(map[string]*GroupStats)
+	for name, counts := range c.stats {
+		groups[name] = &GroupStats{Name: name, Hits: counts.hits, Misses: counts.misses}
+	}
+	now := c.now()
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) {
+			continue
+		}
+		name, _, _ := strings.Cut(key, ":")
+		if groups[name] == nil {
+			groups[name] = &GroupStats{Name: name}
+		}
+		groups[name].Entries++
+	}
+
+	stats := Stats{TTL: c.ttl, LastPurged: c.purged, Total: GroupStats{Name: "Total"}}
+	for _, g := range groups {
+		stats.Groups = append(stats.Groups, *g)
+		stats.Total.Entries += g.Entries
+		stats.Total.Hits += g.Hits
+		stats.Total.Misses += g.Misses
+	}
+	sort.Slice(stats.Groups, func(i, j int) bool { return stats.Groups[i].Name < stats.Groups[j].Name })
+	return stats
+}
+
+// GetOrLoad returns the cached value for key, calling load and caching its result on
+// a miss. Errors are returned and not cached.
+func GetOrLoad[T any](c *Cache, key string, load func() (T, error)) (T, error) {
+	if v, ok := c.Get(key); ok {
+		if typed, ok := v.(T); ok {
+			return typed, nil
+		}
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.Set(key, value)
+	return value, nil
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c := New(time.Minute)
+	now := time.Date(2026, 1, 21, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return []string{"rex"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := GetOrLoad(c, "products:all", load)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"rex"}, v)
+	}
+	assert.Equal(t, 1, loads)
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Minute)
+	_, err := GetOrLoad(c, "products:all", load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+
+	stats := c.Stats()
+	require.Len(t, stats.Groups, 1)
+	assert.Equal(t, GroupStats{Name: "products", Entries: 1, Hits: 2, Misses: 2}, stats.Groups[0])
+	assert.Equal(t, 0.5, stats.Total.HitRate())
+}
+
+func TestGetOrLoadDoesNotCacheErrors(t *testing.T) {
+	c := New(time.Minute)
+	calls := 0
+	failing := func() (int, error) {
+		calls++
+		return 0, errors.New("database is locked")
+	}
+
+	_, err := GetOrLoad(c, "images:p1", failing)
+	assert.Error(t, err)
+	_, err = GetOrLoad(c, "images:p1", failing)
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestInvalidateAndPurge(t *testing.T) {
+	c := New(time.Minute)
+	c.Set("products:all", 1)
+	c.Set("images:p1", 2)
+	c.Set("images:card:p1", 3)
+	c.Set("imagesextra:x", 4)
+
+	c.Invalidate(GroupImages)
+	_, ok := c.Get("images:p1")
+	assert.False(t, ok)
+	_, ok = c.Get("images:card:p1")
+	assert.False(t, ok)
+	_, ok = c.Get("imagesextra:x")
+	assert.True(t, ok, "only the named group is dropped")
+	_, ok = c.Get("products:all")
+	assert.True(t, ok)
+
+	c.Purge()
+	_, ok = c.Get("products:all")
+	assert.False(t, ok)
+	assert.False(t, c.Stats().LastPurged.IsZero())
+}
+
+func TestNilCacheLoadsEveryTime(t *testing.T) {
+	var c *Cache
+	calls := 0
+	for i := 0; i < 2; i++ {
+		v, err := GetOrLoad(c, "categories:all", func() (string, error) {
+			calls++
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", v)
+	}
+	assert.Equal(t, 2, calls)
+	c.Purge()
+	assert.Equal(t, Stats{}, c.Stats())
+}
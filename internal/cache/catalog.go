@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Cache groups used by the catalog
+const (
+	GroupProducts   = "products"
+	GroupCategories = "categories"
+	GroupImages     = "images"
+)
+
+// Catalog wraps the catalog queries the shop runs on every page. Results are copied
+// on the way out so callers can sort or trim them without touching the cached slice.
+type Catalog struct {
+	queries *db.Queries
+	cache   *Cache
+}
+
+func NewCatalog(queries *db.Queries, cache *Cache) *Catalog {
+	return &Catalog{
+		queries: queries,
+		cache:   cache,
+	}
+}
+
+func (c *Catalog) ListProducts(ctx context.Context) ([]db.Product, error) {
+	products, err := GetOrLoad(c.cache, GroupProducts+":all", func() ([]db.Product, error) {
+		return c.queries.ListProducts(ctx)
+	})
+	return slices.Clone(products), err
+}
+
+func (c *Catalog) ListFeaturedProducts(ctx context.Context) ([]db.Product, error) {
+	products, err := GetOrLoad(c.cache, GroupProducts+":featured", func() ([]db.Product, error) {
+		return c.queries.ListFeaturedProducts(ctx)
+	})
+	return slices.Clone(products), err
+}
+
+func (c *Catalog) ListCategories(ctx context.Context) ([]db.Category, error) {
+	categories, err := GetOrLoad(c.cache, GroupCategories+":all", func() ([]db.Category, error) {
+		return c.queries.ListCategories(ctx)
+	})
+	return slices.Clone(categories), err
+}
+
+func (c *Catalog) GetProductImages(ctx context.Context, productID string) ([]db.ProductImage, error) {
+	images, err := GetOrLoad(c.cache, GroupImages+":"+productID, func() ([]db.ProductImage, error) {
+		return c.queries.GetProductImages(ctx, productID)
+	})
+	return slices.Clone(images), err
+}
+
+// ProductImageURLs returns the card image URL for each product, keyed by product ID.
+// Cached URLs are reused and the rest are loaded together in one query. If that query
+// fails the products are simply left without an image.
+func (c *Catalog) ProductImageURLs(ctx context.Context, products []db.Product) map[string]string {
+	urls := make(map[string]string, len(products))
+	var missing []db.Product
+	for _, product := range products {
+		if url, ok := c.cache.Get(productImageKey(product.ID)); ok {
+			if s, ok := url.(string); ok {
+				if s != "" {
+					urls[product.ID] = s
+				}
+				continue
+			}
+		}
+		missing = append(missing, product)
+	}
+	if len(missing) == 0 {
+		return urls
+	}
+
+	loaded, err := utils.ProductImageURLs(ctx, c.queries, missing)
+	if err != nil {
+		slog.Error("failed to load product card images", "count", len(missing), "error", err)
+		return urls
+	}
+	for _, product := range missing {
+		url := loaded[product.ID]
+		c.cache.Set(productImageKey(product.ID), url)
+		if url != "" {
+			urls[product.ID] = url
+		}
+	}
+	return urls
+}
+
+// ProductImageURL returns the card image URL for a single product
+func (c *Catalog) ProductImageURL(ctx context.Context, product db.Product) string {
+	return c.ProductImageURLs(ctx, []db.Product{product})[product.ID]
+}
+
+func productImageKey(productID string) string {
+	return GroupImages + ":card:" + productID
+}
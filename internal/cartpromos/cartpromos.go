@@ -0,0 +1,285 @@
+// Package cartpromos works out the automatic promotions on a cart. Promotions are set
+// up in admin and need no code: free shipping once the subtotal reaches an amount, a
+// percentage off once it reaches an amount, or buy N get one free within a category.
+// Discounts are folded into the line prices sent to Stripe, so checkout shows what
+// each line really costs.
+package cartpromos
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Promotion kinds
+const (
+	KindFreeShipping = "free_shipping"
+	KindPercentOff   = "percent_off"
+	KindBuyNGetOne   = "buy_n_get_one"
+)
+
+// KindOption describes a kind of promotion for the admin form
+type KindOption struct {
+	Value string
+	Label string
+	Help  string
+}
+
+var Kinds = []KindOption{
+	{KindFreeShipping, "Free shipping", "Shipping is free once the subtotal reaches the minimum"},
+	{KindPercentOff, "Percent off", "Every item is discounted once the subtotal reaches the minimum"},
+	{KindBuyNGetOne, "Buy N, get one free", "For every N items bought from the category, the cheapest one after them is free"},
+}
+
+// ValidKind reports whether kind is one of the promotion kinds
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k.Value == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// KindLabel returns the admin label for a kind
+func KindLabel(kind string) string {
+	for _, k := range Kinds {
+		if k.Value == kind {
+			return k.Label
+		}
+	}
+	return kind
+}
+
+// Live reports whether a promotion is switched on and inside its date range at now
+func Live(promo db.CartPromotion, now time.Time) bool {
+	if !promo.IsActive {
+		return false
+	}
+	if promo.StartsAt.Valid && now.Before(promo.StartsAt.Time) {
+		return false
+	}
+	if promo.EndsAt.Valid && !now.Before(promo.EndsAt.Time) {
+		return false
+	}
+	return true
+}
+
+// Line is the part of a cart line promotions look at
+type Line struct {
+	ItemID         string
+	CategoryID     string
+	Quantity       int64
+	UnitPriceCents int64 // after quantity breaks and bundle pricing
+	Bundle         bool
+}
+
+// LinePrice is how the applied promotion changes one line: each paid unit costs
+// UnitPriceCents, and FreeQuantity of the units cost nothing
+type LinePrice struct {
+	UnitPriceCents int64
+	FreeQuantity   int64
+}
+
+// Progress is a discount promotion as the cart shows it: applied, or how far away
+type Progress struct {
+	ID            string
+	Name          string
+	Kind          string
+	Applied       bool
+	DiscountCents int64
+	Message       string
+}
+
+// Result is what the live promotions do to a cart
+type Result struct {
+	// Applied is the discount promotion in effect, nil when none is
+	Applied       *db.CartPromotion
+	DiscountCents int64
+	// Lines holds the lines the applied promotion changes, keyed by cart item ID
+	Lines map[string]LinePrice
+	// FreeShippingThresholdCents is the lowest minimum of the live free-shipping
+	// promotions, zero when there are none
+	FreeShippingThresholdCents int64
+	// Progress lists the applied promotion first, then the ones the cart hasn't reached
+	Progress []Progress
+}
+
+// FreeShippingThreshold combines the store's free-shipping threshold with the
+// promotions', returning the lower of the two that are set
+func (r Result) FreeShippingThreshold(configured int64) int64 {
+	switch {
+	case configured <= 0:
+		return r.FreeShippingThresholdCents
+	case r.FreeShippingThresholdCents <= 0:
+		return configured
+	}
+	return min(configured, r.FreeShippingThresholdCents)
+}
+
+// WithoutDiscount drops the applied discount promotion, for a promotion code used in
+// its place. Free shipping stays, and the dropped promotion is no longer shown.
+func (r Result) WithoutDiscount() Result {
+	if r.Applied == nil {
+		return r
+	}
+	progress := make([]Progress, 0, len(r.Progress))
+	for _, p := range r.Progress {
+		if !p.Applied {
+			progress = append(progress, p)
+		}
+	}
+	return Result{
+		Lines:                      map[string]LinePrice{},
+		FreeShippingThresholdCents: r.FreeShippingThresholdCents,
+		Progress:                   progress,
+	}
+}
+
+// Evaluate applies the live promotions to the cart lines. Minimums are measured against
+// the subtotal after quantity breaks. When more than one discount qualifies, only the
+// one saving the most applies; free shipping is separate and always combines.
+func Evaluate(promos []db.CartPromotion, lines []Line, now time.Time) Result {
+	result := Result{Lines: map[string]LinePrice{}}
+
+	var subtotal int64
+	for _, line := range lines {
+		subtotal += line.UnitPriceCents * line.Quantity
+	}
+
+	var waiting []Progress
+	for _, promo := range promos {
+		if !Live(promo, now) {
+			continue
+		}
+
+		var discount int64
+		var changed map[string]LinePrice
+		switch promo.Kind {
+		case KindFreeShipping:
+			if promo.MinSubtotalCents > 0 && (result.FreeShippingThresholdCents == 0 || promo.MinSubtotalCents < result.FreeShippingThresholdCents) {
+				result.FreeShippingThresholdCents = promo.MinSubtotalCents
+			}
+			continue
+		case KindPercentOff:
+			if subtotal < promo.MinSubtotalCents {
+				waiting = append(waiting, waitingProgress(promo,
+					fmt.Sprintf("Spend %s more for %s", formatPrice(promo.MinSubtotalCents-subtotal), promo.Name)))
+				continue
+			}
+			discount, changed = percentOff(promo.PercentOff, lines)
+		case KindBuyNGetOne:
+			if promo.BuyQuantity < 1 || !promo.CategoryID.Valid {
+				continue
+			}
+			if need := promo.BuyQuantity + 1 - categoryQuantity(promo.CategoryID.String, lines); need > 0 {
+				waiting = append(waiting, waitingProgress(promo,
+					fmt.Sprintf("Add %d more %s for %s", need, itemNoun(need), promo.Name)))
+				continue
+			}
+			discount, changed = buyNGetOne(promo.CategoryID.String, promo.BuyQuantity, lines)
+		default:
+			continue
+		}
+
+		if discount > result.DiscountCents {
+			applied := promo
+			result.Applied = &applied
+			result.DiscountCents = discount
+			result.Lines = changed
+		}
+	}
+
+	if result.Applied != nil {
+		result.Progress = append(result.Progress, Progress{
+			ID:            result.Applied.ID,
+			Name:          result.Applied.Name,
+			Kind:          result.Applied.Kind,
+			Applied:       true,
+			DiscountCents: result.DiscountCents,
+			Message:       fmt.Sprintf("%s: you save %s", result.Applied.Name, formatPrice(result.DiscountCents)),
+		})
+	}
+	result.Progress = append(result.Progress, waiting...)
+	return result
+}
+
+// percentOff takes percent off every line's unit price, rounded to the nearest cent
+func percentOff(percent int64, lines []Line) (int64, map[string]LinePrice) {
+	changed := make(map[string]LinePrice)
+	var discount int64
+	for _, line := range lines {
+		off := (line.UnitPriceCents*percent + 50) / 100
+		if off <= 0 {
+			continue
+		}
+		changed[line.ItemID] = LinePrice{UnitPriceCents: line.UnitPriceCents - off}
+		discount += off * line.Quantity
+	}
+	return discount, changed
+}
+
+// categoryQuantity counts the units in the cart from a category. Bundle lines are
+// already discounted and don't count.
+func categoryQuantity(categoryID string, lines []Line) int64 {
+	var quantity int64
+	for _, line := range lines {
+		if !line.Bundle && line.CategoryID == categoryID {
+			quantity += line.Quantity
+		}
+	}
+	return quantity
+}
+
+// buyNGetOne makes one unit free for every buy+1 units from the category, choosing the
+// cheapest units so the shop never gives away more than the offer promises
+func buyNGetOne(categoryID string, buy int64, lines []Line) (int64, map[string]LinePrice) {
+	var eligible []Line
+	for _, line := range lines {
+		if !line.Bundle && line.CategoryID == categoryID {
+			eligible = append(eligible, line)
+		}
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		if eligible[i].UnitPriceCents != eligible[j].UnitPriceCents {
+			return eligible[i].UnitPriceCents < eligible[j].UnitPriceCents
+		}
+		return eligible[i].ItemID < eligible[j].ItemID
+	})
+
+	free := categoryQuantity(categoryID, lines) / (buy + 1)
+	changed := make(map[string]LinePrice)
+	var discount int64
+	for _, line := range eligible {
+		if free == 0 {
+			break
+		}
+		n := min(free, line.Quantity)
+		changed[line.ItemID] = LinePrice{UnitPriceCents: line.UnitPriceCents, FreeQuantity: n}
+		discount += n * line.UnitPriceCents
+		free -= n
+	}
+	return discount, changed
+}
+
+func waitingProgress(promo db.CartPromotion, message string) Progress {
+	return Progress{
+		ID:      promo.ID,
+		Name:    promo.Name,
+		Kind:    promo.Kind,
+		Message: message,
+	}
+}
+
+func itemNoun(n int64) string {
+	if n == 1 {
+		return "item"
+	}
+	return "items"
+}
+
+func formatPrice(cents int64) string {
+	return fmt.Sprintf("$%.2f", float64(cents)/100)
+}
@@ -0,0 +1,136 @@
+package cartpromos
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+var now = time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC)
+
+func TestLive(t *testing.T) {
+	blackFriday := db.CartPromotion{
+		IsActive: true,
+		StartsAt: sql.NullTime{Time: time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC), Valid: true},
+		EndsAt:   sql.NullTime{Time: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+
+	assert.True(t, Live(blackFriday, now))
+	assert.False(t, Live(blackFriday, now.AddDate(0, 0, -1)), "not started")
+	assert.False(t, Live(blackFriday, blackFriday.EndsAt.Time), "ends at the end date")
+
+	blackFriday.IsActive = false
+	assert.False(t, Live(blackFriday, now))
+}
+
+func TestEvaluatePercentOff(t *testing.T) {
+	promos := []db.CartPromotion{{ID: "ten", Name: "10% off orders over $50", Kind: KindPercentOff, MinSubtotalCents: 5000, PercentOff: 10, IsActive: true}}
+
+	under := Evaluate(promos, []Line{{ItemID: "a", Quantity: 1, UnitPriceCents: 3750}}, now)
+	assert.Nil(t, under.Applied)
+	assert.Zero(t, under.DiscountCents)
+	require.Len(t, under.Progress, 1)
+	assert.Equal(t, "Spend $12.50 more for 10% off orders over $50", under.Progress[0].Message)
+
+	over := Evaluate(promos, []Line{
+		{ItemID: "a", Quantity: 2, UnitPriceCents: 2499},
+		{ItemID: "b", Quantity: 1, UnitPriceCents: 1000},
+	}, now)
+	require.NotNil(t, over.Applied)
+	assert.Equal(t, "ten", over.Applied.ID)
+	assert.Equal(t, LinePrice{UnitPriceCents: 2249}, over.Lines["a"], "rounded to the nearest cent")
+	assert.Equal(t, LinePrice{UnitPriceCents: 900}, over.Lines["b"])
+	assert.Equal(t, int64(2*250+100), over.DiscountCents)
+	require.Len(t, over.Progress, 1)
+	assert.True(t, over.Progress[0].Applied)
+	assert.Equal(t, "10% off orders over $50: you save $6.00", over.Progress[0].Message)
+}
+
+func TestEvaluateBuyNGetOne(t *testing.T) {
+	promos := []db.CartPromotion{{
+		ID:          "dragons",
+		Name:        "Buy 2 dragons, get 1 free",
+		Kind:        KindBuyNGetOne,
+		CategoryID:  sql.NullString{String: "cat-dragons", Valid: true},
+		BuyQuantity: 2,
+		IsActive:    true,
+	}}
+
+	short := Evaluate(promos, []Line{{ItemID: "a", CategoryID: "cat-dragons", Quantity: 2, UnitPriceCents: 2000}}, now)
+	assert.Nil(t, short.Applied)
+	require.Len(t, short.Progress, 1)
+	assert.Equal(t, "Add 1 more item for Buy 2 dragons, get 1 free", short.Progress[0].Message)
+
+	result := Evaluate(promos, []Line{
+		{ItemID: "big", CategoryID: "cat-dragons", Quantity: 4, UnitPriceCents: 3000},
+		{ItemID: "small", CategoryID: "cat-dragons", Quantity: 2, UnitPriceCents: 1500},
+		{ItemID: "set", CategoryID: "cat-dragons", Quantity: 3, UnitPriceCents: 500, Bundle: true},
+		{ItemID: "other", CategoryID: "cat-fidgets", Quantity: 5, UnitPriceCents: 100},
+	}, now)
+	require.NotNil(t, result.Applied)
+	assert.Equal(t, map[string]LinePrice{
+		"small": {UnitPriceCents: 1500, FreeQuantity: 2},
+	}, result.Lines, "six dragons earn two free, taken from the cheapest; bundles and other categories don't count")
+	assert.Equal(t, int64(3000), result.DiscountCents)
+}
+
+func TestEvaluateBiggestDiscountWins(t *testing.T) {
+	promos := []db.CartPromotion{
+		{ID: "ten", Name: "10% off", Kind: KindPercentOff, PercentOff: 10, IsActive: true},
+		{ID: "bogo", Name: "Buy 1 get 1", Kind: KindBuyNGetOne, CategoryID: sql.NullString{String: "c", Valid: true}, BuyQuantity: 1, IsActive: true},
+		{ID: "twenty", Name: "20% off over $500", Kind: KindPercentOff, MinSubtotalCents: 50000, PercentOff: 20, IsActive: true},
+		{ID: "off", Name: "50% off", Kind: KindPercentOff, PercentOff: 50},
+	}
+	lines := []Line{{ItemID: "a", CategoryID: "c", Quantity: 2, UnitPriceCents: 1000}}
+
+	result := Evaluate(promos, lines, now)
+	require.NotNil(t, result.Applied)
+	assert.Equal(t, "bogo", result.Applied.ID)
+	assert.Equal(t, int64(1000), result.DiscountCents)
+	assert.Equal(t, map[string]LinePrice{"a": {UnitPriceCents: 1000, FreeQuantity: 1}}, result.Lines)
+
+	require.Len(t, result.Progress, 2, "the applied promotion, then the one not reached; the inactive one is left out")
+	assert.Equal(t, "bogo", result.Progress[0].ID)
+	assert.Equal(t, "twenty", result.Progress[1].ID)
+	assert.False(t, result.Progress[1].Applied)
+}
+
+func TestFreeShippingThreshold(t *testing.T) {
+	promos := []db.CartPromotion{
+		{Kind: KindFreeShipping, MinSubtotalCents: 7500, IsActive: true},
+		{Kind: KindFreeShipping, MinSubtotalCents: 4000, IsActive: true},
+		{Kind: KindFreeShipping, MinSubtotalCents: 1000},
+	}
+	result := Evaluate(promos, nil, now)
+	assert.Equal(t, int64(4000), result.FreeShippingThresholdCents)
+	assert.Empty(t, result.Progress, "free shipping shows in the cart's own progress bar")
+
+	assert.Equal(t, int64(4000), result.FreeShippingThreshold(0))
+	assert.Equal(t, int64(4000), result.FreeShippingThreshold(5000))
+	assert.Equal(t, int64(3000), result.FreeShippingThreshold(3000))
+	assert.Equal(t, int64(5000), Result{}.FreeShippingThreshold(5000))
+	assert.Zero(t, Result{}.FreeShippingThreshold(0))
+}
+
+func TestWithoutDiscount(t *testing.T) {
+	promos := []db.CartPromotion{
+		{ID: "ten", Name: "10% off", Kind: KindPercentOff, PercentOff: 10, IsActive: true},
+		{ID: "ship", Kind: KindFreeShipping, MinSubtotalCents: 4000, IsActive: true},
+		{ID: "bogo", Name: "Buy 1 get 1", Kind: KindBuyNGetOne, CategoryID: sql.NullString{String: "c", Valid: true}, BuyQuantity: 1, IsActive: true},
+	}
+	result := Evaluate(promos, []Line{{ItemID: "a", Quantity: 1, UnitPriceCents: 1000}}, now)
+	require.NotNil(t, result.Applied)
+
+	without := result.WithoutDiscount()
+	assert.Nil(t, without.Applied)
+	assert.Zero(t, without.DiscountCents)
+	assert.Empty(t, without.Lines)
+	assert.Equal(t, int64(4000), without.FreeShippingThresholdCents)
+	require.Len(t, without.Progress, 1, "the promotion not reached is still shown")
+	assert.Equal(t, "bogo", without.Progress[0].ID)
+}
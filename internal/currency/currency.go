@@ -0,0 +1,116 @@
+// Package currency shows the store's USD prices in a shopper's own currency. The
+// shopper picks a currency once and it sticks for their browser; prices are converted
+// with the latest rates fetched into the exchange_rates table. Converted prices are
+// only for display: checkout charges USD unless Stripe's Adaptive Pricing is on.
+package currency
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Base is the currency prices are stored and charged in
+const Base = "USD"
+
+// CookieName holds the shopper's chosen currency code
+const CookieName = "currency"
+
+// Currency is one currency the storefront can show prices in. Every supported
+// currency has two decimal places.
+type Currency struct {
+	Code   string
+	Symbol string
+	Name   string
+}
+
+// Supported lists the currencies offered in the selector, base first
+var Supported = []Currency{
+	{Code: "USD", Symbol: "$", Name: "US Dollar"},
+	{Code: "CAD", Symbol: "CA$", Name: "Canadian Dollar"},
+	{Code: "EUR", Symbol: "€", Name: "Euro"},
+	{Code: "GBP", Symbol: "£", Name: "British Pound"},
+	{Code: "AUD", Symbol: "A$", Name: "Australian Dollar"},
+}
+
+// Lookup finds a supported currency by code, ignoring case
+func Lookup(code string) (Currency, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, c := range Supported {
+		if c.Code == code {
+			return c, true
+		}
+	}
+	return Currency{}, false
+}
+
+// Display is the currency a shopper sees prices in, with its rate from USD
+type Display struct {
+	Currency
+	Rate float64
+}
+
+// USD shows prices as they're stored
+func USD() Display {
+	c, _ := Lookup(Base)
+	return Display{Currency: c, Rate: 1}
+}
+
+// IsBase reports whether prices are shown unconverted
+func (d Display) IsBase() bool {
+	return d.Code == Base
+}
+
+// Convert turns USD cents into cents of the display currency, to the nearest cent
+func (d Display) Convert(usdCents int64) int64 {
+	if d.IsBase() {
+		return usdCents
+	}
+	return int64(math.Round(float64(usdCents) * d.Rate))
+}
+
+// Format shows USD cents in the display currency, e.g. 1599 -> "CA$21.83"
+func (d Display) Format(usdCents int64) string {
+	cents := d.Convert(usdCents)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%s%d.%02d", sign, d.Symbol, cents/100, cents%100)
+}
+
+type contextKey struct{}
+
+// WithDisplay returns a context whose prices are shown in d
+func WithDisplay(ctx context.Context, d Display) context.Context {
+	return context.WithValue(ctx, contextKey{}, d)
+}
+
+// FromContext is the display currency for a request. Handlers pass
+// c.Request().Context() and templ components their ctx. It's USD outside Middleware.
+func FromContext(ctx context.Context) Display {
+	if d, ok := ctx.Value(contextKey{}).(Display); ok {
+		return d
+	}
+	return USD()
+}
+
+// Middleware shows the request's prices in the currency from the shopper's cookie,
+// falling back to USD when it's unset, unsupported or has no current rate
+func Middleware(store *Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cookie, err := c.Cookie(CookieName)
+			if err != nil || cookie.Value == "" {
+				return next(c)
+			}
+			ctx := WithDisplay(c.Request().Context(), store.Display(c.Request().Context(), cookie.Value))
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayFormat(t *testing.T) {
+	assert.Equal(t, "$15.99", USD().Format(1599))
+
+	cad, _ := Lookup("cad")
+	d := Display{Currency: cad, Rate: 1.365}
+	assert.Equal(t, int64(2183), d.Convert(1599))
+	assert.Equal(t, "CA$21.83", d.Format(1599))
+	assert.Equal(t, "-CA$1.37", d.Format(-100))
+	assert.False(t, d.IsBase())
+
+	_, ok := Lookup("JPY")
+	assert.False(t, ok)
+}
+
+func TestStoreDisplay(t *testing.T) {
+	now := time.Date(2026, 2, 18, 12, 0, 0, 0, time.UTC)
+	loads := 0
+	rates := []Rate{
+		{Currency: "EUR", Rate: 0.92, FetchedAt: now.Add(-time.Hour)},
+		{Currency: "GBP", Rate: 0.79, FetchedAt: now.Add(-MaxRateAge - time.Hour)},
+	}
+	var loadErr error
+	store := newStore(func(ctx context.Context) ([]Rate, error) {
+		loads++
+		return rates, loadErr
+	}, time.Minute)
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	assert.Equal(t, "EUR", store.Display(ctx, "eur").Code)
+	assert.Equal(t, 0.92, store.Display(ctx, "EUR").Rate)
+	assert.True(t, store.Display(ctx, "GBP").IsBase(), "stale rate")
+	assert.True(t, store.Display(ctx, "CAD").IsBase(), "no rate yet")
+	assert.True(t, store.Display(ctx, "XYZ").IsBase(), "unsupported")
+	assert.Equal(t, 1, loads)
+
+	// A failed reload keeps the last good rates
+	loadErr = errors.New("database is locked")
+	store.Invalidate()
+	assert.Equal(t, "EUR", store.Display(ctx, "EUR").Code)
+	assert.Equal(t, 2, loads)
+
+	var nilStore *Store
+	assert.True(t, nilStore.Display(ctx, "EUR").IsBase())
+}
+
+func TestFetchRates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"success","base_code":"USD","rates":{"USD":1,"CAD":1.36,"EUR":0.92,"JPY":150.1,"GBP":0}}`))
+	}))
+	defer srv.Close()
+
+	rates, err := FetchRates(context.Background(), srv.Client(), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"CAD": 1.36, "EUR": 0.92}, rates)
+
+	_, err = ParseRates(strings.NewReader(`{"result":"success","base_code":"EUR","rates":{"CAD":1.47}}`))
+	assert.Error(t, err, "wrong base")
+	_, err = ParseRates(strings.NewReader(`{"result":"error"}`))
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	store := newStore(func(ctx context.Context) ([]Rate, error) {
+		return []Rate{{Currency: "CAD", Rate: 1.36, FetchedAt: time.Now()}}, nil
+	}, time.Minute)
+
+	run := func(cookie string) Display {
+		req := httptest.NewRequest(http.MethodGet, "/shop", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CookieName, Value: cookie})
+		}
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		var got Display
+		err := Middleware(store)(func(c echo.Context) error {
+			got = FromContext(c.Request().Context())
+			return nil
+		})(c)
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t, "CAD", run("CAD").Code)
+	assert.True(t, run("").IsBase())
+	assert.True(t, run("EUR").IsBase(), "no rate")
+}
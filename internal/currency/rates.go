@@ -0,0 +1,172 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// DefaultRatesURL answers with the latest rates from USD and needs no API key
+const DefaultRatesURL = "https://open.er-api.com/v6/latest/USD"
+
+// DefaultTTL is how long rates are cached before being reloaded from the database.
+// The refresh job invalidates the cache itself; this bounds how long other processes
+// keep old rates.
+const DefaultTTL = 10 * time.Minute
+
+// MaxRateAge is how old a rate can get before prices go back to USD rather than
+// showing a conversion that's drifted too far
+const MaxRateAge = 7 * 24 * time.Hour
+
+// Rate is a currency's rate from USD and when it was fetched
+type Rate struct {
+	Currency  string
+	Rate      float64
+	FetchedAt time.Time
+}
+
+// Loader fetches the stored rates
+type Loader func(ctx context.Context) ([]Rate, error)
+
+// Store answers display currencies from a cached copy of the rates. A nil *Store
+// shows everything in USD.
+type Store struct {
+	load Loader
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	rates    map[string]Rate
+	loadedAt time.Time
+}
+
+// NewStore returns a store that loads rates from the database
+func NewStore(queries *db.Queries, ttl time.Duration) *Store {
+	return newStore(func(ctx context.Context) ([]Rate, error) {
+		rows, err := queries.ListExchangeRates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rates := make([]Rate, 0, len(rows))
+		for _, row := range rows {
+			rates = append(rates, Rate{Currency: row.Currency, Rate: row.Rate, FetchedAt: row.FetchedAt})
+		}
+		return rates, nil
+	}, ttl)
+}
+
+func newStore(load Loader, ttl time.Duration) *Store {
+	return &Store{
+		load: load,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Display is how prices are shown to a shopper who picked code
+func (s *Store) Display(ctx context.Context, code string) Display {
+	c, ok := Lookup(code)
+	if !ok || c.Code == Base || s == nil {
+		return USD()
+	}
+	rate, ok := s.rate(ctx, c.Code)
+	if !ok || s.now().Sub(rate.FetchedAt) > MaxRateAge {
+		return USD()
+	}
+	return Display{Currency: c, Rate: rate.Rate}
+}
+
+// Invalidate makes the next lookup reload the rates
+func (s *Store) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+func (s *Store) rate(ctx context.Context, code string) (Rate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rates == nil || s.now().Sub(s.loadedAt) >= s.ttl {
+		rates, err := s.load(ctx)
+		// Keep answering from the last good copy until the next reload when the
+		// database is unavailable
+		if err != nil {
+			slog.Error("failed to load exchange rates", "error", err)
+		} else {
+			s.rates = make(map[string]Rate, len(rates))
+			for _, rate := range rates {
+				s.rates[rate.Currency] = rate
+			}
+		}
+		s.loadedAt = s.now()
+	}
+
+	rate, ok := s.rates[code]
+	return rate, ok && rate.Rate > 0
+}
+
+// ratesResponse is the part of the rates API's answer we use
+type ratesResponse struct {
+	Result   string             `json:"result"`
+	BaseCode string             `json:"base_code"`
+	Rates    map[string]float64 `json:"rates"`
+}
+
+// FetchRates gets the latest rates from USD for the supported currencies from url,
+// which answers like open.er-api.com
+func FetchRates(ctx context.Context, client *http.Client, url string) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rates request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates API returned status %d", resp.StatusCode)
+	}
+	return ParseRates(resp.Body)
+}
+
+// ParseRates reads a rates API answer, keeping the supported currencies
+func ParseRates(r io.Reader) (map[string]float64, error) {
+	var body ratesResponse
+	if err := json.NewDecoder(r).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode rates: %w", err)
+	}
+	if body.Result != "" && body.Result != "success" {
+		return nil, fmt.Errorf("rates API answered %q", body.Result)
+	}
+	if body.BaseCode != "" && !strings.EqualFold(body.BaseCode, Base) {
+		return nil, fmt.Errorf("rates are from %s, not %s", body.BaseCode, Base)
+	}
+
+	rates := make(map[string]float64)
+	for _, c := range Supported {
+		if c.Code == Base {
+			continue
+		}
+		if rate, ok := body.Rates[c.Code]; ok && rate > 0 {
+			rates[c.Code] = rate
+		}
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("rates API returned none of the supported currencies")
+	}
+	return rates, nil
+}
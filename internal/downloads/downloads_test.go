@@ -0,0 +1,41 @@
+package downloads
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerURLRoundTrip(t *testing.T) {
+	signer := NewSigner("test-secret")
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	link, err := url.Parse(signer.URL("https://example.com/", "dl-1", expires))
+	require.NoError(t, err)
+	assert.Equal(t, "/downloads/dl-1", link.Path)
+
+	query := link.Query()
+	assert.NoError(t, signer.Verify("dl-1", query.Get("expires"), query.Get("sig"), now))
+	assert.ErrorIs(t, signer.Verify("dl-2", query.Get("expires"), query.Get("sig"), now), ErrInvalidSignature)
+	assert.ErrorIs(t, NewSigner("other").Verify("dl-1", query.Get("expires"), query.Get("sig"), now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify("dl-1", query.Get("expires"), query.Get("sig"), expires.Add(time.Second)), ErrLinkExpired)
+	assert.ErrorIs(t, signer.Verify("dl-1", "not-a-number", query.Get("sig"), now), ErrInvalidSignature)
+}
+
+func TestFilePath(t *testing.T) {
+	t.Setenv("DIGITAL_FILES_DIR", "/srv/digital")
+
+	path, err := FilePath("product-1/model.stl")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/srv/digital", "product-1", "model.stl"), path)
+
+	for _, bad := range []string{"", "../secrets.env", "/etc/passwd", "a/../../b"} {
+		_, err := FilePath(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -2,7 +2,13 @@ package email
 
 import (
 	"bytes"
+	"context"
 	"html/template"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
 )
 
 // BaseEmailData contains data for the base email wrapper
@@ -10,8 +16,20 @@ type BaseEmailData struct {
 	Content          template.HTML
 	Subject          string
 	UnsubscribeToken string // Optional - for marketing emails only
+
+	// Footer, from the site settings
+	SiteName     string
+	SiteURL      string
+	SiteHost     string // SiteURL without the scheme, for display
+	ContactEmail string
+	Address      string
+	Year         int
 }
 
+// siteSettings supplies the footer details. NewService sets it; until then the
+// footer uses the default settings.
+var siteSettings atomic.Pointer[settings.Store]
+
 // baseEmailTemplate is the reusable wrapper for all emails
 // Uses table-based layout with inline styles for email client compatibility
 const baseEmailTemplate = `
@@ -53,7 +71,7 @@ const baseEmailTemplate = `
                                         <table cellpadding="0" cellspacing="0" border="0">
                                             <tr>
                                                 <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 8px 16px; border-radius: 4px;">
-                                                    <a href="https://www.logans3dcreations.com" style="color: #ffffff; text-decoration: none; font-weight: 600; font-size: 14px; display: block; white-space: nowrap;">Visit Store →</a>
+                                                    <a href="{{.SiteURL}}" style="color: #ffffff; text-decoration: none; font-weight: 600; font-size: 14px; display: block; white-space: nowrap;">Visit Store →</a>
                                                 </td>
                                             </tr>
                                         </table>
@@ -73,25 +91,27 @@ const baseEmailTemplate = `
                     <!-- Footer -->
                     <tr>
                         <td bgcolor="#3D3D3D" style="background-color: #3D3D3D; color: #cccccc; padding: 30px 20px; text-align: center; font-size: 13px;">
-                            <strong style="color: #ffffff; font-size: 15px; display: block; margin-bottom: 15px;">Logan's 3D Creations</strong>
+                            <strong style="color: #ffffff; font-size: 15px; display: block; margin-bottom: 15px;">{{.SiteName}}</strong>
                             <div style="height: 1px; background-color: #555; margin: 15px 0;"></div>
                             <div style="margin-bottom: 10px;">
-                                <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a>
+                                <a href="mailto:{{.ContactEmail}}" style="color: #E85D5D; text-decoration: none;">{{.ContactEmail}}</a>
                                 <span style="color: #666; margin: 0 8px;">•</span>
-                                <a href="https://www.logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">www.logans3dcreations.com</a>
+                                <a href="{{.SiteURL}}" style="color: #E85D5D; text-decoration: none;">{{.SiteHost}}</a>
                             </div>
+                            {{if .Address}}
                             <div style="font-size: 11px; color: #999; margin-bottom: 15px;">
-                                25580 County Highway S, Cadott WI 54727
+                                {{.Address}}
                             </div>
+                            {{end}}
                             {{if .UnsubscribeToken}}
                             <div style="margin-top: 15px; padding-top: 15px; border-top: 1px solid #555; font-size: 11px;">
-                                <a href="https://www.logans3dcreations.com/unsubscribe/{{.UnsubscribeToken}}" style="color: #999; text-decoration: none;">
+                                <a href="{{.SiteURL}}/unsubscribe/{{.UnsubscribeToken}}" style="color: #999; text-decoration: none;">
                                     Unsubscribe from marketing emails
                                 </a>
                             </div>
                             {{end}}
                             <div style="margin-top: 20px; font-size: 11px; color: #999;">
-                                © 2025 Logan's 3D Creations. All rights reserved.
+                                © {{.Year}} {{.SiteName}}. All rights reserved.
                             </div>
                         </td>
                     </tr>
@@ -112,10 +132,17 @@ func WrapEmailContent(content string, subject string) (string, error) {
 func WrapEmailContentWithUnsubscribe(content string, subject string, unsubscribeToken string) (string, error) {
 	tmpl := template.Must(template.New("base").Parse(baseEmailTemplate))
 
+	site := siteSettings.Load().Values(context.Background())
 	data := BaseEmailData{
 		Content:          template.HTML(content),
 		Subject:          subject,
 		UnsubscribeToken: unsubscribeToken,
+		SiteName:         site.SiteName(),
+		SiteURL:          site.SiteURL(),
+		SiteHost:         strings.TrimPrefix(strings.TrimPrefix(site.SiteURL(), "https://"), "http://"),
+		ContactEmail:     site.ContactEmail(),
+		Address:          strings.Join(site.AddressLines(), ", "),
+		Year:             time.Now().Year(),
 	}
 
 	var result bytes.Buffer
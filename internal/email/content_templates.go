@@ -40,7 +40,8 @@ const customerOrderContentTemplate = `
                         {{end}}
                         <td style="vertical-align: top;">
                             {{.ProductName}}
-                            {{if .ShippingTime}}<br><span style="font-size: 12px; color: {{if .NeedsPrinting}}#F59E0B{{else}}#10B981{{end}};">{{.ShippingTime}}</span>{{end}}
+                            {{if .IsPreorder}}<br><span style="display: inline-block; margin-top: 4px; background-color: #8B5CF6; color: white; padding: 2px 8px; border-radius: 10px; font-size: 11px; font-weight: bold;">PRE-ORDER</span>{{end}}
+                            {{if .ShippingTime}}<br><span style="font-size: 12px; color: {{if .IsDigital}}#0EA5E9{{else if .IsPreorder}}#8B5CF6{{else if .NeedsPrinting}}#F59E0B{{else}}#10B981{{end}};">{{.ShippingTime}}</span>{{end}}
                         </td>
                     </tr>
                 </table>
@@ -67,6 +68,12 @@ const customerOrderContentTemplate = `
             <td style="padding: 8px 0;">Shipping:</td>
             <td style="padding: 8px 0; text-align: right;">{{FormatCents .ShippingCents}}</td>
         </tr>
+        {{if .CarbonOffsetCents}}
+        <tr>
+            <td style="padding: 8px 0;">Carbon offset:</td>
+            <td style="padding: 8px 0; text-align: right;">{{FormatCents .CarbonOffsetCents}}</td>
+        </tr>
+        {{end}}
         <tr>
             <td style="padding: 15px 0 0 0; margin-top: 10px; border-top: 2px solid #E85D5D; font-size: 18px; font-weight: bold; color: #E85D5D; padding-top: 15px;">Total:</td>
             <td style="padding: 15px 0 0 0; margin-top: 10px; border-top: 2px solid #E85D5D; font-size: 18px; font-weight: bold; color: #E85D5D; text-align: right; padding-top: 15px;">{{FormatCents .TotalCents}}</td>
@@ -74,6 +81,31 @@ const customerOrderContentTemplate = `
     </table>
 </div>
 
+{{if .CarbonOffsetCents}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#ecfdf5" style="background-color: #ecfdf5; margin-top: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #10B981;">
+            <h3 style="margin-top: 0; margin-bottom: 8px; color: #047857; font-size: 16px;">🌱 Carbon Offset</h3>
+            <p style="margin: 0; color: #555; font-size: 14px;">Thank you for offsetting the carbon from making and shipping this order.</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
+{{if .Downloads}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f0f9ff" style="background-color: #f0f9ff; margin-top: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #0EA5E9;">
+            <h3 style="margin-top: 0; color: #555; font-size: 16px;">Your Downloads</h3>
+            {{range .Downloads}}
+            <p style="margin: 8px 0;"><a href="{{.URL}}" style="color: #0EA5E9; font-weight: 600; text-decoration: none;">{{.FileName}}</a> <span style="color: #777; font-size: 13px;">({{.ProductName}})</span></p>
+            {{end}}
+            <p style="margin: 12px 0 0 0; color: #777; font-size: 12px;">These links expire in 3 days. You can get fresh links any time from your order page.</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
 <table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-top: 25px;">
     <tr>
         <td style="padding: 20px;">
@@ -156,6 +188,29 @@ const adminOrderContentTemplate = `
     </tr>
 </table>
 
+{{if .IsGift}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#fce7f3" style="background-color: #fce7f3; margin: 20px 0;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #DB2777;">
+            <h3 style="margin-top: 0; color: #9D174D; font-size: 16px;">🎁 Gift Order{{if .GiftRecipient}} for {{.GiftRecipient}}{{end}}</h3>
+            {{if .GiftMessage}}<p style="margin: 0 0 10px 0; font-size: 15px; font-style: italic; white-space: pre-line;">"{{.GiftMessage}}"</p>{{end}}
+            <p style="margin: 0; font-size: 13px; color: #666;">Print the gift packing slip: it has the message and leaves out the buyer's contact details.</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
+{{if .CustomerNotes}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#fff8e1" style="background-color: #fff8e1; margin: 20px 0;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #F59E0B;">
+            <h3 style="margin-top: 0; color: #B45309; font-size: 16px;">Customer Instructions</h3>
+            <p style="margin: 0; font-size: 15px; font-weight: 600; white-space: pre-line;">{{.CustomerNotes}}</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
 <h2 style="color: #555; font-size: 20px; margin-top: 30px;">Order Items</h2>
 <table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
     <thead>
@@ -179,8 +234,10 @@ const adminOrderContentTemplate = `
                         {{end}}
                         <td style="vertical-align: top;">
                             <strong>{{.ProductName}}</strong>
+                            {{if .IsPreorder}}<br><span style="font-size: 12px; color: #8B5CF6; font-weight: bold;">⏳ PRE-ORDER - hold until release</span>{{end}}
+                            {{if .IsDigital}}<br><span style="font-size: 12px; color: #0EA5E9; font-weight: bold;">📥 DIGITAL - delivered by download</span>{{end}}
                             {{if .NeedsPrinting}}<br><span style="font-size: 12px; color: #F59E0B; font-weight: bold;">🖨️ NEEDS PRINTING</span>{{end}}
-                            {{if .ShippingTime}}<br><span style="font-size: 12px; color: {{if .NeedsPrinting}}#F59E0B{{else}}#10B981{{end}};">{{.ShippingTime}}</span>{{end}}
+                            {{if .ShippingTime}}<br><span style="font-size: 12px; color: {{if .IsDigital}}#0EA5E9{{else if .IsPreorder}}#8B5CF6{{else if .NeedsPrinting}}#F59E0B{{else}}#10B981{{end}};">{{.ShippingTime}}</span>{{end}}
                         </td>
                     </tr>
                 </table>
@@ -209,6 +266,12 @@ const adminOrderContentTemplate = `
                     <td style="padding: 8px 0; font-size: 16px;">Shipping:</td>
                     <td style="padding: 8px 0; text-align: right; font-size: 16px;">{{FormatCents .ShippingCents}}</td>
                 </tr>
+                {{if .CarbonOffsetCents}}
+                <tr>
+                    <td style="padding: 8px 0; font-size: 16px;">Carbon offset:</td>
+                    <td style="padding: 8px 0; text-align: right; font-size: 16px;">{{FormatCents .CarbonOffsetCents}}</td>
+                </tr>
+                {{end}}
                 <tr>
                     <td style="padding: 15px 0 0 0; border-top: 2px solid #FF9800; font-size: 20px; font-weight: bold; color: #FF9800;">TOTAL:</td>
                     <td style="padding: 15px 0 0 0; border-top: 2px solid #FF9800; font-size: 20px; font-weight: bold; color: #FF9800; text-align: right;">{{FormatCents .TotalCents}}</td>
@@ -888,3 +951,759 @@ const welcomeCouponContentTemplate = `
     </p>
 </div>
 `
+
+// productQuestionNotificationTemplate is the content section for new product question notification emails
+const productQuestionNotificationTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #3B82F6; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">NEW PRODUCT QUESTION</span>
+    <h1 style="color: #3B82F6; margin: 10px 0; font-size: 28px;">{{.ProductName}}</h1>
+    <p style="font-size: 16px; color: #666; margin: 10px 0;">A shopper is waiting for an answer</p>
+</div>
+
+<div style="background-color: #EFF6FF; padding: 20px; border-left: 4px solid #3B82F6; margin-bottom: 25px;">
+    <p style="margin: 8px 0;"><strong style="color: #1E40AF; min-width: 150px; display: inline-block;">Asked By:</strong> {{.AskerName}} (<a href="mailto:{{.AskerEmail}}" style="color: #3B82F6; text-decoration: none;">{{.AskerEmail}}</a>)</p>
+    <p style="margin: 8px 0;"><strong style="color: #1E40AF; min-width: 150px; display: inline-block;">Submitted:</strong> {{.SubmittedAt}}</p>
+</div>
+
+<div style="background-color: #F9FAFB; padding: 20px; border-radius: 8px; margin: 20px 0;">
+    <h3 style="margin-top: 0; color: #374151; font-size: 16px;">Question</h3>
+    <p style="margin: 5px 0; white-space: pre-wrap; line-height: 1.6;">{{.Question}}</p>
+</div>
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#3B82F6" style="background-color: #3B82F6; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/admin/questions" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">Answer Question</a>
+            </td>
+        </tr>
+    </table>
+</div>
+`
+
+// productQuestionAnsweredTemplate is the content section for the email sent when a shopper's question is answered
+const productQuestionAnsweredTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <h1 style="color: #E85D5D; margin: 0; font-size: 28px;">Your Question Was Answered!</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi {{.AskerName}}, thanks for asking about {{.ProductName}}.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #E85D5D;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">You asked:</strong></p>
+            <p style="margin: 5px 0; white-space: pre-wrap; line-height: 1.6;">{{.Question}}</p>
+        </td>
+    </tr>
+</table>
+
+<div style="background-color: #FEF2F2; padding: 20px; border-radius: 8px; margin: 20px 0;">
+    <h3 style="margin-top: 0; color: #991B1B; font-size: 16px;">Our Answer</h3>
+    <p style="margin: 5px 0; white-space: pre-wrap; line-height: 1.6; color: #555;">{{.Answer}}</p>
+</div>
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="{{.ProductURL}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Product</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>Have another question? Just reply to this email or contact us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// preorderShippedTemplate is the content section for the email sent when a customer's pre-order items ship
+const preorderShippedTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #8B5CF6; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">PRE-ORDER SHIPPED</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">Your Pre-Order Is On Its Way!</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi {{.CustomerName}}, thanks for waiting. The items you pre-ordered have shipped.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #8B5CF6;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Order Number:</strong> #{{.OrderID}}</p>
+            {{if .Carrier}}<p style="margin: 5px 0;"><strong style="color: #555;">Carrier:</strong> {{.Carrier}}</p>{{end}}
+            {{if .TrackingNumber}}<p style="margin: 5px 0;"><strong style="color: #555;">Tracking Number:</strong> {{if .TrackingURL}}<a href="{{.TrackingURL}}" style="color: #E85D5D; text-decoration: none;">{{.TrackingNumber}}</a>{{else}}{{.TrackingNumber}}{{end}}</p>{{end}}
+        </td>
+    </tr>
+</table>
+
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Shipped Pre-Order Items</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/account/orders/{{.OrderID}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Order Status</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>If you have any questions about your order, please contact us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// giftShippedTemplate is the content section for the email telling a gift's recipient
+// it's on the way. It names the sender and carries tracking, but never any amounts.
+const giftShippedTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #DB2777; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">A GIFT FOR YOU</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">A Gift Is On Its Way!</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .RecipientName}} {{.RecipientName}}{{end}}, {{.SenderName}} sent you something from Logan's 3D Creations.</p>
+</div>
+
+{{if .GiftMessage}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#fdf2f8" style="background-color: #fdf2f8; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #DB2777;">
+            <p style="margin: 0; font-size: 16px; font-style: italic; white-space: pre-line;">"{{.GiftMessage}}"</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #DB2777;">
+            {{if .Carrier}}<p style="margin: 5px 0;"><strong style="color: #555;">Carrier:</strong> {{.Carrier}}</p>{{end}}
+            {{if .TrackingNumber}}<p style="margin: 5px 0;"><strong style="color: #555;">Tracking Number:</strong> {{if .TrackingURL}}<a href="{{.TrackingURL}}" style="color: #E85D5D; text-decoration: none;">{{.TrackingNumber}}</a>{{else}}{{.TrackingNumber}}{{end}}</p>{{else}}<p style="margin: 5px 0;">It has shipped and should arrive soon.</p>{{end}}
+        </td>
+    </tr>
+</table>
+
+{{if .Items}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">What's Coming</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+`
+
+// giftCardTemplate is the content section for the email delivering a gift card. The
+// code is shown large so it's easy to copy into the cart.
+const giftCardTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #DB2777; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">GIFT CARD</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">{{FormatCents .AmountCents}} to Spend</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .RecipientName}} {{.RecipientName}}{{end}}, {{if .SenderName}}{{.SenderName}} sent you a gift card for Logan's 3D Creations.{{else}}here's your gift card for Logan's 3D Creations.{{end}}</p>
+</div>
+
+{{if .Message}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#fdf2f8" style="background-color: #fdf2f8; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #DB2777;">
+            <p style="margin: 0; font-size: 16px; font-style: italic; white-space: pre-line;">"{{.Message}}"</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 25px; text-align: center;">
+            <p style="margin: 0 0 8px 0; color: #555;">Your gift card code</p>
+            <p style="margin: 0; font-family: monospace; font-size: 24px; font-weight: 700; letter-spacing: 2px; color: #333;">{{.Code}}</p>
+            {{if .ExpiresAt}}<p style="margin: 12px 0 0 0; font-size: 14px; color: #666;">Use it by {{.ExpiresAt}}</p>{{end}}
+        </td>
+    </tr>
+</table>
+
+<p style="font-size: 16px; color: #555;">Enter the code in your cart at checkout. Whatever you don't spend stays on the card for next time.</p>
+
+{{if .ShopURL}}
+<div style="text-align: center; margin: 30px 0;">
+    <a href="{{.ShopURL}}" style="display: inline-block; background-color: #E85D5D; color: white; padding: 14px 28px; border-radius: 6px; text-decoration: none; font-weight: 600;">Start Shopping</a>
+</div>
+{{end}}
+`
+
+// eventRegistrationTemplate is the content section for event booking confirmations and
+// waitlist notices
+const eventRegistrationTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    {{if .Waitlisted}}
+    <span style="display: inline-block; background-color: #F59E0B; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">WAITLIST</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">You're on the Waitlist</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi {{.CustomerName}}, {{.EventTitle}} is full right now. We'll email you if a spot opens up.</p>
+    {{else}}
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">You're Booked!</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi {{.CustomerName}}, we've saved your spot at {{.EventTitle}}.</p>
+    {{end}}
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid {{if .Waitlisted}}#F59E0B{{else}}#10B981{{end}};">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Event:</strong> {{.EventTitle}}</p>
+            <p style="margin: 5px 0;"><strong style="color: #555;">When:</strong> {{.EventDate}}{{if .EventTime}}, {{.EventTime}}{{end}}</p>
+            {{if .Location}}<p style="margin: 5px 0;"><strong style="color: #555;">Where:</strong> {{.Location}}{{if .Address}}<br>{{.Address}}{{end}}</p>{{end}}
+            <p style="margin: 5px 0;"><strong style="color: #555;">Ticket:</strong> {{.TicketName}} &times; {{.Quantity}}</p>
+            {{if .AmountCents}}<p style="margin: 5px 0;"><strong style="color: #555;">Paid:</strong> {{FormatCents .AmountCents}}</p>{{end}}
+            <p style="margin: 5px 0;"><strong style="color: #555;">Booking Reference:</strong> {{.RegistrationID}}</p>
+        </td>
+    </tr>
+</table>
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="{{.EventURL}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Event</a>
+            </td>
+        </tr>
+    </table>
+    {{if not .Waitlisted}}<p style="margin-top: 15px;"><a href="{{.CalendarURL}}" style="color: #E85D5D; text-decoration: none;">Add to your calendar</a></p>{{end}}
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>Can't make it or have a question? Reply to us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// eventReminderTemplate is the content section for the reminder sent the day before an event
+const eventReminderTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">See You Soon!</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi {{.CustomerName}}, just a reminder that {{.EventTitle}} is coming up.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #10B981;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">When:</strong> {{.EventDate}}{{if .EventTime}}, {{.EventTime}}{{end}}</p>
+            {{if .Location}}<p style="margin: 5px 0;"><strong style="color: #555;">Where:</strong> {{.Location}}{{if .Address}}<br>{{.Address}}{{end}}</p>{{end}}
+            <p style="margin: 5px 0;"><strong style="color: #555;">Ticket:</strong> {{.TicketName}} &times; {{.Quantity}}</p>
+            <p style="margin: 5px 0;"><strong style="color: #555;">Booking Reference:</strong> {{.RegistrationID}}</p>
+        </td>
+    </tr>
+</table>
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="{{.EventURL}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">Event Details</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>Questions before the day? Reach us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// checkoutExpiredTemplate is the content section for the email sent when a Stripe
+// checkout expires before it's paid
+const checkoutExpiredTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <h1 style="color: #E85D5D; margin: 0; font-size: 28px;">Your Cart Is Saved</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Your checkout timed out, but nothing was lost</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #E85D5D; text-align: center;">
+            <p style="font-size: 16px; margin: 5px 0;">Hi {{.CustomerName}},</p>
+            <p style="font-size: 16px; margin: 15px 0;">You didn't get to finish paying, so you haven't been charged. The {{.ItemCount}} item{{if ne .ItemCount 1}}s{{end}} in your cart are still there whenever you're ready.</p>
+        </td>
+    </tr>
+</table>
+
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Your Cart</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    <thead>
+        <tr bgcolor="#E85D5D" style="background-color: #E85D5D;">
+            <th style="color: white; padding: 12px; text-align: left; font-weight: 600;">Product</th>
+            <th style="color: white; padding: 12px; text-align: center; font-weight: 600;">Quantity</th>
+            <th style="color: white; padding: 12px; text-align: right; font-weight: 600;">Price</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{range .Items}}
+        <tr style="border-bottom: 1px solid #ddd;">
+            <td style="padding: 12px; border-bottom: 1px solid #ddd;">
+                <table cellpadding="0" cellspacing="0" border="0">
+                    <tr>
+                        {{if .ProductImage}}
+                        <td style="padding-right: 12px; vertical-align: top;">
+                            <img src="https://www.logans3dcreations.com/public/images/products/{{.ProductImage}}" alt="{{.ProductName}}" width="60" height="60" style="display: block; width: 60px; height: 60px; border-radius: 4px; border: 1px solid #ddd;" />
+                        </td>
+                        {{end}}
+                        <td style="vertical-align: top;">{{.ProductName}}</td>
+                    </tr>
+                </table>
+            </td>
+            <td style="padding: 12px; text-align: center; border-bottom: 1px solid #ddd;">{{.Quantity}}</td>
+            <td style="padding: 12px; text-align: right; border-bottom: 1px solid #ddd;">{{FormatCents .UnitPrice}}</td>
+        </tr>
+        {{end}}
+    </tbody>
+</table>
+
+<div style="margin-top: 20px; padding-top: 20px; border-top: 2px solid #ddd;">
+    <table width="100%" cellpadding="0" cellspacing="0" border="0">
+        <tr>
+            <td style="padding: 15px 0 0 0; font-size: 20px; font-weight: bold; color: #E85D5D;">Cart Total:</td>
+            <td style="padding: 15px 0 0 0; text-align: right; font-size: 20px; font-weight: bold; color: #E85D5D;">{{FormatCents .CartValue}}</td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin: 40px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 16px 40px; border-radius: 5px;">
+                <a href="{{.CheckoutURL}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 18px; display: block;">Complete Your Order</a>
+            </td>
+        </tr>
+    </table>
+    <p style="margin-top: 15px; color: #777; font-size: 14px;">Prices and shipping are checked again when you check out.</p>
+</div>
+`
+
+// savedCartTemplate is the content section for the email a shopper asks for from the
+// cart page, with a link that brings their cart back
+const savedCartTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <h1 style="color: #E85D5D; margin: 0; font-size: 28px;">Here's Your Cart</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Saved for whenever you're ready</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #E85D5D; text-align: center;">
+            <p style="font-size: 16px; margin: 5px 0;">Hi {{if .CustomerName}}{{.CustomerName}}{{else}}there{{end}},</p>
+            <p style="font-size: 16px; margin: 15px 0;">You asked us to send you your cart. The link below puts the {{.ItemCount}} item{{if ne .ItemCount 1}}s{{end}} back in your cart on any device.</p>
+        </td>
+    </tr>
+</table>
+
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Your Cart</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    <thead>
+        <tr bgcolor="#E85D5D" style="background-color: #E85D5D;">
+            <th style="color: white; padding: 12px; text-align: left; font-weight: 600;">Product</th>
+            <th style="color: white; padding: 12px; text-align: center; font-weight: 600;">Quantity</th>
+            <th style="color: white; padding: 12px; text-align: right; font-weight: 600;">Price</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{range .Items}}
+        <tr style="border-bottom: 1px solid #ddd;">
+            <td style="padding: 12px; border-bottom: 1px solid #ddd;">
+                <table cellpadding="0" cellspacing="0" border="0">
+                    <tr>
+                        {{if .ProductImage}}
+                        <td style="padding-right: 12px; vertical-align: top;">
+                            <img src="https://www.logans3dcreations.com/public/images/products/{{.ProductImage}}" alt="{{.ProductName}}" width="60" height="60" style="display: block; width: 60px; height: 60px; border-radius: 4px; border: 1px solid #ddd;" />
+                        </td>
+                        {{end}}
+                        <td style="vertical-align: top;">{{.ProductName}}</td>
+                    </tr>
+                </table>
+            </td>
+            <td style="padding: 12px; text-align: center; border-bottom: 1px solid #ddd;">{{.Quantity}}</td>
+            <td style="padding: 12px; text-align: right; border-bottom: 1px solid #ddd;">{{FormatCents .UnitPrice}}</td>
+        </tr>
+        {{end}}
+    </tbody>
+</table>
+
+<div style="margin-top: 20px; padding-top: 20px; border-top: 2px solid #ddd;">
+    <table width="100%" cellpadding="0" cellspacing="0" border="0">
+        <tr>
+            <td style="padding: 15px 0 0 0; font-size: 20px; font-weight: bold; color: #E85D5D;">Cart Total:</td>
+            <td style="padding: 15px 0 0 0; text-align: right; font-size: 20px; font-weight: bold; color: #E85D5D;">{{FormatCents .CartValue}}</td>
+        </tr>
+    </table>
+</div>
+
+{{if .PromoCode}}
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#fff8e6" style="background-color: #fff8e6; margin: 25px 0;">
+    <tr>
+        <td style="padding: 20px; text-align: center;">
+            <p style="font-size: 16px; margin: 0 0 10px 0;">Use this code at checkout:</p>
+            <p style="font-size: 24px; font-weight: bold; letter-spacing: 2px; margin: 0; color: #E85D5D;">{{.PromoCode}}</p>
+        </td>
+    </tr>
+</table>
+{{end}}
+
+<div style="text-align: center; margin: 40px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 16px 40px; border-radius: 5px;">
+                <a href="{{.CartURL}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 18px; display: block;">Back to My Cart</a>
+            </td>
+        </tr>
+    </table>
+    <p style="margin-top: 15px; color: #777; font-size: 14px;">Prices and stock are checked again when you check out.</p>
+</div>
+`
+
+// orderRefundedTemplate is the content section for the email confirming a refund
+// issued from the admin order page
+const orderRefundedTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #0EA5E9; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">{{if .FullRefund}}ORDER REFUNDED{{else}}PARTIAL REFUND{{end}}</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">Your Refund Is On Its Way</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, we've refunded {{FormatCents .AmountCents}} to the card you paid with.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #0EA5E9;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Order Number:</strong> #{{.OrderID}}</p>
+            <p style="margin: 5px 0;"><strong style="color: #555;">Refund:</strong> {{FormatCents .AmountCents}}</p>
+            {{if .Currency}}<p style="margin: 5px 0;">It goes back in {{.Currency}}, at the rate you paid.</p>{{end}}
+            <p style="margin: 5px 0;">Refunds usually show on your statement within 5 to 10 business days.</p>
+        </td>
+    </tr>
+</table>
+
+{{if .Items}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Refunded Items</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/account/orders/{{.OrderID}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Order</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>If you have any questions about your refund, please contact us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// orderPaymentRequestTemplate is the content section for the email asking a customer
+// to pay for a change to their order through a Stripe payment link
+const orderPaymentRequestTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #F59E0B; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">ORDER UPDATED</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">An Update to Your Order</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, we've made a change to order #{{.OrderID}} that comes to {{FormatCents .AmountCents}} more.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #F59E0B;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Order Number:</strong> #{{.OrderID}}</p>
+            <p style="margin: 5px 0;"><strong style="color: #555;">Change:</strong> {{.Description}}</p>
+            <p style="margin: 5px 0;"><strong style="color: #555;">Amount Due:</strong> {{FormatCents .AmountCents}}</p>
+            {{if .Note}}<p style="margin: 5px 0;">{{.Note}}</p>{{end}}
+        </td>
+    </tr>
+</table>
+
+{{if .Items}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Added Items</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="{{.PaymentURL}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">Pay {{FormatCents .AmountCents}}</a>
+            </td>
+        </tr>
+    </table>
+    <p style="color: #777; font-size: 14px; margin-top: 15px;">The link takes you to a secure Stripe page. We'll carry on with your order as soon as it's paid.</p>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>If you didn't ask for this change or have any questions, please contact us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// orderCancelledTemplate is the content section for the email sent when an order is
+// cancelled. Refunds are issued separately and have their own email.
+const orderCancelledTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #6B7280; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">ORDER CANCELLED</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">Your Order Has Been Cancelled</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, {{if .ByCustomer}}as you asked, {{end}}order #{{.OrderID}} has been cancelled and won't be shipped.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #6B7280;">
+            {{if .RefundCents}}
+            <p style="margin: 5px 0;">We've refunded <strong>{{FormatCents .RefundCents}}</strong> to the card you paid with.</p>
+            {{if .Currency}}<p style="margin: 5px 0;">It goes back in {{.Currency}}, at the rate you paid.</p>{{end}}
+            <p style="margin: 5px 0;">Refunds usually show on your statement within 5 to 10 business days.</p>
+            {{else}}
+            <p style="margin: 5px 0;">If you paid for it, your refund is sent separately and we'll email you when it's on its way.</p>
+            {{end}}
+        </td>
+    </tr>
+</table>
+
+{{if .Items}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Cancelled Items</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>If you didn't expect this or have any questions, please contact us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// adminOrderCancelledTemplate is the content section for the email telling the shop a
+// customer cancelled their order
+const adminOrderCancelledTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #6B7280; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">ORDER CANCELLED</span>
+    <h1 style="color: #6B7280; margin: 10px 0; font-size: 28px;">Order #{{.OrderID}}</h1>
+    <p style="font-size: 16px; color: #666; margin: 10px 0;">{{.CustomerName}} cancelled it from their account. Don't make or ship it.</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #6B7280;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Customer:</strong> {{.CustomerName}} (<a href="mailto:{{.CustomerEmail}}" style="color: #E85D5D; text-decoration: none;">{{.CustomerEmail}}</a>)</p>
+            <p style="margin: 5px 0;"><strong style="color: #555;">Refunded:</strong> {{if .RefundCents}}{{FormatCents .RefundCents}} through Stripe{{else}}Nothing{{end}}</p>
+            <p style="margin: 5px 0;">The items have been put back into stock.</p>
+        </td>
+    </tr>
+</table>
+
+{{if .Items}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Cancelled Items</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#6B7280" style="background-color: #6B7280; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/admin/orders/{{.OrderID}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Order</a>
+            </td>
+        </tr>
+    </table>
+</div>
+`
+
+// orderShipmentTemplate is the content section for the email sent when a box of an
+// order ships. Orders sent in more than one box get one for each, listing what's
+// still to come until the last.
+const orderShipmentTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #0891B2; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">{{if .Remaining}}PARTIALLY SHIPPED{{else}}SHIPPED{{end}}</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">{{if .Remaining}}Part of Your Order Is On Its Way!{{else}}Your Order Is On Its Way!{{end}}</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, {{if .Remaining}}some of the items in order #{{.OrderID}} have shipped. The rest will follow in another box.{{else}}order #{{.OrderID}} has shipped.{{end}}</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #0891B2;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Order Number:</strong> #{{.OrderID}}</p>
+            {{if .Carrier}}<p style="margin: 5px 0;"><strong style="color: #555;">Carrier:</strong> {{.Carrier}}</p>{{end}}
+            {{if .TrackingNumber}}<p style="margin: 5px 0;"><strong style="color: #555;">Tracking Number:</strong> {{if .TrackingURL}}<a href="{{.TrackingURL}}" style="color: #E85D5D; text-decoration: none;">{{.TrackingNumber}}</a>{{else}}{{.TrackingNumber}}{{end}}</p>{{end}}
+        </td>
+    </tr>
+</table>
+
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">In This Box</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+
+{{if .Remaining}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Still To Come</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Remaining}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; color: #777;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right; color: #777;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+<p style="color: #777; font-size: 14px;">We'll email you the tracking for each box as it ships.</p>
+{{end}}
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/account/orders/{{.OrderID}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Order Status</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>If you have any questions about your order, please contact us at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// orderShipmentDeliveredTemplate is the content section for the email sent when a box
+// of an order is marked delivered, listing what's still to come if anything is
+const orderShipmentDeliveredTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <span style="display: inline-block; background-color: #16A34A; color: white; padding: 5px 15px; border-radius: 20px; font-weight: 600; font-size: 14px; margin-bottom: 10px;">DELIVERED</span>
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">{{if .Remaining}}A Box of Your Order Has Arrived!{{else}}Your Order Has Arrived!{{end}}</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, {{if .Remaining}}a box from order #{{.OrderID}} has been delivered. The rest is still on its way.{{else}}the last of order #{{.OrderID}} has been delivered. We hope you love it!{{end}}</p>
+</div>
+
+<table width="100%" cellpadding="0" cellspacing="0" border="0" bgcolor="#f9f9f9" style="background-color: #f9f9f9; margin-bottom: 25px;">
+    <tr>
+        <td style="padding: 20px; border-left: 4px solid #16A34A;">
+            <p style="margin: 5px 0;"><strong style="color: #555;">Order Number:</strong> #{{.OrderID}}</p>
+            {{if .Carrier}}<p style="margin: 5px 0;"><strong style="color: #555;">Carrier:</strong> {{.Carrier}}</p>{{end}}
+            {{if .TrackingNumber}}<p style="margin: 5px 0;"><strong style="color: #555;">Tracking Number:</strong> {{if .TrackingURL}}<a href="{{.TrackingURL}}" style="color: #E85D5D; text-decoration: none;">{{.TrackingNumber}}</a>{{else}}{{.TrackingNumber}}{{end}}</p>{{end}}
+        </td>
+    </tr>
+</table>
+
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">In This Box</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Items}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+
+{{if .Remaining}}
+<h2 style="color: #555; font-size: 20px; margin-top: 30px;">Still To Come</h2>
+<table width="100%" cellpadding="0" cellspacing="0" border="0" style="margin: 20px 0;">
+    {{range .Remaining}}
+    <tr>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; color: #777;">{{.ProductName}}</td>
+        <td style="padding: 12px; border-bottom: 1px solid #ddd; text-align: right; color: #777;">Qty {{.Quantity}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/account/orders/{{.OrderID}}" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">View Order Status</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>Something missing or damaged? Let us know at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// privacyExportReadyTemplate is the content section for the email sent when a
+// customer's export of their data is ready to download
+const privacyExportReadyTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">Your Data Export Is Ready</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, the copy of your data you asked for is ready.</p>
+</div>
+
+<p style="color: #555;">It's a ZIP file of your account, orders, favorites and the emails we've sent you. Sign in to download it from your account's privacy page. The download is there until {{.ExpiresOn}}, after which you can ask for a new one.</p>
+
+<div style="text-align: center; margin: 30px 0;">
+    <table cellpadding="0" cellspacing="0" border="0" align="center">
+        <tr>
+            <td bgcolor="#E85D5D" style="background-color: #E85D5D; padding: 14px 35px; border-radius: 5px;">
+                <a href="https://www.logans3dcreations.com/account/privacy" style="color: white; text-decoration: none; font-weight: 600; font-size: 16px; display: block;">Download Your Data</a>
+            </td>
+        </tr>
+    </table>
+</div>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>Didn't ask for this? Let us know at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
+
+// accountDeletedTemplate is the content section for the email confirming a customer's
+// account has been deleted
+const accountDeletedTemplate = `
+<div style="text-align: center; margin-bottom: 30px;">
+    <h1 style="color: #E85D5D; margin: 10px 0; font-size: 28px;">Your Account Has Been Deleted</h1>
+    <p style="font-size: 18px; color: #666; margin: 10px 0;">Hi{{if .CustomerName}} {{.CustomerName}}{{end}}, as you asked, we've deleted your Logan's 3D Creations account.</p>
+</div>
+
+<p style="color: #555;">Your profile, saved address, cart, favorites, collections, saved cards and email history are gone, and you won't get any more emails from us. We keep a record of your past orders for our tax and accounting records, with your name, email, phone number and street address removed from them.</p>
+
+<p style="color: #555;">This is the last email we'll send to this address. You're welcome to make a new account any time.</p>
+
+<div style="text-align: center; margin-top: 30px; padding-top: 20px; border-top: 1px solid #ddd; color: #777; font-size: 14px;">
+    <p>Didn't ask for this? Let us know right away at<br>
+    <a href="mailto:prints@logans3dcreations.com" style="color: #E85D5D; text-decoration: none;">prints@logans3dcreations.com</a></p>
+</div>
+`
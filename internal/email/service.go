@@ -5,19 +5,32 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/smtp"
+	"net/textproto"
 	"os"
 	"strconv"
 
+	"github.com/loganlanou/logans3d-v4/internal/settings"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/oklog/ulid/v2"
 )
 
+// ErrNotConfigured means the Brevo SMTP settings are missing
+var ErrNotConfigured = errors.New("email service not configured")
+
+// ErrOptedOut means the recipient turned this kind of email off, so it wasn't sent
+var ErrOptedOut = errors.New("recipient opted out of this email")
+
 // Service handles email sending via Brevo SMTP
 type Service struct {
 	host     string
@@ -35,6 +48,11 @@ func NewService(queries *db.Queries) *Service {
 		port = 587 // default
 	}
 
+	// Email footers show the contact details from the site settings
+	if queries != nil {
+		siteSettings.Store(settings.For(queries))
+	}
+
 	return &Service{
 		host:     os.Getenv("BREVO_SMTP_HOST"),
 		port:     port,
@@ -62,7 +80,7 @@ func (s *Service) CheckEmailPreference(ctx context.Context, email string, emailT
 		return true, nil
 	}
 
-	// Transactional emails always allowed (order confirmations, etc.)
+	// Transactional emails always allowed (receipts for requests, account notices, etc.)
 	if emailType == "transactional" {
 		return true, nil
 	}
@@ -71,11 +89,8 @@ func (s *Service) CheckEmailPreference(ctx context.Context, email string, emailT
 	prefs, err := s.queries.GetEmailPreferencesByEmail(ctx, email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			// No preferences set - default to allowing abandoned cart, denying others
-			if emailType == "abandoned_cart" {
-				return true, nil
-			}
-			return false, nil
+			// No preferences set - service emails and abandoned cart default on, marketing off
+			return defaultEmailPreference(emailType), nil
 		}
 		return false, fmt.Errorf("failed to get email preferences: %w", err)
 	}
@@ -90,11 +105,49 @@ func (s *Service) CheckEmailPreference(ctx context.Context, email string, emailT
 		return prefs.Newsletter.Valid && prefs.Newsletter.Int64 == 1, nil
 	case "product_updates":
 		return prefs.ProductUpdates.Valid && prefs.ProductUpdates.Int64 == 1, nil
+	case "order_updates":
+		return PreferenceOn(prefs.OrderUpdates), nil
+	case "shipping_updates":
+		return PreferenceOn(prefs.ShippingUpdates), nil
+	case "back_in_stock":
+		return PreferenceOn(prefs.BackInStock), nil
 	default:
 		return false, nil
 	}
 }
 
+// defaultEmailPreference is whether an email type goes to someone who has never
+// saved preferences
+func defaultEmailPreference(emailType string) bool {
+	switch emailType {
+	case "abandoned_cart", "order_updates", "shipping_updates", "back_in_stock":
+		return true
+	}
+	return false
+}
+
+// PreferenceOn reports whether one of the default-on preferences (order updates,
+// shipping updates, back in stock) is on. NULL means it was never set, so it is.
+func PreferenceOn(pref sql.NullInt64) bool {
+	return !pref.Valid || pref.Int64 != 0
+}
+
+// checkRecipient returns ErrOptedOut when the recipient turned off emailType. If the
+// preferences can't be read, order and shipping updates still go out since the
+// customer is waiting on them; anything else is held back.
+func (s *Service) checkRecipient(ctx context.Context, recipient, emailType string) error {
+	allowed, err := s.CheckEmailPreference(ctx, recipient, emailType)
+	if err != nil {
+		slog.Error("failed to check email preference", "error", err, "email", recipient, "type", emailType)
+		allowed = emailType == "order_updates" || emailType == "shipping_updates"
+	}
+	if !allowed {
+		slog.Info("email not sent, recipient opted out", "email", recipient, "type", emailType)
+		return ErrOptedOut
+	}
+	return nil
+}
+
 // LogEmailSend logs an email send to the database
 func (s *Service) LogEmailSend(ctx context.Context, recipientEmail, emailType, subject, templateName, trackingToken string, metadata map[string]interface{}) error {
 	if s.queries == nil {
@@ -117,9 +170,18 @@ func (s *Service) LogEmailSend(ctx context.Context, recipientEmail, emailType, s
 		trackingTokenNullable = sql.NullString{String: trackingToken, Valid: true}
 	}
 
+	// Link the send to the recipient's account so it shows in their history. Guests'
+	// sends are linked when they sign up.
+	var userID sql.NullString
+	if user, err := s.queries.GetUserByEmail(ctx, recipientEmail); err == nil {
+		userID = sql.NullString{String: user.ID, Valid: true}
+	} else if err != sql.ErrNoRows {
+		slog.Warn("failed to look up email recipient", "error", err, "email", recipientEmail)
+	}
+
 	_, err := s.queries.CreateEmailHistory(ctx, db.CreateEmailHistoryParams{
 		ID:             ulid.Make().String(),
-		UserID:         sql.NullString{}, // Will be set by caller if known
+		UserID:         userID,
 		RecipientEmail: recipientEmail,
 		EmailType:      emailType,
 		Subject:        subject,
@@ -179,11 +241,19 @@ func (s *Service) GetOrCreateEmailPreferences(ctx context.Context, email string,
 
 // Email represents an email message
 type Email struct {
-	To      []string
-	Subject string
-	Body    string
-	IsHTML  bool
-	ReplyTo string
+	To          []string
+	Subject     string
+	Body        string
+	IsHTML      bool
+	ReplyTo     string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 // Send sends an email via Brevo SMTP
@@ -193,36 +263,113 @@ func (s *Service) Send(email *Email) error {
 		return fmt.Errorf("email service not configured: missing BREVO_SMTP_HOST, BREVO_SMTP_KEY, or EMAIL_FROM")
 	}
 
-	// Build email message
+	msg, err := buildMessage(s.from, email)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	// Set up authentication
+	auth := smtp.PlainAuth("", s.username, s.password, s.host)
+
+	// Send email
+	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	err = smtp.SendMail(addr, auth, s.from, email.To, msg)
+	if err != nil {
+		slog.Error("failed to send email", "error", err, "to", email.To)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	slog.Info("email sent successfully", "to", email.To, "subject", email.Subject)
+	return nil
+}
+
+// buildMessage writes the headers and body of an email. With attachments it becomes a
+// multipart/mixed message: the body first, then each file base64-encoded.
+func buildMessage(from string, email *Email) ([]byte, error) {
 	var msg bytes.Buffer
-	msg.WriteString(fmt.Sprintf("From: %s\r\n", s.from))
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", from))
 	msg.WriteString(fmt.Sprintf("To: %s\r\n", email.To[0]))
 	if email.ReplyTo != "" {
 		msg.WriteString(fmt.Sprintf("Reply-To: %s\r\n", email.ReplyTo))
 	}
 	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", email.Subject))
 
+	bodyType := "text/plain; charset=\"utf-8\""
 	if email.IsHTML {
-		msg.WriteString("MIME-Version: 1.0\r\n")
-		msg.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+		bodyType = "text/html; charset=\"utf-8\""
 	}
 
-	msg.WriteString("\r\n")
-	msg.WriteString(email.Body)
+	if len(email.Attachments) == 0 {
+		if email.IsHTML {
+			msg.WriteString("MIME-Version: 1.0\r\n")
+			msg.WriteString(fmt.Sprintf("Content-Type: %s\r\n", bodyType))
+		}
+		msg.WriteString("\r\n")
+		msg.WriteString(email.Body)
+		return msg.Bytes(), nil
+	}
 
-	// Set up authentication
-	auth := smtp.PlainAuth("", s.username, s.password, s.host)
+	parts := multipart.NewWriter(&msg)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n\r\n", parts.Boundary()))
 
-	// Send email
-	addr := fmt.Sprintf("%s:%d", s.host, s.port)
-	err := smtp.SendMail(addr, auth, s.from, email.To, msg.Bytes())
+	body, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {bodyType}})
 	if err != nil {
-		slog.Error("failed to send email", "error", err, "to", email.To)
-		return fmt.Errorf("failed to send email: %w", err)
+		return nil, err
+	}
+	if _, err := body.Write([]byte(email.Body)); err != nil {
+		return nil, err
 	}
 
-	slog.Info("email sent successfully", "to", email.To, "subject", email.Subject)
-	return nil
+	for _, attachment := range email.Attachments {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// RFC 2045 limits encoded lines to 76 characters
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// Ping connects to the SMTP server and reads its greeting, without sending anything
+func (s *Service) Ping(ctx context.Context) error {
+	if s.host == "" || s.password == "" || s.from == "" {
+		return fmt.Errorf("missing BREVO_SMTP_HOST, BREVO_SMTP_KEY, or EMAIL_FROM: %w", ErrNotConfigured)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", s.host, s.port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read SMTP greeting: %w", err)
+	}
+	return client.Quit()
 }
 
 // OrderData contains all the data needed for order emails
@@ -239,6 +386,15 @@ type OrderData struct {
 	ShippingAddress Address
 	BillingAddress  Address
 	PaymentIntentID string
+	Downloads       []DownloadLink // Signed links for digital items
+	CustomerNotes   string         // Instructions the customer left at checkout
+	IsGift          bool
+	GiftMessage     string      // Printed on the packing slip
+	GiftRecipient   string      // Recipient's name, when given
+	Invoice         *Attachment // Invoice PDF for the customer's confirmation, when enabled
+	// CarbonOffsetCents is what the customer added at checkout to offset the order's
+	// carbon, or zero
+	CarbonOffsetCents int64
 }
 
 // OrderItem represents a single item in an order
@@ -250,6 +406,15 @@ type OrderItem struct {
 	TotalCents    int64
 	ShippingTime  string // "Ships in 1-3 days" or "Ships in 4-5 days"
 	NeedsPrinting bool   // true if item needs to be printed (stock was 0)
+	IsPreorder    bool   // true if item was bought as a pre-order
+	IsDigital     bool   // true if item is delivered by download
+}
+
+// DownloadLink is a signed, expiring link to a purchased digital file
+type DownloadLink struct {
+	ProductName string
+	FileName    string
+	URL         string
 }
 
 // Address represents a shipping or billing address
@@ -273,6 +438,10 @@ func FormatCents(cents int64) string {
 func (s *Service) SendOrderConfirmation(data *OrderData) error {
 	ctx := context.Background()
 
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
 	// Render the full email (content + base template)
 	html, err := RenderCustomerOrderEmail(data)
 	if err != nil {
@@ -286,14 +455,18 @@ func (s *Service) SendOrderConfirmation(data *OrderData) error {
 		Body:    html,
 		IsHTML:  true,
 	}
+	if data.Invoice != nil {
+		email.Attachments = []Attachment{*data.Invoice}
+	}
 
 	sendErr := s.Send(email)
 
 	// Log the email send
 	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "order_confirmation", subject, "customer_order", "", map[string]interface{}{
-		"order_id":    data.OrderID,
-		"order_total": data.TotalCents,
-		"item_count":  len(data.Items),
+		"order_id":         data.OrderID,
+		"order_total":      data.TotalCents,
+		"item_count":       len(data.Items),
+		"invoice_attached": data.Invoice != nil,
 	})
 	if logErr != nil {
 		slog.Error("failed to log email send", "error", logErr)
@@ -581,11 +754,22 @@ type AbandonedCartData struct {
 }
 
 // SendAbandonedCartRecoveryEmail sends a recovery email to a customer
-// Abandoned cart emails are always sent (transactional reminders)
+// Abandoned cart emails are sent unless the customer turned them off
 // Promo codes are only included if user has opted into promotional emails
 func (s *Service) SendAbandonedCartRecoveryEmail(data *AbandonedCartData, attemptType string) error {
+	return s.SendAbandonedCartSequenceEmail(data, attemptType, "")
+}
+
+// SendAbandonedCartSequenceEmail sends a recovery email using the given template key
+// (email_1hr, email_24hr, email_72hr). A non-empty subject overrides the template's
+// default subject so sequence steps and A/B variants can test their own copy.
+func (s *Service) SendAbandonedCartSequenceEmail(data *AbandonedCartData, attemptType string, subjectOverride string) error {
 	ctx := context.Background()
 
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "abandoned_cart"); err != nil {
+		return err
+	}
+
 	// Get or create email preferences to get unsubscribe token
 	prefs, err := s.GetOrCreateEmailPreferences(ctx, data.CustomerEmail, nil)
 	if err != nil {
@@ -619,6 +803,10 @@ func (s *Service) SendAbandonedCartRecoveryEmail(data *AbandonedCartData, attemp
 		return err
 	}
 
+	if subjectOverride != "" {
+		subject = subjectOverride
+	}
+
 	email := &Email{
 		To:      []string{data.CustomerEmail},
 		Subject: subject,
@@ -744,6 +932,10 @@ func RenderWelcomeCouponEmailWithToken(data *WelcomeCouponData, unsubscribeToken
 
 // SendQuoteDraftRecoveryEmail sends a recovery email for an abandoned quote draft
 func (s *Service) SendQuoteDraftRecoveryEmail(ctx context.Context, draft db.CustomQuoteDraft, subject, customMessage string) error {
+	if err := s.checkRecipient(ctx, draft.Email.String, "abandoned_cart"); err != nil {
+		return err
+	}
+
 	customerName := "there"
 	if draft.Name.Valid && draft.Name.String != "" {
 		customerName = draft.Name.String
@@ -819,3 +1011,1018 @@ func RenderQuoteDraftRecoveryEmail(data *QuoteDraftRecoveryData) (string, error)
 
 	return WrapEmailContent(content.String(), "Continue Your Custom Quote")
 }
+
+// ProductQuestionData contains all data for product question emails
+type ProductQuestionData struct {
+	ID          string
+	ProductName string
+	ProductURL  string
+	AskerName   string
+	AskerEmail  string
+	Question    string
+	Answer      string
+	SubmittedAt string
+}
+
+// SendProductQuestionNotification notifies the admin that a shopper asked a product question
+func (s *Service) SendProductQuestionNotification(data *ProductQuestionData) error {
+	ctx := context.Background()
+
+	html, err := RenderProductQuestionNotificationEmail(data)
+	if err != nil {
+		return err
+	}
+
+	internalEmail := os.Getenv("EMAIL_TO_INTERNAL")
+	if internalEmail == "" {
+		internalEmail = "prints@logans3dcreations.com"
+	}
+
+	subject := fmt.Sprintf("New Product Question - %s", data.ProductName)
+	email := &Email{
+		To:      []string{internalEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+		ReplyTo: data.AskerEmail,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, internalEmail, "product_question_admin", subject, "product_question", "", map[string]interface{}{
+		"question_id": data.ID,
+		"product":     data.ProductName,
+		"asker_email": data.AskerEmail,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderProductQuestionNotificationEmail renders the admin notification for a new product question
+func RenderProductQuestionNotificationEmail(data *ProductQuestionData) (string, error) {
+	tmpl := template.Must(template.New("product_question").Parse(productQuestionNotificationTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render product question email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), fmt.Sprintf("New Product Question - %s", data.ProductName))
+}
+
+// SendProductQuestionAnswered emails the shopper the published answer to their question
+func (s *Service) SendProductQuestionAnswered(data *ProductQuestionData) error {
+	ctx := context.Background()
+
+	html, err := RenderProductQuestionAnsweredEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your question about %s was answered", data.ProductName)
+	email := &Email{
+		To:      []string{data.AskerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.AskerEmail, "product_question_answered", subject, "product_question_answered", "", map[string]interface{}{
+		"question_id": data.ID,
+		"product":     data.ProductName,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderProductQuestionAnsweredEmail renders the answered question email sent to the shopper
+func RenderProductQuestionAnsweredEmail(data *ProductQuestionData) (string, error) {
+	tmpl := template.Must(template.New("product_question_answered").Parse(productQuestionAnsweredTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render product question answered email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Question Was Answered")
+}
+
+// PreorderShippedData contains the data for the pre-order shipped email
+type PreorderShippedData struct {
+	OrderID        string
+	CustomerName   string
+	CustomerEmail  string
+	Items          []PreorderShippedItem
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+}
+
+// PreorderShippedItem is a pre-order line included in the shipped email
+type PreorderShippedItem struct {
+	ProductName string
+	Quantity    int64
+}
+
+// SendPreorderShipped tells the customer that the items they pre-ordered have shipped
+func (s *Service) SendPreorderShipped(data *PreorderShippedData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "shipping_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderPreorderShippedEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your pre-order has shipped - Order #%s", data.OrderID)
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "preorder_shipped", subject, "preorder_shipped", "", map[string]interface{}{
+		"order_id":   data.OrderID,
+		"item_count": len(data.Items),
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderPreorderShippedEmail renders the pre-order shipped email sent to the customer
+func RenderPreorderShippedEmail(data *PreorderShippedData) (string, error) {
+	tmpl := template.Must(template.New("preorder_shipped").Parse(preorderShippedTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render pre-order shipped email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Pre-Order Has Shipped")
+}
+
+// GiftShippedData contains the data for the email telling a gift's recipient it has
+// shipped. It deliberately has no amounts.
+type GiftShippedData struct {
+	OrderID        string
+	RecipientName  string
+	RecipientEmail string
+	SenderName     string
+	GiftMessage    string
+	Items          []GiftShippedItem
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+}
+
+// GiftShippedItem is a line in the gift shipped email
+type GiftShippedItem struct {
+	ProductName string
+	Quantity    int64
+}
+
+// SendGiftShipped tells a gift's recipient that it's on the way
+func (s *Service) SendGiftShipped(data *GiftShippedData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.RecipientEmail, "shipping_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderGiftShippedEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("%s sent you a gift", data.SenderName)
+	email := &Email{
+		To:      []string{data.RecipientEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.RecipientEmail, "gift_shipped", subject, "gift_shipped", "", map[string]interface{}{
+		"order_id": data.OrderID,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderGiftShippedEmail renders the gift shipped email sent to the recipient
+func RenderGiftShippedEmail(data *GiftShippedData) (string, error) {
+	tmpl := template.Must(template.New("gift_shipped").Parse(giftShippedTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render gift shipped email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "A Gift Is On Its Way")
+}
+
+// GiftCardData contains the data for the email delivering a gift card
+type GiftCardData struct {
+	GiftCardID     string
+	Code           string
+	AmountCents    int64
+	RecipientName  string
+	RecipientEmail string
+	// SenderName is empty for a card sent to the person who bought it
+	SenderName string
+	Message    string
+	ExpiresAt  string
+	ShopURL    string
+}
+
+// SendGiftCard delivers a gift card's code to its recipient. The email is the card,
+// so it goes out whatever the recipient's email preferences.
+func (s *Service) SendGiftCard(data *GiftCardData) error {
+	ctx := context.Background()
+
+	html, err := RenderGiftCardEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your %s Logan's 3D Creations gift card", FormatCents(data.AmountCents))
+	if data.SenderName != "" {
+		subject = fmt.Sprintf("%s sent you a %s gift card", data.SenderName, FormatCents(data.AmountCents))
+	}
+	email := &Email{
+		To:      []string{data.RecipientEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.RecipientEmail, "gift_card", subject, "gift_card", "", map[string]interface{}{
+		"gift_card_id": data.GiftCardID,
+		"amount_cents": data.AmountCents,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderGiftCardEmail renders the email delivering a gift card
+func RenderGiftCardEmail(data *GiftCardData) (string, error) {
+	tmpl := template.Must(template.New("gift_card").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+	}).Parse(giftCardTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render gift card email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Gift Card")
+}
+
+// EventRegistrationData contains the data for event booking emails
+type EventRegistrationData struct {
+	RegistrationID string
+	CustomerName   string
+	CustomerEmail  string
+	EventTitle     string
+	EventDate      string
+	EventTime      string
+	Location       string
+	Address        string
+	EventURL       string
+	CalendarURL    string
+	TicketName     string
+	Quantity       int64
+	AmountCents    int64
+	Waitlisted     bool
+}
+
+// SendEventRegistrationConfirmation confirms a booking, or tells the customer they're
+// on the waitlist
+func (s *Service) SendEventRegistrationConfirmation(data *EventRegistrationData) error {
+	html, err := RenderEventRegistrationEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("You're booked for %s", data.EventTitle)
+	emailType := "event_registration"
+	if data.Waitlisted {
+		subject = fmt.Sprintf("You're on the waitlist for %s", data.EventTitle)
+		emailType = "event_waitlist"
+	}
+	return s.sendEventEmail(data, subject, emailType, html)
+}
+
+// SendEventReminder reminds a confirmed attendee that their event is coming up
+func (s *Service) SendEventReminder(data *EventRegistrationData) error {
+	html, err := RenderEventReminderEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Reminder: %s is coming up", data.EventTitle)
+	return s.sendEventEmail(data, subject, "event_reminder", html)
+}
+
+// sendEventEmail sends a booking email. Bookings are orders too, so they follow the
+// customer's order updates preference.
+func (s *Service) sendEventEmail(data *EventRegistrationData, subject, emailType, html string) error {
+	if err := s.checkRecipient(context.Background(), data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(context.Background(), data.CustomerEmail, emailType, subject, emailType, "", map[string]interface{}{
+		"registration_id": data.RegistrationID,
+		"event":           data.EventTitle,
+		"quantity":        data.Quantity,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderEventRegistrationEmail renders the booking confirmation or waitlist email
+func RenderEventRegistrationEmail(data *EventRegistrationData) (string, error) {
+	tmpl := template.Must(template.New("event_registration").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+	}).Parse(eventRegistrationTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render event registration email content: %w", err)
+	}
+
+	title := "You're Booked"
+	if data.Waitlisted {
+		title = "You're on the Waitlist"
+	}
+	return WrapEmailContent(content.String(), title)
+}
+
+// RenderEventReminderEmail renders the reminder sent the day before an event
+func RenderEventReminderEmail(data *EventRegistrationData) (string, error) {
+	tmpl := template.Must(template.New("event_reminder").Parse(eventReminderTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render event reminder email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Event Is Coming Up")
+}
+
+// CheckoutExpiredData contains the data for the email sent when a Stripe checkout
+// expires before it's paid
+type CheckoutExpiredData struct {
+	CustomerName  string
+	CustomerEmail string
+	SessionID     string
+	Items         []AbandonedCartItem
+	ItemCount     int64
+	CartValue     int64
+	CheckoutURL   string
+}
+
+// SendCheckoutExpired reminds a customer that their cart is still waiting after their
+// checkout expired
+func (s *Service) SendCheckoutExpired(data *CheckoutExpiredData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "abandoned_cart"); err != nil {
+		return err
+	}
+
+	var unsubscribeToken string
+	prefs, err := s.GetOrCreateEmailPreferences(ctx, data.CustomerEmail, nil)
+	if err != nil {
+		slog.Warn("failed to get email preferences, sending without unsubscribe link", "email", data.CustomerEmail, "error", err)
+	} else if prefs != nil && prefs.UnsubscribeToken.Valid {
+		unsubscribeToken = prefs.UnsubscribeToken.String
+	}
+
+	html, err := RenderCheckoutExpiredEmail(data, unsubscribeToken)
+	if err != nil {
+		return err
+	}
+
+	subject := "Your cart is saved - finish checking out"
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "abandoned_cart", subject, "checkout_expired", "", map[string]interface{}{
+		"session_id": data.SessionID,
+		"cart_value": data.CartValue,
+		"item_count": data.ItemCount,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderCheckoutExpiredEmail renders the "complete your order" email sent when a
+// checkout expires
+func RenderCheckoutExpiredEmail(data *CheckoutExpiredData, unsubscribeToken string) (string, error) {
+	tmpl := template.Must(template.New("checkout_expired").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+		"ne":          func(a, b int64) bool { return a != b },
+	}).Parse(checkoutExpiredTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render checkout expired email content: %w", err)
+	}
+
+	return WrapEmailContentWithUnsubscribe(content.String(), "Your Cart Is Saved", unsubscribeToken)
+}
+
+// SavedCartData contains the data for the email a shopper asks for from the cart page
+// before leaving
+type SavedCartData struct {
+	CustomerName  string
+	CustomerEmail string
+	Items         []AbandonedCartItem
+	ItemCount     int64
+	CartValue     int64
+	CartURL       string
+	PromoCode     string
+	TrackingToken string
+}
+
+// SendSavedCart emails a shopper the cart they asked to have sent to them
+func (s *Service) SendSavedCart(data *SavedCartData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "abandoned_cart"); err != nil {
+		return err
+	}
+
+	var unsubscribeToken string
+	prefs, err := s.GetOrCreateEmailPreferences(ctx, data.CustomerEmail, nil)
+	if err != nil {
+		slog.Warn("failed to get email preferences, sending without unsubscribe link", "email", data.CustomerEmail, "error", err)
+	} else if prefs != nil && prefs.UnsubscribeToken.Valid {
+		unsubscribeToken = prefs.UnsubscribeToken.String
+	}
+
+	html, err := RenderSavedCartEmail(data, unsubscribeToken)
+	if err != nil {
+		return err
+	}
+
+	subject := "Your saved cart from Logan's 3D Creations"
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "abandoned_cart", subject, "exit_intent", data.TrackingToken, map[string]interface{}{
+		"cart_value": data.CartValue,
+		"item_count": data.ItemCount,
+		"promo_code": data.PromoCode,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderSavedCartEmail renders the email with the cart a shopper asked to be sent
+func RenderSavedCartEmail(data *SavedCartData, unsubscribeToken string) (string, error) {
+	tmpl := template.Must(template.New("saved_cart").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+		"ne":          func(a, b int64) bool { return a != b },
+	}).Parse(savedCartTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render saved cart email content: %w", err)
+	}
+
+	return WrapEmailContentWithUnsubscribe(content.String(), "Your Saved Cart", unsubscribeToken)
+}
+
+// OrderRefundedData contains the data for the email confirming a refund issued from
+// the admin order page
+type OrderRefundedData struct {
+	OrderID       string
+	CustomerName  string
+	CustomerEmail string
+	AmountCents   int64
+	// Items are the lines refunded; a refund of an amount alone has none
+	Items []OrderRefundedItem
+	// FullRefund is set when nothing is left of the order to refund
+	FullRefund bool
+	// Currency is what the customer paid in, when it wasn't USD
+	Currency string
+}
+
+// OrderRefundedItem is a line in the refund confirmation email
+type OrderRefundedItem struct {
+	ProductName string
+	Quantity    int64
+}
+
+// SendOrderRefunded tells the customer a refund is on its way back to their card
+func (s *Service) SendOrderRefunded(data *OrderRefundedData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderOrderRefundedEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your refund for Order #%s", data.OrderID)
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "order_refunded", subject, "order_refunded", "", map[string]interface{}{
+		"order_id":     data.OrderID,
+		"amount_cents": data.AmountCents,
+		"full_refund":  data.FullRefund,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderOrderRefundedEmail renders the refund confirmation email sent to the customer
+func RenderOrderRefundedEmail(data *OrderRefundedData) (string, error) {
+	tmpl := template.Must(template.New("order_refunded").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+	}).Parse(orderRefundedTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render order refunded email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Refund Is On Its Way")
+}
+
+// OrderPaymentRequestData contains the data for the email asking a customer to pay for
+// a change to their order, like added items or dearer shipping
+type OrderPaymentRequestData struct {
+	OrderID       string
+	CustomerName  string
+	CustomerEmail string
+	Description   string
+	AmountCents   int64
+	// Items are the lines added; a change to shipping alone has none
+	Items      []OrderPaymentRequestItem
+	PaymentURL string
+	// Note is the shop's explanation of the change, when it gave one
+	Note string
+}
+
+// OrderPaymentRequestItem is a line in the payment request email
+type OrderPaymentRequestItem struct {
+	ProductName string
+	Quantity    int64
+}
+
+// SendOrderPaymentRequest sends the customer the payment link for a change to their order
+func (s *Service) SendOrderPaymentRequest(data *OrderPaymentRequestData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderOrderPaymentRequestEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("A change to your order needs payment - Order #%s", data.OrderID)
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "order_payment_request", subject, "order_payment_request", "", map[string]interface{}{
+		"order_id":     data.OrderID,
+		"amount_cents": data.AmountCents,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderOrderPaymentRequestEmail renders the email asking a customer to pay for a
+// change to their order
+func RenderOrderPaymentRequestEmail(data *OrderPaymentRequestData) (string, error) {
+	tmpl := template.Must(template.New("order_payment_request").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+	}).Parse(orderPaymentRequestTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render order payment request email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "An Update to Your Order")
+}
+
+// OrderCancelledData contains the data for the email telling a customer their order
+// was cancelled
+type OrderCancelledData struct {
+	OrderID       string
+	CustomerName  string
+	CustomerEmail string
+	Items         []OrderCancelledItem
+	// ByCustomer is set when the customer cancelled the order from their account
+	ByCustomer bool
+	// RefundCents is what the cancellation refunded, when it refunded anything
+	RefundCents int64
+	// Currency is what the customer paid in, when it wasn't USD
+	Currency string
+}
+
+// OrderCancelledItem is a line in the order cancelled email
+type OrderCancelledItem struct {
+	ProductName string
+	Quantity    int64
+}
+
+// SendOrderCancelled tells the customer their order was cancelled
+func (s *Service) SendOrderCancelled(data *OrderCancelledData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderOrderCancelledEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your order has been cancelled - Order #%s", data.OrderID)
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "order_cancelled", subject, "order_cancelled", "", map[string]interface{}{
+		"order_id": data.OrderID,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderOrderCancelledEmail renders the order cancelled email sent to the customer
+func RenderOrderCancelledEmail(data *OrderCancelledData) (string, error) {
+	tmpl := template.Must(template.New("order_cancelled").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+	}).Parse(orderCancelledTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render order cancelled email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Order Has Been Cancelled")
+}
+
+// SendOrderCancelledNotificationToAdmin tells the shop a customer cancelled their order
+func (s *Service) SendOrderCancelledNotificationToAdmin(data *OrderCancelledData) error {
+	ctx := context.Background()
+
+	html, err := RenderAdminOrderCancelledEmail(data)
+	if err != nil {
+		return err
+	}
+
+	internalEmail := os.Getenv("EMAIL_TO_INTERNAL")
+	if internalEmail == "" {
+		internalEmail = "prints@logans3dcreations.com"
+	}
+
+	subject := fmt.Sprintf("Order Cancelled by Customer - Order #%s", data.OrderID)
+	email := &Email{
+		To:      []string{internalEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+		ReplyTo: data.CustomerEmail,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, internalEmail, "order_cancelled_admin", subject, "admin_order_cancelled", "", map[string]interface{}{
+		"order_id":       data.OrderID,
+		"customer_email": data.CustomerEmail,
+		"refund_cents":   data.RefundCents,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderAdminOrderCancelledEmail renders the shop's notice that a customer cancelled
+// their order
+func RenderAdminOrderCancelledEmail(data *OrderCancelledData) (string, error) {
+	tmpl := template.Must(template.New("admin_order_cancelled").Funcs(template.FuncMap{
+		"FormatCents": FormatCents,
+	}).Parse(adminOrderCancelledTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render admin order cancelled email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), fmt.Sprintf("Order Cancelled by Customer - Order #%s", data.OrderID))
+}
+
+// OrderShipmentData contains the data for the email sent when a box of an order ships
+type OrderShipmentData struct {
+	OrderID        string
+	CustomerName   string
+	CustomerEmail  string
+	Items          []OrderShipmentItem
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+	// Remaining lists what's still to come when the order ships in more than one box
+	Remaining []OrderShipmentItem
+}
+
+// OrderShipmentItem is a line in the order shipment email
+type OrderShipmentItem struct {
+	ProductName string
+	Quantity    int64
+}
+
+// SendOrderShipment tells the customer a box of their order has shipped, with its tracking
+func (s *Service) SendOrderShipment(data *OrderShipmentData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderOrderShipmentEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your order has shipped - Order #%s", data.OrderID)
+	if len(data.Remaining) > 0 {
+		subject = fmt.Sprintf("Part of your order has shipped - Order #%s", data.OrderID)
+	}
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "order_shipment", subject, "order_shipment", "", map[string]interface{}{
+		"order_id":        data.OrderID,
+		"tracking_number": data.TrackingNumber,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderOrderShipmentEmail renders the order shipment email sent to the customer
+func RenderOrderShipmentEmail(data *OrderShipmentData) (string, error) {
+	tmpl := template.Must(template.New("order_shipment").Parse(orderShipmentTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render order shipment email content: %w", err)
+	}
+
+	if len(data.Remaining) > 0 {
+		return WrapEmailContent(content.String(), "Part of Your Order Has Shipped")
+	}
+	return WrapEmailContent(content.String(), "Your Order Has Shipped")
+}
+
+// SendOrderShipmentDelivered tells the customer a box of their order has arrived. It
+// takes the same data as the shipment email, with Remaining as what's still on its
+// way or yet to ship.
+func (s *Service) SendOrderShipmentDelivered(data *OrderShipmentData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "order_updates"); err != nil {
+		return err
+	}
+
+	html, err := RenderOrderShipmentDeliveredEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your order has been delivered - Order #%s", data.OrderID)
+	if len(data.Remaining) > 0 {
+		subject = fmt.Sprintf("A box of your order has been delivered - Order #%s", data.OrderID)
+	}
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "order_shipment_delivered", subject, "order_shipment_delivered", "", map[string]interface{}{
+		"order_id":        data.OrderID,
+		"tracking_number": data.TrackingNumber,
+	})
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderOrderShipmentDeliveredEmail renders the email sent to the customer when a box
+// of their order is delivered
+func RenderOrderShipmentDeliveredEmail(data *OrderShipmentData) (string, error) {
+	tmpl := template.Must(template.New("order_shipment_delivered").Parse(orderShipmentDeliveredTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render order shipment delivered email content: %w", err)
+	}
+
+	if len(data.Remaining) > 0 {
+		return WrapEmailContent(content.String(), "A Box of Your Order Has Arrived")
+	}
+	return WrapEmailContent(content.String(), "Your Order Has Arrived")
+}
+
+// PrivacyExportData contains the data for the email sent when a customer's data export
+// is ready
+type PrivacyExportData struct {
+	CustomerName  string
+	CustomerEmail string
+	// ExpiresOn is the date the download goes, written out
+	ExpiresOn string
+}
+
+// SendPrivacyExportReady tells a customer the export of their data they asked for can
+// be downloaded from their account
+func (s *Service) SendPrivacyExportReady(data *PrivacyExportData) error {
+	ctx := context.Background()
+
+	if err := s.checkRecipient(ctx, data.CustomerEmail, "transactional"); err != nil {
+		return err
+	}
+
+	html, err := RenderPrivacyExportReadyEmail(data)
+	if err != nil {
+		return err
+	}
+
+	subject := "Your data export is ready - Logan's 3D Creations"
+	email := &Email{
+		To:      []string{data.CustomerEmail},
+		Subject: subject,
+		Body:    html,
+		IsHTML:  true,
+	}
+
+	sendErr := s.Send(email)
+
+	logErr := s.LogEmailSend(ctx, data.CustomerEmail, "privacy_export_ready", subject, "privacy_export_ready", "", nil)
+	if logErr != nil {
+		slog.Error("failed to log email send", "error", logErr)
+	}
+
+	return sendErr
+}
+
+// RenderPrivacyExportReadyEmail renders the email sent when a customer's data export is
+// ready
+func RenderPrivacyExportReadyEmail(data *PrivacyExportData) (string, error) {
+	tmpl := template.Must(template.New("privacy_export_ready").Parse(privacyExportReadyTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render privacy export ready email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Data Export Is Ready")
+}
+
+// AccountDeletedData contains the data for the email confirming an account's deletion
+type AccountDeletedData struct {
+	CustomerName  string
+	CustomerEmail string
+}
+
+// SendAccountDeleted confirms to a customer that their account has been deleted. It's
+// sent once the account is gone, so it isn't logged to the email history, which would
+// keep the address the deletion removed.
+func (s *Service) SendAccountDeleted(data *AccountDeletedData) error {
+	html, err := RenderAccountDeletedEmail(data)
+	if err != nil {
+		return err
+	}
+
+	return s.Send(&Email{
+		To:      []string{data.CustomerEmail},
+		Subject: "Your account has been deleted - Logan's 3D Creations",
+		Body:    html,
+		IsHTML:  true,
+	})
+}
+
+// RenderAccountDeletedEmail renders the email confirming an account's deletion
+func RenderAccountDeletedEmail(data *AccountDeletedData) (string, error) {
+	tmpl := template.Must(template.New("account_deleted").Parse(accountDeletedTemplate))
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", fmt.Errorf("failed to render account deleted email content: %w", err)
+	}
+
+	return WrapEmailContent(content.String(), "Your Account Has Been Deleted")
+}
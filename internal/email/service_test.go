@@ -1,10 +1,18 @@
 package email
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
 	"testing"
 
+	"github.com/loganlanou/logans3d-v4/internal/settings"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/oklog/ulid/v2"
@@ -138,6 +146,89 @@ func TestCheckEmailPreference_OptedOut(t *testing.T) {
 	assert.False(t, canSend, "Should respect opt-out preference")
 }
 
+// TestCheckEmailPreference_ServiceEmails tests order, shipping and back in stock emails,
+// which are on until the customer turns them off
+func TestCheckEmailPreference_ServiceEmails(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	service := NewService(queries)
+	ctx := context.Background()
+
+	email := "service-emails@example.com"
+	for _, emailType := range []string{"order_updates", "shipping_updates", "back_in_stock"} {
+		canSend, err := service.CheckEmailPreference(ctx, email, emailType)
+		assert.NoError(t, err)
+		assert.True(t, canSend, "%s should default to TRUE when no record exists", emailType)
+	}
+
+	prefs, err := service.GetOrCreateEmailPreferences(ctx, email, nil)
+	require.NoError(t, err)
+
+	err = queries.UpdateEmailPreferences(ctx, db.UpdateEmailPreferencesParams{
+		ID:              prefs.ID,
+		Transactional:   sql.NullInt64{Int64: 1, Valid: true},
+		AbandonedCart:   sql.NullInt64{Int64: 1, Valid: true},
+		Promotional:     sql.NullInt64{Int64: 0, Valid: true},
+		Newsletter:      sql.NullInt64{Int64: 0, Valid: true},
+		ProductUpdates:  sql.NullInt64{Int64: 0, Valid: true},
+		ShippingUpdates: sql.NullInt64{Int64: 0, Valid: true},
+	})
+	require.NoError(t, err)
+
+	canSend, err := service.CheckEmailPreference(ctx, email, "shipping_updates")
+	assert.NoError(t, err)
+	assert.False(t, canSend, "Should respect opt-out")
+
+	canSend, err = service.CheckEmailPreference(ctx, email, "order_updates")
+	assert.NoError(t, err)
+	assert.True(t, canSend, "Preferences that weren't given are left as they were")
+
+	// Callers that only manage marketing opt-ins don't turn shipping updates back on
+	err = queries.UpdateEmailPreferences(ctx, db.UpdateEmailPreferencesParams{
+		ID:             prefs.ID,
+		Transactional:  sql.NullInt64{Int64: 1, Valid: true},
+		AbandonedCart:  sql.NullInt64{Int64: 1, Valid: true},
+		Promotional:    sql.NullInt64{Int64: 1, Valid: true},
+		Newsletter:     sql.NullInt64{Int64: 0, Valid: true},
+		ProductUpdates: sql.NullInt64{Int64: 0, Valid: true},
+	})
+	require.NoError(t, err)
+
+	canSend, err = service.CheckEmailPreference(ctx, email, "shipping_updates")
+	assert.NoError(t, err)
+	assert.False(t, canSend)
+}
+
+// TestSendPreorderShipped_OptedOut tests that a send path honors the recipient's
+// preference before anything is sent
+func TestSendPreorderShipped_OptedOut(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	service := NewService(queries)
+	ctx := context.Background()
+
+	email := "no-shipping@example.com"
+	prefs, err := service.GetOrCreateEmailPreferences(ctx, email, nil)
+	require.NoError(t, err)
+	err = queries.UpdateEmailPreferences(ctx, db.UpdateEmailPreferencesParams{
+		ID:              prefs.ID,
+		Transactional:   sql.NullInt64{Int64: 1, Valid: true},
+		AbandonedCart:   sql.NullInt64{Int64: 1, Valid: true},
+		Promotional:     sql.NullInt64{Int64: 0, Valid: true},
+		Newsletter:      sql.NullInt64{Int64: 0, Valid: true},
+		ProductUpdates:  sql.NullInt64{Int64: 0, Valid: true},
+		ShippingUpdates: sql.NullInt64{Int64: 0, Valid: true},
+	})
+	require.NoError(t, err)
+
+	err = service.SendPreorderShipped(&PreorderShippedData{OrderID: "order-1", CustomerEmail: email})
+	assert.ErrorIs(t, err, ErrOptedOut)
+}
+
 // TestGetOrCreateEmailPreferences_Creates tests creating new preferences
 func TestGetOrCreateEmailPreferences_Creates(t *testing.T) {
 	_, queries, cleanup, err := storage.NewTestDB()
@@ -200,3 +291,311 @@ func TestLogEmailSend_Success(t *testing.T) {
 
 	// Verify it was logged (would need to add a GetEmailHistory query to fully test)
 }
+
+func TestWrapEmailContent_FooterUsesSiteSettings(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+
+	NewService(queries)
+	ctx := context.Background()
+	require.NoError(t, queries.SetSiteConfig(ctx, db.SetSiteConfigParams{Key: "contact_email", Value: "orders@example.com"}))
+	require.NoError(t, queries.SetSiteConfig(ctx, db.SetSiteConfigParams{Key: "business_address", Value: "1 Main St\nCadott, WI 54727"}))
+	settings.For(queries).Invalidate()
+
+	html, err := WrapEmailContent("<p>Hello</p>", "Test")
+	require.NoError(t, err)
+	assert.Contains(t, html, "mailto:orders@example.com")
+	assert.Contains(t, html, "1 Main St, Cadott, WI 54727")
+	assert.NotContains(t, html, "prints@logans3dcreations.com")
+}
+
+func TestRenderAdminOrderEmail_CustomerNotes(t *testing.T) {
+	data := &OrderData{OrderID: "ORDER-1", CustomerName: "Jo", CustomerEmail: "jo@example.com"}
+
+	html, err := RenderAdminOrderEmail(data)
+	require.NoError(t, err)
+	assert.NotContains(t, html, "Customer Instructions")
+
+	data.CustomerNotes = "Leave at side door"
+	html, err = RenderAdminOrderEmail(data)
+	require.NoError(t, err)
+	assert.Contains(t, html, "Customer Instructions")
+	assert.Contains(t, html, "Leave at side door")
+}
+
+func TestRenderGiftShippedEmail_HasNoAmounts(t *testing.T) {
+	html, err := RenderGiftShippedEmail(&GiftShippedData{
+		OrderID:        "ORDER-1",
+		RecipientName:  "Sam",
+		RecipientEmail: "sam@example.com",
+		SenderName:     "Jo",
+		GiftMessage:    "Happy birthday!",
+		Items:          []GiftShippedItem{{ProductName: "Crystal Dragon", Quantity: 1}},
+		Carrier:        "USPS",
+		TrackingNumber: "9400100000000000000000",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Jo sent you something")
+	assert.Contains(t, html, "Happy birthday!")
+	assert.Contains(t, html, "9400100000000000000000")
+	assert.NotContains(t, html, "$")
+}
+
+func TestRenderGiftCardEmail(t *testing.T) {
+	html, err := RenderGiftCardEmail(&GiftCardData{
+		Code:           "7KQM-P2XD-9RWA-H4NE",
+		AmountCents:    5000,
+		RecipientName:  "Sam",
+		RecipientEmail: "sam@example.com",
+		SenderName:     "Jo",
+		Message:        "Happy birthday!",
+		ExpiresAt:      "March 1, 2027",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "$50.00 to Spend")
+	assert.Contains(t, html, "Jo sent you a gift card")
+	assert.Contains(t, html, "7KQM-P2XD-9RWA-H4NE")
+	assert.Contains(t, html, "Happy birthday!")
+	assert.Contains(t, html, "Use it by March 1, 2027")
+
+	html, err = RenderGiftCardEmail(&GiftCardData{Code: "7KQM-P2XD-9RWA-H4NE", AmountCents: 2500})
+	require.NoError(t, err)
+	assert.Contains(t, html, "here's your gift card")
+	assert.NotContains(t, html, "Use it by")
+}
+
+func TestRenderCheckoutExpiredEmail(t *testing.T) {
+	html, err := RenderCheckoutExpiredEmail(&CheckoutExpiredData{
+		CustomerName:  "Sam",
+		CustomerEmail: "sam@example.com",
+		Items:         []AbandonedCartItem{{ProductName: "Crystal Dragon", Quantity: 2, UnitPrice: 2500}},
+		ItemCount:     2,
+		CartValue:     5000,
+		CheckoutURL:   "https://www.logans3dcreations.com/cart/restore/abc123",
+	}, "unsub-token")
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam,")
+	assert.Contains(t, html, "2 items in your cart")
+	assert.Contains(t, html, "$50.00")
+	assert.Contains(t, html, `href="https://www.logans3dcreations.com/cart/restore/abc123"`)
+	assert.Contains(t, html, "unsub-token")
+}
+
+func TestRenderSavedCartEmail(t *testing.T) {
+	html, err := RenderSavedCartEmail(&SavedCartData{
+		CustomerEmail: "guest@example.com",
+		Items:         []AbandonedCartItem{{ProductName: "Crystal Dragon", Quantity: 1, UnitPrice: 2500}},
+		ItemCount:     1,
+		CartValue:     2500,
+		CartURL:       "https://www.logans3dcreations.com/cart/shared/abc123",
+		PromoCode:     "STAY5",
+	}, "unsub-token")
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi there,")
+	assert.Contains(t, html, "1 item back in your cart")
+	assert.Contains(t, html, "$25.00")
+	assert.Contains(t, html, "STAY5")
+	assert.Contains(t, html, `href="https://www.logans3dcreations.com/cart/shared/abc123"`)
+
+	html, err = RenderSavedCartEmail(&SavedCartData{CustomerName: "Sam", ItemCount: 2, CartURL: "https://www.logans3dcreations.com/cart/shared/abc123"}, "")
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam,")
+	assert.NotContains(t, html, "Use this code at checkout")
+}
+
+func TestBuildMessage_Attachments(t *testing.T) {
+	plain, err := buildMessage("shop@example.com", &Email{To: []string{"jo@example.com"}, Subject: "Hi", Body: "<p>Hello</p>", IsHTML: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(plain), "Content-Type: text/html; charset=\"utf-8\"\r\n\r\n<p>Hello</p>")
+
+	pdf := bytes.Repeat([]byte("%PDF-1.3 invoice "), 20)
+	raw, err := buildMessage("shop@example.com", &Email{
+		To:          []string{"jo@example.com"},
+		Subject:     "Order Confirmation",
+		Body:        "<p>Thanks</p>",
+		IsHTML:      true,
+		Attachments: []Attachment{{Filename: "invoice-01jabcde.pdf", ContentType: "application/pdf", Data: pdf}},
+	})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "Order Confirmation", msg.Header.Get("Subject"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	body, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=\"utf-8\"", body.Header.Get("Content-Type"))
+	html, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Thanks</p>", string(html))
+
+	attachment, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "invoice-01jabcde.pdf", attachment.FileName())
+	assert.Equal(t, "application/pdf", attachment.Header.Get("Content-Type"))
+	encoded, err := io.ReadAll(attachment)
+	require.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, pdf, decoded)
+
+	_, err = parts.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRenderOrderRefundedEmail(t *testing.T) {
+	html, err := RenderOrderRefundedEmail(&OrderRefundedData{
+		OrderID:      "order-1",
+		CustomerName: "Sam",
+		AmountCents:  2500,
+		Items:        []OrderRefundedItem{{ProductName: "Crystal Dragon", Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam,")
+	assert.Contains(t, html, "$25.00")
+	assert.Contains(t, html, "PARTIAL REFUND")
+	assert.Contains(t, html, "Crystal Dragon")
+	assert.NotContains(t, html, "at the rate you paid")
+
+	html, err = RenderOrderRefundedEmail(&OrderRefundedData{OrderID: "order-1", AmountCents: 5000, FullRefund: true, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Contains(t, html, "ORDER REFUNDED")
+	assert.Contains(t, html, "It goes back in EUR")
+	assert.NotContains(t, html, "Refunded Items")
+}
+
+func TestRenderOrderCancelledEmail(t *testing.T) {
+	html, err := RenderOrderCancelledEmail(&OrderCancelledData{
+		OrderID:      "order-1",
+		CustomerName: "Sam",
+		Items:        []OrderCancelledItem{{ProductName: "Crystal Dragon", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam, order #order-1 has been cancelled")
+	assert.Contains(t, html, "Crystal Dragon")
+	assert.Contains(t, html, "Qty 2")
+	assert.Contains(t, html, "your refund is sent separately")
+
+	html, err = RenderOrderCancelledEmail(&OrderCancelledData{OrderID: "order-1", CustomerName: "Sam", ByCustomer: true, RefundCents: 4250})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam, as you asked, order #order-1 has been cancelled")
+	assert.Contains(t, html, "We've refunded <strong>$42.50</strong>")
+	assert.NotContains(t, html, "sent separately")
+}
+
+func TestRenderAdminOrderCancelledEmail(t *testing.T) {
+	html, err := RenderAdminOrderCancelledEmail(&OrderCancelledData{
+		OrderID:       "order-1",
+		CustomerName:  "Sam",
+		CustomerEmail: "sam@example.com",
+		ByCustomer:    true,
+		RefundCents:   4250,
+		Items:         []OrderCancelledItem{{ProductName: "Crystal Dragon", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Sam cancelled it from their account")
+	assert.Contains(t, html, "$42.50 through Stripe")
+	assert.Contains(t, html, "/admin/orders/order-1")
+}
+
+func TestRenderOrderShipmentEmail(t *testing.T) {
+	html, err := RenderOrderShipmentEmail(&OrderShipmentData{
+		OrderID:        "order-1",
+		CustomerName:   "Sam",
+		Items:          []OrderShipmentItem{{ProductName: "Crystal Dragon", Quantity: 1}},
+		Carrier:        "USPS",
+		TrackingNumber: "9400100000000000000000",
+		TrackingURL:    "https://tools.usps.com/go/TrackConfirmAction?tLabels=9400100000000000000000",
+		Remaining:      []OrderShipmentItem{{ProductName: "Stone Golem", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Part of Your Order Is On Its Way!")
+	assert.Contains(t, html, "The rest will follow in another box.")
+	assert.Contains(t, html, ">9400100000000000000000</a>")
+	assert.Contains(t, html, "Crystal Dragon")
+	assert.Contains(t, html, "Still To Come")
+	assert.Contains(t, html, "Stone Golem")
+
+	html, err = RenderOrderShipmentEmail(&OrderShipmentData{
+		OrderID:      "order-1",
+		CustomerName: "Sam",
+		Items:        []OrderShipmentItem{{ProductName: "Stone Golem", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam, order #order-1 has shipped.")
+	assert.NotContains(t, html, "Still To Come")
+	assert.NotContains(t, html, "Tracking Number:")
+}
+
+func TestRenderOrderPaymentRequestEmail(t *testing.T) {
+	html, err := RenderOrderPaymentRequestEmail(&OrderPaymentRequestData{
+		OrderID:      "order-1",
+		CustomerName: "Sam",
+		Description:  "Added 2 × Crystal Dragon",
+		AmountCents:  5400,
+		Items:        []OrderPaymentRequestItem{{ProductName: "Crystal Dragon", Quantity: 2}},
+		PaymentURL:   "https://buy.stripe.com/test_abc",
+		Note:         "As discussed on the phone",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "comes to $54.00 more")
+	assert.Contains(t, html, `href="https://buy.stripe.com/test_abc"`)
+	assert.Contains(t, html, "Added Items")
+	assert.Contains(t, html, "As discussed on the phone")
+
+	html, err = RenderOrderPaymentRequestEmail(&OrderPaymentRequestData{
+		OrderID:     "order-1",
+		Description: "Shipping $8.00 to $12.50",
+		AmountCents: 450,
+		PaymentURL:  "https://buy.stripe.com/test_def",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi, we've made a change")
+	assert.NotContains(t, html, "Added Items")
+}
+
+func TestRenderOrderShipmentDeliveredEmail(t *testing.T) {
+	html, err := RenderOrderShipmentDeliveredEmail(&OrderShipmentData{
+		OrderID:        "order-1",
+		CustomerName:   "Sam",
+		Items:          []OrderShipmentItem{{ProductName: "Crystal Dragon", Quantity: 1}},
+		Carrier:        "USPS",
+		TrackingNumber: "9400100000000000000000",
+		Remaining:      []OrderShipmentItem{{ProductName: "Stone Golem", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "A Box of Your Order Has Arrived!")
+	assert.Contains(t, html, "The rest is still on its way.")
+	assert.Contains(t, html, "Crystal Dragon")
+	assert.Contains(t, html, "Still To Come")
+	assert.Contains(t, html, "Stone Golem")
+
+	html, err = RenderOrderShipmentDeliveredEmail(&OrderShipmentData{
+		OrderID:      "order-1",
+		CustomerName: "Sam",
+		Items:        []OrderShipmentItem{{ProductName: "Stone Golem", Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam, the last of order #order-1 has been delivered.")
+	assert.NotContains(t, html, "Still To Come")
+}
+
+func TestRenderPrivacyEmails(t *testing.T) {
+	html, err := RenderPrivacyExportReadyEmail(&PrivacyExportData{CustomerName: "Sam", ExpiresOn: "October 23, 2026"})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi Sam, the copy of your data you asked for is ready.")
+	assert.Contains(t, html, "until October 23, 2026")
+	assert.Contains(t, html, "/account/privacy")
+
+	html, err = RenderAccountDeletedEmail(&AccountDeletedData{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Hi, as you asked, we've deleted your Logan's 3D Creations account.")
+}
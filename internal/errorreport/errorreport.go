@@ -0,0 +1,313 @@
+// Package errorreport captures server errors and panics with their stack, request and
+// signed-in user. Captured errors are grouped by fingerprint and kept in a ring buffer
+// for the developer tools, and sent to a Sentry-compatible service when a DSN is set.
+package errorreport
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
+)
+
+// DefaultBufferSize is how many captured errors the ring buffer keeps
+const DefaultBufferSize = 200
+
+// fingerprintFrames is how many of the app's own stack frames decide an error's group
+const fingerprintFrames = 5
+
+// Frame is one stack frame, innermost first
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+	// InApp is set for this module's own code, as opposed to the standard library,
+	// Echo and other dependencies
+	InApp bool
+}
+
+// Event is one captured error
+type Event struct {
+	ID          string
+	Fingerprint string
+	At          time.Time
+	Type        string
+	Message     string
+	Panic       bool
+	Stack       []Frame
+
+	Method    string
+	Route     string
+	Path      string
+	Query     string
+	Status    int
+	IP        string
+	UserAgent string
+	UserID    string
+	// TraceID is the request's trace, to look up at /dev/traces
+	TraceID string
+}
+
+// Group is the captured errors sharing a fingerprint, as far back as the buffer goes
+type Group struct {
+	Fingerprint string
+	Count       int
+	FirstSeen   time.Time
+	Latest      Event
+}
+
+// Config sets up a Reporter
+type Config struct {
+	// DSN of a Sentry-compatible project; empty keeps errors local
+	DSN         string
+	Environment string
+	Release     string
+	BufferSize  int
+}
+
+// Reporter captures errors into the ring buffer and, with a DSN, sends them on
+type Reporter struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	total  int64
+
+	sentry *sentryTransport
+}
+
+// New makes a Reporter. It fails only on a DSN it can't parse.
+func New(config Config) (*Reporter, error) {
+	size := config.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	r := &Reporter{events: make([]Event, 0, size)}
+	if config.DSN != "" {
+		transport, err := newSentryTransport(config.DSN, config.Environment, config.Release)
+		if err != nil {
+			return nil, err
+		}
+		r.sentry = transport
+	}
+	return r, nil
+}
+
+// Capture records an event and returns its ID, which the error page shows as a
+// reference. A nil Reporter captures nothing.
+func (r *Reporter) Capture(event Event) string {
+	if r == nil {
+		return ""
+	}
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	if event.Fingerprint == "" {
+		event.Fingerprint = Fingerprint(event)
+	}
+
+	r.mu.Lock()
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, event)
+	} else {
+		r.events[r.next] = event
+	}
+	r.next = (r.next + 1) % cap(r.events)
+	r.total++
+	r.mu.Unlock()
+
+	attrs := []any{
+		"event_id", event.ID,
+		"fingerprint", event.Fingerprint,
+		"type", event.Type,
+		"error", event.Message,
+		"method", event.Method,
+		"path", event.Path,
+		"status", event.Status,
+	}
+	if event.UserID != "" {
+		attrs = append(attrs, "user_id", event.UserID)
+	}
+	if event.TraceID != "" {
+		attrs = append(attrs, "trace_id", event.TraceID)
+	}
+	if event.Panic && len(event.Stack) > 0 {
+		attrs = append(attrs, "at", fmt.Sprintf("%s (%s:%d)", event.Stack[0].Function, event.Stack[0].File, event.Stack[0].Line))
+	}
+	slog.Error("captured server error", attrs...)
+
+	if r.sentry != nil {
+		r.sentry.send(event)
+	}
+	return event.ID
+}
+
+// Recent lists buffered events, newest first
+func (r *Reporter) Recent() []Event {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]Event, 0, len(r.events))
+	for i := 1; i <= len(r.events); i++ {
+		events = append(events, r.events[(r.next-i+len(r.events))%len(r.events)])
+	}
+	return events
+}
+
+// Groups folds the buffered events by fingerprint, most recently seen first
+func (r *Reporter) Groups() []Group {
+	var groups []*Group
+	byFingerprint := make(map[string]*Group)
+	for _, event := range r.Recent() {
+		group, ok := byFingerprint[event.Fingerprint]
+		if !ok {
+			group = &Group{Fingerprint: event.Fingerprint, Latest: event}
+			byFingerprint[event.Fingerprint] = group
+			groups = append(groups, group)
+		}
+		group.Count++
+		group.FirstSeen = event.At
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Latest.At.After(result[j].Latest.At) })
+	return result
+}
+
+// Total is how many events have been captured since startup, including those the
+// buffer has since dropped
+func (r *Reporter) Total() int64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+// Remote is the host events are sent to, or "" when they're only kept locally
+func (r *Reporter) Remote() string {
+	if r == nil || r.sentry == nil {
+		return ""
+	}
+	return r.sentry.host
+}
+
+// Reference is the short form of an event ID shown to customers on the error page,
+// which is enough to find the event again at /dev/errors
+func Reference(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+// Fingerprint groups events: panics by the app frames they came through, other
+// errors by their message with IDs and numbers taken out, so the same failure on
+// different orders lands in one group
+func Fingerprint(event Event) string {
+	parts := []string{event.Type, event.Route}
+
+	var frames int
+	for _, frame := range event.Stack {
+		if frame.InApp && frames < fingerprintFrames {
+			parts = append(parts, frame.Function)
+			frames++
+		}
+	}
+	if frames == 0 {
+		message := uuidPattern.ReplaceAllString(event.Message, "<id>")
+		parts = append(parts, numberPattern.ReplaceAllString(message, "<n>"))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// RequestEvent builds the event for an error returned while handling a request
+func RequestEvent(c echo.Context, err error, status int, userID string) Event {
+	req := c.Request()
+	event := Event{
+		Type:      errorType(err),
+		Message:   errorMessage(err),
+		Method:    req.Method,
+		Route:     c.Path(),
+		Path:      req.URL.Path,
+		Query:     scrubQuery(req.URL.Query()),
+		Status:    status,
+		IP:        c.RealIP(),
+		UserAgent: req.UserAgent(),
+		UserID:    userID,
+	}
+	if span := tracing.SpanFromContext(req.Context()); span != nil {
+		event.TraceID = span.TraceID().String()
+	}
+
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		event.Panic = true
+		event.Type = fmt.Sprintf("%T", panicErr.Value)
+		event.Message = fmt.Sprint(panicErr.Value)
+		event.Stack = panicErr.Stack
+	}
+	return event
+}
+
+// errorType names the innermost error's type, since the wrappers around it are
+// usually just fmt.Errorf
+func errorType(err error) string {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Internal != nil {
+		err = httpErr.Internal
+	}
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+func errorMessage(err error) string {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message := fmt.Sprint(httpErr.Message)
+		if httpErr.Internal != nil {
+			message += ": " + httpErr.Internal.Error()
+		}
+		return message
+	}
+	return err.Error()
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package errorreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReporterRingBuffer(t *testing.T) {
+	reporter, err := New(Config{BufferSize: 3})
+	require.NoError(t, err)
+
+	for i := 1; i <= 4; i++ {
+		id := reporter.Capture(Event{Type: "*errors.errorString", Message: fmt.Sprintf("failure %d", i), Route: "/orders/:id"})
+		assert.Len(t, id, 32)
+		assert.Equal(t, id[:8], Reference(id))
+	}
+
+	recent := reporter.Recent()
+	require.Len(t, recent, 3, "the oldest event is dropped")
+	assert.Equal(t, "failure 4", recent[0].Message)
+	assert.Equal(t, "failure 2", recent[2].Message)
+	assert.Equal(t, int64(4), reporter.Total())
+
+	groups := reporter.Groups()
+	require.Len(t, groups, 1, "messages differing only by a number share a group")
+	assert.Equal(t, 3, groups[0].Count)
+	assert.Equal(t, "failure 4", groups[0].Latest.Message)
+	assert.Equal(t, recent[2].At, groups[0].FirstSeen)
+}
+
+func TestFingerprint(t *testing.T) {
+	order := Event{Type: "*errors.errorString", Route: "/admin/orders/:id", Message: "order 6f1c2a0e-8f7b-4c1d-9a2b-1234567890ab not found"}
+	other := order
+	other.Message = "order 0a6b9d7e-1c2d-4e5f-8a9b-abcdefabcdef not found"
+	assert.Equal(t, Fingerprint(order), Fingerprint(other))
+
+	other.Route = "/admin/products/:id"
+	assert.NotEqual(t, Fingerprint(order), Fingerprint(other))
+
+	// Panics group by where they happened, not by message
+	panicA := Event{Type: "runtime.Error", Message: "index out of range [3]", Stack: []Frame{
+		{Function: "runtime.goPanicIndex"},
+		{Function: modulePrefix + "service.(*Service).handleCart", InApp: true},
+	}}
+	panicB := panicA
+	panicB.Message = "index out of range [7]"
+	assert.Equal(t, Fingerprint(panicA), Fingerprint(panicB))
+}
+
+func TestRecoverCapturesPanic(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/shop?token=abc&page=2", nil), httptest.NewRecorder())
+	c.SetPath("/shop")
+
+	err := Recover()(func(echo.Context) error {
+		var items []string
+		_ = items[3]
+		return nil
+	})(c)
+
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr))
+	require.NotEmpty(t, panicErr.Stack)
+	assert.Contains(t, panicErr.Stack[0].Function, "TestRecoverCapturesPanic", "the stack starts where the panic was raised")
+	assert.True(t, panicErr.Stack[0].InApp)
+
+	event := RequestEvent(c, err, http.StatusInternalServerError, "user-1")
+	assert.True(t, event.Panic)
+	assert.Equal(t, "runtime.boundsError", event.Type)
+	assert.Equal(t, "/shop", event.Route)
+	assert.Equal(t, "page=2&token=[filtered]", event.Query)
+	assert.Equal(t, "user-1", event.UserID)
+	assert.Empty(t, event.TraceID, "the request wasn't traced")
+}
+
+func TestRequestEventUnwrapsErrors(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/cart/add", nil), httptest.NewRecorder())
+
+	err := echo.NewHTTPError(http.StatusInternalServerError, "Failed to add item to cart").SetInternal(fmt.Errorf("insert: %w", errSentinel))
+	tracer, tracerErr := tracing.New(tracing.Config{})
+	require.NoError(t, tracerErr)
+	ctx, span := tracer.Start(c.Request().Context(), tracing.ComponentHTTP, "POST /api/cart/add", tracing.KindServer)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	event := RequestEvent(c, err, http.StatusInternalServerError, "")
+	assert.Equal(t, span.TraceID().String(), event.TraceID)
+	assert.Equal(t, "*errors.errorString", event.Type)
+	assert.Equal(t, "Failed to add item to cart: insert: database is locked", event.Message)
+	assert.False(t, event.Panic)
+}
+
+var errSentinel = errors.New("database is locked")
+
+func TestSentryTransport(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/errors/api/42/store/", r.URL.Path)
+		authHeader = r.Header.Get("X-Sentry-Auth")
+		var payload map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://publickey@", 1) + "/errors/42"
+	reporter, err := New(Config{DSN: dsn, Environment: "production", Release: "abc123"})
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimPrefix(server.URL, "http://"), reporter.Remote())
+
+	id := reporter.Capture(Event{
+		Type:    "runtime.boundsError",
+		Message: "index out of range",
+		Panic:   true,
+		Method:  http.MethodGet,
+		Route:   "/cart",
+		UserID:  "user-1",
+		Stack: []Frame{
+			{Function: "inner", File: "a.go", Line: 1, InApp: true},
+			{Function: "outer", File: "b.go", Line: 2},
+		},
+	})
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, id, payload["event_id"])
+		assert.Equal(t, "production", payload["environment"])
+		assert.Equal(t, "GET /cart", payload["transaction"])
+		assert.Equal(t, map[string]any{"id": "user-1"}, payload["user"])
+
+		exception := payload["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+		assert.Equal(t, false, exception["mechanism"].(map[string]any)["handled"])
+		frames := exception["stacktrace"].(map[string]any)["frames"].([]any)
+		assert.Equal(t, "outer", frames[0].(map[string]any)["function"], "frames are sent outermost first")
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not sent")
+	}
+	assert.Contains(t, authHeader, "sentry_key=publickey")
+
+	_, err = New(Config{DSN: "https://sentry.example.com/42"})
+	assert.Error(t, err, "a DSN without a key is rejected")
+}
@@ -0,0 +1,108 @@
+package errorreport
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// modulePrefix marks this module's own functions in a stack
+const modulePrefix = "github.com/loganlanou/logans3d-v4/"
+
+const maxFrames = 50
+
+// PanicError is a recovered panic with the stack it was raised on
+type PanicError struct {
+	Value any
+	Stack []Frame
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover turns a handler panic into a PanicError, which the error handler reports
+// and answers with a 500. It replaces Echo's Recover, whose stack is only logged.
+func Recover() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					// net/http uses this panic to abort a response on purpose
+					if recovered == http.ErrAbortHandler {
+						panic(recovered)
+					}
+					err = &PanicError{Value: recovered, Stack: panicStack()}
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// panicStack is the stack of the goroutine that's panicking, from where the panic
+// was raised outward. It's called from the deferred recover, so the frames above
+// runtime.gopanic are the recovery itself and are dropped.
+func panicStack() []Frame {
+	pcs := make([]uintptr, maxFrames+16)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			stack = stack[:0]
+		} else if len(stack) < maxFrames {
+			stack = append(stack, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+				InApp:    strings.HasPrefix(frame.Function, modulePrefix),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	// The frames right under gopanic are the runtime's own, for panics it raises
+	for len(stack) > 0 && strings.HasPrefix(stack[0].Function, "runtime.") {
+		stack = stack[1:]
+	}
+	return stack
+}
+
+// sensitiveParams are query parameters whose values never leave the server: links
+// carrying tokens, codes and addresses
+var sensitiveParams = []string{"token", "key", "secret", "password", "code", "email", "session", "signature", "sig"}
+
+// scrubQuery keeps a query's parameter names but blanks the values of sensitive ones
+func scrubQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := url.QueryEscape(query.Get(key))
+		lower := strings.ToLower(key)
+		for _, sensitive := range sensitiveParams {
+			if strings.Contains(lower, sensitive) {
+				value = "[filtered]"
+				break
+			}
+		}
+		parts = append(parts, url.QueryEscape(key)+"="+value)
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,194 @@
+package errorreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sentryQueueSize is how many events can wait to be sent; more are dropped rather
+// than slowing requests down while the service is unreachable
+const sentryQueueSize = 64
+
+// sentryTransport posts events to a Sentry-compatible store endpoint, such as Sentry
+// itself or GlitchTip, one at a time from a background goroutine
+type sentryTransport struct {
+	host        string
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	client      *http.Client
+
+	queue chan Event
+}
+
+// newSentryTransport parses a DSN like https://<key>@<host>/<project-id>
+func newSentryTransport(dsn, environment, release string) (*sentryTransport, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid error reporting DSN: %w", err)
+	}
+	key := u.User.Username()
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if u.Scheme == "" || u.Host == "" || key == "" || project == "" {
+		return nil, fmt.Errorf("invalid error reporting DSN: want https://<key>@<host>/<project>")
+	}
+
+	t := &sentryTransport{
+		host:        u.Host,
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:slash], project),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=logans3d/1.0, sentry_key=%s", key),
+		environment: environment,
+		release:     release,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan Event, sentryQueueSize),
+	}
+	go t.run()
+	return t, nil
+}
+
+func (t *sentryTransport) send(event Event) {
+	select {
+	case t.queue <- event:
+	default:
+		slog.Warn("error reporting queue is full, dropping event", "event_id", event.ID)
+	}
+}
+
+func (t *sentryTransport) run() {
+	for event := range t.queue {
+		if err := t.post(event); err != nil {
+			slog.Warn("failed to send error report", "error", err, "event_id", event.ID, "host", t.host)
+		}
+	}
+}
+
+func (t *sentryTransport) post(event Event) error {
+	body, err := json.Marshal(t.payload(event))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", t.auth)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error reporting service returned %s", resp.Status)
+	}
+	return nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Fingerprint []string          `json:"fingerprint"`
+	Tags        map[string]string `json:"tags"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+	Request sentryRequest `json:"request"`
+	User    *sentryUser   `json:"user,omitempty"`
+}
+
+type sentryException struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Mechanism struct {
+		Type    string `json:"type"`
+		Handled bool   `json:"handled"`
+	} `json:"mechanism"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryRequest struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+type sentryUser struct {
+	ID        string `json:"id"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+// payload shapes an event for the store endpoint. Our fingerprint is sent so the
+// service groups events the same way the developer tools do.
+func (t *sentryTransport) payload(event Event) sentryEvent {
+	payload := sentryEvent{
+		EventID:     event.ID,
+		Timestamp:   event.At.UTC().Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Environment: t.environment,
+		Release:     t.release,
+		Transaction: strings.TrimSpace(event.Method + " " + event.Route),
+		Fingerprint: []string{event.Fingerprint},
+		Tags:        map[string]string{"status": fmt.Sprintf("%d", event.Status)},
+		Request: sentryRequest{
+			Method:      event.Method,
+			URL:         event.Path,
+			QueryString: event.Query,
+		},
+	}
+	if event.TraceID != "" {
+		payload.Tags["trace_id"] = event.TraceID
+	}
+	if event.UserAgent != "" {
+		payload.Request.Headers = map[string]string{"User-Agent": event.UserAgent}
+	}
+	if event.UserID != "" {
+		payload.User = &sentryUser{ID: event.UserID, IPAddress: event.IP}
+	}
+
+	exception := sentryException{Type: event.Type, Value: event.Message}
+	exception.Mechanism.Type = "echo"
+	exception.Mechanism.Handled = !event.Panic
+	if len(event.Stack) > 0 {
+		// Sentry lists frames outermost first
+		frames := make([]sentryFrame, len(event.Stack))
+		for i, frame := range event.Stack {
+			frames[len(frames)-1-i] = sentryFrame{
+				Function: frame.Function,
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+				InApp:    frame.InApp,
+			}
+		}
+		exception.Stacktrace = &sentryStacktrace{Frames: frames}
+	}
+	payload.Exception.Values = []sentryException{exception}
+	return payload
+}
@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFeed() Feed {
+	published := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+	return Feed{
+		Title:       "Logan's 3D Creations Blog",
+		Description: "Print notes & news",
+		Author:      "Logan's 3D Creations",
+		Link:        "https://example.com/blog",
+		FeedURL:     "https://example.com/blog/feed.xml",
+		Entries: []Entry{{
+			ID:        "https://example.com/blog/tuning-pla",
+			Title:     "Tuning PLA <fast>",
+			Link:      "https://example.com/blog/tuning-pla",
+			Author:    "Logan",
+			Summary:   "Speed & quality",
+			HTML:      "<p>Use <strong>0.2mm</strong> layers]]> carefully</p>",
+			Published: published,
+			Updated:   published.Add(48 * time.Hour),
+		}},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	out, err := RSS(testFeed())
+	require.NoError(t, err)
+	doc := string(out)
+
+	assert.True(t, strings.HasPrefix(doc, xml.Header))
+	assert.Contains(t, doc, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"`)
+	assert.Contains(t, doc, `<atom:link href="https://example.com/blog/feed.xml" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, doc, "<title>Tuning PLA &lt;fast&gt;</title>")
+	assert.Contains(t, doc, `<guid isPermaLink="true">https://example.com/blog/tuning-pla</guid>`)
+	assert.Contains(t, doc, "<dc:creator>Logan</dc:creator>")
+	assert.Contains(t, doc, "<pubDate>Tue, 10 Mar 2026 09:30:00 +0000</pubDate>")
+	assert.Contains(t, doc, "<lastBuildDate>Thu, 12 Mar 2026 09:30:00 +0000</lastBuildDate>")
+
+	// The content must survive a round trip even when it contains a CDATA terminator
+	var parsed struct {
+		Channel struct {
+			Items []struct {
+				Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(out, &parsed))
+	require.Len(t, parsed.Channel.Items, 1)
+	assert.Equal(t, testFeed().Entries[0].HTML, parsed.Channel.Items[0].Content)
+}
+
+func TestAtom(t *testing.T) {
+	out, err := Atom(testFeed())
+	require.NoError(t, err)
+	doc := string(out)
+
+	assert.Contains(t, doc, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, doc, "<updated>2026-03-12T09:30:00Z</updated>")
+	assert.Contains(t, doc, "<published>2026-03-10T09:30:00Z</published>")
+	assert.Contains(t, doc, `<link href="https://example.com/blog/feed.xml" rel="self" type="application/atom+xml"></link>`)
+	assert.Contains(t, doc, `<content type="html">&lt;p&gt;Use &lt;strong&gt;0.2mm&lt;/strong&gt;`)
+	assert.Contains(t, doc, "<author>\n      <name>Logan</name>")
+}
+
+func TestEmptyFeed(t *testing.T) {
+	f := testFeed()
+	f.Entries = nil
+
+	out, err := RSS(f)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "<item>")
+	assert.NotContains(t, string(out), "lastBuildDate")
+
+	_, err = Atom(f)
+	require.NoError(t, err)
+}
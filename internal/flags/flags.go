@@ -0,0 +1,204 @@
+// Package flags evaluates feature flags stored in the database, so a risky feature
+// can be switched on per environment and rolled out to a share of visitors instead of
+// shipping to everyone at once. Flags are cached in memory and reloaded after a short
+// TTL or when an admin changes one.
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Flags checked in code. A key with no row in feature_flags is off.
+const (
+	AIOGImages             = "ai_og_images"
+	CheckoutAddOns         = "checkout_add_ons"
+	GuestCheckout          = "guest_checkout"
+	PaymentElementCheckout = "payment_element_checkout"
+)
+
+// DefaultTTL is how long flags are cached before being reloaded. Changes made from
+// the admin page invalidate the cache straight away; this bounds how long other
+// processes keep a stale answer.
+const DefaultTTL = 30 * time.Second
+
+// Flag is one feature flag's settings
+type Flag struct {
+	Key         string
+	Description string
+	Enabled     bool
+	// Environments the flag is on in; empty means every environment
+	Environments []string
+	// RolloutPercent is the share of visitors, 0-100, the flag is on for
+	RolloutPercent int
+}
+
+// FromDB converts a feature_flags row
+func FromDB(row db.FeatureFlag) Flag {
+	return Flag{
+		Key:            row.Key,
+		Description:    row.Description,
+		Enabled:        row.Enabled,
+		Environments:   ParseEnvironments(row.Environments),
+		RolloutPercent: int(row.RolloutPercent),
+	}
+}
+
+// EnabledFor reports whether the flag is on for a visitor in an environment. The
+// subject identifies the visitor (a user or session ID); without one, only a full
+// rollout applies.
+func (f Flag) EnabledFor(environment, subject string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if len(f.Environments) > 0 && !slices.Contains(f.Environments, strings.ToLower(environment)) {
+		return false
+	}
+	if f.RolloutPercent >= 100 {
+		return true
+	}
+	if f.RolloutPercent <= 0 || subject == "" {
+		return false
+	}
+	return Bucket(f.Key, subject) < f.RolloutPercent
+}
+
+// Describe summarizes the flag's settings for the audit history
+func (f Flag) Describe() string {
+	state := "off"
+	if f.Enabled {
+		state = "on"
+	}
+	environments := "all environments"
+	if len(f.Environments) > 0 {
+		environments = strings.Join(f.Environments, ", ")
+	}
+	return fmt.Sprintf("%s · %s · %d%%", state, environments, f.RolloutPercent)
+}
+
+// Bucket places a subject in 0-99 for a flag. Hashing the key with the subject keeps
+// a visitor's bucket stable for one flag while spreading them differently across
+// flags, so the same visitors aren't first in line for every rollout.
+func Bucket(key, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return int(h.Sum32() % 100)
+}
+
+// ParseEnvironments reads a comma-separated environment list, dropping blanks and
+// duplicates
+func ParseEnvironments(s string) []string {
+	var environments []string
+	for _, part := range strings.Split(s, ",") {
+		environment := strings.ToLower(strings.TrimSpace(part))
+		if environment != "" && !slices.Contains(environments, environment) {
+			environments = append(environments, environment)
+		}
+	}
+	return environments
+}
+
+// FormatEnvironments is the stored form of an environment list
+func FormatEnvironments(environments []string) string {
+	return strings.Join(environments, ",")
+}
+
+// Loader fetches every flag
+type Loader func(ctx context.Context) ([]Flag, error)
+
+// Store answers flag checks for one environment from a cached copy of the flags. A
+// nil *Store has every flag off.
+type Store struct {
+	load        Loader
+	environment string
+	ttl         time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	flags    map[string]Flag
+	loadedAt time.Time
+}
+
+// NewStore returns a store that loads flags from the database
+func NewStore(queries *db.Queries, environment string, ttl time.Duration) *Store {
+	return newStore(func(ctx context.Context) ([]Flag, error) {
+		rows, err := queries.ListFeatureFlags(ctx)
+		if err != nil {
+			return nil, err
+		}
+		flags := make([]Flag, 0, len(rows))
+		for _, row := range rows {
+			flags = append(flags, FromDB(row))
+		}
+		return flags, nil
+	}, environment, ttl)
+}
+
+func newStore(load Loader, environment string, ttl time.Duration) *Store {
+	return &Store{
+		load:        load,
+		environment: environment,
+		ttl:         ttl,
+		now:         time.Now,
+	}
+}
+
+// Environment is the environment flags are evaluated for
+func (s *Store) Environment() string {
+	if s == nil {
+		return ""
+	}
+	return s.environment
+}
+
+// Enabled reports whether a flag is on for a subject
+func (s *Store) Enabled(ctx context.Context, key, subject string) bool {
+	if s == nil {
+		return false
+	}
+	flag, ok := s.flag(ctx, key)
+	return ok && flag.EnabledFor(s.environment, subject)
+}
+
+// Invalidate makes the next check reload the flags
+func (s *Store) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+func (s *Store) flag(ctx context.Context, key string) (Flag, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.flags == nil || s.now().Sub(s.loadedAt) >= s.ttl {
+		flags, err := s.load(ctx)
+		// Keep answering from the last good copy until the next reload when the
+		// database is unavailable
+		if err != nil {
+			slog.Error("failed to load feature flags", "error", err)
+		} else {
+			s.flags = make(map[string]Flag, len(flags))
+			for _, flag := range flags {
+				s.flags[flag.Key] = flag
+			}
+		}
+		s.loadedAt = s.now()
+	}
+
+	flag, ok := s.flags[key]
+	return flag, ok
+}
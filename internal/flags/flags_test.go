@@ -0,0 +1,126 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagEnabledFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		flag        Flag
+		environment string
+		subject     string
+		want        bool
+	}{
+		{"disabled", Flag{Key: "x", RolloutPercent: 100}, "production", "user-1", false},
+		{"everywhere", Flag{Key: "x", Enabled: true, RolloutPercent: 100}, "production", "user-1", true},
+		{"listed environment", Flag{Key: "x", Enabled: true, Environments: []string{"staging", "production"}, RolloutPercent: 100}, "Production", "", true},
+		{"unlisted environment", Flag{Key: "x", Enabled: true, Environments: []string{"development"}, RolloutPercent: 100}, "production", "user-1", false},
+		{"zero rollout", Flag{Key: "x", Enabled: true}, "production", "user-1", false},
+		{"partial rollout without a visitor", Flag{Key: "x", Enabled: true, RolloutPercent: 99}, "production", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.flag.EnabledFor(tt.environment, tt.subject))
+		})
+	}
+}
+
+func TestPartialRollout(t *testing.T) {
+	flag := Flag{Key: "new_checkout", Enabled: true, RolloutPercent: 25}
+
+	on := 0
+	for i := range 1000 {
+		subject := fmt.Sprintf("session-%d", i)
+		if flag.EnabledFor("production", subject) {
+			on++
+			// Growing the rollout never turns a visitor back off
+			wider := flag
+			wider.RolloutPercent = 50
+			assert.True(t, wider.EnabledFor("production", subject))
+		}
+	}
+	assert.InDelta(t, 250, on, 50)
+
+	assert.Equal(t, Bucket("new_checkout", "session-1"), Bucket("new_checkout", "session-1"))
+}
+
+func TestParseEnvironments(t *testing.T) {
+	assert.Equal(t, []string{"production", "staging"}, ParseEnvironments(" Production, staging,,production "))
+	assert.Nil(t, ParseEnvironments(""))
+	assert.Equal(t, "production,staging", FormatEnvironments([]string{"production", "staging"}))
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, "on · all environments · 100%", Flag{Enabled: true, RolloutPercent: 100}.Describe())
+	assert.Equal(t, "off · production · 25%", Flag{Environments: []string{"production"}, RolloutPercent: 25}.Describe())
+}
+
+func TestStoreCachesUntilInvalidated(t *testing.T) {
+	loads := 0
+	enabled := true
+	var loadErr error
+	store := newStore(func(ctx context.Context) ([]Flag, error) {
+		loads++
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return []Flag{{Key: "beta", Enabled: enabled, RolloutPercent: 100}}, nil
+	}, "production", time.Minute)
+	now := time.Date(2026, 2, 4, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.True(t, store.Enabled(ctx, "beta", ""))
+	assert.False(t, store.Enabled(ctx, "missing", ""))
+	assert.Equal(t, 1, loads)
+
+	enabled = false
+	assert.True(t, store.Enabled(ctx, "beta", ""), "cached until the TTL passes")
+	store.Invalidate()
+	assert.False(t, store.Enabled(ctx, "beta", ""))
+	assert.Equal(t, 2, loads)
+
+	// A failed reload keeps the last good flags
+	loadErr = errors.New("database is locked")
+	now = now.Add(time.Minute)
+	assert.False(t, store.Enabled(ctx, "beta", ""))
+	assert.Equal(t, 3, loads)
+}
+
+func TestNilStore(t *testing.T) {
+	var store *Store
+	assert.False(t, store.Enabled(context.Background(), "beta", "user-1"))
+	store.Invalidate()
+}
+
+func TestMiddleware(t *testing.T) {
+	store := newStore(func(ctx context.Context) ([]Flag, error) {
+		return []Flag{{Key: "beta", Enabled: true, RolloutPercent: 100}}, nil
+	}, "production", time.Minute)
+
+	e := echo.New()
+	e.Use(Middleware(store, func(c echo.Context) string { return "user-1" }))
+	e.GET("/", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		return c.String(http.StatusOK, fmt.Sprint(Enabled(ctx, "beta"), Enabled(ctx, "missing")))
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "true false", rec.Body.String())
+
+	assert.False(t, Enabled(context.Background(), "beta"), "off outside the middleware")
+}
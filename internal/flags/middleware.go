@@ -0,0 +1,44 @@
+package flags
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+type contextKey struct{}
+
+// evaluator answers flag checks for the visitor a request came from
+type evaluator struct {
+	store   *Store
+	subject string
+}
+
+// WithSubject returns a context whose flag checks are answered by store for subject
+func WithSubject(ctx context.Context, store *Store, subject string) context.Context {
+	return context.WithValue(ctx, contextKey{}, evaluator{store: store, subject: subject})
+}
+
+// Enabled reports whether a flag is on for the visitor a request came from. Handlers
+// pass c.Request().Context() and templ components their ctx. It's false outside
+// Middleware.
+func Enabled(ctx context.Context, key string) bool {
+	eval, ok := ctx.Value(contextKey{}).(evaluator)
+	if !ok {
+		return false
+	}
+	return eval.store.Enabled(ctx, key, eval.subject)
+}
+
+// Middleware makes flags available to the rest of the request through Enabled.
+// subject names the visitor percentage rollouts are bucketed by; it runs after
+// authentication so a signed-in customer gets the same answer on every device.
+func Middleware(store *Store, subject func(echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := WithSubject(c.Request().Context(), store, subject(c))
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package giftcards issues gift cards and keeps their balances. A card is bought as
+// a product in the shop or issued from admin, and its balance comes off online
+// orders until it runs out. Every change to a balance is recorded as a transaction,
+// so admin can see where a card's money went.
+//
+// The amount used at checkout is taken off the card when the Stripe session is made,
+// so the same balance can't pay for two orders at once. The hold is released if the
+// session expires unpaid, and linked to the order once it is paid.
+package giftcards
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Transaction kinds
+const (
+	KindIssue   = "issue"
+	KindRedeem  = "redeem"
+	KindRelease = "release"
+	KindAdjust  = "adjust"
+	KindVoid    = "void"
+)
+
+// KindLabel returns the admin label for a transaction kind
+func KindLabel(kind string) string {
+	switch kind {
+	case KindIssue:
+		return "Issued"
+	case KindRedeem:
+		return "Redeemed"
+	case KindRelease:
+		return "Released"
+	case KindAdjust:
+		return "Adjusted"
+	case KindVoid:
+		return "Voided"
+	}
+	return kind
+}
+
+// codeAlphabet leaves out characters that are easy to misread: 0/O, 1/I/L
+const codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// codeLength is the number of characters in a code, shown in groups of four
+const codeLength = 16
+
+// GenerateCode returns a new random code like 7KQM-P2XD-9RWA-H4NE
+func GenerateCode() (string, error) {
+	var b strings.Builder
+	limit := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < codeLength; i++ {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate gift card code: %w", err)
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeCode puts a code as typed by a shopper into the stored form: upper case,
+// in dash-separated groups of four, whatever spacing or dashes were typed
+func NormalizeCode(input string) string {
+	var chars []byte
+	for _, r := range strings.ToUpper(input) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			chars = append(chars, byte(r))
+		}
+	}
+	if len(chars) != codeLength {
+		return string(chars)
+	}
+	var b strings.Builder
+	for i, c := range chars {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Error is a reason a card can't be used or changed, worded for the person using it
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Usable checks that a card can pay for an order at now: not void, not expired and
+// with some balance left
+func Usable(card db.GiftCard, now time.Time) error {
+	if card.VoidedAt.Valid {
+		return &Error{Message: "This gift card is no longer valid"}
+	}
+	if card.ExpiresAt.Valid && !now.Before(card.ExpiresAt.Time) {
+		return &Error{Message: "This gift card has expired"}
+	}
+	if card.BalanceCents <= 0 {
+		return &Error{Message: "This gift card has no balance left"}
+	}
+	return nil
+}
+
+// Ledger issues cards and changes their balances, recording a transaction for each
+// change. Balances are changed with conditional updates, so two requests can't both
+// spend the same money.
+type Ledger struct {
+	queries *db.Queries
+}
+
+func NewLedger(queries *db.Queries) *Ledger {
+	return &Ledger{queries: queries}
+}
+
+// IssueParams describes a new card
+type IssueParams struct {
+	AmountCents    int64
+	ExpiresAt      sql.NullTime
+	RecipientEmail string
+	RecipientName  string
+	SenderName     string
+	Message        string
+	// OrderID is the order the card was bought in, empty for cards issued from admin
+	OrderID string
+	// UserID is the admin issuing the card, empty for cards bought in the shop
+	UserID string
+	Note   string
+}
+
+// Issue creates a card with a new code and records its opening balance
+func (l *Ledger) Issue(ctx context.Context, p IssueParams) (db.GiftCard, error) {
+	if p.AmountCents <= 0 {
+		return db.GiftCard{}, &Error{Message: "The amount must be more than zero"}
+	}
+	code, err := GenerateCode()
+	if err != nil {
+		return db.GiftCard{}, err
+	}
+
+	card, err := l.queries.CreateGiftCard(ctx, db.CreateGiftCardParams{
+		ID:              uuid.New().String(),
+		Code:            code,
+		InitialCents:    p.AmountCents,
+		BalanceCents:    p.AmountCents,
+		ExpiresAt:       p.ExpiresAt,
+		RecipientEmail:  strings.TrimSpace(p.RecipientEmail),
+		RecipientName:   strings.TrimSpace(p.RecipientName),
+		SenderName:      strings.TrimSpace(p.SenderName),
+		Message:         strings.TrimSpace(p.Message),
+		OrderID:         nullString(p.OrderID),
+		CreatedByUserID: nullString(p.UserID),
+	})
+	if err != nil {
+		return db.GiftCard{}, fmt.Errorf("failed to create gift card: %w", err)
+	}
+
+	err = l.record(ctx, db.CreateGiftCardTransactionParams{
+		GiftCardID:        card.ID,
+		Kind:              KindIssue,
+		AmountCents:       p.AmountCents,
+		BalanceAfterCents: p.AmountCents,
+		OrderID:           nullString(p.OrderID),
+		Note:              p.Note,
+		CreatedByUserID:   nullString(p.UserID),
+	})
+	return card, err
+}
+
+// Find looks up a usable card by the code a shopper typed
+func (l *Ledger) Find(ctx context.Context, input string, now time.Time) (db.GiftCard, error) {
+	code := NormalizeCode(input)
+	if code == "" {
+		return db.GiftCard{}, &Error{Message: "Enter a gift card code"}
+	}
+	card, err := l.queries.GetGiftCardByCode(ctx, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.GiftCard{}, &Error{Message: fmt.Sprintf("%s isn't a valid gift card code", code)}
+	}
+	if err != nil {
+		return db.GiftCard{}, fmt.Errorf("failed to look up gift card: %w", err)
+	}
+	if err := Usable(card, now); err != nil {
+		return db.GiftCard{}, err
+	}
+	return card, nil
+}
+
+// Hold takes amountCents off a card for a checkout session about to be made,
+// returning the transaction ID to link to the session once it exists
+func (l *Ledger) Hold(ctx context.Context, cardID string, amountCents int64) (string, error) {
+	balance, err := l.queries.DebitGiftCard(ctx, db.DebitGiftCardParams{
+		AmountCents: amountCents,
+		ID:          cardID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", &Error{Message: "This gift card's balance has changed; apply it again"}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to debit gift card: %w", err)
+	}
+
+	id := uuid.New().String()
+	err = l.record(ctx, db.CreateGiftCardTransactionParams{
+		ID:                id,
+		GiftCardID:        cardID,
+		Kind:              KindRedeem,
+		AmountCents:       -amountCents,
+		BalanceAfterCents: balance,
+	})
+	if err != nil {
+		// A hold with no transaction could never be released, so undo the debit
+		if _, undoErr := l.queries.AdjustGiftCardBalance(ctx, db.AdjustGiftCardBalanceParams{
+			AmountCents: amountCents,
+			ID:          cardID,
+		}); undoErr != nil {
+			return "", errors.Join(err, fmt.Errorf("failed to undo gift card debit: %w", undoErr))
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// LinkHold records the checkout session a hold was made for
+func (l *Ledger) LinkHold(ctx context.Context, transactionID, sessionID string) error {
+	if err := l.queries.SetGiftCardTransactionSession(ctx, db.SetGiftCardTransactionSessionParams{
+		CheckoutSessionID: sessionID,
+		ID:                transactionID,
+	}); err != nil {
+		return fmt.Errorf("failed to link gift card hold to session: %w", err)
+	}
+	return nil
+}
+
+// Release puts a hold's amount back on its card, for a checkout that never completed
+func (l *Ledger) Release(ctx context.Context, hold db.GiftCardTransaction, note string) error {
+	balance, err := l.queries.AdjustGiftCardBalance(ctx, db.AdjustGiftCardBalanceParams{
+		AmountCents: -hold.AmountCents,
+		ID:          hold.GiftCardID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// A card voided since the hold keeps its zero balance
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release gift card hold: %w", err)
+	}
+	return l.record(ctx, db.CreateGiftCardTransactionParams{
+		GiftCardID:        hold.GiftCardID,
+		Kind:              KindRelease,
+		AmountCents:       -hold.AmountCents,
+		BalanceAfterCents: balance,
+		CheckoutSessionID: hold.CheckoutSessionID,
+		Note:              note,
+	})
+}
+
+// ReleaseSession releases every hold made for a checkout session that hasn't been
+// paid or released already
+func (l *Ledger) ReleaseSession(ctx context.Context, sessionID, note string) error {
+	holds, err := l.queries.ListHeldGiftCardRedemptions(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list gift card holds: %w", err)
+	}
+	for _, hold := range holds {
+		if err := l.Release(ctx, hold, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adjust adds a signed amount to a card's balance, refusing to take it below zero
+func (l *Ledger) Adjust(ctx context.Context, cardID string, amountCents int64, note, userID string) (int64, error) {
+	if amountCents == 0 {
+		return 0, &Error{Message: "Enter an amount to add or take off"}
+	}
+	balance, err := l.queries.AdjustGiftCardBalance(ctx, db.AdjustGiftCardBalanceParams{
+		AmountCents: amountCents,
+		ID:          cardID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &Error{Message: "The card is void, or the balance would go below zero"}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to adjust gift card balance: %w", err)
+	}
+	return balance, l.record(ctx, db.CreateGiftCardTransactionParams{
+		GiftCardID:        cardID,
+		Kind:              KindAdjust,
+		AmountCents:       amountCents,
+		BalanceAfterCents: balance,
+		Note:              note,
+		CreatedByUserID:   nullString(userID),
+	})
+}
+
+// Void cancels a card, zeroing its balance
+func (l *Ledger) Void(ctx context.Context, cardID, reason, userID string) error {
+	card, err := l.queries.GetGiftCard(ctx, cardID)
+	if err != nil {
+		return fmt.Errorf("failed to load gift card: %w", err)
+	}
+	rows, err := l.queries.VoidGiftCard(ctx, db.VoidGiftCardParams{
+		VoidReason: reason,
+		ID:         card.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to void gift card: %w", err)
+	}
+	if rows == 0 {
+		return &Error{Message: "This gift card is already void"}
+	}
+	return l.record(ctx, db.CreateGiftCardTransactionParams{
+		GiftCardID:        card.ID,
+		Kind:              KindVoid,
+		AmountCents:       -card.BalanceCents,
+		BalanceAfterCents: 0,
+		Note:              reason,
+		CreatedByUserID:   nullString(userID),
+	})
+}
+
+// record adds a transaction to a card's history. The balance has already changed
+// by the time it's called, so a failure here leaves the history short, not the money.
+func (l *Ledger) record(ctx context.Context, p db.CreateGiftCardTransactionParams) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	if err := l.queries.CreateGiftCardTransaction(ctx, p); err != nil {
+		return fmt.Errorf("failed to record gift card %s transaction: %w", p.Kind, err)
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
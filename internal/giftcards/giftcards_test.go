@@ -0,0 +1,132 @@
+package giftcards
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestGenerateCode(t *testing.T) {
+	pattern := regexp.MustCompile(`^[A-HJKMNP-Z2-9]{4}(-[A-HJKMNP-Z2-9]{4}){3}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		code, err := GenerateCode()
+		require.NoError(t, err)
+		assert.Regexp(t, pattern, code)
+		assert.False(t, seen[code], "codes should not repeat")
+		seen[code] = true
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	assert.Equal(t, "7KQM-P2XD-9RWA-H4NE", NormalizeCode("7kqm p2xd 9rwa h4ne"))
+	assert.Equal(t, "7KQM-P2XD-9RWA-H4NE", NormalizeCode(" 7KQMP2XD9RWAH4NE "))
+	assert.Equal(t, "7KQM-P2XD-9RWA-H4NE", NormalizeCode("7KQM--P2XD-9RWA-H4NE"))
+	assert.Equal(t, "SHORT", NormalizeCode("short"), "codes of the wrong length are left ungrouped")
+	assert.Equal(t, "", NormalizeCode(" - "))
+}
+
+func TestUsable(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	card := db.GiftCard{Code: "7KQM-P2XD-9RWA-H4NE", BalanceCents: 2500}
+	assert.NoError(t, Usable(card, now))
+
+	expired := card
+	expired.ExpiresAt = sql.NullTime{Time: now, Valid: true}
+	assert.EqualError(t, Usable(expired, now), "This gift card has expired")
+
+	future := card
+	future.ExpiresAt = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	assert.NoError(t, Usable(future, now))
+
+	void := card
+	void.VoidedAt = sql.NullTime{Time: now, Valid: true}
+	assert.EqualError(t, Usable(void, now), "This gift card is no longer valid")
+
+	empty := card
+	empty.BalanceCents = 0
+	var cardErr *Error
+	assert.True(t, errors.As(Usable(empty, now), &cardErr))
+}
+
+func TestLedger(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	ledger := NewLedger(queries)
+
+	card, err := ledger.Issue(ctx, IssueParams{AmountCents: 5000, RecipientEmail: "friend@example.com", Note: "Prize"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), card.BalanceCents)
+
+	found, err := ledger.Find(ctx, NormalizeCode(card.Code), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, card.ID, found.ID)
+
+	// A hold comes off straight away and is released when its session expires
+	holdID, err := ledger.Hold(ctx, card.ID, 3000)
+	require.NoError(t, err)
+	require.NoError(t, ledger.LinkHold(ctx, holdID, "cs_expired"))
+	_, err = ledger.Hold(ctx, card.ID, 3000)
+	var cardErr *Error
+	require.True(t, errors.As(err, &cardErr), "the held balance can't be spent twice")
+
+	require.NoError(t, ledger.ReleaseSession(ctx, "cs_expired", "Checkout expired"))
+	require.NoError(t, ledger.ReleaseSession(ctx, "cs_expired", "Checkout expired"), "releasing twice is a no-op")
+	card, err = queries.GetGiftCard(ctx, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), card.BalanceCents)
+
+	// A paid hold stays spent
+	holdID, err = ledger.Hold(ctx, card.ID, 1200)
+	require.NoError(t, err)
+	require.NoError(t, ledger.LinkHold(ctx, holdID, "cs_paid"))
+	_, err = queries.CreateUser(ctx, db.CreateUserParams{ID: "user-buyer", Email: "buyer@example.com"})
+	require.NoError(t, err)
+	_, err = queries.CreateOrder(ctx, db.CreateOrderParams{
+		ID:            "order-gift-card",
+		UserID:        "user-buyer",
+		CustomerEmail: "buyer@example.com",
+		CustomerName:  "Buyer",
+		SubtotalCents: 1200,
+		TotalCents:    0,
+	})
+	require.NoError(t, err)
+	require.NoError(t, queries.AttachGiftCardRedemptionsToOrder(ctx, db.AttachGiftCardRedemptionsToOrderParams{
+		OrderID:           sql.NullString{String: "order-gift-card", Valid: true},
+		CheckoutSessionID: "cs_paid",
+	}))
+	require.NoError(t, ledger.ReleaseSession(ctx, "cs_paid", "Checkout expired"))
+
+	balance, err := ledger.Adjust(ctx, card.ID, -800, "Goodwill correction", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), balance)
+	_, err = ledger.Adjust(ctx, card.ID, -3001, "Too much", "")
+	require.True(t, errors.As(err, &cardErr), "the balance can't go below zero")
+
+	require.NoError(t, ledger.Void(ctx, card.ID, "Reported stolen", ""))
+	require.True(t, errors.As(ledger.Void(ctx, card.ID, "Again", ""), &cardErr))
+	_, err = ledger.Find(ctx, card.Code, time.Now())
+	require.True(t, errors.As(err, &cardErr))
+
+	history, err := queries.ListGiftCardTransactions(ctx, card.ID)
+	require.NoError(t, err)
+	kinds := []string{}
+	var total int64
+	for _, tx := range history {
+		kinds = append(kinds, tx.Kind)
+		total += tx.AmountCents
+	}
+	assert.Equal(t, []string{KindVoid, KindAdjust, KindRedeem, KindRelease, KindRedeem, KindIssue}, kinds)
+	assert.Equal(t, int64(0), total, "the history adds up to the balance")
+}
@@ -110,21 +110,23 @@ func (h *AdminHandler) HandleAbandonedCartsDashboard(c echo.Context) error {
 		for _, row := range recentCarts {
 			// Convert ListRecentAbandonedCartsRow to AbandonedCart
 			cart := db.AbandonedCart{
-				ID:              row.ID,
-				SessionID:       row.SessionID,
-				UserID:          row.UserID,
-				CustomerEmail:   row.CustomerEmail,
-				CustomerName:    row.CustomerName,
-				CartValueCents:  row.CartValueCents,
-				ItemCount:       row.ItemCount,
-				AbandonedAt:     row.AbandonedAt,
-				RecoveredAt:     row.RecoveredAt,
-				RecoveryMethod:  row.RecoveryMethod,
-				Status:          row.Status,
-				LastContactedAt: row.LastContactedAt,
-				Notes:           row.Notes,
-				CreatedAt:       row.CreatedAt,
-				UpdatedAt:       row.UpdatedAt,
+				ID:               row.ID,
+				SessionID:        row.SessionID,
+				UserID:           row.UserID,
+				CustomerEmail:    row.CustomerEmail,
+				CustomerName:     row.CustomerName,
+				CartValueCents:   row.CartValueCents,
+				ItemCount:        row.ItemCount,
+				AbandonedAt:      row.AbandonedAt,
+				RecoveredAt:      row.RecoveredAt,
+				RecoveryMethod:   row.RecoveryMethod,
+				Status:           row.Status,
+				LastContactedAt:  row.LastContactedAt,
+				Notes:            row.Notes,
+				CreatedAt:        row.CreatedAt,
+				UpdatedAt:        row.UpdatedAt,
+				AbVariant:        row.AbVariant,
+				RecoveredOrderID: row.RecoveredOrderID,
 			}
 			cartDetails = append(cartDetails, admin.AbandonedCartWithDetails{
 				Cart:         cart,
@@ -153,6 +155,12 @@ func (h *AdminHandler) HandleAbandonedCartsDashboard(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Failed to fetch email stats: "+err.Error())
 	}
 
+	// Get A/B variant conversion stats
+	variantStats, err := h.getRecoveryVariantStats(ctx)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to fetch variant stats: "+err.Error())
+	}
+
 	// Get active carts (carts not yet abandoned)
 	activeCarts, err := h.getActiveCarts(ctx)
 	if err != nil {
@@ -178,6 +186,7 @@ func (h *AdminHandler) HandleAbandonedCartsDashboard(c echo.Context) error {
 		trendData,
 		topProducts,
 		emailStats,
+		variantStats,
 		hourlyData,
 		activeCarts,
 		activeCartsMetrics,
@@ -552,6 +561,32 @@ func (h *AdminHandler) getEmailRecoveryStats(ctx context.Context) ([]admin.Recov
 	return result, nil
 }
 
+// getRecoveryVariantStats returns conversion results per recovery sequence variant
+func (h *AdminHandler) getRecoveryVariantStats(ctx context.Context) ([]admin.RecoveryVariantStats, error) {
+	stats, err := h.storage.Queries.GetRecoveryVariantPerformance(ctx, "-30 days")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]admin.RecoveryVariantStats, len(stats))
+	for i, s := range stats {
+		var rate float64
+		if s.CartCount > 0 {
+			rate = float64(s.ConvertedCount) / float64(s.CartCount) * 100
+		}
+		result[i] = admin.RecoveryVariantStats{
+			Variant:               s.AbVariant,
+			CartCount:             s.CartCount,
+			ContactedCount:        s.ContactedCount,
+			ConvertedCount:        s.ConvertedCount,
+			ConversionRatePercent: rate,
+			RevenueCents:          s.ConvertedRevenueCents,
+		}
+	}
+
+	return result, nil
+}
+
 // HandleRecoveryEmailTracking tracks when customers click on recovery email links
 func (h *AdminHandler) HandleRecoveryEmailTracking(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -5,14 +5,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -20,9 +23,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/images"
+	"github.com/loganlanou/logans3d-v4/internal/policies"
 	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	"github.com/loganlanou/logans3d-v4/internal/sms"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
 	"github.com/loganlanou/logans3d-v4/internal/sync"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 	"github.com/loganlanou/logans3d-v4/internal/types"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/loganlanou/logans3d-v4/views/admin"
@@ -32,13 +41,17 @@ type AdminHandler struct {
 	storage         *storage.Storage
 	shippingService *shipping.ShippingService
 	emailService    *email.Service
+	stripeCatalog   *stripe.CatalogSync // nil when Stripe catalog sync is off
+	smsService      *sms.Service
 }
 
-func NewAdminHandler(storage *storage.Storage, shippingService *shipping.ShippingService, emailService *email.Service) *AdminHandler {
+func NewAdminHandler(storage *storage.Storage, shippingService *shipping.ShippingService, emailService *email.Service, stripeCatalog *stripe.CatalogSync) *AdminHandler {
 	return &AdminHandler{
 		storage:         storage,
 		shippingService: shippingService,
 		emailService:    emailService,
+		stripeCatalog:   stripeCatalog,
+		smsService:      sms.NewService(storage.Queries),
 	}
 }
 
@@ -205,12 +218,31 @@ func (h *AdminHandler) HandleAdminDashboard(c echo.Context) error {
 		OrdersByStatus:     ordersByStatus,
 		LowStockProducts:   lowStockProducts,
 		RecentOrders:       recentOrders,
+		Today:              h.dashboardToday(ctx),
 	}
 
 	return Render(c, admin.Dashboard(c, stats))
 }
 
+// dashboardToday reads the numbers across the top of the dashboard. If they can't be
+// read they show as zeros, like the rest of the dashboard.
+func (h *AdminHandler) dashboardToday(ctx context.Context) admin.DashboardToday {
+	today, err := h.storage.Queries.GetDashboardToday(ctx)
+	if err != nil {
+		slog.Error("failed to get today's dashboard numbers", "error", err)
+	}
+	return admin.DashboardToday{Stats: today, UpdatedAt: time.Now()}
+}
+
+// HandleDashboardToday renders the numbers across the top of the dashboard, which
+// polls it to keep them current
+func (h *AdminHandler) HandleDashboardToday(c echo.Context) error {
+	return Render(c, admin.DashboardTodayPanel(h.dashboardToday(c.Request().Context())))
+}
+
 func (h *AdminHandler) HandleProductsList(c echo.Context) error {
+	ctx := c.Request().Context()
+
 	// Get query parameters for filtering and sorting
 	categoryFilter := c.QueryParam("category")
 	featuredFilter := c.QueryParam("featured")
@@ -221,141 +253,76 @@ func (h *AdminHandler) HandleProductsList(c echo.Context) error {
 	sortOrder := c.QueryParam("order")
 
 	// Default sort by name ascending if no sort specified
-	if sortBy == "" {
+	if sortBy != "name" && sortBy != "price" {
 		sortBy = "name"
+	}
+	if sortOrder != "desc" {
 		sortOrder = "asc"
 	}
 
-	// Get all products (admin needs to see inactive products too)
-	products, err := h.storage.Queries.ListAllProducts(c.Request().Context())
+	page := types.ProductListPage{Query: url.Values{}}
+	// Carry the filters and sort through the pager and sort links
+	for _, key := range []string{"category", "featured", "premium", "new", "status"} {
+		if value := c.QueryParam(key); value != "" {
+			page.Query.Set(key, value)
+		}
+	}
+	page.Query.Set("sort", sortBy)
+	page.Query.Set("order", sortOrder)
+	requestedPage, _ := strconv.Atoi(c.QueryParam("page"))
+	perPage := types.DefaultProductPageSize
+	if n, err := strconv.Atoi(c.QueryParam("per_page")); err == nil && slices.Contains(types.ProductPageSizes, n) {
+		perPage = n
+	}
+
+	// Admin sees inactive products too; the status filter narrows to just those
+	category := sql.NullString{String: categoryFilter, Valid: categoryFilter != "" && categoryFilter != "all"}
+	isNew := sql.NullBool{Bool: true, Valid: newFilter == "true"}
+	isFeatured := sql.NullBool{Bool: true, Valid: featuredFilter == "true"}
+	isPremium := sql.NullBool{Bool: true, Valid: premiumFilter == "true"}
+	isActive := sql.NullBool{Bool: false, Valid: statusFilter == "inactive"}
+	// Archived products only show on their own filter
+	archived := statusFilter == "archived"
+
+	total, err := h.storage.Queries.CountAdminProducts(ctx, db.CountAdminProductsParams{
+		CategoryID: category,
+		IsNew:      isNew,
+		IsFeatured: isFeatured,
+		IsPremium:  isPremium,
+		IsActive:   isActive,
+		Archived:   archived,
+	})
 	if err != nil {
+		slog.Error("failed to count admin products", "error", err)
+		return c.String(http.StatusInternalServerError, "Failed to fetch products")
+	}
+	page.Pagination = types.NewPagination(requestedPage, perPage, total)
+
+	products, err := h.storage.Queries.ListAdminProducts(ctx, db.ListAdminProductsParams{
+		CategoryID: category,
+		IsNew:      isNew,
+		IsFeatured: isFeatured,
+		IsPremium:  isPremium,
+		IsActive:   isActive,
+		Archived:   archived,
+		Sort:       sortBy + "_" + sortOrder,
+		PageSize:   int64(page.PerPage),
+		PageOffset: int64(page.Offset()),
+	})
+	if err != nil {
+		slog.Error("failed to list admin products", "error", err, "page", page.Page)
 		return c.String(http.StatusInternalServerError, "Failed to fetch products")
 	}
 
-	productsWithImages := h.buildProductsWithImages(c.Request().Context(), products)
-
-	// Apply filters
-	filteredProducts := filterProducts(productsWithImages, categoryFilter, featuredFilter, premiumFilter, newFilter, statusFilter)
-
-	// Apply sorting
-	sortedProducts := sortProducts(filteredProducts, sortBy, sortOrder)
+	productsWithImages := h.buildProductsWithImages(ctx, products)
 
 	// Get all categories for filter dropdown
-	categories, err := h.storage.Queries.ListCategories(c.Request().Context())
+	categories, err := h.storage.Queries.ListCategories(ctx)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to fetch categories")
 	}
 
-	return Render(c, admin.Products(c, sortedProducts, categories, categoryFilter, featuredFilter, premiumFilter, newFilter, statusFilter, sortBy, sortOrder))
-}
-
-func filterProducts(products []types.ProductWithImage, categoryFilter, featuredFilter, premiumFilter, newFilter, statusFilter string) []types.ProductWithImage {
-	filtered := make([]types.ProductWithImage, 0, len(products))
-
-	for _, p := range products {
-		// Category filter
-		if categoryFilter != "" && categoryFilter != "all" {
-			if !p.Product.CategoryID.Valid || p.Product.CategoryID.String != categoryFilter {
-				continue
-			}
-		}
-
-		// New filter
-		if newFilter == "true" {
-			if !p.IsNew {
-				continue
-			}
-		}
-
-		// Featured filter
-		if featuredFilter == "true" {
-			isFeatured := p.Product.IsFeatured.Valid && p.Product.IsFeatured.Bool
-			if !isFeatured {
-				continue
-			}
-		}
-
-		// Premium filter
-		if premiumFilter == "true" {
-			isPremium := p.Product.IsPremium.Valid && p.Product.IsPremium.Bool
-			if !isPremium {
-				continue
-			}
-		}
-
-		// Status filter - only filter when checkbox is checked
-		if statusFilter == "inactive" {
-			isActive := p.Product.IsActive.Valid && p.Product.IsActive.Bool
-			if isActive {
-				continue
-			}
-		}
-
-		filtered = append(filtered, p)
-	}
-
-	return filtered
-}
-
-func sortProducts(products []types.ProductWithImage, sortBy, sortOrder string) []types.ProductWithImage {
-	if sortBy == "" {
-		return products
-	}
-
-	// Create a copy to avoid modifying the original slice
-	sorted := make([]types.ProductWithImage, len(products))
-	copy(sorted, products)
-
-	// Default to ascending if not specified
-	if sortOrder == "" {
-		sortOrder = "asc"
-	}
-
-	// Sort based on the field
-	switch sortBy {
-	case "name":
-		if sortOrder == "asc" {
-			// Sort by name ascending
-			for i := 0; i < len(sorted); i++ {
-				for j := i + 1; j < len(sorted); j++ {
-					if sorted[i].Product.Name > sorted[j].Product.Name {
-						sorted[i], sorted[j] = sorted[j], sorted[i]
-					}
-				}
-			}
-		} else {
-			// Sort by name descending
-			for i := 0; i < len(sorted); i++ {
-				for j := i + 1; j < len(sorted); j++ {
-					if sorted[i].Product.Name < sorted[j].Product.Name {
-						sorted[i], sorted[j] = sorted[j], sorted[i]
-					}
-				}
-			}
-		}
-	case "price":
-		if sortOrder == "asc" {
-			// Sort by price ascending
-			for i := 0; i < len(sorted); i++ {
-				for j := i + 1; j < len(sorted); j++ {
-					if sorted[i].Product.PriceCents > sorted[j].Product.PriceCents {
-						sorted[i], sorted[j] = sorted[j], sorted[i]
-					}
-				}
-			}
-		} else {
-			// Sort by price descending
-			for i := 0; i < len(sorted); i++ {
-				for j := i + 1; j < len(sorted); j++ {
-					if sorted[i].Product.PriceCents < sorted[j].Product.PriceCents {
-						sorted[i], sorted[j] = sorted[j], sorted[i]
-					}
-				}
-			}
-		}
-	}
-
-	return sorted
+	return Render(c, admin.Products(c, productsWithImages, categories, categoryFilter, featuredFilter, premiumFilter, newFilter, statusFilter, sortBy, sortOrder, page))
 }
 
 func (h *AdminHandler) HandleCategoriesTab(c echo.Context) error {
@@ -416,11 +383,27 @@ func (h *AdminHandler) HandleCategoriesTab(c echo.Context) error {
 
 	productsWithImages := h.buildProductsWithImages(c.Request().Context(), products)
 
-	return Render(c, admin.CategoriesTab(c, productsWithImages, categories, filter))
+	// All categories show as a tree that can be rearranged by dragging
+	var tree []*admin.CategoryTreeNode
+	if filter == "all" {
+		rows, err := h.storage.Queries.ListCategoryTree(c.Request().Context())
+		if err != nil {
+			slog.Error("failed to load category tree", "error", err)
+			return c.String(http.StatusInternalServerError, "Failed to fetch categories")
+		}
+		tree = buildCategoryTree(rows)
+	}
+
+	return Render(c, admin.CategoriesTab(c, productsWithImages, categories, filter, tree, c.QueryParam("saved"), c.QueryParam("error")))
 }
 
 func (h *AdminHandler) buildProductsWithImages(ctx context.Context, products []db.Product) []types.ProductWithImage {
-	// Get products with their primary images
+	// Load every product's card image in one query
+	imageURLs, err := utils.ProductImageURLs(ctx, h.storage.Queries, products)
+	if err != nil {
+		slog.Error("failed to load product images", "count", len(products), "error", err)
+	}
+
 	productsWithImages := make([]types.ProductWithImage, 0, len(products))
 	for _, product := range products {
 		// Use database is_new column
@@ -428,55 +411,9 @@ func (h *AdminHandler) buildProductsWithImages(ctx context.Context, products []d
 		// Check if product is discontinued (inactive)
 		isDiscontinued := !product.IsActive.Valid || !product.IsActive.Bool
 
-		imageURL := ""
-
-		// For products with variants, prefer the AI-generated multi-variant OG image
-		if product.HasVariants.Valid && product.HasVariants.Bool {
-			// Check if multi-variant OG image exists
-			multiOGPath := fmt.Sprintf("public/og-images/product-%s-multi.png", product.ID)
-			if _, err := os.Stat(multiOGPath); err == nil {
-				imageURL = "/" + multiOGPath
-			} else {
-				// Fall back to primary style's primary image
-				styles, err := h.storage.Queries.GetProductStyles(ctx, product.ID)
-				if err == nil && len(styles) > 0 {
-					// First style is primary (ordered by is_primary DESC)
-					primaryStyle := styles[0]
-					styleImage, err := h.storage.Queries.GetPrimaryStyleImage(ctx, primaryStyle.ID)
-					if err == nil && styleImage.ImageUrl != "" {
-						imageURL = "/public/images/products/styles/" + styleImage.ImageUrl
-					}
-				}
-			}
-		}
-
-		// If no variant image found (or not a variant product), try regular product images
-		if imageURL == "" {
-			images, err := h.storage.Queries.GetProductImages(ctx, product.ID)
-			if err == nil && len(images) > 0 {
-				// Get the primary image or the first image
-				var rawImageURL string
-				for _, img := range images {
-					if img.IsPrimary.Valid && img.IsPrimary.Bool {
-						rawImageURL = img.ImageUrl
-						break
-					}
-				}
-				// If no primary image found, use the first one
-				if rawImageURL == "" {
-					rawImageURL = images[0].ImageUrl
-				}
-
-				// Build the full path from the filename
-				if rawImageURL != "" {
-					imageURL = "/public/images/products/" + rawImageURL
-				}
-			}
-		}
-
 		productsWithImages = append(productsWithImages, types.ProductWithImage{
 			Product:        product,
-			ImageURL:       imageURL,
+			ImageURL:       imageURLs[product.ID],
 			IsNew:          isNew,
 			IsDiscontinued: isDiscontinued,
 		})
@@ -573,7 +510,85 @@ func (h *AdminHandler) HandleProductForm(c echo.Context) error {
 		}
 	}
 
-	return Render(c, admin.ProductFormPage(c, product, categories, productImages, productStyles, sizes, skuViews, sizeCharts, productSizeConfigs))
+	var attributes []db.ProductAttribute
+	if product != nil {
+		attributes, err = h.storage.Queries.ListProductAttributes(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch product attributes", "error", err, "product_id", product.ID)
+			attributes = []db.ProductAttribute{}
+		}
+	}
+
+	var files []db.ProductFile
+	if product != nil {
+		files, err = h.storage.Queries.ListProductFiles(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch product files", "error", err, "product_id", product.ID)
+			files = []db.ProductFile{}
+		}
+	}
+
+	var priceTiers []db.ListProductPriceTiersRow
+	if product != nil {
+		priceTiers, err = h.storage.Queries.ListProductPriceTiers(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch price tiers", "error", err, "product_id", product.ID)
+			priceTiers = []db.ListProductPriceTiersRow{}
+		}
+	}
+
+	var videos []db.ProductVideo
+	if product != nil {
+		videos, err = h.storage.Queries.ListProductVideos(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch product videos", "error", err, "product_id", product.ID)
+			videos = []db.ProductVideo{}
+		}
+	}
+
+	var personalizationFields []db.ProductPersonalizationField
+	if product != nil {
+		personalizationFields, err = h.storage.Queries.ListProductPersonalizationFields(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch personalization fields", "error", err, "product_id", product.ID)
+			personalizationFields = []db.ProductPersonalizationField{}
+		}
+	}
+
+	var relations []db.ListProductRelationsRow
+	var relationProducts []db.Product
+	if product != nil {
+		relations, err = h.storage.Queries.ListProductRelations(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch product relations", "error", err, "product_id", product.ID)
+			relations = []db.ListProductRelationsRow{}
+		}
+		relationProducts, err = h.storage.Queries.ListProducts(c.Request().Context())
+		if err != nil {
+			slog.Error("failed to fetch products for relations", "error", err)
+			relationProducts = []db.Product{}
+		}
+	}
+
+	var skuSales []db.ListProductSkuSalesRow
+	if product != nil {
+		skuSales, err = h.storage.Queries.ListProductSkuSales(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch sku sales", "error", err, "product_id", product.ID)
+			skuSales = []db.ListProductSkuSalesRow{}
+		}
+	}
+
+	var slugRedirects []db.SlugRedirect
+	if product != nil {
+		slugRedirects, err = h.storage.Queries.ListProductSlugRedirects(c.Request().Context(), product.ID)
+		if err != nil {
+			slog.Error("failed to fetch product slug redirects", "error", err, "product_id", product.ID)
+			slugRedirects = []db.SlugRedirect{}
+		}
+	}
+
+	return Render(c, admin.ProductFormPage(c, product, categories, productImages, productStyles, sizes, skuViews, sizeCharts, productSizeConfigs, attributes, files, priceTiers, videos, personalizationFields, relations, relationProducts, skuSales, slugRedirects))
 }
 
 func (h *AdminHandler) HandleCreateProduct(c echo.Context) error {
@@ -638,6 +653,22 @@ func (h *AdminHandler) HandleCreateProduct(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Failed to create product: "+err.Error())
 	}
 
+	if err := h.saveProductDigitalSettings(c, productID); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to save digital settings")
+	}
+	if err := h.saveProductPreorderSettings(c, productID); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to save pre-order settings")
+	}
+	if err := h.saveProductDropSettings(c, productID); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to save drop settings")
+	}
+	if err := h.saveProductBackorderSettings(c, productID); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to save backorder settings")
+	}
+	if err := h.saveProductScheduleSettings(c, productID); err != nil {
+		return c.String(scheduleErrorResponse(err))
+	}
+
 	// Handle image upload
 	file, err := c.FormFile("image")
 	if err == nil && file != nil {
@@ -667,6 +698,7 @@ func (h *AdminHandler) HandleCreateProduct(c echo.Context) error {
 			if _, err = io.Copy(dst, src); err != nil {
 				return c.String(http.StatusInternalServerError, "Failed to save image")
 			}
+			images.Default().GenerateInBackground(filename)
 
 			// Save only the filename to database
 			// The view layer will build the full path
@@ -690,6 +722,8 @@ func (h *AdminHandler) HandleCreateProduct(c echo.Context) error {
 		}
 	}
 
+	h.queueStripeSync(productID)
+
 	// Check if this is an HTMX request
 	if c.Request().Header.Get("HX-Request") == "true" {
 		// Trigger toast notification and redirect
@@ -774,7 +808,10 @@ func (h *AdminHandler) HandleUpdateProduct(c echo.Context) error {
 		ShippingCategory: sql.NullString{String: shippingCategory, Valid: shippingCategory != ""},
 	}
 
-	_, err = h.storage.Queries.UpdateProductFields(c.Request().Context(), params)
+	// A new name changes the slug, so the old shop URL is kept to redirect from
+	_, err = updateProductSlug(c.Request().Context(), h.storage, productID, slug, func(queries *db.Queries) (db.Product, error) {
+		return queries.UpdateProductFields(c.Request().Context(), params)
+	})
 	if err != nil {
 		slog.Error("failed to update product in database", "error", err, "product_id", productID, "product_name", name)
 		errMsg := "Failed to update product: " + err.Error()
@@ -793,6 +830,34 @@ func (h *AdminHandler) HandleUpdateProduct(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, errMsg)
 	}
 
+	errMsg := ""
+	errStatus := http.StatusInternalServerError
+	if err := h.saveProductDigitalSettings(c, productID); err != nil {
+		errMsg = "Failed to save digital settings"
+	} else if err := h.saveProductPreorderSettings(c, productID); err != nil {
+		errMsg = "Failed to save pre-order settings"
+	} else if err := h.saveProductDropSettings(c, productID); err != nil {
+		errMsg = "Failed to save drop settings"
+	} else if err := h.saveProductBackorderSettings(c, productID); err != nil {
+		errMsg = "Failed to save backorder settings"
+	} else if err := h.saveProductScheduleSettings(c, productID); err != nil {
+		errStatus, errMsg = scheduleErrorResponse(err)
+	}
+	if errMsg != "" {
+		if c.Request().Header.Get("HX-Request") == "true" {
+			errorHTML := fmt.Sprintf(`
+				<div class="mb-6 p-4 bg-red-600/20 border border-red-600 rounded-lg text-red-400 flex items-center gap-2">
+					<svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+						<path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 8v4m0 4h.01M21 12a9 9 0 11-18 0 9 9 0 0118 0z"></path>
+					</svg>
+					<span>%s</span>
+				</div>
+			`, errMsg)
+			return c.HTML(errStatus, errorHTML)
+		}
+		return c.String(errStatus, errMsg)
+	}
+
 	slog.Debug("product updated successfully in database", "product_id", productID, "product_name", name)
 
 	// Handle multiple image uploads
@@ -858,6 +923,7 @@ func (h *AdminHandler) HandleUpdateProduct(c echo.Context) error {
 
 				src.Close()
 				dst.Close()
+				images.Default().GenerateInBackground(filename)
 
 				// Save only the filename to database
 				imageFilename := filename
@@ -898,6 +964,7 @@ func (h *AdminHandler) HandleUpdateProduct(c echo.Context) error {
 	}
 
 	slog.Debug("product update completed", "product_id", productID)
+	h.queueStripeSync(productID)
 
 	// Check if this is an HTMX request
 	if c.Request().Header.Get("HX-Request") == "true" {
@@ -910,17 +977,107 @@ func (h *AdminHandler) HandleUpdateProduct(c echo.Context) error {
 	return c.Redirect(http.StatusSeeOther, "/admin/product/edit?id="+productID)
 }
 
+// HandleDeleteProduct archives a product. Orders and carts still point at it, so it
+// is hidden rather than deleted; it can be restored from the Archived filter.
 func (h *AdminHandler) HandleDeleteProduct(c echo.Context) error {
+	ctx := c.Request().Context()
 	productID := c.Param("id")
 
-	err := h.storage.Queries.DeleteProduct(c.Request().Context(), productID)
+	product, err := h.storage.Queries.GetProduct(ctx, productID)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Failed to delete product")
+		slog.Error("failed to get product to archive", "error", err, "product_id", productID)
+		return c.String(http.StatusNotFound, "Product not found")
+	}
+	if _, err := h.storage.Queries.ArchiveProduct(ctx, productID); err != nil {
+		slog.Error("failed to archive product", "error", err, "product_id", productID)
+		return c.String(http.StatusInternalServerError, "Failed to archive product")
 	}
+	slog.Info("product archived", "product_id", productID)
+	h.queueStripeArchive(product.StripeProductID.String, "")
 
 	return c.Redirect(http.StatusSeeOther, "/admin")
 }
 
+// HandleRestoreProduct brings an archived product back as a draft, for admin to check
+// before putting it on sale again
+func (h *AdminHandler) HandleRestoreProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	rows, err := h.storage.Queries.RestoreProduct(ctx, productID)
+	if err != nil {
+		slog.Error("failed to restore product", "error", err, "product_id", productID)
+		return c.String(http.StatusInternalServerError, "Failed to restore product")
+	}
+	if rows == 0 {
+		return c.String(http.StatusNotFound, "Archived product not found")
+	}
+	slog.Info("product restored", "product_id", productID)
+	h.queueStripeSync(productID)
+
+	return c.Redirect(http.StatusSeeOther, "/admin/product/edit?id="+productID)
+}
+
+// HandlePermanentlyDeleteProduct deletes an archived product for good. Products that
+// any order included are kept, so order history always has its products.
+func (h *AdminHandler) HandlePermanentlyDeleteProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	archivedURL := "/admin/products?status=archived"
+
+	product, err := h.storage.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		slog.Error("failed to get product to delete", "error", err, "product_id", productID)
+		return c.String(http.StatusNotFound, "Product not found")
+	}
+	if !product.DeletedAt.Valid {
+		return c.Redirect(http.StatusSeeOther, archivedURL+"&error="+url.QueryEscape("Archive the product before deleting it"))
+	}
+	orderItems, err := h.storage.Queries.CountProductOrderItems(ctx, productID)
+	if err != nil {
+		slog.Error("failed to count product order items", "error", err, "product_id", productID)
+		return c.String(http.StatusInternalServerError, "Failed to delete product")
+	}
+	if orderItems > 0 {
+		message := fmt.Sprintf("%s is in %d order line(s), so it stays archived to keep the order history", product.Name, orderItems)
+		return c.Redirect(http.StatusSeeOther, archivedURL+"&error="+url.QueryEscape(message))
+	}
+	videos, _ := h.storage.Queries.ListProductVideos(ctx, productID)
+
+	if err := h.deleteArchivedProduct(ctx, productID); err != nil {
+		slog.Error("failed to delete product", "error", err, "product_id", productID)
+		return c.String(http.StatusInternalServerError, "Failed to delete product")
+	}
+	slog.Info("archived product deleted", "product_id", productID)
+	for _, video := range videos {
+		h.removeUploadedVideo(video.Provider, video.VideoRef, video.PosterUrl)
+	}
+
+	return c.Redirect(http.StatusSeeOther, archivedURL)
+}
+
+// deleteArchivedProduct deletes a product with the abandoned-cart snapshots and
+// importer links that would otherwise block it
+func (h *AdminHandler) deleteArchivedProduct(ctx context.Context, productID string) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin product delete: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	if err := queries.DeleteProductCartSnapshots(ctx, productID); err != nil {
+		return fmt.Errorf("failed to delete cart snapshots: %w", err)
+	}
+	if err := queries.ClearScrapedProductImport(ctx, sql.NullString{String: productID, Valid: true}); err != nil {
+		return fmt.Errorf("failed to unlink imported product: %w", err)
+	}
+	if err := queries.DeleteProduct(ctx, productID); err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	return tx.Commit()
+}
+
 func (h *AdminHandler) HandleDeleteProductImage(c echo.Context) error {
 	imageID := c.Param("imageId")
 	productID := c.QueryParam("product_id")
@@ -928,14 +1085,15 @@ func (h *AdminHandler) HandleDeleteProductImage(c echo.Context) error {
 	slog.Debug("deleting product image", "image_id", imageID, "product_id", productID)
 
 	// Get the image before deleting to remove file
-	images, err := h.storage.Queries.GetProductImages(c.Request().Context(), productID)
+	productImages, err := h.storage.Queries.GetProductImages(c.Request().Context(), productID)
 	if err == nil {
-		// Find and delete the file
-		for _, img := range images {
+		// Find and delete the file along with its resized copies
+		for _, img := range productImages {
 			if img.ID == imageID {
 				uploadDir := "public/images/products"
 				filepath := filepath.Join(uploadDir, img.ImageUrl)
 				os.Remove(filepath)
+				images.Default().Remove(img.ImageUrl)
 				slog.Debug("deleted image file from filesystem", "filepath", filepath)
 				break
 			}
@@ -1128,6 +1286,7 @@ func (h *AdminHandler) HandleToggleProductActive(c echo.Context) error {
 	}
 
 	isActive := product.IsActive.Valid && product.IsActive.Bool
+	h.queueStripeSync(productID)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"success":   true,
@@ -1159,35 +1318,27 @@ func (h *AdminHandler) HandleProductSearch(c echo.Context) error {
 		return c.JSON(http.StatusOK, []map[string]interface{}{})
 	}
 
-	// Get all products and filter by query
-	products, err := h.storage.Queries.ListAllProducts(c.Request().Context())
+	// Products by name or SKU, including their variants' SKUs
+	products, err := h.storage.Queries.AdminSearchProducts(c.Request().Context(), db.AdminSearchProductsParams{
+		Pattern:    likePattern(query),
+		LimitCount: 10,
+	})
 	if err != nil {
+		slog.Error("failed to search products", "error", err, "query", query)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to search products",
 		})
 	}
 
-	productsWithImages := h.buildProductsWithImages(c.Request().Context(), products)
-
-	// Filter products by name (case insensitive)
-	var results []map[string]interface{}
-	queryLower := strings.ToLower(query)
-
-	for _, p := range productsWithImages {
-		if strings.Contains(strings.ToLower(p.Product.Name), queryLower) {
-			results = append(results, map[string]interface{}{
-				"id":    p.Product.ID,
-				"name":  p.Product.Name,
-				"slug":  p.Product.Slug,
-				"price": float64(p.Product.PriceCents) / 100,
-				"image": p.ImageURL,
-			})
-
-			// Limit to 10 results
-			if len(results) >= 10 {
-				break
-			}
-		}
+	results := []map[string]interface{}{}
+	for _, p := range h.buildProductsWithImages(c.Request().Context(), products) {
+		results = append(results, map[string]interface{}{
+			"id":    p.Product.ID,
+			"name":  p.Product.Name,
+			"slug":  p.Product.Slug,
+			"price": float64(p.Product.PriceCents) / 100,
+			"image": p.ImageURL,
+		})
 	}
 
 	return c.JSON(http.StatusOK, results)
@@ -1304,12 +1455,15 @@ func (h *AdminHandler) HandleUpdateProductInline(c echo.Context) error {
 		StockQuantity: sql.NullInt64{Int64: stockQuantity, Valid: true},
 	}
 
-	product, err := h.storage.Queries.UpdateProductInline(c.Request().Context(), params)
+	product, err := updateProductSlug(c.Request().Context(), h.storage, productID, slug, func(queries *db.Queries) (db.Product, error) {
+		return queries.UpdateProductInline(c.Request().Context(), params)
+	})
 	if err != nil {
 		slog.Error("failed to update product inline", "error", err, "product_id", productID)
 		c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "Failed to update product", "type": "error"}}`)
 		return c.String(http.StatusInternalServerError, "Failed to update product")
 	}
+	h.queueStripeSync(productID)
 
 	// Fetch primary image
 	imageURL := ""
@@ -1435,12 +1589,15 @@ func (h *AdminHandler) HandleUpdateProductInlineMobile(c echo.Context) error {
 		StockQuantity: sql.NullInt64{Int64: stockQuantity, Valid: true},
 	}
 
-	product, err := h.storage.Queries.UpdateProductInline(c.Request().Context(), params)
+	product, err := updateProductSlug(c.Request().Context(), h.storage, productID, slug, func(queries *db.Queries) (db.Product, error) {
+		return queries.UpdateProductInline(c.Request().Context(), params)
+	})
 	if err != nil {
 		slog.Error("failed to update product inline mobile", "error", err, "product_id", productID)
 		c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "Failed to update product", "type": "error"}}`)
 		return c.String(http.StatusInternalServerError, "Failed to update product")
 	}
+	h.queueStripeSync(productID)
 
 	// Fetch primary image
 	imageURL := ""
@@ -1478,7 +1635,32 @@ func (h *AdminHandler) HandleCategoryForm(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Failed to fetch categories")
 	}
 
-	return Render(c, admin.CategoryForm(c, category, allCategories))
+	var featured []db.ListCategoryFeaturedProductsRow
+	var featureProducts []db.Product
+	var lineage []db.Category
+	var previousSlugs []db.CategorySlugHistory
+	if category != nil {
+		lineage, err = h.storage.Queries.ListCategoryAncestors(c.Request().Context(), category.ID)
+		if err != nil {
+			slog.Error("failed to fetch category lineage", "error", err, "category_id", category.ID)
+		}
+		previousSlugs, err = h.storage.Queries.ListCategorySlugHistory(c.Request().Context(), category.ID)
+		if err != nil {
+			slog.Error("failed to fetch category slug history", "error", err, "category_id", category.ID)
+		}
+		featured, err = h.storage.Queries.ListCategoryFeaturedProducts(c.Request().Context(), category.ID)
+		if err != nil {
+			slog.Error("failed to fetch featured products", "error", err, "category_id", category.ID)
+			featured = []db.ListCategoryFeaturedProductsRow{}
+		}
+		featureProducts, err = h.storage.Queries.ListProducts(c.Request().Context())
+		if err != nil {
+			slog.Error("failed to fetch products for featuring", "error", err)
+			featureProducts = []db.Product{}
+		}
+	}
+
+	return Render(c, admin.CategoryForm(c, category, allCategories, featured, featureProducts, lineage, previousSlugs))
 }
 
 func (h *AdminHandler) HandleCreateCategory(c echo.Context) error {
@@ -1490,6 +1672,11 @@ func (h *AdminHandler) HandleCreateCategory(c echo.Context) error {
 	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 	categoryID := uuid.New().String()
 
+	landing, err := parseCategoryLandingForm(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Failed to create category: "+err.Error())
+	}
+
 	var displayOrder sql.NullInt64
 	if displayOrderStr != "" {
 		if order, err := strconv.ParseInt(displayOrderStr, 10, 64); err == nil {
@@ -1498,18 +1685,31 @@ func (h *AdminHandler) HandleCreateCategory(c echo.Context) error {
 	}
 
 	params := db.CreateCategoryParams{
-		ID:           categoryID,
-		Name:         name,
-		Slug:         slug,
-		Description:  sql.NullString{String: description, Valid: description != ""},
-		ParentID:     sql.NullString{String: parentID, Valid: parentID != ""},
-		DisplayOrder: displayOrder,
-	}
-
-	_, err := h.storage.Queries.CreateCategory(c.Request().Context(), params)
+		ID:              categoryID,
+		Name:            name,
+		Slug:            slug,
+		Description:     sql.NullString{String: description, Valid: description != ""},
+		ParentID:        sql.NullString{String: parentID, Valid: parentID != ""},
+		DisplayOrder:    displayOrder,
+		BannerAltText:   landing.BannerAltText,
+		ContentMarkdown: landing.ContentMarkdown,
+		SeoTitle:        landing.SeoTitle,
+		SeoDescription:  landing.SeoDescription,
+	}
+
+	category, err := h.storage.Queries.CreateCategory(c.Request().Context(), params)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to create category: "+err.Error())
 	}
+	// The slug may once have belonged to a renamed category; it's this one's now
+	if err := h.storage.Queries.DeleteCategorySlugHistory(c.Request().Context(), slug); err != nil {
+		slog.Error("failed to clear reused category slug", "error", err, "slug", slug)
+	}
+
+	if err := h.saveCategoryBanner(c.Request().Context(), category, landing); err != nil {
+		slog.Error("failed to save category banner", "error", err, "category_id", category.ID)
+		return c.String(http.StatusInternalServerError, "Category created, but the banner failed to upload")
+	}
 
 	return c.Redirect(http.StatusSeeOther, "/admin")
 }
@@ -1524,6 +1724,31 @@ func (h *AdminHandler) HandleUpdateCategory(c echo.Context) error {
 
 	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 
+	landing, err := parseCategoryLandingForm(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Failed to update category: "+err.Error())
+	}
+
+	existing, err := h.storage.Queries.GetCategory(c.Request().Context(), categoryID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "Category not found")
+	}
+
+	// A category can't sit under itself or one of its own subcategories, or the
+	// shop navigation would loop
+	if parentID != "" {
+		ancestors, err := h.storage.Queries.ListCategoryAncestors(c.Request().Context(), parentID)
+		if err != nil {
+			slog.Error("failed to load parent category lineage", "error", err, "parent_id", parentID)
+			return c.String(http.StatusInternalServerError, "Failed to update category")
+		}
+		for _, ancestor := range ancestors {
+			if ancestor.ID == categoryID {
+				return c.String(http.StatusBadRequest, "A category can't be moved under itself or one of its subcategories")
+			}
+		}
+	}
+
 	var displayOrder sql.NullInt64
 	if displayOrderStr != "" {
 		if order, err := strconv.ParseInt(displayOrderStr, 10, 64); err == nil {
@@ -1532,18 +1757,43 @@ func (h *AdminHandler) HandleUpdateCategory(c echo.Context) error {
 	}
 
 	params := db.UpdateCategoryParams{
-		ID:           categoryID,
-		Name:         name,
-		Slug:         slug,
-		Description:  sql.NullString{String: description, Valid: description != ""},
-		ParentID:     sql.NullString{String: parentID, Valid: parentID != ""},
-		DisplayOrder: displayOrder,
+		ID:              categoryID,
+		Name:            name,
+		Slug:            slug,
+		Description:     sql.NullString{String: description, Valid: description != ""},
+		ParentID:        sql.NullString{String: parentID, Valid: parentID != ""},
+		DisplayOrder:    displayOrder,
+		BannerAltText:   landing.BannerAltText,
+		ContentMarkdown: landing.ContentMarkdown,
+		SeoTitle:        landing.SeoTitle,
+		SeoDescription:  landing.SeoDescription,
+	}
+
+	// A new name changes the slug, so the old shop URL is kept to redirect from
+	tx, err := h.storage.DB().BeginTx(c.Request().Context(), nil)
+	if err != nil {
+		slog.Error("failed to begin category update transaction", "error", err, "category_id", categoryID)
+		return c.String(http.StatusInternalServerError, "Failed to update category")
 	}
+	defer tx.Rollback()
 
-	_, err := h.storage.Queries.UpdateCategory(c.Request().Context(), params)
-	if err != nil {
+	queries := h.storage.Queries.WithTx(tx)
+	if _, err := queries.UpdateCategory(c.Request().Context(), params); err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to update category: "+err.Error())
 	}
+	if err := recordCategorySlugChange(c.Request().Context(), queries, categoryID, existing.Slug, slug); err != nil {
+		slog.Error("failed to record category slug change", "error", err, "category_id", categoryID, "old_slug", existing.Slug)
+		return c.String(http.StatusInternalServerError, "Failed to update category")
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit category update", "error", err, "category_id", categoryID)
+		return c.String(http.StatusInternalServerError, "Failed to update category")
+	}
+
+	if err := h.saveCategoryBanner(c.Request().Context(), existing, landing); err != nil {
+		slog.Error("failed to save category banner", "error", err, "category_id", categoryID)
+		return c.String(http.StatusInternalServerError, "Category updated, but the banner failed to upload")
+	}
 
 	return c.Redirect(http.StatusSeeOther, "/admin")
 }
@@ -1551,10 +1801,15 @@ func (h *AdminHandler) HandleUpdateCategory(c echo.Context) error {
 func (h *AdminHandler) HandleDeleteCategory(c echo.Context) error {
 	categoryID := c.Param("id")
 
-	err := h.storage.Queries.DeleteCategory(c.Request().Context(), categoryID)
+	category, err := h.storage.Queries.GetCategory(c.Request().Context(), categoryID)
 	if err != nil {
+		return c.String(http.StatusNotFound, "Category not found")
+	}
+
+	if err := h.storage.Queries.DeleteCategory(c.Request().Context(), categoryID); err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to delete category")
 	}
+	removeCategoryBanner(category.BannerImage)
 
 	return c.Redirect(http.StatusSeeOther, "/admin")
 }
@@ -1612,7 +1867,7 @@ func (h *AdminHandler) HandleDeveloperDashboard(c echo.Context) error {
 		dbStats.DatabaseSize = fmt.Sprintf("%.2f MB", float64(stat.Size())/1024/1024)
 	}
 
-	return Render(c, admin.DevOverview(c, sysInfo, dbStats, memStats))
+	return Render(c, admin.DevOverview(c, sysInfo, dbStats, memStats, tracing.Default().Slowest(5)))
 }
 
 func (h *AdminHandler) HandleDevSystem(c echo.Context) error {
@@ -1677,7 +1932,23 @@ func (h *AdminHandler) HandleDevDatabase(c echo.Context) error {
 		dbStats.DatabaseSize = fmt.Sprintf("%.2f MB", float64(stat.Size())/1024/1024)
 	}
 
-	return Render(c, admin.DevDatabase(c, sysInfo, dbStats))
+	connStats, err := h.storage.ConnectionStats(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to read database connection stats", "error", err)
+	}
+
+	return Render(c, admin.DevDatabase(c, sysInfo, dbStats, connStats))
+}
+
+// HandleDatabaseConnections reports the connection pool and the pragmas its
+// connections have
+func (h *AdminHandler) HandleDatabaseConnections(c echo.Context) error {
+	stats, err := h.storage.ConnectionStats(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to read database connection stats", "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to read connection stats"})
+	}
+	return c.JSON(http.StatusOK, stats)
 }
 
 func (h *AdminHandler) HandleDevMemory(c echo.Context) error {
@@ -1960,22 +2231,26 @@ func (h *AdminHandler) HandleGarbageCollect(c echo.Context) error {
 
 func (h *AdminHandler) HandleOrdersList(c echo.Context) error {
 	status := c.QueryParam("status")
+	preorders := c.QueryParam("preorder") != ""
 
 	var orders []db.Order
 	var err error
 
-	if status != "" {
+	switch {
+	case preorders:
+		orders, err = h.storage.Queries.ListOpenPreorderOrders(c.Request().Context())
+	case status != "":
 		orders, err = h.storage.Queries.ListOrdersByStatus(c.Request().Context(), sql.NullString{String: status, Valid: true})
-	} else {
+	default:
 		orders, err = h.storage.Queries.ListOrders(c.Request().Context())
 	}
 
 	if err != nil {
-		slog.Error("failed to fetch orders", "error", err, "status_filter", status)
+		slog.Error("failed to fetch orders", "error", err, "status_filter", status, "preorders", preorders)
 		return c.String(http.StatusInternalServerError, "Failed to fetch orders")
 	}
 
-	return Render(c, admin.OrdersList(c, orders))
+	return Render(c, admin.OrdersList(c, orders, h.preorderOrderIDs(c.Request().Context())))
 }
 
 func (h *AdminHandler) HandleOrderSearch(c echo.Context) error {
@@ -1984,46 +2259,40 @@ func (h *AdminHandler) HandleOrderSearch(c echo.Context) error {
 		return c.JSON(http.StatusOK, []map[string]interface{}{})
 	}
 
-	// Get all orders
-	orders, err := h.storage.Queries.ListOrders(c.Request().Context())
+	// Orders by customer name, email or order number
+	orders, err := h.storage.Queries.AdminSearchOrders(c.Request().Context(), db.AdminSearchOrdersParams{
+		Pattern:    likePattern(strings.TrimPrefix(query, "#")),
+		LimitCount: 10,
+	})
 	if err != nil {
+		slog.Error("failed to search orders", "error", err, "query", query)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to search orders",
 		})
 	}
 
-	// Filter orders by customer name (case insensitive)
-	var results []map[string]interface{}
-	queryLower := strings.ToLower(query)
-
+	results := []map[string]interface{}{}
 	for _, order := range orders {
-		if strings.Contains(strings.ToLower(order.CustomerName), queryLower) {
-			// Get item count for this order
-			orderItems, _ := h.storage.Queries.GetOrderItems(c.Request().Context(), order.ID)
-			itemCount := len(orderItems)
-
-			// Format date
-			var dateStr string
-			if order.CreatedAt.Valid {
-				dateStr = order.CreatedAt.Time.Format("Jan 2, 2006")
-			}
-
-			results = append(results, map[string]interface{}{
-				"id":             order.ID,
-				"order_number":   order.ID[:8],
-				"customer_name":  order.CustomerName,
-				"customer_email": order.CustomerEmail,
-				"total_cents":    order.TotalCents,
-				"status":         order.Status.String,
-				"created_at":     dateStr,
-				"item_count":     itemCount,
-			})
-
-			// Limit to 10 results
-			if len(results) >= 10 {
-				break
-			}
+		// Get item count for this order
+		orderItems, _ := h.storage.Queries.GetOrderItems(c.Request().Context(), order.ID)
+		itemCount := len(orderItems)
+
+		// Format date
+		var dateStr string
+		if order.CreatedAt.Valid {
+			dateStr = order.CreatedAt.Time.Format("Jan 2, 2006")
 		}
+
+		results = append(results, map[string]interface{}{
+			"id":             order.ID,
+			"order_number":   order.ID[:8],
+			"customer_name":  order.CustomerName,
+			"customer_email": order.CustomerEmail,
+			"total_cents":    order.TotalCents,
+			"status":         order.Status.String,
+			"created_at":     dateStr,
+			"item_count":     itemCount,
+		})
 	}
 
 	return c.JSON(http.StatusOK, results)
@@ -2060,7 +2329,41 @@ func (h *AdminHandler) HandleOrderDetail(c echo.Context) error {
 		slog.Error("failed to fetch shipping selection", "error", err, "order_id", orderID)
 	}
 
-	return Render(c, admin.OrderDetail(c, order, itemsWithImages, shippingSelection))
+	// The terms and policies the order was placed under, for answering disputes
+	orderPolicies, err := policies.NewLibrary(h.storage.Queries).ForOrder(ctx, orderID, order.CreatedAt.Time)
+	if err != nil {
+		slog.Error("failed to fetch order policies", "error", err, "order_id", orderID)
+	}
+
+	history, err := h.storage.Queries.ListOrderStatusHistory(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to fetch order status history", "error", err, "order_id", orderID)
+	}
+
+	return Render(c, admin.OrderDetail(c, order, itemsWithImages, shippingSelection, orderPolicies, h.orderRefunds(ctx, c, order, orderItems), h.orderShipments(ctx, order), h.orderEdits(ctx, order, orderItems), history))
+}
+
+// HandleOrderPackingSlip renders a printable packing slip for an order
+func (h *AdminHandler) HandleOrderPackingSlip(c echo.Context) error {
+	orderID := c.Param("id")
+	ctx := c.Request().Context()
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.String(http.StatusNotFound, "Order not found")
+		}
+		slog.Error("failed to fetch order for packing slip", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to fetch order")
+	}
+
+	orderItems, err := h.storage.Queries.GetOrderItems(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to fetch order items for packing slip", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to fetch order items")
+	}
+
+	return Render(c, admin.PackingSlip(order, orderItems))
 }
 
 // getOrderItemImages fetches all images for an order item (handles both regular products and variants)
@@ -2100,24 +2403,31 @@ func (h *AdminHandler) getOrderItemImages(ctx context.Context, item db.GetOrderI
 	return images
 }
 
+// HandleUpdateOrderStatus moves an order along its workflow, refusing moves the
+// workflow doesn't allow. Cancelling puts the order's units back into stock; shipping
+// saves any tracking given. Either way the change is recorded in the order's history.
 func (h *AdminHandler) HandleUpdateOrderStatus(c echo.Context) error {
 	orderID := c.Param("id")
 
 	// Try to read from JSON body first (for AJAX requests)
 	var requestBody struct {
 		Status         string `json:"status"`
+		Note           string `json:"note"`
 		Carrier        string `json:"carrier"`
 		TrackingNumber string `json:"tracking_number"`
 		TrackingURL    string `json:"tracking_url"`
 	}
 
-	var status string
+	var status, note string
 	if err := c.Bind(&requestBody); err == nil && requestBody.Status != "" {
 		status = requestBody.Status
+		note = requestBody.Note
 	} else {
 		// Fallback to form value (for form submissions)
 		status = c.FormValue("status")
+		note = c.FormValue("note")
 	}
+	note = strings.TrimSpace(note)
 
 	if status == "" {
 		return c.String(http.StatusBadRequest, "Status is required")
@@ -2125,18 +2435,75 @@ func (h *AdminHandler) HandleUpdateOrderStatus(c echo.Context) error {
 
 	ctx := c.Request().Context()
 
-	// Update order status
-	_, err := h.storage.Queries.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{
-		ID:     orderID,
-		Status: sql.NullString{String: status, Valid: true},
-	})
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch order for status change", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to update order status")
+	}
+	from := order.Status.String
+	if status == utils.OrderRefunded {
+		return c.String(http.StatusConflict, "Refund the order from its Refunds card instead")
+	}
+	if status == utils.OrderPartiallyShipped {
+		return c.String(http.StatusConflict, "Record the box from the order's Shipments card instead")
+	}
+	if !utils.CanMoveOrder(from, status) {
+		return c.String(http.StatusConflict, fmt.Sprintf("A %s order can't be marked %s", utils.OrderStatusLabel(from), utils.OrderStatusLabel(status)))
+	}
+
+	// Marking an order shipped sends whatever is left of it, with the tracking given
+	var shipmentLines []shipmentLine
+	if status == utils.OrderShipped {
+		items, err := h.storage.Queries.ListOrderItemsToShip(ctx, orderID)
+		if err != nil {
+			slog.Error("failed to fetch order items to ship", "error", err, "order_id", orderID)
+			return c.String(http.StatusInternalServerError, "Failed to update order status")
+		}
+		shipmentLines, _ = planShipment(items, nil)
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
 	if err != nil {
+		slog.Error("failed to begin order status transaction", "error", err, "order_id", orderID)
 		return c.String(http.StatusInternalServerError, "Failed to update order status")
 	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	if err := moveOrderStatus(ctx, queries, orderID, from, status, adminActor(c), note); err != nil {
+		if errors.Is(err, errOrderStatusChanged) {
+			return c.String(http.StatusConflict, "The order changed in the meantime; reload and try again")
+		}
+		slog.Error("failed to update order status", "error", err, "order_id", orderID, "from", from, "to", status)
+		return c.String(http.StatusInternalServerError, "Failed to update order status")
+	}
+
+	shipmentID := ""
+	if len(shipmentLines) > 0 {
+		trackingURL := requestBody.TrackingURL
+		if trackingURL == "" {
+			trackingURL = shipping.TrackingURL(requestBody.Carrier, requestBody.TrackingNumber)
+		}
+		shipmentID = uuid.New().String()
+		if err := saveShipment(ctx, queries, db.CreateOrderShipmentParams{
+			ID:             shipmentID,
+			OrderID:        orderID,
+			Carrier:        requestBody.Carrier,
+			TrackingNumber: requestBody.TrackingNumber,
+			TrackingUrl:    trackingURL,
+			CreatedBy:      adminActor(c),
+		}, shipmentLines); err != nil {
+			slog.Error("failed to record shipment for shipped order", "error", err, "order_id", orderID)
+			return c.String(http.StatusInternalServerError, "Failed to record the shipment")
+		}
+	}
 
 	// If changing to shipped and tracking info provided, update tracking
-	if status == "shipped" && requestBody.TrackingNumber != "" {
-		_, err := h.storage.Queries.UpdateOrderTracking(ctx, db.UpdateOrderTrackingParams{
+	if status == utils.OrderShipped && requestBody.TrackingNumber != "" {
+		_, err := queries.UpdateOrderTracking(ctx, db.UpdateOrderTrackingParams{
 			ID:             orderID,
 			TrackingNumber: sql.NullString{String: requestBody.TrackingNumber, Valid: true},
 			TrackingUrl:    sql.NullString{String: requestBody.TrackingURL, Valid: requestBody.TrackingURL != ""},
@@ -2147,6 +2514,24 @@ func (h *AdminHandler) HandleUpdateOrderStatus(c echo.Context) error {
 		}
 	}
 
+	if status == utils.OrderCancelled {
+		if err := restockCancelledOrder(ctx, queries, orderID); err != nil {
+			slog.Error("failed to restock cancelled order", "error", err, "order_id", orderID)
+			return c.String(http.StatusInternalServerError, "Failed to put the order's items back into stock")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit order status change", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to update order status")
+	}
+
+	slog.Info("order status updated", "order_id", orderID, "from", from, "to", status, "by", adminActor(c))
+	h.afterOrderStatusChange(ctx, orderID, status, "")
+	if shipmentID != "" {
+		go h.notifyShipment(orderID, shipmentID)
+	}
+
 	// Return JSON for AJAX requests
 	if c.Request().Header.Get("Content-Type") == "application/json" {
 		return c.JSON(http.StatusOK, map[string]string{"status": "success"})
@@ -2190,7 +2575,9 @@ func (h *AdminHandler) HandleGetOrderTrackingLookup(c echo.Context) error {
 	})
 }
 
-// HandleGetOrderShippingRates retrieves current shipping rates for an order's EasyPost shipment
+// HandleGetOrderShippingRates retrieves current shipping rates for an order's EasyPost shipment.
+// When inventory locations are set up, rates are quoted from the chosen location (the
+// default one unless location_id is given) so the label leaves from where the stock is.
 func (h *AdminHandler) HandleGetOrderShippingRates(c echo.Context) error {
 	orderID := c.Param("id")
 	ctx := c.Request().Context()
@@ -2206,16 +2593,85 @@ func (h *AdminHandler) HandleGetOrderShippingRates(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No EasyPost shipment linked to this order"})
 	}
 
-	// Get refreshed rates from EasyPost
-	rates, err := h.shippingService.RefreshShipmentRates(order.EasypostShipmentID.String)
+	locations, err := h.storage.Queries.ListActiveInventoryLocations(ctx)
 	if err != nil {
-		slog.Error("failed to refresh rates from EasyPost", "error", err, "shipment_id", order.EasypostShipmentID.String)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve shipping rates"})
+		slog.Error("failed to list inventory locations for rates", "error", err, "order_id", orderID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load locations"})
+	}
+
+	// A box of its own, or any box after the first label, needs a new shipment
+	box, problem := boxFromQuery(c)
+	if problem != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": problem})
+	}
+	labelBought := order.EasypostLabelUrl.Valid && order.EasypostLabelUrl.String != ""
+
+	locationOptions := make([]map[string]interface{}, 0, len(locations))
+	for _, loc := range locations {
+		locationOptions = append(locationOptions, map[string]interface{}{
+			"id":         loc.ID,
+			"name":       loc.Name,
+			"city":       loc.CityLocality + ", " + loc.StateProvince,
+			"is_default": loc.IsDefault,
+		})
+	}
+
+	shipmentID := order.EasypostShipmentID.String
+	locationID := ""
+	var rates []shipping.Rate
+	if len(locations) > 0 {
+		location := locations[0]
+		if want := c.QueryParam("location_id"); want != "" {
+			found := false
+			for _, loc := range locations {
+				if loc.ID == want {
+					location, found = loc, true
+					break
+				}
+			}
+			if !found {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Location not found"})
+			}
+		}
+		locationID = location.ID
+
+		// Quote a new shipment from the location's address
+		from := inventoryLocationAddress(location)
+		if box != nil {
+			rates, err = h.shippingService.BoxRates(shipmentID, &from, box)
+		} else {
+			rates, err = h.shippingService.RatesFromAddress(shipmentID, from)
+		}
+		if err != nil {
+			slog.Error("failed to get rates from location", "error", err, "shipment_id", shipmentID, "location_id", location.ID)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve shipping rates from " + location.Name})
+		}
+		if len(rates) > 0 && rates[0].ShipmentID != "" {
+			shipmentID = rates[0].ShipmentID
+		}
+	} else if box != nil || labelBought {
+		rates, err = h.shippingService.BoxRates(shipmentID, nil, box)
+		if err != nil {
+			slog.Error("failed to get rates for another box", "error", err, "shipment_id", shipmentID)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve shipping rates"})
+		}
+		if len(rates) > 0 && rates[0].ShipmentID != "" {
+			shipmentID = rates[0].ShipmentID
+		}
+	} else {
+		// Get refreshed rates from EasyPost
+		rates, err = h.shippingService.RefreshShipmentRates(shipmentID)
+		if err != nil {
+			slog.Error("failed to refresh rates from EasyPost", "error", err, "shipment_id", shipmentID)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve shipping rates"})
+		}
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"shipment_id": order.EasypostShipmentID.String,
+		"shipment_id": shipmentID,
 		"rates":       rates,
+		"locations":   locationOptions,
+		"location_id": locationID,
 	})
 }
 
@@ -2224,9 +2680,15 @@ func (h *AdminHandler) HandleBuyShippingLabel(c echo.Context) error {
 	orderID := c.Param("id")
 	ctx := c.Request().Context()
 
-	// Parse request body
+	// Parse request body. shipment_id and location_id come from rates quoted at an
+	// inventory location or for another box; without them the checkout shipment is
+	// used. items is how many units of each line are in the box, by line ID; without
+	// it the box holds everything left to ship.
 	var req struct {
-		RateID string `json:"rate_id"`
+		RateID     string           `json:"rate_id"`
+		ShipmentID string           `json:"shipment_id"`
+		LocationID string           `json:"location_id"`
+		Items      map[string]int64 `json:"items"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
@@ -2247,53 +2709,136 @@ func (h *AdminHandler) HandleBuyShippingLabel(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No EasyPost shipment linked to this order"})
 	}
 
-	// Check if label already purchased
-	if order.EasypostLabelUrl.Valid && order.EasypostLabelUrl.String != "" {
+	if status := order.Status.String; status != utils.OrderShipped && !utils.CanMoveOrder(status, utils.OrderShipped) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": fmt.Sprintf("A %s order can't be shipped", utils.OrderStatusLabel(status))})
+	}
+
+	shipmentID := order.EasypostShipmentID.String
+	if req.ShipmentID != "" {
+		shipmentID = req.ShipmentID
+	}
+
+	// A shipment only takes one label; the next box is quoted as a new shipment
+	if shipmentID == order.EasypostShipmentID.String && order.EasypostLabelUrl.Valid && order.EasypostLabelUrl.String != "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error":     "Label already purchased for this order",
+			"error":     "Label already purchased for this shipment; get rates for the next box",
 			"label_url": order.EasypostLabelUrl.String,
 		})
 	}
 
+	items, err := h.storage.Queries.ListOrderItemsToShip(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to fetch order items to ship", "error", err, "order_id", orderID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load order items"})
+	}
+	lines, problem := planShipment(items, req.Items)
+	if problem != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": problem})
+	}
+
 	// Buy shipping label from EasyPost
-	label, err := h.shippingService.CreateLabelFromShipment(order.EasypostShipmentID.String, req.RateID)
+	label, err := h.shippingService.CreateLabelFromShipment(shipmentID, req.RateID)
 	if err != nil {
 		slog.Error("failed to buy label from EasyPost", "error", err,
-			"shipment_id", order.EasypostShipmentID.String,
+			"shipment_id", shipmentID,
 			"rate_id", req.RateID)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to purchase shipping label"})
 	}
 
-	// Update order with label URL, tracking info, and set status to shipped
+	carrier, status, err := h.recordLabelPurchase(ctx, order, shipmentID, req.LocationID, adminActor(c), label, lines)
+	if err != nil {
+		slog.Error("failed to update order with label info", "error", err, "order_id", orderID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Label purchased but failed to update order"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success":         true,
+		"label_url":       label.LabelDownload.Hrefs.PDF,
+		"tracking_number": label.TrackingNumber,
+		"carrier":         carrier,
+		"status":          status,
+	})
+}
+
+// recordLabelPurchase saves a bought label as a shipment of lines, moving the order to
+// partially shipped or shipped and taking its items from the location they leave from.
+// shipmentID is the shipment the label was bought on. It returns the carrier recorded
+// and the order's status after the shipment.
+func (h *AdminHandler) recordLabelPurchase(ctx context.Context, order db.Order, shipmentID, locationID, changedBy string, label *shipping.Label, lines []shipmentLine) (string, string, error) {
+	orderID := order.ID
+
+	// Point the order at the shipment the label was bought on so tracking follows it
+	if shipmentID != order.EasypostShipmentID.String {
+		if err := h.storage.Queries.UpdateOrderEasypostShipment(ctx, db.UpdateOrderEasypostShipmentParams{
+			EasypostShipmentID: sql.NullString{String: shipmentID, Valid: true},
+			ID:                 orderID,
+		}); err != nil {
+			slog.Error("failed to update order shipment", "error", err, "order_id", orderID, "shipment_id", shipmentID)
+		}
+	}
+
+	// The order keeps the latest label and tracking
 	carrier := label.ServiceCode
 	if label.CarrierID != "" {
 		carrier = label.CarrierID
 	}
 
-	_, updateErr := h.storage.Queries.UpdateOrderLabel(ctx, db.UpdateOrderLabelParams{
+	_, err := h.storage.Queries.UpdateOrderLabel(ctx, db.UpdateOrderLabelParams{
 		ID:               orderID,
 		EasypostLabelUrl: sql.NullString{String: label.LabelDownload.Hrefs.PDF, Valid: true},
 		TrackingNumber:   sql.NullString{String: label.TrackingNumber, Valid: true},
 		Carrier:          sql.NullString{String: carrier, Valid: true},
-		Status:           sql.NullString{String: "shipped", Valid: true},
 	})
-	if updateErr != nil {
-		slog.Error("failed to update order with label info", "error", updateErr, "order_id", orderID)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Label purchased but failed to update order"})
+	if err != nil {
+		return "", "", err
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+	recordID := uuid.New().String()
+	moved, err := recordShipment(ctx, h.storage.Queries.WithTx(tx), order, db.CreateOrderShipmentParams{
+		ID:                 recordID,
+		OrderID:            orderID,
+		Carrier:            carrier,
+		TrackingNumber:     label.TrackingNumber,
+		TrackingUrl:        shipping.TrackingURL(carrier, label.TrackingNumber),
+		EasypostShipmentID: shipmentID,
+		LabelUrl:           label.LabelDownload.Hrefs.PDF,
+		LocationID:         locationID,
+		CreatedBy:          changedBy,
+	}, lines, changedBy, "Shipping label bought")
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("record shipment: %w", err)
+	}
+	status := order.Status.String
+	if moved != "" {
+		status = moved
 	}
 
 	slog.Info("shipping label purchased and order updated",
 		"order_id", orderID,
 		"tracking_number", label.TrackingNumber,
-		"carrier", carrier)
+		"carrier", carrier,
+		"status", status)
+
+	// Batch printing puts the image version of the label on a page with the packing slip
+	if label.LabelDownload.Hrefs.PNG != "" {
+		if err := h.storage.Queries.SetOrderLabelImageURL(ctx, db.SetOrderLabelImageURLParams{
+			LabelImageUrl: label.LabelDownload.Hrefs.PNG,
+			ID:            orderID,
+		}); err != nil {
+			slog.Error("failed to save label image URL", "error", err, "order_id", orderID)
+		}
+	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success":         true,
-		"label_url":       label.LabelDownload.Hrefs.PDF,
-		"tracking_number": label.TrackingNumber,
-		"carrier":         carrier,
-		"status":          "shipped",
-	})
+	h.afterShipment(ctx, orderID, recordID, moved, locationID)
+	return carrier, status, nil
 }
 
 // Quotes Management Functions
@@ -2377,6 +2922,28 @@ func (h *AdminHandler) HandleUpdateQuote(c echo.Context) error {
 
 // Events Management Functions
 
+// eventCapacity parses the seat limit; blank means unlimited (0)
+func eventCapacity(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	capacity, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || capacity < 0 {
+		return 0, fmt.Errorf("invalid capacity %q", raw)
+	}
+	return capacity, nil
+}
+
+// eventSlug normalises an admin-entered event slug. Without one it's made from the
+// title and start date, so a show that returns every year gets a new page each time.
+func eventSlug(slug, title string, startDate time.Time) string {
+	if strings.TrimSpace(slug) == "" {
+		slug = title + " " + startDate.Format("2006-01-02")
+	}
+	return bundleSlug(slug, title)
+}
+
 func (h *AdminHandler) HandleEventsList(c echo.Context) error {
 	filter := c.QueryParam("filter")
 
@@ -2444,8 +3011,14 @@ func (h *AdminHandler) HandleCreateEvent(c echo.Context) error {
 		}
 	}
 
+	capacity, err := eventCapacity(c.FormValue("capacity"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Capacity must be a whole number of seats")
+	}
+
 	isActive := isActiveStr == "on" || isActiveStr == "true"
 	eventID := uuid.New().String()
+	slug := eventSlug(c.FormValue("slug"), title, startDate)
 
 	params := db.CreateEventParams{
 		ID:          eventID,
@@ -2457,10 +3030,16 @@ func (h *AdminHandler) HandleCreateEvent(c echo.Context) error {
 		EndDate:     endDate,
 		Url:         sql.NullString{String: url, Valid: url != ""},
 		IsActive:    sql.NullBool{Bool: isActive, Valid: true},
+		Slug:        sql.NullString{String: slug, Valid: slug != ""},
+		Capacity:    capacity,
 	}
 
 	_, err = h.storage.Queries.CreateEvent(c.Request().Context(), params)
 	if err != nil {
+		slog.Error("failed to create event", "error", err, "slug", slug)
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return c.String(http.StatusConflict, "Another event already uses the slug "+slug)
+		}
 		return c.String(http.StatusInternalServerError, "Failed to create event: "+err.Error())
 	}
 
@@ -2495,7 +3074,13 @@ func (h *AdminHandler) HandleUpdateEvent(c echo.Context) error {
 		}
 	}
 
+	capacity, err := eventCapacity(c.FormValue("capacity"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Capacity must be a whole number of seats")
+	}
+
 	isActive := isActiveStr == "on" || isActiveStr == "true"
+	slug := eventSlug(c.FormValue("slug"), title, startDate)
 
 	params := db.UpdateEventParams{
 		ID:          eventID,
@@ -2507,10 +3092,16 @@ func (h *AdminHandler) HandleUpdateEvent(c echo.Context) error {
 		EndDate:     endDate,
 		Url:         sql.NullString{String: url, Valid: url != ""},
 		IsActive:    sql.NullBool{Bool: isActive, Valid: true},
+		Slug:        sql.NullString{String: slug, Valid: slug != ""},
+		Capacity:    capacity,
 	}
 
 	_, err = h.storage.Queries.UpdateEvent(c.Request().Context(), params)
 	if err != nil {
+		slog.Error("failed to update event", "error", err, "event_id", eventID, "slug", slug)
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return c.String(http.StatusConflict, "Another event already uses the slug "+slug)
+		}
 		return c.String(http.StatusInternalServerError, "Failed to update event: "+err.Error())
 	}
 
@@ -2869,6 +3460,24 @@ func (h *AdminHandler) HandleSaveShippingSettings(c echo.Context) error {
 	config.Shipping.RatePreferences.PresentTopN = presentTopN
 	config.Shipping.RatePreferences.Sort = c.FormValue("sort")
 
+	// A blank threshold turns free shipping off
+	config.Shipping.RatePreferences.FreeShippingThresholdCents = 0
+	if threshold := strings.TrimSpace(c.FormValue("free_shipping_threshold")); threshold != "" {
+		thresholdCents, err := parseCurrencyToCents(threshold)
+		if err != nil || thresholdCents < 0 {
+			return c.String(http.StatusBadRequest, "Invalid free shipping threshold")
+		}
+		config.Shipping.RatePreferences.FreeShippingThresholdCents = thresholdCents
+	}
+
+	// Batch label buying picks from these services, one per line or comma-separated
+	config.Shipping.RatePreferences.LabelServices = nil
+	for _, service := range strings.FieldsFunc(c.FormValue("label_services"), func(r rune) bool { return r == ',' || r == '\n' }) {
+		if service = strings.TrimSpace(service); service != "" {
+			config.Shipping.RatePreferences.LabelServices = append(config.Shipping.RatePreferences.LabelServices, service)
+		}
+	}
+
 	// Update label format
 	config.Shipping.Labels.Format = c.FormValue("label_format")
 
@@ -3027,6 +3636,7 @@ func createSampleOrderData() *email.OrderData {
 			Country:    "US",
 		},
 		PaymentIntentID: "pi_1234567890abcdef",
+		CustomerNotes:   "Use blue filament if possible.\nLeave at the side door.",
 	}
 }
 
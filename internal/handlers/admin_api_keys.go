@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -37,7 +38,9 @@ func (h *AdminHandler) HandleAdminAPIKeysList(c echo.Context) error {
 }
 
 type CreateAPIKeyRequest struct {
-	Name string `json:"name"`
+	Name               string   `json:"name"`
+	Scopes             []string `json:"scopes"`
+	RateLimitPerMinute int64    `json:"rate_limit_per_minute"`
 }
 
 type CreateAPIKeyResponse struct {
@@ -59,6 +62,23 @@ func (h *AdminHandler) HandleAdminAPIKeyCreate(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name is required"})
 	}
 
+	// Keys created without scopes keep the importer's original access
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{auth.ScopeProductsRead, auth.ScopeProductsWrite}
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsValidScope(scope) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown scope: " + scope})
+		}
+	}
+
+	if req.RateLimitPerMinute == 0 {
+		req.RateLimitPerMinute = auth.DefaultAPIKeyRateLimit
+	}
+	if req.RateLimitPerMinute < 1 || req.RateLimitPerMinute > 10000 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Rate limit must be between 1 and 10000 requests per minute"})
+	}
+
 	plaintext, hash, prefix, err := auth.GenerateAPIKey(req.Name)
 	if err != nil {
 		slog.Error("failed to generate API key", "error", err)
@@ -68,18 +88,19 @@ func (h *AdminHandler) HandleAdminAPIKeyCreate(c echo.Context) error {
 	id := uuid.New().String()
 
 	_, err = h.storage.Queries.CreateAPIKey(ctx, db.CreateAPIKeyParams{
-		ID:          id,
-		Name:        req.Name,
-		KeyHash:     hash,
-		KeyPrefix:   prefix,
-		Permissions: sql.NullString{String: "products:read,products:write", Valid: true},
+		ID:                 id,
+		Name:               req.Name,
+		KeyHash:            hash,
+		KeyPrefix:          prefix,
+		Permissions:        sql.NullString{String: strings.Join(req.Scopes, ","), Valid: true},
+		RateLimitPerMinute: req.RateLimitPerMinute,
 	})
 	if err != nil {
 		slog.Error("failed to create API key", "error", err, "name", req.Name)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create API key"})
 	}
 
-	slog.Info("API key created", "name", req.Name, "id", id)
+	slog.Info("API key created", "name", req.Name, "id", id, "scopes", req.Scopes)
 
 	return c.JSON(http.StatusCreated, CreateAPIKeyResponse{
 		ID:     id,
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// giftCardListLimit is how many cards the admin list shows
+const giftCardListLimit = 200
+
+type AdminGiftCardsHandler struct {
+	queries      *db.Queries
+	emailService *email.Service
+	ledger       *giftcards.Ledger
+}
+
+func NewAdminGiftCardsHandler(queries *db.Queries, emailService *email.Service) *AdminGiftCardsHandler {
+	return &AdminGiftCardsHandler{
+		queries:      queries,
+		emailService: emailService,
+		ledger:       giftcards.NewLedger(queries),
+	}
+}
+
+// HandleGiftCardsList shows gift cards, newest first, with the form to issue one
+func (h *AdminGiftCardsHandler) HandleGiftCardsList(c echo.Context) error {
+	ctx := c.Request().Context()
+	query := strings.TrimSpace(c.QueryParam("q"))
+
+	pattern := "%"
+	if query != "" {
+		pattern = likePattern(query)
+	}
+	cards, err := h.queries.ListGiftCards(ctx, db.ListGiftCardsParams{Pattern: pattern, LimitCount: giftCardListLimit})
+	if err != nil {
+		slog.Error("failed to list gift cards", "error", err)
+		return c.String(http.StatusInternalServerError, "Failed to load gift cards")
+	}
+	totals, err := h.queries.GetGiftCardTotals(ctx)
+	if err != nil {
+		slog.Error("failed to total gift card balances", "error", err)
+	}
+
+	return Render(c, admin.GiftCardsList(c, cards, totals, query, c.QueryParam("error"), time.Now()))
+}
+
+// HandleIssueGiftCard issues a card from admin, emailing it to the recipient when asked
+func (h *AdminGiftCardsHandler) HandleIssueGiftCard(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	amountCents, ok := parseGiftCardDollars(c.FormValue("amount"))
+	if !ok || amountCents <= 0 {
+		return c.Redirect(http.StatusSeeOther, "/admin/gift-cards?error="+url.QueryEscape("Enter the amount in dollars, like 25 or 49.99"))
+	}
+	expiresAt, err := parseBadgeDate(c.FormValue("expires_on"))
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/admin/gift-cards?error="+url.QueryEscape("Enter the expiry date as YYYY-MM-DD"))
+	}
+	// The card works through the whole of its last day
+	if expiresAt.Valid {
+		expiresAt.Time = expiresAt.Time.AddDate(0, 0, 1)
+	}
+	recipientEmail := strings.TrimSpace(c.FormValue("recipient_email"))
+	sendEmail := c.FormValue("send_email") == "on"
+	if sendEmail && recipientEmail == "" {
+		return c.Redirect(http.StatusSeeOther, "/admin/gift-cards?error="+url.QueryEscape("Enter the recipient's email to send the card"))
+	}
+
+	card, err := h.ledger.Issue(ctx, giftcards.IssueParams{
+		AmountCents:    amountCents,
+		ExpiresAt:      expiresAt,
+		RecipientEmail: recipientEmail,
+		RecipientName:  c.FormValue("recipient_name"),
+		SenderName:     c.FormValue("sender_name"),
+		Message:        c.FormValue("message"),
+		UserID:         adminUserID(c),
+		Note:           strings.TrimSpace(c.FormValue("note")),
+	})
+	if err != nil {
+		slog.Error("failed to issue gift card", "error", err, "amount_cents", amountCents)
+		return c.Redirect(http.StatusSeeOther, "/admin/gift-cards?error="+url.QueryEscape("Could not issue the gift card"))
+	}
+	slog.Info("gift card issued from admin", "gift_card_id", card.ID, "amount_cents", amountCents, "user_id", adminUserID(c))
+
+	if sendEmail {
+		sendGiftCardEmail(ctx, h.queries, h.emailService, card)
+	}
+	return c.Redirect(http.StatusSeeOther, "/admin/gift-cards/"+card.ID)
+}
+
+// HandleGiftCardDetail shows a card with its transaction history
+func (h *AdminGiftCardsHandler) HandleGiftCardDetail(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID := c.Param("id")
+
+	card, err := h.queries.GetGiftCard(ctx, cardID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, "Gift card not found")
+	}
+	if err != nil {
+		slog.Error("failed to get gift card", "error", err, "gift_card_id", cardID)
+		return c.String(http.StatusInternalServerError, "Failed to load gift card")
+	}
+	history, err := h.queries.ListGiftCardTransactions(ctx, cardID)
+	if err != nil {
+		slog.Error("failed to list gift card transactions", "error", err, "gift_card_id", cardID)
+	}
+
+	return Render(c, admin.GiftCardDetail(c, card, history, c.QueryParam("error"), c.QueryParam("saved"), time.Now()))
+}
+
+// HandleAdjustGiftCard adds to or takes from a card's balance, with a note saying why
+func (h *AdminGiftCardsHandler) HandleAdjustGiftCard(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID := c.Param("id")
+	detailURL := "/admin/gift-cards/" + cardID
+
+	amountCents, ok := parseGiftCardDollars(c.FormValue("amount"))
+	if !ok || amountCents == 0 {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Enter the amount to add, or a negative amount to take off"))
+	}
+	note := strings.TrimSpace(c.FormValue("note"))
+	if note == "" {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Add a note saying why the balance changed"))
+	}
+
+	balance, err := h.ledger.Adjust(ctx, cardID, amountCents, note, adminUserID(c))
+	var cardErr *giftcards.Error
+	if errors.As(err, &cardErr) {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape(cardErr.Message))
+	}
+	if err != nil {
+		slog.Error("failed to adjust gift card", "error", err, "gift_card_id", cardID, "amount_cents", amountCents)
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Could not adjust the balance"))
+	}
+
+	slog.Info("gift card balance adjusted", "gift_card_id", cardID, "amount_cents", amountCents, "balance_cents", balance, "user_id", adminUserID(c))
+	return c.Redirect(http.StatusSeeOther, detailURL+"?saved=adjusted")
+}
+
+// HandleVoidGiftCard cancels a card, zeroing its balance
+func (h *AdminGiftCardsHandler) HandleVoidGiftCard(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID := c.Param("id")
+	detailURL := "/admin/gift-cards/" + cardID
+
+	reason := strings.TrimSpace(c.FormValue("reason"))
+	if reason == "" {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Give a reason for voiding the card"))
+	}
+
+	err := h.ledger.Void(ctx, cardID, reason, adminUserID(c))
+	var cardErr *giftcards.Error
+	if errors.As(err, &cardErr) {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape(cardErr.Message))
+	}
+	if err != nil {
+		slog.Error("failed to void gift card", "error", err, "gift_card_id", cardID)
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Could not void the card"))
+	}
+
+	slog.Info("gift card voided", "gift_card_id", cardID, "reason", reason, "user_id", adminUserID(c))
+	return c.Redirect(http.StatusSeeOther, detailURL+"?saved=voided")
+}
+
+// HandleResendGiftCard emails a card to its recipient again
+func (h *AdminGiftCardsHandler) HandleResendGiftCard(c echo.Context) error {
+	ctx := c.Request().Context()
+	cardID := c.Param("id")
+	detailURL := "/admin/gift-cards/" + cardID
+
+	card, err := h.queries.GetGiftCard(ctx, cardID)
+	if err != nil {
+		slog.Error("failed to get gift card to resend", "error", err, "gift_card_id", cardID)
+		return c.String(http.StatusNotFound, "Gift card not found")
+	}
+	if card.RecipientEmail == "" {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("The card has no recipient email"))
+	}
+	if card.VoidedAt.Valid {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("The card is void"))
+	}
+
+	sendGiftCardEmail(ctx, h.queries, h.emailService, card)
+	return c.Redirect(http.StatusSeeOther, detailURL+"?saved=sent")
+}
+
+// parseGiftCardDollars reads a signed dollar amount from a form, in cents
+func parseGiftCardDollars(value string) (int64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "$", "")
+	if value == "" {
+		return 0, false
+	}
+	dollars, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(dollars) || math.IsInf(dollars, 0) {
+		return 0, false
+	}
+	return int64(math.Round(dollars * 100)), true
+}
+
+// adminUserID is the signed-in admin's user ID, for the audit trail
+func adminUserID(c echo.Context) string {
+	if user, ok := auth.GetDBUser(c); ok {
+		return user.ID
+	}
+	return ""
+}
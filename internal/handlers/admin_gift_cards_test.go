@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGiftCardDollars(t *testing.T) {
+	cases := []struct {
+		input string
+		cents int64
+		ok    bool
+	}{
+		{"25", 2500, true},
+		{"$49.99", 4999, true},
+		{" 10.5 ", 1050, true},
+		{"-7.25", -725, true},
+		{"0.1", 10, true},
+		{"", 0, false},
+		{"ten", 0, false},
+		{"NaN", 0, false},
+	}
+	for _, tc := range cases {
+		cents, ok := parseGiftCardDollars(tc.input)
+		assert.Equal(t, tc.ok, ok, tc.input)
+		assert.Equal(t, tc.cents, cents, tc.input)
+	}
+}
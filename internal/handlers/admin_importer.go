@@ -4,11 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
@@ -24,22 +24,28 @@ import (
 
 // AdminImporterHandler handles admin importer routes
 type AdminImporterHandler struct {
-	storage *storage.Storage
-	scraper importer.Scraper
+	storage  *storage.Storage
+	scrapers map[string]importer.Scraper
 }
 
 // NewAdminImporterHandler creates a new admin importer handler
 func NewAdminImporterHandler(s *storage.Storage) *AdminImporterHandler {
 	return &AdminImporterHandler{
-		storage: s,
-		scraper: importer.NewCults3DScraper(),
+		storage:  s,
+		scrapers: importer.NewScrapers(),
 	}
 }
 
 // HandleImporterDashboard shows the main importer dashboard
 func (h *AdminImporterHandler) HandleImporterDashboard(c echo.Context) error {
 	ctx := c.Request().Context()
+	data := h.dashboardData(ctx)
+	return admin.ImporterDashboard(c, data).Render(ctx, c.Response().Writer)
+}
 
+// dashboardData gathers the designers' stats, the recent jobs and the categories
+// pasted URLs can be imported into
+func (h *AdminImporterHandler) dashboardData(ctx context.Context) admin.ImporterDashboardData {
 	// Build designer stats
 	var designerStats []admin.DesignerStats
 	for _, designer := range importer.Designers {
@@ -86,12 +92,118 @@ func (h *AdminImporterHandler) HandleImporterDashboard(c echo.Context) error {
 		jobs = []db.ImportJob{}
 	}
 
-	data := admin.ImporterDashboardData{
+	categories, err := h.storage.Queries.ListCategories(ctx)
+	if err != nil {
+		slog.Error("failed to list categories", "error", err)
+		categories = []db.Category{}
+	}
+
+	return admin.ImporterDashboardData{
 		Designers:  designerStats,
 		RecentJobs: jobs,
+		Categories: categories,
 	}
+}
 
-	return admin.ImporterDashboard(c, data).Render(ctx, c.Response().Writer)
+// HandleQueueURLImport queues the pasted design URLs for the URL importer job, then shows
+// the job's progress
+func (h *AdminImporterHandler) HandleQueueURLImport(c echo.Context) error {
+	ctx := c.Request().Context()
+	text := c.FormValue("urls")
+	categoryID := c.FormValue("category_id")
+
+	urls, rejected := importer.ParseProductURLs(text)
+	errMsg := ""
+	switch {
+	case len(rejected) > 0:
+		errMsg = fmt.Sprintf("These aren't Cults3D or Thingiverse design URLs: %s", strings.Join(rejected, ", "))
+	case len(urls) == 0:
+		errMsg = "Paste at least one design URL."
+	case len(urls) > importer.MaxURLsPerImport:
+		errMsg = fmt.Sprintf("Import at most %d URLs at a time.", importer.MaxURLsPerImport)
+	}
+	if errMsg != "" {
+		data := h.dashboardData(ctx)
+		data.URLs = text
+		data.CategoryID = categoryID
+		data.Error = errMsg
+		c.Response().WriteHeader(http.StatusBadRequest)
+		return admin.ImporterDashboard(c, data).Render(ctx, c.Response().Writer)
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin URL import", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue import")
+	}
+	defer tx.Rollback()
+	qtx := h.storage.Queries.WithTx(tx)
+
+	jobID := uuid.New().String()
+	if _, err := qtx.CreateURLImportJob(ctx, db.CreateURLImportJobParams{
+		ID:         jobID,
+		TotalItems: sql.NullInt64{Int64: int64(len(urls)), Valid: true},
+	}); err != nil {
+		slog.Error("failed to create URL import job", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue import")
+	}
+	for i, u := range urls {
+		if err := qtx.CreateImportJobURL(ctx, db.CreateImportJobURLParams{
+			ID:         uuid.New().String(),
+			JobID:      jobID,
+			Position:   int64(i),
+			Url:        u.URL,
+			Platform:   u.Platform,
+			CategoryID: categoryID,
+		}); err != nil {
+			slog.Error("failed to queue URL", "error", err, "url", u.URL)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue import")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit URL import", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue import")
+	}
+
+	slog.Info("queued URL import", "job_id", jobID, "urls", len(urls))
+	return c.Redirect(http.StatusSeeOther, "/admin/importer/jobs/"+jobID)
+}
+
+// HandleURLImportJob shows a URL import's progress and what became of each URL
+func (h *AdminImporterHandler) HandleURLImportJob(c echo.Context) error {
+	data, err := h.urlImportJob(c)
+	if err != nil {
+		return err
+	}
+	return admin.ImporterURLJob(c, data).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// HandleURLImportJobProgress is the part of the URL import's page that polls for progress
+func (h *AdminImporterHandler) HandleURLImportJobProgress(c echo.Context) error {
+	data, err := h.urlImportJob(c)
+	if err != nil {
+		return err
+	}
+	return admin.ImporterURLJobProgress(data).Render(c.Request().Context(), c.Response().Writer)
+}
+
+func (h *AdminImporterHandler) urlImportJob(c echo.Context) (admin.ImporterURLJobData, error) {
+	ctx := c.Request().Context()
+	jobID := c.Param("id")
+
+	job, err := h.storage.Queries.GetImportJob(ctx, jobID)
+	if err != nil || job.JobType != "url_import" {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to get import job", "error", err, "job_id", jobID)
+		}
+		return admin.ImporterURLJobData{}, echo.NewHTTPError(http.StatusNotFound, "Import not found")
+	}
+	urls, err := h.storage.Queries.ListImportJobURLs(ctx, jobID)
+	if err != nil {
+		slog.Error("failed to list import job URLs", "error", err, "job_id", jobID)
+		return admin.ImporterURLJobData{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load import")
+	}
+	return admin.ImporterURLJobData{Job: job, URLs: urls}, nil
 }
 
 // HandleImporterDesignerDetail shows detail page for a designer
@@ -232,8 +344,20 @@ func (h *AdminImporterHandler) runScrapeJob(jobID string, designer importer.Desi
 		slog.Warn("ollama not available, skipping description generation")
 	}
 
+	scraper, ok := h.scrapers[src.Platform]
+	if !ok {
+		slog.Error("no scraper for platform", "platform", src.Platform, "job_id", jobID)
+		if failErr := h.storage.Queries.FailImportJob(ctx, db.FailImportJobParams{
+			ErrorMessage: sql.NullString{String: "no scraper for " + src.Platform, Valid: true},
+			ID:           jobID,
+		}); failErr != nil {
+			slog.Error("failed to mark job as failed", "error", failErr, "job_id", jobID)
+		}
+		return
+	}
+
 	// Fetch all product URLs
-	productURLs, err := h.scraper.FetchDesignerProducts(ctx, src.URL)
+	productURLs, err := scraper.FetchDesignerProducts(ctx, src.URL)
 	if err != nil {
 		slog.Error("failed to fetch designer products", "error", err, "job_id", jobID)
 		if failErr := h.storage.Queries.FailImportJob(ctx, db.FailImportJobParams{
@@ -259,7 +383,7 @@ func (h *AdminImporterHandler) runScrapeJob(jobID string, designer importer.Desi
 
 	// Fetch each product
 	for i, productURL := range productURLs {
-		product, err := h.scraper.FetchProduct(ctx, productURL)
+		product, err := scraper.FetchProduct(ctx, productURL)
 		if err != nil {
 			slog.Error("failed to fetch product", "error", err, "url", productURL, "job_id", jobID)
 			continue
@@ -433,28 +557,10 @@ func (h *AdminImporterHandler) runImportJob(jobID string, designer importer.Desi
 	// Import each product
 	imported := 0
 	for i, scraped := range products {
-		// Create default import settings for batch import
-		// (no variants - batch import creates simple products)
-		settings := &ImportSettings{
-			CategoryID:      categoryID,
-			BasePriceCents:  1500, // Default $15
-			Sizes:           []string{},
-			SizeAdjustments: make(map[string]int64),
-			IsNew:           true,
-			IsPremium:       false,
-			IsFeatured:      false,
-		}
-		// Use AI price if available, otherwise markup original price
-		if scraped.AiPriceCents.Valid && scraped.AiPriceCents.Int64 > 0 {
-			settings.BasePriceCents = scraped.AiPriceCents.Int64
-		} else if scraped.OriginalPriceCents.Valid && scraped.OriginalPriceCents.Int64 > 0 {
-			settings.BasePriceCents = scraped.OriginalPriceCents.Int64 * 2
-			if settings.BasePriceCents < 1000 {
-				settings.BasePriceCents = 1000
-			}
-		}
+		// Batch import creates simple products, without variants
+		settings := importer.DefaultImportSettings(scraped, categoryID)
 
-		err := h.importProduct(ctx, scraped, settings, designer.Name, downloader)
+		_, err := importer.ImportProduct(ctx, h.storage.Queries, scraped, settings, designer.Name, downloader)
 		if err != nil {
 			slog.Error("failed to import product", "error", err, "product_id", scraped.ID, "name", scraped.Name)
 			continue
@@ -484,214 +590,6 @@ func (h *AdminImporterHandler) runImportJob(jobID string, designer importer.Desi
 	slog.Info("import job completed", "job_id", jobID, "total", len(products), "imported", imported)
 }
 
-// importProduct imports a single scraped product
-func (h *AdminImporterHandler) importProduct(ctx context.Context, scraped db.ScrapedProduct, settings *ImportSettings, designerName string, downloader *importer.ImageDownloader) error {
-	productID := uuid.New().String()
-	slug := generateProductSlug(scraped.Name)
-
-	// Determine if product has variants (sizes selected)
-	hasVariants := len(settings.Sizes) > 0
-
-	// Create product
-	params := db.CreateProductParams{
-		ID:               productID,
-		Name:             scraped.Name,
-		Slug:             slug,
-		Description:      scraped.Description,
-		ShortDescription: sql.NullString{Valid: false},
-		PriceCents:       settings.BasePriceCents,
-		CategoryID:       sql.NullString{String: settings.CategoryID, Valid: settings.CategoryID != ""},
-		Sku:              sql.NullString{Valid: false},
-		StockQuantity:    sql.NullInt64{Int64: 100, Valid: true},
-		HasVariants:      sql.NullBool{Bool: hasVariants, Valid: true},
-		WeightGrams:      sql.NullInt64{Valid: false},
-		LeadTimeDays:     sql.NullInt64{Int64: 3, Valid: true},
-		IsActive:         sql.NullBool{Bool: true, Valid: true},
-		IsFeatured:       sql.NullBool{Bool: settings.IsFeatured, Valid: true},
-		IsPremium:        sql.NullBool{Bool: settings.IsPremium, Valid: true},
-		Disclaimer:       sql.NullString{Valid: false},
-		SeoTitle:         sql.NullString{String: scraped.Name, Valid: true},
-		SeoDescription:   scraped.Description,
-		SeoKeywords:      sql.NullString{Valid: false},
-		OgImageUrl:       sql.NullString{Valid: false},
-	}
-
-	_, err := h.storage.Queries.CreateProduct(ctx, params)
-	if err != nil {
-		slog.Error("failed to create product", "error", err, "name", scraped.Name)
-		return fmt.Errorf("create product: %w", err)
-	}
-
-	// Update product with source info and is_new flag
-	err = h.storage.Queries.UpdateProductSource(ctx, db.UpdateProductSourceParams{
-		SourceUrl:      sql.NullString{String: scraped.SourceUrl, Valid: true},
-		SourcePlatform: sql.NullString{String: scraped.Platform, Valid: true},
-		DesignerName:   sql.NullString{String: designerName, Valid: true},
-		ID:             productID,
-	})
-	if err != nil {
-		slog.Error("failed to update product source", "error", err, "product_id", productID)
-	}
-
-	// Set is_new flag if enabled
-	if settings.IsNew {
-		err = h.storage.Queries.UpdateProductIsNew(ctx, db.UpdateProductIsNewParams{
-			IsNew: sql.NullBool{Bool: true, Valid: true},
-			ID:    productID,
-		})
-		if err != nil {
-			slog.Error("failed to update product is_new", "error", err, "product_id", productID)
-		}
-	}
-
-	// Get images to import - prefer selected scraped images, fall back to original URLs
-	var imagesToImport []string
-	scrapedImages, err := h.storage.Queries.ListScrapedProductImages(ctx, scraped.ID)
-	if err == nil && len(scrapedImages) > 0 {
-		// Use downloaded scraped images that are selected for import
-		for _, img := range scrapedImages {
-			// For now, use all downloaded images (selection feature can be added later)
-			if img.LocalFilename.Valid && img.LocalFilename.String != "" && img.DownloadStatus.Valid && img.DownloadStatus.String == "downloaded" {
-				imagesToImport = append(imagesToImport, fmt.Sprintf("scraped/%s", img.LocalFilename.String))
-			}
-		}
-	}
-
-	// If no scraped images, try AI images
-	if len(imagesToImport) == 0 {
-		aiImages, err := h.storage.Queries.ListScrapedProductAIImages(ctx, scraped.ID)
-		if err == nil {
-			for _, img := range aiImages {
-				if img.LocalFilename != "" {
-					imagesToImport = append(imagesToImport, fmt.Sprintf("scraped/ai/%s", img.LocalFilename))
-				}
-			}
-		}
-	}
-
-	// If still no images, download from original URLs
-	if len(imagesToImport) == 0 && scraped.ImageUrls.Valid && scraped.ImageUrls.String != "" {
-		var imageURLs []string
-		if err := json.Unmarshal([]byte(scraped.ImageUrls.String), &imageURLs); err == nil && len(imageURLs) > 0 {
-			if len(imageURLs) > 5 {
-				imageURLs = imageURLs[:5]
-			}
-
-			downloaded, err := downloader.DownloadImages(ctx, imageURLs, productID)
-			if err != nil {
-				slog.Error("failed to download images", "error", err, "product_id", productID)
-			}
-			for _, img := range downloaded {
-				imagesToImport = append(imagesToImport, img.Filename)
-			}
-		}
-	}
-
-	// Create image records
-	for i, imgPath := range imagesToImport {
-		isPrimary := i == 0
-		_, err := h.storage.Queries.CreateProductImage(ctx, db.CreateProductImageParams{
-			ID:           uuid.New().String(),
-			ProductID:    productID,
-			ImageUrl:     imgPath,
-			AltText:      sql.NullString{String: scraped.Name, Valid: true},
-			DisplayOrder: sql.NullInt64{Int64: int64(i), Valid: true},
-			IsPrimary:    sql.NullBool{Bool: isPrimary, Valid: true},
-		})
-		if err != nil {
-			slog.Error("failed to create product image", "error", err, "product_id", productID, "path", imgPath)
-		}
-	}
-
-	// If product has variants (sizes), create style, size configs, and SKUs
-	if hasVariants {
-		// Create default style
-		styleID := uuid.New().String()
-		_, err = h.storage.Queries.CreateProductStyle(ctx, db.CreateProductStyleParams{
-			ID:           styleID,
-			ProductID:    productID,
-			Name:         "Default",
-			IsPrimary:    sql.NullBool{Bool: true, Valid: true},
-			DisplayOrder: sql.NullInt64{Int64: 0, Valid: true},
-		})
-		if err != nil {
-			slog.Error("failed to create product style", "error", err, "product_id", productID)
-		} else {
-			// Create style images from product images
-			for i, imgPath := range imagesToImport {
-				isPrimary := i == 0
-				_, err = h.storage.Queries.CreateProductStyleImage(ctx, db.CreateProductStyleImageParams{
-					ID:             uuid.New().String(),
-					ProductStyleID: styleID,
-					ImageUrl:       imgPath,
-					IsPrimary:      sql.NullBool{Bool: isPrimary, Valid: true},
-					DisplayOrder:   sql.NullInt64{Int64: int64(i), Valid: true},
-				})
-				if err != nil {
-					slog.Error("failed to create product style image", "error", err, "style_id", styleID, "path", imgPath)
-				}
-			}
-
-			// Create size configs and SKUs for each selected size
-			for i, sizeID := range settings.Sizes {
-				// Get price adjustment for this size (from form or default to 0)
-				priceAdjustment := int64(0)
-				if adj, ok := settings.SizeAdjustments[sizeID]; ok {
-					priceAdjustment = adj
-				}
-
-				// Create size config
-				_, err = h.storage.Queries.UpsertProductSizeConfig(ctx, db.UpsertProductSizeConfigParams{
-					ID:                   uuid.New().String(),
-					ProductID:            productID,
-					SizeID:               sizeID,
-					PriceAdjustmentCents: sql.NullInt64{Int64: priceAdjustment, Valid: true},
-					IsEnabled:            sql.NullBool{Bool: true, Valid: true},
-					DisplayOrder:         sql.NullInt64{Int64: int64(i), Valid: true},
-				})
-				if err != nil {
-					slog.Error("failed to create product size config", "error", err, "product_id", productID, "size_id", sizeID)
-					continue
-				}
-
-				// Create SKU for this style + size combination
-				skuCode := fmt.Sprintf("%s-%s", slug, sizeID)
-				_, err = h.storage.Queries.CreateProductSku(ctx, db.CreateProductSkuParams{
-					ID:                   uuid.New().String(),
-					ProductID:            productID,
-					ProductStyleID:       styleID,
-					SizeID:               sizeID,
-					Sku:                  skuCode,
-					PriceAdjustmentCents: sql.NullInt64{Int64: priceAdjustment, Valid: true},
-					StockQuantity:        sql.NullInt64{Int64: 100, Valid: true},
-					IsActive:             sql.NullBool{Bool: true, Valid: true},
-				})
-				if err != nil {
-					slog.Error("failed to create product SKU", "error", err, "product_id", productID, "style_id", styleID, "size_id", sizeID)
-				}
-			}
-		}
-	}
-
-	// Mark scraped product as imported
-	err = h.storage.Queries.MarkProductImported(ctx, db.MarkProductImportedParams{
-		ImportedProductID: sql.NullString{String: productID, Valid: true},
-		ID:                scraped.ID,
-	})
-	if err != nil {
-		slog.Error("failed to mark product imported", "error", err, "scraped_id", scraped.ID)
-	}
-
-	slog.Info("imported product",
-		"product_id", productID,
-		"name", scraped.Name,
-		"has_variants", hasVariants,
-		"sizes", len(settings.Sizes),
-		"images", len(imagesToImport),
-	)
-	return nil
-}
-
 // HandleScrapedProductDetail shows detail page for a scraped product
 func (h *AdminImporterHandler) HandleScrapedProductDetail(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -735,7 +633,7 @@ func (h *AdminImporterHandler) HandleScrapedProductDetail(c echo.Context) error
 			slog.Debug("failed to parse image URLs", "error", err, "product_id", productID)
 		} else {
 			// Deduplicate - same underlying image may appear in different formats (webp vs jpg)
-			imageURLs = deduplicateImageURLs(allURLs)
+			imageURLs = importer.DedupeImageURLs(allURLs)
 		}
 	}
 
@@ -833,7 +731,11 @@ func (h *AdminImporterHandler) HandleRescrapeProduct(c echo.Context) error {
 	}
 
 	// Re-fetch the product from source
-	freshProduct, err := h.scraper.FetchProduct(ctx, product.SourceUrl)
+	scraper, ok := h.scrapers[product.Platform]
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Products from "+product.Platform+" can't be re-scraped")
+	}
+	freshProduct, err := scraper.FetchProduct(ctx, product.SourceUrl)
 	if err != nil {
 		slog.Error("failed to re-fetch product", "error", err, "url", product.SourceUrl)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to re-scrape product")
@@ -872,17 +774,6 @@ func (h *AdminImporterHandler) HandleRescrapeProduct(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
-// ImportSettings holds configuration for importing a scraped product
-type ImportSettings struct {
-	CategoryID      string
-	BasePriceCents  int64
-	Sizes           []string         // Size IDs to enable
-	SizeAdjustments map[string]int64 // SizeID -> adjustment in cents
-	IsNew           bool
-	IsPremium       bool
-	IsFeatured      bool
-}
-
 // HandleImportSingleProduct imports a single scraped product
 func (h *AdminImporterHandler) HandleImportSingleProduct(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -915,7 +806,7 @@ func (h *AdminImporterHandler) HandleImportSingleProduct(c echo.Context) error {
 
 	// Import the product
 	downloader := importer.NewImageDownloader("public/images/products")
-	err = h.importProduct(ctx, scraped, settings, designerName, downloader)
+	_, err = importer.ImportProduct(ctx, h.storage.Queries, scraped, settings, designerName, downloader)
 	if err != nil {
 		slog.Error("failed to import product", "error", err, "product_id", productID)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to import product")
@@ -926,8 +817,8 @@ func (h *AdminImporterHandler) HandleImportSingleProduct(c echo.Context) error {
 }
 
 // parseImportSettings parses import settings from form values
-func parseImportSettings(c echo.Context) (*ImportSettings, error) {
-	settings := &ImportSettings{
+func parseImportSettings(c echo.Context) (*importer.ImportSettings, error) {
+	settings := &importer.ImportSettings{
 		CategoryID:      c.FormValue("category_id"),
 		BasePriceCents:  999, // Default $9.99
 		Sizes:           []string{},
@@ -1318,74 +1209,6 @@ func createDirIfNotExists(dir string) error {
 	return nil
 }
 
-// generateProductSlug creates a URL-friendly slug from a name
-func generateProductSlug(name string) string {
-	// Convert to lowercase
-	slug := strings.ToLower(name)
-
-	// Replace spaces with hyphens
-	slug = strings.ReplaceAll(slug, " ", "-")
-
-	// Remove special characters
-	reg := regexp.MustCompile(`[^a-z0-9-]`)
-	slug = reg.ReplaceAllString(slug, "")
-
-	// Remove multiple consecutive hyphens
-	reg = regexp.MustCompile(`-+`)
-	slug = reg.ReplaceAllString(slug, "-")
-
-	// Trim hyphens from ends
-	slug = strings.Trim(slug, "-")
-
-	// Add unique suffix to prevent duplicates
-	slug = slug + "-" + uuid.New().String()[:8]
-
-	return slug
-}
-
-// deduplicateImageURLs removes duplicate images that appear in different formats
-// (e.g., same image in webp and jpg format from CDN)
-func deduplicateImageURLs(urls []string) []string {
-	seen := make(map[string]bool)
-	var result []string
-
-	for _, url := range urls {
-		// Extract the underlying filename from the URL
-		// URLs look like: https://images.cults3d.com/.../filename.jpg
-		// or with format filter: https://images.cults3d.com/...format(webp)/.../filename.jpg
-		key := extractImageKey(url)
-		if key == "" {
-			key = url // fallback to full URL if extraction fails
-		}
-
-		if !seen[key] {
-			seen[key] = true
-			result = append(result, url)
-		}
-	}
-
-	return result
-}
-
-// extractImageKey extracts a unique identifier from an image URL
-// by finding the underlying filename
-func extractImageKey(url string) string {
-	// The URLs contain the original filename at the end
-	// Find the last path segment after the last /
-	lastSlash := strings.LastIndex(url, "/")
-	if lastSlash == -1 {
-		return ""
-	}
-
-	filename := url[lastSlash+1:]
-	// Remove query parameters if any
-	if idx := strings.Index(filename, "?"); idx != -1 {
-		filename = filename[:idx]
-	}
-
-	return filename
-}
-
 // HandleRegenerateDescription regenerates the AI description for a scraped product
 func (h *AdminImporterHandler) HandleRegenerateDescription(c echo.Context) error {
 	ctx := c.Request().Context()
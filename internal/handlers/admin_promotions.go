@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/promocodes"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/loganlanou/logans3d-v4/views/admin"
 )
@@ -124,7 +128,77 @@ func (h *AdminPromotionsHandler) HandlePromotionDetail(c echo.Context) error {
 		RedemptionRatePercent: activeStats.RedemptionRatePercent,
 	}
 
-	return admin.PromotionDetail(c, campaign, codes, combinedStats).Render(c.Request().Context(), c.Response().Writer)
+	// Code rules
+	rules := admin.PromotionRules{
+		Excluded: map[string]bool{},
+		Error:    c.QueryParam("error"),
+		Saved:    c.QueryParam("saved") == "1",
+	}
+	if rules.Categories, err = h.queries.ListCategories(ctx); err != nil {
+		slog.Error("failed to list categories for promotion rules", "error", err)
+	}
+	excluded, err := h.queries.ListCampaignExcludedCategories(ctx, campaignID)
+	if err != nil {
+		slog.Error("failed to list promotion excluded categories", "error", err, "campaign_id", campaignID)
+	}
+	for _, categoryID := range excluded {
+		rules.Excluded[categoryID] = true
+	}
+
+	return admin.PromotionDetail(c, campaign, codes, combinedStats, rules).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// HandleUpdatePromotionRules saves the exclusions, stacking policy and per-customer
+// limit checked when the campaign's codes are entered in the cart
+func (h *AdminPromotionsHandler) HandleUpdatePromotionRules(c echo.Context) error {
+	ctx := c.Request().Context()
+	campaignID := c.Param("id")
+	detailURL := fmt.Sprintf("/admin/promotions/%s", campaignID)
+
+	stacking := c.FormValue("stacking")
+	if !promocodes.ValidStacking(stacking) {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Choose how codes go with automatic cart promotions"))
+	}
+	maxUsesPerCustomer, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("max_uses_per_customer")), 10, 64)
+	if err != nil || maxUsesPerCustomer < 0 {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Uses per customer must be 0 or more"))
+	}
+
+	if _, err := h.queries.GetPromotionCampaignByID(ctx, campaignID); err != nil {
+		slog.Error("failed to get promotion campaign for rules", "error", err, "campaign_id", campaignID)
+		return c.String(http.StatusNotFound, "Campaign not found")
+	}
+
+	if err := h.queries.UpdatePromotionCampaignRules(ctx, db.UpdatePromotionCampaignRulesParams{
+		ExcludeSaleItems:   c.FormValue("exclude_sale_items") == "on",
+		Stacking:           stacking,
+		MaxUsesPerCustomer: maxUsesPerCustomer,
+		ID:                 campaignID,
+	}); err != nil {
+		slog.Error("failed to update promotion rules", "error", err, "campaign_id", campaignID)
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Could not save the rules"))
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Could not read the excluded categories"))
+	}
+	if err := h.queries.DeleteCampaignExcludedCategories(ctx, campaignID); err != nil {
+		slog.Error("failed to clear promotion excluded categories", "error", err, "campaign_id", campaignID)
+		return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Could not save the excluded categories"))
+	}
+	for _, categoryID := range form["excluded_category"] {
+		if err := h.queries.AddCampaignExcludedCategory(ctx, db.AddCampaignExcludedCategoryParams{
+			CampaignID: campaignID,
+			CategoryID: categoryID,
+		}); err != nil {
+			slog.Error("failed to add promotion excluded category", "error", err, "campaign_id", campaignID, "category_id", categoryID)
+			return c.Redirect(http.StatusSeeOther, detailURL+"?error="+url.QueryEscape("Could not save the excluded categories"))
+		}
+	}
+
+	slog.Info("promotion rules updated", "campaign_id", campaignID, "stacking", stacking, "max_uses_per_customer", maxUsesPerCustomer, "excluded_categories", len(form["excluded_category"]))
+	return c.Redirect(http.StatusSeeOther, detailURL+"?saved=1")
 }
 
 // HandlePopupStatus checks if popup has been shown to an email
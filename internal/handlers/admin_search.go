@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// adminSearchLimit is how many matches of each type the admin search returns
+const adminSearchLimit = 8
+
+// likeEscaper escapes LIKE wildcards for the admin search queries, which use \ as
+// their ESCAPE character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern matches text containing q anywhere
+func likePattern(q string) string {
+	return "%" + likeEscaper.Replace(q) + "%"
+}
+
+// HandleAdminSearch searches orders, products, users and contact requests at once.
+// The omnibox in the admin header asks for JSON; anything else gets the results page.
+func (h *AdminHandler) HandleAdminSearch(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	wantsJSON := strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON)
+
+	var groups []admin.SearchGroup
+	if query != "" {
+		var err error
+		groups, err = searchAdmin(c.Request().Context(), h.storage.Queries, query, adminSearchLimit)
+		if err != nil {
+			slog.Error("admin search failed", "error", err, "query", query)
+			if wantsJSON {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Search failed"})
+			}
+			return c.String(http.StatusInternalServerError, "Search failed")
+		}
+	}
+
+	if wantsJSON {
+		if groups == nil {
+			groups = []admin.SearchGroup{}
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"query":  query,
+			"groups": groups,
+		})
+	}
+	return Render(c, admin.SearchPage(c, query, groups))
+}
+
+// searchAdmin runs the admin search, returning up to limit matches of each type.
+// Types with no matches are left out.
+func searchAdmin(ctx context.Context, queries *db.Queries, query string, limit int64) ([]admin.SearchGroup, error) {
+	// Order numbers are shown as #01HX2ABC
+	pattern := likePattern(strings.TrimPrefix(query, "#"))
+	var groups []admin.SearchGroup
+
+	orders, err := queries.AdminSearchOrders(ctx, db.AdminSearchOrdersParams{Pattern: pattern, LimitCount: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+	if len(orders) > 0 {
+		group := admin.SearchGroup{Type: "order", Label: "Orders"}
+		for _, order := range orders {
+			number := order.ID
+			if len(number) > 8 {
+				number = number[:8]
+			}
+			group.Results = append(group.Results, admin.SearchResult{
+				Type:     "order",
+				ID:       order.ID,
+				Title:    fmt.Sprintf("#%s · %s", number, order.CustomerName),
+				Subtitle: fmt.Sprintf("%s · $%.2f", order.CustomerEmail, float64(order.TotalCents)/100),
+				Badge:    order.Status.String,
+				URL:      "/admin/orders/" + order.ID,
+			})
+		}
+		groups = append(groups, group)
+	}
+
+	products, err := queries.AdminSearchProducts(ctx, db.AdminSearchProductsParams{Pattern: pattern, LimitCount: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	if len(products) > 0 {
+		group := admin.SearchGroup{Type: "product", Label: "Products"}
+		for _, product := range products {
+			subtitle := fmt.Sprintf("$%.2f", float64(product.PriceCents)/100)
+			if product.Sku.Valid && product.Sku.String != "" {
+				subtitle = product.Sku.String + " · " + subtitle
+			}
+			result := admin.SearchResult{
+				Type:     "product",
+				ID:       product.ID,
+				Title:    product.Name,
+				Subtitle: subtitle,
+				URL:      "/admin/product/edit?id=" + url.QueryEscape(product.ID),
+			}
+			if product.IsActive.Valid && !product.IsActive.Bool {
+				result.Badge = "inactive"
+			}
+			group.Results = append(group.Results, result)
+		}
+		groups = append(groups, group)
+	}
+
+	users, err := queries.AdminSearchUsers(ctx, db.AdminSearchUsersParams{Pattern: pattern, LimitCount: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	if len(users) > 0 {
+		group := admin.SearchGroup{Type: "user", Label: "Users", MoreURL: "/admin/users?search=" + url.QueryEscape(query)}
+		for _, user := range users {
+			title := user.FullName
+			if title == "" {
+				title = user.Email
+			}
+			result := admin.SearchResult{
+				Type:     "user",
+				ID:       user.ID,
+				Title:    title,
+				Subtitle: user.Email,
+				URL:      "/admin/users/" + user.ID,
+			}
+			if user.IsAdmin {
+				result.Badge = "admin"
+			}
+			group.Results = append(group.Results, result)
+		}
+		groups = append(groups, group)
+	}
+
+	contacts, err := queries.AdminSearchContactRequests(ctx, db.AdminSearchContactRequestsParams{Pattern: pattern, LimitCount: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search contact requests: %w", err)
+	}
+	if len(contacts) > 0 {
+		group := admin.SearchGroup{Type: "contact", Label: "Contact Requests", MoreURL: "/admin/contacts?search=" + url.QueryEscape(query)}
+		for _, contact := range contacts {
+			subtitle := contact.Subject
+			if contact.Email.Valid && contact.Email.String != "" {
+				subtitle += " · " + contact.Email.String
+			}
+			group.Results = append(group.Results, admin.SearchResult{
+				Type:     "contact",
+				ID:       contact.ID,
+				Title:    strings.TrimSpace(contact.FirstName + " " + contact.LastName),
+				Subtitle: subtitle,
+				Badge:    contact.Status.String,
+				URL:      "/admin/contacts/" + contact.ID,
+			})
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
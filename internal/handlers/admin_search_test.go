@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func TestLikePattern(t *testing.T) {
+	assert.Equal(t, "%dragon%", likePattern("dragon"))
+	assert.Equal(t, `%50\% off%`, likePattern("50% off"))
+	assert.Equal(t, `%DRG\_RED%`, likePattern("DRG_RED"))
+	assert.Equal(t, `%a\\b%`, likePattern(`a\b`))
+}
+
+func TestSearchAdmin(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:         "prod-dragon",
+		Name:       "Articulated Dragon",
+		Slug:       "articulated-dragon",
+		PriceCents: 2500,
+		Sku:        sql.NullString{String: "DRG_RED", Valid: true},
+		IsActive:   sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:         "prod-drgxred",
+		Name:       "Dice Tower",
+		Slug:       "dice-tower",
+		PriceCents: 1800,
+		Sku:        sql.NullString{String: "DRGXRED", Valid: true},
+		IsActive:   sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	user, err := CreateTestUserWithEmail(queries, "dragon.fan@example.com")
+	require.NoError(t, err)
+
+	_, err = queries.CreateOrder(ctx, db.CreateOrderParams{
+		ID:            "01HXDRAGON0000000000000000",
+		UserID:        user.ID,
+		CustomerEmail: "dragon.fan@example.com",
+		CustomerName:  "Dana Smith",
+		SubtotalCents: 2500,
+		TotalCents:    2500,
+		Status:        sql.NullString{String: "received", Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = queries.CreateContactRequest(ctx, db.CreateContactRequestParams{
+		ID:        "contact-1",
+		FirstName: "Dana",
+		LastName:  "Smith",
+		Email:     sql.NullString{String: "dana@example.com", Valid: true},
+		Subject:   "custom",
+		Message:   "Can you print a bigger dragon?",
+		Status:    sql.NullString{String: "new", Valid: true},
+	})
+	require.NoError(t, err)
+
+	groups, err := searchAdmin(ctx, queries, "dragon", 8)
+	require.NoError(t, err)
+	byType := map[string]admin.SearchGroup{}
+	for _, group := range groups {
+		byType[group.Type] = group
+	}
+	require.Len(t, byType["order"].Results, 1)
+	assert.Equal(t, "/admin/orders/01HXDRAGON0000000000000000", byType["order"].Results[0].URL)
+	require.Len(t, byType["product"].Results, 1)
+	assert.Equal(t, "/admin/product/edit?id=prod-dragon", byType["product"].Results[0].URL)
+	require.Len(t, byType["user"].Results, 1)
+	assert.Equal(t, "/admin/users/"+user.ID, byType["user"].Results[0].URL)
+	assert.NotContains(t, byType, "contact", "the message body isn't searched")
+
+	// Order numbers match with or without the #
+	groups, err = searchAdmin(ctx, queries, "#01hxdrag", 8)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "order", groups[0].Type)
+
+	// Underscores match literally, not as a wildcard
+	groups, err = searchAdmin(ctx, queries, "DRG_RED", 8)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Results, 1)
+	assert.Equal(t, "prod-dragon", groups[0].Results[0].ID)
+
+	groups, err = searchAdmin(ctx, queries, "dana smith", 8)
+	require.NoError(t, err)
+	types := []string{}
+	for _, group := range groups {
+		types = append(types, group.Type)
+	}
+	assert.Equal(t, []string{"order", "contact"}, types)
+
+	groups, err = searchAdmin(ctx, queries, "nothing like this", 8)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// storeCreditListLimit is how many accounts and recent changes the admin list shows
+const storeCreditListLimit = 100
+
+// storeCreditHistoryLimit is how many changes an account's page shows
+const storeCreditHistoryLimit = 200
+
+type AdminStoreCreditHandler struct {
+	queries *db.Queries
+	ledger  *storecredit.Ledger
+}
+
+func NewAdminStoreCreditHandler(queries *db.Queries) *AdminStoreCreditHandler {
+	return &AdminStoreCreditHandler{
+		queries: queries,
+		ledger:  storecredit.NewLedger(queries),
+	}
+}
+
+// HandleStoreCreditList shows the accounts holding credit and the latest changes, with
+// the form to grant credit to a customer by email
+func (h *AdminStoreCreditHandler) HandleStoreCreditList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	accounts, err := h.queries.ListStoreCreditAccounts(ctx, storeCreditListLimit)
+	if err != nil {
+		slog.Error("failed to list store credit accounts", "error", err)
+		return c.String(http.StatusInternalServerError, "Failed to load store credit")
+	}
+	recent, err := h.queries.ListRecentStoreCreditTransactions(ctx, storeCreditListLimit)
+	if err != nil {
+		slog.Error("failed to list recent store credit changes", "error", err)
+	}
+	totals, err := h.queries.GetStoreCreditTotals(ctx)
+	if err != nil {
+		slog.Error("failed to total store credit balances", "error", err)
+	}
+
+	return Render(c, admin.StoreCreditList(c, accounts, recent, totals, c.QueryParam("error")))
+}
+
+// HandleGrantStoreCreditByEmail grants credit to the customer with the email entered
+// on the list page, then shows their account
+func (h *AdminStoreCreditHandler) HandleGrantStoreCreditByEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	email := strings.TrimSpace(c.FormValue("email"))
+	user, err := h.queries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Redirect(http.StatusSeeOther, "/admin/store-credit?error="+url.QueryEscape("No customer account uses that email"))
+	}
+	if err != nil {
+		slog.Error("failed to look up customer for store credit", "error", err)
+		return c.Redirect(http.StatusSeeOther, "/admin/store-credit?error="+url.QueryEscape("Could not look up the customer"))
+	}
+
+	return h.grant(c, user.ID, "/admin/store-credit")
+}
+
+// HandleStoreCreditAccount shows a customer's credit balance with its history
+func (h *AdminStoreCreditHandler) HandleStoreCreditAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID := c.Param("userId")
+
+	user, err := h.queries.GetUser(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, "Customer not found")
+	}
+	if err != nil {
+		slog.Error("failed to get customer for store credit", "error", err, "user_id", userID)
+		return c.String(http.StatusInternalServerError, "Failed to load store credit")
+	}
+	balance, err := h.ledger.Balance(ctx, userID)
+	if err != nil {
+		slog.Error("failed to get store credit balance", "error", err, "user_id", userID)
+		return c.String(http.StatusInternalServerError, "Failed to load store credit")
+	}
+	history, err := h.queries.ListStoreCreditTransactions(ctx, db.ListStoreCreditTransactionsParams{
+		UserID:     userID,
+		LimitCount: storeCreditHistoryLimit,
+	})
+	if err != nil {
+		slog.Error("failed to list store credit transactions", "error", err, "user_id", userID)
+	}
+
+	return Render(c, admin.StoreCreditAccount(c, user, balance, history, c.QueryParam("error"), c.QueryParam("saved")))
+}
+
+// HandleGrantStoreCredit adds credit to a customer's account, with the reason and a note
+func (h *AdminStoreCreditHandler) HandleGrantStoreCredit(c echo.Context) error {
+	userID := c.Param("userId")
+	return h.grant(c, userID, storeCreditAccountURL(userID))
+}
+
+// grant reads the grant form and adds the credit, sending errors back to errorURL
+func (h *AdminStoreCreditHandler) grant(c echo.Context, userID, errorURL string) error {
+	ctx := c.Request().Context()
+
+	amountCents, ok := parseGiftCardDollars(c.FormValue("amount"))
+	if !ok || amountCents <= 0 {
+		return c.Redirect(http.StatusSeeOther, errorURL+"?error="+url.QueryEscape("Enter the amount in dollars, like 10 or 24.99"))
+	}
+	note := strings.TrimSpace(c.FormValue("note"))
+	if note == "" {
+		return c.Redirect(http.StatusSeeOther, errorURL+"?error="+url.QueryEscape("Add a note saying why the credit was granted"))
+	}
+
+	balance, err := h.ledger.Grant(ctx, storecredit.GrantParams{
+		UserID:      userID,
+		AmountCents: amountCents,
+		Reason:      c.FormValue("reason"),
+		Note:        note,
+		AdminID:     adminUserID(c),
+	})
+	var creditErr *storecredit.Error
+	if errors.As(err, &creditErr) {
+		return c.Redirect(http.StatusSeeOther, errorURL+"?error="+url.QueryEscape(creditErr.Message))
+	}
+	if err != nil {
+		slog.Error("failed to grant store credit", "error", err, "user_id", userID, "amount_cents", amountCents)
+		return c.Redirect(http.StatusSeeOther, errorURL+"?error="+url.QueryEscape("Could not grant the credit"))
+	}
+
+	slog.Info("store credit granted", "user_id", userID, "amount_cents", amountCents, "reason", c.FormValue("reason"), "balance_cents", balance, "by", adminUserID(c))
+	return c.Redirect(http.StatusSeeOther, storeCreditAccountURL(userID)+"?saved=granted")
+}
+
+// HandleRevokeStoreCredit takes credit back off a customer's account, with a note
+// saying why
+func (h *AdminStoreCreditHandler) HandleRevokeStoreCredit(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID := c.Param("userId")
+	accountURL := storeCreditAccountURL(userID)
+
+	amountCents, ok := parseGiftCardDollars(c.FormValue("amount"))
+	if !ok || amountCents <= 0 {
+		return c.Redirect(http.StatusSeeOther, accountURL+"?error="+url.QueryEscape("Enter the amount to revoke in dollars"))
+	}
+	note := strings.TrimSpace(c.FormValue("note"))
+	if note == "" {
+		return c.Redirect(http.StatusSeeOther, accountURL+"?error="+url.QueryEscape("Add a note saying why the credit was revoked"))
+	}
+
+	balance, err := h.ledger.Revoke(ctx, userID, amountCents, note, adminUserID(c))
+	var creditErr *storecredit.Error
+	if errors.As(err, &creditErr) {
+		return c.Redirect(http.StatusSeeOther, accountURL+"?error="+url.QueryEscape(creditErr.Message))
+	}
+	if err != nil {
+		slog.Error("failed to revoke store credit", "error", err, "user_id", userID, "amount_cents", amountCents)
+		return c.Redirect(http.StatusSeeOther, accountURL+"?error="+url.QueryEscape("Could not revoke the credit"))
+	}
+
+	slog.Info("store credit revoked", "user_id", userID, "amount_cents", amountCents, "balance_cents", balance, "by", adminUserID(c))
+	return c.Redirect(http.StatusSeeOther, accountURL+"?saved=revoked")
+}
+
+func storeCreditAccountURL(userID string) string {
+	return "/admin/store-credit/" + userID
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+)
+
+func TestAdminStoreCreditGrantAndRevoke(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+	h := NewAdminStoreCreditHandler(queries)
+
+	customer, err := CreateTestUserWithEmail(queries, "customer@example.com")
+	require.NoError(t, err)
+
+	post := func(path string, form url.Values, handler echo.HandlerFunc) string {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("userId")
+		c.SetParamValues(customer.ID)
+		require.NoError(t, handler(c))
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		return rec.Header().Get(echo.HeaderLocation)
+	}
+	accountURL := "/admin/store-credit/" + customer.ID
+
+	location := post(accountURL+"/grant", url.Values{"amount": {"15"}, "reason": {storecredit.ReasonGoodwill}}, h.HandleGrantStoreCredit)
+	assert.Contains(t, location, "error=", "a grant needs a note")
+
+	location = post("/admin/store-credit", url.Values{"email": {"nobody@example.com"}, "amount": {"15"}, "reason": {storecredit.ReasonGoodwill}, "note": {"Late order"}}, h.HandleGrantStoreCreditByEmail)
+	assert.Contains(t, location, "/admin/store-credit?error=")
+
+	location = post("/admin/store-credit", url.Values{"email": {"customer@example.com"}, "amount": {"15"}, "reason": {storecredit.ReasonGoodwill}, "note": {"Late order"}}, h.HandleGrantStoreCreditByEmail)
+	assert.Equal(t, accountURL+"?saved=granted", location)
+
+	location = post(accountURL+"/revoke", url.Values{"amount": {"20"}, "note": {"Too much"}}, h.HandleRevokeStoreCredit)
+	assert.Contains(t, location, "error=", "credit can't go below zero")
+
+	location = post(accountURL+"/revoke", url.Values{"amount": {"5"}, "note": {"Partly granted by mistake"}}, h.HandleRevokeStoreCredit)
+	assert.Equal(t, accountURL+"?saved=revoked", location)
+
+	balance, err := storecredit.NewLedger(queries).Balance(context.Background(), customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), balance)
+}
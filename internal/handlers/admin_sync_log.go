@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+const syncLogPageSize = 200
+
+// HandleSyncLog shows the most recent product pushes received from the sync CLI
+func (h *AdminHandler) HandleSyncLog(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	entries, err := h.storage.Queries.ListProductSyncLog(ctx, syncLogPageSize)
+	if err != nil {
+		slog.Error("failed to list product sync log", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load sync log")
+	}
+
+	return Render(c, admin.SyncLog(c, entries))
+}
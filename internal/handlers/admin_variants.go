@@ -14,6 +14,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/images"
 	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/loganlanou/logans3d-v4/views/admin"
@@ -130,6 +131,7 @@ func (h *AdminHandler) HandleCreateProductStyle(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to write uploaded file")
 		}
 		dst.Close()
+		images.Default().GenerateInBackground(filename)
 
 		isFirstImage := idx == 0
 		if _, err := h.storage.Queries.CreateProductStyleImage(ctx, db.CreateProductStyleImageParams{
@@ -472,6 +474,7 @@ func (h *AdminHandler) HandleCreateProductSKU(c echo.Context) error {
 	}); err != nil {
 		slog.Error("failed to update product variant flag", "error", err)
 	}
+	h.queueStripeSync(productID)
 
 	return c.Redirect(http.StatusSeeOther, "/admin/product/edit?id="+productID)
 }
@@ -486,10 +489,13 @@ func (h *AdminHandler) HandleDeleteProductSku(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "missing sku or product")
 	}
 
+	sku, _ := h.storage.Queries.GetProductSku(ctx, skuID)
+
 	if err := h.storage.Queries.DeleteProductSku(ctx, skuID); err != nil {
 		slog.Error("failed to delete SKU", "error", err, "sku_id", skuID)
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete SKU")
 	}
+	h.queueStripeArchive("", sku.StripePriceID.String)
 
 	return c.Redirect(http.StatusSeeOther, "/admin/product/edit?id="+productID)
 }
@@ -780,6 +786,7 @@ func (h *AdminHandler) HandleAddStyleImages(c echo.Context) error {
 		}
 		src.Close()
 		dst.Close()
+		images.Default().GenerateInBackground(newFilename)
 
 		// First image becomes primary if no existing primary
 		isPrimary := !hasPrimary && i == 0
@@ -829,7 +836,12 @@ func (h *AdminHandler) HandleUpdateSkuPrice(c echo.Context) error {
 		c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "Failed to update price", "type": "error"}}`)
 		return c.NoContent(http.StatusInternalServerError)
 	}
+	// Mid-sale, the new adjustment is kept for when the sale ends
+	if err := h.storage.Queries.KeepSkuSalePrice(ctx, skuID); err != nil {
+		slog.Error("failed to keep SKU sale price", "error", err, "sku_id", skuID)
+	}
 
+	h.queueStripeSyncForSku(ctx, skuID)
 	c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "Price updated", "type": "success"}}`)
 
 	// If we have style_id and product_id, refresh the panel
@@ -897,6 +909,8 @@ func (h *AdminHandler) HandleToggleSkuActive(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
+	h.queueStripeSyncForSku(ctx, skuID)
+
 	statusText := "deactivated"
 	if isActive {
 		statusText = "activated"
@@ -970,6 +984,7 @@ func (h *AdminHandler) HandleAddStyleSku(c echo.Context) error {
 		return h.HandleGetStylePanel(c)
 	}
 
+	h.queueStripeSync(productID)
 	c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "SKU created", "type": "success"}}`)
 	return h.HandleGetStylePanel(c)
 }
@@ -985,6 +1000,8 @@ func (h *AdminHandler) HandleDeleteSkuFromPanel(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "missing SKU ID")
 	}
 
+	sku, _ := h.storage.Queries.GetProductSku(ctx, skuID)
+
 	if err := h.storage.Queries.DeleteProductSku(ctx, skuID); err != nil {
 		slog.Error("failed to delete SKU", "error", err, "sku_id", skuID)
 		c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "Failed to delete SKU", "type": "error"}}`)
@@ -994,6 +1011,7 @@ func (h *AdminHandler) HandleDeleteSkuFromPanel(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
+	h.queueStripeArchive("", sku.StripePriceID.String)
 	c.Response().Header().Set("HX-Trigger", `{"showToast": {"message": "SKU deleted", "type": "success"}}`)
 
 	// Refresh the panel
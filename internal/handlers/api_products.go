@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/images"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
@@ -120,6 +122,9 @@ func (h *APIProductsHandler) GetProduct(c echo.Context) error {
 		slog.Error("failed to get product", "error", err, "id", id)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get product")
 	}
+	if product.DeletedAt.Valid {
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
 
 	return c.JSON(http.StatusOK, productToResponse(product))
 }
@@ -264,9 +269,10 @@ func (h *APIProductsHandler) DeleteProduct(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete product")
 	}
 
-	err = h.store.Queries.DeleteProduct(c.Request().Context(), id)
+	// Products are archived, as from admin, so orders keep their products
+	_, err = h.store.Queries.ArchiveProduct(c.Request().Context(), id)
 	if err != nil {
-		slog.Error("failed to delete product", "error", err, "id", id)
+		slog.Error("failed to archive product", "error", err, "id", id)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete product")
 	}
 
@@ -335,7 +341,9 @@ func (h *APIProductsHandler) UpdateProduct(c echo.Context) error {
 		ID:               id,
 	}
 
-	product, err := h.store.Queries.UpdateProduct(c.Request().Context(), params)
+	product, err := updateProductSlug(c.Request().Context(), h.store, id, req.Slug, func(queries *db.Queries) (db.Product, error) {
+		return queries.UpdateProduct(c.Request().Context(), params)
+	})
 	if err != nil {
 		slog.Error("failed to update product", "error", err, "id", id)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update product")
@@ -442,37 +450,9 @@ func (h *APIProductsHandler) AddProductImage(c echo.Context) error {
 	// Try multipart file upload first
 	file, err := c.FormFile("image")
 	if err == nil && file != nil {
-		// Handle file upload
-		src, err := file.Open()
+		imageFilename, err = saveProductImageUpload(productID, file)
 		if err != nil {
-			slog.Error("failed to open uploaded file", "error", err)
-			return echo.NewHTTPError(http.StatusBadRequest, "Failed to open uploaded file")
-		}
-		defer src.Close()
-
-		uploadDir := "public/images/products"
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			slog.Error("failed to create upload directory", "error", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save image")
-		}
-
-		ext := filepath.Ext(file.Filename)
-		if ext == "" {
-			ext = ".jpg"
-		}
-		imageFilename = fmt.Sprintf("%s_%d%s", productID, time.Now().UnixNano(), ext)
-		filePath := filepath.Join(uploadDir, imageFilename)
-
-		dst, err := os.Create(filePath)
-		if err != nil {
-			slog.Error("failed to create image file", "error", err)
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save image")
-		}
-		defer dst.Close()
-
-		if _, err = io.Copy(dst, src); err != nil {
-			os.Remove(filePath)
-			slog.Error("failed to write image file", "error", err)
+			slog.Error("failed to save uploaded image", "error", err, "product_id", productID)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save image")
 		}
 
@@ -524,6 +504,41 @@ func (h *APIProductsHandler) AddProductImage(c echo.Context) error {
 	return c.JSON(http.StatusCreated, image)
 }
 
+// saveProductImageUpload stores an uploaded product image under public/images/products
+// and returns the filename to record on the product_images row
+func saveProductImageUpload(productID string, file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("open upload: %w", err)
+	}
+	defer src.Close()
+
+	uploadDir := "public/images/products"
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return "", fmt.Errorf("create upload directory: %w", err)
+	}
+
+	ext := filepath.Ext(file.Filename)
+	if ext == "" {
+		ext = ".jpg"
+	}
+	filename := fmt.Sprintf("%s_%d%s", productID, time.Now().UnixNano(), ext)
+	filePath := filepath.Join(uploadDir, filename)
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("create image file: %w", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(filePath)
+		return "", fmt.Errorf("write image file: %w", err)
+	}
+	images.Default().GenerateInBackground(filename)
+	return filename, nil
+}
+
 func productToResponse(p db.Product) ProductResponse {
 	resp := ProductResponse{
 		ID:         p.ID,
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+const (
+	storefrontDefaultPageSize = 50
+	storefrontMaxPageSize     = 200
+)
+
+// APIStorefrontHandler serves the read-only catalog API used by external tools
+// (POS, marketplace listers). Only active products are exposed.
+type APIStorefrontHandler struct {
+	store *storage.Storage
+}
+
+func NewAPIStorefrontHandler(store *storage.Storage) *APIStorefrontHandler {
+	return &APIStorefrontHandler{store: store}
+}
+
+type StorefrontProduct struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Slug             string `json:"slug"`
+	ShortDescription string `json:"short_description,omitempty"`
+	PriceCents       int64  `json:"price_cents"`
+	CategoryID       string `json:"category_id,omitempty"`
+	SKU              string `json:"sku,omitempty"`
+	StockQuantity    int64  `json:"stock_quantity"`
+	HasVariants      bool   `json:"has_variants"`
+	ProductType      string `json:"product_type"`
+	ImageURL         string `json:"image_url,omitempty"`
+	URL              string `json:"url"`
+	UpdatedAt        string `json:"updated_at,omitempty"`
+}
+
+type StorefrontProductList struct {
+	Products []StorefrontProduct `json:"products"`
+	Total    int64               `json:"total"`
+	Limit    int64               `json:"limit"`
+	Offset   int64               `json:"offset"`
+}
+
+type StorefrontProductDetail struct {
+	StorefrontProduct
+	Description    string                `json:"description,omitempty"`
+	AllowBackorder bool                  `json:"allow_backorder"`
+	IsPreorder     bool                  `json:"is_preorder"`
+	Images         []StorefrontImage     `json:"images"`
+	Variants       []StorefrontVariant   `json:"variants"`
+	Attributes     []StorefrontAttribute `json:"attributes"`
+}
+
+type StorefrontImage struct {
+	URL       string `json:"url"`
+	AltText   string `json:"alt_text,omitempty"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type StorefrontVariant struct {
+	ID            string `json:"id"`
+	SKU           string `json:"sku"`
+	Style         string `json:"style"`
+	Size          string `json:"size"`
+	PriceCents    int64  `json:"price_cents"`
+	StockQuantity int64  `json:"stock_quantity"`
+	ImageURL      string `json:"image_url,omitempty"`
+}
+
+type StorefrontAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+	Unit  string `json:"unit,omitempty"`
+}
+
+type StorefrontCategory struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	Description  string `json:"description,omitempty"`
+	ParentID     string `json:"parent_id,omitempty"`
+	DisplayOrder int64  `json:"display_order"`
+}
+
+type StorefrontStockItem struct {
+	ProductID      string               `json:"product_id"`
+	Slug           string               `json:"slug"`
+	SKU            string               `json:"sku,omitempty"`
+	ProductType    string               `json:"product_type"`
+	StockQuantity  int64                `json:"stock_quantity"`
+	AllowBackorder bool                 `json:"allow_backorder"`
+	IsPreorder     bool                 `json:"is_preorder"`
+	Variants       []StorefrontSkuStock `json:"variants,omitempty"`
+}
+
+type StorefrontSkuStock struct {
+	ID            string `json:"id"`
+	SKU           string `json:"sku"`
+	StockQuantity int64  `json:"stock_quantity"`
+}
+
+// requireScope rejects the request unless its API key was granted scope
+func requireScope(c echo.Context, scope string) error {
+	apiKey := auth.GetAPIKeyInfo(c.Request().Context())
+	if apiKey == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "API key required")
+	}
+	if !apiKey.HasPermission(scope) {
+		return echo.NewHTTPError(http.StatusForbidden, "Permission denied: "+scope+" required")
+	}
+	return nil
+}
+
+// storefrontPage reads limit/offset query params, clamping the page size
+func storefrontPage(limitParam, offsetParam string) (int64, int64) {
+	limit, err := strconv.ParseInt(limitParam, 10, 64)
+	if err != nil || limit <= 0 {
+		limit = storefrontDefaultPageSize
+	}
+	if limit > storefrontMaxPageSize {
+		limit = storefrontMaxPageSize
+	}
+	offset, err := strconv.ParseInt(offsetParam, 10, 64)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// siteURL is the origin the request came in on, used to make image and product links absolute
+func siteURL(c echo.Context) string {
+	return c.Scheme() + "://" + c.Request().Host
+}
+
+// buildStorefrontVariants turns a product's active SKUs into API variants priced
+// from the product's base price
+func buildStorefrontVariants(baseURL string, basePriceCents int64, skus []db.GetProductSkusRow) []StorefrontVariant {
+	variants := make([]StorefrontVariant, 0, len(skus))
+	for _, sku := range skus {
+		if sku.IsActive.Valid && !sku.IsActive.Bool {
+			continue
+		}
+		variant := StorefrontVariant{
+			ID:            sku.ID,
+			SKU:           sku.Sku,
+			Style:         sku.StyleName,
+			Size:          sku.SizeDisplayName,
+			PriceCents:    basePriceCents + int64FromNull(sku.PriceAdjustmentCents),
+			StockQuantity: int64FromNull(sku.StockQuantity),
+		}
+		if sku.StylePrimaryImage != "" {
+			variant.ImageURL = baseURL + "/public/images/products/styles/" + sku.StylePrimaryImage
+		}
+		variants = append(variants, variant)
+	}
+	return variants
+}
+
+// ListProducts returns a page of active products, optionally filtered by category ID
+func (h *APIStorefrontHandler) ListProducts(c echo.Context) error {
+	if err := requireScope(c, auth.ScopeCatalogRead); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+
+	categoryID := c.QueryParam("category_id")
+	category := sql.NullString{String: categoryID, Valid: categoryID != ""}
+	limit, offset := storefrontPage(c.QueryParam("limit"), c.QueryParam("offset"))
+
+	rows, err := h.store.Queries.ListStorefrontProducts(ctx, db.ListStorefrontProductsParams{
+		CategoryID: category,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		slog.Error("failed to list storefront products", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list products")
+	}
+
+	total, err := h.store.Queries.CountStorefrontProducts(ctx, category)
+	if err != nil {
+		slog.Error("failed to count storefront products", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list products")
+	}
+
+	baseURL := siteURL(c)
+	products := make([]StorefrontProduct, len(rows))
+	for i, row := range rows {
+		products[i] = StorefrontProduct{
+			ID:               row.ID,
+			Name:             row.Name,
+			Slug:             row.Slug,
+			ShortDescription: row.ShortDescription.String,
+			PriceCents:       row.PriceCents,
+			CategoryID:       row.CategoryID.String,
+			SKU:              row.Sku.String,
+			StockQuantity:    int64FromNull(row.StockQuantity),
+			HasVariants:      row.HasVariants.Valid && row.HasVariants.Bool,
+			ProductType:      row.ProductType,
+			URL:              baseURL + "/shop/product/" + row.Slug,
+		}
+		if row.PrimaryImageUrl != "" {
+			products[i].ImageURL = baseURL + "/public/images/products/" + row.PrimaryImageUrl
+		}
+		if row.UpdatedAt.Valid {
+			products[i].UpdatedAt = row.UpdatedAt.Time.UTC().Format(time.RFC3339)
+		}
+	}
+
+	return c.JSON(http.StatusOK, StorefrontProductList{
+		Products: products,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
+
+// GetProduct returns one active product by ID or slug, with images, variants and attributes
+func (h *APIStorefrontHandler) GetProduct(c echo.Context) error {
+	if err := requireScope(c, auth.ScopeCatalogRead); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	idOrSlug := c.Param("id")
+
+	product, err := h.store.Queries.GetProduct(ctx, idOrSlug)
+	if errors.Is(err, sql.ErrNoRows) {
+		product, err = h.store.Queries.GetProductBySlug(ctx, idOrSlug)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+		}
+		slog.Error("failed to get storefront product", "error", err, "product", idOrSlug)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get product")
+	}
+	if product.IsActive.Valid && !product.IsActive.Bool {
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	images, err := h.store.Queries.GetProductImages(ctx, product.ID)
+	if err != nil {
+		slog.Error("failed to get product images", "error", err, "product_id", product.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get product")
+	}
+
+	skus, err := h.store.Queries.GetProductSkus(ctx, product.ID)
+	if err != nil {
+		slog.Error("failed to get product SKUs", "error", err, "product_id", product.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get product")
+	}
+
+	attributes, err := h.store.Queries.ListProductAttributes(ctx, product.ID)
+	if err != nil {
+		slog.Error("failed to get product attributes", "error", err, "product_id", product.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get product")
+	}
+
+	baseURL := siteURL(c)
+	detail := StorefrontProductDetail{
+		StorefrontProduct: StorefrontProduct{
+			ID:               product.ID,
+			Name:             product.Name,
+			Slug:             product.Slug,
+			ShortDescription: product.ShortDescription.String,
+			PriceCents:       product.PriceCents,
+			CategoryID:       product.CategoryID.String,
+			SKU:              product.Sku.String,
+			StockQuantity:    int64FromNull(product.StockQuantity),
+			HasVariants:      product.HasVariants.Valid && product.HasVariants.Bool,
+			ProductType:      product.ProductType,
+			URL:              baseURL + "/shop/product/" + product.Slug,
+		},
+		Description:    product.Description.String,
+		AllowBackorder: product.AllowBackorder,
+		IsPreorder:     product.IsPreorder,
+		Images:         make([]StorefrontImage, len(images)),
+		Variants:       buildStorefrontVariants(baseURL, product.PriceCents, skus),
+		Attributes:     make([]StorefrontAttribute, len(attributes)),
+	}
+	if product.UpdatedAt.Valid {
+		detail.UpdatedAt = product.UpdatedAt.Time.UTC().Format(time.RFC3339)
+	}
+	for i, image := range images {
+		detail.Images[i] = StorefrontImage{
+			URL:       baseURL + "/public/images/products/" + image.ImageUrl,
+			AltText:   image.AltText.String,
+			IsPrimary: image.IsPrimary.Valid && image.IsPrimary.Bool,
+		}
+		if detail.Images[i].IsPrimary {
+			detail.ImageURL = detail.Images[i].URL
+		}
+	}
+	if detail.ImageURL == "" && len(detail.Images) > 0 {
+		detail.ImageURL = detail.Images[0].URL
+	}
+	for i, attribute := range attributes {
+		detail.Attributes[i] = StorefrontAttribute{Name: attribute.Name, Value: attribute.Value, Type: attribute.ValueType, Unit: attribute.Unit}
+	}
+
+	return c.JSON(http.StatusOK, detail)
+}
+
+// ListCategories returns every category in display order
+func (h *APIStorefrontHandler) ListCategories(c echo.Context) error {
+	if err := requireScope(c, auth.ScopeCatalogRead); err != nil {
+		return err
+	}
+
+	categories, err := h.store.Queries.ListCategories(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to list categories", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list categories")
+	}
+
+	response := make([]StorefrontCategory, len(categories))
+	for i, category := range categories {
+		response[i] = StorefrontCategory{
+			ID:           category.ID,
+			Name:         category.Name,
+			Slug:         category.Slug,
+			Description:  category.Description.String,
+			ParentID:     category.ParentID.String,
+			DisplayOrder: int64FromNull(category.DisplayOrder),
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListStock returns stock levels for every active product and its active SKUs
+func (h *APIStorefrontHandler) ListStock(c echo.Context) error {
+	if err := requireScope(c, auth.ScopeInventoryRead); err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+
+	products, err := h.store.Queries.ListStorefrontProductStock(ctx)
+	if err != nil {
+		slog.Error("failed to list product stock", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list stock")
+	}
+
+	skus, err := h.store.Queries.ListStorefrontSkuStock(ctx)
+	if err != nil {
+		slog.Error("failed to list SKU stock", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list stock")
+	}
+
+	skusByProduct := make(map[string][]StorefrontSkuStock)
+	for _, sku := range skus {
+		if sku.IsActive.Valid && !sku.IsActive.Bool {
+			continue
+		}
+		skusByProduct[sku.ProductID] = append(skusByProduct[sku.ProductID], StorefrontSkuStock{
+			ID:            sku.ID,
+			SKU:           sku.Sku,
+			StockQuantity: int64FromNull(sku.StockQuantity),
+		})
+	}
+
+	response := make([]StorefrontStockItem, len(products))
+	for i, product := range products {
+		response[i] = StorefrontStockItem{
+			ProductID:      product.ID,
+			Slug:           product.Slug,
+			SKU:            product.Sku.String,
+			ProductType:    product.ProductType,
+			StockQuantity:  int64FromNull(product.StockQuantity),
+			AllowBackorder: product.AllowBackorder,
+			IsPreorder:     product.IsPreorder,
+		}
+		if product.HasVariants.Valid && product.HasVariants.Bool {
+			response[i].Variants = skusByProduct[product.ID]
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildStorefrontVariants(t *testing.T) {
+	skus := []db.GetProductSkusRow{
+		{ID: "sku-1", Sku: "RED-S", StyleName: "Red", SizeDisplayName: "Small", PriceAdjustmentCents: sql.NullInt64{Int64: 250, Valid: true}, StockQuantity: sql.NullInt64{Int64: 4, Valid: true}, IsActive: sql.NullBool{Bool: true, Valid: true}, StylePrimaryImage: "red.jpg"},
+		{ID: "sku-2", Sku: "RED-M", StyleName: "Red", SizeDisplayName: "Medium", IsActive: sql.NullBool{Bool: false, Valid: true}},
+		{ID: "sku-3", Sku: "BLUE-S", StyleName: "Blue", SizeDisplayName: "Small"},
+	}
+
+	variants := buildStorefrontVariants("https://example.com", 1500, skus)
+
+	require.Len(t, variants, 2, "inactive SKUs are not exposed")
+	assert.Equal(t, StorefrontVariant{
+		ID:            "sku-1",
+		SKU:           "RED-S",
+		Style:         "Red",
+		Size:          "Small",
+		PriceCents:    1750,
+		StockQuantity: 4,
+		ImageURL:      "https://example.com/public/images/products/styles/red.jpg",
+	}, variants[0])
+	assert.Equal(t, int64(1500), variants[1].PriceCents)
+	assert.Empty(t, variants[1].ImageURL)
+}
+
+func TestStorefrontPage(t *testing.T) {
+	limit, offset := storefrontPage("", "")
+	assert.Equal(t, int64(storefrontDefaultPageSize), limit)
+	assert.Equal(t, int64(0), offset)
+
+	limit, offset = storefrontPage("1000", "-5")
+	assert.Equal(t, int64(storefrontMaxPageSize), limit)
+	assert.Equal(t, int64(0), offset)
+
+	limit, offset = storefrontPage("25", "50")
+	assert.Equal(t, int64(25), limit)
+	assert.Equal(t, int64(50), offset)
+}
@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	productsync "github.com/loganlanou/logans3d-v4/internal/sync"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Sync log actions
+const (
+	syncActionCreated  = "created"
+	syncActionUpdated  = "updated"
+	syncActionConflict = "conflict"
+	syncActionFailed   = "failed"
+)
+
+// APISyncHandler receives product pushes from the sync CLI (scripts/sync)
+type APISyncHandler struct {
+	store *storage.Storage
+}
+
+func NewAPISyncHandler(store *storage.Storage) *APISyncHandler {
+	return &APISyncHandler{store: store}
+}
+
+// Health confirms the API key works and the database is reachable
+func (h *APISyncHandler) Health(c echo.Context) error {
+	ctx := c.Request().Context()
+	apiKey := auth.GetAPIKeyInfo(ctx)
+	if apiKey == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "API key required")
+	}
+
+	if err := h.store.DB().PingContext(ctx); err != nil {
+		slog.Error("sync health check failed to reach database", "error", err)
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"status":    "ok",
+		"api_key":   apiKey.Name,
+		"can_write": apiKey.HasPermission(auth.ScopeProductsWrite),
+		"time":      time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// parseSyncRequest reads the pushed product from a JSON body, or from the "product"
+// field of a multipart form whose "images" files replace the product's gallery
+func parseSyncRequest(c echo.Context) (productsync.ProductRequest, []*multipart.FileHeader, error) {
+	var req productsync.ProductRequest
+
+	if !strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		if err := c.Bind(&req); err != nil {
+			return req, nil, errors.New("invalid JSON body")
+		}
+		return req, nil, nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return req, nil, errors.New("invalid multipart form")
+	}
+	payload := form.Value["product"]
+	if len(payload) == 0 {
+		return req, nil, errors.New("missing product field")
+	}
+	if err := json.Unmarshal([]byte(payload[0]), &req); err != nil {
+		return req, nil, errors.New("product field is not valid JSON")
+	}
+	return req, form.File["images"], nil
+}
+
+// parseReleaseDate accepts RFC3339 or YYYY-MM-DD
+func parseReleaseDate(value *string) (sql.NullTime, error) {
+	if value == nil || *value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", *value)
+		if err != nil {
+			return sql.NullTime{}, errors.New("invalid release_date format. Use RFC3339 or YYYY-MM-DD")
+		}
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// resolveSyncTarget picks the product a push should update, or nil to create one.
+// A product with the same source URL always wins; otherwise the slug decides. It
+// returns a conflict message when the slug or SKU already belongs to a different
+// product, so a push never silently overwrites something else.
+func resolveSyncTarget(req productsync.ProductRequest, bySource, bySlug, bySku *db.Product) (*db.Product, string) {
+	target := bySource
+	if target == nil && bySlug != nil {
+		if req.SourceURL != "" && bySlug.SourceUrl.Valid && bySlug.SourceUrl.String != "" && bySlug.SourceUrl.String != req.SourceURL {
+			return nil, fmt.Sprintf("slug %q belongs to a product imported from a different source (%s)", bySlug.Slug, bySlug.SourceUrl.String)
+		}
+		target = bySlug
+	}
+
+	if bySlug != nil && target != nil && bySlug.ID != target.ID {
+		return nil, fmt.Sprintf("slug %q is already used by another product (%s)", bySlug.Slug, bySlug.Name)
+	}
+	if bySku != nil && (target == nil || bySku.ID != target.ID) {
+		return nil, fmt.Sprintf("SKU %q is already used by another product (%s)", req.SKU, bySku.Name)
+	}
+	return target, ""
+}
+
+// findProduct turns a lookup's no-rows error into a nil product
+func findProduct(product db.Product, err error) (*db.Product, error) {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// logSync records a push in the sync audit log. Failures are logged, not returned,
+// so auditing never blocks a sync.
+func (h *APISyncHandler) logSync(ctx context.Context, apiKey *auth.APIKeyInfo, req productsync.ProductRequest, productID, action string, images int, message string) {
+	err := h.store.Queries.CreateProductSyncLog(ctx, db.CreateProductSyncLogParams{
+		ID:             uuid.New().String(),
+		ApiKeyID:       sql.NullString{String: apiKey.ID, Valid: true},
+		ApiKeyName:     apiKey.Name,
+		ProductID:      sql.NullString{String: productID, Valid: productID != ""},
+		ProductName:    req.Name,
+		Slug:           req.Slug,
+		Sku:            sql.NullString{String: req.SKU, Valid: req.SKU != ""},
+		SourceUrl:      sql.NullString{String: req.SourceURL, Valid: req.SourceURL != ""},
+		Action:         action,
+		ImagesUploaded: int64(images),
+		Message:        sql.NullString{String: message, Valid: message != ""},
+	})
+	if err != nil {
+		slog.Error("failed to write product sync log", "error", err, "slug", req.Slug, "action", action)
+	}
+}
+
+// SyncProduct creates or updates a product from a sync CLI push
+func (h *APISyncHandler) SyncProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	apiKey := auth.GetAPIKeyInfo(ctx)
+	if apiKey == nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "API key required")
+	}
+	if !apiKey.HasPermission(auth.ScopeProductsWrite) {
+		return echo.NewHTTPError(http.StatusForbidden, "Permission denied: products:write required")
+	}
+
+	req, images, err := parseSyncRequest(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name is required")
+	}
+	if req.Slug == "" {
+		req.Slug = generateSlug(req.Name)
+	}
+	releaseDate, err := parseReleaseDate(req.ReleaseDate)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	failSync := func(status int, message string, cause error) error {
+		if cause != nil {
+			slog.Error("product sync failed", "error", cause, "slug", req.Slug, "reason", message)
+		}
+		h.logSync(ctx, apiKey, req, "", syncActionFailed, 0, message)
+		return echo.NewHTTPError(status, message)
+	}
+
+	var bySource *db.Product
+	if req.SourceURL != "" {
+		if bySource, err = findProduct(h.store.Queries.GetProductBySourceURL(ctx, sql.NullString{String: req.SourceURL, Valid: true})); err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to look up product", err)
+		}
+	}
+	bySlug, err := findProduct(h.store.Queries.GetProductBySlugAnyStatus(ctx, req.Slug))
+	if err != nil {
+		return failSync(http.StatusInternalServerError, "Failed to look up product", err)
+	}
+	var bySku *db.Product
+	if req.SKU != "" {
+		if bySku, err = findProduct(h.store.Queries.GetProductBySku(ctx, sql.NullString{String: req.SKU, Valid: true})); err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to look up product", err)
+		}
+	}
+
+	target, conflict := resolveSyncTarget(req, bySource, bySlug, bySku)
+	if conflict != "" {
+		slog.Warn("product sync conflict", "slug", req.Slug, "sku", req.SKU, "conflict", conflict)
+		h.logSync(ctx, apiKey, req, "", syncActionConflict, 0, conflict)
+		return c.JSON(http.StatusConflict, productsync.SyncResult{Action: syncActionConflict, Error: conflict})
+	}
+	if target == nil && req.CategoryID == "" {
+		return failSync(http.StatusBadRequest, "Category ID is required to create a product", nil)
+	}
+
+	tx, err := h.store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return failSync(http.StatusInternalServerError, "Failed to save product", err)
+	}
+	defer tx.Rollback()
+	queries := h.store.Queries.WithTx(tx)
+
+	var product db.Product
+	action := syncActionCreated
+	if target == nil {
+		product, err = queries.CreateProductWithSource(ctx, db.CreateProductWithSourceParams{
+			ID:               uuid.New().String(),
+			Name:             req.Name,
+			Slug:             req.Slug,
+			Description:      sql.NullString{String: req.Description, Valid: req.Description != ""},
+			ShortDescription: sql.NullString{String: req.ShortDescription, Valid: req.ShortDescription != ""},
+			PriceCents:       req.PriceCents,
+			CategoryID:       sql.NullString{String: req.CategoryID, Valid: true},
+			Sku:              sql.NullString{String: req.SKU, Valid: req.SKU != ""},
+			StockQuantity:    sql.NullInt64{Int64: req.StockQuantity, Valid: true},
+			HasVariants:      sql.NullBool{Bool: false, Valid: true},
+			WeightGrams:      sql.NullInt64{Int64: req.WeightGrams, Valid: req.WeightGrams > 0},
+			LeadTimeDays:     sql.NullInt64{Int64: req.LeadTimeDays, Valid: req.LeadTimeDays > 0},
+			IsActive:         sql.NullBool{Bool: req.IsActive, Valid: true},
+			IsFeatured:       sql.NullBool{Bool: req.IsFeatured, Valid: true},
+			IsPremium:        sql.NullBool{Bool: req.IsPremium, Valid: true},
+			IsNew:            sql.NullBool{Bool: req.IsNew, Valid: true},
+			Disclaimer:       sql.NullString{String: req.Disclaimer, Valid: req.Disclaimer != ""},
+			SeoTitle:         sql.NullString{String: req.SEOTitle, Valid: req.SEOTitle != ""},
+			SeoDescription:   sql.NullString{String: req.SEODescription, Valid: req.SEODescription != ""},
+			SeoKeywords:      sql.NullString{String: req.SEOKeywords, Valid: req.SEOKeywords != ""},
+			OgImageUrl:       sql.NullString{String: req.OGImageURL, Valid: req.OGImageURL != ""},
+			SourceUrl:        sql.NullString{String: req.SourceURL, Valid: req.SourceURL != ""},
+			SourcePlatform:   sql.NullString{String: req.SourcePlatform, Valid: req.SourcePlatform != ""},
+			DesignerName:     sql.NullString{String: req.DesignerName, Valid: req.DesignerName != ""},
+			ReleaseDate:      releaseDate,
+		})
+		if err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to create product", err)
+		}
+	} else {
+		action = syncActionUpdated
+		categoryID := target.CategoryID
+		if req.CategoryID != "" {
+			categoryID = sql.NullString{String: req.CategoryID, Valid: true}
+		}
+		product, err = queries.UpdateProduct(ctx, db.UpdateProductParams{
+			Name:             req.Name,
+			Slug:             req.Slug,
+			Description:      sql.NullString{String: req.Description, Valid: req.Description != ""},
+			ShortDescription: sql.NullString{String: req.ShortDescription, Valid: req.ShortDescription != ""},
+			PriceCents:       req.PriceCents,
+			CategoryID:       categoryID,
+			Sku:              sql.NullString{String: req.SKU, Valid: req.SKU != ""},
+			StockQuantity:    sql.NullInt64{Int64: req.StockQuantity, Valid: true},
+			HasVariants:      target.HasVariants,
+			WeightGrams:      sql.NullInt64{Int64: req.WeightGrams, Valid: req.WeightGrams > 0},
+			LeadTimeDays:     sql.NullInt64{Int64: req.LeadTimeDays, Valid: req.LeadTimeDays > 0},
+			IsActive:         sql.NullBool{Bool: req.IsActive, Valid: true},
+			IsFeatured:       sql.NullBool{Bool: req.IsFeatured, Valid: true},
+			IsPremium:        sql.NullBool{Bool: req.IsPremium, Valid: true},
+			Disclaimer:       sql.NullString{String: req.Disclaimer, Valid: req.Disclaimer != ""},
+			SeoTitle:         sql.NullString{String: req.SEOTitle, Valid: req.SEOTitle != ""},
+			SeoDescription:   sql.NullString{String: req.SEODescription, Valid: req.SEODescription != ""},
+			SeoKeywords:      sql.NullString{String: req.SEOKeywords, Valid: req.SEOKeywords != ""},
+			OgImageUrl:       sql.NullString{String: req.OGImageURL, Valid: req.OGImageURL != ""},
+			ID:               target.ID,
+		})
+		if err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to update product", err)
+		}
+		if err := recordProductSlugChange(ctx, queries, target.ID, target.Slug, req.Slug); err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to update product", err)
+		}
+
+		if err := queries.UpdateProductIsNew(ctx, db.UpdateProductIsNewParams{
+			IsNew: sql.NullBool{Bool: req.IsNew, Valid: true},
+			ID:    product.ID,
+		}); err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to update product", err)
+		}
+
+		if err := queries.UpdateProductSource(ctx, db.UpdateProductSourceParams{
+			SourceUrl:      sql.NullString{String: req.SourceURL, Valid: req.SourceURL != ""},
+			SourcePlatform: sql.NullString{String: req.SourcePlatform, Valid: req.SourcePlatform != ""},
+			DesignerName:   sql.NullString{String: req.DesignerName, Valid: req.DesignerName != ""},
+			ReleaseDate:    releaseDate,
+			ID:             product.ID,
+		}); err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to update product", err)
+		}
+	}
+
+	if req.Tags != nil {
+		if err := queries.ClearProductTags(ctx, product.ID); err != nil {
+			return failSync(http.StatusInternalServerError, "Failed to save product tags", err)
+		}
+		for _, tagID := range req.Tags {
+			if err := queries.AddProductTag(ctx, db.AddProductTagParams{ProductID: product.ID, TagID: tagID}); err != nil {
+				return failSync(http.StatusInternalServerError, "Failed to save product tags", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return failSync(http.StatusInternalServerError, "Failed to save product", err)
+	}
+
+	// Pushed images replace the gallery so repeated syncs don't pile up duplicates.
+	// Old files stay on disk; orders and emails may still reference them.
+	result := productsync.SyncResult{Action: action, ProductID: product.ID}
+	var imageErrors int
+	if len(images) > 0 {
+		if err := h.store.Queries.DeleteProductImagesByProduct(ctx, product.ID); err != nil {
+			slog.Error("failed to clear product images before sync", "error", err, "product_id", product.ID)
+			imageErrors = len(images)
+			images = nil
+		}
+	}
+	for i, file := range images {
+		filename, err := saveProductImageUpload(product.ID, file)
+		if err != nil {
+			slog.Error("failed to save synced product image", "error", err, "product_id", product.ID, "file", file.Filename)
+			imageErrors++
+			continue
+		}
+		image, err := h.store.Queries.CreateProductImage(ctx, db.CreateProductImageParams{
+			ID:           uuid.New().String(),
+			ProductID:    product.ID,
+			ImageUrl:     filename,
+			DisplayOrder: sql.NullInt64{Int64: int64(i), Valid: true},
+			IsPrimary:    sql.NullBool{Bool: len(result.Images) == 0, Valid: true},
+		})
+		if err != nil {
+			slog.Error("failed to create synced product image", "error", err, "product_id", product.ID)
+			imageErrors++
+			continue
+		}
+		result.Images = append(result.Images, productsync.ImageResponse{
+			ID:           image.ID,
+			ProductID:    image.ProductID,
+			ImageURL:     image.ImageUrl,
+			DisplayOrder: image.DisplayOrder.Int64,
+			IsPrimary:    image.IsPrimary.Bool,
+		})
+	}
+
+	// Re-read so the response reflects source, is_new and release date updates
+	if fresh, err := h.store.Queries.GetProduct(ctx, product.ID); err == nil {
+		product = fresh
+	}
+	response := productsync.ProductResponse(productToResponse(product))
+	result.Product = &response
+
+	var message string
+	if imageErrors > 0 {
+		message = fmt.Sprintf("%d of %d images failed to upload", imageErrors, imageErrors+len(result.Images))
+		result.Error = message
+	}
+	h.logSync(ctx, apiKey, req, product.ID, action, len(result.Images), message)
+
+	slog.Info("product synced", "action", action, "product_id", product.ID, "slug", product.Slug, "images", len(result.Images), "api_key", apiKey.Name)
+
+	status := http.StatusOK
+	if action == syncActionCreated {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, result)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	productsync "github.com/loganlanou/logans3d-v4/internal/sync"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSyncTarget(t *testing.T) {
+	fromCults := &db.Product{ID: "p1", Name: "Dragon", Slug: "dragon", SourceUrl: sql.NullString{String: "https://cults3d.com/dragon", Valid: true}}
+	manual := &db.Product{ID: "p2", Name: "Dragon Egg", Slug: "dragon-egg"}
+
+	t.Run("new product", func(t *testing.T) {
+		target, conflict := resolveSyncTarget(productsync.ProductRequest{Slug: "new"}, nil, nil, nil)
+		assert.Nil(t, target)
+		assert.Empty(t, conflict)
+	})
+
+	t.Run("source URL match wins", func(t *testing.T) {
+		req := productsync.ProductRequest{Slug: "dragon", SourceURL: "https://cults3d.com/dragon"}
+		target, conflict := resolveSyncTarget(req, fromCults, fromCults, nil)
+		assert.Empty(t, conflict)
+		assert.Equal(t, "p1", target.ID)
+	})
+
+	t.Run("slug match without source", func(t *testing.T) {
+		target, conflict := resolveSyncTarget(productsync.ProductRequest{Slug: "dragon-egg"}, nil, manual, nil)
+		assert.Empty(t, conflict)
+		assert.Equal(t, "p2", target.ID)
+	})
+
+	t.Run("slug from a different source", func(t *testing.T) {
+		req := productsync.ProductRequest{Slug: "dragon", SourceURL: "https://myminifactory.com/dragon"}
+		target, conflict := resolveSyncTarget(req, nil, fromCults, nil)
+		assert.Nil(t, target)
+		assert.Contains(t, conflict, "different source")
+	})
+
+	t.Run("rename onto a taken slug", func(t *testing.T) {
+		req := productsync.ProductRequest{Slug: "dragon-egg", SourceURL: "https://cults3d.com/dragon"}
+		_, conflict := resolveSyncTarget(req, fromCults, manual, nil)
+		assert.Contains(t, conflict, "already used by another product")
+	})
+
+	t.Run("SKU owned by another product", func(t *testing.T) {
+		req := productsync.ProductRequest{Slug: "dragon", SKU: "EGG-1", SourceURL: "https://cults3d.com/dragon"}
+		_, conflict := resolveSyncTarget(req, fromCults, fromCults, manual)
+		assert.Equal(t, `SKU "EGG-1" is already used by another product (Dragon Egg)`, conflict)
+
+		_, conflict = resolveSyncTarget(productsync.ProductRequest{Slug: "new", SKU: "EGG-1"}, nil, nil, manual)
+		assert.NotEmpty(t, conflict)
+	})
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	date, err := parseReleaseDate(nil)
+	assert.NoError(t, err)
+	assert.False(t, date.Valid)
+
+	value := "2026-01-15"
+	date, err = parseReleaseDate(&value)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, date.Time.Day())
+
+	value = "2026-01-15T10:00:00Z"
+	date, err = parseReleaseDate(&value)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, date.Time.Hour())
+
+	value = "January 15"
+	_, err = parseReleaseDate(&value)
+	assert.Error(t, err)
+}
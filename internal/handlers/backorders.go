@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// HandleBackorderReport lists open orders waiting on out-of-stock items
+func (h *AdminHandler) HandleBackorderReport(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	summary, err := h.storage.Queries.GetBackorderSummary(ctx)
+	if err != nil {
+		slog.Error("failed to get backorder summary", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load backorders")
+	}
+
+	items, err := h.storage.Queries.ListOpenBackorders(ctx)
+	if err != nil {
+		slog.Error("failed to list open backorders", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load backorders")
+	}
+
+	return Render(c, admin.BackorderReport(c, summary, items))
+}
+
+// saveProductBackorderSettings applies the backorder fields from the product form.
+// Forms that don't include the backorder section leave the settings untouched.
+func (h *AdminHandler) saveProductBackorderSettings(c echo.Context, productID string) error {
+	if c.FormValue("backorder_settings") == "" {
+		return nil
+	}
+
+	shipDate := strings.TrimSpace(c.FormValue("backorder_ship_date"))
+	if shipDate != "" {
+		if _, err := time.Parse(utils.BackorderDateLayout, shipDate); err != nil {
+			slog.Warn("ignoring invalid backorder ship date", "product_id", productID, "value", shipDate)
+			shipDate = ""
+		}
+	}
+
+	maxQuantity, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("max_backorder_quantity")), 10, 64)
+	allow := c.FormValue("allow_backorder") == "on" || c.FormValue("allow_backorder") == "true"
+
+	err := h.storage.Queries.UpdateProductBackorderSettings(c.Request().Context(), db.UpdateProductBackorderSettingsParams{
+		AllowBackorder:       allow,
+		BackorderShipDate:    sql.NullString{String: shipDate, Valid: shipDate != ""},
+		MaxBackorderQuantity: sql.NullInt64{Int64: maxQuantity, Valid: maxQuantity > 0},
+		ID:                   productID,
+	})
+	if err != nil {
+		slog.Error("failed to update product backorder settings", "error", err, "product_id", productID)
+		return err
+	}
+	return nil
+}
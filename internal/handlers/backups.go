@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/backup"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// restoreConfirmation must be typed to restore a snapshot over the live database
+const restoreConfirmation = "RESTORE"
+
+type BackupHandler struct {
+	backups *backup.Manager
+}
+
+func NewBackupHandler(backups *backup.Manager) *BackupHandler {
+	return &BackupHandler{
+		backups: backups,
+	}
+}
+
+// HandleBackups lists the database snapshots on disk
+func (h *BackupHandler) HandleBackups(c echo.Context) error {
+	snapshots, err := h.backups.List()
+	if err != nil {
+		slog.Error("failed to list backups", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list backups")
+	}
+
+	return Render(c, admin.Backups(c, admin.BackupsData{
+		Snapshots: snapshots,
+		Config:    h.backups.Config(),
+		Created:   c.QueryParam("created"),
+		Restored:  c.QueryParam("restored"),
+		Error:     c.QueryParam("error"),
+	}))
+}
+
+// HandleCreateBackup takes a snapshot now
+func (h *BackupHandler) HandleCreateBackup(c echo.Context) error {
+	snapshot, err := h.backups.Create(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to create backup", "error", err)
+		return c.Redirect(http.StatusSeeOther, "/admin/dev/backups?error="+url.QueryEscape(err.Error()))
+	}
+	slog.Info("backup created from admin", "name", snapshot.Name)
+
+	return c.Redirect(http.StatusSeeOther, "/admin/dev/backups?created="+url.QueryEscape(snapshot.Name))
+}
+
+// HandleDownloadBackup sends a snapshot as a file download
+func (h *BackupHandler) HandleDownloadBackup(c echo.Context) error {
+	name := c.Param("name")
+	path, err := h.backups.Path(name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Backup not found")
+	}
+	return c.Attachment(path, name)
+}
+
+// HandleRestoreBackup replaces the live database with a snapshot. The admin has to
+// type RESTORE to confirm, since every change since the snapshot is rolled back.
+func (h *BackupHandler) HandleRestoreBackup(c echo.Context) error {
+	name := c.Param("name")
+	if c.FormValue("confirm") != restoreConfirmation {
+		return c.Redirect(http.StatusSeeOther, "/admin/dev/backups?error="+url.QueryEscape("Type "+restoreConfirmation+" to confirm the restore"))
+	}
+
+	if err := h.backups.Restore(c.Request().Context(), name); err != nil {
+		if errors.Is(err, backup.ErrInvalidSnapshot) {
+			return echo.NewHTTPError(http.StatusNotFound, "Backup not found")
+		}
+		slog.Error("failed to restore backup", "error", err, "name", name)
+		return c.Redirect(http.StatusSeeOther, "/admin/dev/backups?error="+url.QueryEscape(err.Error()))
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/admin/dev/backups?restored="+url.QueryEscape(name))
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/badges"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// badgeDateLayout is the format of the badge form's date inputs
+const badgeDateLayout = "2006-01-02"
+
+func badgeFormURL(badgeID, errorMsg string) string {
+	target := fmt.Sprintf("/admin/badges/%s", badgeID)
+	if badgeID == "" {
+		target = "/admin/badges/new"
+	}
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+	return target
+}
+
+// HandleBadgesList shows every badge with how many products it's assigned to
+func (h *AdminHandler) HandleBadgesList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	list, err := h.storage.Queries.ListProductBadges(ctx)
+	if err != nil {
+		slog.Error("failed to list product badges", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load badges")
+	}
+
+	return Render(c, admin.BadgesList(c, list, time.Now()))
+}
+
+// HandleBadgeForm shows the badge editor, with its assigned products when editing
+func (h *AdminHandler) HandleBadgeForm(c echo.Context) error {
+	ctx := c.Request().Context()
+	badgeID := c.Param("id")
+
+	var badge *db.ProductBadge
+	var assigned []db.ListProductBadgeProductsRow
+	var products []db.Product
+	if badgeID != "" {
+		b, err := h.storage.Queries.GetProductBadge(ctx, badgeID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return echo.NewHTTPError(http.StatusNotFound, "Badge not found")
+			}
+			slog.Error("failed to get product badge", "error", err, "badge_id", badgeID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load badge")
+		}
+		badge = &b
+
+		assigned, err = h.storage.Queries.ListProductBadgeProducts(ctx, badgeID)
+		if err != nil {
+			slog.Error("failed to list badge products", "error", err, "badge_id", badgeID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load badge")
+		}
+
+		products, err = h.storage.Queries.ListProducts(ctx)
+		if err != nil {
+			slog.Error("failed to list products for badge form", "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+		}
+	}
+
+	return Render(c, admin.BadgeForm(c, badge, assigned, products, c.QueryParam("error")))
+}
+
+// badgeFormInput holds the badge fields shared by create and update
+type badgeFormInput struct {
+	Label     string
+	Color     string
+	Icon      string
+	Rule      string
+	Threshold int64
+	StartsAt  sql.NullTime
+	EndsAt    sql.NullTime
+	Priority  int64
+	IsActive  bool
+}
+
+// parseBadgeForm reads and validates the badge fields, returning a message for the admin on failure
+func parseBadgeForm(c echo.Context) (badgeFormInput, string) {
+	threshold, _ := strconv.ParseInt(c.FormValue("threshold"), 10, 64)
+	priority, _ := strconv.ParseInt(c.FormValue("priority"), 10, 64)
+
+	input := badgeFormInput{
+		Label:     strings.TrimSpace(c.FormValue("label")),
+		Color:     c.FormValue("color"),
+		Icon:      strings.TrimSpace(c.FormValue("icon")),
+		Rule:      c.FormValue("rule"),
+		Threshold: threshold,
+		Priority:  priority,
+		IsActive:  c.FormValue("is_active") == "on",
+	}
+	if input.Label == "" {
+		return input, "Label is required"
+	}
+	if !badges.ValidColor(input.Color) {
+		input.Color = badges.DefaultColor
+	}
+	if !badges.ValidRule(input.Rule) {
+		return input, "Choose which products the badge is shown on"
+	}
+	if (input.Rule == badges.RuleLowStock || input.Rule == badges.RuleBestSeller) && input.Threshold < 1 {
+		return input, "Low stock and best seller badges need a threshold of at least 1"
+	}
+
+	var err error
+	if input.StartsAt, err = parseBadgeDate(c.FormValue("starts_on")); err != nil {
+		return input, "Enter the start date as YYYY-MM-DD"
+	}
+	// The end date is the last day shown, so the badge ends at midnight after it
+	if input.EndsAt, err = parseBadgeDate(c.FormValue("ends_on")); err != nil {
+		return input, "Enter the end date as YYYY-MM-DD"
+	}
+	if input.EndsAt.Valid {
+		input.EndsAt.Time = input.EndsAt.Time.AddDate(0, 0, 1)
+	}
+	if input.StartsAt.Valid && input.EndsAt.Valid && !input.StartsAt.Time.Before(input.EndsAt.Time) {
+		return input, "The end date can't be before the start date"
+	}
+	return input, ""
+}
+
+// parseBadgeDate reads an optional date input as local midnight
+func parseBadgeDate(value string) (sql.NullTime, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.ParseInLocation(badgeDateLayout, value, time.Local)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// HandleCreateBadge creates a badge and opens it for assigning products
+func (h *AdminHandler) HandleCreateBadge(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	input, errMsg := parseBadgeForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, badgeFormURL("", errMsg))
+	}
+
+	badge, err := h.storage.Queries.CreateProductBadge(ctx, db.CreateProductBadgeParams{
+		ID:        uuid.New().String(),
+		Label:     input.Label,
+		Color:     input.Color,
+		Icon:      input.Icon,
+		Rule:      input.Rule,
+		Threshold: input.Threshold,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		Priority:  input.Priority,
+		IsActive:  input.IsActive,
+	})
+	if err != nil {
+		slog.Error("failed to create product badge", "error", err, "label", input.Label)
+		return c.Redirect(http.StatusSeeOther, badgeFormURL("", "Could not save badge"))
+	}
+
+	slog.Info("product badge created", "badge_id", badge.ID, "label", badge.Label, "rule", badge.Rule)
+	return c.Redirect(http.StatusSeeOther, badgeFormURL(badge.ID, ""))
+}
+
+// HandleUpdateBadge saves changes to a badge
+func (h *AdminHandler) HandleUpdateBadge(c echo.Context) error {
+	ctx := c.Request().Context()
+	badgeID := c.Param("id")
+
+	input, errMsg := parseBadgeForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, errMsg))
+	}
+
+	_, err := h.storage.Queries.UpdateProductBadge(ctx, db.UpdateProductBadgeParams{
+		ID:        badgeID,
+		Label:     input.Label,
+		Color:     input.Color,
+		Icon:      input.Icon,
+		Rule:      input.Rule,
+		Threshold: input.Threshold,
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		Priority:  input.Priority,
+		IsActive:  input.IsActive,
+	})
+	if err != nil {
+		slog.Error("failed to update product badge", "error", err, "badge_id", badgeID)
+		return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, "Could not save badge"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, ""))
+}
+
+// HandleDeleteBadge removes a badge and its product assignments
+func (h *AdminHandler) HandleDeleteBadge(c echo.Context) error {
+	ctx := c.Request().Context()
+	badgeID := c.Param("id")
+
+	if err := h.storage.Queries.DeleteProductBadge(ctx, badgeID); err != nil {
+		slog.Error("failed to delete product badge", "error", err, "badge_id", badgeID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete badge")
+	}
+
+	slog.Info("product badge deleted", "badge_id", badgeID)
+	return c.Redirect(http.StatusSeeOther, "/admin/badges")
+}
+
+// HandleAddBadgeProduct assigns a manual badge to a product
+func (h *AdminHandler) HandleAddBadgeProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	badgeID := c.Param("id")
+
+	productID := c.FormValue("product_id")
+	if productID == "" {
+		return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, "Choose a product"))
+	}
+
+	if err := h.storage.Queries.AddProductBadgeAssignment(ctx, db.AddProductBadgeAssignmentParams{
+		BadgeID:   badgeID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to assign badge to product", "error", err, "badge_id", badgeID, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, "Could not add product"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, ""))
+}
+
+// HandleRemoveBadgeProduct takes a manual badge off a product
+func (h *AdminHandler) HandleRemoveBadgeProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	badgeID := c.Param("id")
+	productID := c.Param("productId")
+
+	if err := h.storage.Queries.RemoveProductBadgeAssignment(ctx, db.RemoveProductBadgeAssignmentParams{
+		BadgeID:   badgeID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to remove badge from product", "error", err, "badge_id", badgeID, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, "Could not remove product"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, badgeFormURL(badgeID, ""))
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// HandleBlogPostsList shows every blog post, drafts included
+func (h *AdminHandler) HandleBlogPostsList(c echo.Context) error {
+	posts, err := h.storage.Queries.ListBlogPosts(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to list blog posts", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load blog posts")
+	}
+
+	return Render(c, admin.BlogPostsList(c, posts, utils.WallClockNow()))
+}
+
+// HandleBlogPostForm shows the post editor, empty for a new post
+func (h *AdminHandler) HandleBlogPostForm(c echo.Context) error {
+	postID := c.Param("id")
+	if postID == "" {
+		return Render(c, admin.BlogPostForm(c, nil, ""))
+	}
+
+	post, err := h.storage.Queries.GetBlogPost(c.Request().Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Blog post not found")
+		}
+		slog.Error("failed to get blog post", "error", err, "post_id", postID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load blog post")
+	}
+
+	return Render(c, admin.BlogPostForm(c, &post, c.QueryParam("error")))
+}
+
+// parseBlogPostForm reads and validates the post fields, returning a message for the
+// admin on failure. Publishing without a date publishes now.
+func parseBlogPostForm(c echo.Context) (db.BlogPost, string) {
+	title := strings.TrimSpace(c.FormValue("title"))
+	post := db.BlogPost{
+		Title:        title,
+		Slug:         bundleSlug(c.FormValue("slug"), title),
+		Summary:      strings.TrimSpace(c.FormValue("summary")),
+		BodyMarkdown: c.FormValue("body_markdown"),
+		AuthorName:   strings.TrimSpace(c.FormValue("author_name")),
+		Status:       c.FormValue("status"),
+	}
+
+	publishedAt := strings.TrimSpace(c.FormValue("published_at"))
+	if publishedAt != "" {
+		t, err := time.Parse("2006-01-02T15:04", publishedAt)
+		if err != nil {
+			return post, "Publish date is not a valid date and time"
+		}
+		post.PublishedAt = sql.NullTime{Time: t, Valid: true}
+	}
+
+	if post.Title == "" || post.Slug == "" {
+		return post, "Title is required"
+	}
+	if !utils.ValidBlogStatus(post.Status) {
+		return post, "Choose draft or published"
+	}
+	if post.Status == utils.BlogStatusPublished {
+		if strings.TrimSpace(post.BodyMarkdown) == "" {
+			return post, "Write the post before publishing it"
+		}
+		if !post.PublishedAt.Valid {
+			post.PublishedAt = sql.NullTime{Time: utils.WallClockNow().Truncate(time.Minute), Valid: true}
+		}
+	}
+	return post, ""
+}
+
+// HandleCreateBlogPost saves a new post. Invalid input re-renders the editor so the
+// text isn't lost.
+func (h *AdminHandler) HandleCreateBlogPost(c echo.Context) error {
+	input, errMsg := parseBlogPostForm(c)
+	if errMsg != "" {
+		return Render(c, admin.BlogPostForm(c, &input, errMsg))
+	}
+
+	post, err := h.storage.Queries.CreateBlogPost(c.Request().Context(), db.CreateBlogPostParams{
+		ID:           uuid.New().String(),
+		Title:        input.Title,
+		Slug:         input.Slug,
+		Summary:      input.Summary,
+		BodyMarkdown: input.BodyMarkdown,
+		AuthorName:   input.AuthorName,
+		Status:       input.Status,
+		PublishedAt:  input.PublishedAt,
+	})
+	if err != nil {
+		slog.Error("failed to create blog post", "error", err, "slug", input.Slug)
+		return Render(c, admin.BlogPostForm(c, &input, "Could not save post (is the slug already used?)"))
+	}
+
+	slog.Info("blog post created", "post_id", post.ID, "slug", post.Slug, "status", post.Status)
+	return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/blog/%s", post.ID))
+}
+
+// HandleUpdateBlogPost saves changes to a post
+func (h *AdminHandler) HandleUpdateBlogPost(c echo.Context) error {
+	postID := c.Param("id")
+
+	input, errMsg := parseBlogPostForm(c)
+	input.ID = postID
+	if errMsg != "" {
+		return Render(c, admin.BlogPostForm(c, &input, errMsg))
+	}
+
+	_, err := h.storage.Queries.UpdateBlogPost(c.Request().Context(), db.UpdateBlogPostParams{
+		Title:        input.Title,
+		Slug:         input.Slug,
+		Summary:      input.Summary,
+		BodyMarkdown: input.BodyMarkdown,
+		AuthorName:   input.AuthorName,
+		Status:       input.Status,
+		PublishedAt:  input.PublishedAt,
+		ID:           postID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Blog post not found")
+		}
+		slog.Error("failed to update blog post", "error", err, "post_id", postID)
+		return Render(c, admin.BlogPostForm(c, &input, "Could not save post (is the slug already used?)"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/blog/%s", postID))
+}
+
+// HandleDeleteBlogPost removes a post
+func (h *AdminHandler) HandleDeleteBlogPost(c echo.Context) error {
+	postID := c.Param("id")
+
+	if err := h.storage.Queries.DeleteBlogPost(c.Request().Context(), postID); err != nil {
+		slog.Error("failed to delete blog post", "error", err, "post_id", postID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete blog post")
+	}
+
+	slog.Info("blog post deleted", "post_id", postID)
+	return c.Redirect(http.StatusSeeOther, "/admin/blog")
+}
+
+// HandlePreviewBlogPost renders the editor's markdown for the preview tab
+func (h *AdminHandler) HandlePreviewBlogPost(c echo.Context) error {
+	html, err := utils.RenderMarkdown(c.FormValue("body_markdown"))
+	if err != nil {
+		slog.Error("failed to render blog preview", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to render preview")
+	}
+	if strings.TrimSpace(html) == "" {
+		html = `<p class="admin-text-muted-foreground">Nothing to preview yet.</p>`
+	}
+	return c.HTML(http.StatusOK, html)
+}
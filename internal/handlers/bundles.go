@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// bundleSlug normalises an admin-entered slug, falling back to the bundle name
+func bundleSlug(slug, name string) string {
+	if strings.TrimSpace(slug) == "" {
+		slug = name
+	}
+	slug = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-' || r == '_':
+			return '-'
+		}
+		return -1
+	}, strings.ToLower(strings.TrimSpace(slug)))
+
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	return strings.Trim(slug, "-")
+}
+
+func bundleFormURL(bundleID, errorMsg string) string {
+	target := fmt.Sprintf("/admin/bundles/%s", bundleID)
+	if bundleID == "" {
+		target = "/admin/bundles/new"
+	}
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+	return target
+}
+
+// HandleBundlesList shows all product bundles
+func (h *AdminHandler) HandleBundlesList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	bundles, err := h.storage.Queries.ListProductBundles(ctx)
+	if err != nil {
+		slog.Error("failed to list product bundles", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load bundles")
+	}
+
+	return Render(c, admin.BundlesList(c, bundles))
+}
+
+// HandleBundleForm shows the bundle editor, with its components when editing
+func (h *AdminHandler) HandleBundleForm(c echo.Context) error {
+	ctx := c.Request().Context()
+	bundleID := c.Param("id")
+
+	var bundle *db.ProductBundle
+	var items []db.ListProductBundleItemsRow
+	if bundleID != "" {
+		b, err := h.storage.Queries.GetProductBundle(ctx, bundleID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return echo.NewHTTPError(http.StatusNotFound, "Bundle not found")
+			}
+			slog.Error("failed to get product bundle", "error", err, "bundle_id", bundleID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load bundle")
+		}
+		bundle = &b
+
+		items, err = h.storage.Queries.ListProductBundleItems(ctx, bundleID)
+		if err != nil {
+			slog.Error("failed to list bundle items", "error", err, "bundle_id", bundleID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load bundle")
+		}
+	}
+
+	products, err := h.storage.Queries.ListProducts(ctx)
+	if err != nil {
+		slog.Error("failed to list products for bundle form", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+	}
+
+	skus, err := h.storage.Queries.ListBundleSkuOptions(ctx)
+	if err != nil {
+		slog.Error("failed to list SKU options for bundle form", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load variants")
+	}
+
+	return Render(c, admin.BundleForm(c, bundle, items, products, skus, c.QueryParam("error")))
+}
+
+// bundleFormInput holds the bundle fields shared by create and update
+type bundleFormInput struct {
+	Name         string
+	Slug         string
+	Description  sql.NullString
+	ImageURL     sql.NullString
+	PriceCents   int64
+	IsActive     bool
+	DisplayOrder int64
+}
+
+// parseBundleForm reads and validates the bundle fields, returning a message for the admin on failure
+func parseBundleForm(c echo.Context) (bundleFormInput, string) {
+	name := strings.TrimSpace(c.FormValue("name"))
+	description := strings.TrimSpace(c.FormValue("description"))
+	imageURL := strings.TrimSpace(c.FormValue("image_url"))
+	displayOrder, _ := strconv.ParseInt(c.FormValue("display_order"), 10, 64)
+
+	input := bundleFormInput{
+		Name:         name,
+		Slug:         bundleSlug(c.FormValue("slug"), name),
+		Description:  sql.NullString{String: description, Valid: description != ""},
+		ImageURL:     sql.NullString{String: imageURL, Valid: imageURL != ""},
+		IsActive:     c.FormValue("is_active") == "on",
+		DisplayOrder: displayOrder,
+	}
+	if input.Name == "" || input.Slug == "" {
+		return input, "Name is required"
+	}
+
+	price, err := strconv.ParseFloat(c.FormValue("price"), 64)
+	if err != nil || price <= 0 {
+		return input, "Enter a bundle price greater than zero"
+	}
+	input.PriceCents = int64(math.Round(price * 100))
+	return input, ""
+}
+
+// HandleCreateBundle creates a bundle and opens it for adding components
+func (h *AdminHandler) HandleCreateBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	input, errMsg := parseBundleForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, bundleFormURL("", errMsg))
+	}
+
+	bundle, err := h.storage.Queries.CreateProductBundle(ctx, db.CreateProductBundleParams{
+		ID:           uuid.New().String(),
+		Name:         input.Name,
+		Slug:         input.Slug,
+		Description:  input.Description,
+		PriceCents:   input.PriceCents,
+		ImageUrl:     input.ImageURL,
+		IsActive:     input.IsActive,
+		DisplayOrder: input.DisplayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to create product bundle", "error", err, "slug", input.Slug)
+		return c.Redirect(http.StatusSeeOther, bundleFormURL("", "Could not save bundle (is the slug already used?)"))
+	}
+
+	slog.Info("product bundle created", "bundle_id", bundle.ID, "slug", bundle.Slug)
+	return c.Redirect(http.StatusSeeOther, bundleFormURL(bundle.ID, ""))
+}
+
+// HandleUpdateBundle saves changes to a bundle's details
+func (h *AdminHandler) HandleUpdateBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+	bundleID := c.Param("id")
+
+	input, errMsg := parseBundleForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, errMsg))
+	}
+
+	_, err := h.storage.Queries.UpdateProductBundle(ctx, db.UpdateProductBundleParams{
+		ID:           bundleID,
+		Name:         input.Name,
+		Slug:         input.Slug,
+		Description:  input.Description,
+		PriceCents:   input.PriceCents,
+		ImageUrl:     input.ImageURL,
+		IsActive:     input.IsActive,
+		DisplayOrder: input.DisplayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to update product bundle", "error", err, "bundle_id", bundleID)
+		return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, "Could not save bundle (is the slug already used?)"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, ""))
+}
+
+// HandleDeleteBundle removes a bundle. Carts holding it lose those lines; past orders
+// keep their component items.
+func (h *AdminHandler) HandleDeleteBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+	bundleID := c.Param("id")
+
+	if err := h.storage.Queries.DeleteProductBundle(ctx, bundleID); err != nil {
+		slog.Error("failed to delete product bundle", "error", err, "bundle_id", bundleID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete bundle")
+	}
+
+	slog.Info("product bundle deleted", "bundle_id", bundleID)
+	return c.Redirect(http.StatusSeeOther, "/admin/bundles")
+}
+
+// HandleAddBundleItem adds a component product (optionally a specific SKU) to a bundle
+func (h *AdminHandler) HandleAddBundleItem(c echo.Context) error {
+	ctx := c.Request().Context()
+	bundleID := c.Param("id")
+
+	productID := c.FormValue("product_id")
+	skuID := c.FormValue("product_sku_id")
+	quantity, _ := strconv.ParseInt(c.FormValue("quantity"), 10, 64)
+	if productID == "" || quantity < 1 {
+		return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, "Choose a product and a quantity of at least 1"))
+	}
+
+	product, err := h.storage.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load bundle component product", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, "Product not found"))
+	}
+
+	// Variant products are sold by SKU, so the component must name one
+	hasVariants := product.HasVariants.Valid && product.HasVariants.Bool
+	if hasVariants && skuID == "" {
+		return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, product.Name+" has variants; choose which one to include"))
+	}
+	if skuID != "" {
+		if _, err := h.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+			ID:        skuID,
+			ProductID: productID,
+		}); err != nil {
+			return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, "That variant doesn't belong to "+product.Name))
+		}
+	}
+
+	_, err = h.storage.Queries.AddProductBundleItem(ctx, db.AddProductBundleItemParams{
+		ID:           uuid.New().String(),
+		BundleID:     bundleID,
+		ProductID:    productID,
+		ProductSkuID: sql.NullString{String: skuID, Valid: skuID != ""},
+		Quantity:     quantity,
+	})
+	if err != nil {
+		slog.Error("failed to add bundle item", "error", err, "bundle_id", bundleID, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, "Could not add component"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, ""))
+}
+
+// HandleDeleteBundleItem removes a component from a bundle
+func (h *AdminHandler) HandleDeleteBundleItem(c echo.Context) error {
+	ctx := c.Request().Context()
+	bundleID := c.Param("id")
+	itemID := c.Param("itemId")
+
+	if err := h.storage.Queries.DeleteProductBundleItem(ctx, db.DeleteProductBundleItemParams{
+		ID:       itemID,
+		BundleID: bundleID,
+	}); err != nil {
+		slog.Error("failed to delete bundle item", "error", err, "bundle_id", bundleID, "item_id", itemID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove component")
+	}
+
+	return c.Redirect(http.StatusSeeOther, bundleFormURL(bundleID, ""))
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// summarizeCarbonOffsets turns the monthly rows into report rows and a total
+func summarizeCarbonOffsets(rows []db.GetCarbonOffsetsByMonthRow) (byMonth []admin.CarbonOffsetTotal, total admin.CarbonOffsetTotal) {
+	total.Label = "Total"
+	for _, row := range rows {
+		byMonth = append(byMonth, admin.CarbonOffsetTotal{
+			Label:        row.Month,
+			Orders:       row.OrderCount,
+			OffsetOrders: row.OffsetOrders,
+			OffsetCents:  row.OffsetCents,
+		})
+		total.Orders += row.OrderCount
+		total.OffsetOrders += row.OffsetOrders
+		total.OffsetCents += row.OffsetCents
+	}
+	return byMonth, total
+}
+
+// HandleCarbonOffsetReport shows the carbon offsets customers added at checkout, by
+// month, for passing on to the offset program
+func (h *AdminHandler) HandleCarbonOffsetReport(c echo.Context) error {
+	ctx := c.Request().Context()
+	year := taxReportYear(c)
+
+	rows, err := h.storage.Queries.GetCarbonOffsetsByMonth(ctx, db.GetCarbonOffsetsByMonthParams{
+		StartDate: fmt.Sprintf("%d-01-01", year),
+		EndDate:   fmt.Sprintf("%d-01-01", year+1),
+	})
+	if err != nil {
+		slog.Error("failed to load carbon offsets", "error", err, "year", year)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load carbon offset report")
+	}
+
+	byMonth, total := summarizeCarbonOffsets(rows)
+	offset, offered := settings.For(h.storage.Queries).Values(ctx).CarbonOffset()
+	return Render(c, admin.CarbonOffsetReport(c, admin.CarbonOffsetReportData{
+		Year:    year,
+		Years:   taxReportYears(),
+		ByMonth: byMonth,
+		Total:   total,
+		Offered: offered,
+		Offset:  offset,
+	}))
+}
+
+// HandleCarbonOffsetExport downloads the orders with a carbon offset in a year as CSV
+func (h *AdminHandler) HandleCarbonOffsetExport(c echo.Context) error {
+	year := taxReportYear(c)
+
+	orders, err := h.storage.Queries.ListCarbonOffsetOrders(c.Request().Context(), db.ListCarbonOffsetOrdersParams{
+		StartDate: fmt.Sprintf("%d-01-01", year),
+		EndDate:   fmt.Sprintf("%d-01-01", year+1),
+	})
+	if err != nil {
+		slog.Error("failed to list carbon offset orders", "error", err, "year", year)
+		return c.String(http.StatusInternalServerError, "Failed to load carbon offsets")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"carbon-offsets-%d.csv\"", year))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	defer w.Flush()
+
+	w.Write([]string{"Date", "Order", "Customer", "Offset"})
+	for _, order := range orders {
+		w.Write([]string{
+			order.CreatedAt.Time.Format("2006-01-02"),
+			order.ID,
+			order.CustomerName,
+			fmt.Sprintf("%.2f", float64(order.CarbonOffsetCents)/100),
+		})
+	}
+
+	return nil
+}
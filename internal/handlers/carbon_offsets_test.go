@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestSummarizeCarbonOffsets(t *testing.T) {
+	byMonth, total := summarizeCarbonOffsets([]db.GetCarbonOffsetsByMonthRow{
+		{Month: "2026-01", OrderCount: 8, OffsetOrders: 2, OffsetCents: 200},
+		{Month: "2026-02", OrderCount: 4, OffsetOrders: 0, OffsetCents: 0},
+		{Month: "2026-03", OrderCount: 3, OffsetOrders: 3, OffsetCents: 350},
+	})
+
+	assert.Len(t, byMonth, 3)
+	assert.Equal(t, "2026-01", byMonth[0].Label)
+	assert.Equal(t, "25.0%", byMonth[0].Share())
+	assert.Equal(t, "0.0%", byMonth[1].Share())
+
+	assert.Equal(t, "Total", total.Label)
+	assert.Equal(t, int64(15), total.Orders)
+	assert.Equal(t, int64(5), total.OffsetOrders)
+	assert.Equal(t, int64(550), total.OffsetCents)
+	assert.Equal(t, "33.3%", total.Share())
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/cartpromos"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func cartPromotionFormURL(promoID, errorMsg string) string {
+	target := fmt.Sprintf("/admin/promotions/cart/%s", promoID)
+	if promoID == "" {
+		target = "/admin/promotions/cart/new"
+	}
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+	return target
+}
+
+// HandleCartPromotions lists the automatic cart promotions
+func (h *AdminPromotionsHandler) HandleCartPromotions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	promos, err := h.queries.ListCartPromotions(ctx)
+	if err != nil {
+		slog.Error("failed to list cart promotions", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load cart promotions")
+	}
+
+	return Render(c, admin.CartPromotionsList(c, promos, time.Now()))
+}
+
+// HandleCartPromotionForm shows the cart promotion editor
+func (h *AdminPromotionsHandler) HandleCartPromotionForm(c echo.Context) error {
+	ctx := c.Request().Context()
+	promoID := c.Param("id")
+
+	var promo *db.CartPromotion
+	if promoID != "" {
+		p, err := h.queries.GetCartPromotion(ctx, promoID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return echo.NewHTTPError(http.StatusNotFound, "Cart promotion not found")
+			}
+			slog.Error("failed to get cart promotion", "error", err, "promotion_id", promoID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load cart promotion")
+		}
+		promo = &p
+	}
+
+	categories, err := h.queries.ListCategories(ctx)
+	if err != nil {
+		slog.Error("failed to list categories for cart promotion form", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load categories")
+	}
+
+	return Render(c, admin.CartPromotionForm(c, promo, categories, c.QueryParam("error")))
+}
+
+// cartPromotionFormInput holds the cart promotion fields shared by create and update
+type cartPromotionFormInput struct {
+	Name             string
+	Kind             string
+	MinSubtotalCents int64
+	PercentOff       int64
+	CategoryID       sql.NullString
+	BuyQuantity      int64
+	StartsAt         sql.NullTime
+	EndsAt           sql.NullTime
+	IsActive         bool
+}
+
+// parseCartPromotionForm reads and validates the promotion fields, returning a message for the admin on failure
+func parseCartPromotionForm(c echo.Context) (cartPromotionFormInput, string) {
+	percentOff, _ := strconv.ParseInt(c.FormValue("percent_off"), 10, 64)
+	buyQuantity, _ := strconv.ParseInt(c.FormValue("buy_quantity"), 10, 64)
+
+	input := cartPromotionFormInput{
+		Name:     strings.TrimSpace(c.FormValue("name")),
+		Kind:     c.FormValue("kind"),
+		IsActive: c.FormValue("is_active") == "on",
+	}
+	if input.Name == "" {
+		return input, "Name is required"
+	}
+	if !cartpromos.ValidKind(input.Kind) {
+		return input, "Choose a kind of promotion"
+	}
+
+	minSubtotal := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(c.FormValue("min_subtotal")), "$"))
+	if minSubtotal != "" {
+		dollars, err := strconv.ParseFloat(minSubtotal, 64)
+		if err != nil || dollars < 0 {
+			return input, "Enter the minimum subtotal in dollars, like 75 or 49.99"
+		}
+		input.MinSubtotalCents = int64(math.Round(dollars * 100))
+	}
+
+	// Only keep the fields the kind uses, so switching kinds doesn't leave stale rules behind
+	switch input.Kind {
+	case cartpromos.KindFreeShipping:
+		if input.MinSubtotalCents <= 0 {
+			return input, "Free shipping needs a minimum subtotal"
+		}
+	case cartpromos.KindPercentOff:
+		if percentOff < 1 || percentOff > 100 {
+			return input, "Percent off must be between 1 and 100"
+		}
+		input.PercentOff = percentOff
+	case cartpromos.KindBuyNGetOne:
+		categoryID := c.FormValue("category_id")
+		if categoryID == "" {
+			return input, "Choose the category the offer applies to"
+		}
+		if buyQuantity < 1 {
+			return input, "Customers need to buy at least 1 item to get one free"
+		}
+		input.MinSubtotalCents = 0
+		input.CategoryID = sql.NullString{String: categoryID, Valid: true}
+		input.BuyQuantity = buyQuantity
+	}
+
+	var err error
+	if input.StartsAt, err = parseBadgeDate(c.FormValue("starts_on")); err != nil {
+		return input, "Enter the start date as YYYY-MM-DD"
+	}
+	// The end date is the last day the promotion runs, so it ends at midnight after it
+	if input.EndsAt, err = parseBadgeDate(c.FormValue("ends_on")); err != nil {
+		return input, "Enter the end date as YYYY-MM-DD"
+	}
+	if input.EndsAt.Valid {
+		input.EndsAt.Time = input.EndsAt.Time.AddDate(0, 0, 1)
+	}
+	if input.StartsAt.Valid && input.EndsAt.Valid && !input.StartsAt.Time.Before(input.EndsAt.Time) {
+		return input, "The end date can't be before the start date"
+	}
+	return input, ""
+}
+
+// HandleCreateCartPromotion creates a cart promotion
+func (h *AdminPromotionsHandler) HandleCreateCartPromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	input, errMsg := parseCartPromotionForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, cartPromotionFormURL("", errMsg))
+	}
+
+	promo, err := h.queries.CreateCartPromotion(ctx, db.CreateCartPromotionParams{
+		ID:               uuid.New().String(),
+		Name:             input.Name,
+		Kind:             input.Kind,
+		MinSubtotalCents: input.MinSubtotalCents,
+		PercentOff:       input.PercentOff,
+		CategoryID:       input.CategoryID,
+		BuyQuantity:      input.BuyQuantity,
+		StartsAt:         input.StartsAt,
+		EndsAt:           input.EndsAt,
+		IsActive:         input.IsActive,
+	})
+	if err != nil {
+		slog.Error("failed to create cart promotion", "error", err, "name", input.Name)
+		return c.Redirect(http.StatusSeeOther, cartPromotionFormURL("", "Could not save promotion"))
+	}
+
+	slog.Info("cart promotion created", "promotion_id", promo.ID, "name", promo.Name, "kind", promo.Kind)
+	return c.Redirect(http.StatusSeeOther, "/admin/promotions/cart")
+}
+
+// HandleUpdateCartPromotion saves changes to a cart promotion
+func (h *AdminPromotionsHandler) HandleUpdateCartPromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	promoID := c.Param("id")
+
+	input, errMsg := parseCartPromotionForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, cartPromotionFormURL(promoID, errMsg))
+	}
+
+	_, err := h.queries.UpdateCartPromotion(ctx, db.UpdateCartPromotionParams{
+		ID:               promoID,
+		Name:             input.Name,
+		Kind:             input.Kind,
+		MinSubtotalCents: input.MinSubtotalCents,
+		PercentOff:       input.PercentOff,
+		CategoryID:       input.CategoryID,
+		BuyQuantity:      input.BuyQuantity,
+		StartsAt:         input.StartsAt,
+		EndsAt:           input.EndsAt,
+		IsActive:         input.IsActive,
+	})
+	if err != nil {
+		slog.Error("failed to update cart promotion", "error", err, "promotion_id", promoID)
+		return c.Redirect(http.StatusSeeOther, cartPromotionFormURL(promoID, "Could not save promotion"))
+	}
+
+	slog.Info("cart promotion updated", "promotion_id", promoID, "kind", input.Kind, "is_active", input.IsActive)
+	return c.Redirect(http.StatusSeeOther, "/admin/promotions/cart")
+}
+
+// HandleToggleCartPromotion switches a cart promotion on or off from the list
+func (h *AdminPromotionsHandler) HandleToggleCartPromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	promoID := c.Param("id")
+	active := c.FormValue("is_active") == "true"
+
+	if err := h.queries.SetCartPromotionActive(ctx, db.SetCartPromotionActiveParams{
+		IsActive: active,
+		ID:       promoID,
+	}); err != nil {
+		slog.Error("failed to toggle cart promotion", "error", err, "promotion_id", promoID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update cart promotion")
+	}
+
+	slog.Info("cart promotion toggled", "promotion_id", promoID, "is_active", active)
+	return c.Redirect(http.StatusSeeOther, "/admin/promotions/cart")
+}
+
+// HandleDeleteCartPromotion removes a cart promotion
+func (h *AdminPromotionsHandler) HandleDeleteCartPromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	promoID := c.Param("id")
+
+	if err := h.queries.DeleteCartPromotion(ctx, promoID); err != nil {
+		slog.Error("failed to delete cart promotion", "error", err, "promotion_id", promoID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete cart promotion")
+	}
+
+	slog.Info("cart promotion deleted", "promotion_id", promoID)
+	return c.Redirect(http.StatusSeeOther, "/admin/promotions/cart")
+}
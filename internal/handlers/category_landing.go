@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// categoryImagesDir holds category banners; the image pipeline resizes them like
+// product images
+const categoryImagesDir = "public/images/categories"
+
+func categoryFormURL(categoryID string) string {
+	return fmt.Sprintf("/admin/category/edit?id=%s", categoryID)
+}
+
+// categoryLandingInput is the landing page half of the category form
+type categoryLandingInput struct {
+	BannerAltText   string
+	ContentMarkdown string
+	SeoTitle        string
+	SeoDescription  string
+	Banner          *multipart.FileHeader
+	RemoveBanner    bool
+}
+
+// parseCategoryLandingForm reads the landing page fields, rejecting a banner that
+// isn't an image before anything is saved
+func parseCategoryLandingForm(c echo.Context) (categoryLandingInput, error) {
+	input := categoryLandingInput{
+		BannerAltText:   strings.TrimSpace(c.FormValue("banner_alt_text")),
+		ContentMarkdown: strings.TrimSpace(c.FormValue("content_markdown")),
+		SeoTitle:        strings.TrimSpace(c.FormValue("seo_title")),
+		SeoDescription:  strings.TrimSpace(c.FormValue("seo_description")),
+		RemoveBanner:    c.FormValue("remove_banner") == "on",
+	}
+
+	if file, err := c.FormFile("banner_image"); err == nil && file != nil {
+		if !portfolioImageExtensions[strings.ToLower(filepath.Ext(file.Filename))] {
+			return input, fmt.Errorf("banner must be a JPG, PNG or WebP image")
+		}
+		input.Banner = file
+	}
+	return input, nil
+}
+
+// saveCategoryBanner applies the form's banner change: a new upload replaces the
+// current banner, and ticking "remove" clears it
+func (h *AdminHandler) saveCategoryBanner(ctx context.Context, category db.Category, input categoryLandingInput) error {
+	if input.Banner == nil {
+		if !input.RemoveBanner || category.BannerImage == "" {
+			return nil
+		}
+		if err := h.storage.Queries.SetCategoryBannerImage(ctx, db.SetCategoryBannerImageParams{
+			BannerImage: "",
+			ID:          category.ID,
+		}); err != nil {
+			return err
+		}
+		removeCategoryBanner(category.BannerImage)
+		return nil
+	}
+
+	// Resized copies are cached by filename across all upload directories, so the
+	// prefix keeps banners apart from product images
+	filename := "category_" + uuid.New().String() + strings.ToLower(filepath.Ext(input.Banner.Filename))
+	if err := saveUpload(input.Banner, categoryImagesDir, filename); err != nil {
+		return err
+	}
+	if err := h.storage.Queries.SetCategoryBannerImage(ctx, db.SetCategoryBannerImageParams{
+		BannerImage: filename,
+		ID:          category.ID,
+	}); err != nil {
+		os.Remove(filepath.Join(categoryImagesDir, filename))
+		return err
+	}
+	images.Default().GenerateInBackground(filename)
+	removeCategoryBanner(category.BannerImage)
+	return nil
+}
+
+// removeCategoryBanner deletes an uploaded banner and its resized copies
+func removeCategoryBanner(filename string) {
+	if filename == "" || filename != filepath.Base(filename) {
+		return
+	}
+	if err := os.Remove(filepath.Join(categoryImagesDir, filename)); err != nil && !os.IsNotExist(err) {
+		slog.Error("failed to remove category banner", "error", err, "filename", filename)
+	}
+	images.Default().Remove(filename)
+}
+
+// HandleSaveCategoryFeaturedProduct hand-picks a product to lead the category page.
+// Picking one that's already featured updates its position.
+func (h *AdminHandler) HandleSaveCategoryFeaturedProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	categoryID := c.Param("id")
+
+	productID := c.FormValue("product_id")
+	if productID == "" {
+		return c.String(http.StatusBadRequest, "Choose a product to feature")
+	}
+	displayOrder, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("display_order")), 10, 64)
+
+	if _, err := h.storage.Queries.GetCategory(ctx, categoryID); err != nil {
+		return c.String(http.StatusNotFound, "Category not found")
+	}
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		return c.String(http.StatusNotFound, "Product not found")
+	}
+
+	if err := h.storage.Queries.UpsertCategoryFeaturedProduct(ctx, db.UpsertCategoryFeaturedProductParams{
+		CategoryID:   categoryID,
+		ProductID:    productID,
+		DisplayOrder: displayOrder,
+	}); err != nil {
+		slog.Error("failed to feature product", "error", err, "category_id", categoryID, "product_id", productID)
+		return c.String(http.StatusInternalServerError, "Failed to feature product")
+	}
+
+	return c.Redirect(http.StatusSeeOther, categoryFormURL(categoryID)+"#featured-products")
+}
+
+// HandleDeleteCategoryFeaturedProduct drops a hand-picked product from the category page
+func (h *AdminHandler) HandleDeleteCategoryFeaturedProduct(c echo.Context) error {
+	categoryID := c.Param("id")
+	productID := c.Param("productId")
+
+	if err := h.storage.Queries.DeleteCategoryFeaturedProduct(c.Request().Context(), db.DeleteCategoryFeaturedProductParams{
+		CategoryID: categoryID,
+		ProductID:  productID,
+	}); err != nil {
+		slog.Error("failed to unfeature product", "error", err, "category_id", categoryID, "product_id", productID)
+		return c.String(http.StatusInternalServerError, "Failed to remove featured product")
+	}
+
+	return c.Redirect(http.StatusSeeOther, categoryFormURL(categoryID)+"#featured-products")
+}
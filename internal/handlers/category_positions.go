@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func categoryArrangeURL(categoryID, flash, errorMsg string) string {
+	target := fmt.Sprintf("/admin/category/%s/arrange", categoryID)
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// HandleArrangeCategory shows a category's products in storefront order for dragging
+// into place
+func (h *AdminHandler) HandleArrangeCategory(c echo.Context) error {
+	ctx := c.Request().Context()
+	categoryID := c.Param("id")
+
+	category, err := h.storage.Queries.GetCategory(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Category not found")
+		}
+		slog.Error("failed to get category for arranging", "error", err, "category_id", categoryID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load category")
+	}
+
+	products, err := h.storage.Queries.ListCategoryProductsForArranging(ctx, categoryID)
+	if err != nil {
+		slog.Error("failed to list category products for arranging", "error", err, "category_id", categoryID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+	}
+
+	return Render(c, admin.CategoryArrangePage(c, category, products, c.QueryParam("saved"), c.QueryParam("error")))
+}
+
+// HandleSaveCategoryArrangement stores the submitted product order as the category's
+// positions, replacing the previous arrangement in one transaction. Products that have
+// left the category since the page loaded are skipped.
+func (h *AdminHandler) HandleSaveCategoryArrangement(c echo.Context) error {
+	ctx := c.Request().Context()
+	categoryID := c.Param("id")
+
+	form, err := c.FormParams()
+	if err != nil {
+		slog.Error("failed to read category arrangement form", "error", err, "category_id", categoryID)
+		return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "", "Could not read the submitted order"))
+	}
+	productIDs := form["product_id"]
+	if len(productIDs) == 0 {
+		return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "", "There are no products to arrange"))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin category arrangement transaction", "error", err, "category_id", categoryID)
+		return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "", "Could not save the order"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	if err := queries.ClearCategoryProductPositions(ctx, categoryID); err != nil {
+		slog.Error("failed to clear category positions", "error", err, "category_id", categoryID)
+		return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "", "Could not save the order"))
+	}
+	placed := 0
+	for i, productID := range productIDs {
+		affected, err := queries.SetCategoryProductPosition(ctx, db.SetCategoryProductPositionParams{
+			Position:   int64(i + 1),
+			ProductID:  productID,
+			CategoryID: categoryID,
+		})
+		if err != nil {
+			slog.Error("failed to set category position", "error", err, "category_id", categoryID, "product_id", productID)
+			return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "", "Could not save the order"))
+		}
+		if affected == 0 {
+			slog.Warn("skipping product no longer in category", "category_id", categoryID, "product_id", productID)
+			continue
+		}
+		placed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit category arrangement", "error", err, "category_id", categoryID)
+		return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "", "Could not save the order"))
+	}
+
+	slog.Info("category arrangement saved", "category_id", categoryID, "product_count", placed)
+	return c.Redirect(http.StatusSeeOther, categoryArrangeURL(categoryID, "Product order saved", ""))
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func categoriesURL(flash, errorMsg string) string {
+	switch {
+	case errorMsg != "":
+		return "/admin/categories?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		return "/admin/categories?saved=" + url.QueryEscape(flash)
+	}
+	return "/admin/categories"
+}
+
+// buildCategoryTree nests the categories under their parents, keeping the query's
+// order among siblings. A category whose parent is missing, or that sits in a parent
+// loop left by older data, is shown at the top level so it can be dragged back into
+// place.
+func buildCategoryTree(rows []db.ListCategoryTreeRow) []*admin.CategoryTreeNode {
+	known := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		known[row.ID] = true
+	}
+	children := make(map[string][]db.ListCategoryTreeRow)
+	var roots []db.ListCategoryTreeRow
+	for _, row := range rows {
+		if row.ParentID.Valid && known[row.ParentID.String] && row.ParentID.String != row.ID {
+			children[row.ParentID.String] = append(children[row.ParentID.String], row)
+		} else {
+			roots = append(roots, row)
+		}
+	}
+
+	placed := make(map[string]bool, len(rows))
+	var attach func(row db.ListCategoryTreeRow) *admin.CategoryTreeNode
+	attach = func(row db.ListCategoryTreeRow) *admin.CategoryTreeNode {
+		placed[row.ID] = true
+		node := &admin.CategoryTreeNode{Category: row}
+		for _, child := range children[row.ID] {
+			if !placed[child.ID] {
+				node.Children = append(node.Children, attach(child))
+			}
+		}
+		return node
+	}
+
+	tree := make([]*admin.CategoryTreeNode, 0, len(roots))
+	for _, row := range roots {
+		tree = append(tree, attach(row))
+	}
+	for _, row := range rows {
+		if !placed[row.ID] {
+			tree = append(tree, attach(row))
+		}
+	}
+	return tree
+}
+
+// planCategoryTree turns the tree submitted by the editor into each category's new
+// parent and position. ids lists the categories parents first, in display order, with
+// parentIDs alongside; an empty parent puts a category at the top level. Categories
+// missing from the submission, such as ones added since the page loaded, keep their
+// place. A tree that can't be saved comes back with the problem to show instead.
+func planCategoryTree(categories []db.Category, ids, parentIDs []string) ([]db.MoveCategoryParams, string) {
+	if len(ids) == 0 || len(ids) != len(parentIDs) {
+		return nil, "Could not read the submitted tree"
+	}
+
+	parents := make(map[string]string, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID.String
+	}
+
+	submitted := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		if _, ok := parents[id]; !ok {
+			return nil, "A category was deleted since the page loaded. Reload and try again"
+		}
+		if submitted[id] {
+			return nil, "Could not read the submitted tree"
+		}
+		submitted[id] = true
+		parentID := parentIDs[i]
+		if parentID != "" {
+			if _, ok := parents[parentID]; !ok {
+				return nil, "A category was deleted since the page loaded. Reload and try again"
+			}
+		}
+		parents[id] = parentID
+	}
+
+	// Following the parents up from any category must reach the top level, or the
+	// shop navigation would loop
+	for _, id := range ids {
+		seen := map[string]bool{id: true}
+		for parentID := parents[id]; parentID != ""; parentID = parents[parentID] {
+			if seen[parentID] {
+				return nil, "A category can't be moved under itself or one of its subcategories"
+			}
+			seen[parentID] = true
+		}
+	}
+
+	positions := make(map[string]int64)
+	moves := make([]db.MoveCategoryParams, 0, len(ids))
+	for i, id := range ids {
+		parentID := parentIDs[i]
+		positions[parentID]++
+		moves = append(moves, db.MoveCategoryParams{
+			ParentID:     sql.NullString{String: parentID, Valid: parentID != ""},
+			DisplayOrder: sql.NullInt64{Int64: positions[parentID], Valid: true},
+			ID:           id,
+		})
+	}
+	return moves, ""
+}
+
+// HandleSaveCategoryTree stores the tree arranged by dragging on the categories page:
+// each category's parent and its order among its siblings, in one transaction
+func (h *AdminHandler) HandleSaveCategoryTree(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	form, err := c.FormParams()
+	if err != nil {
+		slog.Error("failed to read category tree form", "error", err)
+		return c.Redirect(http.StatusSeeOther, categoriesURL("", "Could not read the submitted tree"))
+	}
+
+	categories, err := h.storage.Queries.ListCategories(ctx)
+	if err != nil {
+		slog.Error("failed to list categories for tree", "error", err)
+		return c.Redirect(http.StatusSeeOther, categoriesURL("", "Could not save the tree"))
+	}
+	moves, problem := planCategoryTree(categories, form["category_id"], form["parent_id"])
+	if problem != "" {
+		return c.Redirect(http.StatusSeeOther, categoriesURL("", problem))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin category tree transaction", "error", err)
+		return c.Redirect(http.StatusSeeOther, categoriesURL("", "Could not save the tree"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	for _, move := range moves {
+		if _, err := queries.MoveCategory(ctx, move); err != nil {
+			slog.Error("failed to move category", "error", err, "category_id", move.ID, "parent_id", move.ParentID.String)
+			return c.Redirect(http.StatusSeeOther, categoriesURL("", "Could not save the tree"))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit category tree", "error", err)
+		return c.Redirect(http.StatusSeeOther, categoriesURL("", "Could not save the tree"))
+	}
+
+	slog.Info("category tree saved", "category_count", len(moves))
+	return c.Redirect(http.StatusSeeOther, categoriesURL("Category tree saved", ""))
+}
+
+// recordCategorySlugChange keeps the slug a category is giving up, so its old shop
+// URL redirects to the new one, and drops the new slug from the history now that it
+// is in use again
+func recordCategorySlugChange(ctx context.Context, queries *db.Queries, categoryID, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := queries.DeleteCategorySlugHistory(ctx, newSlug); err != nil {
+		return err
+	}
+	if oldSlug == "" {
+		return nil
+	}
+	return queries.RecordCategorySlug(ctx, db.RecordCategorySlugParams{
+		Slug:       oldSlug,
+		CategoryID: categoryID,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func testCategory(id, parentID string) db.Category {
+	return db.Category{ID: id, Name: id, Slug: id, ParentID: sql.NullString{String: parentID, Valid: parentID != ""}}
+}
+
+func TestPlanCategoryTree(t *testing.T) {
+	categories := []db.Category{testCategory("toys", ""), testCategory("dinos", "toys"), testCategory("rex", "dinos"), testCategory("art", "")}
+
+	moves, problem := planCategoryTree(categories, []string{"art", "dinos", "rex", "toys"}, []string{"", "art", "art", ""})
+	require.Empty(t, problem)
+	assert.Equal(t, []db.MoveCategoryParams{
+		{ID: "art", ParentID: sql.NullString{}, DisplayOrder: sql.NullInt64{Int64: 1, Valid: true}},
+		{ID: "dinos", ParentID: sql.NullString{String: "art", Valid: true}, DisplayOrder: sql.NullInt64{Int64: 1, Valid: true}},
+		{ID: "rex", ParentID: sql.NullString{String: "art", Valid: true}, DisplayOrder: sql.NullInt64{Int64: 2, Valid: true}},
+		{ID: "toys", ParentID: sql.NullString{}, DisplayOrder: sql.NullInt64{Int64: 2, Valid: true}},
+	}, moves)
+
+	_, problem = planCategoryTree(categories, []string{"toys"}, []string{"rex"})
+	assert.Contains(t, problem, "under itself", "toys can't go under its own grandchild")
+
+	_, problem = planCategoryTree(categories, []string{"toys", "gone"}, []string{"", "toys"})
+	assert.Contains(t, problem, "deleted since the page loaded")
+
+	_, problem = planCategoryTree(categories, []string{"toys", "dinos"}, []string{""})
+	assert.NotEmpty(t, problem)
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	row := func(id, parentID string) db.ListCategoryTreeRow {
+		return db.ListCategoryTreeRow{ID: id, Name: id, ParentID: sql.NullString{String: parentID, Valid: parentID != ""}}
+	}
+	tree := buildCategoryTree([]db.ListCategoryTreeRow{
+		row("toys", ""), row("dinos", "toys"), row("rex", "dinos"), row("orphan", "missing"), row("loop-a", "loop-b"), row("loop-b", "loop-a"),
+	})
+
+	require.Len(t, tree, 3)
+	assert.Equal(t, "toys", tree[0].Category.ID)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "rex", tree[0].Children[0].Children[0].Category.ID)
+	assert.Equal(t, "orphan", tree[1].Category.ID)
+	assert.Equal(t, "loop-a", tree[2].Category.ID, "a parent loop still shows, so it can be fixed")
+	assert.Equal(t, "loop-b", tree[2].Children[0].Category.ID)
+}
+
+func TestRecordCategorySlugChange(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, id := range []string{"cat-1", "cat-2"} {
+		_, err := queries.CreateCategory(ctx, db.CreateCategoryParams{ID: id, Name: id, Slug: id})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, recordCategorySlugChange(ctx, queries, "cat-1", "dinosaurs", "dinos"))
+	require.NoError(t, recordCategorySlugChange(ctx, queries, "cat-1", "dinos", "prehistoric"))
+	for _, slug := range []string{"dinosaurs", "dinos"} {
+		renamed, err := queries.GetCategoryByPreviousSlug(ctx, slug)
+		require.NoError(t, err)
+		assert.Equal(t, "cat-1", renamed.ID, "every old slug points at the category, not the slug after it")
+	}
+
+	// Another category taking an old slug stops it redirecting
+	require.NoError(t, recordCategorySlugChange(ctx, queries, "cat-2", "cat-2", "dinos"))
+	_, err := queries.GetCategoryByPreviousSlug(ctx, "dinos")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	renamed, err := queries.GetCategoryByPreviousSlug(ctx, "cat-2")
+	require.NoError(t, err)
+	assert.Equal(t, "cat-2", renamed.ID)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func checkoutAddOnsURL(errorMsg string) string {
+	if errorMsg == "" {
+		return "/admin/checkout-add-ons"
+	}
+	return "/admin/checkout-add-ons?error=" + url.QueryEscape(errorMsg)
+}
+
+// HandleCheckoutAddOns lists the add-on rules with how often each offer was taken
+func (h *AdminHandler) HandleCheckoutAddOns(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	rules, err := h.storage.Queries.ListCheckoutAddOnRules(ctx)
+	if err != nil {
+		slog.Error("failed to list checkout add-on rules", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load add-ons")
+	}
+
+	categories, err := h.storage.Queries.ListCategories(ctx)
+	if err != nil {
+		slog.Error("failed to list categories for checkout add-ons", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load categories")
+	}
+
+	products, err := h.storage.Queries.ListProducts(ctx)
+	if err != nil {
+		slog.Error("failed to list products for checkout add-ons", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+	}
+
+	return Render(c, admin.CheckoutAddOns(c, rules, categories, products, c.QueryParam("error")))
+}
+
+// HandleCreateCheckoutAddOnRule adds a rule offering a product with carts from a category,
+// or with every cart when no category is chosen
+func (h *AdminHandler) HandleCreateCheckoutAddOnRule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	productID := strings.TrimSpace(c.FormValue("product_id"))
+	if productID == "" {
+		return c.Redirect(http.StatusSeeOther, checkoutAddOnsURL("Choose a product to offer"))
+	}
+	categoryID := strings.TrimSpace(c.FormValue("category_id"))
+
+	var priority int64
+	if raw := strings.TrimSpace(c.FormValue("priority")); raw != "" {
+		p, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.Redirect(http.StatusSeeOther, checkoutAddOnsURL("Priority must be a whole number"))
+		}
+		priority = p
+	}
+
+	ruleID := uuid.New().String()
+	if err := h.storage.Queries.CreateCheckoutAddOnRule(ctx, db.CreateCheckoutAddOnRuleParams{
+		ID:         ruleID,
+		CategoryID: sql.NullString{String: categoryID, Valid: categoryID != ""},
+		ProductID:  productID,
+		Priority:   priority,
+		IsActive:   true,
+	}); err != nil {
+		slog.Error("failed to create checkout add-on rule", "error", err, "product_id", productID, "category_id", categoryID)
+		return c.Redirect(http.StatusSeeOther, checkoutAddOnsURL("Could not save add-on"))
+	}
+
+	slog.Info("checkout add-on rule created", "rule_id", ruleID, "product_id", productID, "category_id", categoryID)
+	return c.Redirect(http.StatusSeeOther, "/admin/checkout-add-ons")
+}
+
+// HandleToggleCheckoutAddOnRule switches an add-on rule on or off
+func (h *AdminHandler) HandleToggleCheckoutAddOnRule(c echo.Context) error {
+	ctx := c.Request().Context()
+	ruleID := c.Param("id")
+	active := c.FormValue("is_active") == "true"
+
+	if err := h.storage.Queries.SetCheckoutAddOnRuleActive(ctx, db.SetCheckoutAddOnRuleActiveParams{
+		IsActive: active,
+		ID:       ruleID,
+	}); err != nil {
+		slog.Error("failed to toggle checkout add-on rule", "error", err, "rule_id", ruleID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update add-on")
+	}
+
+	slog.Info("checkout add-on rule toggled", "rule_id", ruleID, "is_active", active)
+	return c.Redirect(http.StatusSeeOther, "/admin/checkout-add-ons")
+}
+
+// HandleDeleteCheckoutAddOnRule removes an add-on rule along with its counts
+func (h *AdminHandler) HandleDeleteCheckoutAddOnRule(c echo.Context) error {
+	ctx := c.Request().Context()
+	ruleID := c.Param("id")
+
+	if err := h.storage.Queries.DeleteCheckoutAddOnRule(ctx, ruleID); err != nil {
+		slog.Error("failed to delete checkout add-on rule", "error", err, "rule_id", ruleID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete add-on")
+	}
+
+	slog.Info("checkout add-on rule deleted", "rule_id", ruleID)
+	return c.Redirect(http.StatusSeeOther, "/admin/checkout-add-ons")
+}
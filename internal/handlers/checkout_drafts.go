@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	stripego "github.com/stripe/stripe-go/v80"
+)
+
+// HandleCheckoutDraftPaid makes the order for an embedded checkout once its payment
+// intent has succeeded. The draft is turned into the Checkout Session it stands in for,
+// so the order is made just as a hosted checkout's is. It's called from the webhook and
+// from the payment page's return, whichever comes first.
+func (h *PaymentHandler) HandleCheckoutDraftPaid(c echo.Context, draftID string, intent *stripego.PaymentIntent) error {
+	ctx := c.Request().Context()
+
+	draft, err := h.queries.GetCheckoutDraft(ctx, draftID)
+	if err != nil {
+		return fmt.Errorf("get checkout draft: %w", err)
+	}
+	if intent.ID != draft.PaymentIntentID {
+		return fmt.Errorf("payment intent %s does not belong to checkout draft %s", intent.ID, draftID)
+	}
+	if intent.Amount != draft.AmountCents || intent.Currency != stripego.CurrencyUSD {
+		return fmt.Errorf("payment intent %s charged %d %s, checkout draft %s is %d usd", intent.ID, intent.Amount, intent.Currency, draftID, draft.AmountCents)
+	}
+
+	session, err := draftCheckoutSession(draft)
+	if err != nil {
+		return err
+	}
+	if err := h.handleCheckoutCompleted(c, session); err != nil {
+		return err
+	}
+
+	rows, err := h.queries.MarkCheckoutDraftPaid(ctx, draftID)
+	if err != nil {
+		// The order exists; the draft staying open only leaves it for the expiry job,
+		// which won't cancel a payment that succeeded
+		slog.Error("failed to mark checkout draft paid", "error", err, "draft_id", draftID)
+		return nil
+	}
+	if rows > 0 {
+		h.recordCheckoutDraftTax(ctx, draft)
+	}
+	return nil
+}
+
+// draftCheckoutSession rebuilds the Checkout Session a draft stands in for
+func draftCheckoutSession(draft db.CheckoutDraft) (*stripego.CheckoutSession, error) {
+	var lines []stripe.DraftLine
+	if err := json.Unmarshal([]byte(draft.LinesJson), &lines); err != nil {
+		return nil, fmt.Errorf("decode checkout draft lines: %w", err)
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(draft.MetadataJson), &metadata); err != nil {
+		return nil, fmt.Errorf("decode checkout draft metadata: %w", err)
+	}
+	var address shipping.Address
+	if err := json.Unmarshal([]byte(draft.ShippingAddressJson), &address); err != nil {
+		return nil, fmt.Errorf("decode checkout draft address: %w", err)
+	}
+
+	country := address.CountryCode
+	if country == "" {
+		country = "US"
+	}
+	name := address.Name
+	if name == "" {
+		name = draft.CustomerEmail
+	}
+	var created int64
+	if draft.CreatedAt.Valid {
+		created = draft.CreatedAt.Time.Unix()
+	}
+
+	return stripe.DraftSession(stripe.DraftCheckout{
+		ID:            draft.ID,
+		Lines:         lines,
+		Metadata:      metadata,
+		CustomerEmail: draft.CustomerEmail,
+		CustomerName:  name,
+		CustomerPhone: address.Phone,
+		Address: &stripego.Address{
+			Line1:      address.AddressLine1,
+			Line2:      address.AddressLine2,
+			City:       address.CityLocality,
+			State:      address.StateProvince,
+			PostalCode: address.PostalCode,
+			Country:    country,
+		},
+		TotalCents:       draft.AmountCents,
+		TaxCents:         draft.TaxCents,
+		DiscountCents:    draft.DiscountCents,
+		StripeCustomerID: draft.StripeCustomerID,
+		PaymentIntentID:  draft.PaymentIntentID,
+		Created:          created,
+	}), nil
+}
+
+// recordCheckoutDraftTax records a paid draft's tax calculation as a Stripe Tax
+// transaction, which hosted Checkout does by itself, so it shows in Stripe's tax reports
+func (h *PaymentHandler) recordCheckoutDraftTax(ctx context.Context, draft db.CheckoutDraft) {
+	if draft.TaxCalculationID == "" {
+		return
+	}
+	transaction, err := stripe.RecordDraftTax(draft.TaxCalculationID, draft.ID)
+	if err != nil {
+		slog.Error("failed to record tax transaction for checkout draft", "error", err, "draft_id", draft.ID, "tax_calculation_id", draft.TaxCalculationID)
+		return
+	}
+	if err := h.queries.SetCheckoutDraftTaxTransaction(ctx, db.SetCheckoutDraftTaxTransactionParams{
+		TaxTransactionID: transaction.ID,
+		ID:               draft.ID,
+	}); err != nil {
+		slog.Error("failed to save tax transaction for checkout draft", "error", err, "draft_id", draft.ID, "tax_transaction_id", transaction.ID)
+	}
+}
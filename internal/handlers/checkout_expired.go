@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	stripego "github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// expiredCheckoutEmail picks the address to remind: whatever the customer typed into
+// Stripe, falling back to their account's email. A guest has no account, so user is
+// empty for them.
+func expiredCheckoutEmail(session *stripego.CheckoutSession, user db.User) string {
+	if session.CustomerDetails != nil && session.CustomerDetails.Email != "" {
+		return session.CustomerDetails.Email
+	}
+	if session.CustomerEmail != "" {
+		return session.CustomerEmail
+	}
+	return user.Email
+}
+
+// handleCartCheckoutExpired follows up on a cart checkout that Stripe expired before
+// it was paid. Stock is only taken when an order is paid, and what the checkout held
+// has been released by now; the cart is only cleared on payment too, so it's still as
+// the customer left it. The session is recorded so a retried webhook is ignored, and
+// the customer gets one "complete your order" email linking back to their cart, where
+// Checkout starts a fresh session. A guest's cart is found by the browser session the
+// checkout started from, and the email goes to the address they gave Stripe.
+func (h *PaymentHandler) handleCartCheckoutExpired(ctx context.Context, session *stripego.CheckoutSession) error {
+	userID := session.Metadata["user_id"]
+	sessionID := session.Metadata["session_id"]
+
+	var cartItems []db.GetCartByUserRow
+	switch {
+	case userID != "":
+		items, err := h.queries.GetCartByUser(ctx, sql.NullString{String: userID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to load cart: %w", err)
+		}
+		cartItems = items
+	case sessionID != "":
+		items, err := h.queries.GetCartBySession(ctx, sql.NullString{String: sessionID, Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to load guest cart: %w", err)
+		}
+		for _, item := range items {
+			cartItems = append(cartItems, db.GetCartByUserRow(item))
+		}
+	default:
+		return nil
+	}
+	if len(cartItems) == 0 {
+		// Emptied since, or bought in another checkout
+		return nil
+	}
+
+	var user db.User
+	if userID != "" {
+		var err error
+		user, err = h.queries.GetUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to load user: %w", err)
+		}
+	}
+	customerEmail := expiredCheckoutEmail(session, user)
+
+	token := uuid.New().String()
+	recorded, err := h.queries.CreateExpiredCheckout(ctx, db.CreateExpiredCheckoutParams{
+		ID:            session.ID,
+		UserID:        sql.NullString{String: userID, Valid: userID != ""},
+		SessionID:     sql.NullString{String: sessionID, Valid: userID == ""},
+		CustomerEmail: customerEmail,
+		Token:         token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record expired checkout: %w", err)
+	}
+	if recorded == 0 || customerEmail == "" {
+		return nil
+	}
+
+	data := &email.CheckoutExpiredData{
+		CustomerName:  strings.TrimSpace(user.FirstName.String),
+		CustomerEmail: customerEmail,
+		SessionID:     session.ID,
+		CheckoutURL:   settings.For(h.queries).Values(ctx).SiteURL() + "/cart/restore/" + token,
+	}
+	if data.CustomerName == "" {
+		data.CustomerName = user.FullName
+	}
+	if data.CustomerName == "" && session.CustomerDetails != nil {
+		data.CustomerName = session.CustomerDetails.Name
+	}
+	for _, item := range cartItems {
+		data.Items = append(data.Items, email.AbandonedCartItem{
+			ProductName:  item.Name,
+			ProductImage: item.ImageUrl,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.PriceCents,
+		})
+		data.ItemCount += item.Quantity
+		data.CartValue += item.PriceCents * item.Quantity
+	}
+
+	// Customers who turned off cart reminders aren't sent one
+	if err := h.emailService.SendCheckoutExpired(data); errors.Is(err, email.ErrOptedOut) {
+		return nil
+	} else if err != nil {
+		slog.Error("failed to send expired checkout email", "error", err, "session_id", session.ID)
+		return nil
+	}
+	if err := h.queries.MarkExpiredCheckoutEmailed(ctx, session.ID); err != nil {
+		slog.Error("failed to mark expired checkout emailed", "error", err, "session_id", session.ID)
+	}
+
+	slog.Info("expired checkout reminder sent", "session_id", session.ID, "user_id", userID, "guest", userID == "", "items", data.ItemCount)
+	return nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripe "github.com/stripe/stripe-go/v80"
+
+	emailutil "github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestHandleCartCheckoutExpiredGuest(t *testing.T) {
+	database, queries, cleanup := NewTestDB()
+	defer cleanup()
+	ctx := context.Background()
+	handler := NewPaymentHandler(queries, emailutil.NewService(queries))
+
+	_, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:         "prod-owl",
+		Name:       "Flexi Owl",
+		Slug:       "flexi-owl",
+		PriceCents: 1200,
+		IsActive:   sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, queries.AddToCart(ctx, db.AddToCartParams{
+		ID:        "line-owl",
+		SessionID: sql.NullString{String: "guest-session", Valid: true},
+		ProductID: "prod-owl",
+		Quantity:  2,
+	}))
+
+	expire := func(id string, metadata map[string]string) {
+		require.NoError(t, handler.handleCartCheckoutExpired(ctx, &stripe.CheckoutSession{
+			ID:              id,
+			Metadata:        metadata,
+			CustomerDetails: &stripe.CheckoutSessionCustomerDetails{Email: "guest@example.com", Name: "Guest Shopper"},
+		}))
+	}
+
+	// A guest's cart is found by the browser session and reminded at the Stripe email
+	expire("cs_guest", map[string]string{"session_id": "guest-session", "user_id": ""})
+	var userID, sessionID sql.NullString
+	var customerEmail string
+	require.NoError(t, database.QueryRow(`SELECT user_id, session_id, customer_email FROM expired_checkouts WHERE id = 'cs_guest'`).Scan(&userID, &sessionID, &customerEmail))
+	assert.False(t, userID.Valid)
+	assert.Equal(t, "guest-session", sessionID.String)
+	assert.Equal(t, "guest@example.com", customerEmail)
+
+	// An empty guest cart has nothing to come back to
+	expire("cs_empty", map[string]string{"session_id": "other-session"})
+	var count int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM expired_checkouts WHERE id = 'cs_empty'`).Scan(&count))
+	assert.Zero(t, count)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func designerFormURL(slug, errorMsg string) string {
+	target := fmt.Sprintf("/admin/designers/%s", slug)
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+	return target
+}
+
+// HandleDesignersList shows every designer with products, and whether they have a profile
+func (h *AdminHandler) HandleDesignersList(c echo.Context) error {
+	designers, err := h.storage.Queries.ListDesignersWithProfiles(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to list designers", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designers")
+	}
+
+	return Render(c, admin.DesignersList(c, designers))
+}
+
+// designerBySlug finds the designer a slug belongs to among those with products
+func (h *AdminHandler) designerBySlug(c echo.Context, slug string) (string, error) {
+	designers, err := h.storage.Queries.ListDesigners(c.Request().Context())
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(designers))
+	for _, designer := range designers {
+		if designer.DesignerName.Valid {
+			names = append(names, designer.DesignerName.String)
+		}
+	}
+	name, ok := utils.DesignerNameForSlug(names, slug)
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return name, nil
+}
+
+// HandleDesignerForm shows the profile editor for a designer
+func (h *AdminHandler) HandleDesignerForm(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	name, err := h.designerBySlug(c, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Designer not found")
+		}
+		slog.Error("failed to find designer", "error", err, "slug", slug)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designer")
+	}
+
+	profile, err := h.storage.Queries.GetDesignerProfile(ctx, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get designer profile", "error", err, "designer", name)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designer")
+	}
+	profile.DesignerName = name
+
+	return Render(c, admin.DesignerForm(c, slug, profile, c.QueryParam("error")))
+}
+
+// HandleSaveDesigner creates or updates a designer's profile
+func (h *AdminHandler) HandleSaveDesigner(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	name, err := h.designerBySlug(c, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Designer not found")
+		}
+		slog.Error("failed to find designer", "error", err, "slug", slug)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save designer")
+	}
+
+	websiteURL := strings.TrimSpace(c.FormValue("website_url"))
+	if websiteURL != "" && utils.SafeExternalURL(websiteURL) == "" {
+		return c.Redirect(http.StatusSeeOther, designerFormURL(slug, "Website must be a full http:// or https:// link"))
+	}
+
+	if err := h.storage.Queries.UpsertDesignerProfile(ctx, db.UpsertDesignerProfileParams{
+		DesignerName: name,
+		BioMarkdown:  strings.TrimSpace(c.FormValue("bio_markdown")),
+		WebsiteUrl:   websiteURL,
+		LicenseNote:  strings.TrimSpace(c.FormValue("license_note")),
+	}); err != nil {
+		slog.Error("failed to save designer profile", "error", err, "designer", name)
+		return c.Redirect(http.StatusSeeOther, designerFormURL(slug, "Could not save designer"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, designerFormURL(slug, ""))
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/downloads"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func productFilesURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#files", productID)
+}
+
+// saveProductDigitalSettings applies the product type, download limit and gift card
+// flag from the product form. Forms that don't include the section leave the
+// settings untouched.
+func (h *AdminHandler) saveProductDigitalSettings(c echo.Context, productID string) error {
+	if c.FormValue("digital_settings") == "" {
+		return nil
+	}
+
+	productType := utils.ProductTypePhysical
+	if c.FormValue("product_type") == utils.ProductTypeDigital {
+		productType = utils.ProductTypeDigital
+	}
+	// Gift cards are delivered by email, so there's nothing to ship
+	isGiftCard := c.FormValue("is_gift_card") == "on" || c.FormValue("is_gift_card") == "true"
+	if isGiftCard {
+		productType = utils.ProductTypeDigital
+	}
+
+	// A blank or zero limit means unlimited downloads
+	limit, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("download_limit")), 10, 64)
+
+	err := h.storage.Queries.UpdateProductDigitalSettings(c.Request().Context(), db.UpdateProductDigitalSettingsParams{
+		ProductType:   productType,
+		DownloadLimit: sql.NullInt64{Int64: limit, Valid: limit > 0},
+		IsGiftCard:    isGiftCard,
+		ID:            productID,
+	})
+	if err != nil {
+		slog.Error("failed to update product digital settings", "error", err, "product_id", productID)
+		return err
+	}
+	return nil
+}
+
+// HandleUploadProductFile stores a file for a digital product in private storage
+func (h *AdminHandler) HandleUploadProductFile(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		slog.Error("failed to load product for file upload", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Choose a file to upload")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		slog.Error("failed to open uploaded product file", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not read the uploaded file")
+	}
+	defer src.Close()
+
+	// Stored under a generated name; the original name is only used for the download
+	fileName := filepath.Base(file.Filename)
+	storagePath := filepath.Join(productID, uuid.New().String()+strings.ToLower(filepath.Ext(fileName)))
+	fullPath, err := downloads.FilePath(storagePath)
+	if err != nil {
+		slog.Error("invalid product file storage path", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid product")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
+		slog.Error("failed to create digital files directory", "error", err, "path", filepath.Dir(fullPath))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save file")
+	}
+
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		slog.Error("failed to create product file", "error", err, "path", fullPath)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save file")
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, src)
+	if err != nil {
+		slog.Error("failed to write product file", "error", err, "path", fullPath)
+		os.Remove(fullPath)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save file")
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(fileName))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	_, err = h.storage.Queries.CreateProductFile(ctx, db.CreateProductFileParams{
+		ID:          uuid.New().String(),
+		ProductID:   productID,
+		FileName:    fileName,
+		StoragePath: storagePath,
+		ContentType: contentType,
+		SizeBytes:   size,
+	})
+	if err != nil {
+		slog.Error("failed to save product file record", "error", err, "product_id", productID)
+		os.Remove(fullPath)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save file")
+	}
+
+	slog.Info("product file uploaded", "product_id", productID, "file_name", fileName, "size_bytes", size)
+	return c.Redirect(http.StatusSeeOther, productFilesURL(productID))
+}
+
+// HandleDeleteProductFile removes a digital product file. Past buyers lose access to it.
+func (h *AdminHandler) HandleDeleteProductFile(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	fileID := c.Param("fileId")
+
+	file, err := h.storage.Queries.GetProductFile(ctx, db.GetProductFileParams{
+		ID:        fileID,
+		ProductID: productID,
+	})
+	if err != nil {
+		slog.Error("failed to get product file", "error", err, "product_id", productID, "file_id", fileID)
+		return echo.NewHTTPError(http.StatusNotFound, "File not found")
+	}
+
+	if err := h.storage.Queries.DeleteProductFile(ctx, db.DeleteProductFileParams{
+		ID:        fileID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to delete product file", "error", err, "product_id", productID, "file_id", fileID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove file")
+	}
+
+	if fullPath, err := downloads.FilePath(file.StoragePath); err == nil {
+		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+			slog.Warn("failed to remove product file from storage", "error", err, "path", fullPath)
+		}
+	}
+
+	return c.Redirect(http.StatusSeeOther, productFilesURL(productID))
+}
+
+// grantDownloads gives an order item access to each of its product's files, with
+// the product's current download limit, and returns signed links for the
+// confirmation email
+func (h *PaymentHandler) grantDownloads(ctx context.Context, orderID, orderItemID, productID, productName string) ([]email.DownloadLink, error) {
+	product, err := h.queries.GetProduct(ctx, productID)
+	if err != nil {
+		slog.Error("failed to get digital product", "error", err, "product_id", productID)
+		return nil, err
+	}
+
+	files, err := h.queries.ListProductFiles(ctx, productID)
+	if err != nil {
+		slog.Error("failed to list product files", "error", err, "product_id", productID)
+		return nil, err
+	}
+	if len(files) == 0 {
+		slog.Warn("digital product sold without any files", "product_id", productID, "order_id", orderID)
+	}
+
+	signer := downloads.DefaultSigner()
+	expires := time.Now().Add(downloads.LinkTTL)
+	baseURL := downloads.BaseURL()
+
+	links := make([]email.DownloadLink, 0, len(files))
+	for _, file := range files {
+		downloadID := uuid.New().String()
+		if err := h.queries.CreateOrderDownload(ctx, db.CreateOrderDownloadParams{
+			ID:            downloadID,
+			OrderID:       orderID,
+			OrderItemID:   orderItemID,
+			ProductFileID: file.ID,
+			MaxDownloads:  product.DownloadLimit,
+		}); err != nil {
+			slog.Error("failed to create order download", "error", err, "order_id", orderID, "file_id", file.ID)
+			return links, err
+		}
+		links = append(links, email.DownloadLink{
+			ProductName: productName,
+			FileName:    file.FileName,
+			URL:         signer.URL(baseURL, downloadID, expires),
+		})
+	}
+	return links, nil
+}
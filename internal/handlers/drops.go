@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// saveProductDropSettings applies the drop time, purchase limit and per-order cap from
+// the product form. Forms that don't include the drop section leave the settings untouched.
+func (h *AdminHandler) saveProductDropSettings(c echo.Context, productID string) error {
+	if c.FormValue("drop_settings") == "" {
+		return nil
+	}
+
+	dropAt := sql.NullTime{}
+	if raw := strings.TrimSpace(c.FormValue("drop_at")); raw != "" {
+		if t, err := time.Parse("2006-01-02T15:04", raw); err == nil {
+			dropAt = sql.NullTime{Time: t, Valid: true}
+		} else {
+			slog.Warn("ignoring invalid drop time", "product_id", productID, "value", raw)
+		}
+	}
+
+	// Blank or invalid means no limit
+	limit, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("purchase_limit")), 10, 64)
+	limit = max(limit, 0)
+	perOrder, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("max_per_order")), 10, 64)
+	perOrder = max(perOrder, 0)
+
+	err := h.storage.Queries.UpdateProductDropSettings(c.Request().Context(), db.UpdateProductDropSettingsParams{
+		DropAt:        dropAt,
+		PurchaseLimit: limit,
+		MaxPerOrder:   perOrder,
+		ID:            productID,
+	})
+	if err != nil {
+		slog.Error("failed to update product drop settings", "error", err, "product_id", productID)
+		return err
+	}
+	return nil
+}
+
+// flagPurchaseLimits notes on the order any limited products the customer has now
+// bought more of than allowed. Payment has already been taken, so the order stands
+// and the note tells the admin to refund the excess. Add-to-cart enforces the limits
+// too; this catches what gets past it, like guest carts merged at sign-in or two
+// checkouts racing each other.
+func (h *PaymentHandler) flagPurchaseLimits(ctx context.Context, orderID, userID, customerEmail string, products []db.Product) {
+	var notes []string
+	checked := make(map[string]bool)
+	for _, product := range products {
+		if checked[product.ID] {
+			continue
+		}
+		checked[product.ID] = true
+
+		purchased, err := h.queries.GetCustomerPurchasedQuantity(ctx, db.GetCustomerPurchasedQuantityParams{
+			ProductID:     product.ID,
+			UserID:        userID,
+			CustomerEmail: customerEmail,
+		})
+		if err != nil {
+			slog.Error("failed to check purchase limit", "error", err, "order_id", orderID, "product_id", product.ID)
+			continue
+		}
+		if purchased <= product.PurchaseLimit {
+			continue
+		}
+
+		slog.Warn("order exceeds purchase limit", "order_id", orderID, "product_id", product.ID, "purchased", purchased, "limit", product.PurchaseLimit)
+		notes = append(notes, fmt.Sprintf("Purchase limit exceeded: customer has bought %d of %s (limit %d per customer)", purchased, product.Name, product.PurchaseLimit))
+	}
+	if len(notes) == 0 {
+		return
+	}
+
+	if _, err := h.queries.UpdateOrderNotes(ctx, db.UpdateOrderNotesParams{
+		Notes: sql.NullString{String: strings.Join(notes, "\n"), Valid: true},
+		ID:    orderID,
+	}); err != nil {
+		slog.Error("failed to flag order over purchase limit", "error", err, "order_id", orderID)
+	}
+}
@@ -108,35 +108,46 @@ func (h *EmailPreferencesHandler) HandleGetEmailPreferences(c echo.Context) erro
 		if err == sql.ErrNoRows {
 			// Return default preferences
 			return c.JSON(http.StatusOK, map[string]interface{}{
-				"email":           email,
-				"transactional":   true,
-				"abandoned_cart":  true,
-				"promotional":     false,
-				"newsletter":      false,
-				"product_updates": false,
+				"email":            email,
+				"transactional":    true,
+				"order_updates":    true,
+				"shipping_updates": true,
+				"abandoned_cart":   true,
+				"promotional":      false,
+				"newsletter":       false,
+				"product_updates":  false,
+				"back_in_stock":    true,
 			})
 		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch preferences"})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"email":           prefs.Email,
-		"transactional":   prefs.Transactional.Valid && prefs.Transactional.Int64 == 1,
-		"abandoned_cart":  prefs.AbandonedCart.Valid && prefs.AbandonedCart.Int64 == 1,
-		"promotional":     prefs.Promotional.Valid && prefs.Promotional.Int64 == 1,
-		"newsletter":      prefs.Newsletter.Valid && prefs.Newsletter.Int64 == 1,
-		"product_updates": prefs.ProductUpdates.Valid && prefs.ProductUpdates.Int64 == 1,
+		"email":            prefs.Email,
+		"transactional":    prefs.Transactional.Valid && prefs.Transactional.Int64 == 1,
+		"order_updates":    emailutil.PreferenceOn(prefs.OrderUpdates),
+		"shipping_updates": emailutil.PreferenceOn(prefs.ShippingUpdates),
+		"abandoned_cart":   prefs.AbandonedCart.Valid && prefs.AbandonedCart.Int64 == 1,
+		"promotional":      prefs.Promotional.Valid && prefs.Promotional.Int64 == 1,
+		"newsletter":       prefs.Newsletter.Valid && prefs.Newsletter.Int64 == 1,
+		"product_updates":  prefs.ProductUpdates.Valid && prefs.ProductUpdates.Int64 == 1,
+		"back_in_stock":    emailutil.PreferenceOn(prefs.BackInStock),
 	})
 }
 
 // HandleUpdateEmailPreferences updates the user's email preferences (JSON API)
 func (h *EmailPreferencesHandler) HandleUpdateEmailPreferences(c echo.Context) error {
+	// The service-email preferences are optional so older clients that only send the
+	// marketing ones leave them as they are
 	var req struct {
-		Email          string `json:"email"`
-		AbandonedCart  bool   `json:"abandoned_cart"`
-		Promotional    bool   `json:"promotional"`
-		Newsletter     bool   `json:"newsletter"`
-		ProductUpdates bool   `json:"product_updates"`
+		Email           string `json:"email"`
+		OrderUpdates    *bool  `json:"order_updates"`
+		ShippingUpdates *bool  `json:"shipping_updates"`
+		AbandonedCart   bool   `json:"abandoned_cart"`
+		Promotional     bool   `json:"promotional"`
+		Newsletter      bool   `json:"newsletter"`
+		ProductUpdates  bool   `json:"product_updates"`
+		BackInStock     *bool  `json:"back_in_stock"`
 	}
 
 	if err := c.Bind(&req); err != nil {
@@ -160,12 +171,15 @@ func (h *EmailPreferencesHandler) HandleUpdateEmailPreferences(c echo.Context) e
 
 	// Update preferences
 	err = h.queries.UpdateEmailPreferences(ctx, db.UpdateEmailPreferencesParams{
-		Transactional:  sql.NullInt64{Int64: 1, Valid: true}, // Always on
-		AbandonedCart:  sql.NullInt64{Int64: boolToInt64(req.AbandonedCart), Valid: true},
-		Promotional:    sql.NullInt64{Int64: boolToInt64(req.Promotional), Valid: true},
-		Newsletter:     sql.NullInt64{Int64: boolToInt64(req.Newsletter), Valid: true},
-		ProductUpdates: sql.NullInt64{Int64: boolToInt64(req.ProductUpdates), Valid: true},
-		ID:             prefs.ID,
+		Transactional:   sql.NullInt64{Int64: 1, Valid: true}, // Always on
+		AbandonedCart:   sql.NullInt64{Int64: boolToInt64(req.AbandonedCart), Valid: true},
+		Promotional:     sql.NullInt64{Int64: boolToInt64(req.Promotional), Valid: true},
+		Newsletter:      sql.NullInt64{Int64: boolToInt64(req.Newsletter), Valid: true},
+		ProductUpdates:  sql.NullInt64{Int64: boolToInt64(req.ProductUpdates), Valid: true},
+		OrderUpdates:    optionalPreference(req.OrderUpdates),
+		ShippingUpdates: optionalPreference(req.ShippingUpdates),
+		BackInStock:     optionalPreference(req.BackInStock),
+		ID:              prefs.ID,
 	})
 
 	if err != nil {
@@ -182,6 +196,14 @@ func boolToInt64(b bool) int64 {
 	return 0
 }
 
+// optionalPreference is NULL when a preference wasn't sent, which keeps the saved value
+func optionalPreference(b *bool) sql.NullInt64 {
+	if b == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: boolToInt64(*b), Valid: true}
+}
+
 // HandleEmailPreferencesPage renders the user-facing email preferences page
 func (h *EmailPreferencesHandler) HandleEmailPreferencesPage(c echo.Context) error {
 	// Get user from auth middleware
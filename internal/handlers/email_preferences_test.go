@@ -124,6 +124,57 @@ func TestHandleUpdateEmailPreferences_Success(t *testing.T) {
 	assert.Equal(t, prefs.ID, updatedPrefs.ID) // Same record, just updated
 }
 
+// TestHandleUpdateEmailPreferences_ServiceEmails tests the order, shipping and back in
+// stock preferences, which are only changed when sent
+func TestHandleUpdateEmailPreferences_ServiceEmails(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+
+	handler := NewEmailPreferencesHandler(queries)
+
+	user, err := CreateTestUser(queries)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = queries.GetOrCreateEmailPreferences(ctx, db.GetOrCreateEmailPreferencesParams{
+		ID:               ulid.Make().String(),
+		UserID:           sql.NullString{String: user.ID, Valid: true},
+		Email:            user.Email,
+		UnsubscribeToken: sql.NullString{String: ulid.Make().String(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	c, rec := NewTestContext(http.MethodPut, "/api/email-preferences", map[string]interface{}{
+		"email":            user.Email,
+		"shipping_updates": false,
+		"back_in_stock":    false,
+		"abandoned_cart":   true,
+	})
+	SetTestUser(c, user)
+	require.NoError(t, handler.HandleUpdateEmailPreferences(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	updatedPrefs, err := queries.GetEmailPreferencesByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updatedPrefs.ShippingUpdates.Int64)
+	assert.Equal(t, int64(0), updatedPrefs.BackInStock.Int64)
+	assert.Equal(t, int64(1), updatedPrefs.OrderUpdates.Int64, "not sent, so left on")
+
+	// A client that only sends the marketing preferences leaves them alone
+	c, rec = NewTestContext(http.MethodPut, "/api/email-preferences", map[string]interface{}{
+		"email":          user.Email,
+		"abandoned_cart": false,
+	})
+	SetTestUser(c, user)
+	require.NoError(t, handler.HandleUpdateEmailPreferences(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	updatedPrefs, err = queries.GetEmailPreferencesByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updatedPrefs.ShippingUpdates.Int64)
+	assert.Equal(t, int64(0), updatedPrefs.AbandonedCart.Int64)
+}
+
 // TestHandleUnsubscribe_ValidToken tests unsubscribe with valid token
 func TestHandleUnsubscribe_ValidToken(t *testing.T) {
 	_, queries, cleanup := NewTestDB()
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	stripego "github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+	"github.com/loganlanou/logans3d-v4/views/events"
+)
+
+func eventRegistrationsURL(eventID string) string {
+	return fmt.Sprintf("/admin/events/%s/registrations", eventID)
+}
+
+// handleEventCheckoutCompleted confirms a paid event booking. Stripe retries webhooks,
+// so a booking that's already confirmed is left alone.
+func (h *PaymentHandler) handleEventCheckoutCompleted(ctx context.Context, registrationID string, session *stripego.CheckoutSession) error {
+	if session.PaymentStatus != stripego.CheckoutSessionPaymentStatusPaid {
+		slog.Warn("event checkout completed without payment", "registration_id", registrationID, "payment_status", session.PaymentStatus)
+		return nil
+	}
+
+	confirmed, err := h.queries.UpdateEventRegistrationStatus(ctx, db.UpdateEventRegistrationStatusParams{
+		Status:     utils.RegistrationConfirmed,
+		ID:         registrationID,
+		FromStatus: utils.RegistrationPending,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to confirm event registration: %w", err)
+	}
+
+	registration, err := h.queries.GetEventRegistration(ctx, registrationID)
+	if err != nil {
+		return fmt.Errorf("failed to load event registration: %w", err)
+	}
+	if confirmed == 0 {
+		if registration.Status != utils.RegistrationConfirmed {
+			// Paid after the hold lapsed and the booking was cancelled; needs a refund or a seat
+			slog.Error("payment received for inactive event registration", "registration_id", registrationID, "status", registration.Status, "session_id", session.ID)
+		}
+		return nil
+	}
+
+	event, err := h.queries.GetEvent(ctx, registration.EventID)
+	if err != nil {
+		return fmt.Errorf("failed to load event: %w", err)
+	}
+
+	slog.Info("event registration paid", "registration_id", registrationID, "event_id", event.ID, "quantity", registration.Quantity)
+	if err := h.emailService.SendEventRegistrationConfirmation(events.RegistrationEmail(event, registration)); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send event registration email", "error", err, "registration_id", registrationID)
+	}
+	return nil
+}
+
+// handleEventCheckoutExpired releases the seats held by an unpaid booking
+func (h *PaymentHandler) handleEventCheckoutExpired(ctx context.Context, registrationID string) {
+	if _, err := h.queries.UpdateEventRegistrationStatus(ctx, db.UpdateEventRegistrationStatusParams{
+		Status:     utils.RegistrationCancelled,
+		ID:         registrationID,
+		FromStatus: utils.RegistrationPending,
+	}); err != nil {
+		slog.Error("failed to cancel expired event registration", "error", err, "registration_id", registrationID)
+	}
+}
+
+// HandleEventRegistrations shows an event's ticket types and attendee list
+func (h *AdminHandler) HandleEventRegistrations(c echo.Context) error {
+	ctx := c.Request().Context()
+	eventID := c.Param("id")
+
+	event, err := h.storage.Queries.GetEvent(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Event not found")
+		}
+		slog.Error("failed to fetch event", "error", err, "event_id", eventID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load event")
+	}
+
+	ticketTypes, err := h.storage.Queries.ListEventTicketTypes(ctx, eventID)
+	if err != nil {
+		slog.Error("failed to fetch event ticket types", "error", err, "event_id", eventID)
+		ticketTypes = []db.EventTicketType{}
+	}
+
+	registrations, err := h.storage.Queries.ListEventRegistrations(ctx, eventID)
+	if err != nil {
+		slog.Error("failed to fetch event registrations", "error", err, "event_id", eventID)
+		registrations = []db.EventRegistration{}
+	}
+
+	return Render(c, admin.EventRegistrationsPage(c, event, ticketTypes, registrations))
+}
+
+// HandleCreateEventTicketType adds a ticket type. A price of 0 makes it a free RSVP.
+func (h *AdminHandler) HandleCreateEventTicketType(c echo.Context) error {
+	ctx := c.Request().Context()
+	eventID := c.Param("id")
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name is required")
+	}
+	var priceCents int64
+	if raw := strings.TrimSpace(c.FormValue("price")); raw != "" {
+		price, err := strconv.ParseFloat(raw, 64)
+		if err != nil || price < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid price")
+		}
+		priceCents = int64(price*100 + 0.5)
+	}
+	displayOrder, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("display_order")), 10, 64)
+	description := strings.TrimSpace(c.FormValue("description"))
+
+	if _, err := h.storage.Queries.GetEvent(ctx, eventID); err != nil {
+		slog.Error("failed to load event for ticket type", "error", err, "event_id", eventID)
+		return echo.NewHTTPError(http.StatusNotFound, "Event not found")
+	}
+
+	_, err := h.storage.Queries.CreateEventTicketType(ctx, db.CreateEventTicketTypeParams{
+		ID:           uuid.New().String(),
+		EventID:      eventID,
+		Name:         name,
+		Description:  sql.NullString{String: description, Valid: description != ""},
+		PriceCents:   priceCents,
+		DisplayOrder: displayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to create event ticket type", "error", err, "event_id", eventID, "name", name)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save ticket type")
+	}
+
+	return c.Redirect(http.StatusSeeOther, eventRegistrationsURL(eventID))
+}
+
+// HandleDeleteEventTicketType removes a ticket type. Existing bookings keep the ticket name.
+func (h *AdminHandler) HandleDeleteEventTicketType(c echo.Context) error {
+	ctx := c.Request().Context()
+	eventID := c.Param("id")
+	ticketTypeID := c.Param("ticketTypeId")
+
+	if err := h.storage.Queries.DeleteEventTicketType(ctx, db.DeleteEventTicketTypeParams{
+		ID:      ticketTypeID,
+		EventID: eventID,
+	}); err != nil {
+		slog.Error("failed to delete event ticket type", "error", err, "event_id", eventID, "ticket_type_id", ticketTypeID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove ticket type")
+	}
+
+	return c.Redirect(http.StatusSeeOther, eventRegistrationsURL(eventID))
+}
+
+// HandleToggleEventCheckIn marks an attendee as arrived, or undoes it
+func (h *AdminHandler) HandleToggleEventCheckIn(c echo.Context) error {
+	ctx := c.Request().Context()
+	eventID := c.Param("id")
+	registrationID := c.Param("registrationId")
+
+	if _, err := h.storage.Queries.ToggleEventRegistrationCheckIn(ctx, db.ToggleEventRegistrationCheckInParams{
+		ID:      registrationID,
+		EventID: eventID,
+	}); err != nil {
+		slog.Error("failed to toggle event check-in", "error", err, "registration_id", registrationID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update check-in")
+	}
+
+	return c.Redirect(http.StatusSeeOther, eventRegistrationsURL(eventID)+"#registration-"+registrationID)
+}
+
+// HandleConfirmEventRegistration moves a booking off the waitlist and emails the
+// customer. Any payment for a paid ticket is arranged directly.
+func (h *AdminHandler) HandleConfirmEventRegistration(c echo.Context) error {
+	registration, err := h.adminEventRegistration(c)
+	if err != nil {
+		return err
+	}
+	if registration.Status != utils.RegistrationWaitlisted {
+		return echo.NewHTTPError(http.StatusBadRequest, "Only waitlisted registrations can be confirmed")
+	}
+	return h.moveEventRegistration(c, registration, utils.RegistrationConfirmed)
+}
+
+// HandleCancelEventRegistration cancels a booking, freeing its seats. Refunds for paid
+// tickets are made in Stripe.
+func (h *AdminHandler) HandleCancelEventRegistration(c echo.Context) error {
+	registration, err := h.adminEventRegistration(c)
+	if err != nil {
+		return err
+	}
+	return h.moveEventRegistration(c, registration, utils.RegistrationCancelled)
+}
+
+// adminEventRegistration loads the :registrationId registration, checking it belongs to the :id event
+func (h *AdminHandler) adminEventRegistration(c echo.Context) (db.EventRegistration, error) {
+	registrationID := c.Param("registrationId")
+	registration, err := h.storage.Queries.GetEventRegistration(c.Request().Context(), registrationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.EventRegistration{}, echo.NewHTTPError(http.StatusNotFound, "Registration not found")
+		}
+		slog.Error("failed to fetch event registration", "error", err, "registration_id", registrationID)
+		return db.EventRegistration{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load registration")
+	}
+	if registration.EventID != c.Param("id") {
+		return db.EventRegistration{}, echo.NewHTTPError(http.StatusNotFound, "Registration not found")
+	}
+	return registration, nil
+}
+
+func (h *AdminHandler) moveEventRegistration(c echo.Context, registration db.EventRegistration, to string) error {
+	ctx := c.Request().Context()
+
+	moved, err := h.storage.Queries.UpdateEventRegistrationStatus(ctx, db.UpdateEventRegistrationStatusParams{
+		Status:     to,
+		ID:         registration.ID,
+		FromStatus: registration.Status,
+	})
+	if err != nil {
+		slog.Error("failed to update event registration", "error", err, "registration_id", registration.ID, "status", to)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update registration")
+	}
+
+	if moved > 0 && to == utils.RegistrationConfirmed {
+		event, err := h.storage.Queries.GetEvent(ctx, registration.EventID)
+		if err != nil {
+			slog.Error("failed to load event for registration email", "error", err, "event_id", registration.EventID)
+		} else {
+			registration.Status = to
+			go h.sendEventRegistrationConfirmation(events.RegistrationEmail(event, registration))
+		}
+	}
+
+	return c.Redirect(http.StatusSeeOther, eventRegistrationsURL(registration.EventID)+"#registration-"+registration.ID)
+}
+
+func (h *AdminHandler) sendEventRegistrationConfirmation(data *email.EventRegistrationData) {
+	if err := h.emailService.SendEventRegistrationConfirmation(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send event registration email", "error", err, "registration_id", data.RegistrationID)
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/flags"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// featureFlagHistorySize is how many recent changes the flags page lists
+const featureFlagHistorySize = 50
+
+// Flag keys are what code checks, so they're limited to snake_case identifiers
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,62}$`)
+
+// Why a flag changed, as stored on feature_flag_changes.action
+const (
+	flagChangeCreated = "created"
+	flagChangeUpdated = "updated"
+	flagChangeDeleted = "deleted"
+)
+
+type FeatureFlagHandler struct {
+	storage *storage.Storage
+	flags   *flags.Store
+}
+
+func NewFeatureFlagHandler(storage *storage.Storage, flagStore *flags.Store) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		storage: storage,
+		flags:   flagStore,
+	}
+}
+
+func featureFlagsURL(flash, errorMsg string) string {
+	target := "/admin/feature-flags"
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// HandleFeatureFlags lists the flags and their recent changes
+func (h *FeatureFlagHandler) HandleFeatureFlags(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	rows, err := h.storage.Queries.ListFeatureFlags(ctx)
+	if err != nil {
+		slog.Error("failed to list feature flags", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load feature flags")
+	}
+	changes, err := h.storage.Queries.ListFeatureFlagChanges(ctx, featureFlagHistorySize)
+	if err != nil {
+		slog.Error("failed to list feature flag changes", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load feature flags")
+	}
+
+	featureFlags := make([]flags.Flag, 0, len(rows))
+	for _, row := range rows {
+		featureFlags = append(featureFlags, flags.FromDB(row))
+	}
+
+	return Render(c, admin.FeatureFlagsPage(c, featureFlags, changes, h.flags.Environment(), c.QueryParam("saved"), c.QueryParam("error")))
+}
+
+// HandleFeatureFlagForm shows the flag editor, empty for a new flag
+func (h *FeatureFlagHandler) HandleFeatureFlagForm(c echo.Context) error {
+	key := c.Param("key")
+	if key == "" {
+		return Render(c, admin.FeatureFlagForm(c, flags.Flag{RolloutPercent: 100}, true, ""))
+	}
+
+	row, err := h.storage.Queries.GetFeatureFlag(c.Request().Context(), key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Feature flag not found")
+		}
+		slog.Error("failed to get feature flag", "error", err, "key", key)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load feature flag")
+	}
+
+	return Render(c, admin.FeatureFlagForm(c, flags.FromDB(row), false, ""))
+}
+
+// parseFeatureFlagForm reads and validates the flag fields, returning a message for
+// the admin on failure
+func parseFeatureFlagForm(c echo.Context) (flags.Flag, string) {
+	flag := flags.Flag{
+		Key:          strings.TrimSpace(c.FormValue("key")),
+		Description:  strings.TrimSpace(c.FormValue("description")),
+		Enabled:      c.FormValue("enabled") != "",
+		Environments: flags.ParseEnvironments(c.FormValue("environments")),
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSpace(c.FormValue("rollout_percent")))
+	if err != nil || percent < 0 || percent > 100 {
+		return flag, "Rollout must be a whole number from 0 to 100"
+	}
+	flag.RolloutPercent = percent
+
+	if !featureFlagKeyPattern.MatchString(flag.Key) {
+		return flag, "Key must be lowercase letters, numbers and underscores, starting with a letter"
+	}
+	return flag, ""
+}
+
+// HandleCreateFeatureFlag saves a new flag
+func (h *FeatureFlagHandler) HandleCreateFeatureFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	flag, errMsg := parseFeatureFlagForm(c)
+	if errMsg != "" {
+		return Render(c, admin.FeatureFlagForm(c, flag, true, errMsg))
+	}
+
+	if _, err := h.storage.Queries.GetFeatureFlag(ctx, flag.Key); err == nil {
+		return Render(c, admin.FeatureFlagForm(c, flag, true, "A flag with this key already exists"))
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to check for existing feature flag", "error", err, "key", flag.Key)
+		return Render(c, admin.FeatureFlagForm(c, flag, true, "Could not save flag"))
+	}
+
+	actor := adminActor(c)
+	err := h.withChange(ctx, flag.Key, flagChangeCreated, "", flag.Describe(), actor, func(queries *db.Queries) error {
+		return queries.CreateFeatureFlag(ctx, db.CreateFeatureFlagParams{
+			Key:            flag.Key,
+			Description:    flag.Description,
+			Enabled:        flag.Enabled,
+			Environments:   flags.FormatEnvironments(flag.Environments),
+			RolloutPercent: int64(flag.RolloutPercent),
+			UpdatedBy:      actor,
+		})
+	})
+	if err != nil {
+		slog.Error("failed to create feature flag", "error", err, "key", flag.Key)
+		return Render(c, admin.FeatureFlagForm(c, flag, true, "Could not save flag"))
+	}
+
+	slog.Info("feature flag created", "key", flag.Key, "state", flag.Describe(), "by", actor)
+	return c.Redirect(http.StatusSeeOther, featureFlagsURL("Flag added", ""))
+}
+
+// HandleUpdateFeatureFlag saves changes to a flag
+func (h *FeatureFlagHandler) HandleUpdateFeatureFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+	key := c.Param("key")
+
+	flag, errMsg := parseFeatureFlagForm(c)
+	flag.Key = key
+	if errMsg != "" {
+		return Render(c, admin.FeatureFlagForm(c, flag, false, errMsg))
+	}
+
+	if err := h.saveFlag(c, flag); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Feature flag not found")
+		}
+		slog.Error("failed to update feature flag", "error", err, "key", key)
+		return Render(c, admin.FeatureFlagForm(c, flag, false, "Could not save flag"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, featureFlagsURL("Flag saved", ""))
+}
+
+// HandleToggleFeatureFlag switches a flag on or off, keeping its other settings
+func (h *FeatureFlagHandler) HandleToggleFeatureFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+	key := c.Param("key")
+
+	row, err := h.storage.Queries.GetFeatureFlag(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Feature flag not found")
+		}
+		slog.Error("failed to get feature flag", "error", err, "key", key)
+		return c.Redirect(http.StatusSeeOther, featureFlagsURL("", "Could not change flag"))
+	}
+
+	flag := flags.FromDB(row)
+	flag.Enabled = !flag.Enabled
+	if err := h.saveFlag(c, flag); err != nil {
+		slog.Error("failed to toggle feature flag", "error", err, "key", key)
+		return c.Redirect(http.StatusSeeOther, featureFlagsURL("", "Could not change flag"))
+	}
+
+	if flag.Enabled {
+		return c.Redirect(http.StatusSeeOther, featureFlagsURL(key+" turned on", ""))
+	}
+	return c.Redirect(http.StatusSeeOther, featureFlagsURL(key+" turned off", ""))
+}
+
+// saveFlag updates a flag and records the change. It returns sql.ErrNoRows when the
+// flag doesn't exist.
+func (h *FeatureFlagHandler) saveFlag(c echo.Context, flag flags.Flag) error {
+	ctx := c.Request().Context()
+
+	row, err := h.storage.Queries.GetFeatureFlag(ctx, flag.Key)
+	if err != nil {
+		return err
+	}
+	before := flags.FromDB(row).Describe()
+	actor := adminActor(c)
+
+	err = h.withChange(ctx, flag.Key, flagChangeUpdated, before, flag.Describe(), actor, func(queries *db.Queries) error {
+		return queries.UpdateFeatureFlag(ctx, db.UpdateFeatureFlagParams{
+			Description:    flag.Description,
+			Enabled:        flag.Enabled,
+			Environments:   flags.FormatEnvironments(flag.Environments),
+			RolloutPercent: int64(flag.RolloutPercent),
+			UpdatedBy:      actor,
+			Key:            flag.Key,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("feature flag updated", "key", flag.Key, "before", before, "after", flag.Describe(), "by", actor)
+	return nil
+}
+
+// HandleDeleteFeatureFlag removes a flag, which turns it off everywhere. Its history
+// is kept.
+func (h *FeatureFlagHandler) HandleDeleteFeatureFlag(c echo.Context) error {
+	ctx := c.Request().Context()
+	key := c.Param("key")
+
+	row, err := h.storage.Queries.GetFeatureFlag(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Feature flag not found")
+		}
+		slog.Error("failed to get feature flag", "error", err, "key", key)
+		return c.Redirect(http.StatusSeeOther, featureFlagsURL("", "Could not delete flag"))
+	}
+
+	actor := adminActor(c)
+	err = h.withChange(ctx, key, flagChangeDeleted, flags.FromDB(row).Describe(), "", actor, func(queries *db.Queries) error {
+		return queries.DeleteFeatureFlag(ctx, key)
+	})
+	if err != nil {
+		slog.Error("failed to delete feature flag", "error", err, "key", key)
+		return c.Redirect(http.StatusSeeOther, featureFlagsURL("", "Could not delete flag"))
+	}
+
+	slog.Info("feature flag deleted", "key", key, "by", actor)
+	return c.Redirect(http.StatusSeeOther, featureFlagsURL("Flag deleted", ""))
+}
+
+// withChange runs a flag write and its audit row in one transaction, then drops the
+// cached flags so the change applies to the next request
+func (h *FeatureFlagHandler) withChange(ctx context.Context, key, action, before, after, actor string, write func(*db.Queries) error) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin feature flag change: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	if err := write(queries); err != nil {
+		return err
+	}
+	if err := queries.CreateFeatureFlagChange(ctx, db.CreateFeatureFlagChangeParams{
+		ID:          uuid.New().String(),
+		FlagKey:     key,
+		Action:      action,
+		BeforeState: before,
+		AfterState:  after,
+		ChangedBy:   actor,
+	}); err != nil {
+		return fmt.Errorf("failed to record feature flag change: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit feature flag change: %w", err)
+	}
+
+	h.flags.Invalidate()
+	return nil
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFeatureFlagForm(t *testing.T) {
+	parse := func(form url.Values) (string, string, []string, int) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/feature-flags", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		flag, errMsg := parseFeatureFlagForm(c)
+		return errMsg, flag.Key, flag.Environments, flag.RolloutPercent
+	}
+
+	errMsg, key, environments, percent := parse(url.Values{
+		"key":             {"new_checkout"},
+		"environments":    {"Staging, production"},
+		"rollout_percent": {"25"},
+		"enabled":         {"1"},
+	})
+	assert.Empty(t, errMsg)
+	assert.Equal(t, "new_checkout", key)
+	assert.Equal(t, []string{"staging", "production"}, environments)
+	assert.Equal(t, 25, percent)
+
+	errMsg, _, _, _ = parse(url.Values{"key": {"New-Checkout"}, "rollout_percent": {"100"}})
+	assert.Contains(t, errMsg, "Key must be")
+
+	errMsg, _, _, _ = parse(url.Values{"key": {"new_checkout"}, "rollout_percent": {"150"}})
+	assert.Contains(t, errMsg, "Rollout must be")
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// maxBatchPrintOrders caps how many orders one batch print or label purchase takes,
+// which also bounds the label downloads and rate requests it waits on
+const maxBatchPrintOrders = 50
+
+// labelClient downloads label images for batch printing
+var labelClient = &http.Client{Timeout: 15 * time.Second}
+
+// loadStockLocations looks up where the given products are kept
+func loadStockLocations(ctx context.Context, queries *db.Queries, productIDs []string) (pdf.Locations, error) {
+	locations := make(pdf.Locations)
+	if len(productIDs) == 0 {
+		return locations, nil
+	}
+	rows, err := queries.ListStockLocationsForProducts(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		key := pdf.StockKey(row.ProductID, row.ProductSkuID)
+		locations[key] = append(locations[key], pdf.StockLocation{
+			Location: row.LocationName,
+			Bin:      row.Bin,
+			Quantity: row.Quantity,
+		})
+	}
+	return locations, nil
+}
+
+// buildPickList combines the lines of the orders waiting to be packed into one line per
+// product or SKU, sorted by where they're kept so the shelves are walked in order.
+// Items with no location come last. It also returns how many orders the list covers.
+func buildPickList(items []db.ListPickListItemsRow, locations pdf.Locations) ([]pdf.PickLine, int) {
+	var lines []pdf.PickLine
+	index := make(map[string]int)
+	orders := make(map[string]bool)
+	for _, item := range items {
+		orders[item.OrderID] = true
+		key := pdf.StockKey(item.ProductID, item.ProductSkuID)
+		i, ok := index[key]
+		if !ok {
+			i = len(lines)
+			index[key] = i
+			lines = append(lines, pdf.PickLine{
+				ProductName: item.ProductName,
+				SKU:         item.ProductSku,
+				Location:    locations.For(item.ProductID, item.ProductSkuID),
+			})
+		}
+		line := &lines[i]
+		line.Quantity += item.Quantity
+		line.ToPrint += min(item.BackorderedQuantity, item.Quantity)
+		if len(utils.DecodePersonalization(item.Personalization)) > 0 {
+			line.Personalized += item.Quantity
+		}
+		number := item.OrderID
+		if len(number) > 8 {
+			number = number[:8]
+		}
+		if !slices.Contains(line.Orders, number) {
+			line.Orders = append(line.Orders, number)
+		}
+	}
+
+	slices.SortStableFunc(lines, func(a, b pdf.PickLine) int {
+		if (a.Location == "") != (b.Location == "") {
+			if a.Location == "" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(cmp.Compare(a.Location, b.Location), cmp.Compare(a.ProductName, b.ProductName))
+	})
+	return lines, len(orders)
+}
+
+// renderPackingSlips renders packing slips for orders as one PDF
+func renderPackingSlips(ctx context.Context, queries *db.Queries, slips []pdf.Slip) ([]byte, error) {
+	var productIDs []string
+	for i, slip := range slips {
+		items, err := queries.GetOrderItems(ctx, slip.Order.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order items: %w", err)
+		}
+		slips[i].Items = items
+		for _, item := range items {
+			if !slices.Contains(productIDs, item.ProductID) {
+				productIDs = append(productIDs, item.ProductID)
+			}
+		}
+	}
+
+	locations, err := loadStockLocations(ctx, queries, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock locations: %w", err)
+	}
+
+	var buf bytes.Buffer
+	business := pdf.BusinessFromSettings(settings.For(queries).Values(ctx))
+	if err := pdf.PackingSlips(&buf, business, slips, locations); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// selectedOrderIDs reads the orders ticked on the orders list, or explains what's wrong
+// with the selection. action names what's being done with them.
+func selectedOrderIDs(c echo.Context, action string) ([]string, string) {
+	form, err := c.FormParams()
+	if err != nil {
+		return nil, "Could not read the selected orders"
+	}
+	var orderIDs []string
+	for _, id := range form["order_ids"] {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(orderIDs, id) {
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return nil, fmt.Sprintf("Select at least one order to %s", action)
+	}
+	if len(orderIDs) > maxBatchPrintOrders {
+		return nil, fmt.Sprintf("Select at most %d orders to %s at a time", maxBatchPrintOrders, action)
+	}
+	return orderIDs, ""
+}
+
+// fetchLabelImage downloads a bought label's image for batch printing
+func fetchLabelImage(ctx context.Context, labelURL string) (*pdf.LabelImage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, labelURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := labelClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("label download returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return &pdf.LabelImage{Data: data, Type: "PNG"}, nil
+	case "image/jpeg":
+		return &pdf.LabelImage{Data: data, Type: "JPG"}, nil
+	case "image/gif":
+		return &pdf.LabelImage{Data: data, Type: "GIF"}, nil
+	}
+	return nil, fmt.Errorf("label is not an image")
+}
+
+// HandleOrderPackingSlipPDF serves an order's packing slip as a PDF, with where each
+// item is kept
+func (h *AdminHandler) HandleOrderPackingSlipPDF(c echo.Context) error {
+	orderID := c.Param("id")
+	ctx := c.Request().Context()
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Order not found")
+		}
+		slog.Error("failed to fetch order for packing slip", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to fetch order")
+	}
+
+	slip, err := renderPackingSlips(ctx, h.storage.Queries, []pdf.Slip{{Order: order}})
+	if err != nil {
+		slog.Error("failed to render packing slip", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to create packing slip")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%s", pdf.PackingSlipFilename(order)))
+	return c.Blob(http.StatusOK, "application/pdf", slip)
+}
+
+// pickListDay reads the day a pick list is for from ?date=YYYY-MM-DD, in the shop's
+// time zone. It's today when not given.
+func pickListDay(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, now.Location())
+}
+
+// HandlePickList serves the combined pick list for a day: every open order placed by
+// the end of it, with what's still to be packed
+func (h *AdminHandler) HandlePickList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	now := time.Now()
+	day, err := pickListDay(c.QueryParam("date"), now)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+	}
+	// Orders are stamped in UTC
+	placedBefore := day.AddDate(0, 0, 1).UTC().Format("2006-01-02 15:04:05")
+	items, err := h.storage.Queries.ListPickListItems(ctx, placedBefore)
+	if err != nil {
+		slog.Error("failed to list pick list items", "error", err)
+		return c.String(http.StatusInternalServerError, "Failed to create pick list")
+	}
+
+	var productIDs []string
+	for _, item := range items {
+		if !slices.Contains(productIDs, item.ProductID) {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	locations, err := loadStockLocations(ctx, h.storage.Queries, productIDs)
+	if err != nil {
+		slog.Error("failed to load stock locations for pick list", "error", err)
+		return c.String(http.StatusInternalServerError, "Failed to create pick list")
+	}
+
+	lines, orderCount := buildPickList(items, locations)
+	var buf bytes.Buffer
+	business := pdf.BusinessFromSettings(settings.For(h.storage.Queries).Values(ctx))
+	if err := pdf.PickList(&buf, business, lines, orderCount, day, now); err != nil {
+		slog.Error("failed to render pick list", "error", err, "date", day.Format("2006-01-02"))
+		return c.String(http.StatusInternalServerError, "Failed to create pick list")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=pick-list-%s.pdf", day.Format("2006-01-02")))
+	return c.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// HandleBatchPrintOrders prints the orders ticked on the orders list as one PDF: each
+// order's label, when one has been bought, followed by its packing slip
+func (h *AdminHandler) HandleBatchPrintOrders(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	orderIDs, problem := selectedOrderIDs(c, "print")
+	if problem != "" {
+		return c.String(http.StatusBadRequest, problem)
+	}
+
+	orders, err := h.storage.Queries.ListOrdersByIDs(ctx, orderIDs)
+	if err != nil {
+		slog.Error("failed to fetch orders for batch printing", "error", err, "count", len(orderIDs))
+		return c.String(http.StatusInternalServerError, "Failed to fetch orders")
+	}
+	if len(orders) == 0 {
+		return c.String(http.StatusNotFound, "Orders not found")
+	}
+
+	slips := make([]pdf.Slip, 0, len(orders))
+	for _, order := range orders {
+		slip := pdf.Slip{Order: order}
+		if order.LabelImageUrl != "" {
+			// A label that can't be fetched still leaves its packing slip in the batch
+			label, err := fetchLabelImage(ctx, order.LabelImageUrl)
+			if err != nil {
+				slog.Warn("failed to fetch label image for batch printing", "error", err, "order_id", order.ID)
+			} else {
+				slip.Label = label
+			}
+		}
+		slips = append(slips, slip)
+	}
+
+	batch, err := renderPackingSlips(ctx, h.storage.Queries, slips)
+	if err != nil {
+		slog.Error("failed to render batch print", "error", err, "count", len(slips))
+		return c.String(http.StatusInternalServerError, "Failed to create labels and packing slips")
+	}
+
+	slog.Info("orders batch printed", "count", len(slips))
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=orders-%s.pdf", time.Now().Format("2006-01-02")))
+	return c.Blob(http.StatusOK, "application/pdf", batch)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPickList(t *testing.T) {
+	items := []db.ListPickListItemsRow{
+		{OrderID: "order-one-1", ProductID: "dragon", ProductSkuID: "red", ProductName: "Dragon (Red)", ProductSku: "DRG-RED", Quantity: 1},
+		{OrderID: "order-one-1", ProductID: "keychain", ProductName: "Keychain", Quantity: 2},
+		{OrderID: "order-two-2", ProductID: "dragon", ProductSkuID: "red", ProductName: "Dragon (Red)", ProductSku: "DRG-RED", Quantity: 2, BackorderedQuantity: 1},
+		{OrderID: "order-two-2", ProductID: "dragon", ProductSkuID: "blue", ProductName: "Dragon (Blue)", ProductSku: "DRG-BLU", Quantity: 1},
+		{OrderID: "order-thr-3", ProductID: "nameplate", ProductName: "Nameplate", Quantity: 1, Personalization: `[{"label":"Name","value":"Ada"}]`},
+	}
+	locations := pdf.Locations{
+		pdf.StockKey("dragon", "red"):  {{Location: "Studio", Bin: "B2"}},
+		pdf.StockKey("dragon", "blue"): {{Location: "Studio", Bin: "B1"}},
+		pdf.StockKey("nameplate", ""):  {{Location: "Studio", Bin: "A1"}},
+	}
+
+	lines, orderCount := buildPickList(items, locations)
+
+	assert.Equal(t, 3, orderCount)
+	require.Len(t, lines, 4)
+
+	// Walked in location order, with the unplaced keychain last
+	assert.Equal(t, "Nameplate", lines[0].ProductName)
+	assert.Equal(t, int64(1), lines[0].Personalized)
+	assert.Equal(t, "Dragon (Blue)", lines[1].ProductName)
+
+	red := lines[2]
+	assert.Equal(t, "Dragon (Red)", red.ProductName)
+	assert.Equal(t, "Studio · B2", red.Location)
+	assert.Equal(t, int64(3), red.Quantity)
+	assert.Equal(t, int64(1), red.ToPrint)
+	assert.Equal(t, []string{"order-on", "order-tw"}, red.Orders)
+
+	assert.Equal(t, "Keychain", lines[3].ProductName)
+	assert.Empty(t, lines[3].Location)
+	assert.Equal(t, int64(2), lines[3].Quantity)
+}
+
+func TestPickListDay(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err)
+	now := time.Date(2026, 3, 12, 21, 30, 0, 0, chicago)
+
+	day, err := pickListDay("", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 12, 0, 0, 0, 0, chicago), day)
+
+	day, err = pickListDay("2026-03-10", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, chicago), day)
+
+	_, err = pickListDay("03/10/2026", now)
+	assert.Error(t, err)
+}
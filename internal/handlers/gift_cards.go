@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// giftCardShopURL is where the gift card email sends its recipient
+func giftCardShopURL() string {
+	baseURL := os.Getenv("SITE_URL")
+	if baseURL == "" {
+		baseURL = "https://www.logans3dcreations.com"
+	}
+	return baseURL + "/shop"
+}
+
+// issuePurchasedGiftCards issues the cards bought on an order line, one per unit,
+// and emails them. A gift order's cards go to its recipient; otherwise they go to the
+// buyer to pass on. The order is already recorded, so failures are logged for admin
+// to issue the card by hand rather than failing the webhook.
+func (h *PaymentHandler) issuePurchasedGiftCards(ctx context.Context, orderID string, quantity, unitCents int64, customerName, customerEmail string, gift utils.GiftOptions) {
+	params := giftcards.IssueParams{
+		AmountCents:    unitCents,
+		RecipientEmail: customerEmail,
+		RecipientName:  customerName,
+		OrderID:        orderID,
+		Note:           "Bought online",
+	}
+	if gift.IsGift && gift.RecipientEmail != "" {
+		params.RecipientEmail = gift.RecipientEmail
+		params.RecipientName = gift.RecipientName
+		params.SenderName = customerName
+		params.Message = gift.Message
+	}
+
+	for i := int64(0); i < quantity; i++ {
+		card, err := h.giftCards.Issue(ctx, params)
+		if err != nil {
+			slog.Error("failed to issue purchased gift card", "error", err, "order_id", orderID, "amount_cents", unitCents)
+			continue
+		}
+		slog.Info("gift card issued for order", "gift_card_id", card.ID, "order_id", orderID, "amount_cents", unitCents)
+		sendGiftCardEmail(ctx, h.queries, h.emailService, card)
+	}
+}
+
+// sendGiftCardEmail emails a card to its recipient and records when it went
+func sendGiftCardEmail(ctx context.Context, queries *db.Queries, emailService *email.Service, card db.GiftCard) {
+	if card.RecipientEmail == "" {
+		return
+	}
+	data := &email.GiftCardData{
+		GiftCardID:     card.ID,
+		Code:           card.Code,
+		AmountCents:    card.BalanceCents,
+		RecipientName:  card.RecipientName,
+		RecipientEmail: card.RecipientEmail,
+		SenderName:     card.SenderName,
+		Message:        card.Message,
+		ShopURL:        giftCardShopURL(),
+	}
+	if card.ExpiresAt.Valid {
+		data.ExpiresAt = card.ExpiresAt.Time.Format("January 2, 2006")
+	}
+	if err := emailService.SendGiftCard(data); err != nil {
+		slog.Error("failed to send gift card email", "error", err, "gift_card_id", card.ID)
+		return
+	}
+	if err := queries.MarkGiftCardDelivered(ctx, card.ID); err != nil {
+		slog.Error("failed to mark gift card delivered", "error", err, "gift_card_id", card.ID)
+	}
+}
+
+// recordGiftCardPayment links the amount held on a gift card at checkout to the order
+// it paid for
+func (h *PaymentHandler) recordGiftCardPayment(ctx context.Context, orderID, checkoutSessionID, giftCardID string, amountCents int64) {
+	if err := h.queries.SetOrderGiftCard(ctx, db.SetOrderGiftCardParams{
+		GiftCardID:    sql.NullString{String: giftCardID, Valid: true},
+		GiftCardCents: amountCents,
+		ID:            orderID,
+	}); err != nil {
+		slog.Error("failed to record gift card payment on order", "error", err, "order_id", orderID, "gift_card_id", giftCardID)
+	}
+	if err := h.queries.AttachGiftCardRedemptionsToOrder(ctx, db.AttachGiftCardRedemptionsToOrderParams{
+		OrderID:           sql.NullString{String: orderID, Valid: true},
+		CheckoutSessionID: checkoutSessionID,
+	}); err != nil {
+		slog.Error("failed to link gift card redemption to order", "error", err, "order_id", orderID, "session_id", checkoutSessionID)
+	}
+}
+
+// releaseGiftCardHolds puts the gift card balance held for a checkout back on the card
+// once the checkout expires unpaid
+func (h *PaymentHandler) releaseGiftCardHolds(ctx context.Context, checkoutSessionID string) {
+	if err := h.giftCards.ReleaseSession(ctx, checkoutSessionID, "Checkout expired"); err != nil {
+		slog.Error("failed to release gift card hold", "error", err, "session_id", checkoutSessionID)
+		return
+	}
+	slog.Info("gift card hold released for expired checkout", "session_id", checkoutSessionID)
+}
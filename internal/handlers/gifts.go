@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+)
+
+// notifyGiftRecipient emails the recipient of a gift order when it ships, with the
+// tracking details but no prices, then marks the order so the email is only sent once.
+// Runs in the background after the status change, so failures are logged rather than
+// returned.
+func (h *AdminHandler) notifyGiftRecipient(orderID string) {
+	ctx := context.Background()
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order for gift shipped email", "error", err, "order_id", orderID)
+		return
+	}
+	if !order.IsGift || order.GiftRecipientEmail == "" || order.GiftNotifiedAt.Valid {
+		return
+	}
+
+	items, err := h.storage.Queries.GetOrderItems(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order items for gift shipped email", "error", err, "order_id", orderID)
+		return
+	}
+
+	data := &email.GiftShippedData{
+		OrderID:        order.ID,
+		RecipientName:  order.GiftRecipientName,
+		RecipientEmail: order.GiftRecipientEmail,
+		SenderName:     order.CustomerName,
+		GiftMessage:    order.GiftMessage,
+		Carrier:        order.Carrier.String,
+		TrackingNumber: order.TrackingNumber.String,
+		TrackingURL:    order.TrackingUrl.String,
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, email.GiftShippedItem{
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	if err := h.emailService.SendGiftShipped(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send gift shipped email", "error", err, "order_id", orderID)
+		return
+	}
+
+	if err := h.storage.Queries.MarkGiftRecipientNotified(ctx, orderID); err != nil {
+		slog.Error("failed to mark gift recipient notified", "error", err, "order_id", orderID)
+	}
+}
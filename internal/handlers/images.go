@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+)
+
+// imageCacheControl lets browsers and proxies keep resized images for a week; after
+// that the ETag makes revalidating cheap
+const imageCacheControl = "public, max-age=604800, stale-while-revalidate=86400"
+
+type ImageHandler struct {
+	pipeline *images.Pipeline
+}
+
+func NewImageHandler(pipeline *images.Pipeline) *ImageHandler {
+	return &ImageHandler{pipeline: pipeline}
+}
+
+// HandleImage serves /img/:preset/:filename, a resized copy of an uploaded product
+// image in the best format the browser accepts. Copies are generated on first request
+// if the upload or backfill hasn't made them yet.
+func (h *ImageHandler) HandleImage(c echo.Context) error {
+	preset, ok := images.PresetByName(c.Param("preset"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Image not found")
+	}
+	filename := c.Param("filename")
+
+	format := negotiateImageFormat(c.Request().Header.Get("Accept"), h.pipeline.Formats())
+	path, err := h.pipeline.Variant(c.Request().Context(), preset, filename, format)
+	if err != nil {
+		if errors.Is(err, images.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Image not found")
+		}
+		slog.Error("failed to prepare image variant", "error", err, "preset", preset.Name, "filename", filename)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load image")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		slog.Error("failed to open image variant", "error", err, "path", path)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load image")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		slog.Error("failed to stat image variant", "error", err, "path", path)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load image")
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, images.ContentType(path))
+	header.Set("Cache-Control", imageCacheControl)
+	header.Add(echo.HeaderVary, "Accept")
+	// The format is part of the tag so a cached WebP is never revalidated as a JPEG
+	header.Set("ETag", fmt.Sprintf(`"%s-%x-%x"`, strings.TrimPrefix(filepath.Ext(path), "."), info.ModTime().UnixNano(), info.Size()))
+
+	// ServeContent answers If-None-Match and Range requests against the headers above
+	http.ServeContent(c.Response(), c.Request(), "", info.ModTime(), f)
+	return nil
+}
+
+// negotiateImageFormat picks the first available format the Accept header lists
+func negotiateImageFormat(accept string, available []string) string {
+	for _, format := range available {
+		if strings.Contains(accept, "image/"+format) {
+			return format
+		}
+	}
+	return images.FormatOriginal
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+)
+
+func TestHandleImage(t *testing.T) {
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "products")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+	f, err := os.Create(filepath.Join(sourceDir, "dragon.jpg"))
+	require.NoError(t, err)
+	require.NoError(t, jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, 1000, 1000)), nil))
+	f.Close()
+
+	h := NewImageHandler(images.New(filepath.Join(dir, "variants"), sourceDir))
+	e := echo.New()
+	e.GET("/img/:preset/:filename", h.HandleImage)
+
+	req := httptest.NewRequest(http.MethodGet, "/img/card/dragon.jpg", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/jpeg", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, imageCacheControl, rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "Accept", rec.Header().Get(echo.HeaderVary))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/img/card/dragon.jpg", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+
+	for _, path := range []string{"/img/huge/dragon.jpg", "/img/card/missing.jpg", "/img/card/..%2Fproducts%2Fdragon.jpg"} {
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
+
+func TestNegotiateImageFormat(t *testing.T) {
+	available := []string{images.FormatAVIF, images.FormatWebP}
+	assert.Equal(t, images.FormatAVIF, negotiateImageFormat("image/avif,image/webp,*/*", available))
+	assert.Equal(t, images.FormatWebP, negotiateImageFormat("image/webp,*/*", available))
+	assert.Equal(t, images.FormatOriginal, negotiateImageFormat("*/*", available))
+	assert.Equal(t, images.FormatOriginal, negotiateImageFormat("image/avif", nil))
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+)
+
+// consumeInventoryHolds marks the stock held for a paid checkout used, now the
+// order's items have taken it off
+func (h *PaymentHandler) consumeInventoryHolds(ctx context.Context, orderID, checkoutSessionID string) {
+	if _, err := h.reservations.Consume(ctx, checkoutSessionID); err != nil {
+		// Left held, it would count against stock twice until it lapses
+		slog.Error("failed to consume stock holds", "error", err, "order_id", orderID, "session_id", checkoutSessionID)
+	}
+}
+
+// releaseInventoryHolds puts the stock held for a checkout back on sale once the
+// checkout expires unpaid
+func (h *PaymentHandler) releaseInventoryHolds(ctx context.Context, checkoutSessionID string) {
+	released, err := h.reservations.ReleaseSession(ctx, checkoutSessionID)
+	if err != nil {
+		slog.Error("failed to release stock holds", "error", err, "session_id", checkoutSessionID)
+		return
+	}
+	if released > 0 {
+		slog.Info("stock holds released for expired checkout", "session_id", checkoutSessionID, "lines", released)
+	}
+}
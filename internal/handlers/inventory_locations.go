@@ -0,0 +1,722 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// Why location stock changed, as stored on inventory_movements.reason
+const (
+	movementAdjustment  = "adjustment"
+	movementTransfer    = "transfer"
+	movementFulfillment = "fulfillment"
+)
+
+// productMovementHistorySize is how many recent movements the stock page lists
+const productMovementHistorySize = 50
+
+func inventoryLocationsURL(flash, errorMsg string) string {
+	target := "/admin/shipping/locations"
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+func productLocationsURL(productID, flash, errorMsg string) string {
+	target := fmt.Sprintf("/admin/product/%s/locations", productID)
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// inventoryLocationAddress is the ship-from address for labels sent from a location
+func inventoryLocationAddress(location db.InventoryLocation) shipping.Address {
+	name := location.ContactName
+	if name == "" {
+		name = location.Name
+	}
+	return shipping.Address{
+		Name:          name,
+		Phone:         location.Phone,
+		AddressLine1:  location.AddressLine1,
+		AddressLine2:  location.AddressLine2,
+		CityLocality:  location.CityLocality,
+		StateProvince: location.StateProvince,
+		PostalCode:    location.PostalCode,
+		CountryCode:   location.CountryCode,
+	}
+}
+
+// adminActor names the signed-in admin for the movement audit trail
+func adminActor(c echo.Context) string {
+	if user, ok := auth.GetDBUser(c); ok {
+		return user.Email
+	}
+	return ""
+}
+
+// HandleInventoryLocations lists the places stock is kept
+func (h *AdminHandler) HandleInventoryLocations(c echo.Context) error {
+	locations, err := h.storage.Queries.ListInventoryLocations(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to list inventory locations", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load locations")
+	}
+
+	return Render(c, admin.InventoryLocationsPage(c, locations, c.QueryParam("saved"), c.QueryParam("error")))
+}
+
+// HandleInventoryLocationForm shows the location editor, empty for a new location
+func (h *AdminHandler) HandleInventoryLocationForm(c echo.Context) error {
+	locationID := c.Param("id")
+	if locationID == "" {
+		return Render(c, admin.InventoryLocationForm(c, nil, ""))
+	}
+
+	location, err := h.storage.Queries.GetInventoryLocation(c.Request().Context(), locationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Location not found")
+		}
+		slog.Error("failed to get inventory location", "error", err, "location_id", locationID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load location")
+	}
+
+	return Render(c, admin.InventoryLocationForm(c, &location, ""))
+}
+
+// parseInventoryLocationForm reads and validates the location fields, returning a
+// message for the admin on failure. The address is only required once the location
+// is active, since labels can't be bought from an incomplete address.
+func parseInventoryLocationForm(c echo.Context) (db.InventoryLocation, string) {
+	location := db.InventoryLocation{
+		Name:          strings.TrimSpace(c.FormValue("name")),
+		ContactName:   strings.TrimSpace(c.FormValue("contact_name")),
+		Phone:         strings.TrimSpace(c.FormValue("phone")),
+		AddressLine1:  strings.TrimSpace(c.FormValue("address_line1")),
+		AddressLine2:  strings.TrimSpace(c.FormValue("address_line2")),
+		CityLocality:  strings.TrimSpace(c.FormValue("city_locality")),
+		StateProvince: strings.ToUpper(strings.TrimSpace(c.FormValue("state_province"))),
+		PostalCode:    strings.TrimSpace(c.FormValue("postal_code")),
+		CountryCode:   strings.ToUpper(strings.TrimSpace(c.FormValue("country_code"))),
+		IsDefault:     c.FormValue("is_default") != "",
+		IsActive:      c.FormValue("is_active") != "",
+	}
+	if location.CountryCode == "" {
+		location.CountryCode = "US"
+	}
+
+	if order := strings.TrimSpace(c.FormValue("display_order")); order != "" {
+		n, err := strconv.ParseInt(order, 10, 64)
+		if err != nil {
+			return location, "Display order must be a whole number"
+		}
+		location.DisplayOrder = n
+	}
+
+	if location.Name == "" {
+		return location, "Name is required"
+	}
+	if location.IsActive && (location.AddressLine1 == "" || location.CityLocality == "" || location.StateProvince == "" || location.PostalCode == "") {
+		return location, "An active location needs a full ship-from address"
+	}
+	if location.IsDefault && !location.IsActive {
+		return location, "The default location must be active"
+	}
+	return location, ""
+}
+
+// HandleCreateInventoryLocation saves a new location
+func (h *AdminHandler) HandleCreateInventoryLocation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	input, errMsg := parseInventoryLocationForm(c)
+	if errMsg != "" {
+		return Render(c, admin.InventoryLocationForm(c, &input, errMsg))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin inventory location transaction", "error", err)
+		return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	location, err := queries.CreateInventoryLocation(ctx, db.CreateInventoryLocationParams{
+		ID:            uuid.New().String(),
+		Name:          input.Name,
+		ContactName:   input.ContactName,
+		Phone:         input.Phone,
+		AddressLine1:  input.AddressLine1,
+		AddressLine2:  input.AddressLine2,
+		CityLocality:  input.CityLocality,
+		StateProvince: input.StateProvince,
+		PostalCode:    input.PostalCode,
+		CountryCode:   input.CountryCode,
+		IsDefault:     input.IsDefault,
+		IsActive:      input.IsActive,
+		DisplayOrder:  input.DisplayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to create inventory location", "error", err, "name", input.Name)
+		return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+	}
+	if location.IsDefault {
+		if err := queries.ClearOtherDefaultInventoryLocations(ctx, location.ID); err != nil {
+			slog.Error("failed to clear other default locations", "error", err, "location_id", location.ID)
+			return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit inventory location", "error", err, "name", input.Name)
+		return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+	}
+
+	slog.Info("inventory location created", "location_id", location.ID, "name", location.Name)
+	return c.Redirect(http.StatusSeeOther, inventoryLocationsURL("Location added", ""))
+}
+
+// HandleUpdateInventoryLocation saves changes to a location
+func (h *AdminHandler) HandleUpdateInventoryLocation(c echo.Context) error {
+	ctx := c.Request().Context()
+	locationID := c.Param("id")
+
+	input, errMsg := parseInventoryLocationForm(c)
+	input.ID = locationID
+	if errMsg != "" {
+		return Render(c, admin.InventoryLocationForm(c, &input, errMsg))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin inventory location transaction", "error", err, "location_id", locationID)
+		return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	_, err = queries.UpdateInventoryLocation(ctx, db.UpdateInventoryLocationParams{
+		Name:          input.Name,
+		ContactName:   input.ContactName,
+		Phone:         input.Phone,
+		AddressLine1:  input.AddressLine1,
+		AddressLine2:  input.AddressLine2,
+		CityLocality:  input.CityLocality,
+		StateProvince: input.StateProvince,
+		PostalCode:    input.PostalCode,
+		CountryCode:   input.CountryCode,
+		IsDefault:     input.IsDefault,
+		IsActive:      input.IsActive,
+		DisplayOrder:  input.DisplayOrder,
+		ID:            locationID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Location not found")
+		}
+		slog.Error("failed to update inventory location", "error", err, "location_id", locationID)
+		return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+	}
+	if input.IsDefault {
+		if err := queries.ClearOtherDefaultInventoryLocations(ctx, locationID); err != nil {
+			slog.Error("failed to clear other default locations", "error", err, "location_id", locationID)
+			return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit inventory location", "error", err, "location_id", locationID)
+		return Render(c, admin.InventoryLocationForm(c, &input, "Could not save location"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, inventoryLocationsURL("Location saved", ""))
+}
+
+// HandleDeleteInventoryLocation removes a location that has never held stock. Ones
+// with history are kept for the audit trail and should be deactivated instead.
+func (h *AdminHandler) HandleDeleteInventoryLocation(c echo.Context) error {
+	ctx := c.Request().Context()
+	locationID := c.Param("id")
+
+	movements, err := h.storage.Queries.CountInventoryMovementsForLocation(ctx, locationID)
+	if err != nil {
+		slog.Error("failed to count location movements", "error", err, "location_id", locationID)
+		return c.Redirect(http.StatusSeeOther, inventoryLocationsURL("", "Could not delete location"))
+	}
+	if movements > 0 {
+		return c.Redirect(http.StatusSeeOther, inventoryLocationsURL("", "That location has stock history, so deactivate it instead"))
+	}
+
+	if err := h.storage.Queries.DeleteInventoryLocation(ctx, locationID); err != nil {
+		slog.Error("failed to delete inventory location", "error", err, "location_id", locationID)
+		return c.Redirect(http.StatusSeeOther, inventoryLocationsURL("", "Could not delete location"))
+	}
+
+	slog.Info("inventory location deleted", "location_id", locationID)
+	return c.Redirect(http.StatusSeeOther, inventoryLocationsURL("Location deleted", ""))
+}
+
+// buildLocationStockGrid lays a product's stock out as stock items (rows) by location
+// (columns). A variant product has a row per SKU, anything else a single row for the
+// product itself. Inactive locations are only shown while they still hold stock.
+func buildLocationStockGrid(product db.Product, skus []db.GetProductSkusRow, locations []db.InventoryLocation, stock []db.ListProductLocationStockRow) admin.LocationStockGrid {
+	var grid admin.LocationStockGrid
+
+	held := make(map[string]int64, len(stock))
+	bins := make(map[string]string)
+	heldAt := make(map[string]bool)
+	for _, s := range stock {
+		held[s.LocationID+"|"+s.ProductSkuID] = s.Quantity
+		bins[s.LocationID+"|"+s.ProductSkuID] = s.Bin
+		if s.Quantity > 0 {
+			heldAt[s.LocationID] = true
+		}
+	}
+	for _, location := range locations {
+		if location.IsActive || heldAt[location.ID] {
+			grid.Locations = append(grid.Locations, location)
+		}
+	}
+
+	if product.HasVariants.Bool {
+		for _, sku := range skus {
+			grid.Rows = append(grid.Rows, admin.LocationStockRow{
+				ProductSkuID: sku.ID,
+				Label:        fmt.Sprintf("%s / %s (%s)", sku.StyleName, sku.SizeDisplayName, sku.Sku),
+				Available:    int64FromNull(sku.StockQuantity),
+			})
+		}
+	} else {
+		grid.Rows = append(grid.Rows, admin.LocationStockRow{
+			Label:     product.Name,
+			Available: int64FromNull(product.StockQuantity),
+		})
+	}
+
+	for i := range grid.Rows {
+		row := &grid.Rows[i]
+		row.Quantities = make([]int64, len(grid.Locations))
+		row.Bins = make([]string, len(grid.Locations))
+		for j, location := range grid.Locations {
+			row.Quantities[j] = held[location.ID+"|"+row.ProductSkuID]
+			row.Bins[j] = bins[location.ID+"|"+row.ProductSkuID]
+			row.OnHand += row.Quantities[j]
+		}
+	}
+	return grid
+}
+
+// HandleProductLocations shows where a product's stock is kept, with forms to adjust,
+// count and transfer it and the recent movement history
+func (h *AdminHandler) HandleProductLocations(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	product, err := h.storage.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+		}
+		slog.Error("failed to get product for location stock", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load product")
+	}
+
+	skus, err := h.storage.Queries.GetProductSkus(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load product SKUs for location stock", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load stock")
+	}
+	locations, err := h.storage.Queries.ListInventoryLocations(ctx)
+	if err != nil {
+		slog.Error("failed to list inventory locations", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load stock")
+	}
+	stock, err := h.storage.Queries.ListProductLocationStock(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load location stock", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load stock")
+	}
+	movements, err := h.storage.Queries.ListProductInventoryMovements(ctx, db.ListProductInventoryMovementsParams{
+		ProductID: productID,
+		Limit:     productMovementHistorySize,
+	})
+	if err != nil {
+		slog.Error("failed to load inventory movements", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load stock")
+	}
+
+	grid := buildLocationStockGrid(product, skus, locations, stock)
+	return Render(c, admin.ProductLocationsPage(c, product, grid, movements, c.QueryParam("saved"), c.QueryParam("error")))
+}
+
+// checkProductStockItem confirms a submitted SKU belongs to the product; an empty SKU
+// is the product's own stock
+func (h *AdminHandler) checkProductStockItem(ctx context.Context, productID, skuID string) error {
+	if skuID == "" {
+		_, err := h.storage.Queries.GetProduct(ctx, productID)
+		return err
+	}
+	_, err := h.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+		ID:        skuID,
+		ProductID: productID,
+	})
+	return err
+}
+
+// addAvailableStock moves the sellable count of a product or SKU by delta
+func addAvailableStock(ctx context.Context, queries *db.Queries, productID, skuID string, delta int64) error {
+	if skuID == "" {
+		return queries.AddProductAvailableStock(ctx, db.AddProductAvailableStockParams{Delta: delta, ID: productID})
+	}
+	return queries.AddSkuAvailableStock(ctx, db.AddSkuAvailableStockParams{Delta: delta, ID: skuID, ProductID: productID})
+}
+
+// HandleAdjustLocationStock records stock received at, removed from or counted at a
+// location. The sellable count moves by the same amount so the two stay in step.
+func (h *AdminHandler) HandleAdjustLocationStock(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	locationID := c.FormValue("location_id")
+	skuID := c.FormValue("product_sku_id")
+	mode := c.FormValue("mode")
+	note := strings.TrimSpace(c.FormValue("note"))
+
+	quantity, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("quantity")), 10, 64)
+	if err != nil || quantity < 0 {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Enter a quantity of zero or more"))
+	}
+	if _, err := h.storage.Queries.GetInventoryLocation(ctx, locationID); err != nil {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose a location"))
+	}
+	if err := h.checkProductStockItem(ctx, productID, skuID); err != nil {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose a variant of this product"))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin stock adjustment transaction", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not adjust stock"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	current, err := queries.GetLocationStockQuantity(ctx, db.GetLocationStockQuantityParams{
+		LocationID:   locationID,
+		ProductID:    productID,
+		ProductSkuID: skuID,
+	})
+	if err != nil {
+		slog.Error("failed to get location stock", "error", err, "product_id", productID, "location_id", locationID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not adjust stock"))
+	}
+
+	var delta int64
+	switch mode {
+	case "add":
+		delta = quantity
+	case "remove":
+		delta = -quantity
+	case "count":
+		delta = quantity - current
+		if note == "" {
+			note = "Stock count"
+		}
+	default:
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose add, remove or count"))
+	}
+	if delta == 0 {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "Stock unchanged", ""))
+	}
+	if current+delta < 0 {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", fmt.Sprintf("Only %d on hand there", current)))
+	}
+
+	if err := queries.AddLocationStock(ctx, db.AddLocationStockParams{
+		LocationID:   locationID,
+		ProductID:    productID,
+		ProductSkuID: skuID,
+		Delta:        delta,
+	}); err != nil {
+		slog.Error("failed to adjust location stock", "error", err, "product_id", productID, "location_id", locationID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not adjust stock"))
+	}
+	if err := addAvailableStock(ctx, queries, productID, skuID, delta); err != nil {
+		slog.Error("failed to adjust available stock", "error", err, "product_id", productID, "sku_id", skuID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not adjust stock"))
+	}
+	if err := queries.CreateInventoryMovement(ctx, db.CreateInventoryMovementParams{
+		ID:             uuid.New().String(),
+		LocationID:     locationID,
+		ProductID:      productID,
+		ProductSkuID:   skuID,
+		QuantityChange: delta,
+		Reason:         movementAdjustment,
+		Note:           note,
+		CreatedBy:      adminActor(c),
+	}); err != nil {
+		slog.Error("failed to record inventory movement", "error", err, "product_id", productID, "location_id", locationID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not adjust stock"))
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit stock adjustment", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not adjust stock"))
+	}
+
+	slog.Info("location stock adjusted", "product_id", productID, "sku_id", skuID, "location_id", locationID, "delta", delta)
+	return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "Stock updated", ""))
+}
+
+// HandleSetLocationStockBin records which shelf or bin a product or SKU is kept in at a
+// location, for packing slips and the pick list. A blank bin clears it.
+func (h *AdminHandler) HandleSetLocationStockBin(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	locationID := c.FormValue("location_id")
+	skuID := c.FormValue("product_sku_id")
+	bin := strings.TrimSpace(c.FormValue("bin"))
+
+	if len(bin) > 40 {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Keep the bin to 40 characters"))
+	}
+	if _, err := h.storage.Queries.GetInventoryLocation(ctx, locationID); err != nil {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose a location"))
+	}
+	if err := h.checkProductStockItem(ctx, productID, skuID); err != nil {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose a variant of this product"))
+	}
+
+	if err := h.storage.Queries.SetLocationStockBin(ctx, db.SetLocationStockBinParams{
+		LocationID:   locationID,
+		ProductID:    productID,
+		ProductSkuID: skuID,
+		Bin:          bin,
+	}); err != nil {
+		slog.Error("failed to set location stock bin", "error", err, "product_id", productID, "location_id", locationID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not save bin"))
+	}
+
+	slog.Info("location stock bin set", "product_id", productID, "sku_id", skuID, "location_id", locationID, "bin", bin)
+	return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "Bin saved", ""))
+}
+
+// HandleTransferLocationStock moves units between two locations. The sellable count
+// is unchanged; both sides of the move share a transfer ID in the history.
+func (h *AdminHandler) HandleTransferLocationStock(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	fromID := c.FormValue("from_location_id")
+	toID := c.FormValue("to_location_id")
+	skuID := c.FormValue("product_sku_id")
+	note := strings.TrimSpace(c.FormValue("note"))
+
+	quantity, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("quantity")), 10, 64)
+	if err != nil || quantity < 1 {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Enter how many to transfer"))
+	}
+	if fromID == toID {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose two different locations"))
+	}
+	if _, err := h.storage.Queries.GetInventoryLocation(ctx, fromID); err != nil {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose where the stock is coming from"))
+	}
+	to, err := h.storage.Queries.GetInventoryLocation(ctx, toID)
+	if err != nil || !to.IsActive {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose an active location to send the stock to"))
+	}
+	if err := h.checkProductStockItem(ctx, productID, skuID); err != nil {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Choose a variant of this product"))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin stock transfer transaction", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not transfer stock"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	current, err := queries.GetLocationStockQuantity(ctx, db.GetLocationStockQuantityParams{
+		LocationID:   fromID,
+		ProductID:    productID,
+		ProductSkuID: skuID,
+	})
+	if err != nil {
+		slog.Error("failed to get location stock", "error", err, "product_id", productID, "location_id", fromID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not transfer stock"))
+	}
+	if current < quantity {
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", fmt.Sprintf("Only %d on hand to transfer", current)))
+	}
+
+	transferID := uuid.New().String()
+	actor := adminActor(c)
+	for _, side := range []struct {
+		locationID string
+		delta      int64
+	}{
+		{fromID, -quantity},
+		{toID, quantity},
+	} {
+		if err := queries.AddLocationStock(ctx, db.AddLocationStockParams{
+			LocationID:   side.locationID,
+			ProductID:    productID,
+			ProductSkuID: skuID,
+			Delta:        side.delta,
+		}); err != nil {
+			slog.Error("failed to move location stock", "error", err, "product_id", productID, "location_id", side.locationID)
+			return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not transfer stock"))
+		}
+		if err := queries.CreateInventoryMovement(ctx, db.CreateInventoryMovementParams{
+			ID:             uuid.New().String(),
+			LocationID:     side.locationID,
+			ProductID:      productID,
+			ProductSkuID:   skuID,
+			QuantityChange: side.delta,
+			Reason:         movementTransfer,
+			TransferID:     sql.NullString{String: transferID, Valid: true},
+			Note:           note,
+			CreatedBy:      actor,
+		}); err != nil {
+			slog.Error("failed to record transfer movement", "error", err, "product_id", productID, "location_id", side.locationID)
+			return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not transfer stock"))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit stock transfer", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, "", "Could not transfer stock"))
+	}
+
+	slog.Info("location stock transferred", "product_id", productID, "sku_id", skuID, "from", fromID, "to", toID, "quantity", quantity, "transfer_id", transferID)
+	return c.Redirect(http.StatusSeeOther, productLocationsURL(productID, fmt.Sprintf("Moved %d to %s", quantity, to.Name), ""))
+}
+
+// resolveFulfillmentLocation returns the chosen location, or the default one when
+// none was chosen. ok is false when no location applies, e.g. before any are set up.
+func (h *AdminHandler) resolveFulfillmentLocation(ctx context.Context, locationID string) (db.InventoryLocation, bool, error) {
+	var (
+		location db.InventoryLocation
+		err      error
+	)
+	if locationID != "" {
+		location, err = h.storage.Queries.GetInventoryLocation(ctx, locationID)
+	} else {
+		location, err = h.storage.Queries.GetDefaultInventoryLocation(ctx)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return location, false, nil
+	}
+	if err != nil {
+		return location, false, err
+	}
+	return location, true, nil
+}
+
+// fulfillOrderFromLocation takes a shipped order's items out of the location the
+// stock left from, which is the default location when none was chosen. Items the
+// location is short of are taken as far as they go, and an order is only ever
+// fulfilled once.
+func (h *AdminHandler) fulfillOrderFromLocation(ctx context.Context, orderID, locationID string) error {
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+	if order.FulfillmentLocationID.Valid {
+		return nil
+	}
+
+	location, ok, err := h.resolveFulfillmentLocation(ctx, locationID)
+	if err != nil {
+		return fmt.Errorf("failed to get fulfillment location: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin fulfillment transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	items, err := queries.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	for _, item := range items {
+		skuID := item.ProductSkuID.String
+		onHand, err := queries.GetLocationStockQuantity(ctx, db.GetLocationStockQuantityParams{
+			LocationID:   location.ID,
+			ProductID:    item.ProductID,
+			ProductSkuID: skuID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get location stock for %s: %w", item.ProductID, err)
+		}
+		take := min(onHand, item.Quantity)
+		if take < item.Quantity {
+			slog.Warn("fulfillment location short of stock", "order_id", orderID, "location_id", location.ID,
+				"product_id", item.ProductID, "sku_id", skuID, "ordered", item.Quantity, "on_hand", onHand)
+		}
+		if take == 0 {
+			continue
+		}
+		if err := queries.AddLocationStock(ctx, db.AddLocationStockParams{
+			LocationID:   location.ID,
+			ProductID:    item.ProductID,
+			ProductSkuID: skuID,
+			Delta:        -take,
+		}); err != nil {
+			return fmt.Errorf("failed to take location stock for %s: %w", item.ProductID, err)
+		}
+		if err := queries.CreateInventoryMovement(ctx, db.CreateInventoryMovementParams{
+			ID:             uuid.New().String(),
+			LocationID:     location.ID,
+			ProductID:      item.ProductID,
+			ProductSkuID:   skuID,
+			QuantityChange: -take,
+			Reason:         movementFulfillment,
+			OrderID:        sql.NullString{String: orderID, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("failed to record fulfillment movement for %s: %w", item.ProductID, err)
+		}
+	}
+
+	if err := queries.SetOrderFulfillmentLocation(ctx, db.SetOrderFulfillmentLocationParams{
+		FulfillmentLocationID: sql.NullString{String: location.ID, Valid: true},
+		ID:                    orderID,
+	}); err != nil {
+		return fmt.Errorf("failed to set fulfillment location: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fulfillment: %w", err)
+	}
+
+	slog.Info("order fulfilled from location", "order_id", orderID, "location_id", location.ID, "location", location.Name)
+	return nil
+}
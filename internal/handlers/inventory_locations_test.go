@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLocationStockGrid(t *testing.T) {
+	locations := []db.InventoryLocation{
+		{ID: "studio", Name: "Studio", IsActive: true},
+		{ID: "trailer", Name: "Trailer", IsActive: true},
+		{ID: "old", Name: "Old shop"},
+		{ID: "gone", Name: "Storage unit"},
+	}
+
+	t.Run("variant product has a row per SKU", func(t *testing.T) {
+		product := db.Product{ID: "p1", Name: "Dragon", HasVariants: sql.NullBool{Bool: true, Valid: true}}
+		skus := []db.GetProductSkusRow{
+			{ID: "sku-1", Sku: "DRG-RED-S", StyleName: "Red", SizeDisplayName: "Small", StockQuantity: sql.NullInt64{Int64: 5, Valid: true}},
+			{ID: "sku-2", Sku: "DRG-BLU-S", StyleName: "Blue", SizeDisplayName: "Small"},
+		}
+		stock := []db.ListProductLocationStockRow{
+			{LocationID: "studio", ProductSkuID: "sku-1", Quantity: 3, Bin: "B3"},
+			{LocationID: "trailer", ProductSkuID: "sku-1", Quantity: 2},
+			{LocationID: "old", ProductSkuID: "sku-2", Quantity: 1},
+			{LocationID: "gone", ProductSkuID: "sku-2", Quantity: 0},
+		}
+
+		grid := buildLocationStockGrid(product, skus, locations, stock)
+
+		require.Len(t, grid.Locations, 3, "inactive locations show only while they hold stock")
+		assert.Equal(t, "old", grid.Locations[2].ID)
+		require.Len(t, grid.Rows, 2)
+		assert.Equal(t, "Red / Small (DRG-RED-S)", grid.Rows[0].Label)
+		assert.Equal(t, []int64{3, 2, 0}, grid.Rows[0].Quantities)
+		assert.Equal(t, []string{"B3", "", ""}, grid.Rows[0].Bins)
+		assert.Equal(t, int64(5), grid.Rows[0].OnHand)
+		assert.Equal(t, int64(5), grid.Rows[0].Available)
+		assert.Equal(t, int64(1), grid.Rows[1].OnHand)
+		assert.Equal(t, int64(0), grid.Rows[1].Available)
+	})
+
+	t.Run("simple product has one row for its own stock", func(t *testing.T) {
+		product := db.Product{ID: "p2", Name: "Keychain", StockQuantity: sql.NullInt64{Int64: 7, Valid: true}}
+		stock := []db.ListProductLocationStockRow{
+			{LocationID: "trailer", Quantity: 4},
+		}
+
+		grid := buildLocationStockGrid(product, nil, locations, stock)
+
+		require.Len(t, grid.Rows, 1)
+		assert.Empty(t, grid.Rows[0].ProductSkuID)
+		assert.Equal(t, []int64{0, 4}, grid.Rows[0].Quantities)
+		assert.Equal(t, int64(4), grid.Rows[0].OnHand)
+		assert.Equal(t, int64(7), grid.Rows[0].Available)
+	})
+}
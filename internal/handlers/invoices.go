@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/invoices"
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+)
+
+// HandleOrderInvoice serves an order's invoice PDF. It opens in the browser, or downloads with ?download=1.
+func (h *AdminHandler) HandleOrderInvoice(c echo.Context) error {
+	orderID := c.Param("id")
+	ctx := c.Request().Context()
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Order not found")
+		}
+		slog.Error("failed to fetch order for invoice", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to fetch order")
+	}
+
+	invoice, err := invoices.NewStore(h.storage.Queries).PDF(ctx, order)
+	if err != nil {
+		slog.Error("failed to render invoice", "error", err, "order_id", orderID)
+		return c.String(http.StatusInternalServerError, "Failed to create invoice")
+	}
+
+	disposition := "inline"
+	if c.QueryParam("download") == "1" {
+		disposition = "attachment"
+	}
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%s", disposition, pdf.InvoiceFilename(order)))
+	return c.Blob(http.StatusOK, "application/pdf", invoice)
+}
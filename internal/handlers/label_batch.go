@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// batchRateWorkers caps how many orders' rates are fetched from EasyPost at once
+const batchRateWorkers = 4
+
+// labelQuote is the rate picked for one order in a batch, or why there isn't one
+type labelQuote struct {
+	shipmentID string
+	rate       shipping.Rate
+	problem    string
+}
+
+// labelBatchProblem explains why an order can't have a label bought in a batch, or
+// returns "" when it can
+func labelBatchProblem(order db.Order) string {
+	switch {
+	case order.EasypostLabelUrl.Valid && order.EasypostLabelUrl.String != "":
+		return "Label already bought"
+	case !utils.CanMoveOrder(order.Status.String, utils.OrderShipped):
+		return fmt.Sprintf("Order is %s", order.Status.String)
+	case !order.EasypostShipmentID.Valid || order.EasypostShipmentID.String == "":
+		return "No EasyPost shipment linked to this order"
+	}
+	return ""
+}
+
+// quoteLabel fetches an order's rates and picks the cheapest of the preferred label
+// services. With inventory locations set up the rates are quoted from location, like
+// buying a single label from the default location.
+func (h *AdminHandler) quoteLabel(order db.Order, location *db.InventoryLocation) labelQuote {
+	quote := labelQuote{shipmentID: order.EasypostShipmentID.String}
+
+	var rates []shipping.Rate
+	var err error
+	if location != nil {
+		rates, err = h.shippingService.RatesFromAddress(quote.shipmentID, inventoryLocationAddress(*location))
+		if err == nil && len(rates) > 0 && rates[0].ShipmentID != "" {
+			quote.shipmentID = rates[0].ShipmentID
+		}
+	} else {
+		rates, err = h.shippingService.RefreshShipmentRates(quote.shipmentID)
+	}
+	if err != nil {
+		slog.Error("failed to get rates for batch label", "error", err, "order_id", order.ID, "shipment_id", quote.shipmentID)
+		quote.problem = "Could not get shipping rates"
+		return quote
+	}
+
+	rate, ok := h.shippingService.CheapestLabelRate(rates)
+	if !ok {
+		quote.problem = "No rate matches the batch label services"
+		return quote
+	}
+	quote.rate = rate
+	return quote
+}
+
+// HandleBatchBuyLabels buys labels for the orders ticked on the orders list. Each order
+// gets the cheapest rate among the label services in the shipping settings; rates are
+// fetched for several orders at once and the labels then bought one by one. The page
+// that follows lists which orders got a label and why the others didn't.
+func (h *AdminHandler) HandleBatchBuyLabels(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	orderIDs, problem := selectedOrderIDs(c, "buy labels for")
+	if problem != "" {
+		return c.String(http.StatusBadRequest, problem)
+	}
+	if h.shippingService == nil {
+		return c.String(http.StatusServiceUnavailable, "Shipping is not set up")
+	}
+
+	orders, err := h.storage.Queries.ListOrdersByIDs(ctx, orderIDs)
+	if err != nil {
+		slog.Error("failed to fetch orders for batch labels", "error", err, "count", len(orderIDs))
+		return c.String(http.StatusInternalServerError, "Failed to fetch orders")
+	}
+	if len(orders) == 0 {
+		return c.String(http.StatusNotFound, "Orders not found")
+	}
+
+	locations, err := h.storage.Queries.ListActiveInventoryLocations(ctx)
+	if err != nil {
+		slog.Error("failed to list inventory locations for batch labels", "error", err)
+		return c.String(http.StatusInternalServerError, "Failed to load locations")
+	}
+	var location *db.InventoryLocation
+	if len(locations) > 0 {
+		location = &locations[0]
+	}
+
+	results := make([]admin.LabelBatchResult, len(orders))
+	quotes := make([]labelQuote, len(orders))
+	lines := make([][]shipmentLine, len(orders))
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, batchRateWorkers)
+	for i, order := range orders {
+		results[i] = admin.LabelBatchResult{OrderID: order.ID, CustomerName: order.CustomerName}
+		if problem := labelBatchProblem(order); problem != "" {
+			results[i].Error = problem
+			continue
+		}
+		items, err := h.storage.Queries.ListOrderItemsToShip(ctx, order.ID)
+		if err != nil {
+			slog.Error("failed to fetch order items for batch label", "error", err, "order_id", order.ID)
+			results[i].Error = "Failed to load order items"
+			continue
+		}
+		// Each label in a batch sends everything left of its order
+		var problem string
+		if lines[i], problem = planShipment(items, nil); problem != "" {
+			results[i].Error = problem
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			quotes[i] = h.quoteLabel(order, location)
+		}()
+	}
+	wg.Wait()
+
+	locationID := ""
+	if location != nil {
+		locationID = location.ID
+	}
+	for i, order := range orders {
+		if results[i].Error != "" {
+			continue
+		}
+		quote := quotes[i]
+		if quote.problem != "" {
+			results[i].Error = quote.problem
+			continue
+		}
+		results[i].Service = quote.rate.CarrierCode + " " + quote.rate.ServiceCode
+		results[i].CostCents = int64(math.Round(quote.rate.ShippingAmount.Amount * 100))
+
+		label, err := h.shippingService.CreateLabelFromShipment(quote.shipmentID, quote.rate.RateID)
+		if err != nil {
+			slog.Error("failed to buy batch label from EasyPost", "error", err,
+				"order_id", order.ID,
+				"shipment_id", quote.shipmentID,
+				"rate_id", quote.rate.RateID)
+			results[i].Error = "Failed to purchase shipping label"
+			continue
+		}
+		if _, _, err := h.recordLabelPurchase(ctx, order, quote.shipmentID, locationID, adminActor(c), label, lines[i]); err != nil {
+			slog.Error("failed to update order with batch label info", "error", err, "order_id", order.ID)
+			results[i].Error = "Label purchased but failed to update order"
+			continue
+		}
+		results[i].TrackingNumber = label.TrackingNumber
+	}
+
+	slog.Info("batch labels bought", "orders", len(results), "bought", admin.LabelsBought(results))
+	return Render(c, admin.LabelBatchResults(c, results))
+}
+
+// HandleBatchLabelsPDF prints the bought labels of the ticked orders as one PDF of 4x6
+// pages. Orders without a label image are left out.
+func (h *AdminHandler) HandleBatchLabelsPDF(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	orderIDs, problem := selectedOrderIDs(c, "print labels for")
+	if problem != "" {
+		return c.String(http.StatusBadRequest, problem)
+	}
+
+	orders, err := h.storage.Queries.ListOrdersByIDs(ctx, orderIDs)
+	if err != nil {
+		slog.Error("failed to fetch orders for label printing", "error", err, "count", len(orderIDs))
+		return c.String(http.StatusInternalServerError, "Failed to fetch orders")
+	}
+
+	var labels []*pdf.LabelImage
+	for _, order := range orders {
+		if order.LabelImageUrl == "" {
+			continue
+		}
+		label, err := fetchLabelImage(ctx, order.LabelImageUrl)
+		if err != nil {
+			slog.Warn("failed to fetch label image for printing", "error", err, "order_id", order.ID)
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if len(labels) == 0 {
+		return c.String(http.StatusNotFound, "None of the selected orders have a label to print")
+	}
+
+	var buf bytes.Buffer
+	business := pdf.BusinessFromSettings(settings.For(h.storage.Queries).Values(ctx))
+	if err := pdf.ShippingLabels(&buf, business, labels); err != nil {
+		slog.Error("failed to render shipping labels", "error", err, "count", len(labels))
+		return c.String(http.StatusInternalServerError, "Failed to create labels")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=labels-%s.pdf", time.Now().Format("2006-01-02")))
+	return c.Blob(http.StatusOK, "application/pdf", buf.Bytes())
+}
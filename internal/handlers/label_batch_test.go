@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestLabelBatchProblem(t *testing.T) {
+	ready := db.Order{
+		ID:                 "order-1",
+		Status:             sql.NullString{String: "received", Valid: true},
+		EasypostShipmentID: sql.NullString{String: "shp_123", Valid: true},
+	}
+	assert.Empty(t, labelBatchProblem(ready))
+
+	printing := ready
+	printing.Status = sql.NullString{String: "in_production", Valid: true}
+	assert.Empty(t, labelBatchProblem(printing))
+
+	bought := ready
+	bought.EasypostLabelUrl = sql.NullString{String: "https://easypost.test/label.pdf", Valid: true}
+	assert.Equal(t, "Label already bought", labelBatchProblem(bought))
+
+	cancelled := ready
+	cancelled.Status = sql.NullString{String: "cancelled", Valid: true}
+	assert.Equal(t, "Order is cancelled", labelBatchProblem(cancelled))
+
+	noShipment := ready
+	noShipment.EasypostShipmentID = sql.NullString{}
+	assert.Equal(t, "No EasyPost shipment linked to this order", labelBatchProblem(noShipment))
+}
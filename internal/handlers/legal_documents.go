@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/policies"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func legalDocumentsURL(errorMsg string) string {
+	if errorMsg == "" {
+		return "/admin/legal"
+	}
+	return "/admin/legal?error=" + url.QueryEscape(errorMsg)
+}
+
+// HandleLegalDocuments lists every version of the terms and policies, with how many
+// orders agreed to each
+func (h *AdminHandler) HandleLegalDocuments(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	docs, err := h.storage.Queries.ListLegalDocuments(ctx)
+	if err != nil {
+		slog.Error("failed to list legal documents", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load legal documents")
+	}
+
+	current, err := policies.NewLibrary(h.storage.Queries).Current(ctx, time.Now())
+	if err != nil {
+		slog.Error("failed to list current legal documents", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load legal documents")
+	}
+	currentIDs := make(map[string]bool, len(current))
+	for _, doc := range current {
+		currentIDs[doc.ID] = true
+	}
+
+	return Render(c, admin.LegalDocuments(c, docs, currentIDs, time.Now(), c.QueryParam("error")))
+}
+
+// HandleNewLegalDocument shows the form for the next version of a document, starting
+// from the text of the latest one
+func (h *AdminHandler) HandleNewLegalDocument(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kind := c.QueryParam("kind")
+	if !slices.Contains(policies.Kinds, kind) {
+		return c.Redirect(http.StatusSeeOther, legalDocumentsURL("Choose which document to update"))
+	}
+
+	latest, err := h.storage.Queries.GetLatestLegalDocument(ctx, kind)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get latest legal document", "error", err, "kind", kind)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load legal document")
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		latest = db.LegalDocument{Kind: kind, Title: policies.KindLabel(kind)}
+	}
+
+	return Render(c, admin.LegalDocumentForm(c, latest, c.QueryParam("error")))
+}
+
+// HandleCreateLegalDocument publishes a new version, straight away or scheduled
+func (h *AdminHandler) HandleCreateLegalDocument(c echo.Context) error {
+	ctx := c.Request().Context()
+	kind := c.FormValue("kind")
+
+	var effectiveAt time.Time
+	if raw := strings.TrimSpace(c.FormValue("effective_at")); raw != "" {
+		t, err := time.Parse("2006-01-02T15:04", raw)
+		if err != nil {
+			return c.Redirect(http.StatusSeeOther, "/admin/legal/new?kind="+url.QueryEscape(kind)+"&error="+url.QueryEscape("Enter the date and time as shown in the date picker"))
+		}
+		effectiveAt = t
+	}
+
+	doc, err := policies.NewLibrary(h.storage.Queries).Publish(ctx, policies.PublishParams{
+		Kind:          kind,
+		Title:         c.FormValue("title"),
+		BodyMarkdown:  c.FormValue("body_markdown"),
+		ChangeSummary: c.FormValue("change_summary"),
+		EffectiveAt:   effectiveAt,
+	}, time.Now())
+	var policyErr *policies.Error
+	if errors.As(err, &policyErr) {
+		return c.Redirect(http.StatusSeeOther, "/admin/legal/new?kind="+url.QueryEscape(kind)+"&error="+url.QueryEscape(policyErr.Message))
+	}
+	if err != nil {
+		slog.Error("failed to publish legal document", "error", err, "kind", kind)
+		return c.Redirect(http.StatusSeeOther, legalDocumentsURL("Could not publish the new version"))
+	}
+
+	slog.Info("legal document published", "document_id", doc.ID, "effective_at", doc.EffectiveAt)
+	return c.Redirect(http.StatusSeeOther, "/admin/legal/"+doc.ID)
+}
+
+// HandleLegalDocument shows one version's text as customers saw it
+func (h *AdminHandler) HandleLegalDocument(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	doc, err := h.storage.Queries.GetLegalDocument(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Legal document not found")
+		}
+		slog.Error("failed to get legal document", "error", err, "document_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load legal document")
+	}
+
+	html, err := utils.RenderMarkdown(doc.BodyMarkdown)
+	if err != nil {
+		slog.Error("failed to render legal document", "error", err, "document_id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load legal document")
+	}
+
+	return Render(c, admin.LegalDocumentDetail(c, doc, html, time.Now()))
+}
+
+// HandleDeleteLegalDocument removes a version that hasn't taken effect yet
+func (h *AdminHandler) HandleDeleteLegalDocument(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	deleted, err := h.storage.Queries.DeleteScheduledLegalDocument(ctx, db.DeleteScheduledLegalDocumentParams{
+		ID:  id,
+		Now: time.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("failed to delete legal document", "error", err, "document_id", id)
+		return c.Redirect(http.StatusSeeOther, legalDocumentsURL("Could not delete the version"))
+	}
+	if deleted == 0 {
+		return c.Redirect(http.StatusSeeOther, legalDocumentsURL("Only a version that hasn't taken effect can be deleted"))
+	}
+
+	slog.Info("scheduled legal document deleted", "document_id", id)
+	return c.Redirect(http.StatusSeeOther, "/admin/legal")
+}
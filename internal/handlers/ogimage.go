@@ -3,6 +3,7 @@ package handlers
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"log/slog"
@@ -13,7 +14,10 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/badges"
+	"github.com/loganlanou/logans3d-v4/internal/flags"
 	"github.com/loganlanou/logans3d-v4/internal/ogimage"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
@@ -108,14 +112,16 @@ func (h *OGImageHandler) generateProductOGImage(c echo.Context, product db.Produ
 		}
 	}
 
+	badge := h.ogBadge(ctx, product)
+
 	// Build paths
 	productImagePath := filepath.Join("public", "images", "products", primaryImageFile)
-	ogImageFilename := fmt.Sprintf("product-%s.png", product.ID)
+	ogImageFilename := fmt.Sprintf("product-%s%s.png", product.ID, ogBadgeSuffix(badge))
 	ogImagePath := filepath.Join("public", "og-images", ogImageFilename)
 
 	// Check if OG image exists and is recent (skip if forceRefresh)
 	if !forceRefresh {
-		if info, err := os.Stat(ogImagePath); err == nil {
+		if info, err := os.Stat(ogImagePath); err == nil && !badgeChangedSince(badge, info.ModTime()) {
 			productUpdated := product.UpdatedAt
 			if productUpdated.Valid {
 				ogImageCreated := info.ModTime()
@@ -136,6 +142,10 @@ func (h *OGImageHandler) generateProductOGImage(c echo.Context, product db.Produ
 		CategoryName: categoryName,
 		ImagePath:    productImagePath,
 	}
+	if badge != nil {
+		productInfo.BadgeLabel = badge.Label
+		productInfo.BadgeColor = badges.ColorHex(badge.Color)
+	}
 
 	err = ogimage.GenerateOGImage(productInfo, ogImagePath)
 	if err != nil {
@@ -209,14 +219,16 @@ func (h *OGImageHandler) generateVariantOGImage(c echo.Context, product db.Produ
 	}
 	finalPriceCents := product.PriceCents + priceAdjustment
 
+	badge := h.ogBadge(ctx, product)
+
 	// Build paths - variant-specific cache filename
 	productImagePath := filepath.Join("public", "images", "products", imageFile)
-	ogImageFilename := fmt.Sprintf("product-%s-%s-%s.png", product.ID, styleID, sizeID)
+	ogImageFilename := fmt.Sprintf("product-%s-%s-%s%s.png", product.ID, styleID, sizeID, ogBadgeSuffix(badge))
 	ogImagePath := filepath.Join("public", "og-images", ogImageFilename)
 
 	// Check if variant OG image exists and is recent (skip if forceRefresh)
 	if !forceRefresh {
-		if info, err := os.Stat(ogImagePath); err == nil {
+		if info, err := os.Stat(ogImagePath); err == nil && !badgeChangedSince(badge, info.ModTime()) {
 			if time.Since(info.ModTime()) < 7*24*time.Hour {
 				return h.serveOGImage(c, ogImagePath)
 			}
@@ -228,6 +240,10 @@ func (h *OGImageHandler) generateVariantOGImage(c echo.Context, product db.Produ
 		Name:      product.Name,
 		ImagePath: productImagePath,
 	}
+	if badge != nil {
+		productInfo.BadgeLabel = badge.Label
+		productInfo.BadgeColor = badges.ColorHex(badge.Color)
+	}
 
 	variantInfo := ogimage.VariantInfo{
 		StyleName:  style.Name,
@@ -244,6 +260,72 @@ func (h *OGImageHandler) generateVariantOGImage(c echo.Context, product db.Produ
 	return h.serveOGImage(c, ogImagePath)
 }
 
+// ogBadge returns the product's highest priority badge for its OG image, or nil
+func (h *OGImageHandler) ogBadge(ctx context.Context, product db.Product) *badges.Badge {
+	productBadges, err := badges.Load(ctx, h.storage.Queries, []db.Product{product})
+	if err != nil {
+		slog.Debug("failed to load badges for OG image", "error", err, "product_id", product.ID)
+		return nil
+	}
+	if list := productBadges[product.ID]; len(list) > 0 {
+		return &list[0]
+	}
+	return nil
+}
+
+// ogBadgeSuffix keeps images with and without a badge in separate files, so a badge
+// starting or ending switches image rather than serving a stale one
+func ogBadgeSuffix(badge *badges.Badge) string {
+	if badge == nil {
+		return ""
+	}
+	return "-badge-" + badge.ID
+}
+
+// badgeChangedSince reports whether the badge was edited after an image was drawn
+func badgeChangedSince(badge *badges.Badge, drawn time.Time) bool {
+	return badge != nil && badge.UpdatedAt.After(drawn)
+}
+
+// HandleGenerateBlogOGImage generates the title card for a published blog post
+// Route: GET /api/og-image/blog/:post_id
+// Add ?refresh=true to force regeneration
+func (h *OGImageHandler) HandleGenerateBlogOGImage(c echo.Context) error {
+	ctx := c.Request().Context()
+	postID := c.Param("post_id")
+
+	post, err := h.storage.Queries.GetPublishedBlogPost(ctx, db.GetPublishedBlogPostParams{
+		ID:  postID,
+		Now: sql.NullTime{Time: utils.WallClockNow(), Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return echo.NewHTTPError(http.StatusNotFound, "Post not found")
+		}
+		slog.Error("failed to get blog post", "error", err, "post_id", postID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load post")
+	}
+
+	ogImagePath := filepath.Join("public", "og-images", fmt.Sprintf("blog-%s.png", post.ID))
+	if c.QueryParam("refresh") != "true" {
+		if info, err := os.Stat(ogImagePath); err == nil && post.UpdatedAt.Valid && post.UpdatedAt.Time.Before(info.ModTime()) {
+			return h.serveOGImage(c, ogImagePath)
+		}
+	}
+
+	err = ogimage.GenerateArticleOGImage(ogimage.ArticleInfo{
+		Title:    post.Title,
+		Byline:   utils.BlogByline(post.AuthorName, post.PublishedAt.Time),
+		SiteName: utils.BlogName,
+	}, ogImagePath)
+	if err != nil {
+		slog.Error("failed to generate blog OG image", "error", err, "post_id", post.ID)
+		return h.serveDefaultOGImage(c)
+	}
+
+	return h.serveOGImage(c, ogImagePath)
+}
+
 // serveOGImage serves an OG image with short cache headers
 func (h *OGImageHandler) serveOGImage(c echo.Context, path string) error {
 	// 5 minute cache - fresh enough for regenerated images, but reduces redundant fetches
@@ -371,9 +453,9 @@ func (h *OGImageHandler) HandleGenerateMultiVariantOGImage(c echo.Context) error
 		StyleNames: styleNames,
 	}
 
-	// Use AI generator if available, otherwise fall back to grid method
+	// Use AI generator if available and switched on, otherwise fall back to grid method
 	var modelUsed string
-	if h.useAI && h.aiGenerator != nil {
+	if h.useAI && h.aiGenerator != nil && flags.Enabled(c.Request().Context(), flags.AIOGImages) {
 		modelUsed, err = h.aiGenerator.GenerateMultiVariantOGImageWithModel(info, ogImagePath)
 	} else {
 		err = ogimage.GenerateMultiVariantOGImage(info, ogImagePath)
@@ -392,6 +474,8 @@ func (h *OGImageHandler) HandleGenerateMultiVariantOGImage(c echo.Context) error
 		return h.serveDefaultOGImage(c)
 	}
 
+	utils.RecordMultiVariantOGImage(product.ID)
+
 	// Add model info to response header
 	c.Response().Header().Set("X-OG-Model", modelUsed)
 	return h.serveOGImage(c, ogImagePath)
@@ -0,0 +1,702 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	stripego "github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// removableQuantities is how many units of each order line can still be taken off the
+// order: physical units that haven't shipped and are still refundable
+func removableQuantities(toShip []db.ListOrderItemsToShipRow, refundable map[string]int64) map[string]int64 {
+	removable := make(map[string]int64, len(toShip))
+	for _, item := range toShip {
+		line := utils.ShippingLine{Quantity: item.Quantity, Shipped: item.ShippedQuantity, Refunded: item.RefundedQuantity}
+		removable[item.ID] = min(line.Unshipped(), refundable[item.ID])
+	}
+	return removable
+}
+
+// withoutAddedLines stops lines added after the order was paid for being refunded from
+// its payment: they were paid through their own link
+func withoutAddedLines(refundable map[string]int64, added []sql.NullString) map[string]int64 {
+	for _, id := range added {
+		if _, ok := refundable[id.String]; ok {
+			refundable[id.String] = 0
+		}
+	}
+	return refundable
+}
+
+// planRemoval works out the lines taken off an order and what they're refunded at: the
+// chosen units at what they came to, capped at what's left of the payment. A non-empty
+// message says why nothing can be removed.
+func planRemoval(order db.Order, items []db.GetOrderItemsRow, removable map[string]int64, remainingCents int64, quantities map[string]int64) ([]orderRefundLine, int64, string) {
+	if remainingCents <= 0 {
+		return nil, 0, "Nothing is left of the order's payment to refund the items from"
+	}
+
+	var lines []orderRefundLine
+	var cents int64
+	for _, item := range items {
+		quantity := quantities[item.ID]
+		if quantity <= 0 {
+			continue
+		}
+		if quantity > removable[item.ID] {
+			return nil, 0, fmt.Sprintf("Only %d of %s can be taken off the order", removable[item.ID], item.ProductName)
+		}
+		line := orderRefundLine{
+			Item:        item,
+			Quantity:    quantity,
+			AmountCents: orderLineRefundCents(order, item.UnitPriceCents, quantity),
+		}
+		lines = append(lines, line)
+		cents += line.AmountCents
+	}
+	if len(lines) == 0 {
+		return nil, 0, "Choose how many of each item to take off the order"
+	}
+	if cents == 0 {
+		return nil, 0, "The chosen items came to nothing, so there's nothing to refund"
+	}
+	return lines, min(cents, remainingCents), ""
+}
+
+// orderLineChargeCents is what some units added to an order come to: their price plus
+// tax at the rate the order paid. Discounts on the order don't carry over to them.
+func orderLineChargeCents(order db.Order, unitPriceCents, quantity int64) int64 {
+	cents := float64(unitPriceCents * quantity)
+	if order.TaxCents > 0 && order.SubtotalCents > 0 {
+		cents += cents * float64(order.TaxCents) / float64(order.SubtotalCents)
+	}
+	return int64(math.Round(cents))
+}
+
+// describeUnits lists some units of order lines for an adjustment's description
+func describeUnits(verb string, names []string, quantities []int64) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d × %s", quantities[i], name)
+	}
+	return verb + " " + strings.Join(parts, ", ")
+}
+
+// parseDollars reads an amount in dollars from a form, like "12.50" or "$12.50"
+func parseDollars(raw string) (int64, bool) {
+	amount, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(raw), "$"), 64)
+	if err != nil || amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, false
+	}
+	return int64(math.Round(amount * 100)), true
+}
+
+// loadEditableOrder fetches an order being edited and checks it can still change. The
+// form carries the adjustment's ID, so a resubmitted form is recognised. A non-empty
+// message says why the order can't be edited.
+func (h *AdminHandler) loadEditableOrder(ctx context.Context, orderID, adjustmentID string) (db.Order, string, error) {
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		return order, "", err
+	}
+	if !utils.OrderEditable(order.Status.String) {
+		return order, fmt.Sprintf("A %s order can't be changed", strings.ToLower(utils.OrderStatusLabel(order.Status.String))), nil
+	}
+	if _, err := uuid.Parse(adjustmentID); err != nil {
+		return order, "Reload the page and try again", nil
+	}
+	exists, err := h.storage.Queries.OrderAdjustmentExists(ctx, adjustmentID)
+	if err != nil {
+		return order, "", fmt.Errorf("check for an earlier adjustment: %w", err)
+	}
+	if exists {
+		return order, "That change was already made", nil
+	}
+	return order, "", nil
+}
+
+// HandleRemoveOrderItems takes units that haven't shipped off a paid order, refunds
+// what they came to to the card and puts them back into stock
+func (h *AdminHandler) HandleRemoveOrderItems(c echo.Context) error {
+	ctx := c.Request().Context()
+	adjustmentID := c.FormValue("adjustment_id")
+
+	order, problem, err := h.loadEditableOrder(ctx, c.Param("id"), adjustmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to load order to edit", "error", err, "order_id", c.Param("id"))
+		return c.Redirect(http.StatusSeeOther, orderURL(c.Param("id"), "", "Could not change the order"))
+	}
+	if problem != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", problem))
+	}
+	if order.StripePaymentIntentID.String == "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "The order has no Stripe payment to refund the items from"))
+	}
+
+	items, err := h.storage.Queries.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch order items to edit", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not change the order"))
+	}
+	removable, remaining, err := h.orderRemovable(ctx, order, items)
+	if err != nil {
+		slog.Error("failed to work out what can be taken off the order", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not change the order"))
+	}
+
+	quantities := make(map[string]int64, len(items))
+	for _, item := range items {
+		raw := strings.TrimSpace(c.FormValue("remove_" + item.ID))
+		if raw == "" {
+			continue
+		}
+		quantity, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || quantity < 0 {
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Enter how many of "+item.ProductName+" to take off"))
+		}
+		quantities[item.ID] = quantity
+	}
+	lines, cents, errMsg := planRemoval(order, items, removable, remaining, quantities)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", errMsg))
+	}
+
+	names := make([]string, len(lines))
+	counts := make([]int64, len(lines))
+	for i, line := range lines {
+		names[i], counts[i] = line.Item.ProductName, line.Quantity
+	}
+	description := describeUnits("Removed", names, counts)
+	note := strings.TrimSpace(c.FormValue("note"))
+
+	// The refund goes through Stripe first; if it's refused, the order is left as it was
+	refundID := uuid.New().String()
+	chargedCents := stripe.ChargedAmount(cents, order.PaymentCurrency, order.PaymentFxRate)
+	stripeRefund, err := stripe.RefundOrder(order.StripePaymentIntentID.String, chargedCents, refundID, order.ID)
+	if err != nil {
+		slog.Error("failed to refund removed items in Stripe", "error", err, "order_id", order.ID, "adjustment_id", adjustmentID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Stripe refused the refund, so nothing was taken off"))
+	}
+
+	if err := h.recordRemoval(ctx, order, adjustmentID, refundID, stripeRefund.ID, cents, lines, description, note, adminActor(c)); err != nil {
+		slog.Error("failed to record removed order items", "error", err, "order_id", order.ID, "adjustment_id", adjustmentID, "stripe_refund_id", stripeRefund.ID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Refunded in Stripe but failed to save the change on the order"))
+	}
+
+	slog.Info("order items removed", "order_id", order.ID, "adjustment_id", adjustmentID, "refund_id", refundID, "amount_cents", cents, "by", adminActor(c))
+
+	if c.FormValue("notify") != "" {
+		go h.notifyOrderRefunded(order, refundID, cents, lines, false)
+	}
+	return c.Redirect(http.StatusSeeOther, orderURL(order.ID, fmt.Sprintf("%s and refunded $%.2f", description, float64(cents)/100), ""))
+}
+
+// orderRemovable is how many units of each line can be taken off the order, and
+// what's left of its payment to refund them from
+func (h *AdminHandler) orderRemovable(ctx context.Context, order db.Order, items []db.GetOrderItemsRow) (map[string]int64, int64, error) {
+	refunded, err := h.storage.Queries.GetOrderRefundedQuantities(ctx, order.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("load refunded quantities: %w", err)
+	}
+	returned, err := h.storage.Queries.GetOrderReturnedQuantities(ctx, order.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("load returned quantities: %w", err)
+	}
+	toShip, err := h.storage.Queries.ListOrderItemsToShip(ctx, order.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("load items to ship: %w", err)
+	}
+	added, err := h.storage.Queries.ListOrderAddedItemIDs(ctx, order.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("load added items: %w", err)
+	}
+	refundedCents, err := h.storage.Queries.GetOrderRefundedCents(ctx, order.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("sum order refunds: %w", err)
+	}
+	removable := removableQuantities(toShip, withoutAddedLines(refundableQuantities(items, refunded, returned), added))
+	return removable, order.TotalCents - refundedCents, nil
+}
+
+// recordRemoval saves units taken off an order: the refund Stripe made for them, which
+// stops them being shipped or refunded again, the units back on the sellable count,
+// and the adjustment, all together
+func (h *AdminHandler) recordRemoval(ctx context.Context, order db.Order, adjustmentID, refundID, stripeRefundID string, cents int64, lines []orderRefundLine, description, note, createdBy string) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	if _, err := queries.CreateOrderRefund(ctx, db.CreateOrderRefundParams{
+		ID:             refundID,
+		OrderID:        order.ID,
+		AmountCents:    cents,
+		Reason:         "Order changed: " + description,
+		StripeRefundID: stripeRefundID,
+		Restocked:      true,
+		CreatedBy:      createdBy,
+	}); err != nil {
+		return fmt.Errorf("create refund: %w", err)
+	}
+	if err := queries.CreateOrderAdjustment(ctx, db.CreateOrderAdjustmentParams{
+		ID:            adjustmentID,
+		OrderID:       order.ID,
+		Kind:          utils.AdjustmentRemoveItems,
+		Description:   description,
+		AmountCents:   -cents,
+		OrderRefundID: sql.NullString{String: refundID, Valid: true},
+		Note:          note,
+		CreatedBy:     createdBy,
+	}); err != nil {
+		return fmt.Errorf("create adjustment: %w", err)
+	}
+
+	for _, line := range lines {
+		if err := queries.CreateOrderRefundItem(ctx, db.CreateOrderRefundItemParams{
+			ID:          uuid.New().String(),
+			RefundID:    refundID,
+			OrderItemID: line.Item.ID,
+			Quantity:    line.Quantity,
+			AmountCents: line.AmountCents,
+		}); err != nil {
+			return fmt.Errorf("create refund item: %w", err)
+		}
+		if err := queries.CreateOrderAdjustmentItem(ctx, db.CreateOrderAdjustmentItemParams{
+			ID:             uuid.New().String(),
+			AdjustmentID:   adjustmentID,
+			OrderItemID:    sql.NullString{String: line.Item.ID, Valid: true},
+			ProductID:      line.Item.ProductID,
+			ProductSkuID:   line.Item.ProductSkuID.String,
+			ProductName:    line.Item.ProductName,
+			Quantity:       line.Quantity,
+			UnitPriceCents: line.Item.UnitPriceCents,
+		}); err != nil {
+			return fmt.Errorf("create adjustment item: %w", err)
+		}
+		if err := addAvailableStock(ctx, queries, line.Item.ProductID, line.Item.ProductSkuID.String, line.Quantity); err != nil {
+			return fmt.Errorf("restock %s: %w", line.Item.ProductID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// HandleAddOrderItems puts more units on a paid order and sends the customer a Stripe
+// payment link for them. They join the order once the link is paid.
+func (h *AdminHandler) HandleAddOrderItems(c echo.Context) error {
+	ctx := c.Request().Context()
+	adjustmentID := c.FormValue("adjustment_id")
+
+	order, problem, err := h.loadEditableOrder(ctx, c.Param("id"), adjustmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to load order to edit", "error", err, "order_id", c.Param("id"))
+		return c.Redirect(http.StatusSeeOther, orderURL(c.Param("id"), "", "Could not change the order"))
+	}
+	if problem != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", problem))
+	}
+
+	products, err := h.storage.Queries.ListOrderEditProducts(ctx)
+	if err != nil {
+		slog.Error("failed to list products to add to an order", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not change the order"))
+	}
+	var product *db.ListOrderEditProductsRow
+	for i := range products {
+		if products[i].ProductID+":"+products[i].ProductSkuID == c.FormValue("product") {
+			product = &products[i]
+			break
+		}
+	}
+	if product == nil {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Choose a product to add"))
+	}
+	quantity, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("quantity")), 10, 64)
+	if err != nil || quantity <= 0 {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Enter how many to add"))
+	}
+	unitPrice := product.PriceCents
+	if raw := strings.TrimSpace(c.FormValue("unit_price")); raw != "" {
+		var ok bool
+		if unitPrice, ok = parseDollars(raw); !ok {
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Enter a price for each, or leave it blank"))
+		}
+	}
+
+	cents := orderLineChargeCents(order, unitPrice, quantity)
+	if cents <= 0 {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Added items need a price to send a payment link for"))
+	}
+	description := describeUnits("Added", []string{product.Name}, []int64{quantity})
+	item := db.CreateOrderAdjustmentItemParams{
+		ID:             uuid.New().String(),
+		AdjustmentID:   adjustmentID,
+		ProductID:      product.ProductID,
+		ProductSkuID:   product.ProductSkuID,
+		ProductName:    product.Name,
+		Quantity:       quantity,
+		UnitPriceCents: unitPrice,
+	}
+	return h.requestOrderPayment(c, order, adjustmentID, utils.AdjustmentAddItems, description, cents, []db.CreateOrderAdjustmentItemParams{item})
+}
+
+// HandleChangeOrderShipping sets what a paid order's shipping comes to. Shipping that
+// went down is refunded to the card; shipping that went up gets a payment link.
+func (h *AdminHandler) HandleChangeOrderShipping(c echo.Context) error {
+	ctx := c.Request().Context()
+	adjustmentID := c.FormValue("adjustment_id")
+
+	order, problem, err := h.loadEditableOrder(ctx, c.Param("id"), adjustmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to load order to edit", "error", err, "order_id", c.Param("id"))
+		return c.Redirect(http.StatusSeeOther, orderURL(c.Param("id"), "", "Could not change the order"))
+	}
+	if problem != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", problem))
+	}
+
+	shipping, ok := parseDollars(c.FormValue("shipping"))
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Enter what shipping should come to"))
+	}
+	adjusted, err := h.storage.Queries.GetOrderShippingAdjustedCents(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to sum shipping changes", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not change the order"))
+	}
+	current := order.ShippingCents + adjusted
+	diff := shipping - current
+	description := fmt.Sprintf("Shipping %s to %s", email.FormatCents(current), email.FormatCents(shipping))
+
+	switch {
+	case diff == 0:
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Shipping already comes to "+email.FormatCents(shipping)))
+	case diff > 0:
+		return h.requestOrderPayment(c, order, adjustmentID, utils.AdjustmentShipping, description, diff, nil)
+	}
+
+	if order.StripePaymentIntentID.String == "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "The order has no Stripe payment to refund the shipping from"))
+	}
+	refundedCents, err := h.storage.Queries.GetOrderRefundedCents(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to sum order refunds", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not change the order"))
+	}
+	cents := -diff
+	if remaining := order.TotalCents - refundedCents; cents > remaining {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", fmt.Sprintf("Only $%.2f of the order is left to refund", float64(max(remaining, 0))/100)))
+	}
+
+	refundID := uuid.New().String()
+	chargedCents := stripe.ChargedAmount(cents, order.PaymentCurrency, order.PaymentFxRate)
+	stripeRefund, err := stripe.RefundOrder(order.StripePaymentIntentID.String, chargedCents, refundID, order.ID)
+	if err != nil {
+		slog.Error("failed to refund shipping in Stripe", "error", err, "order_id", order.ID, "adjustment_id", adjustmentID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Stripe refused the refund, so shipping wasn't changed"))
+	}
+	note := strings.TrimSpace(c.FormValue("note"))
+	if err := h.recordShippingRefund(ctx, order, adjustmentID, refundID, stripeRefund.ID, cents, description, note, adminActor(c)); err != nil {
+		slog.Error("failed to record shipping refund", "error", err, "order_id", order.ID, "adjustment_id", adjustmentID, "stripe_refund_id", stripeRefund.ID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Refunded in Stripe but failed to save the change on the order"))
+	}
+
+	slog.Info("order shipping lowered", "order_id", order.ID, "adjustment_id", adjustmentID, "refund_id", refundID, "amount_cents", cents, "by", adminActor(c))
+
+	if c.FormValue("notify") != "" {
+		go h.notifyOrderRefunded(order, refundID, cents, nil, false)
+	}
+	return c.Redirect(http.StatusSeeOther, orderURL(order.ID, fmt.Sprintf("%s; refunded $%.2f", description, float64(cents)/100), ""))
+}
+
+// recordShippingRefund saves the refund Stripe made for lower shipping and its
+// adjustment together
+func (h *AdminHandler) recordShippingRefund(ctx context.Context, order db.Order, adjustmentID, refundID, stripeRefundID string, cents int64, description, note, createdBy string) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	if _, err := queries.CreateOrderRefund(ctx, db.CreateOrderRefundParams{
+		ID:             refundID,
+		OrderID:        order.ID,
+		AmountCents:    cents,
+		Reason:         "Order changed: " + description,
+		StripeRefundID: stripeRefundID,
+		CreatedBy:      createdBy,
+	}); err != nil {
+		return fmt.Errorf("create refund: %w", err)
+	}
+	if err := queries.CreateOrderAdjustment(ctx, db.CreateOrderAdjustmentParams{
+		ID:            adjustmentID,
+		OrderID:       order.ID,
+		Kind:          utils.AdjustmentShipping,
+		Description:   description,
+		AmountCents:   -cents,
+		OrderRefundID: sql.NullString{String: refundID, Valid: true},
+		Note:          note,
+		CreatedBy:     createdBy,
+	}); err != nil {
+		return fmt.Errorf("create adjustment: %w", err)
+	}
+	return tx.Commit()
+}
+
+// requestOrderPayment makes a Stripe payment link for a change that adds to what an
+// order comes to, saves the change as waiting on it and, when asked, emails the
+// customer the link
+func (h *AdminHandler) requestOrderPayment(c echo.Context, order db.Order, adjustmentID, kind, description string, cents int64, items []db.CreateOrderAdjustmentItemParams) error {
+	ctx := c.Request().Context()
+
+	// Customers with an account come back to their order; guests to Stripe's own page
+	var redirectURL string
+	if !order.GuestSessionID.Valid {
+		siteURL := os.Getenv("SITE_URL")
+		if siteURL == "" {
+			siteURL = "https://www.logans3dcreations.com"
+		}
+		redirectURL = strings.TrimRight(siteURL, "/") + "/account/orders/" + order.ID
+	}
+
+	link, err := stripe.CreateOrderPaymentLink(adjustmentID, order.ID, fmt.Sprintf("Order #%s: %s", order.ID[:8], description), cents, redirectURL)
+	if err != nil {
+		slog.Error("failed to create payment link for order change", "error", err, "order_id", order.ID, "adjustment_id", adjustmentID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Stripe couldn't make a payment link, so the order wasn't changed"))
+	}
+
+	note := strings.TrimSpace(c.FormValue("note"))
+	if err := h.savePaymentRequest(ctx, order, adjustmentID, kind, description, cents, link, items, note, adminActor(c)); err != nil {
+		slog.Error("failed to save order change", "error", err, "order_id", order.ID, "adjustment_id", adjustmentID, "payment_link_id", link.ID)
+		if err := stripe.DeactivatePaymentLink(link.ID); err != nil {
+			slog.Error("failed to deactivate payment link for unsaved order change", "error", err, "payment_link_id", link.ID)
+		}
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not change the order"))
+	}
+
+	slog.Info("payment requested for order change", "order_id", order.ID, "adjustment_id", adjustmentID, "kind", kind, "amount_cents", cents, "payment_link_id", link.ID, "by", adminActor(c))
+
+	flash := fmt.Sprintf("%s; the customer owes $%.2f", description, float64(cents)/100)
+	if c.FormValue("notify") != "" {
+		go h.notifyPaymentRequested(order, description, cents, link.URL, items, note)
+		flash += " and has been emailed a payment link"
+	}
+	return c.Redirect(http.StatusSeeOther, orderURL(order.ID, flash, ""))
+}
+
+// savePaymentRequest saves a change waiting on its payment link, with the items it
+// adds
+func (h *AdminHandler) savePaymentRequest(ctx context.Context, order db.Order, adjustmentID, kind, description string, cents int64, link *stripego.PaymentLink, items []db.CreateOrderAdjustmentItemParams, note, createdBy string) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	if err := queries.CreateOrderAdjustment(ctx, db.CreateOrderAdjustmentParams{
+		ID:                  adjustmentID,
+		OrderID:             order.ID,
+		Kind:                kind,
+		Description:         description,
+		AmountCents:         cents,
+		StripePaymentLinkID: link.ID,
+		PaymentUrl:          link.URL,
+		Note:                note,
+		CreatedBy:           createdBy,
+	}); err != nil {
+		return fmt.Errorf("create adjustment: %w", err)
+	}
+	for _, item := range items {
+		if err := queries.CreateOrderAdjustmentItem(ctx, item); err != nil {
+			return fmt.Errorf("create adjustment item: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// notifyPaymentRequested emails the customer the payment link for a change to their order
+func (h *AdminHandler) notifyPaymentRequested(order db.Order, description string, cents int64, paymentURL string, items []db.CreateOrderAdjustmentItemParams, note string) {
+	data := &email.OrderPaymentRequestData{
+		OrderID:       order.ID,
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+		Description:   description,
+		AmountCents:   cents,
+		PaymentURL:    paymentURL,
+		Note:          note,
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, email.OrderPaymentRequestItem{
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		})
+	}
+	if err := h.emailService.SendOrderPaymentRequest(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send order payment request email", "error", err, "order_id", order.ID)
+	}
+}
+
+// HandleCancelOrderAdjustment withdraws a change that's still waiting on its payment
+// link. The link stops taking payments and the change never joins the order.
+func (h *AdminHandler) HandleCancelOrderAdjustment(c echo.Context) error {
+	ctx := c.Request().Context()
+	orderID := c.Param("id")
+
+	adjustment, err := h.storage.Queries.GetOrderAdjustment(ctx, c.Param("adjustmentID"))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && adjustment.OrderID != orderID) {
+		return echo.NewHTTPError(http.StatusNotFound, "Change not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch order adjustment", "error", err, "order_id", orderID, "adjustment_id", c.Param("adjustmentID"))
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "Could not withdraw the change"))
+	}
+	if adjustment.StripePaymentLinkID == "" || adjustment.PaidAt.Valid || adjustment.CancelledAt.Valid {
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "Only a change still waiting on payment can be withdrawn"))
+	}
+
+	// The link is switched off first, so the customer can't pay for a withdrawn change
+	if err := stripe.DeactivatePaymentLink(adjustment.StripePaymentLinkID); err != nil {
+		slog.Error("failed to deactivate payment link", "error", err, "order_id", orderID, "adjustment_id", adjustment.ID, "payment_link_id", adjustment.StripePaymentLinkID)
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "Stripe couldn't switch the payment link off"))
+	}
+	cancelled, err := h.storage.Queries.CancelOrderAdjustment(ctx, db.CancelOrderAdjustmentParams{
+		ID:      adjustment.ID,
+		OrderID: orderID,
+	})
+	if err != nil {
+		slog.Error("failed to withdraw order adjustment", "error", err, "order_id", orderID, "adjustment_id", adjustment.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "Could not withdraw the change"))
+	}
+	if cancelled == 0 {
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "The customer paid for the change before it was withdrawn"))
+	}
+
+	slog.Info("order adjustment withdrawn", "order_id", orderID, "adjustment_id", adjustment.ID, "by", adminActor(c))
+	return c.Redirect(http.StatusSeeOther, orderURL(orderID, "Withdrew: "+adjustment.Description, ""))
+}
+
+// handleOrderAdjustmentPaid puts a paid change on its order: added items become order
+// lines and are taken off the sellable count. Stripe retries webhooks, so a change
+// that's already paid is left alone.
+func (h *PaymentHandler) handleOrderAdjustmentPaid(ctx context.Context, adjustmentID string, session *stripego.CheckoutSession) error {
+	if session.PaymentStatus != stripego.CheckoutSessionPaymentStatusPaid {
+		slog.Warn("order adjustment checkout completed without payment", "adjustment_id", adjustmentID, "payment_status", session.PaymentStatus)
+		return nil
+	}
+
+	adjustment, err := h.queries.GetOrderAdjustment(ctx, adjustmentID)
+	if err != nil {
+		return fmt.Errorf("failed to load order adjustment: %w", err)
+	}
+	if adjustment.PaidAt.Valid {
+		return nil
+	}
+	if adjustment.CancelledAt.Valid {
+		// Paid as the link was being switched off; needs refunding by hand
+		slog.Error("payment received for withdrawn order adjustment", "adjustment_id", adjustmentID, "order_id", adjustment.OrderID, "session_id", session.ID)
+		return nil
+	}
+
+	// Added lines take their adjustment item's ID, so doing this again on a retry is harmless
+	if adjustment.Kind == utils.AdjustmentAddItems {
+		if err := h.queries.AddPaidAdjustmentItemsToOrder(ctx, adjustmentID); err != nil {
+			return fmt.Errorf("failed to add paid items to order: %w", err)
+		}
+		if err := h.queries.LinkAddedAdjustmentItems(ctx, adjustmentID); err != nil {
+			return fmt.Errorf("failed to link added items: %w", err)
+		}
+	}
+
+	var paymentIntentID string
+	if session.PaymentIntent != nil {
+		paymentIntentID = session.PaymentIntent.ID
+	}
+	paid, err := h.queries.MarkOrderAdjustmentPaid(ctx, db.MarkOrderAdjustmentPaidParams{
+		StripePaymentIntentID: paymentIntentID,
+		ID:                    adjustmentID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark order adjustment paid: %w", err)
+	}
+	if paid == 0 {
+		return nil
+	}
+
+	if adjustment.Kind == utils.AdjustmentAddItems {
+		items, err := h.queries.GetOrderAdjustmentItems(ctx, adjustmentID)
+		if err != nil {
+			slog.Error("failed to load added items to take from stock", "error", err, "adjustment_id", adjustmentID)
+		}
+		for _, item := range items {
+			if err := addAvailableStock(ctx, h.queries, item.ProductID, item.ProductSkuID, -item.Quantity); err != nil {
+				slog.Error("failed to take added item from stock", "error", err, "adjustment_id", adjustmentID, "product_id", item.ProductID)
+			}
+		}
+	}
+
+	slog.Info("order adjustment paid", "adjustment_id", adjustmentID, "order_id", adjustment.OrderID, "kind", adjustment.Kind, "amount_cents", adjustment.AmountCents, "payment_intent_id", paymentIntentID)
+	return nil
+}
+
+// orderEdits gathers what the order page's edit card and history show about changes
+// made to the order after it was paid for
+func (h *AdminHandler) orderEdits(ctx context.Context, order db.Order, items []db.GetOrderItemsRow) admin.OrderEditData {
+	data := admin.OrderEditData{
+		Editable:        utils.OrderEditable(order.Status.String),
+		NewAdjustmentID: uuid.New().String(),
+	}
+
+	var err error
+	if data.Adjustments, err = h.storage.Queries.ListOrderAdjustments(ctx, order.ID); err != nil {
+		slog.Error("failed to list order adjustments", "error", err, "order_id", order.ID)
+	}
+	if data.Items, err = h.storage.Queries.ListOrderAdjustmentItems(ctx, order.ID); err != nil {
+		slog.Error("failed to list order adjustment items", "error", err, "order_id", order.ID)
+	}
+	if !data.Editable {
+		return data
+	}
+
+	adjusted, err := h.storage.Queries.GetOrderShippingAdjustedCents(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to sum shipping changes", "error", err, "order_id", order.ID)
+	}
+	data.ShippingCents = order.ShippingCents + adjusted
+	if data.Removable, _, err = h.orderRemovable(ctx, order, items); err != nil {
+		slog.Error("failed to work out what can be taken off the order", "error", err, "order_id", order.ID)
+	}
+	if data.Products, err = h.storage.Queries.ListOrderEditProducts(ctx); err != nil {
+		slog.Error("failed to list products to add to an order", "error", err, "order_id", order.ID)
+	}
+	return data
+}
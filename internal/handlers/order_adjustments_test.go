@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestRemovableQuantities(t *testing.T) {
+	toShip := []db.ListOrderItemsToShipRow{
+		{ID: "a", Quantity: 3, ShippedQuantity: 1},
+		{ID: "b", Quantity: 2, RefundedQuantity: 1},
+		{ID: "c", Quantity: 1, ShippedQuantity: 1},
+	}
+	removable := removableQuantities(toShip, map[string]int64{"a": 3, "b": 1, "c": 1})
+	assert.Equal(t, map[string]int64{"a": 2, "b": 1, "c": 0}, removable)
+
+	removable = removableQuantities(toShip, map[string]int64{"a": 1})
+	assert.Equal(t, int64(1), removable["a"], "units on a return can't be removed")
+	assert.Equal(t, int64(0), removable["b"])
+}
+
+func TestWithoutAddedLines(t *testing.T) {
+	refundable := withoutAddedLines(map[string]int64{"a": 2, "added": 1}, []sql.NullString{{String: "added", Valid: true}, {String: "gone", Valid: true}})
+	assert.Equal(t, map[string]int64{"a": 2, "added": 0}, refundable)
+}
+
+func TestPlanRemoval(t *testing.T) {
+	order := db.Order{SubtotalCents: 4000, ShippingCents: 800, TotalCents: 4800}
+	items := []db.GetOrderItemsRow{
+		{ID: "a", ProductName: "Dragon", Quantity: 2, UnitPriceCents: 1500},
+		{ID: "b", ProductName: "Vase", Quantity: 1, UnitPriceCents: 1000},
+	}
+	removable := map[string]int64{"a": 2, "b": 0}
+
+	lines, cents, msg := planRemoval(order, items, removable, 4800, map[string]int64{"a": 1})
+	require.Empty(t, msg)
+	assert.Equal(t, int64(1500), cents)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "a", lines[0].Item.ID)
+
+	_, cents, msg = planRemoval(order, items, removable, 1000, map[string]int64{"a": 2})
+	require.Empty(t, msg)
+	assert.Equal(t, int64(1000), cents, "capped at what's left of the payment")
+
+	_, _, msg = planRemoval(order, items, removable, 4800, map[string]int64{"b": 1})
+	assert.Equal(t, "Only 0 of Vase can be taken off the order", msg, "shipped units stay on the order")
+
+	_, _, msg = planRemoval(order, items, removable, 4800, nil)
+	assert.NotEmpty(t, msg, "nothing chosen")
+
+	_, _, msg = planRemoval(order, items, removable, 0, map[string]int64{"a": 1})
+	assert.NotEmpty(t, msg, "already fully refunded")
+}
+
+func TestOrderLineChargeCents(t *testing.T) {
+	assert.Equal(t, int64(3000), orderLineChargeCents(db.Order{SubtotalCents: 4000}, 1500, 2))
+
+	// 5.5% tax, and the order's discount doesn't carry over
+	taxed := db.Order{SubtotalCents: 3600, OriginalSubtotalCents: sql.NullInt64{Int64: 4000, Valid: true}, TaxCents: 198}
+	assert.Equal(t, int64(1583), orderLineChargeCents(taxed, 1500, 1))
+}
+
+func TestParseDollars(t *testing.T) {
+	cents, ok := parseDollars(" $12.50 ")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1250), cents)
+
+	cents, ok = parseDollars("0")
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), cents)
+
+	for _, raw := range []string{"", "-1", "abc", "NaN", "Inf"} {
+		_, ok = parseDollars(raw)
+		assert.False(t, ok, raw)
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// customerCancelNote goes in the order's status history and on its refund
+const customerCancelNote = "Cancelled by the customer"
+
+// OrderCancellationHandler lets customers cancel their own orders from their account,
+// within the window set in site settings
+type OrderCancellationHandler struct {
+	storage      *storage.Storage
+	emailService *email.Service
+}
+
+func NewOrderCancellationHandler(storage *storage.Storage, emailService *email.Service) *OrderCancellationHandler {
+	return &OrderCancellationHandler{
+		storage:      storage,
+		emailService: emailService,
+	}
+}
+
+func accountOrderURL(orderID, errorMsg string) string {
+	target := "/account/orders/" + orderID
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+	return target
+}
+
+// customerCancelProblem explains why a customer can't cancel an order online even
+// though it's still in the window, or returns "". Gift cards and store credit aren't
+// part of the Stripe payment, so orders that used or bought them are cancelled by the
+// shop, which can give them back. So are orders changed since with a payment link,
+// which is a second payment.
+func customerCancelProblem(order db.Order, giftCardsBought, extraPayments int) string {
+	switch {
+	case order.GiftCardCents > 0 || order.StoreCreditCents > 0:
+		return "Orders paid partly with a gift card or store credit can't be cancelled online. Contact us and we'll cancel it for you."
+	case giftCardsBought > 0:
+		return "Orders with a gift card in them can't be cancelled online. Contact us and we'll cancel it for you."
+	case extraPayments > 0:
+		return "Orders we've changed since you paid can't be cancelled online. Contact us and we'll cancel it for you."
+	}
+	return ""
+}
+
+// HandleCancelOrder cancels one of the customer's orders while it's still received and
+// inside the cancellation window. Its items go back into stock, what's left of the
+// payment is refunded to the card, and the customer and the shop are both emailed.
+func (h *OrderCancellationHandler) HandleCancelOrder(c echo.Context) error {
+	orderID := c.Param("id")
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url="+accountOrderURL(orderID, ""))
+	}
+
+	ctx := c.Request().Context()
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch order to cancel", "error", err, "order_id", orderID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load order")
+	}
+	if order.UserID != user.ID {
+		slog.Error("user attempted to cancel order they don't own", "user_id", user.ID, "order_id", orderID)
+		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	}
+
+	tooLate := "This order can no longer be cancelled online. Contact us and we'll see what we can do."
+	window := settings.For(h.storage.Queries).Values(ctx).OrderCancelWindow()
+	if !utils.CustomerCanCancel(order.Status.String, order.CreatedAt.Time, time.Now(), window) {
+		return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, tooLate))
+	}
+
+	giftCards, err := h.storage.Queries.ListOrderGiftCards(ctx, sql.NullString{String: order.ID, Valid: true})
+	if err != nil {
+		slog.Error("failed to list gift cards bought on order", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, "We couldn't cancel the order. Please try again, or contact us."))
+	}
+	extraPayments, err := h.storage.Queries.CountOrderAdjustmentPayments(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to count payment links sent for order", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, "We couldn't cancel the order. Please try again, or contact us."))
+	}
+	if problem := customerCancelProblem(order, len(giftCards), int(extraPayments)); problem != "" {
+		return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, problem))
+	}
+
+	refundCents, err := h.cancelOrder(ctx, order, user.Email)
+	if errors.Is(err, errOrderStatusChanged) {
+		return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, tooLate))
+	}
+	if err != nil {
+		slog.Error("failed to cancel order for customer", "error", err, "order_id", order.ID, "user_id", user.ID)
+		return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, "We couldn't cancel the order. Please try again, or contact us."))
+	}
+
+	slog.Info("order cancelled by customer", "order_id", order.ID, "user_id", user.ID, "refund_cents", refundCents)
+
+	go h.notifyCancelled(order, refundCents)
+
+	return c.Redirect(http.StatusSeeOther, accountOrderURL(order.ID, "")+"?cancelled=1")
+}
+
+// cancelOrder cancels the order, puts its items back into stock and refunds what's left
+// of its payment, all together: if Stripe won't make the refund, nothing changes. It
+// returns what was refunded.
+func (h *OrderCancellationHandler) cancelOrder(ctx context.Context, order db.Order, customerEmail string) (int64, error) {
+	items, err := h.storage.Queries.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return 0, fmt.Errorf("load order items: %w", err)
+	}
+	refundedQuantities, err := h.storage.Queries.GetOrderRefundedQuantities(ctx, order.ID)
+	if err != nil {
+		return 0, fmt.Errorf("load refunded quantities: %w", err)
+	}
+	returnedQuantities, err := h.storage.Queries.GetOrderReturnedQuantities(ctx, order.ID)
+	if err != nil {
+		return 0, fmt.Errorf("load returned quantities: %w", err)
+	}
+	refunded, err := h.storage.Queries.GetOrderRefundedCents(ctx, order.ID)
+	if err != nil {
+		return 0, fmt.Errorf("sum order refunds: %w", err)
+	}
+	remaining := order.TotalCents - refunded
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	// Moving the status first means a second click waits here, then finds the order
+	// already cancelled, rather than refunding it twice
+	if err := moveOrderStatus(ctx, queries, order.ID, order.Status.String, utils.OrderCancelled, customerEmail, customerCancelNote); err != nil {
+		return 0, err
+	}
+	if err := restockCancelledOrder(ctx, queries, order.ID); err != nil {
+		return 0, fmt.Errorf("restock: %w", err)
+	}
+
+	if remaining <= 0 || order.StripePaymentIntentID.String == "" {
+		return 0, tx.Commit()
+	}
+
+	lines, cents, _ := planOrderRefund(order, items, refundableQuantities(items, refundedQuantities, returnedQuantities), remaining, refundScopeOrder, nil)
+	refundID := uuid.New().String()
+	chargedCents := stripe.ChargedAmount(cents, order.PaymentCurrency, order.PaymentFxRate)
+	stripeRefund, err := stripe.RefundOrder(order.StripePaymentIntentID.String, chargedCents, refundID, order.ID)
+	if err != nil {
+		return 0, fmt.Errorf("refund in stripe: %w", err)
+	}
+
+	err = saveCancellationRefund(ctx, queries, order.ID, refundID, stripeRefund.ID, cents, lines, customerEmail)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		slog.Error("refunded a cancelled order in Stripe but failed to save it", "error", err, "order_id", order.ID, "stripe_refund_id", stripeRefund.ID, "amount_cents", cents)
+		return 0, err
+	}
+	return cents, nil
+}
+
+// saveCancellationRefund records a refund a customer's cancellation made, with its lines
+func saveCancellationRefund(ctx context.Context, queries *db.Queries, orderID, refundID, stripeRefundID string, cents int64, lines []orderRefundLine, createdBy string) error {
+	if _, err := queries.CreateOrderRefund(ctx, db.CreateOrderRefundParams{
+		ID:             refundID,
+		OrderID:        orderID,
+		AmountCents:    cents,
+		Reason:         customerCancelNote,
+		StripeRefundID: stripeRefundID,
+		CreatedBy:      createdBy,
+	}); err != nil {
+		return fmt.Errorf("create refund: %w", err)
+	}
+	for _, line := range lines {
+		if err := queries.CreateOrderRefundItem(ctx, db.CreateOrderRefundItemParams{
+			ID:          uuid.New().String(),
+			RefundID:    refundID,
+			OrderItemID: line.Item.ID,
+			Quantity:    line.Quantity,
+			AmountCents: line.AmountCents,
+		}); err != nil {
+			return fmt.Errorf("create refund item: %w", err)
+		}
+	}
+	return nil
+}
+
+// notifyCancelled emails the customer that their order is cancelled and refunded, and
+// tells the shop not to make it
+func (h *OrderCancellationHandler) notifyCancelled(order db.Order, refundCents int64) {
+	data, err := orderCancelledData(context.Background(), h.storage.Queries, order.ID)
+	if err != nil {
+		slog.Error("failed to load order for cancellation email", "error", err, "order_id", order.ID)
+		return
+	}
+	data.ByCustomer = true
+	data.RefundCents = refundCents
+	if order.PaymentCurrency != "" && order.PaymentCurrency != "usd" {
+		data.Currency = strings.ToUpper(order.PaymentCurrency)
+	}
+
+	if err := h.emailService.SendOrderCancelled(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send order cancelled email", "error", err, "order_id", order.ID)
+	}
+	if err := h.emailService.SendOrderCancelledNotificationToAdmin(data); err != nil {
+		slog.Error("failed to send order cancelled admin notification", "error", err, "order_id", order.ID)
+	}
+}
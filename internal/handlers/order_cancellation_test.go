@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestCustomerCancelProblem(t *testing.T) {
+	assert.Empty(t, customerCancelProblem(db.Order{TotalCents: 4000}, 0, 0))
+	assert.Contains(t, customerCancelProblem(db.Order{TotalCents: 4000, GiftCardCents: 500}, 0, 0), "gift card or store credit")
+	assert.Contains(t, customerCancelProblem(db.Order{TotalCents: 4000, StoreCreditCents: 500}, 0, 0), "gift card or store credit")
+	assert.Contains(t, customerCancelProblem(db.Order{TotalCents: 4000}, 1, 0), "gift card in them")
+	assert.Contains(t, customerCancelProblem(db.Order{TotalCents: 4000}, 0, 1), "changed since you paid")
+}
+
+func TestAccountOrderURL(t *testing.T) {
+	assert.Equal(t, "/account/orders/o1", accountOrderURL("o1", ""))
+	assert.Equal(t, "/account/orders/o1?error=Too+late", accountOrderURL("o1", "Too late"))
+}
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	stripego "github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// Refund scopes on the order page's refund form
+const (
+	refundScopeOrder = "order" // everything left of the order
+	refundScopeItems = "items" // the chosen units of some lines
+)
+
+func orderURL(orderID, flash, errorMsg string) string {
+	target := "/admin/orders/" + orderID
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// orderRefundLine is some units of an order line being refunded
+type orderRefundLine struct {
+	Item        db.GetOrderItemsRow
+	Quantity    int64
+	AmountCents int64
+}
+
+// orderLineRefundCents is what some units of an order line came to: their price less
+// their share of the order's discount, plus their share of its tax
+func orderLineRefundCents(order db.Order, unitPriceCents, quantity int64) int64 {
+	cents := float64(unitPriceCents * quantity)
+	if order.OriginalSubtotalCents.Valid && order.OriginalSubtotalCents.Int64 > order.SubtotalCents && order.OriginalSubtotalCents.Int64 > 0 {
+		cents = cents * float64(order.SubtotalCents) / float64(order.OriginalSubtotalCents.Int64)
+	}
+	if order.TaxCents > 0 && order.SubtotalCents > 0 {
+		cents += cents * float64(order.TaxCents) / float64(order.SubtotalCents)
+	}
+	return int64(math.Round(cents))
+}
+
+// refundableQuantities is how many units of each order line are left to refund from
+// the order page. Units on a return are refunded from the return instead.
+func refundableQuantities(items []db.GetOrderItemsRow, refunded []db.GetOrderRefundedQuantitiesRow, returned []db.GetOrderReturnedQuantitiesRow) map[string]int64 {
+	left := make(map[string]int64, len(items))
+	for _, item := range items {
+		left[item.ID] = item.Quantity
+	}
+	take := func(orderItemID string, quantity int64) {
+		if _, ok := left[orderItemID]; ok {
+			left[orderItemID] = max(left[orderItemID]-quantity, 0)
+		}
+	}
+	for _, row := range refunded {
+		take(row.OrderItemID, row.Quantity)
+	}
+	for _, row := range returned {
+		take(row.OrderItemID, row.Quantity)
+	}
+	return left
+}
+
+// planOrderRefund works out the lines and amount of a refund. A whole-order refund is
+// every unit left and whatever is left of the payment, shipping and tax included; an
+// items refund is the chosen units at what they came to, capped at what's left. A
+// non-empty message says why nothing can be refunded.
+func planOrderRefund(order db.Order, items []db.GetOrderItemsRow, left map[string]int64, remainingCents int64, scope string, quantities map[string]int64) ([]orderRefundLine, int64, string) {
+	if remainingCents <= 0 {
+		return nil, 0, "Nothing is left of this order to refund"
+	}
+
+	var lines []orderRefundLine
+	var cents int64
+	for _, item := range items {
+		quantity := left[item.ID]
+		if scope == refundScopeItems {
+			quantity = quantities[item.ID]
+			if quantity > left[item.ID] {
+				return nil, 0, fmt.Sprintf("Only %d of %s left to refund", left[item.ID], item.ProductName)
+			}
+		}
+		if quantity <= 0 {
+			continue
+		}
+		line := orderRefundLine{
+			Item:        item,
+			Quantity:    quantity,
+			AmountCents: orderLineRefundCents(order, item.UnitPriceCents, quantity),
+		}
+		lines = append(lines, line)
+		cents += line.AmountCents
+	}
+
+	switch scope {
+	case refundScopeOrder:
+		return lines, remainingCents, ""
+	case refundScopeItems:
+		if len(lines) == 0 {
+			return nil, 0, "Choose how many of each item to refund"
+		}
+		return lines, min(cents, remainingCents), ""
+	}
+	return nil, 0, "Choose what to refund"
+}
+
+// HandleRefundOrder refunds the whole order, or some units of its lines, to the card it
+// was paid with. It can put the refunded units back into stock, marks the order
+// refunded once nothing is left of it, and emails the customer a confirmation.
+func (h *AdminHandler) HandleRefundOrder(c echo.Context) error {
+	ctx := c.Request().Context()
+	orderID := c.Param("id")
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch order for refund", "error", err, "order_id", orderID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load order")
+	}
+	if order.StripePaymentIntentID.String == "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "The order has no Stripe payment to refund"))
+	}
+
+	// The form carries the refund's ID so a resubmitted form is recognised
+	refundID := c.FormValue("refund_id")
+	if _, err := uuid.Parse(refundID); err != nil {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Reload the page and try again"))
+	}
+	if exists, err := h.storage.Queries.OrderRefundExists(ctx, refundID); err != nil {
+		slog.Error("failed to check for an earlier refund", "error", err, "order_id", order.ID, "refund_id", refundID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not refund the order"))
+	} else if exists {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "That refund was already issued"))
+	}
+
+	items, err := h.storage.Queries.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch order items for refund", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not refund the order"))
+	}
+	refundedQuantities, err := h.storage.Queries.GetOrderRefundedQuantities(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch refunded quantities", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not refund the order"))
+	}
+	returnedQuantities, err := h.storage.Queries.GetOrderReturnedQuantities(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch returned quantities", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not refund the order"))
+	}
+	addedItems, err := h.storage.Queries.ListOrderAddedItemIDs(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch items added to the order", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not refund the order"))
+	}
+	refunded, err := h.storage.Queries.GetOrderRefundedCents(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to sum order refunds", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not refund the order"))
+	}
+	remaining := order.TotalCents - refunded
+
+	scope := c.FormValue("scope")
+	quantities := make(map[string]int64, len(items))
+	for _, item := range items {
+		raw := strings.TrimSpace(c.FormValue("qty_" + item.ID))
+		if raw == "" {
+			continue
+		}
+		quantity, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || quantity < 0 {
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Enter how many of "+item.ProductName+" to refund"))
+		}
+		quantities[item.ID] = quantity
+	}
+
+	refundable := withoutAddedLines(refundableQuantities(items, refundedQuantities, returnedQuantities), addedItems)
+	lines, cents, errMsg := planOrderRefund(order, items, refundable, remaining, scope, quantities)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", errMsg))
+	}
+	// An items refund can be for a different amount, say to add shipping or keep a fee
+	if raw := strings.TrimSpace(c.FormValue("amount")); scope == refundScopeItems && raw != "" {
+		amount, err := strconv.ParseFloat(strings.TrimPrefix(raw, "$"), 64)
+		if err != nil || amount <= 0 {
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Enter an amount to refund, or leave it blank"))
+		}
+		cents = int64(math.Round(amount * 100))
+		if cents > remaining {
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", fmt.Sprintf("Only $%.2f of the order is left to refund", float64(remaining)/100)))
+		}
+	}
+
+	restock := c.FormValue("restock") != ""
+	locationID := c.FormValue("location_id")
+	if restock && locationID != "" {
+		if _, err := h.storage.Queries.GetInventoryLocation(ctx, locationID); err != nil {
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Choose a location"))
+		}
+	}
+
+	fullyRefunded := cents >= remaining
+	stripeRefundID, errMsg := refundPayment(order, cents, "order", func(chargedCents int64) (*stripego.Refund, error) {
+		return stripe.RefundOrder(order.StripePaymentIntentID.String, chargedCents, refundID, order.ID)
+	}, func(stripeRefundID string) error {
+		return h.recordOrderRefund(ctx, c, order, refundID, stripeRefundID, cents, lines, restock, locationID, fullyRefunded)
+	}, "refund_id", refundID)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", errMsg))
+	}
+
+	slog.Info("order refunded", "order_id", order.ID, "refund_id", refundID, "stripe_refund_id", stripeRefundID, "amount_cents", cents, "scope", scope, "restocked", restock, "full", fullyRefunded, "by", adminActor(c))
+
+	if c.FormValue("notify") != "" {
+		go h.notifyOrderRefunded(order, refundID, cents, lines, fullyRefunded)
+	}
+
+	flash := fmt.Sprintf("Refunded $%.2f", float64(cents)/100)
+	if fullyRefunded {
+		flash += "; the order is fully refunded"
+	}
+	return c.Redirect(http.StatusSeeOther, orderURL(order.ID, flash, ""))
+}
+
+// refundPayment refunds part of an order's payment through Stripe, then saves the
+// refund with record. Orders are kept in USD, but Stripe refunds in the currency the
+// customer paid, so refund is given the amount in that currency. what names the order
+// or return being refunded, and a non-empty message says what went wrong.
+func refundPayment(order db.Order, cents int64, what string, refund func(chargedCents int64) (*stripego.Refund, error), record func(stripeRefundID string) error, logArgs ...any) (string, string) {
+	logArgs = append([]any{"order_id", order.ID, "amount_cents", cents, "currency", order.PaymentCurrency}, logArgs...)
+
+	stripeRefund, err := refund(stripe.ChargedAmount(cents, order.PaymentCurrency, order.PaymentFxRate))
+	if err != nil {
+		slog.Error("failed to refund "+what+" in Stripe", append([]any{"error", err}, logArgs...)...)
+		return "", "Stripe refused the refund"
+	}
+	if err := record(stripeRefund.ID); err != nil {
+		slog.Error("failed to record "+what+" refund", append([]any{"error", err, "stripe_refund_id", stripeRefund.ID}, logArgs...)...)
+		return "", "Refunded in Stripe but failed to save it on the " + what
+	}
+	return stripeRefund.ID, ""
+}
+
+// recordOrderRefund saves a refund Stripe has made, with its lines, puts the units back
+// into stock when asked and marks the order refunded when nothing is left of it, all
+// together
+func (h *AdminHandler) recordOrderRefund(ctx context.Context, c echo.Context, order db.Order, refundID, stripeRefundID string, cents int64, lines []orderRefundLine, restock bool, locationID string, fullyRefunded bool) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	if _, err := queries.CreateOrderRefund(ctx, db.CreateOrderRefundParams{
+		ID:             refundID,
+		OrderID:        order.ID,
+		AmountCents:    cents,
+		Reason:         strings.TrimSpace(c.FormValue("reason")),
+		StripeRefundID: stripeRefundID,
+		Restocked:      restock && len(lines) > 0,
+		CreatedBy:      adminActor(c),
+	}); err != nil {
+		return fmt.Errorf("create refund: %w", err)
+	}
+
+	for _, line := range lines {
+		if err := queries.CreateOrderRefundItem(ctx, db.CreateOrderRefundItemParams{
+			ID:          uuid.New().String(),
+			RefundID:    refundID,
+			OrderItemID: line.Item.ID,
+			Quantity:    line.Quantity,
+			AmountCents: line.AmountCents,
+		}); err != nil {
+			return fmt.Errorf("create refund item: %w", err)
+		}
+		if !restock {
+			continue
+		}
+
+		skuID := line.Item.ProductSkuID.String
+		if err := addAvailableStock(ctx, queries, line.Item.ProductID, skuID, line.Quantity); err != nil {
+			return fmt.Errorf("restock %s: %w", line.Item.ProductID, err)
+		}
+		if locationID == "" {
+			continue
+		}
+		if err := queries.AddLocationStock(ctx, db.AddLocationStockParams{
+			LocationID:   locationID,
+			ProductID:    line.Item.ProductID,
+			ProductSkuID: skuID,
+			Delta:        line.Quantity,
+		}); err != nil {
+			return fmt.Errorf("restock %s at location: %w", line.Item.ProductID, err)
+		}
+		if err := queries.CreateInventoryMovement(ctx, db.CreateInventoryMovementParams{
+			ID:             uuid.New().String(),
+			LocationID:     locationID,
+			ProductID:      line.Item.ProductID,
+			ProductSkuID:   skuID,
+			QuantityChange: line.Quantity,
+			Reason:         movementAdjustment,
+			OrderID:        sql.NullString{String: order.ID, Valid: true},
+			Note:           "Refunded: order " + order.ID[:8],
+			CreatedBy:      adminActor(c),
+		}); err != nil {
+			return fmt.Errorf("record inventory movement: %w", err)
+		}
+	}
+
+	if fullyRefunded && order.Status.String != utils.OrderRefunded {
+		if err := setOrderStatus(ctx, queries, order.ID, order.Status.String, utils.OrderRefunded, adminActor(c), "Fully refunded"); err != nil {
+			return fmt.Errorf("mark order refunded: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// notifyOrderRefunded emails the customer that a refund is on its way
+func (h *AdminHandler) notifyOrderRefunded(order db.Order, refundID string, cents int64, lines []orderRefundLine, fullyRefunded bool) {
+	data := &email.OrderRefundedData{
+		OrderID:       order.ID,
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+		AmountCents:   cents,
+		FullRefund:    fullyRefunded,
+	}
+	if order.PaymentCurrency != "" && order.PaymentCurrency != "usd" {
+		data.Currency = strings.ToUpper(order.PaymentCurrency)
+	}
+	for _, line := range lines {
+		data.Items = append(data.Items, email.OrderRefundedItem{
+			ProductName: line.Item.ProductName,
+			Quantity:    line.Quantity,
+		})
+	}
+
+	if err := h.emailService.SendOrderRefunded(data); err != nil {
+		if !errors.Is(err, email.ErrOptedOut) {
+			slog.Error("failed to send order refunded email", "error", err, "order_id", order.ID, "refund_id", refundID)
+		}
+		return
+	}
+	if err := h.storage.Queries.MarkOrderRefundNotified(context.Background(), refundID); err != nil {
+		slog.Error("failed to mark refund notified", "error", err, "order_id", order.ID, "refund_id", refundID)
+	}
+}
+
+// orderRefunds gathers what the order page's refund card shows
+func (h *AdminHandler) orderRefunds(ctx context.Context, c echo.Context, order db.Order, items []db.GetOrderItemsRow) admin.OrderRefundsData {
+	data := admin.OrderRefundsData{
+		NewRefundID: uuid.New().String(),
+		Flash:       c.QueryParam("saved"),
+		Error:       c.QueryParam("error"),
+	}
+
+	var err error
+	if data.Refunds, err = h.storage.Queries.ListOrderRefunds(ctx, order.ID); err != nil {
+		slog.Error("failed to list order refunds", "error", err, "order_id", order.ID)
+	}
+	if data.Items, err = h.storage.Queries.ListOrderRefundItems(ctx, order.ID); err != nil {
+		slog.Error("failed to list order refund items", "error", err, "order_id", order.ID)
+	}
+	if data.RefundedCents, err = h.storage.Queries.GetOrderRefundedCents(ctx, order.ID); err != nil {
+		slog.Error("failed to sum order refunds", "error", err, "order_id", order.ID)
+	}
+	refunded, err := h.storage.Queries.GetOrderRefundedQuantities(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch refunded quantities", "error", err, "order_id", order.ID)
+	}
+	returned, err := h.storage.Queries.GetOrderReturnedQuantities(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch returned quantities", "error", err, "order_id", order.ID)
+	}
+	added, err := h.storage.Queries.ListOrderAddedItemIDs(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch items added to the order", "error", err, "order_id", order.ID)
+	}
+	data.Refundable = withoutAddedLines(refundableQuantities(items, refunded, returned), added)
+	if data.Locations, err = h.storage.Queries.ListActiveInventoryLocations(ctx); err != nil {
+		slog.Error("failed to list inventory locations for refund", "error", err)
+	}
+	return data
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stripego "github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestOrderLineRefundCents(t *testing.T) {
+	plain := db.Order{SubtotalCents: 4000, TotalCents: 4000}
+	assert.Equal(t, int64(3000), orderLineRefundCents(plain, 1500, 2))
+
+	// 10% off, then 5.5% tax on the discounted subtotal
+	discounted := db.Order{
+		SubtotalCents:         3600,
+		OriginalSubtotalCents: sql.NullInt64{Int64: 4000, Valid: true},
+		TaxCents:              198,
+	}
+	assert.Equal(t, int64(1424), orderLineRefundCents(discounted, 1500, 1), "1350 after the discount plus 74 tax")
+	assert.Equal(t, int64(3798), orderLineRefundCents(discounted, 1000, 4), "every line comes to the subtotal and tax")
+}
+
+func TestRefundableQuantities(t *testing.T) {
+	items := []db.GetOrderItemsRow{{ID: "a", Quantity: 3}, {ID: "b", Quantity: 1}}
+	left := refundableQuantities(items,
+		[]db.GetOrderRefundedQuantitiesRow{{OrderItemID: "a", Quantity: 1}},
+		[]db.GetOrderReturnedQuantitiesRow{{OrderItemID: "a", Quantity: 1}, {OrderItemID: "b", Quantity: 2}, {OrderItemID: "gone", Quantity: 1}},
+	)
+	assert.Equal(t, map[string]int64{"a": 1, "b": 0}, left)
+}
+
+func TestPlanOrderRefund(t *testing.T) {
+	order := db.Order{SubtotalCents: 4000, ShippingCents: 800, TotalCents: 4800}
+	items := []db.GetOrderItemsRow{
+		{ID: "a", ProductName: "Dragon", Quantity: 2, UnitPriceCents: 1500},
+		{ID: "b", ProductName: "Vase", Quantity: 1, UnitPriceCents: 1000},
+	}
+	left := map[string]int64{"a": 2, "b": 1}
+
+	lines, cents, msg := planOrderRefund(order, items, left, 4800, refundScopeOrder, nil)
+	require.Empty(t, msg)
+	assert.Equal(t, int64(4800), cents, "the whole order includes shipping")
+	assert.Len(t, lines, 2)
+
+	lines, cents, msg = planOrderRefund(order, items, left, 4800, refundScopeItems, map[string]int64{"a": 1})
+	require.Empty(t, msg)
+	assert.Equal(t, int64(1500), cents)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "a", lines[0].Item.ID)
+
+	_, cents, msg = planOrderRefund(order, items, left, 1000, refundScopeItems, map[string]int64{"a": 2})
+	require.Empty(t, msg)
+	assert.Equal(t, int64(1000), cents, "capped at what's left of the payment")
+
+	_, _, msg = planOrderRefund(order, items, left, 4800, refundScopeItems, map[string]int64{"b": 2})
+	assert.Equal(t, "Only 1 of Vase left to refund", msg)
+
+	_, _, msg = planOrderRefund(order, items, left, 4800, refundScopeItems, nil)
+	assert.NotEmpty(t, msg, "nothing chosen")
+
+	_, _, msg = planOrderRefund(order, items, left, 0, refundScopeOrder, nil)
+	assert.NotEmpty(t, msg, "already fully refunded")
+}
+
+func TestRefundPayment(t *testing.T) {
+	order := db.Order{ID: "order-1", PaymentCurrency: "eur", PaymentFxRate: 0.9}
+	var charged int64
+	var recorded string
+	refund := func(chargedCents int64) (*stripego.Refund, error) {
+		charged = chargedCents
+		return &stripego.Refund{ID: "re_1"}, nil
+	}
+	record := func(stripeRefundID string) error {
+		recorded = stripeRefundID
+		return nil
+	}
+
+	// Stripe refunds in the currency paid, and the refund is saved with its Stripe ID
+	stripeRefundID, errMsg := refundPayment(order, 1000, "return", refund, record)
+	assert.Empty(t, errMsg)
+	assert.Equal(t, "re_1", stripeRefundID)
+	assert.Equal(t, int64(900), charged)
+	assert.Equal(t, "re_1", recorded)
+
+	// Nothing is saved when Stripe refuses
+	recorded = ""
+	_, errMsg = refundPayment(order, 1000, "return", func(int64) (*stripego.Refund, error) {
+		return nil, errors.New("charge already refunded")
+	}, record)
+	assert.Equal(t, "Stripe refused the refund", errMsg)
+	assert.Empty(t, recorded)
+
+	_, errMsg = refundPayment(order, 1000, "order", refund, func(string) error {
+		return errors.New("database is locked")
+	})
+	assert.Equal(t, "Refunded in Stripe but failed to save it on the order", errMsg)
+}
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// shipmentLine is some units of an order line going out in one shipment
+type shipmentLine struct {
+	Item     db.ListOrderItemsToShipRow
+	Quantity int64
+}
+
+// shippingLines is how far along shipping each of an order's physical lines is
+func shippingLines(items []db.ListOrderItemsToShipRow) []utils.ShippingLine {
+	lines := make([]utils.ShippingLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, utils.ShippingLine{
+			Quantity: item.Quantity,
+			Shipped:  item.ShippedQuantity,
+			Refunded: item.RefundedQuantity,
+		})
+	}
+	return lines
+}
+
+// unshipped is how many units of an order line are still to be sent
+func unshipped(item db.ListOrderItemsToShipRow) int64 {
+	return utils.ShippingLine{Quantity: item.Quantity, Shipped: item.ShippedQuantity, Refunded: item.RefundedQuantity}.Unshipped()
+}
+
+// planShipment picks the units going out in a shipment: the chosen quantities, or
+// every unit left when quantities is nil. A non-empty message says why nothing can
+// be shipped.
+func planShipment(items []db.ListOrderItemsToShipRow, quantities map[string]int64) ([]shipmentLine, string) {
+	var lines []shipmentLine
+	for _, item := range items {
+		left := unshipped(item)
+		quantity := left
+		if quantities != nil {
+			quantity = quantities[item.ID]
+			if quantity > left {
+				return nil, fmt.Sprintf("Only %d of %s left to ship", left, item.ProductName)
+			}
+		}
+		if quantity <= 0 {
+			continue
+		}
+		lines = append(lines, shipmentLine{Item: item, Quantity: quantity})
+	}
+	if len(lines) == 0 {
+		if quantities != nil {
+			return nil, "Choose how many of each item are in the box"
+		}
+		return nil, "Nothing is left of this order to ship"
+	}
+	return lines, ""
+}
+
+// saveShipment records a shipment and the units in it
+func saveShipment(ctx context.Context, queries *db.Queries, shipment db.CreateOrderShipmentParams, lines []shipmentLine) error {
+	if err := queries.CreateOrderShipment(ctx, shipment); err != nil {
+		return fmt.Errorf("create shipment: %w", err)
+	}
+	for _, line := range lines {
+		if err := queries.CreateOrderShipmentItem(ctx, db.CreateOrderShipmentItemParams{
+			ID:          uuid.New().String(),
+			ShipmentID:  shipment.ID,
+			OrderItemID: line.Item.ID,
+			Quantity:    line.Quantity,
+		}); err != nil {
+			return fmt.Errorf("create shipment item: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordShipment saves a shipment and moves the order to partially shipped or shipped
+// to match what has gone out in all its shipments. It returns the status the order
+// moved to, or "" when it stayed where it was.
+func recordShipment(ctx context.Context, queries *db.Queries, order db.Order, shipment db.CreateOrderShipmentParams, lines []shipmentLine, changedBy, note string) (string, error) {
+	if err := saveShipment(ctx, queries, shipment, lines); err != nil {
+		return "", err
+	}
+	items, err := queries.ListOrderItemsToShip(ctx, order.ID)
+	if err != nil {
+		return "", fmt.Errorf("load shipped quantities: %w", err)
+	}
+	to := utils.ShippedStatus(shippingLines(items))
+	if to == "" || to == order.Status.String {
+		return "", nil
+	}
+	if err := moveOrderStatus(ctx, queries, order.ID, order.Status.String, to, changedBy, note); err != nil {
+		return "", err
+	}
+	return to, nil
+}
+
+// shipmentQuantities reads how many units of each line are in a box from the form's
+// ship_<line ID> fields. A non-empty message says which one isn't a number.
+func shipmentQuantities(c echo.Context, items []db.ListOrderItemsToShipRow) (map[string]int64, string) {
+	quantities := make(map[string]int64, len(items))
+	for _, item := range items {
+		raw := strings.TrimSpace(c.FormValue("ship_" + item.ID))
+		if raw == "" {
+			continue
+		}
+		quantity, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || quantity < 0 {
+			return nil, "Enter how many of " + item.ProductName + " are in the box"
+		}
+		quantities[item.ID] = quantity
+	}
+	return quantities, ""
+}
+
+// boxFromQuery reads the size and weight of a box being quoted on its own from the
+// weight_lb, length, width and height query parameters, in pounds and inches. It
+// returns nil when none are given, and a message when they're incomplete.
+func boxFromQuery(c echo.Context) (*shipping.Package, string) {
+	names := []string{"weight_lb", "length", "width", "height"}
+	values := make([]float64, len(names))
+	given := 0
+	for i, name := range names {
+		raw := strings.TrimSpace(c.QueryParam(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 {
+			return nil, "Enter the box's weight and size as positive numbers"
+		}
+		values[i] = value
+		given++
+	}
+	switch given {
+	case 0:
+		return nil, ""
+	case len(names):
+		return &shipping.Package{
+			PackageCode: "package",
+			Weight:      shipping.Weight{Value: values[0], Unit: "pound"},
+			Dimensions:  shipping.Dimensions{Length: values[1], Width: values[2], Height: values[3], Unit: "inch"},
+		}, ""
+	}
+	return nil, "Enter the box's weight, length, width and height"
+}
+
+// HandleRecordShipment records a box of an order sent with tracking from elsewhere,
+// such as a label bought at the post office. The order moves to partially shipped or
+// shipped, and the customer is emailed the box's tracking.
+func (h *AdminHandler) HandleRecordShipment(c echo.Context) error {
+	ctx := c.Request().Context()
+	orderID := c.Param("id")
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch order for shipment", "error", err, "order_id", orderID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load order")
+	}
+	if status := order.Status.String; status != utils.OrderShipped && !utils.CanMoveOrder(status, utils.OrderShipped) {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", fmt.Sprintf("A %s order can't be shipped", utils.OrderStatusLabel(status))))
+	}
+
+	items, err := h.storage.Queries.ListOrderItemsToShip(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch order items to ship", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not record the shipment"))
+	}
+	quantities, errMsg := shipmentQuantities(c, items)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", errMsg))
+	}
+	lines, errMsg := planShipment(items, quantities)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", errMsg))
+	}
+
+	carrier := strings.TrimSpace(c.FormValue("carrier"))
+	trackingNumber := strings.TrimSpace(c.FormValue("tracking_number"))
+	trackingURL := strings.TrimSpace(c.FormValue("tracking_url"))
+	if trackingURL == "" {
+		trackingURL = shipping.TrackingURL(carrier, trackingNumber)
+	}
+	shipment := db.CreateOrderShipmentParams{
+		ID:             uuid.New().String(),
+		OrderID:        order.ID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		TrackingUrl:    trackingURL,
+		CreatedBy:      adminActor(c),
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin shipment transaction", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not record the shipment"))
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	moved, err := recordShipment(ctx, queries, order, shipment, lines, adminActor(c), "Shipment recorded")
+	if errors.Is(err, errOrderStatusChanged) {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "The order changed in the meantime; try again"))
+	}
+	if err != nil {
+		slog.Error("failed to record shipment", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not record the shipment"))
+	}
+	// The order keeps the latest tracking, which is what its older pages show
+	if trackingNumber != "" {
+		if _, err := queries.UpdateOrderTracking(ctx, db.UpdateOrderTrackingParams{
+			ID:             order.ID,
+			TrackingNumber: sql.NullString{String: trackingNumber, Valid: true},
+			TrackingUrl:    sql.NullString{String: trackingURL, Valid: trackingURL != ""},
+			Carrier:        sql.NullString{String: carrier, Valid: carrier != ""},
+		}); err != nil {
+			slog.Error("failed to save order tracking", "error", err, "order_id", order.ID)
+			return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not record the shipment"))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit shipment", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not record the shipment"))
+	}
+
+	slog.Info("shipment recorded", "order_id", order.ID, "shipment_id", shipment.ID, "lines", len(lines), "status", moved, "by", adminActor(c))
+	h.afterShipment(ctx, order.ID, shipment.ID, moved, "")
+
+	return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "Shipment recorded", ""))
+}
+
+// afterShipment emails the customer a shipment's tracking and sets off what the
+// order's new status does, if it moved
+func (h *AdminHandler) afterShipment(ctx context.Context, orderID, shipmentID, moved, locationID string) {
+	if moved != "" {
+		h.afterOrderStatusChange(ctx, orderID, moved, locationID)
+	}
+	go h.notifyShipment(orderID, shipmentID)
+}
+
+// notifyShipment emails the customer what's in a shipment, its tracking, and what's
+// still to come
+func (h *AdminHandler) notifyShipment(orderID, shipmentID string) {
+	ctx := context.Background()
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order for shipment email", "error", err, "order_id", orderID)
+		return
+	}
+	shipments, err := h.storage.Queries.ListOrderShipments(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load shipments for shipment email", "error", err, "order_id", orderID)
+		return
+	}
+	shipmentItems, err := h.storage.Queries.ListOrderShipmentItems(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load shipment items for shipment email", "error", err, "order_id", orderID)
+		return
+	}
+	items, err := h.storage.Queries.ListOrderItemsToShip(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order items for shipment email", "error", err, "order_id", orderID)
+		return
+	}
+
+	data := &email.OrderShipmentData{
+		OrderID:       order.ID,
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+	}
+	for _, shipment := range shipments {
+		if shipment.ID == shipmentID {
+			data.Carrier = shipment.Carrier
+			data.TrackingNumber = shipment.TrackingNumber
+			data.TrackingURL = shipment.TrackingUrl
+		}
+	}
+	for _, item := range shipmentItems {
+		if item.ShipmentID == shipmentID {
+			data.Items = append(data.Items, email.OrderShipmentItem{ProductName: item.ProductName, Quantity: item.Quantity})
+		}
+	}
+	for _, item := range items {
+		if left := unshipped(item); left > 0 {
+			data.Remaining = append(data.Remaining, email.OrderShipmentItem{ProductName: item.ProductName, Quantity: left})
+		}
+	}
+
+	if err := h.emailService.SendOrderShipment(data); err != nil {
+		if !errors.Is(err, email.ErrOptedOut) {
+			slog.Error("failed to send order shipment email", "error", err, "order_id", orderID, "shipment_id", shipmentID)
+		}
+		return
+	}
+	if err := h.storage.Queries.MarkOrderShipmentNotified(ctx, shipmentID); err != nil {
+		slog.Error("failed to mark shipment notified", "error", err, "order_id", orderID, "shipment_id", shipmentID)
+	}
+}
+
+// orderShipments gathers what the order page's shipments card shows
+func (h *AdminHandler) orderShipments(ctx context.Context, order db.Order) admin.OrderShipmentsData {
+	var data admin.OrderShipmentsData
+	var err error
+	if data.Shipments, err = h.storage.Queries.ListOrderShipments(ctx, order.ID); err != nil {
+		slog.Error("failed to list order shipments", "error", err, "order_id", order.ID)
+	}
+	if data.Items, err = h.storage.Queries.ListOrderShipmentItems(ctx, order.ID); err != nil {
+		slog.Error("failed to list order shipment items", "error", err, "order_id", order.ID)
+	}
+	if data.ToShip, err = h.storage.Queries.ListOrderItemsToShip(ctx, order.ID); err != nil {
+		slog.Error("failed to list order items to ship", "error", err, "order_id", order.ID)
+	}
+	status := order.Status.String
+	data.CanShip = status == utils.OrderShipped || utils.CanMoveOrder(status, utils.OrderShipped)
+	return data
+}
+
+// HandleShipmentStatus moves one box of an order to in transit, delivered or problem.
+// Once every box is delivered and nothing is left to send, the order moves to
+// delivered. The customer is emailed when a box is delivered.
+func (h *AdminHandler) HandleShipmentStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	orderID := c.Param("id")
+	shipmentID := c.Param("shipmentID")
+
+	status := c.FormValue("status")
+	if !utils.ValidShipmentStatus(status) {
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "Choose a status for the box"))
+	}
+	note := strings.TrimSpace(c.FormValue("note"))
+	if status == utils.ShipmentProblem && note == "" {
+		return c.Redirect(http.StatusSeeOther, orderURL(orderID, "", "Say what went wrong with the box"))
+	}
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch order for shipment status", "error", err, "order_id", orderID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load order")
+	}
+	shipments, err := h.storage.Queries.ListOrderShipments(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to fetch shipments for shipment status", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not update the box"))
+	}
+	var previous string
+	for _, shipment := range shipments {
+		if shipment.ID == shipmentID {
+			previous = shipment.Status
+		}
+	}
+	if previous == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "Shipment not found")
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin shipment status transaction", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not update the box"))
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	if _, err := queries.UpdateOrderShipmentStatus(ctx, db.UpdateOrderShipmentStatusParams{
+		Status:     status,
+		StatusNote: note,
+		ID:         shipmentID,
+		OrderID:    order.ID,
+	}); err != nil {
+		slog.Error("failed to update shipment status", "error", err, "order_id", order.ID, "shipment_id", shipmentID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not update the box"))
+	}
+	moved, err := deliverIfAllArrived(ctx, queries, order, adminActor(c))
+	if errors.Is(err, errOrderStatusChanged) {
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "The order changed in the meantime; try again"))
+	}
+	if err != nil {
+		slog.Error("failed to move order to delivered", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not update the box"))
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit shipment status", "error", err, "order_id", order.ID)
+		return c.Redirect(http.StatusSeeOther, orderURL(order.ID, "", "Could not update the box"))
+	}
+
+	slog.Info("shipment status updated", "order_id", order.ID, "shipment_id", shipmentID, "from", previous, "to", status, "order_status", moved, "by", adminActor(c))
+	if moved != "" {
+		h.afterOrderStatusChange(ctx, order.ID, moved, "")
+	}
+	if status == utils.ShipmentDelivered && previous != utils.ShipmentDelivered {
+		go h.notifyShipmentDelivered(order.ID, shipmentID)
+	}
+
+	flash := "Box marked " + strings.ToLower(utils.ShipmentStatusLabel(status))
+	if moved != "" {
+		flash = "Every box has arrived; the order is delivered"
+	}
+	return c.Redirect(http.StatusSeeOther, orderURL(order.ID, flash, ""))
+}
+
+// deliverIfAllArrived moves a shipped order to delivered once every one of its boxes
+// has been. It returns the status the order moved to, or "" when it stayed put.
+func deliverIfAllArrived(ctx context.Context, queries *db.Queries, order db.Order, changedBy string) (string, error) {
+	if order.Status.String != utils.OrderShipped {
+		return "", nil
+	}
+	shipments, err := queries.ListOrderShipments(ctx, order.ID)
+	if err != nil {
+		return "", fmt.Errorf("load shipments: %w", err)
+	}
+	items, err := queries.ListOrderItemsToShip(ctx, order.ID)
+	if err != nil {
+		return "", fmt.Errorf("load shipped quantities: %w", err)
+	}
+	statuses := make([]string, 0, len(shipments))
+	for _, shipment := range shipments {
+		statuses = append(statuses, shipment.Status)
+	}
+	if !utils.AllDelivered(statuses, shippingLines(items)) {
+		return "", nil
+	}
+	if err := moveOrderStatus(ctx, queries, order.ID, order.Status.String, utils.OrderDelivered, changedBy, "Every box delivered"); err != nil {
+		return "", err
+	}
+	return utils.OrderDelivered, nil
+}
+
+// notifyShipmentDelivered emails the customer that a box has arrived, with what's
+// still on its way or yet to ship
+func (h *AdminHandler) notifyShipmentDelivered(orderID, shipmentID string) {
+	ctx := context.Background()
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order for delivery email", "error", err, "order_id", orderID)
+		return
+	}
+	shipments, err := h.storage.Queries.ListOrderShipments(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load shipments for delivery email", "error", err, "order_id", orderID)
+		return
+	}
+	shipmentItems, err := h.storage.Queries.ListOrderShipmentItems(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load shipment items for delivery email", "error", err, "order_id", orderID)
+		return
+	}
+	items, err := h.storage.Queries.ListOrderItemsToShip(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order items for delivery email", "error", err, "order_id", orderID)
+		return
+	}
+
+	data := &email.OrderShipmentData{
+		OrderID:       order.ID,
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+	}
+	arrived := make(map[string]bool, len(shipments))
+	for _, shipment := range shipments {
+		arrived[shipment.ID] = shipment.Status == utils.ShipmentDelivered
+		if shipment.ID == shipmentID {
+			data.Carrier = shipment.Carrier
+			data.TrackingNumber = shipment.TrackingNumber
+			data.TrackingURL = shipment.TrackingUrl
+		}
+	}
+	// Still to come is what's in boxes that haven't arrived, and what hasn't shipped
+	coming := make(map[string]int64)
+	var names []string
+	for _, item := range shipmentItems {
+		if item.ShipmentID == shipmentID {
+			data.Items = append(data.Items, email.OrderShipmentItem{ProductName: item.ProductName, Quantity: item.Quantity})
+		}
+		if !arrived[item.ShipmentID] {
+			if coming[item.ProductName] == 0 {
+				names = append(names, item.ProductName)
+			}
+			coming[item.ProductName] += item.Quantity
+		}
+	}
+	for _, item := range items {
+		if left := unshipped(item); left > 0 {
+			if coming[item.ProductName] == 0 {
+				names = append(names, item.ProductName)
+			}
+			coming[item.ProductName] += left
+		}
+	}
+	for _, name := range names {
+		data.Remaining = append(data.Remaining, email.OrderShipmentItem{ProductName: name, Quantity: coming[name]})
+	}
+
+	if err := h.emailService.SendOrderShipmentDelivered(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send shipment delivered email", "error", err, "order_id", orderID, "shipment_id", shipmentID)
+	}
+}
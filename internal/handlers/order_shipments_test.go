@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestPlanShipment(t *testing.T) {
+	items := []db.ListOrderItemsToShipRow{
+		{ID: "a", ProductName: "Dragon", Quantity: 3, ShippedQuantity: 1},
+		{ID: "b", ProductName: "Vase", Quantity: 2, RefundedQuantity: 1},
+		{ID: "c", ProductName: "Golem", Quantity: 1, ShippedQuantity: 1},
+	}
+
+	lines, problem := planShipment(items, nil)
+	require.Empty(t, problem)
+	require.Len(t, lines, 2, "everything left, skipping the line already sent")
+	assert.Equal(t, int64(2), lines[0].Quantity)
+	assert.Equal(t, int64(1), lines[1].Quantity, "the refunded vase isn't sent")
+
+	lines, problem = planShipment(items, map[string]int64{"a": 1})
+	require.Empty(t, problem)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "a", lines[0].Item.ID)
+	assert.Equal(t, int64(1), lines[0].Quantity)
+
+	_, problem = planShipment(items, map[string]int64{"a": 3})
+	assert.Equal(t, "Only 2 of Dragon left to ship", problem)
+
+	_, problem = planShipment(items, map[string]int64{})
+	assert.Equal(t, "Choose how many of each item are in the box", problem)
+
+	_, problem = planShipment([]db.ListOrderItemsToShipRow{{ID: "c", Quantity: 1, ShippedQuantity: 1}}, nil)
+	assert.Equal(t, "Nothing is left of this order to ship", problem)
+}
+
+func TestBoxFromQuery(t *testing.T) {
+	query := func(raw string) echo.Context {
+		return echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/admin/orders/o/shipping/rates?"+raw, nil), httptest.NewRecorder())
+	}
+
+	box, problem := boxFromQuery(query(""))
+	assert.Nil(t, box)
+	assert.Empty(t, problem)
+
+	box, problem = boxFromQuery(query("weight_lb=1.5&length=10&width=8&height=4"))
+	require.Empty(t, problem)
+	require.NotNil(t, box)
+	assert.Equal(t, 1.5, box.Weight.Value)
+	assert.Equal(t, "pound", box.Weight.Unit)
+	assert.Equal(t, 4.0, box.Dimensions.Height)
+
+	_, problem = boxFromQuery(query("weight_lb=1.5"))
+	assert.Equal(t, "Enter the box's weight, length, width and height", problem)
+
+	_, problem = boxFromQuery(query("weight_lb=0&length=10&width=8&height=4"))
+	assert.Equal(t, "Enter the box's weight and size as positive numbers", problem)
+}
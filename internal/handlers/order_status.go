@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/sms"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+var (
+	// errOrderStatusMove is a status change the order workflow doesn't allow
+	errOrderStatusMove = errors.New("order can't be moved to that status")
+	// errOrderStatusChanged means the order left the status the change was made from
+	errOrderStatusChanged = errors.New("order status changed in the meantime")
+)
+
+// setOrderStatus moves an order from one status to another and records the change in
+// its history. It doesn't check the workflow allows the move; moveOrderStatus does.
+// changedBy is the email of the admin, or the customer, who made the change, or empty
+// for the shop itself.
+func setOrderStatus(ctx context.Context, queries *db.Queries, orderID, from, to, changedBy, note string) error {
+	moved, err := queries.MoveOrderStatus(ctx, db.MoveOrderStatusParams{
+		ToStatus:   to,
+		ID:         orderID,
+		FromStatus: from,
+	})
+	if err != nil {
+		return err
+	}
+	if moved == 0 {
+		return errOrderStatusChanged
+	}
+	return queries.CreateOrderStatusChange(ctx, db.CreateOrderStatusChangeParams{
+		ID:         uuid.New().String(),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		Note:       note,
+		ChangedBy:  changedBy,
+	})
+}
+
+// moveOrderStatus is setOrderStatus for the moves the order workflow allows
+func moveOrderStatus(ctx context.Context, queries *db.Queries, orderID, from, to, changedBy, note string) error {
+	if !utils.CanMoveOrder(from, to) {
+		return fmt.Errorf("%w: %s to %s", errOrderStatusMove, from, to)
+	}
+	return setOrderStatus(ctx, queries, orderID, from, to, changedBy, note)
+}
+
+// restockCancelledOrder puts the units a cancelled order took off the sellable count
+// back on it
+func restockCancelledOrder(ctx context.Context, queries *db.Queries, orderID string) error {
+	items, err := queries.ListOrderItemsToRestock(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		if err := addAvailableStock(ctx, queries, item.ProductID, item.ProductSkuID, item.Quantity); err != nil {
+			return fmt.Errorf("restock %s: %w", item.ProductID, err)
+		}
+	}
+	return nil
+}
+
+// afterOrderStatusChange does what a new status sets off once it's saved: a shipped
+// order is taken from location stock and the customer told, a delivered one has its
+// boxes marked delivered and is texted, and a cancelled one emailed. A partially
+// shipped order is taken from location stock whole, from where its first box left;
+// the rest waits for the order to be shipped.
+// locationID is where a shipped order left from, or empty for the default.
+func (h *AdminHandler) afterOrderStatusChange(ctx context.Context, orderID, to, locationID string) {
+	switch to {
+	case utils.OrderPartiallyShipped:
+		if err := h.fulfillOrderFromLocation(ctx, orderID, locationID); err != nil {
+			slog.Error("failed to take shipped order from location stock", "error", err, "order_id", orderID, "location_id", locationID)
+		}
+	case utils.OrderShipped:
+		if err := h.fulfillOrderFromLocation(ctx, orderID, locationID); err != nil {
+			slog.Error("failed to take shipped order from location stock", "error", err, "order_id", orderID, "location_id", locationID)
+		}
+		go h.notifyPreorderShipped(orderID)
+		go h.notifyGiftRecipient(orderID)
+		go textOrderUpdate(h.storage.Queries, h.smsService, orderID, sms.EventShipped)
+	case utils.OrderDelivered:
+		// Boxes still shown in transit arrived with the order
+		if err := h.storage.Queries.MarkOrderShipmentsDelivered(ctx, orderID); err != nil {
+			slog.Error("failed to mark shipments delivered", "error", err, "order_id", orderID)
+		}
+		go textOrderUpdate(h.storage.Queries, h.smsService, orderID, sms.EventDelivered)
+	case utils.OrderCancelled:
+		go h.notifyOrderCancelled(orderID)
+	}
+}
+
+// orderCancelledData gathers what the order cancelled emails show
+func orderCancelledData(ctx context.Context, queries *db.Queries, orderID string) (*email.OrderCancelledData, error) {
+	order, err := queries.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("load order: %w", err)
+	}
+	items, err := queries.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("load order items: %w", err)
+	}
+
+	data := &email.OrderCancelledData{
+		OrderID:       order.ID,
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, email.OrderCancelledItem{
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		})
+	}
+	return data, nil
+}
+
+// notifyOrderCancelled emails the customer that their order was cancelled
+func (h *AdminHandler) notifyOrderCancelled(orderID string) {
+	data, err := orderCancelledData(context.Background(), h.storage.Queries, orderID)
+	if err != nil {
+		slog.Error("failed to load order for cancellation email", "error", err, "order_id", orderID)
+		return
+	}
+	if err := h.emailService.SendOrderCancelled(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send order cancelled email", "error", err, "order_id", orderID)
+	}
+}
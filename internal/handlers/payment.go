@@ -4,17 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
+	"github.com/loganlanou/logans3d-v4/internal/invoices"
 	"github.com/loganlanou/logans3d-v4/internal/meta"
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+	"github.com/loganlanou/logans3d-v4/internal/policies"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/sms"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
 	"github.com/loganlanou/logans3d-v4/internal/stripe"
 	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage/db"
@@ -28,6 +38,10 @@ type PaymentHandler struct {
 	stripeService *stripe.StripeService
 	queries       *db.Queries
 	emailService  *email.Service
+	smsService    *sms.Service
+	giftCards     *giftcards.Ledger
+	storeCredit   *storecredit.Ledger
+	reservations  *inventory.Reservations
 }
 
 func NewPaymentHandler(queries *db.Queries, emailService *email.Service) *PaymentHandler {
@@ -35,6 +49,10 @@ func NewPaymentHandler(queries *db.Queries, emailService *email.Service) *Paymen
 		stripeService: stripe.NewStripeService(),
 		queries:       queries,
 		emailService:  emailService,
+		smsService:    sms.NewService(queries),
+		giftCards:     giftcards.NewLedger(queries),
+		storeCredit:   storecredit.NewLedger(queries),
+		reservations:  inventory.NewReservations(queries),
 	}
 }
 
@@ -126,6 +144,24 @@ func (h *PaymentHandler) HandleWebhook(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusBadRequest, "Error parsing webhook JSON")
 		}
 
+		// Event tickets are booked against a registration rather than creating an order
+		if registrationID := session.Metadata["event_registration_id"]; registrationID != "" {
+			if err := h.handleEventCheckoutCompleted(c.Request().Context(), registrationID, &session); err != nil {
+				slog.Error("error handling event checkout", "error", err, "session_id", session.ID)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process checkout")
+			}
+			break
+		}
+
+		// Payment links for changes to an order pay for the change, not a new order
+		if adjustmentID := session.Metadata["order_adjustment_id"]; adjustmentID != "" {
+			if err := h.handleOrderAdjustmentPaid(c.Request().Context(), adjustmentID, &session); err != nil {
+				slog.Error("error handling order adjustment payment", "error", err, "session_id", session.ID)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process checkout")
+			}
+			break
+		}
+
 		// Handle successful checkout - create order and send emails
 		if err := h.handleCheckoutCompleted(c, &session); err != nil {
 			slog.Error("error handling checkout completed", "error", err, "session_id", session.ID)
@@ -133,12 +169,50 @@ func (h *PaymentHandler) HandleWebhook(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process checkout")
 		}
 
+	case "checkout.session.expired":
+		var session stripego.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			slog.Error("error parsing checkout session", "error", err)
+			return echo.NewHTTPError(http.StatusBadRequest, "Error parsing webhook JSON")
+		}
+		if registrationID := session.Metadata["event_registration_id"]; registrationID != "" {
+			h.handleEventCheckoutExpired(c.Request().Context(), registrationID)
+			break
+		}
+		// Payment link sessions expire without affecting the link, which stays open
+		if session.Metadata["order_adjustment_id"] != "" {
+			break
+		}
+		if session.Metadata["gift_card_id"] != "" {
+			h.releaseGiftCardHolds(c.Request().Context(), session.ID)
+		}
+		if session.Metadata["store_credit_cents"] != "" {
+			h.releaseStoreCreditHolds(c.Request().Context(), session.ID)
+		}
+		h.releaseInventoryHolds(c.Request().Context(), session.ID)
+		// The customer started checkout again, so the cart's newer checkout is open
+		if session.Metadata[stripe.ReplacedCheckoutKey] != "" {
+			break
+		}
+		if err := h.handleCartCheckoutExpired(c.Request().Context(), &session); err != nil {
+			slog.Error("error handling expired checkout", "error", err, "session_id", session.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process expired checkout")
+		}
+
 	case "payment_intent.succeeded":
 		var paymentIntent stripego.PaymentIntent
 		if err := json.Unmarshal(event.Data.Raw, &paymentIntent); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Error parsing webhook JSON")
 		}
 		slog.Info("payment intent succeeded", "payment_intent_id", paymentIntent.ID)
+		// Payments taken on our own payment page make their order here, as Checkout's do
+		// on checkout.session.completed
+		if draftID := paymentIntent.Metadata["checkout_draft_id"]; draftID != "" {
+			if err := h.HandleCheckoutDraftPaid(c, draftID, &paymentIntent); err != nil {
+				slog.Error("error handling checkout draft payment", "error", err, "draft_id", draftID, "payment_intent_id", paymentIntent.ID)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to process payment")
+			}
+		}
 
 	case "payment_intent.payment_failed":
 		var paymentIntent stripego.PaymentIntent
@@ -239,19 +313,26 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 		slog.Warn("stripe session has no metadata", "order_id", orderID)
 	}
 
+	// Digital-only orders are delivered by download and never had a shipping selection
+	digitalOnly := session.Metadata["digital_only"] == "true"
+
 	// Try to get EasyPost shipment ID and shipping costs from session shipping selection
 	easypostShipmentID := sql.NullString{}
 	var sessionShippingSelection db.SessionShippingSelection
 	shippingCents := int64(0)
 	hasShippingSelection := false
 
-	if sessionID != "" {
+	if sessionID != "" && !digitalOnly {
 		shippingSelection, err := h.queries.GetSessionShippingSelection(ctx, sessionID)
 		if err == nil && shippingSelection.ShipmentID != "" {
 			sessionShippingSelection = shippingSelection
 			hasShippingSelection = true
 			easypostShipmentID = sql.NullString{String: shippingSelection.ShipmentID, Valid: true}
 			shippingCents = shippingSelection.PriceCents
+			if session.Metadata["free_shipping"] == "true" {
+				// The label is still bought at this rate; the customer just wasn't charged for it
+				shippingCents = 0
+			}
 			slog.Info("order linked to EasyPost shipment",
 				"shipment_id", shippingSelection.ShipmentID,
 				"order_id", orderID,
@@ -265,25 +346,27 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 
 	// Get billing and shipping addresses
 	billingAddress := session.CustomerDetails.Address
-	shippingAddress := session.ShippingDetails.Address
+	var shippingAddress *stripego.Address
+	if session.ShippingDetails != nil {
+		shippingAddress = session.ShippingDetails.Address
+	}
 	if shippingAddress == nil {
 		shippingAddress = billingAddress // Fallback to billing if no shipping address
 	}
 
-	// Calculate amounts (Stripe amounts are in cents)
-	totalCents := session.AmountTotal
-	taxCents := int64(0)
-	if session.TotalDetails != nil && session.TotalDetails.AmountTax != 0 {
-		taxCents = session.TotalDetails.AmountTax
-	}
+	// Calculate amounts (Stripe amounts are in cents). Orders are kept in USD even
+	// when the customer paid in their own currency.
+	amounts := stripe.SessionAmountsUSD(session)
+	totalCents := amounts.TotalCents
+	taxCents := amounts.TaxCents
 
 	// Get discount amount from Stripe
 	discountCents := int64(0)
 	promotionCode := sql.NullString{}
 	promotionCodeID := sql.NullString{}
 
-	if session.TotalDetails != nil && session.TotalDetails.AmountDiscount != 0 {
-		discountCents = session.TotalDetails.AmountDiscount
+	if amounts.DiscountCents != 0 {
+		discountCents = amounts.DiscountCents
 		slog.Info("discount applied to order", "discount_cents", discountCents, "order_id", orderID)
 
 		// Try to get the promotion code from the session
@@ -369,9 +452,30 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 		}
 	}
 
+	// A code entered in the cart reaches Stripe as a one-off coupon rather than a
+	// promotion code, so it's named in the metadata instead
+	if id := session.Metadata["promotion_code_id"]; id != "" && !promotionCodeID.Valid {
+		promotionCodeID = sql.NullString{String: id, Valid: true}
+		promotionCode = sql.NullString{String: session.Metadata["promotion_code"], Valid: session.Metadata["promotion_code"] != ""}
+	}
+
+	// A gift card or store credit reaches Stripe inside the checkout's coupon, but it
+	// pays for the order rather than discounting it, so it's taken back out of the discount
+	giftCardID := session.Metadata["gift_card_id"]
+	var giftCardCents int64
+	if giftCardID != "" {
+		giftCardCents, _ = strconv.ParseInt(session.Metadata["gift_card_cents"], 10, 64)
+		discountCents = max(0, discountCents-giftCardCents)
+	}
+	storeCreditCents, _ := strconv.ParseInt(session.Metadata["store_credit_cents"], 10, 64)
+	discountCents = max(0, discountCents-storeCreditCents)
+
+	// The carbon offset is a line of its own, kept out of the subtotal like shipping
+	carbonOffsetCents, _ := strconv.ParseInt(session.Metadata["carbon_offset_cents"], 10, 64)
+
 	// Calculate subtotal excluding shipping (since we track shipping separately)
-	// This is the discounted subtotal
-	subtotalCents := totalCents - taxCents - shippingCents
+	// This is the discounted subtotal, including what the gift card and store credit paid
+	subtotalCents := totalCents - taxCents - shippingCents - carbonOffsetCents + giftCardCents + storeCreditCents
 
 	// Calculate original subtotal (before discount)
 	originalSubtotalCents := subtotalCents + discountCents
@@ -385,10 +489,19 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 		return fmt.Errorf("failed to check existing order: %w", existErr)
 	}
 
+	gift := utils.GiftOptionsFromMetadata(session.Metadata)
+
+	// An order paid in full by gift card never had a payment intent
+	var paymentIntentID string
+	if session.PaymentIntent != nil {
+		paymentIntentID = session.PaymentIntent.ID
+	}
+
 	// Create order
 	_, createErr := h.queries.CreateOrder(ctx, db.CreateOrderParams{
 		ID:                      orderID,
 		UserID:                  userID, // Set from metadata if user was authenticated
+		GuestSessionID:          sql.NullString{String: sessionID, Valid: userID == "" && sessionID != ""},
 		CustomerEmail:           customerEmail,
 		CustomerName:            customerName,
 		CustomerPhone:           sql.NullString{String: session.CustomerDetails.Phone, Valid: session.CustomerDetails.Phone != ""},
@@ -406,12 +519,17 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 		DiscountCents:           sql.NullInt64{Int64: discountCents, Valid: discountCents > 0},
 		PromotionCode:           promotionCode,
 		PromotionCodeID:         promotionCodeID,
-		StripePaymentIntentID:   sql.NullString{String: session.PaymentIntent.ID, Valid: true},
+		StripePaymentIntentID:   sql.NullString{String: paymentIntentID, Valid: paymentIntentID != ""},
 		StripeCustomerID:        sql.NullString{String: session.Customer.ID, Valid: true},
 		StripeCheckoutSessionID: sql.NullString{String: session.ID, Valid: true},
 		EasypostShipmentID:      easypostShipmentID,
-		Status:                  sql.NullString{String: "received", Valid: true},
+		Status:                  sql.NullString{String: utils.OrderReceived, Valid: true},
 		Notes:                   sql.NullString{},
+		CustomerNotes:           session.Metadata["order_notes"],
+		IsGift:                  gift.IsGift,
+		GiftMessage:             gift.Message,
+		GiftRecipientName:       gift.RecipientName,
+		GiftRecipientEmail:      gift.RecipientEmail,
 	})
 	if createErr != nil {
 		return fmt.Errorf("failed to create order: %w", createErr)
@@ -419,6 +537,82 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 
 	slog.Info("order created successfully", "order_id", orderID)
 
+	if err := h.queries.CreateOrderStatusChange(ctx, db.CreateOrderStatusChangeParams{
+		ID:       uuid.New().String(),
+		OrderID:  orderID,
+		ToStatus: utils.OrderReceived,
+		Note:     "Paid through Stripe",
+	}); err != nil {
+		slog.Error("failed to record order placed in status history", "error", err, "order_id", orderID)
+	}
+
+	if amounts.Converted() {
+		if err := h.queries.SetOrderPaymentCurrency(ctx, db.SetOrderPaymentCurrencyParams{
+			PaymentCurrency: amounts.Currency,
+			PaymentFxRate:   amounts.FxRate,
+			ID:              orderID,
+		}); err != nil {
+			// Refunds of this order would go out in the wrong currency; log loudly but
+			// keep the order
+			slog.Error("failed to record order payment currency", "error", err, "order_id", orderID, "currency", amounts.Currency)
+		}
+	}
+
+	// The customer asked at checkout for texts about this order
+	if phone := sms.ConsentFromMetadata(session.Metadata); phone != "" {
+		if err := h.queries.SetOrderSMSConsent(ctx, db.SetOrderSMSConsentParams{
+			SmsPhone: phone,
+			ID:       orderID,
+		}); err != nil {
+			slog.Error("failed to record order sms consent", "error", err, "order_id", orderID)
+		}
+	}
+
+	if carbonOffsetCents > 0 {
+		if err := h.queries.SetOrderCarbonOffset(ctx, db.SetOrderCarbonOffsetParams{
+			CarbonOffsetCents: carbonOffsetCents,
+			ID:                orderID,
+		}); err != nil {
+			// The subtotal already leaves the offset out; it just won't be on the report
+			slog.Error("failed to record order carbon offset", "error", err, "order_id", orderID, "carbon_offset_cents", carbonOffsetCents)
+		}
+	}
+
+	// The terms and policies the customer agreed to when checkout started, kept for
+	// disputes
+	if documentIDs := policies.AcceptanceFromMetadata(session.Metadata); len(documentIDs) > 0 {
+		if err := policies.NewLibrary(h.queries).Record(ctx, orderID, documentIDs, time.Unix(session.Created, 0)); err != nil {
+			slog.Error("failed to record order policy acceptance", "error", err, "order_id", orderID, "documents", documentIDs)
+		}
+	}
+
+	// Uses count against the code's and campaign's limits when the next code is checked
+	if promotionCodeID.Valid {
+		if err := h.queries.MarkPromotionCodeUsed(ctx, promotionCodeID.String); err != nil {
+			slog.Error("failed to record promotion code use", "error", err, "promotion_code_id", promotionCodeID.String, "order_id", orderID)
+		}
+		if err := h.queries.IncrementPromotionCampaignUses(ctx, promotionCodeID.String); err != nil {
+			slog.Error("failed to record promotion campaign use", "error", err, "promotion_code_id", promotionCodeID.String, "order_id", orderID)
+		}
+	}
+
+	if giftCardID != "" && giftCardCents > 0 {
+		h.recordGiftCardPayment(ctx, orderID, session.ID, giftCardID, giftCardCents)
+	}
+	// Checkout records the tax it works out itself, but not tax worked out beforehand
+	// for a gift card to pay
+	if calculationID := session.Metadata[stripe.TaxCalculationKey]; calculationID != "" {
+		if _, err := stripe.RecordDraftTax(calculationID, session.ID); err != nil {
+			slog.Error("failed to record tax transaction for checkout session", "error", err, "session_id", session.ID, "tax_calculation_id", calculationID)
+		}
+	}
+	if storeCreditCents > 0 {
+		h.recordStoreCreditPayment(ctx, orderID, session.ID, storeCreditCents)
+	}
+
+	// Attribute the order to an abandoned cart recovery, if there was one
+	h.attributeAbandonedCartRecovery(ctx, orderID, sessionID, userID, customerEmail, promotionCodeID)
+
 	// Create order_shipping_selection record if we have shipping data
 	if hasShippingSelection {
 		_, shippingSelErr := h.queries.CreateOrderShippingSelection(ctx, db.CreateOrderShippingSelectionParams{
@@ -459,6 +653,8 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 
 	// Get line items from session (need to expand)
 	orderItems := []email.OrderItem{}
+	var downloadLinks []email.DownloadLink
+	var limitedProducts []db.Product
 	if session.LineItems != nil {
 		for _, item := range session.LineItems.Data {
 			// Skip shipping line items
@@ -479,9 +675,25 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 					// Calculate item total (excluding tax - Stripe's AmountTotal includes tax)
 					itemTotal := item.Price.UnitAmount * item.Quantity
 
-					// Look up stock quantity to determine shipping time
-					// Calculate effective stock AFTER this order would be fulfilled
+					// Look up stock quantity and backorder settings to determine shipping time.
+					// Stock is read before this order's decrement below.
 					var stockQuantity int64
+					var backorderShipDate, preorderShipDate string
+					product, err := h.queries.GetProduct(ctx, productID)
+					if err != nil {
+						slog.Debug("failed to get product for shipping time", "error", err, "product_id", productID)
+					} else {
+						if product.PurchaseLimit > 0 {
+							limitedProducts = append(limitedProducts, product)
+						}
+						stockQuantity = product.StockQuantity.Int64
+						if product.BackorderShipDate.Valid {
+							backorderShipDate = product.BackorderShipDate.String
+						}
+						if product.PreorderShipDate.Valid {
+							preorderShipDate = product.PreorderShipDate.String
+						}
+					}
 					if skuID != "" {
 						// If SKU exists, get stock from SKU
 						sku, err := h.queries.GetProductSku(ctx, skuID)
@@ -490,18 +702,32 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 						} else {
 							stockQuantity = sku.StockQuantity.Int64
 						}
-					} else {
-						// Otherwise, get stock from product
-						product, err := h.queries.GetProduct(ctx, productID)
-						if err != nil {
-							slog.Debug("failed to get product for shipping time", "error", err, "product_id", productID)
-						} else {
-							stockQuantity = product.StockQuantity.Int64
+					}
+
+					// Units beyond available stock are backordered and carry the promised ship date.
+					// Pre-orders were sold ahead of release, so the whole line waits for the
+					// expected ship date instead.
+					isPreorder := item.Price.Product.Metadata["preorder"] == "true"
+					isDigital := item.Price.Product.Metadata["digital"] == "true"
+					backorderedQuantity := utils.BackorderQuantity(stockQuantity, item.Quantity)
+					estimatedShipDate := sql.NullString{}
+					shippingTime := utils.BackorderShippingMessage(stockQuantity, item.Quantity, backorderShipDate)
+					if isDigital {
+						backorderedQuantity = 0
+						shippingTime = utils.ShippingTimeDigital
+					} else if isPreorder {
+						backorderedQuantity = 0
+						shippingTime = utils.PreorderShippingMessage(preorderShipDate)
+						if _, ok := utils.PromisedShipDate(preorderShipDate, time.Now()); ok {
+							estimatedShipDate = sql.NullString{String: preorderShipDate, Valid: true}
+						}
+					} else if backorderedQuantity > 0 {
+						if _, ok := utils.PromisedShipDate(backorderShipDate, time.Now()); ok {
+							estimatedShipDate = sql.NullString{String: backorderShipDate, Valid: true}
 						}
 					}
 
-					// Calculate effective stock after this order - shows correct shipping time
-					// if ordering more than available (e.g., stock=2, qty=5 → effectiveStock=-3 → backordered)
+					// Effective stock after this order drives the admin "needs printing" flag
 					effectiveStock := stockQuantity - item.Quantity
 					if effectiveStock < 0 {
 						effectiveStock = 0
@@ -512,32 +738,59 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 						Quantity:      item.Quantity,
 						PriceCents:    item.Price.UnitAmount,
 						TotalCents:    itemTotal,
-						ShippingTime:  utils.ShippingTimeMessage(effectiveStock),
-						NeedsPrinting: utils.NeedsPrinting(effectiveStock),
+						ShippingTime:  shippingTime,
+						NeedsPrinting: !isDigital && utils.NeedsPrinting(effectiveStock),
+						IsPreorder:    isPreorder,
+						IsDigital:     isDigital,
 					})
 
+					// Engraving and other custom text entered in the cart, as stored JSON
+					personalization := item.Price.Product.Metadata["personalization"]
+
 					// Create order item in database - CRITICAL: Must succeed or order is corrupt
+					orderItemID := uuid.New().String()
 					_, itemErr := h.queries.CreateOrderItem(ctx, db.CreateOrderItemParams{
-						ID:              uuid.New().String(),
-						OrderID:         orderID,
-						ProductID:       productID,
-						ProductSkuID:    sql.NullString{String: skuID, Valid: skuID != ""},
-						Quantity:        item.Quantity,
-						UnitPriceCents:  item.Price.UnitAmount,
-						TotalPriceCents: itemTotal,
-						ProductName:     item.Description,
-						ProductSku:      sql.NullString{String: skuCode, Valid: skuCode != ""},
+						ID:                  orderItemID,
+						OrderID:             orderID,
+						ProductID:           productID,
+						ProductSkuID:        sql.NullString{String: skuID, Valid: skuID != ""},
+						Quantity:            item.Quantity,
+						UnitPriceCents:      item.Price.UnitAmount,
+						TotalPriceCents:     itemTotal,
+						ProductName:         item.Description,
+						ProductSku:          sql.NullString{String: skuCode, Valid: skuCode != ""},
+						BackorderedQuantity: backorderedQuantity,
+						EstimatedShipDate:   estimatedShipDate,
+						IsPreorder:          isPreorder,
+						Personalization:     sql.NullString{String: personalization, Valid: personalization != ""},
 					})
 					if itemErr != nil {
 						slog.Error("failed to create order item", "error", itemErr, "product_id", productID, "order_id", orderID)
 						return fmt.Errorf("failed to create order item for product %s: %w", productID, itemErr)
 					}
 
+					// Each gift card bought is issued and emailed to whoever it's for
+					if item.Price.Product.Metadata["gift_card"] == "true" {
+						h.issuePurchasedGiftCards(ctx, orderID, item.Quantity, item.Price.UnitAmount, customerName, customerEmail, gift)
+						continue
+					}
+
+					// Digital items have no stock; grant their downloads instead
+					if isDigital {
+						links, err := h.grantDownloads(ctx, orderID, orderItemID, productID, item.Description)
+						if err != nil {
+							// The order is already recorded, so a webhook retry wouldn't grant them either
+							slog.Error("failed to grant downloads for order item", "error", err, "order_id", orderID, "product_id", productID)
+						}
+						downloadLinks = append(downloadLinks, links...)
+						continue
+					}
+
 					// Deduct inventory - INTENTIONAL DESIGN:
 					// - Stock CAN be zero at checkout time (allows pre-orders/backorders)
 					// - The SQL query has "WHERE stock_quantity >= delta" to prevent negative stock
 					// - If stock is insufficient, the query affects 0 rows (no error, just no decrement)
-					// - Customer sees extended shipping times for zero-stock items
+					// - Customer sees extended shipping times (or the promised backorder date) for zero-stock items
 					if skuID != "" {
 						// Variant product: decrement SKU stock
 						if err := h.queries.DecrementProductSkuStock(ctx, db.DecrementProductSkuStockParams{
@@ -564,6 +817,18 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 
 	slog.Debug("order items processed", "order_id", orderID, "item_count", len(orderItems))
 
+	// The held stock has just been taken off for real
+	h.consumeInventoryHolds(ctx, orderID, session.ID)
+
+	h.flagPurchaseLimits(ctx, orderID, userID, customerEmail, limitedProducts)
+
+	// Nothing to ship for download-only orders, so they're complete once paid
+	if digitalOnly {
+		if err := moveOrderStatus(ctx, h.queries, orderID, utils.OrderReceived, utils.OrderDelivered, "", "Download-only order"); err != nil {
+			slog.Error("failed to mark digital order delivered", "error", err, "order_id", orderID)
+		}
+	}
+
 	// Prepare email data
 	emailData := &email.OrderData{
 		OrderID:       orderID,
@@ -593,17 +858,42 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 			PostalCode: billingAddress.PostalCode,
 			Country:    billingAddress.Country,
 		},
-		PaymentIntentID: session.PaymentIntent.ID,
+		PaymentIntentID:   paymentIntentID,
+		Downloads:         downloadLinks,
+		CustomerNotes:     session.Metadata["order_notes"],
+		IsGift:            gift.IsGift,
+		GiftMessage:       gift.Message,
+		GiftRecipient:     gift.RecipientName,
+		CarbonOffsetCents: carbonOffsetCents,
+	}
+
+	// The invoice PDF goes with the customer's confirmation when the site settings ask for it
+	if settings.For(h.queries).Values(ctx).AttachInvoice() {
+		if order, err := h.queries.GetOrder(ctx, orderID); err != nil {
+			slog.Error("failed to fetch order for invoice attachment", "error", err, "order_id", orderID)
+		} else if invoice, err := invoices.NewStore(h.queries).PDF(ctx, order); err != nil {
+			slog.Error("failed to render invoice for confirmation email", "error", err, "order_id", orderID)
+		} else {
+			emailData.Invoice = &email.Attachment{
+				Filename:    pdf.InvoiceFilename(order),
+				ContentType: "application/pdf",
+				Data:        invoice,
+			}
+		}
 	}
 
 	// Send customer confirmation email
-	if err := h.emailService.SendOrderConfirmation(emailData); err != nil {
+	if err := h.emailService.SendOrderConfirmation(emailData); errors.Is(err, email.ErrOptedOut) {
+		slog.Info("customer turned off order emails, confirmation not sent", "order_id", orderID)
+	} else if err != nil {
 		slog.Error("failed to send customer confirmation email", "error", err, "order_id", orderID)
 		// Don't fail the webhook if email fails
 	} else {
 		slog.Info("customer confirmation email sent", "order_id", orderID, "email", customerEmail)
 	}
 
+	go textOrderUpdate(h.queries, h.smsService, orderID, sms.EventOrderConfirmation)
+
 	// Send admin notification email
 	if err := h.emailService.SendOrderNotificationToAdmin(emailData); err != nil {
 		slog.Error("failed to send admin notification email", "error", err, "order_id", orderID)
@@ -625,7 +915,7 @@ func (h *PaymentHandler) handleCheckoutCompleted(c echo.Context, session *stripe
 		}
 		metaClient.TrackPurchase(
 			orderID,
-			float64(session.AmountTotal)/100,
+			float64(totalCents)/100,
 			"USD",
 			customerEmail,
 			metaItems,
@@ -745,3 +1035,51 @@ func (h *PaymentHandler) createExternalPromotionCode(ctx context.Context, code s
 	slog.Info("created external promotion code", "code", code, "promotion_code_id", promoCode.ID, "campaign_id", campaign.ID)
 	return &promoCode, nil
 }
+
+// attributeAbandonedCartRecovery links a new order to the customer's most recent
+// abandoned cart. The recovery method is the attempt whose promo code was redeemed,
+// otherwise the last recovery email sent, otherwise "organic".
+func (h *PaymentHandler) attributeAbandonedCartRecovery(ctx context.Context, orderID, sessionID, userID, customerEmail string, promotionCodeID sql.NullString) {
+	cart, err := h.queries.FindAbandonedCartForOrder(ctx, db.FindAbandonedCartForOrderParams{
+		SessionID:     sql.NullString{String: sessionID, Valid: sessionID != ""},
+		UserID:        sql.NullString{String: userID, Valid: userID != ""},
+		CustomerEmail: sql.NullString{String: customerEmail, Valid: customerEmail != ""},
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("failed to look up abandoned cart for order", "error", err, "order_id", orderID)
+		}
+		return
+	}
+
+	method := "organic"
+	if promotionCodeID.Valid {
+		attempt, err := h.queries.GetRecoveryAttemptByPromotionCode(ctx, db.GetRecoveryAttemptByPromotionCodeParams{
+			AbandonedCartID: cart.ID,
+			PromotionCodeID: promotionCodeID,
+		})
+		if err == nil {
+			method = attempt.AttemptType
+		}
+	}
+	if method == "organic" {
+		attempt, err := h.queries.GetLatestSentRecoveryAttempt(ctx, cart.ID)
+		if err == nil {
+			method = attempt.AttemptType
+		} else if cart.RecoveryMethod.Valid && cart.RecoveryMethod.String != "" {
+			method = cart.RecoveryMethod.String
+		}
+	}
+
+	err = h.queries.AttributeAbandonedCartToOrder(ctx, db.AttributeAbandonedCartToOrderParams{
+		RecoveryMethod:   sql.NullString{String: method, Valid: true},
+		RecoveredOrderID: sql.NullString{String: orderID, Valid: true},
+		ID:               cart.ID,
+	})
+	if err != nil {
+		slog.Error("failed to attribute order to abandoned cart", "error", err, "order_id", orderID, "cart_id", cart.ID)
+		return
+	}
+
+	slog.Info("abandoned cart converted to order", "cart_id", cart.ID, "order_id", orderID, "variant", cart.AbVariant, "recovery_method", method)
+}
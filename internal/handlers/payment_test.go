@@ -393,3 +393,82 @@ func TestBreakdownExpansion(t *testing.T) {
 		// This is why we need to expand "total_details.breakdown" explicitly
 	})
 }
+
+// TestAttributeAbandonedCartRecovery_UsesLatestAttempt tests that an order is linked to the
+// customer's abandoned cart and credited to the last recovery email sent
+func TestAttributeAbandonedCartRecovery_UsesLatestAttempt(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+
+	emailService := emailutil.NewService(queries)
+	handler := NewPaymentHandler(queries, emailService)
+	ctx := context.Background()
+
+	cart, err := queries.CreateAbandonedCart(ctx, db.CreateAbandonedCartParams{
+		ID:             ulid.Make().String(),
+		SessionID:      sql.NullString{String: "sess_recover", Valid: true},
+		CustomerEmail:  sql.NullString{String: "buyer@example.com", Valid: true},
+		CartValueCents: 2500,
+		ItemCount:      1,
+		AbandonedAt:    time.Now().Add(-26 * time.Hour),
+		Status:         sql.NullString{String: "contacted", Valid: true},
+	})
+	require.NoError(t, err)
+
+	err = queries.UpdateAbandonedCartVariant(ctx, db.UpdateAbandonedCartVariantParams{AbVariant: "B", ID: cart.ID})
+	require.NoError(t, err)
+
+	_, err = queries.CreateSequenceRecoveryAttempt(ctx, db.CreateSequenceRecoveryAttemptParams{
+		ID:              ulid.Make().String(),
+		AbandonedCartID: cart.ID,
+		AttemptType:     "email_24hr",
+		SentAt:          time.Now().Add(-time.Hour),
+		TrackingToken:   sql.NullString{String: ulid.Make().String(), Valid: true},
+		Status:          sql.NullString{String: "sent", Valid: true},
+		SequenceStepID:  sql.NullString{String: "seq-b-2", Valid: true},
+		Variant:         sql.NullString{String: "B", Valid: true},
+	})
+	require.NoError(t, err)
+
+	handler.attributeAbandonedCartRecovery(ctx, "order_123", "sess_recover", "", "buyer@example.com", sql.NullString{})
+
+	updated, err := queries.GetAbandonedCartByID(ctx, cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "recovered", updated.Status.String)
+	assert.Equal(t, "email_24hr", updated.RecoveryMethod.String)
+	assert.Equal(t, "order_123", updated.RecoveredOrderID.String)
+	assert.Equal(t, "B", updated.AbVariant)
+
+	// A second order must not re-attribute the same cart
+	handler.attributeAbandonedCartRecovery(ctx, "order_456", "sess_recover", "", "buyer@example.com", sql.NullString{})
+	again, err := queries.GetAbandonedCartByID(ctx, cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "order_123", again.RecoveredOrderID.String)
+}
+
+// TestAttributeAbandonedCartRecovery_Organic tests that carts without recovery emails are marked organic
+func TestAttributeAbandonedCartRecovery_Organic(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+
+	emailService := emailutil.NewService(queries)
+	handler := NewPaymentHandler(queries, emailService)
+	ctx := context.Background()
+
+	cart, err := queries.CreateAbandonedCart(ctx, db.CreateAbandonedCartParams{
+		ID:             ulid.Make().String(),
+		SessionID:      sql.NullString{String: "sess_organic", Valid: true},
+		CartValueCents: 1000,
+		ItemCount:      1,
+		AbandonedAt:    time.Now().Add(-2 * time.Hour),
+		Status:         sql.NullString{String: "active", Valid: true},
+	})
+	require.NoError(t, err)
+
+	handler.attributeAbandonedCartRecovery(ctx, "order_789", "sess_organic", "", "", sql.NullString{})
+
+	updated, err := queries.GetAbandonedCartByID(ctx, cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "organic", updated.RecoveryMethod.String)
+	assert.Equal(t, "order_789", updated.RecoveredOrderID.String)
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// portfolioImagesDir holds portfolio uploads; the image pipeline resizes them like
+// product images
+const portfolioImagesDir = "public/images/portfolio"
+
+var portfolioImageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+func portfolioFormURL(itemID, errorMsg string) string {
+	target := fmt.Sprintf("/admin/portfolio/%s", itemID)
+	if itemID == "" {
+		target = "/admin/portfolio/new"
+	}
+	if errorMsg != "" {
+		target += "?error=" + url.QueryEscape(errorMsg)
+	}
+	return target
+}
+
+// HandlePortfolioList shows every portfolio item, published or not
+func (h *AdminHandler) HandlePortfolioList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	items, err := h.storage.Queries.ListPortfolioItems(ctx)
+	if err != nil {
+		slog.Error("failed to list portfolio items", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load portfolio")
+	}
+
+	return Render(c, admin.PortfolioList(c, items))
+}
+
+// HandlePortfolioForm shows the portfolio item editor, with its photos and linked
+// products when editing
+func (h *AdminHandler) HandlePortfolioForm(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+
+	var item *db.PortfolioItem
+	var itemImages []db.PortfolioItemImage
+	var itemProducts []db.ListPortfolioItemProductsRow
+	if itemID != "" {
+		i, err := h.storage.Queries.GetPortfolioItem(ctx, itemID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return echo.NewHTTPError(http.StatusNotFound, "Portfolio item not found")
+			}
+			slog.Error("failed to get portfolio item", "error", err, "item_id", itemID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load portfolio item")
+		}
+		item = &i
+
+		itemImages, err = h.storage.Queries.ListPortfolioItemImages(ctx, itemID)
+		if err != nil {
+			slog.Error("failed to list portfolio images", "error", err, "item_id", itemID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load portfolio item")
+		}
+
+		itemProducts, err = h.storage.Queries.ListPortfolioItemProducts(ctx, itemID)
+		if err != nil {
+			slog.Error("failed to list portfolio products", "error", err, "item_id", itemID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load portfolio item")
+		}
+	}
+
+	products, err := h.storage.Queries.ListProducts(ctx)
+	if err != nil {
+		slog.Error("failed to list products for portfolio form", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+	}
+
+	return Render(c, admin.PortfolioForm(c, item, itemImages, itemProducts, products, c.QueryParam("error")))
+}
+
+// portfolioFormInput holds the item fields shared by create and update
+type portfolioFormInput struct {
+	Title        string
+	Slug         string
+	Description  string
+	Tags         string
+	IsPublished  bool
+	IsFeatured   bool
+	DisplayOrder int64
+}
+
+// parsePortfolioForm reads and validates the item fields, returning a message for the admin on failure
+func parsePortfolioForm(c echo.Context) (portfolioFormInput, string) {
+	title := strings.TrimSpace(c.FormValue("title"))
+	displayOrder, _ := strconv.ParseInt(c.FormValue("display_order"), 10, 64)
+
+	input := portfolioFormInput{
+		Title:        title,
+		Slug:         bundleSlug(c.FormValue("slug"), title),
+		Description:  strings.TrimSpace(c.FormValue("description")),
+		Tags:         utils.FormatPortfolioTags(utils.ParsePortfolioTags(c.FormValue("tags"))),
+		IsPublished:  c.FormValue("is_published") == "on",
+		IsFeatured:   c.FormValue("is_featured") == "on",
+		DisplayOrder: displayOrder,
+	}
+	if input.Title == "" || input.Slug == "" {
+		return input, "Title is required"
+	}
+	return input, ""
+}
+
+// HandleCreatePortfolioItem creates an item and opens it for adding photos and products
+func (h *AdminHandler) HandleCreatePortfolioItem(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	input, errMsg := parsePortfolioForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL("", errMsg))
+	}
+
+	item, err := h.storage.Queries.CreatePortfolioItem(ctx, db.CreatePortfolioItemParams{
+		ID:           uuid.New().String(),
+		Title:        input.Title,
+		Slug:         input.Slug,
+		Description:  input.Description,
+		Tags:         input.Tags,
+		IsPublished:  input.IsPublished,
+		IsFeatured:   input.IsFeatured,
+		DisplayOrder: input.DisplayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to create portfolio item", "error", err, "slug", input.Slug)
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL("", "Could not save portfolio item (is the slug already used?)"))
+	}
+
+	slog.Info("portfolio item created", "item_id", item.ID, "slug", item.Slug)
+	return c.Redirect(http.StatusSeeOther, portfolioFormURL(item.ID, ""))
+}
+
+// HandleUpdatePortfolioItem saves changes to an item's details
+func (h *AdminHandler) HandleUpdatePortfolioItem(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+
+	input, errMsg := parsePortfolioForm(c)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, errMsg))
+	}
+
+	_, err := h.storage.Queries.UpdatePortfolioItem(ctx, db.UpdatePortfolioItemParams{
+		ID:           itemID,
+		Title:        input.Title,
+		Slug:         input.Slug,
+		Description:  input.Description,
+		Tags:         input.Tags,
+		IsPublished:  input.IsPublished,
+		IsFeatured:   input.IsFeatured,
+		DisplayOrder: input.DisplayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to update portfolio item", "error", err, "item_id", itemID)
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Could not save portfolio item (is the slug already used?)"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, ""))
+}
+
+// HandleDeletePortfolioItem removes an item along with its uploaded photos
+func (h *AdminHandler) HandleDeletePortfolioItem(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+
+	itemImages, err := h.storage.Queries.ListPortfolioItemImages(ctx, itemID)
+	if err != nil {
+		slog.Error("failed to list portfolio images before delete", "error", err, "item_id", itemID)
+	}
+
+	if err := h.storage.Queries.DeletePortfolioItem(ctx, itemID); err != nil {
+		slog.Error("failed to delete portfolio item", "error", err, "item_id", itemID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete portfolio item")
+	}
+	for _, img := range itemImages {
+		removePortfolioImage(img.ImageUrl)
+	}
+
+	slog.Info("portfolio item deleted", "item_id", itemID)
+	return c.Redirect(http.StatusSeeOther, "/admin/portfolio")
+}
+
+// HandleUploadPortfolioImages adds one or more photos to the end of an item's gallery
+func (h *AdminHandler) HandleUploadPortfolioImages(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+
+	item, err := h.storage.Queries.GetPortfolioItem(ctx, itemID)
+	if err != nil {
+		slog.Error("failed to load portfolio item for upload", "error", err, "item_id", itemID)
+		return echo.NewHTTPError(http.StatusNotFound, "Portfolio item not found")
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["images"]) == 0 {
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Choose at least one photo to upload"))
+	}
+	files := form.File["images"]
+	for _, file := range files {
+		if !portfolioImageExtensions[strings.ToLower(filepath.Ext(file.Filename))] {
+			return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Photos must be JPG, PNG or WebP images"))
+		}
+	}
+
+	existing, err := h.storage.Queries.ListPortfolioItemImages(ctx, itemID)
+	if err != nil {
+		slog.Error("failed to list portfolio images", "error", err, "item_id", itemID)
+	}
+	altText := strings.TrimSpace(c.FormValue("alt_text"))
+
+	for i, file := range files {
+		// Variants are cached by filename across all upload directories, so the
+		// prefix keeps portfolio names apart from product ones
+		filename := "portfolio_" + uuid.New().String() + strings.ToLower(filepath.Ext(file.Filename))
+		if err := saveUpload(file, portfolioImagesDir, filename); err != nil {
+			slog.Error("failed to save portfolio image", "error", err, "item_id", itemID, "filename", file.Filename)
+			return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Failed to save "+file.Filename))
+		}
+
+		if _, err := h.storage.Queries.CreatePortfolioItemImage(ctx, db.CreatePortfolioItemImageParams{
+			ID:              uuid.New().String(),
+			PortfolioItemID: itemID,
+			ImageUrl:        filename,
+			AltText:         altText,
+			DisplayOrder:    int64(len(existing) + i),
+		}); err != nil {
+			slog.Error("failed to save portfolio image", "error", err, "item_id", itemID, "filename", filename)
+			os.Remove(filepath.Join(portfolioImagesDir, filename))
+			return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Failed to save "+file.Filename))
+		}
+		images.Default().GenerateInBackground(filename)
+	}
+
+	slog.Info("portfolio images uploaded", "item_id", itemID, "slug", item.Slug, "count", len(files))
+	return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, ""))
+}
+
+// HandleDeletePortfolioImage removes a photo from an item's gallery
+func (h *AdminHandler) HandleDeletePortfolioImage(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+	imageID := c.Param("imageId")
+
+	img, err := h.storage.Queries.GetPortfolioItemImage(ctx, db.GetPortfolioItemImageParams{
+		ID:              imageID,
+		PortfolioItemID: itemID,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Photo not found")
+	}
+
+	if err := h.storage.Queries.DeletePortfolioItemImage(ctx, db.DeletePortfolioItemImageParams{
+		ID:              imageID,
+		PortfolioItemID: itemID,
+	}); err != nil {
+		slog.Error("failed to delete portfolio image", "error", err, "item_id", itemID, "image_id", imageID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove photo")
+	}
+	removePortfolioImage(img.ImageUrl)
+
+	return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, ""))
+}
+
+// HandleAddPortfolioProduct links a product for the item's "shop this print" links
+func (h *AdminHandler) HandleAddPortfolioProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+
+	productID := c.FormValue("product_id")
+	if productID == "" {
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Choose a product to link"))
+	}
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		slog.Error("failed to load portfolio product", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Product not found"))
+	}
+
+	existing, err := h.storage.Queries.ListPortfolioItemProducts(ctx, itemID)
+	if err != nil {
+		slog.Error("failed to list portfolio products", "error", err, "item_id", itemID)
+	}
+
+	if err := h.storage.Queries.AddPortfolioItemProduct(ctx, db.AddPortfolioItemProductParams{
+		PortfolioItemID: itemID,
+		ProductID:       productID,
+		DisplayOrder:    int64(len(existing)),
+	}); err != nil {
+		slog.Error("failed to link portfolio product", "error", err, "item_id", itemID, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, "Could not link product"))
+	}
+
+	return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, ""))
+}
+
+// HandleRemovePortfolioProduct unlinks a product from an item
+func (h *AdminHandler) HandleRemovePortfolioProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	itemID := c.Param("id")
+	productID := c.Param("productId")
+
+	if err := h.storage.Queries.RemovePortfolioItemProduct(ctx, db.RemovePortfolioItemProductParams{
+		PortfolioItemID: itemID,
+		ProductID:       productID,
+	}); err != nil {
+		slog.Error("failed to unlink portfolio product", "error", err, "item_id", itemID, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to unlink product")
+	}
+
+	return c.Redirect(http.StatusSeeOther, portfolioFormURL(itemID, ""))
+}
+
+// removePortfolioImage deletes an uploaded photo and its resized copies
+func removePortfolioImage(filename string) {
+	if filename == "" || filename != filepath.Base(filename) {
+		return
+	}
+	if err := os.Remove(filepath.Join(portfolioImagesDir, filename)); err != nil && !os.IsNotExist(err) {
+		slog.Error("failed to remove portfolio image", "error", err, "filename", filename)
+	}
+	images.Default().Remove(filename)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// saveProductPreorderSettings applies the pre-order fields from the product form.
+// Forms that don't include the pre-order section leave the settings untouched.
+func (h *AdminHandler) saveProductPreorderSettings(c echo.Context, productID string) error {
+	if c.FormValue("preorder_settings") == "" {
+		return nil
+	}
+
+	shipDate := strings.TrimSpace(c.FormValue("preorder_ship_date"))
+	if shipDate != "" {
+		if _, err := time.Parse(utils.BackorderDateLayout, shipDate); err != nil {
+			slog.Warn("ignoring invalid pre-order ship date", "product_id", productID, "value", shipDate)
+			shipDate = ""
+		}
+	}
+
+	err := h.storage.Queries.UpdateProductPreorderSettings(c.Request().Context(), db.UpdateProductPreorderSettingsParams{
+		IsPreorder:       c.FormValue("is_preorder") == "on" || c.FormValue("is_preorder") == "true",
+		PreorderShipDate: sql.NullString{String: shipDate, Valid: shipDate != ""},
+		ID:               productID,
+	})
+	if err != nil {
+		slog.Error("failed to update product pre-order settings", "error", err, "product_id", productID)
+		return err
+	}
+	return nil
+}
+
+// preorderOrderIDs returns the IDs of orders that include pre-order items, for list badges
+func (h *AdminHandler) preorderOrderIDs(ctx context.Context) map[string]bool {
+	ids, err := h.storage.Queries.ListPreorderOrderIDs(ctx)
+	if err != nil {
+		slog.Error("failed to list pre-order order IDs", "error", err)
+		return nil
+	}
+
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// notifyPreorderShipped emails the customer when an order holding pre-order items ships,
+// then marks those items so the email is only sent once. Runs in the background after
+// the status change, so failures are logged rather than returned.
+func (h *AdminHandler) notifyPreorderShipped(orderID string) {
+	ctx := context.Background()
+
+	items, err := h.storage.Queries.ListUnshippedPreorderItems(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to list unshipped pre-order items", "error", err, "order_id", orderID)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	order, err := h.storage.Queries.GetOrder(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order for pre-order shipped email", "error", err, "order_id", orderID)
+		return
+	}
+
+	data := &email.PreorderShippedData{
+		OrderID:        order.ID,
+		CustomerName:   order.CustomerName,
+		CustomerEmail:  order.CustomerEmail,
+		Carrier:        order.Carrier.String,
+		TrackingNumber: order.TrackingNumber.String,
+		TrackingURL:    order.TrackingUrl.String,
+	}
+	for _, item := range items {
+		data.Items = append(data.Items, email.PreorderShippedItem{
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	if err := h.emailService.SendPreorderShipped(data); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send pre-order shipped email", "error", err, "order_id", orderID)
+		return
+	}
+
+	if err := h.storage.Queries.MarkPreorderItemsShipped(ctx, orderID); err != nil {
+		slog.Error("failed to mark pre-order items shipped", "error", err, "order_id", orderID)
+	}
+}
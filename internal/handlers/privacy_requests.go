@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/privacy"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+const privacyRequestsPerPage = 50
+
+// HandlePrivacyRequests shows the compliance log of data exports and account
+// deletions. The log keeps only a hash of each email, so searching by email finds an
+// address's requests without the log naming anyone.
+func (h *AdminHandler) HandlePrivacyRequests(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	view := admin.PrivacyRequestsView{
+		Email: strings.TrimSpace(c.QueryParam("email")),
+		Page:  1,
+	}
+	if view.Email != "" {
+		requests, err := h.storage.Queries.ListPrivacyRequestsByEmailHash(ctx, privacy.EmailHash(view.Email))
+		if err != nil {
+			slog.Error("failed to search privacy requests", "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load privacy requests")
+		}
+		view.Requests = requests
+		view.Total = int64(len(requests))
+		return Render(c, admin.PrivacyRequests(c, view))
+	}
+
+	if n, err := strconv.Atoi(c.QueryParam("page")); err == nil && n > 1 {
+		view.Page = n
+	}
+	total, err := h.storage.Queries.CountPrivacyRequests(ctx)
+	if err != nil {
+		slog.Error("failed to count privacy requests", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load privacy requests")
+	}
+	view.Total = total
+	view.HasMore = int64(view.Page*privacyRequestsPerPage) < total
+
+	requests, err := h.storage.Queries.ListPrivacyRequests(ctx, db.ListPrivacyRequestsParams{
+		Limit:  privacyRequestsPerPage,
+		Offset: int64((view.Page - 1) * privacyRequestsPerPage),
+	})
+	if err != nil {
+		slog.Error("failed to list privacy requests", "error", err, "page", view.Page)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load privacy requests")
+	}
+	view.Requests = requests
+
+	return Render(c, admin.PrivacyRequests(c, view))
+}
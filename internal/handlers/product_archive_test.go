@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestArchiveProduct(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+	ctx := context.Background()
+
+	product, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:         "prod-dragon",
+		Name:       "Articulated Dragon",
+		Slug:       "articulated-dragon",
+		PriceCents: 2500,
+		IsActive:   sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Only archived products can be deleted for good
+	require.NoError(t, queries.DeleteProduct(ctx, product.ID))
+	_, err = queries.GetProduct(ctx, product.ID)
+	require.NoError(t, err)
+
+	rows, err := queries.ArchiveProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	rows, err = queries.ArchiveProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), rows, "archiving twice is a no-op")
+
+	_, err = queries.GetProductBySlug(ctx, product.Slug)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "archived products are off the shop")
+	live, err := queries.ListAllProducts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, live)
+	_, err = queries.ToggleProductActive(ctx, product.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "archived products can't be put back on sale")
+
+	archived, err := queries.ListAdminProducts(ctx, db.ListAdminProductsParams{
+		Archived:   true,
+		Sort:       "name_asc",
+		PageSize:   20,
+		PageOffset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, product.ID, archived[0].ID)
+	count, err := queries.CountAdminProducts(ctx, db.CountAdminProductsParams{Archived: false})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	rows, err = queries.RestoreProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+	restored, err := queries.GetProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	assert.False(t, restored.IsActive.Bool, "restored products come back as drafts")
+
+	// A product an order included keeps its row
+	user, err := CreateTestUserWithEmail(queries, "dragon.fan@example.com")
+	require.NoError(t, err)
+	_, err = queries.CreateOrder(ctx, db.CreateOrderParams{
+		ID:            "order-dragon",
+		UserID:        user.ID,
+		CustomerEmail: "dragon.fan@example.com",
+		CustomerName:  "Dana Smith",
+		SubtotalCents: 2500,
+		TotalCents:    2500,
+	})
+	require.NoError(t, err)
+	_, err = queries.CreateOrderItem(ctx, db.CreateOrderItemParams{
+		ID:          "order-dragon-item",
+		OrderID:     "order-dragon",
+		ProductID:   product.ID,
+		Quantity:    1,
+		ProductName: product.Name,
+	})
+	require.NoError(t, err)
+	orderItems, err := queries.CountProductOrderItems(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), orderItems)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+const (
+	maxAttributeNameLength  = 60
+	maxAttributeValueLength = 200
+	maxAttributeUnitLength  = 20
+)
+
+func productAttributesURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#attributes", productID)
+}
+
+// attributeForm is a specification row as submitted from the admin product form
+type attributeForm struct {
+	Name         string
+	Value        string
+	ValueType    string
+	Unit         string
+	DisplayOrder int64
+}
+
+// parseAttributeForm reads and validates a specification row. A missing type falls
+// back to the preset for that name, so "Weight" is a number in grams unless told otherwise.
+func parseAttributeForm(c echo.Context) (attributeForm, error) {
+	form := attributeForm{
+		Name:      strings.TrimSpace(c.FormValue("name")),
+		ValueType: strings.TrimSpace(c.FormValue("value_type")),
+		Unit:      strings.TrimSpace(c.FormValue("unit")),
+	}
+	form.DisplayOrder, _ = strconv.ParseInt(c.FormValue("display_order"), 10, 64)
+
+	if form.Name == "" || len(form.Name) > maxAttributeNameLength {
+		return form, fmt.Errorf("name is required")
+	}
+	if form.ValueType == "" {
+		form.ValueType = utils.AttributeTypeText
+		for _, preset := range utils.AttributePresets {
+			if strings.EqualFold(preset.Name, form.Name) {
+				form.ValueType = preset.Type
+				if form.Unit == "" {
+					form.Unit = preset.Unit
+				}
+				break
+			}
+		}
+	}
+	if !utils.IsValidAttributeType(form.ValueType) {
+		return form, fmt.Errorf("unknown type %q", form.ValueType)
+	}
+	if len(form.Unit) > maxAttributeUnitLength {
+		return form, fmt.Errorf("unit is too long")
+	}
+
+	value, err := utils.NormalizeAttributeValue(form.ValueType, c.FormValue("value"))
+	if err != nil {
+		return form, err
+	}
+	if len(value) > maxAttributeValueLength {
+		return form, fmt.Errorf("value is too long")
+	}
+	form.Value = value
+	return form, nil
+}
+
+// HandleSaveProductAttribute adds a specification to a product, or updates it when the name already exists
+func (h *AdminHandler) HandleSaveProductAttribute(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	form, err := parseAttributeForm(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid specification: "+err.Error())
+	}
+
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		slog.Error("failed to load product for attribute", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	_, err = h.storage.Queries.UpsertProductAttribute(ctx, db.UpsertProductAttributeParams{
+		ID:           uuid.New().String(),
+		ProductID:    productID,
+		Name:         form.Name,
+		Value:        form.Value,
+		ValueType:    form.ValueType,
+		Unit:         form.Unit,
+		DisplayOrder: form.DisplayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to save product attribute", "error", err, "product_id", productID, "name", form.Name)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save specification")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productAttributesURL(productID))
+}
+
+// HandleUpdateProductAttribute saves an edited specification row, including a rename
+func (h *AdminHandler) HandleUpdateProductAttribute(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	attributeID := c.Param("attributeId")
+
+	form, err := parseAttributeForm(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid specification: "+err.Error())
+	}
+
+	_, err = h.storage.Queries.UpdateProductAttribute(ctx, db.UpdateProductAttributeParams{
+		Name:         form.Name,
+		Value:        form.Value,
+		ValueType:    form.ValueType,
+		Unit:         form.Unit,
+		DisplayOrder: form.DisplayOrder,
+		ID:           attributeID,
+		ProductID:    productID,
+	})
+	if err != nil {
+		slog.Error("failed to update product attribute", "error", err, "product_id", productID, "attribute_id", attributeID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Specification not found")
+		}
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return echo.NewHTTPError(http.StatusConflict, "This product already has a specification with that name")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save specification")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productAttributesURL(productID))
+}
+
+// HandleDeleteProductAttribute removes a specification from a product
+func (h *AdminHandler) HandleDeleteProductAttribute(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	attributeID := c.Param("attributeId")
+
+	if err := h.storage.Queries.DeleteProductAttribute(ctx, db.DeleteProductAttributeParams{
+		ID:        attributeID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to delete product attribute", "error", err, "product_id", productID, "attribute_id", attributeID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove specification")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productAttributesURL(productID))
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+const (
+	productImagesDir = "public/images/products"
+	styleImagesDir   = "public/images/products/styles"
+)
+
+// maxDuplicateCopies bounds the search for a free "(Copy N)" name
+const maxDuplicateCopies = 100
+
+// HandleDuplicateProduct clones a product with its images, styles, sizes and SKUs
+// into an inactive draft, then opens the draft for editing
+func (h *AdminHandler) HandleDuplicateProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	source, err := h.storage.Queries.GetProduct(ctx, c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	product, err := h.duplicateProduct(ctx, source)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to duplicate product")
+	}
+
+	slog.Info("product duplicated", "source_id", source.ID, "product_id", product.ID, "slug", product.Slug)
+	return c.Redirect(http.StatusSeeOther, "/admin/product/edit?id="+product.ID)
+}
+
+// duplicateProduct copies the product rows in one transaction. Image files are copied
+// alongside and removed again if the transaction doesn't commit.
+func (h *AdminHandler) duplicateProduct(ctx context.Context, source db.Product) (db.Product, error) {
+	name, slug, err := h.duplicateName(ctx, source)
+	if err != nil {
+		return db.Product{}, err
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin product duplicate transaction", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+	defer tx.Rollback()
+
+	var copiedFiles []string
+	committed := false
+	defer func() {
+		if !committed {
+			for _, path := range copiedFiles {
+				os.Remove(path)
+			}
+		}
+	}()
+
+	queries := h.storage.Queries.WithTx(tx)
+	product, err := queries.DuplicateProduct(ctx, db.DuplicateProductParams{
+		NewID:    uuid.New().String(),
+		Name:     name,
+		Slug:     slug,
+		SourceID: source.ID,
+	})
+	if err != nil {
+		slog.Error("failed to duplicate product", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+
+	productImages, err := queries.GetProductImages(ctx, source.ID)
+	if err != nil {
+		slog.Error("failed to load product images to duplicate", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+	for i, img := range productImages {
+		filename := fmt.Sprintf("%s_%d_%d%s", product.ID, time.Now().Unix(), i, filepath.Ext(img.ImageUrl))
+		if !copyDuplicateImage(productImagesDir, img.ImageUrl, filename, &copiedFiles) {
+			continue
+		}
+		if _, err := queries.CreateProductImage(ctx, db.CreateProductImageParams{
+			ID:           uuid.New().String(),
+			ProductID:    product.ID,
+			ImageUrl:     filename,
+			AltText:      img.AltText,
+			DisplayOrder: img.DisplayOrder,
+			IsPrimary:    img.IsPrimary,
+		}); err != nil {
+			slog.Error("failed to duplicate product image", "error", err, "product_id", product.ID, "image", img.ImageUrl)
+			return db.Product{}, err
+		}
+	}
+
+	styles, err := queries.GetProductStyles(ctx, source.ID)
+	if err != nil {
+		slog.Error("failed to load product styles to duplicate", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+	styleIDs := make(map[string]string, len(styles))
+	for _, style := range styles {
+		styleID := uuid.New().String()
+		if _, err := queries.CreateProductStyle(ctx, db.CreateProductStyleParams{
+			ID:           styleID,
+			ProductID:    product.ID,
+			Name:         style.Name,
+			IsPrimary:    style.IsPrimary,
+			DisplayOrder: style.DisplayOrder,
+		}); err != nil {
+			slog.Error("failed to duplicate product style", "error", err, "product_id", product.ID, "style", style.Name)
+			return db.Product{}, err
+		}
+		styleIDs[style.ID] = styleID
+
+		styleImages, err := queries.GetProductStyleImages(ctx, style.ID)
+		if err != nil {
+			slog.Error("failed to load style images to duplicate", "error", err, "style_id", style.ID)
+			return db.Product{}, err
+		}
+		for _, img := range styleImages {
+			filename := uuid.New().String() + filepath.Ext(img.ImageUrl)
+			if !copyDuplicateImage(styleImagesDir, img.ImageUrl, filename, &copiedFiles) {
+				continue
+			}
+			if _, err := queries.CreateProductStyleImage(ctx, db.CreateProductStyleImageParams{
+				ID:             uuid.New().String(),
+				ProductStyleID: styleID,
+				ImageUrl:       filename,
+				IsPrimary:      img.IsPrimary,
+				DisplayOrder:   img.DisplayOrder,
+			}); err != nil {
+				slog.Error("failed to duplicate style image", "error", err, "style_id", styleID, "image", img.ImageUrl)
+				return db.Product{}, err
+			}
+		}
+	}
+
+	sizeConfigs, err := queries.GetAllProductSizeConfigs(ctx, source.ID)
+	if err != nil {
+		slog.Error("failed to load size configs to duplicate", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+	for _, size := range sizeConfigs {
+		if _, err := queries.UpsertProductSizeConfig(ctx, db.UpsertProductSizeConfigParams{
+			ID:                   uuid.New().String(),
+			ProductID:            product.ID,
+			SizeID:               size.SizeID,
+			PriceAdjustmentCents: size.PriceAdjustmentCents,
+			IsEnabled:            size.IsEnabled,
+			DisplayOrder:         size.DisplayOrder,
+		}); err != nil {
+			slog.Error("failed to duplicate size config", "error", err, "product_id", product.ID, "size_id", size.SizeID)
+			return db.Product{}, err
+		}
+	}
+
+	skus, err := queries.GetProductSkus(ctx, source.ID)
+	if err != nil {
+		slog.Error("failed to load SKUs to duplicate", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+	for _, sku := range skus {
+		code, err := duplicateSkuCode(ctx, queries, sku.Sku)
+		if err != nil {
+			slog.Error("failed to pick SKU code for duplicate", "error", err, "sku", sku.Sku)
+			return db.Product{}, err
+		}
+		// The copy is a new listing, so it starts without stock
+		if _, err := queries.CreateProductSku(ctx, db.CreateProductSkuParams{
+			ID:                   uuid.New().String(),
+			ProductID:            product.ID,
+			ProductStyleID:       styleIDs[sku.ProductStyleID],
+			SizeID:               sku.SizeID,
+			Sku:                  code,
+			PriceAdjustmentCents: sku.PriceAdjustmentCents,
+			StockQuantity:        sql.NullInt64{Int64: 0, Valid: true},
+			IsActive:             sku.IsActive,
+		}); err != nil {
+			slog.Error("failed to duplicate SKU", "error", err, "product_id", product.ID, "sku", code)
+			return db.Product{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit product duplicate", "error", err, "product_id", source.ID)
+		return db.Product{}, err
+	}
+	committed = true
+
+	for _, path := range copiedFiles {
+		images.Default().GenerateInBackground(filepath.Base(path))
+	}
+	return product, nil
+}
+
+// duplicateName finds the first free "Name (Copy)" / "slug-copy" pair
+func (h *AdminHandler) duplicateName(ctx context.Context, source db.Product) (string, string, error) {
+	for n := 1; n <= maxDuplicateCopies; n++ {
+		name, slug := duplicateCopyName(source.Name, source.Slug, n)
+		taken, err := h.storage.Queries.ProductNameOrSlugTaken(ctx, db.ProductNameOrSlugTakenParams{
+			Name: name,
+			Slug: slug,
+		})
+		if err != nil {
+			slog.Error("failed to check duplicate product name", "error", err, "product_id", source.ID)
+			return "", "", err
+		}
+		if taken == 0 {
+			return name, slug, nil
+		}
+	}
+	return "", "", fmt.Errorf("no free copy name for product %s", source.ID)
+}
+
+// duplicateCopyName returns the name and slug for the nth copy of a product:
+// "Dragon (Copy)" and "dragon-copy", then "Dragon (Copy 2)" and "dragon-copy-2"
+func duplicateCopyName(name, slug string, n int) (string, string) {
+	if n <= 1 {
+		return name + " (Copy)", slug + "-copy"
+	}
+	return fmt.Sprintf("%s (Copy %d)", name, n), fmt.Sprintf("%s-copy-%d", slug, n)
+}
+
+// duplicateSkuCode returns the first unused "-COPY" variant of a SKU code
+func duplicateSkuCode(ctx context.Context, queries *db.Queries, sku string) (string, error) {
+	base := strings.ToUpper(sku) + "-COPY"
+	for n := 1; n <= maxDuplicateCopies; n++ {
+		code := base
+		if n > 1 {
+			code = fmt.Sprintf("%s-%d", base, n)
+		}
+		exists, err := queries.CheckSkuExists(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if exists == 0 {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("no free copy SKU for %s", sku)
+}
+
+// copyDuplicateImage copies an uploaded image within dir and records the new path.
+// A missing original is skipped rather than failing the whole duplicate.
+func copyDuplicateImage(dir, filename, newFilename string, copied *[]string) bool {
+	src, err := os.Open(filepath.Join(dir, filename))
+	if err != nil {
+		slog.Warn("skipping missing image while duplicating product", "error", err, "filename", filename)
+		return false
+	}
+	defer src.Close()
+
+	path := filepath.Join(dir, newFilename)
+	dst, err := os.Create(path)
+	if err != nil {
+		slog.Warn("failed to copy image while duplicating product", "error", err, "filename", filename)
+		return false
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		slog.Warn("failed to copy image while duplicating product", "error", err, "filename", filename)
+		return false
+	}
+	*copied = append(*copied, path)
+	return true
+}
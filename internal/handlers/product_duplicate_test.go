@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateCopyName(t *testing.T) {
+	name, slug := duplicateCopyName("Articulated Dragon", "articulated-dragon", 1)
+	assert.Equal(t, "Articulated Dragon (Copy)", name)
+	assert.Equal(t, "articulated-dragon-copy", slug)
+
+	name, slug = duplicateCopyName("Articulated Dragon", "articulated-dragon", 3)
+	assert.Equal(t, "Articulated Dragon (Copy 3)", name)
+	assert.Equal(t, "articulated-dragon-copy-3", slug)
+}
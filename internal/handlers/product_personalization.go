@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func productPersonalizationURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#personalization", productID)
+}
+
+// HandleSaveProductPersonalizationField adds a field customers fill in when buying,
+// such as engraving text
+func (h *AdminHandler) HandleSaveProductPersonalizationField(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	label := strings.TrimSpace(c.FormValue("label"))
+	if label == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Label is required")
+	}
+	fieldType := c.FormValue("field_type")
+	if !utils.ValidPersonalizationType(fieldType) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid field type")
+	}
+	var maxLength int64
+	if raw := strings.TrimSpace(c.FormValue("max_length")); raw != "" {
+		var err error
+		maxLength, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || maxLength < 0 || maxLength > utils.MaxPersonalizationLength {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Max length must be between 0 and %d", utils.MaxPersonalizationLength))
+		}
+	}
+	displayOrder, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("display_order")), 10, 64)
+
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		slog.Error("failed to load product for personalization field", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	_, err := h.storage.Queries.CreateProductPersonalizationField(ctx, db.CreateProductPersonalizationFieldParams{
+		ID:           uuid.New().String(),
+		ProductID:    productID,
+		Label:        label,
+		FieldType:    fieldType,
+		MaxLength:    maxLength,
+		IsRequired:   c.FormValue("is_required") == "on",
+		DisplayOrder: displayOrder,
+	})
+	if err != nil {
+		slog.Error("failed to save personalization field", "error", err, "product_id", productID, "label", label)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save personalization field")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productPersonalizationURL(productID))
+}
+
+// HandleDeleteProductPersonalizationField removes a personalization field. Carts and
+// orders keep what was already entered.
+func (h *AdminHandler) HandleDeleteProductPersonalizationField(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	fieldID := c.Param("fieldId")
+
+	if err := h.storage.Queries.DeleteProductPersonalizationField(ctx, db.DeleteProductPersonalizationFieldParams{
+		ID:        fieldID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to delete personalization field", "error", err, "product_id", productID, "field_id", fieldID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove personalization field")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productPersonalizationURL(productID))
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func productPriceTiersURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#price-tiers", productID)
+}
+
+// HandleSaveProductPriceTier adds a quantity break to a product, or to one of its SKUs
+func (h *AdminHandler) HandleSaveProductPriceTier(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	minQuantity, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("min_quantity")), 10, 64)
+	if err != nil || minQuantity < 2 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Minimum quantity must be 2 or more")
+	}
+	priceCents, err := parseCurrencyToCents(strings.TrimSpace(c.FormValue("unit_price")))
+	if err != nil || priceCents < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Enter a valid price")
+	}
+
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		slog.Error("failed to load product for price tier", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	skuID := strings.TrimSpace(c.FormValue("sku_id"))
+	if skuID != "" {
+		if _, err := h.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+			ID:        skuID,
+			ProductID: productID,
+		}); err != nil {
+			slog.Error("failed to load SKU for price tier", "error", err, "product_id", productID, "sku_id", skuID)
+			return echo.NewHTTPError(http.StatusBadRequest, "Variant not found")
+		}
+	}
+
+	_, err = h.storage.Queries.CreateProductPriceTier(ctx, db.CreateProductPriceTierParams{
+		ID:             uuid.New().String(),
+		ProductID:      productID,
+		ProductSkuID:   sql.NullString{String: skuID, Valid: skuID != ""},
+		MinQuantity:    minQuantity,
+		UnitPriceCents: priceCents,
+	})
+	if err != nil {
+		slog.Error("failed to save price tier", "error", err, "product_id", productID, "min_quantity", minQuantity)
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return echo.NewHTTPError(http.StatusConflict, "A break at that quantity already exists")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save price break")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productPriceTiersURL(productID))
+}
+
+// HandleDeleteProductPriceTier removes a quantity break
+func (h *AdminHandler) HandleDeleteProductPriceTier(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	tierID := c.Param("tierId")
+
+	if err := h.storage.Queries.DeleteProductPriceTier(ctx, db.DeleteProductPriceTierParams{
+		ID:        tierID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to delete price tier", "error", err, "product_id", productID, "tier_id", tierID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove price break")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productPriceTiersURL(productID))
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// productQuestionStatuses are the moderation queues shown in the admin
+var productQuestionStatuses = map[string]bool{
+	"pending":   true,
+	"published": true,
+	"rejected":  true,
+}
+
+// HandleProductQuestions shows the product question moderation queue
+func (h *AdminHandler) HandleProductQuestions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	status := c.QueryParam("status")
+	if !productQuestionStatuses[status] {
+		status = "pending"
+	}
+
+	questions, err := h.storage.Queries.ListProductQuestionsByStatus(ctx, db.ListProductQuestionsByStatusParams{
+		Status: status,
+		Limit:  100,
+		Offset: 0,
+	})
+	if err != nil {
+		slog.Error("failed to list product questions", "error", err, "status", status)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load product questions")
+	}
+
+	counts := make(map[string]int64, len(productQuestionStatuses))
+	for s := range productQuestionStatuses {
+		count, err := h.storage.Queries.CountProductQuestionsByStatus(ctx, s)
+		if err != nil {
+			slog.Error("failed to count product questions", "error", err, "status", s)
+			continue
+		}
+		counts[s] = count
+	}
+
+	return Render(c, admin.ProductQuestions(c, questions, status, counts, c.QueryParam("error")))
+}
+
+// HandleAnswerProductQuestion saves an answer, publishes the question and emails the asker
+func (h *AdminHandler) HandleAnswerProductQuestion(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	answer := strings.TrimSpace(c.FormValue("answer"))
+	if answer == "" {
+		return c.Redirect(http.StatusSeeOther, "/admin/questions?error=An+answer+is+required+to+publish")
+	}
+
+	answeredBy := sql.NullString{}
+	if user, ok := auth.GetDBUser(c); ok {
+		answeredBy = sql.NullString{String: user.ID, Valid: true}
+	}
+
+	question, err := h.storage.Queries.AnswerProductQuestion(ctx, db.AnswerProductQuestionParams{
+		Answer:     sql.NullString{String: answer, Valid: true},
+		AnsweredBy: answeredBy,
+		ID:         id,
+	})
+	if err != nil {
+		slog.Error("failed to answer product question", "error", err, "id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save answer")
+	}
+
+	slog.Info("product question answered", "id", id, "product_id", question.ProductID)
+
+	// Only notify on the first answer; later edits just update the published text
+	if !question.NotifiedAt.Valid {
+		product, err := h.storage.Queries.GetProduct(ctx, question.ProductID)
+		if err != nil {
+			slog.Error("failed to load product for question answer email", "error", err, "product_id", question.ProductID)
+		} else {
+			go h.sendProductQuestionAnswered(question, product)
+		}
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/admin/questions")
+}
+
+// sendProductQuestionAnswered emails the asker and records the notification
+func (h *AdminHandler) sendProductQuestionAnswered(question db.ProductQuestion, product db.Product) {
+	data := &email.ProductQuestionData{
+		ID:          question.ID,
+		ProductName: product.Name,
+		ProductURL:  fmt.Sprintf("https://www.logans3dcreations.com/shop/product/%s#questions", product.Slug),
+		AskerName:   question.AskerName,
+		AskerEmail:  question.AskerEmail,
+		Question:    question.Question,
+		Answer:      question.Answer.String,
+	}
+
+	if err := h.emailService.SendProductQuestionAnswered(data); err != nil {
+		slog.Error("failed to send product question answered email", "error", err, "id", question.ID)
+		return
+	}
+
+	if err := h.storage.Queries.MarkProductQuestionNotified(context.Background(), question.ID); err != nil {
+		slog.Error("failed to mark product question notified", "error", err, "id", question.ID)
+	}
+}
+
+// HandleRejectProductQuestion hides a question without answering it
+func (h *AdminHandler) HandleRejectProductQuestion(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	err := h.storage.Queries.UpdateProductQuestionStatus(ctx, db.UpdateProductQuestionStatusParams{
+		Status: "rejected",
+		ID:     id,
+	})
+	if err != nil {
+		slog.Error("failed to reject product question", "error", err, "id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to reject question")
+	}
+
+	slog.Info("product question rejected", "id", id)
+	return c.Redirect(http.StatusSeeOther, "/admin/questions")
+}
+
+// HandleDeleteProductQuestion permanently removes a question
+func (h *AdminHandler) HandleDeleteProductQuestion(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if err := h.storage.Queries.DeleteProductQuestion(ctx, id); err != nil {
+		slog.Error("failed to delete product question", "error", err, "id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete question")
+	}
+
+	slog.Info("product question deleted", "id", id)
+	return c.Redirect(http.StatusSeeOther, "/admin/questions?status="+c.QueryParam("status"))
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func productRelationsURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#related-products", productID)
+}
+
+// HandleSaveProductRelation hand-picks a product to recommend alongside this one.
+// Picking one that's already related updates its type and order.
+func (h *AdminHandler) HandleSaveProductRelation(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	relatedProductID := c.FormValue("related_product_id")
+	if relatedProductID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Choose a product")
+	}
+	if relatedProductID == productID {
+		return echo.NewHTTPError(http.StatusBadRequest, "A product can't be related to itself")
+	}
+	relationType := c.FormValue("relation_type")
+	if !utils.ValidRelationType(relationType) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid relation type")
+	}
+	displayOrder, _ := strconv.ParseInt(strings.TrimSpace(c.FormValue("display_order")), 10, 64)
+
+	for _, id := range []string{productID, relatedProductID} {
+		if _, err := h.storage.Queries.GetProduct(ctx, id); err != nil {
+			slog.Error("failed to load product for relation", "error", err, "product_id", id)
+			return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+		}
+	}
+
+	if err := h.storage.Queries.UpsertProductRelation(ctx, db.UpsertProductRelationParams{
+		ProductID:        productID,
+		RelatedProductID: relatedProductID,
+		RelationType:     relationType,
+		DisplayOrder:     displayOrder,
+	}); err != nil {
+		slog.Error("failed to save product relation", "error", err, "product_id", productID, "related_product_id", relatedProductID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save related product")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productRelationsURL(productID))
+}
+
+// HandleDeleteProductRelation removes a hand-picked related product
+func (h *AdminHandler) HandleDeleteProductRelation(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	relatedProductID := c.Param("relatedId")
+
+	if err := h.storage.Queries.DeleteProductRelation(ctx, db.DeleteProductRelationParams{
+		ProductID:        productID,
+		RelatedProductID: relatedProductID,
+	}); err != nil {
+		slog.Error("failed to delete product relation", "error", err, "product_id", productID, "related_product_id", relatedProductID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove related product")
+	}
+
+	return c.Redirect(http.StatusSeeOther, productRelationsURL(productID))
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/schedule"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func productSkuSalesURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#sku-sales", productID)
+}
+
+// parseScheduleTime reads a datetime-local field. Blank means no time set.
+func parseScheduleTime(c echo.Context, field string) (sql.NullTime, error) {
+	raw := strings.TrimSpace(c.FormValue(field))
+	if raw == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse("2006-01-02T15:04", raw)
+	if err != nil {
+		return sql.NullTime{}, &schedule.Error{Message: "Enter dates and times as shown in the date picker"}
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// parseSalePrice reads a sale price in dollars. Blank means no sale.
+func parseSalePrice(c echo.Context) (sql.NullInt64, error) {
+	raw := strings.TrimSpace(c.FormValue("sale_price"))
+	if raw == "" {
+		return sql.NullInt64{}, nil
+	}
+	cents, err := parseCurrencyToCents(raw)
+	if err != nil {
+		return sql.NullInt64{}, &schedule.Error{Message: "Enter a valid sale price"}
+	}
+	return sql.NullInt64{Int64: cents, Valid: true}, nil
+}
+
+// scheduleErrorResponse is the status and message for a failed schedule save. A
+// schedule the admin needs to fix is a bad request; anything else failed on our side.
+func scheduleErrorResponse(err error) (int, string) {
+	var scheduleErr *schedule.Error
+	if errors.As(err, &scheduleErr) {
+		return http.StatusBadRequest, scheduleErr.Message
+	}
+	return http.StatusInternalServerError, "Failed to save publishing and sale settings"
+}
+
+// saveProductScheduleSettings applies the publish and unpublish times and the sale
+// from the product form, then runs the scheduler so anything already due happens
+// now rather than at the next tick. The form saves the regular price before this
+// runs. Forms that don't include the section leave the settings untouched.
+func (h *AdminHandler) saveProductScheduleSettings(c echo.Context, productID string) error {
+	if c.FormValue("schedule_settings") == "" {
+		return nil
+	}
+	ctx := c.Request().Context()
+
+	publishAt, err := parseScheduleTime(c, "publish_at")
+	if err != nil {
+		return err
+	}
+	unpublishAt, err := parseScheduleTime(c, "unpublish_at")
+	if err != nil {
+		return err
+	}
+	if err := schedule.ValidatePublishing(publishAt, unpublishAt); err != nil {
+		return err
+	}
+
+	salePrice, err := parseSalePrice(c)
+	if err != nil {
+		return err
+	}
+	var sale schedule.Window
+	if salePrice.Valid {
+		if sale.Start, err = parseScheduleTime(c, "sale_starts_at"); err != nil {
+			return err
+		}
+		if sale.End, err = parseScheduleTime(c, "sale_ends_at"); err != nil {
+			return err
+		}
+
+		product, err := h.storage.Queries.GetProduct(ctx, productID)
+		if err != nil {
+			slog.Error("failed to load product for sale", "error", err, "product_id", productID)
+			return err
+		}
+		if err := schedule.ValidateSale(salePrice.Int64, product.PriceCents, sale); err != nil {
+			return err
+		}
+		if err := h.checkSkuSaleOverlap(ctx, productID, "", sale); err != nil {
+			return err
+		}
+	}
+
+	now := utils.WallClockNow()
+	if err := h.storage.Queries.UpdateProductSchedule(ctx, db.UpdateProductScheduleParams{
+		PublishAt:   publishAt,
+		UnpublishAt: unpublishAt,
+		Hide:        publishAt.Valid && now.Before(publishAt.Time),
+		ID:          productID,
+	}); err != nil {
+		slog.Error("failed to update product schedule", "error", err, "product_id", productID)
+		return err
+	}
+	if err := h.storage.Queries.UpdateProductSaleSettings(ctx, db.UpdateProductSaleSettingsParams{
+		SalePriceCents: salePrice,
+		SaleStartsAt:   sale.Start,
+		SaleEndsAt:     sale.End,
+		ID:             productID,
+	}); err != nil {
+		slog.Error("failed to update product sale", "error", err, "product_id", productID)
+		return err
+	}
+
+	h.applyProductSchedules(ctx)
+	return nil
+}
+
+// checkSkuSaleOverlap rejects a sale that would run at the same time as another sale
+// on the product. skuID is the SKU being saved, or "" for the product's own sale.
+func (h *AdminHandler) checkSkuSaleOverlap(ctx context.Context, productID, skuID string, sale schedule.Window) error {
+	others := map[string]schedule.Window{}
+	if skuID != "" {
+		product, err := h.storage.Queries.GetProduct(ctx, productID)
+		if err != nil {
+			slog.Error("failed to load product for sale overlap", "error", err, "product_id", productID)
+			return err
+		}
+		if product.SalePriceCents.Valid {
+			others["the whole product"] = schedule.Window{Start: product.SaleStartsAt, End: product.SaleEndsAt}
+		}
+		// SKUs can each run their own sale at the same time, so only the product's
+		// sale matters here
+		return schedule.CheckOverlap(sale, others)
+	}
+
+	skus, err := h.storage.Queries.ListProductSkuSales(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load sku sales", "error", err, "product_id", productID)
+		return err
+	}
+	for _, sku := range skus {
+		if sku.SalePriceCents.Valid {
+			others[sku.Sku] = schedule.Window{Start: sku.SaleStartsAt, End: sku.SaleEndsAt}
+		}
+	}
+	return schedule.CheckOverlap(sale, others)
+}
+
+// applyProductSchedules runs the scheduler straight after an admin change and syncs
+// whatever it changed. A failure is logged; the background job tries again.
+func (h *AdminHandler) applyProductSchedules(ctx context.Context) {
+	changed, err := schedule.Apply(ctx, h.storage.Queries, utils.WallClockNow())
+	if err != nil {
+		slog.Error("failed to apply product schedules", "error", err)
+	}
+	for _, productID := range changed {
+		h.queueStripeSync(productID)
+	}
+}
+
+// HandleSaveSkuSale sets or clears the sale on one of a product's variants
+func (h *AdminHandler) HandleSaveSkuSale(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	skuID := c.Param("skuId")
+
+	product, err := h.storage.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load product for sku sale", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+	sku, err := h.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+		ID:        skuID,
+		ProductID: productID,
+	})
+	if err != nil {
+		slog.Error("failed to load sku for sale", "error", err, "product_id", productID, "sku_id", skuID)
+		return echo.NewHTTPError(http.StatusNotFound, "Variant not found")
+	}
+
+	salePrice, err := parseSalePrice(c)
+	if err != nil {
+		return echo.NewHTTPError(scheduleErrorResponse(err))
+	}
+	var sale schedule.Window
+	if salePrice.Valid {
+		if sale.Start, err = parseScheduleTime(c, "sale_starts_at"); err != nil {
+			return echo.NewHTTPError(scheduleErrorResponse(err))
+		}
+		if sale.End, err = parseScheduleTime(c, "sale_ends_at"); err != nil {
+			return echo.NewHTTPError(scheduleErrorResponse(err))
+		}
+
+		// The variant's regular price is the product's plus its usual adjustment
+		adjustment := sku.PriceAdjustmentCents.Int64
+		if sku.RegularAdjustmentCents.Valid {
+			adjustment = sku.RegularAdjustmentCents.Int64
+		}
+		regular := utils.ProductRegularPriceCents(product) + adjustment
+		if err := schedule.ValidateSale(salePrice.Int64, regular, sale); err != nil {
+			return echo.NewHTTPError(scheduleErrorResponse(err))
+		}
+		if err := h.checkSkuSaleOverlap(ctx, productID, skuID, sale); err != nil {
+			return echo.NewHTTPError(scheduleErrorResponse(err))
+		}
+	}
+
+	if _, err := h.storage.Queries.UpdateSkuSaleSettings(ctx, db.UpdateSkuSaleSettingsParams{
+		SalePriceCents: salePrice,
+		SaleStartsAt:   sale.Start,
+		SaleEndsAt:     sale.End,
+		ID:             skuID,
+		ProductID:      productID,
+	}); err != nil {
+		slog.Error("failed to update sku sale", "error", err, "product_id", productID, "sku_id", skuID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save the sale")
+	}
+
+	h.applyProductSchedules(ctx)
+	h.queueStripeSync(productID)
+
+	return c.Redirect(http.StatusSeeOther, productSkuSalesURL(productID))
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// recordProductSlugChange keeps the slug a product is giving up, so its old shop URL
+// redirects to the new one, and drops the new slug from the redirects now that it is
+// in use again
+func recordProductSlugChange(ctx context.Context, queries *db.Queries, productID, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := queries.DeleteProductSlugRedirect(ctx, newSlug); err != nil {
+		return err
+	}
+	if oldSlug == "" {
+		return nil
+	}
+	return queries.RecordProductSlugRedirect(ctx, db.RecordProductSlugRedirectParams{
+		OldSlug:   oldSlug,
+		ProductID: productID,
+	})
+}
+
+// updateProductSlug runs an update that may give the product a new slug, recording
+// the old one for redirects in the same transaction
+func updateProductSlug(ctx context.Context, store *storage.Storage, productID, newSlug string, update func(queries *db.Queries) (db.Product, error)) (db.Product, error) {
+	existing, err := store.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		return db.Product{}, fmt.Errorf("failed to load product: %w", err)
+	}
+
+	tx, err := store.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return db.Product{}, fmt.Errorf("failed to begin product update: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := store.Queries.WithTx(tx)
+	product, err := update(queries)
+	if err != nil {
+		return db.Product{}, err
+	}
+	if err := recordProductSlugChange(ctx, queries, productID, existing.Slug, newSlug); err != nil {
+		return db.Product{}, fmt.Errorf("failed to record old slug: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return db.Product{}, fmt.Errorf("failed to commit product update: %w", err)
+	}
+	return product, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestRecordProductSlugChange(t *testing.T) {
+	_, queries, cleanup := NewTestDB()
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, id := range []string{"prod-1", "prod-2"} {
+		_, err := queries.CreateProduct(ctx, db.CreateProductParams{
+			ID:         id,
+			Name:       id,
+			Slug:       id,
+			PriceCents: 1000,
+			IsActive:   sql.NullBool{Bool: true, Valid: true},
+		})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, recordProductSlugChange(ctx, queries, "prod-1", "dragon", "crystal-dragon"))
+	require.NoError(t, recordProductSlugChange(ctx, queries, "prod-1", "crystal-dragon", "prod-1"))
+	for _, slug := range []string{"dragon", "crystal-dragon"} {
+		renamed, err := queries.GetProductBySlugRedirect(ctx, slug)
+		require.NoError(t, err)
+		assert.Equal(t, "prod-1", renamed.ID, "every old slug points at the product, not the slug after it")
+	}
+	redirects, err := queries.ListProductSlugRedirects(ctx, "prod-1")
+	require.NoError(t, err)
+	assert.Len(t, redirects, 2)
+
+	// Another product taking an old slug stops it redirecting
+	require.NoError(t, recordProductSlugChange(ctx, queries, "prod-2", "prod-2", "dragon"))
+	_, err = queries.GetProductBySlugRedirect(ctx, "dragon")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Archived products aren't redirected to
+	_, err = queries.ArchiveProduct(ctx, "prod-1")
+	require.NoError(t, err)
+	_, err = queries.GetProductBySlugRedirect(ctx, "crystal-dragon")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
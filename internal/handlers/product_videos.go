@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// productVideoPosterDir holds uploaded poster frames next to the product images
+const productVideoPosterDir = "public/images/products"
+
+var posterExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+func productVideosURL(productID string) string {
+	return fmt.Sprintf("/admin/product/edit?id=%s#videos", productID)
+}
+
+// saveUpload copies an uploaded file to dir/name
+func saveUpload(file *multipart.FileHeader, dir, name string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	fullPath := filepath.Join(dir, name)
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(fullPath)
+		return err
+	}
+	return nil
+}
+
+// HandleAddProductVideo adds a video to the product gallery, either an uploaded file
+// or a YouTube/Vimeo link, with an optional poster frame
+func (h *AdminHandler) HandleAddProductVideo(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	if _, err := h.storage.Queries.GetProduct(ctx, productID); err != nil {
+		slog.Error("failed to load product for video", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	videoID := uuid.New().String()
+	params := db.CreateProductVideoParams{
+		ID:        videoID,
+		ProductID: productID,
+		Title:     strings.TrimSpace(c.FormValue("title")),
+	}
+
+	videoFile, _ := c.FormFile("video")
+	videoURL := strings.TrimSpace(c.FormValue("video_url"))
+	switch {
+	case videoFile != nil && videoFile.Size > 0:
+		contentType := utils.VideoContentType(videoFile.Filename)
+		if contentType == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Upload an MP4, WebM or MOV video")
+		}
+		fileName := productID + "_" + videoID + strings.ToLower(filepath.Ext(videoFile.Filename))
+		if err := saveUpload(videoFile, utils.ProductVideoDir, fileName); err != nil {
+			slog.Error("failed to save product video", "error", err, "product_id", productID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save video")
+		}
+		params.Provider = utils.VideoProviderUpload
+		params.VideoRef = fileName
+		params.ContentType = contentType
+	case videoURL != "":
+		provider, ref, err := utils.ParseVideoURL(videoURL)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		params.Provider = provider
+		params.VideoRef = ref
+		params.ContentType = "text/html"
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "Upload a video or paste a YouTube or Vimeo link")
+	}
+
+	if poster, _ := c.FormFile("poster"); poster != nil && poster.Size > 0 {
+		ext := strings.ToLower(filepath.Ext(poster.Filename))
+		if !posterExtensions[ext] {
+			h.removeUploadedVideo(params.Provider, params.VideoRef, "")
+			return echo.NewHTTPError(http.StatusBadRequest, "Poster must be a JPG, PNG or WebP image")
+		}
+		posterName := productID + "_video_" + videoID + ext
+		if err := saveUpload(poster, productVideoPosterDir, posterName); err != nil {
+			slog.Error("failed to save video poster", "error", err, "product_id", productID)
+			h.removeUploadedVideo(params.Provider, params.VideoRef, "")
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save poster")
+		}
+		params.PosterUrl = "/" + productVideoPosterDir + "/" + posterName
+	}
+
+	existing, err := h.storage.Queries.ListProductVideos(ctx, productID)
+	if err != nil {
+		slog.Error("failed to list product videos", "error", err, "product_id", productID)
+	}
+	params.DisplayOrder = int64(len(existing))
+
+	if _, err := h.storage.Queries.CreateProductVideo(ctx, params); err != nil {
+		slog.Error("failed to save product video", "error", err, "product_id", productID)
+		h.removeUploadedVideo(params.Provider, params.VideoRef, params.PosterUrl)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save video")
+	}
+
+	slog.Info("product video added", "product_id", productID, "provider", params.Provider, "ref", params.VideoRef)
+	return c.Redirect(http.StatusSeeOther, productVideosURL(productID))
+}
+
+// HandleDeleteProductVideo removes a video from the gallery along with any uploaded files
+func (h *AdminHandler) HandleDeleteProductVideo(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	videoID := c.Param("videoId")
+
+	video, err := h.storage.Queries.GetProductVideo(ctx, db.GetProductVideoParams{
+		ID:        videoID,
+		ProductID: productID,
+	})
+	if err != nil {
+		slog.Error("failed to get product video", "error", err, "product_id", productID, "video_id", videoID)
+		return echo.NewHTTPError(http.StatusNotFound, "Video not found")
+	}
+
+	if err := h.storage.Queries.DeleteProductVideo(ctx, db.DeleteProductVideoParams{
+		ID:        videoID,
+		ProductID: productID,
+	}); err != nil {
+		slog.Error("failed to delete product video", "error", err, "product_id", productID, "video_id", videoID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove video")
+	}
+
+	h.removeUploadedVideo(video.Provider, video.VideoRef, video.PosterUrl)
+	return c.Redirect(http.StatusSeeOther, productVideosURL(productID))
+}
+
+// removeUploadedVideo deletes the files behind an uploaded video and its poster
+func (h *AdminHandler) removeUploadedVideo(provider, ref, posterURL string) {
+	var paths []string
+	if provider == utils.VideoProviderUpload && ref != "" {
+		paths = append(paths, filepath.Join(utils.ProductVideoDir, filepath.Base(ref)))
+	}
+	if strings.HasPrefix(posterURL, "/"+productVideoPosterDir+"/") {
+		paths = append(paths, filepath.Join(productVideoPosterDir, filepath.Base(posterURL)))
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("failed to remove product video file", "error", err, "path", path)
+		}
+	}
+}
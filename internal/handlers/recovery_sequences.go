@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// recoveryTemplateKeys are the email templates a sequence step can use
+var recoveryTemplateKeys = map[string]bool{
+	"email_1hr":  true,
+	"email_24hr": true,
+	"email_72hr": true,
+}
+
+// HandleRecoverySequenceSettings shows the configured abandoned cart recovery sequences
+func (h *AdminHandler) HandleRecoverySequenceSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	steps, err := h.storage.Queries.ListRecoverySequenceSteps(ctx)
+	if err != nil {
+		slog.Error("failed to list recovery sequence steps", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load recovery sequences")
+	}
+
+	return Render(c, admin.RecoverySequenceSettings(c, steps, c.QueryParam("error")))
+}
+
+// HandleCreateRecoverySequenceStep adds a step to a recovery sequence variant
+func (h *AdminHandler) HandleCreateRecoverySequenceStep(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	variant := strings.ToUpper(strings.TrimSpace(c.FormValue("variant")))
+	templateKey := c.FormValue("template_key")
+	subject := strings.TrimSpace(c.FormValue("email_subject"))
+	stepNumber, _ := strconv.ParseInt(c.FormValue("step_number"), 10, 64)
+	delayHours, _ := strconv.ParseFloat(c.FormValue("delay_hours"), 64)
+	promoPercent, _ := strconv.ParseInt(c.FormValue("promo_discount_percent"), 10, 64)
+	promoValidDays, _ := strconv.ParseInt(c.FormValue("promo_valid_days"), 10, 64)
+
+	if variant == "" || stepNumber < 1 || delayHours <= 0 || subject == "" || !recoveryTemplateKeys[templateKey] {
+		return c.Redirect(http.StatusSeeOther, "/admin/abandoned-carts/settings?error=Variant,+step,+delay,+template+and+subject+are+required")
+	}
+	if promoPercent < 0 || promoPercent > 50 {
+		return c.Redirect(http.StatusSeeOther, "/admin/abandoned-carts/settings?error=Discount+must+be+between+0+and+50+percent")
+	}
+	if promoValidDays <= 0 {
+		promoValidDays = 10
+	}
+
+	_, err := h.storage.Queries.CreateRecoverySequenceStep(ctx, db.CreateRecoverySequenceStepParams{
+		ID:                   uuid.New().String(),
+		Variant:              variant,
+		StepNumber:           stepNumber,
+		DelayMinutes:         int64(delayHours * 60),
+		TemplateKey:          templateKey,
+		EmailSubject:         subject,
+		IncludePromo:         c.FormValue("include_promo") == "on",
+		PromoDiscountPercent: promoPercent,
+		PromoValidDays:       promoValidDays,
+		IsActive:             true,
+	})
+	if err != nil {
+		slog.Error("failed to create recovery sequence step", "error", err, "variant", variant, "step", stepNumber)
+		return c.Redirect(http.StatusSeeOther, "/admin/abandoned-carts/settings?error=Could+not+save+step+(is+the+step+number+already+used?)")
+	}
+
+	slog.Info("recovery sequence step created", "variant", variant, "step", stepNumber, "template", templateKey)
+	return c.Redirect(http.StatusSeeOther, "/admin/abandoned-carts/settings")
+}
+
+// HandleToggleRecoverySequenceStep enables or disables a sequence step
+func (h *AdminHandler) HandleToggleRecoverySequenceStep(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if err := h.storage.Queries.ToggleRecoverySequenceStep(ctx, id); err != nil {
+		slog.Error("failed to toggle recovery sequence step", "error", err, "id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update step")
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/admin/abandoned-carts/settings")
+}
+
+// HandleDeleteRecoverySequenceStep removes a sequence step
+func (h *AdminHandler) HandleDeleteRecoverySequenceStep(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if err := h.storage.Queries.DeleteRecoverySequenceStep(ctx, id); err != nil {
+		slog.Error("failed to delete recovery sequence step", "error", err, "id", id)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete step")
+	}
+
+	slog.Info("recovery sequence step deleted", "id", id)
+	return c.Redirect(http.StatusSeeOther, "/admin/abandoned-carts/settings")
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	stripego "github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+func returnURL(returnID, flash, errorMsg string) string {
+	target := "/admin/returns/" + returnID
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// suggestedReturnRefund is what the returned items were bought for, capped at what's
+// left of the order's payment after earlier refunds
+func suggestedReturnRefund(items []db.ListReturnItemsRow, orderTotalCents, refundedCents int64) int64 {
+	var cents int64
+	for _, item := range items {
+		cents += item.UnitPriceCents * item.Quantity
+	}
+	return max(min(cents, orderTotalCents-refundedCents), 0)
+}
+
+// HandleReturnsList lists return requests, newest first, optionally by status
+func (h *AdminHandler) HandleReturnsList(c echo.Context) error {
+	status := c.QueryParam("status")
+	returns, err := h.storage.Queries.ListReturns(c.Request().Context(), sql.NullString{String: status, Valid: status != ""})
+	if err != nil {
+		slog.Error("failed to list returns", "error", err, "status", status)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load returns")
+	}
+	return Render(c, admin.ReturnsList(c, returns, status))
+}
+
+// loadReturn fetches a return and its order for the admin return actions
+func (h *AdminHandler) loadReturn(ctx context.Context, returnID string) (db.Return, db.Order, error) {
+	ret, err := h.storage.Queries.GetReturn(ctx, returnID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Return{}, db.Order{}, echo.NewHTTPError(http.StatusNotFound, "Return not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch return", "error", err, "return_id", returnID)
+		return db.Return{}, db.Order{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load return")
+	}
+	order, err := h.storage.Queries.GetOrder(ctx, ret.OrderID)
+	if err != nil {
+		slog.Error("failed to fetch order for return", "error", err, "return_id", returnID, "order_id", ret.OrderID)
+		return db.Return{}, db.Order{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load return")
+	}
+	return ret, order, nil
+}
+
+// HandleReturnDetail shows a return request with its items, photos and the actions
+// that fit its status
+func (h *AdminHandler) HandleReturnDetail(c echo.Context) error {
+	ctx := c.Request().Context()
+	ret, order, err := h.loadReturn(ctx, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	items, err := h.storage.Queries.ListReturnItems(ctx, ret.ID)
+	if err != nil {
+		slog.Error("failed to fetch return items", "error", err, "return_id", ret.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load return")
+	}
+	photos, err := h.storage.Queries.ListReturnPhotos(ctx, ret.ID)
+	if err != nil {
+		slog.Error("failed to fetch return photos", "error", err, "return_id", ret.ID)
+		photos = []db.ReturnPhoto{}
+	}
+	refunded, err := h.storage.Queries.GetOrderRefundedCents(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to sum order refunds", "error", err, "order_id", order.ID)
+	}
+	locations, err := h.storage.Queries.ListActiveInventoryLocations(ctx)
+	if err != nil {
+		slog.Error("failed to list inventory locations for return", "error", err)
+		locations = []db.InventoryLocation{}
+	}
+
+	return Render(c, admin.ReturnDetail(c, admin.ReturnDetailData{
+		Return:          ret,
+		Order:           order,
+		Items:           items,
+		Photos:          photos,
+		Locations:       locations,
+		SuggestedRefund: suggestedReturnRefund(items, order.TotalCents, refunded),
+		CanBuyLabel:     h.shippingService != nil && order.EasypostShipmentID.String != "",
+		Flash:           c.QueryParam("saved"),
+		Error:           c.QueryParam("error"),
+	}))
+}
+
+// HandleReturnPhoto serves a photo the customer attached to a return
+func (h *AdminHandler) HandleReturnPhoto(c echo.Context) error {
+	photo, err := h.storage.Queries.GetReturnPhoto(c.Request().Context(), db.GetReturnPhotoParams{
+		ID:       c.Param("photoID"),
+		ReturnID: c.Param("id"),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Photo not found")
+	}
+	return c.File(photo.FilePath)
+}
+
+// HandleUpdateReturnStatus approves or rejects a request, marks the items received, or
+// closes the return. The note is shown to the customer with the new status.
+func (h *AdminHandler) HandleUpdateReturnStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	ret, _, err := h.loadReturn(ctx, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	status := c.FormValue("status")
+	if !utils.CanMoveReturn(ret.Status, status) {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", fmt.Sprintf("A %s return can't be marked %s", ret.Status, status)))
+	}
+	notes := strings.TrimSpace(c.FormValue("admin_notes"))
+
+	updated, err := h.storage.Queries.UpdateReturnStatus(ctx, db.UpdateReturnStatusParams{
+		Status:     status,
+		AdminNotes: notes,
+		ID:         ret.ID,
+		FromStatus: ret.Status,
+	})
+	if err != nil {
+		slog.Error("failed to update return status", "error", err, "return_id", ret.ID, "status", status)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not update the return"))
+	}
+	if updated == 0 {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "The return changed in the meantime; check it and try again"))
+	}
+
+	slog.Info("return status updated", "return_id", ret.ID, "from", ret.Status, "to", status, "by", adminActor(c))
+	return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "Return marked "+status, ""))
+}
+
+// HandleBuyReturnLabel buys a prepaid label for the customer to send the items back
+// on, at the cheapest of the preferred label services. The parcel is the one the order
+// went out in.
+func (h *AdminHandler) HandleBuyReturnLabel(c echo.Context) error {
+	ctx := c.Request().Context()
+	ret, order, err := h.loadReturn(ctx, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ret.Status != utils.ReturnApproved:
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Approve the return before buying a label"))
+	case ret.ReturnLabelUrl != "":
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "A return label was already bought"))
+	case h.shippingService == nil:
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Shipping is not set up"))
+	case order.EasypostShipmentID.String == "":
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "The order has no EasyPost shipment to send back"))
+	}
+
+	rates, err := h.shippingService.ReturnRates(order.EasypostShipmentID.String)
+	if err != nil {
+		slog.Error("failed to get return label rates", "error", err, "return_id", ret.ID, "shipment_id", order.EasypostShipmentID.String)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not get shipping rates"))
+	}
+	rate, ok := h.shippingService.CheapestLabelRate(rates)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "No rate matches the label services in the shipping settings"))
+	}
+
+	label, err := h.shippingService.CreateLabelFromShipment(rate.ShipmentID, rate.RateID)
+	if err != nil {
+		slog.Error("failed to buy return label from EasyPost", "error", err, "return_id", ret.ID, "shipment_id", rate.ShipmentID, "rate_id", rate.RateID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Failed to purchase the return label"))
+	}
+
+	labelURL := label.LabelDownload.Hrefs.PDF
+	if labelURL == "" {
+		labelURL = label.LabelDownload.Hrefs.PNG
+	}
+	if err := h.storage.Queries.SetReturnLabel(ctx, db.SetReturnLabelParams{
+		EasypostShipmentID:   rate.ShipmentID,
+		ReturnLabelUrl:       labelURL,
+		ReturnTrackingNumber: label.TrackingNumber,
+		ReturnLabelCostCents: int64(math.Round(rate.ShippingAmount.Amount * 100)),
+		ID:                   ret.ID,
+	}); err != nil {
+		slog.Error("failed to record return label", "error", err, "return_id", ret.ID, "shipment_id", rate.ShipmentID, "tracking_number", label.TrackingNumber)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Label purchased but failed to save it on the return"))
+	}
+
+	slog.Info("return label purchased", "return_id", ret.ID, "tracking_number", label.TrackingNumber, "service", rate.CarrierCode+" "+rate.ServiceCode)
+	return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "Return label purchased", ""))
+}
+
+// HandleRefundReturn refunds a received return through Stripe, up to what's left of
+// the order's payment
+func (h *AdminHandler) HandleRefundReturn(c echo.Context) error {
+	ctx := c.Request().Context()
+	ret, order, err := h.loadReturn(ctx, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if ret.Status != utils.ReturnReceived {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Only returns that have been received can be refunded"))
+	}
+	if order.StripePaymentIntentID.String == "" {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "The order has no Stripe payment to refund"))
+	}
+
+	cents, errMsg := h.returnRefundAmount(c, order)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", errMsg))
+	}
+
+	stripeRefundID, errMsg := refundPayment(order, cents, "return", func(chargedCents int64) (*stripego.Refund, error) {
+		return stripe.RefundReturn(order.StripePaymentIntentID.String, chargedCents, ret.ID, order.ID)
+	}, func(stripeRefundID string) error {
+		_, err := h.storage.Queries.SetReturnRefund(ctx, db.SetReturnRefundParams{
+			RefundCents:    cents,
+			StripeRefundID: stripeRefundID,
+			ID:             ret.ID,
+		})
+		return err
+	}, "return_id", ret.ID)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", errMsg))
+	}
+
+	slog.Info("return refunded", "return_id", ret.ID, "order_id", order.ID, "refund_id", stripeRefundID, "amount_cents", cents, "by", adminActor(c))
+	return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "Refund issued", ""))
+}
+
+// returnRefundAmount reads the amount to refund for a return, checking it against
+// what's left of the order after earlier refunds. A non-empty message says why the
+// amount can't be refunded.
+func (h *AdminHandler) returnRefundAmount(c echo.Context, order db.Order) (int64, string) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(c.FormValue("amount")), 64)
+	if err != nil || amount <= 0 {
+		return 0, "Enter an amount to refund"
+	}
+	cents := int64(math.Round(amount * 100))
+
+	refunded, err := h.storage.Queries.GetOrderRefundedCents(c.Request().Context(), order.ID)
+	if err != nil {
+		slog.Error("failed to sum order refunds", "error", err, "order_id", order.ID)
+		return 0, "Could not refund the return"
+	}
+	if cents > order.TotalCents-refunded {
+		return 0, fmt.Sprintf("Only $%.2f of the order is left to refund", float64(order.TotalCents-refunded)/100)
+	}
+	return cents, ""
+}
+
+// HandleCreditReturn refunds a received return as store credit on the customer's
+// account instead of back to their card. The return is marked refunded and the credit
+// granted together, so neither happens without the other.
+func (h *AdminHandler) HandleCreditReturn(c echo.Context) error {
+	ctx := c.Request().Context()
+	ret, order, err := h.loadReturn(ctx, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if ret.Status != utils.ReturnReceived {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Only returns that have been received can be refunded"))
+	}
+
+	cents, errMsg := h.returnRefundAmount(c, order)
+	if errMsg != "" {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", errMsg))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to start return credit", "error", err, "return_id", ret.ID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not credit the return"))
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	rows, err := queries.SetReturnRefund(ctx, db.SetReturnRefundParams{
+		RefundCents: cents,
+		ID:          ret.ID,
+	})
+	if err != nil {
+		slog.Error("failed to record return refund", "error", err, "return_id", ret.ID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not credit the return"))
+	}
+	if rows == 0 {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "The return has already been refunded"))
+	}
+	if _, err := storecredit.NewLedger(queries).Grant(ctx, storecredit.GrantParams{
+		UserID:      ret.UserID,
+		AmountCents: cents,
+		Reason:      storecredit.ReasonRefund,
+		Note:        "Return for order #" + order.ID[:8],
+		OrderID:     order.ID,
+		AdminID:     adminUserID(c),
+	}); err != nil {
+		slog.Error("failed to grant store credit for return", "error", err, "return_id", ret.ID, "amount_cents", cents)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not credit the return"))
+	}
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit return credit", "error", err, "return_id", ret.ID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not credit the return"))
+	}
+
+	slog.Info("return refunded as store credit", "return_id", ret.ID, "order_id", order.ID, "user_id", ret.UserID, "amount_cents", cents, "by", adminActor(c))
+	return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "Store credit issued", ""))
+}
+
+// HandleRestockReturnItem puts returned units back into stock. With inventory locations
+// set up they go into the chosen location and are recorded as an adjustment against
+// the order; either way the sellable count goes up by the same amount.
+func (h *AdminHandler) HandleRestockReturnItem(c echo.Context) error {
+	ctx := c.Request().Context()
+	ret, order, err := h.loadReturn(ctx, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if ret.Status != utils.ReturnReceived && ret.Status != utils.ReturnRefunded && ret.Status != utils.ReturnClosed {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Mark the return received before restocking"))
+	}
+
+	item, err := h.storage.Queries.GetReturnItem(ctx, db.GetReturnItemParams{ID: c.Param("itemID"), ReturnID: ret.ID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Return item not found")
+	}
+	quantity, err := strconv.ParseInt(strings.TrimSpace(c.FormValue("quantity")), 10, 64)
+	if err != nil || quantity <= 0 {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Enter how many to restock"))
+	}
+	if item.RestockedQuantity+quantity > item.Quantity {
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", fmt.Sprintf("Only %d of %s left to restock", item.Quantity-item.RestockedQuantity, item.ProductName)))
+	}
+
+	locationID := c.FormValue("location_id")
+	if locationID != "" {
+		if _, err := h.storage.Queries.GetInventoryLocation(ctx, locationID); err != nil {
+			return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Choose a location"))
+		}
+	}
+	skuID := item.ProductSkuID.String
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin return restock transaction", "error", err, "return_id", ret.ID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not restock"))
+	}
+	defer tx.Rollback()
+	queries := h.storage.Queries.WithTx(tx)
+
+	restocked, err := queries.AddReturnItemRestocked(ctx, db.AddReturnItemRestockedParams{Quantity: quantity, ID: item.ID})
+	if err != nil || restocked == 0 {
+		slog.Error("failed to count restocked return units", "error", err, "return_id", ret.ID, "return_item_id", item.ID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not restock"))
+	}
+	if err := addAvailableStock(ctx, queries, item.ProductID, skuID, quantity); err != nil {
+		slog.Error("failed to restock returned units", "error", err, "return_id", ret.ID, "product_id", item.ProductID, "sku_id", skuID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not restock"))
+	}
+	if locationID != "" {
+		if err := queries.AddLocationStock(ctx, db.AddLocationStockParams{
+			LocationID:   locationID,
+			ProductID:    item.ProductID,
+			ProductSkuID: skuID,
+			Delta:        quantity,
+		}); err != nil {
+			slog.Error("failed to restock returned units at location", "error", err, "return_id", ret.ID, "location_id", locationID)
+			return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not restock"))
+		}
+		if err := queries.CreateInventoryMovement(ctx, db.CreateInventoryMovementParams{
+			ID:             uuid.New().String(),
+			LocationID:     locationID,
+			ProductID:      item.ProductID,
+			ProductSkuID:   skuID,
+			QuantityChange: quantity,
+			Reason:         movementAdjustment,
+			OrderID:        sql.NullString{String: order.ID, Valid: true},
+			Note:           "Returned: RMA " + ret.ID[:8],
+			CreatedBy:      adminActor(c),
+		}); err != nil {
+			slog.Error("failed to record return inventory movement", "error", err, "return_id", ret.ID, "location_id", locationID)
+			return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not restock"))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit return restock", "error", err, "return_id", ret.ID)
+		return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, "", "Could not restock"))
+	}
+
+	slog.Info("returned units restocked", "return_id", ret.ID, "product_id", item.ProductID, "sku_id", skuID, "quantity", quantity, "location_id", locationID)
+	return c.Redirect(http.StatusSeeOther, returnURL(ret.ID, fmt.Sprintf("Restocked %d × %s", quantity, item.ProductName), ""))
+}
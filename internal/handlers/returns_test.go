@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestSuggestedReturnRefund(t *testing.T) {
+	items := []db.ListReturnItemsRow{
+		{Quantity: 2, UnitPriceCents: 1500},
+		{Quantity: 1, UnitPriceCents: 999},
+	}
+
+	assert.Equal(t, int64(3999), suggestedReturnRefund(items, 10000, 0))
+	assert.Equal(t, int64(2500), suggestedReturnRefund(items, 10000, 7500), "capped at what's left of the order")
+	assert.Equal(t, int64(0), suggestedReturnRefund(items, 10000, 10000), "order fully refunded")
+	assert.Equal(t, int64(0), suggestedReturnRefund(nil, 10000, 0))
+}
@@ -40,12 +40,17 @@ type CartSnapshot struct {
 
 type GetShippingRatesRequest struct {
 	ShipTo shipping.Address `json:"ship_to"`
+	// AddressConfirmed keeps the address as entered when a correction was suggested
+	AddressConfirmed bool `json:"address_confirmed"`
 }
 
 type GetShippingRatesResponse struct {
 	Options       []shipping.ShippingOption `json:"options"`
 	DefaultOption *shipping.ShippingOption  `json:"default_option,omitempty"`
 	Error         string                    `json:"error,omitempty"`
+	// Verification is what EasyPost made of the address. When it suggests a
+	// correction, no rates are quoted until the customer picks an address.
+	Verification *shipping.AddressVerification `json:"verification,omitempty"`
 }
 
 func (h *ShippingHandler) GetShippingRates(c echo.Context) error {
@@ -57,6 +62,9 @@ func (h *ShippingHandler) GetShippingRates(c echo.Context) error {
 	if req.ShipTo.PostalCode == "" || req.ShipTo.CountryCode == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Shipping address is required")
 	}
+	if req.ShipTo.AddressLine1 == "" || req.ShipTo.CityLocality == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Street address and city are required")
+	}
 
 	// Get session ID from cookie
 	sessionID, err := h.getSessionID(c)
@@ -82,12 +90,30 @@ func (h *ShippingHandler) GetShippingRates(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Unable to calculate shipping rates")
 	}
 
+	verification := h.verifyAddress(c, req.ShipTo)
+	if !verification.Deliverable() {
+		return c.JSON(http.StatusUnprocessableEntity, GetShippingRatesResponse{
+			Error:        verification.Message(),
+			Verification: verification,
+		})
+	}
+	if verification.Status == shipping.AddressCorrected && !req.AddressConfirmed {
+		return c.JSON(http.StatusOK, GetShippingRatesResponse{Verification: verification})
+	}
+
+	// A verified address is rated as the carrier writes it; one the customer kept over
+	// a correction, or that couldn't be checked, as entered
+	shipTo := req.ShipTo
+	if verification.Status == shipping.AddressVerified {
+		shipTo = verification.Address
+	}
+
 	shippingReq := &shipping.ShippingQuoteRequest{
 		ItemCounts: *counts,
-		ShipTo:     req.ShipTo,
+		ShipTo:     shipTo,
 	}
 
-	quote, err := h.shippingService.GetShippingQuote(shippingReq)
+	quote, err := h.shippingService.GetShippingQuoteWithContext(c.Request().Context(), shippingReq)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get shipping rates")
 	}
@@ -96,11 +122,22 @@ func (h *ShippingHandler) GetShippingRates(c echo.Context) error {
 		Options:       quote.Options,
 		DefaultOption: quote.DefaultOption,
 		Error:         quote.Error,
+		Verification:  verification,
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// verifyAddress checks a destination with EasyPost. An outage is logged and the
+// address taken as unverified rather than holding up checkout.
+func (h *ShippingHandler) verifyAddress(c echo.Context, addr shipping.Address) *shipping.AddressVerification {
+	verification, err := h.shippingService.VerifyAddress(c.Request().Context(), addr)
+	if err != nil {
+		slog.Warn("failed to verify shipping address, using it as entered", "error", err, "postal_code", addr.PostalCode)
+	}
+	return verification
+}
+
 // getSessionID extracts session ID from cookie
 func (h *ShippingHandler) getSessionID(c echo.Context) (string, error) {
 	cookie, err := c.Cookie("session_id")
@@ -350,6 +387,25 @@ func (h *ShippingHandler) SaveShippingSelection(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to serialize shipping address")
 	}
 
+	// The address is checked again rather than trusting the browser's word for it;
+	// the verification from quoting the rates is usually still cached
+	var shipTo shipping.Address
+	if err := json.Unmarshal(shippingAddressJSON, &shipTo); err != nil || shipTo.AddressLine1 == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Shipping address is required")
+	}
+	verification := h.verifyAddress(c, shipTo)
+	if !verification.Deliverable() {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":        verification.Message(),
+			"verification": verification,
+		})
+	}
+	verificationJSON, err := json.Marshal(verification)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to serialize address verification")
+	}
+	verifiedAt := sql.NullTime{Time: verification.VerifiedAt, Valid: verification.Status != shipping.AddressUnverified}
+
 	// Check if session already has a shipping selection
 	_, err = h.queries.GetSessionShippingSelection(ctx, sessionID)
 	if err == sql.ErrNoRows {
@@ -371,6 +427,10 @@ func (h *ShippingHandler) SaveShippingSelection(c echo.Context) error {
 			CartSnapshotJson:    string(cartSnapshotJSON),
 			ShippingAddressJson: string(shippingAddressJSON),
 			IsValid:             sql.NullBool{Bool: true, Valid: true},
+
+			AddressVerificationStatus: verification.Status,
+			AddressVerificationJson:   string(verificationJSON),
+			AddressVerifiedAt:         verifiedAt,
 		})
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save shipping selection")
@@ -395,15 +455,37 @@ func (h *ShippingHandler) SaveShippingSelection(c echo.Context) error {
 			CartSnapshotJson:    string(cartSnapshotJSON),
 			ShippingAddressJson: string(shippingAddressJSON),
 			IsValid:             sql.NullBool{Bool: true, Valid: true},
+
+			AddressVerificationStatus: verification.Status,
+			AddressVerificationJson:   string(verificationJSON),
+			AddressVerifiedAt:         verifiedAt,
 		})
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update shipping selection")
 		}
 	}
 
+	// A signed-in customer's cart quotes rates to this address next time
+	if user, ok := auth.GetDBUser(c); ok {
+		if err := h.queries.UpsertUserDefaultAddress(ctx, db.UpsertUserDefaultAddressParams{
+			UserID:             user.ID,
+			Name:               shipTo.Name,
+			AddressLine1:       shipTo.AddressLine1,
+			AddressLine2:       shipTo.AddressLine2,
+			CityLocality:       shipTo.CityLocality,
+			StateProvince:      shipTo.StateProvince,
+			PostalCode:         shipTo.PostalCode,
+			CountryCode:        shipTo.CountryCode,
+			VerificationStatus: verification.Status,
+		}); err != nil {
+			slog.Error("failed to save default shipping address", "error", err, "user_id", user.ID)
+		}
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"status":  "success",
-		"rate_id": req.RateID,
+		"status":               "success",
+		"rate_id":              req.RateID,
+		"address_verification": verification.Status,
 	})
 }
 
@@ -411,6 +493,9 @@ func (h *ShippingHandler) SaveShippingSelection(c echo.Context) error {
 type GetShippingSelectionResponse struct {
 	Selection       *ShippingSelectionData `json:"selection"`
 	ShippingAddress map[string]interface{} `json:"shipping_address"`
+	// DefaultAddress is the signed-in customer's saved address, which the cart quotes
+	// rates to without asking when there's no valid selection
+	DefaultAddress *shipping.Address `json:"default_address,omitempty"`
 }
 
 type ShippingSelectionData struct {
@@ -422,23 +507,26 @@ type ShippingSelectionData struct {
 	DeliveryDays  int64  `json:"delivery_days"`
 	EstimatedDate string `json:"estimated_date"`
 	IsValid       bool   `json:"is_valid"`
+	// AddressVerification is the shipping.Address* status of the address it was rated for
+	AddressVerification string `json:"address_verification"`
 }
 
 func (h *ShippingHandler) GetShippingSelection(c echo.Context) error {
 	ctx := c.Request().Context()
+	defaultAddress := h.defaultAddress(c)
 
 	// Get session ID
 	sessionID, err := h.getSessionID(c)
 	if err != nil {
 		// No session, return empty response
-		return c.JSON(http.StatusOK, GetShippingSelectionResponse{})
+		return c.JSON(http.StatusOK, GetShippingSelectionResponse{DefaultAddress: defaultAddress})
 	}
 
 	// Get saved shipping selection
 	selection, err := h.queries.GetSessionShippingSelection(ctx, sessionID)
 	if err == sql.ErrNoRows {
 		// No saved selection
-		return c.JSON(http.StatusOK, GetShippingSelectionResponse{})
+		return c.JSON(http.StatusOK, GetShippingSelectionResponse{DefaultAddress: defaultAddress})
 	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get shipping selection")
@@ -465,6 +553,7 @@ func (h *ShippingHandler) GetShippingSelection(c echo.Context) error {
 		}
 		return c.JSON(http.StatusOK, GetShippingSelectionResponse{
 			ShippingAddress: shippingAddress,
+			DefaultAddress:  defaultAddress,
 		})
 	}
 
@@ -499,13 +588,40 @@ func (h *ShippingHandler) GetShippingSelection(c echo.Context) error {
 			DeliveryDays:  deliveryDays,
 			EstimatedDate: estimatedDate,
 			IsValid:       isValid,
+
+			AddressVerification: selection.AddressVerificationStatus,
 		},
 		ShippingAddress: shippingAddress,
+		DefaultAddress:  defaultAddress,
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// defaultAddress is the signed-in customer's saved shipping address, or nil
+func (h *ShippingHandler) defaultAddress(c echo.Context) *shipping.Address {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return nil
+	}
+	saved, err := h.queries.GetUserDefaultAddress(c.Request().Context(), user.ID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("failed to get default shipping address", "error", err, "user_id", user.ID)
+		}
+		return nil
+	}
+	return &shipping.Address{
+		Name:          saved.Name,
+		AddressLine1:  saved.AddressLine1,
+		AddressLine2:  saved.AddressLine2,
+		CityLocality:  saved.CityLocality,
+		StateProvince: saved.StateProvince,
+		PostalCode:    saved.PostalCode,
+		CountryCode:   saved.CountryCode,
+	}
+}
+
 // InvalidateShipping invalidates the shipping selection for a session
 func (h *ShippingHandler) InvalidateShipping(ctx echo.Context, sessionID string) error {
 	return h.queries.InvalidateSessionShipping(ctx.Request().Context(), sessionID)
@@ -571,22 +687,26 @@ func (h *ShippingHandler) DownloadLabel(c echo.Context) error {
 	return echo.NewHTTPError(http.StatusNotImplemented, "Label download not yet implemented")
 }
 
+// ValidateAddress checks an address without quoting rates, e.g. as the customer
+// leaves the address form
 func (h *ShippingHandler) ValidateAddress(c echo.Context) error {
 	var addr shipping.Address
 	if err := c.Bind(&addr); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid address data")
 	}
-
-	if err := h.shippingService.ValidateAddress(addr); err != nil {
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"valid": false,
-			"error": err.Error(),
-		})
+	if addr.AddressLine1 == "" || addr.PostalCode == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Street address and ZIP code are required")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"valid": true,
-	})
+	verification := h.verifyAddress(c, addr)
+	response := map[string]interface{}{
+		"valid":        verification.Deliverable(),
+		"verification": verification,
+	}
+	if !verification.Deliverable() {
+		response["error"] = verification.Message()
+	}
+	return c.JSON(http.StatusOK, response)
 }
 
 func toInt64Value(v interface{}) int64 {
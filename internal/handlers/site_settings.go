@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+type SiteSettingsHandler struct {
+	storage *storage.Storage
+}
+
+func NewSiteSettingsHandler(storage *storage.Storage) *SiteSettingsHandler {
+	return &SiteSettingsHandler{
+		storage: storage,
+	}
+}
+
+func siteSettingsURL(flash, errorMsg string) string {
+	target := "/admin/settings"
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// HandleSiteSettings shows the settings form
+func (h *SiteSettingsHandler) HandleSiteSettings(c echo.Context) error {
+	values := settings.For(h.storage.Queries).Values(c.Request().Context())
+	return Render(c, admin.SiteSettingsPage(c, values, nil, c.QueryParam("saved"), c.QueryParam("error")))
+}
+
+// parseSiteSettingsForm validates every setting, returning the values to save and
+// a message for each field that failed
+func parseSiteSettingsForm(formValue func(string) string) (settings.Values, map[string]string) {
+	values := make(settings.Values, len(settings.Definitions))
+	fieldErrors := make(map[string]string)
+	for _, def := range settings.Definitions {
+		raw := formValue(def.Key)
+		value, err := def.Validate(raw)
+		if err != nil {
+			fieldErrors[def.Key] = fmt.Sprintf("%s %s", def.Label, err)
+			// Show what was typed so it can be corrected
+			value = raw
+		}
+		values[def.Key] = value
+	}
+	return values, fieldErrors
+}
+
+// HandleSaveSiteSettings validates and saves the whole form. Nothing is saved when
+// any field is invalid.
+func (h *SiteSettingsHandler) HandleSaveSiteSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	values, fieldErrors := parseSiteSettingsForm(c.FormValue)
+	if len(fieldErrors) > 0 {
+		return Render(c, admin.SiteSettingsPage(c, values, fieldErrors, "", "Some settings need fixing before they can be saved"))
+	}
+
+	store := settings.For(h.storage.Queries)
+	before := store.Values(ctx)
+
+	var changed []string
+	for _, def := range settings.Definitions {
+		if values.String(def.Key) != before.String(def.Key) {
+			changed = append(changed, def.Key)
+		}
+	}
+	if len(changed) == 0 {
+		return c.Redirect(http.StatusSeeOther, siteSettingsURL("Nothing changed", ""))
+	}
+
+	if err := h.saveSettings(ctx, values, changed); err != nil {
+		slog.Error("failed to save site settings", "error", err)
+		return Render(c, admin.SiteSettingsPage(c, values, nil, "", "Could not save settings"))
+	}
+	store.Invalidate()
+
+	slog.Info("site settings updated", "keys", changed, "by", adminActor(c))
+	return c.Redirect(http.StatusSeeOther, siteSettingsURL("Settings saved", ""))
+}
+
+// saveSettings writes the changed keys in one transaction
+func (h *SiteSettingsHandler) saveSettings(ctx context.Context, values settings.Values, keys []string) error {
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin settings update: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	for _, key := range keys {
+		if err := queries.SetSiteConfig(ctx, db.SetSiteConfigParams{
+			Key:   key,
+			Value: values.String(key),
+		}); err != nil {
+			return fmt.Errorf("failed to save %s: %w", key, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+)
+
+func TestParseSiteSettingsForm(t *testing.T) {
+	form := map[string]string{}
+	for key, value := range settings.Defaults() {
+		form[key] = value
+	}
+	form[settings.TaxNexusStates] = "wi, mn"
+	form[settings.AnnouncementEnabled] = "true"
+	form[settings.AnnouncementText] = "  Spring drop is live  "
+
+	values, fieldErrors := parseSiteSettingsForm(func(key string) string { return form[key] })
+	assert.Empty(t, fieldErrors)
+	assert.Equal(t, []string{"WI", "MN"}, values.TaxNexusStates())
+	banner, ok := values.Announcement()
+	assert.True(t, ok)
+	assert.Equal(t, "Spring drop is live", banner.Text)
+
+	// An unchecked box isn't submitted at all
+	delete(form, settings.AnnouncementEnabled)
+	form[settings.ContactEmail] = "not an email"
+	form[settings.InstagramURL] = "instagram.com/logans3d"
+	values, fieldErrors = parseSiteSettingsForm(func(key string) string { return form[key] })
+	assert.False(t, values.Bool(settings.AnnouncementEnabled))
+	assert.Len(t, fieldErrors, 2)
+	assert.Equal(t, "Contact email must be an email address", fieldErrors[settings.ContactEmail])
+	assert.Equal(t, "not an email", values.ContactEmail(), "invalid input is kept for correcting")
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/sms"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// SMSHandler takes Twilio's webhook for texts sent to the store's number
+type SMSHandler struct {
+	smsService *sms.Service
+	// baseURL is the public site URL Twilio signs its webhook requests against
+	baseURL string
+}
+
+func NewSMSHandler(smsService *sms.Service, baseURL string) *SMSHandler {
+	return &SMSHandler{
+		smsService: smsService,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// twimlResponse is the TwiML answer to an inbound text; an empty one sends no reply
+type twimlResponse struct {
+	XMLName xml.Name `xml:"Response"`
+	Message string   `xml:"Message,omitempty"`
+}
+
+// HandleInbound records STOP, START and HELP replies to the store's number
+func (h *SMSHandler) HandleInbound(c echo.Context) error {
+	params, err := c.FormParams()
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	fullURL := h.baseURL + c.Request().RequestURI
+	if !h.smsService.ValidateWebhook(fullURL, params, c.Request().Header.Get("X-Twilio-Signature")) {
+		slog.Warn("rejected twilio webhook with a bad signature", "url", fullURL)
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	reply, err := h.smsService.HandleInbound(c.Request().Context(), params.Get("From"), params.Get("Body"))
+	if err != nil {
+		// Twilio retries failed webhooks, so only a storage failure is worth a 500
+		slog.Error("failed to handle inbound text", "error", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.XML(http.StatusOK, twimlResponse{Message: reply})
+}
+
+// textOrderUpdate texts the customer an order update. Runs in the background after
+// the order changes, so failures are logged rather than returned.
+func textOrderUpdate(queries *db.Queries, smsService *sms.Service, orderID, event string) {
+	ctx := context.Background()
+
+	order, err := queries.GetOrder(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to load order for text update", "error", err, "order_id", orderID, "event", event)
+		return
+	}
+
+	err = smsService.SendOrderUpdate(ctx, order, event)
+	switch {
+	case errors.Is(err, sms.ErrOptedOut):
+		slog.Info("customer opted out of texts, update not sent", "order_id", orderID, "event", event)
+	case errors.Is(err, sms.ErrNotConfigured):
+		slog.Info("twilio not configured, text update not sent", "order_id", orderID, "event", event)
+	case err != nil:
+		slog.Error("failed to text order update", "error", err, "order_id", orderID, "event", event)
+	}
+}
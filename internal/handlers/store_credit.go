@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// recordStoreCreditPayment links the store credit held at checkout to the order it
+// paid for
+func (h *PaymentHandler) recordStoreCreditPayment(ctx context.Context, orderID, checkoutSessionID string, amountCents int64) {
+	if err := h.queries.SetOrderStoreCredit(ctx, db.SetOrderStoreCreditParams{
+		StoreCreditCents: amountCents,
+		ID:               orderID,
+	}); err != nil {
+		slog.Error("failed to record store credit payment on order", "error", err, "order_id", orderID)
+	}
+	if err := h.queries.AttachStoreCreditRedemptionsToOrder(ctx, db.AttachStoreCreditRedemptionsToOrderParams{
+		OrderID:           sql.NullString{String: orderID, Valid: true},
+		CheckoutSessionID: checkoutSessionID,
+	}); err != nil {
+		slog.Error("failed to link store credit redemption to order", "error", err, "order_id", orderID, "session_id", checkoutSessionID)
+	}
+}
+
+// releaseStoreCreditHolds puts the store credit held for a checkout back on the
+// customer's account once the checkout expires unpaid
+func (h *PaymentHandler) releaseStoreCreditHolds(ctx context.Context, checkoutSessionID string) {
+	if err := h.storeCredit.ReleaseSession(ctx, checkoutSessionID, "Checkout expired"); err != nil {
+		slog.Error("failed to release store credit hold", "error", err, "session_id", checkoutSessionID)
+		return
+	}
+	slog.Info("store credit hold released for expired checkout", "session_id", checkoutSessionID)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// stripeSyncTimeout bounds a background push of one product to Stripe
+const stripeSyncTimeout = 30 * time.Second
+
+// queueStripeSync pushes a product to the Stripe catalog in the background so admin
+// saves don't wait on Stripe. Failures are logged and show up in the drift report.
+func (h *AdminHandler) queueStripeSync(productID string) {
+	if h.stripeCatalog == nil || productID == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), stripeSyncTimeout)
+		defer cancel()
+		if err := h.stripeCatalog.SyncProduct(ctx, productID); err != nil {
+			slog.Error("failed to sync product to stripe", "error", err, "product_id", productID)
+		}
+	}()
+}
+
+// queueStripeSyncForSku syncs the product a SKU belongs to
+func (h *AdminHandler) queueStripeSyncForSku(ctx context.Context, skuID string) {
+	if h.stripeCatalog == nil {
+		return
+	}
+	sku, err := h.storage.Queries.GetProductSku(ctx, skuID)
+	if err != nil {
+		slog.Error("failed to load sku for stripe sync", "error", err, "sku_id", skuID)
+		return
+	}
+	h.queueStripeSync(sku.ProductID)
+}
+
+// queueStripeArchive archives the Stripe product and price left behind by a deleted product or SKU
+func (h *AdminHandler) queueStripeArchive(stripeProductID, stripePriceID string) {
+	if h.stripeCatalog == nil || (stripeProductID == "" && stripePriceID == "") {
+		return
+	}
+	go func() {
+		if stripeProductID != "" {
+			if err := h.stripeCatalog.ArchiveProduct(stripeProductID); err != nil {
+				slog.Error("failed to archive stripe product", "error", err, "stripe_product_id", stripeProductID)
+			}
+		}
+		if stripePriceID != "" {
+			if err := h.stripeCatalog.ArchivePrice(stripePriceID); err != nil {
+				slog.Error("failed to archive stripe price", "error", err, "stripe_price_id", stripePriceID)
+			}
+		}
+	}()
+}
+
+// HandleStripeCatalog shows the catalog sync status. The drift check lists every
+// product and price in Stripe, so it only runs when asked for with ?check=1.
+func (h *AdminHandler) HandleStripeCatalog(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	products, err := h.storage.Queries.ListStripeCatalogProducts(ctx)
+	if err != nil {
+		slog.Error("failed to list products for stripe catalog", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+	}
+
+	data := admin.StripeCatalogData{
+		Enabled:  h.stripeCatalog != nil,
+		Products: products,
+		Synced:   c.QueryParam("synced"),
+	}
+	if data.Enabled && c.QueryParam("check") == "1" {
+		drift, err := h.stripeCatalog.Reconcile(ctx)
+		if err != nil {
+			slog.Error("failed to reconcile stripe catalog", "error", err)
+			data.Error = "Couldn't reach Stripe: " + err.Error()
+		} else {
+			data.Checked = true
+			data.Drift = drift
+		}
+	}
+
+	return Render(c, admin.StripeCatalog(c, data))
+}
+
+// HandleStripeCatalogSync pushes one product to Stripe right away
+func (h *AdminHandler) HandleStripeCatalogSync(c echo.Context) error {
+	productID := c.Param("id")
+	if h.stripeCatalog == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Stripe catalog sync is not enabled")
+	}
+
+	if err := h.stripeCatalog.SyncProduct(c.Request().Context(), productID); err != nil {
+		slog.Error("failed to sync product to stripe", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to sync product to Stripe")
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/admin/stripe-catalog?check=1&synced=1")
+}
+
+// HandleStripeCatalogSyncAll pushes every product to Stripe, e.g. after turning sync on
+func (h *AdminHandler) HandleStripeCatalogSyncAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	if h.stripeCatalog == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Stripe catalog sync is not enabled")
+	}
+
+	products, err := h.storage.Queries.ListStripeCatalogProducts(ctx)
+	if err != nil {
+		slog.Error("failed to list products for stripe sync", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
+	}
+
+	synced := 0
+	for _, product := range products {
+		if err := h.stripeCatalog.SyncProduct(ctx, product.ID); err != nil {
+			slog.Error("failed to sync product to stripe", "error", err, "product_id", product.ID)
+			continue
+		}
+		synced++
+	}
+	slog.Info("synced catalog to stripe", "synced", synced, "total", len(products))
+
+	return c.Redirect(http.StatusSeeOther, "/admin/stripe-catalog?check=1&synced="+strconv.Itoa(synced))
+}
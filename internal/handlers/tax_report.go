@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// taxReportYear reads ?year=, defaulting to the current year
+func taxReportYear(c echo.Context) int {
+	now := time.Now().Year()
+	year, err := strconv.Atoi(c.QueryParam("year"))
+	if err != nil || year < 2000 || year > now {
+		return now
+	}
+	return year
+}
+
+func (h *AdminHandler) taxSummaryRows(c echo.Context, year int) ([]db.GetTaxSummaryByStateMonthRow, error) {
+	rows, err := h.storage.Queries.GetTaxSummaryByStateMonth(c.Request().Context(), db.GetTaxSummaryByStateMonthParams{
+		StartDate: fmt.Sprintf("%d-01-01", year),
+		EndDate:   fmt.Sprintf("%d-01-01", year+1),
+	})
+	if err != nil {
+		slog.Error("failed to load tax summary", "error", err, "year", year)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// summarizeTaxRows totals the state-by-month rows by month and by state. States are
+// sorted by tax collected, largest first.
+func summarizeTaxRows(rows []db.GetTaxSummaryByStateMonthRow) (byMonth, byState []admin.TaxTotal, total admin.TaxTotal) {
+	months := make(map[string]*admin.TaxTotal)
+	states := make(map[string]*admin.TaxTotal)
+	total.Label = "Total"
+
+	add := func(t *admin.TaxTotal, row db.GetTaxSummaryByStateMonthRow) {
+		t.Orders += row.OrderCount
+		t.SubtotalCents += row.SubtotalCents
+		t.ShippingCents += row.ShippingCents
+		t.TaxCents += row.TaxCents
+		t.TotalCents += row.TotalCents
+	}
+
+	for _, row := range rows {
+		if months[row.Month] == nil {
+			months[row.Month] = &admin.TaxTotal{Label: row.Month}
+		}
+		state := row.State
+		if state == "" {
+			state = "Unknown"
+		}
+		if states[state] == nil {
+			states[state] = &admin.TaxTotal{Label: state}
+		}
+		add(months[row.Month], row)
+		add(states[state], row)
+		add(&total, row)
+	}
+
+	for _, t := range months {
+		byMonth = append(byMonth, *t)
+	}
+	sort.Slice(byMonth, func(i, j int) bool { return byMonth[i].Label < byMonth[j].Label })
+
+	for _, t := range states {
+		byState = append(byState, *t)
+	}
+	sort.Slice(byState, func(i, j int) bool {
+		if byState[i].TaxCents != byState[j].TaxCents {
+			return byState[i].TaxCents > byState[j].TaxCents
+		}
+		return byState[i].Label < byState[j].Label
+	})
+
+	return byMonth, byState, total
+}
+
+// markTaxNexus flags the states sales tax is collected in
+func markTaxNexus(byState []admin.TaxTotal, nexus []string) {
+	for i := range byState {
+		byState[i].Nexus = slices.Contains(nexus, byState[i].Label)
+	}
+}
+
+// HandleTaxReport shows tax collected by month and by state for a year
+func (h *AdminHandler) HandleTaxReport(c echo.Context) error {
+	year := taxReportYear(c)
+
+	rows, err := h.taxSummaryRows(c, year)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load tax report")
+	}
+
+	byMonth, byState, total := summarizeTaxRows(rows)
+	nexus := settings.For(h.storage.Queries).Values(c.Request().Context()).TaxNexusStates()
+	markTaxNexus(byState, nexus)
+	return Render(c, admin.TaxReport(c, admin.TaxReportData{
+		Year:    year,
+		Years:   taxReportYears(),
+		ByMonth: byMonth,
+		ByState: byState,
+		Total:   total,
+		Nexus:   nexus,
+	}))
+}
+
+// taxReportYears lists the years offered in the year picker, newest first
+func taxReportYears() []int {
+	now := time.Now().Year()
+	years := make([]int, 0, 5)
+	for y := now; y > now-5; y-- {
+		years = append(years, y)
+	}
+	return years
+}
+
+// HandleTaxReportExport downloads the state-by-month summary for a year as CSV
+func (h *AdminHandler) HandleTaxReportExport(c echo.Context) error {
+	year := taxReportYear(c)
+
+	rows, err := h.taxSummaryRows(c, year)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load tax report")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"sales-tax-%d.csv\"", year))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	defer w.Flush()
+
+	w.Write([]string{"Month", "State", "Orders", "Subtotal", "Shipping", "Tax", "Total"})
+	for _, row := range rows {
+		w.Write([]string{
+			row.Month,
+			row.State,
+			strconv.FormatInt(row.OrderCount, 10),
+			fmt.Sprintf("%.2f", float64(row.SubtotalCents)/100),
+			fmt.Sprintf("%.2f", float64(row.ShippingCents)/100),
+			fmt.Sprintf("%.2f", float64(row.TaxCents)/100),
+			fmt.Sprintf("%.2f", float64(row.TotalCents)/100),
+		})
+	}
+
+	return nil
+}
+
+// HandleTaxReconcile compares a month of orders with the tax Stripe charged on their
+// Checkout Sessions. It makes one Stripe call per order, so it runs a month at a time.
+func (h *AdminHandler) HandleTaxReconcile(c echo.Context) error {
+	month := c.QueryParam("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Choose a month to reconcile")
+	}
+
+	orders, err := h.storage.Queries.ListTaxOrdersForMonth(c.Request().Context(), month)
+	if err != nil {
+		slog.Error("failed to list orders for tax reconciliation", "error", err, "month", month)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load orders")
+	}
+
+	taxOrders := make([]stripe.TaxOrder, 0, len(orders))
+	for _, order := range orders {
+		taxOrders = append(taxOrders, stripe.TaxOrder{
+			OrderID:      order.ID,
+			CustomerName: order.CustomerName,
+			State:        order.State,
+			TaxCents:     order.TaxCents,
+			SessionID:    order.StripeCheckoutSessionID,
+		})
+	}
+
+	result := stripe.ReconcileTax(taxOrders, func(sessionID string) (stripe.SessionTax, error) {
+		if stripe.IsCheckoutDraftID(sessionID) {
+			return h.checkoutDraftTax(c.Request().Context(), sessionID)
+		}
+		tax, err := stripe.GetSessionTax(sessionID)
+		if err != nil {
+			slog.Error("failed to fetch checkout session tax", "error", err, "session_id", sessionID)
+		}
+		return tax, err
+	})
+	slog.Info("reconciled sales tax with stripe", "month", month, "orders", len(orders), "mismatches", result.Mismatches)
+
+	return Render(c, admin.TaxReconcile(c, month, result))
+}
+
+// checkoutDraftTax fetches the tax Stripe recorded for an order paid on our own
+// payment page, which has no Checkout Session to ask
+func (h *AdminHandler) checkoutDraftTax(ctx context.Context, draftID string) (stripe.SessionTax, error) {
+	draft, err := h.storage.Queries.GetCheckoutDraft(ctx, draftID)
+	if err != nil {
+		slog.Error("failed to fetch checkout draft for tax", "error", err, "draft_id", draftID)
+		return stripe.SessionTax{}, err
+	}
+	if draft.TaxTransactionID == "" {
+		return stripe.SessionTax{}, fmt.Errorf("checkout draft %s has no tax transaction recorded", draftID)
+	}
+	tax, err := stripe.GetDraftTax(draft.TaxTransactionID)
+	if err != nil {
+		slog.Error("failed to fetch checkout draft tax", "error", err, "draft_id", draftID)
+	}
+	return tax, err
+}
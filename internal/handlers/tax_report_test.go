@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestSummarizeTaxRows(t *testing.T) {
+	byMonth, byState, total := summarizeTaxRows([]db.GetTaxSummaryByStateMonthRow{
+		{Month: "2026-01", State: "WI", OrderCount: 3, SubtotalCents: 6000, ShippingCents: 900, TaxCents: 330, TotalCents: 7230},
+		{Month: "2026-01", State: "MN", OrderCount: 1, SubtotalCents: 2000, TaxCents: 138, TotalCents: 2138},
+		{Month: "2026-02", State: "WI", OrderCount: 2, SubtotalCents: 4000, ShippingCents: 600, TaxCents: 220, TotalCents: 4820},
+		{Month: "2026-02", State: "", OrderCount: 1, SubtotalCents: 1500, TotalCents: 1500},
+	})
+
+	assert.Len(t, byMonth, 2)
+	assert.Equal(t, "2026-01", byMonth[0].Label)
+	assert.Equal(t, int64(4), byMonth[0].Orders)
+	assert.Equal(t, int64(468), byMonth[0].TaxCents)
+	assert.Equal(t, int64(220), byMonth[1].TaxCents)
+
+	assert.Len(t, byState, 3)
+	assert.Equal(t, "WI", byState[0].Label)
+	assert.Equal(t, int64(550), byState[0].TaxCents)
+	assert.Equal(t, "MN", byState[1].Label)
+	assert.Equal(t, "Unknown", byState[2].Label)
+
+	assert.Equal(t, int64(7), total.Orders)
+	assert.Equal(t, int64(688), total.TaxCents)
+	assert.Equal(t, int64(15688), total.TotalCents)
+
+	markTaxNexus(byState, []string{"WI"})
+	assert.True(t, byState[0].Nexus)
+	assert.False(t, byState[1].Nexus)
+	assert.False(t, byState[2].Nexus)
+}
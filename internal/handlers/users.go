@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/loganlanou/logans3d-v4/views/admin"
@@ -138,16 +140,41 @@ func (h *UserHandler) HandleUserDetail(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Failed to fetch collections: "+err.Error())
 	}
 
-	// Get email history by user's email address (catches emails sent before registration)
-	emails, err := h.storage.Queries.GetEmailHistoryByEmail(ctx, db.GetEmailHistoryByEmailParams{
+	// Get email history linked to the user, or sent to their address (catches emails
+	// sent before registration)
+	emails, err := h.storage.Queries.ListUserEmailHistory(ctx, db.ListUserEmailHistoryParams{
+		UserID:         sql.NullString{String: userID, Valid: true},
 		RecipientEmail: userStats.Email,
-		Limit:          5,
-		Offset:         0,
+		Limit:          20,
 	})
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to fetch email history: "+err.Error())
 	}
 
+	// Get email preferences; a user who never saved any gets the defaults
+	emailPrefs := admin.UserEmailPreferences{OrderUpdates: true, ShippingUpdates: true, AbandonedCart: true, BackInStock: true}
+	prefs, err := h.storage.Queries.GetEmailPreferencesByEmail(ctx, userStats.Email)
+	if err == nil {
+		emailPrefs = admin.UserEmailPreferences{
+			Saved:           true,
+			OrderUpdates:    email.PreferenceOn(prefs.OrderUpdates),
+			ShippingUpdates: email.PreferenceOn(prefs.ShippingUpdates),
+			AbandonedCart:   prefs.AbandonedCart.Valid && prefs.AbandonedCart.Int64 == 1,
+			Promotional:     prefs.Promotional.Valid && prefs.Promotional.Int64 == 1,
+			Newsletter:      prefs.Newsletter.Valid && prefs.Newsletter.Int64 == 1,
+			ProductUpdates:  prefs.ProductUpdates.Valid && prefs.ProductUpdates.Int64 == 1,
+			BackInStock:     email.PreferenceOn(prefs.BackInStock),
+		}
+	} else if err != sql.ErrNoRows {
+		return c.String(http.StatusInternalServerError, "Failed to fetch email preferences: "+err.Error())
+	}
+
+	// Get store credit; an account never granted any has none
+	storeCredit, err := storecredit.NewLedger(h.storage.Queries).Balance(ctx, userID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to fetch store credit: "+err.Error())
+	}
+
 	// Handle LifetimeSpendCents interface{}
 	var lifetimeSpend int64
 	if userStats.LifetimeSpendCents != nil {
@@ -178,6 +205,7 @@ func (h *UserHandler) HandleUserDetail(c echo.Context) error {
 		CollectionsCount:    userStats.CollectionsCount,
 		ActiveCartsCount:    userStats.ActiveCartsCount,
 		AbandonedCartsCount: userStats.AbandonedCartsCount,
+		StoreCreditCents:    storeCredit,
 	}
 
 	// Convert orders to display format
@@ -297,7 +325,7 @@ func (h *UserHandler) HandleUserDetail(c echo.Context) error {
 		})
 	}
 
-	return Render(c, admin.UserDetail(c, user, orderList, activeCartList, abandonedCartList, favoriteList, collectionList, emailList))
+	return Render(c, admin.UserDetail(c, user, orderList, activeCartList, abandonedCartList, favoriteList, collectionList, emailList, emailPrefs))
 }
 
 // Helper functions
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// buildVariantMatrix lays a product's SKUs out as styles (rows) by sizes (columns).
+// Columns follow the product's size configuration; sizes that only appear on SKUs
+// are appended so nothing editable is hidden.
+func buildVariantMatrix(styles []db.ProductStyle, sizeConfigs []db.GetAllProductSizeConfigsRow, skus []db.GetProductSkusRow) admin.VariantMatrix {
+	var matrix admin.VariantMatrix
+
+	columnIndex := make(map[string]int)
+	for _, cfg := range sizeConfigs {
+		columnIndex[cfg.SizeID] = len(matrix.Sizes)
+		matrix.Sizes = append(matrix.Sizes, admin.VariantMatrixSize{
+			ID:          cfg.SizeID,
+			DisplayName: cfg.SizeDisplayName,
+			Enabled:     !cfg.IsEnabled.Valid || cfg.IsEnabled.Bool,
+		})
+	}
+	for _, sku := range skus {
+		if _, ok := columnIndex[sku.SizeID]; !ok {
+			columnIndex[sku.SizeID] = len(matrix.Sizes)
+			matrix.Sizes = append(matrix.Sizes, admin.VariantMatrixSize{
+				ID:          sku.SizeID,
+				DisplayName: sku.SizeDisplayName,
+			})
+		}
+	}
+
+	rowIndex := make(map[string]int, len(styles))
+	for _, style := range styles {
+		rowIndex[style.ID] = len(matrix.Rows)
+		matrix.Rows = append(matrix.Rows, admin.VariantMatrixRow{
+			StyleID:   style.ID,
+			StyleName: style.Name,
+			Cells:     make([]admin.VariantMatrixCell, len(matrix.Sizes)),
+		})
+	}
+
+	for _, sku := range skus {
+		row, ok := rowIndex[sku.ProductStyleID]
+		if !ok {
+			continue
+		}
+		matrix.Rows[row].Cells[columnIndex[sku.SizeID]] = admin.VariantMatrixCell{
+			SkuID:                sku.ID,
+			Sku:                  sku.Sku,
+			PriceAdjustmentCents: int64FromNull(sku.PriceAdjustmentCents),
+			Stock:                int64FromNull(sku.StockQuantity),
+			Active:               sku.IsActive.Bool,
+		}
+		matrix.SkuCount++
+	}
+	return matrix
+}
+
+// variantMatrixUpdate is one SKU's values from the bulk editor form
+type variantMatrixUpdate struct {
+	SkuID                string
+	PriceAdjustmentCents int64
+	Stock                int64
+	Active               bool
+}
+
+// parseVariantMatrixForm reads every SKU cell from the bulk editor. Any invalid cell
+// rejects the whole form so a save never applies half the grid.
+func parseVariantMatrixForm(form url.Values) ([]variantMatrixUpdate, error) {
+	skuIDs := form["sku_id"]
+	updates := make([]variantMatrixUpdate, 0, len(skuIDs))
+
+	for _, skuID := range skuIDs {
+		if skuID == "" {
+			continue
+		}
+		sku := strings.TrimSpace(form.Get("sku_label_" + skuID))
+		if sku == "" {
+			sku = skuID
+		}
+
+		priceCents, err := parseCurrencyToCents(strings.TrimSpace(form.Get("price_" + skuID)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid price adjustment for %s", sku)
+		}
+		stock, err := strconv.ParseInt(strings.TrimSpace(form.Get("stock_"+skuID)), 10, 64)
+		if err != nil || stock < 0 {
+			return nil, fmt.Errorf("invalid stock for %s", sku)
+		}
+
+		updates = append(updates, variantMatrixUpdate{
+			SkuID:                skuID,
+			PriceAdjustmentCents: priceCents,
+			Stock:                stock,
+			Active:               form.Get("active_"+skuID) != "",
+		})
+	}
+	return updates, nil
+}
+
+func variantMatrixURL(productID, flash, errorMsg string) string {
+	target := fmt.Sprintf("/admin/product/%s/variants", productID)
+	switch {
+	case errorMsg != "":
+		target += "?error=" + url.QueryEscape(errorMsg)
+	case flash != "":
+		target += "?saved=" + url.QueryEscape(flash)
+	}
+	return target
+}
+
+// HandleVariantMatrix shows every style and size of a product in one editable grid
+func (h *AdminHandler) HandleVariantMatrix(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	product, err := h.storage.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+		}
+		slog.Error("failed to get product for variant matrix", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load product")
+	}
+
+	styles, err := h.storage.Queries.GetProductStyles(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load product styles", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load variants")
+	}
+
+	sizeConfigs, err := h.storage.Queries.GetAllProductSizeConfigs(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load product size configs", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load variants")
+	}
+
+	skus, err := h.storage.Queries.GetProductSkus(ctx, productID)
+	if err != nil {
+		slog.Error("failed to load product SKUs", "error", err, "product_id", productID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load variants")
+	}
+
+	matrix := buildVariantMatrix(styles, sizeConfigs, skus)
+	return Render(c, admin.VariantMatrixPage(c, product, matrix, c.QueryParam("saved"), c.QueryParam("error")))
+}
+
+// HandleSaveVariantMatrix applies the whole bulk editor grid in a single transaction
+func (h *AdminHandler) HandleSaveVariantMatrix(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	form, err := c.FormParams()
+	if err != nil {
+		slog.Error("failed to read variant matrix form", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "Could not read the submitted grid"))
+	}
+
+	updates, err := parseVariantMatrixForm(form)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", err.Error()))
+	}
+	if len(updates) == 0 {
+		return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "There are no SKUs to save"))
+	}
+
+	tx, err := h.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("failed to begin variant matrix transaction", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "Could not save variants"))
+	}
+	defer tx.Rollback()
+
+	queries := h.storage.Queries.WithTx(tx)
+	for _, update := range updates {
+		affected, err := queries.UpdateSkuMatrixCell(ctx, db.UpdateSkuMatrixCellParams{
+			PriceAdjustmentCents: sql.NullInt64{Int64: update.PriceAdjustmentCents, Valid: true},
+			StockQuantity:        sql.NullInt64{Int64: update.Stock, Valid: true},
+			IsActive:             sql.NullBool{Bool: update.Active, Valid: true},
+			ID:                   update.SkuID,
+			ProductID:            productID,
+		})
+		if err != nil {
+			slog.Error("failed to update SKU from variant matrix", "error", err, "sku_id", update.SkuID, "product_id", productID)
+			return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "Could not save variants"))
+		}
+		if affected == 0 {
+			slog.Warn("variant matrix referenced a SKU outside the product", "sku_id", update.SkuID, "product_id", productID)
+			return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "A SKU in the grid no longer belongs to this product; reload and try again"))
+		}
+		// Mid-sale, a changed adjustment is kept for when the sale ends
+		if err := queries.KeepSkuSalePrice(ctx, update.SkuID); err != nil {
+			slog.Error("failed to keep SKU sale price", "error", err, "sku_id", update.SkuID, "product_id", productID)
+			return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "Could not save variants"))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("failed to commit variant matrix", "error", err, "product_id", productID)
+		return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, "", "Could not save variants"))
+	}
+
+	slog.Info("variant matrix saved", "product_id", productID, "sku_count", len(updates))
+	h.queueStripeSync(productID)
+	return c.Redirect(http.StatusSeeOther, variantMatrixURL(productID, fmt.Sprintf("Saved %d SKUs", len(updates)), ""))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/url"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildVariantMatrix(t *testing.T) {
+	styles := []db.ProductStyle{
+		{ID: "red", Name: "Red"},
+		{ID: "blue", Name: "Blue"},
+	}
+	sizeConfigs := []db.GetAllProductSizeConfigsRow{
+		{SizeID: "s", SizeDisplayName: "Small", IsEnabled: sql.NullBool{Bool: true, Valid: true}},
+		{SizeID: "m", SizeDisplayName: "Medium", IsEnabled: sql.NullBool{Bool: false, Valid: true}},
+	}
+	skus := []db.GetProductSkusRow{
+		{ID: "sku-1", ProductStyleID: "red", SizeID: "s", Sku: "RED-S", PriceAdjustmentCents: sql.NullInt64{Int64: 250, Valid: true}, StockQuantity: sql.NullInt64{Int64: 4, Valid: true}, IsActive: sql.NullBool{Bool: true, Valid: true}},
+		{ID: "sku-2", ProductStyleID: "blue", SizeID: "l", Sku: "BLUE-L", SizeDisplayName: "Large"},
+	}
+
+	matrix := buildVariantMatrix(styles, sizeConfigs, skus)
+
+	require.Len(t, matrix.Sizes, 3, "sizes only found on SKUs get their own column")
+	assert.Equal(t, "Large", matrix.Sizes[2].DisplayName)
+	assert.False(t, matrix.Sizes[1].Enabled)
+	assert.Equal(t, 2, matrix.SkuCount)
+
+	require.Len(t, matrix.Rows, 2)
+	red := matrix.Rows[0]
+	assert.Equal(t, "RED-S", red.Cells[0].Sku)
+	assert.Equal(t, int64(250), red.Cells[0].PriceAdjustmentCents)
+	assert.Equal(t, int64(4), red.Cells[0].Stock)
+	assert.True(t, red.Cells[0].Active)
+	assert.Empty(t, red.Cells[1].SkuID)
+	assert.Equal(t, "sku-2", matrix.Rows[1].Cells[2].SkuID)
+}
+
+func TestParseVariantMatrixForm(t *testing.T) {
+	form := url.Values{
+		"sku_id":   {"a", "b"},
+		"price_a":  {"1.50"},
+		"stock_a":  {"3"},
+		"active_a": {"true"},
+		"price_b":  {"0"},
+		"stock_b":  {"0"},
+	}
+
+	updates, err := parseVariantMatrixForm(form)
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.Equal(t, variantMatrixUpdate{SkuID: "a", PriceAdjustmentCents: 150, Stock: 3, Active: true}, updates[0])
+	assert.False(t, updates[1].Active)
+
+	form.Set("stock_b", "-1")
+	form.Set("sku_label_b", "BLUE-L")
+	_, err = parseVariantMatrixForm(form)
+	assert.EqualError(t, err, "invalid stock for BLUE-L")
+}
@@ -0,0 +1,136 @@
+// Package health runs dependency probes for the deep health check. Each probe gets
+// its own timeout and they run in parallel, so one slow provider can't hold up the
+// report or hide the others.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Dependency and overall statuses
+const (
+	StatusOK       = "ok"
+	StatusError    = "error"
+	StatusSkipped  = "skipped"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// ErrNotConfigured is returned by probes for dependencies with no credentials set;
+// they're reported as skipped rather than failing
+var ErrNotConfigured = errors.New("not configured")
+
+// Check is one dependency. A failing critical check takes the whole service down;
+// anything else only degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is every check's result plus the overall status
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Healthy reports whether every critical dependency is up
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+// Run probes every check in parallel, each with its own timeout. Results keep the
+// order of checks.
+func Run(ctx context.Context, timeout time.Duration, checks []Check) Report {
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, timeout, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusError {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := Result{Name: check.Name, Status: StatusOK, Critical: check.Critical}
+	start := time.Now()
+
+	// A probe that ignores its context still can't hold up the report
+	done := make(chan error, 1)
+	go func() { done <- check.Probe(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		result.Status = StatusSkipped
+		result.Error = err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Status = StatusError
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	case err != nil:
+		result.Status = StatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ProbeHTTP sends a request and fails on anything but a 2xx. The body is drained so
+// the connection can be reused.
+func ProbeHTTP(ctx context.Context, client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
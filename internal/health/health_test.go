@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("connection refused") }
+	unconfigured := func(context.Context) error { return ErrNotConfigured }
+	hanging := func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	report := Run(context.Background(), 50*time.Millisecond, []Check{
+		{Name: "database", Critical: true, Probe: ok},
+		{Name: "stripe", Probe: failing},
+		{Name: "easypost", Probe: unconfigured},
+		{Name: "clerk", Probe: hanging},
+	})
+
+	assert.Equal(t, StatusDegraded, report.Status, "only non-critical checks failed")
+	assert.True(t, report.Healthy())
+	require.Len(t, report.Checks, 4)
+	assert.Equal(t, Result{Name: "database", Status: StatusOK, Critical: true}, withoutLatency(report.Checks[0]))
+	assert.Equal(t, Result{Name: "stripe", Status: StatusError, Error: "connection refused"}, withoutLatency(report.Checks[1]))
+	assert.Equal(t, StatusSkipped, report.Checks[2].Status)
+	assert.Equal(t, Result{Name: "clerk", Status: StatusError, Error: "timed out after 50ms"}, withoutLatency(report.Checks[3]))
+	assert.Less(t, report.Checks[3].LatencyMs, int64(500), "a probe that ignores its context is abandoned at the timeout")
+
+	report = Run(context.Background(), time.Second, []Check{
+		{Name: "database", Critical: true, Probe: failing},
+		{Name: "stripe", Probe: ok},
+	})
+	assert.Equal(t, StatusDown, report.Status)
+	assert.False(t, report.Healthy())
+
+	report = Run(context.Background(), time.Second, []Check{{Name: "stripe", Probe: unconfigured}})
+	assert.Equal(t, StatusOK, report.Status, "unconfigured providers don't degrade the service")
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	err = ProbeHTTP(context.Background(), server.Client(), req)
+	assert.EqualError(t, err, "unexpected status 401")
+
+	req.Header.Set("Authorization", "Bearer sk_test")
+	assert.NoError(t, ProbeHTTP(context.Background(), server.Client(), req))
+}
+
+func withoutLatency(r Result) Result {
+	r.LatencyMs = 0
+	return r
+}
@@ -0,0 +1,124 @@
+// Package ical writes iCalendar (RFC 5545) files for the public events calendar: a
+// single event for "add to calendar" and the whole schedule as a subscribable feed.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// ContentType is served with .ics files
+const ContentType = "text/calendar; charset=utf-8"
+
+// Event is one calendar entry
+type Event struct {
+	UID         string
+	Title       string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	// End is optional; events without one are given a default length
+	End      time.Time
+	Modified time.Time
+}
+
+// DefaultDuration is used for events without an end time
+const DefaultDuration = 2 * time.Hour
+
+// Times are written without a zone. Admins enter an event's local wall-clock time and
+// it's stored as-is, so "floating" times keep it at 10am wherever the calendar is.
+const (
+	floatingLayout = "20060102T150405"
+	utcLayout      = "20060102T150405Z"
+)
+
+// Calendar renders events as an iCalendar document. name is shown by calendar apps
+// that subscribe to the feed.
+func Calendar(name string, events []Event, now time.Time) []byte {
+	var b bytes.Buffer
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//Logan's 3D Creations//Events//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(name))
+	}
+	for _, e := range events {
+		writeEvent(&b, e, now)
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return b.Bytes()
+}
+
+func writeEvent(b *bytes.Buffer, e Event, now time.Time) {
+	end := e.End
+	if end.IsZero() || !end.After(e.Start) {
+		end = e.Start.Add(DefaultDuration)
+	}
+	stamp := e.Modified
+	if stamp.IsZero() {
+		stamp = now
+	}
+
+	writeLine(b, "BEGIN:VEVENT")
+	writeLine(b, "UID:"+e.UID)
+	writeLine(b, "DTSTAMP:"+stamp.UTC().Format(utcLayout))
+	writeLine(b, "DTSTART:"+e.Start.Format(floatingLayout))
+	writeLine(b, "DTEND:"+end.Format(floatingLayout))
+	writeLine(b, "SUMMARY:"+escapeText(e.Title))
+	if e.Description != "" {
+		writeLine(b, "DESCRIPTION:"+escapeText(e.Description))
+	}
+	if e.Location != "" {
+		writeLine(b, "LOCATION:"+escapeText(e.Location))
+	}
+	if e.URL != "" {
+		writeLine(b, "URL:"+e.URL)
+	}
+	writeLine(b, "END:VEVENT")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// maxLineOctets is the longest a content line may be before it's folded
+const maxLineOctets = 75
+
+// writeLine writes a content line with CRLF, folding it onto continuation lines
+// (which start with a space) so none is longer than 75 octets. Folds never split a
+// UTF-8 character.
+func writeLine(b *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !startsRune(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the continuation line's length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// startsRune reports whether c is the first byte of a UTF-8 character
+func startsRune(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,60 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	out := string(Calendar("Logan's 3D Events", []Event{{
+		UID:         "abc@example.com",
+		Title:       "Maker Fair; Day 1, Hall B",
+		Description: "Live printing\nAll ages",
+		Location:    "Convention Center, 1 Main St",
+		URL:         "https://example.com/events/maker-fair",
+		Start:       start,
+	}}, now))
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "X-WR-CALNAME:Logan's 3D Events\r\n")
+	assert.Contains(t, out, "UID:abc@example.com\r\n")
+	assert.Contains(t, out, "DTSTAMP:20260301T120000Z\r\n")
+	assert.Contains(t, out, "DTSTART:20260315T100000\r\n")
+	assert.Contains(t, out, "DTEND:20260315T120000\r\n", "events without an end get the default length")
+	assert.Contains(t, out, `SUMMARY:Maker Fair\; Day 1\, Hall B`+"\r\n")
+	assert.Contains(t, out, `DESCRIPTION:Live printing\nAll ages`+"\r\n")
+	assert.Contains(t, out, `LOCATION:Convention Center\, 1 Main St`+"\r\n")
+}
+
+func TestCalendarUsesEndTime(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	out := string(Calendar("", []Event{{UID: "x", Title: "Show", Start: start, End: start.Add(30 * time.Hour)}}, start))
+
+	assert.Contains(t, out, "DTEND:20260316T160000\r\n")
+	assert.NotContains(t, out, "X-WR-CALNAME")
+}
+
+func TestWriteLineFolds(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("é", 100)
+	out := string(Calendar("", []Event{{UID: "x", Title: "t", Description: strings.Repeat("é", 100), Start: time.Now()}}, time.Now()))
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		assert.True(t, utf8.ValidString(line), "folds must not split characters")
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	assert.Contains(t, unfolded.String(), "\n"+long+"\n")
+}
@@ -0,0 +1,355 @@
+// Package images keeps resized copies of uploaded product images. Each upload is scaled
+// to a handful of preset widths and, where the encoders are installed, converted to
+// AVIF and WebP. The copies live outside /public and are served by /img/:preset/:filename,
+// which picks the best format the browser accepts.
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	// Decoders for the formats uploads arrive in
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/sync/singleflight"
+)
+
+// Preset is a named output width
+type Preset struct {
+	Name  string
+	Width int
+}
+
+// Presets are the widths generated for every image, smallest first
+var Presets = []Preset{
+	{"thumb", 160},
+	{"card", 480},
+	{"detail", 960},
+	{"full", 1600},
+}
+
+// PresetByName looks up a preset
+func PresetByName(name string) (Preset, bool) {
+	for _, p := range Presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Output formats. FormatOriginal is a resized JPEG, or PNG when the upload was a PNG
+// so transparency survives.
+const (
+	FormatAVIF     = "avif"
+	FormatWebP     = "webp"
+	FormatOriginal = ""
+)
+
+// ContentType returns the MIME type for a generated file
+func ContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".avif":
+		return "image/avif"
+	case ".webp":
+		return "image/webp"
+	case ".png":
+		return "image/png"
+	default:
+		return "image/jpeg"
+	}
+}
+
+// jpegQuality is used for resized JPEGs; the AVIF and WebP settings are in encoders
+const jpegQuality = 82
+
+// encoder converts a resized JPEG or PNG into another format with a command-line tool.
+// There's no pure Go AVIF or WebP encoder, so these need libavif's avifenc and
+// libwebp's cwebp on the PATH; without them only the resized originals are made.
+type encoder struct {
+	format  string
+	command string
+	args    func(in, out string) []string
+}
+
+var encoders = []encoder{
+	{FormatAVIF, "avifenc", func(in, out string) []string { return []string{"-q", "60", "-s", "6", in, out} }},
+	{FormatWebP, "cwebp", func(in, out string) []string { return []string{"-quiet", "-q", "80", in, "-o", out} }},
+}
+
+// ErrNotFound is returned when the source image doesn't exist
+var ErrNotFound = errors.New("image not found")
+
+// Pipeline generates and finds resized images
+type Pipeline struct {
+	// CacheDir holds the generated files, one directory per preset
+	CacheDir string
+	// SourceDirs are searched in order for the uploaded original
+	SourceDirs []string
+
+	group     singleflight.Group
+	once      sync.Once
+	available map[string]string // format -> encoder command path
+}
+
+// New returns a pipeline writing to cacheDir and reading originals from sourceDirs
+func New(cacheDir string, sourceDirs ...string) *Pipeline {
+	return &Pipeline{CacheDir: cacheDir, SourceDirs: sourceDirs}
+}
+
+var (
+	defaultPipeline     *Pipeline
+	defaultPipelineOnce sync.Once
+)
+
+// Default is the pipeline for product, style, portfolio and category banner uploads
+func Default() *Pipeline {
+	defaultPipelineOnce.Do(func() {
+		defaultPipeline = New("data/image-variants", "public/images/products", "public/images/products/styles", "public/images/portfolio", "public/images/categories")
+	})
+	return defaultPipeline
+}
+
+// Formats returns the extra formats that can be generated here, best first
+func (p *Pipeline) Formats() []string {
+	p.once.Do(func() {
+		p.available = make(map[string]string)
+		for _, enc := range encoders {
+			if path, err := exec.LookPath(enc.command); err == nil {
+				p.available[enc.format] = path
+			} else {
+				slog.Warn("image encoder not installed, skipping format", "format", enc.format, "command", enc.command)
+			}
+		}
+	})
+	var formats []string
+	for _, enc := range encoders {
+		if _, ok := p.available[enc.format]; ok {
+			formats = append(formats, enc.format)
+		}
+	}
+	return formats
+}
+
+// Source returns the path of an uploaded original. filename must be a bare file name.
+func (p *Pipeline) Source(filename string) (string, error) {
+	if filename == "" || filename != filepath.Base(filename) || strings.HasPrefix(filename, ".") {
+		return "", ErrNotFound
+	}
+	for _, dir := range p.SourceDirs {
+		path := filepath.Join(dir, filename)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// VariantPath is where the preset's copy of filename in format is kept
+func (p *Pipeline) VariantPath(preset Preset, filename, format string) string {
+	stem := strings.TrimSuffix(filename, filepath.Ext(filename))
+	ext := format
+	if ext == FormatOriginal {
+		ext = "jpg"
+		if strings.EqualFold(filepath.Ext(filename), ".png") {
+			ext = "png"
+		}
+	}
+	return filepath.Join(p.CacheDir, preset.Name, stem+"."+ext)
+}
+
+// Variant returns the path of the preset's copy of filename in format, generating it
+// if it's missing or older than the original. Formats without an encoder fall back
+// to FormatOriginal.
+func (p *Pipeline) Variant(ctx context.Context, preset Preset, filename, format string) (string, error) {
+	source, err := p.Source(filename)
+	if err != nil {
+		return "", err
+	}
+	if format != FormatOriginal {
+		if _, ok := p.encoderFor(format); !ok {
+			format = FormatOriginal
+		}
+	}
+
+	path := p.VariantPath(preset, filename, format)
+	if fresh(path, source) {
+		return path, nil
+	}
+
+	// Concurrent requests for the same image share one resize
+	_, err, _ = p.group.Do(source+"|"+preset.Name, func() (any, error) {
+		return nil, p.generatePreset(ctx, source, filename, preset)
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		// The encoder failed for this one; the resized original is always made
+		return p.VariantPath(preset, filename, FormatOriginal), nil
+	}
+	return path, nil
+}
+
+// Generate makes every preset and format for filename, replacing stale copies
+func (p *Pipeline) Generate(ctx context.Context, filename string) error {
+	source, err := p.Source(filename)
+	if err != nil {
+		return err
+	}
+	for _, preset := range Presets {
+		if _, err, _ := p.group.Do(source+"|"+preset.Name, func() (any, error) {
+			return nil, p.generatePreset(ctx, source, filename, preset)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateInBackground runs Generate for a fresh upload without holding up the request.
+// Anything it misses is generated on first request instead.
+func (p *Pipeline) GenerateInBackground(filename string) {
+	go func() {
+		if err := p.Generate(context.Background(), filename); err != nil {
+			slog.Error("failed to generate image variants", "error", err, "filename", filename)
+		}
+	}()
+}
+
+// Remove deletes every generated copy of filename
+func (p *Pipeline) Remove(filename string) {
+	if filename != filepath.Base(filename) {
+		return
+	}
+	formats := append([]string{FormatOriginal}, FormatAVIF, FormatWebP)
+	for _, preset := range Presets {
+		for _, format := range formats {
+			if err := os.Remove(p.VariantPath(preset, filename, format)); err != nil && !os.IsNotExist(err) {
+				slog.Error("failed to remove image variant", "error", err, "filename", filename, "preset", preset.Name)
+			}
+		}
+	}
+}
+
+func (p *Pipeline) encoderFor(format string) (encoder, bool) {
+	p.Formats()
+	if _, ok := p.available[format]; !ok {
+		return encoder{}, false
+	}
+	for _, enc := range encoders {
+		if enc.format == format {
+			return enc, true
+		}
+	}
+	return encoder{}, false
+}
+
+// generatePreset writes the resized original for one preset, then converts it to each
+// available format. A failed conversion is logged and skipped.
+func (p *Pipeline) generatePreset(ctx context.Context, source, filename string, preset Preset) error {
+	base := p.VariantPath(preset, filename, FormatOriginal)
+	if !fresh(base, source) {
+		if err := resizeFile(source, base, preset.Width); err != nil {
+			return fmt.Errorf("resize %s to %s: %w", filename, preset.Name, err)
+		}
+	}
+
+	for _, format := range p.Formats() {
+		out := p.VariantPath(preset, filename, format)
+		if fresh(out, source) {
+			continue
+		}
+		enc, _ := p.encoderFor(format)
+		if err := p.encode(ctx, enc, base, out); err != nil {
+			slog.Error("failed to encode image variant", "error", err, "filename", filename, "preset", preset.Name, "format", format)
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) encode(ctx context.Context, enc encoder, in, out string) error {
+	tmp := out + ".tmp." + enc.format
+	cmd := exec.CommandContext(ctx, p.available[enc.format], enc.args(in, tmp)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%s: %w: %s", enc.command, err, strings.TrimSpace(string(output)))
+	}
+	return os.Rename(tmp, out)
+}
+
+// resizeFile scales source down to width (never up) and writes it to dest
+func resizeFile(source, dest string, width int) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	src, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	img := Resize(src, width)
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	// Write beside the destination and rename so readers never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".resize-*")
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(dest) == ".png" {
+		err = png.Encode(tmp, img)
+	} else {
+		err = jpeg.Encode(tmp, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("encode: %w", err)
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// Resize scales img to width keeping its aspect ratio. Images already narrower are
+// returned as they are.
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// fresh reports whether path exists and is at least as new as source
+func fresh(path, source string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Before(sourceInfo.ModTime())
+}
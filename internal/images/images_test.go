@@ -0,0 +1,121 @@
+package images
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPipeline returns a pipeline over temp dirs with no encoders, so only the
+// resized originals are made
+func newTestPipeline(t *testing.T) (*Pipeline, string) {
+	t.Helper()
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "products")
+	require.NoError(t, os.MkdirAll(sourceDir, 0755))
+
+	p := New(filepath.Join(dir, "variants"), sourceDir)
+	p.once.Do(func() { p.available = map[string]string{} })
+	return p, sourceDir
+}
+
+func writeJPEG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+	}
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, jpeg.Encode(f, img, nil))
+}
+
+func imageSize(t *testing.T, path string) (int, int) {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	return cfg.Width, cfg.Height
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	assert.Equal(t, image.Rect(0, 0, 480, 240), Resize(src, 480).Bounds())
+	assert.Same(t, src, Resize(src, 1600), "never scaled up")
+}
+
+func TestVariantGeneratesPresets(t *testing.T) {
+	p, sourceDir := newTestPipeline(t)
+	writeJPEG(t, filepath.Join(sourceDir, "dragon.jpg"), 1200, 900)
+
+	card, _ := PresetByName("card")
+	path, err := p.Variant(context.Background(), card, "dragon.jpg", FormatWebP)
+	require.NoError(t, err)
+	assert.Equal(t, p.VariantPath(card, "dragon.jpg", FormatOriginal), path, "falls back without cwebp")
+	w, h := imageSize(t, path)
+	assert.Equal(t, 480, w)
+	assert.Equal(t, 360, h)
+
+	full, _ := PresetByName("full")
+	path, err = p.Variant(context.Background(), full, "dragon.jpg", FormatOriginal)
+	require.NoError(t, err)
+	w, _ = imageSize(t, path)
+	assert.Equal(t, 1200, w, "smaller originals keep their size")
+}
+
+func TestVariantRegeneratesWhenSourceChanges(t *testing.T) {
+	p, sourceDir := newTestPipeline(t)
+	source := filepath.Join(sourceDir, "dragon.jpg")
+	writeJPEG(t, source, 800, 800)
+	require.NoError(t, p.Generate(context.Background(), "dragon.jpg"))
+
+	thumb, _ := PresetByName("thumb")
+	path := p.VariantPath(thumb, "dragon.jpg", FormatOriginal)
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	writeJPEG(t, source, 800, 400)
+	path, err := p.Variant(context.Background(), thumb, "dragon.jpg", FormatOriginal)
+	require.NoError(t, err)
+	_, h := imageSize(t, path)
+	assert.Equal(t, 80, h)
+
+	p.Remove("dragon.jpg")
+	assert.NoFileExists(t, path)
+}
+
+func TestSourceRejectsPaths(t *testing.T) {
+	p, sourceDir := newTestPipeline(t)
+	writeJPEG(t, filepath.Join(sourceDir, "dragon.jpg"), 10, 10)
+
+	_, err := p.Source("dragon.jpg")
+	assert.NoError(t, err)
+	for _, name := range []string{"", "../products/dragon.jpg", "styles/dragon.jpg", ".hidden", "missing.jpg"} {
+		_, err := p.Source(name)
+		assert.ErrorIs(t, err, ErrNotFound, name)
+	}
+}
+
+func TestURLs(t *testing.T) {
+	assert.Equal(t, "/img/card/dragon.jpg", URL("card", "/public/images/products/dragon.jpg"))
+	assert.Equal(t, "/img/thumb/abc.png", URL("thumb", "/public/images/products/styles/abc.png"))
+	assert.Equal(t, "/img/detail/portfolio_abc.jpg", URL("detail", "/public/images/portfolio/portfolio_abc.jpg"))
+	assert.Equal(t, "/img/detail/category_abc.jpg", URL("detail", "/public/images/categories/category_abc.jpg"))
+	assert.Equal(t, "/public/og-images/product-1-multi.png", URL("card", "/public/og-images/product-1-multi.png"))
+
+	assert.Equal(t, "/img/thumb/dragon.jpg 160w, /img/card/dragon.jpg 480w, /img/detail/dragon.jpg 960w, /img/full/dragon.jpg 1600w",
+		SrcSet("/public/images/products/dragon.jpg"))
+	assert.Empty(t, SrcSet("/public/images/products/pending/dragon.jpg"))
+	assert.Empty(t, SrcSet("https://example.com/dragon.jpg"))
+}
@@ -0,0 +1,53 @@
+package images
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RoutePrefix is where resized images are served, as /img/:preset/:filename
+const RoutePrefix = "/img/"
+
+// uploadPrefixes are the public URLs of the originals the pipeline can resize
+var uploadPrefixes = []string{
+	"/public/images/products/styles/",
+	"/public/images/products/",
+	"/public/images/portfolio/",
+	"/public/images/categories/",
+}
+
+// filenameFromURL returns the upload's file name if imageURL is a product, style,
+// portfolio or category banner image
+func filenameFromURL(imageURL string) (string, bool) {
+	for _, prefix := range uploadPrefixes {
+		if name, ok := strings.CutPrefix(imageURL, prefix); ok && name != "" && name == path.Base(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// URL returns the preset's resized URL for an uploaded product or style image. Any
+// other URL, such as a generated OG image, is returned unchanged.
+func URL(preset, imageURL string) string {
+	name, ok := filenameFromURL(imageURL)
+	if !ok {
+		return imageURL
+	}
+	return RoutePrefix + preset + "/" + name
+}
+
+// SrcSet returns a srcset with every preset width for an uploaded image, or "" for
+// URLs the pipeline doesn't handle
+func SrcSet(imageURL string) string {
+	name, ok := filenameFromURL(imageURL)
+	if !ok {
+		return ""
+	}
+	entries := make([]string, 0, len(Presets))
+	for _, p := range Presets {
+		entries = append(entries, fmt.Sprintf("%s%s/%s %dw", RoutePrefix, p.Name, name, p.Width))
+	}
+	return strings.Join(entries, ", ")
+}
@@ -107,6 +107,9 @@ func (s *Cults3DScraper) FetchProduct(ctx context.Context, productURL string) (*
 	// Extract designer slug from URL
 	product.DesignerSlug = s.extractDesignerSlug(productURL)
 
+	// Extract the designer's name
+	product.DesignerName = s.extractDesignerName(html)
+
 	return product, nil
 }
 
@@ -268,6 +271,9 @@ func (s *Cults3DScraper) extractImageURLs(html string) []string {
 		urls = append(urls, url)
 	}
 
+	// The same picture is linked in several formats
+	urls = DedupeImageURLs(urls)
+
 	// Limit to first 10 images
 	if len(urls) > 10 {
 		urls = urls[:10]
@@ -305,6 +311,23 @@ func (s *Cults3DScraper) extractTags(html string) []string {
 	return tags
 }
 
+// extractDesignerName extracts the name of the designer who published the model
+func (s *Cults3DScraper) extractDesignerName(html string) string {
+	// The product's JSON-LD names its author
+	re := regexp.MustCompile(`"author"\s*:\s*\{[^}]*?"name"\s*:\s*"([^"]+)"`)
+	if matches := re.FindStringSubmatch(html); len(matches) > 1 {
+		return strings.TrimSpace(matches[1])
+	}
+
+	// Fallback: the first link to a user's page is the designer's
+	re = regexp.MustCompile(`href="/[a-z]{2}/users/([^"/?]+)"`)
+	if matches := re.FindStringSubmatch(html); len(matches) > 1 {
+		return matches[1]
+	}
+
+	return ""
+}
+
 // extractDesignerSlug extracts the designer slug from a product URL
 func (s *Cults3DScraper) extractDesignerSlug(productURL string) string {
 	// Products often have the designer name at the end: /product-name-designername
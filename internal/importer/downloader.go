@@ -25,6 +25,7 @@ type DownloadedImage struct {
 	OriginalURL string
 	Filename    string
 	FilePath    string
+	ContentHash string // SHA-256 of the file
 }
 
 // NewImageDownloader creates a new image downloader
@@ -45,6 +46,9 @@ func (d *ImageDownloader) DownloadImages(ctx context.Context, imageURLs []string
 	}
 
 	var downloaded []DownloadedImage
+	// The same picture is often listed under URLs that don't look alike, so anything
+	// whose content has already been downloaded for this product is dropped
+	seen := make(map[string]bool)
 
 	for i, url := range imageURLs {
 		if url == "" {
@@ -65,6 +69,14 @@ func (d *ImageDownloader) DownloadImages(ctx context.Context, imageURLs []string
 			slog.Error("failed to download image", "error", err, "url", url)
 			continue
 		}
+		if seen[img.ContentHash] {
+			if err := os.Remove(img.FilePath); err != nil {
+				slog.Error("failed to remove duplicate image", "error", err, "path", img.FilePath)
+			}
+			slog.Debug("skipped duplicate image", "url", url, "hash", img.ContentHash)
+			continue
+		}
+		seen[img.ContentHash] = true
 
 		downloaded = append(downloaded, *img)
 		slog.Debug("downloaded image", "url", url, "filename", img.Filename)
@@ -112,8 +124,9 @@ func (d *ImageDownloader) downloadImage(ctx context.Context, imageURL string, pr
 	}
 	defer dst.Close()
 
-	// Copy the image data
-	_, err = io.Copy(dst, resp.Body)
+	// Copy the image data, hashing it on the way
+	contentHash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(dst, contentHash), resp.Body)
 	if err != nil {
 		os.Remove(filePath) // Clean up on error
 		return nil, fmt.Errorf("write file: %w", err)
@@ -123,6 +136,7 @@ func (d *ImageDownloader) downloadImage(ctx context.Context, imageURL string, pr
 		OriginalURL: imageURL,
 		Filename:    filename,
 		FilePath:    filePath,
+		ContentHash: hex.EncodeToString(contentHash.Sum(nil)),
 	}, nil
 }
 
@@ -156,3 +170,52 @@ func (d *ImageDownloader) getExtension(url, contentType string) string {
 	// Default to jpg
 	return ".jpg"
 }
+
+// DedupeImageURLs removes duplicate images that appear in different formats or sizes
+// (e.g., same image in webp and jpg format from CDN)
+func DedupeImageURLs(urls []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+
+	for _, url := range urls {
+		// Extract the underlying filename from the URL
+		// URLs look like: https://images.cults3d.com/.../filename.jpg
+		// or with format filter: https://images.cults3d.com/...format(webp)/.../filename.jpg
+		key := extractImageKey(url)
+		if key == "" {
+			key = url // fallback to full URL if extraction fails
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, url)
+		}
+	}
+
+	return result
+}
+
+// thingiverseSizePrefixes are put before a picture's filename for each size Thingiverse serves
+var thingiverseSizePrefixes = []string{"large_display_", "featured_preview_", "card_preview_"}
+
+// extractImageKey extracts a unique identifier from an image URL
+// by finding the underlying filename
+func extractImageKey(url string) string {
+	// The URLs contain the original filename at the end
+	// Find the last path segment after the last /
+	lastSlash := strings.LastIndex(url, "/")
+	if lastSlash == -1 {
+		return ""
+	}
+
+	filename := url[lastSlash+1:]
+	// Remove query parameters if any
+	if idx := strings.Index(filename, "?"); idx != -1 {
+		filename = filename[:idx]
+	}
+	for _, prefix := range thingiverseSizePrefixes {
+		filename = strings.TrimPrefix(filename, prefix)
+	}
+
+	return filename
+}
@@ -0,0 +1,40 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadImagesSkipsSameContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/other.png" {
+			w.Write([]byte("another picture"))
+			return
+		}
+		w.Write([]byte("the same picture"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	downloaded, err := NewImageDownloader(dir).DownloadImages(context.Background(), []string{
+		server.URL + "/front.png",
+		server.URL + "/front-copy.png",
+		server.URL + "/other.png",
+	}, "product-1")
+	require.NoError(t, err)
+
+	require.Len(t, downloaded, 2)
+	assert.Equal(t, server.URL+"/front.png", downloaded[0].OriginalURL)
+	assert.Equal(t, server.URL+"/other.png", downloaded[1].OriginalURL)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "the copy's file is removed")
+}
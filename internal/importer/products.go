@@ -0,0 +1,283 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// ImportSettings holds configuration for importing a scraped product
+type ImportSettings struct {
+	CategoryID      string
+	BasePriceCents  int64
+	Sizes           []string         // Size IDs to enable
+	SizeAdjustments map[string]int64 // SizeID -> adjustment in cents
+	IsNew           bool
+	IsPremium       bool
+	IsFeatured      bool
+}
+
+// ImportProduct makes a shop product from a scraped one and returns its ID
+func ImportProduct(ctx context.Context, queries *db.Queries, scraped db.ScrapedProduct, settings *ImportSettings, designerName string, downloader *ImageDownloader) (string, error) {
+	productID := uuid.New().String()
+	slug := generateProductSlug(scraped.Name)
+
+	// Determine if product has variants (sizes selected)
+	hasVariants := len(settings.Sizes) > 0
+
+	// Create product
+	params := db.CreateProductParams{
+		ID:               productID,
+		Name:             scraped.Name,
+		Slug:             slug,
+		Description:      scraped.Description,
+		ShortDescription: sql.NullString{Valid: false},
+		PriceCents:       settings.BasePriceCents,
+		CategoryID:       sql.NullString{String: settings.CategoryID, Valid: settings.CategoryID != ""},
+		Sku:              sql.NullString{Valid: false},
+		StockQuantity:    sql.NullInt64{Int64: 100, Valid: true},
+		HasVariants:      sql.NullBool{Bool: hasVariants, Valid: true},
+		WeightGrams:      sql.NullInt64{Valid: false},
+		LeadTimeDays:     sql.NullInt64{Int64: 3, Valid: true},
+		IsActive:         sql.NullBool{Bool: true, Valid: true},
+		IsFeatured:       sql.NullBool{Bool: settings.IsFeatured, Valid: true},
+		IsPremium:        sql.NullBool{Bool: settings.IsPremium, Valid: true},
+		Disclaimer:       sql.NullString{Valid: false},
+		SeoTitle:         sql.NullString{String: scraped.Name, Valid: true},
+		SeoDescription:   scraped.Description,
+		SeoKeywords:      sql.NullString{Valid: false},
+		OgImageUrl:       sql.NullString{Valid: false},
+	}
+
+	_, err := queries.CreateProduct(ctx, params)
+	if err != nil {
+		slog.Error("failed to create product", "error", err, "name", scraped.Name)
+		return "", fmt.Errorf("create product: %w", err)
+	}
+
+	// Update product with source info and is_new flag
+	err = queries.UpdateProductSource(ctx, db.UpdateProductSourceParams{
+		SourceUrl:      sql.NullString{String: scraped.SourceUrl, Valid: true},
+		SourcePlatform: sql.NullString{String: scraped.Platform, Valid: true},
+		DesignerName:   sql.NullString{String: designerName, Valid: true},
+		ID:             productID,
+	})
+	if err != nil {
+		slog.Error("failed to update product source", "error", err, "product_id", productID)
+	}
+
+	// Set is_new flag if enabled
+	if settings.IsNew {
+		err = queries.UpdateProductIsNew(ctx, db.UpdateProductIsNewParams{
+			IsNew: sql.NullBool{Bool: true, Valid: true},
+			ID:    productID,
+		})
+		if err != nil {
+			slog.Error("failed to update product is_new", "error", err, "product_id", productID)
+		}
+	}
+
+	// Get images to import - prefer selected scraped images, fall back to original URLs
+	var imagesToImport []string
+	scrapedImages, err := queries.ListScrapedProductImages(ctx, scraped.ID)
+	if err == nil && len(scrapedImages) > 0 {
+		// Use downloaded scraped images that are selected for import
+		for _, img := range scrapedImages {
+			// For now, use all downloaded images (selection feature can be added later)
+			if img.LocalFilename.Valid && img.LocalFilename.String != "" && img.DownloadStatus.Valid && img.DownloadStatus.String == "downloaded" {
+				imagesToImport = append(imagesToImport, fmt.Sprintf("scraped/%s", img.LocalFilename.String))
+			}
+		}
+	}
+
+	// If no scraped images, try AI images
+	if len(imagesToImport) == 0 {
+		aiImages, err := queries.ListScrapedProductAIImages(ctx, scraped.ID)
+		if err == nil {
+			for _, img := range aiImages {
+				if img.LocalFilename != "" {
+					imagesToImport = append(imagesToImport, fmt.Sprintf("scraped/ai/%s", img.LocalFilename))
+				}
+			}
+		}
+	}
+
+	// If still no images, download from original URLs
+	if len(imagesToImport) == 0 && scraped.ImageUrls.Valid && scraped.ImageUrls.String != "" {
+		var imageURLs []string
+		if err := json.Unmarshal([]byte(scraped.ImageUrls.String), &imageURLs); err == nil && len(imageURLs) > 0 {
+			if len(imageURLs) > 5 {
+				imageURLs = imageURLs[:5]
+			}
+
+			downloaded, err := downloader.DownloadImages(ctx, imageURLs, productID)
+			if err != nil {
+				slog.Error("failed to download images", "error", err, "product_id", productID)
+			}
+			for _, img := range downloaded {
+				imagesToImport = append(imagesToImport, img.Filename)
+			}
+		}
+	}
+
+	// Create image records
+	for i, imgPath := range imagesToImport {
+		isPrimary := i == 0
+		_, err := queries.CreateProductImage(ctx, db.CreateProductImageParams{
+			ID:           uuid.New().String(),
+			ProductID:    productID,
+			ImageUrl:     imgPath,
+			AltText:      sql.NullString{String: scraped.Name, Valid: true},
+			DisplayOrder: sql.NullInt64{Int64: int64(i), Valid: true},
+			IsPrimary:    sql.NullBool{Bool: isPrimary, Valid: true},
+		})
+		if err != nil {
+			slog.Error("failed to create product image", "error", err, "product_id", productID, "path", imgPath)
+		}
+	}
+
+	// If product has variants (sizes), create style, size configs, and SKUs
+	if hasVariants {
+		// Create default style
+		styleID := uuid.New().String()
+		_, err = queries.CreateProductStyle(ctx, db.CreateProductStyleParams{
+			ID:           styleID,
+			ProductID:    productID,
+			Name:         "Default",
+			IsPrimary:    sql.NullBool{Bool: true, Valid: true},
+			DisplayOrder: sql.NullInt64{Int64: 0, Valid: true},
+		})
+		if err != nil {
+			slog.Error("failed to create product style", "error", err, "product_id", productID)
+		} else {
+			// Create style images from product images
+			for i, imgPath := range imagesToImport {
+				isPrimary := i == 0
+				_, err = queries.CreateProductStyleImage(ctx, db.CreateProductStyleImageParams{
+					ID:             uuid.New().String(),
+					ProductStyleID: styleID,
+					ImageUrl:       imgPath,
+					IsPrimary:      sql.NullBool{Bool: isPrimary, Valid: true},
+					DisplayOrder:   sql.NullInt64{Int64: int64(i), Valid: true},
+				})
+				if err != nil {
+					slog.Error("failed to create product style image", "error", err, "style_id", styleID, "path", imgPath)
+				}
+			}
+
+			// Create size configs and SKUs for each selected size
+			for i, sizeID := range settings.Sizes {
+				// Get price adjustment for this size (from form or default to 0)
+				priceAdjustment := int64(0)
+				if adj, ok := settings.SizeAdjustments[sizeID]; ok {
+					priceAdjustment = adj
+				}
+
+				// Create size config
+				_, err = queries.UpsertProductSizeConfig(ctx, db.UpsertProductSizeConfigParams{
+					ID:                   uuid.New().String(),
+					ProductID:            productID,
+					SizeID:               sizeID,
+					PriceAdjustmentCents: sql.NullInt64{Int64: priceAdjustment, Valid: true},
+					IsEnabled:            sql.NullBool{Bool: true, Valid: true},
+					DisplayOrder:         sql.NullInt64{Int64: int64(i), Valid: true},
+				})
+				if err != nil {
+					slog.Error("failed to create product size config", "error", err, "product_id", productID, "size_id", sizeID)
+					continue
+				}
+
+				// Create SKU for this style + size combination
+				skuCode := fmt.Sprintf("%s-%s", slug, sizeID)
+				_, err = queries.CreateProductSku(ctx, db.CreateProductSkuParams{
+					ID:                   uuid.New().String(),
+					ProductID:            productID,
+					ProductStyleID:       styleID,
+					SizeID:               sizeID,
+					Sku:                  skuCode,
+					PriceAdjustmentCents: sql.NullInt64{Int64: priceAdjustment, Valid: true},
+					StockQuantity:        sql.NullInt64{Int64: 100, Valid: true},
+					IsActive:             sql.NullBool{Bool: true, Valid: true},
+				})
+				if err != nil {
+					slog.Error("failed to create product SKU", "error", err, "product_id", productID, "style_id", styleID, "size_id", sizeID)
+				}
+			}
+		}
+	}
+
+	// Mark scraped product as imported
+	err = queries.MarkProductImported(ctx, db.MarkProductImportedParams{
+		ImportedProductID: sql.NullString{String: productID, Valid: true},
+		ID:                scraped.ID,
+	})
+	if err != nil {
+		slog.Error("failed to mark product imported", "error", err, "scraped_id", scraped.ID)
+	}
+
+	slog.Info("imported product",
+		"product_id", productID,
+		"name", scraped.Name,
+		"has_variants", hasVariants,
+		"sizes", len(settings.Sizes),
+		"images", len(imagesToImport),
+	)
+	return productID, nil
+}
+
+// generateProductSlug creates a URL-friendly slug from a name
+func generateProductSlug(name string) string {
+	// Convert to lowercase
+	slug := strings.ToLower(name)
+
+	// Replace spaces with hyphens
+	slug = strings.ReplaceAll(slug, " ", "-")
+
+	// Remove special characters
+	reg := regexp.MustCompile(`[^a-z0-9-]`)
+	slug = reg.ReplaceAllString(slug, "")
+
+	// Remove multiple consecutive hyphens
+	reg = regexp.MustCompile(`-+`)
+	slug = reg.ReplaceAllString(slug, "-")
+
+	// Trim hyphens from ends
+	slug = strings.Trim(slug, "-")
+
+	// Add unique suffix to prevent duplicates
+	slug = slug + "-" + uuid.New().String()[:8]
+
+	return slug
+}
+
+// DefaultImportSettings are what a product imported without the review page gets: a
+// simple product priced from the AI's suggestion, or twice the model's price
+func DefaultImportSettings(scraped db.ScrapedProduct, categoryID string) *ImportSettings {
+	settings := &ImportSettings{
+		CategoryID:      categoryID,
+		BasePriceCents:  1500, // Default $15
+		Sizes:           []string{},
+		SizeAdjustments: make(map[string]int64),
+		IsNew:           true,
+		IsPremium:       false,
+		IsFeatured:      false,
+	}
+	// Use AI price if available, otherwise markup original price
+	if scraped.AiPriceCents.Valid && scraped.AiPriceCents.Int64 > 0 {
+		settings.BasePriceCents = scraped.AiPriceCents.Int64
+	} else if scraped.OriginalPriceCents.Valid && scraped.OriginalPriceCents.Int64 > 0 {
+		settings.BasePriceCents = scraped.OriginalPriceCents.Int64 * 2
+		if settings.BasePriceCents < 1000 {
+			settings.BasePriceCents = 1000
+		}
+	}
+	return settings
+}
@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ThingiverseScraper scrapes designs from Thingiverse. Thing pages are mostly drawn by
+// script, so it reads the meta tags and the JSON the page is served with.
+type ThingiverseScraper struct {
+	client *HTTPClient
+}
+
+// NewThingiverseScraper creates a new Thingiverse scraper
+func NewThingiverseScraper() *ThingiverseScraper {
+	return &ThingiverseScraper{
+		client: NewHTTPClient(30), // 30 requests per minute
+	}
+}
+
+// Name returns the scraper name
+func (s *ThingiverseScraper) Name() string {
+	return "Thingiverse"
+}
+
+// Platform returns the platform slug
+func (s *ThingiverseScraper) Platform() string {
+	return "thingiverse"
+}
+
+// FetchDesignerProducts fetches the thing URLs on a designer's designs page
+func (s *ThingiverseScraper) FetchDesignerProducts(ctx context.Context, designerURL string) ([]string, error) {
+	body, err := s.client.Get(ctx, designerURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch designer page: %w", err)
+	}
+
+	re := regexp.MustCompile(`/thing:(\d+)`)
+	seen := make(map[string]bool)
+	var urls []string
+	for _, match := range re.FindAllStringSubmatch(string(body), -1) {
+		thingURL := "https://www.thingiverse.com/thing:" + match[1]
+		if !seen[thingURL] {
+			seen[thingURL] = true
+			urls = append(urls, thingURL)
+		}
+	}
+	return urls, nil
+}
+
+// FetchProduct fetches full product details from a thing URL
+func (s *ThingiverseScraper) FetchProduct(ctx context.Context, productURL string) (*ScrapedProduct, error) {
+	slog.Debug("fetching product", "url", productURL)
+
+	body, err := s.client.Get(ctx, productURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch product: %w", err)
+	}
+	page := string(body)
+
+	product := &ScrapedProduct{
+		SourceURL: productURL,
+		Platform:  "thingiverse",
+		RawHTML:   page,
+		ScrapedAt: time.Now(),
+	}
+
+	product.Name, product.DesignerName = s.extractNameAndDesigner(page)
+	if product.Name == "" {
+		return nil, fmt.Errorf("could not extract product name from %s", productURL)
+	}
+	product.Description = s.extractDescription(page)
+	product.ReleaseDate = s.extractReleaseDate(page)
+	product.ImageURLs = s.extractImageURLs(page)
+	product.Tags = s.extractTags(page)
+
+	return product, nil
+}
+
+// extractNameAndDesigner reads the title, which Thingiverse writes as "Name by Designer"
+func (s *ThingiverseScraper) extractNameAndDesigner(page string) (string, string) {
+	title := metaContent(page, "og:title")
+	if title == "" {
+		re := regexp.MustCompile(`<title>([^<]+)</title>`)
+		if matches := re.FindStringSubmatch(page); len(matches) > 1 {
+			title = html.UnescapeString(matches[1])
+		}
+	}
+	title = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(title), "- Thingiverse"))
+
+	designer := ""
+	re := regexp.MustCompile(`"creator"\s*:\s*\{[^}]*?"name"\s*:\s*"([^"]+)"`)
+	if matches := re.FindStringSubmatch(page); len(matches) > 1 {
+		designer = matches[1]
+	}
+	if i := strings.LastIndex(title, " by "); i > 0 {
+		if designer == "" {
+			designer = strings.TrimSpace(title[i+len(" by "):])
+		}
+		title = strings.TrimSpace(title[:i])
+	}
+	return title, designer
+}
+
+// extractDescription extracts the thing's summary
+func (s *ThingiverseScraper) extractDescription(page string) string {
+	desc := metaContent(page, "og:description")
+	if desc == "" {
+		desc = metaContent(page, "description")
+	}
+	if len(desc) > 2000 {
+		desc = desc[:2000]
+	}
+	return desc
+}
+
+// extractReleaseDate reads when the thing was published
+func (s *ThingiverseScraper) extractReleaseDate(page string) *time.Time {
+	re := regexp.MustCompile(`"added"\s*:\s*"([^"]+)"`)
+	if matches := re.FindStringSubmatch(page); len(matches) > 1 {
+		if t, err := time.Parse(time.RFC3339, matches[1]); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// extractImageURLs finds the thing's pictures on the Thingiverse CDN. The large display
+// size is kept, and the thumbnails of the same pictures are skipped.
+func (s *ThingiverseScraper) extractImageURLs(page string) []string {
+	var urls []string
+	if image := metaContent(page, "og:image"); image != "" {
+		urls = append(urls, image)
+	}
+
+	re := regexp.MustCompile(`https://cdn\.thingiverse\.com/(?:assets|renders)/[^"'\s\\]+\.(?:jpg|jpeg|png|webp)`)
+	for _, imageURL := range re.FindAllString(page, -1) {
+		name := imageURL[strings.LastIndex(imageURL, "/")+1:]
+		if strings.HasPrefix(name, "thumb_") || strings.HasPrefix(name, "medium_thumb_") || strings.HasPrefix(name, "small_thumb_") {
+			continue
+		}
+		urls = append(urls, imageURL)
+	}
+
+	urls = DedupeImageURLs(urls)
+	if len(urls) > 10 {
+		urls = urls[:10]
+	}
+	return urls
+}
+
+// extractTags extracts the thing's tags
+func (s *ThingiverseScraper) extractTags(page string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	re := regexp.MustCompile(`href="/tag:([^"]+)"`)
+	for _, match := range re.FindAllStringSubmatch(page, -1) {
+		tag := strings.ToLower(strings.ReplaceAll(match[1], "_", " "))
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// metaContent returns the content of a <meta property> or <meta name> tag
+func metaContent(page, name string) string {
+	re := regexp.MustCompile(`<meta\s+(?:property|name)="` + regexp.QuoteMeta(name) + `"\s+content="([^"]*)"`)
+	if matches := re.FindStringSubmatch(page); len(matches) > 1 {
+		return strings.TrimSpace(html.UnescapeString(matches[1]))
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const thingPage = `<html><head>
+<title>Articulated Crystal Wyrm by Cinderwing3D - Thingiverse</title>
+<meta property="og:title" content="Articulated Crystal Wyrm by Cinderwing3D"/>
+<meta property="og:description" content="A print-in-place wyrm &amp; its hoard."/>
+<meta property="og:image" content="https://cdn.thingiverse.com/assets/aa/bb/large_display_wyrm.jpg"/>
+</head><body>
+<a href="/tag:dragon">dragon</a> <a href="/tag:print_in_place">print in place</a> <a href="/tag:dragon">dragon</a>
+<img src="https://cdn.thingiverse.com/assets/aa/bb/thumb_wyrm.jpg">
+<img src="https://cdn.thingiverse.com/assets/aa/bb/wyrm.jpg">
+<img src="https://cdn.thingiverse.com/assets/aa/bb/tail.jpg">
+<script>window.thing = {"id":4567890,"added":"2024-05-01T12:30:00+00:00","creator":{"id":7,"name":"Cinderwing3D","public_url":"https://www.thingiverse.com/cinderwing3d"}}</script>
+</body></html>`
+
+func TestThingiverseExtract(t *testing.T) {
+	s := NewThingiverseScraper()
+
+	name, designer := s.extractNameAndDesigner(thingPage)
+	assert.Equal(t, "Articulated Crystal Wyrm", name)
+	assert.Equal(t, "Cinderwing3D", designer)
+
+	assert.Equal(t, "A print-in-place wyrm & its hoard.", s.extractDescription(thingPage))
+	assert.Equal(t, []string{"dragon", "print in place"}, s.extractTags(thingPage))
+	assert.Equal(t, []string{
+		"https://cdn.thingiverse.com/assets/aa/bb/large_display_wyrm.jpg",
+		"https://cdn.thingiverse.com/assets/aa/bb/tail.jpg",
+	}, s.extractImageURLs(thingPage))
+
+	released := s.extractReleaseDate(thingPage)
+	if assert.NotNil(t, released) {
+		assert.True(t, released.Equal(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)))
+	}
+}
+
+func TestThingiverseDesignerFromTitle(t *testing.T) {
+	name, designer := NewThingiverseScraper().extractNameAndDesigner(`<meta property="og:title" content="Flexi Rex by FlexiFactory"/>`)
+	assert.Equal(t, "Flexi Rex", name)
+	assert.Equal(t, "FlexiFactory", designer)
+}
+
+func TestCults3DDesignerName(t *testing.T) {
+	s := NewCults3DScraper()
+
+	jsonLD := `<script type="application/ld+json">{"@type":"Product","name":"Flexi Dragon","author":{"@type":"Person","name":"TheDragonsDen"}}</script>`
+	assert.Equal(t, "TheDragonsDen", s.extractDesignerName(jsonLD))
+
+	link := `<a class="avatar" href="/en/users/FlexiFactory">FlexiFactory</a>`
+	assert.Equal(t, "FlexiFactory", s.extractDesignerName(link))
+
+	assert.Equal(t, "", s.extractDesignerName(`<h1>Flexi Dragon</h1>`))
+}
@@ -15,7 +15,7 @@ type Designer struct {
 
 // Source represents a platform where a designer publishes models
 type Source struct {
-	Platform string // "cults3d", "mmf"
+	Platform string // "cults3d", "thingiverse"
 	URL      string
 }
 
@@ -23,6 +23,7 @@ type Source struct {
 type ScrapedProduct struct {
 	ID                 string
 	DesignerSlug       string
+	DesignerName       string // As the platform shows it; empty when it couldn't be found
 	Platform           string
 	SourceURL          string
 	Name               string
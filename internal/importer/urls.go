@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MaxURLsPerImport is how many design URLs one import can take
+const MaxURLsPerImport = 50
+
+// ProductURL is a design page on a platform we can scrape
+type ProductURL struct {
+	Platform string
+	URL      string
+}
+
+var (
+	cults3DPathPattern     = regexp.MustCompile(`^/[a-z]{2}/3d-model/[^/]+/[^/]+$`)
+	thingiversePathPattern = regexp.MustCompile(`^/thing:(\d+)`)
+)
+
+// NewScrapers returns a scraper for each platform designs can be imported from, keyed
+// by platform slug
+func NewScrapers() map[string]Scraper {
+	return map[string]Scraper{
+		"cults3d":     NewCults3DScraper(),
+		"thingiverse": NewThingiverseScraper(),
+	}
+}
+
+// ParseProductURL works out which platform a design URL is on and tidies it to the
+// form the platform links to, so the same design is always stored under the same URL
+func ParseProductURL(raw string) (ProductURL, bool) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ProductURL{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+
+	switch host {
+	case "cults3d.com":
+		if !cults3DPathPattern.MatchString(path) {
+			return ProductURL{}, false
+		}
+		return ProductURL{Platform: "cults3d", URL: "https://cults3d.com" + path}, true
+	case "thingiverse.com":
+		matches := thingiversePathPattern.FindStringSubmatch(path)
+		if matches == nil {
+			return ProductURL{}, false
+		}
+		return ProductURL{Platform: "thingiverse", URL: "https://www.thingiverse.com/thing:" + matches[1]}, true
+	}
+	return ProductURL{}, false
+}
+
+// ParseProductURLs reads the URLs pasted into the importer, one per line or separated
+// by spaces or commas. Repeats are dropped, and anything that isn't a Cults3D or
+// Thingiverse design is returned in rejected.
+func ParseProductURLs(text string) (urls []ProductURL, rejected []string) {
+	seen := make(map[string]bool)
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+	for _, field := range fields {
+		parsed, ok := ParseProductURL(field)
+		if !ok {
+			rejected = append(rejected, field)
+			continue
+		}
+		if seen[parsed.URL] {
+			continue
+		}
+		seen[parsed.URL] = true
+		urls = append(urls, parsed)
+	}
+	return urls, rejected
+}
+
+// MatchCategory picks the category an imported design belongs in. A category named in
+// the design's tags wins, then one named in its title, then fallback. Names match
+// without regard to case or a trailing "s", so the tag "dragon" finds "Dragons".
+func MatchCategory(product *ScrapedProduct, categories []string, fallback string) string {
+	byKey := make(map[string]string, len(categories))
+	for _, name := range categories {
+		byKey[categoryKey(name)] = name
+	}
+	for _, tag := range product.Tags {
+		if name, ok := byKey[categoryKey(tag)]; ok {
+			return name
+		}
+	}
+	for _, word := range strings.Fields(product.Name) {
+		if name, ok := byKey[categoryKey(word)]; ok {
+			return name
+		}
+	}
+	return fallback
+}
+
+func categoryKey(name string) string {
+	key := strings.ToLower(strings.Trim(strings.TrimSpace(name), ".,!?:;()"))
+	if len(key) > 3 {
+		key = strings.TrimSuffix(key, "s")
+	}
+	return key
+}
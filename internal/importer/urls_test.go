@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductURL(t *testing.T) {
+	tests := []struct {
+		raw      string
+		want     ProductURL
+		accepted bool
+	}{
+		{"https://cults3d.com/en/3d-model/art/flexi-dragon", ProductURL{"cults3d", "https://cults3d.com/en/3d-model/art/flexi-dragon"}, true},
+		{"cults3d.com/en/3d-model/art/flexi-dragon/?utm_source=x#comments", ProductURL{"cults3d", "https://cults3d.com/en/3d-model/art/flexi-dragon"}, true},
+		{"https://www.cults3d.com/en/3d-model/art/flexi-dragon", ProductURL{"cults3d", "https://cults3d.com/en/3d-model/art/flexi-dragon"}, true},
+		{"https://www.thingiverse.com/thing:4567890", ProductURL{"thingiverse", "https://www.thingiverse.com/thing:4567890"}, true},
+		{"http://thingiverse.com/thing:4567890/files", ProductURL{"thingiverse", "https://www.thingiverse.com/thing:4567890"}, true},
+		{"https://cults3d.com/en/users/FlexiFactory/3d-models", ProductURL{}, false},
+		{"https://www.thingiverse.com/FlexiFactory/designs", ProductURL{}, false},
+		{"https://www.printables.com/model/12345-dragon", ProductURL{}, false},
+		{"ftp://cults3d.com/en/3d-model/art/flexi-dragon", ProductURL{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := ParseProductURL(tt.raw)
+			assert.Equal(t, tt.accepted, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseProductURLs(t *testing.T) {
+	text := `https://cults3d.com/en/3d-model/art/flexi-dragon
+https://www.thingiverse.com/thing:4567890, https://thingiverse.com/thing:4567890/comments
+
+not-a-url	https://cults3d.com/en/3d-model/art/flexi-dragon?ref=home`
+
+	urls, rejected := ParseProductURLs(text)
+	assert.Equal(t, []ProductURL{
+		{Platform: "cults3d", URL: "https://cults3d.com/en/3d-model/art/flexi-dragon"},
+		{Platform: "thingiverse", URL: "https://www.thingiverse.com/thing:4567890"},
+	}, urls)
+	assert.Equal(t, []string{"not-a-url"}, rejected)
+}
+
+func TestMatchCategory(t *testing.T) {
+	categories := []string{"Animals", "Dragons", "Fidget Toys"}
+
+	tests := []struct {
+		name     string
+		product  ScrapedProduct
+		fallback string
+		want     string
+	}{
+		{"tag names a category", ScrapedProduct{Name: "Crystal Wyrm", Tags: []string{"articulated", "dragon"}}, "", "Dragons"},
+		{"tags win over the title", ScrapedProduct{Name: "Dragon Cat", Tags: []string{"animals"}}, "", "Animals"},
+		{"title names a category", ScrapedProduct{Name: "Articulated Dragon!", Tags: []string{"print in place"}}, "", "Dragons"},
+		{"multi-word category from a tag", ScrapedProduct{Name: "Spinner", Tags: []string{"fidget toys"}}, "", "Fidget Toys"},
+		{"nothing matches", ScrapedProduct{Name: "Desk Organizer", Tags: []string{"office"}}, "Animals", "Animals"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchCategory(&tt.product, categories, tt.fallback))
+		})
+	}
+}
+
+func TestDedupeImageURLs(t *testing.T) {
+	urls := []string{
+		"https://images.cults3d.com/abc/format(webp)/dragon.jpg",
+		"https://images.cults3d.com/def/dragon.jpg",
+		"https://cdn.thingiverse.com/assets/1/2/large_display_wyrm.png",
+		"https://cdn.thingiverse.com/assets/1/2/wyrm.png",
+		"https://cdn.thingiverse.com/assets/1/2/tail.png",
+	}
+	assert.Equal(t, []string{
+		"https://images.cults3d.com/abc/format(webp)/dragon.jpg",
+		"https://cdn.thingiverse.com/assets/1/2/large_display_wyrm.png",
+		"https://cdn.thingiverse.com/assets/1/2/tail.png",
+	}, DedupeImageURLs(urls))
+}
@@ -0,0 +1,201 @@
+// Package inventory holds stock for cart checkouts in progress. Stock is only taken
+// off a product when the Stripe webhook records the paid order, so without a hold
+// two shoppers checking out at once could both pay for the last unit.
+//
+// Like gift card and store credit holds, the stock is held when the checkout session
+// is made, linked to the session once it exists, and released if the checkout is
+// cancelled or expires. A hold also lapses on its own at its expiry, which matches
+// the session's, and a background sweep marks those released.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+const (
+	// MinHoldTTL and MaxHoldTTL are the shortest and longest Stripe lets a checkout
+	// session stay open, which bound how long stock can be held for one
+	MinHoldTTL = 30 * time.Minute
+	MaxHoldTTL = 24 * time.Hour
+)
+
+// HoldTTL brings a configured hold time within what Stripe allows for the session
+func HoldTTL(ttl time.Duration) time.Duration {
+	return min(max(ttl, MinHoldTTL), MaxHoldTTL)
+}
+
+// Error is a reason stock can't be held, worded for the shopper
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Line is stock a checkout needs held: units of a product without variants, or of one
+// of its SKUs when SkuID is set
+type Line struct {
+	ProductID string
+	SkuID     string
+	// Name is the product as the shopper knows it, for the message when it's gone
+	Name     string
+	Quantity int64
+}
+
+// Reservations makes and ends holds. Each hold is made with a conditional insert
+// that counts the other holds, so two checkouts can't hold the same unit.
+type Reservations struct {
+	queries *db.Queries
+}
+
+func NewReservations(queries *db.Queries) *Reservations {
+	return &Reservations{queries: queries}
+}
+
+// Reserved is how many units checkouts in progress hold for a product without
+// variants, or for one SKU
+func (r *Reservations) Reserved(ctx context.Context, productID, skuID string) (int64, error) {
+	reserved, err := r.queries.GetReservedStock(ctx, db.GetReservedStockParams{
+		ProductID:    productID,
+		ProductSkuID: skuID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+	return reserved, nil
+}
+
+// Hold holds stock for every line for ttl, returning the hold ID to link to the
+// checkout session once it exists. Either every line is held or none is: when one
+// has run out, what was held for the others is released and an *Error says which.
+func (r *Reservations) Hold(ctx context.Context, cartSessionID string, lines []Line, ttl time.Duration) (string, error) {
+	holdID := uuid.New().String()
+	expiry := fmt.Sprintf("%+d seconds", int64(ttl/time.Second))
+
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			continue
+		}
+		var held int64
+		var err error
+		if line.SkuID != "" {
+			held, err = r.queries.ReserveSkuStock(ctx, db.ReserveSkuStockParams{
+				ID:            uuid.New().String(),
+				HoldID:        holdID,
+				Quantity:      line.Quantity,
+				CartSessionID: cartSessionID,
+				Ttl:           expiry,
+				ProductSkuID:  line.SkuID,
+			})
+		} else {
+			held, err = r.queries.ReserveProductStock(ctx, db.ReserveProductStockParams{
+				ID:            uuid.New().String(),
+				HoldID:        holdID,
+				Quantity:      line.Quantity,
+				CartSessionID: cartSessionID,
+				Ttl:           expiry,
+				ProductID:     line.ProductID,
+			})
+		}
+		if err == nil && held == 0 {
+			err = &Error{Message: fmt.Sprintf("Someone else is checking out the last of %s. Please try again in a few minutes, or lower the quantity.", line.Name)}
+		}
+		if err != nil {
+			// Lines already held for this checkout would otherwise wait out their expiry
+			if _, releaseErr := r.queries.ReleaseInventoryHold(ctx, holdID); releaseErr != nil {
+				return "", errors.Join(err, fmt.Errorf("failed to release partial stock hold: %w", releaseErr))
+			}
+			var holdErr *Error
+			if errors.As(err, &holdErr) {
+				return "", err
+			}
+			return "", fmt.Errorf("failed to hold stock: %w", err)
+		}
+	}
+	return holdID, nil
+}
+
+// Link records the checkout session a hold was made for
+func (r *Reservations) Link(ctx context.Context, holdID, checkoutSessionID string) error {
+	if err := r.queries.LinkInventoryHold(ctx, db.LinkInventoryHoldParams{
+		CheckoutSessionID: checkoutSessionID,
+		HoldID:            holdID,
+	}); err != nil {
+		return fmt.Errorf("failed to link stock hold to session: %w", err)
+	}
+	return nil
+}
+
+// Release ends a hold whose checkout session couldn't be made
+func (r *Reservations) Release(ctx context.Context, holdID string) error {
+	if _, err := r.queries.ReleaseInventoryHold(ctx, holdID); err != nil {
+		return fmt.Errorf("failed to release stock hold: %w", err)
+	}
+	return nil
+}
+
+// HeldCheckouts lists the cart's checkouts that still hold stock: the Checkout
+// Sessions or drafts their holds were linked to
+func (r *Reservations) HeldCheckouts(ctx context.Context, cartSessionID string) ([]string, error) {
+	if cartSessionID == "" {
+		return nil, nil
+	}
+	checkoutIDs, err := r.queries.ListCartHeldCheckouts(ctx, cartSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cart checkouts holding stock: %w", err)
+	}
+	return checkoutIDs, nil
+}
+
+// ReleaseCart ends whatever a cart's earlier checkouts still hold, returning how many
+// lines were released
+func (r *Reservations) ReleaseCart(ctx context.Context, cartSessionID string) (int64, error) {
+	if cartSessionID == "" {
+		return 0, nil
+	}
+	released, err := r.queries.ReleaseCartInventoryHolds(ctx, cartSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release cart stock holds: %w", err)
+	}
+	return released, nil
+}
+
+// ReleaseSession ends the holds of a checkout session that expired unpaid
+func (r *Reservations) ReleaseSession(ctx context.Context, checkoutSessionID string) (int64, error) {
+	released, err := r.queries.ReleaseSessionInventoryHolds(ctx, checkoutSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release session stock holds: %w", err)
+	}
+	return released, nil
+}
+
+// Consume marks a paid checkout's holds used. The stock itself is taken off when the
+// order's items are recorded.
+func (r *Reservations) Consume(ctx context.Context, checkoutSessionID string) (int64, error) {
+	consumed, err := r.queries.ConsumeSessionInventoryHolds(ctx, checkoutSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume stock holds: %w", err)
+	}
+	return consumed, nil
+}
+
+// ReleaseExpired marks released the holds past their expiry, which already no
+// longer count against stock, and deletes finished reservations older than keep
+func (r *Reservations) ReleaseExpired(ctx context.Context, keep time.Duration) (released, deleted int64, err error) {
+	released, err = r.queries.ReleaseExpiredInventoryHolds(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to release expired stock holds: %w", err)
+	}
+	deleted, err = r.queries.DeleteOldInventoryReservations(ctx, fmt.Sprintf("-%d seconds", int64(keep/time.Second)))
+	if err != nil {
+		return released, 0, fmt.Errorf("failed to delete old stock reservations: %w", err)
+	}
+	return released, deleted, nil
+}
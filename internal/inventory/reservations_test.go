@@ -0,0 +1,133 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestHoldTTL(t *testing.T) {
+	assert.Equal(t, MinHoldTTL, HoldTTL(5*time.Minute), "Stripe sessions stay open at least 30 minutes")
+	assert.Equal(t, time.Hour, HoldTTL(time.Hour))
+	assert.Equal(t, MaxHoldTTL, HoldTTL(72*time.Hour))
+}
+
+func TestReservations(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	reservations := NewReservations(queries)
+
+	_, err = queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:            "prod-egg",
+		Name:          "Dragon Egg",
+		Slug:          "dragon-egg",
+		PriceCents:    1500,
+		StockQuantity: sql.NullInt64{Int64: 1, Valid: true},
+		IsActive:      sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:            "prod-dragon",
+		Name:          "Articulated Dragon",
+		Slug:          "articulated-dragon",
+		PriceCents:    2500,
+		StockQuantity: sql.NullInt64{Int64: 5, Valid: true},
+		HasVariants:   sql.NullBool{Bool: true, Valid: true},
+		IsActive:      sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	style, err := queries.CreateProductStyle(ctx, db.CreateProductStyleParams{
+		ID:        "style-red",
+		ProductID: "prod-dragon",
+		Name:      "Red",
+		IsPrimary: sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = queries.CreateProductSku(ctx, db.CreateProductSkuParams{
+		ID:             "sku-red-large",
+		ProductID:      "prod-dragon",
+		ProductStyleID: style.ID,
+		SizeID:         "size_large",
+		Sku:            "DRAGON-RED-L",
+		StockQuantity:  sql.NullInt64{Int64: 2, Valid: true},
+		IsActive:       sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	egg := Line{ProductID: "prod-egg", Name: "Dragon Egg", Quantity: 1}
+	dragon := Line{ProductID: "prod-dragon", SkuID: "sku-red-large", Name: "Articulated Dragon", Quantity: 2}
+
+	// The first checkout holds the last egg and both red dragons
+	holdID, err := reservations.Hold(ctx, "cart-a", []Line{egg, dragon}, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, reservations.Link(ctx, holdID, "cs_a"))
+	reserved, err := reservations.Reserved(ctx, "prod-dragon", "sku-red-large")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), reserved)
+	reserved, err = reservations.Reserved(ctx, "prod-dragon", "")
+	require.NoError(t, err)
+	assert.Zero(t, reserved, "SKU holds don't count against the product's own stock")
+
+	// A second checkout can't have the egg, and holds nothing for its other lines
+	var holdErr *Error
+	_, err = reservations.Hold(ctx, "cart-b", []Line{{ProductID: "prod-dragon", Name: "Articulated Dragon", Quantity: 1}, egg}, time.Hour)
+	require.True(t, errors.As(err, &holdErr))
+	assert.Contains(t, holdErr.Message, "Dragon Egg")
+	reserved, err = reservations.Reserved(ctx, "prod-dragon", "")
+	require.NoError(t, err)
+	assert.Zero(t, reserved, "the partial hold was released")
+	_, err = reservations.Hold(ctx, "cart-b", []Line{{ProductID: "prod-dragon", SkuID: "sku-red-large", Name: "Articulated Dragon", Quantity: 1}}, time.Hour)
+	require.True(t, errors.As(err, &holdErr), "both red dragons are held")
+
+	// Once the first checkout expires the stock is free again
+	released, err := reservations.ReleaseSession(ctx, "cs_a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), released)
+	holdID, err = reservations.Hold(ctx, "cart-b", []Line{egg}, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, reservations.Link(ctx, holdID, "cs_b"))
+
+	// A paid hold is consumed, and can't be released after
+	consumed, err := reservations.Consume(ctx, "cs_b")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), consumed)
+	released, err = reservations.ReleaseSession(ctx, "cs_b")
+	require.NoError(t, err)
+	assert.Zero(t, released)
+
+	// A cart's checkouts holding stock are listed once their hold is linked
+	holdID, err = reservations.Hold(ctx, "cart-c", []Line{dragon}, time.Hour)
+	require.NoError(t, err)
+	checkouts, err := reservations.HeldCheckouts(ctx, "cart-c")
+	require.NoError(t, err)
+	assert.Empty(t, checkouts)
+	require.NoError(t, reservations.Link(ctx, holdID, "cs_c"))
+	checkouts, err = reservations.HeldCheckouts(ctx, "cart-c")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cs_c"}, checkouts)
+
+	// Cancelling checkout releases the cart's holds
+	released, err = reservations.ReleaseCart(ctx, "cart-c")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), released)
+
+	// A lapsed hold stops counting straight away, and the sweep marks it released
+	_, err = reservations.Hold(ctx, "cart-d", []Line{dragon}, -time.Second)
+	require.NoError(t, err)
+	reserved, err = reservations.Reserved(ctx, "prod-dragon", "sku-red-large")
+	require.NoError(t, err)
+	assert.Zero(t, reserved)
+	released, _, err = reservations.ReleaseExpired(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), released)
+}
@@ -0,0 +1,97 @@
+// Package invoices hands out orders' invoice PDFs. Each is rendered the first time
+// it's asked for and kept in order_invoices, so later downloads and the confirmation
+// email get the stored copy. A fingerprint of everything printed on it is kept with
+// it, and the invoice is only made again when that changes: the order is edited or
+// refunded, or the business details in the site settings change.
+package invoices
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// layoutVersion goes into every fingerprint. Bump it when the invoice's layout
+// changes, so stored invoices are made again in the new one.
+const layoutVersion = 1
+
+// Store renders orders' invoices and keeps them
+type Store struct {
+	queries *db.Queries
+}
+
+func NewStore(queries *db.Queries) *Store {
+	return &Store{queries: queries}
+}
+
+// PDF returns an order's invoice, the stored copy when nothing on it has changed
+func (s *Store) PDF(ctx context.Context, order db.Order) ([]byte, error) {
+	items, err := s.queries.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+	changes, err := s.queries.GetOrderChangesPaidCents(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments for changes: %w", err)
+	}
+	refunded, err := s.queries.GetOrderRefundedCents(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+	business := pdf.BusinessFromSettings(settings.For(s.queries).Values(ctx))
+	payments := pdf.Payments{ChangesCents: changes, RefundedCents: refunded}
+
+	fingerprint, err := Fingerprint(business, order, items, payments)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.queries.GetOrderInvoice(ctx, order.ID)
+	if err == nil && stored.Fingerprint == fingerprint {
+		return stored.Pdf, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to get stored invoice", "error", err, "order_id", order.ID)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Invoice(&buf, business, order, items, payments); err != nil {
+		return nil, err
+	}
+	// The invoice is still good to send when it can't be kept; it's made again next time
+	if err := s.queries.SaveOrderInvoice(ctx, db.SaveOrderInvoiceParams{
+		OrderID:     order.ID,
+		Fingerprint: fingerprint,
+		Pdf:         buf.Bytes(),
+	}); err != nil {
+		slog.Error("failed to store invoice", "error", err, "order_id", order.ID)
+	}
+	return buf.Bytes(), nil
+}
+
+// Fingerprint hashes everything an invoice is made from. The order's updated_at is left
+// out: it moves on every save, even one that changes nothing else.
+func Fingerprint(business pdf.Business, order db.Order, items []db.GetOrderItemsRow, payments pdf.Payments) (string, error) {
+	order.UpdatedAt = sql.NullTime{}
+	data, err := json.Marshal(struct {
+		Layout   int
+		Business pdf.Business
+		Order    db.Order
+		Items    []db.GetOrderItemsRow
+		Payments pdf.Payments
+	}{layoutVersion, business, order, items, payments})
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint invoice: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
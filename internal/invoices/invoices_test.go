@@ -0,0 +1,64 @@
+package invoices
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+func TestStorePDF(t *testing.T) {
+	database, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		`INSERT INTO products (id, name, slug, price_cents) VALUES ('product-1', 'Flexi Dragon', 'flexi-dragon', 2000)`,
+		`INSERT INTO orders (
+			id, user_id, customer_name, customer_email,
+			shipping_address_line1, shipping_city, shipping_state, shipping_postal_code,
+			subtotal_cents, total_cents, status
+		) VALUES ('order-1', '', 'Jane Customer', 'jane@customer.com', '12 Elm St', 'Eau Claire', 'WI', '54701', 2000, 2000, 'received')`,
+		`INSERT INTO order_items (id, order_id, product_id, quantity, unit_price_cents, total_price_cents, product_name) VALUES
+			('item-1', 'order-1', 'product-1', 1, 2000, 2000, 'Flexi Dragon')`,
+	} {
+		_, err := database.Exec(stmt)
+		require.NoError(t, err, stmt)
+	}
+	store := NewStore(queries)
+	order, err := queries.GetOrder(ctx, "order-1")
+	require.NoError(t, err)
+
+	invoice, err := store.PDF(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(invoice, []byte("%PDF-")))
+	stored, err := queries.GetOrderInvoice(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, invoice, stored.Pdf)
+
+	// Asking again gets the stored copy, which a marker shows wasn't made again
+	_, err = database.Exec(`UPDATE order_invoices SET pdf = CAST('stored' AS BLOB)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`UPDATE orders SET updated_at = datetime('now', '+1 hour')`)
+	require.NoError(t, err)
+	order, err = queries.GetOrder(ctx, "order-1")
+	require.NoError(t, err)
+	invoice, err = store.PDF(ctx, order)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("stored"), invoice, "only updated_at changed")
+
+	// A refund is printed on it, so the invoice is made again
+	_, err = database.Exec(`INSERT INTO order_refunds (id, order_id, amount_cents) VALUES ('refund-1', 'order-1', 500)`)
+	require.NoError(t, err)
+	invoice, err = store.PDF(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(invoice, []byte("%PDF-")))
+	updated, err := queries.GetOrderInvoice(ctx, "order-1")
+	require.NoError(t, err)
+	assert.NotEqual(t, stored.Fingerprint, updated.Fingerprint)
+}
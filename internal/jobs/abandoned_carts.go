@@ -3,8 +3,10 @@ package jobs
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +22,9 @@ const (
 
 	// DetectionInterval is how often we check for abandoned carts (5 minutes)
 	DetectionInterval = 5 * time.Minute
+
+	// DefaultRecoveryVariant is used when no sequence steps are configured
+	DefaultRecoveryVariant = "A"
 )
 
 type AbandonedCartDetector struct {
@@ -131,7 +136,8 @@ func (d *AbandonedCartDetector) detectAbandonedCarts(ctx context.Context) {
 
 		// If no existing abandoned cart record, create one
 		if checkErr == sql.ErrNoRows {
-			err := d.createAbandonedCartRecord(ctx, sessionID, userID, itemCount, cartValue, lastUpdate)
+			customerEmail, customerName := d.accountContact(ctx, userID)
+			_, err := d.createAbandonedCartRecord(ctx, sessionID, userID, customerEmail, customerName, itemCount, cartValue, lastUpdate)
 			if err != nil {
 				slog.Error("failed to create abandoned cart record", "error", err, "session_id", sessionID, "user_id", userID)
 				continue
@@ -148,24 +154,75 @@ func (d *AbandonedCartDetector) detectAbandonedCarts(ctx context.Context) {
 	}
 }
 
+// accountContact returns the email and name of a signed-in shopper's account, for
+// the recovery emails
+func (d *AbandonedCartDetector) accountContact(ctx context.Context, userID string) (sql.NullString, sql.NullString) {
+	if userID == "" {
+		return sql.NullString{}, sql.NullString{}
+	}
+	user, err := d.storage.Queries.GetUser(ctx, userID)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullString{String: user.Email, Valid: true}, sql.NullString{String: user.FullName, Valid: true}
+}
+
+// CaptureCart puts a shopper's cart into recovery at once, for a shopper who gave
+// their email before leaving, instead of waiting for the cart to go quiet and the
+// next detection run. Pass the session for a guest's cart and the user for a
+// signed-in one. A cart already in recovery just gets the email, so the sequence can
+// reach a guest it had no address for. It returns the abandoned cart's ID.
+func (d *AbandonedCartDetector) CaptureCart(ctx context.Context, sessionID, userID, customerEmail string) (string, error) {
+	var existing db.AbandonedCart
+	var err error
+	if userID != "" {
+		existing, err = d.storage.Queries.GetAbandonedCartByUser(ctx, sql.NullString{String: userID, Valid: true})
+	} else {
+		existing, err = d.storage.Queries.GetAbandonedCartBySession(ctx, sql.NullString{String: sessionID, Valid: true})
+	}
+	if err == nil {
+		if err := d.storage.Queries.SetAbandonedCartEmail(ctx, db.SetAbandonedCartEmailParams{
+			CustomerEmail: sql.NullString{String: customerEmail, Valid: true},
+			ID:            existing.ID,
+		}); err != nil {
+			return "", fmt.Errorf("failed to set abandoned cart email: %w", err)
+		}
+		return existing.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to look up abandoned cart: %w", err)
+	}
+
+	totals, err := d.storage.Queries.GetCartTotalsForRecovery(ctx, db.GetCartTotalsForRecoveryParams{
+		UserID:    sql.NullString{String: userID, Valid: userID != ""},
+		SessionID: sql.NullString{String: sessionID, Valid: userID == ""},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to total cart: %w", err)
+	}
+	if totals.ItemCount == 0 {
+		return "", errors.New("cart is empty")
+	}
+
+	_, customerName := d.accountContact(ctx, userID)
+	cartID, err := d.createAbandonedCartRecord(ctx, sessionID, userID, sql.NullString{String: customerEmail, Valid: true}, customerName, totals.ItemCount, totals.CartValueCents, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	slog.Info("captured cart for recovery", "cart_id", cartID, "session_id", sessionID, "user_id", userID, "value", totals.CartValueCents, "items", totals.ItemCount)
+	return cartID, nil
+}
+
 func (d *AbandonedCartDetector) createAbandonedCartRecord(
 	ctx context.Context,
 	sessionID string,
 	userID string,
+	customerEmail sql.NullString,
+	customerName sql.NullString,
 	itemCount int64,
 	cartValue int64,
 	abandonedAt time.Time,
-) error {
-	// Get customer info if available
-	var customerEmail, customerName sql.NullString
-	if userID != "" {
-		user, err := d.storage.Queries.GetUser(ctx, userID)
-		if err == nil {
-			customerEmail = sql.NullString{String: user.Email, Valid: true}
-			customerName = sql.NullString{String: user.FullName, Valid: true}
-		}
-	}
-
+) (string, error) {
 	// Create abandoned cart record
 	cartID := uuid.New().String()
 	_, err := d.storage.Queries.CreateAbandonedCart(ctx, db.CreateAbandonedCartParams{
@@ -180,7 +237,17 @@ func (d *AbandonedCartDetector) createAbandonedCartRecord(
 		Status:         sql.NullString{String: "active", Valid: true},
 	})
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	// Assign an A/B variant so the cart follows one recovery sequence
+	variant := d.assignRecoveryVariant(ctx)
+	err = d.storage.Queries.UpdateAbandonedCartVariant(ctx, db.UpdateAbandonedCartVariantParams{
+		AbVariant: variant,
+		ID:        cartID,
+	})
+	if err != nil {
+		slog.Error("failed to assign recovery variant", "error", err, "cart_id", cartID, "variant", variant)
 	}
 
 	// Check if customer is a first-time customer and generate promo code
@@ -207,7 +274,7 @@ func (d *AbandonedCartDetector) createAbandonedCartRecord(
 		// Don't fail the whole operation if snapshots fail
 	}
 
-	return nil
+	return cartID, nil
 }
 
 // generatePromoCodeForFirstTimer checks if user has never purchased and generates a 5% promo code
@@ -227,71 +294,59 @@ func (d *AbandonedCartDetector) generatePromoCodeForFirstTimer(ctx context.Conte
 		return ""
 	}
 
-	// Get or create abandoned cart campaign
-	campaign, err := d.getOrCreateAbandonedCartCampaign(ctx)
+	promoCode, err := createRecoveryPromotionCode(ctx, d.storage.Queries, 5, "CART5", userID, email, 10)
 	if err != nil {
-		slog.Error("failed to get abandoned cart campaign", "error", err)
+		slog.Error("failed to create first-time buyer promotion code", "error", err, "email", email)
 		return ""
 	}
 
-	// Generate unique code (CART5-XXXX format)
-	codeStr := fmt.Sprintf("CART5-%s", ulid.Make().String()[0:8])
+	return promoCode.ID
+}
 
-	// Create Stripe promotion code
-	stripePromoCode, err := stripe.CreateUniquePromotionCode(
-		campaign.StripePromotionID.String,
-		codeStr,
-		email,
-		10, // 10 days expiration
-	)
+// assignRecoveryVariant picks a random variant among those with active sequence steps
+func (d *AbandonedCartDetector) assignRecoveryVariant(ctx context.Context) string {
+	variants, err := d.storage.Queries.ListActiveRecoveryVariants(ctx)
 	if err != nil {
-		slog.Error("failed to create Stripe promotion code", "error", err, "code", codeStr)
-		return ""
+		slog.Error("failed to list recovery variants", "error", err)
+		return DefaultRecoveryVariant
 	}
-
-	// Create promotion code in database
-	promoCode, err := d.storage.Queries.CreatePromotionCode(ctx, db.CreatePromotionCodeParams{
-		ID:                    ulid.Make().String(),
-		CampaignID:            campaign.ID,
-		Code:                  codeStr,
-		StripePromotionCodeID: sql.NullString{String: stripePromoCode.ID, Valid: true},
-		Email:                 sql.NullString{String: email, Valid: true},
-		UserID:                sql.NullString{String: userID, Valid: userID != ""},
-		MaxUses:               sql.NullInt64{Int64: 1, Valid: true},
-		ExpiresAt:             sql.NullTime{Time: time.Now().AddDate(0, 0, 10), Valid: true},
-	})
-	if err != nil {
-		slog.Error("failed to create promotion code in database", "error", err, "code", codeStr)
-		return ""
+	if len(variants) == 0 {
+		return DefaultRecoveryVariant
 	}
+	return variants[rand.IntN(len(variants))]
+}
 
-	return promoCode.ID
+// recoveryCampaignName returns the campaign name used for percentage-off recovery codes
+func recoveryCampaignName(percent int64) string {
+	return fmt.Sprintf("Abandoned Cart Recovery - %d%% Off", percent)
 }
 
-// getOrCreateAbandonedCartCampaign gets or creates the 5% abandoned cart campaign
-func (d *AbandonedCartDetector) getOrCreateAbandonedCartCampaign(ctx context.Context) (*db.PromotionCampaign, error) {
+// getOrCreateRecoveryCampaign gets or creates the percentage-off abandoned cart campaign
+func getOrCreateRecoveryCampaign(ctx context.Context, queries *db.Queries, percent int64) (*db.PromotionCampaign, error) {
+	name := recoveryCampaignName(percent)
+
 	// Try to get existing campaign
-	campaigns, err := d.storage.Queries.GetActivePromotionCampaigns(ctx)
+	campaigns, err := queries.GetActivePromotionCampaigns(ctx)
 	if err == nil {
 		for _, campaign := range campaigns {
-			if campaign.Name == "Abandoned Cart Recovery - 5% Off" {
+			if campaign.Name == name {
 				return &campaign, nil
 			}
 		}
 	}
 
 	// Create new campaign
-	stripeCoupon, err := stripe.CreatePromotionCampaign("Abandoned Cart Recovery - 5% Off", "percentage", 5)
+	stripeCoupon, err := stripe.CreatePromotionCampaign(name, "percentage", percent)
 	if err != nil {
 		return nil, err
 	}
 
-	campaign, err := d.storage.Queries.CreatePromotionCampaign(ctx, db.CreatePromotionCampaignParams{
+	campaign, err := queries.CreatePromotionCampaign(ctx, db.CreatePromotionCampaignParams{
 		ID:                ulid.Make().String(),
-		Name:              "Abandoned Cart Recovery - 5% Off",
-		Description:       sql.NullString{String: "5% discount for first-time customers who abandoned their cart", Valid: true},
+		Name:              name,
+		Description:       sql.NullString{String: fmt.Sprintf("%d%% discount for customers who abandoned their cart", percent), Valid: true},
 		DiscountType:      "percentage",
-		DiscountValue:     5,
+		DiscountValue:     percent,
 		StripePromotionID: sql.NullString{String: stripeCoupon.ID, Valid: true},
 		StartDate:         time.Now(),
 		EndDate:           sql.NullTime{},
@@ -302,6 +357,44 @@ func (d *AbandonedCartDetector) getOrCreateAbandonedCartCampaign(ctx context.Con
 	return &campaign, err
 }
 
+// createRecoveryPromotionCode creates a single-use code (PREFIX-XXXXXXXX) in Stripe and the database
+func createRecoveryPromotionCode(ctx context.Context, queries *db.Queries, percent int64, prefix, userID, email string, validDays int) (*db.PromotionCode, error) {
+	campaign, err := getOrCreateRecoveryCampaign(ctx, queries, percent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abandoned cart campaign: %w", err)
+	}
+
+	codeStr := fmt.Sprintf("%s-%s", prefix, ulid.Make().String()[0:8])
+
+	// Create Stripe promotion code
+	stripePromoCode, err := stripe.CreateUniquePromotionCode(
+		campaign.StripePromotionID.String,
+		codeStr,
+		email,
+		validDays,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe promotion code %s: %w", codeStr, err)
+	}
+
+	// Create promotion code in database
+	promoCode, err := queries.CreatePromotionCode(ctx, db.CreatePromotionCodeParams{
+		ID:                    ulid.Make().String(),
+		CampaignID:            campaign.ID,
+		Code:                  codeStr,
+		StripePromotionCodeID: sql.NullString{String: stripePromoCode.ID, Valid: true},
+		Email:                 sql.NullString{String: email, Valid: true},
+		UserID:                sql.NullString{String: userID, Valid: userID != ""},
+		MaxUses:               sql.NullInt64{Int64: 1, Valid: true},
+		ExpiresAt:             sql.NullTime{Time: time.Now().AddDate(0, 0, validDays), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create promotion code %s in database: %w", codeStr, err)
+	}
+
+	return &promoCode, nil
+}
+
 func (d *AbandonedCartDetector) createCartSnapshots(ctx context.Context, abandonedCartID, sessionID, userID string) error {
 	// Handle session-based carts
 	if sessionID != "" {
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+const (
+	// CartCleanupRunHour is the local hour the nightly cart cleanup runs
+	CartCleanupRunHour = 4
+)
+
+// CartCleaner deletes guest carts nobody has touched for the cart TTL, along with the
+// lines their sessions saved for later and their quoted shipping. Carts on an account
+// are kept, since they follow the customer across devices.
+type CartCleaner struct {
+	storage *storage.Storage
+	ttl     time.Duration
+	timer   *time.Timer
+	done    chan bool
+}
+
+// NewCartCleaner makes the cleaner. A ttl of 0 keeps guest carts forever.
+func NewCartCleaner(storage *storage.Storage, ttl time.Duration) *CartCleaner {
+	return &CartCleaner{
+		storage: storage,
+		ttl:     ttl,
+		done:    make(chan bool),
+	}
+}
+
+// Start cleans up immediately, then every night at CartCleanupRunHour
+func (c *CartCleaner) Start(ctx context.Context) {
+	if c.ttl <= 0 {
+		slog.Info("cart expiry is off, guest carts are kept")
+		return
+	}
+	slog.Info("starting cart cleaner", "ttl", c.ttl, "run_hour", CartCleanupRunHour)
+
+	c.timer = time.NewTimer(untilNextRun(time.Now(), CartCleanupRunHour))
+
+	go func() {
+		c.run(ctx)
+
+		for {
+			select {
+			case <-c.timer.C:
+				c.run(ctx)
+				c.timer.Reset(untilNextRun(time.Now(), CartCleanupRunHour))
+			case <-c.done:
+				slog.Info("cart cleaner stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (c *CartCleaner) Stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	close(c.done)
+}
+
+func (c *CartCleaner) run(ctx context.Context) {
+	if err := c.Cleanup(ctx); err != nil {
+		slog.Error("failed to clean up expired carts", "error", err)
+	}
+}
+
+// Cleanup deletes the guest carts that have expired. Their abandoned cart records are
+// marked expired first, so recovery emails stop, and kept with their snapshots.
+func (c *CartCleaner) Cleanup(ctx context.Context) error {
+	maxAge := fmt.Sprintf("-%d seconds", int64(c.ttl/time.Second))
+
+	tx, err := c.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := c.storage.Queries.WithTx(tx)
+
+	expired, err := queries.ExpireGuestCartRecovery(ctx, maxAge)
+	if err != nil {
+		return fmt.Errorf("failed to expire guest cart recovery: %w", err)
+	}
+	deleted, err := queries.DeleteExpiredGuestCarts(ctx, maxAge)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired guest carts: %w", err)
+	}
+	saved, err := queries.DeleteExpiredGuestSavedItems(ctx, maxAge)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired saved items: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cart cleanup: %w", err)
+	}
+
+	shipping, err := c.storage.Queries.DeleteStaleShippingSelections(ctx, maxAge)
+	if err != nil {
+		slog.Error("failed to delete stale shipping selections", "error", err)
+	}
+
+	if deleted > 0 || saved > 0 || shipping > 0 {
+		slog.Info("cleaned up expired guest carts",
+			"cart_lines", deleted,
+			"saved_items", saved,
+			"shipping_selections", shipping,
+			"recovery_stopped", expired)
+	}
+	return nil
+}
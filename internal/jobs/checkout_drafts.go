@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+// CheckoutDraftExpiryInterval is how often lapsed embedded checkouts are closed
+const CheckoutDraftExpiryInterval = 5 * time.Minute
+
+// CheckoutDraftExpirer closes embedded checkouts left unpaid past their expiry, as
+// Stripe does for a hosted Checkout Session. Their payment intent is cancelled and the
+// gift card, store credit and stock they held go back.
+type CheckoutDraftExpirer struct {
+	storage *storage.Storage
+	ticker  *time.Ticker
+	done    chan bool
+}
+
+func NewCheckoutDraftExpirer(storage *storage.Storage) *CheckoutDraftExpirer {
+	return &CheckoutDraftExpirer{
+		storage: storage,
+		done:    make(chan bool),
+	}
+}
+
+// Start expires immediately, then every CheckoutDraftExpiryInterval
+func (e *CheckoutDraftExpirer) Start(ctx context.Context) {
+	slog.Info("starting checkout draft expirer", "interval", CheckoutDraftExpiryInterval)
+
+	e.ticker = time.NewTicker(CheckoutDraftExpiryInterval)
+
+	go func() {
+		e.run(ctx)
+
+		for {
+			select {
+			case <-e.ticker.C:
+				e.run(ctx)
+			case <-e.done:
+				slog.Info("checkout draft expirer stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (e *CheckoutDraftExpirer) Stop() {
+	if e.ticker != nil {
+		e.ticker.Stop()
+	}
+	close(e.done)
+}
+
+func (e *CheckoutDraftExpirer) run(ctx context.Context) {
+	drafts, err := e.storage.Queries.ListExpiredCheckoutDrafts(ctx, time.Now())
+	if err != nil {
+		slog.Error("failed to list expired checkout drafts", "error", err)
+		return
+	}
+
+	for _, draft := range drafts {
+		// A payment that went through, or is still clearing, makes its order through
+		// the webhook; only an abandoned one is cancelled
+		cancelled, err := stripe.CancelDraftPaymentIntent(draft.PaymentIntentID)
+		if err != nil {
+			slog.Error("failed to cancel checkout draft payment intent", "error", err, "draft_id", draft.ID, "payment_intent_id", draft.PaymentIntentID)
+			continue
+		}
+		if !cancelled {
+			continue
+		}
+
+		rows, err := e.storage.Queries.ExpireCheckoutDraft(ctx, draft.ID)
+		if err != nil {
+			slog.Error("failed to expire checkout draft", "error", err, "draft_id", draft.ID)
+			continue
+		}
+		if rows == 0 {
+			continue
+		}
+
+		if err := giftcards.NewLedger(e.storage.Queries).ReleaseSession(ctx, draft.ID, "Checkout expired"); err != nil {
+			slog.Error("failed to release gift card holds for expired checkout draft", "error", err, "draft_id", draft.ID)
+		}
+		if err := storecredit.NewLedger(e.storage.Queries).ReleaseSession(ctx, draft.ID, "Checkout expired"); err != nil {
+			slog.Error("failed to release store credit holds for expired checkout draft", "error", err, "draft_id", draft.ID)
+		}
+		if _, err := inventory.NewReservations(e.storage.Queries).ReleaseSession(ctx, draft.ID); err != nil {
+			slog.Error("failed to release stock holds for expired checkout draft", "error", err, "draft_id", draft.ID)
+		}
+		slog.Info("checkout draft expired", "draft_id", draft.ID)
+	}
+}
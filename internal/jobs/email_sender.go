@@ -3,6 +3,7 @@ package jobs
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -16,13 +17,10 @@ const (
 	// EmailSendInterval is how often we check for emails to send (15 minutes)
 	EmailSendInterval = 15 * time.Minute
 
-	// Email timing offsets
-	Email1HrOffset     = "-1 hour -5 minutes"   // Send 1hr after abandonment (with 5min buffer)
-	Email1HrMinOffset  = "-1 hour -20 minutes"  // Don't send earlier than 1hr 20min ago
-	Email24HrOffset    = "-24 hours -5 minutes" // Send 24hr after abandonment
-	Email24HrMinOffset = "-24 hours -30 minutes"
-	Email72HrOffset    = "-72 hours -5 minutes" // Send 72hr after abandonment
-	Email72HrMinOffset = "-72 hours -30 minutes"
+	// SequenceSendWindow is how long after a step becomes due we still send it.
+	// Carts that miss the window (e.g. during downtime) skip the step rather than
+	// receiving a stale reminder.
+	SequenceSendWindow = 25 * time.Minute
 )
 
 type AbandonedCartEmailSender struct {
@@ -71,37 +69,51 @@ func (s *AbandonedCartEmailSender) Stop() {
 	close(s.done)
 }
 
-// sendRecoveryEmails sends all pending recovery emails
+// sendRecoveryEmails sends all pending recovery emails for every active sequence step
 func (s *AbandonedCartEmailSender) sendRecoveryEmails(ctx context.Context) {
 	slog.Debug("checking for recovery emails to send")
 
-	// Send 1-hour emails
-	sent1hr := s.sendEmailsForAttemptType(ctx, "email_1hr", Email1HrOffset, Email1HrMinOffset)
-
-	// Send 24-hour emails
-	sent24hr := s.sendEmailsForAttemptType(ctx, "email_24hr", Email24HrOffset, Email24HrMinOffset)
+	steps, err := s.storage.Queries.ListActiveRecoverySequenceSteps(ctx)
+	if err != nil {
+		slog.Error("failed to list recovery sequence steps", "error", err)
+		return
+	}
 
-	// Send 72-hour emails
-	sent72hr := s.sendEmailsForAttemptType(ctx, "email_72hr", Email72HrOffset, Email72HrMinOffset)
+	total := 0
+	for _, step := range steps {
+		sent := s.sendEmailsForStep(ctx, step)
+		if sent > 0 {
+			slog.Info("recovery emails sent for step", "variant", step.Variant, "step", step.StepNumber, "template", step.TemplateKey, "sent", sent)
+		}
+		total += sent
+	}
 
-	total := sent1hr + sent24hr + sent72hr
 	if total > 0 {
-		slog.Info("recovery emails sent", "1hr", sent1hr, "24hr", sent24hr, "72hr", sent72hr, "total", total)
+		slog.Info("recovery emails sent", "total", total)
 	} else {
 		slog.Debug("no recovery emails to send")
 	}
 }
 
-// sendEmailsForAttemptType sends emails for a specific attempt type
-func (s *AbandonedCartEmailSender) sendEmailsForAttemptType(ctx context.Context, attemptType string, timeOffset string, minTimeOffset string) int {
-	// Get carts that need this email
-	carts, err := s.storage.Queries.GetCartsNeedingRecoveryEmail(ctx, db.GetCartsNeedingRecoveryEmailParams{
-		AttemptType:   attemptType,
-		TimeOffset:    timeOffset,
-		MinTimeOffset: minTimeOffset,
+// stepTimeOffsets returns the SQLite datetime modifiers bounding when a step is due
+func stepTimeOffsets(delayMinutes int64) (string, string) {
+	windowMinutes := int64(SequenceSendWindow / time.Minute)
+	return fmt.Sprintf("-%d minutes", delayMinutes), fmt.Sprintf("-%d minutes", delayMinutes+windowMinutes)
+}
+
+// sendEmailsForStep sends a single sequence step to every cart that is due for it
+func (s *AbandonedCartEmailSender) sendEmailsForStep(ctx context.Context, step db.CartRecoverySequenceStep) int {
+	timeOffset, minTimeOffset := stepTimeOffsets(step.DelayMinutes)
+
+	// Get carts that need this step
+	carts, err := s.storage.Queries.GetCartsDueForSequenceStep(ctx, db.GetCartsDueForSequenceStepParams{
+		SequenceStepID: sql.NullString{String: step.ID, Valid: true},
+		Variant:        step.Variant,
+		TimeOffset:     timeOffset,
+		MinTimeOffset:  minTimeOffset,
 	})
 	if err != nil {
-		slog.Error("failed to get carts needing recovery email", "attempt_type", attemptType, "error", err)
+		slog.Error("failed to get carts due for sequence step", "step_id", step.ID, "error", err)
 		return 0
 	}
 
@@ -109,7 +121,7 @@ func (s *AbandonedCartEmailSender) sendEmailsForAttemptType(ctx context.Context,
 		return 0
 	}
 
-	slog.Debug("found carts needing recovery email", "attempt_type", attemptType, "count", len(carts))
+	slog.Debug("found carts due for sequence step", "step_id", step.ID, "variant", step.Variant, "count", len(carts))
 
 	sentCount := 0
 	for _, cart := range carts {
@@ -118,6 +130,18 @@ func (s *AbandonedCartEmailSender) sendEmailsForAttemptType(ctx context.Context,
 			continue
 		}
 
+		// Customers who turned off cart reminders are skipped. The step is recorded so
+		// the cart isn't picked up for it again.
+		allowed, err := s.emailService.CheckEmailPreference(ctx, cart.CustomerEmail.String, "abandoned_cart")
+		if err != nil {
+			slog.Error("failed to check abandoned cart preference", "cart_id", cart.ID, "error", err)
+			continue
+		}
+		if !allowed {
+			s.createSequenceAttempt(ctx, cart.ID, step, "", "", "opted_out")
+			continue
+		}
+
 		// Get cart snapshots
 		snapshots, err := s.storage.Queries.GetCartSnapshotsByAbandonedCartID(ctx, cart.ID)
 		if err != nil {
@@ -144,25 +168,7 @@ func (s *AbandonedCartEmailSender) sendEmailsForAttemptType(ctx context.Context,
 
 		trackingToken := uuid.New().String()
 
-		// Get promo code if available (for 24hr and 72hr emails only)
-		// Only include promo code if user has opted into promotional emails
-		var promoCode, promoExpires string
-		if attemptType == "email_24hr" || attemptType == "email_72hr" {
-			// Check if user has opted into promotional emails
-			canSendPromo, err := s.emailService.CheckEmailPreference(ctx, cart.CustomerEmail.String, "promotional")
-			if err != nil {
-				slog.Warn("failed to check promotional preference, not including promo code", "email", cart.CustomerEmail.String, "error", err)
-			} else if canSendPromo {
-				// User is opted in, get promo code
-				cartWithPromo, err := s.storage.Queries.GetAbandonedCartWithPromoCode(ctx, cart.ID)
-				if err == nil && cartWithPromo.PromoCode.Valid {
-					promoCode = cartWithPromo.PromoCode.String
-					if cartWithPromo.PromoExpiresAt.Valid {
-						promoExpires = cartWithPromo.PromoExpiresAt.Time.Format("Jan 2, 2006")
-					}
-				}
-			}
-		}
+		promoCode, promoExpires, promoCodeID := s.stepPromoCode(ctx, step, cart)
 
 		emailData := &email.AbandonedCartData{
 			CustomerName:  customerName,
@@ -177,17 +183,17 @@ func (s *AbandonedCartEmailSender) sendEmailsForAttemptType(ctx context.Context,
 		}
 
 		// Send the email
-		err = s.emailService.SendAbandonedCartRecoveryEmail(emailData, attemptType)
+		err = s.emailService.SendAbandonedCartSequenceEmail(emailData, step.TemplateKey, step.EmailSubject)
 		if err != nil {
-			slog.Error("failed to send recovery email", "cart_id", cart.ID, "attempt_type", attemptType, "error", err)
+			slog.Error("failed to send recovery email", "cart_id", cart.ID, "step_id", step.ID, "error", err)
 
 			// Create failed recovery attempt record
-			s.createRecoveryAttempt(ctx, cart.ID, attemptType, trackingToken, "failed")
+			s.createSequenceAttempt(ctx, cart.ID, step, trackingToken, promoCodeID, "failed")
 			continue
 		}
 
 		// Create successful recovery attempt record
-		s.createRecoveryAttempt(ctx, cart.ID, attemptType, trackingToken, "sent")
+		s.createSequenceAttempt(ctx, cart.ID, step, trackingToken, promoCodeID, "sent")
 
 		// Update cart status to contacted
 		err = s.storage.Queries.MarkCartAsContacted(ctx, cart.ID)
@@ -196,36 +202,65 @@ func (s *AbandonedCartEmailSender) sendEmailsForAttemptType(ctx context.Context,
 		}
 
 		sentCount++
-		slog.Info("sent recovery email", "cart_id", cart.ID, "email", cart.CustomerEmail.String, "attempt_type", attemptType)
+		slog.Info("sent recovery email", "cart_id", cart.ID, "email", cart.CustomerEmail.String, "variant", step.Variant, "step", step.StepNumber)
 	}
 
 	return sentCount
 }
 
-// createRecoveryAttempt creates a record of a recovery email attempt
-func (s *AbandonedCartEmailSender) createRecoveryAttempt(ctx context.Context, cartID string, attemptType string, trackingToken string, status string) {
-	attemptID := uuid.New().String()
-
-	subject := ""
-	switch attemptType {
-	case "email_1hr":
-		subject = "You left something in your cart!"
-	case "email_24hr":
-		subject = "Still interested in your cart?"
-	case "email_72hr":
-		subject = "Last chance to complete your order!"
+// stepPromoCode resolves the promo code for a step. Codes are only included when the
+// customer has opted into promotional emails. A step either reuses the cart's
+// first-time buyer code or generates a fresh single-use code.
+func (s *AbandonedCartEmailSender) stepPromoCode(ctx context.Context, step db.CartRecoverySequenceStep, cart db.AbandonedCart) (string, string, string) {
+	if !step.IncludePromo && step.PromoDiscountPercent <= 0 {
+		return "", "", ""
+	}
+
+	canSendPromo, err := s.emailService.CheckEmailPreference(ctx, cart.CustomerEmail.String, "promotional")
+	if err != nil {
+		slog.Warn("failed to check promotional preference, not including promo code", "email", cart.CustomerEmail.String, "error", err)
+		return "", "", ""
+	}
+	if !canSendPromo {
+		return "", "", ""
+	}
+
+	if step.PromoDiscountPercent > 0 {
+		prefix := fmt.Sprintf("BACK%d", step.PromoDiscountPercent)
+		promo, err := createRecoveryPromotionCode(ctx, s.storage.Queries, step.PromoDiscountPercent, prefix, cart.UserID.String, cart.CustomerEmail.String, int(step.PromoValidDays))
+		if err != nil {
+			slog.Error("failed to generate step promotion code", "cart_id", cart.ID, "step_id", step.ID, "error", err)
+			return "", "", ""
+		}
+		return promo.Code, promo.ExpiresAt.Time.Format("Jan 2, 2006"), promo.ID
 	}
 
-	_, err := s.storage.Queries.CreateRecoveryAttempt(ctx, db.CreateRecoveryAttemptParams{
-		ID:              attemptID,
+	cartWithPromo, err := s.storage.Queries.GetAbandonedCartWithPromoCode(ctx, cart.ID)
+	if err != nil || !cartWithPromo.PromoCode.Valid {
+		return "", "", ""
+	}
+	promoExpires := ""
+	if cartWithPromo.PromoExpiresAt.Valid {
+		promoExpires = cartWithPromo.PromoExpiresAt.Time.Format("Jan 2, 2006")
+	}
+	return cartWithPromo.PromoCode.String, promoExpires, cartWithPromo.PromotionCodeID.String
+}
+
+// createSequenceAttempt records a recovery email sent for a sequence step
+func (s *AbandonedCartEmailSender) createSequenceAttempt(ctx context.Context, cartID string, step db.CartRecoverySequenceStep, trackingToken, promoCodeID, status string) {
+	_, err := s.storage.Queries.CreateSequenceRecoveryAttempt(ctx, db.CreateSequenceRecoveryAttemptParams{
+		ID:              uuid.New().String(),
 		AbandonedCartID: cartID,
-		AttemptType:     attemptType,
+		AttemptType:     step.TemplateKey,
 		SentAt:          time.Now(),
-		EmailSubject:    sql.NullString{String: subject, Valid: true},
+		EmailSubject:    sql.NullString{String: step.EmailSubject, Valid: true},
 		TrackingToken:   sql.NullString{String: trackingToken, Valid: true},
 		Status:          sql.NullString{String: status, Valid: true},
+		SequenceStepID:  sql.NullString{String: step.ID, Valid: true},
+		Variant:         sql.NullString{String: step.Variant, Valid: true},
+		PromotionCodeID: sql.NullString{String: promoCodeID, Valid: promoCodeID != ""},
 	})
 	if err != nil {
-		slog.Error("failed to create recovery attempt record", "cart_id", cartID, "error", err)
+		slog.Error("failed to create recovery attempt record", "cart_id", cartID, "step_id", step.ID, "error", err)
 	}
 }
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/events"
+)
+
+const (
+	// EventReminderInterval is how often we check for attendees to remind
+	EventReminderInterval = time.Hour
+
+	// EventReminderLead is how far ahead of an event its reminder goes out
+	EventReminderLead = 24 * time.Hour
+)
+
+// EventReminderSender emails confirmed attendees the day before their event
+type EventReminderSender struct {
+	storage      *storage.Storage
+	emailService *email.Service
+	ticker       *time.Ticker
+	done         chan bool
+}
+
+func NewEventReminderSender(storage *storage.Storage, emailService *email.Service) *EventReminderSender {
+	return &EventReminderSender{
+		storage:      storage,
+		emailService: emailService,
+		done:         make(chan bool),
+	}
+}
+
+// Start sends any due reminders immediately, then every EventReminderInterval
+func (s *EventReminderSender) Start(ctx context.Context) {
+	slog.Info("starting event reminder sender", "interval", EventReminderInterval)
+
+	s.ticker = time.NewTicker(EventReminderInterval)
+
+	go func() {
+		s.sendReminders(ctx)
+
+		for {
+			select {
+			case <-s.ticker.C:
+				s.sendReminders(ctx)
+			case <-s.done:
+				slog.Info("event reminder sender stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (s *EventReminderSender) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	close(s.done)
+}
+
+// sendReminders emails every confirmed attendee of an event starting within EventReminderLead
+func (s *EventReminderSender) sendReminders(ctx context.Context) {
+	now := utils.WallClockNow()
+	registrations, err := s.storage.Queries.ListEventRegistrationsDueForReminder(ctx, db.ListEventRegistrationsDueForReminderParams{
+		WindowStart: now,
+		WindowEnd:   now.Add(EventReminderLead),
+	})
+	if err != nil {
+		slog.Error("failed to list event registrations due for reminder", "error", err)
+		return
+	}
+
+	loaded := make(map[string]db.Event)
+	sent := 0
+	for _, registration := range registrations {
+		event, ok := loaded[registration.EventID]
+		if !ok {
+			event, err = s.storage.Queries.GetEvent(ctx, registration.EventID)
+			if err != nil {
+				slog.Error("failed to load event for reminder", "error", err, "event_id", registration.EventID)
+				continue
+			}
+			loaded[event.ID] = event
+		}
+
+		if err := s.emailService.SendEventReminder(events.RegistrationEmail(event, registration)); err != nil && !errors.Is(err, email.ErrOptedOut) {
+			slog.Error("failed to send event reminder", "error", err, "registration_id", registration.ID)
+			continue
+		}
+		if err := s.storage.Queries.MarkEventRegistrationReminded(ctx, registration.ID); err != nil {
+			slog.Error("failed to mark event reminder sent", "error", err, "registration_id", registration.ID)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		slog.Info("event reminders sent", "count", sent)
+	}
+}
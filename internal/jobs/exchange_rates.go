@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/currency"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// ExchangeRateUpdater keeps the rates used for converted storefront prices current
+type ExchangeRateUpdater struct {
+	storage  *storage.Storage
+	rates    *currency.Store
+	url      string
+	interval time.Duration
+	client   *http.Client
+	ticker   *time.Ticker
+	done     chan bool
+}
+
+func NewExchangeRateUpdater(storage *storage.Storage, rates *currency.Store, url string, interval time.Duration) *ExchangeRateUpdater {
+	return &ExchangeRateUpdater{
+		storage:  storage,
+		rates:    rates,
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		done:     make(chan bool),
+	}
+}
+
+// Start fetches rates immediately, then every interval. An interval of 0 leaves the
+// stored rates alone; they go stale after currency.MaxRateAge and prices show in USD.
+func (u *ExchangeRateUpdater) Start(ctx context.Context) {
+	if u.interval <= 0 {
+		slog.Info("exchange rate updates disabled")
+		return
+	}
+	slog.Info("starting exchange rate updater", "interval", u.interval)
+
+	u.ticker = time.NewTicker(u.interval)
+
+	go func() {
+		u.update(ctx)
+
+		for {
+			select {
+			case <-u.ticker.C:
+				u.update(ctx)
+			case <-u.done:
+				slog.Info("exchange rate updater stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (u *ExchangeRateUpdater) Stop() {
+	if u.ticker != nil {
+		u.ticker.Stop()
+	}
+	close(u.done)
+}
+
+// update stores the latest rates. A failed fetch keeps the previous rates.
+func (u *ExchangeRateUpdater) update(ctx context.Context) {
+	rates, err := currency.FetchRates(ctx, u.client, u.url)
+	if err != nil {
+		slog.Error("failed to fetch exchange rates", "error", err, "url", u.url)
+		return
+	}
+
+	for code, rate := range rates {
+		if err := u.storage.Queries.UpsertExchangeRate(ctx, db.UpsertExchangeRateParams{
+			Currency: code,
+			Rate:     rate,
+		}); err != nil {
+			slog.Error("failed to store exchange rate", "error", err, "currency", code)
+		}
+	}
+	u.rates.Invalidate()
+
+	slog.Info("exchange rates updated", "currencies", len(rates))
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+const (
+	// InventoryHoldSweepInterval is how often lapsed stock holds are marked released
+	InventoryHoldSweepInterval = 5 * time.Minute
+
+	// InventoryHoldKeep is how long finished holds are kept, to look into an oversell
+	InventoryHoldKeep = 30 * 24 * time.Hour
+)
+
+// InventoryHoldSweeper marks released the stock holds of checkouts that lapsed
+// without Stripe's expiry webhook arriving. A lapsed hold already stops counting
+// against stock, so this keeps the table honest rather than freeing stock.
+type InventoryHoldSweeper struct {
+	storage *storage.Storage
+	ticker  *time.Ticker
+	done    chan bool
+}
+
+func NewInventoryHoldSweeper(storage *storage.Storage) *InventoryHoldSweeper {
+	return &InventoryHoldSweeper{
+		storage: storage,
+		done:    make(chan bool),
+	}
+}
+
+// Start sweeps immediately, then every InventoryHoldSweepInterval
+func (s *InventoryHoldSweeper) Start(ctx context.Context) {
+	slog.Info("starting inventory hold sweeper", "interval", InventoryHoldSweepInterval)
+
+	s.ticker = time.NewTicker(InventoryHoldSweepInterval)
+
+	go func() {
+		s.run(ctx)
+
+		for {
+			select {
+			case <-s.ticker.C:
+				s.run(ctx)
+			case <-s.done:
+				slog.Info("inventory hold sweeper stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (s *InventoryHoldSweeper) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	close(s.done)
+}
+
+func (s *InventoryHoldSweeper) run(ctx context.Context) {
+	released, deleted, err := inventory.NewReservations(s.storage.Queries).ReleaseExpired(ctx, InventoryHoldKeep)
+	if err != nil {
+		slog.Error("failed to sweep inventory holds", "error", err)
+	}
+	if released > 0 || deleted > 0 {
+		slog.Info("swept inventory holds", "released", released, "deleted", deleted)
+	}
+}
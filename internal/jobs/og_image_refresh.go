@@ -11,7 +11,9 @@ import (
 
 	"golang.org/x/sync/semaphore"
 
+	"github.com/loganlanou/logans3d-v4/internal/flags"
 	"github.com/loganlanou/logans3d-v4/internal/ogimage"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage"
 )
 
@@ -39,7 +41,8 @@ func NewOGImageRefresherWithAI(storage *storage.Storage, geminiAPIKey string) *O
 	return r
 }
 
-// Start begins the OG image refresh process in a background goroutine
+// Start begins the OG image refresh process in a background goroutine. AI images
+// are only generated when ctx carries the ai_og_images flag (see flags.WithSubject).
 func (r *OGImageRefresher) Start(ctx context.Context) {
 	go r.refreshAllOGImages(ctx)
 }
@@ -299,9 +302,9 @@ func (r *OGImageRefresher) generateMultiVariantOG(ctx context.Context, productID
 		StyleNames: styleNames,
 	}
 
-	// Use AI generator if available, otherwise fall back to grid method
+	// Use AI generator if available and switched on, otherwise fall back to grid method
 	var genErr error
-	if r.aiGenerator != nil {
+	if r.aiGenerator != nil && flags.Enabled(ctx, flags.AIOGImages) {
 		genErr = r.aiGenerator.GenerateMultiVariantOGImage(info, ogImagePath)
 	} else {
 		genErr = ogimage.GenerateMultiVariantOGImage(info, ogImagePath)
@@ -311,6 +314,7 @@ func (r *OGImageRefresher) generateMultiVariantOG(ctx context.Context, productID
 		return false, genErr
 	}
 
+	utils.RecordMultiVariantOGImage(productID)
 	slog.Debug("generated multi-variant OG image", "product", productName, "styles", styleCount, "output", ogImagePath)
 	return true, nil
 }
@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/privacy"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// PrivacyExportInterval is how often requested data exports are made
+const PrivacyExportInterval = time.Minute
+
+// PrivacyExporter makes the data exports customers ask for from their privacy page,
+// emails them when each is ready, and removes the files once they expire
+type PrivacyExporter struct {
+	storage      *storage.Storage
+	emailService *email.Service
+	dir          string
+	ticker       *time.Ticker
+	done         chan bool
+}
+
+func NewPrivacyExporter(storage *storage.Storage, emailService *email.Service, dir string) *PrivacyExporter {
+	return &PrivacyExporter{
+		storage:      storage,
+		emailService: emailService,
+		dir:          dir,
+		done:         make(chan bool),
+	}
+}
+
+// Start exports immediately, then every PrivacyExportInterval
+func (e *PrivacyExporter) Start(ctx context.Context) {
+	slog.Info("starting privacy exporter", "interval", PrivacyExportInterval, "dir", e.dir)
+
+	e.ticker = time.NewTicker(PrivacyExportInterval)
+
+	go func() {
+		e.run(ctx)
+
+		for {
+			select {
+			case <-e.ticker.C:
+				e.run(ctx)
+			case <-e.done:
+				slog.Info("privacy exporter stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (e *PrivacyExporter) Stop() {
+	if e.ticker != nil {
+		e.ticker.Stop()
+	}
+	close(e.done)
+}
+
+func (e *PrivacyExporter) run(ctx context.Context) {
+	e.removeExpired(ctx)
+
+	requests, err := e.storage.Queries.ListPendingPrivacyExports(ctx)
+	if err != nil {
+		slog.Error("failed to list pending privacy exports", "error", err)
+		return
+	}
+	for _, request := range requests {
+		if err := e.export(ctx, request); err != nil {
+			slog.Error("failed to export customer data", "error", err, "request_id", request.ID, "user_id", request.UserID)
+			if err := e.storage.Queries.FailPrivacyRequest(ctx, db.FailPrivacyRequestParams{
+				Error: err.Error(),
+				ID:    request.ID,
+			}); err != nil {
+				slog.Error("failed to mark privacy export failed", "error", err, "request_id", request.ID)
+			}
+		}
+	}
+}
+
+// export writes one customer's data to a ZIP in the export directory and emails them
+func (e *PrivacyExporter) export(ctx context.Context, request db.PrivacyRequest) error {
+	user, err := e.storage.Queries.GetUser(ctx, request.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("the account has been deleted")
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	export, err := privacy.Collect(ctx, e.storage.DB(), privacy.Subject{UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return err
+	}
+	fileName := request.ID + ".zip"
+	if err := e.writeFile(fileName, export); err != nil {
+		return err
+	}
+	summary, err := json.Marshal(export.Summary())
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+
+	expiresAt := time.Now().Add(privacy.ExportTTL)
+	if err := e.storage.Queries.CompletePrivacyExport(ctx, db.CompletePrivacyExportParams{
+		FileName:    fileName,
+		SummaryJson: string(summary),
+		ExpiresAt:   sql.NullTime{Time: expiresAt, Valid: true},
+		ID:          request.ID,
+	}); err != nil {
+		os.Remove(filepath.Join(e.dir, fileName))
+		return fmt.Errorf("complete request: %w", err)
+	}
+	slog.Info("customer data exported", "request_id", request.ID, "user_id", user.ID)
+
+	name := user.FirstName.String
+	if name == "" {
+		name = user.FullName
+	}
+	if err := e.emailService.SendPrivacyExportReady(&email.PrivacyExportData{
+		CustomerName:  name,
+		CustomerEmail: user.Email,
+		ExpiresOn:     expiresAt.Format("January 2, 2006"),
+	}); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send privacy export email", "error", err, "request_id", request.ID)
+	}
+	return nil
+}
+
+// writeFile writes an export through a temporary file, so a half-written ZIP is never
+// offered for download. Only the server can read it.
+func (e *PrivacyExporter) writeFile(fileName string, export *privacy.Export) error {
+	if err := os.MkdirAll(e.dir, 0o700); err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(e.dir, fileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := privacy.WriteZip(tmp, export); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write export: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(e.dir, fileName))
+}
+
+// removeExpired deletes the files of exports past their expiry
+func (e *PrivacyExporter) removeExpired(ctx context.Context) {
+	requests, err := e.storage.Queries.ListExpiredPrivacyExports(ctx, sql.NullTime{Time: time.Now(), Valid: true})
+	if err != nil {
+		slog.Error("failed to list expired privacy exports", "error", err)
+		return
+	}
+	for _, request := range requests {
+		if err := os.Remove(filepath.Join(e.dir, request.FileName)); err != nil && !os.IsNotExist(err) {
+			slog.Error("failed to remove expired privacy export", "error", err, "request_id", request.ID)
+			continue
+		}
+		if err := e.storage.Queries.ClearPrivacyExportFile(ctx, request.ID); err != nil {
+			slog.Error("failed to clear expired privacy export", "error", err, "request_id", request.ID)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/cache"
+	"github.com/loganlanou/logans3d-v4/internal/schedule"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+// ProductScheduleInterval is how often scheduled publishing and sales are checked, so
+// they happen within a minute of their time
+const ProductScheduleInterval = time.Minute
+
+// ProductSyncer brings a product's Stripe catalog entry up to date
+type ProductSyncer interface {
+	SyncProduct(ctx context.Context, productID string) error
+}
+
+// ProductScheduler publishes and unpublishes products and starts and ends their sales
+// at the times set in admin
+type ProductScheduler struct {
+	storage *storage.Storage
+	cache   *cache.Cache
+	catalog ProductSyncer
+	ticker  *time.Ticker
+	done    chan bool
+}
+
+// NewProductScheduler makes the scheduler. catalog may be nil when the Stripe catalog
+// isn't synced.
+func NewProductScheduler(storage *storage.Storage, cache *cache.Cache, catalog ProductSyncer) *ProductScheduler {
+	return &ProductScheduler{
+		storage: storage,
+		cache:   cache,
+		catalog: catalog,
+		done:    make(chan bool),
+	}
+}
+
+// Start applies anything already due, then checks every ProductScheduleInterval
+func (s *ProductScheduler) Start(ctx context.Context) {
+	slog.Info("starting product scheduler", "interval", ProductScheduleInterval)
+
+	s.ticker = time.NewTicker(ProductScheduleInterval)
+
+	go func() {
+		s.run(ctx)
+
+		for {
+			select {
+			case <-s.ticker.C:
+				s.run(ctx)
+			case <-s.done:
+				slog.Info("product scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (s *ProductScheduler) Stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	close(s.done)
+}
+
+func (s *ProductScheduler) run(ctx context.Context) {
+	changed, err := schedule.Apply(ctx, s.storage.Queries, utils.WallClockNow())
+	if err != nil {
+		// Steps that ran before the failure still stand, so sync what they changed
+		slog.Error("failed to apply product schedules", "error", err)
+	}
+	if len(changed) == 0 {
+		return
+	}
+	slog.Info("applied product schedules", "products", len(changed))
+	// Visibility and prices both show on cached product lists
+	s.cache.Invalidate(cache.GroupProducts)
+
+	if s.catalog == nil {
+		return
+	}
+	for _, productID := range changed {
+		if err := s.catalog.SyncProduct(ctx, productID); err != nil {
+			slog.Error("failed to sync scheduled product to stripe", "error", err, "product_id", productID)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+const (
+	// RecommendationRunHour is the local hour the nightly recommendation rebuild runs
+	RecommendationRunHour = 3
+)
+
+// RecommendationBuilder rebuilds "frequently bought together" pairs and prunes old product views nightly
+type RecommendationBuilder struct {
+	storage *storage.Storage
+	timer   *time.Timer
+	done    chan bool
+}
+
+func NewRecommendationBuilder(storage *storage.Storage) *RecommendationBuilder {
+	return &RecommendationBuilder{
+		storage: storage,
+		done:    make(chan bool),
+	}
+}
+
+// Start rebuilds recommendations immediately, then every night at RecommendationRunHour
+func (b *RecommendationBuilder) Start(ctx context.Context) {
+	slog.Info("starting recommendation builder", "run_hour", RecommendationRunHour)
+
+	b.timer = time.NewTimer(untilNextRun(time.Now(), RecommendationRunHour))
+
+	go func() {
+		b.run(ctx)
+
+		for {
+			select {
+			case <-b.timer.C:
+				b.run(ctx)
+				b.timer.Reset(untilNextRun(time.Now(), RecommendationRunHour))
+			case <-b.done:
+				slog.Info("recommendation builder stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (b *RecommendationBuilder) Stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	close(b.done)
+}
+
+func (b *RecommendationBuilder) run(ctx context.Context) {
+	pruned, err := b.storage.Queries.PruneProductViews(ctx)
+	if err != nil {
+		slog.Error("failed to prune product views", "error", err)
+	} else if pruned > 0 {
+		slog.Info("pruned old product views", "count", pruned)
+	}
+
+	if err := b.RebuildRecommendations(ctx); err != nil {
+		slog.Error("failed to rebuild product recommendations", "error", err)
+	}
+}
+
+// RebuildRecommendations replaces all product pairs with fresh co-occurrence counts from orders
+func (b *RecommendationBuilder) RebuildRecommendations(ctx context.Context) error {
+	startTime := time.Now()
+
+	tx, err := b.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := b.storage.Queries.WithTx(tx)
+	if err := queries.ClearProductRecommendations(ctx); err != nil {
+		return fmt.Errorf("failed to clear recommendations: %w", err)
+	}
+	if err := queries.RebuildProductRecommendations(ctx); err != nil {
+		return fmt.Errorf("failed to compute recommendations: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recommendations: %w", err)
+	}
+
+	slog.Info("product recommendations rebuilt", "duration", time.Since(startTime))
+	return nil
+}
+
+// untilNextRun returns the delay until the next occurrence of hour:00 local time
+func untilNextRun(now time.Time, hour int) time.Duration {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next.Sub(now)
+}
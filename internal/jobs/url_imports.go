@@ -0,0 +1,257 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/loganlanou/logans3d-v4/internal/importer"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// URLImportInterval is how often queued URL imports are picked up
+const URLImportInterval = 10 * time.Second
+
+// What became of a queued design URL
+const (
+	urlImported  = "imported"
+	urlDuplicate = "duplicate"
+	urlFailed    = "failed"
+)
+
+// URLImporter works through the design URLs pasted into the admin importer, one job at
+// a time in the order they were queued. Each URL is scraped, and the design made into
+// a product with its images downloaded. The job's progress is updated after every URL.
+type URLImporter struct {
+	storage   *storage.Storage
+	scrapers  map[string]importer.Scraper
+	imagesDir string
+	ticker    *time.Ticker
+	done      chan bool
+}
+
+func NewURLImporter(storage *storage.Storage) *URLImporter {
+	return &URLImporter{
+		storage:   storage,
+		scrapers:  importer.NewScrapers(),
+		imagesDir: "public/images/products",
+		done:      make(chan bool),
+	}
+}
+
+// Start imports immediately, then every URLImportInterval
+func (u *URLImporter) Start(ctx context.Context) {
+	slog.Info("starting URL importer", "interval", URLImportInterval)
+
+	u.ticker = time.NewTicker(URLImportInterval)
+
+	go func() {
+		u.run(ctx)
+
+		for {
+			select {
+			case <-u.ticker.C:
+				u.run(ctx)
+			case <-u.done:
+				slog.Info("URL importer stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background job
+func (u *URLImporter) Stop() {
+	if u.ticker != nil {
+		u.ticker.Stop()
+	}
+	close(u.done)
+}
+
+func (u *URLImporter) run(ctx context.Context) {
+	jobs, err := u.storage.Queries.ListQueuedURLImportJobs(ctx)
+	if err != nil {
+		slog.Error("failed to list queued URL imports", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		u.runJob(ctx, job)
+	}
+}
+
+func (u *URLImporter) runJob(ctx context.Context, job db.ImportJob) {
+	if err := u.storage.Queries.StartImportJob(ctx, job.ID); err != nil {
+		slog.Error("failed to start URL import", "error", err, "job_id", job.ID)
+		return
+	}
+	queued, err := u.storage.Queries.ListPendingImportJobURLs(ctx, job.ID)
+	if err != nil {
+		slog.Error("failed to list queued URLs", "error", err, "job_id", job.ID)
+		return
+	}
+
+	for _, queuedURL := range queued {
+		if ctx.Err() != nil {
+			return
+		}
+		status, productID, importErr := u.importURL(ctx, queuedURL)
+		errMsg := ""
+		if importErr != nil {
+			slog.Error("failed to import design", "error", importErr, "url", queuedURL.Url, "job_id", job.ID)
+			errMsg = importErr.Error()
+		}
+		if err := u.storage.Queries.FinishImportJobURL(ctx, db.FinishImportJobURLParams{
+			Status:    status,
+			Error:     errMsg,
+			ProductID: sql.NullString{String: productID, Valid: productID != ""},
+			ID:        queuedURL.ID,
+		}); err != nil {
+			slog.Error("failed to record imported URL", "error", err, "url", queuedURL.Url, "job_id", job.ID)
+		}
+
+		counts, err := u.storage.Queries.CountImportJobURLsByStatus(ctx, job.ID)
+		if err != nil {
+			slog.Error("failed to count imported URLs", "error", err, "job_id", job.ID)
+			continue
+		}
+		if err := u.storage.Queries.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{
+			ProcessedItems: sql.NullInt64{Int64: counts.Processed, Valid: true},
+			TotalItems:     sql.NullInt64{Int64: counts.Total, Valid: true},
+			ID:             job.ID,
+		}); err != nil {
+			slog.Error("failed to update job progress", "error", err, "job_id", job.ID)
+		}
+	}
+
+	counts, err := u.storage.Queries.CountImportJobURLsByStatus(ctx, job.ID)
+	if err != nil {
+		slog.Error("failed to count imported URLs", "error", err, "job_id", job.ID)
+		return
+	}
+	if counts.Total > 0 && counts.Failed == counts.Total {
+		if err := u.storage.Queries.FailImportJob(ctx, db.FailImportJobParams{
+			ErrorMessage: sql.NullString{String: fmt.Sprintf("none of the %d URLs could be imported", counts.Total), Valid: true},
+			ID:           job.ID,
+		}); err != nil {
+			slog.Error("failed to mark job as failed", "error", err, "job_id", job.ID)
+		}
+		return
+	}
+	if err := u.storage.Queries.CompleteImportJob(ctx, job.ID); err != nil {
+		slog.Error("failed to complete import job", "error", err, "job_id", job.ID)
+	}
+	slog.Info("URL import completed", "job_id", job.ID, "urls", counts.Total, "failed", counts.Failed)
+}
+
+// importURL scrapes one design and makes it a product. A design the shop already has,
+// by its source URL, is left alone and reported as a duplicate.
+func (u *URLImporter) importURL(ctx context.Context, queued db.ImportJobUrl) (status, productID string, err error) {
+	existing, err := u.storage.Queries.GetProductBySourceURL(ctx, sql.NullString{String: queued.Url, Valid: true})
+	if err == nil {
+		return urlDuplicate, existing.ID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return urlFailed, "", fmt.Errorf("look up existing product: %w", err)
+	}
+
+	scraper, ok := u.scrapers[queued.Platform]
+	if !ok {
+		return urlFailed, "", fmt.Errorf("designs from %s can't be imported", queued.Platform)
+	}
+	product, err := scraper.FetchProduct(ctx, queued.Url)
+	if err != nil {
+		return urlFailed, "", err
+	}
+
+	// A designer we scrape keeps their configured name and slug
+	designerName := product.DesignerName
+	designerSlug := utils.DesignerSlug(designerName)
+	if designer := importer.GetDesigner(designerSlug); designer != nil {
+		designerName = designer.Name
+	}
+
+	scraped, err := u.saveScraped(ctx, product, designerSlug)
+	if err != nil {
+		return urlFailed, "", err
+	}
+	if scraped.ImportedProductID.Valid {
+		return urlDuplicate, scraped.ImportedProductID.String, nil
+	}
+
+	categoryID, err := u.categoryFor(ctx, queued.CategoryID, product, designerSlug)
+	if err != nil {
+		return urlFailed, "", err
+	}
+	downloader := importer.NewImageDownloader(u.imagesDir)
+	productID, err = importer.ImportProduct(ctx, u.storage.Queries, scraped, importer.DefaultImportSettings(scraped, categoryID), designerName, downloader)
+	if err != nil {
+		return urlFailed, "", err
+	}
+	return urlImported, productID, nil
+}
+
+// saveScraped keeps the scraped design with the ones scraped from designers' pages, so
+// it can be found, reviewed and re-scraped the same way
+func (u *URLImporter) saveScraped(ctx context.Context, product *importer.ScrapedProduct, designerSlug string) (db.ScrapedProduct, error) {
+	imageURLsJSON, _ := json.Marshal(product.ImageURLs)
+	tagsJSON, _ := json.Marshal(product.Tags)
+
+	var releaseDate sql.NullTime
+	if product.ReleaseDate != nil {
+		releaseDate = sql.NullTime{Time: *product.ReleaseDate, Valid: true}
+	}
+
+	scraped, err := u.storage.Queries.UpsertScrapedProduct(ctx, db.UpsertScrapedProductParams{
+		ID:                  uuid.New().String(),
+		DesignerSlug:        designerSlug,
+		Platform:            product.Platform,
+		SourceUrl:           product.SourceURL,
+		Name:                product.Name,
+		Description:         sql.NullString{String: product.Description, Valid: product.Description != ""},
+		OriginalPriceCents:  sql.NullInt64{Int64: int64(product.OriginalPriceCents), Valid: product.OriginalPriceCents > 0},
+		ReleaseDate:         releaseDate,
+		ImageUrls:           sql.NullString{String: string(imageURLsJSON), Valid: len(product.ImageURLs) > 0},
+		Tags:                sql.NullString{String: string(tagsJSON), Valid: len(product.Tags) > 0},
+		RawHtml:             sql.NullString{String: product.RawHTML, Valid: product.RawHTML != ""},
+		OriginalDescription: sql.NullString{String: product.Description, Valid: product.Description != ""},
+	})
+	if err != nil {
+		return db.ScrapedProduct{}, fmt.Errorf("save scraped product: %w", err)
+	}
+	return scraped, nil
+}
+
+// categoryFor returns the category the admin chose, or else the one matched from the
+// design's tags and title, falling back to the designer's default category
+func (u *URLImporter) categoryFor(ctx context.Context, chosen string, product *importer.ScrapedProduct, designerSlug string) (string, error) {
+	if chosen != "" {
+		return chosen, nil
+	}
+	categories, err := u.storage.Queries.ListCategories(ctx)
+	if err != nil {
+		return "", fmt.Errorf("list categories: %w", err)
+	}
+	names := make([]string, len(categories))
+	for i, category := range categories {
+		names[i] = category.Name
+	}
+	fallback := ""
+	if designer := importer.GetDesigner(designerSlug); designer != nil {
+		fallback = designer.DefaultCategory
+	}
+
+	match := importer.MatchCategory(product, names, fallback)
+	for _, category := range categories {
+		if category.Name == match {
+			return category.ID, nil
+		}
+	}
+	return "", nil
+}
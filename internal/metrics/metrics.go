@@ -0,0 +1,303 @@
+// Package metrics keeps per-route request counts and latencies in memory. Totals since
+// startup are exported in Prometheus text format, and a rolling per-minute window plus
+// a log of recent requests back the latency and error-rate panel in the developer tools.
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// UnmatchedRoute labels requests that didn't match any registered route, so stray
+// URLs don't each get their own series
+const UnmatchedRoute = "unmatched"
+
+const (
+	windowMinutes = 60
+	recentLimit   = 500
+)
+
+// latencyBounds are the histogram bucket upper bounds, Prometheus' defaults
+var latencyBounds = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Request is one handled request
+type Request struct {
+	At      time.Time
+	Method  string
+	Route   string
+	Path    string
+	Status  int
+	Latency time.Duration
+	UserID  string
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+// histogram counts latencies per bucket; the last slot is everything over the top bound
+type histogram struct {
+	counts [12]int64
+	sum    time.Duration
+	total  int64
+}
+
+func (h *histogram) observe(latency time.Duration) {
+	i := sort.Search(len(latencyBounds), func(i int) bool { return latency <= latencyBounds[i] })
+	h.counts[i]++
+	h.sum += latency
+	h.total++
+}
+
+func (h *histogram) add(other histogram) {
+	for i := range h.counts {
+		h.counts[i] += other.counts[i]
+	}
+	h.sum += other.sum
+	h.total += other.total
+}
+
+// quantile estimates a latency quantile by interpolating within its bucket
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := q * float64(h.total)
+	var seen int64
+	for i, count := range h.counts {
+		if count == 0 || float64(seen+count) < rank {
+			seen += count
+			continue
+		}
+		if i == len(latencyBounds) {
+			return latencyBounds[len(latencyBounds)-1]
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = latencyBounds[i-1]
+		}
+		fraction := (rank - float64(seen)) / float64(count)
+		return lower + time.Duration(fraction*float64(latencyBounds[i]-lower))
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
+
+type minute struct {
+	start   int64
+	latency histogram
+	errors  int64
+	client  int64
+}
+
+type route struct {
+	statuses map[int]int64
+	latency  histogram
+	minutes  [windowMinutes]minute
+}
+
+// Store is safe for concurrent use. A nil *Store records nothing.
+type Store struct {
+	mu      sync.Mutex
+	routes  map[routeKey]*route
+	recent  []Request
+	next    int
+	started time.Time
+	now     func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		routes:  make(map[routeKey]*route),
+		recent:  make([]Request, 0, recentLimit),
+		started: time.Now(),
+		now:     time.Now,
+	}
+}
+
+// Record adds a handled request to the totals, the rolling window and the recent log
+func (s *Store) Record(r Request) {
+	if s == nil {
+		return
+	}
+	if r.Route == "" {
+		r.Route = UnmatchedRoute
+	}
+	if r.At.IsZero() {
+		r.At = s.now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := routeKey{method: r.Method, route: r.Route}
+	rt, ok := s.routes[key]
+	if !ok {
+		rt = &route{statuses: make(map[int]int64)}
+		s.routes[key] = rt
+	}
+	rt.statuses[r.Status]++
+	rt.latency.observe(r.Latency)
+
+	start := r.At.Unix() / 60
+	slot := &rt.minutes[start%windowMinutes]
+	if slot.start != start {
+		*slot = minute{start: start}
+	}
+	slot.latency.observe(r.Latency)
+	switch {
+	case r.Status >= 500:
+		slot.errors++
+	case r.Status >= 400:
+		slot.client++
+	}
+
+	if len(s.recent) < recentLimit {
+		s.recent = append(s.recent, r)
+	} else {
+		s.recent[s.next] = r
+	}
+	s.next = (s.next + 1) % recentLimit
+}
+
+// RouteStats summarises one route over the rolling window
+type RouteStats struct {
+	Method       string
+	Route        string
+	Requests     int64
+	ServerErrors int64
+	ClientErrors int64
+	Average      time.Duration
+	P50          time.Duration
+	P95          time.Duration
+	P99          time.Duration
+	AllTime      int64
+}
+
+// ErrorRate is the share of requests in the window that failed with a 5xx
+func (r RouteStats) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.ServerErrors) / float64(r.Requests)
+}
+
+// Snapshot is the rolling window across all routes, busiest first
+type Snapshot struct {
+	Since  time.Time
+	Window time.Duration
+	Totals RouteStats
+	Routes []RouteStats
+}
+
+// Snapshot summarises the last window of traffic, rounded up to whole minutes and
+// capped at an hour
+func (s *Store) Snapshot(window time.Duration) Snapshot {
+	minutes := int64(min(max((window+time.Minute-1)/time.Minute, 1), windowMinutes))
+	snapshot := Snapshot{Window: time.Duration(minutes) * time.Minute}
+	if s == nil {
+		return snapshot
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot.Since = s.started
+	current := s.now().Unix() / 60
+	var all histogram
+	for key, rt := range s.routes {
+		stats := RouteStats{Method: key.method, Route: key.route, AllTime: rt.latency.total}
+		var latency histogram
+		for _, slot := range rt.minutes {
+			if slot.start <= current-minutes || slot.start > current {
+				continue
+			}
+			latency.add(slot.latency)
+			stats.ServerErrors += slot.errors
+			stats.ClientErrors += slot.client
+		}
+		if latency.total == 0 {
+			continue
+		}
+		stats.fill(latency)
+		all.add(latency)
+		snapshot.Totals.ServerErrors += stats.ServerErrors
+		snapshot.Totals.ClientErrors += stats.ClientErrors
+		snapshot.Totals.AllTime += stats.AllTime
+		snapshot.Routes = append(snapshot.Routes, stats)
+	}
+	snapshot.Totals.fill(all)
+
+	sort.Slice(snapshot.Routes, func(i, j int) bool {
+		a, b := snapshot.Routes[i], snapshot.Routes[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		return a.Method < b.Method
+	})
+	return snapshot
+}
+
+func (r *RouteStats) fill(latency histogram) {
+	r.Requests = latency.total
+	if latency.total > 0 {
+		r.Average = latency.sum / time.Duration(latency.total)
+	}
+	r.P50 = latency.quantile(0.50)
+	r.P95 = latency.quantile(0.95)
+	r.P99 = latency.quantile(0.99)
+}
+
+// Filter narrows the recent request log. Empty fields match everything.
+type Filter struct {
+	Route     string
+	UserID    string
+	MinStatus int
+	Limit     int
+}
+
+// Recent returns logged requests matching the filter, newest first
+func (s *Store) Recent(filter Filter) []Request {
+	if s == nil {
+		return nil
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for i := 1; i <= len(s.recent) && len(requests) < limit; i++ {
+		r := s.recent[(s.next-i+len(s.recent))%len(s.recent)]
+		if filter.Route != "" && r.Route != filter.Route {
+			continue
+		}
+		if filter.UserID != "" && r.UserID != filter.UserID {
+			continue
+		}
+		if r.Status < filter.MinStatus {
+			continue
+		}
+		requests = append(requests, r)
+	}
+	return requests
+}
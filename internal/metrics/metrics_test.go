@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Snapshot(t *testing.T) {
+	s := NewStore()
+	now := time.Date(2026, 1, 22, 12, 30, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 9; i++ {
+		s.Record(Request{At: now, Method: "GET", Route: "/shop/product/:slug", Status: http.StatusOK, Latency: 20 * time.Millisecond})
+	}
+	s.Record(Request{At: now, Method: "GET", Route: "/shop/product/:slug", Status: http.StatusInternalServerError, Latency: 2 * time.Second})
+	s.Record(Request{At: now, Method: "POST", Route: "/api/cart/add", Status: http.StatusBadRequest, Latency: 5 * time.Millisecond})
+
+	// Traffic older than the window only shows in the all-time count
+	s.Record(Request{At: now.Add(-20 * time.Minute), Method: "POST", Route: "/api/cart/add", Status: http.StatusOK, Latency: time.Millisecond})
+
+	snapshot := s.Snapshot(15 * time.Minute)
+	assert.Equal(t, 15*time.Minute, snapshot.Window)
+	require.Len(t, snapshot.Routes, 2)
+
+	product := snapshot.Routes[0]
+	assert.Equal(t, "/shop/product/:slug", product.Route)
+	assert.Equal(t, int64(10), product.Requests)
+	assert.Equal(t, int64(1), product.ServerErrors)
+	assert.InDelta(t, 0.1, product.ErrorRate(), 0.0001)
+	assert.Greater(t, product.P50, 10*time.Millisecond)
+	assert.LessOrEqual(t, product.P50, 25*time.Millisecond)
+	assert.Greater(t, product.P99, time.Second, "the slow request lands in the top percentile")
+
+	cart := snapshot.Routes[1]
+	assert.Equal(t, int64(1), cart.Requests)
+	assert.Equal(t, int64(1), cart.ClientErrors)
+	assert.Equal(t, int64(2), cart.AllTime)
+
+	assert.Equal(t, int64(11), snapshot.Totals.Requests)
+	assert.Equal(t, int64(1), snapshot.Totals.ServerErrors)
+}
+
+func TestStore_Recent(t *testing.T) {
+	s := NewStore()
+	for i := 0; i < recentLimit+10; i++ {
+		s.Record(Request{Method: "GET", Route: "/shop", Status: http.StatusOK, UserID: "user-1"})
+	}
+	s.Record(Request{Method: "POST", Route: "/api/cart/add", Status: http.StatusInternalServerError})
+	s.Record(Request{Method: "GET", Status: http.StatusNotFound})
+
+	recent := s.Recent(Filter{Limit: 3})
+	require.Len(t, recent, 3)
+	assert.Equal(t, UnmatchedRoute, recent[0].Route, "newest first")
+	assert.Equal(t, "/api/cart/add", recent[1].Route)
+
+	assert.Len(t, s.Recent(Filter{MinStatus: 400}), 2)
+	assert.Len(t, s.Recent(Filter{UserID: "user-1", Limit: recentLimit}), recentLimit-2, "the oldest requests roll off")
+	assert.Len(t, s.Recent(Filter{Route: "/api/cart/add"}), 1)
+}
+
+func TestStore_WritePrometheus(t *testing.T) {
+	s := NewStore()
+	s.Record(Request{Method: "GET", Route: "/shop", Status: http.StatusOK, Latency: 30 * time.Millisecond})
+	s.Record(Request{Method: "GET", Route: "/shop", Status: http.StatusOK, Latency: 3 * time.Second})
+
+	var out strings.Builder
+	require.NoError(t, s.WritePrometheus(&out))
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE http_requests_total counter\n")
+	assert.Contains(t, text, `http_requests_total{method="GET",route="/shop",status="200"} 2`)
+	assert.Contains(t, text, `http_request_duration_seconds_bucket{method="GET",route="/shop",le="0.025"} 0`)
+	assert.Contains(t, text, `http_request_duration_seconds_bucket{method="GET",route="/shop",le="0.05"} 1`)
+	assert.Contains(t, text, `http_request_duration_seconds_bucket{method="GET",route="/shop",le="+Inf"} 2`)
+	assert.Contains(t, text, `http_request_duration_seconds_sum{method="GET",route="/shop"} 3.03`)
+	assert.Contains(t, text, `http_request_duration_seconds_count{method="GET",route="/shop"} 2`)
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	s := NewStore()
+	userID := func(c echo.Context) (string, bool) {
+		id, ok := c.Get("user_id").(string)
+		return id, ok
+	}
+	e.Use(Middleware(s, userID))
+	e.GET("/shop/product/:slug", func(c echo.Context) error {
+		c.Set("user_id", "user-1")
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/broken", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+	e.GET("/missing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	})
+
+	for _, path := range []string{"/shop/product/rex", "/broken", "/missing", "/nowhere"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	recent := s.Recent(Filter{})
+	require.Len(t, recent, 4)
+	assert.Equal(t, Request{Method: "GET", Route: UnmatchedRoute, Path: "/nowhere", Status: http.StatusNotFound}, stripTiming(recent[0]))
+	assert.Equal(t, Request{Method: "GET", Route: "/missing", Path: "/missing", Status: http.StatusNotFound}, stripTiming(recent[1]))
+	assert.Equal(t, Request{Method: "GET", Route: "/broken", Path: "/broken", Status: http.StatusInternalServerError}, stripTiming(recent[2]))
+	assert.Equal(t, Request{Method: "GET", Route: "/shop/product/:slug", Path: "/shop/product/rex", Status: http.StatusOK, UserID: "user-1"}, stripTiming(recent[3]))
+}
+
+func stripTiming(r Request) Request {
+	r.At = time.Time{}
+	r.Latency = 0
+	return r
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware logs each request once and records it in the store. It should run
+// first so it sees the status Recover turns a panic into. userID reads the signed-in
+// account, which the auth middleware only sets further down the chain.
+func Middleware(store *Store, userID func(echo.Context) (string, bool)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+
+			request := Request{
+				At:      start,
+				Method:  c.Request().Method,
+				Route:   c.Path(),
+				Path:    c.Request().URL.Path,
+				Status:  responseStatus(c, err),
+				Latency: time.Since(start),
+			}
+			if request.Route == "" {
+				request.Route = UnmatchedRoute
+			}
+			if userID != nil {
+				request.UserID, _ = userID(c)
+			}
+			store.Record(request)
+
+			attrs := []any{
+				"method", request.Method,
+				"route", request.Route,
+				"path", request.Path,
+				"status", request.Status,
+				"duration", request.Latency,
+				"ip", c.RealIP(),
+			}
+			if request.UserID != "" {
+				attrs = append(attrs, "user_id", request.UserID)
+			}
+			if err != nil {
+				attrs = append(attrs, "error", err)
+			}
+			slog.InfoContext(c.Request().Context(), "request handled", attrs...)
+
+			return err
+		}
+	}
+}
+
+// responseStatus is the status the client gets. A returned error hasn't been written
+// yet; the error handler turns it into a response after the middleware returns.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
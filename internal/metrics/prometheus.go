@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes request counts by status and the latency histogram for each
+// route, totalled since startup
+func (s *Store) WritePrometheus(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	type series struct {
+		key   routeKey
+		route route
+	}
+	var all []series
+	if s != nil {
+		s.mu.Lock()
+		for key, rt := range s.routes {
+			statuses := make(map[int]int64, len(rt.statuses))
+			for status, count := range rt.statuses {
+				statuses[status] = count
+			}
+			all = append(all, series{key: key, route: route{statuses: statuses, latency: rt.latency}})
+		}
+		uptime := s.now().Sub(s.started).Seconds()
+		s.mu.Unlock()
+
+		fmt.Fprintln(out, "# HELP process_uptime_seconds Seconds since the server started.")
+		fmt.Fprintln(out, "# TYPE process_uptime_seconds gauge")
+		fmt.Fprintf(out, "process_uptime_seconds %s\n", formatFloat(uptime))
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].key.route != all[j].key.route {
+			return all[i].key.route < all[j].key.route
+		}
+		return all[i].key.method < all[j].key.method
+	})
+
+	fmt.Fprintln(out, "# HELP http_requests_total Requests handled, by method, route pattern and status.")
+	fmt.Fprintln(out, "# TYPE http_requests_total counter")
+	for _, sr := range all {
+		statuses := make([]int, 0, len(sr.route.statuses))
+		for status := range sr.route.statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(out, "http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n",
+				quote(sr.key.method), quote(sr.key.route), status, sr.route.statuses[status])
+		}
+	}
+
+	fmt.Fprintln(out, "# HELP http_request_duration_seconds Request latency, by method and route pattern.")
+	fmt.Fprintln(out, "# TYPE http_request_duration_seconds histogram")
+	for _, sr := range all {
+		labels := fmt.Sprintf("method=%s,route=%s", quote(sr.key.method), quote(sr.key.route))
+		var cumulative int64
+		for i, bound := range latencyBounds {
+			cumulative += sr.route.latency.counts[i]
+			fmt.Fprintf(out, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(bound.Seconds()), cumulative)
+		}
+		fmt.Fprintf(out, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, sr.route.latency.total)
+		fmt.Fprintf(out, "http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(sr.route.latency.sum.Seconds()))
+		fmt.Fprintf(out, "http_request_duration_seconds_count{%s} %d\n", labels, sr.route.latency.total)
+	}
+
+	return out.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -2,6 +2,7 @@ package ogimage
 
 import (
 	"fmt"
+	"image/color"
 	"image/png"
 	"log/slog"
 	"os"
@@ -17,6 +18,8 @@ type ProductInfo struct {
 	Name         string
 	CategoryName string
 	ImagePath    string
+	BadgeLabel   string // optional badge drawn in the top-left corner
+	BadgeColor   string // badge background as a hex colour
 }
 
 // VariantInfo contains variant-specific details for OG image generation
@@ -60,6 +63,8 @@ func GenerateOGImage(product ProductInfo, outputPath string) error {
 		return fmt.Errorf("parse font: %w", err)
 	}
 
+	drawBadge(dc, font, product.BadgeLabel, product.BadgeColor, scaleFactor)
+
 	// Draw product name (large, readable) - scaled font size
 	dc.SetRGB(1, 1, 1)
 	titleFontSize := 48 * scaleFactor
@@ -106,6 +111,29 @@ func GenerateOGImage(product ProductInfo, outputPath string) error {
 	return nil
 }
 
+// drawBadge draws a product badge as a coloured pill in the top-left corner. Badge
+// icons are emoji, which the OG font can't draw, so only the label is used.
+func drawBadge(dc *gg.Context, font *truetype.Font, label, color string, scaleFactor float64) {
+	if label == "" {
+		return
+	}
+
+	face := truetype.NewFace(font, &truetype.Options{Size: 30 * scaleFactor})
+	dc.SetFontFace(face)
+	label = truncateText(label, 24)
+	textWidth, textHeight := dc.MeasureString(label)
+
+	padX, padY := 24*scaleFactor, 14*scaleFactor
+	x, y := 40*scaleFactor, 40*scaleFactor
+	height := textHeight + 2*padY
+	dc.SetHexColor(color)
+	dc.DrawRoundedRectangle(x, y, textWidth+2*padX, height, height/2)
+	dc.Fill()
+
+	dc.SetRGB(1, 1, 1)
+	dc.DrawStringAnchored(label, x+padX, y+height/2, 0, 0.35)
+}
+
 // truncateText truncates text to maxLength characters
 func truncateText(text string, maxLength int) string {
 	if len(text) <= maxLength {
@@ -165,6 +193,8 @@ func GenerateVariantOGImage(product ProductInfo, variant VariantInfo, outputPath
 		return fmt.Errorf("parse font: %w", err)
 	}
 
+	drawBadge(dc, font, product.BadgeLabel, product.BadgeColor, scaleFactor)
+
 	// Draw product name with variant (large, readable) - scaled font size
 	dc.SetRGB(1, 1, 1)
 	titleFontSize := 44 * scaleFactor
@@ -341,3 +371,102 @@ func GenerateMultiVariantOGImage(info MultiVariantInfo, outputPath string) error
 	slog.Debug("generated multi-variant OG image", "product", info.Name, "styles", info.StyleCount, "output", outputPath)
 	return nil
 }
+
+// ArticleInfo contains the text for a blog post's OG card
+type ArticleInfo struct {
+	Title    string
+	Byline   string // e.g. author and publish date
+	SiteName string // shown above the title
+}
+
+// maxArticleTitleLines is how many wrapped lines of a post title fit on the card
+const maxArticleTitleLines = 3
+
+// GenerateArticleOGImage creates a branded title card for a blog post. Posts don't
+// have a product photo, so the card is text on the site's dark gradient.
+func GenerateArticleOGImage(article ArticleInfo, outputPath string) error {
+	const width = 1200
+	const height = 630
+	const margin = 80
+
+	dc := gg.NewContext(width, height)
+
+	// Slate background matching the storefront, with the blue-to-emerald accent bar
+	background := gg.NewLinearGradient(0, 0, width, height)
+	background.AddColorStop(0, color.RGBA{15, 23, 42, 255})
+	background.AddColorStop(1, color.RGBA{30, 41, 59, 255})
+	dc.SetFillStyle(background)
+	dc.DrawRectangle(0, 0, width, height)
+	dc.Fill()
+
+	accent := gg.NewLinearGradient(0, 0, width, 0)
+	accent.AddColorStop(0, color.RGBA{37, 99, 235, 255})
+	accent.AddColorStop(1, color.RGBA{5, 150, 105, 255})
+	dc.SetFillStyle(accent)
+	dc.DrawRectangle(0, height-16, width, 16)
+	dc.Fill()
+
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		slog.Error("failed to parse font", "error", err)
+		return fmt.Errorf("parse font: %w", err)
+	}
+
+	if article.SiteName != "" {
+		dc.SetRGB255(52, 211, 153)
+		dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: 32}))
+		dc.DrawStringAnchored(truncateText(article.SiteName, 60), margin, margin, 0, 1)
+	}
+
+	// Wrap the title, cutting it off with an ellipsis if it runs past the card
+	titleSize := 68.0
+	dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: titleSize}))
+	lines := dc.WordWrap(article.Title, width-2*margin)
+	if len(lines) > maxArticleTitleLines {
+		lines = lines[:maxArticleTitleLines]
+		last := lines[len(lines)-1]
+		for last != "" {
+			if w, _ := dc.MeasureString(last + "..."); w <= width-2*margin {
+				break
+			}
+			runes := []rune(last)
+			last = string(runes[:len(runes)-1])
+		}
+		lines[len(lines)-1] = strings.TrimSpace(last) + "..."
+	}
+
+	lineHeight := titleSize * 1.25
+	textY := float64(height)/2 - lineHeight*float64(len(lines))/2 + titleSize/2
+	dc.SetRGB(1, 1, 1)
+	for _, line := range lines {
+		dc.DrawStringAnchored(line, margin, textY, 0, 0.5)
+		textY += lineHeight
+	}
+
+	if article.Byline != "" {
+		dc.SetRGB255(148, 163, 184)
+		dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: 30}))
+		dc.DrawStringAnchored(truncateText(article.Byline, 70), margin, height-margin, 0, 0)
+	}
+
+	outputDir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		slog.Error("failed to create output directory", "error", err, "dir", outputDir)
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		slog.Error("failed to create output file", "error", err, "path", outputPath)
+		return fmt.Errorf("create output file: %w", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, dc.Image()); err != nil {
+		slog.Error("failed to encode PNG", "error", err)
+		return fmt.Errorf("encode PNG: %w", err)
+	}
+
+	slog.Debug("generated article OG image", "title", article.Title, "output", outputPath)
+	return nil
+}
@@ -0,0 +1,36 @@
+package ogimage
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateArticleOGImage(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "og", "blog-post.png")
+
+	err := GenerateArticleOGImage(ArticleInfo{
+		Title:    strings.Repeat("How we print articulated dragons without supports ", 4),
+		Byline:   "Logan · March 10, 2026",
+		SiteName: "Logan's 3D Creations Blog",
+	}, outputPath)
+	if err != nil {
+		t.Fatalf("GenerateArticleOGImage failed: %v", err)
+	}
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Expected output file to be created: %v", err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("Output is not a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 1200 || b.Dy() != 630 {
+		t.Fatalf("Expected a 1200x630 card, got %dx%d", b.Dx(), b.Dy())
+	}
+}
@@ -0,0 +1,429 @@
+// Package pdf renders printable documents, starting with order invoices. Pages are
+// drawn with gofpdf's built-in Helvetica, so no font files ship with the binary; text
+// is translated to the font's Windows-1252 encoding, which covers the characters
+// customers normally type.
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// LogoPath is the shop's logo, printed beside the business name on invoices
+const LogoPath = "public/images/social/logo-square.png"
+
+// Business is the seller block printed at the top of an invoice
+type Business struct {
+	Name    string
+	Address []string
+	Email   string
+	Phone   string
+	Website string
+	// Logo is a PNG or JPEG on disk; the invoice leaves it out if the file is missing
+	Logo string
+}
+
+// BusinessFromSettings fills the seller block from the site settings
+func BusinessFromSettings(site settings.Values) Business {
+	return Business{
+		Name:    site.SiteName(),
+		Address: site.AddressLines(),
+		Email:   site.ContactEmail(),
+		Phone:   site.ContactPhone(),
+		Website: strings.TrimPrefix(strings.TrimPrefix(site.SiteURL(), "https://"), "http://"),
+		Logo:    LogoPath,
+	}
+}
+
+// Payments is what has happened to an order's money since checkout
+type Payments struct {
+	// ChangesCents is what the customer paid through payment links for changes to the order
+	ChangesCents int64
+	// RefundedCents is everything refunded, by the Refunds card, edits and returns
+	RefundedCents int64
+}
+
+// InvoiceNumber is the number printed on an order's invoice, the short order ID
+// customers already see on their order page and emails
+func InvoiceNumber(order db.Order) string {
+	return "INV-" + strings.ToUpper(shortID(order.ID))
+}
+
+// InvoiceFilename is the download name for an order's invoice
+func InvoiceFilename(order db.Order) string {
+	return fmt.Sprintf("invoice-%s.pdf", shortID(order.ID))
+}
+
+// TotalLine is one row of the invoice totals
+type TotalLine struct {
+	Label string
+	Cents int64
+	Bold  bool
+}
+
+// InvoiceTotals lists the totals rows for an order: the subtotal before any discount,
+// the discount, shipping, any carbon offset, tax and the total. A gift card or store
+// credit that paid part of it, payments for later changes and refunds follow, with
+// what the customer paid in the end.
+func InvoiceTotals(order db.Order, payments Payments) []TotalLine {
+	subtotal := order.SubtotalCents
+	if order.OriginalSubtotalCents.Valid && order.OriginalSubtotalCents.Int64 > 0 {
+		subtotal = order.OriginalSubtotalCents.Int64
+	}
+
+	lines := []TotalLine{{Label: "Subtotal", Cents: subtotal}}
+	if order.DiscountCents.Valid && order.DiscountCents.Int64 > 0 {
+		label := "Discount"
+		if order.PromotionCode.Valid && order.PromotionCode.String != "" {
+			label = fmt.Sprintf("Discount (%s)", order.PromotionCode.String)
+		}
+		lines = append(lines, TotalLine{Label: label, Cents: -order.DiscountCents.Int64})
+	}
+	lines = append(lines, TotalLine{Label: "Shipping", Cents: order.ShippingCents})
+	if order.CarbonOffsetCents > 0 {
+		lines = append(lines, TotalLine{Label: "Carbon offset", Cents: order.CarbonOffsetCents})
+	}
+	// The order's total is what Stripe charged, after any gift card and store credit
+	total := order.TotalCents + order.GiftCardCents + order.StoreCreditCents
+	lines = append(lines,
+		TotalLine{Label: "Tax", Cents: order.TaxCents},
+		TotalLine{Label: "Total", Cents: total, Bold: true},
+	)
+
+	var paid []TotalLine
+	if order.GiftCardCents > 0 {
+		paid = append(paid, TotalLine{Label: "Gift card", Cents: -order.GiftCardCents})
+	}
+	if order.StoreCreditCents > 0 {
+		paid = append(paid, TotalLine{Label: "Store credit", Cents: -order.StoreCreditCents})
+	}
+	if payments.ChangesCents > 0 {
+		paid = append(paid, TotalLine{Label: "Paid for changes", Cents: payments.ChangesCents})
+	}
+	if payments.RefundedCents > 0 {
+		paid = append(paid, TotalLine{Label: "Refunded", Cents: -payments.RefundedCents})
+	}
+	if len(paid) == 0 {
+		return lines
+	}
+	lines[len(lines)-1].Bold = false
+	return append(append(lines, paid...), TotalLine{
+		Label: "Amount paid",
+		Cents: order.TotalCents + payments.ChangesCents - payments.RefundedCents,
+		Bold:  true,
+	})
+}
+
+// Page layout, in millimetres on US Letter
+const (
+	pageMargin = 18.0
+	lineHeight = 5.0
+
+	colItem     = 100.0
+	colQuantity = 18.0
+	colPrice    = 31.0
+	colTotal    = 30.9
+
+	logoSize = 22.0
+)
+
+// The shop's blue, from the storefront's gradient
+const (
+	brandR = 37
+	brandG = 99
+	brandB = 235
+)
+
+// Invoice writes an order's invoice as a PDF: the business and customer details, each
+// line with its price, the totals and how it was paid. Prices are what the customer
+// paid, so it never shows cost or internal notes.
+func Invoice(w io.Writer, business Business, order db.Order, items []db.GetOrderItemsRow, payments Payments) error {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(fmt.Sprintf("Invoice %s", InvoiceNumber(order)), true)
+	pdf.SetAuthor(business.Name, true)
+	pdf.SetCreator(business.Name, true)
+	if order.CreatedAt.Valid {
+		pdf.SetCreationDate(order.CreatedAt.Time)
+	}
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pageMargin + 4)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		footer := fmt.Sprintf("%s · Page %d of {nb}", InvoiceNumber(order), pdf.PageNo())
+		if business.Website != "" {
+			footer = business.Website + " · " + footer
+		}
+		pdf.CellFormat(0, 4, tr(footer), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// A band of the shop's blue across the top
+	pageWidth, _ := pdf.GetPageSize()
+	pdf.SetFillColor(brandR, brandG, brandB)
+	pdf.Rect(0, 0, pageWidth, 4, "F")
+
+	// Seller, after the logo, on the left; invoice details on the right
+	top := pdf.GetY()
+	sellerX := pageMargin
+	logoBottom := top
+	if logo := logoImage(business.Logo); logo != "" {
+		pdf.ImageOptions(business.Logo, pageMargin, top, logoSize, logoSize, false, gofpdf.ImageOptions{ImageType: logo}, 0, "")
+		sellerX += logoSize + 4
+		logoBottom = top + logoSize
+	}
+	sellerWidth := 110 - (sellerX - pageMargin)
+	pdf.SetXY(sellerX, top)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(sellerWidth, 8, tr(business.Name), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range business.Address {
+		pdf.CellFormat(sellerWidth, lineHeight, tr(line), "", 2, "L", false, 0, "")
+	}
+	for _, line := range []string{business.Email, business.Phone} {
+		if line != "" {
+			pdf.CellFormat(sellerWidth, lineHeight, tr(line), "", 2, "L", false, 0, "")
+		}
+	}
+	sellerBottom := max(pdf.GetY(), logoBottom)
+
+	pdf.SetXY(pageMargin+110, top)
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetTextColor(brandR, brandG, brandB)
+	pdf.CellFormat(0, 9, "INVOICE", "", 2, "R", false, 0, "")
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetFont("Helvetica", "", 9)
+	details := []string{
+		"Invoice " + InvoiceNumber(order),
+		"Order #" + shortID(order.ID),
+	}
+	if order.CreatedAt.Valid {
+		details = append(details, "Date "+order.CreatedAt.Time.Local().Format("January 2, 2006"))
+	}
+	details = append(details, invoiceStatus(order))
+	details = append(details, paymentReference(order)...)
+	for _, line := range details {
+		pdf.CellFormat(0, lineHeight, tr(line), "", 2, "R", false, 0, "")
+	}
+
+	pdf.SetY(max(sellerBottom, pdf.GetY()) + 8)
+
+	// Bill to and ship to
+	addressTop := pdf.GetY()
+	billTo := []string{order.CustomerName, order.CustomerEmail}
+	if order.CustomerPhone.Valid && order.CustomerPhone.String != "" {
+		billTo = append(billTo, order.CustomerPhone.String)
+	}
+	addressBlock(pdf, tr, pageMargin, "Bill to", billTo)
+	billBottom := pdf.GetY()
+
+	pdf.SetXY(pageMargin+90, addressTop)
+	shipName := order.CustomerName
+	if order.IsGift && order.GiftRecipientName != "" {
+		shipName = order.GiftRecipientName
+	}
+	addressBlock(pdf, tr, pageMargin+90, "Ship to", shippingAddress(order, shipName))
+
+	pdf.SetY(max(billBottom, pdf.GetY()) + 8)
+
+	// Line items
+	itemsHeader(pdf)
+	for _, item := range items {
+		description := []string{item.ProductName}
+		if item.ProductSku.Valid && item.ProductSku.String != "" {
+			description = append(description, "SKU "+item.ProductSku.String)
+		}
+		for _, value := range utils.DecodePersonalization(item.Personalization.String) {
+			description = append(description, fmt.Sprintf("%s: %s", value.Label, value.Value))
+		}
+
+		// The product name wraps in full colour; the SKU and personalization under it are indented and grey
+		pdf.SetFont("Helvetica", "", 9)
+		var wrapped []string
+		nameLines := 0
+		for i, text := range description {
+			for _, line := range pdf.SplitLines([]byte(tr(text)), colItem-2) {
+				if i > 0 {
+					line = append([]byte("   "), line...)
+				}
+				wrapped = append(wrapped, string(line))
+			}
+			if i == 0 {
+				nameLines = len(wrapped)
+			}
+		}
+		height := float64(len(wrapped))*lineHeight + 2
+
+		// Start the line on a new page rather than splitting it
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+height > pageHeight-pageMargin {
+			pdf.AddPage()
+			itemsHeader(pdf)
+			pdf.SetFont("Helvetica", "", 9)
+		}
+
+		y := pdf.GetY()
+		for i, line := range wrapped {
+			if i == nameLines {
+				pdf.SetTextColor(90, 90, 90)
+			}
+			pdf.SetXY(pageMargin, y+1+float64(i)*lineHeight)
+			pdf.CellFormat(colItem, lineHeight, line, "", 0, "L", false, 0, "")
+		}
+		pdf.SetTextColor(17, 17, 17)
+		pdf.SetXY(pageMargin+colItem, y+1)
+		pdf.CellFormat(colQuantity, lineHeight, fmt.Sprintf("%d", item.Quantity), "", 0, "C", false, 0, "")
+		pdf.CellFormat(colPrice, lineHeight, formatCents(item.UnitPriceCents), "", 0, "R", false, 0, "")
+		pdf.CellFormat(colTotal, lineHeight, formatCents(item.TotalPriceCents), "", 0, "R", false, 0, "")
+
+		pdf.SetDrawColor(221, 221, 221)
+		pdf.Line(pageMargin, y+height, pageMargin+colItem+colQuantity+colPrice+colTotal, y+height)
+		pdf.SetY(y + height)
+	}
+
+	// Totals, right-aligned under the prices
+	pdf.Ln(4)
+	labelX := pageMargin + colItem
+	for _, line := range InvoiceTotals(order, payments) {
+		style := ""
+		if line.Bold {
+			style = "B"
+			pdf.SetDrawColor(17, 17, 17)
+			pdf.Line(labelX, pdf.GetY(), labelX+colQuantity+colPrice+colTotal, pdf.GetY())
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.SetX(labelX)
+		pdf.CellFormat(colQuantity+colPrice, 6, tr(line.Label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(colTotal, 6, formatCents(line.Cents), "", 1, "R", false, 0, "")
+	}
+
+	pdf.Ln(8)
+	pdf.SetFont("Helvetica", "", 9)
+	thanks := "Thank you for your order!"
+	if business.Email != "" {
+		thanks += " Questions? Email " + business.Email
+		if business.Phone != "" {
+			thanks += " or call " + business.Phone
+		}
+		thanks += "."
+	}
+	pdf.MultiCell(0, lineHeight, tr(thanks), "", "L", false)
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render invoice: %w", err)
+	}
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write invoice: %w", err)
+	}
+	return nil
+}
+
+// itemsHeader draws the column headings of the line items table
+func itemsHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.CellFormat(colItem, 7, "Item", "", 0, "L", true, 0, "")
+	pdf.CellFormat(colQuantity, 7, "Qty", "", 0, "C", true, 0, "")
+	pdf.CellFormat(colPrice, 7, "Unit Price", "", 0, "R", true, 0, "")
+	pdf.CellFormat(colTotal, 7, "Amount", "", 1, "R", true, 0, "")
+}
+
+// addressBlock draws a heading and the lines under it at x, leaving the cursor below
+func addressBlock(pdf *gofpdf.Fpdf, tr func(string) string, x float64, heading string, lines []string) {
+	pdf.SetX(x)
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.CellFormat(80, lineHeight, strings.ToUpper(heading), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(17, 17, 17)
+	for _, line := range lines {
+		if line != "" {
+			pdf.CellFormat(80, lineHeight, tr(line), "", 2, "L", false, 0, "")
+		}
+	}
+}
+
+func shippingAddress(order db.Order, name string) []string {
+	lines := []string{name, order.ShippingAddressLine1}
+	if order.ShippingAddressLine2.Valid && order.ShippingAddressLine2.String != "" {
+		lines = append(lines, order.ShippingAddressLine2.String)
+	}
+	return append(lines,
+		fmt.Sprintf("%s, %s %s", order.ShippingCity, order.ShippingState, order.ShippingPostalCode),
+		order.ShippingCountry,
+	)
+}
+
+// logoImage is the gofpdf image type of a logo file, or "" when there's no logo to draw
+func logoImage(path string) string {
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "PNG"
+	case ".jpg", ".jpeg":
+		return "JPG"
+	}
+	return ""
+}
+
+// paymentReference is how the order was paid, under its status: the Stripe payment,
+// which the customer's bank statement and any dispute refer to, and the currency the
+// card was charged in when it wasn't dollars
+func paymentReference(order db.Order) []string {
+	var lines []string
+	if order.StripePaymentIntentID.Valid && order.StripePaymentIntentID.String != "" {
+		lines = append(lines, "Payment "+order.StripePaymentIntentID.String)
+	} else if order.GiftCardCents > 0 || order.StoreCreditCents > 0 {
+		lines = append(lines, "Paid with gift card or store credit")
+	}
+	if order.PaymentCurrency != "" && !strings.EqualFold(order.PaymentCurrency, "usd") {
+		lines = append(lines, "Charged in "+strings.ToUpper(order.PaymentCurrency))
+	}
+	return lines
+}
+
+// invoiceStatus is the payment line under the invoice details. Orders are only created
+// once Stripe has taken payment, so every invoice is paid unless it was cancelled.
+func invoiceStatus(order db.Order) string {
+	if order.Status.Valid && order.Status.String == "cancelled" {
+		return "Cancelled"
+	}
+	if order.Status.Valid && order.Status.String == "refunded" {
+		return "Refunded"
+	}
+	return "Paid"
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func formatCents(cents int64) string {
+	if cents < 0 {
+		return fmt.Sprintf("-$%.2f", float64(-cents)/100)
+	}
+	return fmt.Sprintf("$%.2f", float64(cents)/100)
+}
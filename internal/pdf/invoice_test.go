@@ -0,0 +1,150 @@
+package pdf
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func testOrder() db.Order {
+	return db.Order{
+		ID:                    "01jabcdefghjkmnpqrstvwxyz",
+		CustomerName:          "Renée Dupont",
+		CustomerEmail:         "renee@example.com",
+		ShippingAddressLine1:  "12 Main St",
+		ShippingAddressLine2:  sql.NullString{String: "Apt 4", Valid: true},
+		ShippingCity:          "Eau Claire",
+		ShippingState:         "WI",
+		ShippingPostalCode:    "54701",
+		ShippingCountry:       "US",
+		SubtotalCents:         4500,
+		OriginalSubtotalCents: sql.NullInt64{Int64: 5000, Valid: true},
+		DiscountCents:         sql.NullInt64{Int64: 500, Valid: true},
+		PromotionCode:         sql.NullString{String: "DINO10", Valid: true},
+		ShippingCents:         599,
+		TaxCents:              248,
+		TotalCents:            5347,
+		Status:                sql.NullString{String: "received", Valid: true},
+		CreatedAt:             sql.NullTime{Time: time.Date(2026, 2, 8, 15, 4, 0, 0, time.UTC), Valid: true},
+	}
+}
+
+func TestInvoiceTotals(t *testing.T) {
+	assert.Equal(t, []TotalLine{
+		{Label: "Subtotal", Cents: 5000},
+		{Label: "Discount (DINO10)", Cents: -500},
+		{Label: "Shipping", Cents: 599},
+		{Label: "Tax", Cents: 248},
+		{Label: "Total", Cents: 5347, Bold: true},
+	}, InvoiceTotals(testOrder(), Payments{}))
+
+	noDiscount := testOrder()
+	noDiscount.OriginalSubtotalCents = sql.NullInt64{}
+	noDiscount.DiscountCents = sql.NullInt64{}
+	noDiscount.PromotionCode = sql.NullString{}
+	totals := InvoiceTotals(noDiscount, Payments{})
+	require.Len(t, totals, 4)
+	assert.Equal(t, TotalLine{Label: "Subtotal", Cents: 4500}, totals[0])
+
+	offset := testOrder()
+	offset.CarbonOffsetCents = 100
+	offset.TotalCents += 100
+	totals = InvoiceTotals(offset, Payments{})
+	require.Len(t, totals, 6)
+	assert.Equal(t, TotalLine{Label: "Carbon offset", Cents: 100}, totals[3])
+
+	// A gift card and store credit paid part of it, a change was paid for by payment
+	// link and some was refunded
+	paid := testOrder()
+	paid.GiftCardCents = 1000
+	paid.StoreCreditCents = 347
+	paid.TotalCents = 4000
+	totals = InvoiceTotals(paid, Payments{ChangesCents: 1200, RefundedCents: 500})
+	assert.Equal(t, []TotalLine{
+		{Label: "Subtotal", Cents: 5000},
+		{Label: "Discount (DINO10)", Cents: -500},
+		{Label: "Shipping", Cents: 599},
+		{Label: "Tax", Cents: 248},
+		{Label: "Total", Cents: 5347},
+		{Label: "Gift card", Cents: -1000},
+		{Label: "Store credit", Cents: -347},
+		{Label: "Paid for changes", Cents: 1200},
+		{Label: "Refunded", Cents: -500},
+		{Label: "Amount paid", Cents: 4700, Bold: true},
+	}, totals)
+}
+
+func TestPaymentReference(t *testing.T) {
+	order := testOrder()
+	assert.Empty(t, paymentReference(order))
+
+	order.StripePaymentIntentID = sql.NullString{String: "pi_3Nabc", Valid: true}
+	order.PaymentCurrency = "eur"
+	assert.Equal(t, []string{"Payment pi_3Nabc", "Charged in EUR"}, paymentReference(order))
+
+	covered := testOrder()
+	covered.PaymentCurrency = "usd"
+	covered.GiftCardCents = 5347
+	covered.TotalCents = 0
+	assert.Equal(t, []string{"Paid with gift card or store credit"}, paymentReference(covered))
+}
+
+func TestInvoiceNumber(t *testing.T) {
+	order := testOrder()
+	assert.Equal(t, "INV-01JABCDE", InvoiceNumber(order))
+	assert.Equal(t, "invoice-01jabcde.pdf", InvoiceFilename(order))
+}
+
+func TestInvoice(t *testing.T) {
+	business := Business{
+		Name:    "Logan's 3D Creations",
+		Address: []string{"25892 County Hwy S", "Cadott, WI 54727"},
+		Email:   "prints@logans3dcreations.com",
+		Website: "www.logans3dcreations.com",
+	}
+	items := []db.GetOrderItemsRow{
+		{
+			ProductName:     "Articulated T-Rex – Large",
+			ProductSku:      sql.NullString{String: "TREX-L", Valid: true},
+			Quantity:        2,
+			UnitPriceCents:  2500,
+			TotalPriceCents: 5000,
+			Personalization: sql.NullString{String: `[{"label":"Name","value":"Zoë"}]`, Valid: true},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, Invoice(&out, business, testOrder(), items, Payments{}))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, out.String(), "/Count 1")
+
+	// With the logo beside the business name; a missing logo is left out
+	business.Logo = "../../" + LogoPath
+	out.Reset()
+	require.NoError(t, Invoice(&out, business, testOrder(), items, Payments{RefundedCents: 500}))
+	assert.Contains(t, out.String(), "/Subtype /Image")
+	business.Logo = "missing.png"
+	out.Reset()
+	require.NoError(t, Invoice(&out, business, testOrder(), items, Payments{}))
+
+	// A long order carries on to another page
+	for i := range 60 {
+		items = append(items, db.GetOrderItemsRow{
+			ProductName:     fmt.Sprintf("Flexi Dragon %d %s", i, strings.Repeat("with a very long name ", i%4)),
+			Quantity:        1,
+			UnitPriceCents:  1500,
+			TotalPriceCents: 1500,
+		})
+	}
+	out.Reset()
+	require.NoError(t, Invoice(&out, business, testOrder(), items, Payments{}))
+	assert.NotContains(t, out.String(), "/Count 1\n")
+}
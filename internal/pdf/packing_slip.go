@@ -0,0 +1,339 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// StockLocation is a place an item is kept, so whoever packs knows where to find it
+type StockLocation struct {
+	Location string
+	Bin      string
+	Quantity int64
+}
+
+func (l StockLocation) String() string {
+	if l.Bin == "" {
+		return l.Location
+	}
+	return l.Location + " · " + l.Bin
+}
+
+// Locations maps a product or SKU, keyed by StockKey, to where it's kept, most
+// likely place first
+type Locations map[string][]StockLocation
+
+// StockKey identifies a product's own stock, or a SKU's when skuID is set, in Locations
+func StockKey(productID, skuID string) string {
+	return productID + "|" + skuID
+}
+
+// For lists where a product or SKU is kept, e.g. "Studio · Shelf B3; Trailer"
+func (l Locations) For(productID, skuID string) string {
+	var places []string
+	for _, location := range l[StockKey(productID, skuID)] {
+		places = append(places, location.String())
+	}
+	return strings.Join(places, "; ")
+}
+
+// LabelImage is a bought shipping label as a PNG, JPEG or GIF image
+type LabelImage struct {
+	Data []byte
+	// Type is gofpdf's image type: "PNG", "JPG" or "GIF"
+	Type string
+}
+
+// Slip is one order to print a packing slip for, with its label when batch printing
+type Slip struct {
+	Order db.Order
+	Items []db.GetOrderItemsRow
+	Label *LabelImage
+}
+
+// PackingSlipFilename is the download name for an order's packing slip
+func PackingSlipFilename(order db.Order) string {
+	return fmt.Sprintf("packing-slip-%s.pdf", shortID(order.ID))
+}
+
+// Packing slip columns, in millimetres
+const (
+	colCheck    = 8.0
+	colSlipItem = 92.0
+	colSKU      = 32.0
+	colLocation = 34.0
+	colSlipQty  = 13.9
+	slipWidth   = colCheck + colSlipItem + colSKU + colLocation + colSlipQty
+
+	// Labels print on their own 4x6 inch page
+	labelWidth  = 101.6
+	labelHeight = 152.4
+)
+
+// PackingSlips writes packing slips for one or more orders as a single PDF, each on
+// its own page. A slip with a label gets a 4x6 label page in front of it, so a batch
+// prints label, slip, label, slip in the order they're packed. Like the printable slip,
+// it never shows prices, and for a gift it leaves off the buyer's contact details and
+// notes and prints the gift message.
+func PackingSlips(w io.Writer, business Business, slips []Slip, locations Locations) error {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle("Packing Slips", true)
+	pdf.SetAuthor(business.Name, true)
+	pdf.SetCreator(business.Name, true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// The footer names the order so loose pages can be matched up again
+	var footer string
+	pdf.SetFooterFunc(func() {
+		if footer == "" {
+			return
+		}
+		pdf.SetY(-pageMargin + 4)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 4, tr(footer), "", 0, "C", false, 0, "")
+	})
+
+	// Each page's footer is drawn as the next page is added, so the text is set once
+	// the new page has been started
+	letter := pdf.GetPageSizeStr("Letter")
+	for i, slip := range slips {
+		if slip.Label != nil {
+			labelPage(pdf, fmt.Sprintf("label-%d", i), slip.Label)
+			footer = ""
+		}
+		pdf.AddPageFormat("P", letter)
+		footer = fmt.Sprintf("Packing slip · Order #%s", shortID(slip.Order.ID))
+		packingSlipPage(pdf, tr, business, slip, locations)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render packing slips: %w", err)
+	}
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write packing slips: %w", err)
+	}
+	return nil
+}
+
+// ShippingLabels writes bought labels as one PDF, each on its own 4x6 page, for a
+// label printer
+func ShippingLabels(w io.Writer, business Business, labels []*LabelImage) error {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           gofpdf.SizeType{Wd: labelWidth, Ht: labelHeight},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle("Shipping Labels", true)
+	pdf.SetAuthor(business.Name, true)
+	pdf.SetCreator(business.Name, true)
+
+	for i, label := range labels {
+		labelPage(pdf, fmt.Sprintf("label-%d", i), label)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render shipping labels: %w", err)
+	}
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write shipping labels: %w", err)
+	}
+	return nil
+}
+
+// labelPage adds a 4x6 page with the label scaled to fit, turned upright if it's landscape
+func labelPage(pdf *gofpdf.Fpdf, name string, label *LabelImage) {
+	pdf.AddPageFormat("P", gofpdf.SizeType{Wd: labelWidth, Ht: labelHeight})
+	info := pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: label.Type}, bytes.NewReader(label.Data))
+	if info == nil || pdf.Err() {
+		return
+	}
+
+	imageWidth, imageHeight := info.Width(), info.Height()
+	rotate := imageWidth > imageHeight
+	fitWidth, fitHeight := labelWidth, labelHeight
+	if rotate {
+		fitWidth, fitHeight = labelHeight, labelWidth
+	}
+	scale := min(fitWidth/imageWidth, fitHeight/imageHeight)
+	width, height := imageWidth*scale, imageHeight*scale
+
+	centerX, centerY := labelWidth/2, labelHeight/2
+	if rotate {
+		pdf.TransformBegin()
+		pdf.TransformRotate(90, centerX, centerY)
+	}
+	pdf.ImageOptions(name, centerX-width/2, centerY-height/2, width, height, false, gofpdf.ImageOptions{ImageType: label.Type}, 0, "")
+	if rotate {
+		pdf.TransformEnd()
+	}
+}
+
+func packingSlipPage(pdf *gofpdf.Fpdf, tr func(string) string, business Business, slip Slip, locations Locations) {
+	order := slip.Order
+
+	// Business on the left, order details on the right
+	top := pdf.GetY()
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(110, 8, tr(business.Name), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(110, lineHeight, "Packing Slip", "", 2, "L", false, 0, "")
+	nameBottom := pdf.GetY()
+
+	pdf.SetXY(pageMargin+110, top)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, "Order #"+shortID(order.ID), "", 2, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	if order.CreatedAt.Valid {
+		pdf.CellFormat(0, lineHeight, order.CreatedAt.Time.Local().Format("January 2, 2006"), "", 2, "R", false, 0, "")
+	}
+
+	y := max(nameBottom, pdf.GetY()) + 2
+	pdf.SetDrawColor(17, 17, 17)
+	pdf.SetLineWidth(0.6)
+	pdf.Line(pageMargin, y, pageMargin+slipWidth, y)
+	pdf.SetLineWidth(0.2)
+	pdf.SetY(y + 6)
+
+	// Ship to, and who it's from or how to reach the customer
+	addressTop := pdf.GetY()
+	shipName := order.CustomerName
+	if order.IsGift && order.GiftRecipientName != "" {
+		shipName = order.GiftRecipientName
+	}
+	addressBlock(pdf, tr, pageMargin, "Ship to", shippingAddress(order, shipName))
+	shipBottom := pdf.GetY()
+
+	pdf.SetXY(pageMargin+90, addressTop)
+	if order.IsGift {
+		addressBlock(pdf, tr, pageMargin+90, "From", []string{order.CustomerName})
+	} else {
+		contact := []string{order.CustomerEmail}
+		if order.CustomerPhone.Valid && order.CustomerPhone.String != "" {
+			contact = append(contact, order.CustomerPhone.String)
+		}
+		addressBlock(pdf, tr, pageMargin+90, "Contact", contact)
+	}
+	pdf.SetY(max(shipBottom, pdf.GetY()) + 6)
+
+	if order.IsGift {
+		pdf.SetDashPattern([]float64{1.5, 1}, 0)
+		boxedText(pdf, tr, "A gift for you", order.GiftMessage, "I", "C")
+		pdf.SetDashPattern([]float64{}, 0)
+	}
+	if order.CustomerNotes != "" {
+		boxedText(pdf, tr, "Customer instructions", order.CustomerNotes, "B", "L")
+	}
+
+	// Items, with a box to tick as each goes in
+	slipItemsHeader(pdf)
+	for _, item := range slip.Items {
+		pdf.SetFont("Helvetica", "", 9)
+		description := pdf.SplitLines([]byte(tr(item.ProductName)), colSlipItem-2)
+		nameLines := len(description)
+		for _, value := range utils.DecodePersonalization(item.Personalization.String) {
+			for _, line := range pdf.SplitLines([]byte(tr(fmt.Sprintf("%s: %s", value.Label, value.Value))), colSlipItem-6) {
+				description = append(description, append([]byte("   "), line...))
+			}
+		}
+		place := pdf.SplitLines([]byte(tr(locations.For(item.ProductID, item.ProductSkuID.String))), colLocation-2)
+		height := float64(max(len(description), len(place), 1))*lineHeight + 2
+
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+height > pageHeight-pageMargin {
+			pdf.AddPage()
+			slipItemsHeader(pdf)
+			pdf.SetFont("Helvetica", "", 9)
+		}
+
+		y := pdf.GetY()
+		pdf.SetDrawColor(17, 17, 17)
+		pdf.Rect(pageMargin+2, y+1.5, 4, 4, "D")
+		for i, line := range description {
+			// Personalization is what gets checked before sealing the box, so it stays bold
+			if i == nameLines {
+				pdf.SetFont("Helvetica", "B", 9)
+			}
+			pdf.SetXY(pageMargin+colCheck, y+1+float64(i)*lineHeight)
+			pdf.CellFormat(colSlipItem, lineHeight, string(line), "", 0, "L", false, 0, "")
+		}
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetXY(pageMargin+colCheck+colSlipItem, y+1)
+		pdf.CellFormat(colSKU, lineHeight, tr(item.ProductSku.String), "", 0, "L", false, 0, "")
+		for i, line := range place {
+			pdf.SetXY(pageMargin+colCheck+colSlipItem+colSKU, y+1+float64(i)*lineHeight)
+			pdf.CellFormat(colLocation, lineHeight, string(line), "", 0, "L", false, 0, "")
+		}
+		pdf.SetXY(pageMargin+colCheck+colSlipItem+colSKU+colLocation, y+1)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(colSlipQty, lineHeight, fmt.Sprintf("%d", item.Quantity), "", 0, "C", false, 0, "")
+
+		pdf.SetDrawColor(221, 221, 221)
+		pdf.Line(pageMargin, y+height, pageMargin+slipWidth, y+height)
+		pdf.SetY(y + height)
+	}
+
+	if !order.IsGift && order.Notes.Valid && order.Notes.String != "" {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.CellFormat(0, lineHeight, "NOTES", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(17, 17, 17)
+		pdf.MultiCell(0, lineHeight, tr(order.Notes.String), "", "L", false)
+	}
+}
+
+// slipItemsHeader draws the column headings of the packing slip's items table
+func slipItemsHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.CellFormat(colCheck, 7, "", "", 0, "C", true, 0, "")
+	pdf.CellFormat(colSlipItem, 7, "Item", "", 0, "L", true, 0, "")
+	pdf.CellFormat(colSKU, 7, "SKU", "", 0, "L", true, 0, "")
+	pdf.CellFormat(colLocation, 7, "Location", "", 0, "L", true, 0, "")
+	pdf.CellFormat(colSlipQty, 7, "Qty", "", 1, "C", true, 0, "")
+}
+
+// boxedText draws a heading and text inside a border across the page, leaving the
+// cursor below it. The border takes the current dash pattern.
+func boxedText(pdf *gofpdf.Fpdf, tr func(string) string, heading, text, style, align string) {
+	const padding = 3.0
+	width := slipWidth
+	pdf.SetFont("Helvetica", style, 11)
+	lines := pdf.SplitLines([]byte(tr(text)), width-2*padding)
+	if text == "" {
+		lines = nil
+	}
+	height := lineHeight + float64(len(lines))*6 + 2*padding
+
+	y := pdf.GetY()
+	pdf.SetDrawColor(17, 17, 17)
+	pdf.SetLineWidth(0.5)
+	pdf.Rect(pageMargin, y, width, height, "D")
+	pdf.SetLineWidth(0.2)
+
+	pdf.SetXY(pageMargin+padding, y+padding)
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetTextColor(90, 90, 90)
+	pdf.CellFormat(width-2*padding, lineHeight, strings.ToUpper(heading), "", 2, align, false, 0, "")
+	pdf.SetFont("Helvetica", style, 11)
+	pdf.SetTextColor(17, 17, 17)
+	for _, line := range lines {
+		pdf.CellFormat(width-2*padding, 6, string(line), "", 2, align, false, 0, "")
+	}
+	pdf.SetXY(pageMargin, y+height+6)
+}
@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func testLabel(t *testing.T, width, height int) *LabelImage {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, height/2, color.Black)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return &LabelImage{Data: buf.Bytes(), Type: "PNG"}
+}
+
+func TestLocationsFor(t *testing.T) {
+	locations := Locations{
+		StockKey("trex", ""):      {{Location: "Studio", Bin: "Shelf B3", Quantity: 4}, {Location: "Trailer", Quantity: 1}},
+		StockKey("dragon", "red"): {{Location: "Studio", Bin: "Bin 12"}},
+	}
+	assert.Equal(t, "Studio · Shelf B3; Trailer", locations.For("trex", ""))
+	assert.Equal(t, "Studio · Bin 12", locations.For("dragon", "red"))
+	assert.Equal(t, "", locations.For("dragon", ""))
+}
+
+func TestPackingSlips(t *testing.T) {
+	business := Business{Name: "Logan's 3D Creations"}
+	items := []db.GetOrderItemsRow{
+		{
+			ProductID:       "trex",
+			ProductName:     "Articulated T-Rex – Large",
+			ProductSku:      sql.NullString{String: "TREX-L", Valid: true},
+			Quantity:        2,
+			Personalization: sql.NullString{String: `[{"label":"Name","value":"Zoë"}]`, Valid: true},
+		},
+	}
+	locations := Locations{StockKey("trex", ""): {{Location: "Studio", Bin: "Shelf B3"}}}
+
+	gift := testOrder()
+	gift.ID = "02jabcdefghjkmnpqrstvwxyz"
+	gift.IsGift = true
+	gift.GiftRecipientName = "Sam"
+	gift.GiftMessage = "Happy birthday!"
+
+	var out bytes.Buffer
+	require.NoError(t, PackingSlips(&out, business, []Slip{
+		{Order: testOrder(), Items: items},
+		{Order: gift, Items: items},
+	}, locations))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, out.String(), "/Count 2")
+
+	// A label goes on its own page in front of the slip, upright or turned
+	out.Reset()
+	require.NoError(t, PackingSlips(&out, business, []Slip{
+		{Order: testOrder(), Items: items, Label: testLabel(t, 400, 600)},
+		{Order: gift, Items: items, Label: testLabel(t, 600, 400)},
+	}, locations))
+	assert.Contains(t, out.String(), "/Count 4")
+	assert.Contains(t, out.String(), "/MediaBox [0 0 288.00 432.00]")
+}
+
+func TestShippingLabels(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, ShippingLabels(&out, Business{Name: "Logan's 3D Creations"}, []*LabelImage{
+		testLabel(t, 400, 600),
+		testLabel(t, 600, 400),
+	}))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, out.String(), "/Count 2")
+	assert.Contains(t, out.String(), "/MediaBox [0 0 288.00 432.00]")
+}
+
+func TestPickList(t *testing.T) {
+	lines := []PickLine{
+		{ProductName: "Articulated T-Rex", SKU: "TREX-L", Location: "Studio · Shelf B3", Quantity: 3, Personalized: 1, Orders: []string{"01jabcde", "02jabcde"}},
+		{ProductName: "Flexi Dragon", Quantity: 1, ToPrint: 1, Orders: []string{"02jabcde"}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, PickList(&out, Business{Name: "Logan's 3D Creations"}, lines, 2, time.Date(2026, 2, 11, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 11, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, out.String(), "/Count 1")
+
+	out.Reset()
+	require.NoError(t, PickList(&out, Business{}, nil, 0, time.Now(), time.Now()))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+}
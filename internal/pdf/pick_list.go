@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PickLine is one product or SKU to pull from the shelves for a batch of orders
+type PickLine struct {
+	ProductName string
+	SKU         string
+	Location    string
+	Quantity    int64
+	// ToPrint is how many of Quantity are on backorder and still to be printed
+	ToPrint int64
+	// Personalized is how many units carry personalization to check against each slip
+	Personalized int64
+	// Orders are the short numbers of the orders the units go to
+	Orders []string
+}
+
+// Pick list columns, in millimetres
+const (
+	colPickItem     = 110.0
+	colPickLocation = 45.0
+	colPickQty      = 16.9
+)
+
+// PickList writes the combined pick list for the orders open at the end of day: each
+// product once with the total to pull, where it's kept and which orders it goes to
+func PickList(w io.Writer, business Business, lines []PickLine, orderCount int, day, generated time.Time) error {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle("Pick List", true)
+	pdf.SetAuthor(business.Name, true)
+	pdf.SetCreator(business.Name, true)
+	pdf.SetCreationDate(generated)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pageMargin + 4)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 4, tr(fmt.Sprintf("Pick list · Page %d of {nb}", pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	var units int64
+	for _, line := range lines {
+		units += line.Quantity
+	}
+
+	pdf.SetTextColor(17, 17, 17)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, tr("Pick List · "+day.Format("Monday, January 2, 2006")), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	summary := fmt.Sprintf("%s · %d orders · %d items · printed %s", business.Name, orderCount, units, generated.Local().Format("January 2, 2006 3:04 PM"))
+	pdf.CellFormat(0, lineHeight, tr(summary), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	if len(lines) == 0 {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(0, 8, tr("No orders placed by "+day.Format("January 2")+" are waiting to be packed."), "", 1, "L", false, 0, "")
+	} else {
+		pickHeader(pdf)
+	}
+
+	for _, line := range lines {
+		pdf.SetFont("Helvetica", "", 9)
+		description := pdf.SplitLines([]byte(tr(line.ProductName)), colPickItem-colCheck-2)
+		nameLines := len(description)
+		var details []string
+		if line.SKU != "" {
+			details = append(details, "SKU "+line.SKU)
+		}
+		if line.ToPrint > 0 {
+			details = append(details, fmt.Sprintf("%d to print", line.ToPrint))
+		}
+		if line.Personalized > 0 {
+			details = append(details, fmt.Sprintf("%d personalized", line.Personalized))
+		}
+		details = append(details, "Orders "+strings.Join(line.Orders, ", "))
+		for _, detail := range details {
+			for _, wrapped := range pdf.SplitLines([]byte(tr(detail)), colPickItem-colCheck-6) {
+				description = append(description, append([]byte("   "), wrapped...))
+			}
+		}
+		place := pdf.SplitLines([]byte(tr(line.Location)), colPickLocation-2)
+		height := float64(max(len(description), len(place)))*lineHeight + 2
+
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+height > pageHeight-pageMargin {
+			pdf.AddPage()
+			pickHeader(pdf)
+			pdf.SetFont("Helvetica", "", 9)
+		}
+
+		y := pdf.GetY()
+		pdf.SetDrawColor(17, 17, 17)
+		pdf.Rect(pageMargin+2, y+1.5, 4, 4, "D")
+		for i, text := range description {
+			if i == nameLines {
+				pdf.SetTextColor(90, 90, 90)
+			}
+			pdf.SetXY(pageMargin+colCheck, y+1+float64(i)*lineHeight)
+			pdf.CellFormat(colPickItem-colCheck, lineHeight, string(text), "", 0, "L", false, 0, "")
+		}
+		pdf.SetTextColor(17, 17, 17)
+		for i, text := range place {
+			pdf.SetXY(pageMargin+colPickItem, y+1+float64(i)*lineHeight)
+			pdf.CellFormat(colPickLocation, lineHeight, string(text), "", 0, "L", false, 0, "")
+		}
+		pdf.SetXY(pageMargin+colPickItem+colPickLocation, y+1)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(colPickQty, lineHeight, fmt.Sprintf("%d", line.Quantity), "", 0, "C", false, 0, "")
+
+		pdf.SetDrawColor(221, 221, 221)
+		pdf.Line(pageMargin, y+height, pageMargin+colPickItem+colPickLocation+colPickQty, y+height)
+		pdf.SetY(y + height)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render pick list: %w", err)
+	}
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write pick list: %w", err)
+	}
+	return nil
+}
+
+// pickHeader draws the column headings of the pick list
+func pickHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetTextColor(17, 17, 17)
+	pdf.CellFormat(colCheck, 7, "", "", 0, "C", true, 0, "")
+	pdf.CellFormat(colPickItem-colCheck, 7, "Item", "", 0, "L", true, 0, "")
+	pdf.CellFormat(colPickLocation, 7, "Location", "", 0, "L", true, 0, "")
+	pdf.CellFormat(colPickQty, 7, "Qty", "", 1, "C", true, 0, "")
+}
@@ -0,0 +1,265 @@
+// Package policies keeps the versioned legal documents: the terms of service, the
+// shipping and returns policy and the custom work policy. Each kind has numbered
+// versions that take effect at a set time, and the public page shows whichever is in
+// effect. Customers agree to the versions in effect when they check out, and the
+// order records which ones, so a dispute can be answered with the exact text they
+// agreed to.
+//
+// A version can't be edited. Changing a policy means publishing a new version, which
+// can be scheduled ahead so customers can be told before it applies.
+package policies
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Document kinds
+const (
+	KindTerms        = "terms"
+	KindShipping     = "shipping"
+	KindCustomPolicy = "custom_policy"
+)
+
+// Kinds lists the document kinds in the order they're shown
+var Kinds = []string{KindTerms, KindShipping, KindCustomPolicy}
+
+// KindLabel returns the name of a document kind
+func KindLabel(kind string) string {
+	switch kind {
+	case KindTerms:
+		return "Terms of Service"
+	case KindShipping:
+		return "Shipping & Returns"
+	case KindCustomPolicy:
+		return "Custom Work Policy"
+	}
+	return kind
+}
+
+// Path returns the public page showing the version of a kind in effect
+func Path(kind string) string {
+	switch kind {
+	case KindTerms:
+		return "/terms"
+	case KindShipping:
+		return "/shipping"
+	case KindCustomPolicy:
+		return "/custom-policy"
+	}
+	return "/"
+}
+
+// ErrNotAccepted means checkout was tried without agreeing to the policies
+var ErrNotAccepted = errors.New("policies not accepted")
+
+// ErrChanged means a new version took effect after the cart showed the customer the
+// policies, so they haven't seen what they'd be agreeing to
+var ErrChanged = errors.New("policies changed since they were shown")
+
+// Error is a reason a version can't be published, worded for the admin publishing it
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Library reads and publishes document versions and records which ones orders
+// were placed under
+type Library struct {
+	queries *db.Queries
+}
+
+func NewLibrary(queries *db.Queries) *Library {
+	return &Library{queries: queries}
+}
+
+// Current returns the version of every kind in effect at a time
+func (l *Library) Current(ctx context.Context, at time.Time) ([]db.LegalDocument, error) {
+	docs, err := l.queries.ListCurrentLegalDocuments(ctx, at.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list current legal documents: %w", err)
+	}
+	return docs, nil
+}
+
+// CurrentOf returns the version of one kind in effect at a time. It returns
+// sql.ErrNoRows when no version has taken effect yet.
+func (l *Library) CurrentOf(ctx context.Context, kind string, at time.Time) (db.LegalDocument, error) {
+	return l.queries.GetCurrentLegalDocument(ctx, db.GetCurrentLegalDocumentParams{
+		Kind: kind,
+		At:   at.UTC(),
+	})
+}
+
+// PublishParams is a new version of a document
+type PublishParams struct {
+	Kind          string
+	Title         string
+	BodyMarkdown  string
+	ChangeSummary string
+	// EffectiveAt is when the version applies; zero means straight away
+	EffectiveAt time.Time
+}
+
+// Publish adds the next version of a document. It can't take effect in the past,
+// where orders were already placed under another version, or before the latest
+// version already scheduled.
+func (l *Library) Publish(ctx context.Context, p PublishParams, now time.Time) (db.LegalDocument, error) {
+	if !slices.Contains(Kinds, p.Kind) {
+		return db.LegalDocument{}, &Error{Message: "Choose which document this is a version of"}
+	}
+	p.Title = strings.TrimSpace(p.Title)
+	p.BodyMarkdown = strings.TrimSpace(p.BodyMarkdown)
+	if p.Title == "" || p.BodyMarkdown == "" {
+		return db.LegalDocument{}, &Error{Message: "A version needs a title and text"}
+	}
+	if p.EffectiveAt.IsZero() {
+		p.EffectiveAt = now
+	}
+	if p.EffectiveAt.Before(now.Add(-time.Minute)) {
+		return db.LegalDocument{}, &Error{Message: "A version can't take effect in the past"}
+	}
+
+	var version int64 = 1
+	latest, err := l.queries.GetLatestLegalDocument(ctx, p.Kind)
+	switch {
+	case err == nil:
+		if !p.EffectiveAt.After(latest.EffectiveAt) {
+			return db.LegalDocument{}, &Error{Message: fmt.Sprintf("Version %d takes effect %s, so this one has to take effect after that",
+				latest.Version, latest.EffectiveAt.Format("Jan 2, 2006 3:04 PM MST"))}
+		}
+		version = latest.Version + 1
+	case !errors.Is(err, sql.ErrNoRows):
+		return db.LegalDocument{}, fmt.Errorf("failed to get latest legal document: %w", err)
+	}
+
+	doc, err := l.queries.CreateLegalDocument(ctx, db.CreateLegalDocumentParams{
+		ID:            p.Kind + "-v" + strconv.FormatInt(version, 10),
+		Kind:          p.Kind,
+		Version:       version,
+		Title:         p.Title,
+		BodyMarkdown:  p.BodyMarkdown,
+		ChangeSummary: strings.TrimSpace(p.ChangeSummary),
+		EffectiveAt:   p.EffectiveAt.UTC(),
+	})
+	if err != nil {
+		return db.LegalDocument{}, fmt.Errorf("failed to create legal document: %w", err)
+	}
+	return doc, nil
+}
+
+// Check confirms a customer agreed to every version in effect. accepted is the
+// versions the cart showed them when they ticked the box.
+func Check(current []db.LegalDocument, accepted []string) error {
+	if len(accepted) == 0 {
+		return ErrNotAccepted
+	}
+	for _, doc := range current {
+		if !slices.Contains(accepted, doc.ID) {
+			return ErrChanged
+		}
+	}
+	return nil
+}
+
+// metadataKey carries the versions agreed to from the cart to the order
+const metadataKey = "policy_documents"
+
+// AddAcceptanceToMetadata records on checkout session metadata the versions the
+// customer agreed to
+func AddAcceptanceToMetadata(metadata map[string]string, docs []db.LegalDocument) {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	if len(ids) > 0 {
+		metadata[metadataKey] = strings.Join(ids, ",")
+	}
+}
+
+// AcceptanceFromMetadata reads back the versions a customer agreed to, if any
+func AcceptanceFromMetadata(metadata map[string]string) []string {
+	if metadata[metadataKey] == "" {
+		return nil
+	}
+	return strings.Split(metadata[metadataKey], ",")
+}
+
+// Record keeps the versions agreed to with the order they were agreed to for
+func (l *Library) Record(ctx context.Context, orderID string, documentIDs []string, acceptedAt time.Time) error {
+	for _, id := range documentIDs {
+		if err := l.queries.CreateOrderPolicyAcceptance(ctx, db.CreateOrderPolicyAcceptanceParams{
+			OrderID:         orderID,
+			LegalDocumentID: id,
+			AcceptedAt:      acceptedAt.UTC(),
+		}); err != nil {
+			return fmt.Errorf("failed to record acceptance of %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// OrderPolicy is a version that applies to an order
+type OrderPolicy struct {
+	DocumentID  string
+	Kind        string
+	Version     int64
+	Title       string
+	EffectiveAt time.Time
+	// Accepted is whether the customer ticked the box for it at checkout. Orders
+	// placed before checkout asked weren't, and get the version that was in effect.
+	Accepted   bool
+	AcceptedAt time.Time
+}
+
+// ForOrder returns the versions an order was placed under: the ones the customer
+// agreed to, or for an order from before checkout asked, the ones in effect when it
+// was placed
+func (l *Library) ForOrder(ctx context.Context, orderID string, placedAt time.Time) ([]OrderPolicy, error) {
+	accepted, err := l.queries.ListOrderPolicyAcceptances(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order policy acceptances: %w", err)
+	}
+	if len(accepted) > 0 {
+		policies := make([]OrderPolicy, len(accepted))
+		for i, a := range accepted {
+			policies[i] = OrderPolicy{
+				DocumentID:  a.ID,
+				Kind:        a.Kind,
+				Version:     a.Version,
+				Title:       a.Title,
+				EffectiveAt: a.EffectiveAt,
+				Accepted:    true,
+				AcceptedAt:  a.AcceptedAt,
+			}
+		}
+		return policies, nil
+	}
+
+	docs, err := l.Current(ctx, placedAt)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]OrderPolicy, len(docs))
+	for i, doc := range docs {
+		policies[i] = OrderPolicy{
+			DocumentID:  doc.ID,
+			Kind:        doc.Kind,
+			Version:     doc.Version,
+			Title:       doc.Title,
+			EffectiveAt: doc.EffectiveAt,
+		}
+	}
+	return policies, nil
+}
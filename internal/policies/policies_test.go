@@ -0,0 +1,101 @@
+package policies
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestLibrary(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	library := NewLibrary(queries)
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	// The migration carries over the policies that were on the site
+	current, err := library.Current(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, current, 3)
+	assert.Equal(t, []string{"terms-v1", "shipping-v1", "custom_policy-v1"}, []string{current[0].ID, current[1].ID, current[2].ID})
+
+	var policyErr *Error
+	_, err = library.Publish(ctx, PublishParams{Kind: "privacy", Title: "Privacy", BodyMarkdown: "Text"}, now)
+	require.True(t, errors.As(err, &policyErr), "only known kinds are versioned")
+	_, err = library.Publish(ctx, PublishParams{Kind: KindTerms, Title: "Terms of Service", BodyMarkdown: "  "}, now)
+	require.True(t, errors.As(err, &policyErr))
+	_, err = library.Publish(ctx, PublishParams{Kind: KindTerms, Title: "Terms of Service", BodyMarkdown: "Text", EffectiveAt: now.Add(-time.Hour)}, now)
+	require.True(t, errors.As(err, &policyErr), "orders were already placed under the version in effect then")
+
+	effective := now.Add(7 * 24 * time.Hour)
+	v2, err := library.Publish(ctx, PublishParams{Kind: KindTerms, Title: "Terms of Service", BodyMarkdown: "## New terms", ChangeSummary: "Added returns", EffectiveAt: effective}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), v2.Version)
+	assert.Equal(t, "terms-v2", v2.ID)
+
+	_, err = library.Publish(ctx, PublishParams{Kind: KindTerms, Title: "Terms of Service", BodyMarkdown: "Text", EffectiveAt: effective.Add(-time.Hour)}, now)
+	require.True(t, errors.As(err, &policyErr), "versions take effect in order")
+
+	doc, err := library.CurrentOf(ctx, KindTerms, now)
+	require.NoError(t, err)
+	assert.Equal(t, "terms-v1", doc.ID, "a scheduled version doesn't apply yet")
+	doc, err = library.CurrentOf(ctx, KindTerms, effective)
+	require.NoError(t, err)
+	assert.Equal(t, "terms-v2", doc.ID)
+	_, err = library.CurrentOf(ctx, KindTerms, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	// An order placed before checkout asked gets the versions in effect then
+	_, err = queries.CreateOrder(ctx, db.CreateOrderParams{
+		ID:            "order-policies",
+		CustomerEmail: "customer@example.com",
+		CustomerName:  "Customer",
+		SubtotalCents: 1200,
+		TotalCents:    1200,
+	})
+	require.NoError(t, err)
+	policies, err := library.ForOrder(ctx, "order-policies", effective.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, policies, 3)
+	assert.Equal(t, "terms-v2", policies[0].DocumentID)
+	assert.False(t, policies[0].Accepted)
+
+	acceptedAt := now.Add(time.Minute)
+	require.NoError(t, library.Record(ctx, "order-policies", []string{"terms-v1", "shipping-v1", "custom_policy-v1"}, acceptedAt))
+	require.NoError(t, library.Record(ctx, "order-policies", []string{"terms-v1"}, acceptedAt), "a webhook delivered twice records once")
+	policies, err = library.ForOrder(ctx, "order-policies", effective.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, policies, 3)
+	assert.Equal(t, "terms-v1", policies[0].DocumentID, "what was agreed to wins over what's in effect")
+	assert.True(t, policies[0].Accepted)
+	assert.True(t, acceptedAt.Equal(policies[0].AcceptedAt))
+
+	deleted, err := queries.DeleteScheduledLegalDocument(ctx, db.DeleteScheduledLegalDocumentParams{ID: "terms-v1", Now: now})
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "a version in effect stays")
+	deleted, err = queries.DeleteScheduledLegalDocument(ctx, db.DeleteScheduledLegalDocumentParams{ID: "terms-v2", Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestCheck(t *testing.T) {
+	current := []db.LegalDocument{{ID: "terms-v2"}, {ID: "shipping-v1"}}
+
+	assert.ErrorIs(t, Check(current, nil), ErrNotAccepted)
+	assert.ErrorIs(t, Check(current, []string{"terms-v1", "shipping-v1"}), ErrChanged, "terms changed after the cart loaded")
+	assert.NoError(t, Check(current, []string{"terms-v2", "shipping-v1"}))
+
+	metadata := map[string]string{}
+	AddAcceptanceToMetadata(metadata, current)
+	assert.Equal(t, []string{"terms-v2", "shipping-v1"}, AcceptanceFromMetadata(metadata))
+	assert.Nil(t, AcceptanceFromMetadata(map[string]string{}))
+}
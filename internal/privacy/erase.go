@@ -0,0 +1,106 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+)
+
+// deletedName stands in for the customer's name where a row is kept
+const deletedName = "Deleted customer"
+
+// erasure is one statement of an account's deletion, and the table its count goes to
+type erasure struct {
+	table string
+	query string
+}
+
+// erasures remove or scrub everything the export shows. Orders are kept for the books,
+// with the customer's contact details, street address and notes cleared; the state and
+// country stay, as sales tax is reported by them. Tables keyed by order go first, as they
+// find the orders by the details being cleared. What's left on the user, like sessions,
+// store credit, the saved address and collections, goes with it by cascade.
+//
+// A new table holding customers' personal information needs adding here and to the
+// export's sections.
+var erasures = []erasure{
+	{"order_items", `UPDATE order_items SET personalization = NULL
+		WHERE personalization IS NOT NULL AND order_id IN ` + subjectOrderIDs},
+	{"sms_messages", `UPDATE sms_messages SET phone = '', body = '' WHERE order_id IN ` + subjectOrderIDs},
+	{"returns", `UPDATE returns SET user_id = '', details = ''
+		WHERE user_id = :user_id OR order_id IN ` + subjectOrderIDs},
+	// A customer's own cancellations and payments are recorded under their email
+	{"order_status_history", `UPDATE order_status_history SET changed_by = ''
+		WHERE changed_by = :email COLLATE NOCASE AND order_id IN ` + subjectOrderIDs},
+	{"order_refunds", `UPDATE order_refunds SET created_by = ''
+		WHERE created_by = :email COLLATE NOCASE AND order_id IN ` + subjectOrderIDs},
+	{"order_adjustments", `UPDATE order_adjustments SET created_by = ''
+		WHERE created_by = :email COLLATE NOCASE AND order_id IN ` + subjectOrderIDs},
+	// Stored invoices print the customer's name and address; they're made again from
+	// the scrubbed order if anyone asks
+	{"order_invoices", `DELETE FROM order_invoices WHERE order_id IN ` + subjectOrderIDs},
+	{"orders", `UPDATE orders SET
+			user_id = '', guest_session_id = NULL, stripe_customer_id = NULL,
+			customer_name = '` + deletedName + `', customer_email = '', customer_phone = NULL,
+			sms_phone = '', sms_consent_at = NULL,
+			shipping_address_line1 = '', shipping_address_line2 = NULL, shipping_city = '', shipping_postal_code = '',
+			customer_notes = '', gift_message = '', gift_recipient_name = '', gift_recipient_email = '',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE ` + subjectOrders},
+
+	{"cart_items", `DELETE FROM cart_items WHERE user_id = :user_id`},
+	{"saved_cart_items", `DELETE FROM saved_cart_items WHERE user_id = :user_id`},
+	{"shared_carts", `DELETE FROM shared_carts WHERE user_id = :user_id`},
+	{"abandoned_carts", `DELETE FROM abandoned_carts WHERE user_id = :user_id OR customer_email = :email COLLATE NOCASE`},
+	{"expired_checkouts", `DELETE FROM expired_checkouts WHERE user_id = :user_id OR customer_email = :email COLLATE NOCASE`},
+	{"checkout_drafts", `DELETE FROM checkout_drafts WHERE user_id = :user_id OR customer_email = :email COLLATE NOCASE`},
+	{"user_favorites", `DELETE FROM user_favorites WHERE user_id = :user_id`},
+	{"user_collections", `DELETE FROM user_collections WHERE user_id = :user_id`},
+
+	{"email_history", `DELETE FROM email_history WHERE user_id = :user_id OR recipient_email = :email COLLATE NOCASE`},
+	{"email_preferences", `DELETE FROM email_preferences WHERE user_id = :user_id OR email = :email COLLATE NOCASE`},
+	{"marketing_contacts", `DELETE FROM marketing_contacts WHERE email = :email COLLATE NOCASE`},
+	{"contact_requests", `DELETE FROM contact_requests WHERE email = :email COLLATE NOCASE`},
+	// Quotes keep their files and what was quoted, for the orders made from them
+	{"quote_requests", `UPDATE quote_requests SET
+			customer_name = '` + deletedName + `', customer_email = '', customer_phone = NULL, project_description = ''
+		WHERE customer_email = :email COLLATE NOCASE`},
+	{"custom_quote_drafts", `UPDATE custom_quote_drafts SET user_id = NULL, name = NULL, email = NULL, description = NULL
+		WHERE user_id = :user_id OR email = :email COLLATE NOCASE`},
+	// Answered questions stay on the product page without who asked
+	{"product_questions", `UPDATE product_questions SET user_id = NULL, asker_name = '` + deletedName + `', asker_email = ''
+		WHERE user_id = :user_id OR asker_email = :email COLLATE NOCASE`},
+	{"event_registrations", `UPDATE event_registrations SET user_id = NULL, name = '` + deletedName + `', email = ''
+		WHERE user_id = :user_id OR email = :email COLLATE NOCASE`},
+	{"promotion_codes", `UPDATE promotion_codes SET user_id = NULL, email = NULL
+		WHERE user_id = :user_id OR email = :email COLLATE NOCASE`},
+	// References without ON DELETE, which would otherwise stop the user going
+	{"gift_certificates", `UPDATE gift_certificates SET redeemed_by_user_id = NULL WHERE redeemed_by_user_id = :user_id`},
+	{"contact_requests", `UPDATE contact_requests SET assigned_to_user_id = NULL WHERE assigned_to_user_id = :user_id`},
+
+	{"users", `DELETE FROM users WHERE id = :user_id`},
+}
+
+// Erase deletes a customer's account and everything held about them, keeping their
+// orders with their details scrubbed. It should run in a transaction, so a failure
+// leaves the account as it was.
+func Erase(ctx context.Context, q querier, s Subject) (Summary, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	summary := Summary{}
+	for _, e := range erasures {
+		result, err := q.ExecContext(ctx, e.query, s.args()...)
+		if err != nil {
+			return nil, fmt.Errorf("erase %s: %w", e.table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("erase %s: %w", e.table, err)
+		}
+		summary[e.table] += n
+	}
+	if summary["users"] == 0 {
+		return nil, fmt.Errorf("user %s not found", s.UserID)
+	}
+	return summary, nil
+}
@@ -0,0 +1,243 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// section is one file of an export and the query filling it. Columns are listed rather
+// than selected with *, so a column added later isn't exported without a look at it.
+type section struct {
+	name        string
+	description string
+	query       string
+}
+
+var sections = []section{
+	{
+		name:        "profile",
+		description: "Your account",
+		query:       `SELECT id, email, full_name, first_name, last_name, username, profile_image_url, created_at, updated_at FROM users WHERE id = :user_id`,
+	},
+	{
+		name:        "default_address",
+		description: "The shipping address saved to your account",
+		query: `SELECT name, address_line1, address_line2, city_locality, state_province, postal_code, country_code, updated_at
+			FROM user_default_addresses WHERE user_id = :user_id`,
+	},
+	{
+		name:        "email_preferences",
+		description: "Which emails you've chosen to get",
+		query: `SELECT email, transactional, order_updates, shipping_updates, abandoned_cart, promotional, newsletter, product_updates, back_in_stock, updated_at
+			FROM email_preferences WHERE user_id = :user_id OR email = :email COLLATE NOCASE`,
+	},
+	{
+		name:        "mailing_list",
+		description: "Sign-ups to our mailing list",
+		query: `SELECT email, first_name, last_name, source, opted_in, created_at
+			FROM marketing_contacts WHERE email = :email COLLATE NOCASE`,
+	},
+	{
+		name:        "orders",
+		description: "Your orders, including guest orders placed with your email",
+		query: `SELECT id, status, customer_name, customer_email, customer_phone, sms_phone,
+				shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country,
+				subtotal_cents, discount_cents, promotion_code, shipping_cents, tax_cents, gift_card_cents, store_credit_cents, total_cents,
+				payment_currency, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email,
+				tracking_number, carrier, created_at
+			FROM orders WHERE ` + subjectOrders + ` ORDER BY created_at`,
+	},
+	{
+		name:        "order_items",
+		description: "What was in each order",
+		query: `SELECT order_id, product_name, product_sku, quantity, unit_price_cents, total_price_cents, personalization
+			FROM order_items WHERE order_id IN ` + subjectOrderIDs + ` ORDER BY order_id, created_at`,
+	},
+	{
+		name:        "returns",
+		description: "Returns you've asked for",
+		query: `SELECT id, order_id, status, reason, details, refund_cents, created_at
+			FROM returns WHERE user_id = :user_id OR order_id IN ` + subjectOrderIDs + ` ORDER BY created_at`,
+	},
+	{
+		name:        "text_messages",
+		description: "Order updates sent to you by text",
+		query: `SELECT order_id, phone, event, body, status, created_at
+			FROM sms_messages WHERE order_id IN ` + subjectOrderIDs + ` ORDER BY created_at`,
+	},
+	{
+		name:        "store_credit",
+		description: "Store credit added to and spent from your account",
+		query: `SELECT kind, reason, amount_cents, balance_after_cents, order_id, note, created_at
+			FROM store_credit_transactions WHERE user_id = :user_id ORDER BY created_at`,
+	},
+	{
+		name:        "favorites",
+		description: "Products you've favorited",
+		query: `SELECT p.name AS product_name, p.slug AS product_slug, f.created_at
+			FROM user_favorites f JOIN products p ON p.id = f.product_id
+			WHERE f.user_id = :user_id ORDER BY f.created_at`,
+	},
+	{
+		name:        "collections",
+		description: "Your collections and what's in them",
+		query: `SELECT c.name AS collection, c.description, p.name AS product_name, i.quantity, i.notes, c.created_at
+			FROM user_collections c
+			LEFT JOIN collection_items i ON i.collection_id = c.id
+			LEFT JOIN products p ON p.id = i.product_id
+			WHERE c.user_id = :user_id ORDER BY c.created_at, i.created_at`,
+	},
+	{
+		name:        "cart",
+		description: "What's in your cart",
+		query: `SELECT p.name AS product_name, ci.quantity, ci.personalization, ci.created_at
+			FROM cart_items ci JOIN products p ON p.id = ci.product_id
+			WHERE ci.user_id = :user_id ORDER BY ci.created_at`,
+	},
+	{
+		name:        "saved_for_later",
+		description: "What you've saved for later from your cart",
+		query: `SELECT p.name AS product_name, si.quantity, si.personalization, si.created_at
+			FROM saved_cart_items si JOIN products p ON p.id = si.product_id
+			WHERE si.user_id = :user_id ORDER BY si.created_at`,
+	},
+	{
+		name:        "emails",
+		description: "Emails we've sent you",
+		query: `SELECT email_type, subject, recipient_email, sent_at, opened_at, clicked_at
+			FROM email_history WHERE user_id = :user_id OR recipient_email = :email COLLATE NOCASE ORDER BY sent_at`,
+	},
+	{
+		name:        "product_questions",
+		description: "Questions you've asked about products",
+		query: `SELECT p.name AS product_name, q.asker_name, q.asker_email, q.question, q.answer, q.status, q.created_at
+			FROM product_questions q JOIN products p ON p.id = q.product_id
+			WHERE q.user_id = :user_id OR q.asker_email = :email COLLATE NOCASE ORDER BY q.created_at`,
+	},
+	{
+		name:        "event_registrations",
+		description: "Events you've registered for",
+		query: `SELECT event_id, ticket_name, name, email, quantity, amount_cents, status, created_at
+			FROM event_registrations WHERE user_id = :user_id OR email = :email COLLATE NOCASE ORDER BY created_at`,
+	},
+	{
+		name:        "contact_requests",
+		description: "Messages you've sent through our contact form",
+		query: `SELECT first_name, last_name, email, phone, subject, message, created_at
+			FROM contact_requests WHERE email = :email COLLATE NOCASE ORDER BY created_at`,
+	},
+	{
+		name:        "quote_requests",
+		description: "Custom print quotes you've asked for",
+		query: `SELECT customer_name, customer_email, customer_phone, project_description, quantity,
+				material_preference, finish_preference, budget_range, status, quoted_price_cents, created_at
+			FROM quote_requests WHERE customer_email = :email COLLATE NOCASE ORDER BY created_at`,
+	},
+}
+
+// Section is one table's worth of an export
+type Section struct {
+	Name        string
+	Description string
+	Rows        []map[string]any
+}
+
+// Export is everything held about a customer
+type Export struct {
+	GeneratedAt time.Time
+	Sections    []Section
+}
+
+// Summary counts the rows in each section
+func (e *Export) Summary() Summary {
+	summary := Summary{}
+	for _, s := range e.Sections {
+		summary[s.Name] = int64(len(s.Rows))
+	}
+	return summary
+}
+
+// Collect gathers everything held about a customer
+func Collect(ctx context.Context, q querier, s Subject) (*Export, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	export := &Export{GeneratedAt: time.Now().UTC()}
+	for _, sec := range sections {
+		rows, err := queryRows(ctx, q, sec.query, s.args()...)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", sec.name, err)
+		}
+		export.Sections = append(export.Sections, Section{Name: sec.name, Description: sec.description, Rows: rows})
+	}
+	return export, nil
+}
+
+// queryRows reads a query's rows as column name to value
+func queryRows(ctx context.Context, q querier, query string, args ...any) ([]map[string]any, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// WriteZip writes an export as a ZIP of one JSON file per section, with a README
+// saying what each is
+func WriteZip(w io.Writer, export *Export) error {
+	zw := zip.NewWriter(w)
+
+	readme, err := zw.Create("README.txt")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(readme, "Your data from Logan's 3D Creations, exported %s.\n\n", export.GeneratedAt.Format("January 2, 2006 at 3:04 PM MST"))
+	fmt.Fprintln(readme, "Each file is a JSON list of records. Amounts are in US cents.")
+	fmt.Fprintln(readme)
+	for _, s := range export.Sections {
+		fmt.Fprintf(readme, "%s.json: %s (%d)\n", s.Name, s.Description, len(s.Rows))
+	}
+
+	for _, s := range export.Sections {
+		f, err := zw.Create(s.Name + ".json")
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s.Rows); err != nil {
+			return fmt.Errorf("write %s: %w", s.Name, err)
+		}
+	}
+	return zw.Close()
+}
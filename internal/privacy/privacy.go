@@ -0,0 +1,74 @@
+// Package privacy answers customers' requests about their own data: an export of
+// everything the shop holds about them, and the erasure of their account.
+//
+// A customer is matched by their account and by their email, since guest orders,
+// emails and contact forms only have the email. The same matching is used to export and
+// to erase, so what the export shows is what deletion removes, apart from what the shop
+// keeps for its books: orders stay, with the customer's details scrubbed from them.
+package privacy
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ExportTTL is how long a finished export can be downloaded before it's removed
+const ExportTTL = 7 * 24 * time.Hour
+
+// Subject is the customer a request is about
+type Subject struct {
+	UserID string
+	Email  string
+}
+
+func (s Subject) validate() error {
+	if s.UserID == "" || strings.TrimSpace(s.Email) == "" {
+		return errors.New("privacy request needs the user's ID and email")
+	}
+	return nil
+}
+
+func (s Subject) args() []any {
+	return []any{sql.Named("user_id", s.UserID), sql.Named("email", strings.TrimSpace(s.Email))}
+}
+
+// EmailHash is what the compliance log keeps of an email: enough to tell whether a
+// request was made for an address, without keeping the address
+func EmailHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// Summary counts the rows a request exported or erased, by table
+type Summary map[string]int64
+
+// querier is a database or a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// subjectOrders matches the customer's orders: those on their account, and guest
+// orders placed with their email
+const subjectOrders = `(user_id = :user_id OR (user_id = '' AND customer_email = :email COLLATE NOCASE))`
+
+// subjectOrderIDs is the IDs of the customer's orders, for tables keyed by order
+const subjectOrderIDs = `(SELECT id FROM orders WHERE ` + subjectOrders + `)`
+
+// OpenOrders counts the customer's orders still being made or on their way. An account
+// can't be deleted while it has any, since they'd lose the order's page and emails.
+func OpenOrders(ctx context.Context, q querier, s Subject) (int64, error) {
+	if err := s.validate(); err != nil {
+		return 0, err
+	}
+	var count int64
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders WHERE `+subjectOrders+`
+		AND status IN ('received', 'in_production', 'on_hold', 'partially_shipped', 'shipped')`, s.args()...).Scan(&count)
+	return count, err
+}
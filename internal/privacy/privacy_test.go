@@ -0,0 +1,187 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+)
+
+var jane = Subject{UserID: "user-1", Email: "jane@customer.com"}
+
+// seedCustomer adds a customer with an order on their account, a guest order placed
+// with their email written differently, and another customer's order that's left alone
+func seedCustomer(t *testing.T, database *sql.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		`INSERT INTO users (id, email, full_name, clerk_id) VALUES ('user-1', 'jane@customer.com', 'Jane Customer', 'user_2abc')`,
+		`INSERT INTO user_default_addresses (user_id, name, address_line1, city_locality, state_province, postal_code, country_code) VALUES ('user-1', 'Jane Customer', '12 Elm St', 'Eau Claire', 'WI', '54701', 'US')`,
+		`INSERT INTO products (id, name, slug, price_cents) VALUES ('product-1', 'Name Plate', 'name-plate', 2000)`,
+		`INSERT INTO orders (
+			id, user_id, customer_name, customer_email, customer_phone,
+			shipping_address_line1, shipping_city, shipping_state, shipping_postal_code,
+			subtotal_cents, total_cents, status, customer_notes
+		) VALUES
+			('order-1', 'user-1', 'Jane Customer', 'jane@customer.com', '715-555-0100', '12 Elm St', 'Eau Claire', 'WI', '54701', 2000, 2000, 'delivered', 'Leave it with Bob'),
+			('order-2', '', 'Jane C', 'JANE@customer.com', NULL, '12 Elm St', 'Eau Claire', 'WI', '54701', 2000, 2000, 'received', ''),
+			('order-3', '', 'Sam Other', 'sam@other.com', NULL, '1 Oak Ave', 'Madison', 'WI', '53703', 2000, 2000, 'received', '')`,
+		`INSERT INTO order_items (id, order_id, product_id, quantity, unit_price_cents, total_price_cents, product_name, personalization) VALUES
+			('item-1', 'order-1', 'product-1', 1, 2000, 2000, 'Name Plate', '[{"label":"Name","value":"Lily"}]'),
+			('item-3', 'order-3', 'product-1', 1, 2000, 2000, 'Name Plate', '[{"label":"Name","value":"Max"}]')`,
+		`INSERT INTO order_status_history (id, order_id, from_status, to_status, changed_by) VALUES
+			('history-1', 'order-1', 'received', 'shipped', 'admin@logans3dcreations.com'),
+			('history-2', 'order-2', 'received', 'cancelled', 'jane@customer.com')`,
+		`INSERT INTO order_invoices (order_id, fingerprint, pdf) VALUES ('order-1', 'abc', x'255044462d'), ('order-3', 'def', x'255044462d')`,
+		`INSERT INTO sms_messages (id, order_id, phone, event, body, status) VALUES ('sms-1', 'order-1', '+17155550100', 'shipped', 'Your order shipped', 'sent')`,
+		`INSERT INTO user_favorites (id, user_id, product_id) VALUES ('favorite-1', 'user-1', 'product-1')`,
+		`INSERT INTO cart_items (id, user_id, product_id, quantity) VALUES ('cart-1', 'user-1', 'product-1', 2)`,
+		`INSERT INTO email_history (id, user_id, recipient_email, email_type, subject, template_name) VALUES
+			('email-1', 'user-1', 'jane@customer.com', 'order_confirmation', 'Thanks for your order', 'order_confirmation'),
+			('email-2', NULL, 'Jane@Customer.com', 'abandoned_cart', 'You left something behind', 'abandoned_cart'),
+			('email-3', NULL, 'sam@other.com', 'order_confirmation', 'Thanks for your order', 'order_confirmation')`,
+		`INSERT INTO product_questions (id, product_id, user_id, asker_name, asker_email, question, answer, status) VALUES ('question-1', 'product-1', 'user-1', 'Jane', 'jane@customer.com', 'How big is it?', 'About 8 inches', 'published')`,
+	} {
+		_, err := database.Exec(stmt)
+		require.NoError(t, err, stmt)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	database, _, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	seedCustomer(t, database)
+
+	_, err = Collect(ctx, database, Subject{UserID: "user-1"})
+	require.Error(t, err, "an email is required")
+
+	export, err := Collect(ctx, database, jane)
+	require.NoError(t, err)
+	summary := export.Summary()
+	assert.Equal(t, int64(1), summary["profile"])
+	assert.Equal(t, int64(2), summary["orders"], "guest orders with the customer's email are theirs too")
+	assert.Equal(t, int64(1), summary["order_items"])
+	assert.Equal(t, int64(2), summary["emails"])
+	assert.Equal(t, int64(1), summary["favorites"])
+	assert.Equal(t, int64(1), summary["product_questions"])
+	assert.Equal(t, int64(0), summary["quote_requests"])
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteZip(&buf, export))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	require.Contains(t, files, "README.txt")
+	require.Contains(t, files, "orders.json")
+	assert.Len(t, files, len(sections)+1)
+
+	rc, err := files["orders.json"].Open()
+	require.NoError(t, err)
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	var orders []map[string]any
+	require.NoError(t, json.Unmarshal(body, &orders))
+	require.Len(t, orders, 2)
+	assert.Equal(t, "order-1", orders[0]["id"])
+	assert.NotContains(t, string(body), "sam@other.com")
+}
+
+func TestErase(t *testing.T) {
+	database, _, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	seedCustomer(t, database)
+
+	open, err := OpenOrders(ctx, database, jane)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), open, "the guest order is still open")
+
+	tx, err := database.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	summary, err := Erase(ctx, tx, jane)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, int64(1), summary["users"])
+	assert.Equal(t, int64(2), summary["orders"])
+	assert.Equal(t, int64(2), summary["email_history"])
+
+	var invoices int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM order_invoices`).Scan(&invoices))
+	assert.Equal(t, 1, invoices, "only the other customer's stored invoice is left")
+
+	var users, favorites, carts, addresses int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM users WHERE id = 'user-1'`).Scan(&users))
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM user_favorites`).Scan(&favorites))
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM cart_items`).Scan(&carts))
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM user_default_addresses`).Scan(&addresses))
+	assert.Zero(t, users)
+	assert.Zero(t, favorites)
+	assert.Zero(t, carts)
+	assert.Zero(t, addresses)
+
+	var orders int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM orders`).Scan(&orders))
+	assert.Equal(t, 3, orders, "orders are kept for the books")
+
+	var userID, name, email, street, state, notes string
+	var total int64
+	require.NoError(t, database.QueryRow(`
+		SELECT user_id, customer_name, customer_email, shipping_address_line1, shipping_state, customer_notes, total_cents
+		FROM orders WHERE id = 'order-1'`,
+	).Scan(&userID, &name, &email, &street, &state, &notes, &total))
+	assert.Empty(t, userID)
+	assert.Equal(t, deletedName, name)
+	assert.Empty(t, email)
+	assert.Empty(t, street)
+	assert.Equal(t, "WI", state, "the state stays for sales tax")
+	assert.Empty(t, notes)
+	assert.Equal(t, int64(2000), total)
+
+	var personalization sql.NullString
+	require.NoError(t, database.QueryRow(`SELECT personalization FROM order_items WHERE id = 'item-1'`).Scan(&personalization))
+	assert.False(t, personalization.Valid)
+
+	var admin, customer string
+	require.NoError(t, database.QueryRow(`SELECT changed_by FROM order_status_history WHERE id = 'history-1'`).Scan(&admin))
+	require.NoError(t, database.QueryRow(`SELECT changed_by FROM order_status_history WHERE id = 'history-2'`).Scan(&customer))
+	assert.Equal(t, "admin@logans3dcreations.com", admin, "admins' names on the order are kept")
+	assert.Empty(t, customer)
+
+	var asker, question string
+	require.NoError(t, database.QueryRow(`SELECT asker_email, question FROM product_questions`).Scan(&asker, &question))
+	assert.Empty(t, asker)
+	assert.Equal(t, "How big is it?", question, "answered questions stay on the product")
+
+	// Someone else's order and emails are left alone
+	var otherEmail, otherPersonalization string
+	var otherEmails int
+	require.NoError(t, database.QueryRow(`SELECT customer_email FROM orders WHERE id = 'order-3'`).Scan(&otherEmail))
+	require.NoError(t, database.QueryRow(`SELECT personalization FROM order_items WHERE id = 'item-3'`).Scan(&otherPersonalization))
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM email_history`).Scan(&otherEmails))
+	assert.Equal(t, "sam@other.com", otherEmail)
+	assert.Contains(t, otherPersonalization, "Max")
+	assert.Equal(t, 1, otherEmails)
+
+	_, err = Erase(ctx, database, jane)
+	require.Error(t, err, "the account is already gone")
+}
+
+func TestEmailHash(t *testing.T) {
+	assert.Equal(t, EmailHash("jane@customer.com"), EmailHash(" Jane@Customer.com "))
+	assert.NotEqual(t, EmailHash("jane@customer.com"), EmailHash("sam@other.com"))
+	assert.Len(t, EmailHash("jane@customer.com"), 64)
+}
@@ -0,0 +1,141 @@
+// Package promocodes checks promotion codes entered in the cart against their
+// campaign's rules and works out what they take off. A campaign can leave sale items
+// or whole categories out of the discount, decide how its codes stack with the
+// automatic cart promotions, and limit how often one customer uses its codes. The
+// discount is worked out here rather than by Stripe, which can't see those rules.
+package promocodes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Discount types of a promotion campaign
+const (
+	TypePercentage  = "percentage"
+	TypeFixedAmount = "fixed_amount"
+	// TypeAmount is a fixed amount off, as recorded for codes made in the Stripe Dashboard
+	TypeAmount = "amount"
+)
+
+// Stacking policies: how a campaign's codes go with an automatic cart promotion
+const (
+	StackingCombine = "combine"
+	StackingReplace = "replace"
+	StackingNever   = "never"
+)
+
+// StackingOption describes a stacking policy for the admin form
+type StackingOption struct {
+	Value string
+	Label string
+	Help  string
+}
+
+var StackingOptions = []StackingOption{
+	{StackingCombine, "Combine", "The code comes off the prices after any automatic cart promotion"},
+	{StackingReplace, "Replace", "The code is used instead of any automatic cart promotion's discount; free shipping still applies"},
+	{StackingNever, "Never", "The code can't be used while an automatic cart promotion applies"},
+}
+
+// ValidStacking reports whether stacking is one of the stacking policies
+func ValidStacking(stacking string) bool {
+	for _, s := range StackingOptions {
+		if s.Value == stacking {
+			return true
+		}
+	}
+	return false
+}
+
+// Error is a reason a code can't be used, worded for the shopper
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// CheckCode checks that a code and its campaign can be used at now: both switched on,
+// inside their dates and under their use limits
+func CheckCode(code db.PromotionCode, campaign db.PromotionCampaign, now time.Time) error {
+	if campaign.Active.Valid && campaign.Active.Int64 == 0 {
+		return errorf("%s is no longer available", code.Code)
+	}
+	if now.Before(campaign.StartDate) {
+		return errorf("%s isn't active yet", code.Code)
+	}
+	if campaign.EndDate.Valid && !now.Before(campaign.EndDate.Time) {
+		return errorf("%s has expired", code.Code)
+	}
+	if code.ExpiresAt.Valid && !now.Before(code.ExpiresAt.Time) {
+		return errorf("%s has expired", code.Code)
+	}
+	if campaign.MaxUses.Valid && campaign.MaxUses.Int64 > 0 && campaign.CurrentUses.Int64 >= campaign.MaxUses.Int64 {
+		return errorf("%s has reached its limit", code.Code)
+	}
+	if code.MaxUses.Valid && code.MaxUses.Int64 > 0 && code.CurrentUses.Int64 >= code.MaxUses.Int64 {
+		return errorf("%s has already been used", code.Code)
+	}
+	return nil
+}
+
+// Rules are the exclusions of a campaign's codes
+type Rules struct {
+	ExcludeSaleItems bool
+	// ExcludedCategories holds category IDs whose products the codes don't discount
+	ExcludedCategories map[string]bool
+}
+
+// Line is the part of a cart line a code looks at
+type Line struct {
+	ItemID     string
+	CategoryID string
+	Quantity   int64
+	// UnitPriceCents is what each unit costs before the code
+	UnitPriceCents int64
+	// Sale is set for lines already discounted: bundles, quantity breaks and
+	// automatic cart promotions
+	Sale bool
+}
+
+// Eligible returns the lines the rules let the code discount
+func (r Rules) Eligible(lines []Line) []Line {
+	var eligible []Line
+	for _, line := range lines {
+		if r.ExcludeSaleItems && line.Sale {
+			continue
+		}
+		if r.ExcludedCategories[line.CategoryID] {
+			continue
+		}
+		eligible = append(eligible, line)
+	}
+	return eligible
+}
+
+// Discount works out what a code takes off the eligible lines. Percentages round
+// down to the cent, and a fixed amount is capped at what the lines cost, so the
+// discount never comes to more than the eligible subtotal.
+func Discount(discountType string, value int64, eligible []Line) int64 {
+	var subtotal int64
+	for _, line := range eligible {
+		subtotal += line.UnitPriceCents * line.Quantity
+	}
+
+	var discount int64
+	switch discountType {
+	case TypePercentage:
+		discount = subtotal * value / 100
+	case TypeFixedAmount, TypeAmount:
+		discount = value
+	}
+	return max(0, min(discount, subtotal))
+}
@@ -0,0 +1,105 @@
+package promocodes
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+var now = time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC)
+
+func TestCheckCode(t *testing.T) {
+	campaign := db.PromotionCampaign{
+		Active:    sql.NullInt64{Int64: 1, Valid: true},
+		StartDate: now.AddDate(0, 0, -7),
+		EndDate:   sql.NullTime{Time: now.AddDate(0, 0, 7), Valid: true},
+	}
+	code := db.PromotionCode{Code: "SAVE10", MaxUses: sql.NullInt64{Int64: 1, Valid: true}}
+	assert.NoError(t, CheckCode(code, campaign, now))
+
+	tests := []struct {
+		name     string
+		code     func(db.PromotionCode) db.PromotionCode
+		campaign func(db.PromotionCampaign) db.PromotionCampaign
+		message  string
+	}{
+		{"campaign off", nil, func(c db.PromotionCampaign) db.PromotionCampaign { c.Active.Int64 = 0; return c }, "SAVE10 is no longer available"},
+		{"not started", nil, func(c db.PromotionCampaign) db.PromotionCampaign { c.StartDate = now.Add(time.Hour); return c }, "SAVE10 isn't active yet"},
+		{"campaign ended", nil, func(c db.PromotionCampaign) db.PromotionCampaign { c.EndDate.Time = now; return c }, "SAVE10 has expired"},
+		{"code expired", func(c db.PromotionCode) db.PromotionCode {
+			c.ExpiresAt = sql.NullTime{Time: now.Add(-time.Minute), Valid: true}
+			return c
+		}, nil, "SAVE10 has expired"},
+		{"campaign used up", nil, func(c db.PromotionCampaign) db.PromotionCampaign {
+			c.MaxUses = sql.NullInt64{Int64: 100, Valid: true}
+			c.CurrentUses = sql.NullInt64{Int64: 100, Valid: true}
+			return c
+		}, "SAVE10 has reached its limit"},
+		{"code used", func(c db.PromotionCode) db.PromotionCode {
+			c.CurrentUses = sql.NullInt64{Int64: 1, Valid: true}
+			return c
+		}, nil, "SAVE10 has already been used"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, cp := code, campaign
+			if tt.code != nil {
+				c = tt.code(c)
+			}
+			if tt.campaign != nil {
+				cp = tt.campaign(cp)
+			}
+			err := CheckCode(c, cp, now)
+			var codeErr *Error
+			if assert.True(t, errors.As(err, &codeErr)) {
+				assert.Equal(t, tt.message, codeErr.Message)
+			}
+		})
+	}
+
+	unlimited := code
+	unlimited.MaxUses = sql.NullInt64{}
+	unlimited.CurrentUses = sql.NullInt64{Int64: 40, Valid: true}
+	assert.NoError(t, CheckCode(unlimited, campaign, now), "no limit on the code")
+}
+
+func TestEligible(t *testing.T) {
+	lines := []Line{
+		{ItemID: "dragon", CategoryID: "cat-dragons", Quantity: 1, UnitPriceCents: 2500},
+		{ItemID: "bundle", CategoryID: "cat-dragons", Quantity: 2, UnitPriceCents: 1800, Sale: true},
+		{ItemID: "gift-card", CategoryID: "cat-gift-cards", Quantity: 1, UnitPriceCents: 5000},
+	}
+
+	assert.Len(t, Rules{}.Eligible(lines), 3)
+
+	eligible := Rules{ExcludeSaleItems: true, ExcludedCategories: map[string]bool{"cat-gift-cards": true}}.Eligible(lines)
+	if assert.Len(t, eligible, 1) {
+		assert.Equal(t, "dragon", eligible[0].ItemID)
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	lines := []Line{
+		{ItemID: "a", Quantity: 3, UnitPriceCents: 999},
+		{ItemID: "b", Quantity: 1, UnitPriceCents: 1250},
+	}
+
+	assert.Equal(t, int64(424), Discount(TypePercentage, 10, lines), "10% of $42.47 rounds down")
+	assert.Equal(t, int64(500), Discount(TypeFixedAmount, 500, lines))
+	assert.Equal(t, int64(500), Discount(TypeAmount, 500, lines))
+	assert.Equal(t, int64(4247), Discount(TypeFixedAmount, 10000, lines), "never more than the eligible lines cost")
+	assert.Zero(t, Discount(TypeFixedAmount, 500, nil), "nothing eligible")
+	assert.Zero(t, Discount("bogus", 500, lines))
+}
+
+func TestValidStacking(t *testing.T) {
+	assert.True(t, ValidStacking(StackingCombine))
+	assert.True(t, ValidStacking(StackingReplace))
+	assert.True(t, ValidStacking(StackingNever))
+	assert.False(t, ValidStacking("sometimes"))
+}
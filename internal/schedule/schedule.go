@@ -0,0 +1,135 @@
+// Package schedule puts products on and off the shop at set times and runs their
+// sales. Times are the wall-clock times the admin typed, like event and drop times,
+// so they are compared against utils.WallClockNow.
+//
+// A running sale is applied to the stored price rather than worked out on every read:
+// price_cents holds the sale price while the sale runs, and regular_price_cents keeps
+// the price to put back. Carts, checkout, feeds and Stripe therefore charge the sale
+// price without knowing about sales. SKU sales work the same way through the SKU's
+// price adjustment.
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Error is a schedule the admin needs to correct, with a message to show them
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Window is when something is scheduled to happen. An open start means it has
+// already begun, and an open end means it never finishes.
+type Window struct {
+	Start sql.NullTime
+	End   sql.NullTime
+}
+
+// Overlaps reports whether two windows share any time. A window ending when the
+// other starts doesn't overlap it.
+func (w Window) Overlaps(other Window) bool {
+	return startsBefore(w.Start, other.End) && startsBefore(other.Start, w.End)
+}
+
+func startsBefore(start, end sql.NullTime) bool {
+	if !start.Valid || !end.Valid {
+		return true
+	}
+	return start.Time.Before(end.Time)
+}
+
+// ValidatePublishing checks that a product set to go on and off the shop goes on first
+func ValidatePublishing(publishAt, unpublishAt sql.NullTime) error {
+	if publishAt.Valid && unpublishAt.Valid && !publishAt.Time.Before(unpublishAt.Time) {
+		return &Error{Message: "The unpublish time must be after the publish time"}
+	}
+	return nil
+}
+
+// ValidateSale checks a sale price against the regular price it discounts and that
+// its window starts before it ends
+func ValidateSale(salePriceCents, regularPriceCents int64, window Window) error {
+	if salePriceCents <= 0 {
+		return &Error{Message: "The sale price must be more than $0"}
+	}
+	if salePriceCents >= regularPriceCents {
+		return &Error{Message: fmt.Sprintf("The sale price must be less than the regular price of $%.2f", float64(regularPriceCents)/100)}
+	}
+	if window.Start.Valid && window.End.Valid && !window.Start.Time.Before(window.End.Time) {
+		return &Error{Message: "The sale must end after it starts"}
+	}
+	return nil
+}
+
+// CheckOverlap rejects a sale whose window overlaps another sale on the same product.
+// A product sale and a variant sale running at once would leave the variant's price
+// ambiguous, so they have to take turns.
+func CheckOverlap(window Window, others map[string]Window) error {
+	names := make([]string, 0, len(others))
+	for name := range others {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if window.Overlaps(others[name]) {
+			return &Error{Message: fmt.Sprintf("This sale overlaps the sale on %s. Change one so they don't run at the same time.", name)}
+		}
+	}
+	return nil
+}
+
+// Apply publishes and unpublishes products whose times have come, and starts and
+// ends sales. It returns the IDs of the products whose visibility or prices changed,
+// so their Stripe catalog entries can be brought up to date.
+func Apply(ctx context.Context, queries *db.Queries, now time.Time) ([]string, error) {
+	at := sql.NullTime{Time: now, Valid: true}
+	changed := map[string]bool{}
+	add := func(ids []string, err error) error {
+		for _, id := range ids {
+			changed[id] = true
+		}
+		return err
+	}
+
+	// Sales end before others start, so back-to-back sales hand over cleanly, and
+	// SKU sales are pinned last against the product prices just set
+	steps := []struct {
+		name string
+		run  func() ([]string, error)
+	}{
+		{"publish products", func() ([]string, error) { return queries.PublishScheduledProducts(ctx, at) }},
+		{"unpublish products", func() ([]string, error) { return queries.UnpublishScheduledProducts(ctx, at) }},
+		{"end product sales", func() ([]string, error) { return queries.EndProductSales(ctx, at) }},
+		{"end sku sales", func() ([]string, error) { return queries.EndSkuSales(ctx, at) }},
+		{"repin product sales", func() ([]string, error) { return queries.RepinProductSales(ctx) }},
+		{"start product sales", func() ([]string, error) { return queries.StartProductSales(ctx, at) }},
+		{"start sku sales", func() ([]string, error) { return queries.StartSkuSales(ctx, at) }},
+		{"repin sku sales", func() ([]string, error) { return queries.RepinSkuSales(ctx) }},
+	}
+	for _, step := range steps {
+		if err := add(step.run()); err != nil {
+			return sortedKeys(changed), fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return sortedKeys(changed), nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
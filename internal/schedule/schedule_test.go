@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func at(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func TestWindowOverlaps(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	march := Window{Start: at(day), End: at(day.AddDate(0, 1, 0))}
+
+	assert.True(t, march.Overlaps(Window{Start: at(day.AddDate(0, 0, 10)), End: at(day.AddDate(0, 0, 12))}))
+	assert.True(t, march.Overlaps(Window{}), "a window with no ends covers everything")
+	assert.True(t, march.Overlaps(Window{End: at(day.AddDate(0, 0, 1))}), "an open start has already begun")
+	assert.True(t, march.Overlaps(Window{Start: at(day.AddDate(0, 0, 30))}), "an open end never finishes")
+	assert.False(t, march.Overlaps(Window{Start: at(day.AddDate(0, 1, 0))}), "back-to-back windows don't overlap")
+	assert.False(t, march.Overlaps(Window{End: at(day)}))
+}
+
+func TestValidateSale(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, ValidateSale(1500, 2000, Window{Start: at(day), End: at(day.Add(time.Hour))}))
+	assert.NoError(t, ValidateSale(1500, 2000, Window{}))
+
+	assert.EqualError(t, ValidateSale(0, 2000, Window{}), "The sale price must be more than $0")
+	assert.EqualError(t, ValidateSale(2000, 2000, Window{}), "The sale price must be less than the regular price of $20.00")
+	assert.EqualError(t, ValidateSale(1500, 2000, Window{Start: at(day), End: at(day)}), "The sale must end after it starts")
+
+	var scheduleErr *Error
+	assert.True(t, errors.As(ValidatePublishing(at(day), at(day.Add(-time.Hour))), &scheduleErr))
+	assert.NoError(t, ValidatePublishing(at(day), sql.NullTime{}))
+}
+
+func TestCheckOverlap(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	sale := Window{Start: at(day), End: at(day.AddDate(0, 0, 7))}
+	others := map[string]Window{
+		"Red / Large":  {Start: at(day.AddDate(0, 0, 7))},
+		"Blue / Small": {Start: at(day.AddDate(0, 0, 6)), End: at(day.AddDate(0, 0, 8))},
+	}
+	assert.EqualError(t, CheckOverlap(sale, others), "This sale overlaps the sale on Blue / Small. Change one so they don't run at the same time.")
+
+	delete(others, "Blue / Small")
+	assert.NoError(t, CheckOverlap(sale, others))
+}
+
+func TestApply(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	product, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:         "prod-dragon",
+		Name:       "Articulated Dragon",
+		Slug:       "articulated-dragon",
+		PriceCents: 2500,
+		IsActive:   sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, queries.UpdateProductSchedule(ctx, db.UpdateProductScheduleParams{
+		PublishAt:   at(now.Add(time.Hour)),
+		UnpublishAt: at(now.Add(3 * time.Hour)),
+		Hide:        true,
+		ID:          product.ID,
+	}))
+	require.NoError(t, queries.UpdateProductSaleSettings(ctx, db.UpdateProductSaleSettingsParams{
+		SalePriceCents: sql.NullInt64{Int64: 2000, Valid: true},
+		SaleStartsAt:   at(now.Add(-time.Minute)),
+		SaleEndsAt:     at(now.Add(2 * time.Hour)),
+		ID:             product.ID,
+	}))
+
+	changed, err := Apply(ctx, queries, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{product.ID}, changed)
+	got, err := queries.GetProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.False(t, got.IsActive.Bool, "hidden until it's published")
+	assert.Equal(t, int64(2000), got.PriceCents)
+	assert.Equal(t, int64(2500), got.RegularPriceCents.Int64)
+
+	changed, err = Apply(ctx, queries, now)
+	require.NoError(t, err)
+	assert.Empty(t, changed, "nothing is due twice")
+
+	// A price set mid-sale by another editor becomes the regular price
+	_, err = queries.UpdateProductInline(ctx, db.UpdateProductInlineParams{
+		ID:         product.ID,
+		Name:       product.Name,
+		Slug:       product.Slug,
+		PriceCents: 2800,
+	})
+	require.NoError(t, err)
+
+	_, err = Apply(ctx, queries, now.Add(time.Hour))
+	require.NoError(t, err)
+	got, err = queries.GetProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.True(t, got.IsActive.Bool)
+	assert.False(t, got.PublishAt.Valid)
+	assert.Equal(t, int64(2000), got.PriceCents)
+	assert.Equal(t, int64(2800), got.RegularPriceCents.Int64)
+
+	_, err = Apply(ctx, queries, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	got, err = queries.GetProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.False(t, got.IsActive.Bool)
+	assert.Equal(t, int64(2800), got.PriceCents)
+	assert.False(t, got.RegularPriceCents.Valid)
+	assert.False(t, got.SalePriceCents.Valid, "a finished sale is cleared")
+}
+
+func TestApplySkuSale(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	product, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:          "prod-dragon",
+		Name:        "Articulated Dragon",
+		Slug:        "articulated-dragon",
+		PriceCents:  2500,
+		HasVariants: sql.NullBool{Bool: true, Valid: true},
+		IsActive:    sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	style, err := queries.CreateProductStyle(ctx, db.CreateProductStyleParams{
+		ID:        "style-red",
+		ProductID: product.ID,
+		Name:      "Red",
+		IsPrimary: sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	sku, err := queries.CreateProductSku(ctx, db.CreateProductSkuParams{
+		ID:                   "sku-red-large",
+		ProductID:            product.ID,
+		ProductStyleID:       style.ID,
+		SizeID:               "size_large",
+		Sku:                  "DRAGON-RED-L",
+		PriceAdjustmentCents: sql.NullInt64{Int64: 500, Valid: true},
+		IsActive:             sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	rows, err := queries.UpdateSkuSaleSettings(ctx, db.UpdateSkuSaleSettingsParams{
+		SalePriceCents: sql.NullInt64{Int64: 2400, Valid: true},
+		SaleEndsAt:     at(now.Add(time.Hour)),
+		ID:             sku.ID,
+		ProductID:      product.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	_, err = Apply(ctx, queries, now)
+	require.NoError(t, err)
+	got, err := queries.GetProductSku(ctx, sku.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-100), got.PriceAdjustmentCents.Int64, "2500 - 100 is the 2400 sale price")
+	assert.Equal(t, int64(500), got.RegularAdjustmentCents.Int64)
+
+	// The SKU stays at its sale price when the product's price moves
+	_, err = queries.UpdateProductInline(ctx, db.UpdateProductInlineParams{
+		ID:         product.ID,
+		Name:       product.Name,
+		Slug:       product.Slug,
+		PriceCents: 3000,
+	})
+	require.NoError(t, err)
+	_, err = Apply(ctx, queries, now)
+	require.NoError(t, err)
+	got, err = queries.GetProductSku(ctx, sku.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-600), got.PriceAdjustmentCents.Int64)
+
+	_, err = Apply(ctx, queries, now.Add(time.Hour))
+	require.NoError(t, err)
+	got, err = queries.GetProductSku(ctx, sku.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), got.PriceAdjustmentCents.Int64)
+	assert.False(t, got.SalePriceCents.Valid)
+}
@@ -0,0 +1,541 @@
+// Package settings holds the store-wide details an admin can change without a
+// deploy: contact details, social links, tax nexus states, the minimum order, the
+// customer cancellation window, checkout payment methods, the carbon offset add-on,
+// the announcement banner and the cart page's exit-intent popup. Values live in the
+// site_config table as strings; every key is declared here with its kind, default
+// and validation, and read through typed accessors.
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Kind decides how a setting is edited and validated
+type Kind string
+
+const (
+	KindText     Kind = "text"
+	KindTextarea Kind = "textarea"
+	KindEmail    Kind = "email"
+	KindURL      Kind = "url"
+	KindBool     Kind = "bool"
+	KindStates   Kind = "states" // comma-separated two-letter US state codes
+	KindMoney    Kind = "money"  // dollars, stored with two decimal places
+	KindHours    Kind = "hours"  // a whole number of hours, zero or more
+)
+
+// Setting keys
+const (
+	SiteName        = "site_name"
+	SiteURL         = "site_url"
+	SiteDescription = "site_description"
+	DefaultOGImage  = "default_og_image"
+
+	ContactEmail    = "contact_email"
+	ContactPhone    = "contact_phone"
+	BusinessAddress = "business_address"
+
+	TwitterHandle  = "twitter_handle"
+	FacebookPageID = "facebook_page_id"
+	FacebookAppID  = "facebook_app_id"
+	FacebookURL    = "facebook_url"
+	InstagramURL   = "instagram_url"
+	YouTubeURL     = "youtube_url"
+	TikTokURL      = "tiktok_url"
+
+	TaxNexusStates = "tax_nexus_states"
+
+	AttachInvoice     = "attach_invoice"
+	MinimumOrder      = "minimum_order"
+	OrderCancelWindow = "order_cancel_window"
+
+	PaymentLink          = "payment_link"
+	PaymentKlarna        = "payment_klarna"
+	PaymentAfterpay      = "payment_afterpay"
+	PaymentAutomatic     = "payment_automatic"
+	PaymentLocalCurrency = "payment_local_currency"
+
+	CarbonOffsetEnabled = "carbon_offset_enabled"
+	CarbonOffsetAmount  = "carbon_offset_amount"
+	CarbonOffsetText    = "carbon_offset_text"
+
+	SMSOrderConfirmation = "sms_order_confirmation"
+	SMSShipped           = "sms_shipped"
+	SMSDelivered         = "sms_delivered"
+
+	AnnouncementEnabled = "announcement_enabled"
+	AnnouncementText    = "announcement_text"
+	AnnouncementLink    = "announcement_link"
+
+	ExitIntentEnabled = "exit_intent_enabled"
+	ExitIntentCode    = "exit_intent_code"
+)
+
+// Definition describes one setting for the admin form
+type Definition struct {
+	Key     string
+	Label   string
+	Group   string
+	Kind    Kind
+	Default string
+	Help    string
+	// Required settings can't be saved blank
+	Required bool
+}
+
+// Definitions lists every setting in the order the admin page shows them
+var Definitions = []Definition{
+	{Key: SiteName, Label: "Store name", Group: "General", Kind: KindText, Default: "Logan's 3D Creations", Required: true},
+	{Key: SiteURL, Label: "Site URL", Group: "General", Kind: KindURL, Default: "https://www.logans3dcreations.com", Required: true, Help: "Used for canonical links, share images and email links."},
+	{Key: SiteDescription, Label: "Default description", Group: "General", Kind: KindTextarea, Default: "Custom 3D printed collectibles, dinosaurs, and more", Required: true, Help: "Meta description for pages that don't set their own."},
+	{Key: DefaultOGImage, Label: "Default share image", Group: "General", Kind: KindText, Default: "/public/images/social/default-og.jpg", Required: true, Help: "Path or URL of the image shown when a page without its own is shared."},
+
+	{Key: ContactEmail, Label: "Contact email", Group: "Contact", Kind: KindEmail, Default: "prints@logans3dcreations.com", Required: true},
+	{Key: ContactPhone, Label: "Phone", Group: "Contact", Kind: KindText, Default: "715-703-3768"},
+	{Key: BusinessAddress, Label: "Workshop address", Group: "Contact", Kind: KindTextarea, Default: "25892 County Hwy S\nCadott, WI 54727", Help: "Shown on the contact page and in email footers."},
+
+	{Key: FacebookURL, Label: "Facebook page", Group: "Social", Kind: KindURL, Default: "https://www.facebook.com/Logans3D"},
+	{Key: InstagramURL, Label: "Instagram", Group: "Social", Kind: KindURL, Default: "https://www.instagram.com/logans3dcreations/"},
+	{Key: YouTubeURL, Label: "YouTube", Group: "Social", Kind: KindURL},
+	{Key: TikTokURL, Label: "TikTok", Group: "Social", Kind: KindURL},
+	{Key: TwitterHandle, Label: "X/Twitter handle", Group: "Social", Kind: KindText, Help: "With the @, for Twitter card tags."},
+	{Key: FacebookPageID, Label: "Facebook page ID", Group: "Social", Kind: KindText},
+	{Key: FacebookAppID, Label: "Facebook app ID", Group: "Social", Kind: KindText},
+
+	{Key: TaxNexusStates, Label: "Tax nexus states", Group: "Tax", Kind: KindStates, Default: "WI", Help: "Comma-separated state codes where sales tax is collected, e.g. WI, MN. Highlighted on the sales tax report."},
+
+	{Key: AttachInvoice, Label: "Attach invoice PDF to order confirmations", Group: "Orders", Kind: KindBool, Default: "false", Help: "Customers can always download their invoice from their order page."},
+	{Key: MinimumOrder, Label: "Minimum order subtotal", Group: "Orders", Kind: KindMoney, Help: "In dollars, before shipping and discounts. Checkout is blocked until the cart reaches it. Leave blank for no minimum."},
+	{Key: OrderCancelWindow, Label: "Customer cancellation window", Group: "Orders", Kind: KindHours, Default: "24", Help: "Hours after ordering that customers can cancel an order from their account and get their money back, as long as it's still Received. 0 turns self-service cancellation off."},
+
+	{Key: PaymentLink, Label: "Stripe Link", Group: "Payments", Kind: KindBool, Default: "false", Help: "Customers who saved their details with Link check out in one click. Cards, Apple Pay and Google Pay are always offered."},
+	{Key: PaymentKlarna, Label: "Klarna", Group: "Payments", Kind: KindBool, Default: "false", Help: "Pay over time. Turn it on in the Stripe Dashboard first."},
+	{Key: PaymentAfterpay, Label: "Afterpay", Group: "Payments", Kind: KindBool, Default: "false", Help: "Pay in 4, on shipped orders of $1 to $4,000. Turn it on in the Stripe Dashboard first."},
+	{Key: PaymentAutomatic, Label: "Let Stripe choose payment methods", Group: "Payments", Kind: KindBool, Default: "false", Help: "Offer whatever is turned on in the Stripe Dashboard, and let Stripe pick what suits each customer, instead of the methods above. The cart page still shows the methods above."},
+	{Key: PaymentLocalCurrency, Label: "Charge in the customer's currency", Group: "Payments", Kind: KindBool, Default: "false", Help: "Stripe Adaptive Pricing: customers outside the US see and pay their total in their own currency. Orders are still recorded in USD. Turn it on in the Stripe Dashboard first."},
+
+	{Key: CarbonOffsetEnabled, Label: "Offer a carbon offset at checkout", Group: "Carbon offset", Kind: KindBool, Default: "false", Help: "Customers can tick a box in the cart to add a small amount to their order, to offset the carbon from making and shipping it. What's collected is on the carbon offset report, to pass on to an offset program."},
+	{Key: CarbonOffsetAmount, Label: "Offset amount", Group: "Carbon offset", Kind: KindMoney, Default: "1.00", Help: "In dollars, added once per order. It isn't taxed."},
+	{Key: CarbonOffsetText, Label: "Offset description", Group: "Carbon offset", Kind: KindText, Help: "Optional. One line under the checkbox and on the Stripe checkout page, like the program the money goes to."},
+
+	{Key: SMSOrderConfirmation, Label: "Text order confirmations", Group: "SMS", Kind: KindBool, Default: "true", Help: "Texts go only to customers who ask for them at checkout, and only once Twilio is set up."},
+	{Key: SMSShipped, Label: "Text when an order ships", Group: "SMS", Kind: KindBool, Default: "true", Help: "Includes the tracking link when there is one."},
+	{Key: SMSDelivered, Label: "Text when an order is delivered", Group: "SMS", Kind: KindBool, Default: "false"},
+
+	{Key: AnnouncementEnabled, Label: "Show announcement banner", Group: "Announcement", Kind: KindBool, Default: "false"},
+	{Key: AnnouncementText, Label: "Banner text", Group: "Announcement", Kind: KindText, Help: "One short line across the top of every storefront page."},
+	{Key: AnnouncementLink, Label: "Banner link", Group: "Announcement", Kind: KindURL, Help: "Optional. A full URL or a path like /shop/drops."},
+
+	{Key: ExitIntentEnabled, Label: "Offer to email the cart when leaving", Group: "Cart", Kind: KindBool, Default: "false", Help: "When a shopper with items in their cart heads off the cart page, a popup offers to email them a link to it. Guests type their email; the cart then goes straight into the abandoned cart emails."},
+	{Key: ExitIntentCode, Label: "Popup incentive code", Group: "Cart", Kind: KindText, Help: "Optional. A promotion code the popup shows, like STAY5, with a button to apply it. Create the code under Promotions first."},
+}
+
+// Lookup finds a setting's definition
+func Lookup(key string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Validate checks a submitted value and returns it in its stored form
+func (d Definition) Validate(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	if d.Kind != KindTextarea {
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if value == "" {
+		if d.Kind == KindBool {
+			return "false", nil
+		}
+		if d.Required {
+			return "", errors.New("is required")
+		}
+		return "", nil
+	}
+
+	switch d.Kind {
+	case KindEmail:
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Name != "" {
+			return "", errors.New("must be an email address")
+		}
+		return addr.Address, nil
+	case KindURL:
+		// Site-relative paths are allowed for links, but the site URL itself must be absolute
+		if strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//") && d.Key != SiteURL {
+			return value, nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return "", errors.New("must be a full http(s) URL")
+		}
+		if d.Key == SiteURL {
+			value = strings.TrimRight(value, "/")
+		}
+		return value, nil
+	case KindBool:
+		switch strings.ToLower(value) {
+		case "true", "on", "1", "yes":
+			return "true", nil
+		case "false", "off", "0", "no":
+			return "false", nil
+		}
+		return "", errors.New("must be on or off")
+	case KindMoney:
+		dollars, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil || dollars < 0 || math.IsInf(dollars, 0) || math.IsNaN(dollars) {
+			return "", errors.New("must be an amount in dollars, like 25.00")
+		}
+		return fmt.Sprintf("%.2f", dollars), nil
+	case KindHours:
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 0 {
+			return "", errors.New("must be a whole number of hours, like 24")
+		}
+		return strconv.Itoa(hours), nil
+	case KindStates:
+		states, err := parseStates(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(states, ","), nil
+	}
+	return value, nil
+}
+
+func parseStates(value string) ([]string, error) {
+	var states []string
+	for _, part := range strings.Split(value, ",") {
+		state := strings.ToUpper(strings.TrimSpace(part))
+		if state == "" {
+			continue
+		}
+		if len(state) != 2 || state[0] < 'A' || state[0] > 'Z' || state[1] < 'A' || state[1] > 'Z' {
+			return nil, fmt.Errorf("must be two-letter state codes, not %q", strings.TrimSpace(part))
+		}
+		if !slices.Contains(states, state) {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+// Values is a snapshot of every setting. Keys that were never saved read as their
+// defaults, so a zero Values is the default settings.
+type Values map[string]string
+
+// Defaults returns the values used before anything is saved
+func Defaults() Values {
+	values := make(Values, len(Definitions))
+	for _, def := range Definitions {
+		values[def.Key] = def.Default
+	}
+	return values
+}
+
+// String returns a setting's value
+func (v Values) String(key string) string {
+	if value, ok := v[key]; ok {
+		return value
+	}
+	def, _ := Lookup(key)
+	return def.Default
+}
+
+// Bool returns a boolean setting
+func (v Values) Bool(key string) bool {
+	return v.String(key) == "true"
+}
+
+// List returns a comma-separated setting as a list
+func (v Values) List(key string) []string {
+	var items []string
+	for _, item := range strings.Split(v.String(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (v Values) SiteName() string        { return v.String(SiteName) }
+func (v Values) SiteURL() string         { return v.String(SiteURL) }
+func (v Values) SiteDescription() string { return v.String(SiteDescription) }
+func (v Values) DefaultOGImage() string  { return v.String(DefaultOGImage) }
+func (v Values) ContactEmail() string    { return v.String(ContactEmail) }
+func (v Values) ContactPhone() string    { return v.String(ContactPhone) }
+func (v Values) TwitterHandle() string   { return v.String(TwitterHandle) }
+func (v Values) FacebookPageID() string  { return v.String(FacebookPageID) }
+func (v Values) FacebookAppID() string   { return v.String(FacebookAppID) }
+
+// AddressLines is the business address split for display
+func (v Values) AddressLines() []string {
+	var lines []string
+	for _, line := range strings.Split(v.String(BusinessAddress), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// TaxNexusStates lists the states sales tax is collected in
+func (v Values) TaxNexusStates() []string {
+	return v.List(TaxNexusStates)
+}
+
+// AttachInvoice reports whether order confirmation emails carry the invoice PDF
+func (v Values) AttachInvoice() bool {
+	return v.Bool(AttachInvoice)
+}
+
+// MinimumOrderCents is the smallest subtotal checkout accepts, or zero for no minimum
+func (v Values) MinimumOrderCents() int64 {
+	dollars, err := strconv.ParseFloat(v.String(MinimumOrder), 64)
+	if err != nil || dollars <= 0 {
+		return 0
+	}
+	return int64(math.Round(dollars * 100))
+}
+
+// OrderCancelWindow is how long after ordering a customer can cancel the order
+// themselves, or zero when they can't
+func (v Values) OrderCancelWindow() time.Duration {
+	hours, err := strconv.Atoi(v.String(OrderCancelWindow))
+	if err != nil || hours <= 0 {
+		return 0
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// CarbonOffset is the add-on customers can tick at checkout to offset their order's
+// carbon
+type CarbonOffset struct {
+	Cents int64
+	// Text says where the money goes, if the admin wrote something
+	Text string
+}
+
+// CarbonOffset returns the checkout add-on, if it's switched on and has an amount
+func (v Values) CarbonOffset() (CarbonOffset, bool) {
+	if !v.Bool(CarbonOffsetEnabled) {
+		return CarbonOffset{}, false
+	}
+	dollars, err := strconv.ParseFloat(v.String(CarbonOffsetAmount), 64)
+	if err != nil || dollars <= 0 {
+		return CarbonOffset{}, false
+	}
+	return CarbonOffset{Cents: int64(math.Round(dollars * 100)), Text: v.String(CarbonOffsetText)}, true
+}
+
+// PaymentMethods is which payment methods checkout offers besides cards and the
+// Apple Pay and Google Pay wallets, which come with them
+type PaymentMethods struct {
+	// Automatic leaves the choice to the Stripe Dashboard
+	Automatic bool
+	Link      bool
+	Klarna    bool
+	Afterpay  bool
+}
+
+// ChargesLocalCurrency reports whether checkout lets Stripe charge customers in their
+// own currency rather than USD
+func (v Values) ChargesLocalCurrency() bool {
+	return v.Bool(PaymentLocalCurrency)
+}
+
+// PaymentMethods returns the checkout payment method settings
+func (v Values) PaymentMethods() PaymentMethods {
+	return PaymentMethods{
+		Automatic: v.Bool(PaymentAutomatic),
+		Link:      v.Bool(PaymentLink),
+		Klarna:    v.Bool(PaymentKlarna),
+		Afterpay:  v.Bool(PaymentAfterpay),
+	}
+}
+
+// Accepted names the ways to pay, for the logos on the cart page
+func (p PaymentMethods) Accepted() []string {
+	accepted := []string{"Visa", "Mastercard", "American Express", "Discover", "Apple Pay", "Google Pay"}
+	if p.Link {
+		accepted = append(accepted, "Link")
+	}
+	if p.Klarna {
+		accepted = append(accepted, "Klarna")
+	}
+	if p.Afterpay {
+		accepted = append(accepted, "Afterpay")
+	}
+	return accepted
+}
+
+// SocialLink is a profile on another site
+type SocialLink struct {
+	Network string // "facebook", "instagram", "youtube" or "tiktok"
+	Label   string
+	URL     string
+}
+
+// SocialLinks lists the social profiles that are filled in
+func (v Values) SocialLinks() []SocialLink {
+	var links []SocialLink
+	for _, link := range []SocialLink{
+		{Network: "facebook", Label: "Facebook", URL: v.String(FacebookURL)},
+		{Network: "instagram", Label: "Instagram", URL: v.String(InstagramURL)},
+		{Network: "youtube", Label: "YouTube", URL: v.String(YouTubeURL)},
+		{Network: "tiktok", Label: "TikTok", URL: v.String(TikTokURL)},
+	} {
+		if link.URL != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// Announcement is the banner across the top of storefront pages
+type Announcement struct {
+	Text string
+	Link string
+}
+
+// Announcement returns the banner, if it's switched on and has text
+func (v Values) Announcement() (Announcement, bool) {
+	if !v.Bool(AnnouncementEnabled) || v.String(AnnouncementText) == "" {
+		return Announcement{}, false
+	}
+	return Announcement{Text: v.String(AnnouncementText), Link: v.String(AnnouncementLink)}, true
+}
+
+// ExitIntent is the popup offered to shoppers about to leave the cart page
+type ExitIntent struct {
+	// Code is a promotion code shown as an incentive to stay, if one is set
+	Code string
+}
+
+// ExitIntent returns the cart page popup, if it's switched on
+func (v Values) ExitIntent() (ExitIntent, bool) {
+	if !v.Bool(ExitIntentEnabled) {
+		return ExitIntent{}, false
+	}
+	return ExitIntent{Code: strings.ToUpper(v.String(ExitIntentCode))}, true
+}
+
+// DefaultTTL is how long settings are cached. Saving from the admin page invalidates
+// the cache at once.
+const DefaultTTL = time.Minute
+
+// Loader reads the saved settings by key
+type Loader func(ctx context.Context) (map[string]string, error)
+
+// Store caches settings read from the database
+type Store struct {
+	load Loader
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu       sync.Mutex
+	values   Values
+	loadedAt time.Time
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[*db.Queries]*Store)
+)
+
+// For returns the store for a database. Everything reading settings through the
+// same *db.Queries shares one cache, so a save from the admin page reaches page
+// meta, emails and the contact page together.
+func For(queries *db.Queries) *Store {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	store, ok := stores[queries]
+	if !ok {
+		store = newStore(func(ctx context.Context) (map[string]string, error) {
+			rows, err := queries.GetAllSiteConfig(ctx)
+			if err != nil {
+				return nil, err
+			}
+			saved := make(map[string]string, len(rows))
+			for _, row := range rows {
+				saved[row.Key] = row.Value
+			}
+			return saved, nil
+		}, DefaultTTL)
+		stores[queries] = store
+	}
+	return store
+}
+
+func newStore(load Loader, ttl time.Duration) *Store {
+	return &Store{
+		load: load,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Values returns every setting. When the database can't be read, the last loaded
+// values are used, or the defaults before anything has loaded. A nil *Store has the
+// defaults.
+func (s *Store) Values(ctx context.Context) Values {
+	if s == nil {
+		return Defaults()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.values != nil && s.now().Sub(s.loadedAt) < s.ttl {
+		return s.values
+	}
+
+	saved, err := s.load(ctx)
+	if err != nil {
+		slog.Error("failed to load site settings", "error", err)
+		if s.values == nil {
+			return Defaults()
+		}
+		return s.values
+	}
+
+	values := Defaults()
+	for key, value := range saved {
+		// A blank optional setting is a deliberate "none", but a blank required one
+		// would break pages, so it keeps its default
+		if def, ok := Lookup(key); ok && def.Required && value == "" {
+			continue
+		}
+		values[key] = value
+	}
+	s.values = values
+	s.loadedAt = s.now()
+	return values
+}
+
+// Invalidate makes the next read reload settings
+func (s *Store) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = nil
+}
@@ -0,0 +1,183 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"email", ContactEmail, "  Prints@Example.com ", "Prints@Example.com", false},
+		{"email with a name", ContactEmail, "Logan <prints@example.com>", "", true},
+		{"not an email", ContactEmail, "prints", "", true},
+		{"required blank", ContactEmail, "   ", "", true},
+		{"optional blank", YouTubeURL, "", "", false},
+		{"url", InstagramURL, "https://instagram.com/x", "https://instagram.com/x", false},
+		{"url without a scheme", InstagramURL, "instagram.com/x", "", true},
+		{"javascript url", AnnouncementLink, "javascript:alert(1)", "", true},
+		{"relative link", AnnouncementLink, "/shop/drops", "/shop/drops", false},
+		{"protocol-relative link", AnnouncementLink, "//evil.example", "", true},
+		{"site url must be absolute", SiteURL, "/shop", "", true},
+		{"site url trailing slash", SiteURL, "https://www.example.com/", "https://www.example.com", false},
+		{"bool on", AnnouncementEnabled, "on", "true", false},
+		{"bool blank", AnnouncementEnabled, "", "false", false},
+		{"states", TaxNexusStates, "wi, MN,,wi", "WI,MN", false},
+		{"bad state", TaxNexusStates, "WI, Minnesota", "", true},
+		{"money", MinimumOrder, "$25", "25.00", false},
+		{"money blank", MinimumOrder, "", "", false},
+		{"negative money", MinimumOrder, "-5", "", true},
+		{"not money", MinimumOrder, "twenty", "", true},
+		{"hours", OrderCancelWindow, " 48 ", "48", false},
+		{"zero hours", OrderCancelWindow, "0", "0", false},
+		{"fractional hours", OrderCancelWindow, "1.5", "", true},
+		{"negative hours", OrderCancelWindow, "-2", "", true},
+		{"text collapses spaces", AnnouncementText, "  Free   shipping\nthis week ", "Free shipping this week", false},
+		{"textarea keeps lines", BusinessAddress, " 1 Main St\nCadott, WI ", "1 Main St\nCadott, WI", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, ok := Lookup(tt.key)
+			require.True(t, ok)
+			got, err := def.Validate(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDefaultsAreValid(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, def := range Definitions {
+		assert.False(t, seen[def.Key], "duplicate key %s", def.Key)
+		seen[def.Key] = true
+
+		got, err := def.Validate(def.Default)
+		if assert.NoError(t, err, def.Key) && def.Kind != KindBool {
+			assert.Equal(t, def.Default, got, def.Key)
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	values := Defaults()
+	values[BusinessAddress] = "1 Main St\n\n Cadott, WI "
+	values[TaxNexusStates] = "WI,MN"
+	values[FacebookURL] = ""
+	values[TikTokURL] = "https://www.tiktok.com/@logans3d"
+
+	assert.Equal(t, []string{"1 Main St", "Cadott, WI"}, values.AddressLines())
+	assert.Equal(t, []string{"WI", "MN"}, values.TaxNexusStates())
+
+	links := values.SocialLinks()
+	require.Len(t, links, 2)
+	assert.Equal(t, "instagram", links[0].Network)
+	assert.Equal(t, "tiktok", links[1].Network)
+
+	var none Values
+	assert.Equal(t, "Logan's 3D Creations", none.SiteName(), "a zero Values reads as the defaults")
+	assert.Len(t, none.SocialLinks(), 2)
+
+	assert.Equal(t, int64(0), values.MinimumOrderCents(), "no minimum by default")
+	values[MinimumOrder] = "19.99"
+	assert.Equal(t, int64(1999), values.MinimumOrderCents())
+
+	assert.Equal(t, 24*time.Hour, values.OrderCancelWindow())
+	values[OrderCancelWindow] = "0"
+	assert.Zero(t, values.OrderCancelWindow(), "customers can't cancel")
+
+	_, ok := values.Announcement()
+	assert.False(t, ok, "off by default")
+	values[AnnouncementEnabled] = "true"
+	_, ok = values.Announcement()
+	assert.False(t, ok, "no banner without text")
+	values[AnnouncementText] = "Holiday orders close Dec 15"
+	banner, ok := values.Announcement()
+	assert.True(t, ok)
+	assert.Equal(t, "Holiday orders close Dec 15", banner.Text)
+
+	_, ok = values.ExitIntent()
+	assert.False(t, ok, "no exit-intent popup by default")
+	values[ExitIntentEnabled] = "true"
+	popup, ok := values.ExitIntent()
+	assert.True(t, ok)
+	assert.Empty(t, popup.Code, "the popup can offer the email alone")
+	values[ExitIntentCode] = "stay5"
+	popup, _ = values.ExitIntent()
+	assert.Equal(t, "STAY5", popup.Code)
+
+	_, ok = values.CarbonOffset()
+	assert.False(t, ok, "no carbon offset by default")
+	values[CarbonOffsetEnabled] = "true"
+	offset, ok := values.CarbonOffset()
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), offset.Cents)
+	values[CarbonOffsetAmount] = ""
+	_, ok = values.CarbonOffset()
+	assert.False(t, ok, "nothing to offer without an amount")
+
+	assert.Equal(t, PaymentMethods{}, values.PaymentMethods(), "cards only by default")
+	assert.NotContains(t, values.PaymentMethods().Accepted(), "Klarna")
+	values[PaymentKlarna] = "true"
+	assert.True(t, values.PaymentMethods().Klarna)
+	assert.Contains(t, values.PaymentMethods().Accepted(), "Klarna")
+	assert.False(t, values.ChargesLocalCurrency(), "USD checkout by default")
+}
+
+func TestStoreCachesUntilInvalidated(t *testing.T) {
+	loads := 0
+	saved := map[string]string{ContactEmail: "hello@example.com", SiteName: ""}
+	var loadErr error
+	store := newStore(func(ctx context.Context) (map[string]string, error) {
+		loads++
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		copied := make(map[string]string, len(saved))
+		for k, v := range saved {
+			copied[k] = v
+		}
+		return copied, nil
+	}, time.Minute)
+	now := time.Date(2026, 2, 5, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	values := store.Values(ctx)
+	assert.Equal(t, "hello@example.com", values.ContactEmail())
+	assert.Equal(t, "Logan's 3D Creations", values.SiteName(), "blank required settings keep their default")
+	assert.Equal(t, "715-703-3768", values.ContactPhone(), "unsaved settings use their default")
+
+	saved[ContactEmail] = "orders@example.com"
+	assert.Equal(t, "hello@example.com", store.Values(ctx).ContactEmail(), "cached until the TTL passes")
+	store.Invalidate()
+	assert.Equal(t, "orders@example.com", store.Values(ctx).ContactEmail())
+	assert.Equal(t, 2, loads)
+
+	// A failed reload keeps the last good values
+	loadErr = errors.New("database is locked")
+	now = now.Add(time.Minute)
+	assert.Equal(t, "orders@example.com", store.Values(ctx).ContactEmail())
+	assert.Equal(t, 3, loads)
+
+	// and before anything has loaded, the defaults
+	empty := newStore(func(ctx context.Context) (map[string]string, error) {
+		return nil, loadErr
+	}, time.Minute)
+	assert.Equal(t, Defaults(), empty.Values(ctx))
+}
@@ -0,0 +1,183 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EasyPost/easypost-go/v5"
+	"github.com/loganlanou/logans3d-v4/internal/cache"
+)
+
+// Address verification statuses, as stored on a shipping selection
+const (
+	// AddressVerified is an address the carrier delivers to, as it was entered
+	AddressVerified = "verified"
+	// AddressCorrected is deliverable once corrected, e.g. a misspelled street or wrong ZIP
+	AddressCorrected = "corrected"
+	// AddressUndeliverable is one the carrier can't find or deliver to; it isn't rated
+	AddressUndeliverable = "undeliverable"
+	// AddressUnverified couldn't be checked, because EasyPost was unreachable or doesn't
+	// verify addresses in that country. It's rated as entered.
+	AddressUnverified = "unverified"
+)
+
+// addressVerificationTTL is how long a verification is reused for the same address,
+// so quoting rates and saving the selection don't each call EasyPost
+const addressVerificationTTL = time.Hour
+
+// AddressVerification is what EasyPost made of a destination
+type AddressVerification struct {
+	Status string `json:"status"`
+	// Address is the normalized address, e.g. upper case with the ZIP+4, or the
+	// address as entered when it couldn't be normalized
+	Address Address `json:"address"`
+	// Suggestion is the correction to offer as "did you mean", set when Status is
+	// AddressCorrected
+	Suggestion *Address `json:"suggestion,omitempty"`
+	// Messages say what's wrong with an undeliverable address
+	Messages    []string  `json:"messages,omitempty"`
+	Residential bool      `json:"residential"`
+	VerifiedAt  time.Time `json:"verified_at"`
+}
+
+// Deliverable reports whether the address can be rated
+func (v *AddressVerification) Deliverable() bool {
+	return v.Status != AddressUndeliverable
+}
+
+// Message is the undeliverable reasons as one sentence for the customer
+func (v *AddressVerification) Message() string {
+	if len(v.Messages) == 0 {
+		return "We couldn't find that address. Please check the street, city and ZIP code."
+	}
+	return strings.Join(v.Messages, ". ")
+}
+
+// VerifyAddressWithContext asks EasyPost whether it can deliver to addr and how it
+// would write it. Without an API key every address is taken as verified.
+func (c *EasyPostClient) VerifyAddressWithContext(ctx context.Context, addr Address) (*AddressVerification, error) {
+	if c.IsUsingMockData() {
+		return &AddressVerification{Status: AddressVerified, Address: addr, VerifiedAt: time.Now()}, nil
+	}
+
+	verified, err := c.client.CreateAddressWithContext(ctx, &easypost.Address{
+		Name:    addr.Name,
+		Street1: addr.AddressLine1,
+		Street2: addr.AddressLine2,
+		City:    addr.CityLocality,
+		State:   addr.StateProvince,
+		Zip:     addr.PostalCode,
+		Country: addr.CountryCode,
+		Phone:   addr.Phone,
+	}, &easypost.CreateAddressOptions{Verify: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify address: %w", err)
+	}
+	return verificationFromEasyPost(addr, verified, time.Now()), nil
+}
+
+// verificationFromEasyPost reads the delivery verification off an address EasyPost
+// created with verify set
+func verificationFromEasyPost(entered Address, verified *easypost.Address, now time.Time) *AddressVerification {
+	result := &AddressVerification{Status: AddressUnverified, Address: entered, VerifiedAt: now}
+	if verified == nil || verified.Verifications == nil || verified.Verifications.Delivery == nil {
+		return result
+	}
+
+	delivery := verified.Verifications.Delivery
+	if !delivery.Success {
+		result.Status = AddressUndeliverable
+		for _, fieldErr := range delivery.Errors {
+			if fieldErr == nil || fieldErr.Message == "" {
+				continue
+			}
+			message := strings.TrimSuffix(fieldErr.Message, ".")
+			if fieldErr.Suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %s?)", fieldErr.Suggestion)
+			}
+			result.Messages = append(result.Messages, message)
+		}
+		return result
+	}
+
+	normalized := addressFromEasyPost(verified)
+	// EasyPost doesn't send back what it wasn't given to verify
+	normalized.Name = entered.Name
+	normalized.Phone = entered.Phone
+	if normalized.CountryCode == "" {
+		normalized.CountryCode = entered.CountryCode
+	}
+	if verified.Residential {
+		normalized.AddressResidentialIndicator = "yes"
+	} else {
+		normalized.AddressResidentialIndicator = "no"
+	}
+
+	result.Address = normalized
+	result.Residential = verified.Residential
+	result.Status = AddressVerified
+	if addressCorrected(entered, normalized) {
+		result.Status = AddressCorrected
+		suggestion := normalized
+		result.Suggestion = &suggestion
+	}
+	return result
+}
+
+// addressCorrected reports whether normalizing changed more than the case, spacing,
+// punctuation or ZIP+4 of an address, which is worth asking the customer about
+func addressCorrected(entered, normalized Address) bool {
+	return addressPart(entered.AddressLine1) != addressPart(normalized.AddressLine1) ||
+		addressPart(entered.AddressLine2) != addressPart(normalized.AddressLine2) ||
+		addressPart(entered.CityLocality) != addressPart(normalized.CityLocality) ||
+		addressPart(entered.StateProvince) != addressPart(normalized.StateProvince) ||
+		zip5(entered.PostalCode) != zip5(normalized.PostalCode)
+}
+
+// addressPart is an address field with only its letters and digits, upper case
+func addressPart(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// zip5 is a US ZIP code without its +4, or any other postal code as it is
+func zip5(postalCode string) string {
+	postalCode = strings.TrimSpace(postalCode)
+	if len(postalCode) == 10 && postalCode[5] == '-' {
+		return postalCode[:5]
+	}
+	return addressPart(postalCode)
+}
+
+// addressKey identifies an address in the verification cache
+func addressKey(addr Address) string {
+	return "address:" + strings.Join([]string{
+		addressPart(addr.AddressLine1),
+		addressPart(addr.AddressLine2),
+		addressPart(addr.CityLocality),
+		addressPart(addr.StateProvince),
+		addressPart(addr.PostalCode),
+		addressPart(addr.CountryCode),
+	}, "|")
+}
+
+// VerifyAddress checks a destination before it's rated, reusing a verification of
+// the same address from the last hour. When EasyPost can't be reached the address
+// comes back AddressUnverified along with the error, so checkout isn't held up by an
+// outage.
+func (s *ShippingService) VerifyAddress(ctx context.Context, addr Address) (*AddressVerification, error) {
+	verification, err := cache.GetOrLoad(s.verifications, addressKey(addr), func() (*AddressVerification, error) {
+		return s.client.VerifyAddressWithContext(ctx, addr)
+	})
+	if err != nil {
+		return &AddressVerification{Status: AddressUnverified, Address: addr, VerifiedAt: time.Now()}, err
+	}
+	return verification, nil
+}
@@ -0,0 +1,78 @@
+package shipping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EasyPost/easypost-go/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationFromEasyPost(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entered := Address{
+		Name:          "Jane Doe",
+		AddressLine1:  "417 montgomery st.",
+		AddressLine2:  "floor 5",
+		CityLocality:  "San Francisco",
+		StateProvince: "ca",
+		PostalCode:    "94104",
+		CountryCode:   "US",
+	}
+	delivered := func(street1, zip string) *easypost.Address {
+		return &easypost.Address{
+			Street1:       street1,
+			Street2:       "FL 5",
+			City:          "SAN FRANCISCO",
+			State:         "CA",
+			Zip:           zip,
+			Country:       "US",
+			Verifications: &easypost.AddressVerifications{Delivery: &easypost.AddressVerification{Success: true}},
+		}
+	}
+
+	// The ZIP+4, case and punctuation aren't worth asking about, but "FL" for "floor" is
+	verification := verificationFromEasyPost(entered, delivered("417 MONTGOMERY ST", "94104-1129"), now)
+	assert.Equal(t, AddressCorrected, verification.Status)
+
+	entered.AddressLine2 = "Fl 5"
+	verification = verificationFromEasyPost(entered, delivered("417 MONTGOMERY ST", "94104-1129"), now)
+	assert.Equal(t, AddressVerified, verification.Status)
+	assert.Nil(t, verification.Suggestion)
+	assert.Equal(t, "94104-1129", verification.Address.PostalCode)
+	assert.Equal(t, "Jane Doe", verification.Address.Name, "the name is kept")
+	assert.Equal(t, now, verification.VerifiedAt)
+	assert.True(t, verification.Deliverable())
+
+	verification = verificationFromEasyPost(entered, delivered("417 MONTGOMERY ST", "94111-1129"), now)
+	assert.Equal(t, AddressCorrected, verification.Status)
+	require.NotNil(t, verification.Suggestion)
+	assert.Equal(t, "94111-1129", verification.Suggestion.PostalCode)
+
+	undeliverable := &easypost.Address{Verifications: &easypost.AddressVerifications{Delivery: &easypost.AddressVerification{
+		Errors: []*easypost.AddressVerificationFieldError{
+			{Code: "E.ADDRESS.NOT_FOUND", Field: "address", Message: "Address not found."},
+			{Code: "E.HOUSE_NUMBER.INVALID", Field: "street1", Message: "Invalid primary street number", Suggestion: "417"},
+		},
+	}}}
+	verification = verificationFromEasyPost(entered, undeliverable, now)
+	assert.Equal(t, AddressUndeliverable, verification.Status)
+	assert.False(t, verification.Deliverable())
+	assert.Equal(t, "Address not found. Invalid primary street number (did you mean 417?)", verification.Message())
+	assert.Equal(t, entered, verification.Address)
+
+	// Countries EasyPost can't verify in come back without a delivery verification
+	verification = verificationFromEasyPost(entered, &easypost.Address{}, now)
+	assert.Equal(t, AddressUnverified, verification.Status)
+	assert.True(t, verification.Deliverable())
+}
+
+func TestAddressKeyIgnoresFormatting(t *testing.T) {
+	a := Address{AddressLine1: "417 Montgomery St.", CityLocality: "San Francisco", StateProvince: "CA", PostalCode: "94104", CountryCode: "US"}
+	b := Address{AddressLine1: "417 MONTGOMERY ST", CityLocality: "san francisco", StateProvince: "ca", PostalCode: " 94104", CountryCode: "us", Name: "Jane"}
+	assert.Equal(t, addressKey(a), addressKey(b))
+
+	b.AddressLine1 = "418 Montgomery St"
+	assert.NotEqual(t, addressKey(a), addressKey(b))
+}
@@ -64,6 +64,12 @@ type ShipFromAddress struct {
 type RatePreferences struct {
 	PresentTopN int    `json:"present_top_n"`
 	Sort        string `json:"sort"`
+	// FreeShippingThresholdCents waives the shipping charge once the cart subtotal
+	// reaches it; zero turns free shipping off
+	FreeShippingThresholdCents int64 `json:"free_shipping_threshold_cents"`
+	// LabelServices limits the rates batch label buying picks from, like "USPS
+	// GroundAdvantage"; empty allows every service
+	LabelServices []string `json:"label_services,omitempty"`
 }
 
 type LabelsConfig struct {
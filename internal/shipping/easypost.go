@@ -1,12 +1,15 @@
 package shipping
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/EasyPost/easypost-go/v5"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 )
 
 type EasyPostClient struct {
@@ -60,6 +63,8 @@ func NewEasyPostClient() *EasyPostClient {
 	}
 
 	client := easypost.New(apiKey)
+	// EasyPost sets the timeout on whichever client it's given
+	client.Client = &http.Client{Transport: tracing.Transport(nil, tracing.ComponentEasyPost)}
 	return &EasyPostClient{
 		client: client,
 	}
@@ -71,6 +76,11 @@ func (c *EasyPostClient) IsUsingMockData() bool {
 
 // GetRates retrieves shipping rates for a given shipment
 func (c *EasyPostClient) GetRates(fromAddr Address, toAddr Address, pkg Package, carrierAccountIDs []string) ([]Rate, error) {
+	return c.GetRatesWithContext(context.Background(), fromAddr, toAddr, pkg, carrierAccountIDs)
+}
+
+// GetRatesWithContext is GetRates with the EasyPost request made under ctx
+func (c *EasyPostClient) GetRatesWithContext(ctx context.Context, fromAddr Address, toAddr Address, pkg Package, carrierAccountIDs []string) ([]Rate, error) {
 	if c.IsUsingMockData() {
 		return c.getMockRates(pkg), nil
 	}
@@ -129,7 +139,7 @@ func (c *EasyPostClient) GetRates(fromAddr Address, toAddr Address, pkg Package,
 		from.City, from.Zip, to.City, to.Zip, weightLbs,
 		pkg.Dimensions.Length, pkg.Dimensions.Width, pkg.Dimensions.Height)
 
-	createdShipment, err := c.client.CreateShipment(shipment)
+	createdShipment, err := c.client.CreateShipmentWithContext(ctx, shipment)
 	if err != nil {
 		fmt.Printf("EasyPost API error creating shipment: %v\n", err)
 		return nil, fmt.Errorf("failed to create shipment: %w", err)
@@ -364,14 +374,15 @@ func (c *EasyPostClient) GetShipmentTracking(shipmentID string) (*ShipmentTracki
 
 	// Build tracking URL if we have carrier and tracking number
 	if tracking.TrackingNumber != "" && tracking.TrackingURL == "" {
-		tracking.TrackingURL = buildTrackingURL(tracking.Carrier, tracking.TrackingNumber)
+		tracking.TrackingURL = TrackingURL(tracking.Carrier, tracking.TrackingNumber)
 	}
 
 	return tracking, nil
 }
 
-// buildTrackingURL constructs a tracking URL based on carrier and tracking number
-func buildTrackingURL(carrier, trackingNumber string) string {
+// TrackingURL is the carrier's tracking page for a tracking number, or "" for carriers
+// it doesn't know
+func TrackingURL(carrier, trackingNumber string) string {
 	switch carrier {
 	case "USPS":
 		return fmt.Sprintf("https://tools.usps.com/go/TrackConfirmAction?tLabels=%s", trackingNumber)
@@ -435,6 +446,101 @@ func (c *EasyPostClient) RefreshShipmentRates(shipmentID string) ([]Rate, error)
 	return rates, nil
 }
 
+// RatesFromAddress creates a new shipment with the same destination and parcel as an
+// existing one but leaving from fromAddr, and returns its rates. The rates carry the
+// new shipment's ID, which is what a label must then be bought against.
+func (c *EasyPostClient) RatesFromAddress(shipmentID string, fromAddr Address, carrierAccountIDs []string) ([]Rate, error) {
+	if c.IsUsingMockData() {
+		return c.RefreshShipmentRates(shipmentID)
+	}
+
+	shipment, err := c.GetShipment(shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.ToAddress == nil || shipment.Parcel == nil {
+		return nil, fmt.Errorf("shipment %s has no destination or parcel", shipmentID)
+	}
+
+	return c.GetRates(fromAddr, addressFromEasyPost(shipment.ToAddress), packageFromParcel(shipment.Parcel), carrierAccountIDs)
+}
+
+// BoxRates creates a shipment to an existing one's destination for another box of the
+// same order and returns its rates. from and box replace the original shipment's
+// origin and parcel when given. As with RatesFromAddress, the label is bought against
+// the new shipment's ID.
+func (c *EasyPostClient) BoxRates(shipmentID string, from *Address, box *Package, carrierAccountIDs []string) ([]Rate, error) {
+	if c.IsUsingMockData() {
+		return c.RefreshShipmentRates(shipmentID)
+	}
+
+	shipment, err := c.GetShipment(shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.ToAddress == nil || shipment.FromAddress == nil || shipment.Parcel == nil {
+		return nil, fmt.Errorf("shipment %s has no addresses or parcel", shipmentID)
+	}
+
+	fromAddr := addressFromEasyPost(shipment.FromAddress)
+	if from != nil {
+		fromAddr = *from
+	}
+	pkg := packageFromParcel(shipment.Parcel)
+	if box != nil {
+		pkg = *box
+	}
+	return c.GetRates(fromAddr, addressFromEasyPost(shipment.ToAddress), pkg, carrierAccountIDs)
+}
+
+// ReturnRates creates a shipment for sending back the parcel of an existing one, from
+// its destination to where it came from, and returns its rates. As with
+// RatesFromAddress, a return label is bought against the new shipment's ID.
+func (c *EasyPostClient) ReturnRates(shipmentID string, carrierAccountIDs []string) ([]Rate, error) {
+	if c.IsUsingMockData() {
+		return c.RefreshShipmentRates(shipmentID)
+	}
+
+	shipment, err := c.GetShipment(shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.ToAddress == nil || shipment.FromAddress == nil || shipment.Parcel == nil {
+		return nil, fmt.Errorf("shipment %s has no addresses or parcel", shipmentID)
+	}
+
+	return c.GetRates(addressFromEasyPost(shipment.ToAddress), addressFromEasyPost(shipment.FromAddress), packageFromParcel(shipment.Parcel), carrierAccountIDs)
+}
+
+func addressFromEasyPost(addr *easypost.Address) Address {
+	return Address{
+		Name:          addr.Name,
+		Phone:         addr.Phone,
+		AddressLine1:  addr.Street1,
+		AddressLine2:  addr.Street2,
+		CityLocality:  addr.City,
+		StateProvince: addr.State,
+		PostalCode:    addr.Zip,
+		CountryCode:   addr.Country,
+	}
+}
+
+// packageFromParcel turns a stored parcel back into a Package. GetRates sends the
+// weight through unchanged unless it's in ounces, so the parcel weight goes back
+// exactly as it was created.
+func packageFromParcel(parcel *easypost.Parcel) Package {
+	return Package{
+		PackageCode: "package",
+		Weight:      Weight{Value: parcel.Weight, Unit: "pound"},
+		Dimensions: Dimensions{
+			Length: parcel.Length,
+			Width:  parcel.Width,
+			Height: parcel.Height,
+			Unit:   "inch",
+		},
+	}
+}
+
 // ShipmentTracking contains tracking information for a shipment
 type ShipmentTracking struct {
 	TrackingNumber string
@@ -0,0 +1,45 @@
+package shipping
+
+import "strings"
+
+// labelServiceMatches reports whether a rate is one of the services named in the label
+// preferences. A service is named by its code ("Priority"), its carrier and code
+// ("USPS Priority") or its full name ("USPS Priority Mail"), ignoring case.
+func labelServiceMatches(rate Rate, services []string) bool {
+	if len(services) == 0 {
+		return true
+	}
+	names := []string{
+		rate.ServiceCode,
+		rate.CarrierCode + " " + rate.ServiceCode,
+		rate.ServiceType,
+	}
+	for _, service := range services {
+		service = strings.TrimSpace(service)
+		for _, name := range names {
+			if service != "" && strings.EqualFold(service, strings.TrimSpace(name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheapestLabelRate picks the rate to buy when labels are bought in a batch without
+// looking at the rates: the cheapest of the preferred label services, or of every
+// rate when none are set. Ties go to the faster rate. It returns false when no rate
+// qualifies.
+func (p RatePreferences) CheapestLabelRate(rates []Rate) (Rate, bool) {
+	var best Rate
+	found := false
+	for _, rate := range rates {
+		if len(rate.ErrorMessages) > 0 || !labelServiceMatches(rate, p.LabelServices) {
+			continue
+		}
+		if !found || rate.ShippingAmount.Amount < best.ShippingAmount.Amount ||
+			(rate.ShippingAmount.Amount == best.ShippingAmount.Amount && rate.DeliveryDays < best.DeliveryDays) {
+			best, found = rate, true
+		}
+	}
+	return best, found
+}
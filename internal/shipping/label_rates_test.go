@@ -0,0 +1,35 @@
+package shipping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheapestLabelRate(t *testing.T) {
+	rates := []Rate{
+		{RateID: "priority", CarrierCode: "USPS", ServiceCode: "Priority", ServiceType: "USPS Priority Mail", ShippingAmount: Amount{Amount: 9.80}, DeliveryDays: 2},
+		{RateID: "ground", CarrierCode: "USPS", ServiceCode: "GroundAdvantage", ServiceType: "USPS Ground Advantage", ShippingAmount: Amount{Amount: 5.30}, DeliveryDays: 5},
+		{RateID: "ups", CarrierCode: "UPS", ServiceCode: "Ground", ServiceType: "UPS Ground", ShippingAmount: Amount{Amount: 7.80}, DeliveryDays: 4},
+		{RateID: "broken", CarrierCode: "FedEx", ServiceCode: "Home", ShippingAmount: Amount{Amount: 1.00}, ErrorMessages: []string{"address not serviceable"}},
+	}
+
+	rate, ok := RatePreferences{}.CheapestLabelRate(rates)
+	assert.True(t, ok)
+	assert.Equal(t, "ground", rate.RateID, "any service when none are preferred, skipping rates with errors")
+
+	rate, ok = RatePreferences{LabelServices: []string{"ups ground", " Priority "}}.CheapestLabelRate(rates)
+	assert.True(t, ok)
+	assert.Equal(t, "ups", rate.RateID, "carrier and code, ignoring case and spaces")
+
+	rate, ok = RatePreferences{LabelServices: []string{"USPS Priority Mail"}}.CheapestLabelRate(rates)
+	assert.True(t, ok)
+	assert.Equal(t, "priority", rate.RateID, "full service name")
+
+	_, ok = RatePreferences{LabelServices: []string{"Express"}}.CheapestLabelRate(rates)
+	assert.False(t, ok)
+
+	tied := append(rates, Rate{RateID: "fast", CarrierCode: "USPS", ServiceCode: "GroundAdvantage", ShippingAmount: Amount{Amount: 5.30}, DeliveryDays: 3})
+	rate, _ = RatePreferences{}.CheapestLabelRate(tied)
+	assert.Equal(t, "fast", rate.RateID, "ties go to the faster rate")
+}
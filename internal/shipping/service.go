@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/loganlanou/logans3d-v4/internal/cache"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
 
@@ -45,6 +46,7 @@ type ShippingService struct {
 	carrierMap                 map[string]Carrier // Maps carrier ID to carrier info
 	carrierAccountsByCadott    []string           // USPS carrier account IDs for Cadott (54727)
 	carrierAccountsByEauClaire []string           // UPS/FedEx carrier account IDs for Eau Claire (54701)
+	verifications              *cache.Cache       // Recent address verifications, see VerifyAddress
 }
 
 func NewShippingService(config *ShippingConfig, queries *db.Queries) (*ShippingService, error) {
@@ -52,9 +54,10 @@ func NewShippingService(config *ShippingConfig, queries *db.Queries) (*ShippingS
 	packer := NewPacker(config)
 
 	service := &ShippingService{
-		config: config,
-		client: client,
-		packer: packer,
+		config:        config,
+		client:        client,
+		packer:        packer,
+		verifications: cache.New(addressVerificationTTL),
 	}
 
 	if err := service.loadCarrierIDs(); err != nil {
@@ -122,6 +125,12 @@ func (s *ShippingService) loadCarrierAccounts(queries *db.Queries) error {
 }
 
 func (s *ShippingService) GetShippingQuote(req *ShippingQuoteRequest) (*ShippingQuoteResponse, error) {
+	return s.GetShippingQuoteWithContext(context.Background(), req)
+}
+
+// GetShippingQuoteWithContext is GetShippingQuote with the EasyPost calls made under
+// ctx, so they're traced as part of the request asking for the quote
+func (s *ShippingService) GetShippingQuoteWithContext(ctx context.Context, req *ShippingQuoteRequest) (*ShippingQuoteResponse, error) {
 	slog.Debug("GetShippingQuote: Starting shipping quote calculation",
 		"small_count", req.ItemCounts.Small,
 		"medium_count", req.ItemCounts.Medium,
@@ -145,7 +154,7 @@ func (s *ShippingService) GetShippingQuote(req *ShippingQuoteRequest) (*Shipping
 	// Get rates for each box - ALL boxes must succeed or we fail the quote
 	var boxRates []BoxRatesResult
 	for boxIdx, boxSelection := range packingSolution.Boxes {
-		rates, err := s.getRatesForBox(boxSelection, req.ShipTo)
+		rates, err := s.getRatesForBox(ctx, boxSelection, req.ShipTo)
 		if err != nil {
 			slog.Error("GetShippingQuote: Failed to get rates for box",
 				"box_index", boxIdx,
@@ -186,7 +195,7 @@ func (s *ShippingService) GetShippingQuote(req *ShippingQuoteRequest) (*Shipping
 	return response, nil
 }
 
-func (s *ShippingService) getRatesForBox(boxSelection BoxSelection, shipTo Address) ([]Rate, error) {
+func (s *ShippingService) getRatesForBox(ctx context.Context, boxSelection BoxSelection, shipTo Address) ([]Rate, error) {
 	// If using mock data (no API credentials), return mock rates
 	if s.client.IsUsingMockData() {
 		return s.getMockRates(boxSelection, shipTo), nil
@@ -196,7 +205,7 @@ func (s *ShippingService) getRatesForBox(boxSelection BoxSelection, shipTo Addre
 
 	// Get rates for USPS from Cadott, WI (54727) using only USPS carrier accounts
 	if len(s.carrierAccountsByCadott) > 0 {
-		uspsRates, err := s.getRatesForCarriers(s.carrierAccountsByCadott, boxSelection, shipTo, s.addressFromConfigUSPS())
+		uspsRates, err := s.getRatesForCarriers(ctx, s.carrierAccountsByCadott, boxSelection, shipTo, s.addressFromConfigUSPS())
 		if err == nil {
 			allRates = append(allRates, uspsRates...)
 		}
@@ -204,7 +213,7 @@ func (s *ShippingService) getRatesForBox(boxSelection BoxSelection, shipTo Addre
 
 	// Get rates for UPS/FedEx from Eau Claire, WI (54701) using only UPS/FedEx carrier accounts
 	if len(s.carrierAccountsByEauClaire) > 0 {
-		otherRates, err := s.getRatesForCarriers(s.carrierAccountsByEauClaire, boxSelection, shipTo, s.addressFromConfigOther())
+		otherRates, err := s.getRatesForCarriers(ctx, s.carrierAccountsByEauClaire, boxSelection, shipTo, s.addressFromConfigOther())
 		if err == nil {
 			allRates = append(allRates, otherRates...)
 		}
@@ -218,7 +227,7 @@ func (s *ShippingService) getRatesForBox(boxSelection BoxSelection, shipTo Addre
 }
 
 // getRatesForCarriers gets rates for specific carriers from a specific origin
-func (s *ShippingService) getRatesForCarriers(carrierIDs []string, boxSelection BoxSelection, shipTo Address, shipFrom Address) ([]Rate, error) {
+func (s *ShippingService) getRatesForCarriers(ctx context.Context, carrierIDs []string, boxSelection BoxSelection, shipTo Address, shipFrom Address) ([]Rate, error) {
 	slog.Debug("getRatesForCarriers: Requesting rates",
 		"box_sku", boxSelection.Box.SKU,
 		"box_name", boxSelection.Box.Name,
@@ -250,7 +259,7 @@ func (s *ShippingService) getRatesForCarriers(carrierIDs []string, boxSelection
 	}
 
 	// Get rates from EasyPost with specific carrier accounts
-	rates, err := s.client.GetRates(shipFrom, shipTo, pkg, carrierIDs)
+	rates, err := s.client.GetRatesWithContext(ctx, shipFrom, shipTo, pkg, carrierIDs)
 	if err != nil {
 		slog.Debug("getRatesForCarriers: Failed to get rates", "error", err)
 		return nil, fmt.Errorf("failed to get rates: %w", err)
@@ -504,20 +513,6 @@ func (s *ShippingService) RefreshCarriers() error {
 	return s.loadCarrierIDs()
 }
 
-func (s *ShippingService) ValidateAddress(addr Address) error {
-	// EasyPost validates addresses during rate retrieval
-	// We'll do a simple rate check with minimal package to validate
-	pkg := Package{
-		PackageCode: "package",
-		Weight:      Weight{Value: 1, Unit: "ounce"},
-		Dimensions:  Dimensions{Length: 1, Width: 1, Height: 1, Unit: "inch"},
-	}
-
-	// Use empty carrier accounts list - we just want to validate the address
-	_, err := s.client.GetRates(s.addressFromConfig(), addr, pkg, []string{})
-	return err
-}
-
 // UpdateConfig updates the shipping service configuration and recreates the packer
 func (s *ShippingService) UpdateConfig(config *ShippingConfig) {
 	slog.Info("ShippingService: Configuration reloaded",
@@ -528,6 +523,18 @@ func (s *ShippingService) UpdateConfig(config *ShippingConfig) {
 	s.packer = NewPacker(config)
 }
 
+// FreeShippingThresholdCents returns the cart subtotal at which shipping is free, or
+// zero when free shipping is off
+func (s *ShippingService) FreeShippingThresholdCents() int64 {
+	return s.config.Shipping.RatePreferences.FreeShippingThresholdCents
+}
+
+// CheapestLabelRate picks the rate batch label buying uses, following the configured
+// label services
+func (s *ShippingService) CheapestLabelRate(rates []Rate) (Rate, bool) {
+	return s.config.Shipping.RatePreferences.CheapestLabelRate(rates)
+}
+
 // GetShipmentTracking retrieves tracking info for a shipment from EasyPost
 func (s *ShippingService) GetShipmentTracking(shipmentID string) (*ShipmentTracking, error) {
 	return s.client.GetShipmentTracking(shipmentID)
@@ -538,6 +545,28 @@ func (s *ShippingService) RefreshShipmentRates(shipmentID string) ([]Rate, error
 	return s.client.RefreshShipmentRates(shipmentID)
 }
 
+// RatesFromAddress re-quotes an existing shipment leaving from another address, such as
+// the inventory location an order's stock is at. All carrier accounts are offered since
+// the usual per-carrier origins don't apply.
+func (s *ShippingService) RatesFromAddress(shipmentID string, from Address) ([]Rate, error) {
+	carrierIDs := append(append([]string{}, s.carrierAccountsByCadott...), s.carrierAccountsByEauClaire...)
+	return s.client.RatesFromAddress(shipmentID, from, carrierIDs)
+}
+
+// BoxRates quotes another box of an order that already has a label, to the same
+// address. from and box default to the existing shipment's origin and parcel.
+func (s *ShippingService) BoxRates(shipmentID string, from *Address, box *Package) ([]Rate, error) {
+	carrierIDs := append(append([]string{}, s.carrierAccountsByCadott...), s.carrierAccountsByEauClaire...)
+	return s.client.BoxRates(shipmentID, from, box, carrierIDs)
+}
+
+// ReturnRates quotes sending an order's parcel back from the customer to the address it
+// was shipped from, on any carrier account
+func (s *ShippingService) ReturnRates(shipmentID string) ([]Rate, error) {
+	carrierIDs := append(append([]string{}, s.carrierAccountsByCadott...), s.carrierAccountsByEauClaire...)
+	return s.client.ReturnRates(shipmentID, carrierIDs)
+}
+
 // GetDefaultItemWeights returns the configured default weights per category (in oz)
 func (s *ShippingService) GetDefaultItemWeights() map[string]float64 {
 	weights := make(map[string]float64)
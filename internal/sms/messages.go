@@ -0,0 +1,58 @@
+package sms
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// MessageData fills an order update text
+type MessageData struct {
+	SiteName       string
+	OrderNumber    string
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+}
+
+// OrderMessageData is the text details for an order
+func OrderMessageData(order db.Order, siteName string) MessageData {
+	number := order.ID
+	if len(number) > 8 {
+		number = number[:8]
+	}
+	return MessageData{
+		SiteName:       siteName,
+		OrderNumber:    number,
+		Carrier:        order.Carrier.String,
+		TrackingNumber: order.TrackingNumber.String,
+		TrackingURL:    order.TrackingUrl.String,
+	}
+}
+
+// The first text to a number says how to stop them, as carriers require
+var messageTemplates = map[string]*template.Template{
+	EventOrderConfirmation: template.Must(template.New(EventOrderConfirmation).Parse(
+		`{{.SiteName}}: Thanks for your order #{{.OrderNumber}}! We'll text you when it ships. Reply STOP to opt out.`)),
+	EventShipped: template.Must(template.New(EventShipped).Parse(
+		`{{.SiteName}}: Your order #{{.OrderNumber}} has shipped{{if .Carrier}} with {{.Carrier}}{{end}}.` +
+			`{{if .TrackingURL}} Track it: {{.TrackingURL}}{{else if .TrackingNumber}} Tracking number: {{.TrackingNumber}}{{end}}` +
+			` Reply STOP to opt out.`)),
+	EventDelivered: template.Must(template.New(EventDelivered).Parse(
+		`{{.SiteName}}: Your order #{{.OrderNumber}} has been delivered. Enjoy! Reply STOP to opt out.`)),
+}
+
+// Render writes the text for an order update
+func Render(event string, data MessageData) (string, error) {
+	tmpl, ok := messageTemplates[event]
+	if !ok {
+		return "", fmt.Errorf("no text for %q", event)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s text: %w", event, err)
+	}
+	return buf.String(), nil
+}
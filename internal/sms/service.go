@@ -0,0 +1,162 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/oklog/ulid/v2"
+)
+
+// eventSettings are the site settings that turn each order update text on
+var eventSettings = map[string]string{
+	EventOrderConfirmation: settings.SMSOrderConfirmation,
+	EventShipped:           settings.SMSShipped,
+	EventDelivered:         settings.SMSDelivered,
+}
+
+// Offered reports whether checkout should ask customers for texts: Twilio is set up
+// and at least one update is turned on
+func Offered(site settings.Values) bool {
+	if TwilioFromEnv() == nil {
+		return false
+	}
+	for _, key := range eventSettings {
+		if site.Bool(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Service texts order updates and handles replies to the store's number
+type Service struct {
+	twilio  *Twilio
+	queries *db.Queries
+}
+
+// NewService creates an SMS service from the TWILIO_* environment
+func NewService(queries *db.Queries) *Service {
+	return &Service{twilio: TwilioFromEnv(), queries: queries}
+}
+
+// Configured reports whether Twilio credentials are set
+func (s *Service) Configured() bool {
+	return s.twilio != nil
+}
+
+// SendOrderUpdate texts the customer an order update, if they asked for texts at
+// checkout and the update is turned on in the site settings. Each update goes to an
+// order once; a failed send is logged and can be tried again.
+func (s *Service) SendOrderUpdate(ctx context.Context, order db.Order, event string) error {
+	key, ok := eventSettings[event]
+	if !ok {
+		return fmt.Errorf("unknown sms event %q", event)
+	}
+	if order.SmsPhone == "" {
+		return nil
+	}
+
+	site := settings.For(s.queries).Values(ctx)
+	if !site.Bool(key) {
+		return nil
+	}
+	if s.twilio == nil {
+		return ErrNotConfigured
+	}
+
+	optedOut, err := s.queries.CountSMSOptOut(ctx, order.SmsPhone)
+	if err != nil {
+		return fmt.Errorf("failed to check sms opt-out: %w", err)
+	}
+	if optedOut > 0 {
+		return ErrOptedOut
+	}
+
+	sent, err := s.queries.CountSentSMSForOrderEvent(ctx, db.CountSentSMSForOrderEventParams{
+		OrderID: order.ID,
+		Event:   event,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check sent texts: %w", err)
+	}
+	if sent > 0 {
+		return nil
+	}
+
+	body, err := Render(event, OrderMessageData(order, site.SiteName()))
+	if err != nil {
+		return err
+	}
+
+	sid, sendErr := s.twilio.Send(ctx, order.SmsPhone, body)
+	message := db.CreateSMSMessageParams{
+		ID:                ulid.Make().String(),
+		OrderID:           order.ID,
+		Phone:             order.SmsPhone,
+		Event:             event,
+		Body:              body,
+		Status:            "sent",
+		ProviderMessageID: sid,
+	}
+	if sendErr != nil {
+		message.Status = "failed"
+		message.Error = sendErr.Error()
+	}
+	if err := s.queries.CreateSMSMessage(ctx, message); err != nil {
+		slog.Error("failed to record sms message", "error", err, "order_id", order.ID, "event", event)
+	}
+
+	// Twilio already knows this number replied STOP; remember it so we stop trying
+	var twilioErr *TwilioError
+	if errors.As(sendErr, &twilioErr) && twilioErr.Code == twilioUnsubscribedCode {
+		if err := s.queries.CreateSMSOptOut(ctx, order.SmsPhone); err != nil {
+			slog.Error("failed to record sms opt-out", "error", err)
+		}
+		return ErrOptedOut
+	}
+	if sendErr != nil {
+		return fmt.Errorf("failed to send %s text: %w", event, sendErr)
+	}
+	return nil
+}
+
+// HandleInbound records STOP and START replies from a number and returns the text to
+// reply with, if any. Twilio's own opt-out handling answers STOP and START on
+// numbers that have it turned on, so those get no reply of ours.
+func (s *Service) HandleInbound(ctx context.Context, from, body string) (string, error) {
+	phone, err := NormalizePhone(from)
+	if err != nil {
+		return "", fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+
+	switch Keyword(body) {
+	case KeywordStop:
+		if err := s.queries.CreateSMSOptOut(ctx, phone); err != nil {
+			return "", fmt.Errorf("failed to record sms opt-out: %w", err)
+		}
+		slog.Info("sms opt-out recorded", "phone", phone)
+	case KeywordStart:
+		if err := s.queries.DeleteSMSOptOut(ctx, phone); err != nil {
+			return "", fmt.Errorf("failed to clear sms opt-out: %w", err)
+		}
+		slog.Info("sms opt-in recorded", "phone", phone)
+	case KeywordHelp:
+		site := settings.For(s.queries).Values(ctx)
+		return fmt.Sprintf("%s order updates. Questions? Email %s. Reply STOP to opt out, START to opt back in.",
+			site.SiteName(), site.ContactEmail()), nil
+	}
+	return "", nil
+}
+
+// ValidateWebhook checks that a request to the inbound webhook came from Twilio
+func (s *Service) ValidateWebhook(fullURL string, params url.Values, signature string) bool {
+	if s.twilio == nil {
+		return false
+	}
+	return ValidateSignature(s.twilio.authToken, fullURL, params, signature)
+}
@@ -0,0 +1,103 @@
+// Package sms texts customers about their orders through Twilio. Customers opt in at
+// checkout with a mobile number; which updates go out is chosen in the SMS group of
+// the site settings. Replies of STOP and START to the store's number turn texts off
+// and back on for that number.
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrNotConfigured means the Twilio credentials are missing
+var ErrNotConfigured = errors.New("sms service not configured")
+
+// ErrOptedOut means the number replied STOP, so nothing was sent
+var ErrOptedOut = errors.New("recipient opted out of texts")
+
+// Order updates that can be texted
+const (
+	EventOrderConfirmation = "order_confirmation"
+	EventShipped           = "shipped"
+	EventDelivered         = "delivered"
+)
+
+// Reply keywords, as Twilio and the carriers recognize them
+const (
+	KeywordStop  = "stop"
+	KeywordStart = "start"
+	KeywordHelp  = "help"
+)
+
+var keywords = map[string]string{
+	"STOP":        KeywordStop,
+	"STOPALL":     KeywordStop,
+	"UNSUBSCRIBE": KeywordStop,
+	"CANCEL":      KeywordStop,
+	"END":         KeywordStop,
+	"QUIT":        KeywordStop,
+	"REVOKE":      KeywordStop,
+	"OPTOUT":      KeywordStop,
+	"START":       KeywordStart,
+	"UNSTOP":      KeywordStart,
+	"YES":         KeywordStart,
+	"HELP":        KeywordHelp,
+	"INFO":        KeywordHelp,
+}
+
+// Keyword reads an opt-out, opt-in or help keyword from a reply. Only a reply that
+// is just the keyword counts, so "stop by tomorrow?" isn't an opt-out.
+func Keyword(body string) string {
+	word := strings.ToUpper(strings.TrimFunc(body, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}))
+	return keywords[word]
+}
+
+// NormalizePhone puts a mobile number in E.164 form. Ten-digit numbers are taken as
+// US numbers; others need their country code.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
+
+	var digits strings.Builder
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == '-' || r == '.' || r == '(' || r == ')' || unicode.IsSpace(r):
+		default:
+			return "", fmt.Errorf("phone number can only have digits, spaces and + - ( )")
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case international && len(number) >= 8 && len(number) <= 15 && number[0] != '0':
+		return "+" + number, nil
+	case !international && len(number) == 10 && number[0] >= '2':
+		return "+1" + number, nil
+	case !international && len(number) == 11 && number[0] == '1' && number[1] >= '2':
+		return "+" + number, nil
+	}
+	return "", fmt.Errorf("enter a 10-digit US number, or + and the country code")
+}
+
+// consentMetadataKey carries the number a customer agreed to get texts on from the
+// cart to the order
+const consentMetadataKey = "sms_phone"
+
+// AddConsentToMetadata records on checkout session metadata that the customer asked
+// for texts at phone. An empty phone records nothing.
+func AddConsentToMetadata(metadata map[string]string, phone string) {
+	if phone != "" {
+		metadata[consentMetadataKey] = phone
+	}
+}
+
+// ConsentFromMetadata reads back the number a customer asked for texts on, if any
+func ConsentFromMetadata(metadata map[string]string) string {
+	return metadata[consentMetadataKey]
+}
@@ -0,0 +1,114 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	valid := map[string]string{
+		"(715) 555-0123":   "+17155550123",
+		"715.555.0123":     "+17155550123",
+		"1 715 555 0123":   "+17155550123",
+		"+1 715-555-0123":  "+17155550123",
+		"+44 20 7946 0958": "+442079460958",
+	}
+	for input, want := range valid {
+		got, err := NormalizePhone(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "555-0123", "015 555 0123", "715-555-0123 ext 4", "+0 20 7946 0958", "+1234567"} {
+		_, err := NormalizePhone(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestKeyword(t *testing.T) {
+	assert.Equal(t, KeywordStop, Keyword("STOP"))
+	assert.Equal(t, KeywordStop, Keyword(" unsubscribe. "))
+	assert.Equal(t, KeywordStart, Keyword("Start"))
+	assert.Equal(t, KeywordHelp, Keyword("help!"))
+	assert.Equal(t, "", Keyword("stop by tomorrow?"))
+	assert.Equal(t, "", Keyword("thanks"))
+}
+
+func TestRender(t *testing.T) {
+	data := MessageData{SiteName: "Logan's 3D", OrderNumber: "01HX2ABC"}
+
+	confirmation, err := Render(EventOrderConfirmation, data)
+	require.NoError(t, err)
+	assert.Contains(t, confirmation, "#01HX2ABC")
+	assert.Contains(t, confirmation, "Reply STOP to opt out")
+
+	data.Carrier = "USPS"
+	data.TrackingNumber = "9400111"
+	shipped, err := Render(EventShipped, data)
+	require.NoError(t, err)
+	assert.Contains(t, shipped, "with USPS")
+	assert.Contains(t, shipped, "Tracking number: 9400111")
+
+	data.TrackingURL = "https://tools.usps.com/go/TrackConfirmAction?tLabels=9400111"
+	shipped, err = Render(EventShipped, data)
+	require.NoError(t, err)
+	assert.Contains(t, shipped, "Track it: "+data.TrackingURL)
+	assert.NotContains(t, shipped, "Tracking number")
+
+	_, err = Render("refunded", data)
+	assert.Error(t, err)
+}
+
+func TestValidateSignature(t *testing.T) {
+	fullURL := "https://logans3dcreations.com/api/twilio/sms"
+	params := url.Values{"From": {"+17155550123"}, "Body": {"STOP"}, "MessageSid": {"SM123"}}
+	signature := computeSignature("token", fullURL, params)
+
+	assert.True(t, ValidateSignature("token", fullURL, params, signature))
+	assert.False(t, ValidateSignature("other-token", fullURL, params, signature))
+	assert.False(t, ValidateSignature("token", fullURL+"?x=1", params, signature))
+	assert.False(t, ValidateSignature("token", fullURL, url.Values{"From": {"+17155550123"}, "Body": {"START"}, "MessageSid": {"SM123"}}, signature))
+	assert.False(t, ValidateSignature("token", fullURL, params, ""))
+}
+
+func TestTwilioSend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/Accounts/AC123/Messages.json", r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "secret", pass)
+		require.NoError(t, r.ParseForm())
+
+		if r.PostForm.Get("To") == "+17155550000" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code": 21610, "message": "Attempt to send to unsubscribed recipient"}`))
+			return
+		}
+		assert.Equal(t, "+15555550100", r.PostForm.Get("From"))
+		assert.Equal(t, "hello", r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sid": "SM42"}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio("AC123", "secret", "+15555550100", "")
+	twilio.baseURL = server.URL
+
+	sid, err := twilio.Send(context.Background(), "+17155550123", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "SM42", sid)
+
+	_, err = twilio.Send(context.Background(), "+17155550000", "hello")
+	var twilioErr *TwilioError
+	require.True(t, errors.As(err, &twilioErr))
+	assert.Equal(t, twilioUnsubscribedCode, twilioErr.Code)
+	assert.Equal(t, http.StatusBadRequest, twilioErr.Status)
+}
@@ -0,0 +1,139 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// twilioUnsubscribedCode is Twilio refusing to text a number that replied STOP
+const twilioUnsubscribedCode = 21610
+
+// Twilio sends texts through Twilio's Messages API
+type Twilio struct {
+	accountSID string
+	authToken  string
+	// Texts come from the messaging service when there is one, otherwise from the number
+	from                string
+	messagingServiceSID string
+	baseURL             string
+	client              *http.Client
+}
+
+// NewTwilio returns a Twilio sender
+func NewTwilio(accountSID, authToken, from, messagingServiceSID string) *Twilio {
+	return &Twilio{
+		accountSID:          accountSID,
+		authToken:           authToken,
+		from:                from,
+		messagingServiceSID: messagingServiceSID,
+		baseURL:             twilioAPIBase,
+		client:              &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// TwilioFromEnv reads the Twilio credentials from the environment. It's nil when
+// they're incomplete.
+func TwilioFromEnv() *Twilio {
+	accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
+	authToken := os.Getenv("TWILIO_AUTH_TOKEN")
+	from := os.Getenv("TWILIO_FROM_NUMBER")
+	messagingServiceSID := os.Getenv("TWILIO_MESSAGING_SERVICE_SID")
+	if accountSID == "" || authToken == "" || (from == "" && messagingServiceSID == "") {
+		return nil
+	}
+	return NewTwilio(accountSID, authToken, from, messagingServiceSID)
+}
+
+// TwilioError is an error answer from the Twilio API
+type TwilioError struct {
+	Status  int
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *TwilioError) Error() string {
+	return fmt.Sprintf("twilio returned status %d: %d %s", e.Status, e.Code, e.Message)
+}
+
+// Send texts body to a number in E.164 form and returns Twilio's message SID
+func (t *Twilio) Send(ctx context.Context, to, body string) (string, error) {
+	form := url.Values{"To": {to}, "Body": {body}}
+	if t.messagingServiceSID != "" {
+		form.Set("MessagingServiceSid", t.messagingServiceSID)
+	} else {
+		form.Set("From", t.from)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", t.baseURL, url.PathEscape(t.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build twilio request: %w", err)
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		twilioErr := &TwilioError{Status: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(twilioErr)
+		return "", twilioErr
+	}
+
+	var message struct {
+		SID string `json:"sid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return "", fmt.Errorf("failed to decode twilio response: %w", err)
+	}
+	return message.SID, nil
+}
+
+// ValidateSignature checks the X-Twilio-Signature header on a webhook Twilio posted
+// to fullURL, the public URL configured in Twilio including any query string
+func ValidateSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	if authToken == "" || signature == "" {
+		return false
+	}
+	expected := computeSignature(authToken, fullURL, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// computeSignature is Twilio's request signature: the URL followed by each POST
+// parameter's name and value in name order, HMAC-SHA1'd with the auth token
+func computeSignature(authToken, fullURL string, params url.Values) string {
+	var payload strings.Builder
+	payload.WriteString(fullURL)
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range params[name] {
+			payload.WriteString(name)
+			payload.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(payload.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
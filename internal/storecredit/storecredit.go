@@ -0,0 +1,261 @@
+// Package storecredit keeps the store credit on customer accounts. Admin grants
+// credit for a refund, as goodwill or as a promotion, and it comes off the
+// customer's online orders at checkout without them doing anything. Every change to
+// a balance is recorded as a transaction, so both admin and the customer can see
+// where the credit came from and went.
+//
+// Like a gift card, the amount used at checkout is taken off the account when the
+// Stripe session is made, released if the session expires unpaid, and linked to the
+// order once it is paid.
+package storecredit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Transaction kinds
+const (
+	KindGrant   = "grant"
+	KindRevoke  = "revoke"
+	KindRedeem  = "redeem"
+	KindRelease = "release"
+)
+
+// KindLabel returns the admin label for a transaction kind
+func KindLabel(kind string) string {
+	switch kind {
+	case KindGrant:
+		return "Granted"
+	case KindRevoke:
+		return "Revoked"
+	case KindRedeem:
+		return "Used at checkout"
+	case KindRelease:
+		return "Released"
+	}
+	return kind
+}
+
+// Reasons credit is granted for
+const (
+	ReasonRefund    = "refund"
+	ReasonGoodwill  = "goodwill"
+	ReasonPromotion = "promotion"
+)
+
+// Reasons lists the grant reasons in the order admin offers them
+var Reasons = []string{ReasonRefund, ReasonGoodwill, ReasonPromotion}
+
+// ReasonLabel returns the label for a grant reason, as the customer sees it
+func ReasonLabel(reason string) string {
+	switch reason {
+	case ReasonRefund:
+		return "Refund"
+	case ReasonGoodwill:
+		return "Goodwill"
+	case ReasonPromotion:
+		return "Promotion"
+	}
+	return reason
+}
+
+// Error is a reason credit can't be granted, revoked or spent, worded for the person
+// doing it
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Ledger changes account balances, recording a transaction for each change. Balances
+// are changed with conditional updates, so two requests can't both spend the same
+// credit.
+type Ledger struct {
+	queries *db.Queries
+}
+
+func NewLedger(queries *db.Queries) *Ledger {
+	return &Ledger{queries: queries}
+}
+
+// Balance is the credit an account has to spend; an account never granted any has none
+func (l *Ledger) Balance(ctx context.Context, userID string) (int64, error) {
+	balance, err := l.queries.GetStoreCreditBalance(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get store credit balance: %w", err)
+	}
+	return balance, nil
+}
+
+// GrantParams describes credit being added to an account
+type GrantParams struct {
+	UserID      string
+	AmountCents int64
+	Reason      string
+	Note        string
+	// OrderID is the order a refund was for, if any
+	OrderID string
+	// AdminID is the admin granting the credit
+	AdminID string
+}
+
+// Grant adds credit to an account, returning the new balance
+func (l *Ledger) Grant(ctx context.Context, p GrantParams) (int64, error) {
+	if p.AmountCents <= 0 {
+		return 0, &Error{Message: "The amount must be more than zero"}
+	}
+	if !slices.Contains(Reasons, p.Reason) {
+		return 0, &Error{Message: "Choose why the credit is being granted"}
+	}
+	balance, err := l.queries.CreditStoreCredit(ctx, db.CreditStoreCreditParams{
+		UserID:      p.UserID,
+		AmountCents: p.AmountCents,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to credit store credit: %w", err)
+	}
+	return balance, l.record(ctx, db.CreateStoreCreditTransactionParams{
+		UserID:            p.UserID,
+		Kind:              KindGrant,
+		Reason:            p.Reason,
+		AmountCents:       p.AmountCents,
+		BalanceAfterCents: balance,
+		OrderID:           nullString(p.OrderID),
+		Note:              p.Note,
+		CreatedByUserID:   nullString(p.AdminID),
+	})
+}
+
+// Revoke takes credit back off an account, refusing to take it below zero
+func (l *Ledger) Revoke(ctx context.Context, userID string, amountCents int64, note, adminID string) (int64, error) {
+	if amountCents <= 0 {
+		return 0, &Error{Message: "The amount must be more than zero"}
+	}
+	balance, err := l.queries.DebitStoreCredit(ctx, db.DebitStoreCreditParams{
+		AmountCents: amountCents,
+		UserID:      userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &Error{Message: "The account doesn't have that much credit to revoke"}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to debit store credit: %w", err)
+	}
+	return balance, l.record(ctx, db.CreateStoreCreditTransactionParams{
+		UserID:            userID,
+		Kind:              KindRevoke,
+		AmountCents:       -amountCents,
+		BalanceAfterCents: balance,
+		Note:              note,
+		CreatedByUserID:   nullString(adminID),
+	})
+}
+
+// Hold takes amountCents off an account for a checkout session about to be made,
+// returning the transaction ID to link to the session once it exists
+func (l *Ledger) Hold(ctx context.Context, userID string, amountCents int64) (string, error) {
+	balance, err := l.queries.DebitStoreCredit(ctx, db.DebitStoreCreditParams{
+		AmountCents: amountCents,
+		UserID:      userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", &Error{Message: "Your store credit balance has changed; please try checking out again"}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to debit store credit: %w", err)
+	}
+
+	id := uuid.New().String()
+	err = l.record(ctx, db.CreateStoreCreditTransactionParams{
+		ID:                id,
+		UserID:            userID,
+		Kind:              KindRedeem,
+		AmountCents:       -amountCents,
+		BalanceAfterCents: balance,
+	})
+	if err != nil {
+		// A hold with no transaction could never be released, so undo the debit
+		if _, undoErr := l.queries.CreditStoreCredit(ctx, db.CreditStoreCreditParams{
+			UserID:      userID,
+			AmountCents: amountCents,
+		}); undoErr != nil {
+			return "", errors.Join(err, fmt.Errorf("failed to undo store credit debit: %w", undoErr))
+		}
+		return "", err
+	}
+	return id, nil
+}
+
+// LinkHold records the checkout session a hold was made for
+func (l *Ledger) LinkHold(ctx context.Context, transactionID, sessionID string) error {
+	if err := l.queries.SetStoreCreditTransactionSession(ctx, db.SetStoreCreditTransactionSessionParams{
+		CheckoutSessionID: sessionID,
+		ID:                transactionID,
+	}); err != nil {
+		return fmt.Errorf("failed to link store credit hold to session: %w", err)
+	}
+	return nil
+}
+
+// Release puts a hold's amount back on its account, for a checkout that never completed
+func (l *Ledger) Release(ctx context.Context, hold db.StoreCreditTransaction, note string) error {
+	balance, err := l.queries.CreditStoreCredit(ctx, db.CreditStoreCreditParams{
+		UserID:      hold.UserID,
+		AmountCents: -hold.AmountCents,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release store credit hold: %w", err)
+	}
+	return l.record(ctx, db.CreateStoreCreditTransactionParams{
+		UserID:            hold.UserID,
+		Kind:              KindRelease,
+		AmountCents:       -hold.AmountCents,
+		BalanceAfterCents: balance,
+		CheckoutSessionID: hold.CheckoutSessionID,
+		Note:              note,
+	})
+}
+
+// ReleaseSession releases every hold made for a checkout session that hasn't been
+// paid or released already
+func (l *Ledger) ReleaseSession(ctx context.Context, sessionID, note string) error {
+	holds, err := l.queries.ListHeldStoreCreditRedemptions(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list store credit holds: %w", err)
+	}
+	for _, hold := range holds {
+		if err := l.Release(ctx, hold, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record adds a transaction to an account's history. The balance has already
+// changed by the time it's called, so a failure here leaves the history short, not
+// the money.
+func (l *Ledger) record(ctx context.Context, p db.CreateStoreCreditTransactionParams) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	if err := l.queries.CreateStoreCreditTransaction(ctx, p); err != nil {
+		return fmt.Errorf("failed to record store credit %s transaction: %w", p.Kind, err)
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,92 @@
+package storecredit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestLedger(t *testing.T) {
+	_, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	defer cleanup()
+	ctx := context.Background()
+	ledger := NewLedger(queries)
+
+	_, err = queries.CreateUser(ctx, db.CreateUserParams{ID: "user-customer", Email: "customer@example.com", FullName: "Customer"})
+	require.NoError(t, err)
+
+	balance, err := ledger.Balance(ctx, "user-customer")
+	require.NoError(t, err)
+	assert.Zero(t, balance, "an account never granted credit has none")
+
+	var creditErr *Error
+	_, err = ledger.Grant(ctx, GrantParams{UserID: "user-customer", AmountCents: 1000, Reason: "birthday"})
+	require.True(t, errors.As(err, &creditErr), "grants need a known reason")
+	_, err = ledger.Grant(ctx, GrantParams{UserID: "user-customer", AmountCents: 0, Reason: ReasonGoodwill})
+	require.True(t, errors.As(err, &creditErr))
+
+	balance, err = ledger.Grant(ctx, GrantParams{UserID: "user-customer", AmountCents: 2500, Reason: ReasonRefund, Note: "Cracked in shipping"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2500), balance)
+	balance, err = ledger.Grant(ctx, GrantParams{UserID: "user-customer", AmountCents: 500, Reason: ReasonGoodwill})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), balance)
+
+	// A hold comes off straight away and is released when its session expires
+	holdID, err := ledger.Hold(ctx, "user-customer", 2000)
+	require.NoError(t, err)
+	require.NoError(t, ledger.LinkHold(ctx, holdID, "cs_expired"))
+	_, err = ledger.Hold(ctx, "user-customer", 2000)
+	require.True(t, errors.As(err, &creditErr), "the held credit can't be spent twice")
+
+	require.NoError(t, ledger.ReleaseSession(ctx, "cs_expired", "Checkout expired"))
+	require.NoError(t, ledger.ReleaseSession(ctx, "cs_expired", "Checkout expired"), "releasing twice is a no-op")
+	balance, err = ledger.Balance(ctx, "user-customer")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), balance)
+
+	// A paid hold stays spent
+	holdID, err = ledger.Hold(ctx, "user-customer", 1200)
+	require.NoError(t, err)
+	require.NoError(t, ledger.LinkHold(ctx, holdID, "cs_paid"))
+	_, err = queries.CreateOrder(ctx, db.CreateOrderParams{
+		ID:            "order-store-credit",
+		UserID:        "user-customer",
+		CustomerEmail: "customer@example.com",
+		CustomerName:  "Customer",
+		SubtotalCents: 1200,
+		TotalCents:    0,
+	})
+	require.NoError(t, err)
+	require.NoError(t, queries.AttachStoreCreditRedemptionsToOrder(ctx, db.AttachStoreCreditRedemptionsToOrderParams{
+		OrderID:           sql.NullString{String: "order-store-credit", Valid: true},
+		CheckoutSessionID: "cs_paid",
+	}))
+	require.NoError(t, ledger.ReleaseSession(ctx, "cs_paid", "Checkout expired"))
+
+	_, err = ledger.Revoke(ctx, "user-customer", 1801, "Too much", "")
+	require.True(t, errors.As(err, &creditErr), "the balance can't go below zero")
+	balance, err = ledger.Revoke(ctx, "user-customer", 800, "Granted twice by mistake", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), balance)
+
+	history, err := queries.ListStoreCreditTransactions(ctx, db.ListStoreCreditTransactionsParams{UserID: "user-customer", LimitCount: 50})
+	require.NoError(t, err)
+	kinds := []string{}
+	var total int64
+	for _, tx := range history {
+		kinds = append(kinds, tx.Kind)
+		total += tx.AmountCents
+	}
+	assert.Equal(t, []string{KindRevoke, KindRedeem, KindRelease, KindRedeem, KindGrant, KindGrant}, kinds)
+	assert.Equal(t, balance, total, "the history adds up to the balance")
+	assert.Equal(t, "order-store-credit", history[1].OrderID.String)
+}
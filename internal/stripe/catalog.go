@@ -0,0 +1,466 @@
+package stripe
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/stripe/stripe-go/v80"
+	"github.com/stripe/stripe-go/v80/price"
+	"github.com/stripe/stripe-go/v80/product"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// catalogCurrency is the only currency the shop sells in
+const catalogCurrency = "usd"
+
+// maxStripeDescription keeps product descriptions well under Stripe's limit
+const maxStripeDescription = 1000
+
+// CatalogAPI is the part of the Stripe API the catalog sync uses
+type CatalogAPI interface {
+	CreateProduct(params *stripe.ProductParams) (*stripe.Product, error)
+	UpdateProduct(id string, params *stripe.ProductParams) (*stripe.Product, error)
+	GetPrice(id string) (*stripe.Price, error)
+	CreatePrice(params *stripe.PriceParams) (*stripe.Price, error)
+	UpdatePrice(id string, params *stripe.PriceParams) (*stripe.Price, error)
+	ListProducts() ([]*stripe.Product, error)
+	ListPrices() ([]*stripe.Price, error)
+}
+
+// liveCatalogAPI calls Stripe using the package-level key
+type liveCatalogAPI struct{}
+
+func (liveCatalogAPI) CreateProduct(params *stripe.ProductParams) (*stripe.Product, error) {
+	return product.New(params)
+}
+
+func (liveCatalogAPI) UpdateProduct(id string, params *stripe.ProductParams) (*stripe.Product, error) {
+	return product.Update(id, params)
+}
+
+func (liveCatalogAPI) GetPrice(id string) (*stripe.Price, error) {
+	return price.Get(id, nil)
+}
+
+func (liveCatalogAPI) CreatePrice(params *stripe.PriceParams) (*stripe.Price, error) {
+	return price.New(params)
+}
+
+func (liveCatalogAPI) UpdatePrice(id string, params *stripe.PriceParams) (*stripe.Price, error) {
+	return price.Update(id, params)
+}
+
+func (liveCatalogAPI) ListProducts() ([]*stripe.Product, error) {
+	var products []*stripe.Product
+	iter := product.List(&stripe.ProductListParams{})
+	for iter.Next() {
+		products = append(products, iter.Product())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error listing products: %w", err)
+	}
+	return products, nil
+}
+
+// ListPrices returns active and archived prices; Stripe lists only one state at a time
+func (liveCatalogAPI) ListPrices() ([]*stripe.Price, error) {
+	var prices []*stripe.Price
+	for _, active := range []bool{true, false} {
+		iter := price.List(&stripe.PriceListParams{Active: stripe.Bool(active)})
+		for iter.Next() {
+			prices = append(prices, iter.Price())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("error listing prices: %w", err)
+		}
+	}
+	return prices, nil
+}
+
+// CatalogSync keeps Stripe Products and Prices in step with the local catalog.
+// The local catalog is the source of truth: syncing pushes local values to Stripe,
+// and Reconcile reports anything that has drifted on either side.
+type CatalogSync struct {
+	queries *db.Queries
+	api     CatalogAPI
+	baseURL string
+}
+
+// NewCatalogSync returns a catalog sync that talks to Stripe with the configured key.
+// baseURL is used to give Stripe absolute image URLs.
+func NewCatalogSync(queries *db.Queries, baseURL string) *CatalogSync {
+	return &CatalogSync{
+		queries: queries,
+		api:     liveCatalogAPI{},
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// SyncProduct creates or updates the Stripe Product for a local product, then makes
+// sure its base price and every SKU price has a matching active Stripe Price
+func (s *CatalogSync) SyncProduct(ctx context.Context, productID string) error {
+	local, err := s.queries.GetProduct(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("failed to load product: %w", err)
+	}
+
+	params := s.productParams(ctx, local)
+	var stripeProduct *stripe.Product
+	if local.StripeProductID.Valid && local.StripeProductID.String != "" {
+		stripeProduct, err = s.api.UpdateProduct(local.StripeProductID.String, params)
+		if isResourceMissing(err) {
+			slog.Warn("stripe product missing, creating a new one", "product_id", productID, "stripe_product_id", local.StripeProductID.String)
+			stripeProduct, err = s.api.CreateProduct(params)
+		}
+	} else {
+		stripeProduct, err = s.api.CreateProduct(params)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save stripe product: %w", err)
+	}
+
+	priceID, err := s.ensurePrice(stripeProduct.ID, local.StripePriceID.String, local.PriceCents, local.Name, map[string]string{
+		"product_id": local.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save base price: %w", err)
+	}
+
+	if err := s.queries.UpdateProductStripeIDs(ctx, db.UpdateProductStripeIDsParams{
+		StripeProductID: sql.NullString{String: stripeProduct.ID, Valid: true},
+		StripePriceID:   sql.NullString{String: priceID, Valid: true},
+		ID:              local.ID,
+	}); err != nil {
+		return fmt.Errorf("failed to store stripe ids: %w", err)
+	}
+
+	skus, err := s.queries.GetProductSkus(ctx, local.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load skus: %w", err)
+	}
+	for _, sku := range skus {
+		active := !sku.IsActive.Valid || sku.IsActive.Bool
+		if !active {
+			// Retired SKUs keep their price ID but the price is archived
+			if sku.StripePriceID.Valid && sku.StripePriceID.String != "" {
+				if err := s.ArchivePrice(sku.StripePriceID.String); err != nil {
+					return fmt.Errorf("failed to archive price for sku %s: %w", sku.Sku, err)
+				}
+			}
+			continue
+		}
+
+		amount := local.PriceCents + sku.PriceAdjustmentCents.Int64
+		nickname := fmt.Sprintf("%s - %s / %s", local.Name, sku.StyleName, sku.SizeDisplayName)
+		skuPriceID, err := s.ensurePrice(stripeProduct.ID, sku.StripePriceID.String, amount, nickname, map[string]string{
+			"product_id": local.ID,
+			"sku_id":     sku.ID,
+			"sku":        sku.Sku,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save price for sku %s: %w", sku.Sku, err)
+		}
+		if skuPriceID != sku.StripePriceID.String {
+			if err := s.queries.UpdateProductSkuStripePrice(ctx, db.UpdateProductSkuStripePriceParams{
+				StripePriceID: sql.NullString{String: skuPriceID, Valid: true},
+				ID:            sku.ID,
+			}); err != nil {
+				return fmt.Errorf("failed to store price for sku %s: %w", sku.Sku, err)
+			}
+		}
+	}
+
+	slog.Info("synced product to stripe", "product_id", local.ID, "stripe_product_id", stripeProduct.ID, "skus", len(skus))
+	return nil
+}
+
+// ArchiveProduct deactivates a Stripe Product whose local product was deleted.
+// Stripe won't delete products that have prices, so they are archived instead.
+func (s *CatalogSync) ArchiveProduct(stripeProductID string) error {
+	_, err := s.api.UpdateProduct(stripeProductID, &stripe.ProductParams{Active: stripe.Bool(false)})
+	if err != nil && !isResourceMissing(err) {
+		return fmt.Errorf("failed to archive stripe product: %w", err)
+	}
+	return nil
+}
+
+func (s *CatalogSync) productParams(ctx context.Context, local db.Product) *stripe.ProductParams {
+	description := local.ShortDescription.String
+	if description == "" {
+		description = local.Description.String
+	}
+	if runes := []rune(description); len(runes) > maxStripeDescription {
+		description = string(runes[:maxStripeDescription])
+	}
+
+	params := &stripe.ProductParams{
+		Name:     stripe.String(local.Name),
+		Active:   stripe.Bool((!local.IsActive.Valid || local.IsActive.Bool) && !local.DeletedAt.Valid),
+		Metadata: map[string]string{"product_id": local.ID},
+	}
+	// Stripe rejects an empty description, so only send one when there is one
+	if description != "" {
+		params.Description = stripe.String(description)
+	}
+	if image, err := s.queries.GetPrimaryProductImage(ctx, local.ID); err == nil && image.ImageUrl != "" && s.baseURL != "" {
+		params.Images = []*string{stripe.String(fmt.Sprintf("%s/public/images/products/%s", s.baseURL, image.ImageUrl))}
+	}
+	return params
+}
+
+// ensurePrice returns currentID when it is still an active price for the amount,
+// otherwise creates a replacement and archives the old one
+func (s *CatalogSync) ensurePrice(stripeProductID, currentID string, amount int64, nickname string, metadata map[string]string) (string, error) {
+	if currentID != "" {
+		current, err := s.api.GetPrice(currentID)
+		if err != nil && !isResourceMissing(err) {
+			return "", err
+		}
+		if err == nil && priceMatches(current, stripeProductID, amount) {
+			return currentID, nil
+		}
+	}
+
+	created, err := s.api.CreatePrice(&stripe.PriceParams{
+		Product:    stripe.String(stripeProductID),
+		UnitAmount: stripe.Int64(amount),
+		Currency:   stripe.String(catalogCurrency),
+		Nickname:   stripe.String(nickname),
+		Metadata:   metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if currentID != "" {
+		if err := s.ArchivePrice(currentID); err != nil {
+			slog.Warn("failed to archive replaced stripe price", "error", err, "stripe_price_id", currentID)
+		}
+	}
+	return created.ID, nil
+}
+
+// ArchivePrice deactivates a Stripe Price, e.g. for a deleted SKU
+func (s *CatalogSync) ArchivePrice(priceID string) error {
+	_, err := s.api.UpdatePrice(priceID, &stripe.PriceParams{Active: stripe.Bool(false)})
+	if err != nil && !isResourceMissing(err) {
+		return err
+	}
+	return nil
+}
+
+func priceMatches(p *stripe.Price, stripeProductID string, amount int64) bool {
+	if p == nil || !p.Active || p.UnitAmount != amount || string(p.Currency) != catalogCurrency {
+		return false
+	}
+	return p.Product != nil && p.Product.ID == stripeProductID
+}
+
+func isResourceMissing(err error) bool {
+	var stripeErr *stripe.Error
+	return errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing
+}
+
+// Kinds of catalog drift
+const (
+	DriftNotSynced      = "not_synced"       // local product has never been pushed
+	DriftMissing        = "missing"          // stored Stripe ID no longer exists
+	DriftName           = "name"             // Stripe product name differs
+	DriftActive         = "active"           // active flag differs
+	DriftPrice          = "price"            // Stripe price amount or currency differs
+	DriftPriceArchived  = "price_archived"   // stored price is archived in Stripe
+	DriftOrphaned       = "orphaned"         // active Stripe product with no local product
+	DriftSkuPriceActive = "sku_price_active" // retired SKU still has an active price
+)
+
+// CatalogDrift is one difference between the local catalog and Stripe
+type CatalogDrift struct {
+	Kind        string
+	ProductID   string // empty for orphaned Stripe products
+	ProductName string
+	SkuID       string
+	Sku         string
+	StripeID    string
+	Local       string
+	Stripe      string
+}
+
+// LocalCatalogProduct is a local product as the reconciliation sees it
+type LocalCatalogProduct struct {
+	ID              string
+	Name            string
+	Active          bool
+	PriceCents      int64
+	StripeProductID string
+	StripePriceID   string
+	Skus            []LocalCatalogSku
+}
+
+// LocalCatalogSku is a local SKU as the reconciliation sees it
+type LocalCatalogSku struct {
+	ID            string
+	Sku           string
+	Active        bool
+	PriceCents    int64 // base price plus adjustment
+	StripePriceID string
+}
+
+// Reconcile compares the whole local catalog with Stripe and reports the differences
+func (s *CatalogSync) Reconcile(ctx context.Context) ([]CatalogDrift, error) {
+	products, err := s.queries.ListStripeCatalogProducts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local products: %w", err)
+	}
+	skus, err := s.queries.ListStripeCatalogSkus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local skus: %w", err)
+	}
+
+	skusByProduct := make(map[string][]LocalCatalogSku)
+	for _, sku := range skus {
+		skusByProduct[sku.ProductID] = append(skusByProduct[sku.ProductID], LocalCatalogSku{
+			ID:            sku.ID,
+			Sku:           sku.Sku,
+			Active:        !sku.IsActive.Valid || sku.IsActive.Bool,
+			PriceCents:    sku.PriceCents,
+			StripePriceID: sku.StripePriceID,
+		})
+	}
+	local := make([]LocalCatalogProduct, 0, len(products))
+	for _, p := range products {
+		local = append(local, LocalCatalogProduct{
+			ID:              p.ID,
+			Name:            p.Name,
+			Active:          !p.IsActive.Valid || p.IsActive.Bool,
+			PriceCents:      p.PriceCents,
+			StripeProductID: p.StripeProductID,
+			StripePriceID:   p.StripePriceID,
+			Skus:            skusByProduct[p.ID],
+		})
+	}
+
+	remoteProducts, err := s.api.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+	remotePrices, err := s.api.ListPrices()
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffCatalog(local, remoteProducts, remotePrices), nil
+}
+
+// DiffCatalog compares local products with Stripe's products and prices
+func DiffCatalog(local []LocalCatalogProduct, remoteProducts []*stripe.Product, remotePrices []*stripe.Price) []CatalogDrift {
+	productsByID := make(map[string]*stripe.Product, len(remoteProducts))
+	for _, p := range remoteProducts {
+		productsByID[p.ID] = p
+	}
+	pricesByID := make(map[string]*stripe.Price, len(remotePrices))
+	for _, p := range remotePrices {
+		pricesByID[p.ID] = p
+	}
+
+	var drift []CatalogDrift
+	linked := make(map[string]bool)
+	for _, lp := range local {
+		base := CatalogDrift{ProductID: lp.ID, ProductName: lp.Name}
+		if lp.StripeProductID == "" {
+			d := base
+			d.Kind = DriftNotSynced
+			drift = append(drift, d)
+			continue
+		}
+		linked[lp.StripeProductID] = true
+
+		remote, ok := productsByID[lp.StripeProductID]
+		if !ok || remote.Deleted {
+			d := base
+			d.Kind, d.StripeID = DriftMissing, lp.StripeProductID
+			drift = append(drift, d)
+			continue
+		}
+		if remote.Name != lp.Name {
+			d := base
+			d.Kind, d.StripeID, d.Local, d.Stripe = DriftName, remote.ID, lp.Name, remote.Name
+			drift = append(drift, d)
+		}
+		if remote.Active != lp.Active {
+			d := base
+			d.Kind, d.StripeID, d.Local, d.Stripe = DriftActive, remote.ID, fmt.Sprintf("%t", lp.Active), fmt.Sprintf("%t", remote.Active)
+			drift = append(drift, d)
+		}
+
+		drift = append(drift, diffPrice(base, lp.StripePriceID, lp.PriceCents, true, pricesByID)...)
+		for _, sku := range lp.Skus {
+			skuBase := base
+			skuBase.SkuID, skuBase.Sku = sku.ID, sku.Sku
+			drift = append(drift, diffPrice(skuBase, sku.StripePriceID, sku.PriceCents, sku.Active, pricesByID)...)
+		}
+	}
+
+	// Stripe products created by the sync carry the local product ID; anything still
+	// active that no local product points at was left behind
+	for _, remote := range remoteProducts {
+		if remote.Deleted || !remote.Active || linked[remote.ID] || remote.Metadata["product_id"] == "" {
+			continue
+		}
+		drift = append(drift, CatalogDrift{
+			Kind:     DriftOrphaned,
+			StripeID: remote.ID,
+			Stripe:   remote.Name,
+		})
+	}
+	return drift
+}
+
+func diffPrice(base CatalogDrift, priceID string, amount int64, active bool, pricesByID map[string]*stripe.Price) []CatalogDrift {
+	if priceID == "" {
+		if !active {
+			return nil
+		}
+		d := base
+		d.Kind = DriftNotSynced
+		return []CatalogDrift{d}
+	}
+
+	remote, ok := pricesByID[priceID]
+	if !ok {
+		d := base
+		d.Kind, d.StripeID = DriftMissing, priceID
+		return []CatalogDrift{d}
+	}
+
+	var drift []CatalogDrift
+	if !active {
+		if remote.Active {
+			d := base
+			d.Kind, d.StripeID = DriftSkuPriceActive, priceID
+			drift = append(drift, d)
+		}
+		return drift
+	}
+	if !remote.Active {
+		d := base
+		d.Kind, d.StripeID = DriftPriceArchived, priceID
+		drift = append(drift, d)
+	}
+	if remote.UnitAmount != amount || string(remote.Currency) != catalogCurrency {
+		d := base
+		d.Kind, d.StripeID = DriftPrice, priceID
+		d.Local = formatCents(amount, catalogCurrency)
+		d.Stripe = formatCents(remote.UnitAmount, string(remote.Currency))
+		drift = append(drift, d)
+	}
+	return drift
+}
+
+func formatCents(amount int64, currency string) string {
+	return fmt.Sprintf("%.2f %s", float64(amount)/100, strings.ToUpper(currency))
+}
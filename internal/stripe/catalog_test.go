@@ -0,0 +1,86 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v80"
+)
+
+func remotePrice(id, productID string, amount int64, active bool) *stripe.Price {
+	return &stripe.Price{
+		ID:         id,
+		Product:    &stripe.Product{ID: productID},
+		UnitAmount: amount,
+		Currency:   stripe.CurrencyUSD,
+		Active:     active,
+	}
+}
+
+func driftKinds(drift []CatalogDrift) []string {
+	kinds := make([]string, 0, len(drift))
+	for _, d := range drift {
+		kinds = append(kinds, d.Kind)
+	}
+	return kinds
+}
+
+func TestDiffCatalog(t *testing.T) {
+	remoteProducts := []*stripe.Product{
+		{ID: "prod_1", Name: "Dragon", Active: true, Metadata: map[string]string{"product_id": "p1"}},
+		{ID: "prod_old", Name: "Retired Egg", Active: true, Metadata: map[string]string{"product_id": "gone"}},
+		{ID: "prod_manual", Name: "Gift wrap", Active: true},
+	}
+	remotePrices := []*stripe.Price{
+		remotePrice("price_base", "prod_1", 1000, true),
+		remotePrice("price_large", "prod_1", 1500, true),
+		remotePrice("price_retired", "prod_1", 1200, true),
+	}
+
+	t.Run("in step", func(t *testing.T) {
+		local := []LocalCatalogProduct{{
+			ID: "p1", Name: "Dragon", Active: true, PriceCents: 1000,
+			StripeProductID: "prod_1", StripePriceID: "price_base",
+			Skus: []LocalCatalogSku{{ID: "s1", Sku: "DRG-L", Active: true, PriceCents: 1500, StripePriceID: "price_large"}},
+		}}
+		assert.Equal(t, []string{DriftOrphaned}, driftKinds(DiffCatalog(local, remoteProducts, remotePrices)))
+	})
+
+	t.Run("drift on both sides", func(t *testing.T) {
+		local := []LocalCatalogProduct{
+			{
+				ID: "p1", Name: "Articulated Dragon", Active: false, PriceCents: 1100,
+				StripeProductID: "prod_1", StripePriceID: "price_base",
+				Skus: []LocalCatalogSku{
+					{ID: "s1", Sku: "DRG-L", Active: true},
+					{ID: "s2", Sku: "DRG-XL", Active: false, StripePriceID: "price_retired"},
+				},
+			},
+			{ID: "p2", Name: "New Egg"},
+		}
+
+		drift := DiffCatalog(local, remoteProducts, remotePrices)
+		assert.Equal(t, []string{DriftName, DriftActive, DriftPrice, DriftNotSynced, DriftSkuPriceActive, DriftNotSynced, DriftOrphaned}, driftKinds(drift))
+		assert.Equal(t, "11.00 USD", drift[2].Local)
+		assert.Equal(t, "10.00 USD", drift[2].Stripe)
+		assert.Equal(t, "DRG-XL", drift[4].Sku)
+		assert.Equal(t, "prod_old", drift[6].StripeID)
+	})
+
+	t.Run("stored ids missing from stripe", func(t *testing.T) {
+		local := []LocalCatalogProduct{
+			{ID: "p3", Name: "Vase", Active: true, StripeProductID: "prod_deleted"},
+			{ID: "p1", Name: "Dragon", Active: true, PriceCents: 1000, StripeProductID: "prod_1", StripePriceID: "price_gone"},
+		}
+		drift := DiffCatalog(local, remoteProducts, remotePrices)
+		assert.Equal(t, []string{DriftMissing, DriftMissing, DriftOrphaned}, driftKinds(drift))
+	})
+}
+
+func TestPriceMatches(t *testing.T) {
+	assert.True(t, priceMatches(remotePrice("price", "prod_1", 1000, true), "prod_1", 1000))
+	assert.False(t, priceMatches(remotePrice("price", "prod_1", 1000, false), "prod_1", 1000))
+	assert.False(t, priceMatches(remotePrice("price", "prod_1", 900, true), "prod_1", 1000))
+	assert.False(t, priceMatches(remotePrice("price", "prod_2", 1000, true), "prod_1", 1000))
+	assert.False(t, priceMatches(nil, "prod_1", 1000))
+}
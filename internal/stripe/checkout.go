@@ -0,0 +1,38 @@
+package stripe
+
+import (
+	"github.com/stripe/stripe-go/v80"
+	checkoutsession "github.com/stripe/stripe-go/v80/checkout/session"
+)
+
+// ReplacedCheckoutKey marks a Checkout Session closed because the customer started
+// checkout again, so its checkout.session.expired event sends no "complete your
+// order" email
+const ReplacedCheckoutKey = "replaced"
+
+// ExpireReplacedSession closes a Checkout Session the customer replaced by starting
+// checkout again, so it can't be paid alongside the new one. It reports false and
+// leaves the session alone once it's been paid; a session paid while it's being
+// closed fails the expiry and is left alone too.
+func ExpireReplacedSession(sessionID string) (bool, error) {
+	session, err := checkoutsession.Get(sessionID, nil)
+	if err != nil {
+		return false, err
+	}
+	switch session.Status {
+	case stripe.CheckoutSessionStatusExpired:
+		return true, nil
+	case stripe.CheckoutSessionStatusComplete:
+		return false, nil
+	}
+
+	params := &stripe.CheckoutSessionParams{}
+	params.AddMetadata(ReplacedCheckoutKey, "true")
+	if _, err := checkoutsession.Update(sessionID, params); err != nil {
+		return false, err
+	}
+	if _, err := checkoutsession.Expire(sessionID, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
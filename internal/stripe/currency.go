@@ -0,0 +1,85 @@
+package stripe
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stripe/stripe-go/v80"
+)
+
+// minorUnits is how many of a currency's smallest unit make one whole unit. Stripe
+// takes zero-decimal currencies in whole units and three-decimal ones in thousandths.
+func minorUnits(currency string) float64 {
+	switch strings.ToLower(currency) {
+	case "bif", "clp", "djf", "gnf", "jpy", "kmf", "krw", "mga", "pyg", "rwf", "ugx", "vnd", "vuv", "xaf", "xof", "xpf":
+		return 1
+	case "bhd", "jod", "kwd", "omr", "tnd":
+		return 1000
+	}
+	return 100
+}
+
+// EnableAdaptivePricing lets Stripe show and charge a checkout session in the
+// customer's own currency. Prices stay in USD; Stripe converts at its rate and pays
+// out in USD.
+func EnableAdaptivePricing(params *stripe.CheckoutSessionParams) {
+	params.AddExtra("adaptive_pricing[enabled]", "true")
+}
+
+// SessionAmounts are a paid checkout session's totals in USD cents, with the
+// currency the customer was charged in
+type SessionAmounts struct {
+	TotalCents    int64
+	TaxCents      int64
+	DiscountCents int64
+	// Currency is the lower-case currency charged, and FxRate its rate from USD
+	Currency string
+	FxRate   float64
+}
+
+// Converted reports whether the customer paid in a currency other than USD
+func (a SessionAmounts) Converted() bool {
+	return a.Currency != "usd"
+}
+
+// SessionAmountsUSD reads a paid session's totals. With Adaptive Pricing the
+// session's amounts are in the customer's currency; its currency conversion has the
+// USD total, and tax and discount are converted back at the session's rate.
+func SessionAmountsUSD(session *stripe.CheckoutSession) SessionAmounts {
+	amounts := SessionAmounts{TotalCents: session.AmountTotal, Currency: "usd", FxRate: 1}
+	if session.TotalDetails != nil {
+		amounts.TaxCents = session.TotalDetails.AmountTax
+		amounts.DiscountCents = session.TotalDetails.AmountDiscount
+	}
+
+	conv := session.CurrencyConversion
+	if conv != nil && conv.FxRate > 0 && strings.EqualFold(string(conv.SourceCurrency), "usd") {
+		amounts.Currency = strings.ToLower(string(session.Currency))
+		amounts.FxRate = conv.FxRate
+		amounts.TotalCents = conv.AmountTotal
+		amounts.TaxCents = usdCents(amounts.TaxCents, amounts.Currency, conv.FxRate)
+		amounts.DiscountCents = usdCents(amounts.DiscountCents, amounts.Currency, conv.FxRate)
+	}
+
+	// Tax worked out before the session is a line of its own, kept in USD in the metadata
+	if taxCents, err := strconv.ParseInt(session.Metadata[PrecalculatedTaxKey], 10, 64); err == nil {
+		amounts.TaxCents = taxCents
+	}
+	return amounts
+}
+
+// ChargedAmount converts USD cents to the currency an order was charged in, rounding
+// down so a refund never asks for more than was paid
+func ChargedAmount(usdCents int64, currency string, fxRate float64) int64 {
+	if currency == "" || strings.EqualFold(currency, "usd") || fxRate <= 0 {
+		return usdCents
+	}
+	// The small allowance keeps float error from losing a whole cent
+	return int64(math.Floor(float64(usdCents)/100*fxRate*minorUnits(currency) + 1e-6))
+}
+
+// usdCents converts an amount in the charged currency back to USD cents
+func usdCents(amount int64, currency string, fxRate float64) int64 {
+	return int64(math.Round(float64(amount) / minorUnits(currency) / fxRate * 100))
+}
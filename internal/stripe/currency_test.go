@@ -0,0 +1,49 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v80"
+)
+
+func TestSessionAmountsUSD(t *testing.T) {
+	usd := &stripe.CheckoutSession{
+		AmountTotal:  5400,
+		Currency:     stripe.CurrencyUSD,
+		TotalDetails: &stripe.CheckoutSessionTotalDetails{AmountTax: 400, AmountDiscount: 500},
+	}
+	amounts := SessionAmountsUSD(usd)
+	assert.Equal(t, SessionAmounts{TotalCents: 5400, TaxCents: 400, DiscountCents: 500, Currency: "usd", FxRate: 1}, amounts)
+	assert.False(t, amounts.Converted())
+
+	cad := &stripe.CheckoutSession{
+		AmountTotal:  7344,
+		Currency:     stripe.CurrencyCAD,
+		TotalDetails: &stripe.CheckoutSessionTotalDetails{AmountTax: 544, AmountDiscount: 680},
+		CurrencyConversion: &stripe.CheckoutSessionCurrencyConversion{
+			AmountTotal:    5400,
+			FxRate:         1.36,
+			SourceCurrency: stripe.CurrencyUSD,
+		},
+	}
+	amounts = SessionAmountsUSD(cad)
+	assert.True(t, amounts.Converted())
+	assert.Equal(t, "cad", amounts.Currency)
+	assert.Equal(t, int64(5400), amounts.TotalCents)
+	assert.Equal(t, int64(400), amounts.TaxCents)
+	assert.Equal(t, int64(500), amounts.DiscountCents)
+
+	// Tax worked out before a gift card checkout is read from the metadata, in USD
+	cad.Metadata = map[string]string{PrecalculatedTaxKey: "412"}
+	cad.TotalDetails.AmountTax = 0
+	assert.Equal(t, int64(412), SessionAmountsUSD(cad).TaxCents)
+}
+
+func TestChargedAmount(t *testing.T) {
+	assert.Equal(t, int64(2500), ChargedAmount(2500, "usd", 1))
+	assert.Equal(t, int64(2500), ChargedAmount(2500, "", 0))
+	assert.Equal(t, int64(3400), ChargedAmount(2500, "cad", 1.36))
+	assert.Equal(t, int64(3752), ChargedAmount(2500, "jpy", 150.1), "yen have no minor unit")
+	assert.Equal(t, int64(1), ChargedAmount(1, "eur", 1.999), "rounds down")
+}
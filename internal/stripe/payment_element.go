@@ -0,0 +1,410 @@
+package stripe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v80"
+	"github.com/stripe/stripe-go/v80/customer"
+	"github.com/stripe/stripe-go/v80/customersession"
+	"github.com/stripe/stripe-go/v80/paymentintent"
+	"github.com/stripe/stripe-go/v80/paymentmethod"
+	"github.com/stripe/stripe-go/v80/setupintent"
+	"github.com/stripe/stripe-go/v80/tax/calculation"
+	"github.com/stripe/stripe-go/v80/tax/transaction"
+)
+
+// The embedded checkout takes payment with the Payment Element on the shop's own page.
+// What a Checkout Session would hold is kept in a checkout draft instead: the lines
+// and metadata are built as for Checkout, tax comes from a Stripe Tax calculation and
+// the customer pays a PaymentIntent. Once it succeeds the draft is turned back into a
+// Checkout Session shape, so orders are made by the same code either way.
+
+// checkoutDraftPrefix starts checkout draft IDs. A draft's ID stands in for a Checkout
+// Session's on the order, its guest order link and its holds.
+const checkoutDraftPrefix = "draft_"
+
+// MinimumChargeCents is the least Stripe charges a card in USD. Checkouts coming to
+// less go through hosted Checkout, which can take nothing at all.
+const MinimumChargeCents = 50
+
+// NewCheckoutDraftID returns a checkout draft ID. It's long and random because, like a
+// session ID, it's the key to a guest's order.
+func NewCheckoutDraftID() string {
+	return checkoutDraftPrefix + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// IsCheckoutDraftID reports whether an order's checkout ID is a draft's rather than a
+// Checkout Session's
+func IsCheckoutDraftID(id string) bool {
+	return strings.HasPrefix(id, checkoutDraftPrefix)
+}
+
+// DraftLine is one line of a checkout draft, as it would have gone to Checkout
+type DraftLine struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	UnitAmount  int64             `json:"unit_amount"`
+	Quantity    int64             `json:"quantity"`
+	TaxCode     string            `json:"tax_code,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// AmountCents is what the line comes to before any discount
+func (l DraftLine) AmountCents() int64 {
+	return l.UnitAmount * l.Quantity
+}
+
+// DraftLines converts the line items built for a Checkout Session
+func DraftLines(items []*stripe.CheckoutSessionLineItemParams) []DraftLine {
+	lines := make([]DraftLine, 0, len(items))
+	for _, item := range items {
+		line := DraftLine{
+			UnitAmount: stripe.Int64Value(item.PriceData.UnitAmount),
+			Quantity:   stripe.Int64Value(item.Quantity),
+		}
+		if product := item.PriceData.ProductData; product != nil {
+			line.Name = stripe.StringValue(product.Name)
+			line.Description = stripe.StringValue(product.Description)
+			line.TaxCode = stripe.StringValue(product.TaxCode)
+			line.Metadata = product.Metadata
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// AllocateDiscount spreads a discount over the lines in proportion to what each comes
+// to, as a Checkout coupon does, so tax is worked out on what's paid. It returns what
+// each line comes to after the discount. Cents lost to rounding come off the last
+// lines that have room for them.
+func AllocateDiscount(lines []DraftLine, discountCents int64) []int64 {
+	amounts := make([]int64, len(lines))
+	var total int64
+	for i, line := range lines {
+		amounts[i] = line.AmountCents()
+		total += amounts[i]
+	}
+	discountCents = min(discountCents, total)
+	if discountCents <= 0 {
+		return amounts
+	}
+
+	shares := make([]int64, len(lines))
+	var given int64
+	for i, amount := range amounts {
+		shares[i] = discountCents * amount / total
+		given += shares[i]
+	}
+	for i := len(amounts) - 1; i >= 0 && given < discountCents; i-- {
+		extra := min(discountCents-given, amounts[i]-shares[i])
+		shares[i] += extra
+		given += extra
+	}
+	for i := range amounts {
+		amounts[i] -= shares[i]
+	}
+	return amounts
+}
+
+// CalculateDraftTax asks Stripe Tax what a draft's lines owe, shipped to address.
+// amounts are the lines after the discount, from AllocateDiscount.
+func CalculateDraftTax(draftID string, lines []DraftLine, amounts []int64, address *stripe.AddressParams) (*stripe.TaxCalculation, error) {
+	return calculateTax("checkout-draft-tax-"+draftID, lines, amounts, address)
+}
+
+func calculateTax(idempotencyKey string, lines []DraftLine, amounts []int64, address *stripe.AddressParams) (*stripe.TaxCalculation, error) {
+	params := &stripe.TaxCalculationParams{
+		Currency: stripe.String(catalogCurrency),
+		CustomerDetails: &stripe.TaxCalculationCustomerDetailsParams{
+			Address:       address,
+			AddressSource: stripe.String(string(stripe.TaxCalculationCustomerDetailsAddressSourceShipping)),
+		},
+	}
+	for i, line := range lines {
+		if line.Quantity <= 0 {
+			continue
+		}
+		item := &stripe.TaxCalculationLineItemParams{
+			Amount:      stripe.Int64(amounts[i]),
+			Quantity:    stripe.Int64(line.Quantity),
+			Reference:   stripe.String(fmt.Sprintf("line-%d", i+1)),
+			TaxBehavior: stripe.String(string(stripe.TaxCalculationLineItemTaxBehaviorExclusive)),
+		}
+		if line.TaxCode != "" {
+			item.TaxCode = stripe.String(line.TaxCode)
+		}
+		params.LineItems = append(params.LineItems, item)
+	}
+	params.SetIdempotencyKey(idempotencyKey)
+
+	return calculation.New(params)
+}
+
+// DraftPaymentIntent is what a checkout draft's PaymentIntent charges
+type DraftPaymentIntent struct {
+	DraftID     string
+	AmountCents int64
+	// CustomerID is the signed-in customer's Stripe customer, so their saved cards
+	// can pay and new ones can be saved; empty for guests
+	CustomerID         string
+	Email              string
+	Shipping           *stripe.ShippingDetailsParams
+	PaymentMethodTypes []*string
+}
+
+// CreateDraftPaymentIntent creates the PaymentIntent a checkout draft is paid with.
+// Its metadata names the draft, so the webhook can make the order. The draft ID is the
+// idempotency key.
+func CreateDraftPaymentIntent(draft DraftPaymentIntent) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:      stripe.Int64(draft.AmountCents),
+		Currency:    stripe.String(catalogCurrency),
+		Description: stripe.String("Logan's 3D Creations order"),
+		Shipping:    draft.Shipping,
+		Metadata:    map[string]string{"checkout_draft_id": draft.DraftID},
+	}
+	if draft.CustomerID != "" {
+		params.Customer = stripe.String(draft.CustomerID)
+	}
+	if draft.Email != "" {
+		params.ReceiptEmail = stripe.String(draft.Email)
+	}
+	// Nil leaves the choice to the methods turned on in the Dashboard, as for Checkout
+	if draft.PaymentMethodTypes == nil {
+		params.AutomaticPaymentMethods = &stripe.PaymentIntentAutomaticPaymentMethodsParams{Enabled: stripe.Bool(true)}
+	} else {
+		params.PaymentMethodTypes = draft.PaymentMethodTypes
+	}
+	params.SetIdempotencyKey("checkout-draft-" + draft.DraftID)
+
+	return paymentintent.New(params)
+}
+
+// SetDraftReceiptEmail sends the receipt for a guest's draft to the email they entered
+func SetDraftReceiptEmail(paymentIntentID, email string) error {
+	_, err := paymentintent.Update(paymentIntentID, &stripe.PaymentIntentParams{ReceiptEmail: stripe.String(email)})
+	return err
+}
+
+// CancelDraftPaymentIntent cancels the PaymentIntent of a draft that lapsed unpaid.
+// It reports false and leaves the PaymentIntent alone once the customer has paid or
+// the payment is processing, so a draft paid at the last moment isn't expired.
+func CancelDraftPaymentIntent(paymentIntentID string) (bool, error) {
+	intent, err := paymentintent.Get(paymentIntentID, nil)
+	if err != nil {
+		return false, err
+	}
+	switch intent.Status {
+	case stripe.PaymentIntentStatusCanceled:
+		return true, nil
+	case stripe.PaymentIntentStatusSucceeded, stripe.PaymentIntentStatusProcessing, stripe.PaymentIntentStatusRequiresCapture:
+		return false, nil
+	}
+	_, err = paymentintent.Cancel(paymentIntentID, &stripe.PaymentIntentCancelParams{
+		CancellationReason: stripe.String(string(stripe.PaymentIntentCancellationReasonAbandoned)),
+	})
+	return err == nil, err
+}
+
+// DraftPaymentStatus fetches a draft's PaymentIntent for the payment page: its client
+// secret, which the Payment Element confirms, and its status
+func DraftPaymentStatus(paymentIntentID string) (*stripe.PaymentIntent, error) {
+	return paymentintent.Get(paymentIntentID, nil)
+}
+
+// RecordDraftTax records a paid draft's tax calculation as a Stripe Tax transaction,
+// which is what Stripe's tax reports count. Checkout does this by itself, except for
+// tax worked out before the session was made; those pass the session's ID as draftID.
+func RecordDraftTax(calculationID, draftID string) (*stripe.TaxTransaction, error) {
+	params := &stripe.TaxTransactionCreateFromCalculationParams{
+		Calculation: stripe.String(calculationID),
+		Reference:   stripe.String(draftID),
+	}
+	params.SetIdempotencyKey("checkout-draft-tax-transaction-" + draftID)
+
+	return transaction.CreateFromCalculation(params)
+}
+
+// GetDraftTax fetches the tax recorded for a paid draft, for tax reconciliation
+func GetDraftTax(transactionID string) (SessionTax, error) {
+	tx, err := transaction.Get(transactionID, nil)
+	if err != nil {
+		return SessionTax{}, err
+	}
+
+	var tax SessionTax
+	if tx.ShippingCost != nil {
+		tax.TaxCents += tx.ShippingCost.AmountTax
+	}
+	items := transaction.ListLineItems(&stripe.TaxTransactionListLineItemsParams{Transaction: stripe.String(transactionID)})
+	for items.Next() {
+		tax.TaxCents += items.TaxTransactionLineItem().AmountTax
+	}
+	if err := items.Err(); err != nil {
+		return SessionTax{}, err
+	}
+	if tx.CustomerDetails != nil && tx.CustomerDetails.Address != nil {
+		tax.State = strings.ToUpper(strings.TrimSpace(tx.CustomerDetails.Address.State))
+		tax.Country = tx.CustomerDetails.Address.Country
+	}
+	return tax, nil
+}
+
+// CreateShopCustomer makes the Stripe customer a signed-in customer's cards are saved
+// to. The user ID is the idempotency key, so two checkouts at once make one customer.
+func CreateShopCustomer(userID, email, name string) (*stripe.Customer, error) {
+	params := &stripe.CustomerParams{
+		Email:    stripe.String(email),
+		Metadata: map[string]string{"user_id": userID},
+	}
+	if name != "" {
+		params.Name = stripe.String(name)
+	}
+	params.SetIdempotencyKey("shop-customer-" + userID)
+
+	return customer.New(params)
+}
+
+// CustomerSessionSecret lets the Payment Element show a customer's saved cards and
+// offer to save a new one
+func CustomerSessionSecret(customerID string) (string, error) {
+	session, err := customersession.New(&stripe.CustomerSessionParams{
+		Customer: stripe.String(customerID),
+		Components: &stripe.CustomerSessionComponentsParams{
+			PaymentElement: &stripe.CustomerSessionComponentsPaymentElementParams{
+				Enabled: stripe.Bool(true),
+				Features: &stripe.CustomerSessionComponentsPaymentElementFeaturesParams{
+					PaymentMethodRedisplay: stripe.String("enabled"),
+					PaymentMethodSave:      stripe.String("enabled"),
+					PaymentMethodSaveUsage: stripe.String("on_session"),
+					PaymentMethodRemove:    stripe.String("enabled"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return session.ClientSecret, nil
+}
+
+// CreateCardSetupIntent starts saving a card to a customer from their account, without
+// paying for anything
+func CreateCardSetupIntent(customerID string) (*stripe.SetupIntent, error) {
+	return setupintent.New(&stripe.SetupIntentParams{
+		Customer:           stripe.String(customerID),
+		PaymentMethodTypes: CardPaymentMethodTypes(),
+		Usage:              stripe.String(string(stripe.SetupIntentUsageOnSession)),
+	})
+}
+
+// ListSavedCards lists the cards saved to a customer
+func ListSavedCards(customerID string) ([]*stripe.PaymentMethod, error) {
+	params := &stripe.PaymentMethodListParams{
+		Customer: stripe.String(customerID),
+		Type:     stripe.String(string(stripe.PaymentMethodTypeCard)),
+	}
+	var cards []*stripe.PaymentMethod
+	i := paymentmethod.List(params)
+	for i.Next() {
+		cards = append(cards, i.PaymentMethod())
+	}
+	if err := i.Err(); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// ErrNotCustomerCard is returned when removing a card saved to someone else
+var ErrNotCustomerCard = errors.New("card isn't saved to this customer")
+
+// RemoveSavedCard detaches a card from a customer, after checking it's theirs
+func RemoveSavedCard(customerID, paymentMethodID string) error {
+	card, err := paymentmethod.Get(paymentMethodID, nil)
+	if err != nil {
+		return err
+	}
+	if card.Customer == nil || card.Customer.ID != customerID {
+		return ErrNotCustomerCard
+	}
+	_, err = paymentmethod.Detach(paymentMethodID, nil)
+	return err
+}
+
+// DeleteShopCustomer deletes a customer's Stripe customer, and the cards saved to it,
+// when they delete their account. Their past payments stay in Stripe.
+func DeleteShopCustomer(customerID string) error {
+	_, err := customer.Del(customerID, nil)
+	return err
+}
+
+// DraftCheckout is a paid checkout draft, with what the customer entered and paid
+type DraftCheckout struct {
+	ID               string
+	Lines            []DraftLine
+	Metadata         map[string]string
+	CustomerEmail    string
+	CustomerName     string
+	CustomerPhone    string
+	Address          *stripe.Address
+	TotalCents       int64
+	TaxCents         int64
+	DiscountCents    int64
+	StripeCustomerID string
+	PaymentIntentID  string
+	Created          int64
+}
+
+// DraftSession shapes a paid draft like the Checkout Session it stands in for, with
+// its line items and total details already expanded, so the order is made from it as
+// from a session. The billing address is taken to be the shipping address.
+func DraftSession(draft DraftCheckout) *stripe.CheckoutSession {
+	items := make([]*stripe.LineItem, 0, len(draft.Lines))
+	for _, line := range draft.Lines {
+		items = append(items, &stripe.LineItem{
+			Description:    line.Name,
+			Quantity:       line.Quantity,
+			AmountSubtotal: line.AmountCents(),
+			Currency:       stripe.CurrencyUSD,
+			Price: &stripe.Price{
+				UnitAmount: line.UnitAmount,
+				Currency:   stripe.CurrencyUSD,
+				Product: &stripe.Product{
+					Name:        line.Name,
+					Description: line.Description,
+					Metadata:    line.Metadata,
+				},
+			},
+		})
+	}
+
+	return &stripe.CheckoutSession{
+		ID:          draft.ID,
+		Created:     draft.Created,
+		Currency:    stripe.CurrencyUSD,
+		AmountTotal: draft.TotalCents,
+		Metadata:    draft.Metadata,
+		LineItems:   &stripe.LineItemList{Data: items},
+		TotalDetails: &stripe.CheckoutSessionTotalDetails{
+			AmountTax:      draft.TaxCents,
+			AmountDiscount: draft.DiscountCents,
+			// The discount is our own coupon, never a Stripe promotion code
+			Breakdown: &stripe.CheckoutSessionTotalDetailsBreakdown{},
+		},
+		Customer: &stripe.Customer{ID: draft.StripeCustomerID},
+		CustomerDetails: &stripe.CheckoutSessionCustomerDetails{
+			Email:   draft.CustomerEmail,
+			Name:    draft.CustomerName,
+			Phone:   draft.CustomerPhone,
+			Address: draft.Address,
+		},
+		ShippingDetails: &stripe.ShippingDetails{
+			Name:    draft.CustomerName,
+			Address: draft.Address,
+		},
+		PaymentIntent: &stripe.PaymentIntent{ID: draft.PaymentIntentID},
+	}
+}
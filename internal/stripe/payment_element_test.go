@@ -0,0 +1,85 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v80"
+)
+
+func TestCheckoutDraftID(t *testing.T) {
+	id := NewCheckoutDraftID()
+	assert.True(t, IsCheckoutDraftID(id))
+	assert.Len(t, id, len("draft_")+32)
+	assert.NotEqual(t, id, NewCheckoutDraftID())
+	assert.False(t, IsCheckoutDraftID("cs_test_a1b2c3"))
+}
+
+func TestDraftLines(t *testing.T) {
+	lines := DraftLines([]*stripe.CheckoutSessionLineItemParams{{
+		PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+			UnitAmount: stripe.Int64(1500),
+			ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+				Name:     stripe.String("Dragon"),
+				TaxCode:  stripe.String(NontaxableTaxCode),
+				Metadata: map[string]string{"product_id": "p1"},
+			},
+		},
+		Quantity: stripe.Int64(2),
+	}})
+	require.Len(t, lines, 1)
+	assert.Equal(t, DraftLine{Name: "Dragon", UnitAmount: 1500, Quantity: 2, TaxCode: NontaxableTaxCode, Metadata: map[string]string{"product_id": "p1"}}, lines[0])
+	assert.Equal(t, int64(3000), lines[0].AmountCents())
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	lines := []DraftLine{
+		{UnitAmount: 1500, Quantity: 2},
+		{UnitAmount: 1000, Quantity: 1},
+		{UnitAmount: 0, Quantity: 1},
+	}
+	assert.Equal(t, []int64{3000, 1000, 0}, AllocateDiscount(lines, 0))
+	assert.Equal(t, []int64{2250, 750, 0}, AllocateDiscount(lines, 1000), "in proportion to each line")
+	assert.Equal(t, []int64{0, 0, 0}, AllocateDiscount(lines, 9000), "never more than the lines come to")
+
+	thirds := []DraftLine{{UnitAmount: 100, Quantity: 1}, {UnitAmount: 100, Quantity: 1}, {UnitAmount: 100, Quantity: 1}}
+	amounts := AllocateDiscount(thirds, 100)
+	assert.Equal(t, int64(200), amounts[0]+amounts[1]+amounts[2], "rounding doesn't lose a cent")
+	assert.Equal(t, []int64{67, 67, 66}, amounts)
+}
+
+func TestDraftSession(t *testing.T) {
+	address := &stripe.Address{Line1: "1 Main St", City: "Eau Claire", State: "WI", PostalCode: "54701", Country: "US"}
+	session := DraftSession(DraftCheckout{
+		ID:               "draft_abc",
+		Lines:            []DraftLine{{Name: "Dragon", UnitAmount: 1500, Quantity: 2, Metadata: map[string]string{"product_id": "p1"}}},
+		Metadata:         map[string]string{"user_id": "u1"},
+		CustomerEmail:    "pat@example.com",
+		CustomerName:     "Pat",
+		Address:          address,
+		TotalCents:       3165,
+		TaxCents:         165,
+		DiscountCents:    0,
+		StripeCustomerID: "cus_1",
+		PaymentIntentID:  "pi_1",
+	})
+
+	assert.Equal(t, "draft_abc", session.ID)
+	assert.Equal(t, int64(3165), session.AmountTotal)
+	assert.Equal(t, int64(165), session.TotalDetails.AmountTax)
+	assert.NotNil(t, session.TotalDetails.Breakdown, "nothing left for the webhook to fetch")
+	require.Len(t, session.LineItems.Data, 1)
+	item := session.LineItems.Data[0]
+	assert.Equal(t, "Dragon", item.Description)
+	assert.Equal(t, int64(1500), item.Price.UnitAmount)
+	assert.Equal(t, "p1", item.Price.Product.Metadata["product_id"])
+	assert.Equal(t, "cus_1", session.Customer.ID)
+	assert.Equal(t, "pi_1", session.PaymentIntent.ID)
+	assert.Equal(t, address, session.ShippingDetails.Address)
+	assert.Equal(t, "pat@example.com", session.CustomerDetails.Email)
+
+	amounts := SessionAmountsUSD(session)
+	assert.Equal(t, int64(3165), amounts.TotalCents)
+	assert.False(t, amounts.Converted())
+}
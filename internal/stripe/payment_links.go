@@ -0,0 +1,66 @@
+package stripe
+
+import (
+	"github.com/stripe/stripe-go/v80"
+	"github.com/stripe/stripe-go/v80/paymentlink"
+	"github.com/stripe/stripe-go/v80/price"
+)
+
+// CreateOrderPaymentLink makes a single-use Stripe payment link for what a change to
+// a paid order added, like extra items or dearer shipping. Checkout sessions from the
+// link carry the adjustment's ID in their metadata, so the webhook can find it. The
+// adjustment ID is the idempotency key, so a resubmitted form can't make two links.
+func CreateOrderPaymentLink(adjustmentID, orderID, description string, amountCents int64, redirectURL string) (*stripe.PaymentLink, error) {
+	metadata := map[string]string{
+		"order_adjustment_id": adjustmentID,
+		"order_id":            orderID,
+	}
+
+	priceParams := &stripe.PriceParams{
+		Currency:   stripe.String(catalogCurrency),
+		UnitAmount: stripe.Int64(amountCents),
+		ProductData: &stripe.PriceProductDataParams{
+			Name:     stripe.String(description),
+			Metadata: metadata,
+		},
+		Metadata: metadata,
+	}
+	priceParams.SetIdempotencyKey("order-adjustment-price-" + adjustmentID)
+	linePrice, err := price.New(priceParams)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.PaymentLinkParams{
+		LineItems: []*stripe.PaymentLinkLineItemParams{{
+			Price:    stripe.String(linePrice.ID),
+			Quantity: stripe.Int64(1),
+		}},
+		Restrictions: &stripe.PaymentLinkRestrictionsParams{
+			CompletedSessions: &stripe.PaymentLinkRestrictionsCompletedSessionsParams{
+				Limit: stripe.Int64(1),
+			},
+		},
+		PaymentIntentData: &stripe.PaymentLinkPaymentIntentDataParams{
+			Description: stripe.String(description),
+			Metadata:    metadata,
+		},
+		Metadata: metadata,
+	}
+	if redirectURL != "" {
+		params.AfterCompletion = &stripe.PaymentLinkAfterCompletionParams{
+			Type:     stripe.String(string(stripe.PaymentLinkAfterCompletionTypeRedirect)),
+			Redirect: &stripe.PaymentLinkAfterCompletionRedirectParams{URL: stripe.String(redirectURL)},
+		}
+	}
+	params.SetIdempotencyKey("order-adjustment-link-" + adjustmentID)
+
+	return paymentlink.New(params)
+}
+
+// DeactivatePaymentLink stops a payment link taking payments, for a change that was
+// withdrawn before the customer paid
+func DeactivatePaymentLink(paymentLinkID string) error {
+	_, err := paymentlink.Update(paymentLinkID, &stripe.PaymentLinkParams{Active: stripe.Bool(false)})
+	return err
+}
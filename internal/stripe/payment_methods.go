@@ -0,0 +1,40 @@
+package stripe
+
+import (
+	"github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+)
+
+// Afterpay only takes US orders in this range, and needs a shipping address
+const (
+	afterpayMinCents = 100
+	afterpayMaxCents = 400000
+)
+
+// CheckoutPaymentMethodTypes lists the payment_method_types for a checkout session
+// of amountCents. Nil leaves the choice to Stripe, using the methods turned on in the
+// Dashboard. Cards always come first; Apple Pay and Google Pay show with them on
+// devices that support them.
+func CheckoutPaymentMethodTypes(methods settings.PaymentMethods, amountCents int64, shipped bool) []*string {
+	if methods.Automatic {
+		return nil
+	}
+
+	types := CardPaymentMethodTypes()
+	if methods.Link {
+		types = append(types, stripe.String(string(stripe.PaymentMethodTypeLink)))
+	}
+	if methods.Klarna {
+		types = append(types, stripe.String(string(stripe.PaymentMethodTypeKlarna)))
+	}
+	if methods.Afterpay && shipped && amountCents >= afterpayMinCents && amountCents <= afterpayMaxCents {
+		types = append(types, stripe.String(string(stripe.PaymentMethodTypeAfterpayClearpay)))
+	}
+	return types
+}
+
+// CardPaymentMethodTypes is cards only, for when Stripe turns down the other methods
+func CardPaymentMethodTypes() []*string {
+	return []*string{stripe.String(string(stripe.PaymentMethodTypeCard))}
+}
@@ -0,0 +1,30 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+)
+
+func TestCheckoutPaymentMethodTypes(t *testing.T) {
+	names := func(types []*string) []string {
+		var out []string
+		for _, typ := range types {
+			out = append(out, stripe.StringValue(typ))
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"card"}, names(CheckoutPaymentMethodTypes(settings.PaymentMethods{}, 5000, true)))
+
+	all := settings.PaymentMethods{Link: true, Klarna: true, Afterpay: true}
+	assert.Equal(t, []string{"card", "link", "klarna", "afterpay_clearpay"}, names(CheckoutPaymentMethodTypes(all, 5000, true)))
+	assert.Equal(t, []string{"card", "link", "klarna"}, names(CheckoutPaymentMethodTypes(all, 5000, false)), "Afterpay needs a shipping address")
+	assert.Equal(t, []string{"card", "link", "klarna"}, names(CheckoutPaymentMethodTypes(all, 450000, true)), "over Afterpay's limit")
+
+	all.Automatic = true
+	assert.Nil(t, CheckoutPaymentMethodTypes(all, 5000, true), "Stripe chooses")
+}
@@ -87,3 +87,16 @@ func ValidatePromotionCode(code string) (*stripe.PromotionCode, error) {
 func GetPromotionCodeByID(id string) (*stripe.PromotionCode, error) {
 	return promotioncode.Get(id, nil)
 }
+
+// CreateCheckoutCoupon creates a single-use coupon for one checkout session, for a
+// promotion code whose discount was worked out by the store
+func CreateCheckoutCoupon(code string, amountOffCents int64) (*stripe.Coupon, error) {
+	params := &stripe.CouponParams{
+		Name:           stripe.String(code),
+		AmountOff:      stripe.Int64(amountOffCents),
+		Currency:       stripe.String(string(stripe.CurrencyUSD)),
+		Duration:       stripe.String(string(stripe.CouponDurationOnce)),
+		MaxRedemptions: stripe.Int64(1),
+	}
+	return coupon.New(params)
+}
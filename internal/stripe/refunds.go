@@ -0,0 +1,40 @@
+package stripe
+
+import (
+	"github.com/stripe/stripe-go/v80"
+	"github.com/stripe/stripe-go/v80/refund"
+)
+
+// RefundReturn refunds part of an order's payment for a return. The return ID is the
+// idempotency key, so a double-submitted refund form can't pay out twice.
+func RefundReturn(paymentIntentID string, amountCents int64, returnID, orderID string) (*stripe.Refund, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+		Amount:        stripe.Int64(amountCents),
+		Reason:        stripe.String(string(stripe.RefundReasonRequestedByCustomer)),
+		Metadata: map[string]string{
+			"return_id": returnID,
+			"order_id":  orderID,
+		},
+	}
+	params.SetIdempotencyKey("return-refund-" + returnID)
+
+	return refund.New(params)
+}
+
+// RefundOrder refunds some or all of an order's payment from the admin order page.
+// refundID is the local refund's ID and the idempotency key.
+func RefundOrder(paymentIntentID string, amountCents int64, refundID, orderID string) (*stripe.Refund, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+		Amount:        stripe.Int64(amountCents),
+		Reason:        stripe.String(string(stripe.RefundReasonRequestedByCustomer)),
+		Metadata: map[string]string{
+			"order_refund_id": refundID,
+			"order_id":        orderID,
+		},
+	}
+	params.SetIdempotencyKey("order-refund-" + refundID)
+
+	return refund.New(params)
+}
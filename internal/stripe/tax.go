@@ -0,0 +1,166 @@
+package stripe
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stripe/stripe-go/v80"
+	checkoutsession "github.com/stripe/stripe-go/v80/checkout/session"
+)
+
+// Tax reconciliation reads what Stripe Tax calculated from each order's Checkout
+// Session. Checkout records its tax transactions internally rather than as Tax API
+// transaction objects, so the session's total_details is the per-order record.
+
+// NontaxableTaxCode is Stripe Tax's category for lines that aren't taxed anywhere,
+// like a carbon offset
+const NontaxableTaxCode = "txcd_00000000"
+
+// A gift card pays for the tax too, so a Checkout Session it pays part of can't have
+// Stripe work out the tax after the card comes off. Its tax is worked out beforehand
+// and goes on as an untaxed line, with automatic tax off; the metadata keeps the
+// amount in USD and the calculation, which is recorded once the order is paid.
+const (
+	PrecalculatedTaxKey  = "tax_cents"
+	TaxCalculationKey    = "tax_calculation_id"
+	precalculatedTaxName = "Sales tax"
+)
+
+// CalculateCheckoutTax works out a checkout's tax before its session is made, as
+// CalculateDraftTax does for a draft. reference keeps a retried request from
+// calculating twice.
+func CalculateCheckoutTax(reference string, lines []DraftLine, amounts []int64, address *stripe.AddressParams) (*stripe.TaxCalculation, error) {
+	return calculateTax("checkout-tax-"+reference, lines, amounts, address)
+}
+
+// AddPrecalculatedTax puts tax worked out beforehand on a Checkout Session in place of
+// Stripe's automatic tax
+func AddPrecalculatedTax(params *stripe.CheckoutSessionParams, calc *stripe.TaxCalculation) {
+	params.AutomaticTax = &stripe.CheckoutSessionAutomaticTaxParams{Enabled: stripe.Bool(false)}
+	if calc.TaxAmountExclusive > 0 {
+		params.LineItems = append(params.LineItems, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:   stripe.String(catalogCurrency),
+				UnitAmount: stripe.Int64(calc.TaxAmountExclusive),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name:    stripe.String(precalculatedTaxName),
+					TaxCode: stripe.String(NontaxableTaxCode),
+				},
+			},
+			Quantity: stripe.Int64(1),
+		})
+	}
+	if params.Metadata == nil {
+		params.Metadata = make(map[string]string)
+	}
+	params.Metadata[PrecalculatedTaxKey] = strconv.FormatInt(calc.TaxAmountExclusive, 10)
+	params.Metadata[TaxCalculationKey] = calc.ID
+}
+
+// SessionTax is the tax Stripe charged on a Checkout Session
+type SessionTax struct {
+	TaxCents  int64
+	State     string // shipping address state, falling back to the billing address
+	Country   string
+	TaxStatus string // status of Stripe's automatic tax calculation
+}
+
+// GetSessionTax fetches the tax Stripe charged on a Checkout Session
+func GetSessionTax(sessionID string) (SessionTax, error) {
+	params := &stripe.CheckoutSessionParams{}
+	params.AddExpand("total_details")
+
+	session, err := checkoutsession.Get(sessionID, params)
+	if err != nil {
+		return SessionTax{}, err
+	}
+	return sessionTax(session), nil
+}
+
+func sessionTax(session *stripe.CheckoutSession) SessionTax {
+	// Orders record tax in USD, including ones paid in the customer's own currency
+	tax := SessionTax{TaxCents: SessionAmountsUSD(session).TaxCents}
+	if session.AutomaticTax != nil {
+		tax.TaxStatus = string(session.AutomaticTax.Status)
+	}
+
+	var address *stripe.Address
+	if session.ShippingDetails != nil && session.ShippingDetails.Address != nil {
+		address = session.ShippingDetails.Address
+	} else if session.CustomerDetails != nil && session.CustomerDetails.Address != nil {
+		address = session.CustomerDetails.Address
+	}
+	if address != nil {
+		tax.State = strings.ToUpper(strings.TrimSpace(address.State))
+		tax.Country = address.Country
+	}
+	return tax
+}
+
+// Tax reconciliation issues
+const (
+	TaxIssueNoSession     = "no_session"     // order has no Checkout Session to compare with
+	TaxIssueLookupFailed  = "lookup_failed"  // Stripe couldn't return the session
+	TaxIssueAmount        = "amount"         // tax amounts differ
+	TaxIssueState         = "state"          // Stripe taxed a different state
+	TaxIssueNotCalculated = "not_calculated" // Stripe's automatic tax didn't complete
+)
+
+// TaxOrder is a local order as the tax reconciliation sees it
+type TaxOrder struct {
+	OrderID      string
+	CustomerName string
+	State        string
+	TaxCents     int64
+	SessionID    string
+}
+
+// TaxReconciliationRow compares one order with its Checkout Session
+type TaxReconciliationRow struct {
+	TaxOrder
+	Stripe SessionTax
+	Issue  string // empty when the order matches Stripe
+	Error  string
+}
+
+// TaxReconciliation is a month of orders compared with Stripe
+type TaxReconciliation struct {
+	Rows           []TaxReconciliationRow
+	LocalTaxCents  int64
+	StripeTaxCents int64
+	Mismatches     int
+}
+
+// ReconcileTax compares each order's recorded tax with what Stripe charged. lookup
+// fetches a Checkout Session's tax, normally GetSessionTax.
+func ReconcileTax(orders []TaxOrder, lookup func(sessionID string) (SessionTax, error)) TaxReconciliation {
+	var result TaxReconciliation
+	for _, order := range orders {
+		row := TaxReconciliationRow{TaxOrder: order}
+		result.LocalTaxCents += order.TaxCents
+
+		if order.SessionID == "" {
+			row.Issue = TaxIssueNoSession
+		} else if tax, err := lookup(order.SessionID); err != nil {
+			row.Issue = TaxIssueLookupFailed
+			row.Error = err.Error()
+		} else {
+			row.Stripe = tax
+			result.StripeTaxCents += tax.TaxCents
+			switch {
+			case tax.TaxCents != order.TaxCents:
+				row.Issue = TaxIssueAmount
+			case tax.State != "" && !strings.EqualFold(tax.State, order.State):
+				row.Issue = TaxIssueState
+			case tax.TaxStatus != "" && tax.TaxStatus != string(stripe.CheckoutSessionAutomaticTaxStatusComplete):
+				row.Issue = TaxIssueNotCalculated
+			}
+		}
+
+		if row.Issue != "" {
+			result.Mismatches++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
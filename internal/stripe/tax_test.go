@@ -0,0 +1,82 @@
+package stripe
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v80"
+)
+
+func TestSessionTax(t *testing.T) {
+	tax := sessionTax(&stripe.CheckoutSession{
+		TotalDetails:    &stripe.CheckoutSessionTotalDetails{AmountTax: 412},
+		AutomaticTax:    &stripe.CheckoutSessionAutomaticTax{Status: stripe.CheckoutSessionAutomaticTaxStatusComplete},
+		ShippingDetails: &stripe.ShippingDetails{Address: &stripe.Address{State: "wi", Country: "US"}},
+		CustomerDetails: &stripe.CheckoutSessionCustomerDetails{Address: &stripe.Address{State: "MN", Country: "US"}},
+	})
+	assert.Equal(t, SessionTax{TaxCents: 412, State: "WI", Country: "US", TaxStatus: "complete"}, tax)
+
+	// Digital orders have no shipping address
+	tax = sessionTax(&stripe.CheckoutSession{
+		CustomerDetails: &stripe.CheckoutSessionCustomerDetails{Address: &stripe.Address{State: "MN", Country: "US"}},
+	})
+	assert.Equal(t, "MN", tax.State)
+	assert.Equal(t, int64(0), tax.TaxCents)
+}
+
+func TestAddPrecalculatedTax(t *testing.T) {
+	params := &stripe.CheckoutSessionParams{
+		AutomaticTax: &stripe.CheckoutSessionAutomaticTaxParams{Enabled: stripe.Bool(true)},
+		LineItems:    []*stripe.CheckoutSessionLineItemParams{{Quantity: stripe.Int64(1)}},
+	}
+	AddPrecalculatedTax(params, &stripe.TaxCalculation{ID: "taxcalc_1", TaxAmountExclusive: 412})
+	assert.False(t, stripe.BoolValue(params.AutomaticTax.Enabled))
+	if assert.Len(t, params.LineItems, 2) {
+		line := params.LineItems[1]
+		assert.Equal(t, int64(412), stripe.Int64Value(line.PriceData.UnitAmount))
+		assert.Equal(t, NontaxableTaxCode, stripe.StringValue(line.PriceData.ProductData.TaxCode))
+	}
+	assert.Equal(t, map[string]string{PrecalculatedTaxKey: "412", TaxCalculationKey: "taxcalc_1"}, params.Metadata)
+
+	// No tax owed means no line, but Stripe still mustn't add its own
+	params = &stripe.CheckoutSessionParams{Metadata: map[string]string{"user_id": "u1"}}
+	AddPrecalculatedTax(params, &stripe.TaxCalculation{ID: "taxcalc_2"})
+	assert.Empty(t, params.LineItems)
+	assert.False(t, stripe.BoolValue(params.AutomaticTax.Enabled))
+	assert.Equal(t, "0", params.Metadata[PrecalculatedTaxKey])
+}
+
+func TestReconcileTax(t *testing.T) {
+	sessions := map[string]SessionTax{
+		"cs_ok":     {TaxCents: 500, State: "WI", TaxStatus: "complete"},
+		"cs_amount": {TaxCents: 450, State: "WI", TaxStatus: "complete"},
+		"cs_state":  {TaxCents: 300, State: "MN", TaxStatus: "complete"},
+		"cs_failed": {TaxCents: 0, State: "WI", TaxStatus: "failed"},
+	}
+	lookup := func(id string) (SessionTax, error) {
+		tax, ok := sessions[id]
+		if !ok {
+			return SessionTax{}, errors.New("no such checkout session")
+		}
+		return tax, nil
+	}
+
+	result := ReconcileTax([]TaxOrder{
+		{OrderID: "o1", State: "WI", TaxCents: 500, SessionID: "cs_ok"},
+		{OrderID: "o2", State: "WI", TaxCents: 500, SessionID: "cs_amount"},
+		{OrderID: "o3", State: "WI", TaxCents: 300, SessionID: "cs_state"},
+		{OrderID: "o4", State: "WI", TaxCents: 0, SessionID: "cs_failed"},
+		{OrderID: "o5", State: "WI", TaxCents: 100},
+		{OrderID: "o6", State: "WI", TaxCents: 100, SessionID: "cs_gone"},
+	}, lookup)
+
+	issues := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		issues = append(issues, row.Issue)
+	}
+	assert.Equal(t, []string{"", TaxIssueAmount, TaxIssueState, TaxIssueNotCalculated, TaxIssueNoSession, TaxIssueLookupFailed}, issues)
+	assert.Equal(t, 5, result.Mismatches)
+	assert.Equal(t, int64(1500), result.LocalTaxCents)
+	assert.Equal(t, int64(1250), result.StripeTaxCents)
+}
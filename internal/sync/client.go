@@ -112,7 +112,7 @@ type ImageResponse struct {
 }
 
 type SyncResult struct {
-	Action    string           `json:"action"` // "created" or "updated"
+	Action    string           `json:"action"` // "created", "updated" or "conflict"
 	ProductID string           `json:"product_id"`
 	Product   *ProductResponse `json:"product"`
 	Images    []ImageResponse  `json:"images,omitempty"`
@@ -138,183 +138,88 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 	return c.httpClient.Do(req)
 }
 
-func (c *Client) GetProductBySourceURL(ctx context.Context, sourceURL string) (*ProductResponse, error) {
-	path := "/api/products/by-source?source_url=" + sourceURL
-	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, "")
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	var product ProductResponse
-	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
-	}
-
-	return &product, nil
-}
-
-func (c *Client) CreateProduct(ctx context.Context, req ProductRequest) (*ProductResponse, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
-
-	resp, err := c.doRequest(ctx, http.MethodPost, "/api/products", bytes.NewReader(body), "application/json")
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var product ProductResponse
-	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+// SyncProduct pushes a product and its images to production in one request. The
+// server upserts by source URL or slug and rejects slug/SKU conflicts with 409.
+func (c *Client) SyncProduct(ctx context.Context, req ProductRequest, imagePaths []string) (*SyncResult, error) {
+	if !c.IsConfigured() {
+		return nil, fmt.Errorf("sync client not configured: PRODUCTION_API_KEY not set")
 	}
 
-	return &product, nil
-}
-
-func (c *Client) UpdateProduct(ctx context.Context, productID string, req ProductRequest) (*ProductResponse, error) {
-	body, err := json.Marshal(req)
+	payload, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	path := "/api/products/" + productID
-	resp, err := c.doRequest(ctx, http.MethodPut, path, bytes.NewReader(body), "application/json")
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var product ProductResponse
-	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
-	}
-
-	return &product, nil
-}
-
-func (c *Client) UploadImage(ctx context.Context, productID, imagePath string, displayOrder int, isPrimary bool) (*ImageResponse, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return nil, fmt.Errorf("open image file: %w", err)
-	}
-	defer file.Close()
-
 	var body bytes.Buffer
 	writer := multipart.NewWriter(&body)
-
-	part, err := writer.CreateFormFile("image", filepath.Base(imagePath))
-	if err != nil {
-		return nil, fmt.Errorf("create form file: %w", err)
+	if err := writer.WriteField("product", string(payload)); err != nil {
+		return nil, fmt.Errorf("write product field: %w", err)
 	}
 
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("copy file content: %w", err)
-	}
-
-	_ = writer.WriteField("display_order", fmt.Sprintf("%d", displayOrder))
-	if isPrimary {
-		_ = writer.WriteField("is_primary", "true")
+	for _, imagePath := range imagePaths {
+		if err := addImagePart(writer, imagePath); err != nil {
+			slog.Warn("skipping image", "error", err, "path", imagePath)
+		}
 	}
 
 	if err := writer.Close(); err != nil {
 		return nil, fmt.Errorf("close multipart writer: %w", err)
 	}
 
-	path := "/api/products/" + productID + "/images"
-	resp, err := c.doRequest(ctx, http.MethodPost, path, &body, writer.FormDataContentType())
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/sync/products", &body, writer.FormDataContentType())
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusConflict {
+		var conflict SyncResult
+		if err := json.NewDecoder(resp.Body).Decode(&conflict); err != nil {
+			return nil, fmt.Errorf("conflict (could not decode response: %w)", err)
+		}
+		return nil, fmt.Errorf("conflict: %s", conflict.Error)
+	}
+
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
-	var image ImageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&image); err != nil {
+	var result SyncResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
 
-	return &image, nil
-}
-
-func (c *Client) SyncProduct(ctx context.Context, req ProductRequest, imagePaths []string) (*SyncResult, error) {
-	if !c.IsConfigured() {
-		return nil, fmt.Errorf("sync client not configured: PRODUCTION_API_KEY not set")
+	if result.Error != "" {
+		slog.Warn("product synced with warnings", "product_id", result.ProductID, "warning", result.Error)
 	}
 
-	result := &SyncResult{}
+	slog.Info("product synced to production",
+		"action", result.Action,
+		"product_id", result.ProductID,
+		"name", req.Name,
+		"images_uploaded", len(result.Images),
+	)
+
+	return &result, nil
+}
 
-	existing, err := c.GetProductBySourceURL(ctx, req.SourceURL)
+func addImagePart(writer *multipart.Writer, imagePath string) error {
+	file, err := os.Open(imagePath)
 	if err != nil {
-		slog.Error("failed to check for existing product", "error", err, "source_url", req.SourceURL)
-		return nil, fmt.Errorf("check existing product: %w", err)
+		return fmt.Errorf("open image file: %w", err)
 	}
+	defer file.Close()
 
-	var product *ProductResponse
-	if existing != nil {
-		slog.Debug("product exists, updating", "product_id", existing.ID, "source_url", req.SourceURL)
-		product, err = c.UpdateProduct(ctx, existing.ID, req)
-		if err != nil {
-			slog.Error("failed to update product", "error", err, "product_id", existing.ID)
-			return nil, fmt.Errorf("update product: %w", err)
-		}
-		result.Action = "updated"
-	} else {
-		slog.Debug("product does not exist, creating", "source_url", req.SourceURL)
-		product, err = c.CreateProduct(ctx, req)
-		if err != nil {
-			slog.Error("failed to create product", "error", err, "name", req.Name)
-			return nil, fmt.Errorf("create product: %w", err)
-		}
-		result.Action = "created"
+	part, err := writer.CreateFormFile("images", filepath.Base(imagePath))
+	if err != nil {
+		return fmt.Errorf("create form file: %w", err)
 	}
 
-	result.ProductID = product.ID
-	result.Product = product
-
-	for i, imagePath := range imagePaths {
-		isPrimary := i == 0
-		image, err := c.UploadImage(ctx, product.ID, imagePath, i, isPrimary)
-		if err != nil {
-			slog.Warn("failed to upload image", "error", err, "path", imagePath, "product_id", product.ID)
-			continue
-		}
-		result.Images = append(result.Images, *image)
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("copy file content: %w", err)
 	}
-
-	slog.Info("product synced to production",
-		"action", result.Action,
-		"product_id", result.ProductID,
-		"name", product.Name,
-		"images_uploaded", len(result.Images),
-	)
-
-	return result, nil
+	return nil
 }
 
 func (c *Client) TestConnection(ctx context.Context) error {
@@ -322,7 +227,7 @@ func (c *Client) TestConnection(ctx context.Context) error {
 		return fmt.Errorf("PRODUCTION_API_KEY not set")
 	}
 
-	resp, err := c.doRequest(ctx, http.MethodGet, "/api/categories", nil, "")
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/sync/health", nil, "")
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// DBTX is the database sqlc's queries run against, matching its generated interface
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// DB wraps a database so each query made while a span is in progress gets a child
+// span named for its sqlc query, like "db GetProduct". Queries outside a traced
+// request, such as from background jobs, aren't traced, and neither are queries run
+// in a transaction through Queries.WithTx.
+func DB(inner DBTX) DBTX {
+	return &tracedDB{inner: inner}
+}
+
+type tracedDB struct {
+	inner DBTX
+}
+
+func (d *tracedDB) start(ctx context.Context, query string) (context.Context, *Span) {
+	if SpanFromContext(ctx) == nil {
+		return ctx, nil
+	}
+	name := QueryName(query)
+	return Start(ctx, ComponentDB, "db "+name, KindClient,
+		String("db.system", "sqlite"),
+		String("db.operation.name", name),
+	)
+}
+
+func (d *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+	result, err := d.inner.ExecContext(ctx, query, args...)
+	if err != nil {
+		span.SetError(err)
+	} else if rows, rowsErr := result.RowsAffected(); rowsErr == nil {
+		span.SetAttributes(Int("db.rows_affected", int(rows)))
+	}
+	return result, err
+}
+
+func (d *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.inner.PrepareContext(ctx, query)
+}
+
+// QueryContext's span covers running the query, not reading the rows afterwards
+func (d *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+	rows, err := d.inner.QueryContext(ctx, query, args...)
+	span.SetError(err)
+	return rows, err
+}
+
+func (d *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+	row := d.inner.QueryRowContext(ctx, query, args...)
+	span.SetError(row.Err())
+	return row
+}
+
+// QueryName is the name sqlc gives a query in its "-- name: GetProduct :one" comment,
+// or for other SQL its first keyword
+func QueryName(query string) string {
+	if i := strings.Index(query, "-- name: "); i >= 0 {
+		if fields := strings.Fields(query[i+len("-- name: "):]); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "query"
+}
@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler adds trace_id and span_id to records logged with a context that has a
+// span in progress, e.g. slog.InfoContext(c.Request().Context(), ...), so a request's
+// log lines can be found from its trace and the other way round
+func LogHandler(h slog.Handler) slog.Handler {
+	return &logHandler{inner: h}
+}
+
+type logHandler struct {
+	inner slog.Handler
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if span := SpanFromContext(ctx); span != nil {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{inner: h.inner.WithGroup(name)}
+}
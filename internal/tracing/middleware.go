@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware starts a server span for each request, continuing the caller's trace
+// when the request has a traceparent header, and puts it on the request's context
+// for the spans made while handling it. Static files aren't traced.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tracer := Default()
+			req := c.Request()
+			if tracer == nil || strings.HasPrefix(req.URL.Path, "/public/") {
+				return next(c)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = req.URL.Path
+			}
+			ctx := ContextWithTraceparent(req.Context(), req.Header.Get("traceparent"))
+			ctx, span := tracer.Start(ctx, ComponentHTTP, req.Method+" "+route, KindServer,
+				String("http.request.method", req.Method),
+				String("http.route", route),
+				String("url.path", req.URL.Path),
+				String("client.address", c.RealIP()),
+				String("user_agent.original", req.UserAgent()),
+			)
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+
+			// The error handler writes the response after this returns, so an error's
+			// status is the one it will send
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			span.SetAttributes(Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				if err == nil {
+					err = fmt.Errorf("%d %s", status, http.StatusText(status))
+				}
+				span.SetError(err)
+			}
+			return err
+		}
+	}
+}
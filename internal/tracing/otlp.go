@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpQueueSize is how many traces can wait to be exported; more are dropped rather
+// than slowing requests down while the collector is unreachable
+const otlpQueueSize = 256
+
+// otlpExporter posts finished traces to an OTLP/HTTP collector as JSON, one trace at
+// a time from a background goroutine
+type otlpExporter struct {
+	host     string
+	endpoint string
+	headers  map[string]string
+	resource otlpResource
+	client   *http.Client
+
+	queue chan Trace
+}
+
+func newOTLPExporter(endpoint string, headers map[string]string, serviceName, environment, version string) *otlpExporter {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil {
+		host = u.Host
+	}
+	resource := otlpResource{Attributes: []otlpAttr{otlpAttribute(String("service.name", serviceName))}}
+	if environment != "" {
+		resource.Attributes = append(resource.Attributes, otlpAttribute(String("deployment.environment.name", environment)))
+	}
+	if version != "" {
+		resource.Attributes = append(resource.Attributes, otlpAttribute(String("service.version", version)))
+	}
+
+	e := &otlpExporter{
+		host:     host,
+		endpoint: endpoint,
+		headers:  headers,
+		resource: resource,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan Trace, otlpQueueSize),
+	}
+	go e.run()
+	return e
+}
+
+func (e *otlpExporter) send(trace Trace) {
+	select {
+	case e.queue <- trace:
+	default:
+		slog.Warn("trace export queue is full, dropping trace", "trace_id", trace.Root.TraceID.String())
+	}
+}
+
+func (e *otlpExporter) run() {
+	for trace := range e.queue {
+		if err := e.post(trace); err != nil {
+			slog.Warn("failed to export trace", "error", err, "trace_id", trace.Root.TraceID.String(), "host", e.host)
+		}
+	}
+}
+
+func (e *otlpExporter) post(trace Trace) error {
+	body, err := json.Marshal(e.payload(trace))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("trace collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              Kind       `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []otlpAttr `json:"attributes,omitempty"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpStatusError is STATUS_CODE_ERROR
+const otlpStatusError = 2
+
+// payload shapes a trace for the collector. OTLP's JSON encoding takes IDs as hex and
+// 64-bit integers as strings.
+func (e *otlpExporter) payload(trace Trace) otlpRequest {
+	scope := otlpScopeSpans{Spans: make([]otlpSpan, 0, len(trace.Spans)+1)}
+	scope.Scope.Name = "github.com/loganlanou/logans3d-v4/internal/tracing"
+	scope.Spans = append(scope.Spans, otlpSpanData(trace.Root))
+	for _, span := range trace.Spans {
+		scope.Spans = append(scope.Spans, otlpSpanData(span))
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource,
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+}
+
+func otlpSpanData(span SpanData) otlpSpan {
+	result := otlpSpan{
+		TraceID:           span.TraceID.String(),
+		SpanID:            span.SpanID.String(),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.Start.Add(span.Duration).UnixNano(), 10),
+	}
+	if !span.ParentID.IsZero() {
+		result.ParentSpanID = span.ParentID.String()
+	}
+	for _, attr := range span.Attrs {
+		result.Attributes = append(result.Attributes, otlpAttribute(attr))
+	}
+	if span.Error != "" {
+		result.Status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+	}
+	return result
+}
+
+func otlpAttribute(attr Attr) otlpAttr {
+	switch value := attr.Value.(type) {
+	case int64:
+		return otlpAttr{Key: attr.Key, Value: map[string]any{"intValue": strconv.FormatInt(value, 10)}}
+	case bool:
+		return otlpAttr{Key: attr.Key, Value: map[string]any{"boolValue": value}}
+	default:
+		return otlpAttr{Key: attr.Key, Value: map[string]any{"stringValue": fmt.Sprint(value)}}
+	}
+}
+
+// ParseHeaders reads OTEL_EXPORTER_OTLP_HEADERS, a comma-separated list of
+// key=value pairs with URL-encoded values, e.g. "x-honeycomb-team=abc,x-dataset=shop"
+func ParseHeaders(list string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers
+}
@@ -0,0 +1,495 @@
+// Package tracing records spans for requests and the database queries, Stripe and
+// EasyPost calls made while serving them. Finished traces are kept in a ring buffer
+// for the developer tools, which list the slowest, and exported to an OpenTelemetry
+// collector over OTLP/HTTP when an endpoint is configured.
+//
+// Spans follow the OpenTelemetry model (W3C trace and span IDs, server and client
+// kinds, semantic convention attribute names) so collectors, Jaeger and Tempo show
+// them like any other service's.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultBufferSize is how many finished traces the ring buffer keeps
+	DefaultBufferSize = 200
+	// maxSpansPerTrace caps the spans kept for one trace, so a request that loops over
+	// queries can't hold on to unbounded memory. Later spans are counted as dropped.
+	maxSpansPerTrace = 500
+)
+
+// Components the spans come from, used to break a trace's time down
+const (
+	ComponentHTTP     = "http"
+	ComponentDB       = "db"
+	ComponentStripe   = "stripe"
+	ComponentEasyPost = "easypost"
+)
+
+// TraceID identifies a trace across services
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsZero() bool   { return id == TraceID{} }
+
+// SpanID identifies one span within a trace
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) IsZero() bool   { return id == SpanID{} }
+
+// Kind is the OTLP span kind
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// Attr is a span attribute. Value is a string, int64 or bool.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attr    { return Attr{Key: key, Value: value} }
+func Int(key string, value int) Attr   { return Attr{Key: key, Value: int64(value)} }
+func Bool(key string, value bool) Attr { return Attr{Key: key, Value: value} }
+
+// SpanData is a finished span
+type SpanData struct {
+	TraceID   TraceID
+	SpanID    SpanID
+	ParentID  SpanID
+	Name      string
+	Kind      Kind
+	Component string
+	Start     time.Time
+	Duration  time.Duration
+	Attrs     []Attr
+	// Error is why the span failed, empty when it didn't
+	Error string
+}
+
+// Trace is a finished request, or other root span, with the spans made under it
+type Trace struct {
+	Root SpanData
+	// Spans are the root's descendants in the order they started
+	Spans []SpanData
+	// Dropped is how many spans went over the per-trace cap
+	Dropped int
+	// Sampled is set when the trace was exported
+	Sampled bool
+}
+
+// ComponentTime is how much of a trace one component accounts for
+type ComponentTime struct {
+	Component string
+	Count     int
+	Duration  time.Duration
+}
+
+// Breakdown sums the trace's spans by component, most time first. Overlapping spans
+// are both counted, so the total can pass the root's duration.
+func (t Trace) Breakdown() []ComponentTime {
+	var result []ComponentTime
+	index := make(map[string]int)
+	for _, span := range t.Spans {
+		i, ok := index[span.Component]
+		if !ok {
+			i = len(result)
+			index[span.Component] = i
+			result = append(result, ComponentTime{Component: span.Component})
+		}
+		result[i].Count++
+		result[i].Duration += span.Duration
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Duration > result[j].Duration })
+	return result
+}
+
+// Offset is how long after the root started a span did
+func (t Trace) Offset(span SpanData) time.Duration {
+	return span.Start.Sub(t.Root.Start)
+}
+
+// Config sets up a Tracer
+type Config struct {
+	ServiceName string
+	Environment string
+	Version     string
+	// Endpoint is the OTLP/HTTP traces URL, such as http://collector:4318/v1/traces;
+	// empty keeps traces local
+	Endpoint string
+	Headers  map[string]string
+	// SampleRatio is the share of new traces exported, from 0 to 1. Traces continued
+	// from a caller follow the caller's decision. Every trace is kept locally.
+	SampleRatio float64
+	BufferSize  int
+}
+
+// Tracer starts spans and keeps finished traces
+type Tracer struct {
+	sampleRatio float64
+
+	mu     sync.Mutex
+	traces []Trace
+	next   int
+	total  int64
+
+	exporter *otlpExporter
+}
+
+// New makes a Tracer. It fails only on an endpoint it can't parse.
+func New(config Config) (*Tracer, error) {
+	size := config.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	t := &Tracer{
+		sampleRatio: min(max(config.SampleRatio, 0), 1),
+		traces:      make([]Trace, 0, size),
+	}
+	if config.Endpoint != "" {
+		u, err := url.Parse(config.Endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid OTLP traces endpoint %q", config.Endpoint)
+		}
+		t.exporter = newOTLPExporter(u.String(), config.Headers, config.ServiceName, config.Environment, config.Version)
+	}
+	return t, nil
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault makes t the tracer the middleware, transports and database wrapper use
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default is the tracer set with SetDefault, or nil, which records nothing
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Start starts a span with the default tracer
+func Start(ctx context.Context, component, name string, kind Kind, attrs ...Attr) (context.Context, *Span) {
+	return Default().Start(ctx, component, name, kind, attrs...)
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// remoteParent is a span in a calling service, from its traceparent header
+type remoteParent struct {
+	traceID TraceID
+	spanID  SpanID
+	sampled bool
+}
+
+// SpanFromContext is the span in progress, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// liveTrace collects the spans of a trace until its root ends
+type liveTrace struct {
+	mu      sync.Mutex
+	spans   []SpanData
+	dropped int
+	sampled bool
+	done    bool
+}
+
+// Span is a span in progress. A nil Span, from a nil Tracer, ignores every call.
+type Span struct {
+	tracer *Tracer
+	trace  *liveTrace
+	root   bool
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// Start starts a span under the one in ctx, or under the remote parent from a
+// traceparent header, or as the root of a new trace
+func (t *Tracer) Start(ctx context.Context, component, name string, kind Kind, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			SpanID:    newSpanID(),
+			Name:      name,
+			Kind:      kind,
+			Component: component,
+			Start:     time.Now(),
+			Attrs:     attrs,
+		},
+	}
+	if parent := SpanFromContext(ctx); parent != nil && parent.tracer == t {
+		span.trace = parent.trace
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentID = parent.data.SpanID
+	} else {
+		span.root = true
+		span.trace = &liveTrace{}
+		if remote, ok := ctx.Value(remoteKey{}).(remoteParent); ok {
+			span.data.TraceID = remote.traceID
+			span.data.ParentID = remote.spanID
+			span.trace.sampled = remote.sampled
+		} else {
+			span.data.TraceID = newTraceID()
+			span.trace.sampled = t.sampleRatio >= 1 || mathrand.Float64() < t.sampleRatio
+		}
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// TraceID is the span's trace, or zero for a nil span
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+	return s.data.TraceID
+}
+
+// SpanID is the span's own ID, or zero for a nil span
+func (s *Span) SpanID() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+	return s.data.SpanID
+}
+
+// Sampled reports whether the span's trace is exported
+func (s *Span) Sampled() bool {
+	if s == nil {
+		return false
+	}
+	return s.trace.sampled
+}
+
+// SetName renames the span, e.g. once the route is known
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// SetError marks the span failed
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Error = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span. Ending the root records the trace; spans that end after
+// their root, such as from goroutines it started, are left out.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.Duration = time.Since(s.data.Start)
+	data := s.data
+	s.mu.Unlock()
+
+	trace := s.trace
+	trace.mu.Lock()
+	if trace.done {
+		trace.mu.Unlock()
+		return
+	}
+	if !s.root {
+		if len(trace.spans) < maxSpansPerTrace {
+			trace.spans = append(trace.spans, data)
+		} else {
+			trace.dropped++
+		}
+		trace.mu.Unlock()
+		return
+	}
+	trace.done = true
+	spans := trace.spans
+	dropped := trace.dropped
+	trace.mu.Unlock()
+
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+	s.tracer.record(Trace{Root: data, Spans: spans, Dropped: dropped, Sampled: trace.sampled})
+}
+
+func (t *Tracer) record(trace Trace) {
+	t.mu.Lock()
+	if len(t.traces) < cap(t.traces) {
+		t.traces = append(t.traces, trace)
+	} else {
+		t.traces[t.next] = trace
+	}
+	t.next = (t.next + 1) % cap(t.traces)
+	t.total++
+	t.mu.Unlock()
+
+	if trace.Sampled && t.exporter != nil {
+		t.exporter.send(trace)
+	}
+}
+
+// Recent lists buffered traces, newest first
+func (t *Tracer) Recent() []Trace {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	traces := make([]Trace, 0, len(t.traces))
+	for i := 1; i <= len(t.traces); i++ {
+		traces = append(traces, t.traces[(t.next-i+len(t.traces))%len(t.traces)])
+	}
+	return traces
+}
+
+// Slowest lists up to n buffered traces, longest first
+func (t *Tracer) Slowest(n int) []Trace {
+	traces := t.Recent()
+	sort.SliceStable(traces, func(i, j int) bool { return traces[i].Root.Duration > traces[j].Root.Duration })
+	if len(traces) > n {
+		traces = traces[:n]
+	}
+	return traces
+}
+
+// Find looks up a buffered trace by its hex ID
+func (t *Tracer) Find(traceID string) (Trace, bool) {
+	for _, trace := range t.Recent() {
+		if trace.Root.TraceID.String() == traceID {
+			return trace, true
+		}
+	}
+	return Trace{}, false
+}
+
+// Total is how many traces have finished since startup, including those the buffer
+// has since dropped
+func (t *Tracer) Total() int64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// Remote is the host traces are exported to, or "" when they're only kept locally
+func (t *Tracer) Remote() string {
+	if t == nil || t.exporter == nil {
+		return ""
+	}
+	return t.exporter.host
+}
+
+// SampleRatio is the configured share of new traces exported
+func (t *Tracer) SampleRatio() float64 {
+	if t == nil {
+		return 0
+	}
+	return t.sampleRatio
+}
+
+// ParseTraceparent reads a W3C traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func ParseTraceparent(header string) (TraceID, SpanID, bool, error) {
+	var traceID TraceID
+	var spanID SpanID
+	if len(header) < 55 || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return traceID, spanID, false, errors.New("malformed traceparent")
+	}
+	version, err := hex.DecodeString(header[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(header) != 55) {
+		return traceID, spanID, false, errors.New("unsupported traceparent version")
+	}
+	if _, err := hex.Decode(traceID[:], []byte(header[3:35])); err != nil || traceID.IsZero() {
+		return traceID, spanID, false, errors.New("invalid traceparent trace ID")
+	}
+	if _, err := hex.Decode(spanID[:], []byte(header[36:52])); err != nil || spanID.IsZero() {
+		return traceID, spanID, false, errors.New("invalid traceparent parent ID")
+	}
+	flags, err := hex.DecodeString(header[53:55])
+	if err != nil {
+		return traceID, spanID, false, errors.New("invalid traceparent flags")
+	}
+	return traceID, spanID, flags[0]&1 == 1, nil
+}
+
+// ContextWithTraceparent continues the trace in a traceparent header, if it's valid,
+// for the next span started from ctx
+func ContextWithTraceparent(ctx context.Context, header string) context.Context {
+	if header == "" {
+		return ctx
+	}
+	traceID, spanID, sampled, err := ParseTraceparent(header)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, remoteParent{traceID: traceID, spanID: spanID, sampled: sampled})
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	if _, err := rand.Read(id[:]); err != nil {
+		binaryTime(id[8:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	if _, err := rand.Read(id[:]); err != nil {
+		binaryTime(id[:])
+	}
+	return id
+}
+
+func binaryTime(b []byte) {
+	n := time.Now().UnixNano()
+	for i := range b {
+		b[i] = byte(n >> (8 * i))
+	}
+}
@@ -0,0 +1,233 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, sampled, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", spanID.String())
+	assert.True(t, sampled)
+
+	_, _, sampled, err = ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	assert.False(t, sampled)
+
+	for _, header := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		_, _, _, err := ParseTraceparent(header)
+		assert.Error(t, err, header)
+	}
+}
+
+func TestSpansMakeTraces(t *testing.T) {
+	tracer, err := New(Config{BufferSize: 2, SampleRatio: 1})
+	require.NoError(t, err)
+
+	ctx, root := tracer.Start(context.Background(), ComponentHTTP, "GET /cart", KindServer)
+	_, query := tracer.Start(ctx, ComponentDB, "db GetCart", KindClient)
+	query.End()
+	_, call := tracer.Start(ctx, ComponentStripe, "stripe POST /v1/checkout/sessions", KindClient)
+	call.SetError(errors.New("card declined"))
+	call.End()
+	root.End()
+	root.End()
+
+	recent := tracer.Recent()
+	require.Len(t, recent, 1, "ending a span twice records it once")
+	trace := recent[0]
+	assert.Equal(t, "GET /cart", trace.Root.Name)
+	assert.True(t, trace.Root.ParentID.IsZero())
+	require.Len(t, trace.Spans, 2)
+	for _, span := range trace.Spans {
+		assert.Equal(t, trace.Root.TraceID, span.TraceID)
+		assert.Equal(t, trace.Root.SpanID, span.ParentID)
+	}
+	assert.Equal(t, "card declined", trace.Spans[1].Error)
+	assert.True(t, trace.Sampled)
+
+	breakdown := trace.Breakdown()
+	require.Len(t, breakdown, 2)
+	assert.Equal(t, 1, breakdown[0].Count)
+
+	// A span ending after its root is left out rather than starting a new trace
+	_, late := tracer.Start(ctx, ComponentDB, "db Late", KindClient)
+	late.End()
+	assert.Len(t, tracer.Recent(), 1)
+
+	// A caller's trace is continued, and its sampling decision followed
+	remote := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, continued := tracer.Start(remote, ComponentHTTP, "POST /webhooks/stripe", KindServer)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", continued.TraceID().String())
+	assert.False(t, continued.Sampled())
+	continued.End()
+
+	found, ok := tracer.Find("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.True(t, ok)
+	assert.Equal(t, "00f067aa0ba902b7", found.Root.ParentID.String())
+}
+
+func TestSlowest(t *testing.T) {
+	tracer, err := New(Config{BufferSize: 3})
+	require.NoError(t, err)
+
+	for _, d := range []time.Duration{4, 1, 3, 2} {
+		tracer.record(Trace{Root: SpanData{Name: d.String(), Duration: d * time.Millisecond}})
+	}
+	slowest := tracer.Slowest(2)
+	require.Len(t, slowest, 2)
+	assert.Equal(t, 3*time.Millisecond, slowest[0].Root.Duration, "the oldest trace has left the buffer")
+	assert.Equal(t, 2*time.Millisecond, slowest[1].Root.Duration)
+	assert.Equal(t, int64(4), tracer.Total())
+
+	var none *Tracer
+	assert.Empty(t, none.Slowest(5))
+	_, span := none.Start(context.Background(), ComponentHTTP, "GET /", KindServer)
+	span.End()
+}
+
+func TestMiddleware(t *testing.T) {
+	tracer, err := New(Config{SampleRatio: 1})
+	require.NoError(t, err)
+	SetDefault(tracer)
+	defer SetDefault(nil)
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/orders/:id", func(c echo.Context) error {
+		assert.NotNil(t, SpanFromContext(c.Request().Context()))
+		return echo.NewHTTPError(http.StatusBadGateway, "stripe is down")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	recent := tracer.Recent()
+	require.Len(t, recent, 1)
+	root := recent[0].Root
+	assert.Equal(t, "GET /orders/:id", root.Name)
+	assert.Equal(t, KindServer, root.Kind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.TraceID.String())
+	assert.Contains(t, root.Attrs, Int("http.response.status_code", http.StatusBadGateway))
+	assert.NotEmpty(t, root.Error)
+}
+
+func TestTransportAndDB(t *testing.T) {
+	tracer, err := New(Config{})
+	require.NoError(t, err)
+	SetDefault(tracer)
+	defer SetDefault(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("traceparent"), "trace headers aren't sent to third parties")
+	}))
+	defer server.Close()
+
+	sqliteDB, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer sqliteDB.Close()
+	database := DB(sqliteDB)
+
+	// Queries outside a traced request aren't traced
+	_, err = database.ExecContext(context.Background(), "CREATE TABLE carts (id TEXT)")
+	require.NoError(t, err)
+	assert.Empty(t, tracer.Recent())
+
+	ctx, root := tracer.Start(context.Background(), ComponentHTTP, "POST /checkout", KindServer)
+	_, err = database.ExecContext(ctx, "-- name: CreateCart :exec\nINSERT INTO carts (id) VALUES (?)", "cart-1")
+	require.NoError(t, err)
+	var id string
+	require.NoError(t, database.QueryRowContext(ctx, "SELECT id FROM carts").Scan(&id))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/v1/checkout/sessions/cs_test_a1b2c3", nil)
+	require.NoError(t, err)
+	resp, err := HTTPClient(ComponentStripe, time.Second).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	root.End()
+
+	spans := tracer.Recent()[0].Spans
+	require.Len(t, spans, 3)
+	assert.Equal(t, "db CreateCart", spans[0].Name)
+	assert.Contains(t, spans[0].Attrs, Int("db.rows_affected", 1))
+	assert.Equal(t, "db SELECT", spans[1].Name)
+	assert.Equal(t, "stripe POST /v1/checkout/sessions/{id}", spans[2].Name)
+	assert.Equal(t, ComponentStripe, spans[2].Component)
+}
+
+func TestOTLPExport(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		apiKey = r.Header.Get("X-Api-Key")
+		var payload map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	tracer, err := New(Config{
+		ServiceName: "logans3d",
+		Environment: "production",
+		Endpoint:    server.URL + "/v1/traces",
+		Headers:     map[string]string{"X-Api-Key": "secret"},
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	ctx, root := tracer.Start(context.Background(), ComponentHTTP, "GET /shop", KindServer, Int("http.response.status_code", 200))
+	_, query := tracer.Start(ctx, ComponentDB, "db ListProducts", KindClient, Bool("cached", false))
+	query.SetError(errors.New("database is locked"))
+	query.End()
+	root.End()
+
+	select {
+	case payload := <-received:
+		resourceSpans := payload["resourceSpans"].([]any)[0].(map[string]any)
+		resource := resourceSpans["resource"].(map[string]any)["attributes"].([]any)
+		assert.Equal(t, map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "logans3d"}}, resource[0])
+
+		spans := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)
+		require.Len(t, spans, 2)
+		rootSpan := spans[0].(map[string]any)
+		assert.Equal(t, root.TraceID().String(), rootSpan["traceId"])
+		assert.Equal(t, float64(KindServer), rootSpan["kind"])
+		assert.NotContains(t, rootSpan, "parentSpanId")
+		assert.Equal(t, map[string]any{"intValue": "200"}, rootSpan["attributes"].([]any)[0].(map[string]any)["value"])
+
+		querySpan := spans[1].(map[string]any)
+		assert.Equal(t, root.SpanID().String(), querySpan["parentSpanId"])
+		assert.Equal(t, map[string]any{"code": float64(2), "message": "database is locked"}, querySpan["status"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("trace was not exported")
+	}
+	assert.Equal(t, "secret", apiKey)
+
+	assert.Equal(t, map[string]string{"x-honeycomb-team": "abc+d f", "x-dataset": "shop"},
+		ParseHeaders("x-honeycomb-team=abc+d%20f, x-dataset=shop,malformed"))
+
+	_, err = New(Config{Endpoint: "collector:4318"})
+	assert.Error(t, err, "an endpoint without a scheme is rejected")
+}
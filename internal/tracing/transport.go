@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Transport makes a client span for each request sent through base, named for the
+// component and the request's path with IDs taken out, like
+// "stripe POST /v1/checkout/sessions/{id}". Trace headers aren't sent on, as the
+// services called this way are third parties.
+func Transport(base http.RoundTripper, component string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, component: component}
+}
+
+// HTTPClient is a client whose requests are traced as component
+func HTTPClient(component string, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport(nil, component)}
+}
+
+type transport struct {
+	base      http.RoundTripper
+	component string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracer := Default()
+	if tracer == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, span := tracer.Start(req.Context(), t.component, t.component+" "+req.Method+" "+RoutePath(req.URL.Path), KindClient,
+		String("http.request.method", req.Method),
+		String("server.address", req.URL.Hostname()),
+		String("url.path", req.URL.Path),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.SetError(err)
+		return resp, err
+	}
+	span.SetAttributes(Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetError(fmt.Errorf("%s returned %s", t.component, resp.Status))
+	}
+	return resp, nil
+}
+
+// RoutePath replaces the segments of path that look like IDs, which have a digit
+// and are longer than an API version like v1, with {id}
+func RoutePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if len(segment) > 3 && strings.ContainsAny(segment, "0123456789") {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
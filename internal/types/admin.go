@@ -1,6 +1,9 @@
 package types
 
 import (
+	"net/url"
+	"strconv"
+
 	"github.com/loganlanou/logans3d-v4/internal/social"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
@@ -12,6 +15,53 @@ type ProductWithImage struct {
 	IsDiscontinued bool // Product is inactive (won't show on site)
 }
 
+// DefaultProductPageSize is the number of products per admin list page unless another is chosen
+const DefaultProductPageSize = 50
+
+// ProductPageSizes lists the page sizes offered on the admin product list
+var ProductPageSizes = []int{25, 50, 100}
+
+// ProductListPage is one page of the admin product list. Query holds the filters and
+// sort that produced it, without paging, so links can carry them along.
+type ProductListPage struct {
+	Pagination
+	Query url.Values
+}
+
+// URL returns the product list link for page with the current filters, sort and page size
+func (p ProductListPage) URL(page int) string {
+	q := url.Values{}
+	for key, values := range p.Query {
+		q[key] = values
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if p.PerPage != DefaultProductPageSize {
+		q.Set("per_page", strconv.Itoa(p.PerPage))
+	}
+	if encoded := q.Encode(); encoded != "" {
+		return "/admin/products?" + encoded
+	}
+	return "/admin/products"
+}
+
+// SortURL returns the first page sorted by field, flipping the order if it's already the sort
+func (p ProductListPage) SortURL(field string) string {
+	order := "asc"
+	if p.Query.Get("sort") == field && p.Query.Get("order") == "asc" {
+		order = "desc"
+	}
+	sorted := p
+	sorted.Query = url.Values{}
+	for key, values := range p.Query {
+		sorted.Query[key] = values
+	}
+	sorted.Query.Set("sort", field)
+	sorted.Query.Set("order", order)
+	return sorted.URL(1)
+}
+
 type ProductWithStatus struct {
 	Product           db.Product
 	CategoryName      string
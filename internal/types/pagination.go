@@ -0,0 +1,72 @@
+package types
+
+import "fmt"
+
+// Pagination is one page of a paged list, shared by the shop and the admin lists
+type Pagination struct {
+	Page       int // 1-based
+	PerPage    int
+	TotalCount int64
+	TotalPages int // at least 1, even for an empty list
+}
+
+// NewPagination returns page of a list of total items, clamped to the pages that exist
+func NewPagination(page, perPage int, total int64) Pagination {
+	totalPages := max(1, int((total+int64(perPage)-1)/int64(perPage)))
+	return Pagination{
+		Page:       min(max(page, 1), totalPages),
+		PerPage:    perPage,
+		TotalCount: total,
+		TotalPages: totalPages,
+	}
+}
+
+// Offset is the number of items before the current page, for LIMIT/OFFSET queries
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// HasPrev reports whether there is a page before the current one
+func (p Pagination) HasPrev() bool {
+	return p.Page > 1
+}
+
+// HasNext reports whether there is a page after the current one
+func (p Pagination) HasNext() bool {
+	return p.Page < p.TotalPages
+}
+
+// RangeLabel describes the visible slice of the list, e.g. "51–100 of 230"
+func (p Pagination) RangeLabel() string {
+	if p.TotalCount == 0 {
+		return "0 of 0"
+	}
+	start := int64(p.Offset()) + 1
+	end := min(start+int64(p.PerPage)-1, p.TotalCount)
+	return fmt.Sprintf("%d–%d of %d", start, end, p.TotalCount)
+}
+
+// PageNumbers returns the page numbers to show in a pager, with 0 marking a gap
+func (p Pagination) PageNumbers() []int {
+	if p.TotalPages <= 7 {
+		pages := make([]int, 0, p.TotalPages)
+		for i := 1; i <= p.TotalPages; i++ {
+			pages = append(pages, i)
+		}
+		return pages
+	}
+
+	pages := []int{1}
+	start := max(2, p.Page-1)
+	end := min(p.TotalPages-1, p.Page+1)
+	if start > 2 {
+		pages = append(pages, 0)
+	}
+	for i := start; i <= end; i++ {
+		pages = append(pages, i)
+	}
+	if end < p.TotalPages-1 {
+		pages = append(pages, 0)
+	}
+	return append(pages, p.TotalPages)
+}
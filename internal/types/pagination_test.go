@@ -0,0 +1,27 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPagination(t *testing.T) {
+	// Out of range pages clamp, and an empty list still has one page
+	assert.Equal(t, Pagination{Page: 3, PerPage: 50, TotalCount: 230, TotalPages: 5}, NewPagination(3, 50, 230))
+	assert.Equal(t, Pagination{Page: 5, PerPage: 50, TotalCount: 230, TotalPages: 5}, NewPagination(9, 50, 230))
+	assert.Equal(t, Pagination{Page: 1, PerPage: 50, TotalCount: 0, TotalPages: 1}, NewPagination(0, 50, 0))
+
+	page := NewPagination(2, 50, 230)
+	assert.Equal(t, 50, page.Offset())
+	assert.True(t, page.HasPrev())
+	assert.True(t, page.HasNext())
+	assert.Equal(t, "51–100 of 230", page.RangeLabel())
+	assert.Equal(t, "201–230 of 230", NewPagination(5, 50, 230).RangeLabel())
+	assert.Equal(t, "0 of 0", NewPagination(1, 50, 0).RangeLabel())
+}
+
+func TestPaginationPageNumbers(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, Pagination{Page: 1, TotalPages: 3}.PageNumbers())
+	assert.Equal(t, []int{1, 0, 5, 6, 7, 0, 12}, Pagination{Page: 6, TotalPages: 12}.PageNumbers())
+}
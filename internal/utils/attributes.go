@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Product attribute value types
+const (
+	// AttributeTypeText is free text such as a material or finish; text specs can be filtered on in the shop
+	AttributeTypeText = "text"
+
+	// AttributeTypeNumber is a single measurement such as weight or layer height
+	AttributeTypeNumber = "number"
+
+	// AttributeTypeDimensions is two or three measurements, e.g. "80 x 40 x 25"
+	AttributeTypeDimensions = "dimensions"
+)
+
+// AttributePreset is a common specification with its usual type and unit
+type AttributePreset struct {
+	Name string
+	Type string
+	Unit string
+}
+
+// AttributePresets are suggested in the admin form so the same spec is named the
+// same way across products, which keeps the shop filter and comparison table tidy
+var AttributePresets = []AttributePreset{
+	{Name: "Material", Type: AttributeTypeText},
+	{Name: "Dimensions", Type: AttributeTypeDimensions, Unit: "mm"},
+	{Name: "Weight", Type: AttributeTypeNumber, Unit: "g"},
+	{Name: "Print Resolution", Type: AttributeTypeNumber, Unit: "mm"},
+	{Name: "Finish", Type: AttributeTypeText},
+}
+
+// IsValidAttributeType reports whether t is a known attribute value type
+func IsValidAttributeType(t string) bool {
+	switch t {
+	case AttributeTypeText, AttributeTypeNumber, AttributeTypeDimensions:
+		return true
+	}
+	return false
+}
+
+// NormalizeAttributeValue checks a value against its type and returns it in the stored
+// form: numbers without trailing zeros and dimensions as "80 x 40 x 25"
+func NormalizeAttributeValue(valueType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("value is required")
+	}
+
+	switch valueType {
+	case AttributeTypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a number", value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case AttributeTypeDimensions:
+		parts := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+			return r == 'x' || r == '×' || r == '*' || r == ' '
+		})
+		if len(parts) < 2 || len(parts) > 3 {
+			return "", fmt.Errorf("dimensions need two or three measurements, e.g. 80 x 40 x 25")
+		}
+		for i, part := range parts {
+			n, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return "", fmt.Errorf("%q is not a number", part)
+			}
+			parts[i] = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		return strings.Join(parts, " x "), nil
+	}
+	return value, nil
+}
+
+// FormatAttributeValue renders a stored value with its unit for display
+func FormatAttributeValue(valueType, value, unit string) string {
+	if valueType == AttributeTypeDimensions {
+		value = strings.ReplaceAll(value, " x ", " × ")
+	}
+	if unit == "" {
+		return value
+	}
+	return value + " " + unit
+}
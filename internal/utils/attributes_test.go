@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAttributeValue(t *testing.T) {
+	value, err := NormalizeAttributeValue(AttributeTypeNumber, " 120.50 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "120.5", value)
+
+	value, err = NormalizeAttributeValue(AttributeTypeDimensions, "80×40 X 25.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "80 x 40 x 25", value)
+
+	value, err = NormalizeAttributeValue(AttributeTypeText, " PLA+ ")
+	assert.NoError(t, err)
+	assert.Equal(t, "PLA+", value)
+
+	_, err = NormalizeAttributeValue(AttributeTypeNumber, "heavy")
+	assert.Error(t, err)
+	_, err = NormalizeAttributeValue(AttributeTypeDimensions, "80")
+	assert.Error(t, err)
+	_, err = NormalizeAttributeValue(AttributeTypeText, "  ")
+	assert.Error(t, err)
+}
+
+func TestFormatAttributeValue(t *testing.T) {
+	assert.Equal(t, "80 × 40 × 25 mm", FormatAttributeValue(AttributeTypeDimensions, "80 x 40 x 25", "mm"))
+	assert.Equal(t, "120 g", FormatAttributeValue(AttributeTypeNumber, "120", "g"))
+	assert.Equal(t, "PLA+", FormatAttributeValue(AttributeTypeText, "PLA+", ""))
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// BackorderDateLayout is the storage format for promised backorder ship dates
+	BackorderDateLayout = "2006-01-02"
+
+	// ShippingTimeBackorderPrefix precedes the promised ship date for backordered items
+	ShippingTimeBackorderPrefix = "Backordered - ships by"
+)
+
+var (
+	// ErrBackorderNotAllowed is returned when a product is out of stock and backorders are disabled
+	ErrBackorderNotAllowed = errors.New("backorders are not allowed for this product")
+
+	// ErrBackorderLimitReached is returned when a backorder would exceed the product's maximum
+	ErrBackorderLimitReached = errors.New("backorder limit reached for this product")
+)
+
+// BackorderPolicy holds a product's backorder settings
+type BackorderPolicy struct {
+	Allow       bool
+	MaxQuantity int64  // units allowed on backorder across open orders; 0 means unlimited
+	ShipDate    string // promised ship date (YYYY-MM-DD), empty when not set
+}
+
+// BackorderQuantity returns how many of the requested units exceed available stock
+func BackorderQuantity(stockQuantity, quantity int64) int64 {
+	if stockQuantity < 0 {
+		stockQuantity = 0
+	}
+	if quantity <= stockQuantity {
+		return 0
+	}
+	return quantity - stockQuantity
+}
+
+// CheckBackorder validates a requested quantity against the product's backorder policy.
+// outstanding is the number of units already backordered on open orders.
+func CheckBackorder(policy BackorderPolicy, stockQuantity, quantity, outstanding int64) error {
+	backordered := BackorderQuantity(stockQuantity, quantity)
+	if backordered == 0 {
+		return nil
+	}
+	if !policy.Allow {
+		return ErrBackorderNotAllowed
+	}
+	if policy.MaxQuantity > 0 && outstanding+backordered > policy.MaxQuantity {
+		return ErrBackorderLimitReached
+	}
+	return nil
+}
+
+// PromisedShipDate parses a backorder ship date, returning false when it is unset,
+// invalid or already in the past
+func PromisedShipDate(shipDate string, now time.Time) (time.Time, bool) {
+	if shipDate == "" {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation(BackorderDateLayout, shipDate, now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if date.Before(today) {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// BackorderShippingMessage returns the shipping message for a line item. Items covered by
+// stock get the standard in-stock message; backordered items show the promised ship date
+// when one is set, otherwise the standard out-of-stock lead time.
+func BackorderShippingMessage(stockQuantity, quantity int64, shipDate string) string {
+	if BackorderQuantity(stockQuantity, quantity) == 0 {
+		return ShippingTimeInStock
+	}
+	if date, ok := PromisedShipDate(shipDate, time.Now()); ok {
+		return fmt.Sprintf("%s %s", ShippingTimeBackorderPrefix, date.Format("January 2, 2006"))
+	}
+	return ShippingTimeOutOfStock
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackorderQuantity(t *testing.T) {
+	assert.Equal(t, int64(0), BackorderQuantity(5, 3))
+	assert.Equal(t, int64(0), BackorderQuantity(3, 3))
+	assert.Equal(t, int64(2), BackorderQuantity(3, 5))
+	assert.Equal(t, int64(4), BackorderQuantity(0, 4))
+	assert.Equal(t, int64(4), BackorderQuantity(-2, 4))
+}
+
+func TestCheckBackorder(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      BackorderPolicy
+		stock       int64
+		quantity    int64
+		outstanding int64
+		want        error
+	}{
+		{name: "in stock ignores policy", policy: BackorderPolicy{Allow: false}, stock: 5, quantity: 5},
+		{name: "backorder denied", policy: BackorderPolicy{Allow: false}, stock: 1, quantity: 2, want: ErrBackorderNotAllowed},
+		{name: "unlimited backorder", policy: BackorderPolicy{Allow: true}, stock: 0, quantity: 50, outstanding: 100},
+		{name: "within limit", policy: BackorderPolicy{Allow: true, MaxQuantity: 5}, stock: 1, quantity: 4, outstanding: 2},
+		{name: "over limit", policy: BackorderPolicy{Allow: true, MaxQuantity: 5}, stock: 0, quantity: 3, outstanding: 3, want: ErrBackorderLimitReached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CheckBackorder(tt.policy, tt.stock, tt.quantity, tt.outstanding))
+		})
+	}
+}
+
+func TestPromisedShipDate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	date, ok := PromisedShipDate("2026-03-20", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), date)
+
+	_, ok = PromisedShipDate("2026-03-10", now)
+	assert.True(t, ok, "today is still a valid promise")
+
+	_, ok = PromisedShipDate("2026-03-09", now)
+	assert.False(t, ok)
+
+	_, ok = PromisedShipDate("", now)
+	assert.False(t, ok)
+
+	_, ok = PromisedShipDate("not-a-date", now)
+	assert.False(t, ok)
+}
+
+func TestBackorderShippingMessage(t *testing.T) {
+	future := time.Now().AddDate(0, 1, 0)
+
+	assert.Equal(t, ShippingTimeInStock, BackorderShippingMessage(5, 2, future.Format(BackorderDateLayout)))
+	assert.Equal(t, ShippingTimeOutOfStock, BackorderShippingMessage(0, 2, ""))
+	assert.Equal(t, ShippingTimeBackorderPrefix+" "+future.Format("January 2, 2006"), BackorderShippingMessage(1, 2, future.Format(BackorderDateLayout)))
+}
@@ -0,0 +1,33 @@
+package utils
+
+import "time"
+
+// Blog post statuses
+const (
+	BlogStatusDraft     = "draft"
+	BlogStatusPublished = "published"
+)
+
+// BlogName titles the blog pages, feeds and OG cards
+const BlogName = "Logan's 3D Creations Blog"
+
+// BlogExcerptLength is how much of a post's text stands in for a missing summary
+const BlogExcerptLength = 200
+
+// ValidBlogStatus reports whether s is a blog post status
+func ValidBlogStatus(s string) bool {
+	return s == BlogStatusDraft || s == BlogStatusPublished
+}
+
+// BlogDateLabel formats a post's publish date, like "March 10, 2026"
+func BlogDateLabel(t time.Time) string {
+	return t.Format("January 2, 2006")
+}
+
+// BlogByline is "Author • March 10, 2026", or just the date without an author
+func BlogByline(author string, published time.Time) string {
+	if author == "" {
+		return BlogDateLabel(published)
+	}
+	return author + " • " + BlogDateLabel(published)
+}
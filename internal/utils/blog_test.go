@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlogByline(t *testing.T) {
+	published := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "Logan • March 10, 2026", BlogByline("Logan", published))
+	assert.Equal(t, "March 10, 2026", BlogByline("", published))
+	assert.True(t, ValidBlogStatus(BlogStatusDraft))
+	assert.False(t, ValidBlogStatus("scheduled"))
+}
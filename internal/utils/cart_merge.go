@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergedCartQuantity combines a guest cart line with the signed-in customer's matching
+// line. When stock limits the product, the sum is capped at what's in stock; 0 means
+// it's sold out and the line should be removed.
+func MergedCartQuantity(existing, guest, stock int64, stockLimited bool) int64 {
+	total := existing + guest
+	if !stockLimited {
+		return total
+	}
+	return max(min(total, stock), 0)
+}
+
+// CartMergeSummary records what happened to each guest cart line when it merged into
+// the signed-in customer's cart
+type CartMergeSummary struct {
+	// Moved lines transferred to the account as new lines
+	Moved int
+	// Combined lines were folded into a matching line already in the account's cart
+	Combined int
+	// Reduced counts moved or combined lines lowered to what's in stock
+	Reduced int
+	// Removed names products that are no longer sold or are sold out, whose lines were dropped
+	Removed []string
+}
+
+// Lines is how many guest cart lines the merge handled
+func (s CartMergeSummary) Lines() int {
+	return s.Moved + s.Combined + len(s.Removed)
+}
+
+// Message describes the merge for the customer, or is empty when there was nothing
+// to merge
+func (s CartMergeSummary) Message() string {
+	var parts []string
+	if kept := s.Moved + s.Combined; kept > 0 {
+		parts = append(parts, fmt.Sprintf("We added %d %s from before you signed in to your cart.", kept, pluralize(kept, "item", "items")))
+	}
+	if s.Combined == 1 {
+		parts = append(parts, "1 matched an item already in your cart and was combined with it.")
+	} else if s.Combined > 1 {
+		parts = append(parts, fmt.Sprintf("%d matched items already in your cart and were combined with them.", s.Combined))
+	}
+	if s.Reduced > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s lowered to what's in stock.", s.Reduced, pluralize(s.Reduced, "was", "were")))
+	}
+	if len(s.Removed) == 1 {
+		parts = append(parts, s.Removed[0]+" is no longer available and was removed.")
+	} else if len(s.Removed) > 1 {
+		parts = append(parts, strings.Join(s.Removed, ", ")+" are no longer available and were removed.")
+	}
+	return strings.Join(parts, " ")
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
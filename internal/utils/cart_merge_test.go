@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergedCartQuantity(t *testing.T) {
+	tests := []struct {
+		name                   string
+		existing, guest, stock int64
+		stockLimited           bool
+		want                   int64
+	}{
+		{"sums within stock", 1, 2, 5, true, 3},
+		{"capped at stock", 3, 4, 5, true, 5},
+		{"capped below the larger line", 4, 2, 3, true, 3},
+		{"out of stock removes the line", 1, 2, 0, true, 0},
+		{"oversold stock removes the line", 1, 2, -1, true, 0},
+		{"backorders and pre-orders aren't capped", 3, 4, 5, false, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MergedCartQuantity(tt.existing, tt.guest, tt.stock, tt.stockLimited))
+		})
+	}
+}
+
+func TestCartMergeSummaryMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary CartMergeSummary
+		want    string
+	}{
+		{"nothing merged", CartMergeSummary{}, ""},
+		{"moved", CartMergeSummary{Moved: 2}, "We added 2 items from before you signed in to your cart."},
+		{
+			"combined and reduced",
+			CartMergeSummary{Moved: 1, Combined: 1, Reduced: 1},
+			"We added 2 items from before you signed in to your cart. 1 matched an item already in your cart and was combined with it. 1 was lowered to what's in stock.",
+		},
+		{
+			"everything removed",
+			CartMergeSummary{Removed: []string{"Dragon", "Owl"}},
+			"Dragon, Owl are no longer available and were removed.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.summary.Message())
+		})
+	}
+}
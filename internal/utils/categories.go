@@ -0,0 +1,73 @@
+package utils
+
+import "github.com/loganlanou/logans3d-v4/storage/db"
+
+// CategoryImagePathPrefix is the public URL prefix for uploaded category banners
+const CategoryImagePathPrefix = "/public/images/categories/"
+
+// MaxFeaturedCategoryProducts caps the hand-picked products shown above a category's grid
+const MaxFeaturedCategoryProducts = 4
+
+// CategoryBannerURL returns the public URL of a category's banner, stored as a bare
+// filename like product images
+func CategoryBannerURL(filename string) string {
+	if filename == "" {
+		return ""
+	}
+	return CategoryImagePathPrefix + filename
+}
+
+// CategoryNode is a category with its subcategories, for the nested shop navigation
+type CategoryNode struct {
+	Category db.Category
+	Children []CategoryNode
+}
+
+// BuildCategoryTree nests a flat category list by parent. Categories without a parent,
+// or whose parent isn't in the list, become top-level nodes. Siblings keep the order
+// of the input, so pass categories already sorted by display order. A category is
+// placed at most once, which keeps a parent loop from recursing forever.
+func BuildCategoryTree(categories []db.Category) []CategoryNode {
+	known := make(map[string]bool, len(categories))
+	for _, cat := range categories {
+		known[cat.ID] = true
+	}
+
+	children := make(map[string][]db.Category)
+	var roots []db.Category
+	for _, cat := range categories {
+		if cat.ParentID.Valid && cat.ParentID.String != cat.ID && known[cat.ParentID.String] {
+			children[cat.ParentID.String] = append(children[cat.ParentID.String], cat)
+			continue
+		}
+		roots = append(roots, cat)
+	}
+
+	placed := make(map[string]bool, len(categories))
+	var build func(cats []db.Category) []CategoryNode
+	build = func(cats []db.Category) []CategoryNode {
+		var nodes []CategoryNode
+		for _, cat := range cats {
+			if placed[cat.ID] {
+				continue
+			}
+			placed[cat.ID] = true
+			nodes = append(nodes, CategoryNode{Category: cat, Children: build(children[cat.ID])})
+		}
+		return nodes
+	}
+	return build(roots)
+}
+
+// FindCategoryNode returns the node for a category anywhere in the tree
+func FindCategoryNode(nodes []CategoryNode, id string) (CategoryNode, bool) {
+	for _, node := range nodes {
+		if node.Category.ID == id {
+			return node, true
+		}
+		if found, ok := FindCategoryNode(node.Children, id); ok {
+			return found, true
+		}
+	}
+	return CategoryNode{}, false
+}
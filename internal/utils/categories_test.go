@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func category(id, parentID string) db.Category {
+	return db.Category{ID: id, Name: id, ParentID: sql.NullString{String: parentID, Valid: parentID != ""}}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	tree := BuildCategoryTree([]db.Category{
+		category("dragons", "animals"),
+		category("animals", ""),
+		category("cats", "animals"),
+		category("orphan", "deleted"),
+		category("mini-dragons", "dragons"),
+	})
+
+	require.Len(t, tree, 2)
+	assert.Equal(t, "animals", tree[0].Category.ID)
+	assert.Equal(t, "orphan", tree[1].Category.ID, "a missing parent makes the category top level")
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "dragons", tree[0].Children[0].Category.ID)
+	assert.Equal(t, "cats", tree[0].Children[1].Category.ID)
+	require.Len(t, tree[0].Children[0].Children, 1)
+	assert.Equal(t, "mini-dragons", tree[0].Children[0].Children[0].Category.ID)
+
+	node, ok := FindCategoryNode(tree, "dragons")
+	require.True(t, ok)
+	assert.Len(t, node.Children, 1)
+	_, ok = FindCategoryNode(tree, "nope")
+	assert.False(t, ok)
+}
+
+func TestBuildCategoryTreeIgnoresParentLoops(t *testing.T) {
+	tree := BuildCategoryTree([]db.Category{
+		category("a", "b"),
+		category("b", "a"),
+		category("self", "self"),
+	})
+
+	require.Len(t, tree, 1, "categories caught in a loop have no root to hang from")
+	assert.Equal(t, "self", tree[0].Category.ID)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// sourcePlatformLabels names the model marketplaces products are imported from
+var sourcePlatformLabels = map[string]string{
+	"cults3d":     "Cults3D",
+	"mmf":         "MyMiniFactory",
+	"printables":  "Printables",
+	"thingiverse": "Thingiverse",
+}
+
+// SourcePlatformLabel returns the display name of a source platform, falling back to
+// the stored value for ones we don't know
+func SourcePlatformLabel(platform string) string {
+	if label, ok := sourcePlatformLabels[strings.ToLower(platform)]; ok {
+		return label
+	}
+	return platform
+}
+
+// DesignerSlug turns a designer name into its shop URL slug. It matches the importer's
+// designer slugs, e.g. "TheDragonsDen" becomes "thedragonsden".
+func DesignerSlug(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-' || r == '_' || r == '.':
+			return '-'
+		}
+		return -1
+	}, strings.ToLower(strings.TrimSpace(name)))
+
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	return strings.Trim(slug, "-")
+}
+
+// DesignerPath returns the shop page of a designer, or "" if the name has no usable slug
+func DesignerPath(name string) string {
+	slug := DesignerSlug(name)
+	if slug == "" {
+		return ""
+	}
+	return "/shop/designer/" + slug
+}
+
+// DesignerNameForSlug finds the designer a shop URL slug belongs to. Designer names
+// are only stored on products, so the slug is matched against each of them.
+func DesignerNameForSlug(names []string, slug string) (string, bool) {
+	if slug == "" {
+		return "", false
+	}
+	for _, name := range names {
+		if DesignerSlug(name) == slug {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// SafeExternalURL returns rawURL if it's an absolute http(s) link, so admin-entered
+// attribution links can't carry javascript: or other schemes
+func SafeExternalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDesignerSlug(t *testing.T) {
+	assert.Equal(t, "thedragonsden", DesignerSlug("TheDragonsDen"))
+	assert.Equal(t, "mr-3d-studio", DesignerSlug(" Mr. 3D  Studio! "))
+	assert.Equal(t, "", DesignerSlug("***"))
+	assert.Equal(t, "/shop/designer/cinderwing3d", DesignerPath("Cinderwing3D"))
+	assert.Equal(t, "", DesignerPath("***"))
+}
+
+func TestDesignerNameForSlug(t *testing.T) {
+	names := []string{"FlexiFactory", "TheDragonsDen"}
+
+	name, ok := DesignerNameForSlug(names, "thedragonsden")
+	assert.True(t, ok)
+	assert.Equal(t, "TheDragonsDen", name)
+
+	_, ok = DesignerNameForSlug(names, "nobody")
+	assert.False(t, ok)
+	_, ok = DesignerNameForSlug([]string{"***"}, "")
+	assert.False(t, ok)
+}
+
+func TestSourcePlatformLabel(t *testing.T) {
+	assert.Equal(t, "MyMiniFactory", SourcePlatformLabel("mmf"))
+	assert.Equal(t, "Cults3D", SourcePlatformLabel("Cults3D"))
+	assert.Equal(t, "etsy", SourcePlatformLabel("etsy"))
+}
+
+func TestSafeExternalURL(t *testing.T) {
+	assert.Equal(t, "https://cults3d.com/en/users/TheDragonsDen", SafeExternalURL(" https://cults3d.com/en/users/TheDragonsDen "))
+	assert.Equal(t, "", SafeExternalURL("javascript:alert(1)"))
+	assert.Equal(t, "", SafeExternalURL("cults3d.com/en/users/TheDragonsDen"))
+	assert.Equal(t, "", SafeExternalURL(""))
+}
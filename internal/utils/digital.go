@@ -0,0 +1,12 @@
+package utils
+
+const (
+	// ProductTypePhysical products are printed and shipped
+	ProductTypePhysical = "physical"
+
+	// ProductTypeDigital products (e.g. STL files) are delivered by download
+	ProductTypeDigital = "digital"
+
+	// ShippingTimeDigital is shown instead of a ship time for digital products
+	ShippingTimeDigital = "Instant download after purchase"
+)
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DropRushWindow is how long after a drop goes live that add-to-cart is throttled.
+// Most of a drop's traffic lands in these first minutes.
+const DropRushWindow = 10 * time.Minute
+
+// DropRushRequestsPerMinute caps add-to-cart requests per product during the rush, so
+// the crowd retries from the browser instead of queueing on SQLite's single writer
+const DropRushRequestsPerMinute = 300
+
+// DropUpcoming reports whether a scheduled drop hasn't gone live yet. Drop times are
+// stored as wall-clock times, so now should come from WallClockNow.
+func DropUpcoming(dropAt sql.NullTime, now time.Time) bool {
+	return dropAt.Valid && now.Before(dropAt.Time)
+}
+
+// InDropRush reports whether a drop went live less than DropRushWindow ago
+func InDropRush(dropAt sql.NullTime, now time.Time) bool {
+	return dropAt.Valid && !now.Before(dropAt.Time) && now.Sub(dropAt.Time) < DropRushWindow
+}
+
+// DropLabel formats a drop time for customers, e.g. "Friday, March 6 at 7:00 PM"
+func DropLabel(dropAt time.Time) string {
+	return dropAt.Format("Monday, January 2 at 3:04 PM")
+}
+
+// PurchaseLimitLeft returns how many more units a customer can add given what's in
+// their cart and what they've already bought, or -1 when limit is 0 (unlimited)
+func PurchaseLimitLeft(limit, inCart, purchased int64) int64 {
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-inCart-purchased, 0)
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropWindows(t *testing.T) {
+	dropAt := time.Date(2026, 3, 6, 19, 0, 0, 0, time.UTC)
+	drop := sql.NullTime{Time: dropAt, Valid: true}
+
+	assert.True(t, DropUpcoming(drop, dropAt.Add(-time.Second)))
+	assert.False(t, DropUpcoming(drop, dropAt))
+	assert.False(t, DropUpcoming(sql.NullTime{}, dropAt), "products without a drop are always on sale")
+
+	assert.False(t, InDropRush(drop, dropAt.Add(-time.Second)))
+	assert.True(t, InDropRush(drop, dropAt))
+	assert.True(t, InDropRush(drop, dropAt.Add(DropRushWindow-time.Second)))
+	assert.False(t, InDropRush(drop, dropAt.Add(DropRushWindow)))
+	assert.False(t, InDropRush(sql.NullTime{}, dropAt))
+
+	assert.Equal(t, "Friday, March 6 at 7:00 PM", DropLabel(dropAt))
+}
+
+func TestPurchaseLimitLeft(t *testing.T) {
+	assert.Equal(t, int64(-1), PurchaseLimitLeft(0, 5, 5))
+	assert.Equal(t, int64(2), PurchaseLimitLeft(3, 1, 0))
+	assert.Equal(t, int64(1), PurchaseLimitLeft(3, 1, 1))
+	assert.Equal(t, int64(0), PurchaseLimitLeft(3, 2, 2))
+}
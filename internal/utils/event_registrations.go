@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event registration statuses
+const (
+	RegistrationPending    = "pending"
+	RegistrationConfirmed  = "confirmed"
+	RegistrationWaitlisted = "waitlisted"
+	RegistrationCancelled  = "cancelled"
+)
+
+// EventCheckoutHold is how long a paid booking holds its seats while the customer is
+// in Stripe Checkout. It's Stripe's shortest session expiry.
+const EventCheckoutHold = 30 * time.Minute
+
+// EventCheckoutHoldOffset is EventCheckoutHold as an SQLite datetime modifier
+func EventCheckoutHoldOffset() string {
+	return fmt.Sprintf("-%d minutes", int(EventCheckoutHold/time.Minute))
+}
+
+// SeatsLeft returns the seats still bookable, or -1 when capacity is 0 (unlimited)
+func SeatsLeft(capacity, taken int64) int64 {
+	if capacity <= 0 {
+		return -1
+	}
+	return max(capacity-taken, 0)
+}
+
+// WallClockNow is the current local time labelled as UTC. Event times are stored as
+// the wall-clock time the admin typed, so this is what they compare against.
+func WallClockNow() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+}
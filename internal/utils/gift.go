@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxGiftMessageLength caps the message printed on a gift's packing slip. It's
+// Stripe's limit for a metadata value, which is how the message reaches the order.
+const MaxGiftMessageLength = 500
+
+// Checkout session metadata keys for gift orders
+const (
+	giftMetadataKey               = "gift"
+	giftMessageMetadataKey        = "gift_message"
+	giftRecipientNameMetadataKey  = "gift_recipient_name"
+	giftRecipientEmailMetadataKey = "gift_recipient_email"
+)
+
+// GiftOptions is what a shopper chose for an order sent as a gift. The recipient's
+// email is optional; when set, they're told when the gift ships.
+type GiftOptions struct {
+	IsGift         bool
+	Message        string
+	RecipientName  string
+	RecipientEmail string
+}
+
+// NormalizeGiftOptions tidies and checks the gift fields from the cart. Options for an
+// order that isn't a gift are cleared, so stray fields never reach the order.
+func NormalizeGiftOptions(opts GiftOptions) (GiftOptions, error) {
+	if !opts.IsGift {
+		return GiftOptions{}, nil
+	}
+
+	opts.Message = cleanCustomerText(opts.Message)
+	if utf8.RuneCountInString(opts.Message) > MaxGiftMessageLength {
+		return GiftOptions{}, fmt.Errorf("gift message can be up to %d characters", MaxGiftMessageLength)
+	}
+	opts.RecipientName = strings.Join(strings.Fields(opts.RecipientName), " ")
+	if utf8.RuneCountInString(opts.RecipientName) > 100 {
+		return GiftOptions{}, errors.New("recipient name can be up to 100 characters")
+	}
+
+	opts.RecipientEmail = strings.TrimSpace(opts.RecipientEmail)
+	if opts.RecipientEmail != "" {
+		addr, err := mail.ParseAddress(opts.RecipientEmail)
+		if err != nil || addr.Name != "" {
+			return GiftOptions{}, errors.New("recipient email doesn't look like an email address")
+		}
+		opts.RecipientEmail = addr.Address
+	}
+	return opts, nil
+}
+
+// AddToMetadata records gift options on checkout session metadata
+func (g GiftOptions) AddToMetadata(metadata map[string]string) {
+	if !g.IsGift {
+		return
+	}
+	metadata[giftMetadataKey] = "true"
+	if g.Message != "" {
+		metadata[giftMessageMetadataKey] = g.Message
+	}
+	if g.RecipientName != "" {
+		metadata[giftRecipientNameMetadataKey] = g.RecipientName
+	}
+	if g.RecipientEmail != "" {
+		metadata[giftRecipientEmailMetadataKey] = g.RecipientEmail
+	}
+}
+
+// GiftOptionsFromMetadata reads gift options back from checkout session metadata
+func GiftOptionsFromMetadata(metadata map[string]string) GiftOptions {
+	if metadata[giftMetadataKey] != "true" {
+		return GiftOptions{}
+	}
+	return GiftOptions{
+		IsGift:         true,
+		Message:        metadata[giftMessageMetadataKey],
+		RecipientName:  metadata[giftRecipientNameMetadataKey],
+		RecipientEmail: metadata[giftRecipientEmailMetadataKey],
+	}
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeGiftOptions(t *testing.T) {
+	opts, err := NormalizeGiftOptions(GiftOptions{
+		IsGift:         true,
+		Message:        "  Happy birthday, Sam!\r\nLove, Jo  ",
+		RecipientName:  "  Sam   Smith ",
+		RecipientEmail: " sam@example.com ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, GiftOptions{
+		IsGift:         true,
+		Message:        "Happy birthday, Sam!\nLove, Jo",
+		RecipientName:  "Sam Smith",
+		RecipientEmail: "sam@example.com",
+	}, opts)
+
+	opts, err = NormalizeGiftOptions(GiftOptions{Message: "left over from a toggle", RecipientEmail: "sam@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, GiftOptions{}, opts, "not a gift")
+
+	_, err = NormalizeGiftOptions(GiftOptions{IsGift: true, RecipientEmail: "sam at example"})
+	assert.Error(t, err)
+	_, err = NormalizeGiftOptions(GiftOptions{IsGift: true, Message: strings.Repeat("a", MaxGiftMessageLength+1)})
+	assert.Error(t, err)
+}
+
+func TestGiftOptionsMetadata(t *testing.T) {
+	metadata := map[string]string{"user_id": "u1"}
+	GiftOptions{}.AddToMetadata(metadata)
+	assert.Len(t, metadata, 1)
+	assert.Equal(t, GiftOptions{}, GiftOptionsFromMetadata(metadata))
+
+	gift := GiftOptions{IsGift: true, Message: "Enjoy!", RecipientEmail: "sam@example.com"}
+	gift.AddToMetadata(metadata)
+	assert.Equal(t, "true", metadata["gift"])
+	assert.NotContains(t, metadata, "gift_recipient_name")
+	assert.Equal(t, gift, GiftOptionsFromMetadata(metadata))
+}
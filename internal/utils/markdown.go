@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// markdown renders GitHub-flavoured markdown. Raw HTML in the source is left out, so
+// rendered posts are safe to insert into pages and feeds as-is.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, extension.Typographer),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// RenderMarkdown converts markdown to HTML
+func RenderMarkdown(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MarkdownPlainText returns the readable text of markdown without any formatting,
+// with blocks separated by spaces. Code blocks are skipped.
+func MarkdownPlainText(src string) string {
+	source := []byte(src)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+
+	var b strings.Builder
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if entering {
+				b.Write(node.Segment.Value(source))
+				if node.SoftLineBreak() || node.HardLineBreak() {
+					b.WriteByte(' ')
+				}
+			}
+		case *ast.String:
+			if entering {
+				b.Write(node.Value)
+			}
+		default:
+			if !entering && n.Type() == ast.TypeBlock {
+				b.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// MarkdownExcerpt is the start of the markdown's plain text, cut at a word boundary
+// to at most maxRunes characters with an ellipsis when shortened
+func MarkdownExcerpt(src string, maxRunes int) string {
+	plain := MarkdownPlainText(src)
+	if utf8.RuneCountInString(plain) <= maxRunes {
+		return plain
+	}
+	runes := []rune(plain)
+	cut := string(runes[:maxRunes])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:") + "…"
+}
+
+// ReadingMinutes estimates how long markdown takes to read, at least one minute
+func ReadingMinutes(src string) int {
+	const wordsPerMinute = 200
+	words := len(strings.Fields(MarkdownPlainText(src)))
+	return max(1, (words+wordsPerMinute-1)/wordsPerMinute)
+}
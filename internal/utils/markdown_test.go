@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	html, err := RenderMarkdown("## Print settings\n\nUse **PLA** at 0.2mm.\n\n<script>alert(1)</script>\n\n| Layer | Speed |\n|---|---|\n| 0.2 | 60 |\n")
+	require.NoError(t, err)
+
+	assert.Contains(t, html, `<h2 id="print-settings">Print settings</h2>`)
+	assert.Contains(t, html, "<strong>PLA</strong>")
+	assert.Contains(t, html, "<table>")
+	assert.NotContains(t, html, "<script>", "raw HTML is dropped")
+}
+
+func TestMarkdownPlainText(t *testing.T) {
+	src := "# Hello\n\nA *flexi* [dragon](/shop/product/dragon)\nprints in place.\n\n```\ngcode\n```\n\n- one\n- two\n"
+	assert.Equal(t, "Hello A flexi dragon prints in place. one two", MarkdownPlainText(src))
+}
+
+func TestMarkdownExcerpt(t *testing.T) {
+	assert.Equal(t, "Short post", MarkdownExcerpt("Short **post**", 50))
+	assert.Equal(t, "The quick brown…", MarkdownExcerpt("The quick brown fox jumps", 18))
+}
+
+func TestReadingMinutes(t *testing.T) {
+	assert.Equal(t, 1, ReadingMinutes(""))
+	assert.Equal(t, 2, ReadingMinutes(strings.Repeat("word ", 201)))
+}
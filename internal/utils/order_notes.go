@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxOrderNotesLength caps the notes a customer leaves at checkout. It's Stripe's
+// limit for a metadata value, which is how the notes reach the order.
+const MaxOrderNotesLength = 500
+
+// NormalizeOrderNotes tidies the notes a customer typed on the cart: line endings
+// are unified, control characters dropped and surrounding space trimmed. Notes over
+// MaxOrderNotesLength are an error rather than cut off mid-instruction.
+func NormalizeOrderNotes(notes string) (string, error) {
+	notes = cleanCustomerText(notes)
+	if n := utf8.RuneCountInString(notes); n > MaxOrderNotesLength {
+		return "", fmt.Errorf("order notes are %d characters; the limit is %d", n, MaxOrderNotesLength)
+	}
+	return notes, nil
+}
+
+// cleanCustomerText unifies line endings, drops control characters and trims
+// surrounding space from free text a customer typed
+func cleanCustomerText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, text)
+	return strings.TrimSpace(text)
+}
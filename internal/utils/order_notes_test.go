@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeOrderNotes(t *testing.T) {
+	notes, err := NormalizeOrderNotes("  Use blue filament if possible.\r\nLeave at side door\x00\t \n")
+	require.NoError(t, err)
+	assert.Equal(t, "Use blue filament if possible.\nLeave at side door", notes)
+
+	notes, err = NormalizeOrderNotes("   ")
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+
+	_, err = NormalizeOrderNotes(strings.Repeat("é", MaxOrderNotesLength))
+	assert.NoError(t, err, "the limit counts characters, not bytes")
+	_, err = NormalizeOrderNotes(strings.Repeat("a", MaxOrderNotesLength+1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package utils
+
+import "time"
+
+// Order statuses
+const (
+	OrderReceived         = "received"
+	OrderInProduction     = "in_production"
+	OrderOnHold           = "on_hold"
+	OrderPartiallyShipped = "partially_shipped"
+	OrderShipped          = "shipped"
+	OrderDelivered        = "delivered"
+	OrderCancelled        = "cancelled"
+	OrderRefunded         = "refunded"
+)
+
+// OrderStatuses lists every order status, in workflow order
+var OrderStatuses = []string{
+	OrderReceived,
+	OrderInProduction,
+	OrderOnHold,
+	OrderPartiallyShipped,
+	OrderShipped,
+	OrderDelivered,
+	OrderCancelled,
+	OrderRefunded,
+}
+
+var orderStatusLabels = map[string]string{
+	OrderReceived:         "Received",
+	OrderInProduction:     "In Production",
+	OrderOnHold:           "On Hold",
+	OrderPartiallyShipped: "Partially Shipped",
+	OrderShipped:          "Shipped",
+	OrderDelivered:        "Delivered",
+	OrderCancelled:        "Cancelled",
+	OrderRefunded:         "Refunded",
+}
+
+// OrderStatusLabel returns the display text for an order status
+func OrderStatusLabel(status string) string {
+	if label, ok := orderStatusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+// ValidOrderStatus reports whether status is one of OrderStatuses
+func ValidOrderStatus(status string) bool {
+	_, ok := orderStatusLabels[status]
+	return ok
+}
+
+// orderTransitions lists where an order can be moved from each status. Delivered can
+// come straight from received or in production for orders handed over in person.
+// Partially shipped comes from recording a shipment that leaves units behind, and
+// can't be cancelled since some of it has gone. Refunded only comes through the
+// refund action, once nothing is left to refund, and cancelled and refunded orders
+// stay where they are.
+var orderTransitions = map[string][]string{
+	OrderReceived:         {OrderInProduction, OrderOnHold, OrderPartiallyShipped, OrderShipped, OrderDelivered, OrderCancelled},
+	OrderInProduction:     {OrderReceived, OrderOnHold, OrderPartiallyShipped, OrderShipped, OrderDelivered, OrderCancelled},
+	OrderOnHold:           {OrderReceived, OrderInProduction, OrderCancelled},
+	OrderPartiallyShipped: {OrderShipped, OrderDelivered},
+	OrderShipped:          {OrderDelivered},
+}
+
+// CanMoveOrder reports whether an order can be moved from one status to another. An
+// order left in a status from before these were fixed, like pending, can be moved to
+// any of them but refunded.
+func CanMoveOrder(from, to string) bool {
+	for _, next := range NextOrderStatuses(from) {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOrderStatuses lists the statuses an order can be moved to from status
+func NextOrderStatuses(status string) []string {
+	if ValidOrderStatus(status) {
+		return orderTransitions[status]
+	}
+	var next []string
+	for _, s := range OrderStatuses {
+		if s != OrderRefunded {
+			next = append(next, s)
+		}
+	}
+	return next
+}
+
+// CustomerCanCancel reports whether a customer can still cancel their own order: it
+// hasn't gone past received, and it was placed less than window ago. A zero window
+// means customers can't cancel orders themselves.
+func CustomerCanCancel(status string, placedAt, now time.Time, window time.Duration) bool {
+	return status == OrderReceived && window > 0 && now.Before(placedAt.Add(window))
+}
+
+// OrderEditable reports whether an order's items and shipping can still be changed
+// from the admin: it's paid for and not everything has gone out
+func OrderEditable(status string) bool {
+	switch status {
+	case OrderReceived, OrderInProduction, OrderOnHold, OrderPartiallyShipped:
+		return true
+	}
+	return false
+}
+
+// Kinds of change made to an order after it was paid for
+const (
+	AdjustmentRemoveItems = "remove_items"
+	AdjustmentAddItems    = "add_items"
+	AdjustmentShipping    = "shipping"
+)
+
+var adjustmentLabels = map[string]string{
+	AdjustmentRemoveItems: "Items removed",
+	AdjustmentAddItems:    "Items added",
+	AdjustmentShipping:    "Shipping changed",
+}
+
+// AdjustmentLabel returns the display text for a kind of order adjustment
+func AdjustmentLabel(kind string) string {
+	if label, ok := adjustmentLabels[kind]; ok {
+		return label
+	}
+	return kind
+}
+
+// ShippingLine is how much of one physical order line has gone out
+type ShippingLine struct {
+	Quantity int64
+	Shipped  int64
+	// Refunded units won't be sent, so they don't count as left to ship
+	Refunded int64
+}
+
+// Unshipped is how many units of the line are still to be sent
+func (l ShippingLine) Unshipped() int64 {
+	return max(l.Quantity-l.Shipped-l.Refunded, 0)
+}
+
+// ShippedStatus works out an order's status from its shipments: shipped once no
+// units are left to send, partially shipped while some have gone and some haven't,
+// and "" before any have
+func ShippedStatus(lines []ShippingLine) string {
+	var shipped, left int64
+	for _, line := range lines {
+		shipped += line.Shipped
+		left += line.Unshipped()
+	}
+	switch {
+	case shipped == 0:
+		return ""
+	case left > 0:
+		return OrderPartiallyShipped
+	}
+	return OrderShipped
+}
+
+// Shipment statuses: where each box of an order has got to
+const (
+	ShipmentInTransit = "in_transit"
+	ShipmentDelivered = "delivered"
+	// ShipmentProblem is a box lost, damaged or sent back
+	ShipmentProblem = "problem"
+)
+
+// ShipmentStatuses lists every shipment status
+var ShipmentStatuses = []string{ShipmentInTransit, ShipmentDelivered, ShipmentProblem}
+
+var shipmentStatusLabels = map[string]string{
+	ShipmentInTransit: "In Transit",
+	ShipmentDelivered: "Delivered",
+	ShipmentProblem:   "Problem",
+}
+
+// ShipmentStatusLabel returns the display text for a shipment status
+func ShipmentStatusLabel(status string) string {
+	if label, ok := shipmentStatusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+// ValidShipmentStatus reports whether status is one of the shipment statuses
+func ValidShipmentStatus(status string) bool {
+	_, ok := shipmentStatusLabels[status]
+	return ok
+}
+
+// AllDelivered reports whether an order's boxes have all arrived: it has at least one,
+// every one is delivered and no units are left to send
+func AllDelivered(shipmentStatuses []string, lines []ShippingLine) bool {
+	if len(shipmentStatuses) == 0 {
+		return false
+	}
+	for _, status := range shipmentStatuses {
+		if status != ShipmentDelivered {
+			return false
+		}
+	}
+	for _, line := range lines {
+		if line.Unshipped() > 0 {
+			return false
+		}
+	}
+	return true
+}
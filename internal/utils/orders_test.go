@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanMoveOrder(t *testing.T) {
+	assert.True(t, CanMoveOrder(OrderReceived, OrderInProduction))
+	assert.True(t, CanMoveOrder(OrderInProduction, OrderShipped))
+	assert.True(t, CanMoveOrder(OrderShipped, OrderDelivered))
+	assert.True(t, CanMoveOrder(OrderReceived, OrderPartiallyShipped))
+	assert.True(t, CanMoveOrder(OrderPartiallyShipped, OrderShipped))
+	assert.True(t, CanMoveOrder(OrderReceived, OrderOnHold))
+	assert.True(t, CanMoveOrder(OrderOnHold, OrderInProduction))
+	assert.True(t, CanMoveOrder(OrderInProduction, OrderCancelled))
+
+	assert.False(t, CanMoveOrder(OrderOnHold, OrderShipped), "release the hold first")
+	assert.False(t, CanMoveOrder(OrderShipped, OrderCancelled), "shipped orders are refunded, not cancelled")
+	assert.False(t, CanMoveOrder(OrderPartiallyShipped, OrderCancelled), "some of it has already gone")
+	assert.False(t, CanMoveOrder(OrderPartiallyShipped, OrderReceived))
+	assert.False(t, CanMoveOrder(OrderDelivered, OrderReceived))
+	assert.False(t, CanMoveOrder(OrderCancelled, OrderReceived))
+	assert.False(t, CanMoveOrder(OrderReceived, OrderRefunded), "refunds go through the refund action")
+	assert.False(t, CanMoveOrder(OrderReceived, OrderReceived))
+	assert.False(t, CanMoveOrder(OrderReceived, "lost"))
+	assert.Empty(t, NextOrderStatuses(OrderRefunded))
+
+	assert.True(t, CanMoveOrder("pending", OrderReceived), "old statuses can move into the workflow")
+	assert.False(t, CanMoveOrder("pending", OrderRefunded))
+}
+
+func TestOrderStatusLabel(t *testing.T) {
+	assert.Equal(t, "In Production", OrderStatusLabel(OrderInProduction))
+	assert.Equal(t, "On Hold", OrderStatusLabel(OrderOnHold))
+	assert.Equal(t, "pending", OrderStatusLabel("pending"))
+}
+
+func TestCustomerCanCancel(t *testing.T) {
+	placed := time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)
+	window := 24 * time.Hour
+
+	assert.True(t, CustomerCanCancel(OrderReceived, placed, placed.Add(23*time.Hour), window))
+	assert.False(t, CustomerCanCancel(OrderReceived, placed, placed.Add(24*time.Hour), window), "the window has closed")
+	assert.False(t, CustomerCanCancel(OrderInProduction, placed, placed.Add(time.Hour), window), "already being made")
+	assert.False(t, CustomerCanCancel(OrderOnHold, placed, placed.Add(time.Hour), window))
+	assert.False(t, CustomerCanCancel(OrderReceived, placed, placed.Add(time.Minute), 0), "self-service cancellation is off")
+}
+
+func TestOrderEditable(t *testing.T) {
+	assert.True(t, OrderEditable(OrderReceived))
+	assert.True(t, OrderEditable(OrderOnHold))
+	assert.True(t, OrderEditable(OrderPartiallyShipped), "the rest hasn't gone yet")
+	assert.False(t, OrderEditable(OrderShipped))
+	assert.False(t, OrderEditable(OrderCancelled))
+	assert.False(t, OrderEditable(OrderRefunded))
+	assert.False(t, OrderEditable("pending"), "older orders are moved into the workflow first")
+}
+
+func TestAdjustmentLabel(t *testing.T) {
+	assert.Equal(t, "Items removed", AdjustmentLabel(AdjustmentRemoveItems))
+	assert.Equal(t, "Shipping changed", AdjustmentLabel(AdjustmentShipping))
+	assert.Equal(t, "mystery", AdjustmentLabel("mystery"))
+}
+
+func TestShippedStatus(t *testing.T) {
+	assert.Equal(t, "", ShippedStatus(nil))
+	assert.Equal(t, "", ShippedStatus([]ShippingLine{{Quantity: 2}, {Quantity: 1}}), "nothing has gone yet")
+	assert.Equal(t, OrderPartiallyShipped, ShippedStatus([]ShippingLine{{Quantity: 2, Shipped: 2}, {Quantity: 1}}))
+	assert.Equal(t, OrderPartiallyShipped, ShippedStatus([]ShippingLine{{Quantity: 3, Shipped: 1}}))
+	assert.Equal(t, OrderShipped, ShippedStatus([]ShippingLine{{Quantity: 2, Shipped: 2}, {Quantity: 1, Shipped: 1}}))
+	assert.Equal(t, OrderShipped, ShippedStatus([]ShippingLine{{Quantity: 3, Shipped: 2, Refunded: 1}}), "refunded units aren't waited for")
+}
+
+func TestShippingLineUnshipped(t *testing.T) {
+	assert.Equal(t, int64(2), ShippingLine{Quantity: 3, Shipped: 1}.Unshipped())
+	assert.Equal(t, int64(1), ShippingLine{Quantity: 3, Shipped: 1, Refunded: 1}.Unshipped())
+	assert.Equal(t, int64(0), ShippingLine{Quantity: 3, Shipped: 3, Refunded: 1}.Unshipped(), "returned after shipping")
+}
+
+func TestShipmentStatusLabel(t *testing.T) {
+	assert.Equal(t, "In Transit", ShipmentStatusLabel(ShipmentInTransit))
+	assert.Equal(t, "Problem", ShipmentStatusLabel(ShipmentProblem))
+	assert.True(t, ValidShipmentStatus(ShipmentDelivered))
+	assert.False(t, ValidShipmentStatus("lost"))
+}
+
+func TestAllDelivered(t *testing.T) {
+	shipped := []ShippingLine{{Quantity: 2, Shipped: 2}, {Quantity: 1, Shipped: 1}}
+	assert.True(t, AllDelivered([]string{ShipmentDelivered, ShipmentDelivered}, shipped))
+	assert.False(t, AllDelivered([]string{ShipmentDelivered, ShipmentInTransit}, shipped), "a box is still on its way")
+	assert.False(t, AllDelivered([]string{ShipmentDelivered, ShipmentProblem}, shipped))
+	assert.False(t, AllDelivered([]string{ShipmentDelivered}, []ShippingLine{{Quantity: 2, Shipped: 1}}), "more is still to ship")
+	assert.True(t, AllDelivered([]string{ShipmentDelivered}, []ShippingLine{{Quantity: 2, Shipped: 1, Refunded: 1}}))
+	assert.False(t, AllDelivered(nil, nil))
+}
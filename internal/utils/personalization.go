@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Personalization field types
+const (
+	PersonalizationTypeText     = "text"
+	PersonalizationTypeTextarea = "textarea"
+)
+
+// MaxPersonalizationLength caps the encoded personalization on a line. It's Stripe's
+// limit for a metadata value, which is how it reaches the order.
+const MaxPersonalizationLength = 500
+
+// PersonalizationValue is what a customer entered for one field. The label is kept
+// with the value so orders read the same after the field is renamed or removed.
+type PersonalizationValue struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// ValidPersonalizationType reports whether t is a personalization field type
+func ValidPersonalizationType(t string) bool {
+	return t == PersonalizationTypeText || t == PersonalizationTypeTextarea
+}
+
+// ValidatePersonalization checks the customer's input, keyed by field ID, against a
+// product's fields and returns the values in field order. Blank optional fields are
+// left out; the error message is shown to the customer.
+func ValidatePersonalization(fields []db.ProductPersonalizationField, input map[string]string) ([]PersonalizationValue, error) {
+	var values []PersonalizationValue
+	for _, field := range fields {
+		value := strings.TrimSpace(input[field.ID])
+		if field.FieldType == PersonalizationTypeText {
+			// Single-line fields are printed or engraved on one line
+			value = strings.Join(strings.Fields(value), " ")
+		}
+		if value == "" {
+			if field.IsRequired {
+				return nil, fmt.Errorf("%s is required", field.Label)
+			}
+			continue
+		}
+		if field.MaxLength > 0 && int64(utf8.RuneCountInString(value)) > field.MaxLength {
+			return nil, fmt.Errorf("%s can be at most %d characters", field.Label, field.MaxLength)
+		}
+		values = append(values, PersonalizationValue{Label: field.Label, Value: value})
+	}
+	if len(EncodePersonalization(values)) > MaxPersonalizationLength {
+		return nil, fmt.Errorf("personalization is too long")
+	}
+	return values, nil
+}
+
+// EncodePersonalization stores values as JSON, or "" when there are none
+func EncodePersonalization(values []PersonalizationValue) string {
+	if len(values) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DecodePersonalization reads values stored by EncodePersonalization. Anything
+// unreadable decodes as no personalization.
+func DecodePersonalization(raw string) []PersonalizationValue {
+	if raw == "" {
+		return nil
+	}
+	var values []PersonalizationValue
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// PersonalizationSummary joins values as "Label: value; Label: value" for places that
+// only take plain text, such as the Stripe line description
+func PersonalizationSummary(values []PersonalizationValue) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, v.Label+": "+v.Value)
+	}
+	return strings.Join(parts, "; ")
+}
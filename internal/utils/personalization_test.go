@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestValidatePersonalization(t *testing.T) {
+	fields := []db.ProductPersonalizationField{
+		{ID: "name", Label: "Engraving", FieldType: PersonalizationTypeText, MaxLength: 10, IsRequired: true},
+		{ID: "note", Label: "Note", FieldType: PersonalizationTypeTextarea},
+	}
+
+	values, err := ValidatePersonalization(fields, map[string]string{"name": "  Max   Power ", "other": "ignored"})
+	require.NoError(t, err)
+	assert.Equal(t, []PersonalizationValue{{Label: "Engraving", Value: "Max Power"}}, values)
+
+	values, err = ValidatePersonalization(fields, map[string]string{"name": "Max", "note": "line one\nline two"})
+	require.NoError(t, err)
+	assert.Equal(t, "line one\nline two", values[1].Value, "textareas keep their line breaks")
+
+	_, err = ValidatePersonalization(fields, map[string]string{"note": "hello"})
+	assert.EqualError(t, err, "Engraving is required")
+
+	_, err = ValidatePersonalization(fields, map[string]string{"name": "Maximilian Power"})
+	assert.EqualError(t, err, "Engraving can be at most 10 characters")
+
+	_, err = ValidatePersonalization(fields, map[string]string{"name": "Max", "note": strings.Repeat("x", MaxPersonalizationLength)})
+	assert.EqualError(t, err, "personalization is too long")
+
+	values, err = ValidatePersonalization(nil, map[string]string{"name": "Max"})
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestPersonalizationRoundTrip(t *testing.T) {
+	values := []PersonalizationValue{{Label: "Engraving", Value: "Max"}, {Label: "Colour", Value: "blue"}}
+
+	raw := EncodePersonalization(values)
+	assert.Equal(t, values, DecodePersonalization(raw))
+	assert.Equal(t, "Engraving: Max; Colour: blue", PersonalizationSummary(values))
+
+	assert.Equal(t, "", EncodePersonalization(nil))
+	assert.Nil(t, DecodePersonalization(""))
+	assert.Nil(t, DecodePersonalization("not json"))
+}
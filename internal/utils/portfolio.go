@@ -0,0 +1,52 @@
+package utils
+
+import "strings"
+
+// PortfolioImagePathPrefix is the public URL prefix for uploaded portfolio images
+const PortfolioImagePathPrefix = "/public/images/portfolio/"
+
+// MaxPortfolioTagLength caps a single portfolio tag so filter buttons stay short
+const MaxPortfolioTagLength = 40
+
+// ParsePortfolioTags splits an admin-entered, comma-separated tag list. Tags are
+// trimmed, inner whitespace collapsed, and repeats dropped regardless of case; the
+// first spelling and the original order are kept.
+func ParsePortfolioTags(raw string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		tag := strings.Join(strings.Fields(part), " ")
+		if tag == "" {
+			continue
+		}
+		if runes := []rune(tag); len(runes) > MaxPortfolioTagLength {
+			tag = strings.TrimSpace(string(runes[:MaxPortfolioTagLength]))
+		}
+		key := PortfolioTagKey(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// FormatPortfolioTags joins tags the way they're stored and shown in the admin form
+func FormatPortfolioTags(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+// PortfolioTagKey is the case-insensitive form used to match tags in the gallery filter
+func PortfolioTagKey(tag string) string {
+	return strings.ToLower(tag)
+}
+
+// PortfolioImageURL returns the public URL of an uploaded portfolio image, which is
+// stored as a bare filename like product images
+func PortfolioImageURL(filename string) string {
+	if filename == "" {
+		return ""
+	}
+	return PortfolioImagePathPrefix + filename
+}
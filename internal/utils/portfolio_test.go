@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePortfolioTags(t *testing.T) {
+	assert.Equal(t, []string{"Cosplay", "large prints", "Gifts"},
+		ParsePortfolioTags(" Cosplay ,large  prints,, cosplay,Large Prints, Gifts "))
+	assert.Nil(t, ParsePortfolioTags(" , "))
+
+	long := strings.Repeat("é", MaxPortfolioTagLength+5)
+	assert.Equal(t, []string{strings.Repeat("é", MaxPortfolioTagLength)}, ParsePortfolioTags(long))
+}
+
+func TestFormatPortfolioTags(t *testing.T) {
+	assert.Equal(t, "cosplay, gifts", FormatPortfolioTags(ParsePortfolioTags("cosplay,gifts, Cosplay")))
+	assert.Equal(t, "large prints", PortfolioTagKey("Large Prints"))
+}
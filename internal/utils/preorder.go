@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// ShippingTimePreorderPrefix precedes the expected ship date for pre-order items
+	ShippingTimePreorderPrefix = "Pre-order - expected to ship by"
+
+	// ShippingTimePreorderUndated is shown for pre-order items without an upcoming ship date
+	ShippingTimePreorderUndated = "Pre-order - ships when released"
+)
+
+// PreorderShippingMessage returns the shipping message for a pre-order item, using the
+// expected ship date (YYYY-MM-DD) when it is set and still upcoming
+func PreorderShippingMessage(shipDate string) string {
+	if date, ok := PromisedShipDate(shipDate, time.Now()); ok {
+		return fmt.Sprintf("%s %s", ShippingTimePreorderPrefix, date.Format("January 2, 2006"))
+	}
+	return ShippingTimePreorderUndated
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreorderShippingMessage(t *testing.T) {
+	future := time.Now().AddDate(0, 2, 0)
+	past := time.Now().AddDate(0, 0, -3)
+
+	assert.Equal(t, ShippingTimePreorderPrefix+" "+future.Format("January 2, 2006"), PreorderShippingMessage(future.Format(BackorderDateLayout)))
+	assert.Equal(t, ShippingTimePreorderUndated, PreorderShippingMessage(""))
+	assert.Equal(t, ShippingTimePreorderUndated, PreorderShippingMessage(past.Format(BackorderDateLayout)))
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Public URL prefixes for uploaded product and style images
+const (
+	ProductImagePathPrefix = "/public/images/products/"
+	StyleImagePathPrefix   = "/public/images/products/styles/"
+)
+
+// multiVariantOGImages remembers which products have a generated multi-variant OG
+// image, so card listings check the disk once per product rather than on every load
+var multiVariantOGImages sync.Map
+
+// MultiVariantOGImagePath is where a variant product's multi-variant OG image is written
+func MultiVariantOGImagePath(productID string) string {
+	return fmt.Sprintf("public/og-images/product-%s-multi.png", productID)
+}
+
+// RecordMultiVariantOGImage notes that a product's multi-variant OG image was just
+// written, so cards pick it up without another disk check
+func RecordMultiVariantOGImage(productID string) {
+	multiVariantOGImages.Store(productID, true)
+}
+
+func hasMultiVariantOGImage(productID string) bool {
+	if exists, ok := multiVariantOGImages.Load(productID); ok {
+		return exists.(bool)
+	}
+	_, err := os.Stat(MultiVariantOGImagePath(productID))
+	multiVariantOGImages.Store(productID, err == nil)
+	return err == nil
+}
+
+// ProductImageURLs returns the card image URL for each product, keyed by product ID,
+// using a single query for the whole list. Products without any image are left out.
+func ProductImageURLs(ctx context.Context, queries *db.Queries, products []db.Product) (map[string]string, error) {
+	urls := make(map[string]string, len(products))
+	if len(products) == 0 {
+		return urls, nil
+	}
+
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	rows, err := queries.ListProductCardImages(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product card images: %w", err)
+	}
+	images := make(map[string]db.ListProductCardImagesRow, len(rows))
+	for _, row := range rows {
+		images[row.ProductID] = row
+	}
+
+	for _, product := range products {
+		row := images[product.ID]
+		if url := ProductImageURL(product, row.ProductImage, row.StyleImage); url != "" {
+			urls[product.ID] = url
+		}
+	}
+	return urls, nil
+}
+
+// ProductImageURL picks a product's card image. Variant products prefer the generated
+// multi-variant OG image, then their primary style's image; everything falls back to
+// the primary (or first) product image.
+func ProductImageURL(product db.Product, productImage, styleImage string) string {
+	if product.HasVariants.Valid && product.HasVariants.Bool {
+		if hasMultiVariantOGImage(product.ID) {
+			return "/" + MultiVariantOGImagePath(product.ID)
+		}
+		if styleImage != "" {
+			return StyleImagePathPrefix + styleImage
+		}
+	}
+
+	if productImage != "" {
+		return ProductImagePathPrefix + productImage
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func newProductImageTestDB(t testing.TB) (*sql.DB, *db.Queries) {
+	database, queries, cleanup, err := storage.NewTestDB()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	return database, queries
+}
+
+func mustExec(t testing.TB, database *sql.DB, query string, args ...any) {
+	_, err := database.Exec(query, args...)
+	require.NoError(t, err)
+}
+
+func TestProductImageURLs(t *testing.T) {
+	database, queries := newProductImageTestDB(t)
+
+	// Plain product: the primary image wins over display order
+	mustExec(t, database, `INSERT INTO products (id, name, slug, price_cents) VALUES ('trex', 'T-Rex', 'trex', 2500)`)
+	mustExec(t, database, `INSERT INTO product_images (id, product_id, image_url, is_primary, display_order) VALUES ('trex-1', 'trex', 'trex-side.jpg', 0, 0)`)
+	mustExec(t, database, `INSERT INTO product_images (id, product_id, image_url, is_primary, display_order) VALUES ('trex-2', 'trex', 'trex-front.jpg', 1, 1)`)
+
+	// Variant product: the primary style's image wins over product images
+	mustExec(t, database, `INSERT INTO products (id, name, slug, price_cents, has_variants) VALUES ('raptor', 'Raptor', 'raptor', 1800, 1)`)
+	mustExec(t, database, `INSERT INTO product_images (id, product_id, image_url, is_primary, display_order) VALUES ('raptor-1', 'raptor', 'raptor.jpg', 1, 0)`)
+	mustExec(t, database, `INSERT INTO product_styles (id, product_id, name, is_primary, display_order) VALUES ('raptor-red', 'raptor', 'Red', 0, 0)`)
+	mustExec(t, database, `INSERT INTO product_styles (id, product_id, name, is_primary, display_order) VALUES ('raptor-green', 'raptor', 'Green', 1, 1)`)
+	mustExec(t, database, `INSERT INTO product_style_images (id, product_style_id, image_url, is_primary, display_order) VALUES ('red-1', 'raptor-red', 'raptor-red.jpg', 1, 0)`)
+	mustExec(t, database, `INSERT INTO product_style_images (id, product_style_id, image_url, is_primary, display_order) VALUES ('green-1', 'raptor-green', 'raptor-green.jpg', 1, 0)`)
+
+	// Variant product whose style has no photo yet falls back to the product image
+	mustExec(t, database, `INSERT INTO products (id, name, slug, price_cents, has_variants) VALUES ('stego', 'Stego', 'stego', 2000, 1)`)
+	mustExec(t, database, `INSERT INTO product_images (id, product_id, image_url, is_primary, display_order) VALUES ('stego-1', 'stego', 'stego.jpg', 0, 0)`)
+	mustExec(t, database, `INSERT INTO product_styles (id, product_id, name, is_primary, display_order) VALUES ('stego-blue', 'stego', 'Blue', 1, 0)`)
+
+	// No images at all
+	mustExec(t, database, `INSERT INTO products (id, name, slug, price_cents) VALUES ('egg', 'Egg', 'egg', 500)`)
+
+	products, err := queries.ListProducts(context.Background())
+	require.NoError(t, err)
+	require.Len(t, products, 4)
+
+	urls, err := ProductImageURLs(context.Background(), queries, products)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"trex":   "/public/images/products/trex-front.jpg",
+		"raptor": "/public/images/products/styles/raptor-green.jpg",
+		"stego":  "/public/images/products/stego.jpg",
+	}, urls)
+
+	// The batched lookup agrees with the per-product lookups it replaced
+	for _, product := range products {
+		assert.Equal(t, urls[product.ID], perProductImageURL(context.Background(), queries, product), product.ID)
+	}
+}
+
+func TestProductImageURLsEmpty(t *testing.T) {
+	urls, err := ProductImageURLs(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
+
+func TestProductImageURLMultiVariantOG(t *testing.T) {
+	product := db.Product{ID: "og-test-ankylo", HasVariants: sql.NullBool{Bool: true, Valid: true}}
+
+	// No generated image on disk: the style image is used, and the miss is remembered
+	assert.Equal(t, "/public/images/products/styles/ankylo.jpg", ProductImageURL(product, "", "ankylo.jpg"))
+
+	// Once the generator records its image, cards switch to it without a restart
+	RecordMultiVariantOGImage(product.ID)
+	t.Cleanup(func() { multiVariantOGImages.Delete(product.ID) })
+	assert.Equal(t, "/public/og-images/product-og-test-ankylo-multi.png", ProductImageURL(product, "", "ankylo.jpg"))
+
+	// Products without variants never use it
+	product.HasVariants = sql.NullBool{}
+	assert.Equal(t, "/public/images/products/ankylo.jpg", ProductImageURL(product, "ankylo.jpg", ""))
+}
+
+// perProductImageURL is the old listing lookup: up to three queries per product
+func perProductImageURL(ctx context.Context, queries *db.Queries, product db.Product) string {
+	var styleImage string
+	if product.HasVariants.Valid && product.HasVariants.Bool {
+		styles, err := queries.GetProductStyles(ctx, product.ID)
+		if err == nil && len(styles) > 0 {
+			image, err := queries.GetPrimaryStyleImage(ctx, styles[0].ID)
+			if err == nil {
+				styleImage = image.ImageUrl
+			}
+		}
+	}
+
+	var productImage string
+	images, err := queries.GetProductImages(ctx, product.ID)
+	if err == nil && len(images) > 0 {
+		productImage = images[0].ImageUrl
+		for _, img := range images {
+			if img.IsPrimary.Valid && img.IsPrimary.Bool {
+				productImage = img.ImageUrl
+				break
+			}
+		}
+	}
+	return ProductImageURL(product, productImage, styleImage)
+}
+
+// BenchmarkProductImageURLs compares a shop page's worth of per-product image lookups
+// with the single batched query
+func BenchmarkProductImageURLs(b *testing.B) {
+	database, queries := newProductImageTestDB(b)
+	for i := 0; i < 24; i++ {
+		id := fmt.Sprintf("p%02d", i)
+		mustExec(b, database, `INSERT INTO products (id, name, slug, price_cents, has_variants) VALUES (?, ?, ?, 1000, ?)`, id, id, id, i%2)
+		for j := 0; j < 3; j++ {
+			mustExec(b, database, `INSERT INTO product_images (id, product_id, image_url, is_primary, display_order) VALUES (?, ?, ?, ?, ?)`,
+				fmt.Sprintf("%s-img-%d", id, j), id, fmt.Sprintf("%s-%d.jpg", id, j), j == 1, j)
+		}
+		if i%2 == 1 {
+			styleID := id + "-style"
+			mustExec(b, database, `INSERT INTO product_styles (id, product_id, name, is_primary, display_order) VALUES (?, ?, 'Default', 1, 0)`, styleID, id)
+			mustExec(b, database, `INSERT INTO product_style_images (id, product_style_id, image_url, is_primary, display_order) VALUES (?, ?, ?, 1, 0)`,
+				styleID+"-img", styleID, styleID+".jpg")
+		}
+	}
+
+	ctx := context.Background()
+	products, err := queries.ListProducts(ctx)
+	require.NoError(b, err)
+
+	b.Run("per-product", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, product := range products {
+				perProductImageURL(ctx, queries, product)
+			}
+		}
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ProductImageURLs(ctx, queries, products); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package utils
+
+// Hand-picked product relation types. Cross-sells go alongside the product; upsells
+// are a step up from it.
+const (
+	RelationTypeCrossSell = "cross_sell"
+	RelationTypeUpsell    = "upsell"
+)
+
+// ValidRelationType reports whether t is a product relation type
+func ValidRelationType(t string) bool {
+	return t == RelationTypeCrossSell || t == RelationTypeUpsell
+}
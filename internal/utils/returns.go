@@ -0,0 +1,89 @@
+package utils
+
+// Return (RMA) statuses
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+	ReturnClosed    = "closed"
+)
+
+// MaxReturnPhotos caps how many photos a customer can attach to a return request
+const MaxReturnPhotos = 5
+
+// MaxReturnPhotoSize is the largest photo accepted with a return request, in bytes
+const MaxReturnPhotoSize = 10 << 20
+
+// ReturnReason is a reason code a customer picks when asking to return items
+type ReturnReason struct {
+	Code  string
+	Label string
+}
+
+// ReturnReasons are offered in this order on the return form
+var ReturnReasons = []ReturnReason{
+	{Code: "damaged", Label: "Arrived damaged"},
+	{Code: "defective", Label: "Defective or broke in use"},
+	{Code: "wrong_item", Label: "Wrong item or color"},
+	{Code: "not_as_described", Label: "Not as described"},
+	{Code: "no_longer_needed", Label: "No longer needed"},
+	{Code: "other", Label: "Other"},
+}
+
+// ReturnReasonLabel returns the customer-facing text for a reason code
+func ReturnReasonLabel(code string) string {
+	for _, reason := range ReturnReasons {
+		if reason.Code == code {
+			return reason.Label
+		}
+	}
+	return code
+}
+
+// ValidReturnReason reports whether code is one of ReturnReasons
+func ValidReturnReason(code string) bool {
+	for _, reason := range ReturnReasons {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// returnTransitions lists where a return can go from each status. Refunded only
+// comes from received, through the refund action rather than a status change.
+var returnTransitions = map[string][]string{
+	ReturnRequested: {ReturnApproved, ReturnRejected},
+	ReturnApproved:  {ReturnReceived, ReturnClosed},
+	ReturnReceived:  {ReturnClosed},
+	ReturnRefunded:  {ReturnClosed},
+}
+
+// CanMoveReturn reports whether an admin can move a return from one status to another
+func CanMoveReturn(from, to string) bool {
+	for _, next := range returnTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextReturnStatuses lists the statuses a return can be moved to from status
+func NextReturnStatuses(status string) []string {
+	return returnTransitions[status]
+}
+
+// OrderReturnable reports whether a customer can ask to return items from an order
+// in this status. Items have to have been sent first.
+func OrderReturnable(orderStatus string) bool {
+	return orderStatus == "shipped" || orderStatus == "delivered"
+}
+
+// ReturnableQuantity is how many units of an order line can still go on a return,
+// given the units already on returns that weren't rejected
+func ReturnableQuantity(ordered, alreadyReturned int64) int64 {
+	return max(ordered-alreadyReturned, 0)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanMoveReturn(t *testing.T) {
+	assert.True(t, CanMoveReturn(ReturnRequested, ReturnApproved))
+	assert.True(t, CanMoveReturn(ReturnRequested, ReturnRejected))
+	assert.True(t, CanMoveReturn(ReturnApproved, ReturnReceived))
+	assert.True(t, CanMoveReturn(ReturnReceived, ReturnClosed))
+
+	assert.False(t, CanMoveReturn(ReturnRequested, ReturnReceived), "has to be approved first")
+	assert.False(t, CanMoveReturn(ReturnReceived, ReturnRefunded), "refunds go through the refund action")
+	assert.False(t, CanMoveReturn(ReturnRejected, ReturnApproved))
+	assert.False(t, CanMoveReturn(ReturnClosed, ReturnRequested))
+	assert.Empty(t, NextReturnStatuses(ReturnClosed))
+}
+
+func TestReturnReasons(t *testing.T) {
+	assert.True(t, ValidReturnReason("damaged"))
+	assert.False(t, ValidReturnReason("changed_mind"))
+	assert.Equal(t, "Wrong item or color", ReturnReasonLabel("wrong_item"))
+	assert.Equal(t, "mystery", ReturnReasonLabel("mystery"))
+}
+
+func TestReturnableQuantity(t *testing.T) {
+	assert.Equal(t, int64(3), ReturnableQuantity(3, 0))
+	assert.Equal(t, int64(1), ReturnableQuantity(3, 2))
+	assert.Equal(t, int64(0), ReturnableQuantity(2, 5))
+
+	assert.True(t, OrderReturnable("delivered"))
+	assert.True(t, OrderReturnable("shipped"))
+	assert.False(t, OrderReturnable("in_production"))
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "github.com/loganlanou/logans3d-v4/storage/db"
+
+// ProductOnSale reports whether a product's sale is running. A running sale is
+// already applied to PriceCents, and RegularPriceCents keeps the price it replaced.
+func ProductOnSale(product db.Product) bool {
+	return product.RegularPriceCents.Valid && product.RegularPriceCents.Int64 > product.PriceCents
+}
+
+// ProductRegularPriceCents is the price a product sells for when it isn't on sale
+func ProductRegularPriceCents(product db.Product) int64 {
+	if ProductOnSale(product) {
+		return product.RegularPriceCents.Int64
+	}
+	return product.PriceCents
+}
+
+// SaleEndsLabel formats when a sale ends for customers, e.g. "Sale ends Friday, March 6
+// at 7:00 PM". Sales with no end get no label.
+func SaleEndsLabel(product db.Product) string {
+	if !ProductOnSale(product) || !product.SaleEndsAt.Valid {
+		return ""
+	}
+	return "Sale ends " + product.SaleEndsAt.Time.Format("Monday, January 2 at 3:04 PM")
+}
+
+// SalePercentOff is how much a sale takes off, rounded down to a whole percent
+func SalePercentOff(saleCents, regularCents int64) int64 {
+	if regularCents <= 0 || saleCents >= regularCents {
+		return 0
+	}
+	return (regularCents - saleCents) * 100 / regularCents
+}
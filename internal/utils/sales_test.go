@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestProductSale(t *testing.T) {
+	product := db.Product{PriceCents: 2500}
+	assert.False(t, ProductOnSale(product))
+	assert.Equal(t, int64(2500), ProductRegularPriceCents(product))
+	assert.Empty(t, SaleEndsLabel(product))
+
+	// A sale that's scheduled but hasn't started leaves the price alone
+	product.SalePriceCents = sql.NullInt64{Int64: 2000, Valid: true}
+	assert.False(t, ProductOnSale(product))
+
+	product.PriceCents = 2000
+	product.RegularPriceCents = sql.NullInt64{Int64: 2500, Valid: true}
+	assert.True(t, ProductOnSale(product))
+	assert.Equal(t, int64(2500), ProductRegularPriceCents(product))
+	assert.Empty(t, SaleEndsLabel(product), "sales with no end get no label")
+
+	product.SaleEndsAt = sql.NullTime{Time: time.Date(2026, 3, 6, 19, 0, 0, 0, time.UTC), Valid: true}
+	assert.Equal(t, "Sale ends Friday, March 6 at 7:00 PM", SaleEndsLabel(product))
+
+	assert.Equal(t, int64(20), SalePercentOff(2000, 2500))
+	assert.Equal(t, int64(33), SalePercentOff(1999, 3000))
+	assert.Equal(t, int64(0), SalePercentOff(2500, 2500))
+	assert.Equal(t, int64(0), SalePercentOff(100, 0))
+}
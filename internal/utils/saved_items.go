@@ -0,0 +1,48 @@
+package utils
+
+import "fmt"
+
+// Availability of a saved-for-later line, so the cart can warn before it's moved back
+const (
+	SavedItemAvailable   = "available"
+	SavedItemLowStock    = "low_stock"
+	SavedItemBackorder   = "backorder"
+	SavedItemSoldOut     = "sold_out"
+	SavedItemUnavailable = "unavailable"
+)
+
+// SavedItemStock is what decides whether a saved line can go back in the cart
+type SavedItemStock struct {
+	Active            bool
+	Stock             int64
+	LowStockThreshold int64
+	Quantity          int64
+	AllowBackorder    bool
+	Preorder          bool
+	Digital           bool
+}
+
+// SavedItemAvailability returns a saved line's status and the message shown under it.
+// Only sold-out and unavailable lines can't be moved back to the cart.
+func SavedItemAvailability(item SavedItemStock) (string, string) {
+	switch {
+	case !item.Active:
+		return SavedItemUnavailable, "No longer available"
+	case item.Digital, item.Preorder:
+		return SavedItemAvailable, ""
+	case item.Stock <= 0 && item.AllowBackorder:
+		return SavedItemBackorder, "Out of stock - can be backordered"
+	case item.Stock <= 0:
+		return SavedItemSoldOut, "Sold out"
+	case item.Stock < item.Quantity && item.AllowBackorder:
+		return SavedItemBackorder, fmt.Sprintf("Only %d in stock - the rest will be backordered", item.Stock)
+	case item.Stock < item.Quantity || item.Stock <= item.LowStockThreshold:
+		return SavedItemLowStock, fmt.Sprintf("Only %d left", item.Stock)
+	}
+	return SavedItemAvailable, ""
+}
+
+// SavedItemMovable reports whether a line with this status can go back in the cart
+func SavedItemMovable(status string) bool {
+	return status != SavedItemSoldOut && status != SavedItemUnavailable
+}
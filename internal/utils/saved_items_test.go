@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedItemAvailability(t *testing.T) {
+	tests := []struct {
+		name        string
+		item        SavedItemStock
+		wantStatus  string
+		wantMessage string
+	}{
+		{"in stock", SavedItemStock{Active: true, Stock: 10, LowStockThreshold: 5, Quantity: 1}, SavedItemAvailable, ""},
+		{"running low", SavedItemStock{Active: true, Stock: 3, LowStockThreshold: 5, Quantity: 1}, SavedItemLowStock, "Only 3 left"},
+		{"fewer than saved", SavedItemStock{Active: true, Stock: 2, Quantity: 4}, SavedItemLowStock, "Only 2 left"},
+		{"fewer than saved, backorderable", SavedItemStock{Active: true, Stock: 2, Quantity: 4, AllowBackorder: true}, SavedItemBackorder, "Only 2 in stock - the rest will be backordered"},
+		{"sold out", SavedItemStock{Active: true, Quantity: 1}, SavedItemSoldOut, "Sold out"},
+		{"sold out, backorderable", SavedItemStock{Active: true, Quantity: 1, AllowBackorder: true}, SavedItemBackorder, "Out of stock - can be backordered"},
+		{"pre-order", SavedItemStock{Active: true, Quantity: 1, Preorder: true}, SavedItemAvailable, ""},
+		{"download", SavedItemStock{Active: true, Quantity: 1, Digital: true}, SavedItemAvailable, ""},
+		{"deactivated", SavedItemStock{Stock: 10, Quantity: 1}, SavedItemUnavailable, "No longer available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := SavedItemAvailability(tt.item)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
+
+func TestSavedItemMovable(t *testing.T) {
+	assert.True(t, SavedItemMovable(SavedItemAvailable))
+	assert.True(t, SavedItemMovable(SavedItemLowStock))
+	assert.True(t, SavedItemMovable(SavedItemBackorder))
+	assert.False(t, SavedItemMovable(SavedItemSoldOut))
+	assert.False(t, SavedItemMovable(SavedItemUnavailable))
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// VideoProviderUpload videos are files served from public/videos/products
+	VideoProviderUpload = "upload"
+
+	// VideoProviderYouTube videos are embedded from YouTube by video ID
+	VideoProviderYouTube = "youtube"
+
+	// VideoProviderVimeo videos are embedded from Vimeo by video ID
+	VideoProviderVimeo = "vimeo"
+
+	// ProductVideoDir is where uploaded product videos are stored
+	ProductVideoDir = "public/videos/products"
+)
+
+// VideoContentTypes are the upload formats browsers can play, by extension
+var VideoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+}
+
+var (
+	youTubeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	vimeoIDPattern   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// ErrUnsupportedVideoURL is returned for links that aren't a YouTube or Vimeo video
+var ErrUnsupportedVideoURL = errors.New("enter a YouTube or Vimeo video link")
+
+// ParseVideoURL returns the provider and video ID for a YouTube or Vimeo link.
+// Watch, share, shorts and embed links are all accepted.
+func ParseVideoURL(raw string) (provider, id string, err error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", "", ErrUnsupportedVideoURL
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	switch host {
+	case "youtube.com", "youtube-nocookie.com":
+		if len(segments) == 1 && segments[0] == "watch" {
+			id = u.Query().Get("v")
+		} else if len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts" || segments[0] == "live") {
+			id = segments[1]
+		}
+		if youTubeIDPattern.MatchString(id) {
+			return VideoProviderYouTube, id, nil
+		}
+	case "youtu.be":
+		if len(segments) == 1 && youTubeIDPattern.MatchString(segments[0]) {
+			return VideoProviderYouTube, segments[0], nil
+		}
+	case "vimeo.com", "player.vimeo.com":
+		// vimeo.com/123, vimeo.com/channels/staffpicks/123, player.vimeo.com/video/123
+		if last := segments[len(segments)-1]; vimeoIDPattern.MatchString(last) {
+			return VideoProviderVimeo, last, nil
+		}
+	}
+	return "", "", ErrUnsupportedVideoURL
+}
+
+// VideoContentType returns the content type for an uploaded video file name, or
+// "" if browsers can't play it
+func VideoContentType(fileName string) string {
+	return VideoContentTypes[strings.ToLower(filepath.Ext(fileName))]
+}
+
+// VideoEmbedURL is the player URL for a provider video, or the file URL for an upload
+func VideoEmbedURL(provider, ref string) string {
+	switch provider {
+	case VideoProviderYouTube:
+		return "https://www.youtube-nocookie.com/embed/" + ref + "?rel=0"
+	case VideoProviderVimeo:
+		return "https://player.vimeo.com/video/" + ref
+	default:
+		return "/public/videos/products/" + ref
+	}
+}
+
+// VideoWatchURL is the public page for a video, used for og:video
+func VideoWatchURL(provider, ref string) string {
+	switch provider {
+	case VideoProviderYouTube:
+		return "https://www.youtube.com/watch?v=" + ref
+	case VideoProviderVimeo:
+		return "https://vimeo.com/" + ref
+	default:
+		return "/public/videos/products/" + ref
+	}
+}
+
+// VideoPosterURL is the poster frame for a video. An uploaded poster wins; YouTube
+// falls back to its own thumbnail. Vimeo and uploads without a poster return "".
+func VideoPosterURL(provider, ref, poster string) string {
+	if poster != "" {
+		return poster
+	}
+	if provider == VideoProviderYouTube {
+		return "https://i.ytimg.com/vi/" + ref + "/hqdefault.jpg"
+	}
+	return ""
+}
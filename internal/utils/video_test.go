@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVideoURL(t *testing.T) {
+	tests := []struct {
+		raw      string
+		provider string
+		id       string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42s", VideoProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://youtu.be/dQw4w9WgXcQ?si=abc", VideoProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://m.youtube.com/shorts/dQw4w9WgXcQ", VideoProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", VideoProviderYouTube, "dQw4w9WgXcQ"},
+		{"https://vimeo.com/76979871", VideoProviderVimeo, "76979871"},
+		{"https://vimeo.com/channels/staffpicks/76979871", VideoProviderVimeo, "76979871"},
+		{"https://player.vimeo.com/video/76979871?h=abc", VideoProviderVimeo, "76979871"},
+	}
+	for _, tt := range tests {
+		provider, id, err := ParseVideoURL(tt.raw)
+		assert.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.provider, provider, tt.raw)
+		assert.Equal(t, tt.id, id, tt.raw)
+	}
+
+	for _, raw := range []string{
+		"",
+		"not a url",
+		"https://www.youtube.com/watch?v=short",
+		"https://www.youtube.com/channel/UC123",
+		"https://vimeo.com/about",
+		"https://example.com/video.mp4",
+	} {
+		_, _, err := ParseVideoURL(raw)
+		assert.ErrorIs(t, err, ErrUnsupportedVideoURL, raw)
+	}
+}
+
+func TestVideoPosterURL(t *testing.T) {
+	assert.Equal(t, "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg", VideoPosterURL(VideoProviderYouTube, "dQw4w9WgXcQ", ""))
+	assert.Equal(t, "/public/images/products/poster.jpg", VideoPosterURL(VideoProviderYouTube, "dQw4w9WgXcQ", "/public/images/products/poster.jpg"))
+	assert.Equal(t, "", VideoPosterURL(VideoProviderVimeo, "76979871", ""))
+	assert.Equal(t, "video/webm", VideoContentType("Print Timelapse.WEBM"))
+	assert.Equal(t, "", VideoContentType("model.stl"))
+}
@@ -102,6 +102,49 @@ const Analytics = {
         });
     },
 
+    /**
+     * Track when the checkout add-ons step is shown
+     * @param {Array} offers - Offered add-ons, each with id, name and price in dollars
+     */
+    viewAddOns: function(offers) {
+        if (typeof gtag === 'undefined') return;
+
+        gtag('event', 'view_item_list', {
+            item_list_id: 'checkout_add_ons',
+            item_list_name: 'Checkout add-ons',
+            items: offers.map((offer, index) => ({
+                item_id: offer.id,
+                item_name: offer.name,
+                price: offer.price,
+                index: index
+            }))
+        });
+    },
+
+    /**
+     * Track when a user adds an add-on from the checkout add-ons step
+     * @param {Object} offer - Add-on data
+     * @param {string} offer.id - Product ID
+     * @param {string} offer.name - Product name
+     * @param {number} offer.price - Product price in dollars
+     */
+    addAddOnToCart: function(offer) {
+        if (typeof gtag === 'undefined') return;
+
+        gtag('event', 'add_to_cart', {
+            currency: 'USD',
+            value: offer.price,
+            items: [{
+                item_id: offer.id,
+                item_name: offer.name,
+                item_list_id: 'checkout_add_ons',
+                item_list_name: 'Checkout add-ons',
+                price: offer.price,
+                quantity: 1
+            }]
+        });
+    },
+
     /**
      * Track when a user begins checkout
      * @param {Object} cart - Cart data
@@ -0,0 +1,114 @@
+// Live stock - polls /api/products/:id/availability so stock notes and add-to-cart
+// buttons on the product and cart pages keep up with other shoppers' purchases
+(function () {
+    const POLL_INTERVAL_MS = 30000;
+    const watchers = new Map();
+    const latest = new Map();
+    let timer = null;
+
+    async function load(productId) {
+        try {
+            const response = await fetch('/api/products/' + encodeURIComponent(productId) + '/availability');
+            if (!response.ok) {
+                return;
+            }
+            const data = await response.json();
+            latest.set(productId, data);
+            (watchers.get(productId) || []).forEach(callback => callback(data));
+        } catch (error) {
+            console.error('Error loading availability:', error);
+        }
+    }
+
+    function refresh() {
+        return Promise.all(Array.from(watchers.keys()).map(load));
+    }
+
+    // Poll only while the page is visible; background tabs catch up when they're shown
+    function schedule() {
+        clearTimeout(timer);
+        if (document.hidden || watchers.size === 0) {
+            return;
+        }
+        timer = setTimeout(() => refresh().then(schedule), POLL_INTERVAL_MS);
+    }
+
+    // Call callback with the product's availability now and whenever it's polled
+    function watch(productId, callback) {
+        if (!productId) {
+            return;
+        }
+        const callbacks = watchers.get(productId) || [];
+        callbacks.push(callback);
+        watchers.set(productId, callbacks);
+        if (latest.has(productId)) {
+            callback(latest.get(productId));
+        } else {
+            load(productId);
+        }
+        schedule();
+    }
+
+    document.addEventListener('visibilitychange', () => {
+        if (document.hidden) {
+            clearTimeout(timer);
+        } else {
+            refresh().then(schedule);
+        }
+    });
+
+    // What's in the cart changes how many more can be added
+    window.addEventListener('cart-updated', () => {
+        refresh().then(schedule);
+    });
+
+    // What an add-to-cart button says when nothing more can be added
+    function blockedLabel(availability) {
+        if (availability.status === 'sold_out' || availability.status === 'unavailable') {
+            return availability.message;
+        }
+        return "Can't add more";
+    }
+
+    // Simple products: the stock note, quantity select and add-to-cart button rendered
+    // inside [data-availability-product]. Variant products update from productVariants.
+    function bindSimpleProduct(container) {
+        const button = container.querySelector('.add-to-cart-btn');
+        const note = container.querySelector('[data-availability-note]');
+        const select = container.querySelector('#product-quantity');
+        const label = button ? button.textContent.trim() : '';
+
+        watch(container.dataset.availabilityProduct, (data) => {
+            if (note) {
+                note.textContent = data.message || '';
+                note.classList.toggle('hidden', !data.message);
+            }
+            if (button) {
+                button.disabled = !data.can_add_to_cart;
+                button.textContent = data.can_add_to_cart ? label : blockedLabel(data);
+                button.classList.toggle('opacity-50', !data.can_add_to_cart);
+                button.classList.toggle('cursor-not-allowed', !data.can_add_to_cart);
+            }
+            if (select) {
+                const limit = data.max_quantity === null ? Infinity : Math.max(data.max_quantity, 1);
+                Array.from(select.options).forEach(option => {
+                    option.disabled = parseInt(option.value, 10) > limit;
+                });
+                if (parseInt(select.value, 10) > limit) {
+                    select.value = String(limit);
+                }
+            }
+        });
+    }
+
+    document.addEventListener('DOMContentLoaded', () => {
+        document.querySelectorAll('[data-availability-product]').forEach(bindSimpleProduct);
+    });
+
+    window.ProductAvailability = {
+        watch: watch,
+        refresh: refresh,
+        get: productId => latest.get(productId),
+        blockedLabel: blockedLabel
+    };
+})();
@@ -8,6 +8,7 @@ document.addEventListener('DOMContentLoaded', function() {
             }
             const cart = await response.json();
             renderCart(cart);
+            fetchAndRenderSaved();
 
             // Track view_cart event with GA4
             if (typeof Analytics !== 'undefined' && cart.items && cart.items.length > 0) {
@@ -24,8 +25,8 @@ document.addEventListener('DOMContentLoaded', function() {
                 });
             }
 
-            // Load saved shipping selection after cart renders
-            if (cart.items && cart.items.length > 0 && window.shippingManager) {
+            // Load saved shipping selection after cart renders (downloads need no shipping)
+            if (cart.items && cart.items.length > 0 && !cart.digitalOnly && window.shippingManager) {
                 await window.shippingManager.loadSavedShipping();
             }
         } catch (error) {
@@ -34,6 +35,79 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     }
 
+    async function fetchAndRenderSaved() {
+        try {
+            const response = await fetch('/api/cart/saved');
+            if (!response.ok) {
+                throw new Error('Failed to fetch saved items');
+            }
+            const saved = await response.json();
+            renderSaved(saved.items || []);
+        } catch (error) {
+            console.error('Error fetching saved items:', error);
+            renderSaved([]);
+        }
+    }
+
+    // Saved lines sit outside the cart, so they stay visible even when the cart is empty
+    function renderSaved(items) {
+        const savedSection = document.getElementById('saved-items-section');
+        const savedItems = document.getElementById('saved-items');
+        if (!savedSection || !savedItems) return;
+
+        savedSection.classList.toggle('hidden', items.length === 0);
+        const savedCount = document.getElementById('saved-items-count');
+        if (savedCount) savedCount.textContent = items.length;
+
+        const availabilityClass = {
+            available: 'text-emerald-400',
+            low_stock: 'text-amber-400',
+            backorder: 'text-amber-400',
+            sold_out: 'text-red-400',
+            unavailable: 'text-slate-400'
+        };
+
+        savedItems.innerHTML = items.map(item => {
+            const imageSrc = cartImageSrc(item.image_url);
+            const imageHtml = imageSrc ?
+                '<img src="' + imageSrc + '" alt="' + escapeHtml(item.name) + '" class="w-16 h-16 rounded-xl object-cover bg-slate-700/50">' :
+                '<div class="w-16 h-16 rounded-xl bg-slate-700/50"></div>';
+            const variantLine = item.variant_name ? '<p class="text-sm text-slate-300">' + escapeHtml(item.variant_name) + '</p>' : '';
+            const moveButton = item.can_move_to_cart ?
+                '<button class="saved-move-btn px-4 py-2 rounded-lg bg-blue-600 hover:bg-blue-700 text-white text-sm font-semibold transition-colors duration-200" data-saved-item-id="' + item.id + '">Move to cart</button>' :
+                '<button class="saved-move-btn px-4 py-2 rounded-lg bg-slate-600 text-slate-300 text-sm font-semibold cursor-not-allowed opacity-50" data-saved-item-id="' + item.id + '" disabled>Move to cart</button>';
+
+            return '<div class="flex items-center gap-4 p-4 rounded-2xl bg-slate-800/40 border border-slate-700/50">' +
+                '<div class="flex-shrink-0">' + imageHtml + '</div>' +
+                '<div class="flex-1 min-w-0">' +
+                    '<h3 class="text-lg font-semibold text-white">' + escapeHtml(item.name) + '</h3>' +
+                    variantLine +
+                    '<p class="text-sm text-slate-300">' + formatMoney(item.price_cents) + ' · Qty ' + item.quantity + '</p>' +
+                    '<p class="text-xs ' + (availabilityClass[item.availability] || 'text-slate-400') + '">' + escapeHtml(item.availability_message) + '</p>' +
+                '</div>' +
+                '<div class="flex flex-col items-end gap-2">' +
+                    moveButton +
+                    '<button class="saved-remove-btn text-sm text-red-400 hover:text-red-300" data-saved-item-id="' + item.id + '">Remove</button>' +
+                '</div>' +
+            '</div>';
+        }).join('');
+    }
+
+    // Customer-entered text must not be rendered as markup
+    function escapeHtml(value) {
+        return String(value ?? '').replace(/[&<>"']/g, ch => ({
+            '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'
+        })[ch]);
+    }
+
+    // Product images are stored as bare filenames, /images paths or full /public paths
+    function cartImageSrc(imageURL) {
+        if (!imageURL) return '';
+        if (imageURL.startsWith('/public/')) return imageURL;
+        if (imageURL.startsWith('/images/')) return '/public' + imageURL;
+        return '/public/images/products/' + imageURL;
+    }
+
     function renderCart(cart) {
         const items = cart.items || [];
         const emptyCart = document.getElementById('empty-cart');
@@ -45,6 +119,9 @@ document.addEventListener('DOMContentLoaded', function() {
         const cartSubtotal = document.getElementById('cart-subtotal');
         const checkoutSteps = document.getElementById('checkout-steps');
 
+        // Carts holding only digital products skip the shipping step entirely
+        window.cartDigitalOnly = !!cart.digitalOnly;
+
         if (items.length === 0) {
             emptyCart.classList.remove('hidden');
             if (cartItemsSection) cartItemsSection.classList.add('hidden');
@@ -57,25 +134,26 @@ document.addEventListener('DOMContentLoaded', function() {
         emptyCart.classList.add('hidden');
         if (cartItemsSection) cartItemsSection.classList.remove('hidden');
         cartSummary.classList.remove('hidden');
-        cartShipping.classList.remove('hidden');
-        if (checkoutSteps) checkoutSteps.classList.remove('hidden');
+        cartShipping.classList.toggle('hidden', window.cartDigitalOnly);
+        if (checkoutSteps) checkoutSteps.classList.toggle('hidden', window.cartDigitalOnly);
 
-        // Initialize checkout button in disabled state
+        // Parse the personalization JSON stored on a cart line
+    function personalizationValues(raw) {
+        if (!raw) return [];
+        try {
+            const values = JSON.parse(raw);
+            return Array.isArray(values) ? values : [];
+        } catch (e) {
+            return [];
+        }
+    }
+
+    // Initialize checkout button in disabled state
         initializeCheckoutButton();
 
         // Render cart items using string concatenation
         cartItems.innerHTML = items.map(item => {
-            // Fix image URL - check if it already has the full path
-            let imageSrc = '';
-            if (item.image_url) {
-                if (item.image_url.startsWith('/public/')) {
-                    imageSrc = item.image_url;
-                } else if (item.image_url.startsWith('/images/')) {
-                    imageSrc = '/public' + item.image_url;
-                } else {
-                    imageSrc = '/public/images/products/' + item.image_url;
-                }
-            }
+            const imageSrc = cartImageSrc(item.image_url);
 
             const imageHtml = imageSrc ?
                 '<img src="' + imageSrc + '" alt="' + item.name + '" class="w-24 h-24 rounded-xl object-cover bg-slate-700/50">' :
@@ -87,12 +165,64 @@ document.addEventListener('DOMContentLoaded', function() {
             const variantLabel = item.variant_name || '';
             const variantSku = item.variant_sku || '';
             const variantLine = variantLabel ? `<p class="text-sm text-slate-300">${variantLabel}${variantSku ? ` · ${variantSku}` : ''}</p>` : '';
+            const bundleLine = item.bundle_name ? `<p class="text-xs text-amber-400">Part of the ${item.bundle_name} bundle</p>` : '';
+            const personalizationLines = personalizationValues(item.personalization).map(v =>
+                `<p class="text-sm text-slate-300 whitespace-pre-line"><span class="text-slate-400">${escapeHtml(v.label)}:</span> ${escapeHtml(v.value)}</p>`
+            ).join('');
+
+            // Quantity-break pricing shows the regular price struck through next to the volume price
+            const volume = (cart.volumePricing || {})[item.id];
+            const priceLine = volume ?
+                '<p class="text-lg font-semibold text-emerald-400 mb-4">' + formatMoney(volume.unitPriceCents) +
+                    ' <span class="text-sm text-slate-400 line-through">' + formatMoney(volume.regularPriceCents) + '</span>' +
+                    ' <span class="text-xs text-emerald-300">Volume price (' + volume.minQuantity + '+)</span></p>' :
+                '<p class="text-lg font-semibold text-emerald-400 mb-4">' + formatMoney(item.price_cents) + '</p>';
+
+            // Bundle lines are priced as a set, so only the whole bundle can be removed
+            const quantityControls = item.bundle_group_id ?
+                '<span class="text-white font-semibold">Qty: ' + item.quantity + '</span>' :
+                '<div class="flex items-center space-x-3">' +
+                    '<label class="text-white font-medium">Qty:</label>' +
+                    '<button class="cart-update-btn w-8 h-8 rounded-full bg-slate-600/50 hover:bg-slate-500/50 text-white flex items-center justify-center transition-colors duration-200" data-cart-item-id="' + item.id + '" data-quantity="' + (item.quantity - 1) + '">−</button>' +
+                    '<span class="text-white font-semibold min-w-[2rem] text-center">' + item.quantity + '</span>' +
+                    '<button class="cart-update-btn w-8 h-8 rounded-full bg-slate-600/50 hover:bg-slate-500/50 text-white flex items-center justify-center transition-colors duration-200 disabled:opacity-40 disabled:cursor-not-allowed" data-cart-item-id="' + item.id + '" data-quantity="' + (item.quantity + 1) + '" data-availability-increase data-product-id="' + item.product_id + '" data-sku-id="' + (item.product_sku_id || '') + '">+</button>' +
+                '</div>';
+
+            // Saving for later takes the line out of the total until it's moved back
+            const saveButton = item.bundle_group_id ? '' :
+                '<button class="cart-save-btn ml-auto mr-4 text-sm text-blue-400 hover:text-blue-300 font-semibold transition-colors duration-200" data-cart-item-id="' + item.id + '">Save for later</button>';
 
             // Shipping time based on stock quantity
             const shippingConfig = cart.shippingConfig || { inStockMessage: 'Ships in 1-3 days', outOfStockMessage: 'Ships in 4-5 days' };
             const stockQuantity = item.stock_quantity || 0;
-            const shippingTimeText = stockQuantity > 0 ? shippingConfig.inStockMessage : shippingConfig.outOfStockMessage;
-            const shippingTimeClass = stockQuantity > 0 ? 'text-emerald-400' : 'text-amber-400';
+            let shippingTimeText = stockQuantity > 0 ? shippingConfig.inStockMessage : shippingConfig.outOfStockMessage;
+            let shippingTimeClass = stockQuantity > 0 ? 'text-emerald-400' : 'text-amber-400';
+
+            // Backordered items show the product's promised ship date when one is set
+            const shipBy = promisedShipDate(item.backorder_ship_date);
+            if (item.quantity > stockQuantity && shipBy) {
+                shippingTimeText = (shippingConfig.backorderMessage || 'Backordered - ships by') + ' ' + shipBy;
+            }
+
+            // Pre-orders wait for the expected ship date regardless of stock
+            let preorderBadge = '';
+            if (item.is_preorder) {
+                const expected = promisedShipDate(item.preorder_ship_date);
+                shippingTimeText = expected ?
+                    (shippingConfig.preorderMessage || 'Pre-order - expected to ship by') + ' ' + expected :
+                    (shippingConfig.preorderUndated || 'Pre-order - ships when released');
+                shippingTimeClass = 'text-violet-300';
+                preorderBadge = '<span class="inline-block mb-1 px-2 py-0.5 rounded-full text-xs font-semibold bg-violet-500/20 text-violet-300 border border-violet-400/30">Pre-order</span>';
+            }
+
+            // Digital products are downloaded right after payment
+            if (item.product_type === 'digital') {
+                shippingTimeText = shippingConfig.digitalMessage || 'Instant download after purchase';
+                shippingTimeClass = 'text-sky-300';
+            }
+            // Filled in by the availability poll, so stock others buy shows up here
+            const availabilityLine = item.bundle_group_id ? '' :
+                '<p class="hidden text-xs font-semibold" data-availability-line data-product-id="' + item.product_id + '" data-sku-id="' + (item.product_sku_id || '') + '"></p>';
             const shippingTimeLine = `<p class="text-xs ${shippingTimeClass} flex items-center gap-1"><svg class="w-3 h-3" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M8 7H5a2 2 0 00-2 2v9a2 2 0 002 2h14a2 2 0 002-2V9a2 2 0 00-2-2h-3m-1 4l-3 3m0 0l-3-3m3 3V4"></path></svg>${shippingTimeText}</p>`;
 
             return '<div class="bg-gradient-to-br from-slate-800/50 to-slate-900/50 rounded-2xl border border-slate-700/50 backdrop-blur-sm shadow-xl p-6">' +
@@ -100,16 +230,16 @@ document.addEventListener('DOMContentLoaded', function() {
                     '<div class="flex-shrink-0">' + imageHtml + '</div>' +
                     '<div class="flex-1 min-w-0">' +
                         '<h3 class="text-xl font-bold text-white mb-1">' + item.name + '</h3>' +
+                        preorderBadge +
                         variantLine +
+                        personalizationLines +
+                        bundleLine +
                         shippingTimeLine +
-                        '<p class="text-lg font-semibold text-emerald-400 mb-4">$' + (item.price_cents / 100).toFixed(2) + '</p>' +
+                        availabilityLine +
+                        priceLine +
                         '<div class="flex items-center justify-between">' +
-                            '<div class="flex items-center space-x-3">' +
-                                '<label class="text-white font-medium">Qty:</label>' +
-                                '<button class="cart-update-btn w-8 h-8 rounded-full bg-slate-600/50 hover:bg-slate-500/50 text-white flex items-center justify-center transition-colors duration-200" data-cart-item-id="' + item.id + '" data-quantity="' + (item.quantity - 1) + '">−</button>' +
-                                '<span class="text-white font-semibold min-w-[2rem] text-center">' + item.quantity + '</span>' +
-                                '<button class="cart-update-btn w-8 h-8 rounded-full bg-slate-600/50 hover:bg-slate-500/50 text-white flex items-center justify-center transition-colors duration-200" data-cart-item-id="' + item.id + '" data-quantity="' + (item.quantity + 1) + '">+</button>' +
-                            '</div>' +
+                            quantityControls +
+                            saveButton +
                             '<button class="cart-remove-btn text-red-400 hover:text-red-300 font-semibold transition-colors duration-200" data-cart-item-id="' + item.id + '">' +
                                 '<svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">' +
                                     '<path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M19 7l-.867 12.142A2 2 0 0116.138 21H7.862a2 2 0 01-1.995-1.858L5 7m5 4v6m4-6v6m1-10V4a1 1 0 00-1-1h-4a1 1 0 00-1 1v3M4 7h16"></path>' +
@@ -121,15 +251,117 @@ document.addEventListener('DOMContentLoaded', function() {
             '</div>';
         }).join('');
 
+        renderPromotions(cart);
+        renderProblems(cart);
+        watchAvailability(items);
+
         // Update subtotal and total - API returns totalCents (camelCase), not total_cents (snake_case)
         const subtotal = cart.totalCents || 0;
-        cartSubtotal.textContent = '$' + (subtotal / 100).toFixed(2);
-        cartTotal.textContent = '$' + (subtotal / 100).toFixed(2); // Initial total = subtotal
+        const discount = cart.discountCents || 0;
+        cartSubtotal.textContent = formatMoney(subtotal);
+        cartTotal.textContent = formatMoney(subtotal - discount); // Initial total = subtotal less promotions
 
-        // Initialize shipping options
+        // Initialize shipping options, or go straight to checkout when there's nothing to ship
         if (window.shippingManager) {
-            window.shippingManager.updateShippingUI('address-required');
+            if (window.cartDigitalOnly) {
+                window.shippingManager.enableCheckoutButton();
+            } else {
+                window.shippingManager.updateShippingUI('address-required');
+            }
+        }
+    }
+
+    // Show the automatic promotions the cart has earned or is working toward, and the
+    // applied promotion's discount in the summary
+    function renderPromotions(cart) {
+        const list = document.getElementById('cart-promotions');
+        const discountRow = document.getElementById('promotion-discount-row');
+        const promotions = cart.promotions || [];
+
+        if (list) {
+            list.innerHTML = promotions.map(promo => {
+                const style = promo.applied ?
+                    'bg-emerald-500/20 border-emerald-500/50 text-emerald-300' :
+                    'bg-blue-500/10 border-blue-500/40 text-blue-200';
+                return '<li class="p-3 border rounded-xl text-sm ' + style + '">' + escapeHtml(promo.message) + '</li>';
+            }).join('');
+            list.classList.toggle('hidden', promotions.length === 0);
         }
+
+        if (discountRow) {
+            const applied = promotions.find(promo => promo.applied);
+            const discount = cart.discountCents || 0;
+            discountRow.classList.toggle('hidden', !applied || discount <= 0);
+            if (applied) {
+                document.getElementById('promotion-discount-label').textContent = applied.name + ':';
+                document.getElementById('promotion-discount').textContent = '-' + formatMoney(discount);
+            }
+        }
+    }
+
+    // List what stops checkout: products over their per-order limit and a subtotal
+    // under the store minimum. The checkout button stays disabled while any remain.
+    function renderProblems(cart) {
+        const list = document.getElementById('cart-problems');
+        const problems = cart.problems || [];
+        window.cartProblems = problems;
+
+        if (list) {
+            list.innerHTML = problems.map(problem =>
+                '<li class="p-3 border rounded-xl text-sm bg-red-500/10 border-red-500/40 text-red-200">' + escapeHtml(problem) + '</li>'
+            ).join('');
+            list.classList.toggle('hidden', problems.length === 0);
+        }
+    }
+
+    // Poll stock for the products in the cart. Lines re-render on every cart change,
+    // so each product is watched once and its latest availability applied again.
+    const watchedProducts = new Set();
+    function watchAvailability(items) {
+        if (!window.ProductAvailability) return;
+        items.filter(item => !item.bundle_group_id).forEach(item => {
+            if (watchedProducts.has(item.product_id)) {
+                const latest = window.ProductAvailability.get(item.product_id);
+                if (latest) showAvailability(latest);
+                return;
+            }
+            watchedProducts.add(item.product_id);
+            window.ProductAvailability.watch(item.product_id, showAvailability);
+        });
+    }
+
+    // Show a product's stock under its lines and stop their + buttons once no more
+    // can be added
+    function showAvailability(availability) {
+        const skus = new Map((availability.skus || []).map(sku => [sku.id, sku]));
+        const stockFor = el => el.dataset.skuId ? skus.get(el.dataset.skuId) : availability;
+        const selector = '[data-product-id="' + availability.product_id + '"]';
+
+        document.querySelectorAll('[data-availability-line]' + selector).forEach(line => {
+            const stock = stockFor(line);
+            if (!stock) return;
+            const gone = stock.status === 'sold_out' || stock.status === 'unavailable';
+            line.textContent = stock.message;
+            line.classList.toggle('hidden', !stock.message);
+            line.classList.toggle('text-red-400', gone);
+            line.classList.toggle('text-amber-300', !gone);
+        });
+        document.querySelectorAll('[data-availability-increase]' + selector).forEach(button => {
+            const stock = stockFor(button);
+            if (stock) button.disabled = !stock.can_add_to_cart;
+        });
+    }
+
+    // Format a YYYY-MM-DD backorder or pre-order ship date, ignoring dates that have already passed
+    function promisedShipDate(value) {
+        if (!value) return '';
+        const parts = value.split('-').map(Number);
+        if (parts.length !== 3 || parts.some(isNaN)) return '';
+        const date = new Date(parts[0], parts[1] - 1, parts[2]);
+        const today = new Date();
+        today.setHours(0, 0, 0, 0);
+        if (date < today) return '';
+        return date.toLocaleDateString('en-US', { month: 'long', day: 'numeric', year: 'numeric' });
     }
 
     // Initialize checkout button in disabled state
@@ -11,8 +11,11 @@ async function validateCartSession() {
         if (response.ok) {
             const data = await response.json();
             if (data.should_clear) {
-                // Clear localStorage cart
+                // Clear localStorage cart and the notes and gift options that went with the order
                 localStorage.removeItem('stripe_cart');
+                localStorage.removeItem(ORDER_NOTES_KEY);
+                localStorage.removeItem(GIFT_OPTIONS_KEY);
+                localStorage.removeItem(GIFT_CARD_KEY);
 
                 // Update cart count display
                 const cartCount = document.getElementById('cart-count');
@@ -36,7 +39,9 @@ if (document.readyState === 'loading') {
 }
 
 // Cart functionality
-async function addToCart(productId, quantity = 1, productName = '', productSkuId = '', productPrice = '0', productCategory = '') {
+async function addToCart(productId, quantity = 1, productName = '', productSkuId = '', productPrice = '0', productCategory = '', personalization = {}) {
+    // Count the item straight away; the badge is put back if the add fails
+    const previousCount = bumpCartCount(parseInt(quantity));
     try {
         const response = await fetch('/api/cart/add', {
             method: 'POST',
@@ -46,13 +51,14 @@ async function addToCart(productId, quantity = 1, productName = '', productSkuId
             body: JSON.stringify({
                 productId: productId,
                 productSkuId: productSkuId,
-                quantity: parseInt(quantity)
+                quantity: parseInt(quantity),
+                personalization: personalization
             })
         });
 
         if (!response.ok) {
             const errorData = await response.json();
-            throw new Error(errorData.error || 'Failed to add item to cart');
+            throw new Error(errorData.error || errorData.message || 'Failed to add item to cart');
         }
 
         const data = await response.json();
@@ -82,11 +88,53 @@ async function addToCart(productId, quantity = 1, productName = '', productSkuId
             });
         }
 
-        // Update cart count if element exists
-        await updateCartCount();
+        notifyCartChanged();
+
+        // Lets the product page suggest things that pair well with it
+        window.dispatchEvent(new CustomEvent('cart-item-added', { detail: { productId: productId } }));
 
     } catch (error) {
         console.error('Error adding to cart:', error);
+        renderCartCount(previousCount);
+        showToast(error.message, 'error');
+    }
+}
+
+async function addBundleToCart(bundleId, quantity = 1, bundleName = '', bundlePrice = '0') {
+    try {
+        const response = await fetch('/api/cart/bundle', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({
+                bundleId: bundleId,
+                quantity: parseInt(quantity)
+            })
+        });
+
+        if (!response.ok) {
+            const errorData = await response.json();
+            throw new Error(errorData.error || errorData.message || 'Failed to add bundle to cart');
+        }
+
+        const displayName = bundleName || 'bundle';
+        showToast(`Added ${displayName} to cart!`, 'success');
+
+        // Track AddToCart event with GA4
+        if (typeof Analytics !== 'undefined') {
+            Analytics.addToCart({
+                id: bundleId,
+                name: displayName,
+                category: 'Bundle',
+                price: bundlePrice ? parseFloat(bundlePrice) / 100 : 0
+            }, quantity);
+        }
+
+        notifyCartChanged();
+
+    } catch (error) {
+        console.error('Error adding bundle to cart:', error);
         showToast(error.message, 'error');
     }
 }
@@ -120,7 +168,7 @@ async function removeFromCart(cartItemId) {
             }, 100);
         }
 
-        await updateCartCount();
+        notifyCartChanged();
 
     } catch (error) {
         console.error('Error removing from cart:', error);
@@ -146,7 +194,8 @@ async function updateCartQuantity(cartItemId, quantity) {
         });
 
         if (!response.ok) {
-            throw new Error('Failed to update cart item');
+            const errorData = await response.json().catch(() => ({}));
+            throw new Error(errorData.message || 'Failed to update cart');
         }
 
         showToast('Cart updated', 'success');
@@ -168,11 +217,61 @@ async function updateCartQuantity(cartItemId, quantity) {
             }, 100);
         }
 
-        await updateCartCount();
+        notifyCartChanged();
 
     } catch (error) {
         console.error('Error updating cart:', error);
-        showToast('Failed to update cart', 'error');
+        showToast(error.message, 'error');
+    }
+}
+
+// Moves a cart line to the saved-for-later list, or a saved line back into the cart.
+// Saved lines aren't part of the cart total, so both directions change the cart.
+async function changeSavedItem(url, method, successMessage, failureMessage) {
+    try {
+        const response = await fetch(url, { method: method });
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            throw new Error(data.message || failureMessage);
+        }
+
+        showToast(data.message || successMessage, 'success');
+
+        if (window.location.pathname === '/cart' && window.refreshCart) {
+            window.refreshCart();
+        }
+
+        notifyCartChanged();
+
+    } catch (error) {
+        console.error(failureMessage, error);
+        showToast(error.message, 'error');
+    }
+}
+
+function saveForLater(cartItemId) {
+    return changeSavedItem(`/api/cart/item/${cartItemId}/save`, 'POST', 'Item saved for later', 'Failed to save item for later');
+}
+
+function moveSavedToCart(savedItemId) {
+    return changeSavedItem(`/api/cart/saved/${savedItemId}/move`, 'POST', 'Item moved to cart', 'Failed to move item to cart');
+}
+
+async function removeSavedItem(savedItemId) {
+    try {
+        const response = await fetch(`/api/cart/saved/${savedItemId}`, { method: 'DELETE' });
+        if (!response.ok) {
+            throw new Error('Failed to remove saved item');
+        }
+
+        showToast('Saved item removed', 'success');
+
+        if (window.location.pathname === '/cart' && window.refreshCart) {
+            window.refreshCart();
+        }
+    } catch (error) {
+        console.error('Error removing saved item:', error);
+        showToast('Failed to remove saved item', 'error');
     }
 }
 
@@ -180,31 +279,478 @@ async function updateCartCount() {
     try {
         const response = await fetch('/api/cart');
         if (response.ok) {
-            const cart = await response.json();
-            const totalItems = cart.items ? cart.items.reduce((sum, item) => sum + item.quantity, 0) : 0;
-
-            // Update cart count badge if it exists
-            const cartCountElement = document.querySelector('#cart-count');
-            if (cartCountElement) {
-                cartCountElement.textContent = totalItems;
-                if (totalItems > 0) {
-                    cartCountElement.style.display = 'flex';
-                    cartCountElement.classList.add('show');
-                } else {
-                    cartCountElement.style.display = 'none';
-                    cartCountElement.classList.remove('show');
-                }
-            }
+            setCartCount(await response.json());
         }
     } catch (error) {
         console.error('Error updating cart count:', error);
     }
 }
 
+// Show a cart payload's item count on the cart badge
+function setCartCount(cart) {
+    const totalItems = cart.items ? cart.items.reduce((sum, item) => sum + item.quantity, 0) : 0;
+    renderCartCount(totalItems);
+}
+
+// Add to the badge before the server answers, returning the count it showed before
+function bumpCartCount(quantity) {
+    const cartCountElement = document.querySelector('#cart-count');
+    const previousCount = cartCountElement ? parseInt(cartCountElement.textContent) || 0 : 0;
+    renderCartCount(previousCount + (quantity || 1));
+    return previousCount;
+}
+
+function renderCartCount(totalItems) {
+    const cartCountElement = document.querySelector('#cart-count');
+    if (cartCountElement) {
+        cartCountElement.textContent = totalItems;
+        if (totalItems > 0) {
+            cartCountElement.style.display = 'flex';
+            cartCountElement.classList.add('show');
+        } else {
+            cartCountElement.style.display = 'none';
+            cartCountElement.classList.remove('show');
+        }
+    }
+}
+
+// Pick up the cart built before signing in, which the server folds into the account's
+// cart on the first signed-in request. The response is the merged cart, so the badge
+// is refreshed from it without another request.
+async function mergeGuestCart() {
+    try {
+        const response = await fetch('/api/cart/merge', { method: 'POST' });
+        if (!response.ok) {
+            await updateCartCount();
+            return;
+        }
+        setCartCount(await response.json());
+        showCartMergeNotice(response.headers.get('X-Cart-Merge-Notice'));
+
+        if (response.headers.get('X-Cart-Merged') === 'true') {
+            // The cart page rendered before the merge, so show it the merged lines
+            if (window.refreshCart) {
+                window.refreshCart();
+            }
+            notifyCartChanged();
+        }
+    } catch (error) {
+        console.error('Error merging cart:', error);
+    }
+}
+
+// The server merges the guest cart on the first request after signing in and hands
+// its summary to the next merge call, once
+function showCartMergeNotice(encoded) {
+    if (!encoded) {
+        return;
+    }
+
+    let message = '';
+    try {
+        message = decodeURIComponent(encoded);
+    } catch (error) {
+        console.debug('Ignoring malformed cart merge notice');
+    }
+    if (message) {
+        showToast(message, 'info');
+    }
+}
+
+// Order notes are kept in the browser until checkout so they survive a reload or a
+// trip back to the shop
+const ORDER_NOTES_KEY = 'order_notes';
+
+function initOrderNotes() {
+    const field = document.getElementById('order-notes');
+    if (!field) {
+        return;
+    }
+    field.value = localStorage.getItem(ORDER_NOTES_KEY) || '';
+    field.addEventListener('input', () => {
+        if (field.value.trim()) {
+            localStorage.setItem(ORDER_NOTES_KEY, field.value);
+        } else {
+            localStorage.removeItem(ORDER_NOTES_KEY);
+        }
+    });
+}
+
+// Gift options are kept the same way, as one JSON object
+const GIFT_OPTIONS_KEY = 'gift_options';
+const GIFT_FIELDS = {
+    message: 'gift-message',
+    recipient_name: 'gift-recipient-name',
+    recipient_email: 'gift-recipient-email'
+};
+
+function readGiftOptions() {
+    const toggle = document.getElementById('gift-toggle');
+    if (!toggle) {
+        // Pages after the cart, like the add-ons step, use what was saved there
+        try {
+            const saved = JSON.parse(localStorage.getItem(GIFT_OPTIONS_KEY) || '{}');
+            return {
+                gift: !!saved.gift,
+                message: saved.message || '',
+                recipient_name: saved.recipient_name || '',
+                recipient_email: saved.recipient_email || ''
+            };
+        } catch (error) {
+            return { gift: false, message: '', recipient_name: '', recipient_email: '' };
+        }
+    }
+    const options = { gift: toggle.checked };
+    for (const [key, id] of Object.entries(GIFT_FIELDS)) {
+        const field = document.getElementById(id);
+        options[key] = field ? field.value : '';
+    }
+    return options;
+}
+
+function initGiftOptions() {
+    const toggle = document.getElementById('gift-toggle');
+    const panel = document.getElementById('gift-options');
+    if (!toggle || !panel) {
+        return;
+    }
+
+    let saved = {};
+    try {
+        saved = JSON.parse(localStorage.getItem(GIFT_OPTIONS_KEY) || '{}');
+    } catch (error) {
+        localStorage.removeItem(GIFT_OPTIONS_KEY);
+    }
+    toggle.checked = !!saved.gift;
+    panel.classList.toggle('hidden', !toggle.checked);
+    for (const [key, id] of Object.entries(GIFT_FIELDS)) {
+        const field = document.getElementById(id);
+        if (field) {
+            field.value = saved[key] || '';
+        }
+    }
+
+    const save = () => {
+        const options = readGiftOptions();
+        if (options.gift) {
+            localStorage.setItem(GIFT_OPTIONS_KEY, JSON.stringify(options));
+        } else {
+            localStorage.removeItem(GIFT_OPTIONS_KEY);
+        }
+    };
+    toggle.addEventListener('change', () => {
+        panel.classList.toggle('hidden', !toggle.checked);
+        save();
+    });
+    for (const id of Object.values(GIFT_FIELDS)) {
+        const field = document.getElementById(id);
+        if (field) {
+            field.addEventListener('input', save);
+        }
+    }
+}
+
+// Text updates are kept too, so a returning customer's number is filled in for them
+const SMS_OPTIONS_KEY = 'sms_options';
+
+function readSMSOptions() {
+    const toggle = document.getElementById('sms-opt-in');
+    if (!toggle) {
+        try {
+            const saved = JSON.parse(localStorage.getItem(SMS_OPTIONS_KEY) || '{}');
+            return { opt_in: !!saved.opt_in, phone: saved.phone || '' };
+        } catch (error) {
+            return { opt_in: false, phone: '' };
+        }
+    }
+    const phone = document.getElementById('sms-phone');
+    return { opt_in: toggle.checked, phone: phone ? phone.value : '' };
+}
+
+function initSMSOptions() {
+    const toggle = document.getElementById('sms-opt-in');
+    const panel = document.getElementById('sms-options');
+    const phone = document.getElementById('sms-phone');
+    if (!toggle || !panel || !phone) {
+        return;
+    }
+
+    let saved = {};
+    try {
+        saved = JSON.parse(localStorage.getItem(SMS_OPTIONS_KEY) || '{}');
+    } catch (error) {
+        localStorage.removeItem(SMS_OPTIONS_KEY);
+    }
+    toggle.checked = !!saved.opt_in;
+    phone.value = saved.phone || '';
+    panel.classList.toggle('hidden', !toggle.checked);
+
+    const save = () => {
+        const options = readSMSOptions();
+        if (options.opt_in) {
+            localStorage.setItem(SMS_OPTIONS_KEY, JSON.stringify(options));
+        } else {
+            localStorage.removeItem(SMS_OPTIONS_KEY);
+        }
+    };
+    toggle.addEventListener('change', () => {
+        panel.classList.toggle('hidden', !toggle.checked);
+        if (toggle.checked) {
+            phone.focus();
+        }
+        save();
+    });
+    phone.addEventListener('input', save);
+}
+
+// The carbon offset is kept too, so it's still ticked after a trip back to the shop
+const CARBON_OFFSET_KEY = 'carbon_offset';
+
+function readCarbonOffset() {
+    const box = document.getElementById('carbon-offset');
+    if (!box) {
+        return localStorage.getItem(CARBON_OFFSET_KEY) === 'true';
+    }
+    return box.checked;
+}
+
+function initCarbonOffset() {
+    const box = document.getElementById('carbon-offset');
+    if (!box) {
+        return;
+    }
+
+    box.checked = localStorage.getItem(CARBON_OFFSET_KEY) === 'true';
+    box.addEventListener('change', () => {
+        if (box.checked) {
+            localStorage.setItem(CARBON_OFFSET_KEY, 'true');
+        } else {
+            localStorage.removeItem(CARBON_OFFSET_KEY);
+        }
+    });
+}
+
+// Agreeing to the terms and policies is kept for the browser session, so the add-ons
+// page can go on to Stripe with the versions the cart showed
+const POLICY_ACCEPTANCE_KEY = 'policy_acceptance';
+// Set when checkout found a newer version than the cart showed, so the reloaded cart
+// can say why the box needs ticking again
+const POLICIES_CHANGED_KEY = 'policies_changed';
+
+function readPolicyAcceptance() {
+    const box = document.getElementById('policy-acceptance');
+    if (!box) {
+        return (sessionStorage.getItem(POLICY_ACCEPTANCE_KEY) || '').split(',').filter(Boolean);
+    }
+    return box.checked ? box.dataset.documents.split(',').filter(Boolean) : [];
+}
+
+function initPolicyAcceptance() {
+    const box = document.getElementById('policy-acceptance');
+    if (!box) {
+        return;
+    }
+
+    sessionStorage.removeItem(POLICY_ACCEPTANCE_KEY);
+    box.addEventListener('change', () => {
+        if (box.checked) {
+            sessionStorage.setItem(POLICY_ACCEPTANCE_KEY, box.dataset.documents);
+        } else {
+            sessionStorage.removeItem(POLICY_ACCEPTANCE_KEY);
+        }
+    });
+
+    const changed = sessionStorage.getItem(POLICIES_CHANGED_KEY);
+    if (changed) {
+        sessionStorage.removeItem(POLICIES_CHANGED_KEY);
+        showToast(changed, 'error');
+        box.scrollIntoView({ behavior: 'smooth', block: 'center' });
+    }
+}
+
+// showPoliciesChanged reloads the cart with the versions now in effect for the
+// customer to agree to
+function showPoliciesChanged(message) {
+    sessionStorage.removeItem(POLICY_ACCEPTANCE_KEY);
+    sessionStorage.setItem(POLICIES_CHANGED_KEY, message);
+    window.location.href = '/cart';
+}
+
+// A promo code is checked against the cart when applied and again at checkout, where
+// the server enforces the campaign's exclusions, stacking and per-customer limits
+const PROMO_CODE_KEY = 'promo_code';
+
+function readPromoCode() {
+    const field = document.getElementById('promo-code');
+    if (!field) {
+        return localStorage.getItem(PROMO_CODE_KEY) || '';
+    }
+    return field.value.trim();
+}
+
+function showPromoCodeMessage(text, ok) {
+    const message = document.getElementById('promo-code-message');
+    if (!message) {
+        return;
+    }
+    message.textContent = text;
+    message.classList.toggle('hidden', !text);
+    message.classList.toggle('text-emerald-400', ok);
+    message.classList.toggle('text-red-400', !ok);
+}
+
+async function applyPromoCode() {
+    const field = document.getElementById('promo-code');
+    const code = field ? field.value.trim() : '';
+    if (!code) {
+        localStorage.removeItem(PROMO_CODE_KEY);
+        showPromoCodeMessage('', true);
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/cart/promo-code', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ code: code })
+        });
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            localStorage.removeItem(PROMO_CODE_KEY);
+            showPromoCodeMessage(data.error || 'That code can\'t be used', false);
+            return;
+        }
+
+        localStorage.setItem(PROMO_CODE_KEY, data.code);
+        field.value = data.code;
+        let text = `${data.code} applied`;
+        if (data.discount_cents > 0) {
+            text += `: ${data.estimated ? 'about ' : ''}-$${(data.discount_cents / 100).toFixed(2)} at checkout`;
+        }
+        if (data.replaces_promotion) {
+            text += `, instead of ${data.replaces_promotion}`;
+        }
+        showPromoCodeMessage(text, true);
+    } catch (error) {
+        console.error('Error applying promo code:', error);
+        showPromoCodeMessage('Unable to check the code right now', false);
+    }
+}
+
+function initPromoCode() {
+    const field = document.getElementById('promo-code');
+    const button = document.getElementById('promo-code-apply');
+    if (!field || !button) {
+        return;
+    }
+
+    button.addEventListener('click', applyPromoCode);
+    field.addEventListener('keydown', (event) => {
+        if (event.key === 'Enter') {
+            event.preventDefault();
+            applyPromoCode();
+        }
+    });
+
+    // The cart may have changed since the code was applied, so check it again
+    const saved = localStorage.getItem(PROMO_CODE_KEY);
+    if (saved) {
+        field.value = saved;
+        applyPromoCode();
+    }
+}
+
+// A gift card is only looked up in the cart; the amount used comes off its balance
+// when the checkout session is made
+const GIFT_CARD_KEY = 'gift_card_code';
+
+function readGiftCardCode() {
+    const field = document.getElementById('gift-card-code');
+    if (!field) {
+        return localStorage.getItem(GIFT_CARD_KEY) || '';
+    }
+    return field.value.trim();
+}
+
+function showGiftCardMessage(text, ok) {
+    const message = document.getElementById('gift-card-message');
+    if (!message) {
+        return;
+    }
+    message.textContent = text;
+    message.classList.toggle('hidden', !text);
+    message.classList.toggle('text-emerald-400', ok);
+    message.classList.toggle('text-red-400', !ok);
+}
+
+async function applyGiftCard() {
+    const field = document.getElementById('gift-card-code');
+    const code = field ? field.value.trim() : '';
+    if (!code) {
+        localStorage.removeItem(GIFT_CARD_KEY);
+        showGiftCardMessage('', true);
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/cart/gift-card', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ code: code })
+        });
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            localStorage.removeItem(GIFT_CARD_KEY);
+            showGiftCardMessage(data.error || 'That gift card can\'t be used', false);
+            return;
+        }
+
+        localStorage.setItem(GIFT_CARD_KEY, data.code);
+        field.value = data.code;
+        showGiftCardMessage(`$${(data.balance_cents / 100).toFixed(2)} available, used at checkout`, true);
+    } catch (error) {
+        console.error('Error applying gift card:', error);
+        showGiftCardMessage('Unable to check the gift card right now', false);
+    }
+}
+
+function initGiftCard() {
+    const field = document.getElementById('gift-card-code');
+    const button = document.getElementById('gift-card-apply');
+    if (!field || !button) {
+        return;
+    }
+
+    button.addEventListener('click', applyGiftCard);
+    field.addEventListener('keydown', (event) => {
+        if (event.key === 'Enter') {
+            event.preventDefault();
+            applyGiftCard();
+        }
+    });
+
+    // The balance may have been spent since the card was applied, so check it again
+    const saved = localStorage.getItem(GIFT_CARD_KEY);
+    if (saved) {
+        field.value = saved;
+        applyGiftCard();
+    }
+}
+
 async function proceedToCheckout() {
     try {
-        // Check if shipping is selected
-        if (!window.shippingManager || !window.shippingManager.selectedShippingOption) {
+        // Per-order limits and the minimum order have to be sorted out in the cart first
+        if (window.cartProblems && window.cartProblems.length > 0) {
+            showToast(window.cartProblems[0], 'error');
+            const problems = document.getElementById('cart-problems');
+            if (problems) {
+                problems.scrollIntoView({ behavior: 'smooth', block: 'center' });
+            }
+            return;
+        }
+
+        // Check if shipping is selected (digital-only carts have nothing to ship)
+        if (!window.cartDigitalOnly && (!window.shippingManager || !window.shippingManager.selectedShippingOption)) {
             showToast('Please select a shipping method before checkout', 'error');
             // Scroll to shipping section
             const shippingSection = document.getElementById('cart-shipping');
@@ -214,76 +760,205 @@ async function proceedToCheckout() {
             return;
         }
 
-        const response = await fetch('/checkout/create-session-cart', {
-            method: 'POST',
-            headers: {
-                'Content-Type': 'application/json',
-            }
-        });
-
-        if (!response.ok) {
-            const errorData = await response.json();
-            throw new Error(errorData.error || 'Failed to create checkout session');
+        // The terms and policies have to be agreed to before paying
+        const policyBox = document.getElementById('policy-acceptance');
+        if (policyBox && !policyBox.checked) {
+            showToast('Please agree to the terms and policies to check out', 'error');
+            policyBox.scrollIntoView({ behavior: 'smooth', block: 'center' });
+            policyBox.focus();
+            return;
         }
 
-        const data = await response.json();
-
-        // Track begin_checkout event with GA4
-        if (typeof Analytics !== 'undefined') {
-            // Fetch cart data to get items for tracking
+        // When the add-ons step is on, offer those before Stripe if any suit this cart
+        if (window.checkoutAddOns) {
             try {
-                const cartResponse = await fetch('/api/cart');
-                if (cartResponse.ok) {
-                    const cart = await cartResponse.json();
-                    const cartItems = cart.items || [];
-                    const cartTotal = cartItems.reduce((sum, item) => sum + (item.price_cents * item.quantity), 0) / 100;
-
-                    Analytics.beginCheckout({
-                        total: cartTotal,
-                        items: cartItems.map(item => ({
-                            id: item.product_id,
-                            name: item.name,
-                            category: item.category_name || '',
-                            price: item.price_cents / 100,
-                            quantity: item.quantity
-                        }))
-                    });
-
-                    // Track add_payment_info event (Stripe checkout = Credit Card)
-                    Analytics.addPaymentInfo({
-                        total: cartTotal,
-                        items: cartItems.map(item => ({
-                            id: item.product_id,
-                            name: item.name,
-                            category: item.category_name || '',
-                            price: item.price_cents / 100,
-                            quantity: item.quantity
-                        }))
-                    }, 'Credit Card');
+                const addOnsResponse = await fetch('/api/checkout/add-ons');
+                if (addOnsResponse.ok) {
+                    const addOns = await addOnsResponse.json();
+                    if (addOns.offers && addOns.offers.length > 0) {
+                        window.location.href = '/checkout/add-ons';
+                        return;
+                    }
                 }
-            } catch (cartErr) {
-                console.error('Error fetching cart for GA4 tracking:', cartErr);
+            } catch (addOnsErr) {
+                console.error('Error checking checkout add-ons:', addOnsErr);
             }
         }
 
-        // Track InitiateCheckout event with Meta Pixel
-        if (typeof fbq !== 'undefined') {
-            fbq('track', 'InitiateCheckout', {
-                content_type: 'product',
-                currency: 'USD'
-            });
+        await startStripeCheckout();
+    } catch (error) {
+        console.error('Error creating checkout session:', error);
+        if (error.changes) {
+            showCartChanges(error.changes);
+            return;
         }
+        if (error.policiesChanged) {
+            showPoliciesChanged(error.message);
+            return;
+        }
+        showToast(error.message, 'error');
+    }
+}
 
-        // Redirect to Stripe checkout
-        if (data.url) {
-            window.location.href = data.url;
-        } else {
-            throw new Error('No checkout URL received');
+// Changes checkout found since items were added wait here when they have to be shown
+// on the cart page
+const CART_CHANGES_KEY = 'cart_changes';
+
+// showCartChanges lists what changed in the cart and offers to update it. Away from the
+// cart page it sends the shopper there to see them.
+function showCartChanges(changes) {
+    const panel = document.getElementById('cart-changes');
+    const list = document.getElementById('cart-changes-list');
+    if (!panel || !list) {
+        sessionStorage.setItem(CART_CHANGES_KEY, JSON.stringify(changes));
+        window.location.href = '/cart';
+        return;
+    }
+
+    list.replaceChildren(...changes.map(change => {
+        const item = document.createElement('li');
+        item.textContent = change;
+        return item;
+    }));
+    panel.classList.remove('hidden');
+    panel.scrollIntoView({ behavior: 'smooth', block: 'center' });
+}
+
+function showSavedCartChanges() {
+    const saved = sessionStorage.getItem(CART_CHANGES_KEY);
+    if (!saved) {
+        return;
+    }
+    sessionStorage.removeItem(CART_CHANGES_KEY);
+    try {
+        const changes = JSON.parse(saved);
+        if (Array.isArray(changes) && changes.length > 0) {
+            showCartChanges(changes);
         }
+    } catch (error) {
+        console.error('Error reading cart changes:', error);
+    }
+}
 
+// refreshCartChanges accepts current prices and stock so checkout can go ahead
+async function refreshCartChanges(button) {
+    button.disabled = true;
+    try {
+        const response = await fetch('/api/cart/refresh', { method: 'POST' });
+        const data = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            throw new Error(data.message || 'Failed to update cart');
+        }
+
+        const panel = document.getElementById('cart-changes');
+        if (panel) {
+            panel.classList.add('hidden');
+        }
+        showToast(data.message || 'Cart updated', 'success');
+        updateCartCount();
+        if (window.refreshCart) {
+            window.refreshCart();
+        }
     } catch (error) {
-        console.error('Error creating checkout session:', error);
+        console.error('Error refreshing cart:', error);
         showToast(error.message, 'error');
+    } finally {
+        button.disabled = false;
+    }
+}
+
+// startStripeCheckout creates the Stripe session for the cart and sends the shopper
+// there. Errors are left to the caller to show.
+async function startStripeCheckout() {
+    const notesField = document.getElementById('order-notes');
+    const notes = notesField ? notesField.value : (localStorage.getItem(ORDER_NOTES_KEY) || '');
+    const gift = readGiftOptions();
+    const textUpdates = readSMSOptions();
+    const promoCode = readPromoCode();
+    const giftCardCode = readGiftCardCode();
+    const response = await fetch('/checkout/create-session-cart', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({
+            notes: notes,
+            gift: gift.gift,
+            gift_message: gift.message,
+            gift_recipient_name: gift.recipient_name,
+            gift_recipient_email: gift.recipient_email,
+            sms_opt_in: textUpdates.opt_in,
+            sms_phone: textUpdates.phone,
+            promo_code: promoCode,
+            gift_card_code: giftCardCode,
+            carbon_offset: readCarbonOffset(),
+            accepted_policies: readPolicyAcceptance()
+        })
+    });
+
+    if (!response.ok) {
+        const errorData = await response.json();
+        const error = new Error(errorData.error || 'Failed to create checkout session');
+        // Prices or stock moved since the items were added; the cart has to be updated
+        error.changes = errorData.changes;
+        // A policy was updated after the cart showed it; it has to be agreed to again
+        error.policiesChanged = !!errorData.policies_changed;
+        throw error;
+    }
+
+    const data = await response.json();
+
+    // Track begin_checkout event with GA4
+    if (typeof Analytics !== 'undefined') {
+        // Fetch cart data to get items for tracking
+        try {
+            const cartResponse = await fetch('/api/cart');
+            if (cartResponse.ok) {
+                const cart = await cartResponse.json();
+                const cartItems = cart.items || [];
+                const cartTotal = (cart.totalCents || 0) / 100;
+
+                Analytics.beginCheckout({
+                    total: cartTotal,
+                    items: cartItems.map(item => ({
+                        id: item.product_id,
+                        name: item.name,
+                        category: item.category_name || '',
+                        price: item.price_cents / 100,
+                        quantity: item.quantity
+                    }))
+                });
+
+                // Track add_payment_info event (Stripe checkout = Credit Card)
+                Analytics.addPaymentInfo({
+                    total: cartTotal,
+                    items: cartItems.map(item => ({
+                        id: item.product_id,
+                        name: item.name,
+                        category: item.category_name || '',
+                        price: item.price_cents / 100,
+                        quantity: item.quantity
+                    }))
+                }, 'Credit Card');
+            }
+        } catch (cartErr) {
+            console.error('Error fetching cart for GA4 tracking:', cartErr);
+        }
+    }
+
+    // Track InitiateCheckout event with Meta Pixel
+    if (typeof fbq !== 'undefined') {
+        fbq('track', 'InitiateCheckout', {
+            content_type: 'product',
+            currency: 'USD'
+        });
+    }
+    // Redirect to Stripe checkout, or to our payment page for an embedded checkout
+    // Redirect to Stripe checkout
+    if (data.url) {
+        window.location.href = data.url;
+    } else {
+        throw new Error('No checkout URL received');
     }
 }
 
@@ -325,6 +1000,15 @@ function showToast(message, type = 'info') {
 
 // Initialize event listeners when DOM is loaded
 document.addEventListener('DOMContentLoaded', async function() {
+    initOrderNotes();
+    initGiftOptions();
+    initSMSOptions();
+    initCarbonOffset();
+    initPolicyAcceptance();
+    initPromoCode();
+    initGiftCard();
+    showSavedCartChanges();
+
     // Wait for Clerk authentication to be ready before checking cart
     // This prevents race condition where cart API is called before auth token exists
     if (window.Clerk) {
@@ -335,8 +1019,13 @@ document.addEventListener('DOMContentLoaded', async function() {
         }
     }
 
-    // Update cart count on page load (now with auth ready)
-    updateCartCount();
+    // Update cart count on page load (now with auth ready), picking up anything
+    // added before signing in
+    if (window.Clerk && window.Clerk.user) {
+        mergeGuestCart();
+    } else {
+        updateCartCount();
+    }
 
     // Initialize interactive cart button
     initializeCartHoverEffects();
@@ -359,8 +1048,25 @@ document.addEventListener('DOMContentLoaded', async function() {
                 quantity = quantitySelect.value || '1';
             }
 
+            // Personalization inputs on the product page, keyed by field ID
+            const personalization = {};
+            document.querySelectorAll('[data-personalization-field]').forEach(input => {
+                if (input.dataset.personalizationProduct === productId) {
+                    personalization[input.dataset.personalizationField] = input.value;
+                }
+            });
+
             if (productId) {
-                addToCart(productId, parseInt(quantity), productName, productSkuId, productPrice, productCategory);
+                addToCart(productId, parseInt(quantity), productName, productSkuId, productPrice, productCategory, personalization);
+            }
+        }
+
+        // Add Bundle to Cart buttons
+        if (e.target.classList.contains('add-bundle-to-cart-btn')) {
+            e.preventDefault();
+            const bundleId = e.target.dataset.bundleId;
+            if (bundleId) {
+                addBundleToCart(bundleId, 1, e.target.dataset.bundleName || '', e.target.dataset.bundlePrice || '0');
             }
         }
 
@@ -405,12 +1111,42 @@ document.addEventListener('DOMContentLoaded', async function() {
             }
         }
 
+        // Save for later and saved-list buttons
+        const saveButton = e.target.closest('.cart-save-btn');
+        if (saveButton) {
+            e.preventDefault();
+            if (saveButton.dataset.cartItemId) {
+                saveForLater(saveButton.dataset.cartItemId);
+            }
+        }
+
+        const moveButton = e.target.closest('.saved-move-btn');
+        if (moveButton && !moveButton.disabled) {
+            e.preventDefault();
+            if (moveButton.dataset.savedItemId) {
+                moveSavedToCart(moveButton.dataset.savedItemId);
+            }
+        }
+
+        const savedRemoveButton = e.target.closest('.saved-remove-btn');
+        if (savedRemoveButton) {
+            e.preventDefault();
+            if (savedRemoveButton.dataset.savedItemId) {
+                removeSavedItem(savedRemoveButton.dataset.savedItemId);
+            }
+        }
+
         // Proceed to checkout button
         if (e.target.classList.contains('proceed-checkout-btn')) {
             e.preventDefault();
             proceedToCheckout();
         }
 
+        if (e.target.id === 'cart-changes-refresh') {
+            e.preventDefault();
+            refreshCartChanges(e.target);
+        }
+
         // DISABLED: Cart preview modal button - now links directly to /cart page
         // if (e.target.classList.contains('cart-preview-btn') || e.target.closest('.cart-preview-btn')) {
         //     e.preventDefault();
@@ -673,15 +1409,39 @@ window.addEventListener('clerk:loaded', () => {
 if (window.Clerk) {
     window.Clerk.addListener((event) => {
         if (event.session) {
-            console.debug('Clerk session available, updating cart count');
-            updateCartCount();
+            console.debug('Clerk session available, merging guest cart');
+            mergeGuestCart();
         }
     });
 }
 
+// Tabs on the same site tell each other when the cart changes, so every open page
+// keeps its badge, mini-cart and cart page current
+const cartChannel = typeof BroadcastChannel !== 'undefined' ? new BroadcastChannel('cart') : null;
+
+// Announce a change to this page's cart. The mini-cart reloads on cart-updated too.
+function notifyCartChanged() {
+    window.dispatchEvent(new CustomEvent('cart-updated'));
+}
+
+if (cartChannel) {
+    cartChannel.addEventListener('message', () => {
+        window.dispatchEvent(new CustomEvent('cart-updated', { detail: { remote: true } }));
+    });
+}
+
 // Global event listener for cart updates from any source
 // This allows components (like "Buy Again" buttons) to trigger cart count refresh
-window.addEventListener('cart-updated', () => {
+window.addEventListener('cart-updated', (event) => {
     console.debug('cart-updated event received, refreshing cart count');
     updateCartCount();
+
+    const remote = event.detail && event.detail.remote;
+    if (!remote) {
+        if (cartChannel) {
+            cartChannel.postMessage('cart-updated');
+        }
+    } else if (window.location.pathname === '/cart' && window.refreshCart) {
+        window.refreshCart();
+    }
 });
@@ -0,0 +1,82 @@
+// Checkout add-ons step: one-click extras between the cart and Stripe
+document.addEventListener('DOMContentLoaded', function() {
+    const buttons = document.querySelectorAll('.checkout-add-on-btn');
+    const continueButton = document.getElementById('checkout-add-ons-continue');
+    const shippingNotice = document.getElementById('checkout-add-ons-shipping');
+    if (!continueButton) {
+        return;
+    }
+
+    function offerFromButton(button) {
+        return {
+            id: button.dataset.productId,
+            name: button.dataset.productName,
+            price: parseInt(button.dataset.productPrice, 10) / 100
+        };
+    }
+
+    if (typeof Analytics !== 'undefined' && buttons.length > 0) {
+        Analytics.viewAddOns(Array.from(buttons).map(offerFromButton));
+    }
+
+    // Adding anything changes the parcel, so the saved shipping selection no longer
+    // applies and the shopper goes back to the cart to pick it again
+    let added = false;
+
+    buttons.forEach(button => {
+        button.addEventListener('click', async () => {
+            button.disabled = true;
+            try {
+                const response = await fetch(`/api/checkout/add-ons/${encodeURIComponent(button.dataset.ruleId)}`, {
+                    method: 'POST'
+                });
+                const data = await response.json().catch(() => ({}));
+                if (!response.ok) {
+                    throw new Error(data.message || 'Failed to add item to cart');
+                }
+
+                added = true;
+                button.textContent = 'Added ✓';
+                bumpCartCount(1);
+                notifyCartChanged();
+                showToast(data.message, 'success');
+                if (typeof Analytics !== 'undefined') {
+                    Analytics.addAddOnToCart(offerFromButton(button));
+                }
+
+                continueButton.textContent = 'Continue to shipping';
+                if (shippingNotice) {
+                    shippingNotice.classList.remove('hidden');
+                }
+            } catch (error) {
+                console.error('Error adding checkout add-on:', error);
+                showToast(error.message, 'error');
+                button.disabled = false;
+            }
+        });
+    });
+
+    continueButton.addEventListener('click', async () => {
+        if (added) {
+            window.location.href = '/cart#cart-shipping';
+            return;
+        }
+
+        continueButton.disabled = true;
+        try {
+            await startStripeCheckout();
+        } catch (error) {
+            console.error('Error creating checkout session:', error);
+            if (error.changes) {
+                showCartChanges(error.changes);
+                return;
+            }
+            if (error.policiesChanged) {
+                showPoliciesChanged(error.message);
+                return;
+            }
+            showToast(error.message, 'error');
+            continueButton.disabled = false;
+        }
+    });
+});
@@ -0,0 +1,132 @@
+// Embedded checkout: Stripe's Payment Element on our own payment page, and on the
+// account page for saving a card
+document.addEventListener('DOMContentLoaded', function() {
+    const payForm = document.getElementById('checkout-pay-form');
+    if (payForm) {
+        setUpCheckoutPay(payForm);
+    }
+    const cardForm = document.getElementById('saved-card-form');
+    if (cardForm) {
+        setUpSavedCard(cardForm);
+    }
+});
+
+const checkoutPayAppearance = {
+    theme: 'night',
+    variables: {
+        colorPrimary: '#3b82f6',
+        borderRadius: '12px'
+    }
+};
+
+function showCheckoutPayError(message) {
+    const box = document.getElementById('checkout-pay-error');
+    if (!box) {
+        return;
+    }
+    box.textContent = message;
+    box.classList.toggle('hidden', !message);
+}
+
+function setUpCheckoutPay(form) {
+    const draftId = form.dataset.draftId;
+    const stripe = Stripe(form.dataset.publishableKey);
+    const options = {
+        clientSecret: form.dataset.clientSecret,
+        appearance: checkoutPayAppearance
+    };
+    // Signed-in customers see the cards they've saved and can save this one
+    if (form.dataset.customerSessionSecret) {
+        options.customerSessionClientSecret = form.dataset.customerSessionSecret;
+    }
+    const elements = stripe.elements(options);
+    const paymentElement = elements.create('payment', {
+        layout: 'tabs'
+    });
+    paymentElement.mount('#payment-element');
+
+    const submit = document.getElementById('checkout-pay-submit');
+    const emailInput = document.getElementById('checkout-pay-email');
+
+    form.addEventListener('submit', async (event) => {
+        event.preventDefault();
+        submit.disabled = true;
+        showCheckoutPayError('');
+
+        try {
+            if (emailInput) {
+                const response = await fetch(`/checkout/pay/${encodeURIComponent(draftId)}/email`, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ email: emailInput.value })
+                });
+                if (!response.ok) {
+                    const data = await response.json().catch(() => ({}));
+                    throw new Error(data.message || 'Please check your email address');
+                }
+            }
+
+            // Stripe only returns here on an error; otherwise it sends the customer
+            // to the return URL, redirecting through their bank first if it needs to
+            const { error } = await stripe.confirmPayment({
+                elements,
+                confirmParams: {
+                    return_url: `${window.location.origin}/checkout/pay/${encodeURIComponent(draftId)}/complete`
+                }
+            });
+            throw new Error(error.message || 'Your payment could not be completed');
+        } catch (error) {
+            console.error('Error confirming payment:', error);
+            showCheckoutPayError(error.message);
+            submit.disabled = false;
+        }
+    });
+}
+
+function setUpSavedCard(form) {
+    const stripe = Stripe(form.dataset.publishableKey);
+    const addButton = document.getElementById('saved-card-add');
+    const submit = document.getElementById('saved-card-submit');
+    let elements = null;
+
+    addButton.addEventListener('click', async () => {
+        addButton.disabled = true;
+        try {
+            const response = await fetch('/account/payment-methods/setup', { method: 'POST' });
+            const data = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(data.message || 'Unable to add a card right now');
+            }
+            elements = stripe.elements({
+                clientSecret: data.client_secret,
+                appearance: checkoutPayAppearance
+            });
+            elements.create('payment').mount('#payment-element');
+            addButton.classList.add('hidden');
+            form.classList.remove('hidden');
+        } catch (error) {
+            console.error('Error starting card setup:', error);
+            showCheckoutPayError(error.message);
+            addButton.disabled = false;
+        }
+    });
+
+    form.addEventListener('submit', async (event) => {
+        event.preventDefault();
+        if (!elements) {
+            return;
+        }
+        submit.disabled = true;
+        showCheckoutPayError('');
+
+        const { error } = await stripe.confirmSetup({
+            elements,
+            confirmParams: {
+                return_url: `${window.location.origin}/account/payment-methods?flash=Card+saved`
+            }
+        });
+        console.error('Error saving card:', error);
+        showCheckoutPayError(error.message || 'Your card could not be saved');
+        submit.disabled = false;
+    });
+}
@@ -0,0 +1,108 @@
+// Product comparison - "Compare" checkboxes in the shop grid and the sticky tray
+(function () {
+    const STORAGE_KEY = 'compareProducts';
+    const MAX_PRODUCTS = 4;
+
+    function loadSelection() {
+        try {
+            const items = JSON.parse(localStorage.getItem(STORAGE_KEY) || '[]');
+            return Array.isArray(items) ? items.slice(0, MAX_PRODUCTS) : [];
+        } catch (error) {
+            return [];
+        }
+    }
+
+    function saveSelection(items) {
+        localStorage.setItem(STORAGE_KEY, JSON.stringify(items.slice(0, MAX_PRODUCTS)));
+    }
+
+    function compareURL(items) {
+        if (items.length === 0) {
+            return '/shop/compare';
+        }
+        return '/shop/compare?ids=' + items.map((item) => encodeURIComponent(item.id)).join(',');
+    }
+
+    function render() {
+        const items = loadSelection();
+        const selected = new Set(items.map((item) => item.id));
+
+        document.querySelectorAll('[data-compare-toggle]').forEach((checkbox) => {
+            checkbox.checked = selected.has(checkbox.dataset.productId);
+        });
+
+        const tray = document.getElementById('compare-tray');
+        if (!tray) {
+            return;
+        }
+        tray.classList.toggle('hidden', items.length === 0);
+        tray.querySelector('[data-compare-count]').textContent = items.length;
+        tray.querySelector('[data-compare-names]').textContent = items.map((item) => item.name).join(' · ');
+
+        const link = tray.querySelector('[data-compare-link]');
+        link.href = compareURL(items);
+        const ready = items.length >= 2;
+        link.classList.toggle('opacity-50', !ready);
+        link.classList.toggle('pointer-events-none', !ready);
+        link.setAttribute('aria-disabled', ready ? 'false' : 'true');
+    }
+
+    function toggle(checkbox) {
+        let items = loadSelection();
+        const id = checkbox.dataset.productId;
+
+        if (checkbox.checked) {
+            if (items.length >= MAX_PRODUCTS) {
+                checkbox.checked = false;
+                if (typeof showToast === 'function') {
+                    showToast(`You can compare up to ${MAX_PRODUCTS} products`, 'error');
+                }
+                return;
+            }
+            items.push({ id: id, name: checkbox.dataset.productName || '' });
+        } else {
+            items = items.filter((item) => item.id !== id);
+        }
+
+        saveSelection(items);
+        render();
+    }
+
+    document.addEventListener('change', (event) => {
+        const checkbox = event.target.closest('[data-compare-toggle]');
+        if (checkbox) {
+            toggle(checkbox);
+        }
+    });
+
+    document.addEventListener('click', (event) => {
+        if (event.target.closest('[data-compare-clear]')) {
+            event.preventDefault();
+            saveSelection([]);
+            if (window.location.pathname === '/shop/compare') {
+                window.location.href = '/shop';
+                return;
+            }
+            render();
+            return;
+        }
+
+        const remove = event.target.closest('[data-compare-remove]');
+        if (remove) {
+            saveSelection(loadSelection().filter((item) => item.id !== remove.dataset.compareRemove));
+        }
+    });
+
+    document.addEventListener('DOMContentLoaded', () => {
+        // The comparison page is the source of truth when opened from a shared link
+        const sync = document.querySelector('[data-compare-sync]');
+        if (sync) {
+            try {
+                saveSelection(JSON.parse(sync.dataset.compareSync || '[]'));
+            } catch (error) {
+                console.error('Error syncing comparison:', error);
+            }
+        }
+        render();
+    });
+})();
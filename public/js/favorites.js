@@ -0,0 +1,96 @@
+// Favorites - heart toggles on product cards and product pages
+(function () {
+    function setFavoriteState(button, favorited) {
+        button.setAttribute('aria-pressed', favorited ? 'true' : 'false');
+        button.title = favorited ? 'Remove from favorites' : 'Save to favorites';
+        button.classList.toggle('text-rose-500', favorited);
+        button.classList.toggle('text-white', !favorited);
+        const icon = button.querySelector('svg');
+        if (icon) {
+            icon.setAttribute('fill', favorited ? 'currentColor' : 'none');
+        }
+    }
+
+    function buttonsFor(productId) {
+        return document.querySelectorAll(`[data-favorite-toggle][data-product-id="${productId}"]`);
+    }
+
+    async function loadFavoriteState() {
+        const buttons = document.querySelectorAll('[data-favorite-toggle]');
+        if (buttons.length === 0) {
+            return;
+        }
+
+        try {
+            const response = await fetch('/api/favorites/ids');
+            if (!response.ok) {
+                return;
+            }
+            const data = await response.json();
+            const favorited = new Set(data.product_ids || []);
+            buttons.forEach((button) => {
+                setFavoriteState(button, favorited.has(button.dataset.productId));
+            });
+        } catch (error) {
+            console.error('Error loading favorites:', error);
+        }
+    }
+
+    async function toggleFavorite(button) {
+        const productId = button.dataset.productId;
+        const favorited = button.getAttribute('aria-pressed') === 'true';
+
+        button.disabled = true;
+        try {
+            const response = favorited
+                ? await fetch(`/api/favorites/${encodeURIComponent(productId)}`, { method: 'DELETE' })
+                : await fetch('/api/favorites', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ product_id: productId })
+                });
+
+            if (response.status === 401) {
+                window.location.href = '/login?redirect_url=' + encodeURIComponent(window.location.pathname);
+                return;
+            }
+            if (!response.ok) {
+                throw new Error('Failed to update favorites');
+            }
+
+            buttonsFor(productId).forEach((b) => setFavoriteState(b, !favorited));
+            if (typeof showToast === 'function') {
+                showToast(favorited ? 'Removed from favorites' : 'Saved to favorites', 'success');
+            }
+
+            // On the favorites page, drop the card once it's removed
+            const card = button.closest('[data-favorite-card]');
+            if (favorited && card) {
+                card.remove();
+            }
+        } catch (error) {
+            console.error('Error updating favorite:', error);
+            if (typeof showToast === 'function') {
+                showToast('Could not update favorites. Please try again.', 'error');
+            }
+        } finally {
+            button.disabled = false;
+        }
+    }
+
+    document.addEventListener('click', (event) => {
+        const button = event.target.closest('[data-favorite-toggle]');
+        if (!button) {
+            return;
+        }
+        event.preventDefault();
+        event.stopPropagation();
+        toggleFavorite(button);
+    });
+
+    if (document.readyState === 'loading') {
+        document.addEventListener('DOMContentLoaded', loadFavoriteState);
+    } else {
+        loadFavoriteState();
+    }
+})();
@@ -4,11 +4,16 @@ class ShippingManager {
         this.selectedShippingOption = null;
         this.shippingRates = [];
         this.shippingAddress = {};
+        this.addressSuggestion = null;
         this.isLoadingRates = false;
+        // Rates quoted to a known address without asking pick the cheapest option
+        this.prefetched = false;
     }
 
-    // Get shipping rates from backend
-    async getShippingRates(shippingAddress) {
+    // Get shipping rates from backend. The address is verified first: an undeliverable
+    // one gets an error, and a corrected one a "did you mean" before any rates.
+    // confirmed keeps the address as entered over a suggested correction.
+    async getShippingRates(shippingAddress, confirmed = false) {
         this.isLoadingRates = true;
         this.updateShippingUI('loading');
 
@@ -22,22 +27,36 @@ class ShippingManager {
                     ship_to: {
                         name: shippingAddress.name || '',
                         address_line1: shippingAddress.address_line1 || '',
+                        address_line2: shippingAddress.address_line2 || '',
                         city_locality: shippingAddress.city_locality || '',
                         state_province: shippingAddress.state_province || '',
                         postal_code: shippingAddress.postal_code || '',
                         country_code: shippingAddress.country_code || 'US'
-                    }
+                    },
+                    address_confirmed: confirmed
                 })
             });
 
+            const data = await response.json().catch(() => ({}));
             if (!response.ok) {
-                const errorData = await response.json();
-                throw new Error(errorData.error || 'Failed to get shipping rates');
+                throw new Error(data.error || data.message || 'Failed to get shipping rates');
+            }
+
+            const verification = data.verification;
+            this.addressSuggestion = null;
+            if (verification && verification.status === 'corrected' && !confirmed) {
+                this.shippingRates = [];
+                this.shippingAddress = shippingAddress;
+                this.addressSuggestion = verification.suggestion;
+                this.updateShippingUI('suggestion');
+                return [];
             }
 
-            const data = await response.json();
             this.shippingRates = data.options || [];
-            this.shippingAddress = shippingAddress;
+            // A verified address is kept as the carrier writes it
+            this.shippingAddress = verification && verification.status === 'verified'
+                ? verification.address
+                : shippingAddress;
 
             this.updateShippingUI('rates');
             return this.shippingRates;
@@ -146,12 +165,10 @@ class ShippingManager {
             case 'rates':
                 shippingContainer.innerHTML = this.getShippingRatesHTML();
                 this.attachShippingEventListeners();
-                // Auto-select first rate after rendering
+                // Auto-select a rate after rendering
                 if (this.shippingRates && this.shippingRates.length > 0) {
-                    setTimeout(() => {
-                        console.log('Auto-selecting first shipping option:', this.shippingRates[0].rate_id);
-                        this.selectShippingOption(this.shippingRates[0].rate_id);
-                    }, 100);
+                    const rate = this.preferredRate();
+                    setTimeout(() => this.selectShippingOption(rate.rate_id), 100);
                 }
                 break;
             case 'error':
@@ -159,10 +176,39 @@ class ShippingManager {
                 break;
             case 'address-required':
                 shippingContainer.innerHTML = this.getAddressRequiredHTML();
+                this.prefillAddress(this.shippingAddress);
+                break;
+            case 'suggestion':
+                shippingContainer.innerHTML = this.getSuggestionHTML();
                 break;
         }
     }
 
+    // The first rate as sorted by the shipping settings, or the cheapest when the rates
+    // were quoted to a known address without asking
+    preferredRate() {
+        if (!this.prefetched) {
+            return this.shippingRates[0];
+        }
+        return this.shippingRates.reduce((cheapest, rate) =>
+            rate.total_cost < cheapest.total_cost ? rate : cheapest);
+    }
+
+    // Quote rates to an address the customer has used before: their saved default, or
+    // the one from a selection the cart has since outgrown. It was verified when it
+    // was saved, so a suggested correction isn't asked about again.
+    prefetchRates(address) {
+        this.prefetched = true;
+        this.getShippingRates(address, true).catch(() => {});
+    }
+
+    // One line for the address rates are quoted to
+    addressSummary(address) {
+        if (!address || !address.address_line1) return '';
+        return [address.address_line1, address.city_locality, `${address.state_province || ''} ${address.postal_code || ''}`.trim()]
+            .filter(Boolean).join(', ');
+    }
+
     // Update selected shipping option UI
     updateSelectedShippingUI() {
         // Update cart total with shipping
@@ -210,10 +256,19 @@ class ShippingManager {
                     }
                 }
 
-                const total = subtotal + shippingCost;
+                // Checkout waives the charge once the subtotal reaches the free-shipping threshold
+                const freeShipping = !!(cart.freeShipping && cart.freeShipping.qualifies);
+                if (freeShipping) {
+                    shippingCost = 0;
+                }
+
+                // Cart promotions are taken off the subtotal at checkout
+                const discount = cart.discountCents || 0;
+                const total = subtotal - discount + shippingCost;
 
                 console.log('Cart total calculation:', {
                     subtotal,
+                    discount,
                     shippingCost,
                     total,
                     selectedOption: this.selectedShippingOption
@@ -221,13 +276,17 @@ class ShippingManager {
 
                 // Update display elements
                 if (subtotalElement) {
-                    subtotalElement.textContent = '$' + (subtotal / 100).toFixed(2);
+                    subtotalElement.textContent = formatMoney(subtotal);
                 }
                 if (shippingCostElement) {
-                    shippingCostElement.textContent = this.selectedShippingOption ?
-                        '$' + (shippingCost / 100).toFixed(2) : 'TBD';
+                    if (freeShipping) {
+                        shippingCostElement.textContent = 'FREE';
+                    } else {
+                        shippingCostElement.textContent = this.selectedShippingOption ?
+                            formatMoney(shippingCost) : 'TBD';
+                    }
                 }
-                cartTotalElement.textContent = '$' + (total / 100).toFixed(2);
+                cartTotalElement.textContent = formatMoney(total);
 
                 // Update checkout button text with new total
                 this.updateCheckoutButtonText();
@@ -258,12 +317,15 @@ class ShippingManager {
         return `
             <div class="shipping-rates">
                 <div class="flex items-center justify-between mb-4">
-                    <h3 class="text-lg font-semibold text-white">Select Shipping Method</h3>
+                    <div>
+                        <h3 class="text-lg font-semibold text-white">Select Shipping Method</h3>
+                        ${this.addressSummary(this.shippingAddress) ? `<p class="text-sm text-slate-400">Shipping to ${escapeShippingHTML(this.addressSummary(this.shippingAddress))}</p>` : ''}
+                    </div>
                     <button
                         onclick="window.shippingManager.showAddressForm()"
                         class="text-sm text-blue-400 hover:text-blue-300 transition-colors"
                     >
-                        Change ZIP Code
+                        Edit Address
                     </button>
                 </div>
                 <div class="space-y-3 max-h-96 overflow-y-auto pr-2 shipping-rates-scroll">
@@ -283,7 +345,7 @@ class ShippingManager {
                                     </div>
                                 </div>
                                 <div class="text-lg font-semibold text-emerald-400">
-                                    $${rate.total_cost.toFixed(2)}
+                                    ${formatMoney(Math.round(rate.total_cost * 100))}
                                 </div>
                             </label>
                         </div>
@@ -301,7 +363,7 @@ class ShippingManager {
                         <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 9v2m0 4h.01m-6.938 4h13.856c1.54 0 2.502-1.667 1.732-2.5L13.732 4c-.77-.833-1.964-.833-2.732 0L4.082 16.5c-.77.833.192 2.5 1.732 2.5z"></path>
                     </svg>
                 </div>
-                <p class="text-slate-300">${message}</p>
+                <p class="text-slate-300">${escapeShippingHTML(message)}</p>
                 <button onclick="window.shippingManager.showAddressForm()" class="mt-4 bg-blue-600 text-white px-4 py-2 rounded-lg hover:bg-blue-700 transition-colors">
                     Enter Different Address
                 </button>
@@ -310,64 +372,97 @@ class ShippingManager {
     }
 
     getAddressRequiredHTML() {
+        const inputClass = 'w-full bg-slate-700/50 border border-slate-600 rounded-lg px-4 py-3 text-white placeholder-slate-400 focus:border-blue-500 focus:outline-none focus:text-white';
         return `
-            <div class="shipping-address-required text-center py-8">
-                <div class="text-slate-300 mb-4">
+            <div class="shipping-address-required py-8">
+                <div class="text-slate-300 mb-4 text-center">
                     <svg class="w-12 h-12 mx-auto mb-4" fill="none" stroke="currentColor" viewBox="0 0 24 24">
                         <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M17.657 16.657L13.414 20.9a1.998 1.998 0 01-2.827 0l-4.244-4.243a8 8 0 1111.314 0z"></path>
                         <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M15 11a3 3 0 11-6 0 3 3 0 016 0z"></path>
                     </svg>
+                    <p>Enter your shipping address to see shipping options</p>
                 </div>
-                <p class="text-slate-300 mb-6">Enter your ZIP code to see shipping options</p>
-                <div class="max-w-sm mx-auto">
-                    <div class="flex gap-3">
-                        <input
-                            type="text"
-                            id="shipping-zip-input"
-                            placeholder="ZIP Code"
-                            class="flex-1 bg-slate-700/50 border border-slate-600 rounded-lg px-4 py-3 text-white placeholder-slate-400 focus:border-blue-500 focus:outline-none focus:text-white"
-                            maxlength="10"
-                            style="color: white !important;"
-                        >
-                        <button
-                            onclick="window.shippingManager.getQuickRates()"
-                            class="bg-blue-600 text-white px-6 py-3 rounded-lg hover:bg-blue-700 transition-colors whitespace-nowrap"
-                        >
-                            Get Rates
-                        </button>
+                <div class="max-w-md mx-auto space-y-3">
+                    <input type="text" id="shipping-street-input" placeholder="Street address" autocomplete="shipping address-line1" class="${inputClass} shipping-address-input" style="color: white !important;">
+                    <input type="text" id="shipping-street2-input" placeholder="Apt, suite, unit (optional)" autocomplete="shipping address-line2" class="${inputClass} shipping-address-input" style="color: white !important;">
+                    <div class="grid grid-cols-6 gap-3">
+                        <input type="text" id="shipping-city-input" placeholder="City" autocomplete="shipping address-level2" class="col-span-3 ${inputClass} shipping-address-input" style="color: white !important;">
+                        <input type="text" id="shipping-state-input" placeholder="State" autocomplete="shipping address-level1" maxlength="2" class="col-span-1 ${inputClass} shipping-address-input uppercase" style="color: white !important;">
+                        <input type="text" id="shipping-zip-input" placeholder="ZIP" autocomplete="shipping postal-code" maxlength="10" class="col-span-2 ${inputClass} shipping-address-input" style="color: white !important;">
                     </div>
+                    <button
+                        onclick="window.shippingManager.getQuickRates()"
+                        class="w-full bg-blue-600 text-white px-6 py-3 rounded-lg hover:bg-blue-700 transition-colors"
+                    >
+                        Get Rates
+                    </button>
                 </div>
             </div>
         `;
     }
 
-    // Get quick rates with ZIP code
-    async getQuickRates() {
-        const zipInput = document.getElementById('shipping-zip-input');
-        if (!zipInput) return;
+    // "Did you mean" for an address the carrier would write differently
+    getSuggestionHTML() {
+        const formatAddress = (address) => [
+            address.address_line1,
+            address.address_line2,
+            `${address.city_locality || ''}, ${address.state_province || ''} ${address.postal_code || ''}`
+        ].filter(Boolean).map(escapeShippingHTML).join('<br>');
 
-        const zipCode = zipInput.value.trim();
-        if (!zipCode) {
-            showToast('Please enter a ZIP code', 'error');
-            return;
+        return `
+            <div class="shipping-suggestion py-4">
+                <h3 class="text-lg font-semibold text-white mb-4">Did you mean this address?</h3>
+                <div class="grid gap-3 sm:grid-cols-2">
+                    <button onclick="window.shippingManager.useSuggestedAddress()" class="text-left bg-blue-500/20 border border-blue-500/50 rounded-xl p-4 hover:border-blue-400 transition-colors">
+                        <p class="text-xs uppercase tracking-wide text-blue-300 mb-2">Suggested</p>
+                        <p class="text-white">${formatAddress(this.addressSuggestion || {})}</p>
+                    </button>
+                    <button onclick="window.shippingManager.keepEnteredAddress()" class="text-left bg-slate-700/30 border border-slate-600/50 rounded-xl p-4 hover:border-slate-400 transition-colors">
+                        <p class="text-xs uppercase tracking-wide text-slate-400 mb-2">As entered</p>
+                        <p class="text-slate-200">${formatAddress(this.shippingAddress)}</p>
+                    </button>
+                </div>
+                <button onclick="window.shippingManager.showAddressForm()" class="mt-4 text-sm text-blue-400 hover:text-blue-300 transition-colors">
+                    Edit Address
+                </button>
+            </div>
+        `;
+    }
+
+    useSuggestedAddress() {
+        if (this.addressSuggestion) {
+            this.getShippingRates(this.addressSuggestion).catch(() => {});
         }
+    }
 
-        this.updateShippingUI('loading');
+    keepEnteredAddress() {
+        this.getShippingRates(this.shippingAddress, true).catch(() => {});
+    }
+
+    // Get rates for the address in the form
+    async getQuickRates() {
+        const value = (id) => {
+            const input = document.getElementById(id);
+            return input ? input.value.trim() : '';
+        };
+        const address = {
+            address_line1: value('shipping-street-input'),
+            address_line2: value('shipping-street2-input'),
+            city_locality: value('shipping-city-input'),
+            state_province: value('shipping-state-input').toUpperCase(),
+            postal_code: value('shipping-zip-input'),
+            country_code: 'US'
+        };
+        if (!address.address_line1 || !address.city_locality || !address.state_province || !address.postal_code) {
+            showToast('Please enter your street address, city, state and ZIP code', 'error');
+            return;
+        }
 
+        this.prefetched = false;
         try {
-            const rates = await this.getEstimatedRates(zipCode);
-            if (rates.length > 0) {
-                this.shippingRates = rates;
-                this.shippingAddress = {
-                    postal_code: zipCode,
-                    country_code: 'US'
-                };
-                this.updateShippingUI('rates');
-            } else {
-                this.updateShippingUI('error', 'No shipping options available for this ZIP code');
-            }
+            await this.getShippingRates(address);
         } catch (error) {
-            this.updateShippingUI('error', 'Failed to get shipping rates');
+            // getShippingRates has already shown the error
         }
     }
 
@@ -376,7 +471,8 @@ class ShippingManager {
         // Reset shipping state and show the ZIP code input form again
         this.shippingRates = [];
         this.selectedShippingOption = null;
-        this.shippingAddress = {};
+        this.addressSuggestion = null;
+        this.prefetched = false;
         this.updateShippingUI('address-required');
     }
 
@@ -402,36 +498,6 @@ class ShippingManager {
         });
     }
 
-    // Get estimated rates without full address (for quick preview)
-    async getEstimatedRates(zipCode) {
-        try {
-            const response = await fetch('/api/shipping/rates', {
-                method: 'POST',
-                headers: {
-                    'Content-Type': 'application/json',
-                },
-                body: JSON.stringify({
-                    ship_to: {
-                        name: 'Customer',
-                        address_line1: '123 Main St',
-                        city_locality: 'Anytown',
-                        state_province: 'CA',
-                        postal_code: zipCode,
-                        country_code: 'US'
-                    }
-                })
-            });
-
-            if (response.ok) {
-                const data = await response.json();
-                return data.options || [];
-            }
-        } catch (error) {
-            console.error('Error getting estimated rates:', error);
-        }
-        return [];
-    }
-
     // Load saved shipping selection from database
     async loadSavedShipping() {
         try {
@@ -444,13 +510,23 @@ class ShippingManager {
 
             const data = await response.json();
 
+            // An address used before is quoted again straight away rather than asked for
+            const knownAddress = data.shipping_address && data.shipping_address.address_line1
+                ? data.shipping_address
+                : data.default_address;
+
             if (!data.selection) {
+                if (knownAddress) {
+                    this.disableCheckoutButton();
+                    this.prefetchRates(knownAddress);
+                    return null;
+                }
                 // Pre-fill address if available
                 if (data.shipping_address && data.shipping_address.postal_code) {
                     this.shippingAddress = data.shipping_address;
                     // Need to wait for UI to render before pre-filling
                     setTimeout(() => {
-                        this.prefillZipCode(data.shipping_address.postal_code);
+                        this.prefillAddress(data.shipping_address);
                     }, 100);
                 }
                 this.disableCheckoutButton();
@@ -458,13 +534,19 @@ class ShippingManager {
             }
 
             if (!data.selection.is_valid) {
-                // Cart changed - show message and pre-fill ZIP
+                if (knownAddress) {
+                    // Cart changed - quote the same address for what's in it now
+                    this.disableCheckoutButton();
+                    this.prefetchRates(knownAddress);
+                    return null;
+                }
+                // Cart changed - show message and pre-fill the address
                 this.showCartChangedMessage();
                 if (data.shipping_address && data.shipping_address.postal_code) {
                     this.shippingAddress = data.shipping_address;
                     // Need to wait for UI to render before pre-filling
                     setTimeout(() => {
-                        this.prefillZipCode(data.shipping_address.postal_code);
+                        this.prefillAddress(data.shipping_address);
                     }, 100);
                 }
                 this.disableCheckoutButton();
@@ -472,6 +554,9 @@ class ShippingManager {
             }
 
             // Valid shipping selection exists
+            if (data.shipping_address) {
+                this.shippingAddress = data.shipping_address;
+            }
             this.selectedShippingOption = data.selection;
             this.updateCartTotalWithShipping();
             this.enableCheckoutButton();
@@ -485,11 +570,21 @@ class ShippingManager {
         }
     }
 
-    prefillZipCode(zipCode) {
-        const zipInput = document.getElementById('shipping-zip-input');
-        if (zipInput) {
-            zipInput.value = zipCode;
-        }
+    prefillAddress(address) {
+        if (!address) return;
+        const fields = {
+            'shipping-street-input': address.address_line1,
+            'shipping-street2-input': address.address_line2,
+            'shipping-city-input': address.city_locality,
+            'shipping-state-input': address.state_province,
+            'shipping-zip-input': address.postal_code
+        };
+        Object.entries(fields).forEach(([id, value]) => {
+            const input = document.getElementById(id);
+            if (input && value) {
+                input.value = value;
+            }
+        });
     }
 
     showCartChangedMessage() {
@@ -527,15 +622,16 @@ class ShippingManager {
                                 <p class="text-white font-medium mt-2">${this.selectedShippingOption.carrier_name} ${this.selectedShippingOption.service_name}</p>
                                 <p class="text-green-200 text-sm mt-1">
                                     ${this.selectedShippingOption.delivery_days} business days •
-                                    $${(this.selectedShippingOption.price_cents / 100).toFixed(2)}
+                                    ${formatMoney(this.selectedShippingOption.price_cents)}
                                 </p>
+                                ${this.addressSummary(this.shippingAddress) ? `<p class="text-slate-300 text-sm mt-1">To ${escapeShippingHTML(this.addressSummary(this.shippingAddress))}</p>` : ''}
                             </div>
                         </div>
                         <button
                             onclick="window.shippingManager.changeShipping()"
                             class="text-blue-400 hover:text-blue-300 text-sm font-medium"
                         >
-                            Change
+                            Edit Address
                         </button>
                     </div>
                 </div>
@@ -557,6 +653,17 @@ class ShippingManager {
         const checkoutBtn = document.getElementById('proceed-checkout-btn');
         const checkoutBtnText = document.getElementById('checkout-btn-text');
 
+        // Per-order limits and the minimum order listed in the summary keep it shut
+        if (window.cartProblems && window.cartProblems.length > 0) {
+            if (window.initializeCheckoutButton) {
+                window.initializeCheckoutButton();
+            }
+            if (checkoutBtnText) {
+                checkoutBtnText.textContent = 'Update Your Cart to Continue';
+            }
+            return;
+        }
+
         if (checkoutBtn) {
             console.log('Checkout button found, enabling...');
             checkoutBtn.disabled = false;
@@ -662,6 +769,12 @@ class ShippingManager {
     }
 }
 
+function escapeShippingHTML(value) {
+    const div = document.createElement('div');
+    div.textContent = value == null ? '' : String(value);
+    return div.innerHTML;
+}
+
 // Initialize shipping manager
 window.shippingManager = new ShippingManager();
 
@@ -672,9 +785,9 @@ document.addEventListener('DOMContentLoaded', function() {
         window.shippingManager.updateShippingUI('address-required');
     }
 
-    // Add global event listener for Enter key on ZIP input (using keydown for better compatibility)
+    // Add global event listener for Enter key in the address form (using keydown for better compatibility)
     document.addEventListener('keydown', function(e) {
-        if (e.target.id === 'shipping-zip-input' && e.key === 'Enter') {
+        if (e.target.classList && e.target.classList.contains('shipping-address-input') && e.key === 'Enter') {
             e.preventDefault();
             e.stopPropagation();
             window.shippingManager.getQuickRates();
@@ -7,8 +7,8 @@ import (
 	"log"
 	"os"
 
+	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
@@ -17,13 +17,13 @@ func main() {
 		dbPath = "./data/database.db"
 	}
 
-	database, err := sql.Open("sqlite3", dbPath)
+	store, err := storage.New(dbPath)
 	if err != nil {
 		log.Fatal("Failed to open database:", err)
 	}
-	defer database.Close()
+	defer store.Close()
 
-	queries := db.New(database)
+	queries := store.Queries
 	ctx := context.Background()
 
 	// Get all products
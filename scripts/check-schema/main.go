@@ -5,15 +5,16 @@ import (
 	"fmt"
 	"log"
 
-	_ "modernc.org/sqlite"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
-	db, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer store.Close()
+	db := store.DB()
 
 	fmt.Println("=== Products Table Schema ===")
 	rows, err := db.Query("PRAGMA table_info(products)")
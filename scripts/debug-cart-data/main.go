@@ -5,16 +5,17 @@ import (
 	"fmt"
 	"log"
 
-	_ "modernc.org/sqlite"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
 	// Open database
-	db, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer store.Close()
+	db := store.DB()
 
 	// Query cart_items with Bone Dragons specifically
 	rows, err := db.Query(`
@@ -1,21 +1,21 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"path/filepath"
 
-	_ "modernc.org/sqlite"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
 	// Open database with correct path
-	db, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer store.Close()
+	db := store.DB()
 
 	// Check current image URLs
 	rows, err := db.Query("SELECT id, image_url FROM product_images LIMIT 10")
@@ -1,20 +1,20 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 
-	_ "modernc.org/sqlite"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
 	// Open database
-	db, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	defer store.Close()
+	db := store.DB()
 
 	// Query product IDs
 	rows, err := db.Query(`SELECT id, name FROM products LIMIT 5`)
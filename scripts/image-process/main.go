@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+)
+
+// Backfills the resized WebP/AVIF/JPEG copies for product and style images uploaded
+// before the image pipeline existed. Images that are already up to date are skipped,
+// so it's safe to run again.
+func main() {
+	workers := flag.Int("workers", 4, "Number of images to process at once")
+	force := flag.Bool("force", false, "Regenerate copies even if they're up to date")
+	flag.Parse()
+
+	pipeline := images.Default()
+	slog.Info("image encoders", "formats", pipeline.Formats())
+
+	var filenames []string
+	for _, dir := range pipeline.SourceDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Fatalf("Failed to read %s: %v", dir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+				filenames = append(filenames, entry.Name())
+			}
+		}
+	}
+
+	slog.Info("starting image backfill", "images", len(filenames), "workers", *workers, "force", *force)
+
+	ctx := context.Background()
+	jobs := make(chan string)
+	var processed, failed atomic.Int64
+	var wg sync.WaitGroup
+	for range max(1, *workers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filename := range jobs {
+				if *force {
+					pipeline.Remove(filename)
+				}
+				if err := pipeline.Generate(ctx, filename); err != nil {
+					slog.Error("failed to process image", "error", err, "filename", filename)
+					failed.Add(1)
+					continue
+				}
+				if n := processed.Add(1); n%50 == 0 {
+					slog.Info("progress", "processed", n, "total", len(filenames))
+				}
+			}
+		}()
+	}
+	for _, filename := range filenames {
+		jobs <- filename
+	}
+	close(jobs)
+	wg.Wait()
+
+	slog.Info("image backfill complete", "processed", processed.Load(), "failed", failed.Load())
+	if failed.Load() > 0 {
+		os.Exit(1)
+	}
+}
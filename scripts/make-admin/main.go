@@ -1,12 +1,11 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"os"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
@@ -15,11 +14,12 @@ func main() {
 		dbPath = "./data/database.db"
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	store, err := storage.New(dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
-	defer db.Close()
+	defer store.Close()
+	db := store.DB()
 
 	email := "logan@lanou.com"
 
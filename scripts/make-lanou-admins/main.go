@@ -7,7 +7,7 @@ import (
 	"log"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
@@ -15,18 +15,13 @@ func main() {
 	dbPath := flag.String("db", "./data/database.db", "Path to the database file")
 	flag.Parse()
 
-	// Open database connection with same settings as main app
-	db, err := sql.Open("sqlite", *dbPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(10000)")
+	// Open database connection with the main app's settings
+	store, err := storage.New(*dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
-	defer db.Close()
-
-	// Ensure connection is actually established
-	log.Println("Testing database connection...")
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
+	defer store.Close()
+	db := store.DB()
 	log.Println("Database connection successful")
 
 	// Update all @lanou.com users to be admins with retry logic
@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
+	cryptorand "crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/google/uuid"
-	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/loganlanou/logans3d-v4/internal/anonymize"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 const (
@@ -68,17 +75,27 @@ func main() {
 	rand.Seed(time.Now().UnixNano())
 	gofakeit.Seed(time.Now().UnixNano())
 
+	anonymizeData := flag.Bool("anonymize", false, "Scrub customers' personal information instead of seeding fake data")
+	from := flag.String("from", "", "With -anonymize, copy this database (e.g. a production snapshot) to DB_PATH and anonymize the copy")
+	salt := flag.String("salt", os.Getenv("ANONYMIZE_SALT"), "With -anonymize, key for choosing fake values; the same salt gives the same fakes")
+	flag.Parse()
+
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
 		dbPath = "./data/database.db"
 	}
 
-	var err error
-	db, err = sql.Open("sqlite3", dbPath)
+	if *anonymizeData {
+		runAnonymize(dbPath, *from, *salt)
+		return
+	}
+
+	store, err := storage.New(dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
-	defer db.Close()
+	defer store.Close()
+	db = store.DB()
 
 	fmt.Println("🌱 Starting database seeding...")
 	fmt.Println()
@@ -111,6 +128,61 @@ func main() {
 	printSummary()
 }
 
+// runAnonymize rewrites the personal information in the database at dbPath, first
+// copying it there from another database when from is set
+func runAnonymize(dbPath, from, salt string) {
+	ctx := context.Background()
+
+	if from != "" {
+		fmt.Printf("📋 Copying %s to %s...\n", from, dbPath)
+		if err := anonymize.Copy(ctx, from, dbPath); err != nil {
+			log.Fatalf("❌ Failed to copy database: %v", err)
+		}
+	} else {
+		fmt.Printf("⚠️  No -from given, so %s is anonymized in place\n", dbPath)
+	}
+
+	if salt == "" {
+		b := make([]byte, 16)
+		if _, err := cryptorand.Read(b); err != nil {
+			log.Fatalf("Failed to generate salt: %v", err)
+		}
+		salt = hex.EncodeToString(b)
+		fmt.Println("   Using a random salt; pass -salt for the same fakes every time")
+	}
+
+	// Opening through storage migrates the copy, so its columns match the list anonymize rewrites
+	store, err := storage.New(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	fmt.Println("🕶️  Anonymizing...")
+	report, err := anonymize.Run(ctx, store.DB(), anonymize.Options{Salt: salt})
+	if err != nil {
+		log.Fatalf("❌ Failed to anonymize: %v", err)
+	}
+
+	fmt.Println()
+	fmt.Println("✅ Database anonymized!")
+	fmt.Println()
+	fmt.Println("📊 Rows rewritten:")
+	fmt.Println()
+	tables := make([]string, 0, len(report.Rows))
+	for table := range report.Rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		if report.Rows[table] > 0 {
+			fmt.Printf("  %-28s %d\n", table+":", report.Rows[table])
+		}
+	}
+	fmt.Println()
+	fmt.Printf("  Orders unchanged:            %d totalling $%.2f\n", report.Orders, float64(report.OrderTotalCents)/100)
+}
+
 func loadProductIDs() {
 	rows, err := db.Query("SELECT id FROM products WHERE is_active = 1 LIMIT 50")
 	if err != nil {
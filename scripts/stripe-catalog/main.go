@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage"
+	stripego "github.com/stripe/stripe-go/v80"
+)
+
+// Reports drift between the local product catalog and the Stripe catalog.
+// With -fix, every drifted local product is pushed to Stripe again.
+func main() {
+	dbPath := flag.String("db", "./data/database.db", "Path to SQLite database")
+	stripeKey := flag.String("stripe-key", os.Getenv("STRIPE_SECRET_KEY"), "Stripe secret key")
+	baseURL := flag.String("base-url", "https://www.logans3dcreations.com", "Site URL used for product images")
+	fix := flag.Bool("fix", false, "Re-sync drifted products to Stripe")
+	flag.Parse()
+
+	if *stripeKey == "" {
+		log.Fatal("Stripe secret key is required (--stripe-key or STRIPE_SECRET_KEY env var)")
+	}
+
+	// Initialize Stripe
+	stripego.Key = *stripeKey
+
+	// Open database
+	storage, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+	catalog := stripe.NewCatalogSync(storage.Queries, *baseURL)
+
+	drift, err := catalog.Reconcile(ctx)
+	if err != nil {
+		log.Fatalf("Failed to reconcile catalog: %v", err)
+	}
+
+	if len(drift) == 0 {
+		fmt.Println("Local catalog and Stripe match.")
+		return
+	}
+
+	toFix := make(map[string]bool)
+	for _, d := range drift {
+		name := d.ProductName
+		if d.Sku != "" {
+			name += " / " + d.Sku
+		}
+		fmt.Printf("%-18s %-40s %-30s local=%q stripe=%q\n", d.Kind, name, d.StripeID, d.Local, d.Stripe)
+		if d.ProductID != "" {
+			toFix[d.ProductID] = true
+		}
+	}
+	fmt.Printf("\n%d difference(s) across %d local product(s)\n", len(drift), len(toFix))
+
+	if !*fix {
+		return
+	}
+
+	fixed := 0
+	failed := 0
+	for productID := range toFix {
+		if err := catalog.SyncProduct(ctx, productID); err != nil {
+			slog.Error("failed to sync product", "error", err, "product_id", productID)
+			failed++
+			continue
+		}
+		fixed++
+	}
+	slog.Info("re-synced drifted products", "fixed", fixed, "failed", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -7,20 +7,18 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/loganlanou/logans3d-v4/storage/db"
-	_ "modernc.org/sqlite"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
 	// Open database connection
-	database, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer database.Close()
-
-	// Create queries instance
-	queries := db.New(database)
+	defer store.Close()
+	database := store.DB()
+	queries := store.Queries
 
 	// Get a cart session that has Bone Dragons
 	ctx := context.Background()
@@ -1,14 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/pressly/goose/v3"
+
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
@@ -39,7 +39,7 @@ func main() {
 
 	// Test: Migrate UP from scratch
 	log.Println("\n📈 Step 1: Testing UP migrations from scratch...")
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
+	db, err := storage.Open(dbPath)
 	if err != nil {
 		log.Fatalf("❌ Failed to open database: %v", err)
 	}
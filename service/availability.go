@@ -0,0 +1,77 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/api"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// handleProductAvailability returns a product's current stock, per variant, and how
+// many more the shopper can add. The product and cart pages poll it so "Only 2 left"
+// and the add-to-cart button don't go stale when someone else buys.
+func (s *Service) handleProductAvailability(c echo.Context) error {
+	ctx := c.Request().Context()
+	product, err := s.storage.Queries.GetProduct(ctx, c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && product.DeletedAt.Valid) {
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+	if err != nil {
+		slog.Error("failed to load product for availability", "error", err, "product_id", c.Param("id"))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load availability")
+	}
+
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+	inCart, err := s.storage.Queries.GetCartProductQuantity(ctx, db.GetCartProductQuantityParams{
+		SessionID: owner.sessionParam(),
+		UserID:    owner.userParam(),
+		ProductID: product.ID,
+	})
+	if err != nil {
+		slog.Error("failed to get cart quantity for availability", "error", err, "product_id", product.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load availability")
+	}
+
+	shopper := api.AvailabilityShopper{
+		InCart:            inCart,
+		SkuInCart:         map[string]int64{},
+		PurchaseLimitLeft: -1,
+		DropUpcoming:      utils.DropUpcoming(product.DropAt, utils.WallClockNow()),
+	}
+	if product.PurchaseLimit > 0 {
+		shopper.PurchaseLimitLeft = utils.PurchaseLimitLeft(product.PurchaseLimit, inCart, s.purchasedQuantity(c, product))
+	}
+
+	var skus []db.ListProductSkuStockRow
+	if product.HasVariants.Valid && product.HasVariants.Bool {
+		skus, err = s.storage.Queries.ListProductSkuStock(ctx, product.ID)
+		if err != nil {
+			slog.Error("failed to list sku stock for availability", "error", err, "product_id", product.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load availability")
+		}
+		lines, err := s.storage.Queries.ListCartSkuQuantities(ctx, db.ListCartSkuQuantitiesParams{
+			SessionID: owner.sessionParam(),
+			UserID:    owner.userParam(),
+			ProductID: product.ID,
+		})
+		if err != nil {
+			slog.Error("failed to get cart sku quantities for availability", "error", err, "product_id", product.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load availability")
+		}
+		for _, line := range lines {
+			shopper.SkuInCart[line.ProductSkuID] = line.Quantity
+		}
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, api.NewProductAvailability(product, skus, shopper))
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// backorderPolicy reads a product's backorder settings
+func backorderPolicy(product db.Product) utils.BackorderPolicy {
+	policy := utils.BackorderPolicy{Allow: product.AllowBackorder}
+	if product.MaxBackorderQuantity.Valid {
+		policy.MaxQuantity = product.MaxBackorderQuantity.Int64
+	}
+	if product.BackorderShipDate.Valid {
+		policy.ShipDate = product.BackorderShipDate.String
+	}
+	return policy
+}
+
+// itemStockQuantity returns the stock that covers a cart line: the SKU's when one
+// is selected, otherwise the product's
+func itemStockQuantity(product db.Product, sku *db.ProductSku) int64 {
+	if sku != nil {
+		return sku.StockQuantity.Int64
+	}
+	return product.StockQuantity.Int64
+}
+
+// checkBackorder verifies a quantity can be purchased under the product's backorder
+// settings. The returned error message is safe to show to customers.
+func (s *Service) checkBackorder(ctx context.Context, product db.Product, sku *db.ProductSku, quantity int64) error {
+	// Pre-orders are sold ahead of stock by design, and downloads have no stock,
+	// so backorder limits don't apply
+	if product.IsPreorder || product.ProductType == utils.ProductTypeDigital {
+		return nil
+	}
+
+	policy := backorderPolicy(product)
+	stock := itemStockQuantity(product, sku)
+
+	var outstanding int64
+	if policy.Allow && policy.MaxQuantity > 0 && utils.BackorderQuantity(stock, quantity) > 0 {
+		var err error
+		outstanding, err = s.storage.Queries.GetOpenBackorderQuantity(ctx, product.ID)
+		if err != nil {
+			// Checkout has always allowed backorders, so don't block a sale on a lookup failure
+			slog.Error("failed to get open backorder quantity", "error", err, "product_id", product.ID)
+			outstanding = 0
+		}
+	}
+
+	switch err := utils.CheckBackorder(policy, stock, quantity, outstanding); {
+	case errors.Is(err, utils.ErrBackorderNotAllowed):
+		if stock <= 0 {
+			return fmt.Errorf("%s is out of stock", product.Name)
+		}
+		return fmt.Errorf("only %d of %s in stock", stock, product.Name)
+	case errors.Is(err, utils.ErrBackorderLimitReached):
+		return fmt.Errorf("%s has reached its backorder limit; please reduce the quantity", product.Name)
+	}
+	return nil
+}
+
+// itemShippingMessage describes when a cart line ships: instant delivery for downloads,
+// the expected date for pre-orders, otherwise the stock or backorder lead time
+func itemShippingMessage(product db.Product, sku *db.ProductSku, quantity int64) string {
+	if product.ProductType == utils.ProductTypeDigital {
+		return utils.ShippingTimeDigital
+	}
+	if product.IsPreorder {
+		return utils.PreorderShippingMessage(product.PreorderShipDate.String)
+	}
+	return utils.BackorderShippingMessage(itemStockQuantity(product, sku), quantity, backorderPolicy(product).ShipDate)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/feed"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/blog"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+// blogFeedSize is how many of the newest posts the feeds carry
+const blogFeedSize = 20
+
+// handleBlog lists published posts, newest first
+func (s *Service) handleBlog(c echo.Context) error {
+	ctx := c.Request().Context()
+	now := sql.NullTime{Time: utils.WallClockNow(), Valid: true}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	total, err := s.storage.Queries.CountPublishedBlogPosts(ctx, now)
+	if err != nil {
+		slog.Error("failed to count blog posts", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load blog")
+	}
+	posts, err := s.storage.Queries.ListPublishedBlogPosts(ctx, db.ListPublishedBlogPostsParams{
+		Now:    now,
+		Limit:  blog.PageSize,
+		Offset: int64((page - 1) * blog.PageSize),
+	})
+	if err != nil {
+		slog.Error("failed to fetch blog posts", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load blog")
+	}
+
+	listing := blog.Listing{
+		Posts:      make([]blog.PostView, 0, len(posts)),
+		Page:       page,
+		TotalPages: int((total + blog.PageSize - 1) / blog.PageSize),
+	}
+	for _, post := range posts {
+		listing.Posts = append(listing.Posts, blog.PostView{
+			Post:           post,
+			Excerpt:        blogSummary(post),
+			ReadingMinutes: utils.ReadingMinutes(post.BodyMarkdown),
+		})
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Blog | Logan's 3D Creations"
+	meta.Description = "Print tips, project stories and news from Logan's 3D Creations."
+	meta.Keywords = []string{"3D printing blog", "3D printing tips", "maker projects"}
+	meta.OGType = "website"
+	if page > 1 {
+		meta.Title = fmt.Sprintf("Blog (Page %d) | Logan's 3D Creations", page)
+	}
+	return Render(c, blog.Index(c, meta, listing))
+}
+
+// handleBlogPost shows one published post by slug
+func (s *Service) handleBlogPost(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	post, err := s.storage.Queries.GetPublishedBlogPostBySlug(ctx, db.GetPublishedBlogPostBySlugParams{
+		Slug: slug,
+		Now:  sql.NullTime{Time: utils.WallClockNow(), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Post not found")
+		}
+		slog.Error("failed to fetch blog post", "error", err, "slug", slug)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load post")
+	}
+
+	html, err := utils.RenderMarkdown(post.BodyMarkdown)
+	if err != nil {
+		slog.Error("failed to render blog post", "error", err, "post_id", post.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load post")
+	}
+
+	view := blog.PostView{
+		Post:           post,
+		HTML:           html,
+		Excerpt:        blogSummary(post),
+		ReadingMinutes: utils.ReadingMinutes(post.BodyMarkdown),
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = post.Title + " | Blog | Logan's 3D Creations"
+	meta.Description = view.Excerpt
+	meta.CanonicalURL = layout.CanonicalURL(c, blog.Path(post))
+	meta.OGURL = meta.CanonicalURL
+	meta.OGTitle = post.Title
+	meta.OGDescription = view.Excerpt
+	meta.OGType = "article"
+	meta = meta.WithOGImage("/api/og-image/blog/" + post.ID)
+	return Render(c, blog.Post(c, meta, view))
+}
+
+// handleBlogFeed serves the newest posts as RSS
+func (s *Service) handleBlogFeed(c echo.Context) error {
+	f, err := s.blogFeed(c, blog.FeedPath)
+	if err != nil {
+		return err
+	}
+	out, err := feed.RSS(f)
+	if err != nil {
+		slog.Error("failed to write RSS feed", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to build feed")
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.Blob(http.StatusOK, feed.RSSContentType, out)
+}
+
+// handleBlogAtom serves the newest posts as Atom
+func (s *Service) handleBlogAtom(c echo.Context) error {
+	f, err := s.blogFeed(c, blog.AtomPath)
+	if err != nil {
+		return err
+	}
+	out, err := feed.Atom(f)
+	if err != nil {
+		slog.Error("failed to write Atom feed", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to build feed")
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.Blob(http.StatusOK, feed.AtomContentType, out)
+}
+
+// blogFeed loads the newest published posts for a feed served at feedPath
+func (s *Service) blogFeed(c echo.Context, feedPath string) (feed.Feed, error) {
+	posts, err := s.storage.Queries.ListPublishedBlogPosts(c.Request().Context(), db.ListPublishedBlogPostsParams{
+		Now:   sql.NullTime{Time: utils.WallClockNow(), Valid: true},
+		Limit: blogFeedSize,
+	})
+	if err != nil {
+		slog.Error("failed to fetch blog posts for feed", "error", err)
+		return feed.Feed{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load posts")
+	}
+
+	f := feed.Feed{
+		Title:       utils.BlogName,
+		Description: "Print tips, project stories and news from Logan's 3D Creations.",
+		Author:      "Logan's 3D Creations",
+		Link:        layout.CanonicalURL(c, "/blog"),
+		FeedURL:     layout.CanonicalURL(c, feedPath),
+		Entries:     make([]feed.Entry, 0, len(posts)),
+	}
+	for _, post := range posts {
+		html, err := utils.RenderMarkdown(post.BodyMarkdown)
+		if err != nil {
+			slog.Warn("failed to render blog post for feed", "error", err, "post_id", post.ID)
+		}
+		link := layout.CanonicalURL(c, blog.Path(post))
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:        link,
+			Title:     post.Title,
+			Link:      link,
+			Author:    post.AuthorName,
+			Summary:   blogSummary(post),
+			HTML:      html,
+			Published: post.PublishedAt.Time,
+			Updated:   post.UpdatedAt.Time,
+		})
+	}
+	return f, nil
+}
+
+// blogSummary is the post's own summary, or the start of its text when it has none
+func blogSummary(post db.BlogPost) string {
+	if post.Summary != "" {
+		return post.Summary
+	}
+	return utils.MarkdownExcerpt(post.BodyMarkdown, utils.BlogExcerptLength)
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// bundleAvailability returns how many complete bundles current component stock
+// covers, and whether every component can still be sold
+func bundleAvailability(items []db.ListProductBundleItemsRow) (int64, bool) {
+	if len(items) == 0 {
+		return 0, false
+	}
+
+	var available int64 = -1
+	purchasable := true
+	for _, item := range items {
+		if (item.ProductIsActive.Valid && !item.ProductIsActive.Bool) || (item.SkuIsActive.Valid && !item.SkuIsActive.Bool) {
+			purchasable = false
+		}
+
+		covered := int64(0)
+		if item.StockQuantity > 0 && item.Quantity > 0 {
+			covered = item.StockQuantity / item.Quantity
+		}
+		if available < 0 || covered < available {
+			available = covered
+		}
+	}
+	return available, purchasable
+}
+
+// bundleListPrice is what the components cost when bought separately
+func bundleListPrice(items []db.ListProductBundleItemsRow) int64 {
+	var total int64
+	for _, item := range items {
+		total += item.UnitPriceCents * item.Quantity
+	}
+	return total
+}
+
+// allocateBundlePrice splits the bundle price into per-unit prices for each component,
+// proportional to list price. Rounding cents go to a single-quantity component so the
+// lines add up to the bundle price; if every component has a quantity above one, the
+// leftover (fewer cents than the smallest quantity) is dropped in the customer's favor.
+func allocateBundlePrice(priceCents int64, items []db.ListProductBundleItemsRow) []int64 {
+	units := make([]int64, len(items))
+	if len(items) == 0 || priceCents <= 0 {
+		return units
+	}
+
+	// Weight by list price, falling back to piece count when components are free
+	weights := make([]int64, len(items))
+	var totalWeight int64
+	for i, item := range items {
+		weights[i] = item.UnitPriceCents * item.Quantity
+		totalWeight += weights[i]
+	}
+	if totalWeight == 0 {
+		for i, item := range items {
+			weights[i] = item.Quantity
+			totalWeight += weights[i]
+		}
+	}
+
+	var allocated int64
+	for i, item := range items {
+		units[i] = priceCents * weights[i] / totalWeight / item.Quantity
+		allocated += units[i] * item.Quantity
+	}
+
+	remainder := priceCents - allocated
+	for i, item := range items {
+		if item.Quantity == 1 {
+			units[i] += remainder
+			return units
+		}
+	}
+	for i, item := range items {
+		extra := remainder / item.Quantity
+		units[i] += extra
+		remainder -= extra * item.Quantity
+	}
+	return units
+}
+
+// loadBundleView gathers a bundle's components and derives its price and availability
+func (s *Service) loadBundleView(ctx context.Context, bundle db.ProductBundle) (shop.BundleView, error) {
+	items, err := s.storage.Queries.ListProductBundleItems(ctx, bundle.ID)
+	if err != nil {
+		slog.Error("failed to list bundle items", "error", err, "bundle_id", bundle.ID)
+		return shop.BundleView{}, err
+	}
+
+	available, purchasable := bundleAvailability(items)
+	view := shop.BundleView{
+		Bundle:         bundle,
+		Components:     items,
+		ListPriceCents: bundleListPrice(items),
+		AvailableCount: available,
+		Purchasable:    purchasable,
+	}
+
+	if bundle.ImageUrl.Valid && bundle.ImageUrl.String != "" {
+		view.ImageURL = bundle.ImageUrl.String
+	} else {
+		for _, item := range items {
+			if url := shop.BundleComponentImageURL(item); url != "" {
+				view.ImageURL = url
+				break
+			}
+		}
+	}
+	return view, nil
+}
+
+// loadActiveBundles returns the storefront bundles, skipping any without components
+func (s *Service) loadActiveBundles(ctx context.Context) ([]shop.BundleView, error) {
+	bundles, err := s.storage.Queries.ListActiveProductBundles(ctx)
+	if err != nil {
+		slog.Error("failed to list active bundles", "error", err)
+		return nil, err
+	}
+
+	views := make([]shop.BundleView, 0, len(bundles))
+	for _, bundle := range bundles {
+		view, err := s.loadBundleView(ctx, bundle)
+		if err != nil {
+			return nil, err
+		}
+		if len(view.Components) == 0 {
+			continue
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *Service) handleBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	bundle, err := s.storage.Queries.GetActiveProductBundleBySlug(ctx, slug)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to get bundle", "error", err, "slug", slug)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load bundle")
+		}
+		return echo.NewHTTPError(http.StatusNotFound, "Bundle not found")
+	}
+
+	view, err := s.loadBundleView(ctx, bundle)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load bundle")
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = fmt.Sprintf("%s - Logan's 3D Creations", bundle.Name)
+	meta.Description = fmt.Sprintf("Get %d pieces together in the %s bundle.", view.ItemCount(), bundle.Name)
+	if bundle.Description.Valid && bundle.Description.String != "" {
+		meta.Description = bundle.Description.String
+	}
+	meta.CanonicalURL = layout.BuildAbsoluteURL(meta.SiteURL, view.URL())
+	meta.OGURL = meta.CanonicalURL
+	if view.ImageURL != "" {
+		meta.OGImageURL = layout.BuildAbsoluteURL(meta.SiteURL, view.ImageURL)
+		meta.TwitterImageURL = meta.OGImageURL
+	}
+
+	return Render(c, shop.Bundle(c, meta, view))
+}
+
+// handleAddBundleToCart expands a bundle into its component cart lines. Each line
+// carries its share of the bundle price and shares a group ID so the bundle is
+// removed as a unit.
+func (s *Service) handleAddBundleToCart(c echo.Context) error {
+	var req struct {
+		BundleID string `json:"bundleId"`
+		Quantity int64  `json:"quantity"`
+	}
+
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request format")
+	}
+
+	if req.BundleID == "" || req.Quantity <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing or invalid bundleId or quantity")
+	}
+
+	sessionID, err := s.getOrCreateSessionID(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create session")
+	}
+
+	user, isAuthenticated := auth.GetDBUser(c)
+	var userID string
+	if isAuthenticated {
+		userID = user.ID
+	}
+
+	ctx := c.Request().Context()
+
+	bundle, err := s.storage.Queries.GetProductBundle(ctx, req.BundleID)
+	if err != nil || !bundle.IsActive {
+		return echo.NewHTTPError(http.StatusNotFound, "Bundle not found")
+	}
+
+	items, err := s.storage.Queries.ListProductBundleItems(ctx, bundle.ID)
+	if err != nil {
+		slog.Error("failed to list bundle items", "error", err, "bundle_id", bundle.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add bundle to cart")
+	}
+	if _, purchasable := bundleAvailability(items); !purchasable {
+		return echo.NewHTTPError(http.StatusBadRequest, "This bundle is currently unavailable")
+	}
+
+	// Each component must be on sale and purchasable under its own backorder settings
+	// and purchase limits
+	for _, item := range items {
+		product, err := s.storage.Queries.GetProduct(ctx, item.ProductID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "This bundle is currently unavailable")
+		}
+		if err := s.checkDrop(c, product); err != nil {
+			return err
+		}
+		var sku *db.ProductSku
+		if item.ProductSkuID.Valid && item.ProductSkuID.String != "" {
+			skuRecord, err := s.storage.Queries.GetProductSku(ctx, item.ProductSkuID.String)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "This bundle is currently unavailable")
+			}
+			sku = &skuRecord
+		}
+		if backorderErr := s.checkBackorder(ctx, product, sku, item.Quantity*req.Quantity); backorderErr != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, backorderErr.Error())
+		}
+		if limitErr := s.checkPurchaseLimit(c, product, sessionID, item.Quantity*req.Quantity); limitErr != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+		}
+		if limitErr := s.checkMaxPerOrder(c, product, cartOwner{SessionID: sessionID, UserID: userID}, item.Quantity*req.Quantity); limitErr != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+		}
+	}
+
+	unitPrices := allocateBundlePrice(bundle.PriceCents, items)
+	groupID := uuid.New().String()
+	for i, item := range items {
+		err := s.storage.Queries.AddBundleCartItem(ctx, db.AddBundleCartItemParams{
+			ID:                   uuid.New().String(),
+			SessionID:            sql.NullString{String: sessionID, Valid: !isAuthenticated},
+			UserID:               sql.NullString{String: userID, Valid: isAuthenticated},
+			ProductID:            item.ProductID,
+			ProductSkuID:         item.ProductSkuID,
+			Quantity:             item.Quantity * req.Quantity,
+			BundleID:             sql.NullString{String: bundle.ID, Valid: true},
+			BundleGroupID:        sql.NullString{String: groupID, Valid: true},
+			BundleUnitPriceCents: sql.NullInt64{Int64: unitPrices[i], Valid: true},
+		})
+		if err != nil {
+			slog.Error("failed to add bundle item to cart", "error", err, "bundle_id", bundle.ID, "product_id", item.ProductID)
+			// Don't leave a partial bundle behind
+			if cleanupErr := s.storage.Queries.RemoveCartBundleGroup(ctx, sql.NullString{String: groupID, Valid: true}); cleanupErr != nil {
+				slog.Error("failed to remove partial bundle from cart", "error", cleanupErr, "bundle_group_id", groupID)
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add bundle to cart")
+		}
+	}
+
+	if s.shippingHandler != nil {
+		if err := s.shippingHandler.InvalidateShipping(c, sessionID); err != nil {
+			slog.Error("failed to invalidate shipping after cart change", "error", err, "session_id", sessionID)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Bundle added to cart successfully",
+	})
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func bundleItem(unitPriceCents, quantity, stock int64) db.ListProductBundleItemsRow {
+	return db.ListProductBundleItemsRow{
+		UnitPriceCents: unitPriceCents,
+		Quantity:       quantity,
+		StockQuantity:  stock,
+	}
+}
+
+func allocatedTotal(units []int64, items []db.ListProductBundleItemsRow) int64 {
+	var total int64
+	for i, item := range items {
+		total += units[i] * item.Quantity
+	}
+	return total
+}
+
+func TestAllocateBundlePrice(t *testing.T) {
+	t.Run("proportional to list price", func(t *testing.T) {
+		items := []db.ListProductBundleItemsRow{bundleItem(3000, 1, 0), bundleItem(1000, 1, 0)}
+		units := allocateBundlePrice(3000, items)
+		assert.Equal(t, []int64{2250, 750}, units)
+	})
+
+	t.Run("rounding cents land on a single-quantity component", func(t *testing.T) {
+		items := []db.ListProductBundleItemsRow{bundleItem(1000, 3, 0), bundleItem(1000, 1, 0)}
+		units := allocateBundlePrice(3999, items)
+		assert.Equal(t, int64(3999), allocatedTotal(units, items))
+	})
+
+	t.Run("never charges more than the bundle price", func(t *testing.T) {
+		items := []db.ListProductBundleItemsRow{bundleItem(1000, 2, 0), bundleItem(500, 3, 0)}
+		units := allocateBundlePrice(2999, items)
+		total := allocatedTotal(units, items)
+		assert.LessOrEqual(t, total, int64(2999))
+		assert.Greater(t, total, int64(2999-2))
+	})
+
+	t.Run("free components split by piece count", func(t *testing.T) {
+		items := []db.ListProductBundleItemsRow{bundleItem(0, 1, 0), bundleItem(0, 1, 0)}
+		units := allocateBundlePrice(1001, items)
+		assert.Equal(t, int64(1001), allocatedTotal(units, items))
+	})
+}
+
+func TestBundleAvailability(t *testing.T) {
+	t.Run("limited by the scarcest component", func(t *testing.T) {
+		available, purchasable := bundleAvailability([]db.ListProductBundleItemsRow{
+			bundleItem(1000, 1, 10),
+			bundleItem(1000, 2, 5),
+		})
+		assert.Equal(t, int64(2), available)
+		assert.True(t, purchasable)
+	})
+
+	t.Run("inactive component blocks purchase", func(t *testing.T) {
+		item := bundleItem(1000, 1, 10)
+		item.SkuIsActive = sql.NullBool{Bool: false, Valid: true}
+		_, purchasable := bundleAvailability([]db.ListProductBundleItemsRow{item})
+		assert.False(t, purchasable)
+	})
+
+	t.Run("empty bundle is not purchasable", func(t *testing.T) {
+		available, purchasable := bundleAvailability(nil)
+		assert.Equal(t, int64(0), available)
+		assert.False(t, purchasable)
+	})
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/cache"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// catalog returns the cached catalog queries. Without a cache (as in tests) every
+// call goes straight to the database.
+func (s *Service) catalog() *cache.Catalog {
+	return cache.NewCatalog(s.storage.Queries, s.cache)
+}
+
+// invalidateCacheOnWrite drops cached catalog data after any successful write through
+// the routes it wraps, so admin edits show in the shop right away. With no groups it
+// purges everything.
+func (s *Service) invalidateCacheOnWrite(groups ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return err
+			}
+			if err != nil || c.Response().Status >= http.StatusBadRequest {
+				return err
+			}
+
+			if len(groups) == 0 {
+				s.cache.Purge()
+			}
+			for _, group := range groups {
+				s.cache.Invalidate(group)
+			}
+			return nil
+		}
+	}
+}
+
+func (s *Service) handleDevCache(c echo.Context) error {
+	return Render(c, admin.DevCache(c, s.cache.Stats(), c.QueryParam("purged") != ""))
+}
+
+func (s *Service) handleDevCachePurge(c echo.Context) error {
+	s.cache.Purge()
+	slog.Info("catalog cache purged from developer tools")
+	return c.Redirect(http.StatusSeeOther, "/dev/cache?purged=1")
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	stripeutil "github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// The steps of turning a cart into a checkout that hold something or pay for it. The
+// cart checkout handler builds the lines, then works out what store credit and a gift
+// card pay, holds stock and balances, and opens the checkout with the holds linked.
+
+// replaceEarlierCheckouts closes the cart's earlier checkouts that still hold stock,
+// so they can't be paid alongside the one starting, and only then releases what they
+// held: stock, gift card and store credit. A checkout expire can't close, because
+// it's been paid or its payment is going through, keeps its holds.
+func (s *Service) replaceEarlierCheckouts(ctx context.Context, cartSessionID string, expire func(ctx context.Context, checkoutID string) (bool, error)) {
+	reservations := inventory.NewReservations(s.storage.Queries)
+	checkoutIDs, err := reservations.HeldCheckouts(ctx, cartSessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list earlier checkouts", "error", err, "session_id", cartSessionID)
+		return
+	}
+
+	for _, checkoutID := range checkoutIDs {
+		expired, err := expire(ctx, checkoutID)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to close earlier checkout", "error", err, "checkout_id", checkoutID)
+			continue
+		}
+		if !expired {
+			slog.InfoContext(ctx, "earlier checkout left open, it's paid or being paid", "checkout_id", checkoutID)
+			continue
+		}
+
+		if err := giftcards.NewLedger(s.storage.Queries).ReleaseSession(ctx, checkoutID, "Checkout replaced"); err != nil {
+			slog.ErrorContext(ctx, "failed to release gift card holds for replaced checkout", "error", err, "checkout_id", checkoutID)
+		}
+		if err := storecredit.NewLedger(s.storage.Queries).ReleaseSession(ctx, checkoutID, "Checkout replaced"); err != nil {
+			slog.ErrorContext(ctx, "failed to release store credit holds for replaced checkout", "error", err, "checkout_id", checkoutID)
+		}
+		if _, err := reservations.ReleaseSession(ctx, checkoutID); err != nil {
+			slog.ErrorContext(ctx, "failed to release stock holds for replaced checkout", "error", err, "checkout_id", checkoutID)
+		}
+		slog.InfoContext(ctx, "earlier checkout replaced", "checkout_id", checkoutID, "session_id", cartSessionID)
+	}
+}
+
+// expireCheckout closes a Checkout Session or draft the customer replaced, reporting
+// false for one that's been paid or whose payment is going through
+func (s *Service) expireCheckout(ctx context.Context, checkoutID string) (bool, error) {
+	if !stripeutil.IsCheckoutDraftID(checkoutID) {
+		return stripeutil.ExpireReplacedSession(checkoutID)
+	}
+
+	draft, err := s.storage.Queries.GetCheckoutDraft(ctx, checkoutID)
+	if err != nil {
+		return false, err
+	}
+	if draft.Status != "open" {
+		return draft.Status == "expired", nil
+	}
+	cancelled, err := stripeutil.CancelDraftPaymentIntent(draft.PaymentIntentID)
+	if err != nil || !cancelled {
+		return false, err
+	}
+	if _, err := s.storage.Queries.ExpireCheckoutDraft(ctx, checkoutID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkoutPaymentInput is the cart store credit and a gift card pay toward
+type checkoutPaymentInput struct {
+	UserID       string
+	GiftCardCode string
+	PromoCode    appliedPromoCode
+	DigitalOnly  bool
+	// AmountCents is what the lines come to before tax. GiftCardLinesCents is the part
+	// of it buying gift cards, which neither credit nor a card can pay for.
+	AmountCents        int64
+	GiftCardLinesCents int64
+}
+
+// checkoutPayment is what pays for a checkout besides the customer's card
+type checkoutPayment struct {
+	StoreCreditCents int64
+	GiftCard         db.GiftCard
+	GiftCardCents    int64
+	// Tax is worked out before the session when a gift card pays, so the card pays the
+	// tax too; nil when Stripe works it out
+	Tax *stripe.TaxCalculation
+}
+
+// TaxCents is the tax worked out for the gift card, or 0 without one
+func (p checkoutPayment) TaxCents() int64 {
+	if p.Tax == nil {
+		return 0
+	}
+	return p.Tax.TaxAmountExclusive
+}
+
+// checkoutPayments works out what store credit and a gift card pay for a checkout.
+//
+// Store credit on the customer's account pays for what's left after the promo code,
+// without being asked for. It goes on the same coupon as the code, so a code Stripe
+// applies itself wins over it. Like the code it comes off before tax.
+//
+// A gift card pays for the rest, tax included. It's a payment rather than a discount,
+// so calculateTax works out the tax first, on the lines after discountCents comes off.
+// Downloads are taxed by the billing address Stripe's page asks for, which isn't
+// known yet, so a card can't pay for them. Stripe takes only one discount, so a code
+// Stripe applies itself can't share. Errors the shopper can fix are *echo.HTTPError.
+func (s *Service) checkoutPayments(ctx context.Context, in checkoutPaymentInput, calculateTax func(discountCents int64) (*stripe.TaxCalculation, error)) (checkoutPayment, error) {
+	var payment checkoutPayment
+	if in.UserID != "" && in.PromoCode.StripePromotionCodeID == "" {
+		balance, err := storecredit.NewLedger(s.storage.Queries).Balance(ctx, in.UserID)
+		if err != nil {
+			// Checkout goes ahead at full price rather than failing
+			slog.ErrorContext(ctx, "failed to get store credit balance", "error", err, "user_id", in.UserID)
+		}
+		payment.StoreCreditCents = min(balance, max(0, in.AmountCents-in.PromoCode.DiscountCents-in.GiftCardLinesCents))
+	}
+
+	if strings.TrimSpace(in.GiftCardCode) == "" {
+		return payment, nil
+	}
+	if in.PromoCode.StripePromotionCodeID != "" {
+		return payment, echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("%s can't be combined with a gift card; remove one of them", in.PromoCode.Code),
+		})
+	}
+	if in.DigitalOnly {
+		return payment, echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+			"error": "Gift cards can't pay for download-only orders; remove the gift card to check out",
+		})
+	}
+	card, err := giftcards.NewLedger(s.storage.Queries).Find(ctx, in.GiftCardCode, time.Now())
+	var cardErr *giftcards.Error
+	if errors.As(err, &cardErr) {
+		return payment, echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+			"error": cardErr.Message,
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check gift card", "error", err)
+		return payment, echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+	}
+
+	discountCents := in.PromoCode.DiscountCents + payment.StoreCreditCents
+	tax, err := calculateTax(discountCents)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to calculate tax for gift card checkout", "error", err, "gift_card_id", card.ID)
+		return payment, echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+	}
+	payment.GiftCard = card
+	payment.Tax = tax
+	payment.GiftCardCents = min(card.BalanceCents, max(0, in.AmountCents-discountCents+tax.TaxAmountExclusive-in.GiftCardLinesCents))
+	return payment, nil
+}
+
+// checkoutHolds are what an open checkout keeps anyone else from buying or spending:
+// its in-stock units, the gift card amount and the store credit
+type checkoutHolds struct {
+	reservations *inventory.Reservations
+	ledger       *giftcards.Ledger
+	credit       *storecredit.Ledger
+	userID       string
+	payment      checkoutPayment
+
+	stockHoldID    string
+	giftCardHoldID string
+	creditHoldID   string
+}
+
+// holdCheckout holds a checkout's in-stock units for ttl, then the gift card amount and
+// the store credit, so the same units or balance can't pay for another checkout
+// meanwhile. Either everything is held or nothing is. Errors the shopper can fix, like
+// the last unit going to someone else, are *echo.HTTPError.
+func (s *Service) holdCheckout(ctx context.Context, cartSessionID, userID string, stockLines []inventory.Line, payment checkoutPayment, ttl time.Duration) (*checkoutHolds, error) {
+	holds := &checkoutHolds{
+		reservations: inventory.NewReservations(s.storage.Queries),
+		ledger:       giftcards.NewLedger(s.storage.Queries),
+		credit:       storecredit.NewLedger(s.storage.Queries),
+		userID:       userID,
+		payment:      payment,
+	}
+
+	var err error
+	if len(stockLines) > 0 {
+		holds.stockHoldID, err = holds.reservations.Hold(ctx, cartSessionID, stockLines, ttl)
+		var stockErr *inventory.Error
+		if errors.As(err, &stockErr) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": stockErr.Message,
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to hold stock", "error", err, "session_id", cartSessionID)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+		}
+	}
+
+	if payment.GiftCardCents > 0 {
+		holds.giftCardHoldID, err = holds.ledger.Hold(ctx, payment.GiftCard.ID, payment.GiftCardCents)
+		if err != nil {
+			holds.release(ctx)
+		}
+		var cardErr *giftcards.Error
+		if errors.As(err, &cardErr) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": cardErr.Message,
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to hold gift card balance", "error", err, "gift_card_id", payment.GiftCard.ID)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+		}
+	}
+
+	if payment.StoreCreditCents > 0 {
+		holds.creditHoldID, err = holds.credit.Hold(ctx, userID, payment.StoreCreditCents)
+		if err != nil {
+			holds.release(ctx)
+		}
+		var creditErr *storecredit.Error
+		if errors.As(err, &creditErr) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": creditErr.Message,
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to hold store credit", "error", err, "user_id", userID)
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+		}
+	}
+	return holds, nil
+}
+
+// holdsStock reports whether stock is held, in which case the checkout closes when
+// the hold lapses
+func (h *checkoutHolds) holdsStock() bool {
+	return h.stockHoldID != ""
+}
+
+// release puts back what's held for a checkout that couldn't be made
+func (h *checkoutHolds) release(ctx context.Context) {
+	if h.stockHoldID != "" {
+		if err := h.reservations.Release(ctx, h.stockHoldID); err != nil {
+			slog.ErrorContext(ctx, "failed to release stock hold", "error", err, "hold_id", h.stockHoldID)
+		}
+		h.stockHoldID = ""
+	}
+	if h.giftCardHoldID != "" {
+		hold := db.GiftCardTransaction{ID: h.giftCardHoldID, GiftCardID: h.payment.GiftCard.ID, AmountCents: -h.payment.GiftCardCents}
+		if err := h.ledger.Release(ctx, hold, "Checkout session failed"); err != nil {
+			slog.ErrorContext(ctx, "failed to release gift card hold", "error", err, "gift_card_id", h.payment.GiftCard.ID, "amount_cents", h.payment.GiftCardCents)
+		}
+		h.giftCardHoldID = ""
+	}
+	if h.creditHoldID != "" {
+		hold := db.StoreCreditTransaction{ID: h.creditHoldID, UserID: h.userID, AmountCents: -h.payment.StoreCreditCents}
+		if err := h.credit.Release(ctx, hold, "Checkout session failed"); err != nil {
+			slog.ErrorContext(ctx, "failed to release store credit hold", "error", err, "user_id", h.userID, "amount_cents", h.payment.StoreCreditCents)
+		}
+		h.creditHoldID = ""
+	}
+}
+
+// link records the checkout the holds were made for, so they're released if it
+// expires and used when it's paid
+func (h *checkoutHolds) link(ctx context.Context, checkoutID string) {
+	if h.giftCardHoldID != "" {
+		if err := h.ledger.LinkHold(ctx, h.giftCardHoldID, checkoutID); err != nil {
+			// Without the session the hold won't be released if the checkout expires
+			slog.ErrorContext(ctx, "failed to link gift card hold to checkout session", "error", err, "gift_card_id", h.payment.GiftCard.ID, "session_id", checkoutID)
+		}
+	}
+	if h.creditHoldID != "" {
+		if err := h.credit.LinkHold(ctx, h.creditHoldID, checkoutID); err != nil {
+			slog.ErrorContext(ctx, "failed to link store credit hold to checkout session", "error", err, "user_id", h.userID, "session_id", checkoutID)
+		}
+	}
+	if h.stockHoldID != "" {
+		if err := h.reservations.Link(ctx, h.stockHoldID, checkoutID); err != nil {
+			// The hold still lapses with the session, but won't be consumed when it's paid
+			slog.ErrorContext(ctx, "failed to link stock hold to checkout session", "error", err, "session_id", checkoutID)
+		}
+	}
+}
+
+// openCheckout makes the checkout with create and links its holds to it, returning
+// where to send the shopper. When it can't be made the holds go back.
+func openCheckout(ctx context.Context, holds *checkoutHolds, create func() (checkoutID, checkoutURL string, err error)) (string, error) {
+	checkoutID, checkoutURL, err := create()
+	if err != nil {
+		holds.release(ctx)
+		return "", err
+	}
+	holds.link(ctx, checkoutID)
+	return checkoutURL, nil
+}
+
+// createCheckout makes a Checkout Session, or with draft a checkout draft paid on our
+// own page. Checkout Sessions close at params.ExpiresAt, or after Stripe's default of
+// a day; drafts do the same.
+func (s *Service) createCheckout(ctx context.Context, params *stripe.CheckoutSessionParams, draft *checkoutDraftInput) (checkoutID, checkoutURL string, err error) {
+	if draft != nil {
+		draft.ExpiresAt = time.Now().Add(24 * time.Hour)
+		if params.ExpiresAt != nil {
+			draft.ExpiresAt = time.Unix(*params.ExpiresAt, 0)
+		}
+		if checkoutID, err = s.createCheckoutDraft(ctx, params, *draft); err != nil {
+			return "", "", err
+		}
+		return checkoutID, "/checkout/pay/" + checkoutID, nil
+	}
+
+	session, err := newCheckoutSession(params)
+	if err != nil {
+		return "", "", err
+	}
+	return session.ID, session.URL, nil
+}
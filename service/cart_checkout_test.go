@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+	"github.com/loganlanou/logans3d-v4/internal/handlers"
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// checkoutTestStock adds a product with stock for checkout holds
+func checkoutTestStock(t *testing.T, svc *Service, stock int64) inventory.Line {
+	t.Helper()
+	_, err := svc.storage.Queries.CreateProduct(context.Background(), db.CreateProductParams{
+		ID:            "prod-egg",
+		Name:          "Dragon Egg",
+		Slug:          "dragon-egg",
+		PriceCents:    1500,
+		StockQuantity: sql.NullInt64{Int64: stock, Valid: true},
+		IsActive:      sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	return inventory.Line{ProductID: "prod-egg", Name: "Dragon Egg", Quantity: 1}
+}
+
+func giftCardBalance(t *testing.T, svc *Service, cardID string) int64 {
+	t.Helper()
+	card, err := svc.storage.Queries.GetGiftCard(context.Background(), cardID)
+	require.NoError(t, err)
+	return card.BalanceCents
+}
+
+func TestReplaceEarlierCheckouts(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+	egg := checkoutTestStock(t, svc, 2)
+	reservations := inventory.NewReservations(svc.storage.Queries)
+	ledger := giftcards.NewLedger(svc.storage.Queries)
+	card, err := ledger.Issue(ctx, giftcards.IssueParams{AmountCents: 5000})
+	require.NoError(t, err)
+
+	// The cart's earlier checkout holds an egg and part of the card; another one, paid
+	// while it was replaced, holds the other egg
+	holdID, err := reservations.Hold(ctx, "cart-1", []inventory.Line{egg}, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, reservations.Link(ctx, holdID, "cs_open"))
+	cardHoldID, err := ledger.Hold(ctx, card.ID, 2000)
+	require.NoError(t, err)
+	require.NoError(t, ledger.LinkHold(ctx, cardHoldID, "cs_open"))
+	holdID, err = reservations.Hold(ctx, "cart-1", []inventory.Line{egg}, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, reservations.Link(ctx, holdID, "cs_paid"))
+
+	var expired []string
+	svc.replaceEarlierCheckouts(ctx, "cart-1", func(_ context.Context, checkoutID string) (bool, error) {
+		expired = append(expired, checkoutID)
+		return checkoutID == "cs_open", nil
+	})
+	assert.Equal(t, []string{"cs_open", "cs_paid"}, expired)
+
+	// Only the closed checkout's holds went back
+	reserved, err := reservations.Reserved(ctx, "prod-egg", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reserved)
+	assert.Equal(t, int64(5000), giftCardBalance(t, svc, card.ID))
+	checkouts, err := reservations.HeldCheckouts(ctx, "cart-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cs_paid"}, checkouts)
+
+	// A checkout that couldn't be closed keeps its holds
+	svc.replaceEarlierCheckouts(ctx, "cart-1", func(context.Context, string) (bool, error) {
+		return false, errors.New("stripe unavailable")
+	})
+	reserved, err = reservations.Reserved(ctx, "prod-egg", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reserved)
+}
+
+func TestCheckoutPayments(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+	user, err := handlers.CreateTestUser(svc.storage.Queries)
+	require.NoError(t, err)
+	_, err = storecredit.NewLedger(svc.storage.Queries).Grant(ctx, storecredit.GrantParams{UserID: user.ID, AmountCents: 1000, Reason: storecredit.ReasonGoodwill})
+	require.NoError(t, err)
+	card, err := giftcards.NewLedger(svc.storage.Queries).Issue(ctx, giftcards.IssueParams{AmountCents: 10000})
+	require.NoError(t, err)
+
+	// taxOn charges 10% on what's left after the discount, and records what it was given
+	var taxedDiscount int64 = -1
+	taxOn := func(amountCents int64) func(int64) (*stripe.TaxCalculation, error) {
+		return func(discountCents int64) (*stripe.TaxCalculation, error) {
+			taxedDiscount = discountCents
+			return &stripe.TaxCalculation{ID: "taxcalc_1", TaxAmountExclusive: (amountCents - discountCents) / 10}, nil
+		}
+	}
+	in := checkoutPaymentInput{
+		UserID:      user.ID,
+		PromoCode:   appliedPromoCode{Code: "SAVE10", DiscountCents: 500},
+		AmountCents: 6000,
+	}
+
+	t.Run("store credit comes off before tax, without a gift card", func(t *testing.T) {
+		payment, err := svc.checkoutPayments(ctx, in, taxOn(in.AmountCents))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), payment.StoreCreditCents)
+		assert.Zero(t, payment.GiftCardCents)
+		assert.Nil(t, payment.Tax, "Stripe works out the tax")
+		assert.Equal(t, int64(-1), taxedDiscount)
+	})
+
+	t.Run("a gift card pays the rest, tax included", func(t *testing.T) {
+		in := in
+		in.GiftCardCode = card.Code
+		payment, err := svc.checkoutPayments(ctx, in, taxOn(in.AmountCents))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1500), taxedDiscount, "tax is on what the code and credit leave")
+		assert.Equal(t, int64(450), payment.TaxCents())
+		assert.Equal(t, int64(1000), payment.StoreCreditCents)
+		assert.Equal(t, int64(6000-1500+450), payment.GiftCardCents)
+		assert.Equal(t, card.ID, payment.GiftCard.ID)
+	})
+
+	t.Run("gift cards bought in the order aren't paid by a card", func(t *testing.T) {
+		in := in
+		in.UserID = ""
+		in.GiftCardCode = card.Code
+		in.GiftCardLinesCents = 2500
+		payment, err := svc.checkoutPayments(ctx, in, taxOn(in.AmountCents))
+		require.NoError(t, err)
+		assert.Zero(t, payment.StoreCreditCents, "guests have no credit")
+		assert.Equal(t, int64(6000-500+550-2500), payment.GiftCardCents)
+	})
+
+	refused := func(t *testing.T, in checkoutPaymentInput) {
+		t.Helper()
+		_, err := svc.checkoutPayments(ctx, in, taxOn(in.AmountCents))
+		var httpErr *echo.HTTPError
+		require.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	}
+	t.Run("refused with a Stripe promotion code or downloads", func(t *testing.T) {
+		in := in
+		in.GiftCardCode = card.Code
+		in.DigitalOnly = true
+		refused(t, in)
+
+		in.DigitalOnly = false
+		in.PromoCode = appliedPromoCode{Code: "DASHBOARD", StripePromotionCodeID: "promo_1"}
+		refused(t, in)
+
+		in.PromoCode = appliedPromoCode{}
+		in.GiftCardCode = "NOT-A-CARD"
+		refused(t, in)
+	})
+}
+
+func TestHoldAndOpenCheckout(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+	egg := checkoutTestStock(t, svc, 1)
+	user, err := handlers.CreateTestUser(svc.storage.Queries)
+	require.NoError(t, err)
+	credit := storecredit.NewLedger(svc.storage.Queries)
+	_, err = credit.Grant(ctx, storecredit.GrantParams{UserID: user.ID, AmountCents: 1000, Reason: storecredit.ReasonGoodwill})
+	require.NoError(t, err)
+	card, err := giftcards.NewLedger(svc.storage.Queries).Issue(ctx, giftcards.IssueParams{AmountCents: 5000})
+	require.NoError(t, err)
+	reservations := inventory.NewReservations(svc.storage.Queries)
+	payment := checkoutPayment{StoreCreditCents: 800, GiftCard: card, GiftCardCents: 3000}
+
+	held := func() (stock, cardBalance, creditBalance int64) {
+		stock, err := reservations.Reserved(ctx, "prod-egg", "")
+		require.NoError(t, err)
+		creditBalance, err = credit.Balance(ctx, user.ID)
+		require.NoError(t, err)
+		return stock, giftCardBalance(t, svc, card.ID), creditBalance
+	}
+
+	// A checkout that can't be made puts back everything it held
+	holds, err := svc.holdCheckout(ctx, "cart-1", user.ID, []inventory.Line{egg}, payment, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, holds.holdsStock())
+	stock, cardBalance, creditBalance := held()
+	assert.Equal(t, []int64{1, 2000, 200}, []int64{stock, cardBalance, creditBalance})
+	_, err = openCheckout(ctx, holds, func() (string, string, error) {
+		return "", "", errors.New("stripe unavailable")
+	})
+	require.Error(t, err)
+	stock, cardBalance, creditBalance = held()
+	assert.Equal(t, []int64{0, 5000, 1000}, []int64{stock, cardBalance, creditBalance})
+
+	// One that's made has its holds linked to it
+	holds, err = svc.holdCheckout(ctx, "cart-1", user.ID, []inventory.Line{egg}, payment, time.Hour)
+	require.NoError(t, err)
+	url, err := openCheckout(ctx, holds, func() (string, string, error) {
+		return "cs_new", "https://checkout.stripe.com/c/pay/cs_new", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://checkout.stripe.com/c/pay/cs_new", url)
+	checkouts, err := reservations.HeldCheckouts(ctx, "cart-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cs_new"}, checkouts)
+	require.NoError(t, giftcards.NewLedger(svc.storage.Queries).ReleaseSession(ctx, "cs_new", "Checkout expired"))
+	require.NoError(t, credit.ReleaseSession(ctx, "cs_new", "Checkout expired"))
+	_, cardBalance, creditBalance = held()
+	assert.Equal(t, []int64{5000, 1000}, []int64{cardBalance, creditBalance})
+
+	// When the stock has gone to another checkout nothing is held
+	_, err = svc.holdCheckout(ctx, "cart-2", user.ID, []inventory.Line{egg}, payment, time.Hour)
+	var httpErr *echo.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	_, cardBalance, creditBalance = held()
+	assert.Equal(t, []int64{5000, 1000}, []int64{cardBalance, creditBalance})
+}
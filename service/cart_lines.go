@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// cartOwner identifies whose cart a request works on: the signed-in account, or else
+// the browser session
+type cartOwner struct {
+	SessionID string
+	UserID    string
+}
+
+func (o cartOwner) sessionParam() sql.NullString {
+	return sql.NullString{String: o.SessionID, Valid: o.UserID == ""}
+}
+
+func (o cartOwner) userParam() sql.NullString {
+	return sql.NullString{String: o.UserID, Valid: o.UserID != ""}
+}
+
+// owns reports whether a cart line belongs to this shopper
+func (o cartOwner) owns(item db.CartItem) bool {
+	if o.UserID != "" {
+		return item.UserID.Valid && item.UserID.String == o.UserID
+	}
+	return !item.UserID.Valid && item.SessionID.Valid && item.SessionID.String == o.SessionID
+}
+
+func (s *Service) cartOwner(c echo.Context) (cartOwner, error) {
+	sessionID, err := s.getOrCreateSessionID(c)
+	if err != nil {
+		return cartOwner{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to create session")
+	}
+	owner := cartOwner{SessionID: sessionID}
+	if user, ok := auth.GetDBUser(c); ok {
+		owner.UserID = user.ID
+	}
+	return owner, nil
+}
+
+// invalidateShipping drops the saved shipping rate after the cart changes, since the
+// packages it was quoted for no longer match
+func (s *Service) invalidateShipping(c echo.Context, sessionID string) {
+	if s.shippingHandler == nil {
+		return
+	}
+	if err := s.shippingHandler.InvalidateShipping(c, sessionID); err != nil {
+		slog.Error("failed to invalidate shipping after cart change", "error", err, "session_id", sessionID)
+	}
+}
+
+// cartLine is a product line going into the cart from somewhere other than the
+// product page: the saved-for-later list or a shared cart
+type cartLine struct {
+	ProductID       string
+	ProductSkuID    sql.NullString
+	Quantity        int64
+	Personalization sql.NullString
+}
+
+// cartPlacement is a line that passed the cart's checks. Line.Quantity may be less
+// than asked for when stock ran short; Existing is the matching cart line it merges
+// into, if any. PriceCents is the unit price the line is quoted at.
+type cartPlacement struct {
+	Product    db.Product
+	Line       cartLine
+	Existing   *db.GetExistingCartItemRow
+	PriceCents int64
+}
+
+// placeInCart runs a line through the same checks as adding from the product page:
+// the product and variant must still be on sale, drops, purchase limits and per-order
+// caps apply, and
+// products that can't be backordered are capped at what's left in stock. Errors are
+// echo HTTP errors with a message for the shopper.
+func (s *Service) placeInCart(c echo.Context, owner cartOwner, line cartLine) (cartPlacement, error) {
+	ctx := c.Request().Context()
+
+	product, err := s.storage.Queries.GetProduct(ctx, line.ProductID)
+	if err != nil {
+		return cartPlacement{}, echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+	if product.IsActive.Valid && !product.IsActive.Bool {
+		return cartPlacement{}, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("%s is no longer available", product.Name))
+	}
+	if err := s.checkDrop(c, product); err != nil {
+		return cartPlacement{}, err
+	}
+
+	var sku *db.ProductSku
+	if line.ProductSkuID.Valid && line.ProductSkuID.String != "" {
+		skuRecord, skuErr := s.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+			ID:        line.ProductSkuID.String,
+			ProductID: line.ProductID,
+		})
+		if skuErr != nil || (skuRecord.IsActive.Valid && !skuRecord.IsActive.Bool) {
+			return cartPlacement{}, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("The selected %s variant is no longer available", product.Name))
+		}
+		sku = &skuRecord
+	}
+
+	placement := cartPlacement{Product: product, Line: line, PriceCents: itemPriceCents(product, sku)}
+	existing, err := s.storage.Queries.GetExistingCartItem(ctx, db.GetExistingCartItemParams{
+		SessionID:       owner.sessionParam(),
+		UserID:          owner.userParam(),
+		ProductID:       line.ProductID,
+		ProductSkuID:    line.ProductSkuID,
+		Personalization: line.Personalization,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to find matching cart item", "error", err, "product_id", line.ProductID)
+		return cartPlacement{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to add item to cart")
+	}
+	var inCart int64
+	if err == nil {
+		placement.Existing = &existing
+		inCart = existing.Quantity
+	}
+
+	// Only what's left in stock goes in when the product can't be backordered
+	if !product.AllowBackorder && !product.IsPreorder && product.ProductType != utils.ProductTypeDigital {
+		available := itemStockQuantity(product, sku) - inCart
+		if available <= 0 {
+			return cartPlacement{}, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("%s is sold out", product.Name))
+		}
+		placement.Line.Quantity = min(line.Quantity, available)
+	}
+	if backorderErr := s.checkBackorder(ctx, product, sku, inCart+placement.Line.Quantity); backorderErr != nil {
+		return cartPlacement{}, echo.NewHTTPError(http.StatusConflict, backorderErr.Error())
+	}
+	if limitErr := s.checkPurchaseLimit(c, product, owner.SessionID, placement.Line.Quantity); limitErr != nil {
+		return cartPlacement{}, echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+	}
+	if limitErr := s.checkMaxPerOrder(c, product, owner, placement.Line.Quantity); limitErr != nil {
+		return cartPlacement{}, echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+	}
+
+	return placement, nil
+}
+
+// apply adds the placed line to the cart, folding it into the matching line if
+// there is one
+func (p cartPlacement) apply(ctx context.Context, queries *db.Queries, owner cartOwner) error {
+	var err error
+	if p.Existing != nil {
+		err = queries.UpdateCartItemQuantity(ctx, db.UpdateCartItemQuantityParams{
+			ID:       p.Existing.ID,
+			Quantity: p.Existing.Quantity + p.Line.Quantity,
+		})
+	} else {
+		err = queries.AddToCart(ctx, db.AddToCartParams{
+			ID:               uuid.New().String(),
+			SessionID:        owner.sessionParam(),
+			UserID:           owner.userParam(),
+			ProductID:        p.Line.ProductID,
+			ProductSkuID:     p.Line.ProductSkuID,
+			Quantity:         p.Line.Quantity,
+			Personalization:  p.Line.Personalization,
+			QuotedPriceCents: sql.NullInt64{Int64: p.PriceCents, Valid: true},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add line to cart: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// cartMergeKey holds the summary of a merge done by mergeCartOnLogin for the rest of
+// the request
+const cartMergeKey = "cart_merge"
+
+// cartMergeNoticeCookie carries the merge summary to the next /api/cart/merge call,
+// which hands it to the page once and clears the cookie
+const cartMergeNoticeCookie = "cart_merge_notice"
+
+// cartMergedCookie names the signed-in customer whose guest cart this browser has
+// already merged, so the merge runs once per sign-in rather than on every request.
+// The first signed-out request clears it, ready for the next sign-in.
+const cartMergedCookie = "cart_merged"
+
+// mergeCartOnLogin folds the browser's guest cart into the customer's cart on the
+// first request after signing in, so the merge doesn't wait for checkout or for the
+// page's script. A failed merge is logged and retried on the next request rather
+// than failing this one.
+func (s *Service) mergeCartOnLogin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			merged, _ := c.Cookie(cartMergedCookie)
+			user, ok := auth.GetDBUser(c)
+			if !ok {
+				if merged != nil {
+					c.SetCookie(cartMergeCookie(cartMergedCookie, "", -1))
+				}
+				return next(c)
+			}
+			if merged != nil && merged.Value == user.ID {
+				return next(c)
+			}
+
+			// Only an existing session can hold a guest cart, so don't start a new one here
+			cookie, err := c.Cookie("session_id")
+			if err != nil || cookie.Value == "" {
+				c.SetCookie(cartMergeCookie(cartMergedCookie, user.ID, 0))
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			pending, err := s.storage.Queries.CountGuestCartRows(ctx, sql.NullString{String: cookie.Value, Valid: true})
+			if err != nil {
+				slog.Error("failed to check for a guest cart", "error", err, "user_id", user.ID)
+				return next(c)
+			}
+			if pending == 0 {
+				c.SetCookie(cartMergeCookie(cartMergedCookie, user.ID, 0))
+				return next(c)
+			}
+
+			summary, err := s.mergeGuestCart(ctx, cookie.Value, user.ID)
+			if err != nil {
+				slog.Error("failed to merge guest cart", "error", err, "user_id", user.ID)
+				return next(c)
+			}
+			c.SetCookie(cartMergeCookie(cartMergedCookie, user.ID, 0))
+			c.Set(cartMergeKey, summary)
+			if summary.Lines() > 0 {
+				s.invalidateShipping(c, cookie.Value)
+				c.SetCookie(cartMergeCookie(cartMergeNoticeCookie, url.PathEscape(summary.Message()), 300))
+			}
+			return next(c)
+		}
+	}
+}
+
+// cartMergeCookie builds one of the merge's cookies. A maxAge of 0 lasts the browser
+// session and a negative one deletes the cookie.
+func cartMergeCookie(name, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// mergeGuestCart moves the lines a customer added before signing in onto their
+// account. A line matching one already in their cart (same product, SKU and
+// personalization) is folded into it, capping the combined quantity at available
+// stock; other lines are capped at stock and transfer, as do lines saved for later
+// and shared carts. Lines for products no longer sold are deleted.
+func (s *Service) mergeGuestCart(ctx context.Context, sessionID, userID string) (utils.CartMergeSummary, error) {
+	var summary utils.CartMergeSummary
+	tx, err := s.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return summary, fmt.Errorf("failed to begin cart merge: %w", err)
+	}
+	defer tx.Rollback()
+	queries := s.storage.Queries.WithTx(tx)
+
+	guestItems, err := queries.ListGuestCartItems(ctx, sql.NullString{String: sessionID, Valid: true})
+	if err != nil {
+		return summary, fmt.Errorf("failed to list guest cart items: %w", err)
+	}
+
+	for _, item := range guestItems {
+		// Bundle lines belong to their bundle group, so they keep their own rows
+		if item.BundleGroupID.Valid {
+			summary.Moved++
+			continue
+		}
+		if err := mergeGuestCartItem(ctx, queries, item, userID, &summary); err != nil {
+			return summary, err
+		}
+	}
+
+	if err := queries.TransferCartToUser(ctx, db.TransferCartToUserParams{
+		UserID:    sql.NullString{String: userID, Valid: true},
+		SessionID: sql.NullString{String: sessionID, Valid: true},
+	}); err != nil {
+		return summary, fmt.Errorf("failed to transfer cart to user: %w", err)
+	}
+	if err := queries.TransferSavedCartItemsToUser(ctx, db.TransferSavedCartItemsToUserParams{
+		UserID:    sql.NullString{String: userID, Valid: true},
+		SessionID: sql.NullString{String: sessionID, Valid: true},
+	}); err != nil {
+		return summary, fmt.Errorf("failed to transfer saved items to user: %w", err)
+	}
+	if err := queries.TransferSharedCartsToUser(ctx, db.TransferSharedCartsToUserParams{
+		UserID:    sql.NullString{String: userID, Valid: true},
+		SessionID: sql.NullString{String: sessionID, Valid: true},
+	}); err != nil {
+		return summary, fmt.Errorf("failed to transfer shared carts to user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return summary, fmt.Errorf("failed to commit cart merge: %w", err)
+	}
+	if len(guestItems) > 0 {
+		slog.Info("merged guest cart into user cart",
+			"user_id", userID,
+			"lines", len(guestItems),
+			"combined_lines", summary.Combined,
+			"reduced_lines", summary.Reduced,
+			"removed_lines", len(summary.Removed))
+	}
+	return summary, nil
+}
+
+// mergeGuestCartItem settles one guest line before the rest transfer: it's dropped if
+// the product is gone, otherwise folded into the matching account line if there is
+// one, and the line that stays is capped at stock the same way either way
+func mergeGuestCartItem(ctx context.Context, queries *db.Queries, item db.CartItem, userID string, summary *utils.CartMergeSummary) error {
+	product, sku, onSale, err := guestItemProduct(ctx, queries, item)
+	if err != nil {
+		return err
+	}
+	if !onSale {
+		if err := queries.RemoveCartItem(ctx, item.ID); err != nil {
+			return fmt.Errorf("failed to remove stale guest cart item: %w", err)
+		}
+		if product != nil {
+			summary.Removed = append(summary.Removed, product.Name)
+		}
+		return nil
+	}
+
+	existing, err := queries.GetExistingCartItem(ctx, db.GetExistingCartItemParams{
+		UserID:          sql.NullString{String: userID, Valid: true},
+		ProductID:       item.ProductID,
+		ProductSkuID:    item.ProductSkuID,
+		Personalization: item.Personalization,
+	})
+	combined := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to find matching cart item: %w", err)
+	}
+
+	// The line that stays is the account's matching line, which absorbs the guest
+	// line, or else the guest line itself
+	lineID, lineQuantity := item.ID, item.Quantity
+	var existingQuantity int64
+	if combined {
+		lineID, lineQuantity = existing.ID, existing.Quantity
+		existingQuantity = existing.Quantity
+		if err := queries.RemoveCartItem(ctx, item.ID); err != nil {
+			return fmt.Errorf("failed to remove merged guest cart item: %w", err)
+		}
+	}
+
+	stock := itemStockQuantity(*product, sku)
+	stockLimited := !product.AllowBackorder && !product.IsPreorder && product.ProductType != utils.ProductTypeDigital
+	quantity := utils.MergedCartQuantity(existingQuantity, item.Quantity, stock, stockLimited)
+	if quantity == 0 {
+		if err := queries.RemoveCartItem(ctx, lineID); err != nil {
+			return fmt.Errorf("failed to remove sold out cart item: %w", err)
+		}
+		summary.Removed = append(summary.Removed, product.Name)
+		return nil
+	}
+	if quantity != lineQuantity {
+		if err := queries.UpdateCartItemQuantity(ctx, db.UpdateCartItemQuantityParams{
+			ID:       lineID,
+			Quantity: quantity,
+		}); err != nil {
+			return fmt.Errorf("failed to update merged cart item: %w", err)
+		}
+	}
+
+	if combined {
+		summary.Combined++
+	} else {
+		summary.Moved++
+	}
+	if quantity < existingQuantity+item.Quantity {
+		summary.Reduced++
+	}
+	return nil
+}
+
+// guestItemProduct loads a guest line's product and SKU, and whether they're still
+// on sale. The product is nil when it has been deleted since the line was added.
+func guestItemProduct(ctx context.Context, queries *db.Queries, item db.CartItem) (*db.Product, *db.ProductSku, bool, error) {
+	product, err := queries.GetProduct(ctx, item.ProductID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, false, nil
+	}
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to load product for cart merge: %w", err)
+	}
+	onSale := !product.IsActive.Valid || product.IsActive.Bool
+	if !item.ProductSkuID.Valid {
+		return &product, nil, onSale, nil
+	}
+
+	sku, err := queries.GetProductSku(ctx, item.ProductSkuID.String)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &product, nil, false, nil
+	}
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to load SKU for cart merge: %w", err)
+	}
+	onSale = onSale && (!sku.IsActive.Valid || sku.IsActive.Bool)
+	return &product, &sku, onSale, nil
+}
+
+// handleMergeCart responds with the signed-in customer's cart after the guest cart
+// has been folded in, so a page that signed in without reloading can refresh its
+// cart badge. mergeCartOnLogin has already done the merge by the time this runs; the
+// X-Cart-Merged header tells the page whether anything moved, and X-Cart-Merge-Notice
+// carries the summary to show, from this request's merge or an earlier page load's.
+func (s *Service) handleMergeCart(c echo.Context) error {
+	if _, ok := auth.GetDBUser(c); !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+
+	notice := ""
+	if summary, ok := c.Get(cartMergeKey).(utils.CartMergeSummary); ok && summary.Lines() > 0 {
+		c.Response().Header().Set("X-Cart-Merged", "true")
+		notice = summary.Message()
+	} else if cookie, err := c.Cookie(cartMergeNoticeCookie); err == nil {
+		notice, _ = url.PathUnescape(cookie.Value)
+	}
+	if notice != "" {
+		c.Response().Header().Set("X-Cart-Merge-Notice", url.PathEscape(notice))
+		c.SetCookie(cartMergeCookie(cartMergeNoticeCookie, "", -1))
+	}
+
+	return s.handleGetCart(c)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/handlers"
+)
+
+func TestMergeCartOnLoginMarker(t *testing.T) {
+	svc := setupTestService(t)
+	user, err := handlers.CreateTestUser(svc.storage.Queries)
+	require.NoError(t, err)
+
+	// run sends one request through the middleware and returns the cookies it set
+	run := func(signedIn bool, cookies ...*http.Cookie) map[string]*http.Cookie {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		if signedIn {
+			c.Set(auth.DBUserKey, user)
+		}
+		handler := svc.mergeCartOnLogin()(func(c echo.Context) error { return nil })
+		require.NoError(t, handler(c))
+
+		set := make(map[string]*http.Cookie)
+		for _, cookie := range rec.Result().Cookies() {
+			set[cookie.Name] = cookie
+		}
+		return set
+	}
+
+	// With no guest cart to merge, the first signed-in request marks the browser
+	set := run(true, &http.Cookie{Name: "session_id", Value: "empty-session"})
+	if assert.Contains(t, set, cartMergedCookie) {
+		assert.Equal(t, user.ID, set[cartMergedCookie].Value)
+		assert.True(t, set[cartMergedCookie].HttpOnly)
+	}
+
+	// Marked requests skip the check entirely
+	marker := &http.Cookie{Name: cartMergedCookie, Value: user.ID}
+	assert.Empty(t, run(true, marker, &http.Cookie{Name: "session_id", Value: "empty-session"}))
+
+	// Another customer signing in on the same browser is checked again
+	set = run(true, &http.Cookie{Name: cartMergedCookie, Value: "someone-else"})
+	assert.Equal(t, user.ID, set[cartMergedCookie].Value)
+
+	// Signing out clears the marker for the next sign-in
+	set = run(false, marker)
+	if assert.Contains(t, set, cartMergedCookie) {
+		assert.Negative(t, set[cartMergedCookie].MaxAge)
+	}
+	assert.Empty(t, run(false))
+}
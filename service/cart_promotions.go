@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/api"
+	"github.com/loganlanou/logans3d-v4/internal/cartpromos"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// cartPromotionLines prices the cart lines the way checkout charges them before
+// promotions: bundle lines at their share of the bundle, others at any quantity break.
+// Gift cards are sold at face value and left out.
+func cartPromotionLines(rows []db.GetCartByUserRow, volumePrices map[string]volumePrice) []cartpromos.Line {
+	lines := make([]cartpromos.Line, 0, len(rows))
+	for _, row := range rows {
+		if row.IsGiftCard {
+			continue
+		}
+		unitPrice := row.PriceCents
+		if vp, ok := volumePrices[row.ID]; ok && row.BundleID == "" && vp.UnitPriceCents < unitPrice {
+			unitPrice = vp.UnitPriceCents
+		}
+		lines = append(lines, cartpromos.Line{
+			ItemID:         row.ID,
+			CategoryID:     row.CategoryID,
+			Quantity:       row.Quantity,
+			UnitPriceCents: unitPrice,
+			Bundle:         row.BundleID != "",
+		})
+	}
+	return lines
+}
+
+// cartPromotions applies the live cart promotions. The cart goes without them if they
+// can't be loaded.
+func (s *Service) cartPromotions(ctx context.Context, lines []cartpromos.Line) cartpromos.Result {
+	if len(lines) == 0 {
+		return cartpromos.Evaluate(nil, nil, time.Now())
+	}
+
+	promos, err := s.storage.Queries.ListActiveCartPromotions(ctx)
+	if err != nil {
+		slog.Error("failed to load cart promotions", "error", err)
+		promos = nil
+	}
+	return cartpromos.Evaluate(promos, lines, time.Now())
+}
+
+// apiPromotions converts promotion progress for the cart JSON
+func apiPromotions(progress []cartpromos.Progress) []api.Promotion {
+	promotions := make([]api.Promotion, 0, len(progress))
+	for _, p := range progress {
+		promotions = append(promotions, api.Promotion(p))
+	}
+	return promotions
+}
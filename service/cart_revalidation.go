@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// cartChangedMessage heads the list of changes checkout refuses with
+const cartChangedMessage = "Some items in your cart have changed since you added them. Update your cart to continue."
+
+// itemPriceCents is the regular unit price of a product or one of its SKUs, before
+// quantity breaks and promotions
+func itemPriceCents(product db.Product, sku *db.ProductSku) int64 {
+	if sku != nil {
+		return product.PriceCents + int64FromNull(sku.PriceAdjustmentCents)
+	}
+	return product.PriceCents
+}
+
+// cartFix is how refreshing the cart settles a change
+type cartFix int
+
+const (
+	fixRequote  cartFix = iota // accept the new price
+	fixQuantity                // lower the quantity to what's left
+	fixRemove                  // take the line out, or its whole bundle
+)
+
+// cartChange is a cart line that's different from when it was added
+type cartChange struct {
+	ItemID   string
+	Message  string
+	Fix      cartFix
+	Quantity int64 // what's left, for fixQuantity
+	Price    int64 // the current unit price, for fixRequote
+}
+
+// cartLineChange compares a cart line with its product and SKU as they are now.
+// product is nil when the product has been deleted, and sku nil when the line has no
+// variant or the variant has been deleted.
+func cartLineChange(row db.GetCartByUserRow, product *db.Product, sku *db.ProductSku) (cartChange, bool) {
+	name := row.Name
+	if row.VariantName != "" {
+		name = fmt.Sprintf("%s (%s)", row.Name, row.VariantName)
+	}
+	change := cartChange{ItemID: row.ID, Fix: fixRemove}
+	bundle := row.BundleID != ""
+
+	if product == nil || (product.IsActive.Valid && !product.IsActive.Bool) {
+		change.Message = fmt.Sprintf("%s is no longer available.", name)
+		return change, true
+	}
+	if row.ProductSkuID.Valid && row.ProductSkuID.String != "" && (sku == nil || (sku.IsActive.Valid && !sku.IsActive.Bool)) {
+		change.Message = fmt.Sprintf("%s is no longer available.", name)
+		return change, true
+	}
+
+	// Only products that can't be backordered are held to what's in stock
+	if !product.AllowBackorder && !product.IsPreorder && product.ProductType != utils.ProductTypeDigital {
+		available := itemStockQuantity(*product, sku)
+		switch {
+		case available <= 0:
+			change.Message = fmt.Sprintf("%s is sold out.", name)
+			return change, true
+		case row.Quantity > available && bundle:
+			// A bundle is sold as a set, so it can't be cut down to what's left
+			change.Message = fmt.Sprintf("Only %d of %s are left, not enough for the %s bundle.", available, name, row.BundleName)
+			return change, true
+		case row.Quantity > available:
+			change.Fix = fixQuantity
+			change.Quantity = available
+			change.Message = fmt.Sprintf("Only %d of %s are left, so your cart will have %d instead of %d.", available, name, available, row.Quantity)
+			return change, true
+		}
+	}
+
+	// Bundle lines keep the price they were added at
+	price := itemPriceCents(*product, sku)
+	if !bundle && row.QuotedPriceCents.Valid && row.QuotedPriceCents.Int64 != price {
+		change.Fix = fixRequote
+		change.Price = price
+		change.Message = fmt.Sprintf("%s is now $%.2f each (was $%.2f).", name, float64(price)/100, float64(row.QuotedPriceCents.Int64)/100)
+		return change, true
+	}
+	return cartChange{}, false
+}
+
+// cartChanges checks every line of a cart against its product as it is now
+func (s *Service) cartChanges(ctx context.Context, rows []db.GetCartByUserRow) ([]cartChange, error) {
+	products := make(map[string]*db.Product)
+	var changes []cartChange
+	for _, row := range rows {
+		product, ok := products[row.ProductID]
+		if !ok {
+			record, err := s.storage.Queries.GetProduct(ctx, row.ProductID)
+			switch {
+			case err == nil:
+				product = &record
+			case !errors.Is(err, sql.ErrNoRows):
+				return nil, fmt.Errorf("failed to get product %s: %w", row.ProductID, err)
+			}
+			products[row.ProductID] = product
+		}
+
+		var sku *db.ProductSku
+		if product != nil && row.ProductSkuID.Valid && row.ProductSkuID.String != "" {
+			record, err := s.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+				ID:        row.ProductSkuID.String,
+				ProductID: row.ProductID,
+			})
+			switch {
+			case err == nil:
+				sku = &record
+			case !errors.Is(err, sql.ErrNoRows):
+				return nil, fmt.Errorf("failed to get SKU %s: %w", row.ProductSkuID.String, err)
+			}
+		}
+
+		if change, ok := cartLineChange(row, product, sku); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func cartChangeMessages(changes []cartChange) []string {
+	messages := make([]string, 0, len(changes))
+	for _, change := range changes {
+		messages = append(messages, change.Message)
+	}
+	return messages
+}
+
+// ownerCartRows returns the lines in a shopper's cart
+func (s *Service) ownerCartRows(ctx context.Context, owner cartOwner) ([]db.GetCartByUserRow, error) {
+	if owner.UserID != "" {
+		return s.storage.Queries.GetCartByUser(ctx, owner.userParam())
+	}
+	sessionRows, err := s.storage.Queries.GetCartBySession(ctx, owner.sessionParam())
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]db.GetCartByUserRow, 0, len(sessionRows))
+	for _, row := range sessionRows {
+		rows = append(rows, db.GetCartByUserRow(row))
+	}
+	return rows, nil
+}
+
+// handleRefreshCart brings the cart in line with current prices and stock after
+// checkout found changes: new prices are accepted, quantities lowered to what's left
+// and unavailable lines removed. The response lists what changed.
+func (s *Service) handleRefreshCart(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Request().Context()
+
+	rows, err := s.ownerCartRows(ctx, owner)
+	if err != nil {
+		slog.Error("failed to get cart for refresh", "error", err, "user_id", owner.UserID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update cart")
+	}
+	changes, err := s.cartChanges(ctx, rows)
+	if err != nil {
+		slog.Error("failed to check cart for changes", "error", err, "user_id", owner.UserID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update cart")
+	}
+
+	var parcelChanged bool
+	for _, change := range changes {
+		switch change.Fix {
+		case fixRequote:
+			err = s.storage.Queries.QuoteCartItemPrice(ctx, db.QuoteCartItemPriceParams{
+				QuotedPriceCents: sql.NullInt64{Int64: change.Price, Valid: true},
+				ID:               change.ItemID,
+			})
+		case fixQuantity:
+			parcelChanged = true
+			err = s.storage.Queries.UpdateCartItemQuantity(ctx, db.UpdateCartItemQuantityParams{
+				ID:       change.ItemID,
+				Quantity: change.Quantity,
+			})
+		case fixRemove:
+			parcelChanged = true
+			err = s.removeCartItem(ctx, change.ItemID)
+		}
+		if err != nil {
+			slog.Error("failed to refresh cart line", "error", err, "item_id", change.ItemID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update cart")
+		}
+	}
+	if parcelChanged {
+		s.invalidateShipping(c, owner.SessionID)
+	}
+
+	slog.Info("cart refreshed", "user_id", owner.UserID, "changes", len(changes))
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Your cart now has current prices and stock",
+		"changes": cartChangeMessages(changes),
+	})
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestCartLineChange(t *testing.T) {
+	stocked := db.Product{
+		ID:            "dragon",
+		Name:          "Crystal Dragon",
+		PriceCents:    2500,
+		StockQuantity: sql.NullInt64{Int64: 4, Valid: true},
+		IsActive:      sql.NullBool{Bool: true, Valid: true},
+	}
+	line := db.GetCartByUserRow{
+		ID:               "line-1",
+		ProductID:        "dragon",
+		Name:             "Crystal Dragon",
+		Quantity:         2,
+		QuotedPriceCents: sql.NullInt64{Int64: 2500, Valid: true},
+	}
+
+	_, changed := cartLineChange(line, &stocked, nil)
+	assert.False(t, changed, "nothing has changed")
+
+	change, changed := cartLineChange(line, nil, nil)
+	assert.True(t, changed)
+	assert.Equal(t, cartChange{ItemID: "line-1", Fix: fixRemove, Message: "Crystal Dragon is no longer available."}, change, "deleted product")
+
+	inactive := stocked
+	inactive.IsActive = sql.NullBool{Bool: false, Valid: true}
+	change, _ = cartLineChange(line, &inactive, nil)
+	assert.Equal(t, fixRemove, change.Fix, "inactive product")
+
+	variant := line
+	variant.ProductSkuID = sql.NullString{String: "sku-1", Valid: true}
+	variant.VariantName = "Blue - Large"
+	change, _ = cartLineChange(variant, &stocked, nil)
+	assert.Equal(t, "Crystal Dragon (Blue - Large) is no longer available.", change.Message, "deleted SKU")
+
+	soldOut := stocked
+	soldOut.AllowBackorder = false
+	soldOut.StockQuantity = sql.NullInt64{Int64: 0, Valid: true}
+	change, _ = cartLineChange(line, &soldOut, nil)
+	assert.Equal(t, cartChange{ItemID: "line-1", Fix: fixRemove, Message: "Crystal Dragon is sold out."}, change)
+
+	soldOut.AllowBackorder = true
+	_, changed = cartLineChange(line, &soldOut, nil)
+	assert.False(t, changed, "backordered items aren't held to stock")
+
+	short := stocked
+	short.AllowBackorder = false
+	short.StockQuantity = sql.NullInt64{Int64: 1, Valid: true}
+	change, _ = cartLineChange(line, &short, nil)
+	assert.Equal(t, cartChange{
+		ItemID:   "line-1",
+		Fix:      fixQuantity,
+		Quantity: 1,
+		Message:  "Only 1 of Crystal Dragon are left, so your cart will have 1 instead of 2.",
+	}, change)
+
+	bundled := line
+	bundled.BundleID = "starter"
+	bundled.BundleName = "Starter Set"
+	change, _ = cartLineChange(bundled, &short, nil)
+	assert.Equal(t, fixRemove, change.Fix, "bundles can't be cut down")
+	assert.Equal(t, "Only 1 of Crystal Dragon are left, not enough for the Starter Set bundle.", change.Message)
+
+	repriced := stocked
+	repriced.PriceCents = 2800
+	change, _ = cartLineChange(line, &repriced, nil)
+	assert.Equal(t, cartChange{
+		ItemID:  "line-1",
+		Fix:     fixRequote,
+		Price:   2800,
+		Message: "Crystal Dragon is now $28.00 each (was $25.00).",
+	}, change)
+
+	_, changed = cartLineChange(bundled, &repriced, nil)
+	assert.False(t, changed, "bundle lines keep their price")
+
+	unquoted := line
+	unquoted.QuotedPriceCents = sql.NullInt64{}
+	_, changed = cartLineChange(unquoted, &repriced, nil)
+	assert.False(t, changed, "lines added before quotes were kept")
+
+	sku := db.ProductSku{
+		ID:                   "sku-1",
+		PriceAdjustmentCents: sql.NullInt64{Int64: 500, Valid: true},
+		StockQuantity:        sql.NullInt64{Int64: 3, Valid: true},
+		IsActive:             sql.NullBool{Bool: true, Valid: true},
+	}
+	change, _ = cartLineChange(variant, &stocked, &sku)
+	assert.Equal(t, int64(3000), change.Price, "SKU price adjustments count")
+}
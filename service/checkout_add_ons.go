@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/flags"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// maxCheckoutAddOns caps the offers on the add-ons step so it stays a quick look
+// rather than another shop page
+const maxCheckoutAddOns = 3
+
+// checkoutAddOn is an offer as the cart's checkout button sees it
+type checkoutAddOn struct {
+	RuleID     string `json:"rule_id"`
+	ProductID  string `json:"product_id"`
+	Name       string `json:"name"`
+	PriceCents int64  `json:"price_cents"`
+}
+
+// chooseCheckoutAddOns picks the offers for a cart from the candidate rules, which come
+// highest priority first. A rule applies when its category is in the cart or it has
+// none; each product is offered once and never when it's already in the cart.
+func chooseCheckoutAddOns(candidates []db.ListCheckoutAddOnOffersRow, cartCategories, cartProducts map[string]bool) []db.ListCheckoutAddOnOffersRow {
+	var offers []db.ListCheckoutAddOnOffersRow
+	offered := make(map[string]bool)
+	for _, offer := range candidates {
+		if len(offers) == maxCheckoutAddOns {
+			break
+		}
+		if offer.CategoryID != "" && !cartCategories[offer.CategoryID] {
+			continue
+		}
+		if cartProducts[offer.ProductID] || offered[offer.ProductID] {
+			continue
+		}
+		offered[offer.ProductID] = true
+		offers = append(offers, offer)
+	}
+	return offers
+}
+
+// checkoutAddOns loads the signed-in shopper's cart and the add-ons offered with it.
+// It's empty when the step is switched off, so callers go straight to Stripe.
+func (s *Service) checkoutAddOns(c echo.Context, userID string) []db.ListCheckoutAddOnOffersRow {
+	ctx := c.Request().Context()
+	if !flags.Enabled(ctx, flags.CheckoutAddOns) {
+		return nil
+	}
+
+	cartItems, err := s.storage.Queries.GetCartByUser(ctx, sql.NullString{String: userID, Valid: true})
+	if err != nil {
+		slog.Error("failed to get cart for checkout add-ons", "error", err, "user_id", userID)
+		return nil
+	}
+	if len(cartItems) == 0 {
+		return nil
+	}
+
+	candidates, err := s.storage.Queries.ListCheckoutAddOnOffers(ctx)
+	if err != nil {
+		slog.Error("failed to list checkout add-ons", "error", err)
+		return nil
+	}
+
+	cartCategories := make(map[string]bool)
+	cartProducts := make(map[string]bool)
+	for _, item := range cartItems {
+		if item.CategoryID != "" {
+			cartCategories[item.CategoryID] = true
+		}
+		cartProducts[item.ProductID] = true
+	}
+	return chooseCheckoutAddOns(candidates, cartCategories, cartProducts)
+}
+
+// handleCheckoutAddOnsAPI tells the cart's checkout button whether there are add-ons to
+// offer before Stripe
+func (s *Service) handleCheckoutAddOnsAPI(c echo.Context) error {
+	offers := []checkoutAddOn{}
+	if user, ok := auth.GetDBUser(c); ok {
+		for _, offer := range s.checkoutAddOns(c, user.ID) {
+			offers = append(offers, checkoutAddOn{
+				RuleID:     offer.RuleID,
+				ProductID:  offer.ProductID,
+				Name:       offer.Name,
+				PriceCents: offer.PriceCents,
+			})
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"offers": offers})
+}
+
+// handleCheckoutAddOns shows the add-ons step. With nothing to offer it sends the
+// shopper back to the cart, whose checkout button goes straight to Stripe.
+func (s *Service) handleCheckoutAddOns(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/cart")
+	}
+
+	offers := s.checkoutAddOns(c, user.ID)
+	if len(offers) == 0 {
+		return c.Redirect(http.StatusFound, "/cart")
+	}
+
+	ctx := c.Request().Context()
+	ruleIDs := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		ruleIDs = append(ruleIDs, offer.RuleID)
+	}
+	if err := s.storage.Queries.RecordCheckoutAddOnsShown(ctx, ruleIDs); err != nil {
+		slog.Error("failed to record checkout add-ons shown", "error", err)
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Anything Else? - Logan's 3D Creations"
+	meta.Description = "Add a finishing touch to your order before checkout"
+
+	return Render(c, shop.CheckoutAddOns(c, meta, offers))
+}
+
+// handleAddCheckoutAddOn puts one of the offered add-ons in the cart
+func (s *Service) handleAddCheckoutAddOn(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	ruleID := c.Param("ruleId")
+
+	// Only an offer this cart was shown can be added, at its regular price
+	var offer *db.ListCheckoutAddOnOffersRow
+	for _, o := range s.checkoutAddOns(c, user.ID) {
+		if o.RuleID == ruleID {
+			offer = &o
+			break
+		}
+	}
+	if offer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "This add-on is no longer available")
+	}
+
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+	placement, err := s.placeInCart(c, owner, cartLine{ProductID: offer.ProductID, Quantity: 1})
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := placement.apply(ctx, s.storage.Queries, owner); err != nil {
+		slog.Error("failed to add checkout add-on to cart", "error", err, "rule_id", ruleID, "product_id", offer.ProductID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add item to cart")
+	}
+	s.invalidateShipping(c, owner.SessionID)
+	s.recordCheckoutAddOnAdded(ctx, ruleID)
+
+	slog.Info("checkout add-on added", "rule_id", ruleID, "product_id", offer.ProductID, "user_id", user.ID)
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": offer.Name + " added to your order",
+	})
+}
+
+func (s *Service) recordCheckoutAddOnAdded(ctx context.Context, ruleID string) {
+	if err := s.storage.Queries.RecordCheckoutAddOnAdded(ctx, ruleID); err != nil {
+		slog.Error("failed to record checkout add-on added", "error", err, "rule_id", ruleID)
+	}
+}
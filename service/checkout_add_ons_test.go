@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func addOnOffer(ruleID, categoryID, productID string) db.ListCheckoutAddOnOffersRow {
+	return db.ListCheckoutAddOnOffersRow{RuleID: ruleID, CategoryID: categoryID, ProductID: productID}
+}
+
+func addOnRuleIDs(offers []db.ListCheckoutAddOnOffersRow) []string {
+	ids := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		ids = append(ids, offer.RuleID)
+	}
+	return ids
+}
+
+func TestChooseCheckoutAddOns(t *testing.T) {
+	cartCategories := map[string]bool{"dinosaurs": true}
+	cartProducts := map[string]bool{"trex": true}
+
+	t.Run("rules for categories in the cart and for any cart", func(t *testing.T) {
+		offers := chooseCheckoutAddOns([]db.ListCheckoutAddOnOffersRow{
+			addOnOffer("r1", "dinosaurs", "stand"),
+			addOnOffer("r2", "dragons", "dragon-kit"),
+			addOnOffer("r3", "", "care-kit"),
+		}, cartCategories, cartProducts)
+		assert.Equal(t, []string{"r1", "r3"}, addOnRuleIDs(offers))
+	})
+
+	t.Run("skips products already in the cart or already offered", func(t *testing.T) {
+		offers := chooseCheckoutAddOns([]db.ListCheckoutAddOnOffersRow{
+			addOnOffer("r1", "dinosaurs", "trex"),
+			addOnOffer("r2", "dinosaurs", "stand"),
+			addOnOffer("r3", "", "stand"),
+		}, cartCategories, cartProducts)
+		assert.Equal(t, []string{"r2"}, addOnRuleIDs(offers))
+	})
+
+	t.Run("keeps the highest priority offers up to the limit", func(t *testing.T) {
+		offers := chooseCheckoutAddOns([]db.ListCheckoutAddOnOffersRow{
+			addOnOffer("r1", "", "a"),
+			addOnOffer("r2", "", "b"),
+			addOnOffer("r3", "", "c"),
+			addOnOffer("r4", "", "d"),
+		}, cartCategories, cartProducts)
+		assert.Equal(t, []string{"r1", "r2", "r3"}, addOnRuleIDs(offers))
+	})
+
+	t.Run("nothing applies", func(t *testing.T) {
+		offers := chooseCheckoutAddOns([]db.ListCheckoutAddOnOffersRow{
+			addOnOffer("r1", "dragons", "dragon-kit"),
+		}, cartCategories, cartProducts)
+		assert.Empty(t, offers)
+	})
+}
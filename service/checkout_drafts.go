@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/flags"
+	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	stripeutil "github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/account"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// checkoutDraftInput is what the embedded checkout needs beyond the Checkout Session
+// parameters the cart was turned into
+type checkoutDraftInput struct {
+	Owner cartOwner
+	// Email is a signed-in customer's; guests enter theirs on the payment page
+	Email    string
+	Shipping db.SessionShippingSelection
+	// DiscountCents is what comes off the lines before tax: the promo code and store
+	// credit together
+	DiscountCents int64
+	// GiftCardCents is what a gift card pays, after tax
+	GiftCardCents int64
+	// Tax is the calculation already made to size the gift card; without one it's
+	// worked out here
+	Tax       *stripe.TaxCalculation
+	ExpiresAt time.Time
+}
+
+// createCheckoutDraft saves a cart's checkout for payment on our own page. The lines
+// and metadata are the ones built for Checkout; the discount comes off them here, Stripe
+// Tax works out the tax on what's left, any gift card pays its share of the total and a
+// PaymentIntent is made for the rest. It returns the draft's ID, which the checkout's
+// holds are linked to.
+func (s *Service) createCheckoutDraft(ctx context.Context, params *stripe.CheckoutSessionParams, in checkoutDraftInput) (string, error) {
+	var address shipping.Address
+	if err := json.Unmarshal([]byte(in.Shipping.ShippingAddressJson), &address); err != nil {
+		return "", fmt.Errorf("read shipping address: %w", err)
+	}
+
+	draftID := stripeutil.NewCheckoutDraftID()
+	lines := stripeutil.DraftLines(params.LineItems)
+	amounts := stripeutil.AllocateDiscount(lines, in.DiscountCents)
+	var listCents, dueCents int64
+	for i, line := range lines {
+		listCents += line.AmountCents()
+		dueCents += amounts[i]
+	}
+
+	calc := in.Tax
+	var err error
+	if calc == nil {
+		if calc, err = stripeutil.CalculateDraftTax(draftID, lines, amounts, draftAddressParams(address)); err != nil {
+			return "", fmt.Errorf("calculate tax: %w", err)
+		}
+	}
+	totalCents := dueCents + calc.TaxAmountExclusive - in.GiftCardCents
+
+	// Signed-in customers pay with a Stripe customer of their own, so their cards can
+	// be saved and offered next time. Without one they can still pay.
+	var customerID string
+	if in.Owner.UserID != "" {
+		if customerID, err = s.stripeCustomerID(ctx, in.Owner.UserID); err != nil {
+			slog.ErrorContext(ctx, "failed to get stripe customer for checkout", "error", err, "user_id", in.Owner.UserID)
+		}
+	}
+
+	intent, err := stripeutil.CreateDraftPaymentIntent(stripeutil.DraftPaymentIntent{
+		DraftID:            draftID,
+		AmountCents:        totalCents,
+		CustomerID:         customerID,
+		Email:              in.Email,
+		Shipping:           draftShippingParams(address),
+		PaymentMethodTypes: params.PaymentMethodTypes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("create payment intent: %w", err)
+	}
+
+	linesJSON, err := json.Marshal(lines)
+	if err != nil {
+		return "", fmt.Errorf("encode lines: %w", err)
+	}
+	metadataJSON, err := json.Marshal(params.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("encode metadata: %w", err)
+	}
+	// The discount includes the gift card, which the webhook takes back out as it does
+	// from a Checkout coupon
+	if err := s.storage.Queries.CreateCheckoutDraft(ctx, db.CreateCheckoutDraftParams{
+		ID:                  draftID,
+		SessionID:           in.Owner.SessionID,
+		UserID:              in.Owner.UserID,
+		CustomerEmail:       in.Email,
+		ShippingAddressJson: in.Shipping.ShippingAddressJson,
+		LinesJson:           string(linesJSON),
+		MetadataJson:        string(metadataJSON),
+		AmountCents:         totalCents,
+		TaxCents:            calc.TaxAmountExclusive,
+		DiscountCents:       listCents - dueCents + in.GiftCardCents,
+		TaxCalculationID:    calc.ID,
+		StripeCustomerID:    customerID,
+		PaymentIntentID:     intent.ID,
+		ExpiresAt:           in.ExpiresAt,
+	}); err != nil {
+		return "", fmt.Errorf("save checkout draft: %w", err)
+	}
+
+	slog.InfoContext(ctx, "checkout draft created", "draft_id", draftID, "payment_intent_id", intent.ID, "amount_cents", totalCents, "user_id", in.Owner.UserID)
+	return draftID, nil
+}
+
+// stripeCustomerID is the Stripe customer a signed-in customer's cards are saved to,
+// made the first time it's needed
+func (s *Service) stripeCustomerID(ctx context.Context, userID string) (string, error) {
+	user, err := s.storage.Queries.GetUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.StripeCustomerID != "" {
+		return user.StripeCustomerID, nil
+	}
+
+	customer, err := stripeutil.CreateShopCustomer(user.ID, user.Email, user.FullName)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.Queries.SetUserStripeCustomerID(ctx, db.SetUserStripeCustomerIDParams{
+		StripeCustomerID: customer.ID,
+		ID:               user.ID,
+	}); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+// draftAddressParams is the shipping address Stripe Tax works the tax out for
+func draftAddressParams(address shipping.Address) *stripe.AddressParams {
+	return &stripe.AddressParams{
+		Line1:      stripe.String(address.AddressLine1),
+		Line2:      stripe.String(address.AddressLine2),
+		City:       stripe.String(address.CityLocality),
+		State:      stripe.String(address.StateProvince),
+		PostalCode: stripe.String(address.PostalCode),
+		Country:    stripe.String(draftCountry(address)),
+	}
+}
+
+// calculateCheckoutTax works out a checkout's tax before its session is made, on the
+// lines after discountCents, so a gift card can pay it too
+func calculateCheckoutTax(lineItems []*stripe.CheckoutSessionLineItemParams, discountCents int64, selection db.SessionShippingSelection) (*stripe.TaxCalculation, error) {
+	var address shipping.Address
+	if err := json.Unmarshal([]byte(selection.ShippingAddressJson), &address); err != nil {
+		return nil, fmt.Errorf("read shipping address: %w", err)
+	}
+	lines := stripeutil.DraftLines(lineItems)
+	amounts := stripeutil.AllocateDiscount(lines, discountCents)
+	return stripeutil.CalculateCheckoutTax(uuid.New().String(), lines, amounts, draftAddressParams(address))
+}
+
+// draftShippingParams puts the shipping address on the PaymentIntent, where Stripe's
+// fraud checks and Afterpay look for it
+func draftShippingParams(address shipping.Address) *stripe.ShippingDetailsParams {
+	name := address.Name
+	if name == "" {
+		name = "Customer"
+	}
+	shipTo := &stripe.ShippingDetailsParams{
+		Name:    stripe.String(name),
+		Address: draftAddressParams(address),
+	}
+	if address.Phone != "" {
+		shipTo.Phone = stripe.String(address.Phone)
+	}
+	return shipTo
+}
+
+// draftCountry is the address's country, which the cart only ships within the US
+func draftCountry(address shipping.Address) string {
+	if address.CountryCode == "" {
+		return "US"
+	}
+	return address.CountryCode
+}
+
+// checkoutDraftFor loads a draft for the shopper it belongs to: the browser that
+// started it, or the signed-in customer it was started for
+func (s *Service) checkoutDraftFor(c echo.Context) (db.CheckoutDraft, error) {
+	draft, err := s.storage.Queries.GetCheckoutDraft(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return draft, echo.NewHTTPError(http.StatusNotFound, "Checkout not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch checkout draft", "error", err, "draft_id", c.Param("id"))
+		return draft, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load checkout")
+	}
+
+	if user, ok := auth.GetDBUser(c); ok && draft.UserID != "" && user.ID == draft.UserID {
+		return draft, nil
+	}
+	if draft.UserID == "" && browserSessionID(c) != "" && browserSessionID(c) == draft.SessionID {
+		return draft, nil
+	}
+	return draft, echo.NewHTTPError(http.StatusNotFound, "Checkout not found")
+}
+
+// handleCheckoutPay is the embedded checkout's payment page: the order as it will be
+// charged, where it's going and the Payment Element
+func (s *Service) handleCheckoutPay(c echo.Context) error {
+	ctx := c.Request().Context()
+	draft, err := s.checkoutDraftFor(c)
+	if err != nil {
+		return err
+	}
+	switch draft.Status {
+	case "paid":
+		return c.Redirect(http.StatusSeeOther, "/checkout/pay/"+draft.ID+"/complete")
+	case "expired":
+		return c.Redirect(http.StatusSeeOther, "/cart")
+	}
+
+	intent, err := stripeutil.DraftPaymentStatus(draft.PaymentIntentID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch checkout draft payment intent", "error", err, "draft_id", draft.ID, "payment_intent_id", draft.PaymentIntentID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load checkout")
+	}
+
+	page, err := checkoutPayPage(draft)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read checkout draft", "error", err, "draft_id", draft.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load checkout")
+	}
+	page.PublishableKey = s.config.Stripe.PublishableKey
+	page.ClientSecret = intent.ClientSecret
+	page.Processing = intent.Status == stripe.PaymentIntentStatusProcessing
+	if c.QueryParam("failed") != "" {
+		page.Error = "Your payment didn't go through. Please try again or use a different payment method."
+	}
+	if draft.StripeCustomerID != "" {
+		if page.CustomerSessionSecret, err = stripeutil.CustomerSessionSecret(draft.StripeCustomerID); err != nil {
+			// The customer can still pay with a new card
+			slog.ErrorContext(ctx, "failed to create stripe customer session", "error", err, "draft_id", draft.ID)
+		}
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Payment - Logan's 3D Creations"
+	meta.Description = "Pay for your order"
+
+	return Render(c, shop.CheckoutPayPage(c, meta, page))
+}
+
+// checkoutPayPage lays out a draft for the payment page
+func checkoutPayPage(draft db.CheckoutDraft) (shop.CheckoutPay, error) {
+	page := shop.CheckoutPay{
+		DraftID:       draft.ID,
+		DiscountCents: draft.DiscountCents,
+		TaxCents:      draft.TaxCents,
+		TotalCents:    draft.AmountCents,
+		Email:         draft.CustomerEmail,
+		NeedsEmail:    draft.UserID == "",
+	}
+
+	var lines []stripeutil.DraftLine
+	if err := json.Unmarshal([]byte(draft.LinesJson), &lines); err != nil {
+		return page, fmt.Errorf("decode lines: %w", err)
+	}
+	for _, line := range lines {
+		page.Lines = append(page.Lines, shop.CheckoutPayLine{
+			Name:        line.Name,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			AmountCents: line.AmountCents(),
+		})
+	}
+
+	var address shipping.Address
+	if err := json.Unmarshal([]byte(draft.ShippingAddressJson), &address); err != nil {
+		return page, fmt.Errorf("decode shipping address: %w", err)
+	}
+	for _, part := range []string{
+		address.Name,
+		address.AddressLine1,
+		address.AddressLine2,
+		strings.TrimSpace(fmt.Sprintf("%s, %s %s", address.CityLocality, address.StateProvince, address.PostalCode)),
+	} {
+		if part != "" && part != "," {
+			page.ShipTo = append(page.ShipTo, part)
+		}
+	}
+	return page, nil
+}
+
+// handleCheckoutPayEmail records the email a guest entered on the payment page, where
+// their confirmation and Stripe's receipt go. It's sent just before paying.
+func (s *Service) handleCheckoutPayEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+	draft, err := s.checkoutDraftFor(c)
+	if err != nil {
+		return err
+	}
+	if draft.UserID != "" {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Please enter a valid email address")
+	}
+
+	rows, err := s.storage.Queries.SetCheckoutDraftEmail(ctx, db.SetCheckoutDraftEmailParams{
+		CustomerEmail: addr.Address,
+		ID:            draft.ID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to save checkout email", "error", err, "draft_id", draft.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save your email")
+	}
+	if rows == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "This checkout has closed. Please return to your cart.")
+	}
+	if err := stripeutil.SetDraftReceiptEmail(draft.PaymentIntentID, addr.Address); err != nil {
+		// The order confirmation still goes to the address saved above
+		slog.ErrorContext(ctx, "failed to set receipt email on payment intent", "error", err, "draft_id", draft.ID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// handleCheckoutPayComplete is where Stripe sends the customer back after paying. The
+// webhook makes the order; if it hasn't arrived yet the order is made here, as the
+// hosted checkout's success page does.
+func (s *Service) handleCheckoutPayComplete(c echo.Context) error {
+	ctx := c.Request().Context()
+	draft, err := s.checkoutDraftFor(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := s.storage.Queries.GetOrderByStripeSessionID(ctx, sql.NullString{String: draft.ID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		intent, intentErr := stripeutil.DraftPaymentStatus(draft.PaymentIntentID)
+		if intentErr != nil {
+			slog.ErrorContext(ctx, "failed to fetch checkout draft payment intent", "error", intentErr, "draft_id", draft.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to confirm your payment - please contact support")
+		}
+		switch intent.Status {
+		case stripe.PaymentIntentStatusSucceeded:
+		case stripe.PaymentIntentStatusProcessing:
+			return c.Redirect(http.StatusSeeOther, "/checkout/pay/"+draft.ID)
+		default:
+			return c.Redirect(http.StatusSeeOther, "/checkout/pay/"+draft.ID+"?failed=1")
+		}
+
+		slog.Info("order not found, creating from payment page return", "draft_id", draft.ID)
+		if createErr := s.paymentHandler.HandleCheckoutDraftPaid(c, draft.ID, intent); createErr != nil {
+			slog.Error("failed to create order from payment page return", "error", createErr, "draft_id", draft.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create order - please contact support")
+		}
+		order, err = s.storage.Queries.GetOrderByStripeSessionID(ctx, sql.NullString{String: draft.ID, Valid: true})
+	}
+	if err != nil {
+		slog.Error("failed to fetch order for checkout draft", "error", err, "draft_id", draft.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	orderURL := "/account/orders/" + order.ID + "?purchase=true"
+	if order.UserID == "" {
+		orderURL = guestOrderPath(draft.ID) + "?purchase=true"
+	}
+	return c.Redirect(http.StatusSeeOther, orderURL)
+}
+
+// handleAccountPaymentMethods lists the cards a customer saved at checkout, and lets
+// them add or remove one. It's part of the embedded checkout, so it follows its flag.
+func (s *Service) handleAccountPaymentMethods(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account/payment-methods")
+	}
+	if !flags.Enabled(ctx, flags.PaymentElementCheckout) {
+		return echo.NewHTTPError(http.StatusNotFound, "Page not found")
+	}
+
+	page := account.PaymentMethods{
+		PublishableKey: s.config.Stripe.PublishableKey,
+		Flash:          c.QueryParam("flash"),
+		Error:          c.QueryParam("error"),
+	}
+	if user.StripeCustomerID != "" {
+		cards, err := stripeutil.ListSavedCards(user.StripeCustomerID)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to list saved cards", "error", err, "user_id", user.ID)
+			page.Error = "We couldn't load your saved cards. Please try again."
+		}
+		for _, card := range cards {
+			if card.Card == nil {
+				continue
+			}
+			page.Cards = append(page.Cards, account.SavedCard{
+				ID:       card.ID,
+				Brand:    string(card.Card.Brand),
+				Last4:    card.Card.Last4,
+				ExpMonth: card.Card.ExpMonth,
+				ExpYear:  card.Card.ExpYear,
+			})
+		}
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Saved Cards - Logan's 3D Creations"
+	meta.Description = "Cards saved for checkout"
+
+	return Render(c, account.PaymentMethodsPage(c, meta, page))
+}
+
+// handleAccountPaymentMethodSetup starts adding a card from the account page. The
+// Payment Element confirms the SetupIntent and Stripe saves the card to the customer.
+func (s *Service) handleAccountPaymentMethodSetup(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	if !flags.Enabled(ctx, flags.PaymentElementCheckout) {
+		return echo.NewHTTPError(http.StatusNotFound, "Page not found")
+	}
+
+	customerID, err := s.stripeCustomerID(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get stripe customer to save a card", "error", err, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Unable to add a card right now")
+	}
+	intent, err := stripeutil.CreateCardSetupIntent(customerID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create setup intent", "error", err, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Unable to add a card right now")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"client_secret": intent.ClientSecret})
+}
+
+// handleAccountPaymentMethodRemove removes a saved card
+func (s *Service) handleAccountPaymentMethodRemove(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url=/account/payment-methods")
+	}
+	if user.StripeCustomerID == "" {
+		return c.Redirect(http.StatusSeeOther, "/account/payment-methods")
+	}
+
+	err := stripeutil.RemoveSavedCard(user.StripeCustomerID, c.Param("id"))
+	if errors.Is(err, stripeutil.ErrNotCustomerCard) {
+		return echo.NewHTTPError(http.StatusNotFound, "Card not found")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to remove saved card", "error", err, "user_id", user.ID, "payment_method_id", c.Param("id"))
+		return c.Redirect(http.StatusSeeOther, "/account/payment-methods?error=Could+not+remove+the+card")
+	}
+	slog.InfoContext(ctx, "saved card removed", "user_id", user.ID, "payment_method_id", c.Param("id"))
+	return c.Redirect(http.StatusSeeOther, "/account/payment-methods?flash=Card+removed")
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// handleCartRestore is the link in the "complete your order" email sent when a
+// checkout expires. The cart was never cleared, so it only needs the customer signed
+// in as themselves before sending them on to it. A guest's cart is brought to
+// whichever browser followed the link. Old or unknown links still land on the cart.
+func (s *Service) handleCartRestore(c echo.Context) error {
+	ctx := c.Request().Context()
+	token := c.Param("token")
+
+	expired, err := s.storage.Queries.GetExpiredCheckoutByToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Redirect(http.StatusFound, "/cart")
+	}
+	if err != nil {
+		slog.Error("failed to load expired checkout", "error", err)
+		return c.Redirect(http.StatusFound, "/cart")
+	}
+	if !expired.UserID.Valid {
+		return s.restoreGuestCart(c, expired)
+	}
+
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url="+url.QueryEscape("/cart/restore/"+token))
+	}
+	if user.ID != expired.UserID.String {
+		// Someone else's link; show whoever this is their own cart
+		return c.Redirect(http.StatusFound, "/cart")
+	}
+
+	if _, err := s.storage.Queries.MarkExpiredCheckoutsReturned(ctx, sql.NullString{String: user.ID, Valid: true}); err != nil {
+		slog.Error("failed to mark expired checkout returned", "error", err, "user_id", user.ID)
+	}
+	return c.Redirect(http.StatusFound, "/cart?restored=1")
+}
+
+// restoreGuestCart moves an expired guest checkout's cart to the browser that followed
+// the link, which may not be the one it was left in: onto the account if they've
+// signed in since, otherwise onto this browser's session
+func (s *Service) restoreGuestCart(c echo.Context, expired db.ExpiredCheckout) error {
+	ctx := c.Request().Context()
+	from := expired.SessionID.String
+
+	if user, ok := auth.GetDBUser(c); ok {
+		if _, err := s.mergeGuestCart(ctx, from, user.ID); err != nil {
+			slog.Error("failed to merge restored guest cart", "error", err, "user_id", user.ID)
+		}
+	} else {
+		sessionID, err := s.getOrCreateSessionID(c)
+		if err != nil {
+			slog.Error("failed to start a session for a restored guest cart", "error", err)
+			return c.Redirect(http.StatusFound, "/cart")
+		}
+		if sessionID != from {
+			if err := s.storage.Queries.TransferGuestCartToSession(ctx, db.TransferGuestCartToSessionParams{
+				ToSessionID:   sql.NullString{String: sessionID, Valid: true},
+				FromSessionID: sql.NullString{String: from, Valid: true},
+			}); err != nil {
+				slog.Error("failed to move restored guest cart", "error", err, "checkout_session_id", expired.ID)
+			}
+		}
+	}
+
+	if err := s.storage.Queries.MarkExpiredCheckoutReturned(ctx, expired.ID); err != nil {
+		slog.Error("failed to mark expired checkout returned", "error", err, "checkout_session_id", expired.ID)
+	}
+	return c.Redirect(http.StatusFound, "/cart?restored=1")
+}
+
+// cartRestored reports whether the cart page should say the cart was kept after an
+// expired checkout: either the customer came from the email, or this is their first
+// visit since a checkout of theirs expired
+func (s *Service) cartRestored(c echo.Context) bool {
+	if c.QueryParam("restored") == "1" {
+		return true
+	}
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return false
+	}
+	returned, err := s.storage.Queries.MarkExpiredCheckoutsReturned(c.Request().Context(), sql.NullString{String: user.ID, Valid: true})
+	if err != nil {
+		slog.Error("failed to mark expired checkouts returned", "error", err, "user_id", user.ID)
+		return false
+	}
+	return returned > 0
+}
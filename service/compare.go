@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// parseCompareIDs splits the ids query param, dropping blanks and duplicates and
+// keeping at most shop.MaxCompareProducts
+func parseCompareIDs(raw string) []string {
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, id := range strings.Split(raw, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) == shop.MaxCompareProducts {
+			break
+		}
+	}
+	return ids
+}
+
+// compareAttributeNames orders attribute names with the well-known specs first,
+// followed by everything else alphabetically
+func compareAttributeNames(products []shop.CompareProduct) []string {
+	names := []string{shop.AttributeMaterial, shop.AttributeDimensions}
+	seen := map[string]bool{shop.AttributeMaterial: true, shop.AttributeDimensions: true}
+
+	var extra []string
+	for _, p := range products {
+		for name := range p.Attributes {
+			if !seen[name] {
+				seen[name] = true
+				extra = append(extra, name)
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// loadCompareProduct gathers the comparison data for a single active product
+func (s *Service) loadCompareProduct(ctx context.Context, productID string) (shop.CompareProduct, bool) {
+	product, err := s.storage.Queries.GetProduct(ctx, productID)
+	if err != nil {
+		slog.Debug("compare product not found", "product_id", productID, "error", err)
+		return shop.CompareProduct{}, false
+	}
+	if product.IsActive.Valid && !product.IsActive.Bool {
+		return shop.CompareProduct{}, false
+	}
+
+	cp := shop.CompareProduct{
+		Product: shop.ProductWithImage{
+			Product:  product,
+			ImageURL: s.getProductImageURL(ctx, product),
+		},
+		MinPriceCents: product.PriceCents,
+		MaxPriceCents: product.PriceCents,
+		Attributes:    make(map[string]string),
+	}
+
+	if product.HasVariants.Valid && product.HasVariants.Bool {
+		sizes, err := s.storage.Queries.ListProductCompareSizes(ctx, product.ID)
+		if err != nil {
+			slog.Warn("failed to load sizes for comparison", "product_id", product.ID, "error", err)
+		}
+		for i, size := range sizes {
+			cp.Sizes = append(cp.Sizes, size.SizeName)
+			minPrice := product.PriceCents + size.MinAdjustmentCents
+			maxPrice := product.PriceCents + size.MaxAdjustmentCents
+			if i == 0 || minPrice < cp.MinPriceCents {
+				cp.MinPriceCents = minPrice
+			}
+			if i == 0 || maxPrice > cp.MaxPriceCents {
+				cp.MaxPriceCents = maxPrice
+			}
+		}
+	}
+
+	attributes, err := s.storage.Queries.ListProductAttributes(ctx, product.ID)
+	if err != nil {
+		slog.Warn("failed to load attributes for comparison", "product_id", product.ID, "error", err)
+	}
+	for _, attr := range attributes {
+		cp.Attributes[attr.Name] = utils.FormatAttributeValue(attr.ValueType, attr.Value, attr.Unit)
+	}
+
+	return cp, true
+}
+
+// handleCompare renders the side-by-side comparison for /shop/compare?ids=a,b,c
+func (s *Service) handleCompare(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var comparison shop.ProductComparison
+	for _, id := range parseCompareIDs(c.QueryParam("ids")) {
+		if cp, ok := s.loadCompareProduct(ctx, id); ok {
+			comparison.Products = append(comparison.Products, cp)
+		}
+	}
+	comparison.AttributeNames = compareAttributeNames(comparison.Products)
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Compare Products | Logan's 3D Creations"
+	meta.Description = "Compare prices, sizes, materials and dimensions of our 3D printed products side by side."
+	meta.CanonicalURL = layout.BuildAbsoluteURL(meta.SiteURL, "/shop/compare")
+	meta.OGURL = meta.CanonicalURL
+
+	return Render(c, shop.Compare(c, meta, comparison))
+}
+
+// productSpecs formats a product's attributes for its spec table. Text values link to
+// the shop filtered to that value; measurements are too specific to be worth a link.
+func productSpecs(attributes []db.ProductAttribute) []shop.ProductSpec {
+	specs := make([]shop.ProductSpec, 0, len(attributes))
+	for _, attr := range attributes {
+		spec := shop.ProductSpec{
+			Name:  attr.Name,
+			Value: utils.FormatAttributeValue(attr.ValueType, attr.Value, attr.Unit),
+		}
+		if attr.ValueType == utils.AttributeTypeText {
+			spec.FilterURL = shop.ShopFilters{}.AttributeURL("/shop", attr.Name, attr.Value)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
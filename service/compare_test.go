@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+func TestParseCompareIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"blanks dropped", " a, ,b,", []string{"a", "b"}},
+		{"duplicates dropped", "a,b,a", []string{"a", "b"}},
+		{"capped at max", "a,b,c,d,e", []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseCompareIDs(tt.raw))
+		})
+	}
+}
+
+func TestCompareAttributeNames(t *testing.T) {
+	products := []shop.CompareProduct{
+		{Attributes: map[string]string{"Weight": "20g", shop.AttributeMaterial: "PLA"}},
+		{Attributes: map[string]string{"Finish": "Matte"}},
+	}
+
+	assert.Equal(t, []string{shop.AttributeMaterial, shop.AttributeDimensions, "Finish", "Weight"}, compareAttributeNames(products))
+}
+
+func TestProductSpecs(t *testing.T) {
+	specs := productSpecs([]db.ProductAttribute{
+		{Name: "Material", Value: "PLA+", ValueType: utils.AttributeTypeText},
+		{Name: "Dimensions", Value: "80 x 40 x 25", ValueType: utils.AttributeTypeDimensions, Unit: "mm"},
+	})
+
+	assert.Equal(t, []shop.ProductSpec{
+		{Name: "Material", Value: "PLA+", FilterURL: "/shop?attr=Material&attr_value=PLA%2B"},
+		{Name: "Dimensions", Value: "80 × 40 × 25 mm"},
+	}, specs)
+}
@@ -2,7 +2,15 @@ package service
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/currency"
+	"github.com/loganlanou/logans3d-v4/internal/errorreport"
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 )
 
 type Config struct {
@@ -10,6 +18,9 @@ type Config struct {
 	Port        string
 	BaseURL     string
 	DBPath      string
+	// AutoMigrate applies pending migrations at startup. Without it the app won't
+	// start until they're applied with --migrate-only.
+	AutoMigrate bool
 
 	JWT struct {
 		Secret string
@@ -19,6 +30,7 @@ type Config struct {
 		PublishableKey string
 		SecretKey      string
 		WebhookSecret  string
+		CatalogSync    bool
 	}
 
 	Email struct {
@@ -41,6 +53,61 @@ type Config struct {
 		ConfigPath        string
 		ShipStationAPIKey string
 	}
+
+	Cache struct {
+		TTL time.Duration
+	}
+
+	Cart struct {
+		TTL     time.Duration
+		HoldTTL time.Duration
+	}
+
+	Currency struct {
+		RatesURL        string
+		RefreshInterval time.Duration
+	}
+
+	RateLimit struct {
+		IPPerMinute      int64
+		VisitorPerMinute int64
+		Burst            int64
+		Allowlist        string
+	}
+
+	Metrics struct {
+		Token string
+	}
+
+	ErrorReporting struct {
+		DSN        string
+		Release    string
+		BufferSize int
+	}
+
+	Tracing struct {
+		ServiceName string
+		Endpoint    string
+		Headers     map[string]string
+		SampleRatio float64
+		BufferSize  int
+	}
+
+	Backup struct {
+		Dir         string
+		Keep        int
+		Interval    time.Duration
+		S3Endpoint  string
+		S3Region    string
+		S3Bucket    string
+		S3Prefix    string
+		S3AccessKey string
+		S3SecretKey string
+	}
+
+	Privacy struct {
+		ExportDir string
+	}
 }
 
 func LoadConfig() (*Config, error) {
@@ -49,6 +116,7 @@ func LoadConfig() (*Config, error) {
 		Port:        getEnv("PORT", "8007"),
 		BaseURL:     getEnv("BASE_URL", "http://localhost:8007"),
 		DBPath:      getEnv("DB_PATH", "./db/logans3d.db"),
+		AutoMigrate: getEnv("AUTO_MIGRATE", "true") != "false",
 	}
 
 	// JWT
@@ -58,6 +126,7 @@ func LoadConfig() (*Config, error) {
 	config.Stripe.PublishableKey = getEnv("STRIPE_PUBLISHABLE_KEY", "")
 	config.Stripe.SecretKey = getEnv("STRIPE_SECRET_KEY", "")
 	config.Stripe.WebhookSecret = getEnv("STRIPE_WEBHOOK_SECRET", "")
+	config.Stripe.CatalogSync = getEnv("STRIPE_CATALOG_SYNC", "") == "true" && config.Stripe.SecretKey != ""
 
 	// Email
 	config.Email.From = getEnv("EMAIL_FROM", "noreply@logans3dcreations.com")
@@ -81,6 +150,89 @@ func LoadConfig() (*Config, error) {
 	config.Shipping.ConfigPath = getEnv("SHIPPING_CONFIG_PATH", "./config/shipping.json")
 	config.Shipping.ShipStationAPIKey = getEnv("SHIPSTATION_API_KEY", "")
 
+	// Catalog cache - CACHE_TTL=0 turns it off
+	if ttl, err := time.ParseDuration(getEnv("CACHE_TTL", "5m")); err == nil {
+		config.Cache.TTL = ttl
+	} else {
+		config.Cache.TTL = 5 * time.Minute
+	}
+
+	// Guest carts untouched this long are deleted - CART_TTL=0 keeps them forever.
+	// Carts on an account never expire.
+	if ttl, err := time.ParseDuration(getEnv("CART_TTL", "720h")); err == nil {
+		config.Cart.TTL = ttl
+	} else {
+		config.Cart.TTL = 30 * 24 * time.Hour
+	}
+
+	// Stock is held for a checkout this long, and its Stripe session closes then -
+	// INVENTORY_HOLD_TTL is kept between the 30m and 24h Stripe allows
+	if ttl, err := time.ParseDuration(getEnv("INVENTORY_HOLD_TTL", "30m")); err == nil {
+		config.Cart.HoldTTL = inventory.HoldTTL(ttl)
+	} else {
+		config.Cart.HoldTTL = inventory.MinHoldTTL
+	}
+
+	// Backups - snapshots sit next to the database unless BACKUP_DIR says otherwise
+	config.Backup.Dir = getEnv("BACKUP_DIR", filepath.Join(filepath.Dir(config.DBPath), "backups"))
+	if keep, err := strconv.Atoi(getEnv("BACKUP_KEEP", "14")); err == nil {
+		config.Backup.Keep = keep
+	} else {
+		config.Backup.Keep = 14
+	}
+	// BACKUP_INTERVAL=0 turns scheduled backups off
+	if interval, err := time.ParseDuration(getEnv("BACKUP_INTERVAL", "24h")); err == nil {
+		config.Backup.Interval = interval
+	} else {
+		config.Backup.Interval = 24 * time.Hour
+	}
+	config.Backup.S3Endpoint = getEnv("BACKUP_S3_ENDPOINT", "")
+	config.Backup.S3Region = getEnv("BACKUP_S3_REGION", "us-east-1")
+	config.Backup.S3Bucket = getEnv("BACKUP_S3_BUCKET", "")
+	config.Backup.S3Prefix = getEnv("BACKUP_S3_PREFIX", "")
+	config.Backup.S3AccessKey = getEnv("BACKUP_S3_ACCESS_KEY", "")
+	config.Backup.S3SecretKey = getEnv("BACKUP_S3_SECRET_KEY", "")
+
+	// Customers' data exports wait next to the database until they expire
+	config.Privacy.ExportDir = getEnv("PRIVACY_EXPORT_DIR", filepath.Join(filepath.Dir(config.DBPath), "privacy-exports"))
+
+	// Exchange rates for converted storefront prices - an interval of 0 stops fetching
+	config.Currency.RatesURL = getEnv("EXCHANGE_RATES_URL", currency.DefaultRatesURL)
+	if interval, err := time.ParseDuration(getEnv("EXCHANGE_RATES_INTERVAL", "12h")); err == nil {
+		config.Currency.RefreshInterval = interval
+	} else {
+		config.Currency.RefreshInterval = 12 * time.Hour
+	}
+
+	// Public API and form rate limits - a per-minute value of 0 turns that limit off
+	config.RateLimit.IPPerMinute = getEnvInt64("RATE_LIMIT_IP_PER_MINUTE", 300)
+	config.RateLimit.VisitorPerMinute = getEnvInt64("RATE_LIMIT_VISITOR_PER_MINUTE", 120)
+	config.RateLimit.Burst = getEnvInt64("RATE_LIMIT_BURST", 30)
+	config.RateLimit.Allowlist = getEnv("RATE_LIMIT_ALLOWLIST", "")
+
+	// Bearer token for scraping /metrics; without one only signed-in admins can read it
+	config.Metrics.Token = getEnv("METRICS_TOKEN", "")
+
+	// Server errors and panics go to a Sentry-compatible service when SENTRY_DSN is set,
+	// and are always kept for /dev/errors
+	config.ErrorReporting.DSN = getEnv("SENTRY_DSN", "")
+	config.ErrorReporting.Release = getEnv("RELEASE", "")
+	config.ErrorReporting.BufferSize = int(getEnvInt64("ERROR_BUFFER_SIZE", errorreport.DefaultBufferSize))
+
+	// Traces are always kept for /dev/traces, and exported over OTLP/HTTP when an
+	// endpoint is set, using the standard OpenTelemetry variables
+	config.Tracing.ServiceName = getEnv("OTEL_SERVICE_NAME", "logans3d")
+	config.Tracing.Endpoint = getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if base := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""); config.Tracing.Endpoint == "" && base != "" {
+		config.Tracing.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	config.Tracing.Headers = tracing.ParseHeaders(getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""))
+	config.Tracing.SampleRatio = 1
+	if ratio, err := strconv.ParseFloat(getEnv("OTEL_TRACES_SAMPLER_ARG", ""), 64); err == nil {
+		config.Tracing.SampleRatio = ratio
+	}
+	config.Tracing.BufferSize = int(getEnvInt64("TRACE_BUFFER_SIZE", tracing.DefaultBufferSize))
+
 	return config, nil
 }
 
@@ -90,3 +242,10 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value, err := strconv.ParseInt(getEnv(key, ""), 10, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
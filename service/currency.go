@@ -0,0 +1,38 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/currency"
+)
+
+// handleSetCurrency remembers the currency a shopper wants prices shown in and sends
+// them back to the page they picked it on
+func (s *Service) handleSetCurrency(c echo.Context) error {
+	code := currency.Base
+	if picked, ok := currency.Lookup(c.FormValue("currency")); ok {
+		code = picked.Code
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     currency.CookieName,
+		Value:    code,
+		Path:     "/",
+		MaxAge:   86400 * 365,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusSeeOther, currencyReturnPath(c.FormValue("return_to")))
+}
+
+// currencyReturnPath keeps the redirect on this site: only a local path is followed
+func currencyReturnPath(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		return "/"
+	}
+	return returnTo
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/currency"
+)
+
+func TestHandleSetCurrency(t *testing.T) {
+	svc := &Service{}
+
+	post := func(code, returnTo string) *httptest.ResponseRecorder {
+		form := url.Values{"currency": {code}, "return_to": {returnTo}}
+		req := httptest.NewRequest(http.MethodPost, "/currency", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		require.NoError(t, svc.handleSetCurrency(echo.New().NewContext(req, rec)))
+		return rec
+	}
+
+	rec := post("eur", "/shop/product/dragon?style=red")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/shop/product/dragon?style=red", rec.Header().Get("Location"))
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, currency.CookieName, cookies[0].Name)
+	assert.Equal(t, "EUR", cookies[0].Value)
+
+	rec = post("XYZ", "https://evil.example.com/")
+	assert.Equal(t, "/", rec.Header().Get("Location"))
+	assert.Equal(t, "USD", rec.Result().Cookies()[0].Value)
+
+	assert.Equal(t, "/", currencyReturnPath("//evil.example.com"))
+	assert.Equal(t, "/", currencyReturnPath("/\\evil.example.com"))
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/importer"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// designerDescriptionLength caps the bio excerpt used as the page description
+const designerDescriptionLength = 160
+
+// designerNames lists every designer with products in the catalog
+func (s *Service) designerNames(c echo.Context) ([]string, error) {
+	rows, err := s.storage.Queries.ListDesigners(c.Request().Context())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.DesignerName.Valid {
+			names = append(names, row.DesignerName.String)
+		}
+	}
+	return names, nil
+}
+
+// handleDesigner shows a designer's bio, attribution links and the products we
+// print from their models, optionally narrowed to one source platform
+func (s *Service) handleDesigner(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	names, err := s.designerNames(c)
+	if err != nil {
+		slog.Error("failed to list designers", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designer")
+	}
+	name, ok := utils.DesignerNameForSlug(names, slug)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Designer not found")
+	}
+
+	page := shop.DesignerPage{Name: name, Slug: slug}
+
+	profile, err := s.storage.Queries.GetDesignerProfile(ctx, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("failed to fetch designer profile", "error", err, "designer", name)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designer")
+	}
+	if profile.BioMarkdown != "" {
+		if page.BioHTML, err = utils.RenderMarkdown(profile.BioMarkdown); err != nil {
+			slog.Error("failed to render designer bio", "error", err, "designer", name)
+		}
+	}
+	page.WebsiteURL = utils.SafeExternalURL(profile.WebsiteUrl)
+	page.LicenseNote = profile.LicenseNote
+
+	// Designers we import from link back to their storefront on each platform
+	if designer := importer.GetDesigner(slug); designer != nil {
+		for _, source := range designer.Sources {
+			page.Profiles = append(page.Profiles, shop.DesignerLink{
+				Label: utils.SourcePlatformLabel(source.Platform),
+				URL:   source.URL,
+			})
+		}
+	}
+
+	platforms, err := s.storage.Queries.ListDesignerPlatformCounts(ctx, sql.NullString{String: name, Valid: true})
+	if err != nil {
+		slog.Error("failed to count designer platforms", "error", err, "designer", name)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designer")
+	}
+	for _, platform := range platforms {
+		page.Platforms = append(page.Platforms, shop.DesignerPlatform{
+			Value: platform.SourcePlatform.String,
+			Label: utils.SourcePlatformLabel(platform.SourcePlatform.String),
+			Count: platform.ProductCount,
+		})
+	}
+
+	// Only honour a platform the designer actually has products on
+	if selected := strings.TrimSpace(c.QueryParam("platform")); selected != "" {
+		for _, platform := range page.Platforms {
+			if platform.Value == selected {
+				page.Platform = selected
+			}
+		}
+	}
+
+	products, err := s.storage.Queries.ListDesignerShopProducts(ctx, db.ListDesignerShopProductsParams{
+		DesignerName:   sql.NullString{String: name, Valid: true},
+		SourcePlatform: sql.NullString{String: page.Platform, Valid: page.Platform != ""},
+	})
+	if err != nil {
+		slog.Error("failed to fetch designer products", "error", err, "designer", name)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load designer")
+	}
+	page.Products = s.withProductImages(ctx, products)
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = fmt.Sprintf("%s - 3D Printed Designs | Logan's 3D Creations", name)
+	meta.Description = fmt.Sprintf("3D prints of models designed by %s, printed and finished by Logan's 3D Creations.", name)
+	if profile.BioMarkdown != "" {
+		meta.Description = utils.MarkdownExcerpt(profile.BioMarkdown, designerDescriptionLength)
+	}
+	meta.Keywords = []string{name, name + " 3D prints", "3D printed " + name + " models"}
+	meta.OGType = "website"
+	meta.OGTitle = name + " designs"
+	meta.OGDescription = meta.Description
+	// Platform views are the same page narrowed down, so they all point at the full one
+	meta.CanonicalURL = layout.BuildAbsoluteURL(meta.SiteURL, page.URL())
+	meta.OGURL = meta.CanonicalURL
+
+	return Render(c, shop.Designer(c, meta, page))
+}
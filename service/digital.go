@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/downloads"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/views/account"
+)
+
+// cartIsDigitalOnly reports whether every cart line is a digital product, in which
+// case checkout needs no shipping selection
+func cartIsDigitalOnly(productTypes []string) bool {
+	if len(productTypes) == 0 {
+		return false
+	}
+	for _, productType := range productTypes {
+		if productType != utils.ProductTypeDigital {
+			return false
+		}
+	}
+	return true
+}
+
+// orderDownloadLinks signs fresh download links for an order's digital files
+func (s *Service) orderDownloadLinks(ctx context.Context, orderID string) ([]account.OrderDownload, error) {
+	rows, err := s.storage.Queries.ListOrderDownloads(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to list order downloads", "error", err, "order_id", orderID)
+		return nil, err
+	}
+
+	signer := downloads.DefaultSigner()
+	expires := time.Now().Add(downloads.LinkTTL)
+
+	links := make([]account.OrderDownload, 0, len(rows))
+	for _, row := range rows {
+		link := account.OrderDownload{
+			ProductName: row.ProductName,
+			FileName:    row.FileName,
+			SizeBytes:   row.SizeBytes,
+			Remaining:   -1,
+		}
+		if row.MaxDownloads.Valid {
+			link.Remaining = max(row.MaxDownloads.Int64-row.DownloadCount, 0)
+		}
+		if link.Remaining != 0 {
+			link.URL = signer.URL(s.config.BaseURL, row.ID, expires)
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// handleDownload serves a purchased digital file. The signed link authorizes the
+// request, and each successful download counts against the purchase's limit.
+func (s *Service) handleDownload(c echo.Context) error {
+	ctx := c.Request().Context()
+	downloadID := c.Param("id")
+
+	if err := downloads.DefaultSigner().Verify(downloadID, c.QueryParam("expires"), c.QueryParam("sig"), time.Now()); err != nil {
+		if errors.Is(err, downloads.ErrLinkExpired) {
+			return echo.NewHTTPError(http.StatusGone, "This download link has expired. Get a new one from your order page.")
+		}
+		return echo.NewHTTPError(http.StatusForbidden, "Invalid download link")
+	}
+
+	download, err := s.storage.Queries.GetOrderDownload(ctx, downloadID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Download not found")
+		}
+		slog.Error("failed to get order download", "error", err, "download_id", downloadID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load download")
+	}
+
+	path, err := downloads.FilePath(download.StoragePath)
+	if err != nil {
+		slog.Error("digital file has an invalid storage path", "error", err, "download_id", downloadID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "File unavailable")
+	}
+	if _, err := os.Stat(path); err != nil {
+		slog.Error("digital file missing from storage", "error", err, "download_id", downloadID, "path", path)
+		return echo.NewHTTPError(http.StatusInternalServerError, "File unavailable")
+	}
+
+	// Only count the download once we know the file can be served
+	recorded, err := s.storage.Queries.RecordOrderDownload(ctx, downloadID)
+	if err != nil {
+		slog.Error("failed to record download", "error", err, "download_id", downloadID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start download")
+	}
+	if recorded == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "This purchase has reached its download limit. Contact us if you need another copy.")
+	}
+
+	slog.Info("digital file downloaded", "download_id", downloadID, "order_id", download.OrderID)
+	c.Response().Header().Set(echo.HeaderContentType, download.ContentType)
+	return c.Attachment(path, download.FileName)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartIsDigitalOnly(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		want  bool
+	}{
+		{"empty cart", nil, false},
+		{"all digital", []string{"digital", "digital"}, true},
+		{"mixed", []string{"digital", "physical"}, false},
+		{"all physical", []string{"physical"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cartIsDigitalOnly(tt.types))
+		})
+	}
+}
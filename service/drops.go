@@ -0,0 +1,96 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// checkDrop verifies a product is on sale. Scheduled drops can't be added to the cart
+// before they go live, and for the first minutes afterwards add-to-cart is throttled
+// per product so the rush doesn't pile up on the database.
+func (s *Service) checkDrop(c echo.Context, product db.Product) error {
+	now := utils.WallClockNow()
+	if utils.DropUpcoming(product.DropAt, now) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s drops %s", product.Name, utils.DropLabel(product.DropAt.Time)))
+	}
+
+	if s.dropThrottle == nil || !utils.InDropRush(product.DropAt, now) {
+		return nil
+	}
+	allowed, _, retryAfter := s.dropThrottle.Allow(product.ID, utils.DropRushRequestsPerMinute, time.Now())
+	if !allowed {
+		seconds := int64(math.Ceil(retryAfter.Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+		slog.Debug("drop add-to-cart throttled", "product_id", product.ID, "retry_after", seconds)
+		return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("%s is in high demand right now. Please try again in %d seconds.", product.Name, seconds))
+	}
+	return nil
+}
+
+// checkPurchaseLimit verifies the customer can add quantity more of a limited product,
+// counting what's already in their cart and, when signed in, what they've bought
+// before. The returned error message is safe to show to customers.
+func (s *Service) checkPurchaseLimit(c echo.Context, product db.Product, sessionID string, quantity int64) error {
+	if product.PurchaseLimit <= 0 {
+		return nil
+	}
+
+	ctx := c.Request().Context()
+	user, isAuthenticated := auth.GetDBUser(c)
+	var userID string
+	if isAuthenticated {
+		userID = user.ID
+	}
+
+	inCart, err := s.storage.Queries.GetCartProductQuantity(ctx, db.GetCartProductQuantityParams{
+		SessionID: sql.NullString{String: sessionID, Valid: !isAuthenticated},
+		UserID:    sql.NullString{String: userID, Valid: isAuthenticated},
+		ProductID: product.ID,
+	})
+	if err != nil {
+		// Like backorder limits, a lookup failure shouldn't block the sale; the webhook
+		// flags orders that end up over the limit
+		slog.Error("failed to get cart quantity for purchase limit", "error", err, "product_id", product.ID)
+		return nil
+	}
+
+	left := utils.PurchaseLimitLeft(product.PurchaseLimit, inCart, s.purchasedQuantity(c, product))
+	switch {
+	case quantity <= left:
+		return nil
+	case left == 0:
+		return fmt.Errorf("%s is limited to %d per customer, and you've reached the limit", product.Name, product.PurchaseLimit)
+	default:
+		return fmt.Errorf("%s is limited to %d per customer; you can add %d more", product.Name, product.PurchaseLimit, left)
+	}
+}
+
+// purchasedQuantity is how many of a product the signed-in customer has bought before,
+// counted toward its purchase limit. Guests haven't bought any that can be matched.
+func (s *Service) purchasedQuantity(c echo.Context, product db.Product) int64 {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return 0
+	}
+	purchased, err := s.storage.Queries.GetCustomerPurchasedQuantity(c.Request().Context(), db.GetCustomerPurchasedQuantityParams{
+		ProductID:     product.ID,
+		UserID:        user.ID,
+		CustomerEmail: user.Email,
+	})
+	if err != nil {
+		slog.Error("failed to get purchased quantity for purchase limit", "error", err, "product_id", product.ID, "user_id", user.ID)
+		return 0
+	}
+	return purchased
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/handlers"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestDropAddToCart(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+
+	user, err := handlers.CreateTestUser(queries)
+	require.NoError(t, err)
+	product, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:            "crystal-dragon",
+		Name:          "Crystal Dragon",
+		Slug:          "crystal-dragon",
+		PriceCents:    4500,
+		StockQuantity: sql.NullInt64{Int64: 50, Valid: true},
+		IsActive:      sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	addToCart := func(quantity int) int {
+		body := fmt.Sprintf(`{"productId":%q,"quantity":%d}`, product.ID, quantity)
+		req := httptest.NewRequest(http.MethodPost, "/api/cart/add", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "drop-session"})
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.Set(auth.DBUserKey, user)
+		var he *echo.HTTPError
+		if err := svc.handleAddToCart(c); errors.As(err, &he) {
+			return he.Code
+		}
+		return rec.Code
+	}
+	scheduleDrop := func(dropAt time.Time) {
+		require.NoError(t, queries.UpdateProductDropSettings(ctx, db.UpdateProductDropSettingsParams{
+			DropAt:        sql.NullTime{Time: dropAt, Valid: true},
+			PurchaseLimit: 3,
+			ID:            product.ID,
+		}))
+	}
+
+	// Nothing can be added before the drop goes live
+	scheduleDrop(utils.WallClockNow().Add(time.Hour))
+	assert.Equal(t, http.StatusBadRequest, addToCart(1))
+
+	// Once live, the limit counts past orders as well as the cart
+	scheduleDrop(utils.WallClockNow().Add(-time.Minute))
+	createRecommendationTestOrder(t, queries, user, "order-1", "delivered", product)
+	assert.Equal(t, http.StatusOK, addToCart(1))
+	assert.Equal(t, http.StatusBadRequest, addToCart(2))
+	assert.Equal(t, http.StatusOK, addToCart(1))
+	assert.Equal(t, http.StatusBadRequest, addToCart(1))
+}
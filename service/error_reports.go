@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/errorreport"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// ErrorReporter captures server errors and panics. main.go hands it to the error
+// handler, which sits outside the routes the service registers.
+func (s *Service) ErrorReporter() *errorreport.Reporter {
+	return s.errorReporter
+}
+
+// handleDevErrors lists the captured errors still in the buffer, grouped, with the
+// latest of each expanded on demand. ?ref= finds the event behind a reference a
+// customer quotes from the error page.
+func (s *Service) handleDevErrors(c echo.Context) error {
+	ref := strings.ToLower(strings.TrimSpace(c.QueryParam("ref")))
+
+	var matches []errorreport.Event
+	if ref != "" {
+		for _, event := range s.errorReporter.Recent() {
+			if strings.HasPrefix(event.ID, ref) {
+				matches = append(matches, event)
+			}
+		}
+	}
+	return Render(c, admin.DevErrors(c, admin.DevErrorsData{
+		Groups:  s.errorReporter.Groups(),
+		Total:   s.errorReporter.Total(),
+		Remote:  s.errorReporter.Remote(),
+		Ref:     ref,
+		Matches: matches,
+	}))
+}
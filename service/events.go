@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stripe/stripe-go/v80"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/ical"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	stripeutil "github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/events"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+// eventsFeedName is the calendar's name in apps that subscribe to the feed
+const eventsFeedName = "Logan's 3D Creations Events"
+
+func (s *Service) handleEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	upcoming, err := s.storage.Queries.ListCurrentAndUpcomingEvents(ctx)
+	if err != nil {
+		slog.Error("failed to fetch upcoming events", "error", err)
+		upcoming = []db.Event{}
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Events & Workshops | Logan's 3D Creations"
+	meta.Description = "Join us for 3D printing workshops, events, and educational programs. Learn hands-on 3D printing skills."
+	meta.Keywords = []string{"3D printing events", "workshops", "educational programs", "maker events"}
+	meta.OGType = "website"
+	return Render(c, events.Index(c, meta, upcoming))
+}
+
+// handleEventDetail shows one active event by slug
+func (s *Service) handleEventDetail(c echo.Context) error {
+	event, err := s.findPublicEvent(c)
+	if err != nil {
+		return err
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = event.Title + " | Events | Logan's 3D Creations"
+	meta.Description = events.DateLabel(event)
+	if event.Location.Valid && event.Location.String != "" {
+		meta.Description += " at " + event.Location.String
+	}
+	if event.Description.Valid && event.Description.String != "" {
+		meta.Description += ". " + event.Description.String
+	}
+	meta.CanonicalURL = layout.CanonicalURL(c, events.Path(event))
+	meta.OGURL = meta.CanonicalURL
+	meta.OGTitle = event.Title
+	meta.OGDescription = meta.Description
+	ticketing := s.eventTicketing(c.Request().Context(), event)
+	switch notice := c.QueryParam("booking"); notice {
+	case events.BookingConfirmed, events.BookingWaitlisted, events.BookingPaid, events.BookingCancelled:
+		ticketing.Notice = notice
+	}
+	return Render(c, events.Detail(c, meta, event, ticketing))
+}
+
+// eventTicketing loads what can be booked for an event and how many seats are left
+func (s *Service) eventTicketing(ctx context.Context, event db.Event) events.Ticketing {
+	ticketTypes, err := s.storage.Queries.ListEventTicketTypes(ctx, event.ID)
+	if err != nil {
+		slog.Warn("failed to fetch event ticket types", "error", err, "event_id", event.ID)
+		ticketTypes = []db.EventTicketType{}
+	}
+
+	ticketing := events.Ticketing{
+		TicketTypes: ticketTypes,
+		Open:        event.StartDate.After(utils.WallClockNow()),
+		SeatsLeft:   -1,
+	}
+	if event.Capacity > 0 {
+		taken, err := s.eventSeatsTaken(ctx, event.ID)
+		if err != nil {
+			slog.Warn("failed to count event seats", "error", err, "event_id", event.ID)
+			return ticketing
+		}
+		ticketing.SeatsLeft = utils.SeatsLeft(event.Capacity, taken)
+	}
+	return ticketing
+}
+
+func (s *Service) eventSeatsTaken(ctx context.Context, eventID string) (int64, error) {
+	return s.storage.Queries.CountEventSeatsTaken(ctx, db.CountEventSeatsTakenParams{
+		EventID:     eventID,
+		PendingHold: utils.EventCheckoutHoldOffset(),
+	})
+}
+
+// handleEventRegister books seats at an event. Free tickets are confirmed straight
+// away; paid ones go through Stripe Checkout and are confirmed by the webhook.
+// Bookings that don't fit in the remaining seats go on the waitlist without paying.
+func (s *Service) handleEventRegister(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	event, err := s.findPublicEvent(c)
+	if err != nil {
+		return err
+	}
+	if !event.StartDate.After(utils.WallClockNow()) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Registration for this event has closed")
+	}
+
+	ticketType, err := s.storage.Queries.GetEventTicketType(ctx, db.GetEventTicketTypeParams{
+		ID:      c.FormValue("ticket_type_id"),
+		EventID: event.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Choose a ticket")
+		}
+		slog.Error("failed to fetch event ticket type", "error", err, "event_id", event.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load ticket")
+	}
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	emailAddr := strings.TrimSpace(c.FormValue("email"))
+	if name == "" || !strings.Contains(emailAddr, "@") {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name and email are required")
+	}
+	quantity, err := strconv.ParseInt(c.FormValue("quantity"), 10, 64)
+	if err != nil || quantity < 1 || quantity > events.MaxTicketsPerBooking {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Choose between 1 and %d tickets", events.MaxTicketsPerBooking))
+	}
+
+	status := utils.RegistrationConfirmed
+	if ticketType.PriceCents > 0 {
+		status = utils.RegistrationPending
+	}
+	if event.Capacity > 0 {
+		taken, err := s.eventSeatsTaken(ctx, event.ID)
+		if err != nil {
+			slog.Error("failed to count event seats", "error", err, "event_id", event.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check availability")
+		}
+		if quantity > utils.SeatsLeft(event.Capacity, taken) {
+			status = utils.RegistrationWaitlisted
+		}
+	}
+
+	var userID sql.NullString
+	if user, ok := auth.GetDBUser(c); ok {
+		userID = sql.NullString{String: user.ID, Valid: true}
+	}
+
+	registration, err := s.storage.Queries.CreateEventRegistration(ctx, db.CreateEventRegistrationParams{
+		ID:           uuid.New().String(),
+		EventID:      event.ID,
+		TicketTypeID: sql.NullString{String: ticketType.ID, Valid: true},
+		TicketName:   ticketType.Name,
+		UserID:       userID,
+		Name:         name,
+		Email:        emailAddr,
+		Quantity:     quantity,
+		AmountCents:  ticketType.PriceCents * quantity,
+		Status:       status,
+	})
+	if err != nil {
+		slog.Error("failed to create event registration", "error", err, "event_id", event.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save registration")
+	}
+
+	if status == utils.RegistrationPending {
+		return s.startEventCheckout(c, event, ticketType, registration)
+	}
+
+	go s.sendEventRegistrationConfirmation(event, registration)
+	return c.Redirect(http.StatusSeeOther, events.Path(event)+"?booking="+status+"#booking")
+}
+
+// startEventCheckout sends a paid booking to Stripe. The seats are held until the
+// session expires; the webhook confirms or cancels the booking.
+func (s *Service) startEventCheckout(c echo.Context, event db.Event, ticketType db.EventTicketType, registration db.EventRegistration) error {
+	ctx := c.Request().Context()
+	eventURL := fmt.Sprintf("%s://%s%s", c.Scheme(), c.Request().Host, events.Path(event))
+
+	stripe.Key = s.config.Stripe.SecretKey
+	params := &stripe.CheckoutSessionParams{
+		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:   stripe.String("usd"),
+				UnitAmount: stripe.Int64(ticketType.PriceCents),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name:        stripe.String(fmt.Sprintf("%s - %s", event.Title, ticketType.Name)),
+					Description: stripe.String(events.DateLabel(event) + ", " + events.TimeLabel(event)),
+				},
+			},
+			Quantity: stripe.Int64(registration.Quantity),
+		}},
+		CustomerEmail: stripe.String(registration.Email),
+		SuccessURL:    stripe.String(eventURL + "?booking=" + events.BookingPaid + "#booking"),
+		CancelURL:     stripe.String(eventURL + "?booking=" + events.BookingCancelled + "#booking"),
+		ExpiresAt:     stripe.Int64(time.Now().Add(utils.EventCheckoutHold).Unix()),
+		Metadata: map[string]string{
+			"event_registration_id": registration.ID,
+		},
+		// Nothing ships, so Afterpay isn't offered
+		PaymentMethodTypes: stripeutil.CheckoutPaymentMethodTypes(settings.For(s.storage.Queries).Values(ctx).PaymentMethods(), ticketType.PriceCents*registration.Quantity, false),
+	}
+
+	session, err := newCheckoutSession(params)
+	if err != nil {
+		slog.Error("failed to create event checkout session", "error", err, "registration_id", registration.ID)
+		// Free the held seats straight away
+		if _, err := s.storage.Queries.UpdateEventRegistrationStatus(ctx, db.UpdateEventRegistrationStatusParams{
+			Status:     utils.RegistrationCancelled,
+			ID:         registration.ID,
+			FromStatus: utils.RegistrationPending,
+		}); err != nil {
+			slog.Error("failed to cancel event registration", "error", err, "registration_id", registration.ID)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to start checkout")
+	}
+
+	if err := s.storage.Queries.SetEventRegistrationStripeSession(ctx, db.SetEventRegistrationStripeSessionParams{
+		StripeSessionID: sql.NullString{String: session.ID, Valid: true},
+		ID:              registration.ID,
+	}); err != nil {
+		slog.Error("failed to save event checkout session", "error", err, "registration_id", registration.ID)
+	}
+
+	return c.Redirect(http.StatusSeeOther, session.URL)
+}
+
+// sendEventRegistrationConfirmation emails a confirmed or waitlisted booking
+func (s *Service) sendEventRegistrationConfirmation(event db.Event, registration db.EventRegistration) {
+	if err := s.emailService.SendEventRegistrationConfirmation(events.RegistrationEmail(event, registration)); err != nil && !errors.Is(err, email.ErrOptedOut) {
+		slog.Error("failed to send event registration email", "error", err, "registration_id", registration.ID)
+	}
+}
+
+// handleEventICS downloads one event as an .ics file
+func (s *Service) handleEventICS(c echo.Context) error {
+	event, err := s.findPublicEvent(c)
+	if err != nil {
+		return err
+	}
+
+	filename := event.ID
+	if event.Slug.Valid && event.Slug.String != "" {
+		filename = event.Slug.String
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.ics"`)
+	return c.Blob(http.StatusOK, ical.ContentType, ical.Calendar("", []ical.Event{eventICal(c, event)}, time.Now()))
+}
+
+// handleEventsFeed serves every active event, past and upcoming, as a calendar that
+// can be subscribed to so new events show up on their own
+func (s *Service) handleEventsFeed(c echo.Context) error {
+	active, err := s.storage.Queries.ListActiveEvents(c.Request().Context())
+	if err != nil {
+		slog.Error("failed to fetch events for calendar feed", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load events")
+	}
+
+	entries := make([]ical.Event, len(active))
+	for i, event := range active {
+		entries[i] = eventICal(c, event)
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.Blob(http.StatusOK, ical.ContentType, ical.Calendar(eventsFeedName, entries, time.Now()))
+}
+
+// findPublicEvent loads the active event named by the :slug param. Events saved before
+// slugs existed are linked by ID, so that's tried too.
+func (s *Service) findPublicEvent(c echo.Context) (db.Event, error) {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	event, err := s.storage.Queries.GetActiveEventBySlug(ctx, sql.NullString{String: slug, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		event, err = s.storage.Queries.GetEvent(ctx, slug)
+		if err == nil && !(event.IsActive.Valid && event.IsActive.Bool) {
+			err = sql.ErrNoRows
+		}
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.Event{}, echo.NewHTTPError(http.StatusNotFound, "Event not found")
+		}
+		slog.Error("failed to fetch event", "error", err, "slug", slug)
+		return db.Event{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to load event")
+	}
+	return event, nil
+}
+
+// eventICal converts an event for the calendar files, linking back to its page
+func eventICal(c echo.Context, event db.Event) ical.Event {
+	location := event.Location.String
+	if event.Address.Valid && event.Address.String != "" {
+		if location != "" {
+			location += ", "
+		}
+		location += event.Address.String
+	}
+
+	entry := ical.Event{
+		UID:         event.ID + "@logans3dcreations.com",
+		Title:       event.Title,
+		Description: event.Description.String,
+		Location:    location,
+		URL:         layout.CanonicalURL(c, events.Path(event)),
+		Start:       event.StartDate,
+	}
+	if event.EndDate.Valid {
+		entry.End = event.EndDate.Time
+	}
+	if event.UpdatedAt.Valid {
+		entry.Modified = event.UpdatedAt.Time
+	}
+	return entry
+}
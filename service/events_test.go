@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/events"
+)
+
+func TestEventICal(t *testing.T) {
+	c := echo.New().NewContext(httptest.NewRequest("GET", "/events/calendar.ics", nil), httptest.NewRecorder())
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	entry := eventICal(c, db.Event{
+		ID:        "evt-1",
+		Title:     "Maker Fair",
+		Location:  sql.NullString{String: "Convention Center", Valid: true},
+		Address:   sql.NullString{String: "1 Main St, Springfield", Valid: true},
+		StartDate: start,
+		EndDate:   sql.NullTime{Time: start.Add(8 * time.Hour), Valid: true},
+		Slug:      sql.NullString{String: "maker-fair-2026-03-15", Valid: true},
+	})
+
+	assert.Equal(t, "evt-1@logans3dcreations.com", entry.UID)
+	assert.Equal(t, "Convention Center, 1 Main St, Springfield", entry.Location)
+	assert.Equal(t, "https://www.logans3dcreations.com/events/maker-fair-2026-03-15", entry.URL)
+	assert.Equal(t, start.Add(8*time.Hour), entry.End)
+}
+
+func TestEventViewHelpers(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	event := db.Event{ID: "evt-1", StartDate: start, Address: sql.NullString{String: "1 Main St, Springfield", Valid: true}}
+
+	assert.Equal(t, "/events/evt-1", events.Path(event), "events without a slug are linked by ID")
+	assert.Equal(t, "https://www.google.com/maps/search/?api=1&query=1+Main+St%2C+Springfield", events.MapURL(event))
+	assert.Equal(t, "March 15, 2026", events.DateLabel(event))
+	assert.Equal(t, "10:00 AM", events.TimeLabel(event))
+
+	event.EndDate = sql.NullTime{Time: start.Add(8 * time.Hour), Valid: true}
+	assert.Equal(t, "10:00 AM - 6:00 PM", events.TimeLabel(event))
+
+	event.EndDate = sql.NullTime{Time: start.AddDate(0, 0, 1), Valid: true}
+	assert.Equal(t, "March 15-16, 2026", events.DateLabel(event))
+	event.EndDate = sql.NullTime{Time: start.AddDate(0, 0, 17), Valid: true}
+	assert.Equal(t, "March 15 - April 1, 2026", events.DateLabel(event))
+
+	assert.Equal(t, "", events.MapURL(db.Event{}))
+}
+
+func TestEventRegistrationCapacity(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+
+	event, err := queries.CreateEvent(ctx, db.CreateEventParams{
+		ID:        "workshop",
+		Title:     "Intro to 3D Printing",
+		StartDate: utils.WallClockNow().AddDate(0, 0, 7),
+		IsActive:  sql.NullBool{Bool: true, Valid: true},
+		Slug:      sql.NullString{String: "intro-workshop", Valid: true},
+		Capacity:  3,
+	})
+	require.NoError(t, err)
+	rsvp, err := queries.CreateEventTicketType(ctx, db.CreateEventTicketTypeParams{
+		ID:      "rsvp",
+		EventID: event.ID,
+		Name:    "RSVP",
+	})
+	require.NoError(t, err)
+
+	register := func(quantity string) *httptest.ResponseRecorder {
+		form := url.Values{"ticket_type_id": {rsvp.ID}, "name": {"Sam"}, "email": {"sam@example.com"}, "quantity": {quantity}}
+		req := httptest.NewRequest(http.MethodPost, "/events/intro-workshop/register", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("intro-workshop")
+		require.NoError(t, svc.handleEventRegister(c))
+		return rec
+	}
+
+	// Free tickets are confirmed while there's room
+	rec := register("2")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/events/intro-workshop?booking=confirmed#booking", rec.Header().Get("Location"))
+	assert.Equal(t, int64(1), svc.eventTicketing(ctx, event).SeatsLeft)
+
+	// A booking that doesn't fit goes on the waitlist and takes no seats
+	rec = register("2")
+	assert.Equal(t, "/events/intro-workshop?booking=waitlisted#booking", rec.Header().Get("Location"))
+	assert.Equal(t, int64(1), svc.eventTicketing(ctx, event).SeatsLeft)
+
+	rec = register("1")
+	assert.Equal(t, "/events/intro-workshop?booking=confirmed#booking", rec.Header().Get("Location"))
+	ticketing := svc.eventTicketing(ctx, event)
+	assert.True(t, ticketing.Full())
+	assert.True(t, ticketing.Bookable(), "a full event still takes waitlist bookings")
+
+	registrations, err := queries.ListEventRegistrations(ctx, event.ID)
+	require.NoError(t, err)
+	statuses := make([]string, 0, len(registrations))
+	for _, r := range registrations {
+		statuses = append(statuses, r.Status)
+	}
+	assert.Equal(t, []string{utils.RegistrationConfirmed, utils.RegistrationConfirmed, utils.RegistrationWaitlisted}, statuses)
+
+	// Too many seats in one booking is rejected
+	form := url.Values{"ticket_type_id": {rsvp.ID}, "name": {"Sam"}, "email": {"sam@example.com"}, "quantity": {"11"}}
+	req := httptest.NewRequest(http.MethodPost, "/events/intro-workshop/register", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	c.SetParamNames("slug")
+	c.SetParamValues("intro-workshop")
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, svc.handleEventRegister(c), &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// exitIntentAttempt is the recovery attempt type of the email sent from the cart
+// page popup, so each abandoned cart gets it once
+const exitIntentAttempt = "exit_intent"
+
+// handleEmailCart emails shoppers a link to their cart when they ask from the popup
+// shown as they leave the cart page. Guests give their email there. The cart goes
+// into abandoned cart recovery at once, so the reminder sequence follows up without
+// waiting for the detector to notice it.
+func (s *Service) handleEmailCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	popup, ok := settings.For(s.storage.Queries).Values(ctx).ExitIntent()
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Not found")
+	}
+
+	var req struct {
+		Email string `json:"email" form:"email"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+
+	var customerEmail, customerName string
+	if user, ok := auth.GetDBUser(c); ok {
+		customerEmail = user.Email
+		customerName = strings.TrimSpace(user.FirstName.String)
+		if customerName == "" {
+			customerName = user.FullName
+		}
+	} else {
+		addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+		if err != nil || addr.Name != "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Enter a valid email address")
+		}
+		customerEmail = addr.Address
+	}
+
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+	lines, err := s.storage.Queries.ListCartLinesToShare(ctx, db.ListCartLinesToShareParams{
+		UserID:    owner.userParam(),
+		SessionID: owner.sessionParam(),
+	})
+	if err != nil {
+		slog.Error("failed to list cart lines to email", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+	// The link is a shared cart, which can't hold bundles
+	var shareable []db.CartItem
+	for _, line := range lines {
+		if !line.BundleGroupID.Valid {
+			shareable = append(shareable, line)
+		}
+	}
+	if len(shareable) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Your cart is empty")
+	}
+
+	if s.abandonedCartDetector == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "Emailing carts isn't available right now")
+	}
+	sessionID := owner.SessionID
+	if owner.UserID != "" {
+		sessionID = ""
+	}
+	cartID, err := s.abandonedCartDetector.CaptureCart(ctx, sessionID, owner.UserID, customerEmail)
+	if err != nil {
+		slog.Error("failed to capture cart for recovery", "error", err, "user_id", owner.UserID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+
+	attempts, err := s.storage.Queries.GetRecoveryAttemptsByCartID(ctx, cartID)
+	if err != nil {
+		slog.Error("failed to list recovery attempts", "error", err, "cart_id", cartID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+	for _, attempt := range attempts {
+		if attempt.AttemptType == exitIntentAttempt {
+			return echo.NewHTTPError(http.StatusConflict, "We've already emailed you this cart")
+		}
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		slog.Error("failed to generate shared cart token", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+	if err := s.createSharedCart(ctx, owner, token, "Saved cart", shareable); err != nil {
+		slog.Error("failed to create shared cart to email", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+	sharedCart, err := s.storage.Queries.GetSharedCartByToken(ctx, token)
+	if err != nil {
+		slog.Error("failed to load emailed shared cart", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+	items, err := s.storage.Queries.ListSharedCartItems(ctx, sharedCart.ID)
+	if err != nil {
+		slog.Error("failed to list emailed shared cart items", "error", err, "shared_cart_id", sharedCart.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+
+	data := &email.SavedCartData{
+		CustomerName:  customerName,
+		CustomerEmail: customerEmail,
+		CartURL:       s.sharedCartURL(token),
+		PromoCode:     popup.Code,
+		TrackingToken: uuid.New().String(),
+	}
+	for _, item := range items {
+		name := item.Name
+		if item.VariantName != "" {
+			name += " - " + item.VariantName
+		}
+		data.Items = append(data.Items, email.AbandonedCartItem{
+			ProductName:  name,
+			ProductImage: item.ImageUrl,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.PriceCents,
+		})
+		data.ItemCount += item.Quantity
+		data.CartValue += item.PriceCents * item.Quantity
+	}
+
+	status := "sent"
+	sendErr := s.emailService.SendSavedCart(data)
+	if errors.Is(sendErr, email.ErrOptedOut) {
+		// They turned off cart emails, so recovery won't email them either
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "This address has turned off cart emails")
+	}
+	if sendErr != nil {
+		slog.Error("failed to send saved cart email", "error", sendErr, "cart_id", cartID)
+		status = "failed"
+	}
+
+	if _, err := s.storage.Queries.CreateRecoveryAttempt(ctx, db.CreateRecoveryAttemptParams{
+		ID:              uuid.New().String(),
+		AbandonedCartID: cartID,
+		AttemptType:     exitIntentAttempt,
+		SentAt:          time.Now(),
+		EmailSubject:    sql.NullString{String: "Your saved cart", Valid: true},
+		TrackingToken:   sql.NullString{String: data.TrackingToken, Valid: true},
+		Status:          sql.NullString{String: status, Valid: true},
+	}); err != nil {
+		slog.Error("failed to record exit intent attempt", "error", err, "cart_id", cartID)
+	}
+	if sendErr != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to email cart")
+	}
+	if err := s.storage.Queries.MarkCartAsContacted(ctx, cartID); err != nil {
+		slog.Error("failed to mark cart contacted", "error", err, "cart_id", cartID)
+	}
+
+	slog.Info("cart emailed from exit intent popup", "cart_id", cartID, "user_id", owner.UserID, "items", data.ItemCount)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success": true,
+		"email":   customerEmail,
+	})
+}
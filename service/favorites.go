@@ -0,0 +1,165 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/loganlanou/logans3d-v4/internal/api"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/account"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+// loadFavoriteItems returns the user's saved products with image and stock status, newest first
+func (s *Service) loadFavoriteItems(c echo.Context, userID string) ([]account.FavoriteItem, error) {
+	ctx := c.Request().Context()
+
+	products, err := s.storage.Queries.ListFavoriteProducts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stockLevels, err := s.storage.Queries.ListFavoriteStockLevels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stockByProduct := make(map[string]int64, len(stockLevels))
+	for _, level := range stockLevels {
+		stockByProduct[level.ProductID] = level.AvailableStock
+	}
+
+	imageURLs := s.catalog().ProductImageURLs(ctx, products)
+	items := make([]account.FavoriteItem, 0, len(products))
+	for _, product := range products {
+		items = append(items, account.FavoriteItem{
+			Product:        product,
+			ImageURL:       imageURLs[product.ID],
+			AvailableStock: stockByProduct[product.ID],
+		})
+	}
+
+	return items, nil
+}
+
+// handleAccountFavorites renders the user's saved products
+func (s *Service) handleAccountFavorites(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account/favorites")
+	}
+
+	items, err := s.loadFavoriteItems(c, user.ID)
+	if err != nil {
+		slog.Error("failed to load favorites", "error", err, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load favorites")
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "My Favorites - Logan's 3D Creations"
+	meta.Description = "Products you've saved for later"
+
+	return Render(c, account.Favorites(c, items, meta))
+}
+
+// handleListFavorites returns the user's saved products as JSON
+func (s *Service) handleListFavorites(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Sign in to save favorites"})
+	}
+
+	items, err := s.loadFavoriteItems(c, user.ID)
+	if err != nil {
+		slog.Error("failed to load favorites", "error", err, "user_id", user.ID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load favorites"})
+	}
+
+	favorites := make([]api.ProductSummary, 0, len(items))
+	for _, item := range items {
+		favorites = append(favorites, api.NewProductSummary(item.Product, item.ImageURL, item.AvailableStock))
+	}
+
+	return c.JSON(http.StatusOK, api.Favorites{Version: api.Version, Favorites: favorites})
+}
+
+// handleListFavoriteIDs returns just the favorited product IDs so pages can light up heart toggles
+func (s *Service) handleListFavoriteIDs(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.JSON(http.StatusOK, map[string]interface{}{"product_ids": []string{}, "authenticated": false})
+	}
+
+	ids, err := s.storage.Queries.ListFavoriteProductIDs(c.Request().Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list favorite product ids", "error", err, "user_id", user.ID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load favorites"})
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"product_ids": ids, "authenticated": true})
+}
+
+// handleAddFavorite saves a product to the user's favorites
+func (s *Service) handleAddFavorite(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Sign in to save favorites"})
+	}
+
+	var req struct {
+		ProductID string `json:"product_id"`
+	}
+	if err := c.Bind(&req); err != nil || req.ProductID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "product_id is required"})
+	}
+
+	ctx := c.Request().Context()
+
+	if _, err := s.storage.Queries.GetProduct(ctx, req.ProductID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Product not found"})
+		}
+		slog.Error("failed to load product for favorite", "error", err, "product_id", req.ProductID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save favorite"})
+	}
+
+	err := s.storage.Queries.AddFavorite(ctx, db.AddFavoriteParams{
+		ID:        ulid.Make().String(),
+		UserID:    user.ID,
+		ProductID: req.ProductID,
+	})
+	if err != nil {
+		slog.Error("failed to add favorite", "error", err, "user_id", user.ID, "product_id", req.ProductID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save favorite"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"product_id": req.ProductID, "favorited": true})
+}
+
+// handleRemoveFavorite removes a product from the user's favorites
+func (s *Service) handleRemoveFavorite(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Sign in to save favorites"})
+	}
+
+	productID := c.Param("product_id")
+	err := s.storage.Queries.RemoveFavorite(c.Request().Context(), db.RemoveFavoriteParams{
+		UserID:    user.ID,
+		ProductID: productID,
+	})
+	if err != nil {
+		slog.Error("failed to remove favorite", "error", err, "user_id", user.ID, "product_id", productID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to remove favorite"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"product_id": productID, "favorited": false})
+}
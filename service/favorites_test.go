@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/handlers"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestFavoritesAPI(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+	e := echo.New()
+
+	user, err := handlers.CreateTestUser(queries)
+	require.NoError(t, err)
+
+	product, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:         "test-product-favorite",
+		Name:       "Favorite Dino",
+		Slug:       "favorite-dino",
+		PriceCents: 1500,
+	})
+	require.NoError(t, err)
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		handlers.SetTestUser(c, user)
+		return c, rec
+	}
+
+	// Adding the same product twice is idempotent
+	for i := 0; i < 2; i++ {
+		c, rec := newContext(http.MethodPost, "/api/favorites", `{"product_id":"`+product.ID+`"}`)
+		require.NoError(t, svc.handleAddFavorite(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	c, rec := newContext(http.MethodGet, "/api/favorites/ids", "")
+	require.NoError(t, svc.handleListFavoriteIDs(c))
+	var ids struct {
+		ProductIDs []string `json:"product_ids"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ids))
+	assert.Equal(t, []string{product.ID}, ids.ProductIDs)
+
+	// Unknown products are rejected
+	c, rec = newContext(http.MethodPost, "/api/favorites", `{"product_id":"missing"}`)
+	require.NoError(t, svc.handleAddFavorite(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	c, rec = newContext(http.MethodDelete, "/api/favorites/"+product.ID, "")
+	c.SetParamNames("product_id")
+	c.SetParamValues(product.ID)
+	require.NoError(t, svc.handleRemoveFavorite(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	remaining, err := queries.ListFavoriteProductIDs(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestFavoritesAPI_RequiresSignIn(t *testing.T) {
+	svc := setupTestService(t)
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/favorites", strings.NewReader(`{"product_id":"abc"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, svc.handleAddFavorite(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+)
+
+// flagSubject is who percentage rollouts are bucketed by: the signed-in account, so
+// a customer sees the same features on every device, or else the browser session.
+// A first visit has neither, so it only sees fully rolled out flags.
+func flagSubject(c echo.Context) string {
+	if user, ok := auth.GetDBUser(c); ok {
+		return user.ID
+	}
+	if cookie, err := c.Cookie("session_id"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
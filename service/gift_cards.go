@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/giftcards"
+)
+
+// handleApplyGiftCard checks a gift card code so the cart can show its balance before
+// checkout. The amount used is worked out, and taken off the card, at checkout.
+func (s *Service) handleApplyGiftCard(c echo.Context) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Enter a gift card code"})
+	}
+
+	card, err := giftcards.NewLedger(s.storage.Queries).Find(c.Request().Context(), req.Code, time.Now())
+	var cardErr *giftcards.Error
+	if errors.As(err, &cardErr) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": cardErr.Message})
+	}
+	if err != nil {
+		slog.Error("failed to check gift card", "error", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Unable to check the gift card right now"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"code":          card.Code,
+		"balance_cents": card.BalanceCents,
+	})
+}
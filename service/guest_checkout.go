@@ -0,0 +1,134 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/account"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+// guestOrderPath is where a guest sees an order placed without an account. The
+// Stripe session ID is long and random and only handed to the customer's browser
+// after payment, so it serves as the key.
+func guestOrderPath(stripeSessionID string) string {
+	return "/orders/guest/" + stripeSessionID
+}
+
+// guestOrderLinkable reports whether a signed-in customer may add a guest order to
+// their account: it was placed from the same browser, or with their email
+func guestOrderLinkable(order db.Order, userEmail, browserSessionID string) bool {
+	if order.UserID != "" {
+		return false
+	}
+	if browserSessionID != "" && order.GuestSessionID.Valid && order.GuestSessionID.String == browserSessionID {
+		return true
+	}
+	return userEmail != "" && strings.EqualFold(userEmail, order.CustomerEmail)
+}
+
+func browserSessionID(c echo.Context) string {
+	cookie, err := c.Cookie("session_id")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// guestOrder loads the order behind a guest order link. An order that has since gone
+// into an account is shown there instead, to its owner only; accountURL is then set.
+func (s *Service) guestOrder(c echo.Context) (order db.Order, accountURL string, err error) {
+	stripeSessionID := c.Param("session")
+	order, err = s.storage.Queries.GetOrderByStripeSessionID(c.Request().Context(), sql.NullString{String: stripeSessionID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Order{}, "", echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		slog.Error("failed to fetch guest order", "error", err)
+		return db.Order{}, "", echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve order")
+	}
+
+	if order.UserID != "" {
+		if user, ok := auth.GetDBUser(c); ok && user.ID == order.UserID {
+			return order, "/account/orders/" + order.ID, nil
+		}
+		return db.Order{}, "", echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	return order, "", nil
+}
+
+// handleGuestOrder shows a guest the order they placed, with the offer to keep it in
+// an account
+func (s *Service) handleGuestOrder(c echo.Context) error {
+	order, accountURL, err := s.guestOrder(c)
+	if err != nil {
+		return err
+	}
+	if accountURL != "" {
+		if c.QueryParam("purchase") == "true" {
+			accountURL += "?purchase=true"
+		}
+		return c.Redirect(http.StatusFound, accountURL)
+	}
+
+	ctx := c.Request().Context()
+	itemsWithProduct := s.accountOrderItems(ctx, order.ID)
+	downloads, err := s.orderDownloadLinks(ctx, order.ID)
+	if err != nil {
+		// Still show the order; the customer can reload for their links
+		downloads = nil
+	}
+
+	guest := &account.GuestOrder{SessionID: c.Param("session")}
+	if user, ok := auth.GetDBUser(c); ok {
+		guest.SignedIn = true
+		guest.CanLink = guestOrderLinkable(order, user.Email, browserSessionID(c))
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = fmt.Sprintf("Order #%s - Logan's 3D Creations", order.ID[:8])
+	meta.Description = "View order details and tracking information"
+
+	// Links are private to the customer who placed the order
+	c.Response().Header().Set("X-Robots-Tag", "noindex")
+	return Render(c, account.OrderDetail(c, order, itemsWithProduct, downloads, s.orderStatusSteps(ctx, order.ID), s.orderShipments(ctx, order.ID), account.OrderCancel{}, meta, guest))
+}
+
+// handleLinkGuestOrder adds a guest order to the signed-in customer's account
+func (s *Service) handleLinkGuestOrder(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url="+guestOrderPath(c.Param("session")))
+	}
+
+	order, accountURL, err := s.guestOrder(c)
+	if err != nil {
+		return err
+	}
+	if accountURL != "" {
+		return c.Redirect(http.StatusSeeOther, accountURL)
+	}
+	if !guestOrderLinkable(order, user.Email, browserSessionID(c)) {
+		slog.Warn("guest order link refused", "order_id", order.ID, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusForbidden, "This order was placed with a different email. Sign in with that email to add it to your account.")
+	}
+
+	if _, err := s.storage.Queries.LinkGuestOrder(c.Request().Context(), db.LinkGuestOrderParams{
+		UserID: user.ID,
+		ID:     order.ID,
+	}); err != nil {
+		slog.Error("failed to link guest order", "error", err, "order_id", order.ID, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add the order to your account")
+	}
+
+	slog.Info("guest order added to account", "order_id", order.ID, "user_id", user.ID)
+	return c.Redirect(http.StatusSeeOther, "/account/orders/"+order.ID)
+}
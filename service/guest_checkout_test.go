@@ -0,0 +1,31 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestGuestOrderLinkable(t *testing.T) {
+	order := db.Order{
+		ID:             "order-1",
+		CustomerEmail:  "Sam@Example.com",
+		GuestSessionID: sql.NullString{String: "browser-1", Valid: true},
+	}
+
+	assert.True(t, guestOrderLinkable(order, "someone@else.com", "browser-1"), "same browser")
+	assert.True(t, guestOrderLinkable(order, "sam@example.com", "browser-2"), "same email, any case")
+	assert.False(t, guestOrderLinkable(order, "someone@else.com", "browser-2"), "neither")
+	assert.False(t, guestOrderLinkable(order, "", ""), "no email or session")
+
+	noSession := order
+	noSession.GuestSessionID = sql.NullString{}
+	assert.False(t, guestOrderLinkable(noSession, "someone@else.com", ""), "no session on either side")
+
+	claimed := order
+	claimed.UserID = "user-1"
+	assert.False(t, guestOrderLinkable(claimed, "sam@example.com", "browser-1"), "already in an account")
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/health"
+)
+
+// healthProbeTimeout bounds each dependency probe; they run in parallel, so a deep
+// check takes at most this long
+const healthProbeTimeout = 3 * time.Second
+
+// deepHealthTTL is how long a deep check's report is reused. /health is public, so
+// this keeps monitors and curious visitors from fanning out to every provider.
+const deepHealthTTL = 10 * time.Second
+
+var healthHTTPClient = &http.Client{Timeout: healthProbeTimeout}
+
+type deepHealthCache struct {
+	mu     sync.Mutex
+	report health.Report
+}
+
+func (s *Service) handleDeepHealth(c echo.Context) error {
+	report := s.deepHealthReport(c.Request().Context())
+
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(status, map[string]any{
+		"status":      report.Status,
+		"environment": s.config.Environment,
+		"checked_at":  report.CheckedAt,
+		"checks":      report.Checks,
+	})
+}
+
+func (s *Service) deepHealthReport(ctx context.Context) health.Report {
+	s.deepHealth.mu.Lock()
+	defer s.deepHealth.mu.Unlock()
+
+	if time.Since(s.deepHealth.report.CheckedAt) < deepHealthTTL {
+		return s.deepHealth.report
+	}
+
+	report := health.Run(ctx, healthProbeTimeout, s.healthChecks())
+	for _, check := range report.Checks {
+		if check.Status == health.StatusError {
+			slog.Warn("health check failed", "dependency", check.Name, "error", check.Error, "latency_ms", check.LatencyMs)
+		}
+	}
+	s.deepHealth.report = report
+	return report
+}
+
+// healthChecks lists the dependencies the deep health check probes. Only the database
+// is critical: without it nothing works, while a provider outage breaks one feature.
+func (s *Service) healthChecks() []health.Check {
+	return []health.Check{
+		{Name: "database", Critical: true, Probe: s.probeDatabase},
+		{Name: "stripe", Probe: s.probeStripe},
+		{Name: "easypost", Probe: probeEasyPost},
+		{Name: "clerk", Probe: probeClerkJWKS},
+		{Name: "email", Probe: s.probeEmail},
+	}
+}
+
+func (s *Service) probeDatabase(ctx context.Context) error {
+	var one int
+	return s.storage.DB().QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+func (s *Service) probeStripe(ctx context.Context) error {
+	if s.config.Stripe.SecretKey == "" {
+		return health.ErrNotConfigured
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://api.stripe.com/v1/balance", nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.config.Stripe.SecretKey, "")
+	return health.ProbeHTTP(ctx, healthHTTPClient, req)
+}
+
+func probeEasyPost(ctx context.Context) error {
+	apiKey := os.Getenv("EASYPOST_API_KEY")
+	if apiKey == "" {
+		return health.ErrNotConfigured
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://api.easypost.com/v2/addresses?page_size=1", nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(apiKey, "")
+	return health.ProbeHTTP(ctx, healthHTTPClient, req)
+}
+
+// probeClerkJWKS fetches the keys session tokens are verified against
+func probeClerkJWKS(ctx context.Context) error {
+	secretKey := os.Getenv("CLERK_SECRET_KEY")
+	if secretKey == "" {
+		return health.ErrNotConfigured
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://api.clerk.com/v1/jwks", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+secretKey)
+	return health.ProbeHTTP(ctx, healthHTTPClient, req)
+}
+
+func (s *Service) probeEmail(ctx context.Context) error {
+	if s.emailService == nil {
+		return health.ErrNotConfigured
+	}
+	err := s.emailService.Ping(ctx)
+	if errors.Is(err, email.ErrNotConfigured) {
+		return health.ErrNotConfigured
+	}
+	return err
+}
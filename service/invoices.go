@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/invoices"
+	"github.com/loganlanou/logans3d-v4/internal/pdf"
+)
+
+// handleAccountOrderInvoice serves the invoice PDF for one of the customer's own orders.
+// It opens in the browser, or downloads with ?download=1.
+func (s *Service) handleAccountOrderInvoice(c echo.Context) error {
+	if !auth.IsAuthenticated(c) {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account")
+	}
+
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		slog.Error("authenticated user not found in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "User not found")
+	}
+
+	ctx := c.Request().Context()
+	order, err := s.customerOrder(c, user.ID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	invoice, err := invoices.NewStore(s.storage.Queries).PDF(ctx, order)
+	if err != nil {
+		slog.Error("failed to render invoice", "error", err, "order_id", order.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create invoice")
+	}
+
+	disposition := "inline"
+	if c.QueryParam("download") == "1" {
+		disposition = "attachment"
+	}
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%s", disposition, pdf.InvoiceFilename(order)))
+	return c.Blob(http.StatusOK, "application/pdf", invoice)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// maxPerOrderError reports whether adding units of a product to the inCart already
+// there would go over its per-order cap. The message is safe to show to customers.
+func maxPerOrderError(product db.Product, inCart, adding int64) error {
+	if product.MaxPerOrder <= 0 || inCart+adding <= product.MaxPerOrder {
+		return nil
+	}
+	if left := product.MaxPerOrder - inCart; left > 0 {
+		return fmt.Errorf("%s is limited to %d per order; you can add %d more", product.Name, product.MaxPerOrder, left)
+	}
+	return fmt.Errorf("%s is limited to %d per order, and your cart already has %d", product.Name, product.MaxPerOrder, inCart)
+}
+
+// checkMaxPerOrder verifies quantity more of a product fits under its per-order cap,
+// counting every line of it already in the cart
+func (s *Service) checkMaxPerOrder(c echo.Context, product db.Product, owner cartOwner, quantity int64) error {
+	if product.MaxPerOrder <= 0 {
+		return nil
+	}
+
+	inCart, err := s.storage.Queries.GetCartProductQuantity(c.Request().Context(), db.GetCartProductQuantityParams{
+		SessionID: owner.sessionParam(),
+		UserID:    owner.userParam(),
+		ProductID: product.ID,
+	})
+	if err != nil {
+		// Checkout checks the whole cart again, so a lookup failure here needn't block
+		slog.Error("failed to get cart quantity for per-order limit", "error", err, "product_id", product.ID)
+		return nil
+	}
+	return maxPerOrderError(product, inCart, quantity)
+}
+
+// orderLimitProblems lists each product in the cart over its per-order cap, adding
+// up its lines across variants and personalizations
+func orderLimitProblems(rows []db.GetCartByUserRow) []string {
+	type limited struct {
+		name     string
+		limit    int64
+		quantity int64
+	}
+	var products []*limited
+	byID := make(map[string]*limited)
+	for _, row := range rows {
+		if row.MaxPerOrder <= 0 {
+			continue
+		}
+		product, ok := byID[row.ProductID]
+		if !ok {
+			product = &limited{name: row.Name, limit: row.MaxPerOrder}
+			byID[row.ProductID] = product
+			products = append(products, product)
+		}
+		product.quantity += row.Quantity
+	}
+
+	var problems []string
+	for _, product := range products {
+		if over := product.quantity - product.limit; over > 0 {
+			problems = append(problems, fmt.Sprintf("%s is limited to %d per order. Please remove %d to check out.", product.name, product.limit, over))
+		}
+	}
+	return problems
+}
+
+// minimumOrderProblem explains what's missing when a subtotal is under the store's
+// minimum order, or returns "" when it's met or there's no minimum
+func minimumOrderProblem(subtotalCents, minimumCents int64) string {
+	if minimumCents <= 0 || subtotalCents >= minimumCents {
+		return ""
+	}
+	return fmt.Sprintf("Orders must be at least $%.2f before shipping. Add $%.2f more to check out.",
+		float64(minimumCents)/100, float64(minimumCents-subtotalCents)/100)
+}
+
+// checkoutProblems lists what stops a cart from checking out: products over their
+// per-order cap and a subtotal under the store minimum
+func (s *Service) checkoutProblems(c echo.Context, rows []db.GetCartByUserRow, subtotalCents int64) []string {
+	problems := orderLimitProblems(rows)
+	minimum := settings.For(s.storage.Queries).Values(c.Request().Context()).MinimumOrderCents()
+	if problem := minimumOrderProblem(subtotalCents, minimum); problem != "" {
+		problems = append(problems, problem)
+	}
+	return problems
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestMaxPerOrderError(t *testing.T) {
+	product := db.Product{Name: "Crystal Dragon", MaxPerOrder: 3}
+
+	assert.NoError(t, maxPerOrderError(db.Product{Name: "Keychain"}, 40, 10), "no cap")
+	assert.NoError(t, maxPerOrderError(product, 1, 2))
+	assert.EqualError(t, maxPerOrderError(product, 1, 3), "Crystal Dragon is limited to 3 per order; you can add 2 more")
+	assert.EqualError(t, maxPerOrderError(product, 3, 1), "Crystal Dragon is limited to 3 per order, and your cart already has 3")
+}
+
+func TestOrderLimitProblems(t *testing.T) {
+	rows := []db.GetCartByUserRow{
+		{ProductID: "dragon", Name: "Crystal Dragon", Quantity: 2, MaxPerOrder: 3},
+		{ProductID: "keychain", Name: "Keychain", Quantity: 25},
+		{ProductID: "dragon", Name: "Crystal Dragon", Quantity: 2, MaxPerOrder: 3},
+		{ProductID: "egg", Name: "Dragon Egg", Quantity: 2, MaxPerOrder: 2},
+	}
+
+	assert.Equal(t, []string{"Crystal Dragon is limited to 3 per order. Please remove 1 to check out."}, orderLimitProblems(rows),
+		"lines of the same product count together")
+	assert.Empty(t, orderLimitProblems(rows[1:3]))
+}
+
+func TestMinimumOrderProblem(t *testing.T) {
+	assert.Empty(t, minimumOrderProblem(500, 0), "no minimum")
+	assert.Empty(t, minimumOrderProblem(2500, 2500))
+	assert.Equal(t, "Orders must be at least $25.00 before shipping. Add $4.50 more to check out.", minimumOrderProblem(2050, 2500))
+}
+
+func TestMaxPerOrderAddToCart(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+
+	product, err := queries.CreateProduct(ctx, db.CreateProductParams{
+		ID:            "dragon-egg",
+		Name:          "Dragon Egg",
+		Slug:          "dragon-egg",
+		PriceCents:    1500,
+		StockQuantity: sql.NullInt64{Int64: 50, Valid: true},
+		IsActive:      sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, queries.UpdateProductDropSettings(ctx, db.UpdateProductDropSettingsParams{
+		MaxPerOrder: 3,
+		ID:          product.ID,
+	}))
+
+	addToCart := func(quantity int) int {
+		body := fmt.Sprintf(`{"productId":%q,"quantity":%d}`, product.ID, quantity)
+		req := httptest.NewRequest(http.MethodPost, "/api/cart/add", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "limit-session"})
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		var he *echo.HTTPError
+		if err := svc.handleAddToCart(c); errors.As(err, &he) {
+			return he.Code
+		}
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, addToCart(4))
+	assert.Equal(t, http.StatusOK, addToCart(2))
+	assert.Equal(t, http.StatusBadRequest, addToCart(2), "the cart already holds 2 of 3")
+	assert.Equal(t, http.StatusOK, addToCart(1))
+}
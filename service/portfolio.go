@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/portfolio"
+)
+
+// handlePortfolio shows the published portfolio gallery
+func (s *Service) handlePortfolio(c echo.Context) error {
+	items := s.loadPortfolioItems(c.Request().Context())
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Portfolio | Logan's 3D Creations"
+	meta.Description = "Explore our portfolio of 3D printing projects, custom designs, and creative works."
+	meta.Keywords = []string{"3D printing portfolio", "project gallery", "custom designs"}
+	meta.OGType = "website"
+	return Render(c, portfolio.Index(c, meta, items))
+}
+
+// loadPortfolioItems returns the published items with their images and linked
+// products, using one query for each. A failed lookup leaves that part empty rather
+// than failing the page.
+func (s *Service) loadPortfolioItems(ctx context.Context) []portfolio.ItemView {
+	items, err := s.storage.Queries.ListPublishedPortfolioItems(ctx)
+	if err != nil {
+		slog.Error("failed to fetch portfolio items", "error", err)
+		return nil
+	}
+
+	itemImages := make(map[string][]db.PortfolioItemImage)
+	images, err := s.storage.Queries.ListPublishedPortfolioImages(ctx)
+	if err != nil {
+		slog.Error("failed to fetch portfolio images", "error", err)
+	}
+	for _, img := range images {
+		itemImages[img.PortfolioItemID] = append(itemImages[img.PortfolioItemID], img)
+	}
+
+	itemProducts := make(map[string][]db.ListPublishedPortfolioProductsRow)
+	products, err := s.storage.Queries.ListPublishedPortfolioProducts(ctx)
+	if err != nil {
+		slog.Error("failed to fetch portfolio products", "error", err)
+	}
+	for _, product := range products {
+		itemProducts[product.PortfolioItemID] = append(itemProducts[product.PortfolioItemID], product)
+	}
+
+	views := make([]portfolio.ItemView, 0, len(items))
+	for _, item := range items {
+		views = append(views, portfolio.ItemView{
+			Item:     item,
+			Tags:     utils.ParsePortfolioTags(item.Tags),
+			Images:   itemImages[item.ID],
+			Products: itemProducts[item.ID],
+		})
+	}
+	return views
+}
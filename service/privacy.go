@@ -0,0 +1,284 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/privacy"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	stripeutil "github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/account"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+// privacyExportCooldown is how long after asking for a data export a customer waits
+// before asking for another, unless the last one failed
+const privacyExportCooldown = 24 * time.Hour
+
+// handleAccountPrivacy shows a customer's data exports and lets them delete their account
+func (s *Service) handleAccountPrivacy(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account/privacy")
+	}
+
+	page := account.Privacy{
+		Email:   user.Email,
+		IsAdmin: user.IsAdmin,
+		Flash:   c.QueryParam("flash"),
+		Error:   c.QueryParam("error"),
+	}
+	requests, err := s.storage.Queries.ListUserPrivacyRequests(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list privacy requests", "error", err, "user_id", user.ID)
+	}
+	page.Requests = requests
+
+	open, err := privacy.OpenOrders(ctx, s.storage.DB(), privacy.Subject{UserID: user.ID, Email: user.Email})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count open orders", "error", err, "user_id", user.ID)
+	}
+	page.OpenOrders = open
+
+	balance, err := storecredit.NewLedger(s.storage.Queries).Balance(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get store credit balance", "error", err, "user_id", user.ID)
+	}
+	page.StoreCreditCents = balance
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = "Privacy & Data - Logan's 3D Creations"
+	meta.Description = "Download your data or delete your account"
+
+	return Render(c, account.PrivacyPage(c, meta, page))
+}
+
+// handleAccountPrivacyExport asks for an export of everything the shop has on the
+// customer. The privacy exporter job makes it and emails them when it's ready.
+func (s *Service) handleAccountPrivacyExport(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url=/account/privacy")
+	}
+
+	requests, err := s.storage.Queries.ListUserPrivacyRequests(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list privacy requests", "error", err, "user_id", user.ID)
+		return privacyRedirect(c, "error", "We couldn't start your export. Please try again.")
+	}
+	for _, request := range requests {
+		if request.Kind != "export" || request.Status == "failed" {
+			continue
+		}
+		if request.Status == "pending" {
+			return privacyRedirect(c, "flash", "Your export is already being prepared. We'll email you when it's ready.")
+		}
+		if time.Since(request.RequestedAt) < privacyExportCooldown {
+			return privacyRedirect(c, "error", "You can ask for one export a day. Your latest one is below.")
+		}
+	}
+
+	id := uuid.New().String()
+	if err := s.storage.Queries.CreatePrivacyRequest(ctx, db.CreatePrivacyRequestParams{
+		ID:        id,
+		UserID:    user.ID,
+		EmailHash: privacy.EmailHash(user.Email),
+		Kind:      "export",
+		Status:    "pending",
+	}); err != nil {
+		slog.ErrorContext(ctx, "failed to create privacy export request", "error", err, "user_id", user.ID)
+		return privacyRedirect(c, "error", "We couldn't start your export. Please try again.")
+	}
+	slog.InfoContext(ctx, "privacy export requested", "request_id", id, "user_id", user.ID)
+	return privacyRedirect(c, "flash", "We're preparing your export. We'll email you when it's ready to download.")
+}
+
+// handleAccountPrivacyDownload sends a finished export to the customer it belongs to
+func (s *Service) handleAccountPrivacyDownload(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account/privacy")
+	}
+
+	request, err := s.storage.Queries.GetPrivacyRequest(ctx, c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Export not found")
+	}
+	if request.UserID != user.ID || request.Kind != "export" || request.Status != "completed" ||
+		request.FileName == "" || !request.ExpiresAt.Valid || time.Now().After(request.ExpiresAt.Time) {
+		return echo.NewHTTPError(http.StatusNotFound, "Export not found")
+	}
+
+	path := filepath.Join(s.config.Privacy.ExportDir, request.FileName)
+	if _, err := os.Stat(path); err != nil {
+		slog.ErrorContext(ctx, "privacy export file missing", "error", err, "request_id", request.ID)
+		return echo.NewHTTPError(http.StatusNotFound, "Export not found")
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	name := "logans3d-data-export-" + request.RequestedAt.Format("2006-01-02") + ".zip"
+	return c.Attachment(path, name)
+}
+
+// handleAccountDelete erases a customer's account. Their orders are kept for the
+// books with everything that names them removed; see docs/privacy.md. The compliance
+// log records the deletion and what was erased.
+func (s *Service) handleAccountDelete(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url=/account/privacy")
+	}
+	if !strings.EqualFold(strings.TrimSpace(c.FormValue("confirm_email")), user.Email) {
+		return privacyRedirect(c, "error", "Type your email address exactly to confirm deleting your account.")
+	}
+	if user.IsAdmin {
+		return privacyRedirect(c, "error", "Admin accounts can't be deleted here.")
+	}
+
+	subject := privacy.Subject{UserID: user.ID, Email: user.Email}
+	open, err := privacy.OpenOrders(ctx, s.storage.DB(), subject)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count open orders", "error", err, "user_id", user.ID)
+		return privacyRedirect(c, "error", "We couldn't delete your account. Please try again.")
+	}
+	if open > 0 {
+		return privacyRedirect(c, "error", "Your account can be deleted once your open orders have been delivered or cancelled.")
+	}
+
+	requestID := uuid.New().String()
+	emailHash := privacy.EmailHash(user.Email)
+	if err := s.eraseAccount(c, requestID, emailHash, subject); err != nil {
+		slog.ErrorContext(ctx, "failed to delete account", "error", err, "user_id", user.ID)
+		if err := s.storage.Queries.CreatePrivacyRequest(ctx, db.CreatePrivacyRequestParams{
+			ID:          requestID,
+			UserID:      user.ID,
+			EmailHash:   emailHash,
+			Kind:        "deletion",
+			Status:      "failed",
+			Error:       err.Error(),
+			CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		}); err != nil {
+			slog.ErrorContext(ctx, "failed to record failed account deletion", "error", err, "user_id", user.ID)
+		}
+		return privacyRedirect(c, "error", "We couldn't delete your account. Please try again.")
+	}
+	slog.InfoContext(ctx, "account deleted", "request_id", requestID, "user_id", user.ID)
+
+	// The account is gone; what's left is outside the database, and failures are
+	// noted on the request for an admin to finish by hand
+	var problems []string
+	s.removePrivacyExports(c, user.ID)
+	if user.ClerkID.Valid && user.ClerkID.String != "" {
+		if err := auth.DeleteClerkUser(ctx, user.ClerkID.String); err != nil {
+			slog.ErrorContext(ctx, "failed to delete clerk user", "error", err, "request_id", requestID)
+			problems = append(problems, "clerk: "+err.Error())
+		}
+	}
+	if user.StripeCustomerID != "" {
+		if err := stripeutil.DeleteShopCustomer(user.StripeCustomerID); err != nil {
+			slog.ErrorContext(ctx, "failed to delete stripe customer", "error", err, "request_id", requestID)
+			problems = append(problems, "stripe: "+err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		if err := s.storage.Queries.NotePrivacyRequestError(ctx, db.NotePrivacyRequestErrorParams{
+			Error: strings.Join(problems, "; "),
+			ID:    requestID,
+		}); err != nil {
+			slog.ErrorContext(ctx, "failed to note account deletion problems", "error", err, "request_id", requestID)
+		}
+	}
+
+	name := user.FirstName.String
+	if name == "" {
+		name = user.FullName
+	}
+	go func() {
+		if err := s.emailService.SendAccountDeleted(&email.AccountDeletedData{
+			CustomerName:  name,
+			CustomerEmail: user.Email,
+		}); err != nil {
+			slog.Error("failed to send account deleted email", "error", err, "request_id", requestID)
+		}
+	}()
+
+	// Signing out clears the Clerk session cookies the browser still holds
+	return c.Redirect(http.StatusSeeOther, "/logout")
+}
+
+// eraseAccount erases the customer's data and logs the deletion in one transaction,
+// so the log never claims a deletion that was rolled back
+func (s *Service) eraseAccount(c echo.Context, requestID, emailHash string, subject privacy.Subject) error {
+	ctx := c.Request().Context()
+	tx, err := s.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	summary, err := privacy.Erase(ctx, tx, subject)
+	if err != nil {
+		return err
+	}
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.Queries.WithTx(tx).CreatePrivacyRequest(ctx, db.CreatePrivacyRequestParams{
+		ID:          requestID,
+		UserID:      subject.UserID,
+		EmailHash:   emailHash,
+		Kind:        "deletion",
+		Status:      "completed",
+		SummaryJson: string(summaryJSON),
+		CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// removePrivacyExports deletes a deleted account's export files rather than leaving
+// them until they expire
+func (s *Service) removePrivacyExports(c echo.Context, userID string) {
+	ctx := c.Request().Context()
+	requests, err := s.storage.Queries.ListUserPrivacyRequests(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list privacy exports to remove", "error", err, "user_id", userID)
+		return
+	}
+	for _, request := range requests {
+		if request.FileName == "" {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.config.Privacy.ExportDir, request.FileName))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.ErrorContext(ctx, "failed to remove privacy export", "error", err, "request_id", request.ID)
+			continue
+		}
+		if err := s.storage.Queries.ClearPrivacyExportFile(ctx, request.ID); err != nil {
+			slog.ErrorContext(ctx, "failed to clear privacy export", "error", err, "request_id", request.ID)
+		}
+	}
+}
+
+func privacyRedirect(c echo.Context, key, message string) error {
+	return c.Redirect(http.StatusSeeOther, "/account/privacy?"+key+"="+url.QueryEscape(message))
+}
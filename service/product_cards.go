@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/loganlanou/logans3d-v4/internal/badges"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// productBadges returns the badges for each product, keyed by product ID. Badges are
+// decoration, so if they can't be loaded the products just show none.
+func (s *Service) productBadges(ctx context.Context, products []db.Product) map[string][]badges.Badge {
+	result, err := badges.Load(ctx, s.storage.Queries, products)
+	if err != nil {
+		slog.Error("failed to load product badges", "count", len(products), "error", err)
+		return nil
+	}
+	return result
+}
+
+// withCardVariants adds price ranges, style swatches and SKU stock to the cards of
+// variant products. It runs two queries for the whole list rather than one per card;
+// if they fail the cards fall back to the product's own price and stock.
+func (s *Service) withCardVariants(ctx context.Context, products []shop.ProductWithImage) []shop.ProductWithImage {
+	var productIDs []string
+	for _, p := range products {
+		if p.Product.HasVariants.Valid && p.Product.HasVariants.Bool {
+			productIDs = append(productIDs, p.Product.ID)
+		}
+	}
+	if len(productIDs) == 0 {
+		return products
+	}
+
+	ranges, err := s.storage.Queries.ListProductCardPriceRanges(ctx, productIDs)
+	if err != nil {
+		slog.Error("failed to load product card price ranges", "error", err)
+		return products
+	}
+	swatches, err := s.storage.Queries.ListProductCardSwatches(ctx, productIDs)
+	if err != nil {
+		slog.Error("failed to load product card swatches", "error", err)
+		return products
+	}
+
+	variants := productCardVariants(ranges, swatches)
+	for i := range products {
+		products[i].Variants = variants[products[i].Product.ID]
+	}
+	return products
+}
+
+// productCardVariants groups the batched card rows by product. Products without an
+// active SKU get no entry and keep the plain card.
+func productCardVariants(ranges []db.ListProductCardPriceRangesRow, swatches []db.ListProductCardSwatchesRow) map[string]*shop.ProductCardVariants {
+	variants := make(map[string]*shop.ProductCardVariants, len(ranges))
+	for _, r := range ranges {
+		if r.SkuCount == 0 {
+			continue
+		}
+		variants[r.ProductID] = &shop.ProductCardVariants{
+			MinPriceCents:        r.MinPriceCents,
+			MaxPriceCents:        r.MaxPriceCents,
+			RegularMinPriceCents: r.RegularMinPriceCents,
+			InStock:              r.InStockSkuCount > 0,
+		}
+	}
+
+	for _, sw := range swatches {
+		v, ok := variants[sw.ProductID]
+		if !ok {
+			continue
+		}
+		imageURL := ""
+		if sw.ImageUrl != "" {
+			imageURL = fmt.Sprintf("/public/images/products/styles/%s", sw.ImageUrl)
+		}
+		v.Swatches = append(v.Swatches, shop.CardSwatch{
+			Name:     sw.StyleName,
+			ImageURL: imageURL,
+			InStock:  sw.InStockSkuCount > 0,
+		})
+	}
+	return variants
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+func TestProductCardVariants(t *testing.T) {
+	variants := productCardVariants(
+		[]db.ListProductCardPriceRangesRow{
+			{ProductID: "rex", MinPriceCents: 1500, MaxPriceCents: 2500, RegularMinPriceCents: 1800, SkuCount: 4, InStockSkuCount: 1},
+			{ProductID: "raptor", MinPriceCents: 1200, MaxPriceCents: 1200, SkuCount: 2, InStockSkuCount: 0},
+		},
+		[]db.ListProductCardSwatchesRow{
+			{ProductID: "rex", StyleName: "Green", ImageUrl: "rex_green.jpg", InStockSkuCount: 1},
+			{ProductID: "rex", StyleName: "Red", InStockSkuCount: 0},
+			{ProductID: "raptor", StyleName: "Blue", ImageUrl: "raptor_blue.jpg"},
+			{ProductID: "unknown", StyleName: "Gold"},
+		},
+	)
+
+	require.Len(t, variants, 2)
+
+	rex := variants["rex"]
+	assert.Equal(t, int64(1500), rex.MinPriceCents)
+	assert.Equal(t, int64(2500), rex.MaxPriceCents)
+	assert.Equal(t, int64(1800), rex.RegularMinPriceCents)
+	assert.True(t, rex.InStock)
+	assert.Equal(t, []shop.CardSwatch{
+		{Name: "Green", ImageURL: "/public/images/products/styles/rex_green.jpg", InStock: true},
+		{Name: "Red"},
+	}, rex.Swatches)
+
+	assert.False(t, variants["raptor"].InStock)
+	assert.Len(t, variants["raptor"].Swatches, 1)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/recaptcha"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+const (
+	minQuestionLength = 10
+	maxQuestionLength = 1000
+	// maxQuestionsPerDay limits how many questions a single account can ask in 24 hours
+	maxQuestionsPerDay = 5
+)
+
+// handleProductQuestionSubmit queues a shopper's question for an admin answer
+func (s *Service) handleProductQuestionSubmit(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	productURL := fmt.Sprintf("/shop/product/%s", slug)
+
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url="+productURL)
+	}
+
+	product, err := s.storage.Queries.GetProductBySlug(ctx, slug)
+	if err != nil {
+		slog.Info("question submitted for unknown product", "slug", slug)
+		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+	}
+
+	question := strings.TrimSpace(c.FormValue("question"))
+	if length := utf8.RuneCountInString(question); length < minQuestionLength || length > maxQuestionLength {
+		return c.Redirect(http.StatusSeeOther, productURL+"?question=invalid#questions")
+	}
+
+	valid, score, err := recaptcha.IsValid(c.FormValue("g-recaptcha-response"))
+	if err != nil {
+		slog.Error("recaptcha verification error", "error", err, "user_id", user.ID)
+		return c.Redirect(http.StatusSeeOther, productURL+"?question=captcha#questions")
+	}
+	if !valid {
+		slog.Warn("recaptcha verification failed for product question", "score", score, "user_id", user.ID)
+		return c.Redirect(http.StatusSeeOther, productURL+"?question=captcha#questions")
+	}
+
+	recent, err := s.storage.Queries.CountRecentProductQuestionsByUser(ctx, sql.NullString{String: user.ID, Valid: true})
+	if err != nil {
+		slog.Error("failed to count recent product questions", "error", err, "user_id", user.ID)
+		return c.Redirect(http.StatusSeeOther, productURL+"?question=error#questions")
+	}
+	if recent >= maxQuestionsPerDay {
+		return c.Redirect(http.StatusSeeOther, productURL+"?question=limit#questions")
+	}
+
+	askerName := user.FullName
+	if user.FirstName.Valid && user.FirstName.String != "" {
+		askerName = user.FirstName.String
+	}
+
+	created, err := s.storage.Queries.CreateProductQuestion(ctx, db.CreateProductQuestionParams{
+		ID:         ulid.Make().String(),
+		ProductID:  product.ID,
+		UserID:     sql.NullString{String: user.ID, Valid: true},
+		AskerName:  askerName,
+		AskerEmail: user.Email,
+		Question:   question,
+	})
+	if err != nil {
+		slog.Error("failed to create product question", "error", err, "product_id", product.ID, "user_id", user.ID)
+		return c.Redirect(http.StatusSeeOther, productURL+"?question=error#questions")
+	}
+
+	slog.Info("product question submitted", "question_id", created.ID, "product_id", product.ID, "user_id", user.ID)
+
+	go func() {
+		emailData := &email.ProductQuestionData{
+			ID:          created.ID,
+			ProductName: product.Name,
+			ProductURL:  layout.BuildAbsoluteURL(s.config.BaseURL, productURL),
+			AskerName:   created.AskerName,
+			AskerEmail:  created.AskerEmail,
+			Question:    created.Question,
+			SubmittedAt: time.Now().Format("January 2, 2006 at 3:04 PM MST"),
+		}
+		if err := s.emailService.SendProductQuestionNotification(emailData); err != nil {
+			slog.Error("failed to send product question notification", "error", err, "question_id", created.ID)
+		}
+	}()
+
+	return c.Redirect(http.StatusSeeOther, productURL+"?question=submitted#questions")
+}
+
+// productFAQItems converts published questions into FAQ schema entries
+func productFAQItems(questions []db.ProductQuestion) []layout.FAQItem {
+	items := make([]layout.FAQItem, 0, len(questions))
+	for _, q := range questions {
+		if !q.Answer.Valid || q.Answer.String == "" {
+			continue
+		}
+		items = append(items, layout.FAQItem{Question: q.Question, Answer: q.Answer.String})
+	}
+	return items
+}
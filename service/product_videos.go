@@ -0,0 +1,22 @@
+package service
+
+import (
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// productVideoViews builds the gallery videos for the product page
+func productVideoViews(videos []db.ProductVideo) []shop.ProductVideo {
+	views := make([]shop.ProductVideo, 0, len(videos))
+	for _, video := range videos {
+		views = append(views, shop.ProductVideo{
+			Title:       video.Title,
+			Uploaded:    video.Provider == utils.VideoProviderUpload,
+			EmbedURL:    utils.VideoEmbedURL(video.Provider, video.VideoRef),
+			PosterURL:   utils.VideoPosterURL(video.Provider, video.VideoRef, video.PosterUrl),
+			ContentType: video.ContentType,
+		})
+	}
+	return views
+}
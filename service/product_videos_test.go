@@ -0,0 +1,24 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func TestProductVideoViews(t *testing.T) {
+	views := productVideoViews([]db.ProductVideo{
+		{Provider: "youtube", VideoRef: "dQw4w9WgXcQ", Title: "Timelapse", ContentType: "text/html"},
+		{Provider: "upload", VideoRef: "p1_v2.mp4", PosterUrl: "/public/images/products/p1_video_v2.jpg", ContentType: "video/mp4"},
+	})
+
+	assert.Len(t, views, 2)
+	assert.False(t, views[0].Uploaded)
+	assert.Equal(t, "https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ?rel=0", views[0].EmbedURL)
+	assert.Equal(t, "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg", views[0].PosterURL)
+	assert.True(t, views[1].Uploaded)
+	assert.Equal(t, "/public/videos/products/p1_v2.mp4", views[1].EmbedURL)
+	assert.Equal(t, "/public/images/products/p1_video_v2.jpg", views[1].PosterURL)
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/cartpromos"
+	"github.com/loganlanou/logans3d-v4/internal/promocodes"
+	stripeutil "github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/stripe/stripe-go/v80"
+)
+
+// appliedPromoCode is a promotion code checked against the cart
+type appliedPromoCode struct {
+	Code string
+	// PromotionCodeID is the code's promotion_codes row, empty for a code only Stripe knows
+	PromotionCodeID string
+	// StripePromotionCodeID is set when Stripe works out the discount itself, for
+	// codes made in the Stripe Dashboard. DiscountCents is then only an estimate.
+	StripePromotionCodeID string
+	DiscountCents         int64
+	// DropCartPromotion is set when the code is used instead of the cart's automatic
+	// promotion discount
+	DropCartPromotion bool
+}
+
+// promoCodeLines prices the cart lines the way a code sees them: after quantity
+// breaks, bundle pricing and the automatic cart promotion, counting only paid units.
+// Lines any of those discount are sale items. Gift cards are never discounted.
+func promoCodeLines(rows []db.GetCartByUserRow, volumePrices map[string]volumePrice, promos cartpromos.Result) []promocodes.Line {
+	lines := make([]promocodes.Line, 0, len(rows))
+	for _, row := range rows {
+		if row.IsGiftCard {
+			continue
+		}
+		line := promocodes.Line{
+			ItemID:         row.ID,
+			CategoryID:     row.CategoryID,
+			Quantity:       row.Quantity,
+			UnitPriceCents: row.PriceCents,
+			Sale:           row.BundleID != "",
+		}
+		if row.BundleID != "" {
+			line.UnitPriceCents = row.BundleUnitPriceCents
+		} else if vp, ok := volumePrices[row.ID]; ok && vp.UnitPriceCents < line.UnitPriceCents {
+			line.UnitPriceCents = vp.UnitPriceCents
+			line.Sale = true
+		}
+		if lp, ok := promos.Lines[row.ID]; ok {
+			line.UnitPriceCents = lp.UnitPriceCents
+			line.Quantity -= lp.FreeQuantity
+			line.Sale = true
+		}
+		if line.Quantity > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// checkPromoCode checks a code entered in the cart against its campaign's rules and
+// works out what it takes off. Reasons the shopper can't use the code come back as a
+// *promocodes.Error to show them; other errors are failures to check.
+func (s *Service) checkPromoCode(ctx context.Context, input, userID, email string, rows []db.GetCartByUserRow, volumePrices map[string]volumePrice, promos cartpromos.Result) (appliedPromoCode, error) {
+	input = strings.TrimSpace(input)
+	record, err := s.storage.Queries.FindPromotionCode(ctx, input)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.checkStripePromoCode(input, promoCodeLines(rows, volumePrices, promos))
+	}
+	if err != nil {
+		return appliedPromoCode{}, fmt.Errorf("failed to look up promotion code: %w", err)
+	}
+
+	campaign, err := s.storage.Queries.GetPromotionCampaignByID(ctx, record.CampaignID)
+	if err != nil {
+		return appliedPromoCode{}, fmt.Errorf("failed to load promotion campaign: %w", err)
+	}
+	if err := promocodes.CheckCode(record, campaign, time.Now()); err != nil {
+		return appliedPromoCode{}, err
+	}
+
+	if promos.Applied != nil && campaign.Stacking == promocodes.StackingNever {
+		return appliedPromoCode{}, &promocodes.Error{
+			Message: fmt.Sprintf("%s can't be combined with %s", record.Code, promos.Applied.Name),
+		}
+	}
+
+	if campaign.MaxUsesPerCustomer > 0 {
+		// Guests only give Stripe their email after the session is made, too late to check
+		if userID == "" {
+			return appliedPromoCode{}, &promocodes.Error{Message: fmt.Sprintf("Sign in to use %s", record.Code)}
+		}
+		used, err := s.storage.Queries.CountCustomerCampaignOrders(ctx, db.CountCustomerCampaignOrdersParams{
+			CampaignID: campaign.ID,
+			UserID:     userID,
+			Email:      email,
+		})
+		if err != nil {
+			return appliedPromoCode{}, fmt.Errorf("failed to count customer promotion uses: %w", err)
+		}
+		if used >= campaign.MaxUsesPerCustomer {
+			return appliedPromoCode{}, &promocodes.Error{
+				Message: fmt.Sprintf("You've already used %s as many times as it allows", record.Code),
+			}
+		}
+	}
+
+	applied := appliedPromoCode{
+		Code:              record.Code,
+		PromotionCodeID:   record.ID,
+		DropCartPromotion: promos.Applied != nil && campaign.Stacking == promocodes.StackingReplace,
+	}
+	if applied.DropCartPromotion {
+		promos = promos.WithoutDiscount()
+	}
+	lines := promoCodeLines(rows, volumePrices, promos)
+
+	// Codes recorded from the Stripe Dashboard have a variable discount only Stripe knows
+	if campaign.DiscountValue <= 0 && record.StripePromotionCodeID.Valid {
+		applied.StripePromotionCodeID = record.StripePromotionCodeID.String
+		return applied, nil
+	}
+
+	excluded, err := s.storage.Queries.ListCampaignExcludedCategories(ctx, campaign.ID)
+	if err != nil {
+		return appliedPromoCode{}, fmt.Errorf("failed to load excluded categories: %w", err)
+	}
+	rules := promocodes.Rules{
+		ExcludeSaleItems:   campaign.ExcludeSaleItems,
+		ExcludedCategories: make(map[string]bool, len(excluded)),
+	}
+	for _, categoryID := range excluded {
+		rules.ExcludedCategories[categoryID] = true
+	}
+
+	applied.DiscountCents = promocodes.Discount(campaign.DiscountType, campaign.DiscountValue, rules.Eligible(lines))
+	if applied.DiscountCents == 0 {
+		message := fmt.Sprintf("Nothing in your cart qualifies for %s", record.Code)
+		if campaign.ExcludeSaleItems {
+			message += "; items already on sale are excluded"
+		}
+		return appliedPromoCode{}, &promocodes.Error{Message: message}
+	}
+	return applied, nil
+}
+
+// checkStripePromoCode checks a code made in the Stripe Dashboard and not yet used
+// here. Stripe applies it with its own rules; the discount is estimated for the cart.
+func (s *Service) checkStripePromoCode(code string, lines []promocodes.Line) (appliedPromoCode, error) {
+	stripe.Key = s.config.Stripe.SecretKey
+	stripeCode, err := stripeutil.ValidatePromotionCode(code)
+	if err != nil {
+		slog.Info("promotion code not accepted", "code", code, "reason", err)
+		return appliedPromoCode{}, &promocodes.Error{Message: fmt.Sprintf("%s isn't a valid code", code)}
+	}
+
+	applied := appliedPromoCode{Code: stripeCode.Code, StripePromotionCodeID: stripeCode.ID}
+	if coupon := stripeCode.Coupon; coupon != nil {
+		if coupon.PercentOff > 0 {
+			applied.DiscountCents = promocodes.Discount(promocodes.TypePercentage, int64(coupon.PercentOff), lines)
+		} else {
+			applied.DiscountCents = promocodes.Discount(promocodes.TypeFixedAmount, coupon.AmountOff, lines)
+		}
+	}
+	return applied, nil
+}
+
+// handleApplyPromoCode checks a promotion code against the current cart so the cart
+// can show the discount, or why the code can't be used, before checkout
+func (s *Service) handleApplyPromoCode(c echo.Context) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Enter a promo code"})
+	}
+
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+	var email string
+	if user, ok := auth.GetDBUser(c); ok {
+		email = user.Email
+	}
+	ctx := c.Request().Context()
+
+	rows, err := s.ownerCartRows(ctx, owner)
+	if err != nil {
+		slog.Error("failed to get cart for promo code", "error", err, "user_id", owner.UserID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Unable to check the code right now"})
+	}
+	if len(rows) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Your cart is empty"})
+	}
+
+	volumePrices := s.cartVolumePrices(ctx, cartVolumeLines(rows))
+	promos := s.cartPromotions(ctx, cartPromotionLines(rows, volumePrices))
+	applied, err := s.checkPromoCode(ctx, req.Code, owner.UserID, email, rows, volumePrices, promos)
+	var codeErr *promocodes.Error
+	if errors.As(err, &codeErr) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": codeErr.Message})
+	}
+	if err != nil {
+		slog.Error("failed to check promo code", "error", err, "code", req.Code)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Unable to check the code right now"})
+	}
+
+	response := map[string]any{
+		"code":           applied.Code,
+		"discount_cents": applied.DiscountCents,
+		"estimated":      applied.StripePromotionCodeID != "",
+	}
+	if applied.DropCartPromotion {
+		response["replaces_promotion"] = promos.Applied.Name
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// cartVolumeLines describes the cart rows for working out quantity breaks
+func cartVolumeLines(rows []db.GetCartByUserRow) []volumeLine {
+	lines := make([]volumeLine, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, volumeLine{
+			ItemID:          row.ID,
+			ProductID:       row.ProductID,
+			SkuID:           row.ProductSkuID.String,
+			Quantity:        row.Quantity,
+			RegularCents:    row.PriceCents,
+			AdjustmentCents: row.PriceAdjustmentCents,
+			Bundle:          row.BundleID != "",
+		})
+	}
+	return lines
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// skipPublicRateLimit leaves page views alone so only the JSON API and form posts
+// are throttled. Admin and developer tools sit behind RequireAdmin, and the Stripe
+// and Twilio webhooks come from their servers, so none of those are counted.
+func skipPublicRateLimit(c echo.Context) bool {
+	path := c.Request().URL.Path
+	switch {
+	case strings.HasPrefix(path, "/admin"), strings.HasPrefix(path, "/dev"):
+		return true
+	case path == "/api/stripe/webhook", path == "/api/twilio/sms":
+		return true
+	case strings.HasPrefix(path, "/api/"):
+		return false
+	}
+
+	method := c.Request().Method
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (s *Service) handleDevRateLimits(c echo.Context) error {
+	return Render(c, admin.DevRateLimits(c, s.publicThrottle.Stats()))
+}
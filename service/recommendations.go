@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// recommendationRailSize is the number of products shown in each recommendation rail
+const recommendationRailSize = 4
+
+// productViewer identifies the shopper for view tracking: the signed-in user and/or the cart session
+type productViewer struct {
+	UserID    sql.NullString
+	SessionID sql.NullString
+}
+
+func (s *Service) currentViewer(c echo.Context) productViewer {
+	var viewer productViewer
+	if user, ok := auth.GetDBUser(c); ok {
+		viewer.UserID = sql.NullString{String: user.ID, Valid: true}
+	}
+	if sessionID, err := s.getOrCreateSessionID(c); err == nil && sessionID != "" {
+		viewer.SessionID = sql.NullString{String: sessionID, Valid: true}
+	}
+	return viewer
+}
+
+// recordProductView stores a product page view, replacing the viewer's earlier view of the same product
+func (s *Service) recordProductView(ctx context.Context, viewer productViewer, productID string) {
+	if !viewer.UserID.Valid && !viewer.SessionID.Valid {
+		return
+	}
+
+	if err := s.storage.Queries.DeleteProductViewForViewer(ctx, db.DeleteProductViewForViewerParams{
+		ProductID: productID,
+		UserID:    viewer.UserID,
+		SessionID: viewer.SessionID,
+	}); err != nil {
+		slog.Error("failed to clear previous product view", "error", err, "product_id", productID)
+		return
+	}
+
+	// Signed-in views belong to the account so they follow the shopper across devices
+	sessionID := viewer.SessionID
+	if viewer.UserID.Valid {
+		sessionID = sql.NullString{}
+	}
+	if err := s.storage.Queries.RecordProductView(ctx, db.RecordProductViewParams{
+		ID:        ulid.Make().String(),
+		ProductID: productID,
+		SessionID: sessionID,
+		UserID:    viewer.UserID,
+	}); err != nil {
+		slog.Error("failed to record product view", "error", err, "product_id", productID)
+	}
+}
+
+// loadRecentlyViewed returns the viewer's recently viewed products, excluding excludeProductID
+func (s *Service) loadRecentlyViewed(ctx context.Context, viewer productViewer, excludeProductID string) []shop.ProductWithImage {
+	products, err := s.storage.Queries.ListRecentlyViewedProducts(ctx, db.ListRecentlyViewedProductsParams{
+		UserID:           viewer.UserID,
+		SessionID:        viewer.SessionID,
+		ExcludeProductID: excludeProductID,
+		Limit:            recommendationRailSize,
+	})
+	if err != nil {
+		slog.Warn("failed to fetch recently viewed products", "error", err)
+		return nil
+	}
+	return s.withProductImages(ctx, products)
+}
+
+// loadFrequentlyBoughtTogether returns products most often ordered alongside productID
+func (s *Service) loadFrequentlyBoughtTogether(ctx context.Context, productID string) []shop.ProductWithImage {
+	products, err := s.storage.Queries.ListFrequentlyBoughtTogether(ctx, db.ListFrequentlyBoughtTogetherParams{
+		ProductID: productID,
+		Limit:     recommendationRailSize,
+	})
+	if err != nil {
+		slog.Warn("failed to fetch frequently bought together products", "error", err, "product_id", productID)
+		return nil
+	}
+	return s.withProductImages(ctx, products)
+}
+
+// loadPairsWellWith returns the products hand-picked to go with productID, falling back
+// to co-purchase recommendations when none are set. curated reports which was used.
+func (s *Service) loadPairsWellWith(ctx context.Context, productID string) (products []shop.ProductWithImage, curated bool) {
+	if related := s.loadRelatedProducts(ctx, productID, utils.RelationTypeCrossSell); len(related) > 0 {
+		return related, true
+	}
+	return s.loadFrequentlyBoughtTogether(ctx, productID), false
+}
+
+// loadRelatedProducts returns the active products an admin related to productID with relationType
+func (s *Service) loadRelatedProducts(ctx context.Context, productID, relationType string) []shop.ProductWithImage {
+	products, err := s.storage.Queries.ListRelatedProductsByType(ctx, db.ListRelatedProductsByTypeParams{
+		ProductID:    productID,
+		RelationType: relationType,
+		Limit:        recommendationRailSize,
+	})
+	if err != nil {
+		slog.Warn("failed to fetch related products", "error", err, "product_id", productID, "relation_type", relationType)
+		return nil
+	}
+	return s.withProductImages(ctx, products)
+}
+
+// loadCartRecommendations returns products frequently bought with the viewer's cart contents
+func (s *Service) loadCartRecommendations(ctx context.Context, viewer productViewer) []shop.ProductWithImage {
+	products, err := s.storage.Queries.ListFrequentlyBoughtWithCart(ctx, db.ListFrequentlyBoughtWithCartParams{
+		UserID:    viewer.UserID,
+		SessionID: viewer.SessionID,
+		Limit:     recommendationRailSize,
+	})
+	if err != nil {
+		slog.Warn("failed to fetch cart recommendations", "error", err)
+		return nil
+	}
+	return s.withProductImages(ctx, products)
+}
+
+// withProductImages builds product cards, loading every card image in one query along
+// with badges and variant details
+func (s *Service) withProductImages(ctx context.Context, products []db.Product) []shop.ProductWithImage {
+	imageURLs := s.catalog().ProductImageURLs(ctx, products)
+	productBadges := s.productBadges(ctx, products)
+	result := make([]shop.ProductWithImage, 0, len(products))
+	for _, product := range products {
+		result = append(result, shop.ProductWithImage{
+			Product:  product,
+			ImageURL: imageURLs[product.ID],
+			Badges:   productBadges[product.ID],
+		})
+	}
+	return s.withCardVariants(ctx, result)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/loganlanou/logans3d-v4/internal/handlers"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+func productIDs(products []shop.ProductWithImage) []string {
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.Product.ID)
+	}
+	return ids
+}
+
+func createRecommendationTestProduct(t *testing.T, queries *db.Queries, id string) db.Product {
+	t.Helper()
+	product, err := queries.CreateProduct(context.Background(), db.CreateProductParams{
+		ID:         id,
+		Name:       id,
+		Slug:       id,
+		PriceCents: 1000,
+		IsActive:   sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	return product
+}
+
+func createRecommendationTestOrder(t *testing.T, queries *db.Queries, user *db.User, id, status string, products ...db.Product) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := queries.CreateOrder(ctx, db.CreateOrderParams{
+		ID:                   id,
+		UserID:               user.ID,
+		CustomerEmail:        user.Email,
+		CustomerName:         "Test Customer",
+		ShippingAddressLine1: "1 Main St",
+		ShippingCity:         "Madison",
+		ShippingState:        "WI",
+		ShippingPostalCode:   "53703",
+		ShippingCountry:      "US",
+		Status:               sql.NullString{String: status, Valid: true},
+	})
+	require.NoError(t, err)
+	for _, p := range products {
+		_, err := queries.CreateOrderItem(ctx, db.CreateOrderItemParams{
+			ID:          id + "-" + p.ID,
+			OrderID:     id,
+			ProductID:   p.ID,
+			Quantity:    1,
+			ProductName: p.Name,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestRecentlyViewedProducts(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+
+	dragon := createRecommendationTestProduct(t, queries, "dragon")
+	robot := createRecommendationTestProduct(t, queries, "robot")
+	viewer := productViewer{SessionID: sql.NullString{String: "session-views", Valid: true}}
+
+	svc.recordProductView(ctx, viewer, dragon.ID)
+	svc.recordProductView(ctx, viewer, robot.ID)
+	_, err := svc.storage.Queries.PruneProductViews(ctx)
+	require.NoError(t, err)
+
+	// Re-viewing a product keeps a single entry per product
+	svc.recordProductView(ctx, viewer, dragon.ID)
+	recent := svc.loadRecentlyViewed(ctx, viewer, "")
+	assert.ElementsMatch(t, []string{dragon.ID, robot.ID}, productIDs(recent))
+
+	// The current product is left out of its own rail
+	recent = svc.loadRecentlyViewed(ctx, viewer, robot.ID)
+	assert.Equal(t, []string{dragon.ID}, productIDs(recent))
+
+	// Another session sees nothing
+	other := productViewer{SessionID: sql.NullString{String: "session-other", Valid: true}}
+	assert.Empty(t, svc.loadRecentlyViewed(ctx, other, ""))
+}
+
+func TestFrequentlyBoughtTogether(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+
+	user, err := handlers.CreateTestUser(queries)
+	require.NoError(t, err)
+
+	dragon := createRecommendationTestProduct(t, queries, "dragon")
+	stand := createRecommendationTestProduct(t, queries, "stand")
+	robot := createRecommendationTestProduct(t, queries, "robot")
+
+	createRecommendationTestOrder(t, queries, user, "order-1", "delivered", dragon, stand)
+	createRecommendationTestOrder(t, queries, user, "order-2", "received", dragon, stand, robot)
+	createRecommendationTestOrder(t, queries, user, "order-3", "cancelled", dragon, robot)
+
+	require.NoError(t, queries.ClearProductRecommendations(ctx))
+	require.NoError(t, queries.RebuildProductRecommendations(ctx))
+
+	// The stand was bought with the dragon twice, the robot once (cancelled orders don't count)
+	assert.Equal(t, []string{stand.ID, robot.ID}, productIDs(svc.loadFrequentlyBoughtTogether(ctx, dragon.ID)))
+	assert.Equal(t, []string{dragon.ID, stand.ID}, productIDs(svc.loadFrequentlyBoughtTogether(ctx, robot.ID)))
+}
+
+func TestPairsWellWith(t *testing.T) {
+	svc := setupTestService(t)
+	queries := svc.storage.Queries
+	ctx := context.Background()
+
+	dragon := createRecommendationTestProduct(t, queries, "dragon")
+	stand := createRecommendationTestProduct(t, queries, "stand")
+	egg := createRecommendationTestProduct(t, queries, "egg")
+	bigDragon := createRecommendationTestProduct(t, queries, "big-dragon")
+
+	user, err := handlers.CreateTestUser(queries)
+	require.NoError(t, err)
+	createRecommendationTestOrder(t, queries, user, "order-1", "delivered", dragon, stand)
+	require.NoError(t, queries.RebuildProductRecommendations(ctx))
+
+	// Without hand-picked products the co-purchase pairs are used
+	products, curated := svc.loadPairsWellWith(ctx, dragon.ID)
+	assert.False(t, curated)
+	assert.Equal(t, []string{stand.ID}, productIDs(products))
+
+	relate := func(related db.Product, relationType string, order int64) {
+		require.NoError(t, queries.UpsertProductRelation(ctx, db.UpsertProductRelationParams{
+			ProductID:        dragon.ID,
+			RelatedProductID: related.ID,
+			RelationType:     relationType,
+			DisplayOrder:     order,
+		}))
+	}
+	relate(egg, utils.RelationTypeCrossSell, 2)
+	relate(stand, utils.RelationTypeCrossSell, 1)
+	relate(bigDragon, utils.RelationTypeUpsell, 0)
+
+	// Hand-picked products replace them, in the admin's order
+	products, curated = svc.loadPairsWellWith(ctx, dragon.ID)
+	assert.True(t, curated)
+	assert.Equal(t, []string{stand.ID, egg.ID}, productIDs(products))
+	assert.Equal(t, []string{bigDragon.ID}, productIDs(svc.loadRelatedProducts(ctx, dragon.ID, utils.RelationTypeUpsell)))
+
+	// Re-picking a product moves it rather than adding it twice
+	relate(egg, utils.RelationTypeUpsell, 0)
+	products, _ = svc.loadPairsWellWith(ctx, dragon.ID)
+	assert.Equal(t, []string{stand.ID}, productIDs(products))
+
+	// Inactive products are left out
+	_, err = queries.ToggleProductActive(ctx, stand.ID)
+	require.NoError(t, err)
+	products, curated = svc.loadPairsWellWith(ctx, dragon.ID)
+	assert.False(t, curated)
+	assert.Empty(t, products)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/metrics"
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// metricsWindows are the rolling windows offered on the developer panel, in minutes
+var metricsWindows = []int{5, 15, 60}
+
+// RequestMetrics is the request logging middleware. It logs each request once and
+// records it for /metrics and the developer panel, so main.go registers it first.
+func (s *Service) RequestMetrics() echo.MiddlewareFunc {
+	return metrics.Middleware(s.requestMetrics, auth.GetUserID)
+}
+
+// handleMetrics serves the Prometheus scrape. Scrapers send METRICS_TOKEN as a
+// bearer token; admins can open it in the browser.
+func (s *Service) handleMetrics(c echo.Context) error {
+	if !s.metricsAuthorized(c) {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Metrics require a token")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, metrics.ContentType)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().WriteHeader(http.StatusOK)
+	return s.requestMetrics.WritePrometheus(c.Response())
+}
+
+func (s *Service) metricsAuthorized(c echo.Context) bool {
+	if auth.IsAdmin(c) {
+		return true
+	}
+	token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	return ok && s.config.Metrics.Token != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Metrics.Token)) == 1
+}
+
+func (s *Service) handleDevMetrics(c echo.Context) error {
+	window := metricsWindows[1]
+	if minutes, err := strconv.Atoi(c.QueryParam("window")); err == nil {
+		for _, w := range metricsWindows {
+			if w == minutes {
+				window = w
+			}
+		}
+	}
+
+	filter := metrics.Filter{
+		Route:  c.QueryParam("route"),
+		UserID: strings.TrimSpace(c.QueryParam("user")),
+		Limit:  100,
+	}
+	if c.QueryParam("errors") != "" {
+		filter.MinStatus = http.StatusInternalServerError
+	}
+
+	snapshot := s.requestMetrics.Snapshot(time.Duration(window) * time.Minute)
+	return Render(c, admin.DevMetrics(c, snapshot, metricsWindows, filter, s.requestMetrics.Recent(filter)))
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/account"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+)
+
+var returnPhotoExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+// maxReturnDetailsLength caps the free text a customer can add to a return request
+const maxReturnDetailsLength = 2000
+
+func accountOrderReturnsURL(orderID string) string {
+	return "/account/orders/" + orderID + "/returns"
+}
+
+// returnPhotoProblem explains why an uploaded file can't go with a return request, or
+// returns "" when it can
+func returnPhotoProblem(fh *multipart.FileHeader) string {
+	if !returnPhotoExtensions[strings.ToLower(filepath.Ext(fh.Filename))] {
+		return fmt.Sprintf("%s isn't a photo. Attach JPG, PNG, GIF or WebP images.", fh.Filename)
+	}
+	if fh.Size > utils.MaxReturnPhotoSize {
+		return fmt.Sprintf("%s is too large. Photos can be up to %d MB.", fh.Filename, utils.MaxReturnPhotoSize>>20)
+	}
+	return ""
+}
+
+// returnQuantities reads the quantity asked for on each order line from the return
+// form. Lines left at 0 are skipped; asking for more than can still be returned is an
+// error.
+func returnQuantities(c echo.Context, items []account.ReturnableItem) (map[string]int64, string) {
+	quantities := map[string]int64{}
+	for _, item := range items {
+		raw := strings.TrimSpace(c.FormValue("qty_" + item.Item.ID))
+		if raw == "" {
+			continue
+		}
+		quantity, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || quantity < 0 {
+			return nil, fmt.Sprintf("Invalid quantity for %s", item.Item.ProductName)
+		}
+		if quantity == 0 {
+			continue
+		}
+		if quantity > item.Returnable {
+			return nil, fmt.Sprintf("You can return up to %d of %s", item.Returnable, item.Item.ProductName)
+		}
+		quantities[item.Item.ID] = quantity
+	}
+	if len(quantities) == 0 {
+		return nil, "Choose at least one item to return"
+	}
+	return quantities, ""
+}
+
+// orderReturnData loads an order's returns and how much of each line can still be
+// returned
+func (s *Service) orderReturnData(ctx context.Context, orderID string) ([]account.ReturnableItem, []account.OrderReturn, error) {
+	orderItems, err := s.storage.Queries.GetOrderItems(ctx, orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load order items: %w", err)
+	}
+	returned, err := s.storage.Queries.GetOrderReturnedQuantities(ctx, orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load returned quantities: %w", err)
+	}
+	returnedByItem := make(map[string]int64, len(returned))
+	for _, row := range returned {
+		returnedByItem[row.OrderItemID] = row.Quantity
+	}
+
+	items := make([]account.ReturnableItem, 0, len(orderItems))
+	for _, item := range orderItems {
+		items = append(items, account.ReturnableItem{
+			Item:       item,
+			Returnable: utils.ReturnableQuantity(item.Quantity, returnedByItem[item.ID]),
+		})
+	}
+
+	returns, err := s.storage.Queries.ListReturnsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load returns: %w", err)
+	}
+	orderReturns := make([]account.OrderReturn, 0, len(returns))
+	for _, ret := range returns {
+		returnItems, err := s.storage.Queries.ListReturnItems(ctx, ret.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load return items: %w", err)
+		}
+		orderReturns = append(orderReturns, account.OrderReturn{Return: ret, Items: returnItems})
+	}
+	return items, orderReturns, nil
+}
+
+// renderOrderReturns shows an order's returns and the form for asking to return more
+func (s *Service) renderOrderReturns(c echo.Context, order db.Order, form account.ReturnForm) error {
+	items, returns, err := s.orderReturnData(c.Request().Context(), order.ID)
+	if err != nil {
+		slog.Error("failed to load order returns", "error", err, "order_id", order.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load returns")
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = fmt.Sprintf("Returns for Order #%s - Logan's 3D Creations", order.ID[:8])
+	meta.Description = "Return items from your order"
+
+	return Render(c, account.OrderReturns(c, order, items, returns, form, meta))
+}
+
+// handleAccountOrderReturns shows the returns page for one of the customer's orders
+func (s *Service) handleAccountOrderReturns(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/login?redirect_url="+accountOrderReturnsURL(c.Param("id")))
+	}
+
+	order, err := s.customerOrder(c, user.ID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return s.renderOrderReturns(c, order, account.ReturnForm{Requested: c.QueryParam("requested") == "1"})
+}
+
+// handleCreateReturn records a customer's request to return items from their order,
+// with any photos they attached. Nothing is refunded or restocked until the shop has
+// approved the request and the items have come back.
+func (s *Service) handleCreateReturn(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/login?redirect_url="+accountOrderReturnsURL(c.Param("id")))
+	}
+
+	ctx := c.Request().Context()
+	order, err := s.customerOrder(c, user.ID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if !utils.OrderReturnable(order.Status.String) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Items can be returned once the order has shipped")
+	}
+
+	form := account.ReturnForm{
+		Reason:  c.FormValue("reason"),
+		Details: strings.TrimSpace(c.FormValue("details")),
+	}
+	items, _, err := s.orderReturnData(ctx, order.ID)
+	if err != nil {
+		slog.Error("failed to load order for return", "error", err, "order_id", order.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to request return")
+	}
+
+	quantities, problem := returnQuantities(c, items)
+	var photos []*multipart.FileHeader
+	if problem == "" {
+		switch {
+		case !utils.ValidReturnReason(form.Reason):
+			problem = "Choose a reason for the return"
+		case utf8.RuneCountInString(form.Details) > maxReturnDetailsLength:
+			problem = fmt.Sprintf("Details can be up to %d characters", maxReturnDetailsLength)
+		}
+	}
+	if problem == "" {
+		if multipartForm, err := c.MultipartForm(); err == nil {
+			photos = multipartForm.File["photos"]
+		}
+		if len(photos) > utils.MaxReturnPhotos {
+			problem = fmt.Sprintf("Attach up to %d photos", utils.MaxReturnPhotos)
+		}
+		for _, fh := range photos {
+			if problem == "" {
+				problem = returnPhotoProblem(fh)
+			}
+		}
+	}
+	if problem != "" {
+		form.Error = problem
+		// Sent with the page on its first write, so a load error can still replace it
+		c.Response().Status = http.StatusUnprocessableEntity
+		return s.renderOrderReturns(c, order, form)
+	}
+
+	ret, err := s.createReturn(ctx, order, user.ID, form, quantities)
+	if err != nil {
+		slog.Error("failed to create return", "error", err, "order_id", order.ID, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to request return")
+	}
+
+	for _, fh := range photos {
+		if err := s.saveReturnPhoto(ctx, ret.ID, fh); err != nil {
+			slog.Error("failed to save return photo", "error", err, "return_id", ret.ID, "filename", fh.Filename)
+		}
+	}
+
+	slog.Info("return requested", "return_id", ret.ID, "order_id", order.ID, "reason", ret.Reason, "photos", len(photos))
+	return c.Redirect(http.StatusSeeOther, accountOrderReturnsURL(order.ID)+"?requested=1")
+}
+
+// createReturn saves a return request and its lines together
+func (s *Service) createReturn(ctx context.Context, order db.Order, userID string, form account.ReturnForm, quantities map[string]int64) (db.Return, error) {
+	tx, err := s.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return db.Return{}, fmt.Errorf("failed to begin return: %w", err)
+	}
+	defer tx.Rollback()
+	queries := s.storage.Queries.WithTx(tx)
+
+	ret, err := queries.CreateReturn(ctx, db.CreateReturnParams{
+		ID:      uuid.New().String(),
+		OrderID: order.ID,
+		UserID:  userID,
+		Reason:  form.Reason,
+		Details: form.Details,
+	})
+	if err != nil {
+		return db.Return{}, fmt.Errorf("failed to save return: %w", err)
+	}
+	for orderItemID, quantity := range quantities {
+		if err := queries.CreateReturnItem(ctx, db.CreateReturnItemParams{
+			ID:          uuid.New().String(),
+			ReturnID:    ret.ID,
+			OrderItemID: orderItemID,
+			Quantity:    quantity,
+		}); err != nil {
+			return db.Return{}, fmt.Errorf("failed to save return item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return db.Return{}, fmt.Errorf("failed to commit return: %w", err)
+	}
+	return ret, nil
+}
+
+// saveReturnPhoto saves a photo attached to a return request and records it. The file
+// is only kept if its contents really are an image.
+func (s *Service) saveReturnPhoto(ctx context.Context, returnID string, fh *multipart.FileHeader) error {
+	src, err := fh.Open()
+	if err != nil {
+		return fmt.Errorf("open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	mimeType := http.DetectContentType(head[:n])
+	if !strings.HasPrefix(mimeType, "image/") {
+		return fmt.Errorf("uploaded file is %s, not an image", mimeType)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind uploaded file: %w", err)
+	}
+
+	uploadDir := filepath.Join("data", "return-photos", returnID)
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return fmt.Errorf("create upload directory: %w", err)
+	}
+	filePath := filepath.Join(uploadDir, ulid.Make().String()+strings.ToLower(filepath.Ext(fh.Filename)))
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("create destination file: %w", err)
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(filePath)
+		return fmt.Errorf("copy file: %w", err)
+	}
+
+	if err := s.storage.Queries.CreateReturnPhoto(ctx, db.CreateReturnPhotoParams{
+		ID:               uuid.New().String(),
+		ReturnID:         returnID,
+		FilePath:         filePath,
+		OriginalFilename: fh.Filename,
+		MimeType:         mimeType,
+		FileSize:         fh.Size,
+	}); err != nil {
+		os.Remove(filePath)
+		return fmt.Errorf("record photo in database: %w", err)
+	}
+	return nil
+}
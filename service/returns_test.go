@@ -0,0 +1,49 @@
+package service
+
+import (
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/account"
+)
+
+func returnFormContext(values url.Values) echo.Context {
+	req := httptest.NewRequest(http.MethodPost, "/account/orders/o1/returns", strings.NewReader(values.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestReturnQuantities(t *testing.T) {
+	items := []account.ReturnableItem{
+		{Item: db.GetOrderItemsRow{ID: "i1", ProductName: "Dragon", Quantity: 3}, Returnable: 2},
+		{Item: db.GetOrderItemsRow{ID: "i2", ProductName: "Vase", Quantity: 1}, Returnable: 1},
+	}
+
+	quantities, problem := returnQuantities(returnFormContext(url.Values{"qty_i1": {"2"}, "qty_i2": {"0"}}), items)
+	assert.Empty(t, problem)
+	assert.Equal(t, map[string]int64{"i1": 2}, quantities)
+
+	_, problem = returnQuantities(returnFormContext(url.Values{"qty_i1": {"3"}}), items)
+	assert.Equal(t, "You can return up to 2 of Dragon", problem, "one was already returned")
+
+	_, problem = returnQuantities(returnFormContext(url.Values{"qty_i1": {"0"}}), items)
+	assert.Equal(t, "Choose at least one item to return", problem)
+
+	_, problem = returnQuantities(returnFormContext(url.Values{"qty_i2": {"-1"}}), items)
+	assert.Equal(t, "Invalid quantity for Vase", problem)
+}
+
+func TestReturnPhotoProblem(t *testing.T) {
+	assert.Empty(t, returnPhotoProblem(&multipart.FileHeader{Filename: "crack.JPG", Size: 1 << 20}))
+	assert.Contains(t, returnPhotoProblem(&multipart.FileHeader{Filename: "model.stl", Size: 100}), "isn't a photo")
+	assert.Contains(t, returnPhotoProblem(&multipart.FileHeader{Filename: "huge.png", Size: utils.MaxReturnPhotoSize + 1}), "too large")
+}
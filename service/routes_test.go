@@ -26,19 +26,35 @@ func TestTier1_CriticalPublicRoutes(t *testing.T) {
 		// Shop pages
 		{"Shop listing", "GET", "/shop", http.StatusOK},
 		{"Premium shop", "GET", "/shop/premium", http.StatusOK},
+		{"Compare products", "GET", "/shop/compare", http.StatusOK},
+		{"Unknown designer", "GET", "/shop/designer/no-such-designer", http.StatusNotFound},
 
 		// Cart
 		{"Cart page", "GET", "/cart", http.StatusOK},
+		{"Unknown cart restore link", "GET", "/cart/restore/no-such-token", http.StatusFound},
+		{"Email cart while popup is off", "POST", "/api/cart/email", http.StatusNotFound},
+		{"Unknown product availability", "GET", "/api/products/no-such-product/availability", http.StatusNotFound},
+		{"Unknown guest order", "GET", "/orders/guest/cs_test_missing", http.StatusNotFound},
 
 		// Static pages
 		{"About page", "GET", "/about", http.StatusOK},
 		{"Contact page", "GET", "/contact", http.StatusOK},
 		{"Portfolio page", "GET", "/portfolio", http.StatusOK},
 
+		// Events
+		{"Events page", "GET", "/events", http.StatusOK},
+		{"Events calendar feed", "GET", "/events/calendar.ics", http.StatusOK},
+		{"Unknown event", "GET", "/events/no-such-event", http.StatusNotFound},
+		{"Unknown event calendar file", "GET", "/events/no-such-event/event.ics", http.StatusNotFound},
+		{"Unknown event registration", "POST", "/events/no-such-event/register", http.StatusNotFound},
+
 		// Legal pages
 		{"Privacy policy", "GET", "/privacy", http.StatusOK},
 		{"Terms of service", "GET", "/terms", http.StatusOK},
 		{"Shipping policy", "GET", "/shipping", http.StatusOK},
+		{"Custom work policy", "GET", "/custom-policy", http.StatusOK},
+		{"Terms of service by version", "GET", "/terms?version=1", http.StatusOK},
+		{"Unknown terms version", "GET", "/terms?version=99", http.StatusNotFound},
 
 		// Auth pages
 		{"Login page", "GET", "/login", http.StatusOK},
@@ -47,6 +63,9 @@ func TestTier1_CriticalPublicRoutes(t *testing.T) {
 
 		// Custom quote
 		{"Custom quote page", "GET", "/custom", http.StatusOK},
+
+		// Digital downloads are refused without a valid signed link
+		{"Download without signature", "GET", "/downloads/test-id", http.StatusForbidden},
 	}
 
 	for _, tt := range tests {
@@ -76,16 +95,87 @@ func TestTier2_AuthProtectedRoutes(t *testing.T) {
 		// Account routes - should require auth
 		{"Account dashboard", "GET", "/account", http.StatusFound}, // Redirects to /login
 		{"Email preferences (new path)", "GET", "/account/email-preferences", http.StatusFound},
+		{"Account order invoice", "GET", "/account/orders/test-id/invoice.pdf", http.StatusFound},
+		{"Account order returns", "GET", "/account/orders/test-id/returns", http.StatusFound},
+		{"Request order return", "POST", "/account/orders/test-id/returns", http.StatusSeeOther},
+		{"Cancel order", "POST", "/account/orders/test-id/cancel", http.StatusSeeOther},
 		{"Email preferences (redirect)", "GET", "/email-preferences", http.StatusMovedPermanently},
+		{"Account order JSON", "GET", "/api/account/orders/test-id", http.StatusUnauthorized},
+		{"Cart merge", "POST", "/api/cart/merge", http.StatusUnauthorized},
+		{"Checkout add-ons", "GET", "/checkout/add-ons", http.StatusFound},
+		{"Link guest order", "POST", "/orders/guest/cs_test_missing/link", http.StatusSeeOther},
+		{"Add checkout add-on", "POST", "/api/checkout/add-ons/test-id", http.StatusUnauthorized},
+		{"Saved cards", "GET", "/account/payment-methods", http.StatusFound},
+		{"Add saved card", "POST", "/account/payment-methods/setup", http.StatusUnauthorized},
+		{"Remove saved card", "POST", "/account/payment-methods/pm_test/remove", http.StatusSeeOther},
+		{"Privacy and data", "GET", "/account/privacy", http.StatusFound},
+		{"Request data export", "POST", "/account/privacy/export", http.StatusSeeOther},
+		{"Download data export", "GET", "/account/privacy/exports/test-id", http.StatusFound},
+		{"Delete account", "POST", "/account/privacy/delete", http.StatusSeeOther},
+
+		// Asking a product question redirects to /login
+		{"Ask product question", "POST", "/shop/product/test-product/questions", http.StatusSeeOther},
 
 		// Admin routes - auth middleware now returns 401 when unauthenticated
 		{"Admin dashboard", "GET", "/admin", http.StatusUnauthorized},
+		{"Admin dashboard today", "GET", "/admin/dashboard/today", http.StatusUnauthorized},
 		{"Admin products", "GET", "/admin/products", http.StatusUnauthorized},
+		{"Admin variant editor", "GET", "/admin/product/test-id/variants", http.StatusUnauthorized},
+		{"Admin duplicate product", "POST", "/admin/product/test-id/duplicate", http.StatusUnauthorized},
 		{"Admin orders", "GET", "/admin/orders", http.StatusUnauthorized},
+		{"Admin packing slip", "GET", "/admin/orders/test-id/packing-slip", http.StatusUnauthorized},
+		{"Admin order invoice", "GET", "/admin/orders/test-id/invoice.pdf", http.StatusUnauthorized},
+		{"Admin order packing slip PDF", "GET", "/admin/orders/test-id/packing-slip.pdf", http.StatusUnauthorized},
+		{"Admin refund order", "POST", "/admin/orders/test-id/refund", http.StatusUnauthorized},
+		{"Admin record shipment", "POST", "/admin/orders/test-id/shipments", http.StatusUnauthorized},
+		{"Admin update shipment status", "POST", "/admin/orders/test-id/shipments/test-id/status", http.StatusUnauthorized},
+		{"Admin remove order items", "POST", "/admin/orders/test-id/edit/remove", http.StatusUnauthorized},
+		{"Admin add order items", "POST", "/admin/orders/test-id/edit/add", http.StatusUnauthorized},
+		{"Admin change order shipping", "POST", "/admin/orders/test-id/edit/shipping", http.StatusUnauthorized},
+		{"Admin withdraw order change", "POST", "/admin/orders/test-id/adjustments/test-id/cancel", http.StatusUnauthorized},
+		{"Admin pick list", "GET", "/admin/orders/pick-list.pdf", http.StatusUnauthorized},
+		{"Admin batch print", "POST", "/admin/orders/print", http.StatusUnauthorized},
+		{"Admin batch buy labels", "POST", "/admin/orders/labels", http.StatusUnauthorized},
+		{"Admin batch labels PDF", "POST", "/admin/orders/labels.pdf", http.StatusUnauthorized},
+		{"Admin event attendees", "GET", "/admin/events/test-id/registrations", http.StatusUnauthorized},
+		{"Admin importer queue URLs", "POST", "/admin/importer/urls", http.StatusUnauthorized},
+		{"Admin importer URL job", "GET", "/admin/importer/jobs/test-id", http.StatusUnauthorized},
+		{"Admin importer URL job progress", "GET", "/admin/importer/jobs/test-id/progress", http.StatusUnauthorized},
+		{"Admin portfolio", "GET", "/admin/portfolio", http.StatusUnauthorized},
+		{"Admin portfolio upload", "POST", "/admin/portfolio/test-id/images", http.StatusUnauthorized},
+		{"Admin blog", "GET", "/admin/blog", http.StatusUnauthorized},
+		{"Admin blog preview", "POST", "/admin/blog/preview", http.StatusUnauthorized},
+		{"Admin category arrange", "POST", "/admin/category/test-id/arrange", http.StatusUnauthorized},
+		{"Admin category featured products", "POST", "/admin/category/test-id/featured", http.StatusUnauthorized},
+		{"Admin category tree", "POST", "/admin/categories/tree", http.StatusUnauthorized},
+		{"Admin inventory locations", "GET", "/admin/shipping/locations", http.StatusUnauthorized},
+		{"Admin product stock transfer", "POST", "/admin/product/test-id/locations/transfer", http.StatusUnauthorized},
 		{"Admin contacts", "GET", "/admin/contacts", http.StatusUnauthorized},
 		{"Admin abandoned carts", "GET", "/admin/abandoned-carts", http.StatusUnauthorized},
 		{"Admin emails", "GET", "/admin/emails", http.StatusUnauthorized},
 		{"Admin promotions", "GET", "/admin/promotions", http.StatusUnauthorized},
+		{"Admin cart promotions", "GET", "/admin/promotions/cart", http.StatusUnauthorized},
+		{"Admin checkout add-ons", "GET", "/admin/checkout-add-ons", http.StatusUnauthorized},
+		{"Admin product questions", "GET", "/admin/questions", http.StatusUnauthorized},
+		{"Admin backorders", "GET", "/admin/backorders", http.StatusUnauthorized},
+		{"Admin returns", "GET", "/admin/returns", http.StatusUnauthorized},
+		{"Admin return refund", "POST", "/admin/returns/test-id/refund", http.StatusUnauthorized},
+		{"Admin return store credit", "POST", "/admin/returns/test-id/credit", http.StatusUnauthorized},
+		{"Admin store credit", "GET", "/admin/store-credit", http.StatusUnauthorized},
+		{"Admin store credit account", "GET", "/admin/store-credit/test-id", http.StatusUnauthorized},
+		{"Admin tax report", "GET", "/admin/reports/taxes", http.StatusUnauthorized},
+		{"Admin bundles", "GET", "/admin/bundles", http.StatusUnauthorized},
+		{"Admin badges", "GET", "/admin/badges", http.StatusUnauthorized},
+		{"Admin designers", "GET", "/admin/designers", http.StatusUnauthorized},
+		{"Admin sync log", "GET", "/admin/sync-log", http.StatusUnauthorized},
+		{"Admin stripe catalog", "GET", "/admin/stripe-catalog", http.StatusUnauthorized},
+		{"Admin backups", "GET", "/admin/dev/backups", http.StatusUnauthorized},
+		{"Admin feature flags", "GET", "/admin/feature-flags", http.StatusUnauthorized},
+		{"Admin site settings", "GET", "/admin/settings", http.StatusUnauthorized},
+		{"Admin privacy requests", "GET", "/admin/privacy-requests", http.StatusUnauthorized},
+		{"Developer cache", "GET", "/dev/cache", http.StatusUnauthorized},
+		{"Developer rate limits", "GET", "/dev/rate-limits", http.StatusUnauthorized},
+		{"Developer request metrics", "GET", "/dev/metrics", http.StatusUnauthorized},
 	}
 
 	for _, tt := range tests {
@@ -121,6 +211,10 @@ func TestTier3_APIRoutes(t *testing.T) {
 	}{
 		// Cart API - These may return various statuses depending on session/data
 		{"Get cart", "GET", "/api/cart", false, []int{http.StatusOK, http.StatusUnauthorized, http.StatusFound}},
+		{"Mini-cart fragment", "GET", "/api/cart/fragment", false, []int{http.StatusOK, http.StatusUnauthorized, http.StatusFound}},
+		{"Saved for later", "GET", "/api/cart/saved", false, []int{http.StatusOK, http.StatusUnauthorized, http.StatusFound}},
+		{"Shared carts", "GET", "/api/cart/shares", false, []int{http.StatusOK, http.StatusUnauthorized, http.StatusFound}},
+		{"Refresh cart", "POST", "/api/cart/refresh", false, []int{http.StatusOK}},
 
 		// Email preferences API
 		{"Get email preferences", "GET", "/api/email-preferences?email=test@example.com", false,
@@ -129,6 +223,22 @@ func TestTier3_APIRoutes(t *testing.T) {
 		// Promotions API
 		{"Get popup status", "GET", "/api/promotions/popup-status", false,
 			[]int{http.StatusOK, http.StatusBadRequest}},
+
+		// Storefront API requires an API key
+		{"Storefront products without key", "GET", "/api/v1/storefront/products", false,
+			[]int{http.StatusUnauthorized}},
+		{"Storefront stock without key", "GET", "/api/v1/storefront/stock", false,
+			[]int{http.StatusUnauthorized}},
+
+		// Prometheus scrape requires METRICS_TOKEN or an admin
+		{"Metrics without token", "GET", "/metrics", false,
+			[]int{http.StatusUnauthorized}},
+
+		// Sync API requires an API key
+		{"Sync health without key", "GET", "/api/sync/health", false,
+			[]int{http.StatusUnauthorized}},
+		{"Sync product without key", "POST", "/api/sync/products", false,
+			[]int{http.StatusUnauthorized}},
 	}
 
 	for _, tt := range tests {
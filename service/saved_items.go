@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/api"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// handleListSavedItems returns the shopper's saved-for-later lines with their
+// current availability
+func (s *Service) handleListSavedItems(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.storage.Queries.ListSavedCartItems(c.Request().Context(), db.ListSavedCartItemsParams{
+		UserID:    owner.userParam(),
+		SessionID: sql.NullString{String: owner.SessionID, Valid: true},
+	})
+	if err != nil {
+		slog.Error("failed to list saved cart items", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get saved items")
+	}
+
+	items := make([]api.SavedItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, api.NewSavedItem(row))
+	}
+	return c.JSON(http.StatusOK, api.NewSavedItems(items))
+}
+
+// handleSaveCartItem moves a cart line to the saved-for-later list
+func (s *Service) handleSaveCartItem(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	item, err := s.storage.Queries.GetCartItem(ctx, c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !owner.owns(item)) {
+		return echo.NewHTTPError(http.StatusNotFound, "Cart item not found")
+	}
+	if err != nil {
+		slog.Error("failed to load cart item", "error", err, "item_id", c.Param("id"))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save item for later")
+	}
+
+	// Bundle lines are priced as a set, so they can only be removed together
+	if item.BundleGroupID.Valid {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bundle items can't be saved for later. Remove the bundle instead.")
+	}
+
+	if err := s.moveCartItemToSaved(ctx, owner, item); err != nil {
+		slog.Error("failed to save cart item for later", "error", err, "item_id", item.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save item for later")
+	}
+	s.invalidateShipping(c, owner.SessionID)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Item saved for later",
+	})
+}
+
+func (s *Service) moveCartItemToSaved(ctx context.Context, owner cartOwner, item db.CartItem) error {
+	tx, err := s.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin save for later: %w", err)
+	}
+	defer tx.Rollback()
+	queries := s.storage.Queries.WithTx(tx)
+
+	if err := queries.CreateSavedCartItem(ctx, db.CreateSavedCartItemParams{
+		ID:              uuid.New().String(),
+		SessionID:       owner.sessionParam(),
+		UserID:          owner.userParam(),
+		ProductID:       item.ProductID,
+		ProductSkuID:    item.ProductSkuID,
+		Quantity:        item.Quantity,
+		Personalization: item.Personalization,
+	}); err != nil {
+		return fmt.Errorf("failed to create saved item: %w", err)
+	}
+	if err := queries.RemoveCartItem(ctx, item.ID); err != nil {
+		return fmt.Errorf("failed to remove cart item: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// handleMoveSavedItemToCart puts a saved line back in the cart, merging it with a
+// matching cart line. Products that can't be backordered are capped at what's in
+// stock, and a sold-out line stays saved.
+func (s *Service) handleMoveSavedItemToCart(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	saved, err := s.storage.Queries.GetSavedCartItem(ctx, db.GetSavedCartItemParams{
+		ID:        c.Param("id"),
+		UserID:    owner.userParam(),
+		SessionID: sql.NullString{String: owner.SessionID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Saved item not found")
+	}
+	if err != nil {
+		slog.Error("failed to load saved cart item", "error", err, "saved_item_id", c.Param("id"))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to move item to cart")
+	}
+
+	line := cartLine{
+		ProductID:       saved.ProductID,
+		ProductSkuID:    saved.ProductSkuID,
+		Quantity:        saved.Quantity,
+		Personalization: saved.Personalization,
+	}
+	placement, err := s.placeInCart(c, owner, line)
+	if err != nil {
+		return err
+	}
+
+	if err := s.moveSavedItemToCart(ctx, owner, saved, placement); err != nil {
+		slog.Error("failed to move saved item to cart", "error", err, "saved_item_id", saved.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to move item to cart")
+	}
+	s.invalidateShipping(c, owner.SessionID)
+
+	message := "Item moved to cart"
+	if placement.Line.Quantity < saved.Quantity {
+		message = fmt.Sprintf("Only %d of %s were available, so that's what was moved to your cart", placement.Line.Quantity, placement.Product.Name)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  message,
+		"quantity": placement.Line.Quantity,
+	})
+}
+
+func (s *Service) moveSavedItemToCart(ctx context.Context, owner cartOwner, saved db.SavedCartItem, placement cartPlacement) error {
+	tx, err := s.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin move to cart: %w", err)
+	}
+	defer tx.Rollback()
+	queries := s.storage.Queries.WithTx(tx)
+
+	if err := placement.apply(ctx, queries, owner); err != nil {
+		return err
+	}
+	if err := queries.DeleteSavedCartItem(ctx, saved.ID); err != nil {
+		return fmt.Errorf("failed to delete saved item: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// handleDeleteSavedItem removes a line from the saved-for-later list
+func (s *Service) handleDeleteSavedItem(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	saved, err := s.storage.Queries.GetSavedCartItem(ctx, db.GetSavedCartItemParams{
+		ID:        c.Param("id"),
+		UserID:    owner.userParam(),
+		SessionID: sql.NullString{String: owner.SessionID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Saved item not found")
+	}
+	if err != nil {
+		slog.Error("failed to load saved cart item", "error", err, "saved_item_id", c.Param("id"))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove saved item")
+	}
+
+	if err := s.storage.Queries.DeleteSavedCartItem(ctx, saved.ID); err != nil {
+		slog.Error("failed to delete saved cart item", "error", err, "saved_item_id", saved.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove saved item")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Saved item removed",
+	})
+}
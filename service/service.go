@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,13 +20,29 @@ import (
 	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/api"
 	"github.com/loganlanou/logans3d-v4/internal/auth"
+	"github.com/loganlanou/logans3d-v4/internal/backup"
+	"github.com/loganlanou/logans3d-v4/internal/cache"
+	"github.com/loganlanou/logans3d-v4/internal/currency"
 	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/errorreport"
+	"github.com/loganlanou/logans3d-v4/internal/flags"
 	"github.com/loganlanou/logans3d-v4/internal/handlers"
+	"github.com/loganlanou/logans3d-v4/internal/images"
+	"github.com/loganlanou/logans3d-v4/internal/inventory"
 	"github.com/loganlanou/logans3d-v4/internal/jobs"
 	"github.com/loganlanou/logans3d-v4/internal/meta"
+	"github.com/loganlanou/logans3d-v4/internal/metrics"
+	"github.com/loganlanou/logans3d-v4/internal/policies"
+	"github.com/loganlanou/logans3d-v4/internal/promocodes"
 	"github.com/loganlanou/logans3d-v4/internal/recaptcha"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
 	"github.com/loganlanou/logans3d-v4/internal/shipping"
+	"github.com/loganlanou/logans3d-v4/internal/sms"
+	"github.com/loganlanou/logans3d-v4/internal/storecredit"
+	stripeutil "github.com/loganlanou/logans3d-v4/internal/stripe"
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
@@ -32,12 +50,10 @@ import (
 	"github.com/loganlanou/logans3d-v4/views/account"
 	"github.com/loganlanou/logans3d-v4/views/contact"
 	"github.com/loganlanou/logans3d-v4/views/custom"
-	"github.com/loganlanou/logans3d-v4/views/events"
 	"github.com/loganlanou/logans3d-v4/views/home"
 	"github.com/loganlanou/logans3d-v4/views/innovation"
 	"github.com/loganlanou/logans3d-v4/views/layout"
 	"github.com/loganlanou/logans3d-v4/views/legal"
-	"github.com/loganlanou/logans3d-v4/views/portfolio"
 	"github.com/loganlanou/logans3d-v4/views/shop"
 	"github.com/oklog/ulid/v2"
 	"github.com/stripe/stripe-go/v80"
@@ -54,7 +70,26 @@ type Service struct {
 	authHandler              *handlers.AuthHandler
 	abandonedCartDetector    *jobs.AbandonedCartDetector
 	abandonedCartEmailSender *jobs.AbandonedCartEmailSender
+	cartCleaner              *jobs.CartCleaner
+	inventoryHoldSweeper     *jobs.InventoryHoldSweeper
+	checkoutDraftExpirer     *jobs.CheckoutDraftExpirer
+	privacyExporter          *jobs.PrivacyExporter
+	urlImporter              *jobs.URLImporter
 	ogImageRefresher         *jobs.OGImageRefresher
+	recommendationBuilder    *jobs.RecommendationBuilder
+	eventReminderSender      *jobs.EventReminderSender
+	exchangeRateUpdater      *jobs.ExchangeRateUpdater
+	productScheduler         *jobs.ProductScheduler
+	backupManager            *backup.Manager
+	cache                    *cache.Cache
+	dropThrottle             *auth.RateLimiter
+	publicThrottle           *auth.Throttle
+	requestMetrics           *metrics.Store
+	errorReporter            *errorreport.Reporter
+	tracer                   *tracing.Tracer
+	featureFlags             *flags.Store
+	currencyRates            *currency.Store
+	deepHealth               deepHealthCache
 }
 
 func New(storage *storage.Storage, config *Config) *Service {
@@ -93,9 +128,114 @@ func New(storage *storage.Storage, config *Config) *Service {
 	// Start the email sender
 	abandonedCartEmailSender.Start(ctx)
 
+	// Initialize nightly deletion of guest carts older than the cart TTL
+	cartCleaner := jobs.NewCartCleaner(storage, config.Cart.TTL)
+	cartCleaner.Start(ctx)
+
+	// Initialize the sweep of stock holds left by checkouts that lapsed
+	inventoryHoldSweeper := jobs.NewInventoryHoldSweeper(storage)
+	inventoryHoldSweeper.Start(ctx)
+
+	// Initialize the expiry of embedded checkouts left unpaid
+	checkoutDraftExpirer := jobs.NewCheckoutDraftExpirer(storage)
+	checkoutDraftExpirer.Start(ctx)
+
+	// Initialize the data exports customers ask for from their privacy page
+	privacyExporter := jobs.NewPrivacyExporter(storage, emailService, config.Privacy.ExportDir)
+	privacyExporter.Start(ctx)
+
+	// Initialize the importer's queue of pasted Cults3D and Thingiverse URLs
+	urlImporter := jobs.NewURLImporter(storage)
+	urlImporter.Start(ctx)
+
+	featureFlags := flags.NewStore(storage.Queries, config.Environment, flags.DefaultTTL)
+
 	// Initialize OG image refresher (runs once at startup in background)
 	ogImageRefresher := jobs.NewOGImageRefresherWithAI(storage, os.Getenv("GEMINI_API_KEY"))
-	ogImageRefresher.Start(ctx)
+	ogImageRefresher.Start(flags.WithSubject(ctx, featureFlags, ""))
+
+	// Initialize nightly "frequently bought together" rebuild and product view pruning
+	recommendationBuilder := jobs.NewRecommendationBuilder(storage)
+	recommendationBuilder.Start(ctx)
+
+	// Initialize reminders for confirmed event attendees
+	eventReminderSender := jobs.NewEventReminderSender(storage, emailService)
+	eventReminderSender.Start(ctx)
+
+	// Initialize exchange rate fetching for converted storefront prices
+	currencyRates := currency.NewStore(storage.Queries, currency.DefaultTTL)
+	exchangeRateUpdater := jobs.NewExchangeRateUpdater(storage, currencyRates, config.Currency.RatesURL, config.Currency.RefreshInterval)
+	exchangeRateUpdater.Start(ctx)
+
+	// Initialize scheduled publishing and sale pricing. Changes are pushed to the
+	// Stripe catalog when it's synced, like admin edits are.
+	pageCache := cache.New(config.Cache.TTL)
+	var scheduleCatalog jobs.ProductSyncer
+	if config.Stripe.CatalogSync {
+		scheduleCatalog = stripeutil.NewCatalogSync(storage.Queries, config.BaseURL)
+	}
+	productScheduler := jobs.NewProductScheduler(storage, pageCache, scheduleCatalog)
+	productScheduler.Start(ctx)
+
+	// Initialize scheduled database backups
+	backupManager := backup.NewManager(storage, backup.Config{
+		Dir:      config.Backup.Dir,
+		Keep:     config.Backup.Keep,
+		Interval: config.Backup.Interval,
+		S3: backup.S3Config{
+			Endpoint:  config.Backup.S3Endpoint,
+			Region:    config.Backup.S3Region,
+			Bucket:    config.Backup.S3Bucket,
+			Prefix:    config.Backup.S3Prefix,
+			AccessKey: config.Backup.S3AccessKey,
+			SecretKey: config.Backup.S3SecretKey,
+		},
+	})
+	backupManager.Start(ctx)
+
+	// Rate limits for the public API and form posts
+	allowlist, err := auth.ParseAllowlist(config.RateLimit.Allowlist)
+	if err != nil {
+		slog.Warn("ignoring invalid RATE_LIMIT_ALLOWLIST", "error", err)
+		allowlist = nil
+	}
+	publicThrottle := auth.NewThrottle(auth.ThrottleConfig{
+		PerIP:      auth.ThrottleLimit{PerMinute: config.RateLimit.IPPerMinute, Burst: config.RateLimit.Burst},
+		PerVisitor: auth.ThrottleLimit{PerMinute: config.RateLimit.VisitorPerMinute, Burst: config.RateLimit.Burst},
+		Allowlist:  allowlist,
+	})
+
+	// Server errors and panics, captured by the error handler in main.go
+	errorReporter, err := errorreport.New(errorreport.Config{
+		DSN:         config.ErrorReporting.DSN,
+		Environment: config.Environment,
+		Release:     config.ErrorReporting.Release,
+		BufferSize:  config.ErrorReporting.BufferSize,
+	})
+	if err != nil {
+		slog.Warn("ignoring invalid SENTRY_DSN, server errors are only kept locally", "error", err)
+		errorReporter, _ = errorreport.New(errorreport.Config{BufferSize: config.ErrorReporting.BufferSize})
+	}
+
+	// Request, database, Stripe and EasyPost spans, kept for /dev/traces and exported
+	// over OTLP when an endpoint is configured
+	tracer, err := tracing.New(tracing.Config{
+		ServiceName: config.Tracing.ServiceName,
+		Environment: config.Environment,
+		Version:     config.ErrorReporting.Release,
+		Endpoint:    config.Tracing.Endpoint,
+		Headers:     config.Tracing.Headers,
+		SampleRatio: config.Tracing.SampleRatio,
+		BufferSize:  config.Tracing.BufferSize,
+	})
+	if err != nil {
+		slog.Warn("ignoring invalid OTLP traces endpoint, traces are only kept locally", "error", err)
+		tracer, _ = tracing.New(tracing.Config{SampleRatio: config.Tracing.SampleRatio, BufferSize: config.Tracing.BufferSize})
+	}
+	tracing.SetDefault(tracer)
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		HTTPClient: tracing.HTTPClient(tracing.ComponentStripe, 80*time.Second),
+	}))
 
 	return &Service{
 		storage:                  storage,
@@ -107,7 +247,25 @@ func New(storage *storage.Storage, config *Config) *Service {
 		authHandler:              handlers.NewAuthHandler(),
 		abandonedCartDetector:    abandonedCartDetector,
 		abandonedCartEmailSender: abandonedCartEmailSender,
+		cartCleaner:              cartCleaner,
+		inventoryHoldSweeper:     inventoryHoldSweeper,
+		checkoutDraftExpirer:     checkoutDraftExpirer,
+		privacyExporter:          privacyExporter,
+		urlImporter:              urlImporter,
 		ogImageRefresher:         ogImageRefresher,
+		recommendationBuilder:    recommendationBuilder,
+		eventReminderSender:      eventReminderSender,
+		exchangeRateUpdater:      exchangeRateUpdater,
+		productScheduler:         productScheduler,
+		backupManager:            backupManager,
+		cache:                    pageCache,
+		dropThrottle:             auth.NewRateLimiter(),
+		publicThrottle:           publicThrottle,
+		requestMetrics:           metrics.NewStore(),
+		errorReporter:            errorReporter,
+		tracer:                   tracer,
+		featureFlags:             featureFlags,
+		currencyRates:            currencyRates,
 	}
 }
 
@@ -121,6 +279,10 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	// Static files - no auth middleware
 	e.Static("/public", "public")
 
+	// Resized product images - no auth middleware
+	imageHandler := handlers.NewImageHandler(images.Default())
+	e.GET("/img/:preset/:filename", imageHandler.HandleImage)
+
 	// Logout - no auth middleware (must clear cookies without re-authentication)
 	e.GET("/logout", s.authHandler.HandleLogout)
 
@@ -128,6 +290,10 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	withAuth := e.Group("")
 	withAuth.Use(auth.ClerkHandshakeMiddleware())
 	withAuth.Use(auth.ClerkAuthMiddleware(s.storage))
+	withAuth.Use(auth.PublicRateLimit(s.publicThrottle, skipPublicRateLimit))
+	withAuth.Use(s.mergeCartOnLogin())
+	withAuth.Use(flags.Middleware(s.featureFlags, flagSubject))
+	withAuth.Use(currency.Middleware(s.currencyRates))
 
 	// Auth routes (public) - Clerk JavaScript SDK components
 	withAuth.GET("/login", s.authHandler.HandleLogin)
@@ -140,48 +306,99 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	// Static pages
 	withAuth.GET("/about", s.handleAbout)
 	withAuth.GET("/events", s.handleEvents)
+	withAuth.GET("/events/:slug", s.handleEventDetail)
+	withAuth.POST("/events/:slug/register", s.handleEventRegister)
 	withAuth.GET("/contact", s.handleContact)
 	withAuth.POST("/contact/submit", s.handleContactSubmit)
 	withAuth.GET("/portfolio", s.handlePortfolio)
+	withAuth.GET("/blog", s.handleBlog)
+	withAuth.GET("/blog/:slug", s.handleBlogPost)
 	withAuth.GET("/innovation", s.handleInnovation)
 	withAuth.GET("/innovation/manufacturing", s.handleManufacturing)
 
 	// Legal pages
 	withAuth.GET("/privacy", s.handlePrivacy)
-	withAuth.GET("/terms", s.handleTerms)
-	withAuth.GET("/shipping", s.handleShipping)
-	withAuth.GET("/custom-policy", s.handleCustomPolicy)
+	withAuth.GET("/terms", s.handleLegalDocument(policies.KindTerms,
+		"Review our terms of service for using Logan's 3D Creations website and services.",
+		[]string{"terms of service", "terms and conditions", "user agreement"}))
+	withAuth.GET("/shipping", s.handleLegalDocument(policies.KindShipping,
+		"Learn about our shipping policies, delivery times, and shipping costs.",
+		[]string{"shipping policy", "delivery", "shipping costs"}))
+	withAuth.GET("/custom-policy", s.handleLegalDocument(policies.KindCustomPolicy,
+		"Understand our custom order policy, lead times, and requirements for custom 3D printing projects.",
+		[]string{"custom order policy", "custom printing terms", "order requirements"}))
 	withAuth.GET("/data-deletion", s.handleDataDeletion)
 
 	// Shop routes
 	shop := withAuth.Group("/shop")
 	shop.GET("", s.handleShop)
 	shop.GET("/premium", s.handlePremium)
+	shop.GET("/bundle/:slug", s.handleBundle)
 	shop.GET("/product/:slug", s.handleProduct)
+	shop.GET("/compare", s.handleCompare)
 	shop.GET("/category/:slug", s.handleCategory)
+	shop.GET("/designer/:slug", s.handleDesigner)
+	shop.POST("/product/:slug/questions", s.handleProductQuestionSubmit)
 
 	// Cart routes
 	withAuth.GET("/cart", s.handleCart)
+	withAuth.GET("/cart/shared/:token", s.handleSharedCart)
+	withAuth.GET("/cart/restore/:token", s.handleCartRestore)
+
+	// Display currency for storefront prices
+	withAuth.POST("/currency", s.handleSetCurrency)
 
 	// Email preferences handler (needed for account routes)
 	emailPrefsHandler := handlers.NewEmailPreferencesHandler(s.storage.Queries)
+	orderCancellationHandler := handlers.NewOrderCancellationHandler(s.storage, s.emailService)
 
 	// Account routes
 	withAuth.GET("/account", s.handleAccount)
 	withAuth.GET("/account/orders/:id", s.handleAccountOrderDetail)
+	withAuth.GET("/account/orders/:id/invoice.pdf", s.handleAccountOrderInvoice)
+	withAuth.GET("/account/orders/:id/returns", s.handleAccountOrderReturns)
+	withAuth.POST("/account/orders/:id/returns", s.handleCreateReturn)
+	withAuth.POST("/account/orders/:id/cancel", orderCancellationHandler.HandleCancelOrder)
 	withAuth.GET("/account/email-preferences", emailPrefsHandler.HandleEmailPreferencesPage)
+	withAuth.GET("/account/favorites", s.handleAccountFavorites)
+	withAuth.POST("/account/default-address/delete", s.handleDeleteDefaultAddress)
+	withAuth.GET("/account/payment-methods", s.handleAccountPaymentMethods)
+	withAuth.POST("/account/payment-methods/setup", s.handleAccountPaymentMethodSetup)
+	withAuth.POST("/account/payment-methods/:id/remove", s.handleAccountPaymentMethodRemove)
+	withAuth.GET("/account/privacy", s.handleAccountPrivacy)
+	withAuth.POST("/account/privacy/export", s.handleAccountPrivacyExport)
+	withAuth.GET("/account/privacy/exports/:id", s.handleAccountPrivacyDownload)
+	withAuth.POST("/account/privacy/delete", s.handleAccountDelete)
 
 	// Redirect for backward compatibility
 	withAuth.GET("/email-preferences", func(c echo.Context) error {
 		return c.Redirect(http.StatusMovedPermanently, "/account/email-preferences")
 	})
 
+	// Live stock polled by the product and cart pages
+	withAuth.GET("/api/products/:id/availability", s.handleProductAvailability)
+
 	// Cart API - all routes public for now
 	withAuth.GET("/api/cart", s.handleGetCart)
+	withAuth.GET("/api/cart/fragment", s.handleCartFragment)
 	withAuth.POST("/api/cart/add", s.handleAddToCart)
+	withAuth.POST("/api/cart/bundle", s.handleAddBundleToCart)
 	withAuth.DELETE("/api/cart/item/:id", s.handleRemoveFromCart)
 	withAuth.PUT("/api/cart/item/:id", s.handleUpdateCartItem)
 	withAuth.POST("/api/cart/validate", s.handleValidateCartSession)
+	withAuth.POST("/api/cart/merge", s.handleMergeCart)
+	withAuth.POST("/api/cart/refresh", s.handleRefreshCart)
+	withAuth.POST("/api/cart/promo-code", s.handleApplyPromoCode)
+	withAuth.POST("/api/cart/gift-card", s.handleApplyGiftCard)
+	withAuth.GET("/api/cart/saved", s.handleListSavedItems)
+	withAuth.POST("/api/cart/item/:id/save", s.handleSaveCartItem)
+	withAuth.POST("/api/cart/saved/:id/move", s.handleMoveSavedItemToCart)
+	withAuth.DELETE("/api/cart/saved/:id", s.handleDeleteSavedItem)
+	withAuth.POST("/api/cart/share", s.handleShareCart)
+	withAuth.POST("/api/cart/email", s.handleEmailCart)
+	withAuth.GET("/api/cart/shares", s.handleListSharedCarts)
+	withAuth.DELETE("/api/cart/shares/:id", s.handleDeleteSharedCart)
+	withAuth.POST("/api/cart/shared/:token/load", s.handleLoadSharedCart)
 
 	// Custom quote routes
 	withAuth.GET("/custom", s.handleCustom)
@@ -192,15 +409,47 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	withAuth.DELETE("/api/custom/draft", s.handleDeleteCustomDraft)
 
 	// Stripe Checkout routes
+	withAuth.GET("/checkout/add-ons", s.handleCheckoutAddOns)
+	withAuth.GET("/api/checkout/add-ons", s.handleCheckoutAddOnsAPI)
+	withAuth.POST("/api/checkout/add-ons/:ruleId", s.handleAddCheckoutAddOn)
 	withAuth.POST("/checkout/create-session-cart", s.handleCreateStripeCheckoutSessionCart)
 	withAuth.GET("/checkout/success", s.handleCheckoutSuccess)
 	withAuth.GET("/checkout/cancel", s.handleCheckoutCancel)
+	withAuth.GET("/checkout/pay/:id", s.handleCheckoutPay)
+	withAuth.POST("/checkout/pay/:id/email", s.handleCheckoutPayEmail)
+	withAuth.GET("/checkout/pay/:id/complete", s.handleCheckoutPayComplete)
+	withAuth.GET("/orders/guest/:session", s.handleGuestOrder)
+	withAuth.POST("/orders/guest/:session/link", s.handleLinkGuestOrder)
 
 	// Payment API routes
 	api := withAuth.Group("/api")
 	api.POST("/payment/create-intent", s.paymentHandler.CreatePaymentIntent)
 	api.POST("/payment/create-customer", s.paymentHandler.CreateCustomer)
-	api.POST("/stripe/webhook", s.paymentHandler.HandleWebhook)
+	// Orders change stock, which the cached product lists show
+	api.POST("/stripe/webhook", s.paymentHandler.HandleWebhook, s.invalidateCacheOnWrite(cache.GroupProducts))
+	// Replies to the store's texting number, such as STOP
+	smsHandler := handlers.NewSMSHandler(sms.NewService(s.storage.Queries), s.config.BaseURL)
+	api.POST("/twilio/sms", smsHandler.HandleInbound)
+
+	// Favorites API - requires a signed-in user
+	api.GET("/favorites", s.handleListFavorites)
+	api.GET("/favorites/ids", s.handleListFavoriteIDs)
+	api.POST("/favorites", s.handleAddFavorite)
+	api.DELETE("/favorites/:product_id", s.handleRemoveFavorite)
+
+	// Order details for the signed-in customer who placed the order
+	api.GET("/account/orders/:id", s.handleGetAccountOrder)
+
+	// Digital product downloads (public - authorized by the signed link)
+	e.GET("/downloads/:id", s.handleDownload)
+
+	// Calendar files are fetched by calendar apps, which never have a session
+	e.GET("/events/calendar.ics", s.handleEventsFeed)
+	e.GET("/events/:slug/event.ics", s.handleEventICS)
+
+	// Blog feeds are fetched by feed readers, which never have a session
+	e.GET("/blog/feed.xml", s.handleBlogFeed)
+	e.GET("/blog/atom.xml", s.handleBlogAtom)
 
 	// Email preferences routes (public - accessible via token)
 	e.GET("/unsubscribe/:token", emailPrefsHandler.HandleUnsubscribe)
@@ -210,6 +459,7 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	// Open Graph image generation
 	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
 	ogImageHandler := handlers.NewOGImageHandlerWithAI(s.storage, geminiAPIKey)
+	api.GET("/og-image/blog/:post_id", ogImageHandler.HandleGenerateBlogOGImage)
 	api.GET("/og-image/multi/:product_id", ogImageHandler.HandleGenerateMultiVariantOGImage) // Must be before :product_id route
 	api.GET("/og-image/:product_id", ogImageHandler.HandleGenerateOGImage)
 	api.GET("/carousel/:product_id", ogImageHandler.HandleDownloadCarouselImages) // Instagram carousel ZIP download
@@ -234,7 +484,7 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 
 	// Product Import API - protected with API key authentication
 	apiProductsHandler := handlers.NewAPIProductsHandler(s.storage)
-	productAPI := e.Group("/api/v1", auth.APIKeyAuth(s.storage))
+	productAPI := e.Group("/api/v1", auth.APIKeyAuth(s.storage), s.invalidateCacheOnWrite())
 	productAPI.GET("/products", apiProductsHandler.ListProducts)
 	productAPI.GET("/products/:id", apiProductsHandler.GetProduct)
 	productAPI.GET("/products/lookup", apiProductsHandler.GetProductBySourceURL)
@@ -245,22 +495,46 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	productAPI.GET("/categories", apiProductsHandler.ListCategories)
 	productAPI.GET("/tags", apiProductsHandler.ListTags)
 
+	// Storefront API - read-only catalog and stock for external tools, limited per key
+	apiStorefrontHandler := handlers.NewAPIStorefrontHandler(s.storage)
+	storefrontAPI := e.Group("/api/v1/storefront", auth.APIKeyAuth(s.storage), auth.APIKeyRateLimit(auth.NewRateLimiter()))
+	storefrontAPI.GET("/products", apiStorefrontHandler.ListProducts)
+	storefrontAPI.GET("/products/:id", apiStorefrontHandler.GetProduct)
+	storefrontAPI.GET("/categories", apiStorefrontHandler.ListCategories)
+	storefrontAPI.GET("/stock", apiStorefrontHandler.ListStock)
+
+	// Sync API - receives product pushes from the sync CLI (scripts/sync)
+	apiSyncHandler := handlers.NewAPISyncHandler(s.storage)
+	syncAPI := e.Group("/api/sync", auth.APIKeyAuth(s.storage), s.invalidateCacheOnWrite())
+	syncAPI.GET("/health", apiSyncHandler.Health)
+	syncAPI.POST("/products", apiSyncHandler.SyncProduct)
+
 	// Admin routes - protected with RequireAdmin middleware
 	// Initialize admin handler with all required services
-	adminHandler := handlers.NewAdminHandler(s.storage, s.shippingService, s.emailService)
+	// Catalog sync is opt-in so local and test environments don't write to Stripe
+	var stripeCatalog *stripeutil.CatalogSync
+	if s.config.Stripe.CatalogSync {
+		stripeCatalog = stripeutil.NewCatalogSync(s.storage.Queries, s.config.BaseURL)
+	}
+	adminHandler := handlers.NewAdminHandler(s.storage, s.shippingService, s.emailService, stripeCatalog)
 
 	// Cart recovery email tracking - uses adminHandler but no auth required (customers click from email)
 	withAuth.GET("/cart/recover", adminHandler.HandleRecoveryEmailTracking)
 
-	admin := withAuth.Group("/admin", auth.RequireAdmin(), s.adminBadgeMiddleware())
+	admin := withAuth.Group("/admin", auth.RequireAdmin(), s.adminBadgeMiddleware(), s.invalidateCacheOnWrite())
 	admin.GET("", adminHandler.HandleAdminDashboard)
+	admin.GET("/dashboard/today", adminHandler.HandleDashboardToday)
 	admin.GET("/products", adminHandler.HandleProductsList)
 	admin.GET("/categories", adminHandler.HandleCategoriesTab)
+	admin.POST("/categories/tree", adminHandler.HandleSaveCategoryTree)
 	admin.GET("/product/new", adminHandler.HandleProductForm)
 	admin.POST("/product", adminHandler.HandleCreateProduct)
 	admin.GET("/product/edit", adminHandler.HandleProductForm)
 	admin.POST("/product/:id", adminHandler.HandleUpdateProduct)
 	admin.POST("/product/:id/delete", adminHandler.HandleDeleteProduct)
+	admin.POST("/product/:id/restore", adminHandler.HandleRestoreProduct)
+	admin.POST("/product/:id/delete-permanently", adminHandler.HandlePermanentlyDeleteProduct)
+	admin.POST("/product/:id/duplicate", adminHandler.HandleDuplicateProduct)
 	admin.POST("/product/:id/toggle-featured", adminHandler.HandleToggleProductFeatured)
 	admin.POST("/product/:id/toggle-premium", adminHandler.HandleToggleProductPremium)
 	admin.POST("/product/:id/toggle-active", adminHandler.HandleToggleProductActive)
@@ -272,6 +546,26 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.POST("/product/:id/styles", adminHandler.HandleCreateProductStyle)
 	admin.POST("/product/:id/sizes", adminHandler.HandleSaveProductSizes)
 	admin.POST("/product/:id/skus", adminHandler.HandleCreateProductSKU)
+	admin.GET("/product/:id/variants", adminHandler.HandleVariantMatrix)
+	admin.POST("/product/:id/variants", adminHandler.HandleSaveVariantMatrix)
+	admin.GET("/product/:id/locations", adminHandler.HandleProductLocations)
+	admin.POST("/product/:id/locations/adjust", adminHandler.HandleAdjustLocationStock)
+	admin.POST("/product/:id/locations/transfer", adminHandler.HandleTransferLocationStock)
+	admin.POST("/product/:id/locations/bin", adminHandler.HandleSetLocationStockBin)
+	admin.POST("/product/:id/attributes", adminHandler.HandleSaveProductAttribute)
+	admin.POST("/product/:id/attributes/:attributeId", adminHandler.HandleUpdateProductAttribute)
+	admin.POST("/product/:id/attributes/:attributeId/delete", adminHandler.HandleDeleteProductAttribute)
+	admin.POST("/product/:id/videos", adminHandler.HandleAddProductVideo)
+	admin.POST("/product/:id/videos/:videoId/delete", adminHandler.HandleDeleteProductVideo)
+	admin.POST("/product/:id/price-tiers", adminHandler.HandleSaveProductPriceTier)
+	admin.POST("/product/:id/price-tiers/:tierId/delete", adminHandler.HandleDeleteProductPriceTier)
+	admin.POST("/product/:id/sku/:skuId/sale", adminHandler.HandleSaveSkuSale)
+	admin.POST("/product/:id/personalization", adminHandler.HandleSaveProductPersonalizationField)
+	admin.POST("/product/:id/personalization/:fieldId/delete", adminHandler.HandleDeleteProductPersonalizationField)
+	admin.POST("/product/:id/relations", adminHandler.HandleSaveProductRelation)
+	admin.POST("/product/:id/relations/:relatedId/delete", adminHandler.HandleDeleteProductRelation)
+	admin.POST("/product/:id/files", adminHandler.HandleUploadProductFile)
+	admin.POST("/product/:id/files/:fileId/delete", adminHandler.HandleDeleteProductFile)
 
 	// Style panel routes (for admin SKU management UI)
 	admin.GET("/style/:styleId/panel", adminHandler.HandleGetStylePanel)
@@ -286,6 +580,8 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.DELETE("/style-image/:imageId", adminHandler.HandleDeleteStyleImageFromPanel)
 	admin.POST("/style/:styleId/images", adminHandler.HandleAddStyleImages)
 
+	// Orders, products, users and contact requests from the omnibox in the admin header
+	admin.GET("/search", adminHandler.HandleAdminSearch)
 	admin.GET("/product/search", adminHandler.HandleProductSearch)
 	admin.GET("/product/:id/row", adminHandler.HandleGetProductRow)
 
@@ -301,18 +597,81 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.GET("/product/:id/edit-row-mobile", adminHandler.HandleGetProductEditRowMobile)
 	admin.PUT("/product/:id/inline-mobile", adminHandler.HandleUpdateProductInlineMobile)
 
+	// Bundle management routes
+	admin.GET("/bundles", adminHandler.HandleBundlesList)
+	admin.GET("/bundles/new", adminHandler.HandleBundleForm)
+	admin.POST("/bundles", adminHandler.HandleCreateBundle)
+	admin.GET("/bundles/:id", adminHandler.HandleBundleForm)
+	admin.POST("/bundles/:id", adminHandler.HandleUpdateBundle)
+	admin.POST("/bundles/:id/delete", adminHandler.HandleDeleteBundle)
+	admin.POST("/bundles/:id/items", adminHandler.HandleAddBundleItem)
+	admin.POST("/bundles/:id/items/:itemId/delete", adminHandler.HandleDeleteBundleItem)
+
+	// Product badge routes
+	admin.GET("/badges", adminHandler.HandleBadgesList)
+	admin.GET("/badges/new", adminHandler.HandleBadgeForm)
+	admin.POST("/badges", adminHandler.HandleCreateBadge)
+	admin.GET("/badges/:id", adminHandler.HandleBadgeForm)
+	admin.POST("/badges/:id", adminHandler.HandleUpdateBadge)
+	admin.POST("/badges/:id/delete", adminHandler.HandleDeleteBadge)
+	admin.POST("/badges/:id/products", adminHandler.HandleAddBadgeProduct)
+	admin.POST("/badges/:id/products/:productId/delete", adminHandler.HandleRemoveBadgeProduct)
+
+	// Checkout add-ons
+	admin.GET("/checkout-add-ons", adminHandler.HandleCheckoutAddOns)
+	admin.POST("/checkout-add-ons", adminHandler.HandleCreateCheckoutAddOnRule)
+	admin.POST("/checkout-add-ons/:id/toggle", adminHandler.HandleToggleCheckoutAddOnRule)
+	admin.POST("/checkout-add-ons/:id/delete", adminHandler.HandleDeleteCheckoutAddOnRule)
+
+	// Designer profile routes
+	admin.GET("/designers", adminHandler.HandleDesignersList)
+	admin.GET("/designers/:slug", adminHandler.HandleDesignerForm)
+	admin.POST("/designers/:slug", adminHandler.HandleSaveDesigner)
+
 	// Category management routes
 	admin.GET("/category/new", adminHandler.HandleCategoryForm)
 	admin.POST("/category", adminHandler.HandleCreateCategory)
 	admin.GET("/category/edit", adminHandler.HandleCategoryForm)
 	admin.POST("/category/:id", adminHandler.HandleUpdateCategory)
 	admin.POST("/category/:id/delete", adminHandler.HandleDeleteCategory)
+	admin.GET("/category/:id/arrange", adminHandler.HandleArrangeCategory)
+	admin.POST("/category/:id/arrange", adminHandler.HandleSaveCategoryArrangement)
+	admin.POST("/category/:id/featured", adminHandler.HandleSaveCategoryFeaturedProduct)
+	admin.POST("/category/:id/featured/:productId/delete", adminHandler.HandleDeleteCategoryFeaturedProduct)
 
 	// Orders management routes
 	admin.GET("/orders", adminHandler.HandleOrdersList)
+	admin.GET("/backorders", adminHandler.HandleBackorderReport)
+	admin.GET("/returns", adminHandler.HandleReturnsList)
+	admin.GET("/returns/:id", adminHandler.HandleReturnDetail)
+	admin.GET("/returns/:id/photos/:photoID", adminHandler.HandleReturnPhoto)
+	admin.POST("/returns/:id/status", adminHandler.HandleUpdateReturnStatus)
+	admin.POST("/returns/:id/label", adminHandler.HandleBuyReturnLabel)
+	admin.POST("/returns/:id/refund", adminHandler.HandleRefundReturn)
+	admin.POST("/returns/:id/credit", adminHandler.HandleCreditReturn)
+	admin.POST("/returns/:id/items/:itemID/restock", adminHandler.HandleRestockReturnItem)
+	admin.GET("/reports/taxes", adminHandler.HandleTaxReport)
+	admin.GET("/reports/taxes/export", adminHandler.HandleTaxReportExport)
+	admin.GET("/reports/taxes/reconcile", adminHandler.HandleTaxReconcile)
+	admin.GET("/reports/carbon-offsets", adminHandler.HandleCarbonOffsetReport)
+	admin.GET("/reports/carbon-offsets/export", adminHandler.HandleCarbonOffsetExport)
 	admin.GET("/orders/search", adminHandler.HandleOrderSearch)
+	admin.GET("/orders/pick-list.pdf", adminHandler.HandlePickList)
+	admin.POST("/orders/print", adminHandler.HandleBatchPrintOrders)
+	admin.POST("/orders/labels", adminHandler.HandleBatchBuyLabels)
+	admin.POST("/orders/labels.pdf", adminHandler.HandleBatchLabelsPDF)
 	admin.GET("/orders/:id", adminHandler.HandleOrderDetail)
+	admin.GET("/orders/:id/packing-slip", adminHandler.HandleOrderPackingSlip)
+	admin.GET("/orders/:id/packing-slip.pdf", adminHandler.HandleOrderPackingSlipPDF)
+	admin.GET("/orders/:id/invoice.pdf", adminHandler.HandleOrderInvoice)
 	admin.POST("/orders/:id/status", adminHandler.HandleUpdateOrderStatus)
+	admin.POST("/orders/:id/refund", adminHandler.HandleRefundOrder)
+	admin.POST("/orders/:id/shipments", adminHandler.HandleRecordShipment)
+	admin.POST("/orders/:id/shipments/:shipmentID/status", adminHandler.HandleShipmentStatus)
+	admin.POST("/orders/:id/edit/remove", adminHandler.HandleRemoveOrderItems)
+	admin.POST("/orders/:id/edit/add", adminHandler.HandleAddOrderItems)
+	admin.POST("/orders/:id/edit/shipping", adminHandler.HandleChangeOrderShipping)
+	admin.POST("/orders/:id/adjustments/:adjustmentID/cancel", adminHandler.HandleCancelOrderAdjustment)
 	admin.GET("/orders/:id/tracking/lookup", adminHandler.HandleGetOrderTrackingLookup)
 	admin.GET("/orders/:id/shipping/rates", adminHandler.HandleGetOrderShippingRates)
 	admin.POST("/orders/:id/shipping/buy-label", adminHandler.HandleBuyShippingLabel)
@@ -324,6 +683,25 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.POST("/quotes/:id/archive", adminHandler.HandleArchiveQuoteDraft)
 	admin.POST("/quotes/:id/unarchive", adminHandler.HandleUnarchiveQuoteDraft)
 
+	// Portfolio gallery routes
+	admin.GET("/blog", adminHandler.HandleBlogPostsList)
+	admin.GET("/blog/new", adminHandler.HandleBlogPostForm)
+	admin.POST("/blog", adminHandler.HandleCreateBlogPost)
+	admin.POST("/blog/preview", adminHandler.HandlePreviewBlogPost)
+	admin.GET("/blog/:id", adminHandler.HandleBlogPostForm)
+	admin.POST("/blog/:id", adminHandler.HandleUpdateBlogPost)
+	admin.POST("/blog/:id/delete", adminHandler.HandleDeleteBlogPost)
+	admin.GET("/portfolio", adminHandler.HandlePortfolioList)
+	admin.GET("/portfolio/new", adminHandler.HandlePortfolioForm)
+	admin.POST("/portfolio", adminHandler.HandleCreatePortfolioItem)
+	admin.GET("/portfolio/:id", adminHandler.HandlePortfolioForm)
+	admin.POST("/portfolio/:id", adminHandler.HandleUpdatePortfolioItem)
+	admin.POST("/portfolio/:id/delete", adminHandler.HandleDeletePortfolioItem)
+	admin.POST("/portfolio/:id/images", adminHandler.HandleUploadPortfolioImages)
+	admin.POST("/portfolio/:id/images/:imageId/delete", adminHandler.HandleDeletePortfolioImage)
+	admin.POST("/portfolio/:id/products", adminHandler.HandleAddPortfolioProduct)
+	admin.POST("/portfolio/:id/products/:productId/delete", adminHandler.HandleRemovePortfolioProduct)
+
 	// Events management routes
 	admin.GET("/events", adminHandler.HandleEventsList)
 	admin.GET("/events/new", adminHandler.HandleEventForm)
@@ -331,6 +709,12 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.GET("/events/edit", adminHandler.HandleEventForm)
 	admin.POST("/events/:id", adminHandler.HandleUpdateEvent)
 	admin.POST("/events/:id/delete", adminHandler.HandleDeleteEvent)
+	admin.GET("/events/:id/registrations", adminHandler.HandleEventRegistrations)
+	admin.POST("/events/:id/ticket-types", adminHandler.HandleCreateEventTicketType)
+	admin.POST("/events/:id/ticket-types/:ticketTypeId/delete", adminHandler.HandleDeleteEventTicketType)
+	admin.POST("/events/:id/registrations/:registrationId/check-in", adminHandler.HandleToggleEventCheckIn)
+	admin.POST("/events/:id/registrations/:registrationId/confirm", adminHandler.HandleConfirmEventRegistration)
+	admin.POST("/events/:id/registrations/:registrationId/cancel", adminHandler.HandleCancelEventRegistration)
 
 	// Contact requests management routes
 	admin.GET("/contacts", adminHandler.HandleContactsList)
@@ -342,9 +726,19 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.POST("/contacts/:id/notes", adminHandler.HandleAddContactNotes)
 	admin.POST("/contacts/:id/notes/delete", adminHandler.HandleDeleteContactNotes)
 
+	// Product Q&A moderation routes
+	admin.GET("/questions", adminHandler.HandleProductQuestions)
+	admin.POST("/questions/:id/answer", adminHandler.HandleAnswerProductQuestion)
+	admin.POST("/questions/:id/reject", adminHandler.HandleRejectProductQuestion)
+	admin.POST("/questions/:id/delete", adminHandler.HandleDeleteProductQuestion)
+
 	// Abandoned Carts management routes
 	admin.GET("/abandoned-carts", adminHandler.HandleAbandonedCartsDashboard)
 	admin.GET("/abandoned-carts/export", adminHandler.HandleExportAbandonedCarts)
+	admin.GET("/abandoned-carts/settings", adminHandler.HandleRecoverySequenceSettings)
+	admin.POST("/abandoned-carts/settings/steps", adminHandler.HandleCreateRecoverySequenceStep)
+	admin.POST("/abandoned-carts/settings/steps/:id/toggle", adminHandler.HandleToggleRecoverySequenceStep)
+	admin.POST("/abandoned-carts/settings/steps/:id/delete", adminHandler.HandleDeleteRecoverySequenceStep)
 	admin.GET("/abandoned-carts/:id", adminHandler.HandleAbandonedCartDetail)
 	admin.POST("/abandoned-carts/:id/send-email", adminHandler.HandleSendRecoveryEmail)
 	admin.POST("/abandoned-carts/:id/notes", adminHandler.HandleUpdateCartNotes)
@@ -357,7 +751,32 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	// Promotion management routes
 	promotionsAdminHandler := handlers.NewAdminPromotionsHandler(s.storage.Queries)
 	admin.GET("/promotions", promotionsAdminHandler.HandlePromotionsList)
+	admin.GET("/promotions/cart", promotionsAdminHandler.HandleCartPromotions)
+	admin.GET("/promotions/cart/new", promotionsAdminHandler.HandleCartPromotionForm)
+	admin.POST("/promotions/cart", promotionsAdminHandler.HandleCreateCartPromotion)
+	admin.GET("/promotions/cart/:id", promotionsAdminHandler.HandleCartPromotionForm)
+	admin.POST("/promotions/cart/:id", promotionsAdminHandler.HandleUpdateCartPromotion)
+	admin.POST("/promotions/cart/:id/toggle", promotionsAdminHandler.HandleToggleCartPromotion)
+	admin.POST("/promotions/cart/:id/delete", promotionsAdminHandler.HandleDeleteCartPromotion)
 	admin.GET("/promotions/:id", promotionsAdminHandler.HandlePromotionDetail)
+	admin.POST("/promotions/:id/rules", promotionsAdminHandler.HandleUpdatePromotionRules)
+
+	// Gift card management routes
+	giftCardsAdminHandler := handlers.NewAdminGiftCardsHandler(s.storage.Queries, s.emailService)
+	admin.GET("/gift-cards", giftCardsAdminHandler.HandleGiftCardsList)
+	admin.POST("/gift-cards", giftCardsAdminHandler.HandleIssueGiftCard)
+	admin.GET("/gift-cards/:id", giftCardsAdminHandler.HandleGiftCardDetail)
+	admin.POST("/gift-cards/:id/adjust", giftCardsAdminHandler.HandleAdjustGiftCard)
+	admin.POST("/gift-cards/:id/void", giftCardsAdminHandler.HandleVoidGiftCard)
+	admin.POST("/gift-cards/:id/resend", giftCardsAdminHandler.HandleResendGiftCard)
+
+	// Store credit routes
+	storeCreditAdminHandler := handlers.NewAdminStoreCreditHandler(s.storage.Queries)
+	admin.GET("/store-credit", storeCreditAdminHandler.HandleStoreCreditList)
+	admin.POST("/store-credit", storeCreditAdminHandler.HandleGrantStoreCreditByEmail)
+	admin.GET("/store-credit/:userId", storeCreditAdminHandler.HandleStoreCreditAccount)
+	admin.POST("/store-credit/:userId/grant", storeCreditAdminHandler.HandleGrantStoreCredit)
+	admin.POST("/store-credit/:userId/revoke", storeCreditAdminHandler.HandleRevokeStoreCredit)
 
 	// Social Media management routes
 	admin.GET("/social-media", adminHandler.HandleAdminSocialMedia)
@@ -388,6 +807,12 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.POST("/shipping/config", adminHandler.HandleSaveShippingConfig)
 	admin.GET("/shipping/settings", adminHandler.HandleShippingSettings)
 	admin.POST("/shipping/settings", adminHandler.HandleSaveShippingSettings)
+	admin.GET("/shipping/locations", adminHandler.HandleInventoryLocations)
+	admin.GET("/shipping/locations/new", adminHandler.HandleInventoryLocationForm)
+	admin.POST("/shipping/locations", adminHandler.HandleCreateInventoryLocation)
+	admin.GET("/shipping/locations/:id", adminHandler.HandleInventoryLocationForm)
+	admin.POST("/shipping/locations/:id", adminHandler.HandleUpdateInventoryLocation)
+	admin.POST("/shipping/locations/:id/delete", adminHandler.HandleDeleteInventoryLocation)
 
 	// Email preview routes
 	admin.GET("/email-preview", adminHandler.HandleEmailPreview)
@@ -400,10 +825,51 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	admin.GET("/api-keys/list", adminHandler.HandleAdminAPIKeysList)
 	admin.POST("/api-keys/create", adminHandler.HandleAdminAPIKeyCreate)
 	admin.DELETE("/api-keys/:id", adminHandler.HandleAdminAPIKeyDelete)
+	admin.GET("/sync-log", adminHandler.HandleSyncLog)
+
+	// Stripe catalog sync
+	admin.GET("/stripe-catalog", adminHandler.HandleStripeCatalog)
+	admin.POST("/stripe-catalog/sync-all", adminHandler.HandleStripeCatalogSyncAll)
+	admin.POST("/stripe-catalog/sync/:id", adminHandler.HandleStripeCatalogSync)
+
+	// Site settings
+	siteSettingsHandler := handlers.NewSiteSettingsHandler(s.storage)
+	admin.GET("/settings", siteSettingsHandler.HandleSiteSettings)
+	admin.POST("/settings", siteSettingsHandler.HandleSaveSiteSettings)
+
+	// Customers' data exports and account deletions
+	admin.GET("/privacy-requests", adminHandler.HandlePrivacyRequests)
+
+	// Versioned terms and policies agreed to at checkout
+	admin.GET("/legal", adminHandler.HandleLegalDocuments)
+	admin.GET("/legal/new", adminHandler.HandleNewLegalDocument)
+	admin.POST("/legal", adminHandler.HandleCreateLegalDocument)
+	admin.GET("/legal/:id", adminHandler.HandleLegalDocument)
+	admin.POST("/legal/:id/delete", adminHandler.HandleDeleteLegalDocument)
+
+	// Feature flags
+	featureFlagHandler := handlers.NewFeatureFlagHandler(s.storage, s.featureFlags)
+	admin.GET("/feature-flags", featureFlagHandler.HandleFeatureFlags)
+	admin.GET("/feature-flags/new", featureFlagHandler.HandleFeatureFlagForm)
+	admin.POST("/feature-flags", featureFlagHandler.HandleCreateFeatureFlag)
+	admin.GET("/feature-flags/:key", featureFlagHandler.HandleFeatureFlagForm)
+	admin.POST("/feature-flags/:key", featureFlagHandler.HandleUpdateFeatureFlag)
+	admin.POST("/feature-flags/:key/toggle", featureFlagHandler.HandleToggleFeatureFlag)
+	admin.POST("/feature-flags/:key/delete", featureFlagHandler.HandleDeleteFeatureFlag)
+
+	// Database backups
+	backupHandler := handlers.NewBackupHandler(s.backupManager)
+	admin.GET("/dev/backups", backupHandler.HandleBackups)
+	admin.POST("/dev/backups", backupHandler.HandleCreateBackup)
+	admin.GET("/dev/backups/:name/download", backupHandler.HandleDownloadBackup)
+	admin.POST("/dev/backups/:name/restore", backupHandler.HandleRestoreBackup)
 
 	// Product Importer routes
 	importerHandler := handlers.NewAdminImporterHandler(s.storage)
 	admin.GET("/importer", importerHandler.HandleImporterDashboard)
+	admin.POST("/importer/urls", importerHandler.HandleQueueURLImport)
+	admin.GET("/importer/jobs/:id", importerHandler.HandleURLImportJob)
+	admin.GET("/importer/jobs/:id/progress", importerHandler.HandleURLImportJobProgress)
 	admin.GET("/importer/designers/:slug", importerHandler.HandleImporterDesignerDetail)
 	admin.POST("/importer/scrape/:slug", importerHandler.HandleStartScrape)
 	admin.POST("/importer/import/:slug", importerHandler.HandleImportProducts)
@@ -436,9 +902,19 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	dev.GET("/config", adminHandler.HandleDevConfig)
 	// API routes
 	dev.POST("/gc", adminHandler.HandleGarbageCollect)
+	dev.GET("/database/connections", adminHandler.HandleDatabaseConnections)
 	dev.GET("/logs/stream", adminHandler.HandleLogStream)
 	dev.GET("/logs/tail", adminHandler.HandleLogTail)
 	dev.POST("/logs/clear", adminHandler.HandleLogClear)
+	dev.GET("/cache", s.handleDevCache)
+	dev.GET("/rate-limits", s.handleDevRateLimits)
+	dev.GET("/metrics", s.handleDevMetrics)
+	dev.GET("/errors", s.handleDevErrors)
+	dev.GET("/traces", s.handleDevTraces)
+	dev.POST("/cache/purge", s.handleDevCachePurge)
+
+	// Prometheus scrape endpoint - METRICS_TOKEN bearer token, or a signed-in admin
+	withAuth.GET("/metrics", s.handleMetrics)
 
 	// Health check - no auth
 	e.GET("/health", s.handleHealth)
@@ -449,50 +925,10 @@ func (s *Service) RegisterRoutes(e *echo.Echo) {
 	})
 }
 
-// getProductImageURL returns the primary image URL for a product, correctly handling variants
+// getProductImageURL returns the primary image URL for a product, correctly handling
+// variants. Listings should use catalog().ProductImageURLs to load a page in one query.
 func (s *Service) getProductImageURL(ctx context.Context, product db.Product) string {
-	// For products with variants, prefer the AI-generated multi-variant OG image
-	if product.HasVariants.Valid && product.HasVariants.Bool {
-		// Check if multi-variant OG image exists
-		multiOGPath := fmt.Sprintf("public/og-images/product-%s-multi.png", product.ID)
-		if _, err := os.Stat(multiOGPath); err == nil {
-			return "/" + multiOGPath
-		}
-
-		// Fall back to primary style's primary image
-		styles, err := s.storage.Queries.GetProductStyles(ctx, product.ID)
-		if err == nil && len(styles) > 0 {
-			// First style is primary (ordered by is_primary DESC)
-			primaryStyle := styles[0]
-			styleImage, err := s.storage.Queries.GetPrimaryStyleImage(ctx, primaryStyle.ID)
-			if err == nil && styleImage.ImageUrl != "" {
-				return "/public/images/products/styles/" + styleImage.ImageUrl
-			}
-		}
-	}
-
-	// Fall back to regular product images
-	images, err := s.storage.Queries.GetProductImages(ctx, product.ID)
-	if err != nil || len(images) == 0 {
-		return ""
-	}
-
-	// Get the primary image or the first one
-	rawImageURL := ""
-	for _, img := range images {
-		if img.IsPrimary.Valid && img.IsPrimary.Bool {
-			rawImageURL = img.ImageUrl
-			break
-		}
-	}
-	if rawImageURL == "" {
-		rawImageURL = images[0].ImageUrl
-	}
-
-	if rawImageURL != "" {
-		return "/public/images/products/" + rawImageURL
-	}
-	return ""
+	return s.catalog().ProductImageURL(ctx, product)
 }
 
 // Basic handler implementations
@@ -501,7 +937,7 @@ func (s *Service) handleHome(c echo.Context) error {
 	slog.Info("Home page requested", "ip", c.RealIP())
 
 	// Get featured products
-	featuredProducts, err := s.storage.Queries.ListFeaturedProducts(ctx)
+	featuredProducts, err := s.catalog().ListFeaturedProducts(ctx)
 	if err != nil {
 		slog.Error("failed to fetch featured products", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load featured products")
@@ -509,11 +945,14 @@ func (s *Service) handleHome(c echo.Context) error {
 	slog.Debug("fetched featured products", "count", len(featuredProducts))
 
 	// Combine with images (handles variants correctly)
+	imageURLs := s.catalog().ProductImageURLs(ctx, featuredProducts)
+	productBadges := s.productBadges(ctx, featuredProducts)
 	productsWithImages := make([]home.ProductWithImage, 0, len(featuredProducts))
 	for _, product := range featuredProducts {
 		productsWithImages = append(productsWithImages, home.ProductWithImage{
 			Product:  product,
-			ImageURL: s.getProductImageURL(ctx, product),
+			ImageURL: imageURLs[product.ID],
+			Badges:   productBadges[product.ID],
 		})
 	}
 
@@ -540,29 +979,21 @@ func (s *Service) handleShop(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	// Get all categories for filter
-	categories, err := s.storage.Queries.ListCategories(ctx)
+	categories, err := s.catalog().ListCategories(ctx)
 	if err != nil {
 		slog.Error("failed to fetch categories", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load categories")
 	}
 	slog.Debug("fetched categories", "count", len(categories))
 
-	// Get all products
-	products, err := s.storage.Queries.ListProducts(ctx)
+	// Get the current page of filtered products
+	filters := parseShopFilters(c)
+	productsWithImages, listing, err := s.loadShopListing(ctx, filters, "", "/shop")
 	if err != nil {
 		slog.Error("failed to fetch products", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
 	}
-	slog.Debug("fetched products", "count", len(products))
-
-	// Combine with images (handles variants correctly)
-	productsWithImages := make([]shop.ProductWithImage, 0, len(products))
-	for _, product := range products {
-		productsWithImages = append(productsWithImages, shop.ProductWithImage{
-			Product:  product,
-			ImageURL: s.getProductImageURL(ctx, product),
-		})
-	}
+	slog.Debug("fetched products", "count", len(productsWithImages), "total", listing.TotalCount, "page", listing.Filters.Page)
 
 	// Build PageMeta for shop page
 	meta := layout.NewPageMeta(c, s.storage.Queries)
@@ -570,203 +1001,40 @@ func (s *Service) handleShop(c echo.Context) error {
 	meta.Description = "Browse our collection of unique 3D printed collectibles, dinosaurs, and custom creations. High-quality prints available for purchase."
 	meta.Keywords = []string{"buy 3D prints", "3D printed collectibles", "dinosaur models for sale", "custom 3D printing", "collectible figurines shop"}
 	meta.OGType = "website"
+	meta.CanonicalURL = shopCanonicalURL(meta.SiteURL, listing)
+	meta.OGURL = meta.CanonicalURL
 
-	return Render(c, shop.Index(c, meta, productsWithImages, categories, nil))
+	return Render(c, shop.Index(c, meta, productsWithImages, categories, nil, listing, nil))
 }
 
 func (s *Service) handlePremium(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	// Create sample premium collection tiers
-	collections := []shop.CollectionTier{
-		{
-			Name:          "Bronze",
-			Slug:          "bronze",
-			Description:   "Essential premium pieces to start your collection with high-quality detail and materials",
-			Price:         4999, // $49.99
-			OriginalPrice: 5999, // $59.99
-			Discount:      17,
-			Items:         3,
-			Color:         "amber",
-			GradientFrom:  "from-amber-600",
-			GradientTo:    "to-yellow-600",
-			IconEmoji:     "🥉",
-			Features: []string{
-				"3 carefully selected premium items",
-				"High-detail 0.2mm layer resolution",
-				"Premium PLA+ materials",
-				"Basic post-processing included",
-				"Standard shipping",
-			},
-		},
-		{
-			Name:          "Silver",
-			Slug:          "silver",
-			Description:   "Enhanced collection featuring superior detail and exclusive variations for dedicated collectors",
-			Price:         9999,  // $99.99
-			OriginalPrice: 12999, // $129.99
-			Discount:      23,
-			Items:         6,
-			Color:         "gray",
-			GradientFrom:  "from-gray-500",
-			GradientTo:    "to-slate-500",
-			IconEmoji:     "🥈",
-			Features: []string{
-				"6 premium items with exclusive variants",
-				"Ultra-high 0.15mm layer resolution",
-				"Premium PETG and ABS materials",
-				"Professional finishing included",
-				"Priority shipping",
-				"Collectible packaging",
-			},
-		},
-		{
-			Name:          "Gold",
-			Slug:          "gold",
-			Description:   "Elite tier with the most detailed models, rare materials, and collector-exclusive items",
-			Price:         19999, // $199.99
-			OriginalPrice: 27999, // $279.99
-			Discount:      29,
-			Items:         10,
-			Color:         "amber",
-			GradientFrom:  "from-amber-500",
-			GradientTo:    "to-yellow-500",
-			IconEmoji:     "🥇",
-			Features: []string{
-				"10 premium items including limited editions",
-				"Microscopic 0.1mm layer resolution",
-				"Specialty resins and metal-filled filaments",
-				"Expert hand-finishing and detailing",
-				"Express shipping with insurance",
-				"Luxury presentation boxes",
-				"Certificate of authenticity",
-			},
-		},
-		{
-			Name:          "Platinum",
-			Slug:          "platinum",
-			Description:   "Ultra-exclusive collection with master-crafted pieces and personalized touches",
-			Price:         39999, // $399.99
-			OriginalPrice: 54999, // $549.99
-			Discount:      27,
-			Items:         15,
-			Color:         "slate",
-			GradientFrom:  "from-slate-400",
-			GradientTo:    "to-gray-400",
-			IconEmoji:     "💎",
-			Features: []string{
-				"15 premium items with custom options",
-				"Museum-quality 0.05mm precision",
-				"Exotic materials including carbon fiber",
-				"Master artisan finishing and painting",
-				"White-glove delivery service",
-				"Heirloom-quality presentation",
-				"Personalized engraving available",
-				"Exclusive access to pre-releases",
-			},
-		},
-		{
-			Name:          "Titanium",
-			Slug:          "titanium",
-			Description:   "Industrial-grade collection featuring aerospace materials and cutting-edge techniques",
-			Price:         79999,  // $799.99
-			OriginalPrice: 109999, // $1099.99
-			Discount:      27,
-			Items:         20,
-			Color:         "slate",
-			GradientFrom:  "from-slate-600",
-			GradientTo:    "to-gray-600",
-			IconEmoji:     "🛡️",
-			Features: []string{
-				"20 premium items with industrial materials",
-				"Aerospace-grade titanium components",
-				"Multi-material hybrid construction",
-				"Professional-grade surface treatments",
-				"Insured courier delivery worldwide",
-				"Collector's vault storage box",
-				"Numbered limited edition pieces",
-				"Direct access to master craftsman",
-				"Annual exclusive release preview",
-			},
-		},
-		{
-			Name:          "Diamond",
-			Slug:          "diamond",
-			Description:   "The pinnacle of 3D printing excellence with precious metal inlays and gemstone accents",
-			Price:         159999, // $1599.99
-			OriginalPrice: 219999, // $2199.99
-			Discount:      27,
-			Items:         25,
-			Color:         "blue",
-			GradientFrom:  "from-blue-400",
-			GradientTo:    "to-cyan-400",
-			IconEmoji:     "💎",
-			Features: []string{
-				"25 masterpiece items with precious accents",
-				"Real gold and silver inlay options",
-				"Swarovski crystal detail work",
-				"Museum curator-level preservation",
-				"Personal delivery by master craftsman",
-				"Custom display case included",
-				"Investment-grade documentation",
-				"Lifetime warranty and restoration",
-				"VIP studio tour and consultation",
-				"Bespoke commission privileges",
-			},
-		},
-		{
-			Name:          "Collectors",
-			Slug:          "collectors",
-			Description:   "Ultimate prestige collection for serious collectors with one-of-a-kind masterpieces",
-			Price:         299999, // $2999.99
-			OriginalPrice: 399999, // $3999.99
-			Discount:      25,
-			Items:         50,
-			Color:         "purple",
-			GradientFrom:  "from-purple-600",
-			GradientTo:    "to-pink-600",
-			IconEmoji:     "👑",
-			Features: []string{
-				"50 unique collector pieces - never reproduced",
-				"Collaboration with renowned artists",
-				"Mixed media incorporating rare materials",
-				"Individual artist signature and provenance",
-				"Private viewing and selection process",
-				"Museum-quality archival storage",
-				"Comprehensive insurance coverage",
-				"Collector network membership",
-				"Priority access to artist collaborations",
-				"Legacy collection management services",
-			},
-		},
+	bundles, err := s.loadActiveBundles(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load bundles")
 	}
 
 	// Get some featured premium products (top 8 most expensive)
-	products, err := s.storage.Queries.ListProducts(ctx)
+	products, err := s.catalog().ListProducts(ctx)
 	if err != nil {
 		slog.Error("failed to fetch products", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
 	}
 
 	// Sort products by price descending and take top 8 (handles variants correctly)
-	featuredProducts := make([]shop.ProductWithImage, 0, 8)
-	for i, product := range products {
-		if i >= 8 {
-			break
-		}
-		featuredProducts = append(featuredProducts, shop.ProductWithImage{
-			Product:  product,
-			ImageURL: s.getProductImageURL(ctx, product),
-		})
+	if len(products) > 8 {
+		products = products[:8]
 	}
+	featuredProducts := s.withProductImages(ctx, products)
 
 	// Build page metadata
 	meta := layout.NewPageMeta(c, s.storage.Queries)
 	meta.Title = "Premium Collections - Logan's 3D Creations"
-	meta.Description = "Discover our premium collection tiers with the most detailed models, bundled discounts, and exclusive variations."
+	meta.Description = "Discover our premium bundles of our most detailed models at bundled discounts, plus exclusive variations."
 	meta.Keywords = []string{"premium 3D prints", "collector items", "detailed models", "bundle discounts", "exclusive collections"}
 
-	return Render(c, shop.Premium(c, collections, featuredProducts, meta))
+	return Render(c, shop.Premium(c, bundles, featuredProducts, meta))
 }
 
 func (s *Service) handleProduct(c echo.Context) error {
@@ -779,6 +1047,20 @@ func (s *Service) handleProduct(c echo.Context) error {
 
 	// Get product by slug (only active products)
 	product, err := s.storage.Queries.GetProductBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		// A renamed product's old URL redirects to its current one
+		renamed, lookupErr := s.storage.Queries.GetProductBySlugRedirect(ctx, slug)
+		if lookupErr == nil {
+			target := "/shop/product/" + renamed.Slug
+			if query := c.Request().URL.RawQuery; query != "" {
+				target += "?" + query
+			}
+			return c.Redirect(http.StatusMovedPermanently, target)
+		}
+		if !errors.Is(lookupErr, sql.ErrNoRows) {
+			slog.Error("failed to look up product slug redirect", "slug", slug, "error", lookupErr)
+		}
+	}
 	if err != nil {
 		// Product not found or inactive - show shopping-specific 404
 		slog.Info("product not found or inactive", "slug", slug)
@@ -786,7 +1068,7 @@ func (s *Service) handleProduct(c echo.Context) error {
 	}
 
 	// Get product images
-	images, err := s.storage.Queries.GetProductImages(ctx, product.ID)
+	images, err := s.catalog().GetProductImages(ctx, product.ID)
 	if err != nil {
 		slog.Error("failed to fetch product images", "product_id", product.ID, "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load product images")
@@ -819,19 +1101,15 @@ func (s *Service) handleProduct(c echo.Context) error {
 		}
 
 		// Build ProductWithImage for each related product (handles variants correctly)
-		for _, relatedProduct := range relatedProductsList {
-			relatedProducts = append(relatedProducts, shop.ProductWithImage{
-				Product:  relatedProduct,
-				ImageURL: s.getProductImageURL(ctx, relatedProduct),
-			})
-		}
+		relatedProducts = s.withProductImages(ctx, relatedProductsList)
 	}
 
 	// Load variant data if applicable
 	var variantData *shop.ProductVariantData
 	if product.HasVariants.Valid && product.HasVariants.Bool {
 		data := shop.ProductVariantData{
-			BasePriceCents: product.PriceCents,
+			BasePriceCents:        product.PriceCents,
+			RegularBasePriceCents: utils.ProductRegularPriceCents(product),
 		}
 
 		styles, err := s.storage.Queries.GetProductVariantStyles(ctx, product.ID)
@@ -858,14 +1136,20 @@ func (s *Service) handleProduct(c echo.Context) error {
 
 			sizeOptions := make([]shop.VariantSizeOption, 0, len(sizes))
 			for _, size := range sizes {
+				// A SKU on sale keeps its usual adjustment aside for the strike-through
+				regularAdjustment := int64FromNull(size.PriceAdjustmentCents)
+				if size.RegularAdjustmentCents.Valid {
+					regularAdjustment = size.RegularAdjustmentCents.Int64
+				}
 				sizeOptions = append(sizeOptions, shop.VariantSizeOption{
-					ValueID:              size.SizeID,
-					Value:                size.SizeName,
-					DisplayName:          size.SizeDisplayName,
-					SkuID:                size.ProductSkuID,
-					SKU:                  size.Sku,
-					PriceAdjustmentCents: int64FromNull(size.PriceAdjustmentCents),
-					StockQuantity:        int64FromNull(size.StockQuantity),
+					ValueID:                size.SizeID,
+					Value:                  size.SizeName,
+					DisplayName:            size.SizeDisplayName,
+					SkuID:                  size.ProductSkuID,
+					SKU:                    size.Sku,
+					PriceAdjustmentCents:   int64FromNull(size.PriceAdjustmentCents),
+					RegularAdjustmentCents: regularAdjustment,
+					StockQuantity:          int64FromNull(size.StockQuantity),
 				})
 			}
 
@@ -922,8 +1206,8 @@ func (s *Service) handleProduct(c echo.Context) error {
 	}
 	meta = meta.WithOGImage(ogImageURL)
 
-	// Add category for breadcrumbs and schema
-	meta = meta.WithCategories([]db.Category{category})
+	// Add the category trail for breadcrumbs and schema
+	meta = meta.WithCategories(s.categoryLineage(ctx, category))
 
 	// If variant params provided, validate and apply variant-specific meta for sharing
 	if selectedColorID != "" && selectedSizeID != "" && variantData != nil {
@@ -956,14 +1240,66 @@ func (s *Service) handleProduct(c echo.Context) error {
 		}
 	}
 
-	return Render(c, shop.Product(c, meta, product, category, images, relatedProducts, variantData))
+	// Published Q&A is shown under the product and marked up as an FAQ
+	questions, err := s.storage.Queries.ListPublishedProductQuestions(ctx, product.ID)
+	if err != nil {
+		slog.Warn("failed to fetch product questions", "product_id", product.ID, "error", err)
+		questions = []db.ProductQuestion{}
+	}
+	meta = meta.WithFAQ(productFAQItems(questions))
+
+	// Personalised rails: the shopper's browsing history, hand-picked pairings (or
+	// co-purchase pairs when there are none) and upgrades
+	viewer := s.currentViewer(c)
+	pairsWellWith, curated := s.loadPairsWellWith(ctx, product.ID)
+	recs := shop.ProductRecommendations{
+		RecentlyViewed:       s.loadRecentlyViewed(ctx, viewer, product.ID),
+		PairsWellWith:        pairsWellWith,
+		PairsWellWithCurated: curated,
+		Upgrades:             s.loadRelatedProducts(ctx, product.ID, utils.RelationTypeUpsell),
+	}
+	s.recordProductView(ctx, viewer, product.ID)
+
+	// Quantity breaks for classroom and bulk orders
+	priceTiers, err := s.storage.Queries.ListProductPriceTiers(ctx, product.ID)
+	if err != nil {
+		slog.Warn("failed to fetch price tiers", "product_id", product.ID, "error", err)
+		priceTiers = []db.ListProductPriceTiersRow{}
+	}
+	volumePricing := volumePriceRows(product.PriceCents, priceTiers)
+
+	attributes, err := s.storage.Queries.ListProductAttributes(ctx, product.ID)
+	if err != nil {
+		slog.Warn("failed to fetch product attributes", "product_id", product.ID, "error", err)
+		attributes = []db.ProductAttribute{}
+	}
+
+	// Gallery videos; the first one is shared as og:video
+	videos, err := s.storage.Queries.ListProductVideos(ctx, product.ID)
+	if err != nil {
+		slog.Warn("failed to fetch product videos", "product_id", product.ID, "error", err)
+		videos = []db.ProductVideo{}
+	}
+	if len(videos) > 0 {
+		meta = meta.WithVideo(utils.VideoEmbedURL(videos[0].Provider, videos[0].VideoRef), videos[0].ContentType)
+	}
+
+	productBadges := s.productBadges(ctx, []db.Product{product})[product.ID]
+
+	personalizationFields, err := s.storage.Queries.ListProductPersonalizationFields(ctx, product.ID)
+	if err != nil {
+		slog.Warn("failed to fetch personalization fields", "product_id", product.ID, "error", err)
+		personalizationFields = []db.ProductPersonalizationField{}
+	}
+
+	return Render(c, shop.Product(c, meta, product, category, images, relatedProducts, variantData, questions, recs, volumePricing, productSpecs(attributes), productVideoViews(videos), productBadges, personalizationFields))
 }
 
 func (s *Service) handleProductNotFound(c echo.Context, slug string) error {
 	ctx := c.Request().Context()
 
 	// Get all categories for browsing
-	categories, err := s.storage.Queries.ListCategories(ctx)
+	categories, err := s.catalog().ListCategories(ctx)
 	if err != nil {
 		slog.Error("failed to fetch categories", "error", err)
 		categories = []db.Category{}
@@ -973,19 +1309,13 @@ func (s *Service) handleProductNotFound(c echo.Context, slug string) error {
 	var relatedProducts []shop.ProductWithImage
 
 	// Get a few featured products as suggestions (handles variants correctly)
-	featuredProducts, err := s.storage.Queries.ListFeaturedProducts(ctx)
+	featuredProducts, err := s.catalog().ListFeaturedProducts(ctx)
 	if err == nil && len(featuredProducts) > 0 {
 		limit := 4
 		if len(featuredProducts) < limit {
 			limit = len(featuredProducts)
 		}
-		for i := 0; i < limit; i++ {
-			product := featuredProducts[i]
-			relatedProducts = append(relatedProducts, shop.ProductWithImage{
-				Product:  product,
-				ImageURL: s.getProductImageURL(ctx, product),
-			})
-		}
+		relatedProducts = s.withProductImages(ctx, featuredProducts[:limit])
 	}
 
 	// Build page metadata
@@ -1004,34 +1334,40 @@ func (s *Service) handleCategory(c echo.Context) error {
 
 	// Get category by slug
 	category, err := s.storage.Queries.GetCategoryBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		// A renamed category's old URL redirects to its current one
+		renamed, lookupErr := s.storage.Queries.GetCategoryByPreviousSlug(ctx, slug)
+		if lookupErr == nil {
+			target := "/shop/category/" + renamed.Slug
+			if query := c.Request().URL.RawQuery; query != "" {
+				target += "?" + query
+			}
+			return c.Redirect(http.StatusMovedPermanently, target)
+		}
+		if !errors.Is(lookupErr, sql.ErrNoRows) {
+			slog.Error("failed to look up previous category slug", "slug", slug, "error", lookupErr)
+		}
+	}
 	if err != nil {
 		slog.Error("failed to fetch category", "slug", slug, "error", err)
 		return echo.NewHTTPError(http.StatusNotFound, "Category not found")
 	}
 
 	// Get all categories for filter
-	categories, err := s.storage.Queries.ListCategories(ctx)
+	categories, err := s.catalog().ListCategories(ctx)
 	if err != nil {
 		slog.Error("failed to fetch categories", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load categories")
 	}
 
-	// Get products in this category
-	products, err := s.storage.Queries.ListProductsByCategory(ctx, sql.NullString{String: category.ID, Valid: true})
+	// Get the current page of filtered products in this category
+	filters := parseShopFilters(c)
+	productsWithImages, listing, err := s.loadShopListing(ctx, filters, category.ID, "/shop/category/"+category.Slug)
 	if err != nil {
 		slog.Error("failed to fetch products", "category_id", category.ID, "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load products")
 	}
 
-	// Combine with images (handles variants correctly)
-	productsWithImages := make([]shop.ProductWithImage, 0, len(products))
-	for _, product := range products {
-		productsWithImages = append(productsWithImages, shop.ProductWithImage{
-			Product:  product,
-			ImageURL: s.getProductImageURL(ctx, product),
-		})
-	}
-
 	// Build PageMeta for category page
 	meta := layout.NewPageMeta(c, s.storage.Queries)
 	meta.Title = fmt.Sprintf("%s - 3D Printed Collectibles | Logan's 3D Creations", category.Name)
@@ -1042,8 +1378,28 @@ func (s *Service) handleCategory(c echo.Context) error {
 	}
 	meta.Keywords = []string{"3D printed " + category.Name, category.Name + " collectibles", "buy 3D prints", "custom 3D printing"}
 	meta.OGType = "website"
+	meta.CanonicalURL = shopCanonicalURL(meta.SiteURL, listing)
+	meta.OGURL = meta.CanonicalURL
+	// The owner's SEO fields override the generated title and description, for
+	// search results and link previews alike
+	if category.SeoTitle != "" {
+		meta.Title = category.SeoTitle
+		meta.OGTitle = category.SeoTitle
+		meta.TwitterTitle = category.SeoTitle
+	}
+	if category.SeoDescription != "" {
+		meta.Description = category.SeoDescription
+		meta.OGDescription = category.SeoDescription
+		meta.TwitterDescription = category.SeoDescription
+	}
+	meta = meta.WithCategories(s.categoryLineage(ctx, category))
+
+	landing := s.categoryLanding(ctx, category, listing)
+	if landing.BannerURL != "" {
+		meta = meta.WithOGImage(landing.BannerURL)
+	}
 
-	return Render(c, shop.Index(c, meta, productsWithImages, categories, &category))
+	return Render(c, shop.Index(c, meta, productsWithImages, categories, &category, listing, landing))
 }
 
 // Cart handlers removed - replaced with Stripe Checkout
@@ -1792,8 +2148,18 @@ func (s *Service) handleDeleteCustomDraft(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]interface{}{"success": true})
 }
 
+// handleCheckoutCancel is where Stripe sends a shopper who backs out of checkout. The
+// stock their checkout held goes back on sale, and they return to their cart.
 func (s *Service) handleCheckoutCancel(c echo.Context) error {
-	return c.Redirect(http.StatusSeeOther, "/shop")
+	if cookie, err := c.Cookie("session_id"); err == nil && cookie.Value != "" {
+		released, err := inventory.NewReservations(s.storage.Queries).ReleaseCart(c.Request().Context(), cookie.Value)
+		if err != nil {
+			slog.Error("failed to release stock holds for cancelled checkout", "error", err, "session_id", cookie.Value)
+		} else if released > 0 {
+			slog.Info("stock holds released for cancelled checkout", "session_id", cookie.Value, "lines", released)
+		}
+	}
+	return c.Redirect(http.StatusSeeOther, "/cart")
 }
 
 func (s *Service) handleCheckoutSuccess(c echo.Context) error {
@@ -1807,12 +2173,13 @@ func (s *Service) handleCheckoutSuccess(c echo.Context) error {
 	// Retrieve the Stripe checkout session
 	stripe.Key = s.config.Stripe.SecretKey
 	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
 	params.AddExpand("line_items")
 	params.AddExpand("line_items.data.price.product")
 	params.AddExpand("total_details.breakdown")
 	session, err := checkoutsession.Get(sessionID, params)
 	if err != nil {
-		slog.Error("failed to retrieve stripe session", "error", err, "session_id", sessionID)
+		slog.ErrorContext(ctx, "failed to retrieve stripe session", "error", err, "session_id", sessionID)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve checkout session")
 	}
 
@@ -1840,8 +2207,12 @@ func (s *Service) handleCheckoutSuccess(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve order")
 	}
 
-	// Redirect directly to order detail page with purchase tracking flag
+	// Redirect directly to order detail page with purchase tracking flag. Guests have
+	// no account, so theirs is shown at a link keyed to the Stripe session.
 	orderURL := "/account/orders/" + order.ID + "?purchase=true"
+	if order.UserID == "" {
+		orderURL = guestOrderPath(sessionID) + "?purchase=true"
+	}
 	return c.Redirect(http.StatusSeeOther, orderURL)
 }
 
@@ -1853,7 +2224,30 @@ func (s *Service) handleCart(c echo.Context) error {
 	meta.Description = "Review your items and proceed to checkout"
 	meta.Keywords = []string{"shopping cart", "checkout", "3D printed items"}
 
-	return Render(c, shop.Cart(c, meta))
+	ctx := c.Request().Context()
+	viewer := s.currentViewer(c)
+	recs := shop.ProductRecommendations{
+		RecentlyViewed:           s.loadRecentlyViewed(ctx, viewer, ""),
+		FrequentlyBoughtTogether: s.loadCartRecommendations(ctx, viewer),
+	}
+
+	// Signed-in customers see the store credit checkout will use
+	var storeCredit int64
+	if viewer.UserID.Valid {
+		balance, err := storecredit.NewLedger(s.storage.Queries).Balance(ctx, viewer.UserID.String)
+		if err != nil {
+			slog.Error("failed to fetch store credit balance", "error", err, "user_id", viewer.UserID.String)
+		}
+		storeCredit = balance
+	}
+
+	// Checkout asks the customer to agree to the versions in effect now
+	policyDocs, err := policies.NewLibrary(s.storage.Queries).Current(ctx, time.Now())
+	if err != nil {
+		slog.Error("failed to fetch current policies", "error", err)
+	}
+
+	return Render(c, shop.Cart(c, meta, recs, s.cartRestored(c), storeCredit, policyDocs))
 }
 
 // handleAccount renders the account page with profile and order history
@@ -1889,45 +2283,129 @@ func (s *Service) handleAccount(c echo.Context) error {
 		buyAgainItems = []db.GetBuyAgainItemsRow{}
 	}
 
+	// Store credit and its recent history; a failure just hides the section
+	storeCredit, err := storecredit.NewLedger(s.storage.Queries).Balance(ctx, user.ID)
+	if err != nil {
+		slog.Error("failed to fetch store credit balance", "error", err, "user_id", user.ID)
+	}
+	creditHistory, err := s.storage.Queries.ListStoreCreditTransactions(ctx, db.ListStoreCreditTransactionsParams{
+		UserID:     user.ID,
+		LimitCount: 10,
+	})
+	if err != nil {
+		slog.Error("failed to fetch store credit history", "error", err, "user_id", user.ID)
+	}
+
+	// The address the cart quotes shipping to without asking
+	var defaultAddress *db.UserDefaultAddress
+	if saved, err := s.storage.Queries.GetUserDefaultAddress(ctx, user.ID); err == nil {
+		defaultAddress = &saved
+	} else if err != sql.ErrNoRows {
+		slog.Error("failed to fetch default shipping address", "error", err, "user_id", user.ID)
+	}
+
 	// Build page metadata
 	meta := layout.NewPageMeta(c, s.storage.Queries)
 	meta.Title = "My Account - Logan's 3D Creations"
 	meta.Description = "Manage your account and view order history"
 
 	// Render account page
-	return Render(c, account.Index(c, user, orders, buyAgainItems, meta))
+	return Render(c, account.Index(c, user, orders, buyAgainItems, storeCredit, creditHistory, defaultAddress, meta))
 }
 
-func (s *Service) handleAccountOrderDetail(c echo.Context) error {
-	// Check authentication
-	if !auth.IsAuthenticated(c) {
-		return c.Redirect(http.StatusFound, "/login?redirect_url=/account")
-	}
-
-	// Get user from context
+// handleDeleteDefaultAddress forgets the customer's saved shipping address, so the
+// cart asks for one again
+func (s *Service) handleDeleteDefaultAddress(c echo.Context) error {
 	user, ok := auth.GetDBUser(c)
 	if !ok {
-		slog.Error("authenticated user not found in context")
-		return echo.NewHTTPError(http.StatusUnauthorized, "User not found")
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account")
 	}
+	if err := s.storage.Queries.DeleteUserDefaultAddress(c.Request().Context(), user.ID); err != nil {
+		slog.Error("failed to delete default shipping address", "error", err, "user_id", user.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove address")
+	}
+	return c.Redirect(http.StatusSeeOther, "/account")
+}
 
-	ctx := c.Request().Context()
-	orderID := c.Param("id")
-
-	// Fetch the order
-	order, err := s.storage.Queries.GetOrder(ctx, orderID)
+// customerOrder loads an order for the signed-in customer who placed it
+func (s *Service) customerOrder(c echo.Context, userID, orderID string) (db.Order, error) {
+	order, err := s.storage.Queries.GetOrder(c.Request().Context(), orderID)
 	if err != nil {
 		slog.Error("failed to fetch order", "error", err, "order_id", orderID)
-		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+		return db.Order{}, echo.NewHTTPError(http.StatusNotFound, "Order not found")
 	}
 
 	// Verify the order belongs to the user
-	if order.UserID != user.ID {
-		slog.Error("user attempted to access order they don't own", "user_id", user.ID, "order_id", orderID)
-		return echo.NewHTTPError(http.StatusForbidden, "Access denied")
+	if order.UserID != userID {
+		slog.Error("user attempted to access order they don't own", "user_id", userID, "order_id", orderID)
+		return db.Order{}, echo.NewHTTPError(http.StatusForbidden, "Access denied")
 	}
+	return order, nil
+}
+
+// orderStatusSteps is the order's status history as the customer sees it: when it
+// reached each status, without the shop's notes or who made the change
+func (s *Service) orderStatusSteps(ctx context.Context, orderID string) []account.OrderStatusStep {
+	history, err := s.storage.Queries.ListOrderStatusHistory(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to fetch order status history", "error", err, "order_id", orderID)
+		return nil
+	}
+	steps := make([]account.OrderStatusStep, 0, len(history))
+	for _, change := range history {
+		steps = append(steps, account.OrderStatusStep{Status: change.ToStatus, At: change.CreatedAt.Time})
+	}
+	return steps
+}
 
-	// Fetch order items
+// orderShipments lists the boxes an order went out in, with what was in each
+func (s *Service) orderShipments(ctx context.Context, orderID string) []account.OrderShipment {
+	shipments, err := s.storage.Queries.ListOrderShipments(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to fetch order shipments", "error", err, "order_id", orderID)
+		return nil
+	}
+	items, err := s.storage.Queries.ListOrderShipmentItems(ctx, orderID)
+	if err != nil {
+		slog.Error("failed to fetch order shipment items", "error", err, "order_id", orderID)
+		return nil
+	}
+	result := make([]account.OrderShipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		box := account.OrderShipment{
+			Carrier:        shipment.Carrier,
+			TrackingNumber: shipment.TrackingNumber,
+			TrackingURL:    shipment.TrackingUrl,
+			ShippedAt:      shipment.CreatedAt.Time,
+			Status:         shipment.Status,
+			DeliveredAt:    shipment.DeliveredAt.Time,
+		}
+		for _, item := range items {
+			if item.ShipmentID == shipment.ID {
+				box.Items = append(box.Items, account.OrderShipmentItem{ProductName: item.ProductName, Quantity: item.Quantity})
+			}
+		}
+		result = append(result, box)
+	}
+	return result
+}
+
+// orderCancel works out whether the customer can still cancel the order themselves,
+// and picks up the outcome of a cancellation they just tried
+func (s *Service) orderCancel(c echo.Context, order db.Order) account.OrderCancel {
+	cancel := account.OrderCancel{
+		Cancelled: c.QueryParam("cancelled") == "1",
+		Error:     c.QueryParam("error"),
+	}
+	window := settings.For(s.storage.Queries).Values(c.Request().Context()).OrderCancelWindow()
+	if utils.CustomerCanCancel(order.Status.String, order.CreatedAt.Time, time.Now(), window) {
+		cancel.Deadline = order.CreatedAt.Time.Add(window)
+	}
+	return cancel
+}
+
+// accountOrderItems loads an order's lines with each product's slug, availability and image
+func (s *Service) accountOrderItems(ctx context.Context, orderID string) []account.OrderItemWithProduct {
 	orderItems, err := s.storage.Queries.GetOrderItems(ctx, orderID)
 	if err != nil {
 		slog.Error("failed to fetch order items", "error", err, "order_id", orderID)
@@ -1969,6 +2447,59 @@ func (s *Service) handleAccountOrderDetail(c echo.Context) error {
 			itemsWithProduct[i].ImageURL = "/public/images/products/" + images[0].ImageUrl
 		}
 	}
+	return itemsWithProduct
+}
+
+// handleGetAccountOrder returns one of the customer's orders as JSON
+func (s *Service) handleGetAccountOrder(c echo.Context) error {
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Sign in to view your orders")
+	}
+
+	order, err := s.customerOrder(c, user.ID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	lines := s.accountOrderItems(c.Request().Context(), order.ID)
+	items := make([]api.OrderItem, 0, len(lines))
+	for _, line := range lines {
+		slug := ""
+		if line.IsAvailable {
+			slug = line.ProductSlug
+		}
+		items = append(items, api.NewOrderItem(line.Item, line.ImageURL, slug))
+	}
+
+	return c.JSON(http.StatusOK, api.NewOrder(order, items))
+}
+
+func (s *Service) handleAccountOrderDetail(c echo.Context) error {
+	// Check authentication
+	if !auth.IsAuthenticated(c) {
+		return c.Redirect(http.StatusFound, "/login?redirect_url=/account")
+	}
+
+	// Get user from context
+	user, ok := auth.GetDBUser(c)
+	if !ok {
+		slog.Error("authenticated user not found in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "User not found")
+	}
+
+	ctx := c.Request().Context()
+	order, err := s.customerOrder(c, user.ID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	itemsWithProduct := s.accountOrderItems(ctx, order.ID)
+
+	downloads, err := s.orderDownloadLinks(ctx, order.ID)
+	if err != nil {
+		// Still show the order; the customer can reload for their links
+		downloads = nil
+	}
 
 	// Build page metadata
 	meta := layout.NewPageMeta(c, s.storage.Queries)
@@ -1976,68 +2507,121 @@ func (s *Service) handleAccountOrderDetail(c echo.Context) error {
 	meta.Description = "View order details and tracking information"
 
 	// Render order detail page
-	return Render(c, account.OrderDetail(c, order, itemsWithProduct, meta))
+	return Render(c, account.OrderDetail(c, order, itemsWithProduct, downloads, s.orderStatusSteps(ctx, order.ID), s.orderShipments(ctx, order.ID), s.orderCancel(c, order), meta, nil))
 }
 
-// handleCreateStripeCheckoutSessionCart handles checkout from cart session
-//
-// INTENTIONAL: This handler does NOT block checkout when stock is zero.
-// Products with zero stock can still be purchased - the customer will see
-// extended shipping times (e.g., "Ships in 2-3 weeks") in their cart and order.
-// Stock is decremented in the webhook handler with protection against going negative.
-// This is a business decision to allow pre-orders/backorders rather than losing sales.
+// handleCreateStripeCheckoutSessionCart turns the shopper's cart into a checkout: a
+// Stripe Checkout Session, or a draft paid on our own page when the embedded checkout
+// is on. The cart is checked against current prices, policies and shipping first.
+// Units beyond what's in stock, less what other checkouts hold, are sold only as the
+// product's backorder settings allow; pre-orders, downloads and gift cards aren't sold
+// from stock. The in-stock units, any gift card amount and store credit are held
+// while the checkout is open, and the cart's earlier checkout is closed first.
 func (s *Service) handleCreateStripeCheckoutSessionCart(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	// Get session ID from cookie
 	sessionID, err := s.getOrCreateSessionID(c)
 	if err != nil {
-		slog.Error("failed to get session ID", "error", err)
+		slog.ErrorContext(ctx, "failed to get session ID", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Session error")
 	}
 
-	// Get shipping selection from database
-	shippingSelection, err := s.storage.Queries.GetSessionShippingSelection(ctx, sessionID)
-	if err == sql.ErrNoRows {
+	// SECURITY: Signed-in shoppers check out their own cart. Without an account the
+	// browser session's cart is checked out as a guest, if guest checkout is on.
+	owner := cartOwner{SessionID: sessionID}
+	var customerEmail string
+	if user, ok := auth.GetDBUser(c); ok {
+		owner.UserID = user.ID
+		customerEmail = user.Email
+	} else if !flags.Enabled(ctx, flags.GuestCheckout) {
+		slog.ErrorContext(ctx, "checkout attempted by unauthenticated user")
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+
+	// Optional instructions, gift options, text updates, a promo code, a gift card and
+	// the carbon offset from the cart, carried to the order through session metadata
+	var req struct {
+		Notes              string `json:"notes"`
+		Gift               bool   `json:"gift"`
+		GiftMessage        string `json:"gift_message"`
+		GiftRecipientName  string `json:"gift_recipient_name"`
+		GiftRecipientEmail string `json:"gift_recipient_email"`
+		SMSOptIn           bool   `json:"sms_opt_in"`
+		SMSPhone           string `json:"sms_phone"`
+		PromoCode          string `json:"promo_code"`
+		GiftCardCode       string `json:"gift_card_code"`
+		CarbonOffset       bool   `json:"carbon_offset"`
+		// AcceptedPolicies are the versions of the terms and policies the cart showed
+		// when the customer ticked the box agreeing to them
+		AcceptedPolicies []string `json:"accepted_policies"`
+	}
+	if err := c.Bind(&req); err != nil {
+		slog.ErrorContext(ctx, "failed to bind checkout request", "error", err)
 		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
-			"error": "Please select shipping before checkout",
+			"error": "Invalid request",
 		})
 	}
+	orderNotes, err := utils.NormalizeOrderNotes(req.Notes)
 	if err != nil {
-		slog.Error("failed to get shipping selection", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get shipping selection")
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Order notes can be up to %d characters", utils.MaxOrderNotesLength),
+		})
 	}
-
-	// Validate shipping is still valid
-	if !shippingSelection.IsValid.Valid || !shippingSelection.IsValid.Bool {
+	gift, err := utils.NormalizeGiftOptions(utils.GiftOptions{
+		IsGift:         req.Gift,
+		Message:        req.GiftMessage,
+		RecipientName:  req.GiftRecipientName,
+		RecipientEmail: req.GiftRecipientEmail,
+	})
+	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
-			"error": "Shipping selection is no longer valid. Please select shipping again.",
+			"error": "Gift options: " + err.Error(),
 		})
 	}
+	// A number saved in the browser while texts were offered is dropped once they aren't
+	var smsPhone string
+	if req.SMSOptIn && sms.Offered(settings.For(s.storage.Queries).Values(ctx)) {
+		if smsPhone, err = sms.NormalizePhone(req.SMSPhone); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": "Mobile number: " + err.Error(),
+			})
+		}
+	}
 
-	// SECURITY: Get authenticated user - checkout requires authentication
-	user, ok := auth.GetDBUser(c)
-	if !ok {
-		slog.Error("checkout attempted by unauthenticated user")
-		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	// The customer agrees to the versions in effect, and only to ones they were shown.
+	// If one took effect since the cart loaded, they're sent back to read it.
+	policyDocs, err := policies.NewLibrary(s.storage.Queries).Current(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get current policies", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+	}
+	switch err := policies.Check(policyDocs, req.AcceptedPolicies); {
+	case errors.Is(err, policies.ErrNotAccepted):
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+			"error": "Please agree to the terms and policies to check out",
+		})
+	case errors.Is(err, policies.ErrChanged):
+		return echo.NewHTTPError(http.StatusConflict, map[string]any{
+			"error":            "Our terms or policies were updated while you were shopping. Please review them before checking out.",
+			"policies_changed": true,
+		})
 	}
 
-	// SECURITY: Transfer any session cart items to the authenticated user
+	// SECURITY: Merge any session cart items into the authenticated user's cart
 	// This ensures items added before login are associated with the user
-	transferErr := s.storage.Queries.TransferCartToUser(ctx, db.TransferCartToUserParams{
-		UserID:    sql.NullString{String: user.ID, Valid: true},
-		SessionID: sql.NullString{String: sessionID, Valid: true},
-	})
-	if transferErr != nil {
-		slog.Error("failed to transfer cart to user", "error", transferErr, "user_id", user.ID)
-		// Don't fail - continue with checkout
+	if owner.UserID != "" {
+		if _, mergeErr := s.mergeGuestCart(ctx, sessionID, owner.UserID); mergeErr != nil {
+			slog.ErrorContext(ctx, "failed to merge guest cart", "error", mergeErr, "user_id", owner.UserID)
+			// Don't fail - continue with checkout
+		}
 	}
 
-	// SECURITY: Get cart items by user_id to ensure user owns the cart
-	// This prevents a user from checking out with another user's cart
-	cartItems, err := s.storage.Queries.GetCartByUser(ctx, sql.NullString{String: user.ID, Valid: true})
+	// SECURITY: Get cart items by user_id, or by session for a guest, to ensure the
+	// shopper owns the cart. This prevents checking out with another user's cart.
+	cartItems, err := s.ownerCartRows(ctx, owner)
 	if err != nil {
-		slog.Error("failed to get cart items", "error", err, "user_id", user.ID)
+		slog.ErrorContext(ctx, "failed to get cart items", "error", err, "user_id", owner.UserID, "session_id", sessionID)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch cart")
 	}
 
@@ -2045,13 +2629,113 @@ func (s *Service) handleCreateStripeCheckoutSessionCart(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Cart is empty")
 	}
 
+	// Prices and stock can change between adding to the cart and paying, so any
+	// difference goes back to the shopper to accept before Stripe sees the cart
+	changes, err := s.cartChanges(ctx, cartItems)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check cart for changes", "error", err, "user_id", owner.UserID, "session_id", sessionID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+	}
+	if len(changes) > 0 {
+		slog.InfoContext(ctx, "checkout refused for cart changes", "user_id", owner.UserID, "session_id", sessionID, "changes", len(changes))
+		return echo.NewHTTPError(http.StatusConflict, map[string]any{
+			"error":   cartChangedMessage,
+			"changes": cartChangeMessages(changes),
+		})
+	}
+
+	productTypes := make([]string, 0, len(cartItems))
+	for _, item := range cartItems {
+		productTypes = append(productTypes, item.ProductType)
+	}
+	// Digital-only carts are delivered by download, so there's nothing to ship
+	digitalOnly := cartIsDigitalOnly(productTypes)
+
+	var shippingSelection db.SessionShippingSelection
+	if !digitalOnly {
+		// Get shipping selection from database
+		shippingSelection, err = s.storage.Queries.GetSessionShippingSelection(ctx, sessionID)
+		if err == sql.ErrNoRows {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": "Please select shipping before checkout",
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get shipping selection", "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get shipping selection")
+		}
+
+		// Validate shipping is still valid
+		if !shippingSelection.IsValid.Valid || !shippingSelection.IsValid.Bool {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": "Shipping selection is no longer valid. Please select shipping again.",
+			})
+		}
+		if shippingSelection.AddressVerificationStatus == shipping.AddressUndeliverable {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": "We can't deliver to that shipping address. Please enter a different address.",
+			})
+		}
+	}
+
+	// Quantity breaks depend on the whole cart, so work them out before building lines
+	volumeLines := make([]volumeLine, 0, len(cartItems))
+	for _, item := range cartItems {
+		volumeLines = append(volumeLines, volumeLine{
+			ItemID:          item.ID,
+			ProductID:       item.ProductID,
+			SkuID:           item.ProductSkuID.String,
+			Quantity:        item.Quantity,
+			RegularCents:    item.PriceCents,
+			AdjustmentCents: item.PriceAdjustmentCents,
+			Bundle:          item.BundleID != "",
+		})
+	}
+	volumePrices := s.cartVolumePrices(ctx, volumeLines)
+	promos := s.cartPromotions(ctx, cartPromotionLines(cartItems, volumePrices))
+
+	// Promo codes are checked here, against the campaign's exclusions, stacking and
+	// per-customer limits, rather than entered on the Stripe page where those can't be
+	// enforced
+	var promoCode appliedPromoCode
+	if strings.TrimSpace(req.PromoCode) != "" {
+		promoCode, err = s.checkPromoCode(ctx, req.PromoCode, owner.UserID, customerEmail, cartItems, volumePrices, promos)
+		var codeErr *promocodes.Error
+		if errors.As(err, &codeErr) {
+			return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+				"error": codeErr.Message,
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to check promo code", "error", err, "code", req.PromoCode)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
+		}
+		if promoCode.DropCartPromotion {
+			promos = promos.WithoutDiscount()
+		}
+	}
+
+	// A checkout started again replaces the cart's earlier one, so once that's closed
+	// what it held doesn't count against this one
+	stripe.Key = s.config.Stripe.SecretKey
+	s.replaceEarlierCheckouts(ctx, sessionID, s.expireCheckout)
+	reservations := inventory.NewReservations(s.storage.Queries)
+	// stockLines are the in-stock units this checkout holds, and held counts them by
+	// product or SKU so lines of the same item share what's left
+	var stockLines []inventory.Line
+	held := make(map[string]int64)
+
 	// Convert cart items to Stripe line items
 	var lineItems []*stripe.CheckoutSessionLineItemParams
+	var subtotalCents int64
+	// giftCardLinesCents is what the gift cards bought in this order cost, which a
+	// gift card can't pay for
+	var giftCardLinesCents int64
 
 	for _, item := range cartItems {
 		product, err := s.storage.Queries.GetProduct(ctx, item.ProductID)
 		if err != nil {
-			slog.Error("failed to load product for cart item", "error", err, "product_id", item.ProductID)
+			slog.ErrorContext(ctx, "failed to load product for cart item", "error", err, "product_id", item.ProductID)
 			return echo.NewHTTPError(http.StatusBadRequest, "One of your items is no longer available")
 		}
 
@@ -2070,15 +2754,79 @@ func (s *Service) handleCreateStripeCheckoutSessionCart(c echo.Context) error {
 			sku = &skuRecord
 		}
 
+		// Units other checkouts hold aren't for sale, so stock is what they leave. Gift
+		// cards, downloads and pre-orders aren't sold from stock.
+		if !product.IsPreorder && !product.IsGiftCard && product.ProductType != utils.ProductTypeDigital {
+			skuID := item.ProductSkuID.String
+			reserved, reservedErr := reservations.Reserved(ctx, product.ID, skuID)
+			if reservedErr != nil {
+				// The hold below still refuses units another checkout has
+				slog.ErrorContext(ctx, "failed to get reserved stock", "error", reservedErr, "product_id", product.ID, "sku_id", skuID)
+			}
+			key := product.ID + "/" + skuID
+			available := itemStockQuantity(product, sku) - reserved - held[key]
+			if sku != nil {
+				sku.StockQuantity = sql.NullInt64{Int64: available, Valid: true}
+			} else {
+				product.StockQuantity = sql.NullInt64{Int64: available, Valid: true}
+			}
+			if units := min(item.Quantity, max(available, 0)); units > 0 {
+				held[key] += units
+				stockLines = append(stockLines, inventory.Line{ProductID: product.ID, SkuID: skuID, Name: product.Name, Quantity: units})
+			}
+		}
+
+		if backorderErr := s.checkBackorder(ctx, product, sku, item.Quantity); backorderErr != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, backorderErr.Error())
+		}
+
 		variantName, imageURL, attrs, effectivePrice, err := s.buildSkuPresentation(ctx, product, sku)
 		if err != nil {
-			slog.Error("failed to build variant presentation", "error", err, "product_id", product.ID)
+			slog.ErrorContext(ctx, "failed to build variant presentation", "error", err, "product_id", product.ID)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Unable to prepare checkout")
 		}
 
+		// Bundle lines are charged their share of the bundle price
+		if item.BundleID != "" {
+			effectivePrice = item.BundleUnitPriceCents
+			variantName = fmt.Sprintf("%s (%s)", variantName, item.BundleName)
+		} else if vp, ok := volumePrices[item.ID]; ok && vp.UnitPriceCents < effectivePrice {
+			effectivePrice = vp.UnitPriceCents
+		}
+
 		metadata := map[string]string{
 			"product_id": item.ProductID,
 		}
+		if item.BundleID != "" {
+			metadata["bundle_id"] = item.BundleID
+		}
+		if vp, ok := volumePrices[item.ID]; ok && item.BundleID == "" {
+			metadata["volume_tier"] = fmt.Sprintf("%d+", vp.MinQuantity)
+		}
+
+		// Free shipping is measured against the subtotal before promotions, as in the cart
+		subtotalCents += effectivePrice * item.Quantity
+
+		// The applied cart promotion lowers the unit price or gives units away; free
+		// units go on a line of their own at no charge
+		paidQuantity := item.Quantity
+		var freeQuantity int64
+		if lp, ok := promos.Lines[item.ID]; ok {
+			effectivePrice = lp.UnitPriceCents
+			freeQuantity = lp.FreeQuantity
+			paidQuantity -= freeQuantity
+			metadata["cart_promotion"] = promos.Applied.Name
+		}
+		if product.IsPreorder {
+			metadata["preorder"] = "true"
+		}
+		if product.ProductType == utils.ProductTypeDigital {
+			metadata["digital"] = "true"
+		}
+		if product.IsGiftCard {
+			metadata["gift_card"] = "true"
+			giftCardLinesCents += effectivePrice * paidQuantity
+		}
 		if sku != nil {
 			metadata["sku_id"] = sku.ID
 			if sku.Sku != "" {
@@ -2088,17 +2836,27 @@ func (s *Service) handleCreateStripeCheckoutSessionCart(c echo.Context) error {
 				metadata[key] = val
 			}
 		}
+		description := itemShippingMessage(product, sku, item.Quantity)
+		if item.Personalization != "" {
+			// The webhook copies this onto the order item; the summary shows the customer
+			// what they entered on the Stripe page
+			metadata["personalization"] = item.Personalization
+			if summary := utils.PersonalizationSummary(utils.DecodePersonalization(item.Personalization)); summary != "" {
+				description = summary + " · " + description
+			}
+		}
 
 		lineItem := &stripe.CheckoutSessionLineItemParams{
 			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
 				Currency:   stripe.String("usd"),
 				UnitAmount: stripe.Int64(effectivePrice),
 				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-					Name:     stripe.String(variantName),
-					Metadata: metadata,
+					Name:        stripe.String(variantName),
+					Description: stripe.String(description),
+					Metadata:    metadata,
 				},
 			},
-			Quantity: stripe.Int64(item.Quantity),
+			Quantity: stripe.Int64(paidQuantity),
 		}
 
 		// Add product image if available
@@ -2106,81 +2864,268 @@ func (s *Service) handleCreateStripeCheckoutSessionCart(c echo.Context) error {
 			lineItem.PriceData.ProductData.Images = []*string{stripe.String(imageURL)}
 		}
 
-		lineItems = append(lineItems, lineItem)
+		if paidQuantity > 0 {
+			lineItems = append(lineItems, lineItem)
+		}
+		if freeQuantity > 0 {
+			lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency:   stripe.String("usd"),
+					UnitAmount: stripe.Int64(0),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name:        stripe.String(variantName + " (free)"),
+						Description: stripe.String(promos.Applied.Name + " · " + description),
+						Images:      lineItem.PriceData.ProductData.Images,
+						Metadata:    metadata,
+					},
+				},
+				Quantity: stripe.Int64(freeQuantity),
+			})
+		}
 	}
 
-	// Add shipping as a line item
-	deliveryDaysText := ""
-	if shippingSelection.DeliveryDays.Valid && shippingSelection.DeliveryDays.Int64 > 0 {
-		deliveryDaysText = fmt.Sprintf("Estimated delivery: %d business days", shippingSelection.DeliveryDays.Int64)
+	// The cart page shows these too, but the cart can change in another tab
+	if problems := s.checkoutProblems(c, cartItems, subtotalCents); len(problems) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]string{
+			"error": strings.Join(problems, " "),
+		})
 	}
 
-	shippingLineItem := &stripe.CheckoutSessionLineItemParams{
-		PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-			Currency:   stripe.String("usd"),
-			UnitAmount: stripe.Int64(shippingSelection.PriceCents),
-			ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-				Name:        stripe.String(fmt.Sprintf("Shipping - %s %s", shippingSelection.CarrierName, shippingSelection.ServiceName)),
-				Description: stripe.String(deliveryDaysText),
+	// Add shipping as a line item, waived once the subtotal reaches the threshold
+	freeShippingThreshold := promos.FreeShippingThreshold(s.freeShippingThreshold())
+	freeShipping := !digitalOnly && api.NewFreeShipping(subtotalCents, freeShippingThreshold).Qualifies
+	if !digitalOnly {
+		shippingCents := shippingSelection.PriceCents
+		shippingName := fmt.Sprintf("Shipping - %s %s", shippingSelection.CarrierName, shippingSelection.ServiceName)
+		if freeShipping {
+			shippingCents = 0
+			shippingName = "Free " + shippingName
+		}
+
+		deliveryDaysText := ""
+		if shippingSelection.DeliveryDays.Valid && shippingSelection.DeliveryDays.Int64 > 0 {
+			deliveryDaysText = fmt.Sprintf("Estimated delivery: %d business days", shippingSelection.DeliveryDays.Int64)
+		}
+
+		shippingLineItem := &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:   stripe.String("usd"),
+				UnitAmount: stripe.Int64(shippingCents),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name:        stripe.String(shippingName),
+					Description: stripe.String(deliveryDaysText),
+				},
 			},
-		},
-		Quantity: stripe.Int64(1),
+			Quantity: stripe.Int64(1),
+		}
+		lineItems = append(lineItems, shippingLineItem)
 	}
-	lineItems = append(lineItems, shippingLineItem)
 
-	// Create Stripe Checkout Session
-	stripe.Key = s.config.Stripe.SecretKey
+	// The carbon offset is its own untaxed line. A box ticked while it was offered is
+	// ignored once it isn't.
+	var carbonOffsetCents int64
+	if offset, ok := settings.For(s.storage.Queries).Values(ctx).CarbonOffset(); ok && req.CarbonOffset {
+		carbonOffsetCents = offset.Cents
+		productData := &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+			Name:    stripe.String("Carbon offset"),
+			TaxCode: stripe.String(stripeutil.NontaxableTaxCode),
+		}
+		if offset.Text != "" {
+			productData.Description = stripe.String(offset.Text)
+		}
+		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency:    stripe.String("usd"),
+				UnitAmount:  stripe.Int64(carbonOffsetCents),
+				ProductData: productData,
+			},
+			Quantity: stripe.Int64(1),
+		})
+	}
+
+	// Payment methods, store credit and the gift card depend on the order total, before tax
+	var amountCents int64
+	for _, item := range lineItems {
+		amountCents += stripe.Int64Value(item.PriceData.UnitAmount) * stripe.Int64Value(item.Quantity)
+	}
 
+	// Store credit comes off before tax, and a gift card pays the rest, tax included
+	payment, err := s.checkoutPayments(ctx, checkoutPaymentInput{
+		UserID:             owner.UserID,
+		GiftCardCode:       req.GiftCardCode,
+		PromoCode:          promoCode,
+		DigitalOnly:        digitalOnly,
+		AmountCents:        amountCents,
+		GiftCardLinesCents: giftCardLinesCents,
+	}, func(discountCents int64) (*stripe.TaxCalculation, error) {
+		return calculateCheckoutTax(lineItems, discountCents, shippingSelection)
+	})
+	if err != nil {
+		return err
+	}
+	giftCardCents, storeCreditCents := payment.GiftCardCents, payment.StoreCreditCents
+
+	// The embedded checkout takes payment on our own page when its flag is on. Codes
+	// Stripe applies itself, downloads (taxed by the billing address Stripe's page asks
+	// for) and checkouts too small to charge a card stay on hosted Checkout.
+	couponCents := promoCode.DiscountCents + giftCardCents + storeCreditCents
+	elementCheckout := flags.Enabled(ctx, flags.PaymentElementCheckout) && s.config.Stripe.PublishableKey != "" &&
+		!digitalOnly && promoCode.StripePromotionCodeID == "" && amountCents+payment.TaxCents()-couponCents >= stripeutil.MinimumChargeCents
+
+	// Create Stripe Checkout Session
 	params := &stripe.CheckoutSessionParams{
 		Mode:             stripe.String(string(stripe.CheckoutSessionModePayment)),
 		LineItems:        lineItems,
 		SuccessURL:       stripe.String(fmt.Sprintf("%s://%s/checkout/success?session_id={CHECKOUT_SESSION_ID}", c.Scheme(), c.Request().Host)),
-		CancelURL:        stripe.String(fmt.Sprintf("%s://%s/cart", c.Scheme(), c.Request().Host)),
+		CancelURL:        stripe.String(fmt.Sprintf("%s://%s/checkout/cancel", c.Scheme(), c.Request().Host)),
 		CustomerCreation: stripe.String("always"),
 
 		// Enable automatic tax calculation
 		AutomaticTax: &stripe.CheckoutSessionAutomaticTaxParams{
 			Enabled: stripe.Bool(true),
 		},
+	}
+	// Traces the Stripe call as part of this request
+	params.Context = ctx
 
+	// Codes made in the Stripe Dashboard are applied by Stripe; ours, any gift card and
+	// store credit go on as a coupon for exactly the amount worked out above. With a
+	// gift card the tax is already a line of its own, so the coupon can't lower it.
+	switch {
+	case elementCheckout:
+		// The draft takes the discount and gift card off itself, without a coupon
+	case promoCode.StripePromotionCodeID != "":
+		params.Discounts = []*stripe.CheckoutSessionDiscountParams{{PromotionCode: stripe.String(promoCode.StripePromotionCodeID)}}
+	case promoCode.DiscountCents > 0 || giftCardCents > 0 || storeCreditCents > 0:
+		couponParts := []string{}
+		if promoCode.Code != "" {
+			couponParts = append(couponParts, promoCode.Code)
+		}
+		if giftCardCents > 0 {
+			couponParts = append(couponParts, "gift card")
+		}
+		if storeCreditCents > 0 {
+			couponParts = append(couponParts, "store credit")
+		}
+		couponName := strings.Join(couponParts, " + ")
+		if promoCode.Code == "" && couponName != "" {
+			couponName = strings.ToUpper(couponName[:1]) + couponName[1:]
+		}
+		coupon, couponErr := stripeutil.CreateCheckoutCoupon(couponName, couponCents)
+		if couponErr != nil {
+			slog.ErrorContext(ctx, "failed to create promo code coupon", "error", couponErr, "code", promoCode.Code)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create checkout session")
+		}
+		params.Discounts = []*stripe.CheckoutSessionDiscountParams{{Coupon: stripe.String(coupon.ID)}}
+	}
+
+	if digitalOnly {
+		// No shipping address for downloads; tax is calculated from the billing address
+		params.BillingAddressCollection = stripe.String(string(stripe.CheckoutSessionBillingAddressCollectionRequired))
+	} else {
 		// Collect shipping address for tax calculation
-		ShippingAddressCollection: &stripe.CheckoutSessionShippingAddressCollectionParams{
+		params.ShippingAddressCollection = &stripe.CheckoutSessionShippingAddressCollectionParams{
 			AllowedCountries: []*string{stripe.String("US")},
-		},
-
-		// Enable promotion code input in Stripe checkout
-		AllowPromotionCodes: stripe.Bool(true),
+		}
 	}
 
 	// Store shipment_id and user_id in metadata for label creation and order linking after payment
-	// SECURITY: user.ID is validated above - this ensures the order is linked to the correct user
+	// SECURITY: owner.UserID is validated above - this ensures the order is linked to the correct user.
+	// A guest's order is left without a user and keyed to the session instead.
 	params.Metadata = map[string]string{
 		"session_id":  sessionID,
 		"shipment_id": shippingSelection.ShipmentID,
 		"rate_id":     shippingSelection.RateID,
-		"user_id":     user.ID,
+		"user_id":     owner.UserID,
+	}
+	if digitalOnly {
+		params.Metadata["digital_only"] = "true"
+	}
+	if freeShipping {
+		params.Metadata["free_shipping"] = "true"
+	}
+	if promos.Applied != nil {
+		params.Metadata["cart_promotion"] = promos.Applied.ID
+	}
+	if orderNotes != "" {
+		params.Metadata["order_notes"] = orderNotes
+	}
+	if promoCode.PromotionCodeID != "" {
+		params.Metadata["promotion_code"] = promoCode.Code
+		params.Metadata["promotion_code_id"] = promoCode.PromotionCodeID
+	}
+	if giftCardCents > 0 {
+		params.Metadata["gift_card_id"] = payment.GiftCard.ID
+		params.Metadata["gift_card_cents"] = strconv.FormatInt(giftCardCents, 10)
+	}
+	if storeCreditCents > 0 {
+		params.Metadata["store_credit_cents"] = strconv.FormatInt(storeCreditCents, 10)
+	}
+	if carbonOffsetCents > 0 {
+		params.Metadata["carbon_offset_cents"] = strconv.FormatInt(carbonOffsetCents, 10)
+	}
+	gift.AddToMetadata(params.Metadata)
+	sms.AddConsentToMetadata(params.Metadata, smsPhone)
+	policies.AddAcceptanceToMetadata(params.Metadata, policyDocs)
+	if payment.Tax != nil && !elementCheckout {
+		stripeutil.AddPrecalculatedTax(params, payment.Tax)
 	}
 
 	// Expand line_items and product metadata for webhook processing
 	params.AddExpand("line_items")
 	params.AddExpand("line_items.data.price.product")
 
-	session, err := checkoutsession.New(params)
+	// Payment methods beyond cards are chosen in /admin/settings
+	site := settings.For(s.storage.Queries).Values(ctx)
+	params.PaymentMethodTypes = stripeutil.CheckoutPaymentMethodTypes(site.PaymentMethods(), amountCents, !digitalOnly)
+	if site.ChargesLocalCurrency() {
+		stripeutil.EnableAdaptivePricing(params)
+	}
+
+	// In-stock units are held until the session closes, so nobody else can pay for
+	// them meanwhile. The session closes when the hold lapses rather than after
+	// Stripe's default of a day.
+	holds, err := s.holdCheckout(ctx, sessionID, owner.UserID, stockLines, payment, s.config.Cart.HoldTTL)
+	if err != nil {
+		return err
+	}
+	if holds.holdsStock() {
+		params.ExpiresAt = stripe.Int64(time.Now().Add(s.config.Cart.HoldTTL).Unix())
+	}
+
+	var draft *checkoutDraftInput
+	if elementCheckout {
+		draft = &checkoutDraftInput{
+			Owner:         owner,
+			Email:         customerEmail,
+			Shipping:      shippingSelection,
+			DiscountCents: promoCode.DiscountCents + storeCreditCents,
+			GiftCardCents: giftCardCents,
+			Tax:           payment.Tax,
+		}
+	}
+	checkoutURL, err := openCheckout(ctx, holds, func() (string, string, error) {
+		return s.createCheckout(ctx, params, draft)
+	})
 	if err != nil {
-		slog.Error("failed to create stripe checkout session", "error", err)
+		slog.ErrorContext(ctx, "failed to create stripe checkout session", "error", err, "payment_element", elementCheckout)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create checkout session")
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"url": session.URL})
+	return c.JSON(http.StatusOK, map[string]string{"url": checkoutURL})
 }
 
-func (s *Service) handleEvents(c echo.Context) error {
-	meta := layout.NewPageMeta(c, s.storage.Queries)
-	meta.Title = "Events & Workshops | Logan's 3D Creations"
-	meta.Description = "Join us for 3D printing workshops, events, and educational programs. Learn hands-on 3D printing skills."
-	meta.Keywords = []string{"3D printing events", "workshops", "educational programs", "maker events"}
-	meta.OGType = "website"
-	return Render(c, events.Index(c, meta))
+// newCheckoutSession creates a checkout session. A payment method Stripe won't take
+// for the order, or one not yet turned on in the Dashboard, fails the whole session,
+// so it's retried with cards only.
+func newCheckoutSession(params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error) {
+	session, err := checkoutsession.New(params)
+	if err != nil && len(params.PaymentMethodTypes) > 1 {
+		slog.Warn("checkout session refused payment methods, retrying with cards only", "error", err)
+		params.PaymentMethodTypes = stripeutil.CardPaymentMethodTypes()
+		session, err = checkoutsession.New(params)
+	}
+	return session, err
 }
 
 func (s *Service) handleContact(c echo.Context) error {
@@ -2302,15 +3247,6 @@ func (s *Service) handleContactSubmit(c echo.Context) error {
 	return c.HTML(http.StatusOK, `<div class="mb-4 p-4 bg-emerald-500/20 border border-emerald-500/50 rounded-xl text-emerald-300 text-sm">Thank you! We've received your request and will get back to you soon.</div>`)
 }
 
-func (s *Service) handlePortfolio(c echo.Context) error {
-	meta := layout.NewPageMeta(c, s.storage.Queries)
-	meta.Title = "Portfolio | Logan's 3D Creations"
-	meta.Description = "Explore our portfolio of 3D printing projects, custom designs, and creative works."
-	meta.Keywords = []string{"3D printing portfolio", "project gallery", "custom designs"}
-	meta.OGType = "website"
-	return Render(c, portfolio.Index(c, meta))
-}
-
 func (s *Service) handleInnovation(c echo.Context) error {
 	meta := layout.NewPageMeta(c, s.storage.Queries)
 	meta.Title = "Innovation | Logan's 3D Creations"
@@ -2338,31 +3274,72 @@ func (s *Service) handlePrivacy(c echo.Context) error {
 	return Render(c, legal.Privacy(c, meta))
 }
 
-func (s *Service) handleTerms(c echo.Context) error {
-	meta := layout.NewPageMeta(c, s.storage.Queries)
-	meta.Title = "Terms of Service | Logan's 3D Creations"
-	meta.Description = "Review our terms of service for using Logan's 3D Creations website and services."
-	meta.Keywords = []string{"terms of service", "terms and conditions", "user agreement"}
-	meta.OGType = "website"
-	return Render(c, legal.Terms(c, meta))
-}
+// handleLegalDocument serves the page of a versioned policy: the version in effect,
+// or with ?version= any version, including one scheduled but not yet in effect
+func (s *Service) handleLegalDocument(kind, description string, keywords []string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		now := time.Now()
+		library := policies.NewLibrary(s.storage.Queries)
+
+		var doc db.LegalDocument
+		var err error
+		if raw := c.QueryParam("version"); raw != "" {
+			version, parseErr := strconv.ParseInt(raw, 10, 64)
+			if parseErr != nil {
+				return echo.NewHTTPError(http.StatusNotFound, "Version not found")
+			}
+			doc, err = s.storage.Queries.GetLegalDocumentVersion(ctx, db.GetLegalDocumentVersionParams{
+				Kind:    kind,
+				Version: version,
+			})
+		} else {
+			doc, err = library.CurrentOf(ctx, kind, now)
+		}
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return echo.NewHTTPError(http.StatusNotFound, "Page not found")
+			}
+			slog.ErrorContext(ctx, "failed to fetch legal document", "error", err, "kind", kind)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load page")
+		}
 
-func (s *Service) handleShipping(c echo.Context) error {
-	meta := layout.NewPageMeta(c, s.storage.Queries)
-	meta.Title = "Shipping Policy | Logan's 3D Creations"
-	meta.Description = "Learn about our shipping policies, delivery times, and shipping costs."
-	meta.Keywords = []string{"shipping policy", "delivery", "shipping costs"}
-	meta.OGType = "website"
-	return Render(c, legal.Shipping(c, meta))
-}
+		html, err := utils.RenderMarkdown(doc.BodyMarkdown)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to render legal document", "error", err, "document_id", doc.ID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load page")
+		}
 
-func (s *Service) handleCustomPolicy(c echo.Context) error {
-	meta := layout.NewPageMeta(c, s.storage.Queries)
-	meta.Title = "Custom Order Policy | Logan's 3D Creations"
-	meta.Description = "Understand our custom order policy, lead times, and requirements for custom 3D printing projects."
-	meta.Keywords = []string{"custom order policy", "custom printing terms", "order requirements"}
-	meta.OGType = "website"
-	return Render(c, legal.CustomPolicy(c, meta))
+		view := legal.DocumentView{
+			Document:  doc,
+			HTML:      html,
+			Scheduled: doc.EffectiveAt.After(now),
+		}
+		if current, err := library.CurrentOf(ctx, kind, now); err == nil {
+			view.Current = current.ID == doc.ID
+		}
+		if view.Current {
+			upcoming, err := s.storage.Queries.GetScheduledLegalDocument(ctx, db.GetScheduledLegalDocumentParams{
+				Kind: kind,
+				At:   now.UTC(),
+			})
+			if err == nil {
+				view.Upcoming = &upcoming
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				slog.WarnContext(ctx, "failed to check for a scheduled legal document", "error", err, "kind", kind)
+			}
+		}
+
+		meta := layout.NewPageMeta(c, s.storage.Queries)
+		meta.Title = doc.Title + " | Logan's 3D Creations"
+		meta.Description = description
+		meta.Keywords = keywords
+		meta.OGType = "website"
+		if !view.Current {
+			meta.CanonicalURL = layout.CanonicalURL(c, policies.Path(kind))
+		}
+		return Render(c, legal.Document(c, meta, view))
+	}
 }
 
 func (s *Service) handleDataDeletion(c echo.Context) error {
@@ -2374,11 +3351,15 @@ func (s *Service) handleDataDeletion(c echo.Context) error {
 	return Render(c, legal.DataDeletion(c, meta))
 }
 
+// handleHealth answers liveness checks. With ?deep=1 it probes the database and
+// every external provider, and returns 503 when the database is unreachable.
 func (s *Service) handleHealth(c echo.Context) error {
+	if c.QueryParam("deep") != "" {
+		return s.handleDeepHealth(c)
+	}
 	return c.JSON(http.StatusOK, map[string]any{
 		"status":      "healthy",
 		"environment": s.config.Environment,
-		"database":    "connected",
 	})
 }
 
@@ -2390,6 +3371,8 @@ func (s *Service) handleAddToCart(c echo.Context) error {
 		ProductID    string `json:"productId"`
 		ProductSkuID string `json:"productSkuId"`
 		Quantity     int64  `json:"quantity"`
+		// Personalization values keyed by field ID
+		Personalization map[string]string `json:"personalization"`
 	}
 
 	if err := c.Bind(&req); err != nil {
@@ -2420,34 +3403,66 @@ func (s *Service) handleAddToCart(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Product not found")
 	}
+	if err := s.checkDrop(c, product); err != nil {
+		return err
+	}
 
 	hasVariants := product.HasVariants.Valid && product.HasVariants.Bool
 
 	// When product has variants, require a specific SKU
+	var sku *db.ProductSku
 	if hasVariants {
 		if req.ProductSkuID == "" {
 			return echo.NewHTTPError(http.StatusBadRequest, "Please select a variant before adding to cart")
 		}
-		sku, skuErr := s.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
+		skuRecord, skuErr := s.storage.Queries.GetProductSkuForProduct(ctx, db.GetProductSkuForProductParams{
 			ID:        req.ProductSkuID,
 			ProductID: req.ProductID,
 		})
 		if skuErr != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Invalid variant selection")
 		}
-		if sku.IsActive.Valid && !sku.IsActive.Bool {
+		if skuRecord.IsActive.Valid && !skuRecord.IsActive.Bool {
 			return echo.NewHTTPError(http.StatusBadRequest, "This variant is unavailable")
 		}
+		sku = &skuRecord
 	}
 
-	// Check if item already exists in cart
+	personalizationFields, err := s.storage.Queries.ListProductPersonalizationFields(ctx, req.ProductID)
+	if err != nil {
+		slog.Error("failed to load personalization fields", "error", err, "product_id", req.ProductID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add item to cart")
+	}
+	personalizationValues, err := utils.ValidatePersonalization(personalizationFields, req.Personalization)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	personalization := utils.EncodePersonalization(personalizationValues)
+
+	// Check if item already exists in cart; differently personalized lines stay separate
 	existingItem, err := s.storage.Queries.GetExistingCartItem(ctx, db.GetExistingCartItemParams{
-		SessionID:    sql.NullString{String: sessionID, Valid: !isAuthenticated},
-		UserID:       sql.NullString{String: userID, Valid: isAuthenticated},
-		ProductID:    req.ProductID,
-		ProductSkuID: sql.NullString{String: req.ProductSkuID, Valid: req.ProductSkuID != ""},
+		SessionID:       sql.NullString{String: sessionID, Valid: !isAuthenticated},
+		UserID:          sql.NullString{String: userID, Valid: isAuthenticated},
+		ProductID:       req.ProductID,
+		ProductSkuID:    sql.NullString{String: req.ProductSkuID, Valid: req.ProductSkuID != ""},
+		Personalization: sql.NullString{String: personalization, Valid: personalization != ""},
 	})
 
+	// Enforce the product's backorder settings against the resulting cart quantity
+	requestedQuantity := req.Quantity
+	if err == nil {
+		requestedQuantity += existingItem.Quantity
+	}
+	if backorderErr := s.checkBackorder(ctx, product, sku, requestedQuantity); backorderErr != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, backorderErr.Error())
+	}
+	if limitErr := s.checkPurchaseLimit(c, product, sessionID, req.Quantity); limitErr != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+	}
+	if limitErr := s.checkMaxPerOrder(c, product, cartOwner{SessionID: sessionID, UserID: userID}, req.Quantity); limitErr != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+	}
+
 	if err == nil {
 		// Item exists, update quantity
 		newQuantity := existingItem.Quantity + req.Quantity
@@ -2463,12 +3478,14 @@ func (s *Service) handleAddToCart(c echo.Context) error {
 		// Item doesn't exist, add new item
 		itemID := uuid.New().String()
 		err = s.storage.Queries.AddToCart(ctx, db.AddToCartParams{
-			ID:           itemID,
-			SessionID:    sql.NullString{String: sessionID, Valid: !isAuthenticated},
-			UserID:       sql.NullString{String: userID, Valid: isAuthenticated},
-			ProductID:    req.ProductID,
-			ProductSkuID: sql.NullString{String: req.ProductSkuID, Valid: req.ProductSkuID != ""},
-			Quantity:     req.Quantity,
+			ID:               itemID,
+			SessionID:        sql.NullString{String: sessionID, Valid: !isAuthenticated},
+			UserID:           sql.NullString{String: userID, Valid: isAuthenticated},
+			ProductID:        req.ProductID,
+			ProductSkuID:     sql.NullString{String: req.ProductSkuID, Valid: req.ProductSkuID != ""},
+			Quantity:         req.Quantity,
+			Personalization:  sql.NullString{String: personalization, Valid: personalization != ""},
+			QuotedPriceCents: sql.NullInt64{Int64: itemPriceCents(product, sku), Valid: true},
 		})
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add item to cart")
@@ -2501,7 +3518,7 @@ func (s *Service) handleRemoveFromCart(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
-	err = s.storage.Queries.RemoveCartItem(ctx, itemID)
+	err = s.removeCartItem(ctx, itemID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove item from cart")
 	}
@@ -2518,6 +3535,24 @@ func (s *Service) handleRemoveFromCart(c echo.Context) error {
 	})
 }
 
+// removeCartItem deletes a cart line, or the whole bundle when the line belongs to one
+func (s *Service) removeCartItem(ctx context.Context, itemID string) error {
+	item, err := s.storage.Queries.GetCartItem(ctx, itemID)
+	if err == nil && item.BundleGroupID.Valid {
+		if err := s.storage.Queries.RemoveCartBundleGroup(ctx, item.BundleGroupID); err != nil {
+			slog.Error("failed to remove bundle from cart", "error", err, "bundle_group_id", item.BundleGroupID.String)
+			return err
+		}
+		return nil
+	}
+
+	if err := s.storage.Queries.RemoveCartItem(ctx, itemID); err != nil {
+		slog.Error("failed to remove cart item", "error", err, "item_id", itemID)
+		return err
+	}
+	return nil
+}
+
 // handleUpdateCartItem updates the quantity of an item in the cart
 func (s *Service) handleUpdateCartItem(c echo.Context) error {
 	itemID := c.Param("id")
@@ -2547,11 +3582,45 @@ func (s *Service) handleUpdateCartItem(c echo.Context) error {
 
 	if req.Quantity == 0 {
 		// Remove item if quantity is 0
-		err := s.storage.Queries.RemoveCartItem(ctx, itemID)
+		err := s.removeCartItem(ctx, itemID)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to remove item from cart")
 		}
 	} else {
+		cartItem, itemErr := s.storage.Queries.GetCartItem(ctx, itemID)
+
+		// Bundle lines are priced as a set, so they can only be removed together
+		if itemErr == nil && cartItem.BundleGroupID.Valid {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bundle items can't be changed individually. Remove the bundle to change it.")
+		}
+
+		// Enforce backorder settings before raising the quantity
+		if itemErr == nil && req.Quantity > cartItem.Quantity {
+			product, productErr := s.storage.Queries.GetProduct(ctx, cartItem.ProductID)
+			if productErr != nil {
+				return echo.NewHTTPError(http.StatusNotFound, "Product not found")
+			}
+			var sku *db.ProductSku
+			if cartItem.ProductSkuID.Valid && cartItem.ProductSkuID.String != "" {
+				if skuRecord, skuErr := s.storage.Queries.GetProductSku(ctx, cartItem.ProductSkuID.String); skuErr == nil {
+					sku = &skuRecord
+				}
+			}
+			if backorderErr := s.checkBackorder(ctx, product, sku, req.Quantity); backorderErr != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, backorderErr.Error())
+			}
+			if limitErr := s.checkPurchaseLimit(c, product, sessionID, req.Quantity-cartItem.Quantity); limitErr != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+			}
+			owner := cartOwner{SessionID: sessionID}
+			if user, ok := auth.GetDBUser(c); ok {
+				owner.UserID = user.ID
+			}
+			if limitErr := s.checkMaxPerOrder(c, product, owner, req.Quantity-cartItem.Quantity); limitErr != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, limitErr.Error())
+			}
+		}
+
 		// Update quantity
 		err := s.storage.Queries.UpdateCartItemQuantity(ctx, db.UpdateCartItemQuantityParams{
 			ID:       itemID,
@@ -2576,9 +3645,32 @@ func (s *Service) handleUpdateCartItem(c echo.Context) error {
 
 // handleGetCart returns the current cart contents
 func (s *Service) handleGetCart(c echo.Context) error {
+	cart, err := s.loadCart(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+// handleCartFragment renders the header's slide-out cart
+func (s *Service) handleCartFragment(c echo.Context) error {
+	cart, err := s.loadCart(c)
+	if err != nil {
+		return err
+	}
+
+	// The drawer reloads after every change, so a cached copy would show stale lines
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return Render(c, shop.MiniCart(cart))
+}
+
+// loadCart builds the shopper's cart with quantity-break prices, cart promotions and
+// free-shipping progress applied. Errors are already HTTP errors.
+func (s *Service) loadCart(c echo.Context) (api.Cart, error) {
 	sessionID, err := s.getOrCreateSessionID(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create session")
+		return api.Cart{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to create session")
 	}
 
 	// Check if user is authenticated
@@ -2591,43 +3683,68 @@ func (s *Service) handleGetCart(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	// Get cart items
-	var items interface{}
+	var rows []db.GetCartByUserRow
 	if isAuthenticated {
-		items, err = s.storage.Queries.GetCartByUser(ctx, sql.NullString{String: userID, Valid: true})
+		rows, err = s.storage.Queries.GetCartByUser(ctx, sql.NullString{String: userID, Valid: true})
 	} else {
-		items, err = s.storage.Queries.GetCartBySession(ctx, sql.NullString{String: sessionID, Valid: true})
+		var sessionRows []db.GetCartBySessionRow
+		sessionRows, err = s.storage.Queries.GetCartBySession(ctx, sql.NullString{String: sessionID, Valid: true})
+		for _, row := range sessionRows {
+			rows = append(rows, db.GetCartByUserRow(row))
+		}
 	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get cart items")
+		slog.Error("failed to get cart items", "error", err)
+		return api.Cart{}, echo.NewHTTPError(http.StatusInternalServerError, "Failed to get cart items")
+	}
+
+	items := make([]api.CartItem, 0, len(rows))
+	volumeLines := make([]volumeLine, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, api.NewCartItem(row))
+		volumeLines = append(volumeLines, volumeLine{
+			ItemID:          row.ID,
+			ProductID:       row.ProductID,
+			SkuID:           row.ProductSkuID.String,
+			Quantity:        row.Quantity,
+			RegularCents:    row.PriceCents,
+			AdjustmentCents: row.PriceAdjustmentCents,
+			Bundle:          row.BundleID != "",
+		})
 	}
 
-	// Get cart total
-	total, err := s.storage.Queries.GetCartTotal(ctx, db.GetCartTotalParams{
-		SessionID: sql.NullString{String: sessionID, Valid: !isAuthenticated},
-		UserID:    sql.NullString{String: userID, Valid: isAuthenticated},
-	})
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get cart total")
+	// Items keep their regular price_cents; volumePricing carries the quantity-break
+	// price so the cart can show both, and the totals use whichever applies
+	linePrices := s.cartVolumePrices(ctx, volumeLines)
+	volumePrices := make(map[string]api.VolumePrice)
+	for itemID, vp := range linePrices {
+		volumePrices[itemID] = api.VolumePrice(vp)
 	}
 
-	// Convert total to int64 (it comes as sql.NullFloat64)
-	var totalCents int64
-	if total.Valid {
-		totalCents = int64(total.Float64)
-	}
+	cart := api.NewCart(items, volumePrices)
 
-	// Format response with shipping config for frontend
-	response := map[string]interface{}{
-		"items":       items,
-		"totalCents":  totalCents,
-		"totalDollar": float64(totalCents) / 100,
-		"shippingConfig": map[string]string{
-			"inStockMessage":    utils.ShippingTimeInStock,
-			"outOfStockMessage": utils.ShippingTimeOutOfStock,
-		},
+	// Promotion discounts are shown separately and taken off at checkout; free
+	// shipping is measured against the total before them
+	promos := s.cartPromotions(ctx, cartPromotionLines(rows, linePrices))
+	cart.DiscountCents = promos.DiscountCents
+	cart.Promotions = apiPromotions(promos.Progress)
+	if !cart.DigitalOnly {
+		cart.FreeShipping = api.NewFreeShipping(cart.TotalCents, promos.FreeShippingThreshold(s.freeShippingThreshold()))
+	}
+	// Shown on the cart page, which keeps checkout shut until they're resolved
+	if problems := s.checkoutProblems(c, rows, cart.TotalCents); len(problems) > 0 {
+		cart.Problems = problems
 	}
+	return cart, nil
+}
 
-	return c.JSON(http.StatusOK, response)
+// freeShippingThreshold is the subtotal at which shipping is free, or zero when free
+// shipping is off or the shipping service isn't running
+func (s *Service) freeShippingThreshold() int64 {
+	if s.shippingService == nil {
+		return 0
+	}
+	return s.shippingService.FreeShippingThresholdCents()
 }
 
 // handleValidateCartSession checks if the current cart session should be cleared
@@ -2757,8 +3874,10 @@ func (s *Service) adminBadgeMiddleware() echo.MiddlewareFunc {
 
 			// Store badge counts in context for the sidebar template
 			c.Set(layout.AdminBadgeCountsKey, layout.AdminBadgeCounts{
-				NewContacts:   counts.NewContacts,
-				PendingQuotes: counts.PendingQuotes,
+				NewContacts:      counts.NewContacts,
+				PendingQuotes:    counts.PendingQuotes,
+				PendingQuestions: counts.PendingQuestions,
+				RequestedReturns: counts.RequestedReturns,
 			})
 
 			return next(c)
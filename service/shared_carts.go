@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/api"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+const (
+	maxSharedCartName     = 100
+	defaultSharedCartName = "Shared cart"
+)
+
+// newShareToken returns an unguessable token for a shared cart link
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *Service) sharedCartURL(token string) string {
+	return strings.TrimRight(s.config.BaseURL, "/") + "/cart/shared/" + token
+}
+
+// handleShareCart copies the current cart into a named shared cart and returns its
+// link. The copy doesn't follow later changes to the cart.
+func (s *Service) handleShareCart(c echo.Context) error {
+	var req struct {
+		Name string `json:"name" form:"name"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultSharedCartName
+	}
+	if utf8.RuneCountInString(name) > maxSharedCartName {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Name must be %d characters or fewer", maxSharedCartName))
+	}
+
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	lines, err := s.storage.Queries.ListCartLinesToShare(ctx, db.ListCartLinesToShareParams{
+		UserID:    owner.userParam(),
+		SessionID: owner.sessionParam(),
+	})
+	if err != nil {
+		slog.Error("failed to list cart lines to share", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to share cart")
+	}
+
+	// Bundle lines are priced as a set, so only standalone lines are shared
+	var shareable []db.CartItem
+	skippedBundleItems := 0
+	for _, line := range lines {
+		if line.BundleGroupID.Valid {
+			skippedBundleItems++
+			continue
+		}
+		shareable = append(shareable, line)
+	}
+	if len(shareable) == 0 {
+		if skippedBundleItems > 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bundles can't be shared. Add items individually to share them.")
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "Your cart is empty")
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		slog.Error("failed to generate shared cart token", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to share cart")
+	}
+	if err := s.createSharedCart(ctx, owner, token, name, shareable); err != nil {
+		slog.Error("failed to create shared cart", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to share cart")
+	}
+
+	slog.Info("cart shared", "lines", len(shareable), "skipped_bundle_items", skippedBundleItems, "user_id", owner.UserID)
+	return c.JSON(http.StatusOK, api.SharedCartLink{
+		Version:            api.Version,
+		Token:              token,
+		Name:               name,
+		URL:                s.sharedCartURL(token),
+		ItemCount:          len(shareable),
+		SkippedBundleItems: skippedBundleItems,
+	})
+}
+
+func (s *Service) createSharedCart(ctx context.Context, owner cartOwner, token, name string, lines []db.CartItem) error {
+	tx, err := s.storage.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin shared cart: %w", err)
+	}
+	defer tx.Rollback()
+	queries := s.storage.Queries.WithTx(tx)
+
+	sharedCartID := uuid.New().String()
+	if err := queries.CreateSharedCart(ctx, db.CreateSharedCartParams{
+		ID:        sharedCartID,
+		Token:     token,
+		Name:      name,
+		UserID:    owner.userParam(),
+		SessionID: owner.sessionParam(),
+	}); err != nil {
+		return fmt.Errorf("failed to create shared cart: %w", err)
+	}
+	for i, line := range lines {
+		if err := queries.CreateSharedCartItem(ctx, db.CreateSharedCartItemParams{
+			ID:              uuid.New().String(),
+			SharedCartID:    sharedCartID,
+			ProductID:       line.ProductID,
+			ProductSkuID:    line.ProductSkuID,
+			Quantity:        line.Quantity,
+			Personalization: line.Personalization,
+			Position:        int64(i),
+		}); err != nil {
+			return fmt.Errorf("failed to add shared cart item: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// handleSharedCart shows a shared cart with each line's current price and stock
+func (s *Service) handleSharedCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	sharedCart, err := s.storage.Queries.GetSharedCartByToken(ctx, c.Param("token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Shared cart not found")
+	}
+	if err != nil {
+		slog.Error("failed to load shared cart", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load shared cart")
+	}
+
+	rows, err := s.storage.Queries.ListSharedCartItems(ctx, sharedCart.ID)
+	if err != nil {
+		slog.Error("failed to list shared cart items", "error", err, "shared_cart_id", sharedCart.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load shared cart")
+	}
+
+	view := shop.SharedCart{Token: sharedCart.Token, Name: sharedCart.Name}
+	for _, row := range rows {
+		view.Items = append(view.Items, sharedCartItemView(row))
+	}
+
+	meta := layout.NewPageMeta(c, s.storage.Queries)
+	meta.Title = sharedCart.Name + " - Logan's 3D Creations"
+	meta.Description = "A cart shared from Logan's 3D Creations. Add everything to your own cart in one click."
+
+	// Links are private to whoever they're sent to
+	c.Response().Header().Set("X-Robots-Tag", "noindex")
+	return Render(c, shop.SharedCartPage(c, meta, view))
+}
+
+func sharedCartItemView(row db.ListSharedCartItemsRow) shop.SharedCartItem {
+	status, message := utils.SavedItemAvailability(utils.SavedItemStock{
+		Active:            row.IsActive != 0,
+		Stock:             row.StockQuantity,
+		LowStockThreshold: row.LowStockThreshold,
+		Quantity:          row.Quantity,
+		AllowBackorder:    row.AllowBackorder,
+		Preorder:          row.IsPreorder,
+		Digital:           row.ProductType == utils.ProductTypeDigital,
+	})
+
+	imageURL := row.ImageUrl
+	if imageURL != "" && !strings.HasPrefix(imageURL, "/") {
+		imageURL = utils.ProductImagePathPrefix + imageURL
+	}
+
+	return shop.SharedCartItem{
+		Name:                row.Name,
+		Slug:                row.Slug,
+		VariantName:         row.VariantName,
+		ImageURL:            imageURL,
+		PriceCents:          row.PriceCents,
+		Quantity:            row.Quantity,
+		Personalization:     utils.DecodePersonalization(row.Personalization),
+		Availability:        status,
+		AvailabilityMessage: message,
+	}
+}
+
+// handleLoadSharedCart adds a shared cart's lines to the visitor's own cart. Each
+// line goes through the usual cart checks; lines that fail are skipped and reported
+// rather than failing the whole load.
+func (s *Service) handleLoadSharedCart(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	sharedCart, err := s.storage.Queries.GetSharedCartByToken(ctx, c.Param("token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Shared cart not found")
+	}
+	if err != nil {
+		slog.Error("failed to load shared cart", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add shared cart")
+	}
+
+	rows, err := s.storage.Queries.ListSharedCartItems(ctx, sharedCart.ID)
+	if err != nil {
+		slog.Error("failed to list shared cart items", "error", err, "shared_cart_id", sharedCart.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add shared cart")
+	}
+
+	added, reduced := 0, 0
+	var skipped []api.SharedCartSkip
+	for _, row := range rows {
+		line := cartLine{
+			ProductID:       row.ProductID,
+			ProductSkuID:    row.ProductSkuID,
+			Quantity:        row.Quantity,
+			Personalization: sql.NullString{String: row.Personalization, Valid: row.Personalization != ""},
+		}
+		placement, err := s.placeInCart(c, owner, line)
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Code != http.StatusInternalServerError {
+			skipped = append(skipped, api.SharedCartSkip{Name: row.Name, Reason: fmt.Sprint(httpErr.Message)})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := placement.apply(ctx, s.storage.Queries, owner); err != nil {
+			slog.Error("failed to add shared cart line", "error", err, "shared_cart_id", sharedCart.ID, "product_id", row.ProductID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to add shared cart")
+		}
+		added++
+		if placement.Line.Quantity < row.Quantity {
+			reduced++
+		}
+	}
+
+	if added > 0 {
+		if err := s.storage.Queries.IncrementSharedCartLoads(ctx, sharedCart.ID); err != nil {
+			slog.Error("failed to count shared cart load", "error", err, "shared_cart_id", sharedCart.ID)
+		}
+		s.invalidateShipping(c, owner.SessionID)
+	}
+
+	return c.JSON(http.StatusOK, api.NewSharedCartLoad(added, reduced, skipped))
+}
+
+// handleListSharedCarts returns the carts this shopper has shared
+func (s *Service) handleListSharedCarts(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.storage.Queries.ListSharedCartsForOwner(c.Request().Context(), db.ListSharedCartsForOwnerParams{
+		UserID:    owner.userParam(),
+		SessionID: sql.NullString{String: owner.SessionID, Valid: true},
+	})
+	if err != nil {
+		slog.Error("failed to list shared carts", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get shared carts")
+	}
+
+	carts := make([]api.SharedCartSummary, 0, len(rows))
+	for _, row := range rows {
+		summary := api.SharedCartSummary{
+			ID:        row.ID,
+			Name:      row.Name,
+			URL:       s.sharedCartURL(row.Token),
+			ItemCount: row.ItemCount,
+			LoadCount: row.LoadCount,
+		}
+		if row.CreatedAt.Valid {
+			createdAt := row.CreatedAt.Time
+			summary.CreatedAt = &createdAt
+		}
+		carts = append(carts, summary)
+	}
+	return c.JSON(http.StatusOK, api.NewSharedCarts(carts))
+}
+
+// handleDeleteSharedCart stops a shared cart's link from working
+func (s *Service) handleDeleteSharedCart(c echo.Context) error {
+	owner, err := s.cartOwner(c)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.storage.Queries.DeleteSharedCartForOwner(c.Request().Context(), db.DeleteSharedCartForOwnerParams{
+		ID:        c.Param("id"),
+		UserID:    owner.userParam(),
+		SessionID: sql.NullString{String: owner.SessionID, Valid: true},
+	})
+	if err != nil {
+		slog.Error("failed to delete shared cart", "error", err, "shared_cart_id", c.Param("id"))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete shared cart")
+	}
+	if deleted == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Shared cart not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Shared cart deleted",
+	})
+}
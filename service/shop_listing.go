@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/internal/types"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/layout"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// parseShopFilters reads the shop filter state from the request query string
+func parseShopFilters(c echo.Context) shop.ShopFilters {
+	filters := shop.ShopFilters{
+		MinPriceCents: parseDollarsToCents(c.QueryParam("min_price")),
+		MaxPriceCents: parseDollarsToCents(c.QueryParam("max_price")),
+		InStock:       isTruthyParam(c.QueryParam("in_stock")),
+		New:           isTruthyParam(c.QueryParam("new")),
+		Featured:      isTruthyParam(c.QueryParam("featured")),
+		Premium:       isTruthyParam(c.QueryParam("premium")),
+		Sort:          shop.SortRecommended,
+		Page:          1,
+	}
+
+	// Specification filter, e.g. ?attr=Material&attr_value=PLA%2B; both halves are needed
+	if name, value := strings.TrimSpace(c.QueryParam("attr")), strings.TrimSpace(c.QueryParam("attr_value")); name != "" && value != "" {
+		filters.AttributeName = name
+		filters.AttributeValue = value
+	}
+
+	switch sort := c.QueryParam("sort"); sort {
+	case shop.SortNewest, shop.SortPriceAsc, shop.SortPriceDesc, shop.SortName:
+		filters.Sort = sort
+	}
+
+	if page, err := strconv.Atoi(c.QueryParam("page")); err == nil && page > 1 {
+		filters.Page = page
+	}
+
+	// Only the offered sizes are accepted so a crafted URL can't ask for the whole catalog
+	if perPage, err := strconv.Atoi(c.QueryParam("per_page")); err == nil && perPage != shop.DefaultPageSize {
+		for _, size := range shop.PageSizeOptions {
+			if perPage == size {
+				filters.PerPage = perPage
+			}
+		}
+	}
+
+	// A swapped range is almost always a typo; honour the intent
+	if filters.MinPriceCents > 0 && filters.MaxPriceCents > 0 && filters.MinPriceCents > filters.MaxPriceCents {
+		filters.MinPriceCents, filters.MaxPriceCents = filters.MaxPriceCents, filters.MinPriceCents
+	}
+
+	return filters
+}
+
+// loadShopListing fetches one page of filtered products along with facet counts.
+// categoryID may be empty to list across all categories.
+func (s *Service) loadShopListing(ctx context.Context, filters shop.ShopFilters, categoryID, basePath string) ([]shop.ProductWithImage, shop.ShopListing, error) {
+	listing := shop.ShopListing{
+		BasePath: basePath,
+		Filters:  filters,
+	}
+
+	category := sql.NullString{String: categoryID, Valid: categoryID != ""}
+	minPrice := sql.NullInt64{Int64: filters.MinPriceCents, Valid: filters.MinPriceCents > 0}
+	maxPrice := sql.NullInt64{Int64: filters.MaxPriceCents, Valid: filters.MaxPriceCents > 0}
+	isNew := sql.NullBool{Bool: true, Valid: filters.New}
+	isFeatured := sql.NullBool{Bool: true, Valid: filters.Featured}
+	isPremium := sql.NullBool{Bool: true, Valid: filters.Premium}
+	inStock := sql.NullBool{Bool: true, Valid: filters.InStock}
+	attributeName := sql.NullString{String: filters.AttributeName, Valid: filters.HasAttribute()}
+	attributeValue := sql.NullString{String: filters.AttributeValue, Valid: filters.HasAttribute()}
+
+	total, err := s.storage.Queries.CountShopProducts(ctx, db.CountShopProductsParams{
+		CategoryID:     category,
+		MinPriceCents:  minPrice,
+		MaxPriceCents:  maxPrice,
+		IsNew:          isNew,
+		IsFeatured:     isFeatured,
+		IsPremium:      isPremium,
+		InStock:        inStock,
+		AttributeName:  attributeName,
+		AttributeValue: attributeValue,
+	})
+	if err != nil {
+		return nil, listing, fmt.Errorf("failed to count shop products: %w", err)
+	}
+	listing.Pagination = types.NewPagination(filters.Page, filters.PageSize(), total)
+	listing.Filters.Page = listing.Page
+
+	products, err := s.storage.Queries.ListShopProducts(ctx, db.ListShopProductsParams{
+		CategoryID:     category,
+		MinPriceCents:  minPrice,
+		MaxPriceCents:  maxPrice,
+		IsNew:          isNew,
+		IsFeatured:     isFeatured,
+		IsPremium:      isPremium,
+		InStock:        inStock,
+		AttributeName:  attributeName,
+		AttributeValue: attributeValue,
+		Sort:           listing.Filters.Sort,
+		PageSize:       int64(listing.PerPage),
+		PageOffset:     int64(listing.Offset()),
+	})
+	if err != nil {
+		return nil, listing, fmt.Errorf("failed to list shop products: %w", err)
+	}
+
+	facets, err := s.storage.Queries.GetShopFacetCounts(ctx, db.GetShopFacetCountsParams{
+		CategoryID:     category,
+		MinPriceCents:  minPrice,
+		MaxPriceCents:  maxPrice,
+		IsNew:          isNew,
+		IsFeatured:     isFeatured,
+		IsPremium:      isPremium,
+		InStock:        inStock,
+		AttributeName:  attributeName,
+		AttributeValue: attributeValue,
+	})
+	if err != nil {
+		return nil, listing, fmt.Errorf("failed to load shop facets: %w", err)
+	}
+	listing.Facets = shop.ShopFacets{
+		InStock:        facets.InStockCount,
+		New:            facets.NewCount,
+		Featured:       facets.FeaturedCount,
+		Premium:        facets.PremiumCount,
+		MinPriceCents:  facets.MinPriceCents,
+		MaxPriceCents:  facets.MaxPriceCents,
+		CategoryCounts: make(map[string]int64),
+	}
+
+	categoryCounts, err := s.storage.Queries.ListCategoriesWithProductCounts(ctx)
+	if err != nil {
+		return nil, listing, fmt.Errorf("failed to load category counts: %w", err)
+	}
+	for _, cc := range categoryCounts {
+		listing.Facets.CategoryCounts[cc.ID] = cc.ProductCount
+	}
+
+	attributeFacets, err := s.storage.Queries.ListShopAttributeFacets(ctx, db.ListShopAttributeFacetsParams{
+		CategoryID:    category,
+		MinPriceCents: minPrice,
+		MaxPriceCents: maxPrice,
+		IsNew:         isNew,
+		IsFeatured:    isFeatured,
+		IsPremium:     isPremium,
+		InStock:       inStock,
+	})
+	if err != nil {
+		return nil, listing, fmt.Errorf("failed to load attribute facets: %w", err)
+	}
+	listing.Facets.Attributes = groupAttributeFacets(attributeFacets)
+
+	return s.withProductImages(ctx, products), listing, nil
+}
+
+// categoryLineage returns the category's parents and the category itself, top level
+// first, for breadcrumbs. It falls back to just the category if the lookup fails.
+func (s *Service) categoryLineage(ctx context.Context, category db.Category) []db.Category {
+	lineage, err := s.storage.Queries.ListCategoryAncestors(ctx, category.ID)
+	if err != nil || len(lineage) == 0 {
+		if err != nil {
+			slog.Error("failed to load category lineage", "category_id", category.ID, "error", err)
+		}
+		return []db.Category{category}
+	}
+	return lineage
+}
+
+// categoryLanding builds the banner, content and featured row shown above a
+// category's grid. Featured products are only loaded for the first unfiltered page,
+// the only one that shows them.
+func (s *Service) categoryLanding(ctx context.Context, category db.Category, listing shop.ShopListing) *shop.CategoryLanding {
+	landing := &shop.CategoryLanding{
+		BannerURL: utils.CategoryBannerURL(category.BannerImage),
+		BannerAlt: category.BannerAltText,
+	}
+	if landing.BannerAlt == "" {
+		landing.BannerAlt = category.Name
+	}
+
+	if category.ContentMarkdown != "" {
+		html, err := utils.RenderMarkdown(category.ContentMarkdown)
+		if err != nil {
+			slog.Error("failed to render category content", "category_id", category.ID, "error", err)
+		} else {
+			landing.ContentHTML = html
+		}
+	}
+
+	if listing.Filters.Page <= 1 && !listing.Filters.IsFiltered() {
+		featured, err := s.storage.Queries.ListFeaturedProductsForCategory(ctx, db.ListFeaturedProductsForCategoryParams{
+			CategoryID: category.ID,
+			Limit:      utils.MaxFeaturedCategoryProducts,
+		})
+		if err != nil {
+			slog.Error("failed to load featured category products", "category_id", category.ID, "error", err)
+		} else {
+			landing.Featured = s.withProductImages(ctx, featured)
+		}
+	}
+	return landing
+}
+
+// groupAttributeFacets turns name/value rows, already sorted by name, into one facet per
+// specification name. Names with a single value don't narrow anything and are left out.
+func groupAttributeFacets(rows []db.ListShopAttributeFacetsRow) []shop.AttributeFacet {
+	var facets []shop.AttributeFacet
+	for _, row := range rows {
+		if len(facets) == 0 || facets[len(facets)-1].Name != row.Name {
+			facets = append(facets, shop.AttributeFacet{Name: row.Name})
+		}
+		last := &facets[len(facets)-1]
+		last.Values = append(last.Values, shop.AttributeFacetValue{Value: row.Value, Count: row.ProductCount})
+	}
+
+	filtered := facets[:0]
+	for _, facet := range facets {
+		if len(facet.Values) > 1 {
+			filtered = append(filtered, facet)
+		}
+	}
+	return filtered
+}
+
+// shopCanonicalURL builds the canonical URL for a listing page. Facet filters are
+// dropped so filtered variants don't compete with the base page, but paging at the
+// default page size is kept so every product stays reachable by crawlers.
+func shopCanonicalURL(siteURL string, listing shop.ShopListing) string {
+	if listing.Filters.IsFiltered() || listing.Filters.PerPage > 0 || listing.Filters.Page <= 1 {
+		return layout.BuildAbsoluteURL(siteURL, listing.BasePath)
+	}
+	return layout.BuildAbsoluteURL(siteURL, fmt.Sprintf("%s?page=%d", listing.BasePath, listing.Filters.Page))
+}
+
+// parseDollarsToCents converts a dollar string like "12.50" to cents, returning 0 when invalid
+func parseDollarsToCents(s string) int64 {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "$")
+	if s == "" {
+		return 0
+	}
+	dollars, err := strconv.ParseFloat(s, 64)
+	if err != nil || dollars <= 0 || math.IsInf(dollars, 0) || math.IsNaN(dollars) {
+		return 0
+	}
+	return int64(math.Round(dollars * 100))
+}
+
+func isTruthyParam(v string) bool {
+	switch strings.ToLower(v) {
+	case "1", "true", "on", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/internal/types"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+func TestParseShopFilters(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name  string
+		query string
+		want  shop.ShopFilters
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  shop.ShopFilters{Sort: shop.SortRecommended, Page: 1},
+		},
+		{
+			name:  "all facets",
+			query: "min_price=10&max_price=25.50&in_stock=1&new=on&featured=true&premium=1&sort=price_desc&page=3",
+			want: shop.ShopFilters{
+				MinPriceCents: 1000,
+				MaxPriceCents: 2550,
+				InStock:       true,
+				New:           true,
+				Featured:      true,
+				Premium:       true,
+				Sort:          shop.SortPriceDesc,
+				Page:          3,
+			},
+		},
+		{
+			name:  "newest first",
+			query: "sort=newest",
+			want:  shop.ShopFilters{Sort: shop.SortNewest, Page: 1},
+		},
+		{
+			name:  "swapped price range",
+			query: "min_price=50&max_price=5",
+			want:  shop.ShopFilters{MinPriceCents: 500, MaxPriceCents: 5000, Sort: shop.SortRecommended, Page: 1},
+		},
+		{
+			name:  "specification filter",
+			query: "attr=Material&attr_value=PLA%2B",
+			want:  shop.ShopFilters{AttributeName: "Material", AttributeValue: "PLA+", Sort: shop.SortRecommended, Page: 1},
+		},
+		{
+			name:  "specification filter needs a value",
+			query: "attr=Material",
+			want:  shop.ShopFilters{Sort: shop.SortRecommended, Page: 1},
+		},
+		{
+			name:  "page size",
+			query: "per_page=48",
+			want:  shop.ShopFilters{Sort: shop.SortRecommended, Page: 1, PerPage: 48},
+		},
+		{
+			name:  "unoffered page size",
+			query: "per_page=5000",
+			want:  shop.ShopFilters{Sort: shop.SortRecommended, Page: 1},
+		},
+		{
+			name:  "invalid values fall back to defaults",
+			query: "min_price=abc&max_price=-4&sort=random&page=-2",
+			want:  shop.ShopFilters{Sort: shop.SortRecommended, Page: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/shop?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			assert.Equal(t, tt.want, parseShopFilters(c))
+		})
+	}
+}
+
+func TestShopFiltersURL(t *testing.T) {
+	filters := shop.ShopFilters{
+		MinPriceCents: 1250,
+		InStock:       true,
+		Sort:          shop.SortPriceAsc,
+		Page:          2,
+	}
+
+	assert.Equal(t, "/shop?in_stock=1&min_price=12.50&page=2&sort=price_asc", filters.URL("/shop"))
+	assert.Equal(t, "/shop?in_stock=1&min_price=12.50&page=4&sort=price_asc", filters.PageURL("/shop", 4))
+	assert.Equal(t, "/shop", shop.ShopFilters{Sort: shop.SortRecommended, Page: 1}.URL("/shop"))
+	assert.Equal(t, "/shop?page=3&per_page=96", shop.ShopFilters{Page: 1, PerPage: 96}.PageURL("/shop", 3))
+	assert.Equal(t, "/shop?attr=Material&attr_value=PLA&in_stock=1&min_price=12.50&sort=price_asc", filters.AttributeURL("/shop", "Material", "PLA"))
+}
+
+func TestGroupAttributeFacets(t *testing.T) {
+	rows := []db.ListShopAttributeFacetsRow{
+		{Name: "Finish", Value: "Matte", ProductCount: 4},
+		{Name: "Material", Value: "PETG", ProductCount: 2},
+		{Name: "Material", Value: "PLA", ProductCount: 9},
+	}
+
+	facets := groupAttributeFacets(rows)
+	if assert.Len(t, facets, 1) {
+		assert.Equal(t, "Material", facets[0].Name)
+		assert.Equal(t, []shop.AttributeFacetValue{{Value: "PETG", Count: 2}, {Value: "PLA", Count: 9}}, facets[0].Values)
+	}
+}
+
+func TestShopListingRangeLabel(t *testing.T) {
+	assert.Equal(t, "No products", shop.ShopListing{Pagination: types.NewPagination(1, shop.DefaultPageSize, 0)}.RangeLabel())
+	assert.Equal(t, "Showing 25–30 of 30", shop.ShopListing{Pagination: types.NewPagination(2, shop.DefaultPageSize, 30)}.RangeLabel())
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/auth"
 	"github.com/loganlanou/logans3d-v4/internal/email"
 	"github.com/loganlanou/logans3d-v4/internal/handlers"
 	"github.com/loganlanou/logans3d-v4/storage"
@@ -37,6 +38,7 @@ func setupTestService(t *testing.T) *Service {
 		authHandler:     handlers.NewAuthHandler(),
 		shippingService: nil, // Not needed for route testing
 		shippingHandler: nil, // Not needed for route testing
+		dropThrottle:    auth.NewRateLimiter(),
 		config: &Config{
 			Environment: "test",
 			Port:        "8080",
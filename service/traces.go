@@ -0,0 +1,31 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/loganlanou/logans3d-v4/views/admin"
+)
+
+// devTracesShown is how many of the slowest buffered traces /dev/traces lists
+const devTracesShown = 50
+
+// handleDevTraces lists the slowest traces still in the buffer, each expandable to
+// its spans. ?trace= finds one trace by ID, such as from a captured error or a log line.
+func (s *Service) handleDevTraces(c echo.Context) error {
+	data := admin.DevTracesData{
+		Traces:      s.tracer.Slowest(devTracesShown),
+		Buffered:    len(s.tracer.Recent()),
+		Total:       s.tracer.Total(),
+		Remote:      s.tracer.Remote(),
+		SampleRatio: s.tracer.SampleRatio(),
+		TraceID:     strings.ToLower(strings.TrimSpace(c.QueryParam("trace"))),
+	}
+	if data.TraceID != "" {
+		if trace, ok := s.tracer.Find(data.TraceID); ok {
+			data.Match = &trace
+		}
+	}
+	return Render(c, admin.DevTraces(c, data))
+}
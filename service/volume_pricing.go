@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/views/shop"
+)
+
+// volumeLine is the part of a cart line that quantity-break pricing looks at
+type volumeLine struct {
+	ItemID          string
+	ProductID       string
+	SkuID           string
+	Quantity        int64
+	RegularCents    int64 // base price plus the SKU's adjustment
+	AdjustmentCents int64
+	Bundle          bool
+}
+
+// volumePrice is the discounted unit price for a cart line and the tier that set it
+type volumePrice struct {
+	UnitPriceCents    int64 `json:"unitPriceCents"`
+	RegularPriceCents int64 `json:"regularPriceCents"`
+	MinQuantity       int64 `json:"minQuantity"`
+}
+
+// bestTier returns the tier with the highest minimum the quantity reaches
+func bestTier(tiers []db.ProductPriceTier, quantity int64) (db.ProductPriceTier, bool) {
+	var best db.ProductPriceTier
+	found := false
+	for _, tier := range tiers {
+		if quantity >= tier.MinQuantity && (!found || tier.MinQuantity > best.MinQuantity) {
+			best = tier
+			found = true
+		}
+	}
+	return best, found
+}
+
+// applyVolumePricing works out quantity-break prices for cart lines, keyed by cart item ID.
+// A SKU with its own tiers uses them against that line's quantity, and the tier price is
+// the full unit price. Otherwise product-wide tiers count every unit of the product in the
+// cart and replace the base price, with the SKU's adjustment still added. Bundle lines are
+// already discounted and neither count nor get tier prices. A tier never raises a price.
+func applyVolumePricing(lines []volumeLine, tiers []db.ProductPriceTier) map[string]volumePrice {
+	prices := make(map[string]volumePrice)
+	if len(tiers) == 0 {
+		return prices
+	}
+
+	productTiers := make(map[string][]db.ProductPriceTier)
+	skuTiers := make(map[string][]db.ProductPriceTier)
+	for _, tier := range tiers {
+		if tier.ProductSkuID.Valid && tier.ProductSkuID.String != "" {
+			skuTiers[tier.ProductSkuID.String] = append(skuTiers[tier.ProductSkuID.String], tier)
+		} else {
+			productTiers[tier.ProductID] = append(productTiers[tier.ProductID], tier)
+		}
+	}
+
+	productQty := make(map[string]int64)
+	for _, line := range lines {
+		if !line.Bundle {
+			productQty[line.ProductID] += line.Quantity
+		}
+	}
+
+	for _, line := range lines {
+		if line.Bundle {
+			continue
+		}
+
+		var unitPrice int64
+		var tier db.ProductPriceTier
+		var ok bool
+		if own := skuTiers[line.SkuID]; line.SkuID != "" && len(own) > 0 {
+			tier, ok = bestTier(own, line.Quantity)
+			unitPrice = tier.UnitPriceCents
+		} else {
+			tier, ok = bestTier(productTiers[line.ProductID], productQty[line.ProductID])
+			unitPrice = tier.UnitPriceCents + line.AdjustmentCents
+		}
+		if !ok || unitPrice >= line.RegularCents {
+			continue
+		}
+
+		prices[line.ItemID] = volumePrice{
+			UnitPriceCents:    unitPrice,
+			RegularPriceCents: line.RegularCents,
+			MinQuantity:       tier.MinQuantity,
+		}
+	}
+	return prices
+}
+
+// cartVolumePrices loads the price tiers for the products in the cart and applies them.
+// Pricing falls back to regular prices if the tiers can't be loaded.
+func (s *Service) cartVolumePrices(ctx context.Context, lines []volumeLine) map[string]volumePrice {
+	seen := make(map[string]bool)
+	var productIDs []string
+	for _, line := range lines {
+		if !line.Bundle && !seen[line.ProductID] {
+			seen[line.ProductID] = true
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	if len(productIDs) == 0 {
+		return map[string]volumePrice{}
+	}
+
+	tiers, err := s.storage.Queries.ListPriceTiersForProducts(ctx, productIDs)
+	if err != nil {
+		slog.Error("failed to load price tiers for cart", "error", err)
+		return map[string]volumePrice{}
+	}
+	return applyVolumePricing(lines, tiers)
+}
+
+// volumePriceRows builds the product page table from the product-wide tiers, starting
+// with a row for the regular price below the first break
+func volumePriceRows(basePriceCents int64, tiers []db.ListProductPriceTiersRow) []shop.VolumePriceRow {
+	var productTiers []db.ListProductPriceTiersRow
+	for _, tier := range tiers {
+		if !tier.ProductSkuID.Valid || tier.ProductSkuID.String == "" {
+			productTiers = append(productTiers, tier)
+		}
+	}
+	if len(productTiers) == 0 {
+		return nil
+	}
+
+	rows := []shop.VolumePriceRow{{MinQuantity: 1, UnitPriceCents: basePriceCents}}
+	for _, tier := range productTiers {
+		rows[len(rows)-1].MaxQuantity = tier.MinQuantity - 1
+		rows = append(rows, shop.VolumePriceRow{MinQuantity: tier.MinQuantity, UnitPriceCents: tier.UnitPriceCents})
+	}
+	return rows
+}
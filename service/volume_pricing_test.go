@@ -0,0 +1,88 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+func productTier(productID string, minQuantity, priceCents int64) db.ProductPriceTier {
+	return db.ProductPriceTier{ProductID: productID, MinQuantity: minQuantity, UnitPriceCents: priceCents}
+}
+
+func skuTier(productID, skuID string, minQuantity, priceCents int64) db.ProductPriceTier {
+	tier := productTier(productID, minQuantity, priceCents)
+	tier.ProductSkuID = sql.NullString{String: skuID, Valid: true}
+	return tier
+}
+
+func TestApplyVolumePricing(t *testing.T) {
+	tiers := []db.ProductPriceTier{productTier("p1", 5, 900), productTier("p1", 10, 800)}
+
+	t.Run("below the first break pays the regular price", func(t *testing.T) {
+		lines := []volumeLine{{ItemID: "a", ProductID: "p1", Quantity: 4, RegularCents: 1000}}
+		assert.Empty(t, applyVolumePricing(lines, tiers))
+	})
+
+	t.Run("highest break reached applies", func(t *testing.T) {
+		lines := []volumeLine{{ItemID: "a", ProductID: "p1", Quantity: 12, RegularCents: 1000}}
+		prices := applyVolumePricing(lines, tiers)
+		assert.Equal(t, volumePrice{UnitPriceCents: 800, RegularPriceCents: 1000, MinQuantity: 10}, prices["a"])
+	})
+
+	t.Run("variants of a product count together and keep their upcharge", func(t *testing.T) {
+		lines := []volumeLine{
+			{ItemID: "a", ProductID: "p1", SkuID: "s1", Quantity: 3, RegularCents: 1000},
+			{ItemID: "b", ProductID: "p1", SkuID: "s2", Quantity: 2, RegularCents: 1200, AdjustmentCents: 200},
+		}
+		prices := applyVolumePricing(lines, tiers)
+		assert.Equal(t, int64(900), prices["a"].UnitPriceCents)
+		assert.Equal(t, int64(1100), prices["b"].UnitPriceCents)
+	})
+
+	t.Run("bundle lines neither count nor get a tier price", func(t *testing.T) {
+		lines := []volumeLine{
+			{ItemID: "a", ProductID: "p1", Quantity: 3, RegularCents: 1000},
+			{ItemID: "b", ProductID: "p1", Quantity: 2, RegularCents: 700, Bundle: true},
+		}
+		assert.Empty(t, applyVolumePricing(lines, tiers))
+	})
+
+	t.Run("a SKU's own breaks take precedence", func(t *testing.T) {
+		withSku := append([]db.ProductPriceTier{skuTier("p1", "s1", 3, 950)}, tiers...)
+		lines := []volumeLine{
+			{ItemID: "a", ProductID: "p1", SkuID: "s1", Quantity: 4, RegularCents: 1000},
+			{ItemID: "b", ProductID: "p1", SkuID: "s2", Quantity: 2, RegularCents: 1000},
+		}
+		prices := applyVolumePricing(lines, withSku)
+		assert.Equal(t, volumePrice{UnitPriceCents: 950, RegularPriceCents: 1000, MinQuantity: 3}, prices["a"])
+		assert.Equal(t, int64(900), prices["b"].UnitPriceCents)
+	})
+
+	t.Run("a break never raises the price", func(t *testing.T) {
+		lines := []volumeLine{{ItemID: "a", ProductID: "p1", Quantity: 5, RegularCents: 850}}
+		assert.Empty(t, applyVolumePricing(lines, tiers))
+	})
+}
+
+func TestVolumePriceRows(t *testing.T) {
+	tiers := []db.ListProductPriceTiersRow{
+		{ProductID: "p1", MinQuantity: 5, UnitPriceCents: 900},
+		{ProductID: "p1", MinQuantity: 10, UnitPriceCents: 800},
+		{ProductID: "p1", ProductSkuID: sql.NullString{String: "s1", Valid: true}, MinQuantity: 3, UnitPriceCents: 950},
+	}
+
+	rows := volumePriceRows(1000, tiers)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "1–4", rows[0].Label())
+		assert.Equal(t, int64(1000), rows[0].UnitPriceCents)
+		assert.Equal(t, "5–9", rows[1].Label())
+		assert.Equal(t, "10+", rows[2].Label())
+		assert.Equal(t, int64(800), rows[2].UnitPriceCents)
+	}
+
+	assert.Nil(t, volumePriceRows(1000, tiers[2:]))
+}
@@ -13,9 +13,9 @@ import (
 
 const createEvent = `-- name: CreateEvent :one
 INSERT INTO events (
-    id, title, description, location, address, start_date, end_date, url, is_active
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at
+    id, title, description, location, address, start_date, end_date, url, is_active, slug, capacity
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity
 `
 
 type CreateEventParams struct {
@@ -28,6 +28,8 @@ type CreateEventParams struct {
 	EndDate     sql.NullTime   `db:"end_date" json:"end_date"`
 	Url         sql.NullString `db:"url" json:"url"`
 	IsActive    sql.NullBool   `db:"is_active" json:"is_active"`
+	Slug        sql.NullString `db:"slug" json:"slug"`
+	Capacity    int64          `db:"capacity" json:"capacity"`
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
@@ -41,6 +43,8 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.EndDate,
 		arg.Url,
 		arg.IsActive,
+		arg.Slug,
+		arg.Capacity,
 	)
 	var i Event
 	err := row.Scan(
@@ -55,6 +59,8 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Slug,
+		&i.Capacity,
 	)
 	return i, err
 }
@@ -68,8 +74,33 @@ func (q *Queries) DeleteEvent(ctx context.Context, id string) error {
 	return err
 }
 
+const getActiveEventBySlug = `-- name: GetActiveEventBySlug :one
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events WHERE slug = ? AND is_active = TRUE
+`
+
+func (q *Queries) GetActiveEventBySlug(ctx context.Context, slug sql.NullString) (Event, error) {
+	row := q.db.QueryRowContext(ctx, getActiveEventBySlug, slug)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Location,
+		&i.Address,
+		&i.StartDate,
+		&i.EndDate,
+		&i.Url,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Slug,
+		&i.Capacity,
+	)
+	return i, err
+}
+
 const getEvent = `-- name: GetEvent :one
-SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at FROM events WHERE id = ?
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events WHERE id = ?
 `
 
 func (q *Queries) GetEvent(ctx context.Context, id string) (Event, error) {
@@ -87,6 +118,8 @@ func (q *Queries) GetEvent(ctx context.Context, id string) (Event, error) {
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Slug,
+		&i.Capacity,
 	)
 	return i, err
 }
@@ -120,7 +153,7 @@ func (q *Queries) GetEventStats(ctx context.Context) (GetEventStatsRow, error) {
 }
 
 const listActiveEvents = `-- name: ListActiveEvents :many
-SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at FROM events
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events
 WHERE is_active = TRUE
 ORDER BY start_date ASC
 `
@@ -146,6 +179,52 @@ func (q *Queries) ListActiveEvents(ctx context.Context) ([]Event, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Slug,
+			&i.Capacity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCurrentAndUpcomingEvents = `-- name: ListCurrentAndUpcomingEvents :many
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events
+WHERE is_active = TRUE AND COALESCE(end_date, start_date) >= DATE('now')
+ORDER BY start_date ASC
+`
+
+// Multi-day events stay listed until their last day
+func (q *Queries) ListCurrentAndUpcomingEvents(ctx context.Context) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrentAndUpcomingEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Location,
+			&i.Address,
+			&i.StartDate,
+			&i.EndDate,
+			&i.Url,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Slug,
+			&i.Capacity,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +240,7 @@ func (q *Queries) ListActiveEvents(ctx context.Context) ([]Event, error) {
 }
 
 const listEvents = `-- name: ListEvents :many
-SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at FROM events
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events
 ORDER BY start_date DESC
 `
 
@@ -186,6 +265,8 @@ func (q *Queries) ListEvents(ctx context.Context) ([]Event, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Slug,
+			&i.Capacity,
 		); err != nil {
 			return nil, err
 		}
@@ -201,7 +282,7 @@ func (q *Queries) ListEvents(ctx context.Context) ([]Event, error) {
 }
 
 const listPastEvents = `-- name: ListPastEvents :many
-SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at FROM events
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events
 WHERE is_active = TRUE AND start_date < DATE('now')
 ORDER BY start_date DESC
 `
@@ -227,6 +308,8 @@ func (q *Queries) ListPastEvents(ctx context.Context) ([]Event, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Slug,
+			&i.Capacity,
 		); err != nil {
 			return nil, err
 		}
@@ -242,7 +325,7 @@ func (q *Queries) ListPastEvents(ctx context.Context) ([]Event, error) {
 }
 
 const listUpcomingEvents = `-- name: ListUpcomingEvents :many
-SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at FROM events
+SELECT id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity FROM events
 WHERE is_active = TRUE AND start_date >= DATE('now')
 ORDER BY start_date ASC
 `
@@ -268,6 +351,8 @@ func (q *Queries) ListUpcomingEvents(ctx context.Context) ([]Event, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Slug,
+			&i.Capacity,
 		); err != nil {
 			return nil, err
 		}
@@ -285,10 +370,10 @@ func (q *Queries) ListUpcomingEvents(ctx context.Context) ([]Event, error) {
 const updateEvent = `-- name: UpdateEvent :one
 UPDATE events
 SET title = ?, description = ?, location = ?, address = ?,
-    start_date = ?, end_date = ?, url = ?, is_active = ?,
+    start_date = ?, end_date = ?, url = ?, is_active = ?, slug = ?, capacity = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at
+RETURNING id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity
 `
 
 type UpdateEventParams struct {
@@ -300,6 +385,8 @@ type UpdateEventParams struct {
 	EndDate     sql.NullTime   `db:"end_date" json:"end_date"`
 	Url         sql.NullString `db:"url" json:"url"`
 	IsActive    sql.NullBool   `db:"is_active" json:"is_active"`
+	Slug        sql.NullString `db:"slug" json:"slug"`
+	Capacity    int64          `db:"capacity" json:"capacity"`
 	ID          string         `db:"id" json:"id"`
 }
 
@@ -313,6 +400,8 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		arg.EndDate,
 		arg.Url,
 		arg.IsActive,
+		arg.Slug,
+		arg.Capacity,
 		arg.ID,
 	)
 	var i Event
@@ -328,6 +417,8 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Slug,
+		&i.Capacity,
 	)
 	return i, err
 }
@@ -336,7 +427,7 @@ const updateEventActiveStatus = `-- name: UpdateEventActiveStatus :one
 UPDATE events
 SET is_active = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at
+RETURNING id, title, description, location, address, start_date, end_date, url, is_active, created_at, updated_at, slug, capacity
 `
 
 type UpdateEventActiveStatusParams struct {
@@ -359,6 +450,8 @@ func (q *Queries) UpdateEventActiveStatus(ctx context.Context, arg UpdateEventAc
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Slug,
+		&i.Capacity,
 	)
 	return i, err
 }
@@ -10,6 +10,26 @@ import (
 	"database/sql"
 )
 
+const claimGuestOrdersByEmail = `-- name: ClaimGuestOrdersByEmail :execrows
+UPDATE orders
+SET user_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ''
+  AND customer_email = ? COLLATE NOCASE
+`
+
+type ClaimGuestOrdersByEmailParams struct {
+	UserID string `db:"user_id" json:"user_id"`
+	Email  string `db:"email" json:"email"`
+}
+
+func (q *Queries) ClaimGuestOrdersByEmail(ctx context.Context, arg ClaimGuestOrdersByEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimGuestOrdersByEmail, arg.UserID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createOrder = `-- name: CreateOrder :one
 INSERT INTO orders (
     id, user_id, customer_email, customer_name, customer_phone,
@@ -18,9 +38,11 @@ INSERT INTO orders (
     subtotal_cents, tax_cents, shipping_cents, total_cents,
     original_subtotal_cents, discount_cents, promotion_code, promotion_code_id,
     stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id,
-    easypost_shipment_id, status, notes
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id
+    easypost_shipment_id, status, notes, customer_notes,
+    is_gift, gift_message, gift_recipient_name, gift_recipient_email,
+    guest_session_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents
 `
 
 type CreateOrderParams struct {
@@ -49,6 +71,12 @@ type CreateOrderParams struct {
 	EasypostShipmentID      sql.NullString `db:"easypost_shipment_id" json:"easypost_shipment_id"`
 	Status                  sql.NullString `db:"status" json:"status"`
 	Notes                   sql.NullString `db:"notes" json:"notes"`
+	CustomerNotes           string         `db:"customer_notes" json:"customer_notes"`
+	IsGift                  bool           `db:"is_gift" json:"is_gift"`
+	GiftMessage             string         `db:"gift_message" json:"gift_message"`
+	GiftRecipientName       string         `db:"gift_recipient_name" json:"gift_recipient_name"`
+	GiftRecipientEmail      string         `db:"gift_recipient_email" json:"gift_recipient_email"`
+	GuestSessionID          sql.NullString `db:"guest_session_id" json:"guest_session_id"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
@@ -78,6 +106,12 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		arg.EasypostShipmentID,
 		arg.Status,
 		arg.Notes,
+		arg.CustomerNotes,
+		arg.IsGift,
+		arg.GiftMessage,
+		arg.GiftRecipientName,
+		arg.GiftRecipientEmail,
+		arg.GuestSessionID,
 	)
 	var i Order
 	err := row.Scan(
@@ -112,6 +146,23 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 	)
 	return i, err
 }
@@ -119,21 +170,26 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 const createOrderItem = `-- name: CreateOrderItem :one
 INSERT INTO order_items (
     id, order_id, product_id, product_sku_id, quantity, unit_price_cents,
-    total_price_cents, product_name, product_sku
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING id, order_id, product_id, product_variant_id, quantity, unit_price_cents, total_price_cents, product_name, product_sku, created_at, product_sku_id
+    total_price_cents, product_name, product_sku, backordered_quantity, estimated_ship_date,
+    is_preorder, personalization
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, order_id, product_id, product_variant_id, quantity, unit_price_cents, total_price_cents, product_name, product_sku, created_at, product_sku_id, backordered_quantity, estimated_ship_date, is_preorder, preorder_shipped_at, personalization
 `
 
 type CreateOrderItemParams struct {
-	ID              string         `db:"id" json:"id"`
-	OrderID         string         `db:"order_id" json:"order_id"`
-	ProductID       string         `db:"product_id" json:"product_id"`
-	ProductSkuID    sql.NullString `db:"product_sku_id" json:"product_sku_id"`
-	Quantity        int64          `db:"quantity" json:"quantity"`
-	UnitPriceCents  int64          `db:"unit_price_cents" json:"unit_price_cents"`
-	TotalPriceCents int64          `db:"total_price_cents" json:"total_price_cents"`
-	ProductName     string         `db:"product_name" json:"product_name"`
-	ProductSku      sql.NullString `db:"product_sku" json:"product_sku"`
+	ID                  string         `db:"id" json:"id"`
+	OrderID             string         `db:"order_id" json:"order_id"`
+	ProductID           string         `db:"product_id" json:"product_id"`
+	ProductSkuID        sql.NullString `db:"product_sku_id" json:"product_sku_id"`
+	Quantity            int64          `db:"quantity" json:"quantity"`
+	UnitPriceCents      int64          `db:"unit_price_cents" json:"unit_price_cents"`
+	TotalPriceCents     int64          `db:"total_price_cents" json:"total_price_cents"`
+	ProductName         string         `db:"product_name" json:"product_name"`
+	ProductSku          sql.NullString `db:"product_sku" json:"product_sku"`
+	BackorderedQuantity int64          `db:"backordered_quantity" json:"backordered_quantity"`
+	EstimatedShipDate   sql.NullString `db:"estimated_ship_date" json:"estimated_ship_date"`
+	IsPreorder          bool           `db:"is_preorder" json:"is_preorder"`
+	Personalization     sql.NullString `db:"personalization" json:"personalization"`
 }
 
 func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error) {
@@ -147,6 +203,10 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 		arg.TotalPriceCents,
 		arg.ProductName,
 		arg.ProductSku,
+		arg.BackorderedQuantity,
+		arg.EstimatedShipDate,
+		arg.IsPreorder,
+		arg.Personalization,
 	)
 	var i OrderItem
 	err := row.Scan(
@@ -161,6 +221,11 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 		&i.ProductSku,
 		&i.CreatedAt,
 		&i.ProductSkuID,
+		&i.BackorderedQuantity,
+		&i.EstimatedShipDate,
+		&i.IsPreorder,
+		&i.PreorderShippedAt,
+		&i.Personalization,
 	)
 	return i, err
 }
@@ -208,7 +273,7 @@ LEFT JOIN product_skus ps ON recent.product_sku_id = ps.id
 LEFT JOIN product_styles pst ON ps.product_style_id = pst.id
 LEFT JOIN product_style_images psi ON psi.product_style_id = pst.id AND psi.is_primary = TRUE
 LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
-WHERE p.is_active = TRUE
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
 ORDER BY recent.last_purchased_at DESC
 LIMIT 12
 `
@@ -262,7 +327,7 @@ func (q *Queries) GetBuyAgainItems(ctx context.Context, userID string) ([]GetBuy
 }
 
 const getOrder = `-- name: GetOrder :one
-SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id FROM orders WHERE id = ?
+SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents FROM orders WHERE id = ?
 `
 
 func (q *Queries) GetOrder(ctx context.Context, id string) (Order, error) {
@@ -300,12 +365,29 @@ func (q *Queries) GetOrder(ctx context.Context, id string) (Order, error) {
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 	)
 	return i, err
 }
 
 const getOrderByStripeSessionID = `-- name: GetOrderByStripeSessionID :one
-SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id FROM orders
+SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents FROM orders
 WHERE stripe_checkout_session_id = ?
 LIMIT 1
 `
@@ -345,13 +427,30 @@ func (q *Queries) GetOrderByStripeSessionID(ctx context.Context, stripeCheckoutS
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 	)
 	return i, err
 }
 
 const getOrderItems = `-- name: GetOrderItems :many
 SELECT
-    oi.id, oi.order_id, oi.product_id, oi.product_variant_id, oi.quantity, oi.unit_price_cents, oi.total_price_cents, oi.product_name, oi.product_sku, oi.created_at, oi.product_sku_id,
+    oi.id, oi.order_id, oi.product_id, oi.product_variant_id, oi.quantity, oi.unit_price_cents, oi.total_price_cents, oi.product_name, oi.product_sku, oi.created_at, oi.product_sku_id, oi.backordered_quantity, oi.estimated_ship_date, oi.is_preorder, oi.preorder_shipped_at, oi.personalization,
     COALESCE(c.name, '') as category_name
 FROM order_items oi
 LEFT JOIN products p ON oi.product_id = p.id
@@ -360,18 +459,23 @@ WHERE oi.order_id = ?
 `
 
 type GetOrderItemsRow struct {
-	ID               string         `db:"id" json:"id"`
-	OrderID          string         `db:"order_id" json:"order_id"`
-	ProductID        string         `db:"product_id" json:"product_id"`
-	ProductVariantID sql.NullString `db:"product_variant_id" json:"product_variant_id"`
-	Quantity         int64          `db:"quantity" json:"quantity"`
-	UnitPriceCents   int64          `db:"unit_price_cents" json:"unit_price_cents"`
-	TotalPriceCents  int64          `db:"total_price_cents" json:"total_price_cents"`
-	ProductName      string         `db:"product_name" json:"product_name"`
-	ProductSku       sql.NullString `db:"product_sku" json:"product_sku"`
-	CreatedAt        sql.NullTime   `db:"created_at" json:"created_at"`
-	ProductSkuID     sql.NullString `db:"product_sku_id" json:"product_sku_id"`
-	CategoryName     string         `db:"category_name" json:"category_name"`
+	ID                  string         `db:"id" json:"id"`
+	OrderID             string         `db:"order_id" json:"order_id"`
+	ProductID           string         `db:"product_id" json:"product_id"`
+	ProductVariantID    sql.NullString `db:"product_variant_id" json:"product_variant_id"`
+	Quantity            int64          `db:"quantity" json:"quantity"`
+	UnitPriceCents      int64          `db:"unit_price_cents" json:"unit_price_cents"`
+	TotalPriceCents     int64          `db:"total_price_cents" json:"total_price_cents"`
+	ProductName         string         `db:"product_name" json:"product_name"`
+	ProductSku          sql.NullString `db:"product_sku" json:"product_sku"`
+	CreatedAt           sql.NullTime   `db:"created_at" json:"created_at"`
+	ProductSkuID        sql.NullString `db:"product_sku_id" json:"product_sku_id"`
+	BackorderedQuantity int64          `db:"backordered_quantity" json:"backordered_quantity"`
+	EstimatedShipDate   sql.NullString `db:"estimated_ship_date" json:"estimated_ship_date"`
+	IsPreorder          bool           `db:"is_preorder" json:"is_preorder"`
+	PreorderShippedAt   sql.NullTime   `db:"preorder_shipped_at" json:"preorder_shipped_at"`
+	Personalization     sql.NullString `db:"personalization" json:"personalization"`
+	CategoryName        string         `db:"category_name" json:"category_name"`
 }
 
 func (q *Queries) GetOrderItems(ctx context.Context, orderID string) ([]GetOrderItemsRow, error) {
@@ -395,6 +499,11 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID string) ([]GetOrder
 			&i.ProductSku,
 			&i.CreatedAt,
 			&i.ProductSkuID,
+			&i.BackorderedQuantity,
+			&i.EstimatedShipDate,
+			&i.IsPreorder,
+			&i.PreorderShippedAt,
+			&i.Personalization,
 			&i.CategoryName,
 		); err != nil {
 			return nil, err
@@ -452,7 +561,7 @@ func (q *Queries) GetOrderStats(ctx context.Context) (GetOrderStatsRow, error) {
 
 const getOrderWithItems = `-- name: GetOrderWithItems :one
 SELECT
-    o.id, o.user_id, o.customer_name, o.customer_email, o.customer_phone, o.shipping_address_line1, o.shipping_address_line2, o.shipping_city, o.shipping_state, o.shipping_postal_code, o.shipping_country, o.subtotal_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.status, o.notes, o.stripe_payment_intent_id, o.stripe_customer_id, o.stripe_checkout_session_id, o.tracking_number, o.tracking_url, o.carrier, o.created_at, o.updated_at, o.easypost_shipment_id, o.easypost_label_url, o.original_subtotal_cents, o.discount_cents, o.promotion_code, o.promotion_code_id,
+    o.id, o.user_id, o.customer_name, o.customer_email, o.customer_phone, o.shipping_address_line1, o.shipping_address_line2, o.shipping_city, o.shipping_state, o.shipping_postal_code, o.shipping_country, o.subtotal_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.status, o.notes, o.stripe_payment_intent_id, o.stripe_customer_id, o.stripe_checkout_session_id, o.tracking_number, o.tracking_url, o.carrier, o.created_at, o.updated_at, o.easypost_shipment_id, o.easypost_label_url, o.original_subtotal_cents, o.discount_cents, o.promotion_code, o.promotion_code_id, o.fulfillment_location_id, o.customer_notes, o.is_gift, o.gift_message, o.gift_recipient_name, o.gift_recipient_email, o.gift_notified_at, o.label_image_url, o.guest_session_id, o.payment_currency, o.payment_fx_rate, o.sms_phone, o.sms_consent_at, o.gift_card_id, o.gift_card_cents, o.store_credit_cents, o.carbon_offset_cents,
     GROUP_CONCAT(
         oi.id || ',' || oi.product_id || ',' || oi.quantity || ',' ||
         oi.unit_price_cents || ',' || oi.total_price_cents || ',' ||
@@ -496,6 +605,23 @@ type GetOrderWithItemsRow struct {
 	DiscountCents           sql.NullInt64  `db:"discount_cents" json:"discount_cents"`
 	PromotionCode           sql.NullString `db:"promotion_code" json:"promotion_code"`
 	PromotionCodeID         sql.NullString `db:"promotion_code_id" json:"promotion_code_id"`
+	FulfillmentLocationID   sql.NullString `db:"fulfillment_location_id" json:"fulfillment_location_id"`
+	CustomerNotes           string         `db:"customer_notes" json:"customer_notes"`
+	IsGift                  bool           `db:"is_gift" json:"is_gift"`
+	GiftMessage             string         `db:"gift_message" json:"gift_message"`
+	GiftRecipientName       string         `db:"gift_recipient_name" json:"gift_recipient_name"`
+	GiftRecipientEmail      string         `db:"gift_recipient_email" json:"gift_recipient_email"`
+	GiftNotifiedAt          sql.NullTime   `db:"gift_notified_at" json:"gift_notified_at"`
+	LabelImageUrl           string         `db:"label_image_url" json:"label_image_url"`
+	GuestSessionID          sql.NullString `db:"guest_session_id" json:"guest_session_id"`
+	PaymentCurrency         string         `db:"payment_currency" json:"payment_currency"`
+	PaymentFxRate           float64        `db:"payment_fx_rate" json:"payment_fx_rate"`
+	SmsPhone                string         `db:"sms_phone" json:"sms_phone"`
+	SmsConsentAt            sql.NullTime   `db:"sms_consent_at" json:"sms_consent_at"`
+	GiftCardID              sql.NullString `db:"gift_card_id" json:"gift_card_id"`
+	GiftCardCents           int64          `db:"gift_card_cents" json:"gift_card_cents"`
+	StoreCreditCents        int64          `db:"store_credit_cents" json:"store_credit_cents"`
+	CarbonOffsetCents       int64          `db:"carbon_offset_cents" json:"carbon_offset_cents"`
 	OrderItems              string         `db:"order_items" json:"order_items"`
 }
 
@@ -534,13 +660,50 @@ func (q *Queries) GetOrderWithItems(ctx context.Context, id string) (GetOrderWit
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 		&i.OrderItems,
 	)
 	return i, err
 }
 
+const linkGuestOrder = `-- name: LinkGuestOrder :execrows
+UPDATE orders
+SET user_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND user_id = ''
+`
+
+type LinkGuestOrderParams struct {
+	UserID string `db:"user_id" json:"user_id"`
+	ID     string `db:"id" json:"id"`
+}
+
+func (q *Queries) LinkGuestOrder(ctx context.Context, arg LinkGuestOrderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, linkGuestOrder, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const listOrders = `-- name: ListOrders :many
-SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id FROM orders
+SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents FROM orders
 ORDER BY created_at DESC
 `
 
@@ -585,6 +748,23 @@ func (q *Queries) ListOrders(ctx context.Context) ([]Order, error) {
 			&i.DiscountCents,
 			&i.PromotionCode,
 			&i.PromotionCodeID,
+			&i.FulfillmentLocationID,
+			&i.CustomerNotes,
+			&i.IsGift,
+			&i.GiftMessage,
+			&i.GiftRecipientName,
+			&i.GiftRecipientEmail,
+			&i.GiftNotifiedAt,
+			&i.LabelImageUrl,
+			&i.GuestSessionID,
+			&i.PaymentCurrency,
+			&i.PaymentFxRate,
+			&i.SmsPhone,
+			&i.SmsConsentAt,
+			&i.GiftCardID,
+			&i.GiftCardCents,
+			&i.StoreCreditCents,
+			&i.CarbonOffsetCents,
 		); err != nil {
 			return nil, err
 		}
@@ -600,7 +780,7 @@ func (q *Queries) ListOrders(ctx context.Context) ([]Order, error) {
 }
 
 const listOrdersByStatus = `-- name: ListOrdersByStatus :many
-SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id FROM orders
+SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents FROM orders
 WHERE status = ?
 ORDER BY created_at DESC
 `
@@ -646,6 +826,23 @@ func (q *Queries) ListOrdersByStatus(ctx context.Context, status sql.NullString)
 			&i.DiscountCents,
 			&i.PromotionCode,
 			&i.PromotionCodeID,
+			&i.FulfillmentLocationID,
+			&i.CustomerNotes,
+			&i.IsGift,
+			&i.GiftMessage,
+			&i.GiftRecipientName,
+			&i.GiftRecipientEmail,
+			&i.GiftNotifiedAt,
+			&i.LabelImageUrl,
+			&i.GuestSessionID,
+			&i.PaymentCurrency,
+			&i.PaymentFxRate,
+			&i.SmsPhone,
+			&i.SmsConsentAt,
+			&i.GiftCardID,
+			&i.GiftCardCents,
+			&i.StoreCreditCents,
+			&i.CarbonOffsetCents,
 		); err != nil {
 			return nil, err
 		}
@@ -661,7 +858,7 @@ func (q *Queries) ListOrdersByStatus(ctx context.Context, status sql.NullString)
 }
 
 const listOrdersByUser = `-- name: ListOrdersByUser :many
-SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id FROM orders
+SELECT id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents FROM orders
 WHERE user_id = ?
 ORDER BY created_at DESC
 `
@@ -707,6 +904,23 @@ func (q *Queries) ListOrdersByUser(ctx context.Context, userID string) ([]Order,
 			&i.DiscountCents,
 			&i.PromotionCode,
 			&i.PromotionCodeID,
+			&i.FulfillmentLocationID,
+			&i.CustomerNotes,
+			&i.IsGift,
+			&i.GiftMessage,
+			&i.GiftRecipientName,
+			&i.GiftRecipientEmail,
+			&i.GiftNotifiedAt,
+			&i.LabelImageUrl,
+			&i.GuestSessionID,
+			&i.PaymentCurrency,
+			&i.PaymentFxRate,
+			&i.SmsPhone,
+			&i.SmsConsentAt,
+			&i.GiftCardID,
+			&i.GiftCardCents,
+			&i.StoreCreditCents,
+			&i.CarbonOffsetCents,
 		); err != nil {
 			return nil, err
 		}
@@ -721,18 +935,47 @@ func (q *Queries) ListOrdersByUser(ctx context.Context, userID string) ([]Order,
 	return items, nil
 }
 
+const markGiftRecipientNotified = `-- name: MarkGiftRecipientNotified :exec
+UPDATE orders
+SET gift_notified_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) MarkGiftRecipientNotified(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, markGiftRecipientNotified, id)
+	return err
+}
+
+const setOrderPaymentCurrency = `-- name: SetOrderPaymentCurrency :exec
+UPDATE orders
+SET payment_currency = ?,
+    payment_fx_rate = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetOrderPaymentCurrencyParams struct {
+	PaymentCurrency string  `db:"payment_currency" json:"payment_currency"`
+	PaymentFxRate   float64 `db:"payment_fx_rate" json:"payment_fx_rate"`
+	ID              string  `db:"id" json:"id"`
+}
+
+func (q *Queries) SetOrderPaymentCurrency(ctx context.Context, arg SetOrderPaymentCurrencyParams) error {
+	_, err := q.db.ExecContext(ctx, setOrderPaymentCurrency, arg.PaymentCurrency, arg.PaymentFxRate, arg.ID)
+	return err
+}
+
 const updateOrderLabel = `-- name: UpdateOrderLabel :one
 UPDATE orders
-SET easypost_label_url = ?, tracking_number = ?, carrier = ?, status = ?, updated_at = CURRENT_TIMESTAMP
+SET easypost_label_url = ?, tracking_number = ?, carrier = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id
+RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents
 `
 
 type UpdateOrderLabelParams struct {
 	EasypostLabelUrl sql.NullString `db:"easypost_label_url" json:"easypost_label_url"`
 	TrackingNumber   sql.NullString `db:"tracking_number" json:"tracking_number"`
 	Carrier          sql.NullString `db:"carrier" json:"carrier"`
-	Status           sql.NullString `db:"status" json:"status"`
 	ID               string         `db:"id" json:"id"`
 }
 
@@ -741,7 +984,6 @@ func (q *Queries) UpdateOrderLabel(ctx context.Context, arg UpdateOrderLabelPara
 		arg.EasypostLabelUrl,
 		arg.TrackingNumber,
 		arg.Carrier,
-		arg.Status,
 		arg.ID,
 	)
 	var i Order
@@ -777,6 +1019,23 @@ func (q *Queries) UpdateOrderLabel(ctx context.Context, arg UpdateOrderLabelPara
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 	)
 	return i, err
 }
@@ -785,7 +1044,7 @@ const updateOrderNotes = `-- name: UpdateOrderNotes :one
 UPDATE orders
 SET notes = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id
+RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents
 `
 
 type UpdateOrderNotesParams struct {
@@ -828,57 +1087,23 @@ func (q *Queries) UpdateOrderNotes(ctx context.Context, arg UpdateOrderNotesPara
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
-	)
-	return i, err
-}
-
-const updateOrderStatus = `-- name: UpdateOrderStatus :one
-UPDATE orders
-SET status = ?, updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
-RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id
-`
-
-type UpdateOrderStatusParams struct {
-	Status sql.NullString `db:"status" json:"status"`
-	ID     string         `db:"id" json:"id"`
-}
-
-func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
-	row := q.db.QueryRowContext(ctx, updateOrderStatus, arg.Status, arg.ID)
-	var i Order
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.CustomerName,
-		&i.CustomerEmail,
-		&i.CustomerPhone,
-		&i.ShippingAddressLine1,
-		&i.ShippingAddressLine2,
-		&i.ShippingCity,
-		&i.ShippingState,
-		&i.ShippingPostalCode,
-		&i.ShippingCountry,
-		&i.SubtotalCents,
-		&i.TaxCents,
-		&i.ShippingCents,
-		&i.TotalCents,
-		&i.Status,
-		&i.Notes,
-		&i.StripePaymentIntentID,
-		&i.StripeCustomerID,
-		&i.StripeCheckoutSessionID,
-		&i.TrackingNumber,
-		&i.TrackingUrl,
-		&i.Carrier,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.EasypostShipmentID,
-		&i.EasypostLabelUrl,
-		&i.OriginalSubtotalCents,
-		&i.DiscountCents,
-		&i.PromotionCode,
-		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 	)
 	return i, err
 }
@@ -887,7 +1112,7 @@ const updateOrderTracking = `-- name: UpdateOrderTracking :one
 UPDATE orders
 SET tracking_number = ?, tracking_url = ?, carrier = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id
+RETURNING id, user_id, customer_name, customer_email, customer_phone, shipping_address_line1, shipping_address_line2, shipping_city, shipping_state, shipping_postal_code, shipping_country, subtotal_cents, tax_cents, shipping_cents, total_cents, status, notes, stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id, tracking_number, tracking_url, carrier, created_at, updated_at, easypost_shipment_id, easypost_label_url, original_subtotal_cents, discount_cents, promotion_code, promotion_code_id, fulfillment_location_id, customer_notes, is_gift, gift_message, gift_recipient_name, gift_recipient_email, gift_notified_at, label_image_url, guest_session_id, payment_currency, payment_fx_rate, sms_phone, sms_consent_at, gift_card_id, gift_card_cents, store_credit_cents, carbon_offset_cents
 `
 
 type UpdateOrderTrackingParams struct {
@@ -937,6 +1162,23 @@ func (q *Queries) UpdateOrderTracking(ctx context.Context, arg UpdateOrderTracki
 		&i.DiscountCents,
 		&i.PromotionCode,
 		&i.PromotionCodeID,
+		&i.FulfillmentLocationID,
+		&i.CustomerNotes,
+		&i.IsGift,
+		&i.GiftMessage,
+		&i.GiftRecipientName,
+		&i.GiftRecipientEmail,
+		&i.GiftNotifiedAt,
+		&i.LabelImageUrl,
+		&i.GuestSessionID,
+		&i.PaymentCurrency,
+		&i.PaymentFxRate,
+		&i.SmsPhone,
+		&i.SmsConsentAt,
+		&i.GiftCardID,
+		&i.GiftCardCents,
+		&i.StoreCreditCents,
+		&i.CarbonOffsetCents,
 	)
 	return i, err
 }
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// ErrDatabaseAhead means the database has migrations applied that this binary doesn't
+// have, usually because a newer release ran against it. Its queries would be written
+// for a schema that's since changed, so the app won't start on it.
+var ErrDatabaseAhead = errors.New("database is ahead of this binary")
+
+// ErrPendingMigrations means the database is missing migrations and automatic
+// migration is off
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+// Migration is one embedded migration and whether the database has it
+type Migration struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// MigrationState compares the database's migrations with the binary's
+type MigrationState struct {
+	// Current is the highest version applied to the database, 0 for a new one
+	Current int64
+	// Latest is the highest version embedded in the binary
+	Latest     int64
+	Migrations []Migration
+	// Unknown are versions applied to the database that the binary doesn't have
+	Unknown []int64
+}
+
+// Pending counts the embedded migrations the database doesn't have
+func (s MigrationState) Pending() int {
+	pending := 0
+	for _, m := range s.Migrations {
+		if !m.Applied {
+			pending++
+		}
+	}
+	return pending
+}
+
+// Ahead reports whether the database was migrated past what the binary knows
+func (s MigrationState) Ahead() bool {
+	return s.Current > s.Latest
+}
+
+// Check refuses a database that's ahead of the binary, or behind it when pending
+// migrations aren't going to be applied
+func (s MigrationState) Check(autoMigrate bool) error {
+	if s.Ahead() {
+		return fmt.Errorf("%w: it's at migration %d but the newest this binary has is %d; deploy a newer release or restore a backup",
+			ErrDatabaseAhead, s.Current, s.Latest)
+	}
+	if !autoMigrate && s.Pending() > 0 {
+		return fmt.Errorf("%w: %d to apply, up to %d; run with --migrate-only or set AUTO_MIGRATE=true",
+			ErrPendingMigrations, s.Pending(), s.Latest)
+	}
+	return nil
+}
+
+// Migrations reads the embedded migrations and the ones the database has applied
+func Migrations(ctx context.Context, sqliteDB *sql.DB) (MigrationState, error) {
+	var state MigrationState
+
+	goose.SetBaseFS(embedMigrations)
+	embedded, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return state, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	applied, err := appliedMigrations(ctx, sqliteDB)
+	if err != nil {
+		return state, err
+	}
+
+	known := make(map[int64]bool, len(embedded))
+	for _, m := range embedded {
+		known[m.Version] = true
+		appliedAt, ok := applied[m.Version]
+		state.Migrations = append(state.Migrations, Migration{
+			Version:   m.Version,
+			Name:      strings.TrimSuffix(filepath.Base(m.Source), filepath.Ext(m.Source)),
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+		state.Latest = max(state.Latest, m.Version)
+	}
+	for version := range applied {
+		state.Current = max(state.Current, version)
+		if !known[version] {
+			state.Unknown = append(state.Unknown, version)
+		}
+	}
+	sort.Slice(state.Unknown, func(i, j int) bool { return state.Unknown[i] < state.Unknown[j] })
+	return state, nil
+}
+
+// appliedMigrations reads goose's version table, which a new database doesn't have
+// yet. goose deletes a version's row when it's migrated down.
+func appliedMigrations(ctx context.Context, sqliteDB *sql.DB) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+
+	var table string
+	err := sqliteDB.QueryRowContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", goose.TableName()).Scan(&table)
+	if errors.Is(err, sql.ErrNoRows) {
+		return applied, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look for the migrations table: %w", err)
+	}
+
+	rows, err := sqliteDB.QueryContext(ctx, "SELECT version_id, tstamp FROM "+table+" WHERE version_id > 0 AND is_applied")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read applied migration: %w", err)
+		}
+		applied[version] = appliedAt.Time
+	}
+	return applied, rows.Err()
+}
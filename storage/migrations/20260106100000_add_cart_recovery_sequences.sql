@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Configurable recovery email sequences. Each A/B variant has its own ordered
+-- list of steps; a cart receives every active step of its assigned variant.
+CREATE TABLE cart_recovery_sequence_steps (
+    id TEXT PRIMARY KEY,
+    variant TEXT NOT NULL DEFAULT 'A',
+    step_number INTEGER NOT NULL,
+    delay_minutes INTEGER NOT NULL, -- minutes after abandonment
+    template_key TEXT NOT NULL, -- email_1hr, email_24hr, email_72hr
+    email_subject TEXT NOT NULL,
+    include_promo BOOLEAN NOT NULL DEFAULT FALSE, -- include the cart's first-time buyer code
+    promo_discount_percent INTEGER NOT NULL DEFAULT 0, -- > 0 generates a fresh code for this step
+    promo_valid_days INTEGER NOT NULL DEFAULT 10,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (variant, step_number)
+);
+
+CREATE INDEX idx_cart_recovery_sequence_steps_variant ON cart_recovery_sequence_steps(variant);
+
+-- Variant A mirrors the original fixed 1h/24h/72h schedule
+INSERT INTO cart_recovery_sequence_steps (id, variant, step_number, delay_minutes, template_key, email_subject, include_promo, promo_discount_percent) VALUES
+    ('seq-a-1', 'A', 1, 65, 'email_1hr', 'You left something in your cart!', FALSE, 0),
+    ('seq-a-2', 'A', 2, 1445, 'email_24hr', 'Still interested in your cart?', TRUE, 0),
+    ('seq-a-3', 'A', 3, 4325, 'email_72hr', 'Last chance to complete your order!', TRUE, 0);
+
+-- Variant B skips the first-timer code and offers 10% off on the final reminder
+INSERT INTO cart_recovery_sequence_steps (id, variant, step_number, delay_minutes, template_key, email_subject, include_promo, promo_discount_percent) VALUES
+    ('seq-b-1', 'B', 1, 65, 'email_1hr', 'Your cart is waiting for you', FALSE, 0),
+    ('seq-b-2', 'B', 2, 1445, 'email_24hr', 'Your picks are still available', FALSE, 0),
+    ('seq-b-3', 'B', 3, 4325, 'email_72hr', 'Here''s 10% off to finish your order', FALSE, 10);
+
+-- A/B assignment and order attribution per abandoned cart
+ALTER TABLE abandoned_carts ADD COLUMN ab_variant TEXT NOT NULL DEFAULT 'A';
+ALTER TABLE abandoned_carts ADD COLUMN recovered_order_id TEXT;
+CREATE INDEX idx_abandoned_carts_ab_variant ON abandoned_carts(ab_variant);
+CREATE INDEX idx_abandoned_carts_recovered_order_id ON abandoned_carts(recovered_order_id);
+
+-- Link each attempt to the step that produced it
+ALTER TABLE cart_recovery_attempts ADD COLUMN sequence_step_id TEXT;
+ALTER TABLE cart_recovery_attempts ADD COLUMN variant TEXT;
+ALTER TABLE cart_recovery_attempts ADD COLUMN promotion_code_id TEXT;
+CREATE INDEX idx_cart_recovery_attempts_sequence_step_id ON cart_recovery_attempts(sequence_step_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_cart_recovery_attempts_sequence_step_id;
+ALTER TABLE cart_recovery_attempts DROP COLUMN promotion_code_id;
+ALTER TABLE cart_recovery_attempts DROP COLUMN variant;
+ALTER TABLE cart_recovery_attempts DROP COLUMN sequence_step_id;
+
+DROP INDEX IF EXISTS idx_abandoned_carts_recovered_order_id;
+DROP INDEX IF EXISTS idx_abandoned_carts_ab_variant;
+ALTER TABLE abandoned_carts DROP COLUMN recovered_order_id;
+ALTER TABLE abandoned_carts DROP COLUMN ab_variant;
+
+DROP INDEX IF EXISTS idx_cart_recovery_sequence_steps_variant;
+DROP TABLE IF EXISTS cart_recovery_sequence_steps;
+
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Shopper questions on product pages. Questions stay pending until an admin
+-- answers (publishes) or rejects them; only published Q&A is shown publicly.
+CREATE TABLE product_questions (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    asker_name TEXT NOT NULL,
+    asker_email TEXT NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT,
+    answered_by TEXT, -- admin user id
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'published', 'rejected')),
+    answered_at DATETIME,
+    notified_at DATETIME, -- when the asker was emailed the answer
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_product_questions_product_status ON product_questions(product_id, status);
+CREATE INDEX idx_product_questions_status ON product_questions(status);
+CREATE INDEX idx_product_questions_user_id ON product_questions(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_questions_user_id;
+DROP INDEX IF EXISTS idx_product_questions_status;
+DROP INDEX IF EXISTS idx_product_questions_product_status;
+DROP TABLE IF EXISTS product_questions;
+
+-- +goose StatementEnd
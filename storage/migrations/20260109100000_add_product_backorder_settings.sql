@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Per-product backorder settings. Existing products keep allowing zero-stock
+-- purchases; ship dates are stored as YYYY-MM-DD since they are date-only promises.
+ALTER TABLE products ADD COLUMN allow_backorder BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE products ADD COLUMN backorder_ship_date TEXT;
+ALTER TABLE products ADD COLUMN max_backorder_quantity INTEGER; -- NULL = unlimited
+
+-- Units of each order item that were not covered by stock at purchase time
+ALTER TABLE order_items ADD COLUMN backordered_quantity INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN estimated_ship_date TEXT;
+CREATE INDEX idx_order_items_backordered_quantity ON order_items(backordered_quantity);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_items_backordered_quantity;
+ALTER TABLE order_items DROP COLUMN estimated_ship_date;
+ALTER TABLE order_items DROP COLUMN backordered_quantity;
+
+ALTER TABLE products DROP COLUMN max_backorder_quantity;
+ALTER TABLE products DROP COLUMN backorder_ship_date;
+ALTER TABLE products DROP COLUMN allow_backorder;
+
+-- +goose StatementEnd
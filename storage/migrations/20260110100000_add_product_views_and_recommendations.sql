@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Product page views per shopper. Each viewer keeps one row per product
+-- (re-views refresh viewed_at) and old rows are pruned nightly.
+CREATE TABLE product_views (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    session_id TEXT,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    viewed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CHECK (session_id IS NOT NULL OR user_id IS NOT NULL)
+);
+
+CREATE INDEX idx_product_views_session_id ON product_views(session_id, viewed_at);
+CREATE INDEX idx_product_views_user_id ON product_views(user_id, viewed_at);
+CREATE INDEX idx_product_views_viewed_at ON product_views(viewed_at);
+
+-- "Frequently bought together" pairs, rebuilt nightly from order_items.
+-- score is the number of orders that contained both products.
+CREATE TABLE product_recommendations (
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    recommended_product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL,
+    computed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, recommended_product_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS product_recommendations;
+
+DROP INDEX IF EXISTS idx_product_views_viewed_at;
+DROP INDEX IF EXISTS idx_product_views_user_id;
+DROP INDEX IF EXISTS idx_product_views_session_id;
+DROP TABLE IF EXISTS product_views;
+
+-- +goose StatementEnd
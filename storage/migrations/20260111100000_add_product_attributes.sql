@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Free-form product specifications (material, dimensions, ...) used by the
+-- comparison tool. Names are unique per product.
+CREATE TABLE product_attributes (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name)
+);
+
+CREATE INDEX idx_product_attributes_product_id ON product_attributes(product_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_attributes_product_id;
+DROP TABLE IF EXISTS product_attributes;
+
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Bundles sell a fixed set of component products/SKUs at a single price.
+-- Availability is always derived from component stock, never stored.
+CREATE TABLE product_bundles (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    description TEXT,
+    price_cents INTEGER NOT NULL,
+    image_url TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE product_bundle_items (
+    id TEXT PRIMARY KEY,
+    bundle_id TEXT NOT NULL REFERENCES product_bundles(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT REFERENCES product_skus(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_product_bundle_items_bundle ON product_bundle_items(bundle_id);
+CREATE INDEX idx_product_bundle_items_product ON product_bundle_items(product_id);
+
+-- Bundles are expanded into component cart lines that share a group ID and
+-- carry their share of the bundle price so fulfillment sees real products
+ALTER TABLE cart_items ADD COLUMN bundle_id TEXT REFERENCES product_bundles(id) ON DELETE CASCADE;
+ALTER TABLE cart_items ADD COLUMN bundle_group_id TEXT;
+ALTER TABLE cart_items ADD COLUMN bundle_unit_price_cents INTEGER;
+CREATE INDEX idx_cart_items_bundle_group ON cart_items(bundle_group_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_cart_items_bundle_group;
+DELETE FROM cart_items WHERE bundle_group_id IS NOT NULL;
+ALTER TABLE cart_items DROP COLUMN bundle_unit_price_cents;
+ALTER TABLE cart_items DROP COLUMN bundle_group_id;
+ALTER TABLE cart_items DROP COLUMN bundle_id;
+
+DROP INDEX IF EXISTS idx_product_bundle_items_product;
+DROP INDEX IF EXISTS idx_product_bundle_items_bundle;
+DROP TABLE IF EXISTS product_bundle_items;
+DROP TABLE IF EXISTS product_bundles;
+
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Pre-order products haven't been released yet: every unit sold ships on or after
+-- the expected ship date (YYYY-MM-DD), regardless of stock.
+ALTER TABLE products ADD COLUMN is_preorder BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE products ADD COLUMN preorder_ship_date TEXT;
+
+-- Order items bought as pre-orders, and when the customer was told they shipped
+ALTER TABLE order_items ADD COLUMN is_preorder BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE order_items ADD COLUMN preorder_shipped_at DATETIME;
+CREATE INDEX idx_order_items_is_preorder ON order_items(is_preorder);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_items_is_preorder;
+ALTER TABLE order_items DROP COLUMN preorder_shipped_at;
+ALTER TABLE order_items DROP COLUMN is_preorder;
+
+ALTER TABLE products DROP COLUMN preorder_ship_date;
+ALTER TABLE products DROP COLUMN is_preorder;
+
+-- +goose StatementEnd
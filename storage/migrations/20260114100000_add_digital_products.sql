@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Digital products (e.g. STL files) are delivered by download instead of being
+-- shipped. download_limit is how many times each purchase may be downloaded;
+-- NULL means unlimited.
+ALTER TABLE products ADD COLUMN product_type TEXT NOT NULL DEFAULT 'physical' CHECK (product_type IN ('physical', 'digital'));
+ALTER TABLE products ADD COLUMN download_limit INTEGER DEFAULT 5;
+
+-- Files delivered with a digital product. storage_path is relative to the private
+-- digital files directory, which is never served directly.
+CREATE TABLE product_files (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    storage_path TEXT NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/octet-stream',
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_product_files_product_id ON product_files(product_id);
+
+-- One row per purchased file; max_downloads is copied from the product at purchase
+-- time so later limit changes don't affect past orders.
+CREATE TABLE order_downloads (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    product_file_id TEXT NOT NULL REFERENCES product_files(id) ON DELETE CASCADE,
+    max_downloads INTEGER,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_downloaded_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_order_downloads_order_id ON order_downloads(order_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_downloads_order_id;
+DROP TABLE IF EXISTS order_downloads;
+
+DROP INDEX IF EXISTS idx_product_files_product_id;
+DROP TABLE IF EXISTS product_files;
+
+ALTER TABLE products DROP COLUMN download_limit;
+ALTER TABLE products DROP COLUMN product_type;
+
+-- +goose StatementEnd
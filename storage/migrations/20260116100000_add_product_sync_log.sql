@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Audit trail of product pushes received from the sync CLI
+CREATE TABLE product_sync_log (
+    id TEXT PRIMARY KEY,
+    api_key_id TEXT REFERENCES api_keys(id) ON DELETE SET NULL,
+    api_key_name TEXT NOT NULL DEFAULT '',
+    product_id TEXT REFERENCES products(id) ON DELETE SET NULL,
+    product_name TEXT NOT NULL DEFAULT '',
+    slug TEXT NOT NULL DEFAULT '',
+    sku TEXT,
+    source_url TEXT,
+    action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'conflict', 'failed')),
+    images_uploaded INTEGER NOT NULL DEFAULT 0,
+    message TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_product_sync_log_created_at ON product_sync_log(created_at);
+CREATE INDEX idx_product_sync_log_product_id ON product_sync_log(product_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_sync_log_product_id;
+DROP INDEX IF EXISTS idx_product_sync_log_created_at;
+DROP TABLE IF EXISTS product_sync_log;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Quantity-break pricing. A tier's price applies once the quantity reaches
+-- min_quantity; below the lowest tier the product's normal price is charged.
+-- Product-wide tiers (product_sku_id NULL) replace the base price and count every
+-- unit of the product in the cart; a SKU's own tiers take precedence for that SKU.
+CREATE TABLE product_price_tiers (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT REFERENCES product_skus(id) ON DELETE CASCADE,
+    min_quantity INTEGER NOT NULL CHECK (min_quantity >= 2),
+    unit_price_cents INTEGER NOT NULL CHECK (unit_price_cents >= 0),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_product_price_tiers_unique ON product_price_tiers(product_id, COALESCE(product_sku_id, ''), min_quantity);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_price_tiers_unique;
+DROP TABLE IF EXISTS product_price_tiers;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Typed specifications: the name stays the key, value_type says how the value is
+-- validated and rendered, and unit is shown after it ("120 g", "80 × 40 × 25 mm").
+ALTER TABLE product_attributes ADD COLUMN value_type TEXT NOT NULL DEFAULT 'text'
+    CHECK (value_type IN ('text', 'number', 'dimensions'));
+ALTER TABLE product_attributes ADD COLUMN unit TEXT NOT NULL DEFAULT '';
+
+-- Shop filtering looks attributes up by name and value
+CREATE INDEX idx_product_attributes_name_value ON product_attributes(name, value);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_attributes_name_value;
+ALTER TABLE product_attributes DROP COLUMN unit;
+ALTER TABLE product_attributes DROP COLUMN value_type;
+
+-- +goose StatementEnd
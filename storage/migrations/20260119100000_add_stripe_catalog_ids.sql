@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Links to the Stripe catalog. Each product is a Stripe Product with a Price for its
+-- base price; each SKU gets its own Price on the same Product. Stripe prices can't
+-- change amount, so a new Price replaces the stored one when the local price changes.
+ALTER TABLE products ADD COLUMN stripe_product_id TEXT;
+ALTER TABLE products ADD COLUMN stripe_price_id TEXT;
+ALTER TABLE products ADD COLUMN stripe_synced_at DATETIME;
+ALTER TABLE product_skus ADD COLUMN stripe_price_id TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE product_skus DROP COLUMN stripe_price_id;
+ALTER TABLE products DROP COLUMN stripe_synced_at;
+ALTER TABLE products DROP COLUMN stripe_price_id;
+ALTER TABLE products DROP COLUMN stripe_product_id;
+
+-- +goose StatementEnd
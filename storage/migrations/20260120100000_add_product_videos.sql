@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Videos shown in the product gallery. Uploaded videos live in public/videos/products
+-- and video_ref is the file name; for YouTube and Vimeo it's the provider's video ID.
+-- poster_url is an uploaded poster frame; when empty YouTube's own thumbnail is used.
+CREATE TABLE product_videos (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL CHECK (provider IN ('upload', 'youtube', 'vimeo')),
+    video_ref TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    poster_url TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL DEFAULT '',
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_product_videos_product_id ON product_videos(product_id, display_order);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_videos_product_id;
+DROP TABLE IF EXISTS product_videos;
+
+-- +goose StatementEnd
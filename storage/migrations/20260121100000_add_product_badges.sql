@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Badges are labels shown on product cards, product pages and OG images.
+-- Manual badges go on the products assigned to them; the other rules are
+-- evaluated at render time (new, featured, premium, low stock, best seller).
+CREATE TABLE product_badges (
+    id TEXT PRIMARY KEY,
+    label TEXT NOT NULL,
+    color TEXT NOT NULL DEFAULT 'emerald',
+    icon TEXT NOT NULL DEFAULT '',
+    rule TEXT NOT NULL DEFAULT 'manual' CHECK (rule IN ('manual', 'new', 'featured', 'premium', 'low_stock', 'best_seller')),
+    -- low_stock: most units left to qualify; best_seller: how many top sellers qualify
+    threshold INTEGER NOT NULL DEFAULT 0,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE product_badge_assignments (
+    badge_id TEXT NOT NULL REFERENCES product_badges(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (badge_id, product_id)
+);
+
+CREATE INDEX idx_product_badge_assignments_product ON product_badge_assignments(product_id);
+
+-- Rule badges for the existing flags plus the two automatic ones, switched off
+-- so the storefront doesn't change until they're turned on in admin
+INSERT INTO product_badges (id, label, color, icon, rule, threshold, priority, is_active) VALUES
+    ('badge-new', 'New', 'emerald', '✨', 'new', 0, 30, FALSE),
+    ('badge-featured', 'Featured', 'amber', '⭐', 'featured', 0, 20, FALSE),
+    ('badge-premium', 'Premium', 'purple', '👑', 'premium', 0, 40, FALSE),
+    ('badge-low-stock', 'Only a few left', 'orange', '⏳', 'low_stock', 3, 50, FALSE),
+    ('badge-best-seller', 'Best Seller', 'red', '🔥', 'best_seller', 5, 60, FALSE);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_badge_assignments_product;
+DROP TABLE IF EXISTS product_badge_assignments;
+DROP TABLE IF EXISTS product_badges;
+
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Free-form options a customer fills in when buying, such as engraving text or a
+-- colour note. max_length 0 means no limit beyond the overall cap.
+CREATE TABLE product_personalization_fields (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    label TEXT NOT NULL,
+    field_type TEXT NOT NULL DEFAULT 'text' CHECK (field_type IN ('text', 'textarea')),
+    max_length INTEGER NOT NULL DEFAULT 0 CHECK (max_length >= 0),
+    is_required BOOLEAN NOT NULL DEFAULT FALSE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_product_personalization_fields_product ON product_personalization_fields(product_id);
+
+-- What the customer entered, as a JSON array of {"label", "value"} so the order keeps
+-- the wording even if the field is later renamed or removed. Lines with different
+-- personalization stay separate in the cart.
+ALTER TABLE cart_items ADD COLUMN personalization TEXT;
+ALTER TABLE order_items ADD COLUMN personalization TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE order_items DROP COLUMN personalization;
+ALTER TABLE cart_items DROP COLUMN personalization;
+
+DROP INDEX IF EXISTS idx_product_personalization_fields_product;
+DROP TABLE IF EXISTS product_personalization_fields;
+
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Public event pages live at /events/:slug. Existing events get the title and start
+-- date, with the common punctuation stripped; admins can edit it afterwards.
+ALTER TABLE events ADD COLUMN slug TEXT;
+
+UPDATE events
+SET slug = lower(
+    replace(replace(replace(replace(replace(replace(replace(replace(
+        trim(title), '''', ''), '"', ''), ',', ''), '.', ''), ':', ''), '!', ''), '&', 'and'), ' ', '-')
+) || '-' || strftime('%Y-%m-%d', start_date);
+
+-- Same title on the same day: keep the first and suffix the rest with their ID
+UPDATE events
+SET slug = slug || '-' || substr(id, 1, 8)
+WHERE EXISTS (
+    SELECT 1 FROM events other
+    WHERE other.slug = events.slug AND other.id < events.id
+);
+
+CREATE UNIQUE INDEX idx_events_slug ON events(slug);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_events_slug;
+ALTER TABLE events DROP COLUMN slug;
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Hand-picked "pairs well with" products. cross_sell products are shown alongside the
+-- item and after it's added to the cart; upsell products are nicer versions of it.
+-- Products without any fall back to the co-purchase recommendations.
+CREATE TABLE product_relations (
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    related_product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    relation_type TEXT NOT NULL DEFAULT 'cross_sell' CHECK (relation_type IN ('cross_sell', 'upsell')),
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, related_product_id),
+    CHECK (product_id != related_product_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS product_relations;
+
+-- +goose StatementEnd
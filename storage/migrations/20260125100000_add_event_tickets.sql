@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Seats across all ticket types; 0 means unlimited
+ALTER TABLE events ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0;
+
+-- What can be booked for an event. A price of 0 is a free RSVP; anything else is
+-- paid through Stripe Checkout.
+CREATE TABLE event_ticket_types (
+    id TEXT PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT,
+    price_cents INTEGER NOT NULL DEFAULT 0 CHECK (price_cents >= 0),
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_event_ticket_types_event ON event_ticket_types(event_id);
+
+-- One booking of one or more seats. Paid bookings stay pending until Stripe confirms
+-- payment; bookings past capacity go on the waitlist. The ticket name and amount are
+-- copied so the booking reads the same if the ticket type changes.
+CREATE TABLE event_registrations (
+    id TEXT PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    ticket_type_id TEXT REFERENCES event_ticket_types(id) ON DELETE SET NULL,
+    ticket_name TEXT NOT NULL,
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    amount_cents INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'waitlisted', 'cancelled')),
+    stripe_session_id TEXT,
+    checked_in_at DATETIME,
+    reminder_sent_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_event_registrations_event ON event_registrations(event_id, status);
+CREATE INDEX idx_event_registrations_stripe_session ON event_registrations(stripe_session_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_event_registrations_stripe_session;
+DROP INDEX IF EXISTS idx_event_registrations_event;
+DROP TABLE IF EXISTS event_registrations;
+
+DROP INDEX IF EXISTS idx_event_ticket_types_event;
+DROP TABLE IF EXISTS event_ticket_types;
+
+ALTER TABLE events DROP COLUMN capacity;
+
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Scheduled drops go on sale at drop_at, stored as the wall-clock time the admin
+-- entered like event times. Before then the product page counts down instead of
+-- selling. purchase_limit caps how many one customer can buy; 0 means no limit.
+ALTER TABLE products ADD COLUMN drop_at DATETIME;
+ALTER TABLE products ADD COLUMN purchase_limit INTEGER NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE products DROP COLUMN purchase_limit;
+ALTER TABLE products DROP COLUMN drop_at;
+
+-- +goose StatementEnd
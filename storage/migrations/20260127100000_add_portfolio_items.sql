@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Finished prints shown in the public portfolio gallery. Tags are a comma-separated
+-- list used for the gallery filter; they're separate from product tags, which
+-- describe print properties rather than kinds of project.
+CREATE TABLE portfolio_items (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
+    is_published BOOLEAN NOT NULL DEFAULT FALSE,
+    is_featured BOOLEAN NOT NULL DEFAULT FALSE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The first image by display_order is the item's cover
+CREATE TABLE portfolio_item_images (
+    id TEXT PRIMARY KEY,
+    portfolio_item_id TEXT NOT NULL REFERENCES portfolio_items(id) ON DELETE CASCADE,
+    image_url TEXT NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Products the pictured print can be bought as, for "shop this print" links
+CREATE TABLE portfolio_item_products (
+    portfolio_item_id TEXT NOT NULL REFERENCES portfolio_items(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (portfolio_item_id, product_id)
+);
+
+CREATE INDEX idx_portfolio_item_images_item ON portfolio_item_images(portfolio_item_id);
+CREATE INDEX idx_portfolio_item_products_product ON portfolio_item_products(product_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_portfolio_item_products_product;
+DROP INDEX IF EXISTS idx_portfolio_item_images_item;
+DROP TABLE IF EXISTS portfolio_item_products;
+DROP TABLE IF EXISTS portfolio_item_images;
+DROP TABLE IF EXISTS portfolio_items;
+
+-- +goose StatementEnd
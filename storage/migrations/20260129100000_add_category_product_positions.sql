@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Where the owner placed each product within its category. Arranged products list
+-- first, in position order; products that haven't been placed follow, newest first.
+-- A product moved to another category keeps its old row, which is ignored until it
+-- moves back.
+CREATE TABLE category_product_positions (
+    category_id TEXT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (category_id, product_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS category_product_positions;
+
+-- +goose StatementEnd
@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Places stock is kept, e.g. the home studio or the event trailer. Each location is
+-- also a ship-from address, so labels bought for an order fulfilled from a location
+-- leave from where the stock actually is. The default location is used when an order
+-- ships without one being chosen.
+CREATE TABLE inventory_locations (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    contact_name TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    address_line1 TEXT NOT NULL DEFAULT '',
+    address_line2 TEXT NOT NULL DEFAULT '',
+    city_locality TEXT NOT NULL DEFAULT '',
+    state_province TEXT NOT NULL DEFAULT '',
+    postal_code TEXT NOT NULL DEFAULT '',
+    country_code TEXT NOT NULL DEFAULT 'US',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Units on hand at each location. product_sku_id is '' for a product's own stock
+-- and the SKU's id for a variant, so one key covers both. The stock_quantity columns
+-- on products and product_skus stay the number available to sell; they drop at
+-- checkout, while location stock drops when the order ships.
+CREATE TABLE location_stock (
+    location_id TEXT NOT NULL REFERENCES inventory_locations(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (location_id, product_id, product_sku_id)
+);
+
+CREATE INDEX idx_location_stock_product ON location_stock(product_id);
+
+-- Audit trail of every change to location stock. A transfer is two rows sharing a
+-- transfer_id; a fulfillment row names the order that took the stock.
+CREATE TABLE inventory_movements (
+    id TEXT PRIMARY KEY,
+    location_id TEXT NOT NULL REFERENCES inventory_locations(id),
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT NOT NULL DEFAULT '',
+    quantity_change INTEGER NOT NULL,
+    reason TEXT NOT NULL CHECK (reason IN ('adjustment', 'transfer', 'fulfillment')),
+    transfer_id TEXT,
+    order_id TEXT,
+    note TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_inventory_movements_product ON inventory_movements(product_id, created_at);
+CREATE INDEX idx_inventory_movements_location ON inventory_movements(location_id);
+
+-- Where an order's stock came from, set when its label is bought
+ALTER TABLE orders ADD COLUMN fulfillment_location_id TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE orders DROP COLUMN fulfillment_location_id;
+DROP TABLE IF EXISTS inventory_movements;
+DROP TABLE IF EXISTS location_stock;
+DROP TABLE IF EXISTS inventory_locations;
+
+-- +goose StatementEnd
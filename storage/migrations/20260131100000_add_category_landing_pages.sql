@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Category pages can open with a banner photo and a markdown write-up above the
+-- product grid. banner_image is a bare filename under public/images/categories, like
+-- product images. The SEO fields override the generated title and description when set.
+ALTER TABLE categories ADD COLUMN banner_image TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN banner_alt_text TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN content_markdown TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN seo_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN seo_description TEXT NOT NULL DEFAULT '';
+
+-- Products the owner picked to lead a category page, lowest display_order first. A
+-- featured product doesn't have to be in the category itself.
+CREATE TABLE category_featured_products (
+    category_id TEXT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (category_id, product_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS category_featured_products;
+ALTER TABLE categories DROP COLUMN seo_description;
+ALTER TABLE categories DROP COLUMN seo_title;
+ALTER TABLE categories DROP COLUMN content_markdown;
+ALTER TABLE categories DROP COLUMN banner_alt_text;
+ALTER TABLE categories DROP COLUMN banner_image;
+
+-- +goose StatementEnd
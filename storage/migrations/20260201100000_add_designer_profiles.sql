@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Admin-written details for the designers whose models we print, shown on
+-- /shop/designer/:slug. Keyed by the designer_name products already carry, so a
+-- designer has a page as soon as one of their models is imported, profile or not.
+CREATE TABLE designer_profiles (
+    designer_name TEXT PRIMARY KEY,
+    bio_markdown TEXT NOT NULL DEFAULT '',
+    website_url TEXT NOT NULL DEFAULT '',
+    -- Credit line required by the designer's commercial license, shown with every
+    -- product of theirs
+    license_note TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS designer_profiles;
+
+-- +goose StatementEnd
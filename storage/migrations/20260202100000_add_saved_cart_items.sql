@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Cart lines a shopper has set aside with "Save for later". They live outside
+-- cart_items so totals, shipping rates and checkout never see them. Bundle lines
+-- can't be saved, so there are no bundle columns.
+CREATE TABLE saved_cart_items (
+    id TEXT PRIMARY KEY,
+    session_id TEXT,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT REFERENCES product_skus(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    personalization TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_saved_cart_items_session_id ON saved_cart_items(session_id);
+CREATE INDEX idx_saved_cart_items_user_id ON saved_cart_items(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_saved_cart_items_user_id;
+DROP INDEX IF EXISTS idx_saved_cart_items_session_id;
+DROP TABLE IF EXISTS saved_cart_items;
+
+-- +goose StatementEnd
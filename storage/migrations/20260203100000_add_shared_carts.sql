@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A named copy of a cart that anyone with the token can load into their own cart,
+-- e.g. gift lists and classroom orders. The items are copied when the list is
+-- shared, so later changes to the owner's cart don't change the list.
+CREATE TABLE shared_carts (
+    id TEXT PRIMARY KEY,
+    token TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    session_id TEXT,
+    load_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE shared_cart_items (
+    id TEXT PRIMARY KEY,
+    shared_cart_id TEXT NOT NULL REFERENCES shared_carts(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT REFERENCES product_skus(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    personalization TEXT,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_shared_carts_user_id ON shared_carts(user_id);
+CREATE INDEX idx_shared_carts_session_id ON shared_carts(session_id);
+CREATE INDEX idx_shared_cart_items_shared_cart_id ON shared_cart_items(shared_cart_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_shared_cart_items_shared_cart_id;
+DROP INDEX IF EXISTS idx_shared_carts_session_id;
+DROP INDEX IF EXISTS idx_shared_carts_user_id;
+DROP TABLE IF EXISTS shared_cart_items;
+DROP TABLE IF EXISTS shared_carts;
+
+-- +goose StatementEnd
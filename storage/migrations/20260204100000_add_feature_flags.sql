@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Switches for features that are rolled out gradually. A flag is on for a visitor
+-- when it's enabled, the server's environment is listed (an empty list means every
+-- environment), and the visitor's bucket falls under rollout_percent. Buckets come
+-- from hashing the flag key with the user or session, so a visitor's answer is
+-- stable as the percentage grows.
+CREATE TABLE feature_flags (
+    key TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    environments TEXT NOT NULL DEFAULT '',
+    rollout_percent INTEGER NOT NULL DEFAULT 100 CHECK (rollout_percent BETWEEN 0 AND 100),
+    updated_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Audit trail of every flag change. Rows outlive the flag, so a deleted flag's
+-- history stays readable; before_state and after_state are human-readable summaries.
+CREATE TABLE feature_flag_changes (
+    id TEXT PRIMARY KEY,
+    flag_key TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    before_state TEXT NOT NULL DEFAULT '',
+    after_state TEXT NOT NULL DEFAULT '',
+    changed_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_feature_flag_changes_created ON feature_flag_changes(created_at);
+CREATE INDEX idx_feature_flag_changes_flag ON feature_flag_changes(flag_key, created_at);
+
+-- AI-generated OG images were always on, so the flag starts enabled everywhere
+INSERT INTO feature_flags (key, description, enabled, rollout_percent)
+VALUES ('ai_og_images', 'Generate Open Graph images with Gemini instead of the built-in renderer', TRUE, 100);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS feature_flag_changes;
+DROP TABLE IF EXISTS feature_flags;
+
+-- +goose StatementEnd
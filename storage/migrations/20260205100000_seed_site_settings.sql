@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Contact details, social links, tax nexus and the announcement banner move from
+-- templates into site_config so they can be edited from /admin/settings
+INSERT OR IGNORE INTO site_config (key, value) VALUES
+    ('contact_email', 'prints@logans3dcreations.com'),
+    ('contact_phone', '715-703-3768'),
+    ('business_address', '25892 County Hwy S
+Cadott, WI 54727'),
+    ('facebook_url', 'https://www.facebook.com/Logans3D'),
+    ('instagram_url', 'https://www.instagram.com/logans3dcreations/'),
+    ('youtube_url', ''),
+    ('tiktok_url', ''),
+    ('tax_nexus_states', 'WI'),
+    ('announcement_enabled', 'false'),
+    ('announcement_text', ''),
+    ('announcement_link', '');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM site_config WHERE key IN (
+    'contact_email', 'contact_phone', 'business_address',
+    'facebook_url', 'instagram_url', 'youtube_url', 'tiktok_url',
+    'tax_nexus_states',
+    'announcement_enabled', 'announcement_text', 'announcement_link'
+);
+-- +goose StatementEnd
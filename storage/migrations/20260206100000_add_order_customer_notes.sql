@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Instructions the customer typed on the cart ("leave at side door"), carried through
+-- Stripe checkout metadata. Kept apart from notes, which holds the shop's own notes
+-- such as purchase limit warnings.
+ALTER TABLE orders ADD COLUMN customer_notes TEXT NOT NULL DEFAULT '';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE orders DROP COLUMN customer_notes;
+
+-- +goose StatementEnd
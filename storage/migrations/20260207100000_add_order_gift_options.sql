@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Orders sent as gifts. The message is printed on the packing slip, which leaves out
+-- the buyer's contact details. When a recipient email is given they're told when the
+-- gift ships, without any amounts; gift_notified_at records that so it's sent once.
+ALTER TABLE orders ADD COLUMN is_gift BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orders ADD COLUMN gift_message TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN gift_recipient_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN gift_recipient_email TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN gift_notified_at DATETIME;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE orders DROP COLUMN gift_notified_at;
+ALTER TABLE orders DROP COLUMN gift_recipient_email;
+ALTER TABLE orders DROP COLUMN gift_recipient_name;
+ALTER TABLE orders DROP COLUMN gift_message;
+ALTER TABLE orders DROP COLUMN is_gift;
+
+-- +goose StatementEnd
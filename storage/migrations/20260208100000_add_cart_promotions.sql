@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Promotions applied to the cart automatically, without a code. free_shipping and
+-- percent_off apply once the subtotal reaches min_subtotal_cents; buy_n_get_one
+-- gives the cheapest unit free for every buy_quantity + 1 units from category_id.
+-- Only the biggest discount applies to a cart; free shipping is on top of it.
+CREATE TABLE cart_promotions (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('free_shipping', 'percent_off', 'buy_n_get_one')),
+    min_subtotal_cents INTEGER NOT NULL DEFAULT 0,
+    percent_off INTEGER NOT NULL DEFAULT 0 CHECK (percent_off BETWEEN 0 AND 100),
+    category_id TEXT REFERENCES categories(id) ON DELETE CASCADE,
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_cart_promotions_active ON cart_promotions(is_active);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_cart_promotions_active;
+DROP TABLE IF EXISTS cart_promotions;
+
+-- +goose StatementEnd
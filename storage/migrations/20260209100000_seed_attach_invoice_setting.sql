@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Order confirmation emails can carry the invoice PDF; off until switched on in /admin/settings
+INSERT OR IGNORE INTO site_config (key, value) VALUES ('attach_invoice', 'false');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM site_config WHERE key = 'attach_invoice';
+-- +goose StatementEnd
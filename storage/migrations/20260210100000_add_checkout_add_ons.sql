@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Small extras (display stands, care kits) offered on one page between the cart and
+-- Stripe. A rule offers product_id when the cart holds something from category_id, or
+-- with any cart when category_id is NULL. shown_count and added_count give each rule's
+-- attach rate: how often the offer was taken when it was shown.
+CREATE TABLE checkout_add_on_rules (
+    id TEXT PRIMARY KEY,
+    category_id TEXT REFERENCES categories(id) ON DELETE CASCADE,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    shown_count INTEGER NOT NULL DEFAULT 0,
+    added_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_checkout_add_on_rules_active ON checkout_add_on_rules(is_active, priority);
+
+-- The step is new to checkout, so it starts off and is rolled out from the flags page
+INSERT INTO feature_flags (key, description, enabled, rollout_percent)
+VALUES ('checkout_add_ons', 'Offer add-ons on a page between the cart and Stripe checkout', FALSE, 100);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM feature_flags WHERE key = 'checkout_add_ons';
+DROP INDEX IF EXISTS idx_checkout_add_on_rules_active;
+DROP TABLE IF EXISTS checkout_add_on_rules;
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Where an item sits at a location, e.g. "Shelf B3", printed on packing slips and the
+-- pick list. A row with a bin and no stock keeps the bin for when the item is restocked.
+ALTER TABLE location_stock ADD COLUMN bin TEXT NOT NULL DEFAULT '';
+
+-- The label as an image, which batch printing can put on a page alongside the packing
+-- slip. easypost_label_url keeps the PDF link shown on the order page.
+ALTER TABLE orders ADD COLUMN label_image_url TEXT NOT NULL DEFAULT '';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE orders DROP COLUMN label_image_url;
+ALTER TABLE location_stock DROP COLUMN bin;
+
+-- +goose StatementEnd
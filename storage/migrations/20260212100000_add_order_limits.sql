@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- max_per_order caps how many of a product one order can hold, unlike purchase_limit
+-- which counts a customer's past orders too; 0 means no limit
+ALTER TABLE products ADD COLUMN max_per_order INTEGER NOT NULL DEFAULT 0;
+
+-- Store-wide minimum order subtotal in dollars; blank until set in /admin/settings
+INSERT OR IGNORE INTO site_config (key, value) VALUES ('minimum_order', '');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM site_config WHERE key = 'minimum_order';
+ALTER TABLE products DROP COLUMN max_per_order;
+
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The unit price a line was added at. Checkout compares it with the current price
+-- and asks the shopper to refresh the cart when it has changed. Lines added before
+-- this column, and bundle lines, which keep their own price, have none.
+ALTER TABLE cart_items ADD COLUMN quoted_price_cents INTEGER;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE cart_items DROP COLUMN quoted_price_cents;
+
+-- +goose StatementEnd
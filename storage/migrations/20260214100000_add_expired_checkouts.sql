@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Cart checkouts that Stripe expired before they were paid. Keyed by the Stripe
+-- session so a retried webhook doesn't email the customer twice. The token is the
+-- link in the "complete your order" email; returned_at is set once the customer has
+-- seen their cart again, which turns off the restored-cart banner.
+CREATE TABLE expired_checkouts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    customer_email TEXT NOT NULL DEFAULT '',
+    token TEXT NOT NULL UNIQUE,
+    emailed_at DATETIME,
+    returned_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_expired_checkouts_user ON expired_checkouts(user_id, returned_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_expired_checkouts_user;
+DROP TABLE IF EXISTS expired_checkouts;
+
+-- +goose StatementEnd
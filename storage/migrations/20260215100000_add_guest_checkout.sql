@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Orders placed without signing in have no user. The browser session they were
+-- placed from may add them to an account afterwards; otherwise they're claimed by
+-- email when the customer signs up.
+ALTER TABLE orders ADD COLUMN guest_session_id TEXT;
+
+CREATE INDEX idx_orders_guest_email ON orders(customer_email COLLATE NOCASE) WHERE user_id = '';
+
+-- Checkout has always required an account, so guests are let in from the flags page
+INSERT INTO feature_flags (key, description, enabled, rollout_percent)
+VALUES ('guest_checkout', 'Let shoppers check out without signing in', FALSE, 100);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM feature_flags WHERE key = 'guest_checkout';
+DROP INDEX IF EXISTS idx_orders_guest_email;
+ALTER TABLE orders DROP COLUMN guest_session_id;
+
+-- +goose StatementEnd
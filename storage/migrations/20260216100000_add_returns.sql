@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Return requests (RMAs). A customer asks to send back items from one of their
+-- orders; the shop approves or rejects it, can buy a prepaid return label, marks the
+-- parcel received, then refunds and restocks. user_id is copied from the order so a
+-- return stays with the customer if the order is ever re-linked.
+CREATE TABLE returns (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'requested'
+        CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded', 'closed')),
+    reason TEXT NOT NULL
+        CHECK (reason IN ('damaged', 'defective', 'wrong_item', 'not_as_described', 'no_longer_needed', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    admin_notes TEXT NOT NULL DEFAULT '',
+    easypost_shipment_id TEXT NOT NULL DEFAULT '',
+    return_label_url TEXT NOT NULL DEFAULT '',
+    return_tracking_number TEXT NOT NULL DEFAULT '',
+    return_label_cost_cents INTEGER NOT NULL DEFAULT 0,
+    refund_cents INTEGER NOT NULL DEFAULT 0,
+    stripe_refund_id TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    decided_at DATETIME,
+    received_at DATETIME,
+    refunded_at DATETIME
+);
+
+CREATE INDEX idx_returns_order ON returns(order_id);
+CREATE INDEX idx_returns_status ON returns(status, created_at);
+
+-- The order lines being sent back. restocked_quantity counts the units put back
+-- into stock so far, which is never more than came back.
+CREATE TABLE return_items (
+    id TEXT PRIMARY KEY,
+    return_id TEXT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    restocked_quantity INTEGER NOT NULL DEFAULT 0 CHECK (restocked_quantity >= 0 AND restocked_quantity <= quantity),
+    UNIQUE (return_id, order_item_id)
+);
+
+CREATE INDEX idx_return_items_order_item ON return_items(order_item_id);
+
+-- Photos the customer attached, saved under data/return-photos/<return id>/
+CREATE TABLE return_photos (
+    id TEXT PRIMARY KEY,
+    return_id TEXT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    file_path TEXT NOT NULL,
+    original_filename TEXT NOT NULL DEFAULT '',
+    mime_type TEXT NOT NULL DEFAULT '',
+    file_size INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_return_photos_return ON return_photos(return_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_return_photos_return;
+DROP TABLE IF EXISTS return_photos;
+DROP INDEX IF EXISTS idx_return_items_order_item;
+DROP TABLE IF EXISTS return_items;
+DROP INDEX IF EXISTS idx_returns_status;
+DROP INDEX IF EXISTS idx_returns_order;
+DROP TABLE IF EXISTS returns;
+
+-- +goose StatementEnd
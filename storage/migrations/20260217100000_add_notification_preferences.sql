@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Service emails the customer can turn off, unlike receipts and account notices.
+-- They default on: existing rows keep getting them until the customer says otherwise.
+ALTER TABLE email_preferences ADD COLUMN order_updates INTEGER DEFAULT 1;
+ALTER TABLE email_preferences ADD COLUMN shipping_updates INTEGER DEFAULT 1;
+ALTER TABLE email_preferences ADD COLUMN back_in_stock INTEGER DEFAULT 1;
+
+-- The admin user page lists what was sent to a user's address as well as what was
+-- linked to their account
+CREATE INDEX idx_email_history_recipient ON email_history(recipient_email COLLATE NOCASE, sent_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_email_history_recipient;
+ALTER TABLE email_preferences DROP COLUMN back_in_stock;
+ALTER TABLE email_preferences DROP COLUMN shipping_updates;
+ALTER TABLE email_preferences DROP COLUMN order_updates;
+
+-- +goose StatementEnd
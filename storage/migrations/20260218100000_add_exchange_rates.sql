@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Latest rate from USD for each currency the storefront can show prices in,
+-- refreshed on a schedule
+CREATE TABLE exchange_rates (
+    currency TEXT PRIMARY KEY,
+    rate REAL NOT NULL CHECK (rate > 0),
+    fetched_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The currency Stripe actually charged in, and its rate from USD, when the customer
+-- paid in their own currency. Order amounts stay in USD; refunds are converted back.
+ALTER TABLE orders ADD COLUMN payment_currency TEXT NOT NULL DEFAULT 'usd';
+ALTER TABLE orders ADD COLUMN payment_fx_rate REAL NOT NULL DEFAULT 1;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE orders DROP COLUMN payment_fx_rate;
+ALTER TABLE orders DROP COLUMN payment_currency;
+DROP TABLE IF EXISTS exchange_rates;
+
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The mobile number a customer agreed at checkout to get order texts on, in E.164
+-- form, and when they agreed. Blank means no texts for the order.
+ALTER TABLE orders ADD COLUMN sms_phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN sms_consent_at DATETIME;
+
+-- Numbers that replied STOP. They get no texts, whatever an order says, until they
+-- reply START.
+CREATE TABLE sms_opt_outs (
+    phone TEXT PRIMARY KEY,
+    opted_out_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every text sent, or tried, for an order. A sent row stops the same update going
+-- out twice when an order's status is set again.
+CREATE TABLE sms_messages (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    phone TEXT NOT NULL,
+    event TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    provider_message_id TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sms_messages_order ON sms_messages(order_id, event);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_sms_messages_order;
+DROP TABLE IF EXISTS sms_messages;
+DROP TABLE IF EXISTS sms_opt_outs;
+ALTER TABLE orders DROP COLUMN sms_consent_at;
+ALTER TABLE orders DROP COLUMN sms_phone;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Rules for a campaign's codes, checked when a code is entered in the cart.
+-- exclude_sale_items leaves lines that are already discounted (bundles, quantity
+-- breaks, automatic cart promotions) out of the code's discount. stacking decides
+-- what happens when an automatic cart promotion also applies: combine takes the code
+-- off the promoted prices, replace drops the automatic discount for the code's, and
+-- never refuses the code. max_uses_per_customer of 0 means no limit.
+ALTER TABLE promotion_campaigns ADD COLUMN exclude_sale_items BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE promotion_campaigns ADD COLUMN stacking TEXT NOT NULL DEFAULT 'combine' CHECK (stacking IN ('combine', 'replace', 'never'));
+ALTER TABLE promotion_campaigns ADD COLUMN max_uses_per_customer INTEGER NOT NULL DEFAULT 0;
+
+-- Categories whose products a campaign's codes don't discount
+CREATE TABLE promotion_campaign_excluded_categories (
+    campaign_id TEXT NOT NULL REFERENCES promotion_campaigns(id) ON DELETE CASCADE,
+    category_id TEXT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (campaign_id, category_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS promotion_campaign_excluded_categories;
+ALTER TABLE promotion_campaigns DROP COLUMN max_uses_per_customer;
+ALTER TABLE promotion_campaigns DROP COLUMN stacking;
+ALTER TABLE promotion_campaigns DROP COLUMN exclude_sale_items;
+
+-- +goose StatementEnd
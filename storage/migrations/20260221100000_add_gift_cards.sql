@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Gift cards bought in the shop or issued from admin. Unlike gift certificates,
+-- which are redeemed whole in person, a card carries a balance that comes off
+-- online orders until it runs out. Amounts are in cents.
+CREATE TABLE gift_cards (
+    id TEXT PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    initial_cents INTEGER NOT NULL CHECK (initial_cents > 0),
+    balance_cents INTEGER NOT NULL CHECK (balance_cents >= 0),
+    expires_at DATETIME,
+    recipient_email TEXT NOT NULL DEFAULT '',
+    recipient_name TEXT NOT NULL DEFAULT '',
+    sender_name TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL DEFAULT '',
+    -- order_id is the order the card was bought in, NULL for cards issued from admin
+    order_id TEXT REFERENCES orders(id) ON DELETE SET NULL,
+    delivered_at DATETIME,
+    voided_at DATETIME,
+    void_reason TEXT NOT NULL DEFAULT '',
+    created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_gift_cards_order ON gift_cards(order_id);
+CREATE INDEX idx_gift_cards_created ON gift_cards(created_at);
+
+-- Every change to a card's balance. amount_cents is signed: issue and release
+-- add to the balance, redeem takes from it. A redeem made when the checkout
+-- session is created holds the amount; it is released if the session expires,
+-- and gets its order_id once the order is paid.
+CREATE TABLE gift_card_transactions (
+    id TEXT PRIMARY KEY,
+    gift_card_id TEXT NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('issue', 'redeem', 'release', 'adjust', 'void')),
+    amount_cents INTEGER NOT NULL,
+    balance_after_cents INTEGER NOT NULL,
+    order_id TEXT REFERENCES orders(id) ON DELETE SET NULL,
+    checkout_session_id TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_gift_card_transactions_card ON gift_card_transactions(gift_card_id, created_at);
+CREATE INDEX idx_gift_card_transactions_session ON gift_card_transactions(checkout_session_id);
+
+-- The card an order was paid with in part, and how much it covered
+ALTER TABLE orders ADD COLUMN gift_card_id TEXT REFERENCES gift_cards(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN gift_card_cents INTEGER NOT NULL DEFAULT 0;
+
+-- Gift card products are digital; each unit bought issues a card worth its price
+ALTER TABLE products ADD COLUMN is_gift_card BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE products DROP COLUMN is_gift_card;
+ALTER TABLE orders DROP COLUMN gift_card_cents;
+ALTER TABLE orders DROP COLUMN gift_card_id;
+DROP TABLE IF EXISTS gift_card_transactions;
+DROP TABLE IF EXISTS gift_cards;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Deleting a product from admin archives it instead, so the orders and carts that
+-- point at it keep working. An archived product is hidden everywhere but the admin
+-- Archived tab, where it can be restored or, if no order ever included it, deleted
+-- for good. Existing products are all left live.
+ALTER TABLE products ADD COLUMN deleted_at DATETIME;
+CREATE INDEX idx_products_deleted_at ON products(deleted_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_products_deleted_at;
+ALTER TABLE products DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
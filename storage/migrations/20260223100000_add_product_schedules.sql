@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Scheduled publishing: the product scheduler puts a product on the shop at
+-- publish_at and takes it off at unpublish_at, clearing each time once it has acted.
+ALTER TABLE products ADD COLUMN publish_at DATETIME;
+ALTER TABLE products ADD COLUMN unpublish_at DATETIME;
+
+-- Sale pricing. While a sale runs, price_cents holds the sale price so carts,
+-- checkout and feeds charge it without knowing about sales, and regular_price_cents
+-- holds the price to put back when it ends. regular_price_cents is NULL when no sale
+-- is running, which is how the scheduler tells an applied sale from a pending one.
+ALTER TABLE products ADD COLUMN sale_price_cents INTEGER;
+ALTER TABLE products ADD COLUMN sale_starts_at DATETIME;
+ALTER TABLE products ADD COLUMN sale_ends_at DATETIME;
+ALTER TABLE products ADD COLUMN regular_price_cents INTEGER;
+
+-- A SKU's sale price is the full price of the variant. While it runs,
+-- price_adjustment_cents is set so the product price plus the adjustment comes to
+-- the sale price, and regular_adjustment_cents keeps the adjustment to put back.
+ALTER TABLE product_skus ADD COLUMN sale_price_cents INTEGER;
+ALTER TABLE product_skus ADD COLUMN sale_starts_at DATETIME;
+ALTER TABLE product_skus ADD COLUMN sale_ends_at DATETIME;
+ALTER TABLE product_skus ADD COLUMN regular_adjustment_cents INTEGER;
+
+CREATE INDEX idx_products_publish_at ON products(publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX idx_products_unpublish_at ON products(unpublish_at) WHERE unpublish_at IS NOT NULL;
+CREATE INDEX idx_products_sale_price ON products(sale_price_cents) WHERE sale_price_cents IS NOT NULL;
+CREATE INDEX idx_product_skus_sale_price ON product_skus(sale_price_cents) WHERE sale_price_cents IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_product_skus_sale_price;
+DROP INDEX IF EXISTS idx_products_sale_price;
+DROP INDEX IF EXISTS idx_products_unpublish_at;
+DROP INDEX IF EXISTS idx_products_publish_at;
+
+-- Put back the regular prices of running sales before their columns go
+UPDATE product_skus SET price_adjustment_cents = regular_adjustment_cents
+WHERE regular_adjustment_cents IS NOT NULL;
+UPDATE products SET price_cents = regular_price_cents
+WHERE regular_price_cents IS NOT NULL;
+
+ALTER TABLE product_skus DROP COLUMN regular_adjustment_cents;
+ALTER TABLE product_skus DROP COLUMN sale_ends_at;
+ALTER TABLE product_skus DROP COLUMN sale_starts_at;
+ALTER TABLE product_skus DROP COLUMN sale_price_cents;
+
+ALTER TABLE products DROP COLUMN regular_price_cents;
+ALTER TABLE products DROP COLUMN sale_ends_at;
+ALTER TABLE products DROP COLUMN sale_starts_at;
+ALTER TABLE products DROP COLUMN sale_price_cents;
+ALTER TABLE products DROP COLUMN unpublish_at;
+ALTER TABLE products DROP COLUMN publish_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Store credit on customer accounts, granted from admin for a refund, as goodwill
+-- or as a promotion. Unlike a gift card there's no code: it belongs to the account
+-- and comes off the customer's next online orders by itself. Amounts are in cents.
+CREATE TABLE store_credit_balances (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    balance_cents INTEGER NOT NULL DEFAULT 0 CHECK (balance_cents >= 0),
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every change to an account's credit. amount_cents is signed: grant and release
+-- add to the balance, revoke and redeem take from it. reason says why a grant was
+-- made and is empty for the other kinds. As with gift cards, a redeem made when the
+-- checkout session is created holds the amount; it is released if the session
+-- expires, and gets its order_id once the order is paid.
+CREATE TABLE store_credit_transactions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('grant', 'revoke', 'redeem', 'release')),
+    reason TEXT NOT NULL DEFAULT '' CHECK (reason IN ('', 'refund', 'goodwill', 'promotion')),
+    amount_cents INTEGER NOT NULL,
+    balance_after_cents INTEGER NOT NULL,
+    order_id TEXT REFERENCES orders(id) ON DELETE SET NULL,
+    checkout_session_id TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_by_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_store_credit_transactions_user ON store_credit_transactions(user_id, created_at);
+CREATE INDEX idx_store_credit_transactions_session ON store_credit_transactions(checkout_session_id);
+CREATE INDEX idx_store_credit_transactions_created ON store_credit_transactions(created_at);
+
+-- How much of an order was paid with store credit
+ALTER TABLE orders ADD COLUMN store_credit_cents INTEGER NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE orders DROP COLUMN store_credit_cents;
+DROP TABLE IF EXISTS store_credit_transactions;
+DROP TABLE IF EXISTS store_credit_balances;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Renaming a category changes its slug, and with it the /shop/category/ URL that
+-- search engines and shared links already point at. Each slug a category gives up is
+-- kept here, so the shop can redirect the old URL to the category's current one. A
+-- slug maps to one category, the latest to have used it.
+CREATE TABLE category_slug_history (
+    slug TEXT PRIMARY KEY,
+    category_id TEXT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_category_slug_history_category ON category_slug_history(category_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_category_slug_history_category;
+DROP TABLE IF EXISTS category_slug_history;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Renaming a product regenerates its slug, and with it the /shop/product/ URL that
+-- search engines and shared links already point at. Each slug a product gives up is
+-- kept here, so the shop can redirect the old URL to the product's current one. A
+-- slug maps to one product, the latest to have used it.
+CREATE TABLE slug_redirects (
+    old_slug TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_slug_redirects_product ON slug_redirects(product_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_slug_redirects_product;
+DROP TABLE IF EXISTS slug_redirects;
+
+-- +goose StatementEnd
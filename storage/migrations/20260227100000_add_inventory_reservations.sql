@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Stock held for cart checkouts in progress, so two shoppers can't both pay for the
+-- last unit. A row is made for each stocked cart line when its Stripe session is
+-- created, all sharing a hold_id, and gets the session once it exists. status goes
+-- from held to consumed when the order is paid, or to released when the checkout is
+-- cancelled, expires or outlives expires_at. Only held rows that haven't expired
+-- count against stock. product_sku_id is NULL for products without variants.
+CREATE TABLE inventory_reservations (
+    id TEXT PRIMARY KEY,
+    hold_id TEXT NOT NULL,
+    product_id TEXT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_sku_id TEXT REFERENCES product_skus(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    cart_session_id TEXT NOT NULL DEFAULT '',
+    checkout_session_id TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'consumed', 'released')),
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_inventory_reservations_product ON inventory_reservations(product_id, status);
+CREATE INDEX idx_inventory_reservations_hold ON inventory_reservations(hold_id);
+CREATE INDEX idx_inventory_reservations_session ON inventory_reservations(checkout_session_id);
+CREATE INDEX idx_inventory_reservations_cart ON inventory_reservations(cart_session_id, status);
+CREATE INDEX idx_inventory_reservations_expiry ON inventory_reservations(status, expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS inventory_reservations;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- What EasyPost made of the destination a session's shipping was rated for:
+-- verified, corrected (the customer was offered a correction), undeliverable or
+-- unverified (EasyPost couldn't check it). address_verification_json holds the
+-- normalized address and any messages. Selections saved before verification are left
+-- unverified.
+ALTER TABLE session_shipping_selection ADD COLUMN address_verification_status TEXT NOT NULL DEFAULT 'unverified';
+ALTER TABLE session_shipping_selection ADD COLUMN address_verification_json TEXT NOT NULL DEFAULT '';
+ALTER TABLE session_shipping_selection ADD COLUMN address_verified_at DATETIME;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE session_shipping_selection DROP COLUMN address_verified_at;
+ALTER TABLE session_shipping_selection DROP COLUMN address_verification_json;
+ALTER TABLE session_shipping_selection DROP COLUMN address_verification_status;
+
+-- +goose StatementEnd
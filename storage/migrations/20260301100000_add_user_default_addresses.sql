@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The address a signed-in customer last had shipping rated for, kept so the cart can
+-- quote rates to it as soon as it loads. It's saved with each shipping selection and
+-- can be removed from the account page. verification_status is the address
+-- verification status it was saved with (see session_shipping_selection).
+CREATE TABLE user_default_addresses (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    address_line1 TEXT NOT NULL,
+    address_line2 TEXT NOT NULL DEFAULT '',
+    city_locality TEXT NOT NULL,
+    state_province TEXT NOT NULL,
+    postal_code TEXT NOT NULL,
+    country_code TEXT NOT NULL DEFAULT 'US',
+    verification_status TEXT NOT NULL DEFAULT 'unverified',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_default_addresses;
+
+-- +goose StatementEnd
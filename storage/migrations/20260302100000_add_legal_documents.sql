@@ -0,0 +1,371 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Versions of the policies customers agree to at checkout. A version takes effect at
+-- effective_at and stays current until a later one does; the public page shows the
+-- current one. Versions are never edited once they're in effect, so the text a
+-- customer agreed to can always be shown again.
+CREATE TABLE legal_documents (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('terms', 'shipping', 'custom_policy')),
+    version INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body_markdown TEXT NOT NULL,
+    -- What changed from the previous version, for the admin list
+    change_summary TEXT NOT NULL DEFAULT '',
+    effective_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, version)
+);
+
+CREATE INDEX idx_legal_documents_kind_effective ON legal_documents(kind, effective_at);
+
+-- The versions a customer ticked the box for at checkout, one row per document
+CREATE TABLE order_policy_acceptances (
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    legal_document_id TEXT NOT NULL REFERENCES legal_documents(id),
+    accepted_at DATETIME NOT NULL,
+    PRIMARY KEY (order_id, legal_document_id)
+);
+
+CREATE INDEX idx_order_policy_acceptances_document ON order_policy_acceptances(legal_document_id);
+
+-- The policies as they were on the site's pages, in effect since their last update
+INSERT INTO legal_documents (id, kind, version, title, effective_at, body_markdown) VALUES
+    ('terms-v1', 'terms', 1, 'Terms of Service', '2025-01-01 00:00:00', '## 1. Agreement to Terms
+
+By accessing and using Logan''s 3D Creations services, you agree to be bound by these Terms of Service.
+
+## 2. Contact Information
+
+**Logan''s 3D Creations**\
+Email: prints@logans3dcreations.com\
+Phone: 715-703-3768\
+Address: 25892 County Hwy S, Cadott, WI 54727'),
+    ('shipping-v1', 'shipping', 1, 'Shipping & Returns', '2025-01-01 00:00:00', '## Shipping Information
+
+### Shipping Methods
+
+| Method | Service | Delivery | Cost |
+|--------|---------|----------|------|
+| Standard Shipping | USPS Ground | 5-7 business days | $5.95 - $12.95 |
+| Priority Shipping | USPS Priority | 2-3 business days | $8.95 - $18.95 |
+| Express Shipping | UPS Next Day | 1 business day | $24.95 - $39.95 |
+| Local Pickup | At events or by appointment | Flexible scheduling | Free |
+
+### Shipping Costs
+
+- Shipping costs are calculated based on size, weight, and destination
+- Free standard shipping on orders over $75 (continental US only)
+- Oversized items may require special shipping arrangements
+- International shipping available on request (additional fees apply)
+
+### Processing & Shipping Time
+
+- **In-stock items:** Ships in 1-3 days
+- **Items requiring printing:** Ships in 4-5 days
+- **Rush orders:** Available for additional fee (contact us for details)
+- **Holiday periods:** May experience extended processing times
+
+Shipping times shown on product pages indicate estimated processing and ship time. Items in stock ship faster, while items that need to be printed require additional preparation time.
+
+### Delivery
+
+- Signature may be required for valuable orders
+- We are not responsible for packages left unattended
+- Address accuracy is crucial - we cannot reship for incorrect addresses
+- Tracking information will be provided via email
+
+## Returns & Refunds
+
+### Return Policy Overview
+
+> **Important:** Due to the custom nature of 3D printing, most items are made-to-order and cannot be returned unless there is a manufacturing defect or error on our part.
+
+### Returnable Items
+
+- In-stock products within 14 days of delivery
+- Items with manufacturing defects
+- Incorrect items shipped due to our error
+- Items damaged during shipping
+
+### Non-Returnable Items
+
+- Custom printed items made to your specifications
+- Personalized or engraved products
+- Items modified or damaged by the customer
+- Digital files or design services
+- Items returned without original packaging
+
+### Quality Guarantee
+
+We stand behind the quality of our work. If you receive a custom item that:
+
+- Has significant printing defects or layer adhesion issues
+- Doesn''t match the agreed-upon specifications
+- Is functionally unusable due to our error
+
+We will work with you to resolve the issue through repair, reprint, or refund.
+
+### Return Process
+
+1. **Contact Us:** Email us within 14 days of delivery with photos and description
+2. **Authorization:** Wait for return authorization before sending items back
+3. **Packaging:** Pack items carefully in original packaging if available
+4. **Shipping:** Ship to our return address using a trackable method
+5. **Processing:** Allow 5-10 business days for inspection and refund processing
+
+### Refund Policy
+
+- Refunds processed to original payment method
+- Original shipping costs are non-refundable (unless our error)
+- Customer pays return shipping costs (unless our error)
+- Partial refunds may apply for used or damaged items
+- Processing time: 5-10 business days after we receive the return
+
+## Damaged or Lost Packages
+
+### Shipping Damage
+
+- Inspect packages immediately upon delivery
+- Report damage within 48 hours of delivery
+- Take photos of damaged packaging and items
+- We will work with the shipping carrier to resolve claims
+
+### Lost Packages
+
+- We are responsible until delivery confirmation
+- If tracking shows delivered but you didn''t receive it, contact us immediately
+- We will investigate with the carrier and replace if necessary
+- Claims must be reported within 7 days of delivery date
+
+## International Shipping
+
+Limited international shipping available:
+
+- Contact us for quote and availability
+- Customer responsible for customs duties and taxes
+- Longer delivery times (10-30 business days)
+- No returns on international orders unless manufacturing defect
+
+## Special Circumstances
+
+### Force Majeure
+
+Shipping delays due to weather, natural disasters, or other circumstances beyond our control are not eligible for refunds, but we will work to resolve issues promptly.
+
+### Address Changes
+
+- Address changes must be requested before order ships
+- Additional shipping fees may apply for address corrections
+- Cannot intercept packages already in transit
+
+## Contact Us
+
+For shipping questions, return authorization, or to report issues:
+
+**Logan''s 3D Creations - Customer Service**\
+Email: orders@logans3dcreations.com\
+Phone: (555) 3D-PRINT\
+Business Hours: Monday-Friday, 9:00 AM - 6:00 PM EST\
+Address: 123 Maker Street, Springfield, IL 62701'),
+    ('custom_policy-v1', 'custom_policy', 1, 'Custom Work Policy', '2025-01-01 00:00:00', '## Overview
+
+Logan''s 3D Creations specializes in custom 3D printing services, transforming your ideas into reality through precision manufacturing. This policy outlines our process, requirements, and terms for custom work projects.
+
+## Custom Services Offered
+
+### Print-Only Services
+
+- You provide the 3D file
+- We print using your specifications
+- Material and finish selection
+- Quality assurance and packaging
+
+### Design + Print Services
+
+- Concept development consultation
+- 3D modeling and file creation
+- Design optimization for printing
+- Prototyping and production
+
+## File Requirements & Standards
+
+### Acceptable File Formats
+
+- **STL** - Most common, preferred for basic prints
+- **OBJ** - Good for textured or colored models
+- **3MF** - Microsoft 3D format with material info
+- **STEP/STP** - CAD format for engineering applications
+
+### File Quality Standards
+
+- Manifold geometry (watertight, no holes or gaps)
+- Appropriate resolution (0.1-0.5mm triangle size)
+- Proper scale and units (millimeters preferred)
+- Wall thickness minimum 0.8mm for structural integrity
+- Support-conscious design when possible
+
+### Design Limitations
+
+**Please note these technical limitations:**
+
+- Maximum build volume: 250×210×210mm
+- Minimum feature size: 0.2mm
+- Maximum overhang: 45° without support
+- Minimum wall thickness: 0.8mm
+- Moving parts require 0.3mm+ clearance
+
+## Quote Process
+
+### Initial Consultation
+
+1. **Project Brief:** Tell us about your project goals and requirements
+2. **File Review:** Submit files for technical feasibility assessment
+3. **Material Selection:** Choose appropriate materials for your application
+4. **Timeline Discussion:** Establish realistic delivery expectations
+5. **Quote Preparation:** Detailed pricing breakdown provided within 2 business days
+
+### Pricing Factors
+
+- **Material volume:** Amount of filament used
+- **Print time:** Machine hours required
+- **Complexity:** Support requirements and post-processing
+- **Material type:** Standard vs. specialty filaments
+- **Quantity:** Volume discounts available
+- **Rush orders:** Expedite fees may apply
+
+## Design Services
+
+### What We Provide
+
+- Concept sketching and ideation
+- 3D modeling from sketches or specifications
+- Reverse engineering from physical samples
+- File optimization for 3D printing
+- Technical drawings and documentation
+
+### Design Process
+
+1. **Requirements Gathering:** Detailed project specifications
+2. **Initial Design:** First draft for review and feedback
+3. **Revision Rounds:** Up to 3 revisions included
+4. **File Preparation:** Print-ready files delivered
+5. **Test Print:** Optional prototype for approval
+
+## Production Timeline
+
+### Typical Turnaround Times
+
+#### Print-Only Orders
+
+- Simple parts: 1-3 days
+- Complex parts: 3-7 days
+- Large parts: 5-10 days
+- Multiple parts: 5-14 days
+
+#### Design + Print Orders
+
+- Simple design: 1-2 weeks
+- Complex design: 2-4 weeks
+- Prototype iterations: +1-2 weeks
+- Production run: 2-6 weeks
+
+## Payment Terms
+
+### Payment Schedule
+
+- **Print-only orders:** Full payment before production
+- **Design services:** 50% deposit, balance on completion
+- **Large orders (>$500):** 50% deposit, 50% before shipping
+- **Rush orders:** Full payment upfront
+
+### Quote Validity
+
+- Standard quotes valid for 30 days
+- Material price fluctuations may affect pricing
+- Project scope changes require new quote
+- Rush order surcharges apply for expedited service
+
+## Quality Standards & Revisions
+
+### Quality Assurance
+
+- Visual inspection of every print
+- Dimensional accuracy verification
+- Surface finish quality control
+- Packaging protection during shipping
+
+### Revision Policy
+
+- **Design errors (our fault):** Free revision and reprint
+- **Print defects:** Free reprint with expedited processing
+- **Customer changes:** New quote required
+- **File issues:** May require additional design work
+
+## Intellectual Property
+
+### Your Rights
+
+- You retain all rights to your original designs
+- We maintain strict confidentiality of your projects
+- Files are securely stored and not shared
+- Non-disclosure agreements available upon request
+
+### Our Responsibilities
+
+- Verify you have rights to submitted designs
+- Refuse work that infringes on others'' IP
+- Maintain secure file storage systems
+- Delete files upon request after project completion
+
+## Prohibited Items
+
+**We cannot and will not print:**
+
+- Weapons or weapon components
+- Items that infringe on copyrights or patents
+- Adult content or inappropriate materials
+- Items intended for illegal activities
+- Medical implants or devices requiring certification
+- Food contact items (unless food-safe material specified)
+
+## Communication & Updates
+
+### Project Updates
+
+- Email confirmations at key milestones
+- Photo updates for complex projects
+- Proactive communication about delays
+- Final quality photos before shipping
+
+### Response Times
+
+- **Quote requests:** Within 2 business days
+- **General inquiries:** Within 24 hours
+- **Technical questions:** Within 4 hours during business hours
+- **Urgent issues:** Within 2 hours during business hours
+
+## Contact Information
+
+Ready to start your custom project? Get in touch with us:
+
+**Logan''s 3D Creations - Custom Services**\
+Email: custom@logans3dcreations.com\
+Phone: (555) 3D-PRINT ext. 2\
+Business Hours: Monday-Friday, 9:00 AM - 6:00 PM EST\
+Address: 123 Maker Street, Springfield, IL 62701
+
+**Ready to get started?** Contact us with your project details and we''ll provide a detailed quote within 2 business days. We''re excited to help bring your ideas to life!');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_policy_acceptances_document;
+DROP TABLE IF EXISTS order_policy_acceptances;
+DROP INDEX IF EXISTS idx_legal_documents_kind_effective;
+DROP TABLE IF EXISTS legal_documents;
+
+-- +goose StatementEnd
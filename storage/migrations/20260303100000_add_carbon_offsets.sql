@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- What the customer added at checkout to offset the carbon from making and shipping
+-- their order, in cents; 0 when they didn't
+ALTER TABLE orders ADD COLUMN carbon_offset_cents INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_orders_carbon_offset ON orders(created_at) WHERE carbon_offset_cents > 0;
+
+-- The checkout add-on is off until it's switched on in /admin/settings
+INSERT OR IGNORE INTO site_config (key, value) VALUES ('carbon_offset_enabled', 'false');
+INSERT OR IGNORE INTO site_config (key, value) VALUES ('carbon_offset_amount', '1.00');
+INSERT OR IGNORE INTO site_config (key, value) VALUES ('carbon_offset_text', '');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM site_config WHERE key IN ('carbon_offset_enabled', 'carbon_offset_amount', 'carbon_offset_text');
+DROP INDEX IF EXISTS idx_orders_carbon_offset;
+ALTER TABLE orders DROP COLUMN carbon_offset_cents;
+
+-- +goose StatementEnd
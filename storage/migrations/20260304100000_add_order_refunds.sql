@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Refunds issued from the admin order page, for the whole order or some of its lines.
+-- Return refunds stay on returns; both count toward what's left of an order to refund.
+-- The ID is also the Stripe idempotency key, so a resubmitted form can't pay out twice.
+CREATE TABLE order_refunds (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    stripe_refund_id TEXT NOT NULL DEFAULT '',
+    restocked BOOLEAN NOT NULL DEFAULT FALSE,
+    customer_notified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_refunds_order ON order_refunds(order_id, created_at);
+
+-- The order lines a refund covers; a whole-order refund lists every line that was left
+CREATE TABLE order_refund_items (
+    id TEXT PRIMARY KEY,
+    refund_id TEXT NOT NULL REFERENCES order_refunds(id) ON DELETE CASCADE,
+    order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount_cents INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (refund_id, order_item_id)
+);
+
+CREATE INDEX idx_order_refund_items_order_item ON order_refund_items(order_item_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_refund_items_order_item;
+DROP TABLE IF EXISTS order_refund_items;
+DROP INDEX IF EXISTS idx_order_refunds_order;
+DROP TABLE IF EXISTS order_refunds;
+
+-- +goose StatementEnd
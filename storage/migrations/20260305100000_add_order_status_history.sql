@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Every change of an order's status, oldest first. from_status is empty for the
+-- order being placed; changed_by is the admin's email, or empty for the shop itself
+-- (the Stripe webhook, a label purchase's automatic ship).
+CREATE TABLE order_status_history (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL DEFAULT '',
+    to_status TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    changed_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_status_history_order ON order_status_history(order_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_status_history_order;
+DROP TABLE IF EXISTS order_status_history;
+
+-- +goose StatementEnd
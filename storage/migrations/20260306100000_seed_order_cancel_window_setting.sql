@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Hours after ordering that customers can cancel a received order from their account; 0 turns it off
+INSERT OR IGNORE INTO site_config (key, value) VALUES ('order_cancel_window', '24');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM site_config WHERE key = 'order_cancel_window';
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Each box an order goes out in, with its tracking. easypost_shipment_id and
+-- label_url are set when the label was bought here; tracking entered by hand leaves
+-- them empty. created_by is the admin's email.
+CREATE TABLE order_shipments (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    carrier TEXT NOT NULL DEFAULT '',
+    tracking_number TEXT NOT NULL DEFAULT '',
+    tracking_url TEXT NOT NULL DEFAULT '',
+    easypost_shipment_id TEXT NOT NULL DEFAULT '',
+    label_url TEXT NOT NULL DEFAULT '',
+    location_id TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    customer_notified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_shipments_order ON order_shipments(order_id, created_at);
+
+-- The units of each order line in a shipment
+CREATE TABLE order_shipment_items (
+    id TEXT PRIMARY KEY,
+    shipment_id TEXT NOT NULL REFERENCES order_shipments(id) ON DELETE CASCADE,
+    order_item_id TEXT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_order_shipment_items_shipment ON order_shipment_items(shipment_id);
+CREATE INDEX idx_order_shipment_items_item ON order_shipment_items(order_item_id);
+
+-- Orders already sent with tracking count as one shipment of all their physical units
+INSERT INTO order_shipments (id, order_id, carrier, tracking_number, tracking_url, easypost_shipment_id, label_url, created_at)
+SELECT
+    'legacy-' || id,
+    id,
+    COALESCE(carrier, ''),
+    COALESCE(tracking_number, ''),
+    COALESCE(tracking_url, ''),
+    CASE WHEN COALESCE(easypost_label_url, '') != '' THEN COALESCE(easypost_shipment_id, '') ELSE '' END,
+    COALESCE(easypost_label_url, ''),
+    updated_at
+FROM orders
+WHERE status IN ('shipped', 'delivered')
+  AND (COALESCE(tracking_number, '') != '' OR COALESCE(easypost_label_url, '') != '');
+
+INSERT INTO order_shipment_items (id, shipment_id, order_item_id, quantity)
+SELECT 'legacy-' || oi.id, s.id, oi.id, oi.quantity
+FROM order_items oi
+JOIN order_shipments s ON s.id = 'legacy-' || oi.order_id
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE COALESCE(p.product_type, 'physical') = 'physical'
+  AND oi.quantity > 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_shipment_items_item;
+DROP INDEX IF EXISTS idx_order_shipment_items_shipment;
+DROP TABLE IF EXISTS order_shipment_items;
+DROP INDEX IF EXISTS idx_order_shipments_order;
+DROP TABLE IF EXISTS order_shipments;
+
+-- +goose StatementEnd
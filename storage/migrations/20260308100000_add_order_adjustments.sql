@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Changes made to an order after it was paid for. amount_cents is what the change
+-- came to for the customer: negative when it was refunded, positive when they were
+-- sent a payment link for it. Removed items and lower shipping are refunded straight
+-- away through order_refund_id; added items and higher shipping wait on the link
+-- until paid_at is set. A link that's withdrawn before it's paid is cancelled_at.
+CREATE TABLE order_adjustments (
+    id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('remove_items', 'add_items', 'shipping')),
+    description TEXT NOT NULL DEFAULT '',
+    amount_cents INTEGER NOT NULL DEFAULT 0,
+    order_refund_id TEXT REFERENCES order_refunds(id) ON DELETE SET NULL,
+    stripe_payment_link_id TEXT NOT NULL DEFAULT '',
+    payment_url TEXT NOT NULL DEFAULT '',
+    stripe_payment_intent_id TEXT NOT NULL DEFAULT '',
+    paid_at DATETIME,
+    cancelled_at DATETIME,
+    note TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_adjustments_order ON order_adjustments(order_id, created_at);
+CREATE INDEX idx_order_adjustments_payment_link ON order_adjustments(stripe_payment_link_id);
+
+-- The units an adjustment took off or put on the order. Removed units point at the
+-- order line they came off. Added units become an order line, with this row's ID, once
+-- they're paid for.
+CREATE TABLE order_adjustment_items (
+    id TEXT PRIMARY KEY,
+    adjustment_id TEXT NOT NULL REFERENCES order_adjustments(id) ON DELETE CASCADE,
+    order_item_id TEXT REFERENCES order_items(id) ON DELETE SET NULL,
+    product_id TEXT NOT NULL,
+    product_sku_id TEXT NOT NULL DEFAULT '',
+    product_name TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price_cents INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_order_adjustment_items_adjustment ON order_adjustment_items(adjustment_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_order_adjustment_items_adjustment;
+DROP TABLE IF EXISTS order_adjustment_items;
+DROP INDEX IF EXISTS idx_order_adjustments_payment_link;
+DROP INDEX IF EXISTS idx_order_adjustments_order;
+DROP TABLE IF EXISTS order_adjustments;
+
+-- +goose StatementEnd
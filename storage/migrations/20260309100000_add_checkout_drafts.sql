@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An order waiting on payment through the embedded Payment Element rather than hosted
+-- Stripe Checkout. It holds what a Checkout Session would: the lines, the metadata the
+-- webhook reads and the amounts, worked out server-side when checkout starts. The
+-- order is made from it once the payment intent succeeds. Gift card, store credit and
+-- stock holds are keyed to its ID as they are to a session's.
+CREATE TABLE checkout_drafts (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    user_id TEXT NOT NULL DEFAULT '',
+    -- Signed-in customers' email comes from their account; guests enter it on the
+    -- payment page
+    customer_email TEXT NOT NULL DEFAULT '',
+    shipping_address_json TEXT NOT NULL,
+    -- The lines as they'd have gone to Checkout, and the session metadata
+    lines_json TEXT NOT NULL,
+    metadata_json TEXT NOT NULL,
+    -- What's charged, in USD cents, with the tax and the discount in it. The discount
+    -- includes what a gift card and store credit pay, as the Checkout coupon does.
+    amount_cents INTEGER NOT NULL,
+    tax_cents INTEGER NOT NULL DEFAULT 0,
+    discount_cents INTEGER NOT NULL DEFAULT 0,
+    tax_calculation_id TEXT NOT NULL DEFAULT '',
+    -- The Stripe Tax transaction recorded from the calculation once paid
+    tax_transaction_id TEXT NOT NULL DEFAULT '',
+    stripe_customer_id TEXT NOT NULL DEFAULT '',
+    payment_intent_id TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'paid', 'expired')),
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_checkout_drafts_open ON checkout_drafts(expires_at) WHERE status = 'open';
+CREATE INDEX idx_checkout_drafts_payment_intent ON checkout_drafts(payment_intent_id);
+
+-- The Stripe customer a signed-in customer's cards are saved to, made at their first
+-- Payment Element checkout or when they add a card from their account
+ALTER TABLE users ADD COLUMN stripe_customer_id TEXT NOT NULL DEFAULT '';
+
+-- Checkout has always gone to hosted Stripe Checkout, so the embedded one is rolled out
+-- from the flags page
+INSERT INTO feature_flags (key, description, enabled, rollout_percent)
+VALUES ('payment_element_checkout', 'Take payment on our own checkout page with the Stripe Payment Element instead of hosted Checkout', FALSE, 100);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM feature_flags WHERE key = 'payment_element_checkout';
+ALTER TABLE users DROP COLUMN stripe_customer_id;
+DROP INDEX IF EXISTS idx_checkout_drafts_payment_intent;
+DROP INDEX IF EXISTS idx_checkout_drafts_open;
+DROP TABLE IF EXISTS checkout_drafts;
+
+-- +goose StatementEnd
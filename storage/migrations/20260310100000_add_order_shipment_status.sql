@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Where each box of an order has got to, set from the admin order page. A problem is
+-- a box lost, damaged or sent back, which the shop follows up with the customer.
+ALTER TABLE order_shipments ADD COLUMN status TEXT NOT NULL DEFAULT 'in_transit' CHECK (status IN ('in_transit', 'delivered', 'problem'));
+ALTER TABLE order_shipments ADD COLUMN status_note TEXT NOT NULL DEFAULT '';
+ALTER TABLE order_shipments ADD COLUMN delivered_at DATETIME;
+
+-- Boxes of orders already delivered arrived with them
+UPDATE order_shipments
+SET status = 'delivered',
+    delivered_at = (SELECT o.updated_at FROM orders o WHERE o.id = order_shipments.order_id)
+WHERE order_id IN (SELECT id FROM orders WHERE status = 'delivered');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE order_shipments DROP COLUMN delivered_at;
+ALTER TABLE order_shipments DROP COLUMN status_note;
+ALTER TABLE order_shipments DROP COLUMN status;
+
+-- +goose StatementEnd
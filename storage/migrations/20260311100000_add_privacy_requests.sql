@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The compliance log of customers' privacy requests: exports of their data, made by a
+-- background job, and deletions of their account. Rows are kept after the account is
+-- gone, so nothing here names the customer: user_id is the deleted account's ID and the
+-- email is kept only as a hash, enough to answer whether an address's data was deleted.
+CREATE TABLE privacy_requests (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    -- SHA-256 of the lowercased email the account had
+    email_hash TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('export', 'deletion')),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'failed')),
+    -- A finished export's ZIP, in the export directory, until it expires and is removed
+    file_name TEXT NOT NULL DEFAULT '',
+    -- How many rows were exported or erased from each table, as JSON
+    summary_json TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    requested_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME,
+    -- When a finished export stops being downloadable
+    expires_at DATETIME
+);
+
+CREATE INDEX idx_privacy_requests_user ON privacy_requests(user_id, requested_at);
+CREATE INDEX idx_privacy_requests_pending ON privacy_requests(requested_at) WHERE status = 'pending';
+
+-- Guest orders, and the orders kept from deleted accounts, have user_id ''. orders.user_id
+-- references users, so with foreign keys on they need a row to point at: this one, with
+-- no email, name or sign-in, which the customer lists leave out.
+INSERT OR IGNORE INTO users (id, email, full_name) VALUES ('', '', '');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- The users row for orders without an account stays: deleting it would cascade to them
+
+DROP INDEX IF EXISTS idx_privacy_requests_pending;
+DROP INDEX IF EXISTS idx_privacy_requests_user;
+DROP TABLE IF EXISTS privacy_requests;
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Each order's invoice PDF, made the first time it's asked for and kept for every
+-- download and email after. fingerprint is a hash of everything printed on it, so a
+-- change to the order, its items, payments or the business details makes a new one.
+CREATE TABLE order_invoices (
+    order_id TEXT PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    pdf BLOB NOT NULL,
+    generated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS order_invoices;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The design URLs queued by a "url_import" job, in the order they were pasted. Each
+-- is pending until the importer job gets to it, then imported, a duplicate of a
+-- product the shop already has, or failed with the reason in error. An empty
+-- category_id means the category is matched from the design's tags.
+CREATE TABLE import_job_urls (
+    id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL REFERENCES import_jobs(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    platform TEXT NOT NULL,
+    category_id TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT NOT NULL DEFAULT '',
+    product_id TEXT REFERENCES products(id) ON DELETE SET NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_import_job_urls_job ON import_job_urls(job_id, position);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_import_job_urls_job;
+DROP TABLE IF EXISTS import_job_urls;
+
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Guests' expired checkouts are kept too, keyed by the browser session that holds
+-- their cart instead of a user. SQLite can't drop NOT NULL, so the table is rebuilt.
+CREATE TABLE expired_checkouts_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    session_id TEXT,
+    customer_email TEXT NOT NULL DEFAULT '',
+    token TEXT NOT NULL UNIQUE,
+    emailed_at DATETIME,
+    returned_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CHECK (user_id IS NOT NULL OR session_id IS NOT NULL)
+);
+INSERT INTO expired_checkouts_new (id, user_id, customer_email, token, emailed_at, returned_at, created_at)
+SELECT id, user_id, customer_email, token, emailed_at, returned_at, created_at FROM expired_checkouts;
+DROP INDEX IF EXISTS idx_expired_checkouts_user;
+DROP TABLE expired_checkouts;
+ALTER TABLE expired_checkouts_new RENAME TO expired_checkouts;
+
+CREATE INDEX idx_expired_checkouts_user ON expired_checkouts(user_id, returned_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE TABLE expired_checkouts_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    customer_email TEXT NOT NULL DEFAULT '',
+    token TEXT NOT NULL UNIQUE,
+    emailed_at DATETIME,
+    returned_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO expired_checkouts_old (id, user_id, customer_email, token, emailed_at, returned_at, created_at)
+SELECT id, user_id, customer_email, token, emailed_at, returned_at, created_at FROM expired_checkouts
+WHERE user_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_expired_checkouts_user;
+DROP TABLE expired_checkouts;
+ALTER TABLE expired_checkouts_old RENAME TO expired_checkouts;
+
+CREATE INDEX idx_expired_checkouts_user ON expired_checkouts(user_id, returned_at);
+
+-- +goose StatementEnd
@@ -63,6 +63,22 @@ GROUP BY ac.id
 ORDER BY ac.abandoned_at DESC
 LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(offset);
 
+-- name: GetCartTotalsForRecovery :one
+-- One shopper's cart counted the way the detector counts it: a signed-in shopper's
+-- lines, or a guest's by session
+SELECT
+    COUNT(DISTINCT ci.id) AS item_count,
+    CAST(COALESCE(SUM(p.price_cents * ci.quantity), 0) AS INTEGER) AS cart_value_cents
+FROM cart_items ci
+JOIN products p ON ci.product_id = p.id
+WHERE ci.user_id = sqlc.narg(user_id)
+   OR (ci.user_id IS NULL AND ci.session_id = sqlc.narg(session_id));
+
+-- name: SetAbandonedCartEmail :exec
+UPDATE abandoned_carts
+SET customer_email = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
 -- name: GetCartSnapshotsByAbandonedCartID :many
 SELECT * FROM cart_snapshots
 WHERE abandoned_cart_id = ?
@@ -322,3 +338,114 @@ FROM abandoned_carts ac
 LEFT JOIN promotion_codes pc ON ac.promotion_code_id = pc.id
 LEFT JOIN promotion_campaigns pcamp ON pc.campaign_id = pcamp.id
 WHERE ac.id = ?;
+
+-- Recovery Sequences
+
+-- name: ListRecoverySequenceSteps :many
+SELECT * FROM cart_recovery_sequence_steps
+ORDER BY variant ASC, step_number ASC;
+
+-- name: ListActiveRecoverySequenceSteps :many
+SELECT * FROM cart_recovery_sequence_steps
+WHERE is_active = TRUE
+ORDER BY variant ASC, step_number ASC;
+
+-- name: ListActiveRecoveryVariants :many
+SELECT DISTINCT variant FROM cart_recovery_sequence_steps
+WHERE is_active = TRUE
+ORDER BY variant ASC;
+
+-- name: GetRecoverySequenceStep :one
+SELECT * FROM cart_recovery_sequence_steps
+WHERE id = ?;
+
+-- name: CreateRecoverySequenceStep :one
+INSERT INTO cart_recovery_sequence_steps (
+    id, variant, step_number, delay_minutes, template_key, email_subject,
+    include_promo, promo_discount_percent, promo_valid_days, is_active
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ToggleRecoverySequenceStep :exec
+UPDATE cart_recovery_sequence_steps
+SET is_active = NOT is_active, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteRecoverySequenceStep :exec
+DELETE FROM cart_recovery_sequence_steps
+WHERE id = ?;
+
+-- name: UpdateAbandonedCartVariant :exec
+UPDATE abandoned_carts
+SET ab_variant = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetCartsDueForSequenceStep :many
+SELECT ac.*
+FROM abandoned_carts ac
+LEFT JOIN cart_recovery_attempts cra ON ac.id = cra.abandoned_cart_id AND cra.sequence_step_id = sqlc.arg(sequence_step_id)
+WHERE
+    ac.status IN ('active', 'contacted')
+    AND ac.ab_variant = sqlc.arg(variant)
+    AND ac.customer_email IS NOT NULL
+    AND ac.abandoned_at <= datetime('now', sqlc.arg(time_offset))
+    AND ac.abandoned_at >= datetime('now', sqlc.arg(min_time_offset))
+    AND cra.id IS NULL
+ORDER BY ac.abandoned_at ASC;
+
+-- name: CreateSequenceRecoveryAttempt :one
+INSERT INTO cart_recovery_attempts (
+    id, abandoned_cart_id, attempt_type, sent_at, email_subject,
+    tracking_token, status, sequence_step_id, variant, promotion_code_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- Conversion Attribution
+
+-- name: FindAbandonedCartForOrder :one
+SELECT * FROM abandoned_carts
+WHERE
+    recovered_order_id IS NULL
+    AND status IN ('active', 'contacted', 'recovered')
+    AND abandoned_at >= datetime('now', '-30 days')
+    AND (
+        (sqlc.narg(session_id) IS NOT NULL AND session_id = sqlc.narg(session_id))
+        OR (sqlc.narg(user_id) IS NOT NULL AND user_id = sqlc.narg(user_id))
+        OR (sqlc.narg(customer_email) IS NOT NULL AND customer_email = sqlc.narg(customer_email))
+    )
+ORDER BY abandoned_at DESC
+LIMIT 1;
+
+-- name: GetRecoveryAttemptByPromotionCode :one
+SELECT * FROM cart_recovery_attempts
+WHERE abandoned_cart_id = ? AND promotion_code_id = ?
+LIMIT 1;
+
+-- name: GetLatestSentRecoveryAttempt :one
+SELECT * FROM cart_recovery_attempts
+WHERE abandoned_cart_id = ? AND status NOT IN ('failed', 'opted_out')
+ORDER BY sent_at DESC
+LIMIT 1;
+
+-- name: AttributeAbandonedCartToOrder :exec
+UPDATE abandoned_carts
+SET
+    status = 'recovered',
+    recovered_at = COALESCE(recovered_at, CURRENT_TIMESTAMP),
+    recovery_method = ?,
+    recovered_order_id = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetRecoveryVariantPerformance :many
+SELECT
+    ac.ab_variant,
+    COUNT(*) as cart_count,
+    COUNT(CASE WHEN ac.last_contacted_at IS NOT NULL THEN 1 END) as contacted_count,
+    COUNT(CASE WHEN ac.recovered_order_id IS NOT NULL THEN 1 END) as converted_count,
+    CAST(COALESCE(SUM(o.total_cents), 0) AS INTEGER) as converted_revenue_cents
+FROM abandoned_carts ac
+LEFT JOIN orders o ON o.id = ac.recovered_order_id
+WHERE ac.abandoned_at >= datetime('now', sqlc.arg(period_offset))
+GROUP BY ac.ab_variant
+ORDER BY ac.ab_variant;
@@ -0,0 +1,36 @@
+-- Admin Search Queries
+-- Each takes a LIKE pattern with %, _ and \ escaped by the caller
+
+-- name: AdminSearchOrders :many
+SELECT * FROM orders
+WHERE customer_name LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR customer_email LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR id LIKE sqlc.arg(pattern) ESCAPE '\'
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: AdminSearchProducts :many
+SELECT * FROM products
+WHERE deleted_at IS NULL
+  AND (name LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR COALESCE(sku, '') LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR id IN (SELECT product_id FROM product_skus WHERE product_skus.sku LIKE sqlc.arg(pattern) ESCAPE '\'))
+ORDER BY is_active DESC, name
+LIMIT sqlc.arg(limit_count);
+
+-- name: AdminSearchUsers :many
+SELECT * FROM users
+WHERE id != ''
+  AND (email LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR full_name LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR COALESCE(username, '') LIKE sqlc.arg(pattern) ESCAPE '\')
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: AdminSearchContactRequests :many
+SELECT * FROM contact_requests
+WHERE subject LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR (first_name || ' ' || last_name) LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR COALESCE(email, '') LIKE sqlc.arg(pattern) ESCAPE '\'
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit_count);
@@ -5,9 +5,10 @@ INSERT INTO api_keys (
     key_hash,
     key_prefix,
     permissions,
+    rate_limit_per_minute,
     is_active
 )
-VALUES (?, ?, ?, ?, ?, 1)
+VALUES (?, ?, ?, ?, ?, ?, 1)
 RETURNING *;
 
 -- name: GetAPIKey :one
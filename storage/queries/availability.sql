@@ -0,0 +1,18 @@
+-- Queries backing the live stock shown on product and cart pages
+
+-- name: ListProductSkuStock :many
+SELECT id, sku, stock_quantity, is_active
+FROM product_skus
+WHERE product_id = ?
+ORDER BY sku;
+
+-- name: ListCartSkuQuantities :many
+-- Units of each of a product's variants already in a cart, across personalizations
+SELECT
+    CAST(product_sku_id AS TEXT) AS product_sku_id,
+    CAST(SUM(quantity) AS INTEGER) AS quantity
+FROM cart_items
+WHERE (session_id = ? OR user_id = ?)
+AND product_id = ?
+AND product_sku_id IS NOT NULL
+GROUP BY product_sku_id;
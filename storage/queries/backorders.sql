@@ -0,0 +1,47 @@
+-- name: GetOpenBackorderQuantity :one
+-- Units of a product still waiting on production across orders that haven't shipped
+SELECT CAST(COALESCE(SUM(oi.backordered_quantity), 0) AS INTEGER) as backordered_quantity
+FROM order_items oi
+JOIN orders o ON oi.order_id = o.id
+WHERE oi.product_id = ?
+  AND oi.backordered_quantity > 0
+  AND o.status NOT IN ('shipped', 'delivered', 'cancelled', 'refunded');
+
+-- name: ListOpenBackorders :many
+SELECT
+    oi.id,
+    oi.order_id,
+    oi.product_id,
+    oi.product_name,
+    oi.product_sku,
+    oi.quantity,
+    oi.backordered_quantity,
+    COALESCE(oi.estimated_ship_date, '') as estimated_ship_date,
+    o.customer_name,
+    o.customer_email,
+    o.status as order_status,
+    o.created_at as ordered_at,
+    CAST(COALESCE(ps.stock_quantity, p.stock_quantity, 0) AS INTEGER) as current_stock
+FROM order_items oi
+JOIN orders o ON oi.order_id = o.id
+LEFT JOIN products p ON oi.product_id = p.id
+LEFT JOIN product_skus ps ON oi.product_sku_id = ps.id
+WHERE oi.backordered_quantity > 0
+  AND o.status NOT IN ('shipped', 'delivered', 'cancelled', 'refunded')
+ORDER BY COALESCE(oi.estimated_ship_date, '9999-12-31') ASC, o.created_at ASC;
+
+-- name: GetBackorderSummary :many
+-- Open backordered units grouped by product for the report header
+SELECT
+    oi.product_id,
+    oi.product_name,
+    CAST(SUM(oi.backordered_quantity) AS INTEGER) as backordered_quantity,
+    COUNT(DISTINCT oi.order_id) as order_count,
+    COALESCE(p.backorder_ship_date, '') as backorder_ship_date
+FROM order_items oi
+JOIN orders o ON oi.order_id = o.id
+LEFT JOIN products p ON oi.product_id = p.id
+WHERE oi.backordered_quantity > 0
+  AND o.status NOT IN ('shipped', 'delivered', 'cancelled', 'refunded')
+GROUP BY oi.product_id, oi.product_name, p.backorder_ship_date
+ORDER BY backordered_quantity DESC;
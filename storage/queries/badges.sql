@@ -0,0 +1,85 @@
+-- name: ListProductBadges :many
+SELECT
+    b.*,
+    (SELECT COUNT(*) FROM product_badge_assignments a WHERE a.badge_id = b.id) as product_count
+FROM product_badges b
+ORDER BY b.priority DESC, b.label ASC;
+
+-- name: ListActiveProductBadges :many
+-- Start and end dates are checked by the caller so every page agrees on "now"
+SELECT * FROM product_badges
+WHERE is_active = TRUE
+ORDER BY priority DESC, label ASC;
+
+-- name: GetProductBadge :one
+SELECT * FROM product_badges WHERE id = ?;
+
+-- name: CreateProductBadge :one
+INSERT INTO product_badges (
+    id, label, color, icon, rule, threshold, starts_at, ends_at, priority, is_active
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: UpdateProductBadge :one
+UPDATE product_badges SET
+    label = ?,
+    color = ?,
+    icon = ?,
+    rule = ?,
+    threshold = ?,
+    starts_at = ?,
+    ends_at = ?,
+    priority = ?,
+    is_active = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteProductBadge :exec
+DELETE FROM product_badges WHERE id = ?;
+
+-- name: ListProductBadgeProducts :many
+SELECT p.id, p.name, p.slug
+FROM product_badge_assignments a
+JOIN products p ON p.id = a.product_id
+WHERE a.badge_id = ? AND p.deleted_at IS NULL
+ORDER BY p.name ASC;
+
+-- name: AddProductBadgeAssignment :exec
+INSERT OR IGNORE INTO product_badge_assignments (badge_id, product_id)
+VALUES (?, ?);
+
+-- name: RemoveProductBadgeAssignment :exec
+DELETE FROM product_badge_assignments
+WHERE badge_id = ? AND product_id = ?;
+
+-- name: ListProductBadgeAssignments :many
+SELECT badge_id, product_id
+FROM product_badge_assignments
+WHERE product_id IN (sqlc.slice('product_ids'));
+
+-- name: ListBadgeStockLevels :many
+-- Units available per product: simple product stock plus in-stock active SKUs
+SELECT
+    p.id as product_id,
+    CAST(COALESCE(p.stock_quantity, 0) + COALESCE((
+        SELECT SUM(ps.stock_quantity) FROM product_skus ps
+        WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+    ), 0) AS INTEGER) AS available_stock
+FROM products p
+WHERE p.id IN (sqlc.slice('product_ids'));
+
+-- name: ListBestSellerProductIDs :many
+-- Top products by units sold over the last 30 days
+SELECT
+    oi.product_id,
+    CAST(SUM(oi.quantity) AS INTEGER) as units_sold
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+WHERE o.status NOT IN ('cancelled', 'refunded')
+    AND o.created_at >= datetime('now', '-30 days')
+GROUP BY oi.product_id
+ORDER BY units_sold DESC, oi.product_id ASC
+LIMIT ?;
@@ -0,0 +1,44 @@
+-- name: GetBlogPost :one
+SELECT * FROM blog_posts WHERE id = ?;
+
+-- name: ListBlogPosts :many
+SELECT * FROM blog_posts
+ORDER BY COALESCE(published_at, created_at) DESC;
+
+-- name: GetPublishedBlogPost :one
+SELECT * FROM blog_posts
+WHERE id = sqlc.arg(id)
+  AND status = 'published'
+  AND published_at <= sqlc.arg(now);
+
+-- name: GetPublishedBlogPostBySlug :one
+SELECT * FROM blog_posts
+WHERE slug = sqlc.arg(slug)
+  AND status = 'published'
+  AND published_at <= sqlc.arg(now);
+
+-- name: ListPublishedBlogPosts :many
+SELECT * FROM blog_posts
+WHERE status = 'published' AND published_at <= sqlc.arg(now)
+ORDER BY published_at DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountPublishedBlogPosts :one
+SELECT COUNT(*) FROM blog_posts
+WHERE status = 'published' AND published_at <= sqlc.arg(now);
+
+-- name: CreateBlogPost :one
+INSERT INTO blog_posts (
+    id, title, slug, summary, body_markdown, author_name, status, published_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateBlogPost :one
+UPDATE blog_posts
+SET title = ?, slug = ?, summary = ?, body_markdown = ?, author_name = ?,
+    status = ?, published_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteBlogPost :exec
+DELETE FROM blog_posts WHERE id = ?;
@@ -0,0 +1,90 @@
+-- name: ListProductBundles :many
+SELECT
+    b.*,
+    CAST(COUNT(bi.id) AS INTEGER) as component_count
+FROM product_bundles b
+LEFT JOIN product_bundle_items bi ON bi.bundle_id = b.id
+GROUP BY b.id
+ORDER BY b.display_order ASC, b.name ASC;
+
+-- name: ListActiveProductBundles :many
+SELECT * FROM product_bundles
+WHERE is_active = TRUE
+ORDER BY display_order ASC, name ASC;
+
+-- name: GetProductBundle :one
+SELECT * FROM product_bundles WHERE id = ?;
+
+-- name: GetActiveProductBundleBySlug :one
+SELECT * FROM product_bundles WHERE slug = ? AND is_active = TRUE;
+
+-- name: CreateProductBundle :one
+INSERT INTO product_bundles (id, name, slug, description, price_cents, image_url, is_active, display_order)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateProductBundle :one
+UPDATE product_bundles
+SET name = ?,
+    slug = ?,
+    description = ?,
+    price_cents = ?,
+    image_url = ?,
+    is_active = ?,
+    display_order = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteProductBundle :exec
+DELETE FROM product_bundles WHERE id = ?;
+
+-- name: ListProductBundleItems :many
+-- Components with their current list price and the stock that backs them
+SELECT
+    bi.id,
+    bi.bundle_id,
+    bi.product_id,
+    bi.product_sku_id,
+    bi.quantity,
+    p.name as product_name,
+    p.slug as product_slug,
+    p.is_active as product_is_active,
+    ps.is_active as sku_is_active,
+    CAST(p.price_cents + COALESCE(ps.price_adjustment_cents, 0) AS INTEGER) as unit_price_cents,
+    CAST(COALESCE(ps.stock_quantity, p.stock_quantity, 0) AS INTEGER) as stock_quantity,
+    COALESCE(ps.sku, '') as variant_sku,
+    COALESCE(pst.name || ' - ' || sz.display_name, '') as variant_name,
+    COALESCE(pi.image_url, '') as image_url
+FROM product_bundle_items bi
+JOIN products p ON p.id = bi.product_id
+LEFT JOIN product_skus ps ON ps.id = bi.product_sku_id
+LEFT JOIN product_styles pst ON pst.id = ps.product_style_id
+LEFT JOIN sizes sz ON sz.id = ps.size_id
+LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = TRUE
+WHERE bi.bundle_id = ?
+ORDER BY bi.created_at ASC, p.name ASC;
+
+-- name: AddProductBundleItem :one
+INSERT INTO product_bundle_items (id, bundle_id, product_id, product_sku_id, quantity)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteProductBundleItem :exec
+DELETE FROM product_bundle_items
+WHERE id = ? AND bundle_id = ?;
+
+-- name: ListBundleSkuOptions :many
+-- Active SKUs offered as bundle components, labelled for the admin picker
+SELECT
+    ps.id,
+    ps.product_id,
+    ps.sku,
+    p.name as product_name,
+    COALESCE(pst.name || ' - ' || sz.display_name, '') as variant_name
+FROM product_skus ps
+JOIN products p ON p.id = ps.product_id
+LEFT JOIN product_styles pst ON pst.id = ps.product_style_id
+LEFT JOIN sizes sz ON sz.id = ps.size_id
+WHERE COALESCE(ps.is_active, TRUE) = TRUE
+ORDER BY p.name ASC, ps.sku ASC;
@@ -0,0 +1,33 @@
+-- Carbon offsets customers add at checkout, and the monthly report of what was collected
+
+-- name: SetOrderCarbonOffset :exec
+UPDATE orders
+SET carbon_offset_cents = sqlc.arg(carbon_offset_cents),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: GetCarbonOffsetsByMonth :many
+SELECT
+    CAST(substr(created_at, 1, 7) AS TEXT) as month,
+    COUNT(*) as order_count,
+    CAST(COALESCE(SUM(CASE WHEN carbon_offset_cents > 0 THEN 1 ELSE 0 END), 0) AS INTEGER) as offset_orders,
+    CAST(COALESCE(SUM(carbon_offset_cents), 0) AS INTEGER) as offset_cents
+FROM orders
+WHERE created_at >= sqlc.arg(start_date)
+    AND created_at < sqlc.arg(end_date)
+    AND status NOT IN ('cancelled', 'refunded')
+GROUP BY month
+ORDER BY month ASC;
+
+-- name: ListCarbonOffsetOrders :many
+SELECT
+    id,
+    customer_name,
+    carbon_offset_cents,
+    created_at
+FROM orders
+WHERE carbon_offset_cents > 0
+    AND created_at >= sqlc.arg(start_date)
+    AND created_at < sqlc.arg(end_date)
+    AND status NOT IN ('cancelled', 'refunded')
+ORDER BY created_at ASC;
@@ -1,21 +1,38 @@
 -- name: AddToCart :exec
-INSERT INTO cart_items (id, session_id, user_id, product_id, product_sku_id, quantity)
-VALUES (?, ?, ?, ?, ?, ?);
+INSERT INTO cart_items (id, session_id, user_id, product_id, product_sku_id, quantity, personalization, quoted_price_cents)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetExistingCartItem :one
 SELECT id, quantity FROM cart_items
 WHERE (session_id = ? OR user_id = ?)
 AND product_id = ?
 AND COALESCE(product_sku_id, '') = COALESCE(?, '')
+AND COALESCE(personalization, '') = COALESCE(?, '')
+AND bundle_group_id IS NULL
 LIMIT 1;
 
+-- name: GetCartItem :one
+SELECT * FROM cart_items WHERE id = ?;
+
 -- name: UpdateCartItemQuantity :exec
 UPDATE cart_items SET quantity = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?;
 
+-- name: QuoteCartItemPrice :exec
+-- Accepts a line's current price after the shopper has seen it change
+UPDATE cart_items SET quoted_price_cents = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
 -- name: RemoveCartItem :exec
 DELETE FROM cart_items WHERE id = ?;
 
+-- name: AddBundleCartItem :exec
+INSERT INTO cart_items (id, session_id, user_id, product_id, product_sku_id, quantity, bundle_id, bundle_group_id, bundle_unit_price_cents)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: RemoveCartBundleGroup :exec
+DELETE FROM cart_items WHERE bundle_group_id = ?;
+
 -- name: GetCartBySession :many
 SELECT
     ci.id,
@@ -23,7 +40,8 @@ SELECT
     ci.product_id,
     ci.product_sku_id,
     p.name,
-    (p.price_cents + COALESCE(ps.price_adjustment_cents, 0)) AS price_cents,
+    COALESCE(ci.bundle_unit_price_cents, p.price_cents + COALESCE(ps.price_adjustment_cents, 0)) AS price_cents,
+    COALESCE(ps.price_adjustment_cents, 0) AS price_adjustment_cents,
     COALESCE(
         CASE WHEN psi.image_url IS NOT NULL THEN 'styles/' || psi.image_url END,
         pi.image_url,
@@ -32,16 +50,30 @@ SELECT
     COALESCE(ps.sku, '') as variant_sku,
     COALESCE(pst.name || ' - ' || sz.display_name, '') as variant_name,
     COALESCE(ps.stock_quantity, p.stock_quantity, 0) as stock_quantity,
-    COALESCE(c.name, '') as category_name
+    COALESCE(c.name, '') as category_name,
+    COALESCE(p.category_id, '') as category_id,
+    COALESCE(p.backorder_ship_date, '') as backorder_ship_date,
+    p.is_preorder,
+    COALESCE(p.preorder_ship_date, '') as preorder_ship_date,
+    p.product_type,
+    COALESCE(ci.bundle_id, '') as bundle_id,
+    COALESCE(ci.bundle_group_id, '') as bundle_group_id,
+    COALESCE(ci.bundle_unit_price_cents, 0) as bundle_unit_price_cents,
+    COALESCE(pb.name, '') as bundle_name,
+    COALESCE(ci.personalization, '') as personalization,
+    p.max_per_order,
+    ci.quoted_price_cents,
+    p.is_gift_card
 FROM cart_items ci
 JOIN products p ON ci.product_id = p.id
+LEFT JOIN product_bundles pb ON ci.bundle_id = pb.id
 LEFT JOIN categories c ON p.category_id = c.id
 LEFT JOIN product_skus ps ON ci.product_sku_id = ps.id
 LEFT JOIN product_styles pst ON ps.product_style_id = pst.id
 LEFT JOIN sizes sz ON ps.size_id = sz.id
 LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
 LEFT JOIN product_style_images psi ON psi.product_style_id = pst.id AND psi.is_primary = TRUE
-WHERE ci.session_id = ?
+WHERE ci.session_id = ? AND p.deleted_at IS NULL
 ORDER BY ci.created_at DESC;
 
 -- name: GetCartByUser :many
@@ -51,7 +83,8 @@ SELECT
     ci.product_id,
     ci.product_sku_id,
     p.name,
-    (p.price_cents + COALESCE(ps.price_adjustment_cents, 0)) AS price_cents,
+    COALESCE(ci.bundle_unit_price_cents, p.price_cents + COALESCE(ps.price_adjustment_cents, 0)) AS price_cents,
+    COALESCE(ps.price_adjustment_cents, 0) AS price_adjustment_cents,
     COALESCE(
         CASE WHEN psi.image_url IS NOT NULL THEN 'styles/' || psi.image_url END,
         pi.image_url,
@@ -60,25 +93,32 @@ SELECT
     COALESCE(ps.sku, '') as variant_sku,
     COALESCE(pst.name || ' - ' || sz.display_name, '') as variant_name,
     COALESCE(ps.stock_quantity, p.stock_quantity, 0) as stock_quantity,
-    COALESCE(c.name, '') as category_name
+    COALESCE(c.name, '') as category_name,
+    COALESCE(p.category_id, '') as category_id,
+    COALESCE(p.backorder_ship_date, '') as backorder_ship_date,
+    p.is_preorder,
+    COALESCE(p.preorder_ship_date, '') as preorder_ship_date,
+    p.product_type,
+    COALESCE(ci.bundle_id, '') as bundle_id,
+    COALESCE(ci.bundle_group_id, '') as bundle_group_id,
+    COALESCE(ci.bundle_unit_price_cents, 0) as bundle_unit_price_cents,
+    COALESCE(pb.name, '') as bundle_name,
+    COALESCE(ci.personalization, '') as personalization,
+    p.max_per_order,
+    ci.quoted_price_cents,
+    p.is_gift_card
 FROM cart_items ci
 JOIN products p ON ci.product_id = p.id
+LEFT JOIN product_bundles pb ON ci.bundle_id = pb.id
 LEFT JOIN categories c ON p.category_id = c.id
 LEFT JOIN product_skus ps ON ci.product_sku_id = ps.id
 LEFT JOIN product_styles pst ON ps.product_style_id = pst.id
 LEFT JOIN sizes sz ON ps.size_id = sz.id
 LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
 LEFT JOIN product_style_images psi ON psi.product_style_id = pst.id AND psi.is_primary = TRUE
-WHERE ci.user_id = sqlc.arg(user_id)
+WHERE ci.user_id = sqlc.arg(user_id) AND p.deleted_at IS NULL
 ORDER BY ci.created_at DESC;
 
--- name: GetCartTotal :one
-SELECT SUM((p.price_cents + COALESCE(ps.price_adjustment_cents, 0)) * ci.quantity) as total
-FROM cart_items ci
-JOIN products p ON ci.product_id = p.id
-LEFT JOIN product_skus ps ON ci.product_sku_id = ps.id
-WHERE (ci.session_id = ? OR ci.user_id = ?);
-
 -- name: ClearCart :exec
 DELETE FROM cart_items WHERE session_id = ? OR user_id = ?;
 
@@ -86,3 +126,68 @@ DELETE FROM cart_items WHERE session_id = ? OR user_id = ?;
 UPDATE cart_items
 SET user_id = sqlc.arg(user_id), session_id = NULL, updated_at = CURRENT_TIMESTAMP
 WHERE session_id = sqlc.arg(session_id) AND user_id IS NULL;
+
+-- name: TransferGuestCartToSession :exec
+-- Moves a guest cart to another browser session, for a guest following an emailed
+-- link on a different device
+UPDATE cart_items
+SET session_id = sqlc.arg(to_session_id), updated_at = CURRENT_TIMESTAMP
+WHERE session_id = sqlc.arg(from_session_id) AND user_id IS NULL;
+
+-- name: ListGuestCartItems :many
+-- Lines added before sign-in, still keyed only by the browser session
+SELECT * FROM cart_items
+WHERE session_id = ? AND user_id IS NULL
+ORDER BY created_at ASC;
+
+-- name: CountGuestCartRows :one
+-- Anything a session still holds from before sign-in: cart lines, saved items and
+-- shared carts
+SELECT CAST(
+    (SELECT COUNT(*) FROM cart_items ci WHERE ci.session_id = sqlc.arg(session_id) AND ci.user_id IS NULL)
+    + (SELECT COUNT(*) FROM saved_cart_items sci WHERE sci.session_id = sqlc.arg(session_id) AND sci.user_id IS NULL)
+    + (SELECT COUNT(*) FROM shared_carts sc WHERE sc.session_id = sqlc.arg(session_id) AND sc.user_id IS NULL)
+AS INTEGER) AS guest_rows;
+
+-- Cart expiry. A guest cart expires when none of its lines have changed for the
+-- cart TTL. Carts on an account never expire.
+
+-- name: ExpireGuestCartRecovery :execrows
+-- Stops recovery emails for guest carts about to be deleted. Their abandoned cart
+-- records and snapshots are kept as the record of what was in them.
+UPDATE abandoned_carts
+SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+WHERE status IN ('active', 'contacted')
+  AND user_id IS NULL
+  AND session_id IN (
+    SELECT ci.session_id FROM cart_items ci
+    WHERE ci.user_id IS NULL AND ci.session_id IS NOT NULL
+    GROUP BY ci.session_id
+    HAVING MAX(COALESCE(ci.updated_at, ci.created_at)) < datetime('now', sqlc.arg(max_age))
+  );
+
+-- name: DeleteExpiredGuestCarts :execrows
+DELETE FROM cart_items
+WHERE user_id IS NULL
+  AND session_id IN (
+    SELECT ci.session_id FROM cart_items ci
+    WHERE ci.user_id IS NULL AND ci.session_id IS NOT NULL
+    GROUP BY ci.session_id
+    HAVING MAX(COALESCE(ci.updated_at, ci.created_at)) < datetime('now', sqlc.arg(max_age))
+  );
+
+-- name: DeleteExpiredGuestSavedItems :execrows
+-- Guest lines saved for later go once the cart they were saved from has gone and
+-- they've been saved for the cart TTL
+DELETE FROM saved_cart_items
+WHERE user_id IS NULL
+  AND created_at < datetime('now', sqlc.arg(max_age))
+  AND NOT EXISTS (
+    SELECT 1 FROM cart_items ci
+    WHERE ci.session_id = saved_cart_items.session_id AND ci.user_id IS NULL
+  );
+
+-- name: DeleteStaleShippingSelections :execrows
+-- Shipping rates quoted for a session that hasn't come back within the cart TTL
+DELETE FROM session_shipping_selection
+WHERE COALESCE(updated_at, created_at) < datetime('now', sqlc.arg(max_age));
@@ -0,0 +1,45 @@
+-- name: ListCartPromotions :many
+SELECT
+    cp.*,
+    COALESCE(c.name, '') as category_name
+FROM cart_promotions cp
+LEFT JOIN categories c ON c.id = cp.category_id
+ORDER BY cp.is_active DESC, cp.created_at DESC;
+
+-- name: ListActiveCartPromotions :many
+-- Start and end dates are checked by the caller, as with badges
+SELECT * FROM cart_promotions
+WHERE is_active = TRUE
+ORDER BY created_at ASC;
+
+-- name: GetCartPromotion :one
+SELECT * FROM cart_promotions WHERE id = ?;
+
+-- name: CreateCartPromotion :one
+INSERT INTO cart_promotions (
+    id, name, kind, min_subtotal_cents, percent_off, category_id, buy_quantity, starts_at, ends_at, is_active
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: UpdateCartPromotion :one
+UPDATE cart_promotions SET
+    name = ?,
+    kind = ?,
+    min_subtotal_cents = ?,
+    percent_off = ?,
+    category_id = ?,
+    buy_quantity = ?,
+    starts_at = ?,
+    ends_at = ?,
+    is_active = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: SetCartPromotionActive :exec
+UPDATE cart_promotions SET is_active = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: DeleteCartPromotion :exec
+DELETE FROM cart_promotions WHERE id = ?;
@@ -14,17 +14,26 @@ SELECT * FROM categories WHERE parent_id IS NULL ORDER BY display_order ASC, nam
 SELECT * FROM categories WHERE parent_id = ? ORDER BY display_order ASC, name ASC;
 
 -- name: CreateCategory :one
-INSERT INTO categories (id, name, slug, description, parent_id, display_order)
-VALUES (?, ?, ?, ?, ?, ?)
+INSERT INTO categories (
+    id, name, slug, description, parent_id, display_order,
+    banner_alt_text, content_markdown, seo_title, seo_description
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: UpdateCategory :one
 UPDATE categories
 SET name = ?, slug = ?, description = ?, parent_id = ?, display_order = ?,
+    banner_alt_text = ?, content_markdown = ?, seo_title = ?, seo_description = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
+-- name: SetCategoryBannerImage :exec
+UPDATE categories
+SET banner_image = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
 -- name: DeleteCategory :exec
 DELETE FROM categories WHERE id = ?;
 
@@ -38,3 +47,121 @@ ON CONFLICT(name) DO UPDATE SET
     slug = excluded.slug,
     updated_at = CURRENT_TIMESTAMP
 RETURNING *;
+
+-- name: ListCategoriesWithProductCounts :many
+-- Counts roll up, so a parent category includes the products of its subcategories
+WITH RECURSIVE lineage(ancestor_id, id) AS (
+    SELECT id, id FROM categories
+    UNION
+    SELECT l.ancestor_id, c.id
+    FROM categories c
+    JOIN lineage l ON c.parent_id = l.id
+)
+SELECT
+    c.id,
+    c.name,
+    c.slug,
+    COUNT(p.id) as product_count
+FROM categories c
+JOIN lineage l ON l.ancestor_id = c.id
+LEFT JOIN products p ON p.category_id = l.id AND p.is_active = TRUE AND p.deleted_at IS NULL
+GROUP BY c.id, c.name, c.slug
+ORDER BY c.display_order ASC, c.name ASC;
+
+-- name: ListCategoryAncestors :many
+-- The category and its parents, top level first, for breadcrumbs. The depth cap
+-- stops a parent loop from recursing forever.
+WITH RECURSIVE ancestors(id, depth) AS (
+    SELECT id, 0 FROM categories WHERE id = sqlc.arg(id)
+    UNION ALL
+    SELECT c.parent_id, a.depth + 1
+    FROM ancestors a
+    JOIN categories c ON c.id = a.id
+    WHERE c.parent_id IS NOT NULL AND a.depth < 10
+)
+SELECT c.*
+FROM ancestors a
+JOIN categories c ON c.id = a.id
+ORDER BY a.depth DESC;
+
+-- name: ListCategoryFeaturedProducts :many
+-- The picks for the admin form, including inactive products
+SELECT f.*, p.name AS product_name, p.is_active AS product_is_active
+FROM category_featured_products f
+JOIN products p ON p.id = f.product_id
+WHERE f.category_id = ?
+ORDER BY f.display_order ASC, p.name ASC;
+
+-- name: ListFeaturedProductsForCategory :many
+SELECT p.*
+FROM category_featured_products f
+JOIN products p ON p.id = f.product_id
+WHERE f.category_id = ?
+  AND p.is_active = TRUE AND p.deleted_at IS NULL
+ORDER BY f.display_order ASC, p.name ASC
+LIMIT ?;
+
+-- name: UpsertCategoryFeaturedProduct :exec
+-- Picking a product that's already featured moves it to the new position
+INSERT INTO category_featured_products (category_id, product_id, display_order)
+VALUES (?, ?, ?)
+ON CONFLICT (category_id, product_id) DO UPDATE SET
+    display_order = excluded.display_order;
+
+-- name: DeleteCategoryFeaturedProduct :exec
+DELETE FROM category_featured_products
+WHERE category_id = ? AND product_id = ?;
+
+-- name: ListCategoryTree :many
+-- Every category with its place in the tree and two product counts: its own, and
+-- rolled up to include its subcategories. Archived products aren't counted, inactive
+-- ones are.
+WITH RECURSIVE lineage(ancestor_id, id) AS (
+    SELECT id, id FROM categories
+    UNION
+    SELECT l.ancestor_id, c.id
+    FROM categories c
+    JOIN lineage l ON c.parent_id = l.id
+)
+SELECT
+    c.id,
+    c.name,
+    c.slug,
+    c.parent_id,
+    c.display_order,
+    CAST(COUNT(CASE WHEN l.id = c.id THEN p.id END) AS INTEGER) AS product_count,
+    CAST(COUNT(p.id) AS INTEGER) AS total_product_count
+FROM categories c
+JOIN lineage l ON l.ancestor_id = c.id
+LEFT JOIN products p ON p.category_id = l.id AND p.deleted_at IS NULL
+GROUP BY c.id, c.name, c.slug, c.parent_id, c.display_order
+ORDER BY c.display_order ASC, c.name ASC;
+
+-- name: MoveCategory :execrows
+UPDATE categories
+SET parent_id = ?, display_order = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RecordCategorySlug :exec
+-- Keeps a slug the category has given up. A slug used before by another category
+-- now redirects to this one.
+INSERT INTO category_slug_history (slug, category_id)
+VALUES (?, ?)
+ON CONFLICT (slug) DO UPDATE SET
+    category_id = excluded.category_id,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteCategorySlugHistory :exec
+-- Drops a slug from the history once a category uses it again
+DELETE FROM category_slug_history WHERE slug = ?;
+
+-- name: GetCategoryByPreviousSlug :one
+SELECT c.*
+FROM category_slug_history h
+JOIN categories c ON c.id = h.category_id
+WHERE h.slug = ?;
+
+-- name: ListCategorySlugHistory :many
+SELECT * FROM category_slug_history
+WHERE category_id = ?
+ORDER BY created_at DESC, slug ASC;
@@ -0,0 +1,30 @@
+-- name: ListCategoryProductsForArranging :many
+-- Every product in the category, active or not, in storefront order
+SELECT
+    p.id,
+    p.name,
+    p.slug,
+    p.price_cents,
+    p.is_active,
+    CAST(COALESCE((
+        SELECT i.image_url FROM product_images i
+        WHERE i.product_id = p.id
+        ORDER BY i.is_primary DESC, i.display_order ASC
+        LIMIT 1
+    ), '') AS TEXT) as image_url,
+    cpp.position
+FROM products p
+LEFT JOIN category_product_positions cpp ON cpp.category_id = p.category_id AND cpp.product_id = p.id
+WHERE p.category_id = sqlc.arg(category_id) AND p.deleted_at IS NULL
+ORDER BY cpp.position IS NULL, cpp.position ASC, p.created_at DESC;
+
+-- name: ClearCategoryProductPositions :exec
+DELETE FROM category_product_positions WHERE category_id = ?;
+
+-- name: SetCategoryProductPosition :execrows
+-- Only places products that are in the category
+INSERT INTO category_product_positions (category_id, product_id, position)
+SELECT p.category_id, p.id, sqlc.arg(position)
+FROM products p
+WHERE p.id = sqlc.arg(product_id) AND p.category_id = sqlc.arg(category_id)
+ON CONFLICT(category_id, product_id) DO UPDATE SET position = excluded.position;
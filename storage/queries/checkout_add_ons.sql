@@ -0,0 +1,52 @@
+-- name: ListCheckoutAddOnRules :many
+SELECT
+    r.*,
+    COALESCE(c.name, '') as category_name,
+    p.name as product_name,
+    p.price_cents as product_price_cents
+FROM checkout_add_on_rules r
+JOIN products p ON p.id = r.product_id
+LEFT JOIN categories c ON c.id = r.category_id
+ORDER BY r.is_active DESC, r.priority DESC, r.created_at ASC;
+
+-- name: ListCheckoutAddOnOffers :many
+-- Every active rule whose product can go in the cart with one click: on sale, without
+-- variants or personalization to choose, and not a download. The caller matches rules
+-- to the cart's categories and drops what's already in it.
+SELECT
+    r.id as rule_id,
+    COALESCE(r.category_id, '') as category_id,
+    p.id as product_id,
+    p.name,
+    p.slug,
+    COALESCE(p.short_description, '') as short_description,
+    p.price_cents,
+    COALESCE(pi.image_url, '') as image_url
+FROM checkout_add_on_rules r
+JOIN products p ON p.id = r.product_id
+LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = TRUE
+WHERE r.is_active = TRUE
+  AND COALESCE(p.is_active, TRUE) = TRUE
+  AND p.deleted_at IS NULL
+  AND COALESCE(p.has_variants, FALSE) = FALSE
+  AND p.product_type != 'digital'
+  AND (p.allow_backorder = TRUE OR p.is_preorder = TRUE OR COALESCE(p.stock_quantity, 0) > 0)
+  AND NOT EXISTS (SELECT 1 FROM product_personalization_fields f WHERE f.product_id = p.id)
+ORDER BY r.priority DESC, r.created_at ASC;
+
+-- name: CreateCheckoutAddOnRule :exec
+INSERT INTO checkout_add_on_rules (id, category_id, product_id, priority, is_active)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: SetCheckoutAddOnRuleActive :exec
+UPDATE checkout_add_on_rules SET is_active = ? WHERE id = ?;
+
+-- name: DeleteCheckoutAddOnRule :exec
+DELETE FROM checkout_add_on_rules WHERE id = ?;
+
+-- name: RecordCheckoutAddOnsShown :exec
+UPDATE checkout_add_on_rules SET shown_count = shown_count + 1
+WHERE id IN (sqlc.slice('rule_ids'));
+
+-- name: RecordCheckoutAddOnAdded :exec
+UPDATE checkout_add_on_rules SET added_count = added_count + 1 WHERE id = ?;
@@ -0,0 +1,42 @@
+-- name: CreateCheckoutDraft :exec
+INSERT INTO checkout_drafts (
+    id, session_id, user_id, customer_email, shipping_address_json, lines_json,
+    metadata_json, amount_cents, tax_cents, discount_cents, tax_calculation_id,
+    stripe_customer_id, payment_intent_id, expires_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetCheckoutDraft :one
+SELECT * FROM checkout_drafts WHERE id = ?;
+
+-- name: SetCheckoutDraftEmail :execrows
+-- A guest's email, entered on the payment page before paying
+UPDATE checkout_drafts
+SET customer_email = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'open';
+
+-- name: MarkCheckoutDraftPaid :execrows
+UPDATE checkout_drafts
+SET status = 'paid', updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'open';
+
+-- name: SetCheckoutDraftTaxTransaction :exec
+UPDATE checkout_drafts
+SET tax_transaction_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListExpiredCheckoutDrafts :many
+-- Drafts still waiting on payment after their holds lapsed
+SELECT * FROM checkout_drafts
+WHERE status = 'open' AND expires_at < ?
+ORDER BY expires_at
+LIMIT 100;
+
+-- name: ExpireCheckoutDraft :execrows
+UPDATE checkout_drafts
+SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'open';
+
+-- name: SetUserStripeCustomerID :exec
+UPDATE users
+SET stripe_customer_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND stripe_customer_id = '';
@@ -50,7 +50,7 @@ SELECT
 FROM collection_items ci
 INNER JOIN products p ON ci.product_id = p.id
 LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
-WHERE ci.collection_id = ?
+WHERE ci.collection_id = ? AND p.deleted_at IS NULL
 ORDER BY ci.created_at DESC;
 
 -- name: AddToCollection :one
@@ -61,7 +61,8 @@ SELECT
     COUNT(CASE WHEN is_premium = TRUE THEN 1 END) as premium_products,
     COUNT(CASE WHEN stock_quantity IS NOT NULL AND stock_quantity <= 5 AND stock_quantity > 0 THEN 1 END) as low_stock_products,
     COUNT(CASE WHEN stock_quantity IS NOT NULL AND stock_quantity = 0 THEN 1 END) as out_of_stock_products
-FROM products;
+FROM products
+WHERE deleted_at IS NULL;
 
 -- name: GetDashboardLowStockProducts :many
 SELECT id, name, sku, stock_quantity, price_cents
@@ -70,6 +71,7 @@ WHERE stock_quantity IS NOT NULL
     AND stock_quantity <= 5
     AND stock_quantity > 0
     AND is_active = TRUE
+    AND deleted_at IS NULL
 ORDER BY stock_quantity ASC
 LIMIT 10;
 
@@ -78,7 +80,8 @@ SELECT
     COUNT(DISTINCT id) as total_customers,
     COUNT(DISTINCT CASE WHEN created_at >= datetime('now', '-7 days') THEN id END) as new_customers_week,
     COUNT(DISTINCT CASE WHEN created_at >= datetime('now', '-30 days') THEN id END) as new_customers_month
-FROM users;
+FROM users
+WHERE id != '';
 
 -- name: GetDashboardCustomerOrderStats :one
 SELECT
@@ -144,6 +147,33 @@ SELECT
     COUNT(CASE WHEN created_at >= datetime('now', '-7 days') THEN 1 END) as contacts_week
 FROM contact_requests;
 
+-- name: GetDashboardToday :one
+-- The numbers at the top of the dashboard, refreshed every minute: today's paid
+-- orders, what's waiting to ship, contact requests nobody has opened, carts abandoned
+-- in the last week that weren't recovered, and active products running low
+SELECT
+    (SELECT COUNT(*) FROM orders
+        WHERE DATE(substr(created_at, 1, 10)) = DATE('now')
+            AND status NOT IN ('cancelled', 'refunded')) as orders_today,
+    (SELECT CAST(COALESCE(SUM(total_cents), 0) AS INTEGER) FROM orders
+        WHERE DATE(substr(created_at, 1, 10)) = DATE('now')
+            AND status NOT IN ('cancelled', 'refunded')) as revenue_today_cents,
+    (SELECT COUNT(*) FROM orders
+        WHERE status IN ('received', 'in_production', 'ready_to_ship')) as unshipped_orders,
+    (SELECT COUNT(*) FROM contact_requests WHERE status = 'new') as new_contacts,
+    (SELECT COUNT(*) FROM abandoned_carts
+        WHERE COALESCE(status, '') != 'recovered'
+            AND abandoned_at >= datetime('now', '-7 days')) as abandoned_carts,
+    (SELECT CAST(COALESCE(SUM(cart_value_cents), 0) AS INTEGER) FROM abandoned_carts
+        WHERE COALESCE(status, '') != 'recovered'
+            AND abandoned_at >= datetime('now', '-7 days')) as abandoned_cart_value_cents,
+    (SELECT COUNT(*) FROM products
+        WHERE stock_quantity IS NOT NULL
+            AND stock_quantity <= 5
+            AND stock_quantity > 0
+            AND is_active = TRUE
+            AND deleted_at IS NULL) as low_stock_products;
+
 -- name: GetDashboardRecentOrders :many
 SELECT
     o.id,
@@ -190,4 +220,6 @@ LIMIT 10;
 -- name: GetSidebarBadgeCounts :one
 SELECT
     (SELECT COUNT(*) FROM contact_requests WHERE status = 'new') as new_contacts,
-    (SELECT COUNT(*) FROM quote_requests WHERE status = 'pending') as pending_quotes;
+    (SELECT COUNT(*) FROM quote_requests WHERE status = 'pending') as pending_quotes,
+    (SELECT COUNT(*) FROM product_questions WHERE status = 'pending') as pending_questions,
+    (SELECT COUNT(*) FROM returns WHERE status = 'requested') as requested_returns;
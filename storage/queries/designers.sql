@@ -0,0 +1,41 @@
+-- name: ListDesignersWithProfiles :many
+-- Every designer with products, for the admin list, whether or not they have a profile yet
+SELECT
+    p.designer_name,
+    COUNT(p.id) AS product_count,
+    COUNT(CASE WHEN p.is_active = TRUE THEN 1 END) AS active_product_count,
+    d.updated_at AS profile_updated_at
+FROM products p
+LEFT JOIN designer_profiles d ON d.designer_name = p.designer_name
+WHERE p.designer_name IS NOT NULL AND p.designer_name != '' AND p.deleted_at IS NULL
+GROUP BY p.designer_name
+ORDER BY p.designer_name;
+
+-- name: GetDesignerProfile :one
+SELECT * FROM designer_profiles WHERE designer_name = ?;
+
+-- name: UpsertDesignerProfile :exec
+INSERT INTO designer_profiles (designer_name, bio_markdown, website_url, license_note)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (designer_name) DO UPDATE SET
+    bio_markdown = excluded.bio_markdown,
+    website_url = excluded.website_url,
+    license_note = excluded.license_note,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListDesignerShopProducts :many
+-- A designer's storefront products, optionally only those from one source platform
+SELECT * FROM products
+WHERE designer_name = sqlc.arg(designer_name)
+  AND is_active = TRUE AND deleted_at IS NULL
+  AND (sqlc.narg(source_platform) IS NULL OR source_platform = sqlc.narg(source_platform))
+ORDER BY is_featured DESC, name ASC;
+
+-- name: ListDesignerPlatformCounts :many
+SELECT source_platform, COUNT(*) AS product_count
+FROM products
+WHERE designer_name = ?
+  AND is_active = TRUE AND deleted_at IS NULL
+  AND source_platform IS NOT NULL AND source_platform != ''
+GROUP BY source_platform
+ORDER BY source_platform;
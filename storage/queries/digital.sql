@@ -0,0 +1,58 @@
+-- name: ListProductFiles :many
+SELECT * FROM product_files
+WHERE product_id = ?
+ORDER BY created_at ASC;
+
+-- name: CreateProductFile :one
+INSERT INTO product_files (id, product_id, file_name, storage_path, content_type, size_bytes)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetProductFile :one
+SELECT * FROM product_files
+WHERE id = ? AND product_id = ?;
+
+-- name: DeleteProductFile :exec
+DELETE FROM product_files
+WHERE id = ? AND product_id = ?;
+
+-- name: CreateOrderDownload :exec
+INSERT INTO order_downloads (id, order_id, order_item_id, product_file_id, max_downloads)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: ListOrderDownloads :many
+SELECT
+    od.id,
+    od.max_downloads,
+    od.download_count,
+    od.last_downloaded_at,
+    pf.file_name,
+    pf.size_bytes,
+    oi.product_name
+FROM order_downloads od
+JOIN product_files pf ON pf.id = od.product_file_id
+JOIN order_items oi ON oi.id = od.order_item_id
+WHERE od.order_id = ?
+ORDER BY oi.product_name ASC, pf.file_name ASC;
+
+-- name: GetOrderDownload :one
+SELECT
+    od.id,
+    od.order_id,
+    od.max_downloads,
+    od.download_count,
+    pf.file_name,
+    pf.storage_path,
+    pf.content_type
+FROM order_downloads od
+JOIN product_files pf ON pf.id = od.product_file_id
+WHERE od.id = ?;
+
+-- name: RecordOrderDownload :execrows
+-- Counts a download only while the purchase is under its limit, so concurrent
+-- requests can't exceed it
+UPDATE order_downloads
+SET download_count = download_count + 1,
+    last_downloaded_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND (max_downloads IS NULL OR download_count < max_downloads);
@@ -0,0 +1,15 @@
+-- name: GetCartProductQuantity :one
+-- Units of a product already in a cart, across every line (styles, personalization, bundles)
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) AS quantity
+FROM cart_items
+WHERE (session_id = ? OR user_id = ?)
+AND product_id = ?;
+
+-- name: GetCustomerPurchasedQuantity :one
+-- Units of a product a customer has bought, matched by account or email
+SELECT CAST(COALESCE(SUM(oi.quantity), 0) AS INTEGER) AS quantity
+FROM order_items oi
+JOIN orders o ON oi.order_id = o.id
+WHERE oi.product_id = sqlc.arg(product_id)
+AND o.status NOT IN ('cancelled', 'refunded')
+AND (o.user_id = sqlc.arg(user_id) OR o.customer_email = sqlc.arg(customer_email) COLLATE NOCASE);
@@ -109,3 +109,11 @@ UPDATE email_history
 SET user_id = ?
 WHERE recipient_email = ?
   AND user_id IS NULL;
+
+-- name: ListUserEmailHistory :many
+-- Everything sent to a user: linked to their account, or sent to their address
+-- before they had one
+SELECT * FROM email_history
+WHERE user_id = ? OR recipient_email = ? COLLATE NOCASE
+ORDER BY sent_at DESC
+LIMIT ?;
@@ -31,15 +31,20 @@ SELECT * FROM email_preferences
 WHERE unsubscribe_token = ?;
 
 -- name: UpdateEmailPreferences :exec
+-- The service-email preferences are left alone when not given, so callers that
+-- only manage marketing opt-ins don't reset them
 UPDATE email_preferences
 SET
-    transactional = ?,
-    abandoned_cart = ?,
-    promotional = ?,
-    newsletter = ?,
-    product_updates = ?,
+    transactional = sqlc.arg(transactional),
+    abandoned_cart = sqlc.arg(abandoned_cart),
+    promotional = sqlc.arg(promotional),
+    newsletter = sqlc.arg(newsletter),
+    product_updates = sqlc.arg(product_updates),
+    order_updates = COALESCE(CAST(sqlc.narg(order_updates) AS INTEGER), order_updates),
+    shipping_updates = COALESCE(CAST(sqlc.narg(shipping_updates) AS INTEGER), shipping_updates),
+    back_in_stock = COALESCE(CAST(sqlc.narg(back_in_stock) AS INTEGER), back_in_stock),
     updated_at = CURRENT_TIMESTAMP
-WHERE id = ?;
+WHERE id = sqlc.arg(id);
 
 -- name: UnsubscribeFromAllMarketing :exec
 UPDATE email_preferences
@@ -0,0 +1,77 @@
+-- name: ListEventTicketTypes :many
+SELECT * FROM event_ticket_types
+WHERE event_id = ?
+ORDER BY display_order ASC, price_cents ASC, name ASC;
+
+-- name: GetEventTicketType :one
+SELECT * FROM event_ticket_types
+WHERE id = ? AND event_id = ?;
+
+-- name: CreateEventTicketType :one
+INSERT INTO event_ticket_types (id, event_id, name, description, price_cents, display_order)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteEventTicketType :exec
+DELETE FROM event_ticket_types
+WHERE id = ? AND event_id = ?;
+
+-- name: CountEventSeatsTaken :one
+-- Pending bookings hold their seats while the customer is paying
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) as seats
+FROM event_registrations
+WHERE event_id = sqlc.arg(event_id)
+  AND (status = 'confirmed'
+    OR (status = 'pending' AND created_at > datetime('now', sqlc.arg(pending_hold))));
+
+-- name: CreateEventRegistration :one
+INSERT INTO event_registrations (
+    id, event_id, ticket_type_id, ticket_name, user_id, name, email, quantity, amount_cents, status
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetEventRegistration :one
+SELECT * FROM event_registrations WHERE id = ?;
+
+-- name: SetEventRegistrationStripeSession :exec
+UPDATE event_registrations
+SET stripe_session_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateEventRegistrationStatus :execrows
+-- Only moves a booking that's still in from_status, so repeated webhooks and
+-- double-clicks are no-ops
+UPDATE event_registrations
+SET status = sqlc.arg(status), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status);
+
+-- name: ListEventRegistrations :many
+SELECT * FROM event_registrations
+WHERE event_id = ?
+ORDER BY
+    CASE status WHEN 'confirmed' THEN 0 WHEN 'waitlisted' THEN 1 WHEN 'pending' THEN 2 ELSE 3 END,
+    created_at ASC;
+
+-- name: ToggleEventRegistrationCheckIn :one
+UPDATE event_registrations
+SET checked_in_at = CASE WHEN checked_in_at IS NULL THEN CURRENT_TIMESTAMP ELSE NULL END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND event_id = ?
+RETURNING *;
+
+-- name: ListEventRegistrationsDueForReminder :many
+-- Event times are the organiser's wall-clock, so the window is too
+SELECT r.*
+FROM event_registrations r
+JOIN events e ON e.id = r.event_id
+WHERE r.status = 'confirmed'
+  AND r.reminder_sent_at IS NULL
+  AND e.is_active = TRUE
+  AND e.start_date > sqlc.arg(window_start)
+  AND e.start_date <= sqlc.arg(window_end)
+ORDER BY e.start_date ASC;
+
+-- name: MarkEventRegistrationReminded :exec
+UPDATE event_registrations
+SET reminder_sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
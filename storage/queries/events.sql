@@ -1,6 +1,9 @@
 -- name: GetEvent :one
 SELECT * FROM events WHERE id = ?;
 
+-- name: GetActiveEventBySlug :one
+SELECT * FROM events WHERE slug = ? AND is_active = TRUE;
+
 -- name: ListEvents :many
 SELECT * FROM events
 ORDER BY start_date DESC;
@@ -15,6 +18,12 @@ SELECT * FROM events
 WHERE is_active = TRUE AND start_date >= DATE('now')
 ORDER BY start_date ASC;
 
+-- name: ListCurrentAndUpcomingEvents :many
+-- Multi-day events stay listed until their last day
+SELECT * FROM events
+WHERE is_active = TRUE AND COALESCE(end_date, start_date) >= DATE('now')
+ORDER BY start_date ASC;
+
 -- name: ListPastEvents :many
 SELECT * FROM events
 WHERE is_active = TRUE AND start_date < DATE('now')
@@ -22,14 +31,14 @@ ORDER BY start_date DESC;
 
 -- name: CreateEvent :one
 INSERT INTO events (
-    id, title, description, location, address, start_date, end_date, url, is_active
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    id, title, description, location, address, start_date, end_date, url, is_active, slug, capacity
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: UpdateEvent :one
 UPDATE events
 SET title = ?, description = ?, location = ?, address = ?,
-    start_date = ?, end_date = ?, url = ?, is_active = ?,
+    start_date = ?, end_date = ?, url = ?, is_active = ?, slug = ?, capacity = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
@@ -0,0 +1,12 @@
+-- Exchange Rate Queries
+
+-- name: UpsertExchangeRate :exec
+INSERT INTO exchange_rates (currency, rate, fetched_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (currency) DO UPDATE SET
+    rate = excluded.rate,
+    fetched_at = excluded.fetched_at;
+
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates
+ORDER BY currency;
@@ -0,0 +1,21 @@
+-- name: CreateExpiredCheckout :execrows
+-- A guest's checkout has no user; its cart is found by session_id instead
+INSERT OR IGNORE INTO expired_checkouts (id, user_id, session_id, customer_email, token)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: GetExpiredCheckoutByToken :one
+SELECT * FROM expired_checkouts WHERE token = ?;
+
+-- name: MarkExpiredCheckoutEmailed :exec
+UPDATE expired_checkouts SET emailed_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: MarkExpiredCheckoutsReturned :execrows
+UPDATE expired_checkouts
+SET returned_at = CURRENT_TIMESTAMP
+WHERE user_id = ?
+  AND returned_at IS NULL
+  AND created_at > datetime('now', '-30 days');
+
+-- name: MarkExpiredCheckoutReturned :exec
+-- A guest has no account to match later visits to, so only the link marks their return
+UPDATE expired_checkouts SET returned_at = CURRENT_TIMESTAMP WHERE id = ? AND returned_at IS NULL;
@@ -12,13 +12,13 @@ SELECT
 FROM user_favorites f
 INNER JOIN products p ON f.product_id = p.id
 LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
-WHERE f.user_id = ?
+WHERE f.user_id = ? AND p.deleted_at IS NULL
 ORDER BY f.created_at DESC;
 
--- name: AddFavorite :one
+-- name: AddFavorite :exec
 INSERT INTO user_favorites (id, user_id, product_id, created_at)
 VALUES (?, ?, ?, CURRENT_TIMESTAMP)
-RETURNING *;
+ON CONFLICT (user_id, product_id) DO NOTHING;
 
 -- name: RemoveFavorite :exec
 DELETE FROM user_favorites
@@ -33,3 +33,26 @@ WHERE user_id = ? AND product_id = ?;
 SELECT COUNT(*) as count
 FROM user_favorites
 WHERE user_id = ?;
+
+-- name: ListFavoriteProductIDs :many
+SELECT product_id
+FROM user_favorites
+WHERE user_id = ?;
+
+-- name: ListFavoriteProducts :many
+SELECT p.*
+FROM products p
+INNER JOIN user_favorites f ON f.product_id = p.id
+WHERE f.user_id = ? AND p.deleted_at IS NULL
+ORDER BY f.created_at DESC;
+
+-- name: ListFavoriteStockLevels :many
+SELECT
+    f.product_id,
+    CAST(COALESCE(p.stock_quantity, 0) + COALESCE((
+        SELECT SUM(ps.stock_quantity) FROM product_skus ps
+        WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+    ), 0) AS INTEGER) AS available_stock
+FROM user_favorites f
+INNER JOIN products p ON f.product_id = p.id
+WHERE f.user_id = ? AND p.deleted_at IS NULL;
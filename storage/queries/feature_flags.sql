@@ -0,0 +1,35 @@
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags
+ORDER BY key;
+
+-- name: GetFeatureFlag :one
+SELECT * FROM feature_flags
+WHERE key = ?;
+
+-- name: CreateFeatureFlag :exec
+INSERT INTO feature_flags (key, description, enabled, environments, rollout_percent, updated_by)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: UpdateFeatureFlag :exec
+UPDATE feature_flags
+SET description = ?,
+    enabled = ?,
+    environments = ?,
+    rollout_percent = ?,
+    updated_by = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE key = ?;
+
+-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags
+WHERE key = ?;
+
+-- name: CreateFeatureFlagChange :exec
+INSERT INTO feature_flag_changes (id, flag_key, action, before_state, after_state, changed_by)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListFeatureFlagChanges :many
+-- Most recent changes across every flag, for the admin history
+SELECT * FROM feature_flag_changes
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?;
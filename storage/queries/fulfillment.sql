@@ -0,0 +1,51 @@
+-- name: ListPickListItems :many
+-- The physical units still to pack on open orders placed before placed_before, oldest
+-- order first. Units already sent in a shipment or refunded are left out, so partly
+-- shipped orders only list what's still to go.
+SELECT
+    oi.order_id,
+    oi.product_id,
+    COALESCE(oi.product_sku_id, '') as product_sku_id,
+    oi.product_name,
+    COALESCE(oi.product_sku, '') as product_sku,
+    CAST(oi.quantity
+        - COALESCE((SELECT SUM(si.quantity) FROM order_shipment_items si WHERE si.order_item_id = oi.id), 0)
+        - COALESCE((SELECT SUM(ri.quantity) FROM order_refund_items ri WHERE ri.order_item_id = oi.id), 0)
+    AS INTEGER) as quantity,
+    oi.backordered_quantity,
+    COALESCE(oi.personalization, '') as personalization
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE o.status IN ('received', 'in_production', 'partially_shipped')
+  AND o.created_at < sqlc.arg(placed_before)
+  AND COALESCE(p.product_type, 'physical') = 'physical'
+  AND oi.quantity
+        - COALESCE((SELECT SUM(si.quantity) FROM order_shipment_items si WHERE si.order_item_id = oi.id), 0)
+        - COALESCE((SELECT SUM(ri.quantity) FROM order_refund_items ri WHERE ri.order_item_id = oi.id), 0) > 0
+ORDER BY o.created_at ASC, oi.created_at ASC;
+
+-- name: ListStockLocationsForProducts :many
+-- Where the given products are kept: locations holding stock or with a bin set, the
+-- default location first
+SELECT
+    ls.product_id,
+    ls.product_sku_id,
+    l.name as location_name,
+    ls.bin,
+    ls.quantity
+FROM location_stock ls
+JOIN inventory_locations l ON l.id = ls.location_id
+WHERE ls.product_id IN (sqlc.slice('product_ids'))
+  AND (ls.quantity > 0 OR ls.bin != '')
+ORDER BY l.is_default DESC, l.display_order ASC, l.name ASC;
+
+-- name: ListOrdersByIDs :many
+SELECT * FROM orders
+WHERE id IN (sqlc.slice('ids'))
+ORDER BY created_at ASC;
+
+-- name: SetOrderLabelImageURL :exec
+UPDATE orders
+SET label_image_url = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
@@ -0,0 +1,112 @@
+-- name: CreateGiftCard :one
+INSERT INTO gift_cards (
+    id, code, initial_cents, balance_cents, expires_at,
+    recipient_email, recipient_name, sender_name, message,
+    order_id, created_by_user_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetGiftCard :one
+SELECT * FROM gift_cards WHERE id = ?;
+
+-- name: GetGiftCardByCode :one
+SELECT * FROM gift_cards WHERE code = ?;
+
+-- name: ListGiftCards :many
+-- pattern is a LIKE pattern escaped by the caller; '%' lists every card
+SELECT * FROM gift_cards
+WHERE code LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR recipient_email LIKE sqlc.arg(pattern) ESCAPE '\'
+   OR recipient_name LIKE sqlc.arg(pattern) ESCAPE '\'
+ORDER BY created_at DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetGiftCardTotals :one
+SELECT
+    COUNT(*) AS card_count,
+    CAST(COALESCE(SUM(balance_cents), 0) AS INTEGER) AS outstanding_cents
+FROM gift_cards
+WHERE voided_at IS NULL
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);
+
+-- name: ListOrderGiftCards :many
+SELECT * FROM gift_cards WHERE order_id = ? ORDER BY created_at;
+
+-- name: DebitGiftCard :one
+-- Takes amount_cents off a card that still has it, returning the new balance.
+-- No row comes back when the balance is too low or the card is void.
+UPDATE gift_cards
+SET balance_cents = balance_cents - sqlc.arg(amount_cents),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND voided_at IS NULL
+  AND balance_cents >= sqlc.arg(amount_cents)
+RETURNING balance_cents;
+
+-- name: AdjustGiftCardBalance :one
+-- Adds a signed amount to a card's balance, returning the new balance. No row
+-- comes back when the balance would go below zero or the card is void.
+UPDATE gift_cards
+SET balance_cents = balance_cents + sqlc.arg(amount_cents),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND voided_at IS NULL
+  AND balance_cents + sqlc.arg(amount_cents) >= 0
+RETURNING balance_cents;
+
+-- name: VoidGiftCard :execrows
+UPDATE gift_cards
+SET voided_at = CURRENT_TIMESTAMP,
+    void_reason = ?,
+    balance_cents = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND voided_at IS NULL;
+
+-- name: MarkGiftCardDelivered :exec
+UPDATE gift_cards
+SET delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CreateGiftCardTransaction :exec
+INSERT INTO gift_card_transactions (
+    id, gift_card_id, kind, amount_cents, balance_after_cents,
+    order_id, checkout_session_id, note, created_by_user_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListGiftCardTransactions :many
+SELECT
+    t.*,
+    COALESCE(u.full_name, '') AS created_by_name
+FROM gift_card_transactions t
+LEFT JOIN users u ON t.created_by_user_id = u.id
+WHERE t.gift_card_id = ?
+ORDER BY t.created_at DESC, t.rowid DESC;
+
+-- name: SetGiftCardTransactionSession :exec
+UPDATE gift_card_transactions SET checkout_session_id = ? WHERE id = ?;
+
+-- name: ListHeldGiftCardRedemptions :many
+-- Redemptions held for a checkout session that has neither become an order
+-- nor been released
+SELECT * FROM gift_card_transactions t
+WHERE t.checkout_session_id = sqlc.arg(checkout_session_id)
+  AND t.kind = 'redeem'
+  AND t.order_id IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM gift_card_transactions r
+      WHERE r.gift_card_id = t.gift_card_id
+        AND r.checkout_session_id = t.checkout_session_id
+        AND r.kind = 'release'
+  );
+
+-- name: AttachGiftCardRedemptionsToOrder :exec
+UPDATE gift_card_transactions
+SET order_id = sqlc.arg(order_id)
+WHERE checkout_session_id = sqlc.arg(checkout_session_id)
+  AND kind = 'redeem'
+  AND order_id IS NULL;
+
+-- name: SetOrderGiftCard :exec
+UPDATE orders
+SET gift_card_id = ?, gift_card_cents = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
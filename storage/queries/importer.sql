@@ -262,3 +262,48 @@ SET generated_name = NULL,
     description_model = NULL,
     description_generated_at = NULL
 WHERE id = ?;
+
+-- URL Imports
+
+-- name: CreateURLImportJob :one
+INSERT INTO import_jobs (id, designer_slug, platform, job_type, status, total_items)
+VALUES (?, '', 'urls', 'url_import', 'pending', ?)
+RETURNING *;
+
+-- name: ListQueuedURLImportJobs :many
+-- Jobs still running were cut short by a restart, and carry on from their next pending URL
+SELECT * FROM import_jobs
+WHERE job_type = 'url_import' AND status IN ('pending', 'running')
+ORDER BY created_at, id;
+
+-- name: StartImportJob :exec
+UPDATE import_jobs
+SET status = 'running', started_at = COALESCE(started_at, CURRENT_TIMESTAMP)
+WHERE id = ?;
+
+-- name: CreateImportJobURL :exec
+INSERT INTO import_job_urls (id, job_id, position, url, platform, category_id)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListImportJobURLs :many
+SELECT * FROM import_job_urls
+WHERE job_id = ?
+ORDER BY position;
+
+-- name: ListPendingImportJobURLs :many
+SELECT * FROM import_job_urls
+WHERE job_id = ? AND status = 'pending'
+ORDER BY position;
+
+-- name: FinishImportJobURL :exec
+UPDATE import_job_urls
+SET status = ?, error = ?, product_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CountImportJobURLsByStatus :one
+SELECT
+    CAST(COALESCE(SUM(status != 'pending'), 0) AS INTEGER) AS processed,
+    CAST(COALESCE(SUM(status = 'failed'), 0) AS INTEGER) AS failed,
+    COUNT(*) AS total
+FROM import_job_urls
+WHERE job_id = ?;
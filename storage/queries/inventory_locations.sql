@@ -0,0 +1,110 @@
+-- name: ListInventoryLocations :many
+SELECT * FROM inventory_locations
+ORDER BY display_order ASC, name ASC;
+
+-- name: ListActiveInventoryLocations :many
+SELECT * FROM inventory_locations
+WHERE is_active = TRUE
+ORDER BY is_default DESC, display_order ASC, name ASC;
+
+-- name: GetInventoryLocation :one
+SELECT * FROM inventory_locations WHERE id = ?;
+
+-- name: GetDefaultInventoryLocation :one
+SELECT * FROM inventory_locations
+WHERE is_default = TRUE AND is_active = TRUE
+LIMIT 1;
+
+-- name: CreateInventoryLocation :one
+INSERT INTO inventory_locations (
+    id, name, contact_name, phone, address_line1, address_line2, city_locality,
+    state_province, postal_code, country_code, is_default, is_active, display_order
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateInventoryLocation :one
+UPDATE inventory_locations
+SET name = ?, contact_name = ?, phone = ?, address_line1 = ?, address_line2 = ?,
+    city_locality = ?, state_province = ?, postal_code = ?, country_code = ?,
+    is_default = ?, is_active = ?, display_order = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: ClearOtherDefaultInventoryLocations :exec
+-- Keeps a single default location after one is marked as the default
+UPDATE inventory_locations
+SET is_default = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE is_default = TRUE AND id != ?;
+
+-- name: DeleteInventoryLocation :exec
+DELETE FROM inventory_locations WHERE id = ?;
+
+-- name: CountInventoryMovementsForLocation :one
+SELECT COUNT(*) FROM inventory_movements WHERE location_id = ?;
+
+-- name: ListProductLocationStock :many
+SELECT location_id, product_sku_id, quantity, bin
+FROM location_stock
+WHERE product_id = ?;
+
+-- name: GetLocationStockQuantity :one
+SELECT CAST(COALESCE((
+    SELECT quantity FROM location_stock
+    WHERE location_id = sqlc.arg(location_id)
+      AND product_id = sqlc.arg(product_id)
+      AND product_sku_id = sqlc.arg(product_sku_id)
+), 0) AS INTEGER) AS quantity;
+
+-- name: AddLocationStock :exec
+-- Applies a change to a location's stock, creating the row on first use. The CHECK
+-- on quantity rejects a change that would take it below zero.
+INSERT INTO location_stock (location_id, product_id, product_sku_id, quantity, updated_at)
+VALUES (sqlc.arg(location_id), sqlc.arg(product_id), sqlc.arg(product_sku_id), sqlc.arg(delta), CURRENT_TIMESTAMP)
+ON CONFLICT (location_id, product_id, product_sku_id) DO UPDATE
+SET quantity = location_stock.quantity + excluded.quantity, updated_at = CURRENT_TIMESTAMP;
+
+-- name: SetLocationStockBin :exec
+-- Records where an item is kept at a location, creating the row with no stock on first use
+INSERT INTO location_stock (location_id, product_id, product_sku_id, quantity, bin, updated_at)
+VALUES (sqlc.arg(location_id), sqlc.arg(product_id), sqlc.arg(product_sku_id), 0, sqlc.arg(bin), CURRENT_TIMESTAMP)
+ON CONFLICT (location_id, product_id, product_sku_id) DO UPDATE
+SET bin = excluded.bin, updated_at = CURRENT_TIMESTAMP;
+
+-- name: AddProductAvailableStock :exec
+-- Moves the sellable count with a location adjustment, never below zero
+UPDATE products
+SET stock_quantity = MAX(COALESCE(stock_quantity, 0) + CAST(sqlc.arg(delta) AS INTEGER), 0), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: AddSkuAvailableStock :exec
+UPDATE product_skus
+SET stock_quantity = MAX(COALESCE(stock_quantity, 0) + CAST(sqlc.arg(delta) AS INTEGER), 0), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND product_id = sqlc.arg(product_id);
+
+-- name: CreateInventoryMovement :exec
+INSERT INTO inventory_movements (
+    id, location_id, product_id, product_sku_id, quantity_change, reason,
+    transfer_id, order_id, note, created_by
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListProductInventoryMovements :many
+SELECT
+    m.*,
+    l.name AS location_name,
+    CAST(COALESCE(ps.sku, '') AS TEXT) AS sku
+FROM inventory_movements m
+JOIN inventory_locations l ON l.id = m.location_id
+LEFT JOIN product_skus ps ON ps.id = m.product_sku_id
+WHERE m.product_id = ?
+ORDER BY m.created_at DESC, m.id
+LIMIT ?;
+
+-- name: SetOrderFulfillmentLocation :exec
+UPDATE orders
+SET fulfillment_location_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateOrderEasypostShipment :exec
+UPDATE orders
+SET easypost_shipment_id = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
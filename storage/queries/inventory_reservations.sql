@@ -0,0 +1,84 @@
+-- name: GetReservedStock :one
+-- Units held by checkouts in progress for a product without variants, or for one of
+-- its SKUs when product_sku_id is set
+SELECT CAST(COALESCE(SUM(quantity), 0) AS INTEGER) AS reserved
+FROM inventory_reservations
+WHERE product_id = sqlc.arg(product_id)
+  AND COALESCE(product_sku_id, '') = sqlc.arg(product_sku_id)
+  AND status = 'held'
+  AND expires_at > CURRENT_TIMESTAMP;
+
+-- name: ReserveProductStock :execrows
+-- Holds units of a product without variants while its stock, less what other
+-- checkouts hold, still covers them. Nothing is inserted when it doesn't, so two
+-- checkouts can't hold the same unit. ttl is a datetime modifier like '+1800 seconds'.
+INSERT INTO inventory_reservations (id, hold_id, product_id, quantity, cart_session_id, expires_at)
+SELECT sqlc.arg(id), sqlc.arg(hold_id), p.id, sqlc.arg(quantity), sqlc.arg(cart_session_id), datetime('now', sqlc.arg(ttl))
+FROM products p
+WHERE p.id = sqlc.arg(product_id)
+  AND COALESCE(p.stock_quantity, 0) - (
+      SELECT COALESCE(SUM(r.quantity), 0) FROM inventory_reservations r
+      WHERE r.product_id = p.id
+        AND r.product_sku_id IS NULL
+        AND r.status = 'held'
+        AND r.expires_at > CURRENT_TIMESTAMP
+  ) >= sqlc.arg(quantity);
+
+-- name: ReserveSkuStock :execrows
+-- Holds units of one SKU the same way
+INSERT INTO inventory_reservations (id, hold_id, product_id, product_sku_id, quantity, cart_session_id, expires_at)
+SELECT sqlc.arg(id), sqlc.arg(hold_id), ps.product_id, ps.id, sqlc.arg(quantity), sqlc.arg(cart_session_id), datetime('now', sqlc.arg(ttl))
+FROM product_skus ps
+WHERE ps.id = sqlc.arg(product_sku_id)
+  AND COALESCE(ps.stock_quantity, 0) - (
+      SELECT COALESCE(SUM(r.quantity), 0) FROM inventory_reservations r
+      WHERE r.product_sku_id = ps.id
+        AND r.status = 'held'
+        AND r.expires_at > CURRENT_TIMESTAMP
+  ) >= sqlc.arg(quantity);
+
+-- name: LinkInventoryHold :exec
+UPDATE inventory_reservations
+SET checkout_session_id = sqlc.arg(checkout_session_id), updated_at = CURRENT_TIMESTAMP
+WHERE hold_id = sqlc.arg(hold_id);
+
+-- name: ReleaseInventoryHold :execrows
+UPDATE inventory_reservations
+SET status = 'released', updated_at = CURRENT_TIMESTAMP
+WHERE hold_id = ? AND status = 'held';
+
+-- name: ReleaseCartInventoryHolds :execrows
+-- Releases what a cart's earlier checkouts still hold, when the shopper cancels or
+-- starts again
+UPDATE inventory_reservations
+SET status = 'released', updated_at = CURRENT_TIMESTAMP
+WHERE cart_session_id = ? AND status = 'held';
+
+-- name: ListCartHeldCheckouts :many
+-- The cart's earlier checkouts still holding stock, to close before a new one starts
+SELECT DISTINCT checkout_session_id
+FROM inventory_reservations
+WHERE cart_session_id = ? AND status = 'held' AND checkout_session_id != ''
+  AND expires_at > CURRENT_TIMESTAMP
+ORDER BY checkout_session_id;
+
+-- name: ReleaseSessionInventoryHolds :execrows
+UPDATE inventory_reservations
+SET status = 'released', updated_at = CURRENT_TIMESTAMP
+WHERE checkout_session_id = ? AND status = 'held';
+
+-- name: ConsumeSessionInventoryHolds :execrows
+-- The order was paid, and its stock is taken by the webhook, so the holds are done
+UPDATE inventory_reservations
+SET status = 'consumed', updated_at = CURRENT_TIMESTAMP
+WHERE checkout_session_id = ? AND status = 'held';
+
+-- name: ReleaseExpiredInventoryHolds :execrows
+UPDATE inventory_reservations
+SET status = 'released', updated_at = CURRENT_TIMESTAMP
+WHERE status = 'held' AND expires_at <= CURRENT_TIMESTAMP;
+
+-- name: DeleteOldInventoryReservations :execrows
+-- Finished reservations are only kept for a while, to look into an oversell
+DELETE FROM inventory_reservations
+WHERE status != 'held' AND updated_at < datetime('now', sqlc.arg(max_age));
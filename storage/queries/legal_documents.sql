@@ -0,0 +1,79 @@
+-- ============================================
+-- Versioned legal documents
+-- ============================================
+
+-- name: GetLegalDocument :one
+SELECT * FROM legal_documents
+WHERE id = ?;
+
+-- name: GetCurrentLegalDocument :one
+-- The version of a kind in effect at a time: the latest to have taken effect by then
+SELECT * FROM legal_documents
+WHERE kind = sqlc.arg(kind) AND effective_at <= sqlc.arg(at)
+ORDER BY effective_at DESC, version DESC
+LIMIT 1;
+
+-- name: GetLegalDocumentVersion :one
+SELECT * FROM legal_documents
+WHERE kind = ? AND version = ?;
+
+-- name: GetScheduledLegalDocument :one
+-- The next version of a kind to take effect after a time, if one is scheduled
+SELECT * FROM legal_documents
+WHERE kind = sqlc.arg(kind) AND effective_at > sqlc.arg(at)
+ORDER BY effective_at
+LIMIT 1;
+
+-- name: ListCurrentLegalDocuments :many
+-- The version of every kind in effect at a time, terms first
+SELECT d.* FROM legal_documents d
+WHERE d.id = (
+    SELECT latest.id FROM legal_documents latest
+    WHERE latest.kind = d.kind AND latest.effective_at <= sqlc.arg(at)
+    ORDER BY latest.effective_at DESC, latest.version DESC
+    LIMIT 1
+)
+ORDER BY CASE d.kind WHEN 'terms' THEN 0 WHEN 'shipping' THEN 1 ELSE 2 END;
+
+-- name: ListLegalDocuments :many
+SELECT
+    d.*,
+    (SELECT COUNT(*) FROM order_policy_acceptances a WHERE a.legal_document_id = d.id) AS acceptance_count
+FROM legal_documents d
+ORDER BY CASE d.kind WHEN 'terms' THEN 0 WHEN 'shipping' THEN 1 ELSE 2 END, d.version DESC;
+
+-- name: GetLatestLegalDocument :one
+-- The newest version of a kind, including one that hasn't taken effect yet
+SELECT * FROM legal_documents
+WHERE kind = ?
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: CreateLegalDocument :one
+INSERT INTO legal_documents (
+    id, kind, version, title, body_markdown, change_summary, effective_at
+) VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteScheduledLegalDocument :execrows
+-- Only a version that hasn't taken effect can go, since nobody can have agreed to it
+DELETE FROM legal_documents
+WHERE id = sqlc.arg(id) AND effective_at > sqlc.arg(now);
+
+-- ============================================
+-- Acceptance at checkout
+-- ============================================
+
+-- name: CreateOrderPolicyAcceptance :exec
+INSERT INTO order_policy_acceptances (order_id, legal_document_id, accepted_at)
+VALUES (?, ?, ?)
+ON CONFLICT (order_id, legal_document_id) DO NOTHING;
+
+-- name: ListOrderPolicyAcceptances :many
+SELECT
+    a.accepted_at,
+    d.id, d.kind, d.version, d.title, d.effective_at
+FROM order_policy_acceptances a
+JOIN legal_documents d ON d.id = a.legal_document_id
+WHERE a.order_id = ?
+ORDER BY CASE d.kind WHEN 'terms' THEN 0 WHEN 'shipping' THEN 1 ELSE 2 END;
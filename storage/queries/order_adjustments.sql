@@ -0,0 +1,136 @@
+-- name: CreateOrderAdjustment :exec
+INSERT INTO order_adjustments (
+    id, order_id, kind, description, amount_cents, order_refund_id,
+    stripe_payment_link_id, payment_url, note, created_by
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateOrderAdjustmentItem :exec
+INSERT INTO order_adjustment_items (
+    id, adjustment_id, order_item_id, product_id, product_sku_id, product_name, quantity, unit_price_cents
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: OrderAdjustmentExists :one
+SELECT EXISTS (SELECT 1 FROM order_adjustments WHERE id = ?) AS adjustment_exists;
+
+-- name: GetOrderAdjustment :one
+SELECT * FROM order_adjustments WHERE id = ?;
+
+-- name: ListOrderAdjustments :many
+SELECT * FROM order_adjustments
+WHERE order_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: ListOrderAdjustmentItems :many
+-- The lines of every adjustment of an order
+SELECT ai.*
+FROM order_adjustment_items ai
+JOIN order_adjustments a ON a.id = ai.adjustment_id
+WHERE a.order_id = ?
+ORDER BY a.created_at ASC, ai.product_name ASC;
+
+-- name: GetOrderAdjustmentItems :many
+SELECT * FROM order_adjustment_items
+WHERE adjustment_id = ?
+ORDER BY product_name ASC;
+
+-- name: GetOrderShippingAdjustedCents :one
+-- How much shipping changes have moved the order's shipping: refunds count at once,
+-- charges once they're paid
+SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) AS adjusted_cents
+FROM order_adjustments
+WHERE order_id = ?
+  AND kind = 'shipping'
+  AND (amount_cents < 0 OR paid_at IS NOT NULL);
+
+-- name: GetOrderChangesPaidCents :one
+-- What the customer has paid through payment links for changes to an order
+SELECT CAST(COALESCE(SUM(amount_cents), 0) AS INTEGER) AS paid_cents
+FROM order_adjustments
+WHERE order_id = ? AND paid_at IS NOT NULL AND amount_cents > 0;
+
+-- name: CountOrderAdjustmentPayments :one
+-- Payment links sent for an order that are paid or still open
+SELECT COUNT(*) FROM order_adjustments
+WHERE order_id = ?
+  AND stripe_payment_link_id != ''
+  AND cancelled_at IS NULL;
+
+-- name: ListOrderAddedItemIDs :many
+-- Order lines added after the order was paid for, which were paid through their own link
+SELECT ai.order_item_id
+FROM order_adjustment_items ai
+JOIN order_adjustments a ON a.id = ai.adjustment_id
+WHERE a.order_id = ?
+  AND a.kind = 'add_items'
+  AND ai.order_item_id IS NOT NULL;
+
+-- name: AddPaidAdjustmentItemsToOrder :exec
+-- Puts the items an unpaid adjustment adds on the order. Each line takes its
+-- adjustment item's ID, so a retried webhook can't add it twice.
+INSERT OR IGNORE INTO order_items (
+    id, order_id, product_id, product_sku_id, quantity, unit_price_cents,
+    total_price_cents, product_name, product_sku
+)
+SELECT
+    ai.id,
+    a.order_id,
+    ai.product_id,
+    NULLIF(ai.product_sku_id, ''),
+    ai.quantity,
+    ai.unit_price_cents,
+    ai.unit_price_cents * ai.quantity,
+    ai.product_name,
+    COALESCE(ps.sku, p.sku)
+FROM order_adjustment_items ai
+JOIN order_adjustments a ON a.id = ai.adjustment_id
+LEFT JOIN products p ON p.id = ai.product_id
+LEFT JOIN product_skus ps ON ps.id = ai.product_sku_id
+WHERE a.id = ?
+  AND a.kind = 'add_items'
+  AND a.paid_at IS NULL;
+
+-- name: LinkAddedAdjustmentItems :exec
+UPDATE order_adjustment_items
+SET order_item_id = id
+WHERE adjustment_id = ? AND order_item_id IS NULL
+  AND EXISTS (SELECT 1 FROM order_items oi WHERE oi.id = order_adjustment_items.id);
+
+-- name: MarkOrderAdjustmentPaid :execrows
+-- Only marks an adjustment that's still waiting on its link, so a retried webhook
+-- leaves it alone
+UPDATE order_adjustments
+SET paid_at = CURRENT_TIMESTAMP, stripe_payment_intent_id = ?
+WHERE id = ? AND paid_at IS NULL AND cancelled_at IS NULL;
+
+-- name: CancelOrderAdjustment :execrows
+UPDATE order_adjustments
+SET cancelled_at = CURRENT_TIMESTAMP
+WHERE id = ? AND order_id = ?
+  AND stripe_payment_link_id != ''
+  AND paid_at IS NULL AND cancelled_at IS NULL;
+
+-- name: ListOrderEditProducts :many
+-- What can be added to an order: active physical products, each variant on its own
+SELECT
+    p.id AS product_id,
+    CAST('' AS TEXT) AS product_sku_id,
+    p.name AS name,
+    p.price_cents AS price_cents
+FROM products p
+WHERE COALESCE(p.is_active, TRUE) AND p.deleted_at IS NULL
+  AND p.product_type = 'physical' AND p.is_gift_card = FALSE
+  AND NOT COALESCE(p.has_variants, FALSE)
+UNION ALL
+SELECT
+    p.id AS product_id,
+    ps.id AS product_sku_id,
+    CAST(p.name || ' - ' || pst.name || ' / ' || s.display_name AS TEXT) AS name,
+    CAST(p.price_cents + COALESCE(ps.price_adjustment_cents, 0) AS INTEGER) AS price_cents
+FROM product_skus ps
+JOIN products p ON p.id = ps.product_id
+JOIN product_styles pst ON pst.id = ps.product_style_id
+JOIN sizes s ON s.id = ps.size_id
+WHERE COALESCE(p.is_active, TRUE) AND p.deleted_at IS NULL
+  AND COALESCE(ps.is_active, TRUE)
+  AND p.product_type = 'physical' AND p.is_gift_card = FALSE
+ORDER BY name ASC;
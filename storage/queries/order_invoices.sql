@@ -0,0 +1,10 @@
+-- name: GetOrderInvoice :one
+SELECT * FROM order_invoices WHERE order_id = ?;
+
+-- name: SaveOrderInvoice :exec
+INSERT INTO order_invoices (order_id, fingerprint, pdf, generated_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(order_id) DO UPDATE SET
+    fingerprint = excluded.fingerprint,
+    pdf = excluded.pdf,
+    generated_at = excluded.generated_at;
@@ -0,0 +1,40 @@
+-- name: CreateOrderRefund :one
+INSERT INTO order_refunds (
+    id, order_id, amount_cents, reason, stripe_refund_id, restocked, created_by
+) VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateOrderRefundItem :exec
+INSERT INTO order_refund_items (id, refund_id, order_item_id, quantity, amount_cents)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: OrderRefundExists :one
+SELECT EXISTS (SELECT 1 FROM order_refunds WHERE id = ?) AS refund_exists;
+
+-- name: ListOrderRefunds :many
+SELECT * FROM order_refunds WHERE order_id = ? ORDER BY created_at DESC;
+
+-- name: ListOrderRefundItems :many
+SELECT
+    ri.refund_id,
+    ri.quantity,
+    ri.amount_cents,
+    oi.product_name
+FROM order_refund_items ri
+JOIN order_refunds r ON r.id = ri.refund_id
+JOIN order_items oi ON oi.id = ri.order_item_id
+WHERE r.order_id = ?
+ORDER BY oi.product_name;
+
+-- name: GetOrderRefundedQuantities :many
+-- Units of each order line already refunded from the order page
+SELECT
+    ri.order_item_id,
+    CAST(SUM(ri.quantity) AS INTEGER) AS quantity
+FROM order_refund_items ri
+JOIN order_refunds r ON r.id = ri.refund_id
+WHERE r.order_id = ?
+GROUP BY ri.order_item_id;
+
+-- name: MarkOrderRefundNotified :exec
+UPDATE order_refunds SET customer_notified = TRUE WHERE id = ?;
@@ -0,0 +1,66 @@
+-- name: CreateOrderShipment :exec
+INSERT INTO order_shipments (
+    id, order_id, carrier, tracking_number, tracking_url, easypost_shipment_id, label_url, location_id, created_by
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateOrderShipmentItem :exec
+INSERT INTO order_shipment_items (id, shipment_id, order_item_id, quantity)
+VALUES (?, ?, ?, ?);
+
+-- name: ListOrderShipments :many
+SELECT * FROM order_shipments
+WHERE order_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: ListOrderShipmentItems :many
+-- The lines of every shipment of an order
+SELECT
+    si.shipment_id,
+    si.order_item_id,
+    si.quantity,
+    oi.product_name
+FROM order_shipment_items si
+JOIN order_shipments s ON s.id = si.shipment_id
+JOIN order_items oi ON oi.id = si.order_item_id
+WHERE s.order_id = ?
+ORDER BY s.created_at ASC, oi.product_name ASC;
+
+-- name: ListOrderItemsToShip :many
+-- An order's physical lines, with the units already sent in a shipment and the units
+-- refunded, which won't be sent
+SELECT
+    oi.id,
+    oi.product_name,
+    oi.quantity,
+    CAST(COALESCE((
+        SELECT SUM(si.quantity) FROM order_shipment_items si WHERE si.order_item_id = oi.id
+    ), 0) AS INTEGER) AS shipped_quantity,
+    CAST(COALESCE((
+        SELECT SUM(ri.quantity) FROM order_refund_items ri WHERE ri.order_item_id = oi.id
+    ), 0) AS INTEGER) AS refunded_quantity
+FROM order_items oi
+LEFT JOIN products p ON p.id = oi.product_id
+WHERE oi.order_id = ?
+  AND COALESCE(p.product_type, 'physical') = 'physical'
+ORDER BY oi.created_at ASC, oi.id ASC;
+
+-- name: MarkOrderShipmentNotified :exec
+UPDATE order_shipments SET customer_notified = TRUE WHERE id = ?;
+
+-- name: UpdateOrderShipmentStatus :execrows
+-- Moves a box of an order to a new status. delivered_at is kept from the first time
+-- it was marked delivered and cleared if it's moved back.
+UPDATE order_shipments
+SET status = sqlc.arg(status),
+    status_note = sqlc.arg(status_note),
+    delivered_at = CASE
+        WHEN sqlc.arg(status) = 'delivered' THEN COALESCE(delivered_at, CURRENT_TIMESTAMP)
+        ELSE NULL
+    END
+WHERE id = sqlc.arg(id) AND order_id = sqlc.arg(order_id);
+
+-- name: MarkOrderShipmentsDelivered :exec
+-- Marks the boxes still in transit delivered, when the whole order is
+UPDATE order_shipments
+SET status = 'delivered', delivered_at = CURRENT_TIMESTAMP
+WHERE order_id = ? AND status = 'in_transit';
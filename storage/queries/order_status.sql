@@ -0,0 +1,36 @@
+-- name: MoveOrderStatus :execrows
+-- Only moves an order still in from_status, so two changes at once can't both apply
+UPDATE orders
+SET status = sqlc.arg(to_status), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND COALESCE(status, '') = sqlc.arg(from_status);
+
+-- name: CreateOrderStatusChange :exec
+INSERT INTO order_status_history (id, order_id, from_status, to_status, note, changed_by)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListOrderStatusHistory :many
+SELECT * FROM order_status_history
+WHERE order_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: ListOrderItemsToRestock :many
+-- Units a cancelled order took off the sellable count: physical, in-stock lines, less
+-- any a refund has already put back. Backordered and pre-order lines never took stock.
+SELECT
+    oi.id,
+    oi.product_id,
+    COALESCE(oi.product_sku_id, '') AS product_sku_id,
+    oi.product_name,
+    CAST(oi.quantity - COALESCE((
+        SELECT SUM(ri.quantity)
+        FROM order_refund_items ri
+        JOIN order_refunds r ON r.id = ri.refund_id
+        WHERE ri.order_item_id = oi.id AND r.restocked
+    ), 0) AS INTEGER) AS quantity
+FROM order_items oi
+JOIN products p ON p.id = oi.product_id
+WHERE oi.order_id = ?
+  AND p.product_type = 'physical'
+  AND p.is_gift_card = FALSE
+  AND oi.is_preorder = FALSE
+  AND oi.backordered_quantity = 0;
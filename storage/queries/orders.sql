@@ -41,15 +41,30 @@ INSERT INTO orders (
     subtotal_cents, tax_cents, shipping_cents, total_cents,
     original_subtotal_cents, discount_cents, promotion_code, promotion_code_id,
     stripe_payment_intent_id, stripe_customer_id, stripe_checkout_session_id,
-    easypost_shipment_id, status, notes
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    easypost_shipment_id, status, notes, customer_notes,
+    is_gift, gift_message, gift_recipient_name, gift_recipient_email,
+    guest_session_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
--- name: UpdateOrderStatus :one
+-- name: ClaimGuestOrdersByEmail :execrows
 UPDATE orders
-SET status = ?, updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
-RETURNING *;
+SET user_id = sqlc.arg(user_id), updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ''
+  AND customer_email = sqlc.arg(email) COLLATE NOCASE;
+
+-- name: LinkGuestOrder :execrows
+UPDATE orders
+SET user_id = sqlc.arg(user_id), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND user_id = '';
+
+-- name: SetOrderPaymentCurrency :exec
+UPDATE orders
+SET payment_currency = sqlc.arg(payment_currency),
+    payment_fx_rate = sqlc.arg(payment_fx_rate),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
 
 -- name: UpdateOrderTracking :one
 UPDATE orders
@@ -59,7 +74,7 @@ RETURNING *;
 
 -- name: UpdateOrderLabel :one
 UPDATE orders
-SET easypost_label_url = ?, tracking_number = ?, carrier = ?, status = ?, updated_at = CURRENT_TIMESTAMP
+SET easypost_label_url = ?, tracking_number = ?, carrier = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
@@ -69,6 +84,11 @@ SET notes = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
+-- name: MarkGiftRecipientNotified :exec
+UPDATE orders
+SET gift_notified_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
 -- name: DeleteOrder :exec
 DELETE FROM orders WHERE id = ?;
 
@@ -84,8 +104,9 @@ WHERE oi.order_id = ?;
 -- name: CreateOrderItem :one
 INSERT INTO order_items (
     id, order_id, product_id, product_sku_id, quantity, unit_price_cents,
-    total_price_cents, product_name, product_sku
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    total_price_cents, product_name, product_sku, backordered_quantity, estimated_ship_date,
+    is_preorder, personalization
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetOrderStats :one
@@ -135,6 +156,6 @@ LEFT JOIN product_skus ps ON recent.product_sku_id = ps.id
 LEFT JOIN product_styles pst ON ps.product_style_id = pst.id
 LEFT JOIN product_style_images psi ON psi.product_style_id = pst.id AND psi.is_primary = TRUE
 LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
-WHERE p.is_active = TRUE
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
 ORDER BY recent.last_purchased_at DESC
 LIMIT 12;
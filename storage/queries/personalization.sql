@@ -0,0 +1,13 @@
+-- name: ListProductPersonalizationFields :many
+SELECT * FROM product_personalization_fields
+WHERE product_id = ?
+ORDER BY display_order ASC, created_at ASC;
+
+-- name: CreateProductPersonalizationField :one
+INSERT INTO product_personalization_fields (id, product_id, label, field_type, max_length, is_required, display_order)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteProductPersonalizationField :exec
+DELETE FROM product_personalization_fields
+WHERE id = ? AND product_id = ?;
@@ -0,0 +1,87 @@
+-- name: GetPortfolioItem :one
+SELECT * FROM portfolio_items WHERE id = ?;
+
+-- name: ListPortfolioItems :many
+SELECT
+    pi.*,
+    CAST(COALESCE((
+        SELECT i.image_url FROM portfolio_item_images i
+        WHERE i.portfolio_item_id = pi.id
+        ORDER BY i.display_order ASC, i.created_at ASC
+        LIMIT 1
+    ), '') AS TEXT) AS cover_image_url,
+    (SELECT COUNT(*) FROM portfolio_item_images i WHERE i.portfolio_item_id = pi.id) AS image_count,
+    (SELECT COUNT(*) FROM portfolio_item_products p WHERE p.portfolio_item_id = pi.id) AS product_count
+FROM portfolio_items pi
+ORDER BY pi.display_order ASC, pi.created_at DESC;
+
+-- name: ListPublishedPortfolioItems :many
+SELECT * FROM portfolio_items
+WHERE is_published = TRUE
+ORDER BY is_featured DESC, display_order ASC, created_at DESC;
+
+-- name: CreatePortfolioItem :one
+INSERT INTO portfolio_items (
+    id, title, slug, description, tags, is_published, is_featured, display_order
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdatePortfolioItem :one
+UPDATE portfolio_items
+SET title = ?, slug = ?, description = ?, tags = ?,
+    is_published = ?, is_featured = ?, display_order = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: DeletePortfolioItem :exec
+DELETE FROM portfolio_items WHERE id = ?;
+
+-- name: ListPortfolioItemImages :many
+SELECT * FROM portfolio_item_images
+WHERE portfolio_item_id = ?
+ORDER BY display_order ASC, created_at ASC;
+
+-- name: ListPublishedPortfolioImages :many
+SELECT i.*
+FROM portfolio_item_images i
+JOIN portfolio_items pi ON pi.id = i.portfolio_item_id
+WHERE pi.is_published = TRUE
+ORDER BY i.portfolio_item_id ASC, i.display_order ASC, i.created_at ASC;
+
+-- name: GetPortfolioItemImage :one
+SELECT * FROM portfolio_item_images
+WHERE id = ? AND portfolio_item_id = ?;
+
+-- name: CreatePortfolioItemImage :one
+INSERT INTO portfolio_item_images (id, portfolio_item_id, image_url, alt_text, display_order)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeletePortfolioItemImage :exec
+DELETE FROM portfolio_item_images
+WHERE id = ? AND portfolio_item_id = ?;
+
+-- name: ListPortfolioItemProducts :many
+SELECT pp.*, p.name AS product_name, p.slug AS product_slug, p.is_active AS product_is_active
+FROM portfolio_item_products pp
+JOIN products p ON p.id = pp.product_id
+WHERE pp.portfolio_item_id = ?
+ORDER BY pp.display_order ASC, p.name ASC;
+
+-- name: ListPublishedPortfolioProducts :many
+-- Only active products get a "shop this print" link
+SELECT pp.portfolio_item_id, p.id, p.name, p.slug, p.price_cents
+FROM portfolio_item_products pp
+JOIN portfolio_items pi ON pi.id = pp.portfolio_item_id
+JOIN products p ON p.id = pp.product_id
+WHERE pi.is_published = TRUE AND p.is_active = TRUE AND p.deleted_at IS NULL
+ORDER BY pp.portfolio_item_id ASC, pp.display_order ASC, p.name ASC;
+
+-- name: AddPortfolioItemProduct :exec
+INSERT OR IGNORE INTO portfolio_item_products (portfolio_item_id, product_id, display_order)
+VALUES (?, ?, ?);
+
+-- name: RemovePortfolioItemProduct :exec
+DELETE FROM portfolio_item_products
+WHERE portfolio_item_id = ? AND product_id = ?;
@@ -0,0 +1,35 @@
+-- name: ListOpenPreorderOrders :many
+-- Orders still waiting on at least one pre-order item
+SELECT o.* FROM orders o
+WHERE EXISTS (
+    SELECT 1 FROM order_items oi
+    WHERE oi.order_id = o.id
+      AND oi.is_preorder = TRUE
+      AND oi.preorder_shipped_at IS NULL
+)
+  AND o.status NOT IN ('shipped', 'delivered', 'cancelled', 'refunded')
+ORDER BY o.created_at DESC;
+
+-- name: ListPreorderOrderIDs :many
+SELECT DISTINCT order_id FROM order_items
+WHERE is_preorder = TRUE;
+
+-- name: ListUnshippedPreorderItems :many
+SELECT
+    id,
+    product_name,
+    product_sku,
+    quantity,
+    COALESCE(estimated_ship_date, '') as estimated_ship_date
+FROM order_items
+WHERE order_id = ?
+  AND is_preorder = TRUE
+  AND preorder_shipped_at IS NULL
+ORDER BY created_at ASC;
+
+-- name: MarkPreorderItemsShipped :exec
+UPDATE order_items
+SET preorder_shipped_at = CURRENT_TIMESTAMP
+WHERE order_id = ?
+  AND is_preorder = TRUE
+  AND preorder_shipped_at IS NULL;
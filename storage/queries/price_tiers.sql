@@ -0,0 +1,22 @@
+-- name: ListProductPriceTiers :many
+SELECT
+    t.*,
+    COALESCE(ps.sku, '') as sku
+FROM product_price_tiers t
+LEFT JOIN product_skus ps ON ps.id = t.product_sku_id
+WHERE t.product_id = ?
+ORDER BY t.product_sku_id IS NOT NULL, ps.sku, t.min_quantity;
+
+-- name: ListPriceTiersForProducts :many
+SELECT * FROM product_price_tiers
+WHERE product_id IN (sqlc.slice('product_ids'))
+ORDER BY product_id, min_quantity;
+
+-- name: CreateProductPriceTier :one
+INSERT INTO product_price_tiers (id, product_id, product_sku_id, min_quantity, unit_price_cents)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteProductPriceTier :exec
+DELETE FROM product_price_tiers
+WHERE id = ? AND product_id = ?;
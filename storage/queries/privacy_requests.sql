@@ -0,0 +1,57 @@
+-- name: CreatePrivacyRequest :exec
+INSERT INTO privacy_requests (id, user_id, email_hash, kind, status, summary_json, error, completed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetPrivacyRequest :one
+SELECT * FROM privacy_requests WHERE id = ?;
+
+-- name: ListUserPrivacyRequests :many
+SELECT * FROM privacy_requests
+WHERE user_id = ?
+ORDER BY requested_at DESC
+LIMIT 20;
+
+-- name: ListPrivacyRequests :many
+-- The compliance log, newest first
+SELECT * FROM privacy_requests
+ORDER BY requested_at DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountPrivacyRequests :one
+SELECT COUNT(*) FROM privacy_requests;
+
+-- name: ListPrivacyRequestsByEmailHash :many
+-- Requests made for an email address, which is all the log keeps of it
+SELECT * FROM privacy_requests
+WHERE email_hash = ?
+ORDER BY requested_at DESC;
+
+-- name: ListPendingPrivacyExports :many
+SELECT * FROM privacy_requests
+WHERE kind = 'export' AND status = 'pending'
+ORDER BY requested_at
+LIMIT 10;
+
+-- name: CompletePrivacyExport :exec
+UPDATE privacy_requests
+SET status = 'completed', file_name = ?, summary_json = ?, completed_at = CURRENT_TIMESTAMP, expires_at = ?
+WHERE id = ?;
+
+-- name: FailPrivacyRequest :exec
+UPDATE privacy_requests
+SET status = 'failed', error = ?, completed_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListExpiredPrivacyExports :many
+-- Finished exports past their expiry whose files are still on disk
+SELECT * FROM privacy_requests
+WHERE kind = 'export' AND status = 'completed' AND file_name != '' AND expires_at < ?
+LIMIT 100;
+
+-- name: NotePrivacyRequestError :exec
+-- Something that went wrong after a request was carried out, like the Clerk user
+-- surviving an account's deletion
+UPDATE privacy_requests SET error = ? WHERE id = ?;
+
+-- name: ClearPrivacyExportFile :exec
+UPDATE privacy_requests SET file_name = '' WHERE id = ?;
@@ -0,0 +1,71 @@
+-- name: ListProductAttributes :many
+SELECT * FROM product_attributes
+WHERE product_id = ?
+ORDER BY display_order ASC, name ASC;
+
+-- name: UpsertProductAttribute :one
+INSERT INTO product_attributes (id, product_id, name, value, value_type, unit, display_order)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (product_id, name) DO UPDATE SET
+    value = excluded.value,
+    value_type = excluded.value_type,
+    unit = excluded.unit,
+    display_order = excluded.display_order,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: UpdateProductAttribute :one
+UPDATE product_attributes
+SET name = ?, value = ?, value_type = ?, unit = ?, display_order = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND product_id = ?
+RETURNING *;
+
+-- name: DeleteProductAttribute :exec
+DELETE FROM product_attributes
+WHERE id = ? AND product_id = ?;
+
+-- name: ListProductCompareSizes :many
+-- Active sizes offered for a product with the cheapest price adjustment per size
+SELECT
+    s.display_name as size_name,
+    CAST(MIN(COALESCE(psku.price_adjustment_cents, 0)) AS INTEGER) as min_adjustment_cents,
+    CAST(MAX(COALESCE(psku.price_adjustment_cents, 0)) AS INTEGER) as max_adjustment_cents
+FROM product_skus psku
+JOIN sizes s ON s.id = psku.size_id
+WHERE psku.product_id = ? AND psku.is_active = TRUE
+GROUP BY s.id, s.display_name
+ORDER BY MIN(s.display_order), s.display_name;
+
+-- name: ListShopAttributeFacets :many
+-- Text specifications shared by active products, for the shop's attribute filter. The
+-- shop's other active filters apply, so each value counts what choosing it would list.
+WITH RECURSIVE category_tree(id) AS (
+    SELECT sqlc.narg(category_id)
+    UNION
+    SELECT c.id
+    FROM categories c
+    JOIN category_tree t ON c.parent_id = t.id
+)
+SELECT
+    pa.name,
+    pa.value,
+    COUNT(DISTINCT pa.product_id) as product_count
+FROM product_attributes pa
+JOIN products p ON p.id = pa.product_id
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND pa.value_type = 'text'
+  AND (sqlc.narg(category_id) IS NULL OR p.category_id IN (SELECT id FROM category_tree))
+  AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+  AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+  AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+  AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+  AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+  AND (sqlc.narg(in_stock) IS NULL OR (
+        COALESCE(p.stock_quantity, 0) > 0
+        OR EXISTS (
+            SELECT 1 FROM product_skus ps
+            WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+        )
+      ) = sqlc.narg(in_stock))
+GROUP BY pa.name, pa.value
+ORDER BY pa.name, pa.value;
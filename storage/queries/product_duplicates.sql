@@ -0,0 +1,30 @@
+-- name: DuplicateProduct :one
+-- Copies a product's settings into a new inactive draft. Stock, Stripe IDs, the
+-- importer source, the generated OG image and featured/new flags stay behind.
+INSERT INTO products (
+    id, name, slug, sku, description, short_description, price_cents, category_id,
+    stock_quantity, low_stock_threshold, weight_grams, dimensions_length_mm,
+    dimensions_width_mm, dimensions_height_mm, lead_time_days, is_active, is_featured,
+    shipping_category, is_premium, is_new, seo_title, seo_description, seo_keywords,
+    disclaimer, has_variants, designer_name, release_date, allow_backorder,
+    backorder_ship_date, max_backorder_quantity, is_preorder, preorder_ship_date,
+    product_type, download_limit, drop_at, purchase_limit, max_per_order
+)
+SELECT
+    CAST(sqlc.arg(new_id) AS TEXT), CAST(sqlc.arg(name) AS TEXT), CAST(sqlc.arg(slug) AS TEXT),
+    CASE WHEN sku IS NULL OR sku = '' THEN sku ELSE sku || '-COPY' END,
+    description, short_description, price_cents, category_id,
+    0, low_stock_threshold, weight_grams, dimensions_length_mm,
+    dimensions_width_mm, dimensions_height_mm, lead_time_days, FALSE, FALSE,
+    shipping_category, is_premium, FALSE, seo_title, seo_description, seo_keywords,
+    disclaimer, has_variants, designer_name, release_date, allow_backorder,
+    backorder_ship_date, max_backorder_quantity, is_preorder, preorder_ship_date,
+    product_type, download_limit, drop_at, purchase_limit, max_per_order
+FROM products
+WHERE products.id = sqlc.arg(source_id)
+RETURNING *;
+
+-- name: ProductNameOrSlugTaken :one
+SELECT EXISTS(
+    SELECT 1 FROM products WHERE name = sqlc.arg(name) OR slug = sqlc.arg(slug)
+) AS taken;
@@ -0,0 +1,59 @@
+-- name: CreateProductQuestion :one
+INSERT INTO product_questions (
+    id,
+    product_id,
+    user_id,
+    asker_name,
+    asker_email,
+    question
+) VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetProductQuestion :one
+SELECT * FROM product_questions WHERE id = ?;
+
+-- name: ListPublishedProductQuestions :many
+SELECT * FROM product_questions
+WHERE product_id = ? AND status = 'published'
+ORDER BY answered_at DESC, created_at DESC;
+
+-- name: ListProductQuestionsByStatus :many
+SELECT
+    pq.*,
+    p.name AS product_name,
+    p.slug AS product_slug
+FROM product_questions pq
+JOIN products p ON p.id = pq.product_id
+WHERE pq.status = ?
+ORDER BY pq.created_at ASC
+LIMIT ? OFFSET ?;
+
+-- name: CountProductQuestionsByStatus :one
+SELECT COUNT(*) FROM product_questions WHERE status = ?;
+
+-- name: CountRecentProductQuestionsByUser :one
+SELECT COUNT(*) FROM product_questions
+WHERE user_id = ? AND created_at >= datetime('now', '-1 day');
+
+-- name: AnswerProductQuestion :one
+UPDATE product_questions
+SET answer = ?,
+    answered_by = ?,
+    status = 'published',
+    answered_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateProductQuestionStatus :exec
+UPDATE product_questions
+SET status = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: MarkProductQuestionNotified :exec
+UPDATE product_questions
+SET notified_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteProductQuestion :exec
+DELETE FROM product_questions WHERE id = ?;
@@ -0,0 +1,28 @@
+-- name: ListProductRelations :many
+SELECT r.*, p.name as related_product_name, p.is_active as related_product_is_active
+FROM product_relations r
+JOIN products p ON p.id = r.related_product_id
+WHERE r.product_id = ?
+ORDER BY r.relation_type ASC, r.display_order ASC, p.name ASC;
+
+-- name: ListRelatedProductsByType :many
+SELECT p.*
+FROM product_relations r
+JOIN products p ON p.id = r.related_product_id
+WHERE r.product_id = ?
+  AND r.relation_type = ?
+  AND p.is_active = TRUE AND p.deleted_at IS NULL
+ORDER BY r.display_order ASC, p.name ASC
+LIMIT ?;
+
+-- name: UpsertProductRelation :exec
+-- Picking a product that's already related moves it to the new type and position
+INSERT INTO product_relations (product_id, related_product_id, relation_type, display_order)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (product_id, related_product_id) DO UPDATE SET
+    relation_type = excluded.relation_type,
+    display_order = excluded.display_order;
+
+-- name: DeleteProductRelation :exec
+DELETE FROM product_relations
+WHERE product_id = ? AND related_product_id = ?;
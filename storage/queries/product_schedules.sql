@@ -0,0 +1,143 @@
+-- name: UpdateProductSchedule :exec
+-- A product waiting to be published is kept off the shop until then
+UPDATE products
+SET publish_at = sqlc.narg(publish_at),
+    unpublish_at = sqlc.narg(unpublish_at),
+    is_active = CASE WHEN CAST(sqlc.arg(hide) AS BOOLEAN) THEN FALSE ELSE is_active END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateProductSaleSettings :exec
+-- Ends any running sale before saving the new one. The scheduler applies it again if
+-- its window is open. The product form saves the regular price first, so a price that
+-- no longer matches the sale price is the new regular price and is kept.
+UPDATE products
+SET price_cents = CASE
+        WHEN regular_price_cents IS NOT NULL AND price_cents = sale_price_cents THEN regular_price_cents
+        ELSE price_cents
+    END,
+    regular_price_cents = NULL,
+    sale_price_cents = sqlc.narg(sale_price_cents),
+    sale_starts_at = sqlc.narg(sale_starts_at),
+    sale_ends_at = sqlc.narg(sale_ends_at),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateSkuSaleSettings :execrows
+-- Ends any running sale on the SKU before saving the new one, like UpdateProductSaleSettings
+UPDATE product_skus
+SET price_adjustment_cents = COALESCE(regular_adjustment_cents, price_adjustment_cents),
+    regular_adjustment_cents = NULL,
+    sale_price_cents = sqlc.narg(sale_price_cents),
+    sale_starts_at = sqlc.narg(sale_starts_at),
+    sale_ends_at = sqlc.narg(sale_ends_at),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND product_id = sqlc.arg(product_id);
+
+-- name: KeepSkuSalePrice :exec
+-- After an admin edits a SKU's adjustment mid-sale, keep the edit as the regular
+-- adjustment and put the sale price back
+UPDATE product_skus
+SET regular_adjustment_cents = COALESCE(price_adjustment_cents, 0),
+    price_adjustment_cents = sale_price_cents - (SELECT p.price_cents FROM products p WHERE p.id = product_skus.product_id),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND regular_adjustment_cents IS NOT NULL
+  AND COALESCE(price_adjustment_cents, 0) != sale_price_cents - (SELECT p.price_cents FROM products p WHERE p.id = product_skus.product_id);
+
+-- name: ListProductSkuSales :many
+SELECT
+    ps.id,
+    ps.sku,
+    ps.price_adjustment_cents,
+    ps.regular_adjustment_cents,
+    ps.sale_price_cents,
+    ps.sale_starts_at,
+    ps.sale_ends_at,
+    pst.name as style_name,
+    s.display_name as size_display_name
+FROM product_skus ps
+JOIN product_styles pst ON pst.id = ps.product_style_id
+JOIN sizes s ON s.id = ps.size_id
+WHERE ps.product_id = ?
+ORDER BY pst.display_order, s.display_order;
+
+-- ============================================
+-- Product scheduler
+-- ============================================
+
+-- name: PublishScheduledProducts :many
+UPDATE products
+SET is_active = TRUE, publish_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE publish_at IS NOT NULL AND publish_at <= sqlc.arg(now) AND deleted_at IS NULL
+RETURNING id;
+
+-- name: UnpublishScheduledProducts :many
+UPDATE products
+SET is_active = FALSE, unpublish_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE unpublish_at IS NOT NULL AND unpublish_at <= sqlc.arg(now)
+RETURNING id;
+
+-- name: EndProductSales :many
+-- A finished sale is cleared so it can't start again
+UPDATE products
+SET price_cents = COALESCE(regular_price_cents, price_cents),
+    regular_price_cents = NULL,
+    sale_price_cents = NULL,
+    sale_starts_at = NULL,
+    sale_ends_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE sale_price_cents IS NOT NULL AND sale_ends_at IS NOT NULL AND sale_ends_at <= sqlc.arg(now)
+RETURNING id;
+
+-- name: StartProductSales :many
+UPDATE products
+SET regular_price_cents = price_cents,
+    price_cents = sale_price_cents,
+    updated_at = CURRENT_TIMESTAMP
+WHERE sale_price_cents IS NOT NULL
+  AND regular_price_cents IS NULL
+  AND (sale_starts_at IS NULL OR sale_starts_at <= sqlc.arg(now))
+  AND (sale_ends_at IS NULL OR sale_ends_at > sqlc.arg(now))
+RETURNING id;
+
+-- name: RepinProductSales :many
+-- Something other than the product form (the inline editor, the API, a sync) set a
+-- new price mid-sale. Keep it as the regular price and put the sale price back.
+UPDATE products
+SET regular_price_cents = price_cents,
+    price_cents = sale_price_cents,
+    updated_at = CURRENT_TIMESTAMP
+WHERE regular_price_cents IS NOT NULL AND price_cents != sale_price_cents
+RETURNING id;
+
+-- name: EndSkuSales :many
+UPDATE product_skus
+SET price_adjustment_cents = COALESCE(regular_adjustment_cents, price_adjustment_cents),
+    regular_adjustment_cents = NULL,
+    sale_price_cents = NULL,
+    sale_starts_at = NULL,
+    sale_ends_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE sale_price_cents IS NOT NULL AND sale_ends_at IS NOT NULL AND sale_ends_at <= sqlc.arg(now)
+RETURNING product_id;
+
+-- name: StartSkuSales :many
+UPDATE product_skus
+SET regular_adjustment_cents = COALESCE(price_adjustment_cents, 0),
+    price_adjustment_cents = sale_price_cents - (SELECT p.price_cents FROM products p WHERE p.id = product_skus.product_id),
+    updated_at = CURRENT_TIMESTAMP
+WHERE sale_price_cents IS NOT NULL
+  AND regular_adjustment_cents IS NULL
+  AND (sale_starts_at IS NULL OR sale_starts_at <= sqlc.arg(now))
+  AND (sale_ends_at IS NULL OR sale_ends_at > sqlc.arg(now))
+RETURNING product_id;
+
+-- name: RepinSkuSales :many
+-- The product's price changed mid-sale, so move the adjustment to keep the SKU at its sale price
+UPDATE product_skus
+SET price_adjustment_cents = sale_price_cents - (SELECT p.price_cents FROM products p WHERE p.id = product_skus.product_id),
+    updated_at = CURRENT_TIMESTAMP
+WHERE regular_adjustment_cents IS NOT NULL
+  AND COALESCE(price_adjustment_cents, 0) != sale_price_cents - (SELECT p.price_cents FROM products p WHERE p.id = product_skus.product_id)
+RETURNING product_id;
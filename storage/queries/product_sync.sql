@@ -0,0 +1,19 @@
+-- name: CreateProductSyncLog :exec
+INSERT INTO product_sync_log (
+    id, api_key_id, api_key_name, product_id, product_name, slug, sku, source_url, action, images_uploaded, message
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListProductSyncLog :many
+SELECT * FROM product_sync_log
+ORDER BY created_at DESC
+LIMIT ?;
+
+-- name: GetProductBySlugAnyStatus :one
+-- Unlike GetProductBySlug, also matches inactive products so a sync never duplicates them
+SELECT * FROM products WHERE slug = ?;
+
+-- name: GetProductBySku :one
+SELECT * FROM products WHERE sku = ? LIMIT 1;
+
+-- name: DeleteProductImagesByProduct :exec
+DELETE FROM product_images WHERE product_id = ?;
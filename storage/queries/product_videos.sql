@@ -0,0 +1,17 @@
+-- name: ListProductVideos :many
+SELECT * FROM product_videos
+WHERE product_id = ?
+ORDER BY display_order ASC, created_at ASC;
+
+-- name: CreateProductVideo :one
+INSERT INTO product_videos (id, product_id, provider, video_ref, title, poster_url, content_type, display_order)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetProductVideo :one
+SELECT * FROM product_videos
+WHERE id = ? AND product_id = ?;
+
+-- name: DeleteProductVideo :exec
+DELETE FROM product_videos
+WHERE id = ? AND product_id = ?;
@@ -10,31 +10,67 @@ LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = TRUE
 WHERE p.id = ?;
 
 -- name: GetProductBySlug :one
-SELECT * FROM products WHERE slug = ? AND is_active = TRUE;
+SELECT * FROM products WHERE slug = ? AND is_active = TRUE AND deleted_at IS NULL;
 
 -- name: ListProducts :many
 SELECT * FROM products
-WHERE is_active = TRUE
+WHERE is_active = TRUE AND deleted_at IS NULL
 ORDER BY created_at DESC;
 
 -- name: ListAllProducts :many
 SELECT * FROM products
+WHERE deleted_at IS NULL
 ORDER BY created_at DESC;
 
+-- name: ListAdminProducts :many
+SELECT * FROM products p
+WHERE (sqlc.narg(category_id) IS NULL OR p.category_id = sqlc.narg(category_id))
+  AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+  AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+  AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+  AND (sqlc.narg(is_active) IS NULL OR COALESCE(p.is_active, FALSE) = sqlc.narg(is_active))
+  AND (p.deleted_at IS NOT NULL) = CAST(sqlc.arg(archived) AS BOOLEAN)
+ORDER BY
+  CASE WHEN sqlc.arg(sort) = 'name_asc' THEN p.name END ASC,
+  CASE WHEN sqlc.arg(sort) = 'name_desc' THEN p.name END DESC,
+  CASE WHEN sqlc.arg(sort) = 'price_asc' THEN p.price_cents END ASC,
+  CASE WHEN sqlc.arg(sort) = 'price_desc' THEN p.price_cents END DESC,
+  p.created_at DESC,
+  p.id
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: CountAdminProducts :one
+SELECT COUNT(*) FROM products p
+WHERE (sqlc.narg(category_id) IS NULL OR p.category_id = sqlc.narg(category_id))
+  AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+  AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+  AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+  AND (sqlc.narg(is_active) IS NULL OR COALESCE(p.is_active, FALSE) = sqlc.narg(is_active))
+  AND (p.deleted_at IS NOT NULL) = CAST(sqlc.arg(archived) AS BOOLEAN);
+
 -- name: ListProductsByCategory :many
-SELECT * FROM products
-WHERE category_id = ? AND is_active = TRUE
-ORDER BY created_at DESC;
+SELECT * FROM products p
+WHERE p.category_id = ? AND p.is_active = TRUE AND p.deleted_at IS NULL
+ORDER BY
+  NOT EXISTS (
+      SELECT 1 FROM category_product_positions cpp
+      WHERE cpp.category_id = p.category_id AND cpp.product_id = p.id
+  ) ASC,
+  (
+      SELECT cpp.position FROM category_product_positions cpp
+      WHERE cpp.category_id = p.category_id AND cpp.product_id = p.id
+  ) ASC,
+  p.created_at DESC;
 
 -- name: ListFeaturedProducts :many
 SELECT * FROM products
-WHERE is_featured = TRUE AND is_active = TRUE
+WHERE is_featured = TRUE AND is_active = TRUE AND deleted_at IS NULL
 ORDER BY created_at DESC;
 
 -- name: SearchProducts :many
 SELECT * FROM products
 WHERE (name LIKE '%' || ? || '%' OR description LIKE '%' || ? || '%')
-AND is_active = TRUE
+AND is_active = TRUE AND deleted_at IS NULL
 ORDER BY created_at DESC;
 
 -- name: CreateProduct :one
@@ -66,8 +102,53 @@ SET name = ?, slug = ?, description = ?, short_description = ?,
 WHERE id = ?
 RETURNING *;
 
+-- name: UpdateProductBackorderSettings :exec
+UPDATE products
+SET allow_backorder = ?, backorder_ship_date = ?, max_backorder_quantity = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateProductPreorderSettings :exec
+UPDATE products
+SET is_preorder = ?, preorder_ship_date = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateProductDropSettings :exec
+UPDATE products
+SET drop_at = ?, purchase_limit = ?, max_per_order = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateProductDigitalSettings :exec
+UPDATE products
+SET product_type = ?, download_limit = ?, is_gift_card = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ArchiveProduct :execrows
+-- Archived products come off sale too, so a restore brings them back as drafts
+UPDATE products
+SET deleted_at = CURRENT_TIMESTAMP, is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: RestoreProduct :execrows
+UPDATE products
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: CountProductOrderItems :one
+SELECT COUNT(*) FROM order_items WHERE product_id = ?;
+
+-- name: DeleteProductCartSnapshots :exec
+DELETE FROM cart_snapshots WHERE product_id = ?;
+
+-- name: ClearScrapedProductImport :exec
+UPDATE scraped_products SET imported_product_id = NULL WHERE imported_product_id = ?;
+
 -- name: DeleteProduct :exec
-DELETE FROM products WHERE id = ?;
+-- Only archived products can be deleted for good
+DELETE FROM products WHERE id = ? AND deleted_at IS NOT NULL;
 
 -- name: UpdateProductStock :exec
 UPDATE products
@@ -84,6 +165,34 @@ SELECT * FROM product_images
 WHERE product_id = ?
 ORDER BY display_order ASC, is_primary DESC;
 
+-- name: ListProductCardImages :many
+-- Listing image candidates for many products in one statement: the primary (or first)
+-- product image, and the primary (or first) image of the product's primary style
+SELECT
+    p.id as product_id,
+    COALESCE(pi.image_url, '') as product_image,
+    COALESCE(psi.image_url, '') as style_image
+FROM products p
+LEFT JOIN product_images pi ON pi.id = (
+    SELECT i.id FROM product_images i
+    WHERE i.product_id = p.id
+    ORDER BY i.is_primary DESC, i.display_order ASC
+    LIMIT 1
+)
+LEFT JOIN product_styles ps ON ps.id = (
+    SELECT s.id FROM product_styles s
+    WHERE s.product_id = p.id
+    ORDER BY s.is_primary DESC, s.display_order ASC, s.created_at ASC
+    LIMIT 1
+)
+LEFT JOIN product_style_images psi ON psi.id = (
+    SELECT si.id FROM product_style_images si
+    WHERE si.product_style_id = ps.id
+    ORDER BY si.is_primary DESC, si.display_order ASC, si.created_at ASC
+    LIMIT 1
+)
+WHERE p.id IN (sqlc.slice('product_ids'));
+
 -- name: GetProductByName :one
 SELECT * FROM products WHERE name = ?;
 
@@ -156,7 +265,7 @@ RETURNING *;
 -- name: ToggleProductActive :one
 UPDATE products
 SET is_active = NOT COALESCE(is_active, TRUE), updated_at = CURRENT_TIMESTAMP
-WHERE id = ?
+WHERE id = ? AND deleted_at IS NULL
 RETURNING *;
 
 -- name: ToggleProductNew :one
@@ -177,7 +286,7 @@ WHERE id = ?;
 
 -- name: ListRelatedProducts :many
 SELECT * FROM products
-WHERE category_id = ? AND id != ? AND is_active = TRUE
+WHERE category_id = ? AND id != ? AND is_active = TRUE AND deleted_at IS NULL
 ORDER BY RANDOM()
 LIMIT ?;
 
@@ -200,7 +309,7 @@ ORDER BY created_at DESC;
 
 -- name: ListProductsByDesigner :many
 SELECT * FROM products
-WHERE designer_name = ?
+WHERE designer_name = ? AND deleted_at IS NULL
 ORDER BY created_at DESC;
 
 -- name: UpdateProductSource :exec
@@ -221,7 +330,7 @@ RETURNING *;
 
 -- name: ListNewProducts :many
 SELECT * FROM products
-WHERE is_new = TRUE AND is_active = TRUE
+WHERE is_new = TRUE AND is_active = TRUE AND deleted_at IS NULL
 ORDER BY release_date DESC, created_at DESC;
 
 -- name: ClearExpiredNewFlags :exec
@@ -232,11 +341,233 @@ WHERE is_new = TRUE
   AND release_date < datetime('now', '-6 months');
 
 -- name: CountProductsByDesigner :one
-SELECT COUNT(*) as count FROM products WHERE designer_name = ?;
+SELECT COUNT(*) as count FROM products WHERE designer_name = ? AND deleted_at IS NULL;
 
 -- name: ListDesigners :many
 SELECT DISTINCT designer_name, COUNT(*) as product_count
 FROM products
-WHERE designer_name IS NOT NULL
+WHERE designer_name IS NOT NULL AND deleted_at IS NULL
 GROUP BY designer_name
 ORDER BY designer_name;
+
+-- Storefront listing with facet filters and pagination.
+-- Every filter is optional: pass NULL to skip it. A category includes the products
+-- of all its subcategories.
+
+-- name: ListShopProducts :many
+WITH RECURSIVE category_tree(id) AS (
+    SELECT sqlc.narg(category_id)
+    UNION
+    SELECT c.id
+    FROM categories c
+    JOIN category_tree t ON c.parent_id = t.id
+)
+SELECT * FROM products p
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND (sqlc.narg(category_id) IS NULL OR p.category_id IN (SELECT id FROM category_tree))
+  AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+  AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+  AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+  AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+  AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+  AND (sqlc.narg(in_stock) IS NULL OR (
+        COALESCE(p.stock_quantity, 0) > 0
+        OR EXISTS (
+            SELECT 1 FROM product_skus ps
+            WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+        )
+      ) = sqlc.narg(in_stock))
+  AND (sqlc.narg(attribute_name) IS NULL OR EXISTS (
+        SELECT 1 FROM product_attributes pa
+        WHERE pa.product_id = p.id AND pa.name = sqlc.narg(attribute_name) AND pa.value = sqlc.narg(attribute_value)
+      ))
+ORDER BY
+  CASE WHEN sqlc.arg(sort) = 'price_asc' THEN p.price_cents END ASC,
+  CASE WHEN sqlc.arg(sort) = 'price_desc' THEN p.price_cents END DESC,
+  CASE WHEN sqlc.arg(sort) = 'name' THEN p.name END ASC,
+  -- Recommended: the owner's arrangement within each category, unplaced products after
+  CASE WHEN sqlc.arg(sort) = 'recommended' THEN NOT EXISTS (
+      SELECT 1 FROM category_product_positions cpp
+      WHERE cpp.category_id = p.category_id AND cpp.product_id = p.id
+  ) END ASC,
+  CASE WHEN sqlc.arg(sort) = 'recommended' THEN (
+      SELECT cpp.position FROM category_product_positions cpp
+      WHERE cpp.category_id = p.category_id AND cpp.product_id = p.id
+  ) END ASC,
+  p.created_at DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: CountShopProducts :one
+WITH RECURSIVE category_tree(id) AS (
+    SELECT sqlc.narg(category_id)
+    UNION
+    SELECT c.id
+    FROM categories c
+    JOIN category_tree t ON c.parent_id = t.id
+)
+SELECT COUNT(*) FROM products p
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND (sqlc.narg(category_id) IS NULL OR p.category_id IN (SELECT id FROM category_tree))
+  AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+  AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+  AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+  AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+  AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+  AND (sqlc.narg(in_stock) IS NULL OR (
+        COALESCE(p.stock_quantity, 0) > 0
+        OR EXISTS (
+            SELECT 1 FROM product_skus ps
+            WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+        )
+      ) = sqlc.narg(in_stock))
+  AND (sqlc.narg(attribute_name) IS NULL OR EXISTS (
+        SELECT 1 FROM product_attributes pa
+        WHERE pa.product_id = p.id AND pa.name = sqlc.narg(attribute_name) AND pa.value = sqlc.narg(attribute_value)
+      ));
+
+-- name: GetShopFacetCounts :one
+-- Counts what each facet would list with the other active filters applied, so none
+-- offers matches that lead to an empty page. The price range leaves out the price
+-- filter, so the range can still be widened.
+WITH RECURSIVE category_tree(id) AS (
+    SELECT sqlc.narg(category_id)
+    UNION
+    SELECT c.id
+    FROM categories c
+    JOIN category_tree t ON c.parent_id = t.id
+)
+SELECT
+    CAST(COALESCE(SUM(CASE WHEN
+        (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+        AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+        AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+        AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+        AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+        AND (sqlc.narg(in_stock) IS NULL OR (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        ) = sqlc.narg(in_stock))
+    THEN 1 ELSE 0 END), 0) AS INTEGER) as total_count,
+    CAST(COALESCE(SUM(CASE WHEN
+        (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        )
+        AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+        AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+        AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+        AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+        AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+    THEN 1 ELSE 0 END), 0) AS INTEGER) as in_stock_count,
+    CAST(COALESCE(SUM(CASE WHEN
+        p.is_new = TRUE
+        AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+        AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+        AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+        AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+        AND (sqlc.narg(in_stock) IS NULL OR (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        ) = sqlc.narg(in_stock))
+    THEN 1 ELSE 0 END), 0) AS INTEGER) as new_count,
+    CAST(COALESCE(SUM(CASE WHEN
+        p.is_featured = TRUE
+        AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+        AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+        AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+        AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+        AND (sqlc.narg(in_stock) IS NULL OR (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        ) = sqlc.narg(in_stock))
+    THEN 1 ELSE 0 END), 0) AS INTEGER) as featured_count,
+    CAST(COALESCE(SUM(CASE WHEN
+        p.is_premium = TRUE
+        AND (sqlc.narg(min_price_cents) IS NULL OR p.price_cents >= sqlc.narg(min_price_cents))
+        AND (sqlc.narg(max_price_cents) IS NULL OR p.price_cents <= sqlc.narg(max_price_cents))
+        AND (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+        AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+        AND (sqlc.narg(in_stock) IS NULL OR (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        ) = sqlc.narg(in_stock))
+    THEN 1 ELSE 0 END), 0) AS INTEGER) as premium_count,
+    CAST(COALESCE(MIN(CASE WHEN
+        (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+        AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+        AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+        AND (sqlc.narg(in_stock) IS NULL OR (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        ) = sqlc.narg(in_stock))
+    THEN p.price_cents END), 0) AS INTEGER) as min_price_cents,
+    CAST(COALESCE(MAX(CASE WHEN
+        (sqlc.narg(is_new) IS NULL OR p.is_new = sqlc.narg(is_new))
+        AND (sqlc.narg(is_featured) IS NULL OR p.is_featured = sqlc.narg(is_featured))
+        AND (sqlc.narg(is_premium) IS NULL OR p.is_premium = sqlc.narg(is_premium))
+        AND (sqlc.narg(in_stock) IS NULL OR (
+            COALESCE(p.stock_quantity, 0) > 0
+            OR EXISTS (
+                SELECT 1 FROM product_skus ps
+                WHERE ps.product_id = p.id AND ps.is_active = TRUE AND ps.stock_quantity > 0
+            )
+        ) = sqlc.narg(in_stock))
+    THEN p.price_cents END), 0) AS INTEGER) as max_price_cents
+FROM products p
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND (sqlc.narg(category_id) IS NULL OR p.category_id IN (SELECT id FROM category_tree))
+  AND (sqlc.narg(attribute_name) IS NULL OR EXISTS (
+        SELECT 1 FROM product_attributes pa
+        WHERE pa.product_id = p.id AND pa.name = sqlc.narg(attribute_name) AND pa.value = sqlc.narg(attribute_value)
+      ));
+
+-- Slug redirects
+
+-- name: RecordProductSlugRedirect :exec
+-- Keeps a slug the product has given up. A slug used before by another product now
+-- redirects to this one.
+INSERT INTO slug_redirects (old_slug, product_id)
+VALUES (?, ?)
+ON CONFLICT (old_slug) DO UPDATE SET
+    product_id = excluded.product_id,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteProductSlugRedirect :exec
+-- Drops a slug from the redirects once a product uses it again
+DELETE FROM slug_redirects WHERE old_slug = ?;
+
+-- name: GetProductBySlugRedirect :one
+-- The product an old slug now redirects to, if it's still for sale
+SELECT p.*
+FROM slug_redirects r
+JOIN products p ON p.id = r.product_id
+WHERE r.old_slug = ? AND p.is_active = TRUE AND p.deleted_at IS NULL;
+
+-- name: ListProductSlugRedirects :many
+-- Old slugs still redirecting to the product. One another product now sells under
+-- is left out, since its URL shows that product instead.
+SELECT r.* FROM slug_redirects r
+WHERE r.product_id = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM products p
+    WHERE p.slug = r.old_slug AND p.is_active = TRUE AND p.deleted_at IS NULL
+  )
+ORDER BY r.created_at DESC, r.old_slug ASC;
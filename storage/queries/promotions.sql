@@ -58,6 +58,13 @@ WHERE id = ?;
 SELECT * FROM promotion_codes
 WHERE code = ?;
 
+-- name: FindPromotionCode :one
+-- Codes are matched the way Stripe matches them, ignoring case
+SELECT * FROM promotion_codes
+WHERE code = ? COLLATE NOCASE
+ORDER BY created_at DESC
+LIMIT 1;
+
 -- name: GetPromotionCodesByCampaign :many
 SELECT * FROM promotion_codes
 WHERE campaign_id = ?
@@ -76,6 +83,11 @@ SET current_uses = current_uses + 1,
     last_used_at = CURRENT_TIMESTAMP
 WHERE id = ?;
 
+-- name: IncrementPromotionCampaignUses :exec
+UPDATE promotion_campaigns
+SET current_uses = COALESCE(current_uses, 0) + 1
+WHERE id = (SELECT campaign_id FROM promotion_codes WHERE promotion_codes.id = ?);
+
 -- name: GetPromotionCodeStats :one
 SELECT
     COUNT(*) as total_codes,
@@ -186,3 +198,36 @@ UPDATE marketing_contacts
 SET popup_shown_at = CURRENT_TIMESTAMP,
     updated_at = CURRENT_TIMESTAMP
 WHERE email = ?;
+
+-- Promotion Rules
+
+-- name: UpdatePromotionCampaignRules :exec
+UPDATE promotion_campaigns
+SET exclude_sale_items = ?,
+    stacking = ?,
+    max_uses_per_customer = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListCampaignExcludedCategories :many
+SELECT category_id FROM promotion_campaign_excluded_categories
+WHERE campaign_id = ?
+ORDER BY category_id;
+
+-- name: DeleteCampaignExcludedCategories :exec
+DELETE FROM promotion_campaign_excluded_categories
+WHERE campaign_id = ?;
+
+-- name: AddCampaignExcludedCategory :exec
+INSERT OR IGNORE INTO promotion_campaign_excluded_categories (campaign_id, category_id)
+VALUES (?, ?);
+
+-- name: CountCustomerCampaignOrders :one
+-- Orders a customer has placed with any of a campaign's codes, matched by account
+-- when there is one and by email otherwise. Cancelled orders don't count.
+SELECT COUNT(*) FROM orders o
+JOIN promotion_codes pc ON pc.id = o.promotion_code_id
+WHERE pc.campaign_id = sqlc.arg(campaign_id)
+  AND (o.user_id = sqlc.arg(user_id) AND sqlc.arg(user_id) != ''
+       OR o.customer_email = sqlc.arg(email) COLLATE NOCASE)
+  AND COALESCE(o.status, '') != 'cancelled';
@@ -0,0 +1,71 @@
+-- name: DeleteProductViewForViewer :exec
+-- Drops the viewer's previous view of a product so each product appears once
+DELETE FROM product_views
+WHERE product_id = sqlc.arg(product_id)
+  AND (user_id = sqlc.narg(user_id) OR session_id = sqlc.narg(session_id));
+
+-- name: RecordProductView :exec
+INSERT INTO product_views (id, product_id, session_id, user_id)
+VALUES (?, ?, ?, ?);
+
+-- name: ListRecentlyViewedProducts :many
+-- A signed-in shopper sees views from both their account and current session
+SELECT p.*
+FROM products p
+JOIN (
+    SELECT product_id, MAX(viewed_at) as last_viewed_at
+    FROM product_views
+    WHERE user_id = sqlc.narg(user_id) OR session_id = sqlc.narg(session_id)
+    GROUP BY product_id
+) recent ON recent.product_id = p.id
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND p.id != sqlc.arg(exclude_product_id)
+ORDER BY recent.last_viewed_at DESC
+LIMIT sqlc.arg(limit);
+
+-- name: PruneProductViews :execrows
+DELETE FROM product_views
+WHERE viewed_at < datetime('now', '-90 days');
+
+-- name: ClearProductRecommendations :exec
+DELETE FROM product_recommendations;
+
+-- name: RebuildProductRecommendations :exec
+-- Counts how many orders contain each pair of products
+INSERT INTO product_recommendations (product_id, recommended_product_id, score)
+SELECT a.product_id, b.product_id, COUNT(DISTINCT a.order_id)
+FROM order_items a
+JOIN order_items b ON a.order_id = b.order_id AND a.product_id != b.product_id
+JOIN orders o ON o.id = a.order_id
+JOIN products pa ON pa.id = a.product_id
+JOIN products pb ON pb.id = b.product_id
+WHERE o.status NOT IN ('cancelled', 'refunded')
+GROUP BY a.product_id, b.product_id;
+
+-- name: ListFrequentlyBoughtTogether :many
+SELECT p.*
+FROM product_recommendations r
+JOIN products p ON p.id = r.recommended_product_id
+WHERE r.product_id = ?
+  AND p.is_active = TRUE AND p.deleted_at IS NULL
+ORDER BY r.score DESC, p.name ASC
+LIMIT ?;
+
+-- name: ListFrequentlyBoughtWithCart :many
+-- Recommendations for everything in a cart, excluding what's already in it
+SELECT p.*
+FROM products p
+JOIN (
+    SELECT r.recommended_product_id, SUM(r.score) as total_score
+    FROM product_recommendations r
+    JOIN cart_items ci ON ci.product_id = r.product_id
+    WHERE ci.user_id = sqlc.narg(user_id) OR ci.session_id = sqlc.narg(session_id)
+    GROUP BY r.recommended_product_id
+) rec ON rec.recommended_product_id = p.id
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND p.id NOT IN (
+    SELECT product_id FROM cart_items
+    WHERE user_id = sqlc.narg(user_id) OR session_id = sqlc.narg(session_id)
+  )
+ORDER BY rec.total_score DESC, p.name ASC
+LIMIT sqlc.arg(limit);
@@ -0,0 +1,110 @@
+-- name: CreateReturn :one
+INSERT INTO returns (id, order_id, user_id, reason, details)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: CreateReturnItem :exec
+INSERT INTO return_items (id, return_id, order_item_id, quantity)
+VALUES (?, ?, ?, ?);
+
+-- name: CreateReturnPhoto :exec
+INSERT INTO return_photos (id, return_id, file_path, original_filename, mime_type, file_size)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetReturn :one
+SELECT * FROM returns WHERE id = ?;
+
+-- name: ListReturnsByOrder :many
+SELECT * FROM returns WHERE order_id = ? ORDER BY created_at DESC;
+
+-- name: ListReturns :many
+SELECT
+    r.*,
+    o.customer_name,
+    o.customer_email,
+    o.total_cents AS order_total_cents,
+    CAST(COALESCE((SELECT SUM(ri.quantity) FROM return_items ri WHERE ri.return_id = r.id), 0) AS INTEGER) AS item_count
+FROM returns r
+JOIN orders o ON o.id = r.order_id
+WHERE (sqlc.narg(status) IS NULL OR r.status = sqlc.narg(status))
+ORDER BY r.created_at DESC
+LIMIT 200;
+
+-- name: ListReturnItems :many
+SELECT
+    ri.*,
+    oi.product_id,
+    oi.product_sku_id,
+    oi.product_name,
+    oi.product_sku,
+    oi.unit_price_cents,
+    oi.quantity AS ordered_quantity
+FROM return_items ri
+JOIN order_items oi ON oi.id = ri.order_item_id
+WHERE ri.return_id = ?
+ORDER BY oi.product_name;
+
+-- name: GetReturnItem :one
+SELECT
+    ri.*,
+    oi.product_id,
+    oi.product_sku_id,
+    oi.product_name
+FROM return_items ri
+JOIN order_items oi ON oi.id = ri.order_item_id
+WHERE ri.id = ? AND ri.return_id = ?;
+
+-- name: ListReturnPhotos :many
+SELECT * FROM return_photos WHERE return_id = ? ORDER BY created_at;
+
+-- name: GetReturnPhoto :one
+SELECT * FROM return_photos WHERE id = ? AND return_id = ?;
+
+-- name: GetOrderReturnedQuantities :many
+-- Units of each order line already on a return that wasn't rejected
+SELECT
+    ri.order_item_id,
+    CAST(SUM(ri.quantity) AS INTEGER) AS quantity
+FROM return_items ri
+JOIN returns r ON r.id = ri.return_id
+WHERE r.order_id = ? AND r.status != 'rejected'
+GROUP BY ri.order_item_id;
+
+-- name: GetOrderRefundedCents :one
+-- Everything refunded against an order so far, for returns and from the order page
+SELECT CAST(
+    (SELECT COALESCE(SUM(refund_cents), 0) FROM returns WHERE returns.order_id = sqlc.arg(order_id))
+    + (SELECT COALESCE(SUM(amount_cents), 0) FROM order_refunds WHERE order_refunds.order_id = sqlc.arg(order_id))
+AS INTEGER);
+
+-- name: UpdateReturnStatus :execrows
+UPDATE returns
+SET status = sqlc.arg(status),
+    admin_notes = sqlc.arg(admin_notes),
+    decided_at = CASE WHEN sqlc.arg(status) IN ('approved', 'rejected') THEN CURRENT_TIMESTAMP ELSE decided_at END,
+    received_at = CASE WHEN sqlc.arg(status) = 'received' THEN CURRENT_TIMESTAMP ELSE received_at END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status);
+
+-- name: SetReturnLabel :exec
+UPDATE returns
+SET easypost_shipment_id = ?,
+    return_label_url = ?,
+    return_tracking_number = ?,
+    return_label_cost_cents = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetReturnRefund :execrows
+UPDATE returns
+SET status = 'refunded',
+    refund_cents = ?,
+    stripe_refund_id = ?,
+    refunded_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'received';
+
+-- name: AddReturnItemRestocked :execrows
+UPDATE return_items
+SET restocked_quantity = restocked_quantity + sqlc.arg(quantity)
+WHERE id = sqlc.arg(id) AND restocked_quantity + sqlc.arg(quantity) <= quantity;
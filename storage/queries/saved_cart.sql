@@ -0,0 +1,49 @@
+-- name: ListSavedCartItems :many
+-- A signed-in shopper's saved lines plus any still keyed to this browser session
+SELECT
+    sci.id,
+    sci.quantity,
+    sci.product_id,
+    sci.product_sku_id,
+    p.name,
+    p.price_cents + COALESCE(ps.price_adjustment_cents, 0) AS price_cents,
+    COALESCE(
+        CASE WHEN psi.image_url IS NOT NULL THEN 'styles/' || psi.image_url END,
+        pi.image_url,
+        ''
+    ) AS image_url,
+    COALESCE(pst.name || ' - ' || sz.display_name, '') AS variant_name,
+    COALESCE(ps.stock_quantity, p.stock_quantity, 0) AS stock_quantity,
+    COALESCE(p.low_stock_threshold, 5) AS low_stock_threshold,
+    CAST(COALESCE(p.is_active, TRUE) AND p.deleted_at IS NULL AND COALESCE(ps.is_active, TRUE) AS INTEGER) AS is_active,
+    p.allow_backorder,
+    p.is_preorder,
+    p.product_type,
+    COALESCE(sci.personalization, '') AS personalization
+FROM saved_cart_items sci
+JOIN products p ON sci.product_id = p.id
+LEFT JOIN product_skus ps ON sci.product_sku_id = ps.id
+LEFT JOIN product_styles pst ON ps.product_style_id = pst.id
+LEFT JOIN sizes sz ON ps.size_id = sz.id
+LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
+LEFT JOIN product_style_images psi ON psi.product_style_id = pst.id AND psi.is_primary = TRUE
+WHERE sci.user_id = sqlc.narg(user_id)
+   OR (sci.user_id IS NULL AND sci.session_id = sqlc.narg(session_id))
+ORDER BY sci.created_at DESC;
+
+-- name: GetSavedCartItem :one
+SELECT * FROM saved_cart_items
+WHERE id = sqlc.arg(id)
+  AND (user_id = sqlc.narg(user_id) OR (user_id IS NULL AND session_id = sqlc.narg(session_id)));
+
+-- name: CreateSavedCartItem :exec
+INSERT INTO saved_cart_items (id, session_id, user_id, product_id, product_sku_id, quantity, personalization)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteSavedCartItem :exec
+DELETE FROM saved_cart_items WHERE id = ?;
+
+-- name: TransferSavedCartItemsToUser :exec
+UPDATE saved_cart_items
+SET user_id = sqlc.arg(user_id), session_id = NULL
+WHERE session_id = sqlc.arg(session_id) AND user_id IS NULL;
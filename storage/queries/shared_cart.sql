@@ -0,0 +1,76 @@
+-- name: CreateSharedCart :exec
+INSERT INTO shared_carts (id, token, name, user_id, session_id)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: CreateSharedCartItem :exec
+INSERT INTO shared_cart_items (id, shared_cart_id, product_id, product_sku_id, quantity, personalization, position)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetSharedCartByToken :one
+SELECT * FROM shared_carts WHERE token = ?;
+
+-- name: ListSharedCartItems :many
+SELECT
+    sci.id,
+    sci.quantity,
+    sci.product_id,
+    sci.product_sku_id,
+    p.name,
+    p.slug,
+    p.price_cents + COALESCE(ps.price_adjustment_cents, 0) AS price_cents,
+    COALESCE(
+        CASE WHEN psi.image_url IS NOT NULL THEN 'styles/' || psi.image_url END,
+        pi.image_url,
+        ''
+    ) AS image_url,
+    COALESCE(pst.name || ' - ' || sz.display_name, '') AS variant_name,
+    COALESCE(ps.stock_quantity, p.stock_quantity, 0) AS stock_quantity,
+    COALESCE(p.low_stock_threshold, 5) AS low_stock_threshold,
+    CAST(COALESCE(p.is_active, TRUE) AND p.deleted_at IS NULL AND COALESCE(ps.is_active, TRUE) AS INTEGER) AS is_active,
+    p.allow_backorder,
+    p.is_preorder,
+    p.product_type,
+    COALESCE(sci.personalization, '') AS personalization
+FROM shared_cart_items sci
+JOIN products p ON sci.product_id = p.id
+LEFT JOIN product_skus ps ON sci.product_sku_id = ps.id
+LEFT JOIN product_styles pst ON ps.product_style_id = pst.id
+LEFT JOIN sizes sz ON ps.size_id = sz.id
+LEFT JOIN product_images pi ON p.id = pi.product_id AND pi.is_primary = TRUE
+LEFT JOIN product_style_images psi ON psi.product_style_id = pst.id AND psi.is_primary = TRUE
+WHERE sci.shared_cart_id = ?
+ORDER BY sci.position;
+
+-- name: ListSharedCartsForOwner :many
+-- Lists a shopper has shared, newest first, with how many lines each holds
+SELECT
+    sc.id,
+    sc.token,
+    sc.name,
+    sc.load_count,
+    sc.created_at,
+    (SELECT COUNT(*) FROM shared_cart_items sci WHERE sci.shared_cart_id = sc.id) AS item_count
+FROM shared_carts sc
+WHERE sc.user_id = sqlc.narg(user_id)
+   OR (sc.user_id IS NULL AND sc.session_id = sqlc.narg(session_id))
+ORDER BY sc.created_at DESC;
+
+-- name: DeleteSharedCartForOwner :execrows
+DELETE FROM shared_carts
+WHERE id = sqlc.arg(id)
+  AND (user_id = sqlc.narg(user_id) OR (user_id IS NULL AND session_id = sqlc.narg(session_id)));
+
+-- name: IncrementSharedCartLoads :exec
+UPDATE shared_carts SET load_count = load_count + 1 WHERE id = ?;
+
+-- name: TransferSharedCartsToUser :exec
+UPDATE shared_carts
+SET user_id = sqlc.arg(user_id), session_id = NULL
+WHERE session_id = sqlc.arg(session_id) AND user_id IS NULL;
+
+-- name: ListCartLinesToShare :many
+-- The shopper's cart lines in the order they were added, for copying into a shared cart
+SELECT * FROM cart_items
+WHERE user_id = sqlc.narg(user_id)
+   OR (user_id IS NULL AND session_id = sqlc.narg(session_id))
+ORDER BY created_at ASC;
@@ -174,6 +174,7 @@ WITH resolved AS (
     LEFT JOIN product_skus ps ON ci.product_sku_id = ps.id
     LEFT JOIN size_charts sc ON sc.size_id = ps.size_id
     WHERE (ci.session_id = ? OR ci.user_id = ?)
+      AND p.product_type = 'physical'
 )
 SELECT
     SUM(CASE WHEN category = 'small' THEN quantity ELSE 0 END) as small_items,
@@ -222,8 +223,9 @@ INSERT INTO session_shipping_selection (
     id, session_id, rate_id, shipment_id, carrier_name, service_name,
     price_cents, shipping_amount_cents, box_cost_cents, handling_cost_cents, box_sku,
     delivery_days, estimated_date,
-    cart_snapshot_json, shipping_address_json, is_valid
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    cart_snapshot_json, shipping_address_json, is_valid,
+    address_verification_status, address_verification_json, address_verified_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetSessionShippingSelection :one
@@ -235,6 +237,7 @@ SET rate_id = ?, shipment_id = ?, carrier_name = ?, service_name = ?,
     price_cents = ?, shipping_amount_cents = ?, box_cost_cents = ?, handling_cost_cents = ?, box_sku = ?,
     delivery_days = ?, estimated_date = ?,
     cart_snapshot_json = ?, shipping_address_json = ?, is_valid = ?,
+    address_verification_status = ?, address_verification_json = ?, address_verified_at = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE session_id = ?
 RETURNING *;
@@ -0,0 +1,33 @@
+-- SMS Notification Queries
+
+-- name: SetOrderSMSConsent :exec
+UPDATE orders
+SET sms_phone = sqlc.arg(sms_phone),
+    sms_consent_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: CountSMSOptOut :one
+SELECT COUNT(*) FROM sms_opt_outs WHERE phone = ?;
+
+-- name: CreateSMSOptOut :exec
+INSERT INTO sms_opt_outs (phone)
+VALUES (?)
+ON CONFLICT (phone) DO UPDATE SET opted_out_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteSMSOptOut :exec
+DELETE FROM sms_opt_outs WHERE phone = ?;
+
+-- name: CreateSMSMessage :exec
+INSERT INTO sms_messages (
+    id, order_id, phone, event, body, status, provider_message_id, error
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CountSentSMSForOrderEvent :one
+SELECT COUNT(*) FROM sms_messages
+WHERE order_id = ? AND event = ? AND status = 'sent';
+
+-- name: ListOrderSMSMessages :many
+SELECT * FROM sms_messages
+WHERE order_id = ?
+ORDER BY created_at DESC;
@@ -86,7 +86,7 @@ LEFT JOIN categories c ON p.category_id = c.id
 LEFT JOIN social_media_tasks smt ON p.id = smt.product_id
 LEFT JOIN order_items oi ON p.id = oi.product_id
 LEFT JOIN orders o ON oi.order_id = o.id AND o.status IN ('paid', 'processing', 'shipped', 'delivered')
-WHERE p.is_active = TRUE
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
 GROUP BY p.id, p.name, p.slug, p.price_cents, p.category_id, p.is_active, p.is_featured, c.name
 HAVING COUNT(DISTINCT CASE WHEN smt.status = 'posted' THEN smt.platform END) < 4
 ORDER BY times_sold DESC, p.name;
@@ -99,7 +99,7 @@ SELECT
 FROM products p
 LEFT JOIN order_items oi ON oi.product_id = p.id
 LEFT JOIN orders o ON oi.order_id = o.id
-WHERE p.is_active = TRUE
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
     AND (o.status IS NULL OR o.status IN ('paid', 'processing', 'shipped', 'delivered'))
 GROUP BY p.id
 ORDER BY times_sold DESC, total_quantity_sold DESC
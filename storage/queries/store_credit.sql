@@ -0,0 +1,100 @@
+-- name: GetStoreCreditBalance :one
+SELECT balance_cents FROM store_credit_balances WHERE user_id = ?;
+
+-- name: CreditStoreCredit :one
+-- Adds amount_cents to an account, opening its balance on the first credit, and
+-- returns the new balance
+INSERT INTO store_credit_balances (user_id, balance_cents)
+VALUES (sqlc.arg(user_id), sqlc.arg(amount_cents))
+ON CONFLICT(user_id) DO UPDATE SET
+    balance_cents = store_credit_balances.balance_cents + excluded.balance_cents,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING balance_cents;
+
+-- name: DebitStoreCredit :one
+-- Takes amount_cents off an account that still has it, returning the new balance.
+-- No row comes back when the balance is too low.
+UPDATE store_credit_balances
+SET balance_cents = balance_cents - sqlc.arg(amount_cents),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id)
+  AND balance_cents >= sqlc.arg(amount_cents)
+RETURNING balance_cents;
+
+-- name: CreateStoreCreditTransaction :exec
+INSERT INTO store_credit_transactions (
+    id, user_id, kind, reason, amount_cents, balance_after_cents,
+    order_id, checkout_session_id, note, created_by_user_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListStoreCreditTransactions :many
+SELECT
+    t.*,
+    COALESCE(u.full_name, '') AS created_by_name
+FROM store_credit_transactions t
+LEFT JOIN users u ON t.created_by_user_id = u.id
+WHERE t.user_id = sqlc.arg(user_id)
+ORDER BY t.created_at DESC, t.rowid DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListRecentStoreCreditTransactions :many
+SELECT
+    t.*,
+    c.full_name AS customer_name,
+    c.email AS customer_email,
+    COALESCE(u.full_name, '') AS created_by_name
+FROM store_credit_transactions t
+JOIN users c ON t.user_id = c.id
+LEFT JOIN users u ON t.created_by_user_id = u.id
+ORDER BY t.created_at DESC, t.rowid DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListStoreCreditAccounts :many
+-- Accounts with credit left, largest balance first
+SELECT
+    b.user_id,
+    b.balance_cents,
+    b.updated_at,
+    u.full_name,
+    u.email
+FROM store_credit_balances b
+JOIN users u ON b.user_id = u.id
+WHERE b.balance_cents > 0
+ORDER BY b.balance_cents DESC, u.full_name
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetStoreCreditTotals :one
+SELECT
+    COUNT(*) AS account_count,
+    CAST(COALESCE(SUM(balance_cents), 0) AS INTEGER) AS outstanding_cents
+FROM store_credit_balances
+WHERE balance_cents > 0;
+
+-- name: SetStoreCreditTransactionSession :exec
+UPDATE store_credit_transactions SET checkout_session_id = ? WHERE id = ?;
+
+-- name: ListHeldStoreCreditRedemptions :many
+-- Redemptions held for a checkout session that has neither become an order
+-- nor been released
+SELECT * FROM store_credit_transactions t
+WHERE t.checkout_session_id = sqlc.arg(checkout_session_id)
+  AND t.kind = 'redeem'
+  AND t.order_id IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM store_credit_transactions r
+      WHERE r.user_id = t.user_id
+        AND r.checkout_session_id = t.checkout_session_id
+        AND r.kind = 'release'
+  );
+
+-- name: AttachStoreCreditRedemptionsToOrder :exec
+UPDATE store_credit_transactions
+SET order_id = sqlc.arg(order_id)
+WHERE checkout_session_id = sqlc.arg(checkout_session_id)
+  AND kind = 'redeem'
+  AND order_id IS NULL;
+
+-- name: SetOrderStoreCredit :exec
+UPDATE orders
+SET store_credit_cents = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
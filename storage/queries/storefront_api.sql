@@ -0,0 +1,53 @@
+-- Read-only queries backing the /api/v1/storefront API
+
+-- name: ListStorefrontProducts :many
+SELECT
+    p.id,
+    p.name,
+    p.slug,
+    p.short_description,
+    p.price_cents,
+    p.category_id,
+    p.sku,
+    p.stock_quantity,
+    p.has_variants,
+    p.product_type,
+    p.updated_at,
+    COALESCE(pi.image_url, '') AS primary_image_url
+FROM products p
+LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = TRUE
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+  AND (sqlc.narg(category_id) IS NULL OR p.category_id = sqlc.narg(category_id))
+ORDER BY p.name ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountStorefrontProducts :one
+SELECT COUNT(*) FROM products
+WHERE is_active = TRUE AND deleted_at IS NULL
+  AND (sqlc.narg(category_id) IS NULL OR category_id = sqlc.narg(category_id));
+
+-- name: ListStorefrontProductStock :many
+SELECT
+    id,
+    slug,
+    sku,
+    stock_quantity,
+    has_variants,
+    product_type,
+    allow_backorder,
+    is_preorder
+FROM products
+WHERE is_active = TRUE AND deleted_at IS NULL
+ORDER BY name ASC;
+
+-- name: ListStorefrontSkuStock :many
+SELECT
+    ps.id,
+    ps.product_id,
+    ps.sku,
+    ps.stock_quantity,
+    ps.is_active
+FROM product_skus ps
+JOIN products p ON p.id = ps.product_id
+WHERE p.is_active = TRUE AND p.deleted_at IS NULL
+ORDER BY ps.product_id, ps.sku;
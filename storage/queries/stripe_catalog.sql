@@ -0,0 +1,36 @@
+-- name: UpdateProductStripeIDs :exec
+UPDATE products
+SET stripe_product_id = ?, stripe_price_id = ?, stripe_synced_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateProductSkuStripePrice :exec
+UPDATE product_skus
+SET stripe_price_id = ?
+WHERE id = ?;
+
+-- name: ListStripeCatalogProducts :many
+SELECT
+    p.id,
+    p.name,
+    p.price_cents,
+    p.is_active,
+    p.has_variants,
+    COALESCE(p.stripe_product_id, '') as stripe_product_id,
+    COALESCE(p.stripe_price_id, '') as stripe_price_id,
+    p.stripe_synced_at
+FROM products p
+WHERE p.deleted_at IS NULL
+ORDER BY p.name;
+
+-- name: ListStripeCatalogSkus :many
+SELECT
+    ps.id,
+    ps.product_id,
+    ps.sku,
+    CAST(p.price_cents + COALESCE(ps.price_adjustment_cents, 0) AS INTEGER) as price_cents,
+    ps.is_active,
+    COALESCE(ps.stripe_price_id, '') as stripe_price_id
+FROM product_skus ps
+JOIN products p ON p.id = ps.product_id
+WHERE p.deleted_at IS NULL
+ORDER BY ps.product_id, ps.sku;
@@ -40,7 +40,7 @@ ORDER BY t.name ASC;
 SELECT p.*
 FROM products p
 JOIN product_tags pt ON pt.product_id = p.id
-WHERE pt.tag_id = ?
+WHERE pt.tag_id = ? AND p.deleted_at IS NULL
 ORDER BY p.name ASC;
 
 -- name: GetProductsByTagSlug :many
@@ -48,7 +48,7 @@ SELECT p.*
 FROM products p
 JOIN product_tags pt ON pt.product_id = p.id
 JOIN tags t ON t.id = pt.tag_id
-WHERE t.slug = ?
+WHERE t.slug = ? AND p.deleted_at IS NULL
 ORDER BY p.name ASC;
 
 -- name: ClearProductTags :exec
@@ -0,0 +1,32 @@
+-- Tax reporting. Cancelled and refunded orders are left out, matching the dashboard.
+-- Dates are compared as text so both stored timestamp formats work.
+
+-- name: GetTaxSummaryByStateMonth :many
+SELECT
+    CAST(substr(created_at, 1, 7) AS TEXT) as month,
+    CAST(UPPER(TRIM(shipping_state)) AS TEXT) as state,
+    COUNT(*) as order_count,
+    CAST(COALESCE(SUM(subtotal_cents), 0) AS INTEGER) as subtotal_cents,
+    CAST(COALESCE(SUM(shipping_cents), 0) AS INTEGER) as shipping_cents,
+    CAST(COALESCE(SUM(tax_cents), 0) AS INTEGER) as tax_cents,
+    CAST(COALESCE(SUM(total_cents), 0) AS INTEGER) as total_cents
+FROM orders
+WHERE created_at >= sqlc.arg(start_date)
+    AND created_at < sqlc.arg(end_date)
+    AND status NOT IN ('cancelled', 'refunded')
+GROUP BY month, state
+ORDER BY month ASC, state ASC;
+
+-- name: ListTaxOrdersForMonth :many
+SELECT
+    id,
+    customer_name,
+    CAST(UPPER(TRIM(shipping_state)) AS TEXT) as state,
+    tax_cents,
+    total_cents,
+    COALESCE(stripe_checkout_session_id, '') as stripe_checkout_session_id,
+    created_at
+FROM orders
+WHERE substr(created_at, 1, 7) = sqlc.arg(month)
+    AND status NOT IN ('cancelled', 'refunded')
+ORDER BY created_at ASC;
@@ -0,0 +1,21 @@
+-- name: GetUserDefaultAddress :one
+SELECT * FROM user_default_addresses WHERE user_id = ?;
+
+-- name: UpsertUserDefaultAddress :exec
+INSERT INTO user_default_addresses (
+    user_id, name, address_line1, address_line2, city_locality, state_province,
+    postal_code, country_code, verification_status
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id) DO UPDATE SET
+    name = excluded.name,
+    address_line1 = excluded.address_line1,
+    address_line2 = excluded.address_line2,
+    city_locality = excluded.city_locality,
+    state_province = excluded.state_province,
+    postal_code = excluded.postal_code,
+    country_code = excluded.country_code,
+    verification_status = excluded.verification_status,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteUserDefaultAddress :exec
+DELETE FROM user_default_addresses WHERE user_id = ?;
@@ -80,7 +80,7 @@ ON CONFLICT(clerk_id) DO UPDATE SET
 RETURNING *;
 
 -- name: ListUsers :many
-SELECT * FROM users ORDER BY created_at DESC;
+SELECT * FROM users WHERE id != '' ORDER BY created_at DESC;
 
 -- name: DeleteUser :exec
 DELETE FROM users WHERE id = ?;
@@ -210,7 +210,7 @@ SELECT
 FROM user_favorites f
 JOIN products p ON p.id = f.product_id
 LEFT JOIN product_images pi ON pi.product_id = p.id AND pi.is_primary = true
-WHERE f.user_id = ?
+WHERE f.user_id = ? AND p.deleted_at IS NULL
 ORDER BY f.created_at DESC
 LIMIT 10;
 
@@ -263,6 +263,7 @@ SELECT
     psku.id as product_sku_id,
     psku.sku,
     psku.price_adjustment_cents,
+    psku.regular_adjustment_cents,
     psku.stock_quantity,
     psku.is_active,
     s.id as size_id,
@@ -321,6 +322,12 @@ UPDATE product_skus
 SET is_active = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?;
 
+-- name: UpdateSkuMatrixCell :execrows
+-- Bulk variant editor save; scoped to the product so stray IDs update nothing
+UPDATE product_skus
+SET price_adjustment_cents = ?, stock_quantity = ?, is_active = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND product_id = ?;
+
 -- name: CountStyleSkus :one
 -- Count SKUs for a specific style
 SELECT COUNT(*) as sku_count
@@ -356,3 +363,37 @@ JOIN product_style_images psi ON psi.product_style_id = ps.id AND psi.is_primary
 WHERE ps.product_id = ?
 ORDER BY ps.is_primary DESC, ps.display_order ASC
 LIMIT 10;
+
+-- ============================================
+-- Product card queries (batched for listings)
+-- ============================================
+
+-- name: ListProductCardPriceRanges :many
+-- Price range and stock across each variant product's active SKUs, with the lowest
+-- price before any running sales for the card's strike-through
+SELECT
+    p.id as product_id,
+    CAST(MIN(p.price_cents + COALESCE(psku.price_adjustment_cents, 0)) AS INTEGER) as min_price_cents,
+    CAST(MAX(p.price_cents + COALESCE(psku.price_adjustment_cents, 0)) AS INTEGER) as max_price_cents,
+    CAST(MIN(COALESCE(p.regular_price_cents, p.price_cents) + COALESCE(psku.regular_adjustment_cents, psku.price_adjustment_cents, 0)) AS INTEGER) as regular_min_price_cents,
+    COUNT(psku.id) as sku_count,
+    CAST(COALESCE(SUM(CASE WHEN COALESCE(psku.stock_quantity, 0) > 0 THEN 1 ELSE 0 END), 0) AS INTEGER) as in_stock_sku_count
+FROM products p
+JOIN product_skus psku ON psku.product_id = p.id AND psku.is_active = TRUE
+WHERE p.id IN (sqlc.slice('product_ids'))
+GROUP BY p.id;
+
+-- name: ListProductCardSwatches :many
+-- Styles with at least one active SKU, with their primary image and how many sizes are in stock
+SELECT
+    ps.product_id,
+    ps.id as style_id,
+    ps.name as style_name,
+    COALESCE(psi.image_url, '') as image_url,
+    CAST(SUM(CASE WHEN COALESCE(psku.stock_quantity, 0) > 0 THEN 1 ELSE 0 END) AS INTEGER) as in_stock_sku_count
+FROM product_styles ps
+JOIN product_skus psku ON psku.product_style_id = ps.id AND psku.is_active = TRUE
+LEFT JOIN product_style_images psi ON psi.product_style_id = ps.id AND psi.is_primary = TRUE
+WHERE ps.product_id IN (sqlc.slice('product_ids'))
+GROUP BY ps.id
+ORDER BY ps.product_id, ps.is_primary DESC, ps.display_order ASC, ps.name ASC;
@@ -2,25 +2,20 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 
-	_ "modernc.org/sqlite"
-
-	"github.com/loganlanou/logans3d-v4/storage/db"
+	"github.com/loganlanou/logans3d-v4/storage"
 )
 
 func main() {
 	// Open database connection
-	database, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer database.Close()
-
-	// Create queries object
-	queries := db.New(database)
+	defer store.Close()
+	queries := store.Queries
 	ctx := context.Background()
 
 	// Get all products
@@ -2,14 +2,12 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"flag"
 	"fmt"
 	"log"
 	"math"
 
-	_ "modernc.org/sqlite"
-
+	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
 
@@ -20,19 +18,12 @@ func main() {
 	flag.Parse()
 
 	// Open database connection
-	database, err := sql.Open("sqlite", dbPath)
+	store, err := storage.New(dbPath)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer database.Close()
-
-	// Enable foreign keys
-	if _, err := database.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		log.Fatal(err)
-	}
-
-	// Create queries object using SQLC generated code
-	queries := db.New(database)
+	defer store.Close()
+	queries := store.Queries
 	ctx := context.Background()
 
 	// Get all products
@@ -7,21 +7,19 @@ import (
 	"log"
 
 	"github.com/google/uuid"
-	_ "modernc.org/sqlite"
 
+	"github.com/loganlanou/logans3d-v4/storage"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
 
 func main() {
 	// Open database connection
-	database, err := sql.Open("sqlite", "./data/database.db")
+	store, err := storage.New("./data/database.db")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer database.Close()
-
-	// Create queries object
-	queries := db.New(database)
+	defer store.Close()
+	queries := store.Queries
 	ctx := context.Background()
 
 	// Create a category first
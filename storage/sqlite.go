@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// BusyTimeout is how long a connection waits for another's write lock before failing
+// with SQLITE_BUSY, which covers a script writing while the server is running
+const BusyTimeout = 10 * time.Second
+
+// Pragmas are set on every connection opened to the database. The driver runs them
+// each time it opens a connection for the pool, so they hold on all of them.
+var Pragmas = []string{
+	"foreign_keys(1)",
+	"journal_mode(WAL)",
+	"synchronous(NORMAL)",
+	fmt.Sprintf("busy_timeout(%d)", BusyTimeout.Milliseconds()),
+}
+
+// DSN is the modernc.org/sqlite data source for the database at path, with Pragmas
+func DSN(path string) string {
+	params := make([]string, 0, len(Pragmas))
+	for _, pragma := range Pragmas {
+		params = append(params, "_pragma="+pragma)
+	}
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + strings.Join(params, "&")
+}
+
+// Open connects to the database at path with the standard pragmas, without running
+// migrations. It's for tools that manage the migrations themselves; everything else
+// uses New, which also migrates.
+func Open(path string) (*sql.DB, error) {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	sqliteDB, err := sql.Open("sqlite", DSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := sqliteDB.Ping(); err != nil {
+		sqliteDB.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return sqliteDB, nil
+}
+
+// ConnectionStats is the connection pool's state and the pragmas in effect, as read
+// from one of its connections
+type ConnectionStats struct {
+	Driver        string `json:"driver"`
+	JournalMode   string `json:"journal_mode"`
+	ForeignKeys   bool   `json:"foreign_keys"`
+	Synchronous   string `json:"synchronous"`
+	BusyTimeoutMS int64  `json:"busy_timeout_ms"`
+
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMS     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// synchronousModes names PRAGMA synchronous's values
+var synchronousModes = map[int64]string{0: "OFF", 1: "NORMAL", 2: "FULL", 3: "EXTRA"}
+
+// ConnectionStats reports the pool and the pragmas a connection has. A pragma that
+// doesn't match Pragmas means a connection was opened some other way.
+func (s *Storage) ConnectionStats(ctx context.Context) (ConnectionStats, error) {
+	stats := ConnectionStats{Driver: "modernc.org/sqlite"}
+
+	// The pool is read before taking the connection the pragmas are read on, which
+	// would otherwise count as in use
+	pool := s.db.Stats()
+	stats.MaxOpenConnections = pool.MaxOpenConnections
+	stats.OpenConnections = pool.OpenConnections
+	stats.InUse = pool.InUse
+	stats.Idle = pool.Idle
+	stats.WaitCount = pool.WaitCount
+	stats.WaitDurationMS = pool.WaitDuration.Milliseconds()
+	stats.MaxIdleClosed = pool.MaxIdleClosed
+	stats.MaxIdleTimeClosed = pool.MaxIdleTimeClosed
+	stats.MaxLifetimeClosed = pool.MaxLifetimeClosed
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var synchronous int64
+	for _, pragma := range []struct {
+		name string
+		dest any
+	}{
+		{"journal_mode", &stats.JournalMode},
+		{"foreign_keys", &stats.ForeignKeys},
+		{"synchronous", &synchronous},
+		{"busy_timeout", &stats.BusyTimeoutMS},
+	} {
+		if err := conn.QueryRowContext(ctx, "PRAGMA "+pragma.name).Scan(pragma.dest); err != nil {
+			return stats, fmt.Errorf("failed to read %s: %w", pragma.name, err)
+		}
+	}
+	stats.Synchronous = synchronousModes[synchronous]
+	return stats, nil
+}
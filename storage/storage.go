@@ -1,15 +1,15 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
 	"log/slog"
-	"path/filepath"
 
+	"github.com/loganlanou/logans3d-v4/internal/tracing"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 	"github.com/pressly/goose/v3"
-	_ "modernc.org/sqlite"
 )
 
 //go:embed migrations/*.sql
@@ -20,37 +20,46 @@ type Storage struct {
 	Queries *db.Queries
 }
 
+// Options say how Connect treats the database's migrations
+type Options struct {
+	// Migrate applies pending migrations. Without it a database missing any is refused.
+	Migrate bool
+}
+
+// New opens the database and applies any pending migrations
 func New(dbPath string) (*Storage, error) {
-	// Ensure the directory exists
-	dir := filepath.Dir(dbPath)
-	if err := ensureDir(dir); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
-	}
+	return Connect(dbPath, Options{Migrate: true})
+}
 
-	// Open SQLite database with proper settings
-	sqliteDB, err := sql.Open("sqlite", dbPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)")
+// Connect opens the database after checking its migrations against the binary's. A
+// database that's ahead of the binary is always refused.
+func Connect(dbPath string, opts Options) (*Storage, error) {
+	sqliteDB, err := Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	// Test the connection
-	if err := sqliteDB.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	state, err := Migrations(context.Background(), sqliteDB)
+	if err != nil {
+		sqliteDB.Close()
+		return nil, err
 	}
-
-	// Run database migrations automatically on startup
-	slog.Info("running database migrations", "database", dbPath)
-	goose.SetBaseFS(embedMigrations)
-	if err := goose.SetDialect("sqlite3"); err != nil {
-		return nil, fmt.Errorf("failed to set goose dialect: %w", err)
+	if err := state.Check(opts.Migrate); err != nil {
+		sqliteDB.Close()
+		return nil, err
 	}
 
-	if err := goose.Up(sqliteDB, "migrations"); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	if pending := state.Pending(); pending > 0 {
+		slog.Info("running database migrations", "database", dbPath, "pending", pending)
+		if err := migrate(sqliteDB); err != nil {
+			sqliteDB.Close()
+			return nil, err
+		}
+		slog.Info("database migrations completed successfully", "version", state.Latest)
 	}
-	slog.Info("database migrations completed successfully")
 
-	queries := db.New(sqliteDB)
+	// Queries made while handling a request are traced as part of it
+	queries := db.New(tracing.DB(sqliteDB))
 
 	return &Storage{
 		db:      sqliteDB,
@@ -69,6 +78,32 @@ func (s *Storage) DB() *sql.DB {
 	return s.db
 }
 
+// Migrate applies any pending migrations, e.g. after restoring an older backup. A
+// database that's ahead of the binary, like a backup taken by a newer release, is
+// left alone.
+func (s *Storage) Migrate() error {
+	state, err := Migrations(context.Background(), s.db)
+	if err != nil {
+		return err
+	}
+	if err := state.Check(true); err != nil {
+		return err
+	}
+	return migrate(s.db)
+}
+
+func migrate(sqliteDB *sql.DB) error {
+	goose.SetBaseFS(embedMigrations)
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		return fmt.Errorf("failed to set goose dialect: %w", err)
+	}
+
+	if err := goose.Up(sqliteDB, "migrations"); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
 // ensureDir creates a directory if it doesn't exist
 func ensureDir(dir string) error {
 	if dir == "." || dir == "" {
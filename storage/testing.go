@@ -2,35 +2,24 @@ package storage
 
 import (
 	"database/sql"
-	"embed"
 	"fmt"
 
 	"github.com/loganlanou/logans3d-v4/storage/db"
-	_ "github.com/mattn/go-sqlite3"
-	"github.com/pressly/goose/v3"
 )
 
-//go:embed migrations/*.sql
-var testMigrations embed.FS
-
-// NewTestDB creates an in-memory SQLite database for testing
+// NewTestDB creates an in-memory SQLite database for testing, opened and migrated the
+// same way as the real one
 func NewTestDB() (*sql.DB, *db.Queries, func(), error) {
-	// Create in-memory database
-	database, err := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
+	database, err := Open(":memory:")
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to open test database: %w", err)
 	}
+	// Each connection to :memory: is a database of its own, so the pool keeps to one
+	database.SetMaxOpenConns(1)
 
-	// Run migrations
-	goose.SetBaseFS(testMigrations)
-	if err := goose.SetDialect("sqlite3"); err != nil {
-		database.Close()
-		return nil, nil, nil, fmt.Errorf("failed to set goose dialect: %w", err)
-	}
-
-	if err := goose.Up(database, "migrations"); err != nil {
+	if err := migrate(database); err != nil {
 		database.Close()
-		return nil, nil, nil, fmt.Errorf("failed to run migrations: %w", err)
+		return nil, nil, nil, err
 	}
 
 	queries := db.New(database)
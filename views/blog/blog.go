@@ -0,0 +1,69 @@
+package blog
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// Feed paths, linked from the blog pages and the site head
+const (
+	FeedPath = "/blog/feed.xml"
+	AtomPath = "/blog/atom.xml"
+)
+
+// PageSize is how many posts the index shows per page
+const PageSize = 10
+
+// Path is the post's public page
+func Path(post db.BlogPost) string {
+	return "/blog/" + url.PathEscape(post.Slug)
+}
+
+// PostView is a published post with its markdown already rendered
+type PostView struct {
+	Post           db.BlogPost
+	HTML           string
+	Excerpt        string
+	ReadingMinutes int
+}
+
+// ReadingLabel is e.g. "4 min read"
+func (p PostView) ReadingLabel() string {
+	return fmt.Sprintf("%d min read", p.ReadingMinutes)
+}
+
+// Listing is one page of the blog index
+type Listing struct {
+	Posts      []PostView
+	Page       int
+	TotalPages int
+}
+
+// HasPrev reports whether there is a page of newer posts
+func (l Listing) HasPrev() bool {
+	return l.Page > 1
+}
+
+// HasNext reports whether there is a page of older posts
+func (l Listing) HasNext() bool {
+	return l.Page < l.TotalPages
+}
+
+// PrevURL returns the URL of the page of newer posts
+func (l Listing) PrevURL() string {
+	return pageURL(l.Page - 1)
+}
+
+// NextURL returns the URL of the page of older posts
+func (l Listing) NextURL() string {
+	return pageURL(l.Page + 1)
+}
+
+func pageURL(page int) string {
+	if page <= 1 {
+		return "/blog"
+	}
+	return fmt.Sprintf("/blog?page=%d", page)
+}
@@ -0,0 +1,141 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/email"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// FeedPath is the subscribable calendar of every active event
+const FeedPath = "/events/calendar.ics"
+
+// siteURL prefixes links in emails, which have no request to build them from
+const siteURL = "https://www.logans3dcreations.com"
+
+// MaxTicketsPerBooking caps the seats one registration can take
+const MaxTicketsPerBooking = 10
+
+// Booking notices shown on the event page after registering, from its ?booking= param
+const (
+	BookingConfirmed  = "confirmed"
+	BookingWaitlisted = "waitlisted"
+	BookingPaid       = "paid"
+	BookingCancelled  = "cancelled"
+)
+
+// Ticketing is the booking side of an event page
+type Ticketing struct {
+	TicketTypes []db.EventTicketType
+	// Open is false once the event has started
+	Open bool
+	// SeatsLeft is -1 when the event has no capacity limit
+	SeatsLeft int64
+	Notice    string
+}
+
+// Bookable reports whether the page should show the booking form
+func (t Ticketing) Bookable() bool {
+	return t.Open && len(t.TicketTypes) > 0
+}
+
+// Full reports whether new bookings go on the waitlist
+func (t Ticketing) Full() bool {
+	return t.SeatsLeft == 0
+}
+
+// PriceLabel is a ticket type's price, or "Free" for an RSVP
+func PriceLabel(ticketType db.EventTicketType) string {
+	if ticketType.PriceCents == 0 {
+		return "Free"
+	}
+	return email.FormatCents(ticketType.PriceCents)
+}
+
+// RegistrationEmail fills the booking and reminder emails for a registration
+func RegistrationEmail(event db.Event, registration db.EventRegistration) *email.EventRegistrationData {
+	return &email.EventRegistrationData{
+		RegistrationID: registration.ID,
+		CustomerName:   registration.Name,
+		CustomerEmail:  registration.Email,
+		EventTitle:     event.Title,
+		EventDate:      DateLabel(event),
+		EventTime:      TimeLabel(event),
+		Location:       event.Location.String,
+		Address:        event.Address.String,
+		EventURL:       siteURL + Path(event),
+		CalendarURL:    siteURL + ICSPath(event),
+		TicketName:     registration.TicketName,
+		Quantity:       registration.Quantity,
+		AmountCents:    registration.AmountCents,
+		Waitlisted:     registration.Status == utils.RegistrationWaitlisted,
+	}
+}
+
+// SeatsLabel describes the seats left, e.g. "3 spots left"
+func SeatsLabel(seatsLeft int64) string {
+	if seatsLeft == 1 {
+		return "1 spot left"
+	}
+	return fmt.Sprintf("%d spots left", seatsLeft)
+}
+
+// Path is the event's public page. Events saved before slugs existed fall back to their ID.
+func Path(event db.Event) string {
+	if event.Slug.Valid && event.Slug.String != "" {
+		return "/events/" + url.PathEscape(event.Slug.String)
+	}
+	return "/events/" + url.PathEscape(event.ID)
+}
+
+// ICSPath downloads a single event for adding to a calendar
+func ICSPath(event db.Event) string {
+	return Path(event) + "/event.ics"
+}
+
+// MapURL links to a map search for the event's address, or the location name when
+// there's no address. It's "" when there's neither.
+func MapURL(event db.Event) string {
+	query := event.Address.String
+	if query == "" {
+		query = event.Location.String
+	}
+	if query == "" {
+		return ""
+	}
+	return "https://www.google.com/maps/search/?api=1&query=" + url.QueryEscape(query)
+}
+
+// DateLabel formats the event's dates, e.g. "March 15, 2026" or "March 15-16, 2026"
+func DateLabel(event db.Event) string {
+	start := event.StartDate
+	if !event.EndDate.Valid || sameDay(start, event.EndDate.Time) || event.EndDate.Time.Before(start) {
+		return start.Format("January 2, 2006")
+	}
+	end := event.EndDate.Time
+	switch {
+	case start.Year() != end.Year():
+		return start.Format("January 2, 2006") + " - " + end.Format("January 2, 2006")
+	case start.Month() != end.Month():
+		return start.Format("January 2") + " - " + end.Format("January 2, 2006")
+	default:
+		return start.Format("January 2") + "-" + end.Format("2, 2006")
+	}
+}
+
+// TimeLabel formats the event's hours, e.g. "10:00 AM - 6:00 PM". Multi-day events
+// show the first day's start only.
+func TimeLabel(event db.Event) string {
+	start := event.StartDate.Format("3:04 PM")
+	if event.EndDate.Valid && sameDay(event.StartDate, event.EndDate.Time) && event.EndDate.Time.After(event.StartDate) {
+		return start + " - " + event.EndDate.Time.Format("3:04 PM")
+	}
+	return start
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
@@ -1,9 +1,12 @@
 package helpers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/loganlanou/logans3d-v4/internal/currency"
 )
 
 // FormatInt formats an integer as a string
@@ -32,6 +35,12 @@ func FormatPrice(cents int64) string {
 	return fmt.Sprintf("$%.2f", float64(cents)/100)
 }
 
+// DisplayPrice formats cents in the shopper's display currency (e.g., 1599 -> "CA$21.83"),
+// which is USD unless they picked another
+func DisplayPrice(ctx context.Context, cents int64) string {
+	return currency.FromContext(ctx).Format(cents)
+}
+
 // FormatPercentage formats an integer as a percentage (e.g., 15 -> "15%")
 func FormatPercentage(n int64) string {
 	return fmt.Sprintf("%d%%", n)
@@ -0,0 +1,17 @@
+package layout
+
+import (
+	"github.com/a-h/templ"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+)
+
+// ResponsiveImage returns srcset and sizes attributes for an uploaded product image so
+// the browser fetches a resized copy. Other images get no extra attributes.
+func ResponsiveImage(imageURL, sizes string) templ.Attributes {
+	srcset := images.SrcSet(imageURL)
+	if srcset == "" {
+		return templ.Attributes{}
+	}
+	return templ.Attributes{"srcset": srcset, "sizes": sizes}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/loganlanou/logans3d-v4/internal/settings"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
 	"github.com/loganlanou/logans3d-v4/storage/db"
 )
 
@@ -25,6 +27,8 @@ type PageMeta struct {
 	OGImageURL    string // MUST be absolute URL
 	OGURL         string // MUST be absolute URL
 	OGSiteName    string
+	OGVideoURL    string // MUST be absolute URL
+	OGVideoType   string // "video/mp4", or "text/html" for a YouTube/Vimeo player
 
 	// Twitter Cards
 	TwitterCard        string // "summary_large_image"
@@ -39,14 +43,25 @@ type PageMeta struct {
 
 	// Internal state
 	SiteURL    string // e.g., "https://www.logans3dcreations.com"
+	Site       settings.Values
 	Product    *db.Product
-	Categories []db.Category
+	Categories []db.Category // breadcrumb trail, top-level category first
+
+	// Category tree for the header's shop menu
+	NavCategories []utils.CategoryNode
 
 	// Variant information for sharing
 	SelectedVariant *VariantInfo
 
 	// Schema.org JSON-LD (pre-computed)
 	ProductSchemaJSON string
+	FAQSchemaJSON     string
+}
+
+// FAQItem is a question and answer pair for FAQPage schema
+type FAQItem struct {
+	Question string
+	Answer   string
 }
 
 // VariantInfo contains selected variant details for variant-specific sharing
@@ -66,14 +81,18 @@ type VariantInfo struct {
 func NewPageMeta(c echo.Context, queries *db.Queries) PageMeta {
 	ctx := context.Background()
 
-	// Load site config from database (with fallback defaults)
-	siteURL := getConfigValue(ctx, queries, "site_url", "https://www.logans3dcreations.com")
-	siteName := getConfigValue(ctx, queries, "site_name", "Logan's 3D Creations")
-	defaultDescription := getConfigValue(ctx, queries, "site_description", "Custom 3D printed collectibles, dinosaurs, and more")
-	defaultOGImage := getConfigValue(ctx, queries, "default_og_image", "/public/images/social/default-og.jpg")
-	twitterHandle := getConfigValue(ctx, queries, "twitter_handle", "")
-	facebookPageID := getConfigValue(ctx, queries, "facebook_page_id", "")
-	facebookAppID := getConfigValue(ctx, queries, "facebook_app_id", "")
+	// Site settings are cached, and fall back to defaults if the database is unavailable
+	site := settings.For(queries).Values(ctx)
+	siteURL := site.SiteURL()
+	siteName := site.SiteName()
+	defaultDescription := site.SiteDescription()
+	defaultOGImage := site.DefaultOGImage()
+
+	// The shop menu still renders without categories if they fail to load
+	var navCategories []utils.CategoryNode
+	if categories, err := queries.ListCategories(ctx); err == nil {
+		navCategories = utils.BuildCategoryTree(categories)
+	}
 
 	// Build canonical URL from request path
 	canonicalURL := BuildAbsoluteURL(siteURL, c.Request().URL.Path)
@@ -98,14 +117,16 @@ func NewPageMeta(c echo.Context, queries *db.Queries) PageMeta {
 		TwitterTitle:       siteName,
 		TwitterDescription: defaultDescription,
 		TwitterImageURL:    BuildAbsoluteURL(siteURL, defaultOGImage),
-		TwitterSite:        twitterHandle,
+		TwitterSite:        site.TwitterHandle(),
 
 		// Facebook
-		FacebookPageID: facebookPageID,
-		FacebookAppID:  facebookAppID,
+		FacebookPageID: site.FacebookPageID(),
+		FacebookAppID:  site.FacebookAppID(),
 
 		// Internal
-		SiteURL: siteURL,
+		SiteURL:       siteURL,
+		Site:          site,
+		NavCategories: navCategories,
 	}
 }
 
@@ -195,7 +216,8 @@ func (pm PageMeta) WithProductImage(imageFilename string) PageMeta {
 	return pm
 }
 
-// WithCategories adds category information for breadcrumbs and schema
+// WithCategories adds category information for breadcrumbs and schema. Pass the
+// full lineage, top-level category first; the last one is the page's own category.
 func (pm PageMeta) WithCategories(categories []db.Category) PageMeta {
 	pm.Categories = categories
 
@@ -207,6 +229,48 @@ func (pm PageMeta) WithCategories(categories []db.Category) PageMeta {
 	return pm
 }
 
+// WithFAQ adds FAQPage schema for published question and answer pairs
+func (pm PageMeta) WithFAQ(items []FAQItem) PageMeta {
+	if len(items) == 0 {
+		pm.FAQSchemaJSON = ""
+		return pm
+	}
+
+	questions := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		questions[i] = map[string]interface{}{
+			"@type": "Question",
+			"name":  item.Question,
+			"acceptedAnswer": map[string]interface{}{
+				"@type": "Answer",
+				"text":  item.Answer,
+			},
+		}
+	}
+
+	bytes, err := json.MarshalIndent(map[string]interface{}{
+		"@context":   "https://schema.org",
+		"@type":      "FAQPage",
+		"mainEntity": questions,
+	}, "", "  ")
+	if err != nil {
+		return pm
+	}
+	pm.FAQSchemaJSON = string(bytes)
+
+	return pm
+}
+
+// WithVideo shares a product video as og:video
+func (pm PageMeta) WithVideo(videoURL, contentType string) PageMeta {
+	if videoURL == "" {
+		return pm
+	}
+	pm.OGVideoURL = BuildAbsoluteURL(pm.SiteURL, videoURL)
+	pm.OGVideoType = contentType
+	return pm
+}
+
 // WithOGImage overrides the OG image URL
 func (pm PageMeta) WithOGImage(imageURL string) PageMeta {
 	absoluteURL := BuildAbsoluteURL(pm.SiteURL, imageURL)
@@ -315,6 +379,25 @@ func (pm PageMeta) generateVariantSchemaJSON(v VariantInfo) string {
 	return string(bytes)
 }
 
+// Breadcrumbs returns Home › Shop › each category in the trail › the product, for
+// the BreadcrumbList schema. Pages without a category trail have no breadcrumbs.
+func (pm PageMeta) Breadcrumbs() []BreadcrumbItem {
+	if len(pm.Categories) == 0 {
+		return nil
+	}
+	items := []BreadcrumbItem{
+		{Name: "Home", URL: "/"},
+		{Name: "Shop", URL: "/shop"},
+	}
+	for _, cat := range pm.Categories {
+		items = append(items, BreadcrumbItem{Name: cat.Name, URL: "/shop/category/" + cat.Slug})
+	}
+	if pm.Product != nil {
+		items = append(items, BreadcrumbItem{Name: pm.Product.Name, URL: "/shop/product/" + pm.Product.Slug})
+	}
+	return items
+}
+
 // KeywordsString returns keywords as a comma-separated string
 func (pm PageMeta) KeywordsString() string {
 	return strings.Join(pm.Keywords, ", ")
@@ -462,12 +545,3 @@ func (pm PageMeta) OrganizationSchemaJSON() string {
 	}
 	return string(bytes)
 }
-
-// getConfigValue retrieves a config value from the database with a fallback default
-func getConfigValue(ctx context.Context, queries *db.Queries, key string, defaultValue string) string {
-	value, err := queries.GetSiteConfig(ctx, key)
-	if err != nil || value == "" {
-		return defaultValue
-	}
-	return value
-}
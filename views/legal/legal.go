@@ -0,0 +1,27 @@
+package legal
+
+import (
+	"fmt"
+
+	"github.com/loganlanou/logans3d-v4/internal/policies"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// DocumentView is a version of a terms or policy page with its markdown already
+// rendered
+type DocumentView struct {
+	Document db.LegalDocument
+	HTML     string
+	// Current is whether this is the version in effect, rather than an earlier or
+	// scheduled one asked for by number
+	Current bool
+	// Scheduled is whether the version hasn't taken effect yet
+	Scheduled bool
+	// Upcoming is the next version scheduled to take effect, if there is one
+	Upcoming *db.LegalDocument
+}
+
+// VersionURL links to one version of a document
+func VersionURL(doc db.LegalDocument) string {
+	return fmt.Sprintf("%s?version=%d", policies.Path(doc.Kind), doc.Version)
+}
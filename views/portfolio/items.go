@@ -0,0 +1,144 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/loganlanou/logans3d-v4/internal/images"
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// ItemView is a published portfolio item with its gallery and the products it can be
+// bought as
+type ItemView struct {
+	Item     db.PortfolioItem
+	Tags     []string
+	Images   []db.PortfolioItemImage
+	Products []db.ListPublishedPortfolioProductsRow
+}
+
+// CoverURL is the first gallery image, or "" when the item has none yet
+func (v ItemView) CoverURL() string {
+	if len(v.Images) == 0 {
+		return ""
+	}
+	return utils.PortfolioImageURL(v.Images[0].ImageUrl)
+}
+
+// CoverAlt is the cover's alt text, falling back to the item title
+func (v ItemView) CoverAlt() string {
+	if len(v.Images) > 0 && v.Images[0].AltText != "" {
+		return v.Images[0].AltText
+	}
+	return v.Item.Title
+}
+
+// TagKeys are the tags in the form the gallery filter matches on
+func (v ItemView) TagKeys() []string {
+	keys := make([]string, 0, len(v.Tags))
+	for _, tag := range v.Tags {
+		keys = append(keys, utils.PortfolioTagKey(tag))
+	}
+	return keys
+}
+
+// TagKeysJSON encodes TagKeys for the gallery filter script
+func (v ItemView) TagKeysJSON() string {
+	data, err := json.Marshal(v.TagKeys())
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// lightboxImage and lightboxProduct are what the lightbox script needs per item
+type lightboxImage struct {
+	URL   string `json:"url"`
+	Thumb string `json:"thumb"`
+	Alt   string `json:"alt"`
+}
+
+type lightboxProduct struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Price string `json:"price"`
+}
+
+type lightboxItem struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Images      []lightboxImage   `json:"images"`
+	Products    []lightboxProduct `json:"products"`
+}
+
+// LightboxJSON encodes the item for the gallery lightbox
+func (v ItemView) LightboxJSON() string {
+	item := lightboxItem{
+		Title:       v.Item.Title,
+		Description: v.Item.Description,
+		Tags:        v.Tags,
+		Images:      make([]lightboxImage, 0, len(v.Images)),
+		Products:    make([]lightboxProduct, 0, len(v.Products)),
+	}
+	if item.Tags == nil {
+		item.Tags = []string{}
+	}
+	for _, img := range v.Images {
+		alt := img.AltText
+		if alt == "" {
+			alt = v.Item.Title
+		}
+		url := utils.PortfolioImageURL(img.ImageUrl)
+		item.Images = append(item.Images, lightboxImage{
+			URL:   images.URL("full", url),
+			Thumb: images.URL("thumb", url),
+			Alt:   alt,
+		})
+	}
+	for _, p := range v.Products {
+		item.Products = append(item.Products, lightboxProduct{
+			Name:  p.Name,
+			URL:   ShopURL(p.Slug),
+			Price: fmt.Sprintf("$%.2f", float64(p.PriceCents)/100),
+		})
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// ShopURL is the storefront page for a linked product
+func ShopURL(slug string) string {
+	return "/shop/product/" + slug
+}
+
+// GalleryTag is a filter button: the tag as first written and how many items use it
+type GalleryTag struct {
+	Name  string
+	Key   string
+	Count int
+}
+
+// GalleryTags lists the tags in use across the items, most used first, for the filter bar
+func GalleryTags(items []ItemView) []GalleryTag {
+	var tags []GalleryTag
+	index := make(map[string]int)
+	for _, item := range items {
+		for _, tag := range item.Tags {
+			key := utils.PortfolioTagKey(tag)
+			if i, ok := index[key]; ok {
+				tags[i].Count++
+				continue
+			}
+			index[key] = len(tags)
+			tags = append(tags, GalleryTag{Name: tag, Key: key, Count: 1})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Count > tags[j].Count })
+	return tags
+}
@@ -0,0 +1,79 @@
+package shop
+
+import (
+	"fmt"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// BundleView is a product bundle with its components and computed availability
+type BundleView struct {
+	Bundle         db.ProductBundle
+	Components     []db.ListProductBundleItemsRow
+	ImageURL       string
+	ListPriceCents int64 // what the components cost bought separately
+	AvailableCount int64 // complete bundles current component stock covers
+	Purchasable    bool  // every component is still active
+}
+
+// URL is the storefront page for the bundle
+func (b BundleView) URL() string {
+	return fmt.Sprintf("/shop/bundle/%s", b.Bundle.Slug)
+}
+
+// ItemCount is the total number of pieces in the bundle
+func (b BundleView) ItemCount() int64 {
+	var count int64
+	for _, item := range b.Components {
+		count += item.Quantity
+	}
+	return count
+}
+
+// SavingsCents is how much the bundle saves over buying the components separately
+func (b BundleView) SavingsCents() int64 {
+	if b.ListPriceCents <= b.Bundle.PriceCents {
+		return 0
+	}
+	return b.ListPriceCents - b.Bundle.PriceCents
+}
+
+// DiscountPercent is the savings as a whole percentage of the list price
+func (b BundleView) DiscountPercent() int {
+	if b.ListPriceCents == 0 {
+		return 0
+	}
+	return int(b.SavingsCents() * 100 / b.ListPriceCents)
+}
+
+// AvailabilityLabel describes whether the bundle ships from stock
+func (b BundleView) AvailabilityLabel() string {
+	switch {
+	case !b.Purchasable:
+		return "Currently unavailable"
+	case b.AvailableCount > 0:
+		return "In stock"
+	default:
+		return "Made to order"
+	}
+}
+
+// BundleComponentLabel describes one component line, e.g. "2 × Dragon (Red - Large)"
+func BundleComponentLabel(item db.ListProductBundleItemsRow) string {
+	label := item.ProductName
+	if item.VariantName != "" {
+		label = fmt.Sprintf("%s (%s)", label, item.VariantName)
+	}
+	if item.Quantity > 1 {
+		label = fmt.Sprintf("%d × %s", item.Quantity, label)
+	}
+	return label
+}
+
+// BundleComponentImageURL is the public path for a component's primary image
+func BundleComponentImageURL(item db.ListProductBundleItemsRow) string {
+	if item.ImageUrl == "" {
+		return ""
+	}
+	return "/public/images/products/" + item.ImageUrl
+}
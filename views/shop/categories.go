@@ -0,0 +1,55 @@
+package shop
+
+import "github.com/loganlanou/logans3d-v4/storage/db"
+
+// topLevelCategories returns the categories shown as the main shop pills: those with
+// no parent, or whose parent no longer exists
+func topLevelCategories(categories []db.Category) []db.Category {
+	known := make(map[string]bool, len(categories))
+	for _, cat := range categories {
+		known[cat.ID] = true
+	}
+	var roots []db.Category
+	for _, cat := range categories {
+		if !cat.ParentID.Valid || !known[cat.ParentID.String] {
+			roots = append(roots, cat)
+		}
+	}
+	return roots
+}
+
+// childCategories returns the direct subcategories of a category, in list order
+func childCategories(categories []db.Category, parentID string) []db.Category {
+	var children []db.Category
+	for _, cat := range categories {
+		if cat.ParentID.Valid && cat.ParentID.String == parentID && cat.ID != parentID {
+			children = append(children, cat)
+		}
+	}
+	return children
+}
+
+// subcategoryChips returns the second row of category links on a category page: the
+// current category's children, or its siblings when it has none
+func subcategoryChips(categories []db.Category, current *db.Category) []db.Category {
+	if current == nil {
+		return nil
+	}
+	if children := childCategories(categories, current.ID); len(children) > 0 {
+		return children
+	}
+	if current.ParentID.Valid {
+		return childCategories(categories, current.ParentID.String)
+	}
+	return nil
+}
+
+// inCategoryTrail reports whether a category is the current one or one of its parents
+func inCategoryTrail(trail []db.Category, id string) bool {
+	for _, cat := range trail {
+		if cat.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package shop
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/loganlanou/logans3d-v4/internal/currency"
+)
+
+// Bounds on how many products can be compared at once
+const (
+	MinCompareProducts = 2
+	MaxCompareProducts = 4
+)
+
+// Well-known attribute names that always lead the comparison table
+const (
+	AttributeMaterial   = "Material"
+	AttributeDimensions = "Dimensions"
+)
+
+// CompareProduct is one column of the comparison table
+type CompareProduct struct {
+	Product       ProductWithImage
+	MinPriceCents int64
+	MaxPriceCents int64
+	Sizes         []string
+	Attributes    map[string]string
+}
+
+// PriceLabel shows a single price or a range across sizes
+func (p CompareProduct) PriceLabel(display currency.Display) string {
+	if p.MaxPriceCents > p.MinPriceCents {
+		return display.Format(p.MinPriceCents) + " – " + display.Format(p.MaxPriceCents)
+	}
+	return display.Format(p.MinPriceCents)
+}
+
+// SizesLabel lists the sizes on offer
+func (p CompareProduct) SizesLabel() string {
+	if len(p.Sizes) == 0 {
+		return "One size"
+	}
+	return strings.Join(p.Sizes, ", ")
+}
+
+// Attribute returns the value for name, or a dash when the product doesn't specify it
+func (p CompareProduct) Attribute(name string) string {
+	if value := p.Attributes[name]; value != "" {
+		return value
+	}
+	return "—"
+}
+
+// ProductComparison is the data behind /shop/compare
+type ProductComparison struct {
+	Products       []CompareProduct
+	AttributeNames []string
+}
+
+// IDs returns the compared product IDs in column order
+func (c ProductComparison) IDs() []string {
+	ids := make([]string, 0, len(c.Products))
+	for _, p := range c.Products {
+		ids = append(ids, p.Product.Product.ID)
+	}
+	return ids
+}
+
+// CompareTrayItem is the client-side representation of a selected product
+type CompareTrayItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// TrayItems returns the compared products in the shape the comparison tray stores
+func (c ProductComparison) TrayItems() []CompareTrayItem {
+	items := make([]CompareTrayItem, 0, len(c.Products))
+	for _, p := range c.Products {
+		items = append(items, CompareTrayItem{ID: p.Product.Product.ID, Name: p.Product.Product.Name})
+	}
+	return items
+}
+
+// RemoveURL is the comparison URL without the given product
+func (c ProductComparison) RemoveURL(productID string) string {
+	remaining := make([]string, 0, len(c.Products))
+	for _, id := range c.IDs() {
+		if id != productID {
+			remaining = append(remaining, id)
+		}
+	}
+	return CompareURL(remaining)
+}
+
+// CompareURL builds the comparison page URL for the given product IDs
+func CompareURL(ids []string) string {
+	if len(ids) == 0 {
+		return "/shop/compare"
+	}
+	escaped := make([]string, 0, len(ids))
+	for _, id := range ids {
+		escaped = append(escaped, url.QueryEscape(id))
+	}
+	return "/shop/compare?ids=" + strings.Join(escaped, ",")
+}
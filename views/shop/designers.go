@@ -0,0 +1,41 @@
+package shop
+
+import "net/url"
+
+// DesignerPage is a designer's archive: their profile and the products we print
+// from their models
+type DesignerPage struct {
+	Name string
+	Slug string
+	// BioHTML is rendered from the admin's markdown, which strips raw HTML
+	BioHTML     string
+	WebsiteURL  string
+	LicenseNote string
+	Profiles    []DesignerLink
+	Platforms   []DesignerPlatform
+	Platform    string // the platform filter in effect, or "" for all
+	Products    []ProductWithImage
+}
+
+// DesignerLink is one of the designer's storefronts on a model platform
+type DesignerLink struct {
+	Label string
+	URL   string
+}
+
+// DesignerPlatform is a source platform filter with how many products it covers
+type DesignerPlatform struct {
+	Value string
+	Label string
+	Count int64
+}
+
+// URL returns the designer's page, unfiltered
+func (p DesignerPage) URL() string {
+	return "/shop/designer/" + p.Slug
+}
+
+// PlatformURL returns the designer's page narrowed to one source platform
+func (p DesignerPage) PlatformURL(platform string) string {
+	return p.URL() + "?platform=" + url.QueryEscape(platform)
+}
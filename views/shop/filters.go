@@ -0,0 +1,217 @@
+package shop
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/loganlanou/logans3d-v4/internal/types"
+)
+
+// Sort options accepted by the shop listing. Recommended follows the order the owner
+// arranged each category in.
+const (
+	SortRecommended = "recommended"
+	SortNewest      = "newest"
+	SortPriceAsc    = "price_asc"
+	SortPriceDesc   = "price_desc"
+	SortName        = "name"
+)
+
+// SortOption is a label/value pair for the sort dropdown
+type SortOption struct {
+	Value string
+	Label string
+}
+
+// SortOptions lists the sort choices in display order
+var SortOptions = []SortOption{
+	{Value: SortRecommended, Label: "Recommended"},
+	{Value: SortNewest, Label: "Newest"},
+	{Value: SortPriceAsc, Label: "Price: Low to High"},
+	{Value: SortPriceDesc, Label: "Price: High to Low"},
+	{Value: SortName, Label: "Name: A to Z"},
+}
+
+// DefaultPageSize is the number of products per shop page unless the shopper picks another
+const DefaultPageSize = 24
+
+// PageSizeOptions lists the page sizes a shopper can choose from
+var PageSizeOptions = []int{24, 48, 96}
+
+// ShopFilters holds the filter state parsed from the query string
+type ShopFilters struct {
+	MinPriceCents  int64 // 0 means no minimum
+	MaxPriceCents  int64 // 0 means no maximum
+	InStock        bool
+	New            bool
+	Featured       bool
+	Premium        bool
+	AttributeName  string // with AttributeValue, only products with this specification
+	AttributeValue string
+	Sort           string
+	Page           int
+	PerPage        int // 0 means DefaultPageSize
+}
+
+// IsFiltered reports whether any facet filter is active (sort and paging excluded)
+func (f ShopFilters) IsFiltered() bool {
+	return f.MinPriceCents > 0 || f.MaxPriceCents > 0 || f.InStock || f.New || f.Featured || f.Premium || f.HasAttribute()
+}
+
+// PageSize returns the number of products per page
+func (f ShopFilters) PageSize() int {
+	if f.PerPage > 0 {
+		return f.PerPage
+	}
+	return DefaultPageSize
+}
+
+// HasAttribute reports whether a specification filter is active
+func (f ShopFilters) HasAttribute() bool {
+	return f.AttributeName != "" && f.AttributeValue != ""
+}
+
+// AttributeURL returns basePath filtered to one specification value, resetting paging
+func (f ShopFilters) AttributeURL(basePath, name, value string) string {
+	f.AttributeName = name
+	f.AttributeValue = value
+	f.Page = 0
+	return f.URL(basePath)
+}
+
+// Values encodes the filters as URL query values, omitting defaults
+func (f ShopFilters) Values() url.Values {
+	v := url.Values{}
+	if f.MinPriceCents > 0 {
+		v.Set("min_price", formatDollars(f.MinPriceCents))
+	}
+	if f.MaxPriceCents > 0 {
+		v.Set("max_price", formatDollars(f.MaxPriceCents))
+	}
+	if f.InStock {
+		v.Set("in_stock", "1")
+	}
+	if f.New {
+		v.Set("new", "1")
+	}
+	if f.Featured {
+		v.Set("featured", "1")
+	}
+	if f.Premium {
+		v.Set("premium", "1")
+	}
+	if f.HasAttribute() {
+		v.Set("attr", f.AttributeName)
+		v.Set("attr_value", f.AttributeValue)
+	}
+	if f.Sort != "" && f.Sort != SortRecommended {
+		v.Set("sort", f.Sort)
+	}
+	if f.Page > 1 {
+		v.Set("page", strconv.Itoa(f.Page))
+	}
+	if f.PageSize() != DefaultPageSize {
+		v.Set("per_page", strconv.Itoa(f.PageSize()))
+	}
+	return v
+}
+
+// URL returns basePath with the filters applied as a query string
+func (f ShopFilters) URL(basePath string) string {
+	if q := f.Values().Encode(); q != "" {
+		return basePath + "?" + q
+	}
+	return basePath
+}
+
+// PageURL returns the URL for the given page with the current filters
+func (f ShopFilters) PageURL(basePath string, page int) string {
+	f.Page = page
+	return f.URL(basePath)
+}
+
+// MinPriceInput returns the minimum price formatted for the filter form
+func (f ShopFilters) MinPriceInput() string {
+	if f.MinPriceCents <= 0 {
+		return ""
+	}
+	return formatDollars(f.MinPriceCents)
+}
+
+// MaxPriceInput returns the maximum price formatted for the filter form
+func (f ShopFilters) MaxPriceInput() string {
+	if f.MaxPriceCents <= 0 {
+		return ""
+	}
+	return formatDollars(f.MaxPriceCents)
+}
+
+// ShopFacets holds counts for each facet within the current category
+type ShopFacets struct {
+	InStock        int64
+	New            int64
+	Featured       int64
+	Premium        int64
+	MinPriceCents  int64
+	MaxPriceCents  int64
+	CategoryCounts map[string]int64 // keyed by category ID
+	Attributes     []AttributeFacet
+}
+
+// AttributeFacet is a filterable specification and the values products in the listing have
+type AttributeFacet struct {
+	Name   string
+	Values []AttributeFacetValue
+}
+
+// AttributeFacetValue is one specification value with the number of products that have it
+type AttributeFacetValue struct {
+	Value string
+	Count int64
+}
+
+// ShopListing bundles everything the shop page needs for filtering and paging
+type ShopListing struct {
+	types.Pagination
+	BasePath string
+	Filters  ShopFilters
+	Facets   ShopFacets
+}
+
+// PrevURL returns the URL of the previous page
+func (l ShopListing) PrevURL() string {
+	return l.Filters.PageURL(l.BasePath, l.Page-1)
+}
+
+// NextURL returns the URL of the next page
+func (l ShopListing) NextURL() string {
+	return l.Filters.PageURL(l.BasePath, l.Page+1)
+}
+
+// ClearURL returns the base path with only the sort order and page size preserved
+func (l ShopListing) ClearURL() string {
+	return ShopFilters{Sort: l.Filters.Sort, PerPage: l.Filters.PerPage}.URL(l.BasePath)
+}
+
+// CategoryURL returns a category link that keeps the current filters but resets paging
+func (l ShopListing) CategoryURL(basePath string) string {
+	f := l.Filters
+	f.Page = 0
+	return f.URL(basePath)
+}
+
+// RangeLabel describes the visible slice of results, e.g. "Showing 25–48 of 130"
+func (l ShopListing) RangeLabel() string {
+	if l.TotalCount == 0 {
+		return "No products"
+	}
+	return "Showing " + l.Pagination.RangeLabel()
+}
+
+func formatDollars(cents int64) string {
+	if cents%100 == 0 {
+		return strconv.FormatInt(cents/100, 10)
+	}
+	return fmt.Sprintf("%.2f", float64(cents)/100)
+}
@@ -0,0 +1,27 @@
+package shop
+
+import (
+	"strings"
+
+	"github.com/loganlanou/logans3d-v4/storage/db"
+)
+
+// policyDocumentIDs lists the versions the cart shows, for checkout to send back
+func policyDocumentIDs(docs []db.LegalDocument) string {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+// policySeparator follows the i-th of n document links, as in "A, B and C"
+func policySeparator(i, n int) string {
+	switch {
+	case i == n-1:
+		return "."
+	case i == n-2:
+		return " and "
+	}
+	return ", "
+}
@@ -0,0 +1,52 @@
+package shop
+
+import (
+	"github.com/loganlanou/logans3d-v4/internal/utils"
+)
+
+// SharedCart is a cart someone shared by link, shown at /cart/shared/:token
+type SharedCart struct {
+	Token string
+	Name  string
+	Items []SharedCartItem
+}
+
+// SharedCartItem is one line of a shared cart with its current availability
+type SharedCartItem struct {
+	Name                string
+	Slug                string
+	VariantName         string
+	ImageURL            string
+	PriceCents          int64
+	Quantity            int64
+	Personalization     []utils.PersonalizationValue
+	Availability        string
+	AvailabilityMessage string
+}
+
+// CanAdd reports whether the line can still go in a cart
+func (i SharedCartItem) CanAdd() bool {
+	return utils.SavedItemMovable(i.Availability)
+}
+
+// AddableCount is how many lines can still go in a cart
+func (c SharedCart) AddableCount() int {
+	count := 0
+	for _, item := range c.Items {
+		if item.CanAdd() {
+			count++
+		}
+	}
+	return count
+}
+
+// TotalCents is the price of every line that can still be added, at today's prices
+func (c SharedCart) TotalCents() int64 {
+	var total int64
+	for _, item := range c.Items {
+		if item.CanAdd() {
+			total += item.PriceCents * item.Quantity
+		}
+	}
+	return total
+}
@@ -0,0 +1,21 @@
+package shop
+
+import "fmt"
+
+// VolumePriceRow is one line of the quantity-break table on the product page
+type VolumePriceRow struct {
+	MinQuantity    int64
+	MaxQuantity    int64 // 0 for the open-ended top tier
+	UnitPriceCents int64
+}
+
+// Label is the quantity range, e.g. "1–4" or "10+"
+func (r VolumePriceRow) Label() string {
+	if r.MaxQuantity == 0 {
+		return fmt.Sprintf("%d+", r.MinQuantity)
+	}
+	if r.MaxQuantity == r.MinQuantity {
+		return fmt.Sprintf("%d", r.MinQuantity)
+	}
+	return fmt.Sprintf("%d–%d", r.MinQuantity, r.MaxQuantity)
+}